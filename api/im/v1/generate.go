@@ -0,0 +1,4 @@
+// Package imv1 holds the generated protobuf and gRPC code of the IM gRPC API.
+package imv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative im.proto
@@ -0,0 +1,1433 @@
+// gRPC API mirroring the internal HTTP routes (/internal/msg, /internal/user, /internal/group,
+// /internal/conversation) for service-to-service calls.
+//
+// Every call acts as a user, like the X-User-Id / X-Platform-Id headers of the internal routes:
+// set the x-user-id and x-platform-id metadata. See docs/API.md "gRPC 接口" for authentication.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: im.proto
+
+package imv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MessageContent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Image         string                 `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	Video         string                 `protobuf:"bytes,3,opt,name=video,proto3" json:"video,omitempty"`
+	Audio         string                 `protobuf:"bytes,4,opt,name=audio,proto3" json:"audio,omitempty"`
+	File          string                 `protobuf:"bytes,5,opt,name=file,proto3" json:"file,omitempty"`
+	Custom        string                 `protobuf:"bytes,6,opt,name=custom,proto3" json:"custom,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageContent) Reset() {
+	*x = MessageContent{}
+	mi := &file_im_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageContent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageContent) ProtoMessage() {}
+
+func (x *MessageContent) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageContent.ProtoReflect.Descriptor instead.
+func (*MessageContent) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MessageContent) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *MessageContent) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *MessageContent) GetVideo() string {
+	if x != nil {
+		return x.Video
+	}
+	return ""
+}
+
+func (x *MessageContent) GetAudio() string {
+	if x != nil {
+		return x.Audio
+	}
+	return ""
+}
+
+func (x *MessageContent) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *MessageContent) GetCustom() string {
+	if x != nil {
+		return x.Custom
+	}
+	return ""
+}
+
+type SendMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientMsgId   string                 `protobuf:"bytes,1,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	RecvId        string                 `protobuf:"bytes,2,opt,name=recv_id,json=recvId,proto3" json:"recv_id,omitempty"`    // single chat
+	GroupId       string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"` // group chat
+	SessionType   int32                  `protobuf:"varint,4,opt,name=session_type,json=sessionType,proto3" json:"session_type,omitempty"`
+	MsgType       int32                  `protobuf:"varint,5,opt,name=msg_type,json=msgType,proto3" json:"msg_type,omitempty"`
+	Content       *MessageContent        `protobuf:"bytes,6,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendMessageRequest) Reset() {
+	*x = SendMessageRequest{}
+	mi := &file_im_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessageRequest) ProtoMessage() {}
+
+func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SendMessageRequest) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetRecvId() string {
+	if x != nil {
+		return x.RecvId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetSessionType() int32 {
+	if x != nil {
+		return x.SessionType
+	}
+	return 0
+}
+
+func (x *SendMessageRequest) GetMsgType() int32 {
+	if x != nil {
+		return x.MsgType
+	}
+	return 0
+}
+
+func (x *SendMessageRequest) GetContent() *MessageContent {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type MessageInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Seq            int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	ClientMsgId    string                 `protobuf:"bytes,4,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	SenderId       string                 `protobuf:"bytes,5,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	SessionType    int32                  `protobuf:"varint,6,opt,name=session_type,json=sessionType,proto3" json:"session_type,omitempty"`
+	MsgType        int32                  `protobuf:"varint,7,opt,name=msg_type,json=msgType,proto3" json:"msg_type,omitempty"`
+	Content        *MessageContent        `protobuf:"bytes,8,opt,name=content,proto3" json:"content,omitempty"`
+	Extra          *string                `protobuf:"bytes,9,opt,name=extra,proto3,oneof" json:"extra,omitempty"`
+	SendAt         int64                  `protobuf:"varint,10,opt,name=send_at,json=sendAt,proto3" json:"send_at,omitempty"`          // ms
+	DeletedAt      int64                  `protobuf:"varint,11,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"` // ms, 0 unless the message was deleted
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageInfo) Reset() {
+	*x = MessageInfo{}
+	mi := &file_im_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageInfo) ProtoMessage() {}
+
+func (x *MessageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageInfo.ProtoReflect.Descriptor instead.
+func (*MessageInfo) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MessageInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageInfo) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *MessageInfo) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *MessageInfo) GetSessionType() int32 {
+	if x != nil {
+		return x.SessionType
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetMsgType() int32 {
+	if x != nil {
+		return x.MsgType
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetContent() *MessageContent {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *MessageInfo) GetExtra() string {
+	if x != nil && x.Extra != nil {
+		return *x.Extra
+	}
+	return ""
+}
+
+func (x *MessageInfo) GetSendAt() int64 {
+	if x != nil {
+		return x.SendAt
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetDeletedAt() int64 {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return 0
+}
+
+type UserInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Nickname      string                 `protobuf:"bytes,2,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	Avatar        string                 `protobuf:"bytes,3,opt,name=avatar,proto3" json:"avatar,omitempty"`
+	Extra         *string                `protobuf:"bytes,4,opt,name=extra,proto3,oneof" json:"extra,omitempty"`
+	IsBot         bool                   `protobuf:"varint,5,opt,name=is_bot,json=isBot,proto3" json:"is_bot,omitempty"`
+	IsGuest       bool                   `protobuf:"varint,6,opt,name=is_guest,json=isGuest,proto3" json:"is_guest,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserInfo) Reset() {
+	*x = UserInfo{}
+	mi := &file_im_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserInfo) ProtoMessage() {}
+
+func (x *UserInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserInfo.ProtoReflect.Descriptor instead.
+func (*UserInfo) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UserInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserInfo) GetNickname() string {
+	if x != nil {
+		return x.Nickname
+	}
+	return ""
+}
+
+func (x *UserInfo) GetAvatar() string {
+	if x != nil {
+		return x.Avatar
+	}
+	return ""
+}
+
+func (x *UserInfo) GetExtra() string {
+	if x != nil && x.Extra != nil {
+		return *x.Extra
+	}
+	return ""
+}
+
+func (x *UserInfo) GetIsBot() bool {
+	if x != nil {
+		return x.IsBot
+	}
+	return false
+}
+
+func (x *UserInfo) GetIsGuest() bool {
+	if x != nil {
+		return x.IsGuest
+	}
+	return false
+}
+
+func (x *UserInfo) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type GetUserInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserInfoRequest) Reset() {
+	*x = GetUserInfoRequest{}
+	mi := &file_im_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserInfoRequest) ProtoMessage() {}
+
+func (x *GetUserInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetUserInfoRequest) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetUserInfoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetUsersInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []string               `protobuf:"bytes,1,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsersInfoRequest) Reset() {
+	*x = GetUsersInfoRequest{}
+	mi := &file_im_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsersInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsersInfoRequest) ProtoMessage() {}
+
+func (x *GetUsersInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsersInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetUsersInfoRequest) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetUsersInfoRequest) GetUserIds() []string {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type GetUsersInfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserInfo            `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsersInfoResponse) Reset() {
+	*x = GetUsersInfoResponse{}
+	mi := &file_im_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsersInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsersInfoResponse) ProtoMessage() {}
+
+func (x *GetUsersInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsersInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetUsersInfoResponse) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetUsersInfoResponse) GetUsers() []*UserInfo {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type GroupInfo struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Id                    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Introduction          string                 `protobuf:"bytes,3,opt,name=introduction,proto3" json:"introduction,omitempty"`
+	Avatar                string                 `protobuf:"bytes,4,opt,name=avatar,proto3" json:"avatar,omitempty"`
+	Status                int32                  `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
+	CreatorUserId         string                 `protobuf:"bytes,6,opt,name=creator_user_id,json=creatorUserId,proto3" json:"creator_user_id,omitempty"`
+	MemberCount           int64                  `protobuf:"varint,7,opt,name=member_count,json=memberCount,proto3" json:"member_count,omitempty"`
+	MuteAll               bool                   `protobuf:"varint,8,opt,name=mute_all,json=muteAll,proto3" json:"mute_all,omitempty"`
+	Announcement          string                 `protobuf:"bytes,9,opt,name=announcement,proto3" json:"announcement,omitempty"`
+	AnnouncementUpdatedAt int64                  `protobuf:"varint,10,opt,name=announcement_updated_at,json=announcementUpdatedAt,proto3" json:"announcement_updated_at,omitempty"` // ms
+	CreatedAt             int64                  `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                                       // ms
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *GroupInfo) Reset() {
+	*x = GroupInfo{}
+	mi := &file_im_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupInfo) ProtoMessage() {}
+
+func (x *GroupInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupInfo.ProtoReflect.Descriptor instead.
+func (*GroupInfo) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GroupInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetIntroduction() string {
+	if x != nil {
+		return x.Introduction
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetAvatar() string {
+	if x != nil {
+		return x.Avatar
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *GroupInfo) GetCreatorUserId() string {
+	if x != nil {
+		return x.CreatorUserId
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetMemberCount() int64 {
+	if x != nil {
+		return x.MemberCount
+	}
+	return 0
+}
+
+func (x *GroupInfo) GetMuteAll() bool {
+	if x != nil {
+		return x.MuteAll
+	}
+	return false
+}
+
+func (x *GroupInfo) GetAnnouncement() string {
+	if x != nil {
+		return x.Announcement
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetAnnouncementUpdatedAt() int64 {
+	if x != nil {
+		return x.AnnouncementUpdatedAt
+	}
+	return 0
+}
+
+func (x *GroupInfo) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type GroupMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	GroupNickname string                 `protobuf:"bytes,3,opt,name=group_nickname,json=groupNickname,proto3" json:"group_nickname,omitempty"`
+	GroupAvatar   string                 `protobuf:"bytes,4,opt,name=group_avatar,json=groupAvatar,proto3" json:"group_avatar,omitempty"`
+	Extra         *string                `protobuf:"bytes,5,opt,name=extra,proto3,oneof" json:"extra,omitempty"`
+	RoleLevel     int32                  `protobuf:"varint,6,opt,name=role_level,json=roleLevel,proto3" json:"role_level,omitempty"`
+	Status        int32                  `protobuf:"varint,7,opt,name=status,proto3" json:"status,omitempty"`
+	JoinedAt      int64                  `protobuf:"varint,8,opt,name=joined_at,json=joinedAt,proto3" json:"joined_at,omitempty"` // ms
+	JoinSeq       int64                  `protobuf:"varint,9,opt,name=join_seq,json=joinSeq,proto3" json:"join_seq,omitempty"`
+	InviterUserId string                 `protobuf:"bytes,10,opt,name=inviter_user_id,json=inviterUserId,proto3" json:"inviter_user_id,omitempty"`
+	MutedUntil    int64                  `protobuf:"varint,11,opt,name=muted_until,json=mutedUntil,proto3" json:"muted_until,omitempty"` // ms, 0 when not muted
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupMember) Reset() {
+	*x = GroupMember{}
+	mi := &file_im_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupMember) ProtoMessage() {}
+
+func (x *GroupMember) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupMember.ProtoReflect.Descriptor instead.
+func (*GroupMember) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GroupMember) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *GroupMember) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GroupMember) GetGroupNickname() string {
+	if x != nil {
+		return x.GroupNickname
+	}
+	return ""
+}
+
+func (x *GroupMember) GetGroupAvatar() string {
+	if x != nil {
+		return x.GroupAvatar
+	}
+	return ""
+}
+
+func (x *GroupMember) GetExtra() string {
+	if x != nil && x.Extra != nil {
+		return *x.Extra
+	}
+	return ""
+}
+
+func (x *GroupMember) GetRoleLevel() int32 {
+	if x != nil {
+		return x.RoleLevel
+	}
+	return 0
+}
+
+func (x *GroupMember) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *GroupMember) GetJoinedAt() int64 {
+	if x != nil {
+		return x.JoinedAt
+	}
+	return 0
+}
+
+func (x *GroupMember) GetJoinSeq() int64 {
+	if x != nil {
+		return x.JoinSeq
+	}
+	return 0
+}
+
+func (x *GroupMember) GetInviterUserId() string {
+	if x != nil {
+		return x.InviterUserId
+	}
+	return ""
+}
+
+func (x *GroupMember) GetMutedUntil() int64 {
+	if x != nil {
+		return x.MutedUntil
+	}
+	return 0
+}
+
+type GetGroupInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupInfoRequest) Reset() {
+	*x = GetGroupInfoRequest{}
+	mi := &file_im_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupInfoRequest) ProtoMessage() {}
+
+func (x *GetGroupInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetGroupInfoRequest) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetGroupInfoRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+type GetGroupMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupMembersRequest) Reset() {
+	*x = GetGroupMembersRequest{}
+	mi := &file_im_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupMembersRequest) ProtoMessage() {}
+
+func (x *GetGroupMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupMembersRequest.ProtoReflect.Descriptor instead.
+func (*GetGroupMembersRequest) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetGroupMembersRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+type GetGroupMembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*GroupMember         `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupMembersResponse) Reset() {
+	*x = GetGroupMembersResponse{}
+	mi := &file_im_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupMembersResponse) ProtoMessage() {}
+
+func (x *GetGroupMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupMembersResponse.ProtoReflect.Descriptor instead.
+func (*GetGroupMembersResponse) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetGroupMembersResponse) GetMembers() []*GroupMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type ConversationInfo struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId   string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ConversationType int32                  `protobuf:"varint,2,opt,name=conversation_type,json=conversationType,proto3" json:"conversation_type,omitempty"`
+	PeerUserId       string                 `protobuf:"bytes,3,opt,name=peer_user_id,json=peerUserId,proto3" json:"peer_user_id,omitempty"`
+	GroupId          string                 `protobuf:"bytes,4,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	RecvMsgOpt       int32                  `protobuf:"varint,5,opt,name=recv_msg_opt,json=recvMsgOpt,proto3" json:"recv_msg_opt,omitempty"`
+	IsPinned         bool                   `protobuf:"varint,6,opt,name=is_pinned,json=isPinned,proto3" json:"is_pinned,omitempty"`
+	UnreadCount      int64                  `protobuf:"varint,7,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
+	MaxSeq           int64                  `protobuf:"varint,8,opt,name=max_seq,json=maxSeq,proto3" json:"max_seq,omitempty"`
+	ReadSeq          int64                  `protobuf:"varint,9,opt,name=read_seq,json=readSeq,proto3" json:"read_seq,omitempty"`
+	UpdatedAt        int64                  `protobuf:"varint,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // ms
+	LastMessage      *MessageInfo           `protobuf:"bytes,11,opt,name=last_message,json=lastMessage,proto3" json:"last_message,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ConversationInfo) Reset() {
+	*x = ConversationInfo{}
+	mi := &file_im_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationInfo) ProtoMessage() {}
+
+func (x *ConversationInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationInfo.ProtoReflect.Descriptor instead.
+func (*ConversationInfo) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ConversationInfo) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ConversationInfo) GetConversationType() int32 {
+	if x != nil {
+		return x.ConversationType
+	}
+	return 0
+}
+
+func (x *ConversationInfo) GetPeerUserId() string {
+	if x != nil {
+		return x.PeerUserId
+	}
+	return ""
+}
+
+func (x *ConversationInfo) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *ConversationInfo) GetRecvMsgOpt() int32 {
+	if x != nil {
+		return x.RecvMsgOpt
+	}
+	return 0
+}
+
+func (x *ConversationInfo) GetIsPinned() bool {
+	if x != nil {
+		return x.IsPinned
+	}
+	return false
+}
+
+func (x *ConversationInfo) GetUnreadCount() int64 {
+	if x != nil {
+		return x.UnreadCount
+	}
+	return 0
+}
+
+func (x *ConversationInfo) GetMaxSeq() int64 {
+	if x != nil {
+		return x.MaxSeq
+	}
+	return 0
+}
+
+func (x *ConversationInfo) GetReadSeq() int64 {
+	if x != nil {
+		return x.ReadSeq
+	}
+	return 0
+}
+
+func (x *ConversationInfo) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *ConversationInfo) GetLastMessage() *MessageInfo {
+	if x != nil {
+		return x.LastMessage
+	}
+	return nil
+}
+
+type ConversationListCursor struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UpdatedAt      int64                  `protobuf:"varint,1,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ConversationListCursor) Reset() {
+	*x = ConversationListCursor{}
+	mi := &file_im_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationListCursor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationListCursor) ProtoMessage() {}
+
+func (x *ConversationListCursor) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationListCursor.ProtoReflect.Descriptor instead.
+func (*ConversationListCursor) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ConversationListCursor) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *ConversationListCursor) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+type GetConversationListRequest struct {
+	state           protoimpl.MessageState  `protogen:"open.v1"`
+	WithLastMessage bool                    `protobuf:"varint,1,opt,name=with_last_message,json=withLastMessage,proto3" json:"with_last_message,omitempty"`
+	Limit           int32                   `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`  // 0 for 20, at most 100
+	Cursor          *ConversationListCursor `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"` // next_cursor of the previous page
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetConversationListRequest) Reset() {
+	*x = GetConversationListRequest{}
+	mi := &file_im_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConversationListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConversationListRequest) ProtoMessage() {}
+
+func (x *GetConversationListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConversationListRequest.ProtoReflect.Descriptor instead.
+func (*GetConversationListRequest) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetConversationListRequest) GetWithLastMessage() bool {
+	if x != nil {
+		return x.WithLastMessage
+	}
+	return false
+}
+
+func (x *GetConversationListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetConversationListRequest) GetCursor() *ConversationListCursor {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+type GetConversationListResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	List          []*ConversationInfo     `protobuf:"bytes,1,rep,name=list,proto3" json:"list,omitempty"`
+	HasMore       bool                    `protobuf:"varint,2,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	NextCursor    *ConversationListCursor `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConversationListResponse) Reset() {
+	*x = GetConversationListResponse{}
+	mi := &file_im_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConversationListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConversationListResponse) ProtoMessage() {}
+
+func (x *GetConversationListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_im_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConversationListResponse.ProtoReflect.Descriptor instead.
+func (*GetConversationListResponse) Descriptor() ([]byte, []int) {
+	return file_im_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetConversationListResponse) GetList() []*ConversationInfo {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+func (x *GetConversationListResponse) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+func (x *GetConversationListResponse) GetNextCursor() *ConversationListCursor {
+	if x != nil {
+		return x.NextCursor
+	}
+	return nil
+}
+
+var File_im_proto protoreflect.FileDescriptor
+
+const file_im_proto_rawDesc = "" +
+	"\n" +
+	"\bim.proto\x12\n" +
+	"nexo.im.v1\"\x92\x01\n" +
+	"\x0eMessageContent\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x14\n" +
+	"\x05image\x18\x02 \x01(\tR\x05image\x12\x14\n" +
+	"\x05video\x18\x03 \x01(\tR\x05video\x12\x14\n" +
+	"\x05audio\x18\x04 \x01(\tR\x05audio\x12\x12\n" +
+	"\x04file\x18\x05 \x01(\tR\x04file\x12\x16\n" +
+	"\x06custom\x18\x06 \x01(\tR\x06custom\"\xe0\x01\n" +
+	"\x12SendMessageRequest\x12\"\n" +
+	"\rclient_msg_id\x18\x01 \x01(\tR\vclientMsgId\x12\x17\n" +
+	"\arecv_id\x18\x02 \x01(\tR\x06recvId\x12\x19\n" +
+	"\bgroup_id\x18\x03 \x01(\tR\agroupId\x12!\n" +
+	"\fsession_type\x18\x04 \x01(\x05R\vsessionType\x12\x19\n" +
+	"\bmsg_type\x18\x05 \x01(\x05R\amsgType\x124\n" +
+	"\acontent\x18\x06 \x01(\v2\x1a.nexo.im.v1.MessageContentR\acontent\"\xea\x02\n" +
+	"\vMessageInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\"\n" +
+	"\rclient_msg_id\x18\x04 \x01(\tR\vclientMsgId\x12\x1b\n" +
+	"\tsender_id\x18\x05 \x01(\tR\bsenderId\x12!\n" +
+	"\fsession_type\x18\x06 \x01(\x05R\vsessionType\x12\x19\n" +
+	"\bmsg_type\x18\a \x01(\x05R\amsgType\x124\n" +
+	"\acontent\x18\b \x01(\v2\x1a.nexo.im.v1.MessageContentR\acontent\x12\x19\n" +
+	"\x05extra\x18\t \x01(\tH\x00R\x05extra\x88\x01\x01\x12\x17\n" +
+	"\asend_at\x18\n" +
+	" \x01(\x03R\x06sendAt\x12\x1d\n" +
+	"\n" +
+	"deleted_at\x18\v \x01(\x03R\tdeletedAtB\b\n" +
+	"\x06_extra\"\xc4\x01\n" +
+	"\bUserInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x16\n" +
+	"\x06avatar\x18\x03 \x01(\tR\x06avatar\x12\x19\n" +
+	"\x05extra\x18\x04 \x01(\tH\x00R\x05extra\x88\x01\x01\x12\x15\n" +
+	"\x06is_bot\x18\x05 \x01(\bR\x05isBot\x12\x19\n" +
+	"\bis_guest\x18\x06 \x01(\bR\aisGuest\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAtB\b\n" +
+	"\x06_extra\"-\n" +
+	"\x12GetUserInfoRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"0\n" +
+	"\x13GetUsersInfoRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\tR\auserIds\"B\n" +
+	"\x14GetUsersInfoResponse\x12*\n" +
+	"\x05users\x18\x01 \x03(\v2\x14.nexo.im.v1.UserInfoR\x05users\"\xe4\x02\n" +
+	"\tGroupInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\"\n" +
+	"\fintroduction\x18\x03 \x01(\tR\fintroduction\x12\x16\n" +
+	"\x06avatar\x18\x04 \x01(\tR\x06avatar\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\x05R\x06status\x12&\n" +
+	"\x0fcreator_user_id\x18\x06 \x01(\tR\rcreatorUserId\x12!\n" +
+	"\fmember_count\x18\a \x01(\x03R\vmemberCount\x12\x19\n" +
+	"\bmute_all\x18\b \x01(\bR\amuteAll\x12\"\n" +
+	"\fannouncement\x18\t \x01(\tR\fannouncement\x126\n" +
+	"\x17announcement_updated_at\x18\n" +
+	" \x01(\x03R\x15announcementUpdatedAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\"\xe8\x02\n" +
+	"\vGroupMember\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\tR\agroupId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12%\n" +
+	"\x0egroup_nickname\x18\x03 \x01(\tR\rgroupNickname\x12!\n" +
+	"\fgroup_avatar\x18\x04 \x01(\tR\vgroupAvatar\x12\x19\n" +
+	"\x05extra\x18\x05 \x01(\tH\x00R\x05extra\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"role_level\x18\x06 \x01(\x05R\troleLevel\x12\x16\n" +
+	"\x06status\x18\a \x01(\x05R\x06status\x12\x1b\n" +
+	"\tjoined_at\x18\b \x01(\x03R\bjoinedAt\x12\x19\n" +
+	"\bjoin_seq\x18\t \x01(\x03R\ajoinSeq\x12&\n" +
+	"\x0finviter_user_id\x18\n" +
+	" \x01(\tR\rinviterUserId\x12\x1f\n" +
+	"\vmuted_until\x18\v \x01(\x03R\n" +
+	"mutedUntilB\b\n" +
+	"\x06_extra\"0\n" +
+	"\x13GetGroupInfoRequest\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\tR\agroupId\"3\n" +
+	"\x16GetGroupMembersRequest\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\tR\agroupId\"L\n" +
+	"\x17GetGroupMembersResponse\x121\n" +
+	"\amembers\x18\x01 \x03(\v2\x17.nexo.im.v1.GroupMemberR\amembers\"\x96\x03\n" +
+	"\x10ConversationInfo\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12+\n" +
+	"\x11conversation_type\x18\x02 \x01(\x05R\x10conversationType\x12 \n" +
+	"\fpeer_user_id\x18\x03 \x01(\tR\n" +
+	"peerUserId\x12\x19\n" +
+	"\bgroup_id\x18\x04 \x01(\tR\agroupId\x12 \n" +
+	"\frecv_msg_opt\x18\x05 \x01(\x05R\n" +
+	"recvMsgOpt\x12\x1b\n" +
+	"\tis_pinned\x18\x06 \x01(\bR\bisPinned\x12!\n" +
+	"\funread_count\x18\a \x01(\x03R\vunreadCount\x12\x17\n" +
+	"\amax_seq\x18\b \x01(\x03R\x06maxSeq\x12\x19\n" +
+	"\bread_seq\x18\t \x01(\x03R\areadSeq\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\x03R\tupdatedAt\x12:\n" +
+	"\flast_message\x18\v \x01(\v2\x17.nexo.im.v1.MessageInfoR\vlastMessage\"`\n" +
+	"\x16ConversationListCursor\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x01 \x01(\x03R\tupdatedAt\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\"\x9a\x01\n" +
+	"\x1aGetConversationListRequest\x12*\n" +
+	"\x11with_last_message\x18\x01 \x01(\bR\x0fwithLastMessage\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12:\n" +
+	"\x06cursor\x18\x03 \x01(\v2\".nexo.im.v1.ConversationListCursorR\x06cursor\"\xaf\x01\n" +
+	"\x1bGetConversationListResponse\x120\n" +
+	"\x04list\x18\x01 \x03(\v2\x1c.nexo.im.v1.ConversationInfoR\x04list\x12\x19\n" +
+	"\bhas_more\x18\x02 \x01(\bR\ahasMore\x12C\n" +
+	"\vnext_cursor\x18\x03 \x01(\v2\".nexo.im.v1.ConversationListCursorR\n" +
+	"nextCursor2X\n" +
+	"\x0eMessageService\x12F\n" +
+	"\vSendMessage\x12\x1e.nexo.im.v1.SendMessageRequest\x1a\x17.nexo.im.v1.MessageInfo2\xa5\x01\n" +
+	"\vUserService\x12C\n" +
+	"\vGetUserInfo\x12\x1e.nexo.im.v1.GetUserInfoRequest\x1a\x14.nexo.im.v1.UserInfo\x12Q\n" +
+	"\fGetUsersInfo\x12\x1f.nexo.im.v1.GetUsersInfoRequest\x1a .nexo.im.v1.GetUsersInfoResponse2\xb2\x01\n" +
+	"\fGroupService\x12F\n" +
+	"\fGetGroupInfo\x12\x1f.nexo.im.v1.GetGroupInfoRequest\x1a\x15.nexo.im.v1.GroupInfo\x12Z\n" +
+	"\x0fGetGroupMembers\x12\".nexo.im.v1.GetGroupMembersRequest\x1a#.nexo.im.v1.GetGroupMembersResponse2}\n" +
+	"\x13ConversationService\x12f\n" +
+	"\x13GetConversationList\x12&.nexo.im.v1.GetConversationListRequest\x1a'.nexo.im.v1.GetConversationListResponseB,Z*github.com/ZaiSpace/nexo_im/api/im/v1;imv1b\x06proto3"
+
+var (
+	file_im_proto_rawDescOnce sync.Once
+	file_im_proto_rawDescData []byte
+)
+
+func file_im_proto_rawDescGZIP() []byte {
+	file_im_proto_rawDescOnce.Do(func() {
+		file_im_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_im_proto_rawDesc), len(file_im_proto_rawDesc)))
+	})
+	return file_im_proto_rawDescData
+}
+
+var file_im_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_im_proto_goTypes = []any{
+	(*MessageContent)(nil),              // 0: nexo.im.v1.MessageContent
+	(*SendMessageRequest)(nil),          // 1: nexo.im.v1.SendMessageRequest
+	(*MessageInfo)(nil),                 // 2: nexo.im.v1.MessageInfo
+	(*UserInfo)(nil),                    // 3: nexo.im.v1.UserInfo
+	(*GetUserInfoRequest)(nil),          // 4: nexo.im.v1.GetUserInfoRequest
+	(*GetUsersInfoRequest)(nil),         // 5: nexo.im.v1.GetUsersInfoRequest
+	(*GetUsersInfoResponse)(nil),        // 6: nexo.im.v1.GetUsersInfoResponse
+	(*GroupInfo)(nil),                   // 7: nexo.im.v1.GroupInfo
+	(*GroupMember)(nil),                 // 8: nexo.im.v1.GroupMember
+	(*GetGroupInfoRequest)(nil),         // 9: nexo.im.v1.GetGroupInfoRequest
+	(*GetGroupMembersRequest)(nil),      // 10: nexo.im.v1.GetGroupMembersRequest
+	(*GetGroupMembersResponse)(nil),     // 11: nexo.im.v1.GetGroupMembersResponse
+	(*ConversationInfo)(nil),            // 12: nexo.im.v1.ConversationInfo
+	(*ConversationListCursor)(nil),      // 13: nexo.im.v1.ConversationListCursor
+	(*GetConversationListRequest)(nil),  // 14: nexo.im.v1.GetConversationListRequest
+	(*GetConversationListResponse)(nil), // 15: nexo.im.v1.GetConversationListResponse
+}
+var file_im_proto_depIdxs = []int32{
+	0,  // 0: nexo.im.v1.SendMessageRequest.content:type_name -> nexo.im.v1.MessageContent
+	0,  // 1: nexo.im.v1.MessageInfo.content:type_name -> nexo.im.v1.MessageContent
+	3,  // 2: nexo.im.v1.GetUsersInfoResponse.users:type_name -> nexo.im.v1.UserInfo
+	8,  // 3: nexo.im.v1.GetGroupMembersResponse.members:type_name -> nexo.im.v1.GroupMember
+	2,  // 4: nexo.im.v1.ConversationInfo.last_message:type_name -> nexo.im.v1.MessageInfo
+	13, // 5: nexo.im.v1.GetConversationListRequest.cursor:type_name -> nexo.im.v1.ConversationListCursor
+	12, // 6: nexo.im.v1.GetConversationListResponse.list:type_name -> nexo.im.v1.ConversationInfo
+	13, // 7: nexo.im.v1.GetConversationListResponse.next_cursor:type_name -> nexo.im.v1.ConversationListCursor
+	1,  // 8: nexo.im.v1.MessageService.SendMessage:input_type -> nexo.im.v1.SendMessageRequest
+	4,  // 9: nexo.im.v1.UserService.GetUserInfo:input_type -> nexo.im.v1.GetUserInfoRequest
+	5,  // 10: nexo.im.v1.UserService.GetUsersInfo:input_type -> nexo.im.v1.GetUsersInfoRequest
+	9,  // 11: nexo.im.v1.GroupService.GetGroupInfo:input_type -> nexo.im.v1.GetGroupInfoRequest
+	10, // 12: nexo.im.v1.GroupService.GetGroupMembers:input_type -> nexo.im.v1.GetGroupMembersRequest
+	14, // 13: nexo.im.v1.ConversationService.GetConversationList:input_type -> nexo.im.v1.GetConversationListRequest
+	2,  // 14: nexo.im.v1.MessageService.SendMessage:output_type -> nexo.im.v1.MessageInfo
+	3,  // 15: nexo.im.v1.UserService.GetUserInfo:output_type -> nexo.im.v1.UserInfo
+	6,  // 16: nexo.im.v1.UserService.GetUsersInfo:output_type -> nexo.im.v1.GetUsersInfoResponse
+	7,  // 17: nexo.im.v1.GroupService.GetGroupInfo:output_type -> nexo.im.v1.GroupInfo
+	11, // 18: nexo.im.v1.GroupService.GetGroupMembers:output_type -> nexo.im.v1.GetGroupMembersResponse
+	15, // 19: nexo.im.v1.ConversationService.GetConversationList:output_type -> nexo.im.v1.GetConversationListResponse
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_im_proto_init() }
+func file_im_proto_init() {
+	if File_im_proto != nil {
+		return
+	}
+	file_im_proto_msgTypes[2].OneofWrappers = []any{}
+	file_im_proto_msgTypes[3].OneofWrappers = []any{}
+	file_im_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_im_proto_rawDesc), len(file_im_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_im_proto_goTypes,
+		DependencyIndexes: file_im_proto_depIdxs,
+		MessageInfos:      file_im_proto_msgTypes,
+	}.Build()
+	File_im_proto = out.File
+	file_im_proto_goTypes = nil
+	file_im_proto_depIdxs = nil
+}
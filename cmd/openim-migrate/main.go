@@ -0,0 +1,74 @@
+// Command openim-migrate imports the collections of an OpenIM deployment into nexo_im,
+// or exports nexo_im in the same shape.
+//
+//	openim-migrate import -dir ./openim-dump
+//	openim-migrate export -dir ./nexo-dump
+//
+// The directory holds one mongoexport file per collection: user.json, group.json,
+// group_member.json, friend.json and msg.json.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/openim"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "import" && os.Args[1] != "export") {
+		fmt.Fprintln(os.Stderr, "usage: openim-migrate import|export -dir <path> [-config <file>]")
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	dir := flags.String("dir", "", "directory of the collection files")
+	configPath := flags.String("config", "", "config file, the server's by default")
+	_ = flags.Parse(os.Args[2:])
+	if *dir == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.TODO()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.CtxError(ctx, "failed to load config: %v", err)
+		os.Exit(1)
+	}
+	constant.InitRedisKeyPrefix(cfg.Redis.KeyPrefix)
+	repos, err := repository.NewRepositories(cfg)
+	if err != nil {
+		log.CtxError(ctx, "failed to initialize repositories: %v", err)
+		os.Exit(1)
+	}
+	if err = repos.CheckConnection(ctx); err != nil {
+		log.CtxError(ctx, "database connection check failed: %v", err)
+		os.Exit(1)
+	}
+
+	var report any
+	if command == "import" {
+		report, err = openim.NewImporter(repos).Import(ctx, *dir)
+	} else {
+		if err = os.MkdirAll(*dir, 0o755); err == nil {
+			report, err = openim.NewExporter(repos).Export(ctx, *dir)
+		}
+	}
+	// The counts of a failed run tell how far it went
+	data, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(data))
+	if err != nil {
+		log.CtxError(ctx, "openim %s failed: %v", command, err)
+		os.Exit(1)
+	}
+	log.CtxInfo(ctx, "openim %s completed: dir=%s", command, *dir)
+}
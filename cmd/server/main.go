@@ -8,15 +8,32 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ZaiSpace/nexo_im/internal/agent"
+	"github.com/ZaiSpace/nexo_im/internal/analyticsexport"
+	"github.com/ZaiSpace/nexo_im/internal/chatbridge"
 	"github.com/ZaiSpace/nexo_im/internal/config"
 	"github.com/ZaiSpace/nexo_im/internal/gateway"
+	"github.com/ZaiSpace/nexo_im/internal/graphqlapi"
+	"github.com/ZaiSpace/nexo_im/internal/grpcapi"
 	"github.com/ZaiSpace/nexo_im/internal/handler"
+	"github.com/ZaiSpace/nexo_im/internal/linkpreview"
+	"github.com/ZaiSpace/nexo_im/internal/mqttbridge"
 	"github.com/ZaiSpace/nexo_im/internal/repository"
 	"github.com/ZaiSpace/nexo_im/internal/router"
+	"github.com/ZaiSpace/nexo_im/internal/scan"
 	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/internal/storage"
+	"github.com/ZaiSpace/nexo_im/internal/voip"
+	"github.com/ZaiSpace/nexo_im/pkg/analytics"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
 	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/ratelimit"
 	"github.com/ZaiSpace/nexo_im/pkg/tracing"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	hertzconfig "github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network/standard"
 	hertztracing "github.com/hertz-contrib/obs-opentelemetry/tracing"
 	"github.com/mbeoliero/kit/log"
 )
@@ -43,7 +60,6 @@ func main() {
 		log.CtxError(ctx, "failed to initialize repositories: %v", err)
 		panic(err)
 	}
-	defer func() { _ = repos.Close() }()
 
 	// Check database connection
 	if err = repos.CheckConnection(ctx); err != nil {
@@ -53,62 +69,421 @@ func main() {
 	log.CtxInfo(ctx, "database connection established")
 
 	// Initialize services
-	authService := service.NewAuthService(repos.User, cfg, repos.Redis)
+	authService := service.NewAuthService(repos, cfg)
 	userService := service.NewUserService(repos.User)
 	groupService := service.NewGroupService(repos)
 	msgService := service.NewMessageService(repos)
 	convService := service.NewConversationService(repos)
+	deletionService := service.NewDataDeletionService(repos, cfg)
+	adminService := service.NewAdminService(repos, cfg)
+	statsService := service.NewStatsService(repos)
+	auditService := service.NewAuditService(repos, cfg)
+	webhookService := service.NewWebhookService(repos, cfg)
+	healthService := service.NewHealthService(repos, cfg)
+	broadcastService := service.NewBroadcastService(repos, msgService, cfg)
+	apiKeyService := service.NewAPIKeyService(repos)
+	notificationService := service.NewNotificationService(repos)
+	reminderService := service.NewReminderService(repos, msgService, cfg)
+	favoriteService := service.NewFavoriteService(repos, msgService, cfg)
+	pollService := service.NewPollService(repos, msgService)
+	reactionService := service.NewReactionService(repos, msgService)
+	pinService := service.NewPinService(repos, convService, msgService)
+	authService.SetStats(statsService)
+	groupService.SetStats(statsService)
+	msgService.SetStats(statsService)
+	statsService.SetUsage(&cfg.UsageStats)
+	msgService.SetGuestContacts(cfg.Guest.SupportUserIds)
+	msgService.SetEncryptedLimits(&cfg.Message.Encrypted)
+	msgService.SetRevokeWindow(cfg.Message.Revoke.Window)
+	// PII is masked before the message reaches the external policy
+	if cfg.Message.PII.Enabled {
+		msgService.AddPreSendChecker(service.NewPIIFilter(&cfg.Message.PII))
+	}
+	if cfg.Message.PreSend.Enabled {
+		msgService.AddPreSendChecker(service.NewPreSendCallback(&cfg.Message.PreSend))
+	}
+	var storageService *service.StorageService
+	if cfg.Storage.Enabled {
+		objects, err := storage.New(&cfg.Storage)
+		if err != nil {
+			log.CtxError(ctx, "failed to init object storage: %v", err)
+			panic(err)
+		}
+		storageService = service.NewStorageService(repos, &cfg.Storage, objects)
+		if cfg.Storage.RequireManagedMedia {
+			msgService.SetMediaURLPrefix(storageService.MediaURLPrefix())
+		}
+		if cfg.Storage.Download.Enabled {
+			storageService.SetAccessChecker(msgService)
+			msgService.SetMediaTracker(storageService)
+		}
+		if cfg.Storage.Images.Enabled || cfg.Storage.Audio.Enabled {
+			msgService.SetMediaDescriber(storageService)
+		}
+		if cfg.UsageStats.Enabled {
+			statsService.SetMediaSizer(storageService)
+		}
+		if cfg.Storage.Scan.Enabled {
+			scanner, err := scan.New(&cfg.Storage.Scan)
+			if err != nil {
+				log.CtxError(ctx, "failed to init upload scanner: %v", err)
+				panic(err)
+			}
+			storageService.SetScanner(scanner, &cfg.Storage.Scan)
+		}
+	}
+	var linkPreviewService *service.LinkPreviewService
+	if cfg.Message.LinkPreview.Enabled {
+		fetcher := linkpreview.New(&cfg.Message.LinkPreview)
+		linkPreviewService = service.NewLinkPreviewService(repos, &cfg.Message.LinkPreview, fetcher, msgService)
+		msgService.SetLinkPreviewer(linkPreviewService)
+	}
+	var botService *service.BotService
+	if cfg.Bot.Enabled {
+		botService = service.NewBotService(&cfg.Bot, webhookService)
+		msgService.AddBotNotifier(botService)
+	}
+	var agentRouter *agent.Router
+	if cfg.Agent.Enabled {
+		backend := agent.NewHTTPBackend(&cfg.Agent)
+		if cfg.Agent.Backend == config.AgentBackendQueue {
+			backend = agent.NewQueueBackend(&cfg.Agent, repos.Redis)
+		}
+		agentRouter = agent.New(&cfg.Agent, backend, msgService)
+		msgService.AddBotNotifier(agentRouter)
+	}
+
+	// Message retention: pull ranges honor the policy, the purge job enforces it
+	retentionPolicy := service.NewRetentionPolicy(cfg.Message.Retention)
+	msgService.SetRetentionPolicy(retentionPolicy)
+	retentionService := service.NewRetentionService(repos, retentionPolicy, cfg)
+	// Disappearing messages: pulls hide them once expired, the sweeper deletes them
+	disappearingService := service.NewDisappearingService(repos, cfg)
 
 	// Initialize WebSocket server
 	wsServer := gateway.NewWsServer(cfg, repos.Redis, msgService, convService)
-	wsServer.SetAppPushSender(gateway.NewDefaultAppPushSender())
+	appPushSender := gateway.NewDefaultAppPushSender()
+	wsServer.SetAppPushSender(appPushSender)
+	if pinger, ok := appPushSender.(gateway.AppPushPinger); ok && cfg.Health.CheckAppPush {
+		healthService.AddCheck(service.HealthCheck{Name: "app_push", Check: pinger.Ping})
+	}
 
 	// Set message pusher for message service
 	msgService.SetPusher(wsServer)
+	convService.SetNotifier(wsServer)
+	pollService.SetNotifier(wsServer)
+	reactionService.SetNotifier(wsServer)
+	pinService.SetNotifier(wsServer)
+	userService.SetNotifier(wsServer, repos)
+	adminService.SetKicker(wsServer)
+	deletionService.SetKicker(wsServer)
+	authService.SetSessionKicker(wsServer)
+	wsServer.SetStats(statsService)
+	wsServer.SetNotificationSettings(notificationService)
+	var callService *service.CallService
+	if cfg.Call.Enabled {
+		callService = service.NewCallService(repos, &cfg.Call)
+		callService.SetNotifier(wsServer)
+		wsServer.SetCallService(callService)
+		if cfg.Call.VoIP.Enabled {
+			voipPusher, err := voip.New(&cfg.Call.VoIP, repos.VoIPToken)
+			if err != nil {
+				log.CtxError(ctx, "failed to initialize voip push: %v", err)
+				panic(err)
+			}
+			callService.SetPusher(voipPusher, wsServer)
+		}
+	}
 
 	// Start WebSocket server
 	wsServer.Run(ctx)
 	log.CtxInfo(ctx, "websocket server started")
 
+	// Background workers stop when workerCtx is cancelled during shutdown
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+
+	// Start message retention purge job
+	retentionService.Run(workerCtx)
+
+	// Start disappearing messages sweeper
+	disappearingService.Run(workerCtx)
+
+	// Start admin broadcast delivery job
+	broadcastService.Run(workerCtx)
+
+	// Start message reminder delivery job
+	reminderService.Run(workerCtx)
+
+	// Start security audit trail writer
+	if cfg.Audit.Enabled {
+		audit.SetSink(auditService)
+		auditService.Run(workerCtx)
+	}
+
+	// Start analytics event exporter
+	var analyticsExporter *analyticsexport.Exporter
+	if cfg.Analytics.Enabled {
+		sender, err := analyticsexport.NewSender(&cfg.Analytics)
+		if err != nil {
+			log.CtxError(ctx, "failed to init analytics sink: %v", err)
+			panic(err)
+		}
+		analyticsExporter = analyticsexport.New(&cfg.Analytics, sender)
+		analytics.SetSink(analyticsExporter)
+		analyticsExporter.Run(workerCtx)
+	}
+
+	// Start agent request workers
+	if agentRouter != nil {
+		agentRouter.Run(workerCtx)
+	}
+
+	// Start call ring timeout checks
+	if callService != nil {
+		callService.Run(workerCtx)
+	}
+
+	// Start uploaded image processing workers
+	if storageService != nil {
+		storageService.Run(workerCtx)
+	}
+
+	// Start link preview workers
+	if linkPreviewService != nil {
+		linkPreviewService.Run(workerCtx)
+	}
+
+	// Start outgoing webhook delivery workers, which also deliver to bot webhooks
+	if cfg.Webhook.Enabled {
+		webhook.SetDispatcher(webhookService)
+	}
+	if cfg.Webhook.Enabled || cfg.Bot.Enabled {
+		webhookService.Run(workerCtx)
+	}
+
 	// Initialize handlers
 	handlers := &router.Handlers{
 		Auth:         handler.NewAuthHandler(authService),
 		User:         handler.NewUserHandler(userService, wsServer),
 		Group:        handler.NewGroupHandler(groupService),
-		Message:      handler.NewMessageHandler(msgService),
+		Message:      handler.NewMessageHandler(msgService, wsServer),
 		Conversation: handler.NewConversationHandler(convService),
+		DataDeletion: handler.NewDataDeletionHandler(deletionService),
+		Notification: handler.NewNotificationHandler(notificationService),
+		Reminder:     handler.NewReminderHandler(reminderService),
+		Favorite:     handler.NewFavoriteHandler(favoriteService),
+		Poll:         handler.NewPollHandler(pollService),
+		Reaction:     handler.NewReactionHandler(reactionService),
+		Pin:          handler.NewPinHandler(pinService),
+		Admin:        handler.NewAdminHandler(adminService),
+		Stats:        handler.NewStatsHandler(statsService),
+		Audit:        handler.NewAuditHandler(auditService),
+		Webhook:      handler.NewWebhookHandler(webhookService),
+		Broadcast:    handler.NewBroadcastHandler(broadcastService),
+		APIKey:       handler.NewAPIKeyHandler(apiKeyService),
+		Health:       handler.NewHealthHandler(healthService),
+	}
+	if cfg.Debug.Enabled {
+		handlers.Debug = handler.NewDebugHandler(wsServer)
+	}
+	if cfg.Metrics.Enabled {
+		handlers.Metrics = metrics.Handler()
+	}
+	if botService != nil {
+		handlers.Bot = handler.NewBotHandler(botService)
+	}
+	if agentRouter != nil {
+		handlers.Agent = handler.NewAgentHandler(agentRouter)
+	}
+	if callService != nil {
+		handlers.Call = handler.NewCallHandler(callService)
+	}
+	if cfg.E2EE.Enabled {
+		handlers.E2EE = handler.NewE2EEHandler(service.NewE2EEService(repos, &cfg.E2EE))
+	}
+	if storageService != nil {
+		handlers.Storage = handler.NewStorageHandler(storageService)
+	}
+	if cfg.UsageStats.Enabled {
+		handlers.UsageStats = handler.NewUsageStatsHandler(statsService)
+	}
+	if cfg.ChatBridge.Enabled {
+		bridge := chatbridge.New(&cfg.ChatBridge, msgService, repos.User, groupService)
+		handlers.ChatBridge = handler.NewChatBridgeHandler(bridge)
+	}
+	if cfg.GraphQL.Enabled {
+		graphqlAPI, err := graphqlapi.New(&graphqlapi.Services{
+			User:         userService,
+			Conversation: convService,
+			Group:        groupService,
+		})
+		if err != nil {
+			log.CtxError(ctx, "failed to build graphql schema: %v", err)
+			panic(err)
+		}
+		handlers.GraphQL = handler.NewGraphQLHandler(graphqlAPI)
 	}
 
 	tracing.Init()
 	tracer, tCfg := hertztracing.NewServerTracer()
-	// Create Hertz server
-	h := server.Default(
+	opts := []hertzconfig.Option{
 		server.WithHostPorts(fmt.Sprintf(":%d", cfg.Server.HTTPPort)),
-		server.WithExitWaitTime(time.Second*30),
+		server.WithExitWaitTime(cfg.Server.ShutdownTimeout),
+		server.WithMaxRequestBodySize(cfg.Server.MaxBodyBytes),
 		tracer,
-	)
+	}
+	if cfg.Server.TLS.Enabled {
+		tlsCfg, err := cfg.Server.TLS.Build()
+		if err != nil {
+			log.CtxError(ctx, "failed to load tls certificate: %v", err)
+			panic(err)
+		}
+		// netpoll has no TLS support, TLS needs the standard library transport
+		opts = append(opts, server.WithTLS(tlsCfg), server.WithTransport(standard.NewTransporter))
+	}
+	// Create Hertz server
+	h := server.Default(opts...)
 	h.Use(hertztracing.ServerMiddleware(tCfg))
 
 	// Setup routes
-	router.SetupRouter(h, handlers, wsServer)
+	router.SetupRouter(h, handlers, wsServer, ratelimit.NewLimiter(repos.Redis), apiKeyService)
 
 	log.CtxInfo(ctx, "server starting on port %d", cfg.Server.HTTPPort)
 
-	// Start server in goroutine
+	// Start server in goroutine. Run instead of Spin: shutdown is driven below, in order.
+	serveErr := make(chan error, 3)
 	go func() {
-		h.Spin()
+		serveErr <- h.Run()
 	}()
 
+	// Redirect plain HTTP to HTTPS
+	var redirect *server.Hertz
+	if cfg.Server.TLS.Enabled && cfg.Server.TLS.RedirectHTTPPort > 0 {
+		redirect = router.NewHTTPSRedirectServer(cfg.Server.TLS.RedirectHTTPPort, cfg.Server.HTTPPort)
+		go func() {
+			serveErr <- redirect.Run()
+		}()
+		log.CtxInfo(ctx, "https redirect listening on port %d", cfg.Server.TLS.RedirectHTTPPort)
+	}
+
+	// Serve the gRPC API for service-to-service calls
+	var grpcServer *grpcapi.Server
+	if cfg.GRPC.Enabled {
+		grpcServer, err = grpcapi.NewServer(cfg, &grpcapi.Services{
+			Message:      msgService,
+			User:         userService,
+			Group:        groupService,
+			Conversation: convService,
+		})
+		if err != nil {
+			log.CtxError(ctx, "failed to create grpc server: %v", err)
+			panic(err)
+		}
+		go func() {
+			serveErr <- grpcServer.Run()
+		}()
+		log.CtxInfo(ctx, "grpc server listening on port %d, auth=%s", cfg.GRPC.Port, cfg.GRPC.Auth)
+	}
+
+	// Bridge MQTT devices to the gateway
+	var mqttBridge *mqttbridge.Bridge
+	if cfg.MQTT.Enabled {
+		broker, err := mqttbridge.NewBroker(&cfg.MQTT)
+		if err != nil {
+			log.CtxError(ctx, "failed to connect mqtt broker: %v", err)
+			panic(err)
+		}
+		mqttBridge = mqttbridge.New(cfg, broker, wsServer, msgService)
+		if err = mqttBridge.Run(ctx); err != nil {
+			log.CtxError(ctx, "failed to start mqtt bridge: %v", err)
+			panic(err)
+		}
+		log.CtxInfo(ctx, "mqtt bridge connected to %s, topic_prefix=%s", cfg.MQTT.BrokerURL, cfg.MQTT.TopicPrefix)
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case sig := <-quit:
+		log.CtxInfo(ctx, "received signal %s, shutting down server...", sig)
+	case err = <-serveErr:
+		log.CtxError(ctx, "server stopped unexpectedly, shutting down: %v", err)
+	}
+
+	// Fail readiness first so load balancers stop routing new traffic here
+	healthService.SetDraining()
+	if cfg.Server.DrainDelay > 0 {
+		time.Sleep(cfg.Server.DrainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
 
-	log.CtxInfo(ctx, "shutting down server...")
+	// 1. Stop accepting HTTP requests, gRPC calls, WS upgrades and MQTT devices, finish in-flight requests
+	if redirect != nil {
+		_ = redirect.Shutdown(shutdownCtx)
+	}
+	if err = h.Shutdown(shutdownCtx); err != nil {
+		log.CtxError(ctx, "http server shutdown error: %v", err)
+	}
+	if grpcServer != nil {
+		if err = grpcServer.Shutdown(shutdownCtx); err != nil {
+			log.CtxError(ctx, "grpc server shutdown error: %v", err)
+		}
+	}
+
+	if mqttBridge != nil {
+		if err = mqttBridge.Shutdown(shutdownCtx); err != nil {
+			log.CtxError(ctx, "mqtt bridge shutdown error: %v", err)
+		}
+	}
+
+	// 2. Deliver queued pushes, then close WS connections and mark their users offline
+	if err = wsServer.Shutdown(shutdownCtx); err != nil {
+		log.CtxError(ctx, "websocket server shutdown error: %v", err)
+	}
+
+	// 3. Stop background workers; the audit writer flushes pending events and queued
+	// webhooks get a final attempt
+	stopWorkers()
+	if err = retentionService.Wait(shutdownCtx); err != nil {
+		log.CtxError(ctx, "retention job shutdown error: %v", err)
+	}
+	if err = disappearingService.Wait(shutdownCtx); err != nil {
+		log.CtxError(ctx, "disappearing messages sweeper shutdown error: %v", err)
+	}
+	if err = broadcastService.Wait(shutdownCtx); err != nil {
+		log.CtxError(ctx, "broadcast job shutdown error: %v", err)
+	}
+	if err = reminderService.Wait(shutdownCtx); err != nil {
+		log.CtxError(ctx, "reminder job shutdown error: %v", err)
+	}
+	if err = auditService.Wait(shutdownCtx); err != nil {
+		log.CtxError(ctx, "audit writer shutdown error: %v", err)
+	}
+	if analyticsExporter != nil {
+		if err = analyticsExporter.Wait(shutdownCtx); err != nil {
+			log.CtxError(ctx, "analytics exporter shutdown error: %v", err)
+		}
+	}
+	if err = webhookService.Wait(shutdownCtx); err != nil {
+		log.CtxError(ctx, "webhook dispatcher shutdown error: %v", err)
+	}
+	if storageService != nil {
+		if err = storageService.Wait(shutdownCtx); err != nil {
+			log.CtxError(ctx, "image processing shutdown error: %v", err)
+		}
+	}
+	if linkPreviewService != nil {
+		if err = linkPreviewService.Wait(shutdownCtx); err != nil {
+			log.CtxError(ctx, "link preview shutdown error: %v", err)
+		}
+	}
 
-	// Graceful shutdown
-	if err = h.Shutdown(ctx); err != nil {
-		log.CtxError(ctx, "server shutdown error: %v", err)
+	// 4. Close MySQL and Redis last, everything above may still use them
+	if err = repos.Close(); err != nil {
+		log.CtxError(ctx, "close repositories error: %v", err)
 	}
 
 	log.CtxInfo(ctx, "server stopped")
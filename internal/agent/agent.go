@@ -0,0 +1,188 @@
+// Package agent routes the messages addressed to agent actors to an agent backend and sends
+// the agents' replies, streamed as edits of a single message, back into the conversation.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/common"
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// streamingExtra marks a reply the agent is still writing; it is cleared by the final edit
+const streamingExtra = `{"streaming":true}`
+
+// MessageSender sends and edits the replies of agents
+type MessageSender interface {
+	SendMessage(ctx context.Context, senderId string, req *service.SendMessageRequest) (*entity.Message, error)
+	ReplaceContent(ctx context.Context, msg *entity.Message, content entity.MessageContent, extra *string) error
+}
+
+// Request is what an agent backend receives for a message addressed to an agent
+type Request struct {
+	Id      int64               `json:"id"` // server_msg_id of the message, the reply_to of the reply
+	AgentId string              `json:"agent_id"`
+	RecvId  string              `json:"recv_id,omitempty"`  // single chat: the user to reply to
+	GroupId string              `json:"group_id,omitempty"` // group chat: the group to reply in
+	Message *entity.MessageInfo `json:"message"`
+}
+
+// Reply is a reply of an agent. Text is the whole reply so far: while Done is false the reply
+// is shown as streaming and each reply with the same ReplyTo replaces the text of the last.
+type Reply struct {
+	AgentId string `json:"agent_id" validate:"required,max=64"`
+	ReplyTo int64  `json:"reply_to" validate:"required"`
+	RecvId  string `json:"recv_id,omitempty" validate:"max=64"`
+	GroupId string `json:"group_id,omitempty" validate:"max=64"`
+	Text    string `json:"text" validate:"required"`
+	Done    bool   `json:"done"`
+}
+
+// Backend delivers a request to the agents. reply may be called with the growing reply text
+// while the request is handled; backends whose agents reply later through Router.Reply
+// never call it.
+type Backend interface {
+	Send(ctx context.Context, req *Request, reply func(text string, done bool) error) error
+}
+
+// Router is the service.BotNotifier handing the messages addressed to agents to the backend.
+// Requests are queued without blocking the sender and handled by the workers started by Run.
+type Router struct {
+	backend Backend
+	sender  MessageSender
+	workers int
+	jobs    chan *Request
+}
+
+// New creates a Router for cfg
+func New(cfg *config.AgentConfig, backend Backend, sender MessageSender) *Router {
+	return &Router{
+		backend: backend,
+		sender:  sender,
+		workers: cfg.Workers,
+		jobs:    make(chan *Request, cfg.QueueSize),
+	}
+}
+
+// IsAgent reports whether userId is the user of an agent actor
+func IsAgent(userId string) bool {
+	var actor common.Actor
+	return actor.FromIMUserId(userId) == nil && actor.Role == common.RoleAgent
+}
+
+// NotifyBots queues a request for each agent among the recipients of msg. Messages sent by
+// agents are not routed, so agents cannot keep replying to each other.
+func (r *Router) NotifyBots(ctx context.Context, msg *entity.Message, userIds []string) {
+	if IsAgent(msg.SenderId) {
+		return
+	}
+	var info *entity.MessageInfo
+	for _, userId := range userIds {
+		if !IsAgent(userId) {
+			continue
+		}
+		if info == nil {
+			info = msg.ToMessageInfo()
+		}
+		req := &Request{Id: msg.Id, AgentId: userId, GroupId: msg.GroupId, Message: info}
+		if msg.SessionType == constant.SessionTypeSingle {
+			req.RecvId = msg.SenderId
+		}
+		select {
+		case r.jobs <- req:
+		default:
+			log.CtxWarn(ctx, "agent queue full, request dropped: agent_id=%s, conversation_id=%s, seq=%d",
+				userId, msg.ConversationId, msg.Seq)
+		}
+	}
+}
+
+// Run starts the workers handing queued requests to the backend until ctx is done
+func (r *Router) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range r.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case req := <-r.jobs:
+					r.handle(ctx, req)
+				}
+			}
+		}()
+	}
+	log.CtxInfo(ctx, "agent router started: workers=%d", r.workers)
+}
+
+// handle sends req to the backend, replying with the text it streams back
+func (r *Router) handle(ctx context.Context, req *Request) {
+	reply := func(text string, done bool) error {
+		return r.Reply(ctx, &Reply{AgentId: req.AgentId, ReplyTo: req.Id, RecvId: req.RecvId, GroupId: req.GroupId, Text: text, Done: done})
+	}
+	if err := r.backend.Send(ctx, req, reply); err != nil {
+		log.CtxWarn(ctx, "agent request failed: agent_id=%s, conversation_id=%s, seq=%d, error=%v",
+			req.AgentId, req.Message.ConversationId, req.Message.Seq, err)
+	}
+}
+
+// Reply sends the reply of an agent, or edits the reply already sent to the same message.
+// A partial reply arriving after the final one is ignored.
+func (r *Router) Reply(ctx context.Context, reply *Reply) error {
+	if !IsAgent(reply.AgentId) || reply.ReplyTo <= 0 || reply.Text == "" || (reply.RecvId == "") == (reply.GroupId == "") {
+		return errcode.ErrInvalidParam
+	}
+	var extra *string
+	if !reply.Done {
+		s := streamingExtra
+		extra = &s
+	}
+	req := &service.SendMessageRequest{
+		ClientMsgId: fmt.Sprintf("agent_%d", reply.ReplyTo),
+		RecvId:      reply.RecvId,
+		GroupId:     reply.GroupId,
+		SessionType: constant.SessionTypeSingle,
+		MsgType:     constant.MsgTypeText,
+		Content:     entity.MessageContent{Text: &entity.TextContent{Text: reply.Text}},
+		Extra:       extra,
+	}
+	if reply.GroupId != "" {
+		req.SessionType = constant.SessionTypeGroup
+	}
+	msg, err := r.sender.SendMessage(ctx, reply.AgentId, req)
+	if err != nil {
+		return err
+	}
+
+	// msg is the new reply or, when this message was replied to before, the stored one
+	streaming := isStreaming(msg.Extra)
+	if !streaming && !reply.Done {
+		return nil
+	}
+	if streaming == !reply.Done && msg.Content.Text != nil && msg.Content.Text.Text == reply.Text {
+		return nil
+	}
+	return r.sender.ReplaceContent(ctx, msg, req.Content, extra)
+}
+
+// isStreaming reports whether the extra of a reply has the streaming mark
+func isStreaming(extra *string) bool {
+	if extra == nil {
+		return false
+	}
+	var mark struct {
+		Streaming bool `json:"streaming"`
+	}
+	return json.Unmarshal([]byte(*extra), &mark) == nil && mark.Streaming
+}
@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+// fakeSender stores messages by client_msg_id like MessageService, returning the stored
+// message when one is sent again
+type fakeSender struct {
+	messages map[string]*entity.Message
+	sends    int
+	edits    int
+}
+
+func newFakeSender() *fakeSender {
+	return &fakeSender{messages: make(map[string]*entity.Message)}
+}
+
+func (f *fakeSender) SendMessage(_ context.Context, senderId string, req *service.SendMessageRequest) (*entity.Message, error) {
+	if msg := f.messages[req.ClientMsgId]; msg != nil {
+		return msg, nil
+	}
+	f.sends++
+	msg := &entity.Message{SenderId: senderId, RecvId: req.RecvId, GroupId: req.GroupId, ClientMsgId: req.ClientMsgId,
+		MsgType: req.MsgType, Content: req.Content, Extra: req.Extra}
+	f.messages[req.ClientMsgId] = msg
+	return msg, nil
+}
+
+func (f *fakeSender) ReplaceContent(_ context.Context, msg *entity.Message, content entity.MessageContent, extra *string) error {
+	f.edits++
+	msg.Content, msg.Extra = content, extra
+	return nil
+}
+
+func TestIsAgent(t *testing.T) {
+	for userId, want := range map[string]bool{"ag__7": true, "u___7": false, "ag__x": false, "alice": false} {
+		if got := IsAgent(userId); got != want {
+			t.Errorf("IsAgent(%q) = %v, want %v", userId, got, want)
+		}
+	}
+}
+
+func TestNotifyBotsQueuesAgentRequests(t *testing.T) {
+	r := New(&config.AgentConfig{Workers: 1, QueueSize: 4}, nil, newFakeSender())
+	msg := &entity.Message{Id: 42, ConversationId: "si_ag__7:u___1", Seq: 3, SenderId: "u___1", RecvId: "ag__7",
+		SessionType: constant.SessionTypeSingle, MsgType: constant.MsgTypeText,
+		Content: entity.MessageContent{Text: &entity.TextContent{Text: "hi"}}}
+
+	r.NotifyBots(context.Background(), msg, []string{"ag__7"})
+	if len(r.jobs) != 1 {
+		t.Fatalf("expected one request, got %d", len(r.jobs))
+	}
+	req := <-r.jobs
+	if req.Id != 42 || req.AgentId != "ag__7" || req.RecvId != "u___1" || req.GroupId != "" || req.Message.Content.Text != "hi" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+
+	// Agents do not receive the messages of agents
+	msg.SenderId = "ag__8"
+	r.NotifyBots(context.Background(), msg, []string{"ag__7", "u___1"})
+	if len(r.jobs) != 0 {
+		t.Fatalf("expected no request for an agent sender")
+	}
+}
+
+func TestReplyStreamsAsEdits(t *testing.T) {
+	sender := newFakeSender()
+	r := New(&config.AgentConfig{Workers: 1, QueueSize: 1}, nil, sender)
+	ctx := context.Background()
+	reply := func(text string, done bool) error {
+		return r.Reply(ctx, &Reply{AgentId: "ag__7", ReplyTo: 42, GroupId: "g1", Text: text, Done: done})
+	}
+
+	for _, step := range []struct {
+		text string
+		done bool
+	}{{"He", false}, {"Hello", false}, {"Hello", false}, {"Hello!", true}, {"Hel", false}} {
+		if err := reply(step.text, step.done); err != nil {
+			t.Fatalf("reply %q failed: %v", step.text, err)
+		}
+	}
+	msg := sender.messages["agent_42"]
+	if sender.sends != 1 || sender.edits != 2 {
+		t.Fatalf("expected one send and two edits, got %d and %d", sender.sends, sender.edits)
+	}
+	if msg.Content.Text.Text != "Hello!" || msg.Extra != nil || msg.GroupId != "g1" {
+		t.Fatalf("unexpected final reply %+v", msg)
+	}
+
+	if err := r.Reply(ctx, &Reply{AgentId: "u___1", ReplyTo: 42, GroupId: "g1", Text: "x"}); err != errcode.ErrInvalidParam {
+		t.Fatalf("expected invalid param for a non agent, got %v", err)
+	}
+}
+
+func TestHTTPBackendStreamsReply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(signature.SignatureHeader) == "" || r.Header.Get(signature.ServiceNameHeader) != "nexo_im" {
+			t.Errorf("request not signed: %v", r.Header)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+		_, _ = w.Write([]byte("{\"delta\":\"Hel\"}\n\n{\"delta\":\"lo\"}\n{\"delta\":\"!\"}\n"))
+	}))
+	defer srv.Close()
+
+	backend := NewHTTPBackend(&config.AgentConfig{URL: srv.URL, Secret: "s", ServiceName: "nexo_im", Timeout: time.Second}).(*httpBackend)
+	type call struct {
+		text string
+		done bool
+	}
+	var calls []call
+	reply := func(text string, done bool) error {
+		calls = append(calls, call{text, done})
+		return nil
+	}
+	req := &Request{Id: 1, AgentId: "ag__7", RecvId: "u___1", Message: &entity.MessageInfo{}}
+	if err := backend.Send(context.Background(), req, reply); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	want := []call{{"Hel", false}, {"Hello", false}, {"Hello!", false}, {"Hello!", true}}
+	if len(calls) != len(want) {
+		t.Fatalf("expected %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, calls)
+		}
+	}
+
+	// Edits within the interval are skipped, the final reply is always sent
+	calls = nil
+	backend.editInterval = time.Hour
+	if err := backend.Send(context.Background(), req, reply); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != (call{"Hel", false}) || calls[1] != (call{"Hello!", true}) {
+		t.Fatalf("unexpected throttled calls %v", calls)
+	}
+}
+
+func TestHTTPBackendPlainReply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"text":"pong"}`))
+	}))
+	defer srv.Close()
+
+	backend := NewHTTPBackend(&config.AgentConfig{URL: srv.URL, Secret: "s", Timeout: time.Second})
+	var got string
+	var gotDone bool
+	err := backend.Send(context.Background(), &Request{Id: 1, Message: &entity.MessageInfo{}}, func(text string, done bool) error {
+		got, gotDone = text, done
+		return nil
+	})
+	if err != nil || got != "pong" || !gotDone {
+		t.Fatalf("unexpected reply %q done=%v err=%v", got, gotDone, err)
+	}
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+const (
+	// streamContentType is the content type of a streamed reply, one JSON chunk per line
+	streamContentType = "application/x-ndjson"
+	// maxReplySize bounds a reply read from a response, streamed or not
+	maxReplySize = 1 << 20
+)
+
+// httpReply is the body of a reply that is not streamed
+type httpReply struct {
+	Text string `json:"text"`
+}
+
+// httpChunk is a line of a streamed reply; the deltas are appended to the reply text
+type httpChunk struct {
+	Delta string `json:"delta"`
+}
+
+// httpBackend posts each request to the agent service. The response is the reply: a JSON
+// {"text": ...}, or an NDJSON stream of {"delta": ...} chunks edited into the reply as they
+// arrive. An empty text or a 202/204 response means no reply, or a later one through Router.Reply.
+type httpBackend struct {
+	url          string
+	secret       string
+	serviceName  string
+	editInterval time.Duration
+	client       *http.Client
+	now          func() time.Time
+}
+
+// NewHTTPBackend creates the http backend of cfg
+func NewHTTPBackend(cfg *config.AgentConfig) Backend {
+	return &httpBackend{
+		url:          cfg.URL,
+		secret:       cfg.Secret,
+		serviceName:  cfg.ServiceName,
+		editInterval: cfg.EditInterval,
+		client:       &http.Client{Timeout: cfg.Timeout},
+		now:          time.Now,
+	}
+}
+
+func (b *httpBackend) Send(ctx context.Context, req *Request, reply func(text string, done bool) error) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, "+streamContentType)
+	httpReq.Header.Set(signature.ServiceNameHeader, b.serviceName)
+	httpReq.Header.Set(signature.TimestampHeader, ts)
+	httpReq.Header.Set(signature.SignatureHeader,
+		signature.Sign(b.secret, b.serviceName, ts, http.MethodPost, httpReq.URL.Path, body))
+
+	resp, err := b.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	r := io.LimitReader(resp.Body, maxReplySize)
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == streamContentType {
+		return b.readStream(r, reply)
+	}
+	var result httpReply
+	if err = json.NewDecoder(r).Decode(&result); err != nil && err != io.EOF {
+		return fmt.Errorf("decode reply: %w", err)
+	}
+	if result.Text == "" {
+		return nil
+	}
+	return reply(result.Text, true)
+}
+
+// readStream edits the reply with the text received so far at most once per editInterval,
+// then finishes it. When the stream breaks off, the text received is kept as the final reply.
+func (b *httpBackend) readStream(r io.Reader, reply func(text string, done bool) error) error {
+	var text []byte
+	var sent int
+	var lastEdit time.Time
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxReplySize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk httpChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return finishStream(text, fmt.Errorf("decode reply chunk: %w", err), reply)
+		}
+		text = append(text, chunk.Delta...)
+		if len(text) > sent && b.now().Sub(lastEdit) >= b.editInterval {
+			if err := reply(string(text), false); err != nil {
+				return err
+			}
+			sent, lastEdit = len(text), b.now()
+		}
+	}
+	return finishStream(text, scanner.Err(), reply)
+}
+
+// finishStream sends the final reply, if any, and returns streamErr
+func finishStream(text []byte, streamErr error, reply func(text string, done bool) error) error {
+	if len(text) > 0 {
+		if err := reply(string(text), true); err != nil {
+			return err
+		}
+	}
+	return streamErr
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+// queueRequestField is the stream entry field holding the JSON request
+const queueRequestField = "request"
+
+// queueBackend appends each request to a Redis stream read by the agent workers, typically
+// with a consumer group; the agents reply through Router.Reply.
+type queueBackend struct {
+	rdb    redis.UniversalClient
+	stream string
+	maxLen int64
+}
+
+// NewQueueBackend creates the queue backend of cfg
+func NewQueueBackend(cfg *config.AgentConfig, rdb redis.UniversalClient) Backend {
+	return &queueBackend{rdb: rdb, stream: cfg.Stream, maxLen: cfg.StreamMaxLen}
+}
+
+func (b *queueBackend) Send(ctx context.Context, req *Request, _ func(text string, done bool) error) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return b.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.stream,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]any{queueRequestField: body},
+	}).Err()
+}
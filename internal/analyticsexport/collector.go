@@ -0,0 +1,66 @@
+package analyticsexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+// collectorBatch is the JSON body posted to the collector
+type collectorBatch struct {
+	Events []*Record `json:"events"`
+}
+
+// Collector posts batches to an HTTP collector as {"events": [...]}, signed like
+// internal-auth requests; any 2xx status acknowledges the batch
+type Collector struct {
+	url         string
+	secret      string
+	serviceName string
+	client      *http.Client
+}
+
+// NewCollector creates a new Collector
+func NewCollector(cfg *config.AnalyticsConfig) *Collector {
+	return &Collector{
+		url:         cfg.URL,
+		secret:      cfg.Secret,
+		serviceName: cfg.ServiceName,
+		client:      &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Send posts records to the collector
+func (c *Collector) Send(ctx context.Context, records []*Record) error {
+	body, err := json.Marshal(&collectorBatch{Events: records})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(signature.ServiceNameHeader, c.serviceName)
+	req.Header.Set(signature.TimestampHeader, ts)
+	req.Header.Set(signature.SignatureHeader,
+		signature.Sign(c.secret, c.serviceName, ts, http.MethodPost, req.URL.Path, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package analyticsexport exports the activity events of pkg/analytics to the data platform, an
+// HTTP collector or a Kafka topic, with user, group and conversation ids replaced by keyed
+// hashes so that funnels can be built without identifying anyone.
+package analyticsexport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/analytics"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+)
+
+// pseudonymLength is the number of hex characters kept of the id hashes
+const pseudonymLength = 32
+
+// Record is the exported form of an event
+type Record struct {
+	Event        string         `json:"event"`
+	Time         int64          `json:"ts"` // ms
+	User         string         `json:"user,omitempty"`
+	Group        string         `json:"group,omitempty"`
+	Conversation string         `json:"conversation,omitempty"`
+	Props        map[string]any `json:"props,omitempty"`
+}
+
+// Sender delivers a batch of records to the data platform
+type Sender interface {
+	Send(ctx context.Context, records []*Record) error
+}
+
+// NewSender creates the Sender of the sink of cfg
+func NewSender(cfg *config.AnalyticsConfig) (Sender, error) {
+	switch cfg.Sink {
+	case config.AnalyticsSinkHTTP:
+		return NewCollector(cfg), nil
+	case config.AnalyticsSinkKafka:
+		return NewKafka(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported sink %q", cfg.Sink)
+	}
+}
+
+// Exporter is the analytics.Sink exporting events. Events are pseudonymized and queued
+// without blocking the caller, then sent in batches by Run; a batch that cannot be sent is
+// dropped.
+type Exporter struct {
+	sender        Sender
+	hashKey       []byte
+	records       chan *Record
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	done          chan struct{} // closed when the sender loop exits
+}
+
+// New creates an Exporter sending to sender
+func New(cfg *config.AnalyticsConfig, sender Sender) *Exporter {
+	return &Exporter{
+		sender:        sender,
+		hashKey:       []byte(cfg.HashKey),
+		records:       make(chan *Record, cfg.BufferSize),
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		timeout:       cfg.Timeout,
+	}
+}
+
+// Record queues an event; it is dropped when the buffer is full
+func (e *Exporter) Record(ctx context.Context, event *analytics.Event) {
+	record := &Record{
+		Event:        event.Type,
+		Time:         event.At.UnixMilli(),
+		User:         e.pseudonym(event.UserId),
+		Group:        e.pseudonym(event.GroupId),
+		Conversation: e.pseudonym(event.ConversationId),
+		Props:        event.Props,
+	}
+	select {
+	case e.records <- record:
+	default:
+		metrics.AnalyticsEventsTotal.WithLabelValues("dropped").Inc()
+		log.CtxWarn(ctx, "analytics buffer full, event dropped: event=%s", event.Type)
+	}
+}
+
+// pseudonym returns the keyed hash standing for id, "" for no id
+func (e *Exporter) pseudonym(id string) string {
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, e.hashKey)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))[:pseudonymLength]
+}
+
+// Run starts the sender loop; pending events are sent when ctx is done
+func (e *Exporter) Run(ctx context.Context) {
+	e.done = make(chan struct{})
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.flushInterval)
+		defer ticker.Stop()
+
+		batch := make([]*Record, 0, e.batchSize)
+		flush := func(ctx context.Context) {
+			if len(batch) == 0 {
+				return
+			}
+			sendCtx, cancel := context.WithTimeout(ctx, e.timeout)
+			defer cancel()
+			if err := e.sender.Send(sendCtx, batch); err != nil {
+				metrics.AnalyticsEventsTotal.WithLabelValues("failed").Add(float64(len(batch)))
+				log.CtxError(ctx, "send analytics events failed: count=%d, error=%v", len(batch), err)
+			} else {
+				metrics.AnalyticsEventsTotal.WithLabelValues("sent").Add(float64(len(batch)))
+			}
+			batch = batch[:0]
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case record := <-e.records:
+						batch = append(batch, record)
+						if len(batch) >= e.batchSize {
+							flush(context.WithoutCancel(ctx))
+						}
+					default:
+						flush(context.WithoutCancel(ctx))
+						return
+					}
+				}
+			case record := <-e.records:
+				batch = append(batch, record)
+				if len(batch) >= e.batchSize {
+					flush(ctx)
+				}
+			case <-ticker.C:
+				flush(ctx)
+			}
+		}
+	}()
+	log.CtxInfo(ctx, "analytics exporter started: batch_size=%d, flush_interval=%s", e.batchSize, e.flushInterval)
+}
+
+// Wait blocks until the sender loop has flushed and exited after its Run ctx is done, or until ctx is done
+func (e *Exporter) Wait(ctx context.Context) error {
+	if e.done == nil {
+		return nil
+	}
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
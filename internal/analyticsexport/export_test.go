@@ -0,0 +1,139 @@
+package analyticsexport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/analytics"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+func testConfig(sink, url string) *config.AnalyticsConfig {
+	return &config.AnalyticsConfig{
+		Enabled:       true,
+		Sink:          sink,
+		URL:           url,
+		Secret:        "s3cret",
+		ServiceName:   "nexo_im",
+		Topic:         "im-activity",
+		HashKey:       "key-1",
+		Timeout:       time.Second,
+		BufferSize:    16,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}
+}
+
+type captureSender struct {
+	mu      sync.Mutex
+	batches [][]*Record
+}
+
+func (s *captureSender) Send(_ context.Context, records []*Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]*Record(nil), records...))
+	return nil
+}
+
+func TestExporterPseudonymizesIds(t *testing.T) {
+	e := New(testConfig(config.AnalyticsSinkHTTP, "http://collector"), &captureSender{})
+	other := New(&config.AnalyticsConfig{HashKey: "key-2", BufferSize: 1}, &captureSender{})
+
+	e.Record(context.Background(), &analytics.Event{Type: analytics.EventMessageSent, UserId: "u1", ConversationId: "si_u1_u2", At: time.UnixMilli(1000)})
+	record := <-e.records
+	if record.User == "" || record.User == "u1" || len(record.User) != pseudonymLength {
+		t.Fatalf("expected user pseudonym, got %q", record.User)
+	}
+	if record.User != e.pseudonym("u1") {
+		t.Fatal("expected stable pseudonyms")
+	}
+	if record.User == other.pseudonym("u1") {
+		t.Fatal("expected pseudonyms to depend on the hash key")
+	}
+	if record.Group != "" || record.Conversation == "" || record.Time != 1000 {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
+
+func TestExporterFlushesOnShutdown(t *testing.T) {
+	sender := &captureSender{}
+	e := New(testConfig(config.AnalyticsSinkHTTP, "http://collector"), sender)
+	for range 3 {
+		e.Record(context.Background(), &analytics.Event{Type: analytics.EventUserOnline, UserId: "u1"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.Run(ctx)
+	cancel()
+	if err := e.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, batch := range sender.batches {
+		if len(batch) > 2 {
+			t.Fatalf("expected batches of at most 2 records, got %d", len(batch))
+		}
+		total += len(batch)
+	}
+	if total != 3 {
+		t.Fatalf("expected 3 records sent, got %d", total)
+	}
+}
+
+func TestCollectorSignsBatches(t *testing.T) {
+	var got collectorBatch
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !signature.Verify("s3cret", r.Header.Get(signature.ServiceNameHeader), r.Header.Get(signature.TimestampHeader),
+			r.Method, r.URL.Path, body, r.Header.Get(signature.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	c := NewCollector(testConfig(config.AnalyticsSinkHTTP, srv.URL+"/events"))
+	if err := c.Send(context.Background(), []*Record{{Event: analytics.EventGroupCreated, Group: "g"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Events) != 1 || got.Events[0].Event != analytics.EventGroupCreated {
+		t.Fatalf("unexpected batch %+v", got)
+	}
+}
+
+func TestKafkaProducesKeyedRecords(t *testing.T) {
+	var path, contentType string
+	var got kafkaProduceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1},{"partition":0,"offset":2,"error_code":50002,"error":"timeout"}]}`))
+	}))
+	defer srv.Close()
+
+	k := NewKafka(testConfig(config.AnalyticsSinkKafka, srv.URL+"/"))
+	err := k.Send(context.Background(), []*Record{
+		{Event: analytics.EventMessageSent, User: "a"},
+		{Event: analytics.EventMessageSent, User: "b"},
+	})
+	if err == nil {
+		t.Fatal("expected error for the record not produced")
+	}
+	if path != "/topics/im-activity" || contentType != kafkaContentType {
+		t.Fatalf("unexpected request: path=%s, content_type=%s", path, contentType)
+	}
+	if len(got.Records) != 2 || got.Records[0].Key != "a" || got.Records[1].Value.User != "b" {
+		t.Fatalf("unexpected records %+v", got.Records)
+	}
+}
@@ -0,0 +1,109 @@
+package analyticsexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+// Content types of the Kafka REST proxy v2 API
+const (
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept      = "application/vnd.kafka.v2+json"
+)
+
+// maxKafkaResponseSize bounds the produce response read from the proxy
+const maxKafkaResponseSize = 1 << 20
+
+// kafkaRecord is a record produced to the topic
+type kafkaRecord struct {
+	Key   string  `json:"key,omitempty"`
+	Value *Record `json:"value"`
+}
+
+// kafkaProduceRequest is the body of a produce request
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaProduceResponse reports the outcome of each record of a produce request
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Kafka produces batches to a topic through a Kafka REST proxy (v2 API). Records are keyed
+// by the user pseudonym so that the events of a user keep their order within a partition.
+type Kafka struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewKafka creates a new Kafka sender
+func NewKafka(cfg *config.AnalyticsConfig) *Kafka {
+	return &Kafka{
+		url:      strings.TrimSuffix(cfg.URL, "/") + "/topics/" + url.PathEscape(cfg.Topic),
+		username: cfg.Username,
+		password: cfg.Password,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Send produces records to the topic
+func (k *Kafka) Send(ctx context.Context, records []*Record) error {
+	produce := kafkaProduceRequest{Records: make([]kafkaRecord, 0, len(records))}
+	for _, record := range records {
+		produce.Records = append(produce.Records, kafkaRecord{Key: record.User, Value: record})
+	}
+	body, err := json.Marshal(&produce)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaAccept)
+	if k.username != "" {
+		req.SetBasicAuth(k.username, k.password)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var result kafkaProduceResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxKafkaResponseSize)).Decode(&result); err != nil {
+		return err
+	}
+	failed := 0
+	var firstErr string
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			if failed == 0 {
+				firstErr = offset.Error
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d records not produced: %s", failed, len(records), firstErr)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package chatbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// MessageSender sends the bridged messages
+type MessageSender interface {
+	SendMessage(ctx context.Context, senderId string, req *service.SendMessageRequest) (*entity.Message, error)
+}
+
+// UserStore creates the shadow users of external senders
+type UserStore interface {
+	GetById(ctx context.Context, id string) (*entity.User, error)
+	Create(ctx context.Context, user *entity.User) error
+}
+
+// GroupJoiner adds the shadow users to the groups their chats are mapped to
+type GroupJoiner interface {
+	IsActiveMember(ctx context.Context, groupId, userId string) (bool, error)
+	JoinGroup(ctx context.Context, groupId, userId, inviterId string) error
+}
+
+// Bridge receives the webhooks of the configured connectors
+type Bridge struct {
+	connectors map[string]*connector
+	sender     MessageSender
+	users      UserStore
+	groups     GroupJoiner
+}
+
+// connector is a configured Connector and its chat mapping
+type connector struct {
+	Connector
+	cfg   config.ChatBridgeConnector
+	chats map[string]config.ChatBridgeChat
+}
+
+// New creates a Bridge for the connectors of cfg
+func New(cfg *config.ChatBridgeConfig, sender MessageSender, users UserStore, groups GroupJoiner) *Bridge {
+	b := &Bridge{
+		connectors: make(map[string]*connector, len(cfg.Connectors)),
+		sender:     sender,
+		users:      users,
+		groups:     groups,
+	}
+	for _, c := range cfg.Connectors {
+		conn := &connector{Connector: connectorTypes[c.Type](c.Secret), cfg: c, chats: make(map[string]config.ChatBridgeChat, len(c.Chats))}
+		for _, chat := range c.Chats {
+			conn.chats[chat.ChatId] = chat
+		}
+		b.connectors[c.Name] = conn
+	}
+	return b
+}
+
+// Handle handles a webhook request of the connector name and returns the body to reply with.
+// A message that fails to send fails the request so the platform redelivers it; the
+// messages already sent are deduplicated by their client_msg_id.
+func (b *Bridge) Handle(ctx context.Context, name string, header func(name string) string, body []byte) (any, error) {
+	conn := b.connectors[name]
+	if conn == nil {
+		return nil, errcode.ErrNotFound
+	}
+	if err := conn.Verify(header, body); err != nil {
+		log.CtxWarn(ctx, "chat bridge webhook rejected: connector=%s, error=%v", name, err)
+		return nil, errcode.ErrUnauthorized
+	}
+	messages, reply, err := conn.Parse(body)
+	if err != nil {
+		return nil, errcode.ErrInvalidParam
+	}
+	for _, in := range messages {
+		if err = b.forward(ctx, conn, in); err != nil {
+			log.CtxWarn(ctx, "chat bridge forward failed: connector=%s, chat_id=%s, id=%s, error=%v", name, in.ChatId, in.Id, err)
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+// forward sends an inbound message into the conversation its chat is mapped to
+func (b *Bridge) forward(ctx context.Context, conn *connector, in *Inbound) error {
+	chat, ok := conn.chats[in.ChatId]
+	if !ok {
+		if conn.cfg.DefaultRecvId == "" {
+			log.CtxDebug(ctx, "chat bridge dropped unmapped chat: connector=%s, chat_id=%s", conn.cfg.Name, in.ChatId)
+			return nil
+		}
+		chat = config.ChatBridgeChat{ChatId: in.ChatId, RecvId: conn.cfg.DefaultRecvId}
+	}
+
+	senderId, err := b.shadowUser(ctx, conn.cfg.Name, in)
+	if err != nil {
+		return err
+	}
+	req := &service.SendMessageRequest{
+		ClientMsgId: fmt.Sprintf("bridge_%s_%s", conn.cfg.Name, in.Id),
+		SessionType: constant.SessionTypeSingle,
+		RecvId:      chat.RecvId,
+		MsgType:     constant.MsgTypeText,
+		Content:     entity.MessageContent{Text: &entity.TextContent{Text: in.Text}},
+	}
+	if chat.GroupId != "" {
+		if err = b.joinGroup(ctx, chat.GroupId, senderId); err != nil {
+			return err
+		}
+		req.SessionType = constant.SessionTypeGroup
+		req.RecvId, req.GroupId = "", chat.GroupId
+	}
+	_, err = b.sender.SendMessage(ctx, senderId, req)
+	return err
+}
+
+// shadowUser returns the id of the user standing for the external sender, creating it the first time
+func (b *Bridge) shadowUser(ctx context.Context, name string, in *Inbound) (string, error) {
+	userId := name + "_" + in.UserId
+	user, err := b.users.GetById(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+	if user != nil {
+		return userId, nil
+	}
+	extra := fmt.Sprintf(`{"chat_bridge":%q}`, name)
+	err = b.users.Create(ctx, &entity.User{Id: userId, Nickname: in.UserName, Extra: &extra, Status: constant.UserStatusNormal})
+	if err != nil {
+		// Created by a concurrent delivery
+		if user, _ = b.users.GetById(ctx, userId); user != nil {
+			return userId, nil
+		}
+		return "", err
+	}
+	log.CtxInfo(ctx, "chat bridge shadow user created: user_id=%s", userId)
+	return userId, nil
+}
+
+func (b *Bridge) joinGroup(ctx context.Context, groupId, userId string) error {
+	member, err := b.groups.IsActiveMember(ctx, groupId, userId)
+	if err != nil || member {
+		return err
+	}
+	err = b.groups.JoinGroup(ctx, groupId, userId, "")
+	if errors.Is(err, errcode.ErrAlreadyGroupMember) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package chatbridge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+type fakeSender struct {
+	senders []string
+	reqs    []*service.SendMessageRequest
+}
+
+func (f *fakeSender) SendMessage(ctx context.Context, senderId string, req *service.SendMessageRequest) (*entity.Message, error) {
+	f.senders = append(f.senders, senderId)
+	f.reqs = append(f.reqs, req)
+	return &entity.Message{SenderId: senderId, ClientMsgId: req.ClientMsgId}, nil
+}
+
+type fakeUsers map[string]*entity.User
+
+func (f fakeUsers) GetById(ctx context.Context, id string) (*entity.User, error) {
+	return f[id], nil
+}
+
+func (f fakeUsers) Create(ctx context.Context, user *entity.User) error {
+	f[user.Id] = user
+	return nil
+}
+
+type fakeGroups map[string]bool
+
+func (f fakeGroups) IsActiveMember(ctx context.Context, groupId, userId string) (bool, error) {
+	return f[groupId+"/"+userId], nil
+}
+
+func (f fakeGroups) JoinGroup(ctx context.Context, groupId, userId, inviterId string) error {
+	f[groupId+"/"+userId] = true
+	return nil
+}
+
+func newTestBridge() (*Bridge, *fakeSender, fakeUsers, fakeGroups) {
+	cfg := &config.ChatBridgeConfig{Enabled: true, Connectors: []config.ChatBridgeConnector{
+		{Name: "tg", Type: "telegram", Secret: "tg-secret", DefaultRecvId: "support", Chats: []config.ChatBridgeChat{{ChatId: "-100", GroupId: "g1"}}},
+		{Name: "slack", Type: "slack", Secret: "slack-secret"},
+	}}
+	sender, users, groups := &fakeSender{}, fakeUsers{}, fakeGroups{}
+	return New(cfg, sender, users, groups), sender, users, groups
+}
+
+func headers(values map[string]string) func(string) string {
+	return func(name string) string { return values[name] }
+}
+
+func TestTelegramWebhook(t *testing.T) {
+	b, sender, users, groups := newTestBridge()
+	ctx := context.Background()
+	private := []byte(`{"update_id":1,"message":{"message_id":7,"from":{"id":42,"first_name":"Ann","last_name":"Lee"},"chat":{"id":42},"text":"hello"}}`)
+
+	if _, err := b.Handle(ctx, "tg", headers(map[string]string{telegramSecretHeader: "wrong"}), private); !errors.Is(err, errcode.ErrUnauthorized) {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+
+	secret := headers(map[string]string{telegramSecretHeader: "tg-secret"})
+	if _, err := b.Handle(ctx, "tg", secret, private); err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+	if users["tg_42"] == nil || users["tg_42"].Nickname != "Ann Lee" {
+		t.Fatalf("expected shadow user, got %+v", users)
+	}
+	req := sender.reqs[0]
+	if sender.senders[0] != "tg_42" || req.RecvId != "support" || req.ClientMsgId != "bridge_tg_42:7" || req.Content.Text.Text != "hello" {
+		t.Fatalf("unexpected single chat send: %+v", req)
+	}
+
+	// A mapped group chat joins the shadow user to the group
+	group := []byte(`{"update_id":2,"message":{"message_id":8,"from":{"id":43,"username":"bob"},"chat":{"id":-100},"caption":"photo"}}`)
+	if _, err := b.Handle(ctx, "tg", secret, group); err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+	req = sender.reqs[1]
+	if !groups["g1/tg_43"] || req.GroupId != "g1" || req.RecvId != "" || req.Content.Text.Text != "photo" {
+		t.Fatalf("unexpected group send: %+v, groups=%v", req, groups)
+	}
+
+	// Bot messages are ignored
+	bot := []byte(`{"update_id":3,"message":{"message_id":9,"from":{"id":44,"is_bot":true},"chat":{"id":44},"text":"beep"}}`)
+	if _, err := b.Handle(ctx, "tg", secret, bot); err != nil || len(sender.reqs) != 2 {
+		t.Fatalf("expected bot message ignored, err=%v, sends=%d", err, len(sender.reqs))
+	}
+
+	if _, err := b.Handle(ctx, "unknown", secret, private); !errors.Is(err, errcode.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func slackHeaders(body []byte, ts int64, secret string) func(string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(ts, 10) + ":"))
+	mac.Write(body)
+	return headers(map[string]string{
+		slackTimestampHeader: strconv.FormatInt(ts, 10),
+		slackSignatureHeader: "v0=" + hex.EncodeToString(mac.Sum(nil)),
+	})
+}
+
+func TestSlackWebhook(t *testing.T) {
+	b, sender, _, _ := newTestBridge()
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	challenge := []byte(`{"type":"url_verification","challenge":"abc"}`)
+	reply, err := b.Handle(ctx, "slack", slackHeaders(challenge, now, "slack-secret"), challenge)
+	if err != nil || reply.(map[string]string)["challenge"] != "abc" {
+		t.Fatalf("unexpected challenge reply: %v, err=%v", reply, err)
+	}
+
+	// Unmapped chats are dropped without a default_recv_id
+	event := []byte(`{"type":"event_callback","event":{"type":"message","user":"U1","text":"hi","channel":"C1","ts":"1.2"}}`)
+	if _, err = b.Handle(ctx, "slack", slackHeaders(event, now, "slack-secret"), event); err != nil || len(sender.reqs) != 0 {
+		t.Fatalf("expected unmapped chat dropped, err=%v, sends=%d", err, len(sender.reqs))
+	}
+
+	for name, h := range map[string]func(string) string{
+		"bad secret": slackHeaders(event, now, "other"),
+		"replayed":   slackHeaders(event, now-int64(time.Hour/time.Second), "slack-secret"),
+	} {
+		if _, err = b.Handle(ctx, "slack", h, event); !errors.Is(err, errcode.ErrUnauthorized) {
+			t.Fatalf("%s: expected unauthorized, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package chatbridge sends the messages of external chat platforms into nexo_im conversations.
+package chatbridge
+
+import (
+	"errors"
+)
+
+var (
+	errBadSignature = errors.New("chatbridge: webhook signature invalid")
+	errBadPayload   = errors.New("chatbridge: webhook payload invalid")
+)
+
+// Inbound is a text message received from an external chat
+type Inbound struct {
+	Id       string // unique per connector, deduplicates redeliveries
+	ChatId   string
+	UserId   string
+	UserName string
+	Text     string
+}
+
+// Connector authenticates and parses the webhook requests of an external chat platform
+type Connector interface {
+	// Verify authenticates a webhook request, header returning the request header of a name
+	Verify(header func(name string) string, body []byte) error
+	// Parse returns the messages of a webhook request and, when the platform expects
+	// one, the JSON body to reply with
+	Parse(body []byte) ([]*Inbound, any, error)
+}
+
+// connectorTypes creates the connectors of each supported platform from their secret
+var connectorTypes = map[string]func(secret string) Connector{
+	"telegram": newTelegramConnector,
+	"slack":    newSlackConnector,
+}
@@ -0,0 +1,84 @@
+package chatbridge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	slackSignatureHeader = "X-Slack-Signature"
+	slackTimestampHeader = "X-Slack-Request-Timestamp"
+	// slackMaxSkew rejects replayed requests, as recommended by Slack
+	slackMaxSkew = 5 * time.Minute
+)
+
+// slackEnvelope is the payload of the Slack Events API, only the fields the bridge uses
+type slackEnvelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Event     struct {
+		Type    string `json:"type"`
+		Subtype string `json:"subtype"`
+		User    string `json:"user"`
+		BotId   string `json:"bot_id"`
+		Text    string `json:"text"`
+		Channel string `json:"channel"`
+		Ts      string `json:"ts"`
+	} `json:"event"`
+}
+
+type slackConnector struct {
+	secret string
+	now    func() time.Time
+}
+
+func newSlackConnector(secret string) Connector {
+	return &slackConnector{secret: secret, now: time.Now}
+}
+
+// Verify checks the v0 signature of the request, HMAC-SHA256 of "v0:<timestamp>:<body>"
+func (s *slackConnector) Verify(header func(name string) string, body []byte) error {
+	ts, err := strconv.ParseInt(header(slackTimestampHeader), 10, 64)
+	if err != nil {
+		return errBadSignature
+	}
+	if skew := s.now().Sub(time.Unix(ts, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return errBadSignature
+	}
+	mac := hmac.New(sha256.New, []byte(s.secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(ts, 10) + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(header(slackSignatureHeader)), []byte(expected)) {
+		return errBadSignature
+	}
+	return nil
+}
+
+// Parse answers the url_verification challenge and returns the new messages of users;
+// edits, bot messages and other events are ignored
+func (s *slackConnector) Parse(body []byte) ([]*Inbound, any, error) {
+	var envelope slackEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, nil, errBadPayload
+	}
+	if envelope.Type == "url_verification" {
+		return nil, map[string]string{"challenge": envelope.Challenge}, nil
+	}
+	e := envelope.Event
+	if envelope.Type != "event_callback" || e.Type != "message" || e.Subtype != "" || e.BotId != "" || e.User == "" || e.Text == "" {
+		return nil, nil, nil
+	}
+	return []*Inbound{{
+		// Timestamps are unique per channel
+		Id:       e.Channel + ":" + e.Ts,
+		ChatId:   e.Channel,
+		UserId:   e.User,
+		UserName: e.User, // Resolving the display name needs a Web API token
+		Text:     e.Text,
+	}}, nil, nil
+}
@@ -0,0 +1,81 @@
+package chatbridge
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// telegramSecretHeader carries the secret_token given to setWebhook
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// telegramUpdate is the webhook payload of the Telegram Bot API, only the fields the bridge uses
+type telegramUpdate struct {
+	UpdateId int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	MessageId int64 `json:"message_id"`
+	From      *struct {
+		Id        int64  `json:"id"`
+		IsBot     bool   `json:"is_bot"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Username  string `json:"username"`
+	} `json:"from"`
+	Chat struct {
+		Id int64 `json:"id"`
+	} `json:"chat"`
+	Text    string `json:"text"`
+	Caption string `json:"caption"` // text of photos and documents, whose files are not bridged
+}
+
+type telegramConnector struct {
+	secret string
+}
+
+func newTelegramConnector(secret string) Connector {
+	return &telegramConnector{secret: secret}
+}
+
+func (t *telegramConnector) Verify(header func(name string) string, body []byte) error {
+	if subtle.ConstantTimeCompare([]byte(header(telegramSecretHeader)), []byte(t.secret)) != 1 {
+		return errBadSignature
+	}
+	return nil
+}
+
+// Parse returns the text of new messages from users, edits and other updates are ignored
+func (t *telegramConnector) Parse(body []byte) ([]*Inbound, any, error) {
+	var update telegramUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
+		return nil, nil, errBadPayload
+	}
+	m := update.Message
+	if m == nil || m.From == nil || m.From.IsBot {
+		return nil, nil, nil
+	}
+	text := m.Text
+	if text == "" {
+		text = m.Caption
+	}
+	if text == "" {
+		return nil, nil, nil
+	}
+
+	name := strings.TrimSpace(m.From.FirstName + " " + m.From.LastName)
+	if name == "" {
+		name = m.From.Username
+	}
+	chatId := strconv.FormatInt(m.Chat.Id, 10)
+	return []*Inbound{{
+		// Message ids are unique per chat
+		Id:       chatId + ":" + strconv.FormatInt(m.MessageId, 10),
+		ChatId:   chatId,
+		UserId:   strconv.FormatInt(m.From.Id, 10),
+		UserName: name,
+		Text:     text,
+	}}, nil, nil
+}
@@ -1,12 +1,20 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/pii"
 )
 
 const (
@@ -21,21 +29,57 @@ const (
 
 // Config holds all configuration
 type Config struct {
-	Server       ServerConfig       `mapstructure:"server"`
-	MySQL        MySQLConfig        `mapstructure:"mysql"`
-	Redis        RedisConfig        `mapstructure:"redis"`
-	JWT          JWTConfig          `mapstructure:"jwt"`
-	ExternalJWT  ExternalJWTConfig  `mapstructure:"external_jwt"`
-	InternalAuth InternalAuthConfig `mapstructure:"internal_auth"`
-	WebSocket    WebSocketConfig    `mapstructure:"websocket"`
+	Server         ServerConfig         `mapstructure:"server"`
+	MySQL          MySQLConfig          `mapstructure:"mysql"`
+	Redis          RedisConfig          `mapstructure:"redis"`
+	JWT            JWTConfig            `mapstructure:"jwt"`
+	Verification   VerificationConfig   `mapstructure:"verification"`
+	OAuth          OAuthConfig          `mapstructure:"oauth"`
+	LoginThrottle  LoginThrottleConfig  `mapstructure:"login_throttle"`
+	Captcha        CaptchaConfig        `mapstructure:"captcha"`
+	Guest          GuestConfig          `mapstructure:"guest"`
+	ExternalJWT    ExternalJWTConfig    `mapstructure:"external_jwt"`
+	InternalAuth   InternalAuthConfig   `mapstructure:"internal_auth"`
+	WebSocket      WebSocketConfig      `mapstructure:"websocket"`
+	Message        MessageConfig        `mapstructure:"message"`
+	DataDeletion   DataDeletionConfig   `mapstructure:"data_deletion"`
+	Admin          AdminConfig          `mapstructure:"admin"`
+	Debug          DebugConfig          `mapstructure:"debug"`
+	Metrics        MetricsConfig        `mapstructure:"metrics"`
+	Audit          AuditConfig          `mapstructure:"audit"`
+	Webhook        WebhookConfig        `mapstructure:"webhook"`
+	GRPC           GRPCConfig           `mapstructure:"grpc"`
+	GraphQL        GraphQLConfig        `mapstructure:"graphql"`
+	MQTT           MQTTConfig           `mapstructure:"mqtt"`
+	ChatBridge     ChatBridgeConfig     `mapstructure:"chat_bridge"`
+	Bot            BotConfig            `mapstructure:"bot"`
+	Agent          AgentConfig          `mapstructure:"agent"`
+	Call           CallConfig           `mapstructure:"call"`
+	E2EE           E2EEConfig           `mapstructure:"e2ee"`
+	Storage        StorageConfig        `mapstructure:"storage"`
+	Secrets        SecretsConfig        `mapstructure:"secrets"`
+	RateLimit      RateLimitConfig      `mapstructure:"rate_limit"`
+	RequestTimeout RequestTimeoutConfig `mapstructure:"request_timeout"`
+	Broadcast      BroadcastConfig      `mapstructure:"broadcast"`
+	Reminder       ReminderConfig       `mapstructure:"reminder"`
+	Favorite       FavoriteConfig       `mapstructure:"favorite"`
+	IPAccess       IPAccessConfig       `mapstructure:"ip_access"`
+	Health         HealthConfig         `mapstructure:"health"`
+	RequestLog     RequestLogConfig     `mapstructure:"request_log"`
+	UsageStats     UsageStatsConfig     `mapstructure:"usage_stats"`
+	Analytics      AnalyticsConfig      `mapstructure:"analytics"`
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	HTTPPort       int      `mapstructure:"http_port"`
-	WSPort         int      `mapstructure:"ws_port"`
-	Mode           string   `mapstructure:"mode"`
-	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	HTTPPort        int           `mapstructure:"http_port"`
+	WSPort          int           `mapstructure:"ws_port"`
+	Mode            string        `mapstructure:"mode"`
+	AllowedOrigins  []string      `mapstructure:"allowed_origins"`
+	MaxBodyBytes    int           `mapstructure:"max_body_bytes"` // larger request bodies are rejected with 413
+	TLS             TLSConfig     `mapstructure:"tls"`
+	DrainDelay      time.Duration `mapstructure:"drain_delay"`      // readiness fails this long before the listener closes
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // deadline for stopping all subsystems, defaults to 30s
 }
 
 // MySQLConfig holds MySQL configuration
@@ -79,16 +123,234 @@ func (c *RedisConfig) Addr() string {
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret      string `mapstructure:"secret"`
-	ExpireHours int    `mapstructure:"expire_hours"`
+	Secret      string        `mapstructure:"secret"`
+	ExpireHours int           `mapstructure:"expire_hours"` // session lifetime: refresh tokens expire after this long unused
+	AccessTTL   time.Duration `mapstructure:"access_ttl"`   // access token lifetime, defaults to 15m
+	// Platforms overrides the lifetimes per login platform: ios, android, windows, macos or web
+	Platforms map[string]JWTPlatformConfig `mapstructure:"platforms"`
+}
+
+// JWTPlatformConfig holds the token lifetimes of one platform; zero values use the jwt defaults
+type JWTPlatformConfig struct {
+	ExpireHours int           `mapstructure:"expire_hours"`
+	AccessTTL   time.Duration `mapstructure:"access_ttl"`
+}
+
+// jwtPlatformIds maps the platform names used in jwt.platforms to platform Ids
+var jwtPlatformIds = map[string]int{
+	"ios":     constant.PlatformIdIOS,
+	"android": constant.PlatformIdAndroid,
+	"windows": constant.PlatformIdWindows,
+	"macos":   constant.PlatformIdMacOS,
+	"web":     constant.PlatformIdWeb,
+}
+
+func (c *JWTConfig) validate() error {
+	for name, p := range c.Platforms {
+		if _, ok := jwtPlatformIds[name]; !ok {
+			return fmt.Errorf("unknown platform %q", name)
+		}
+		if p.ExpireHours < 0 || p.AccessTTL < 0 {
+			return fmt.Errorf("platform %q has a negative lifetime", name)
+		}
+	}
+	return nil
+}
+
+// SessionTTL returns how long a session of the platform lasts without being refreshed
+func (c *JWTConfig) SessionTTL(platformId int) time.Duration {
+	hours := c.platform(platformId).ExpireHours
+	if hours == 0 {
+		hours = c.ExpireHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// AccessTokenTTL returns the access token lifetime of the platform
+func (c *JWTConfig) AccessTokenTTL(platformId int) time.Duration {
+	if ttl := c.platform(platformId).AccessTTL; ttl > 0 {
+		return ttl
+	}
+	return c.AccessTTL
+}
+
+func (c *JWTConfig) platform(platformId int) JWTPlatformConfig {
+	for name, id := range jwtPlatformIds {
+		if id == platformId {
+			return c.Platforms[name]
+		}
+	}
+	return JWTPlatformConfig{}
+}
+
+// VerificationConfig controls the codes sent to users registering with an email or phone.
+// Such users cannot log in until they confirm the code.
+type VerificationConfig struct {
+	CodeTTL        time.Duration `mapstructure:"code_ttl"`        // defaults to 10m
+	ResendInterval time.Duration `mapstructure:"resend_interval"` // minimum gap between codes sent to one user, defaults to 60s
+	MaxAttempts    int           `mapstructure:"max_attempts"`    // wrong guesses before the code is dropped, defaults to 5
+}
+
+// LoginThrottleConfig slows down password guessing. Failed logins are counted per user
+// and per client IP in Redis: after FreeAttempts failures each further failure locks the
+// subject for BaseDelay doubled per failure, and LockoutAttempts failures lock it for
+// LockoutDuration. Counters reset after a successful login or LockoutDuration without failures.
+type LoginThrottleConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	FreeAttempts    int           `mapstructure:"free_attempts"`    // defaults to 3
+	BaseDelay       time.Duration `mapstructure:"base_delay"`       // defaults to 1s
+	LockoutAttempts int           `mapstructure:"lockout_attempts"` // defaults to 10
+	LockoutDuration time.Duration `mapstructure:"lockout_duration"` // defaults to 15m
+	IPMultiplier    int           `mapstructure:"ip_multiplier"`    // per IP attempt limits are this many times the per user ones, defaults to 5
+}
+
+// CaptchaConfig requires a solved captcha on public registration and on password login
+// once a user or client IP has failed LoginAfterFailures times (needs login_throttle).
+type CaptchaConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	Provider           string `mapstructure:"provider"`             // "hcaptcha", "turnstile" or "slider"
+	Secret             string `mapstructure:"secret"`               // server side secret of the site
+	VerifyURL          string `mapstructure:"verify_url"`           // siteverify endpoint, required for slider
+	OnRegister         bool   `mapstructure:"on_register"`          // require a captcha on /im/auth/register
+	LoginAfterFailures int    `mapstructure:"login_after_failures"` // defaults to 3, per IP scaled by login_throttle.ip_multiplier
 }
 
-// ExternalJWTConfig holds external JWT configuration for integrating with other systems
+func (c *CaptchaConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	switch c.Provider {
+	case "hcaptcha", "turnstile":
+	case "slider":
+		if c.VerifyURL == "" {
+			return fmt.Errorf("verify_url is required for slider")
+		}
+	default:
+		return fmt.Errorf("unsupported provider %q", c.Provider)
+	}
+	return nil
+}
+
+// GuestConfig configures temporary guest accounts created by /im/auth/guest, e.g. for
+// pre-login support chat. Guests may only message the support users and expire after TTL
+// unless upgraded to a full account.
+type GuestConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	TTL            time.Duration `mapstructure:"ttl"`              // defaults to 24h
+	SupportUserIds []string      `mapstructure:"support_user_ids"` // users guests may chat with
+}
+
+// OAuthConfig lists the identity providers accepted by /im/auth/oauth/callback.
+// Users logging in through a provider for the first time get an IM account linked to it.
+type OAuthConfig struct {
+	Providers []OAuthProviderConfig `mapstructure:"providers"`
+}
+
+// OAuthProviderConfig configures one identity provider
+type OAuthProviderConfig struct {
+	Name         string `mapstructure:"name"` // referenced by the provider field of login requests
+	Type         string `mapstructure:"type"` // "google", "wechat" or "oidc"
+	ClientId     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	RedirectURL  string `mapstructure:"redirect_url"` // redirect_uri used when the request has none
+	Issuer       string `mapstructure:"issuer"`       // OIDC only, endpoints are discovered from it
+	TokenURL     string `mapstructure:"token_url"`    // optional, overrides the discovered endpoint
+	UserInfoURL  string `mapstructure:"userinfo_url"` // optional, overrides the discovered endpoint
+}
+
+func (c *OAuthConfig) validate() error {
+	seen := make(map[string]bool, len(c.Providers))
+	for _, p := range c.Providers {
+		if p.Name == "" || p.ClientId == "" {
+			return fmt.Errorf("providers require name and client_id")
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate provider %q", p.Name)
+		}
+		seen[p.Name] = true
+		switch p.Type {
+		case "google", "wechat":
+		case "oidc":
+			if p.Issuer == "" && (p.TokenURL == "" || p.UserInfoURL == "") {
+				return fmt.Errorf("provider %q requires issuer or token_url and userinfo_url", p.Name)
+			}
+		default:
+			return fmt.Errorf("provider %q has unsupported type %q", p.Name, p.Type)
+		}
+	}
+	return nil
+}
+
+// ExternalJWTConfig holds external JWT configuration for integrating with other systems.
+// Secret configures an implicit issuer accepting any iss claim, tried after Issuers.
 type ExternalJWTConfig struct {
-	Enabled           bool   `mapstructure:"enabled"`
-	Secret            string `mapstructure:"secret"`
-	DefaultRole       string `mapstructure:"default_role"`        // "user" or "agent", defaults to "user"
-	DefaultPlatformId int    `mapstructure:"default_platform_id"` // defaults to PlatformIdWeb(5)
+	Enabled           bool                   `mapstructure:"enabled"`
+	Secret            string                 `mapstructure:"secret"`
+	DefaultRole       string                 `mapstructure:"default_role"`        // "user" or "agent", defaults to "user"
+	DefaultPlatformId int                    `mapstructure:"default_platform_id"` // defaults to PlatformIdIOS(1)
+	Issuers           []ExternalIssuerConfig `mapstructure:"issuers"`
+}
+
+// ExternalIssuerConfig configures one external token issuer, verified with either
+// an HMAC secret or the keys published at jwks_url
+type ExternalIssuerConfig struct {
+	Name              string        `mapstructure:"name"`
+	Issuer            string        `mapstructure:"issuer"`   // expected iss claim, empty accepts any
+	Audience          string        `mapstructure:"audience"` // expected aud claim, empty skips the check
+	Secret            string        `mapstructure:"secret"`
+	JWKSURL           string        `mapstructure:"jwks_url"`
+	JWKSRefresh       time.Duration `mapstructure:"jwks_refresh"`    // defaults to 1h
+	UserIdClaim       string        `mapstructure:"user_id_claim"`   // defaults to "user_id"
+	UserIdMapping     string        `mapstructure:"user_id_mapping"` // "actor" (default) or "raw"
+	UserIdPrefix      string        `mapstructure:"user_id_prefix"`  // prepended to raw user ids
+	RoleClaim         string        `mapstructure:"role_claim"`      // defaults to "role"
+	DefaultRole       string        `mapstructure:"default_role"`    // defaults to external_jwt.default_role
+	PlatformIdClaim   string        `mapstructure:"platform_id_claim"`
+	DefaultPlatformId int           `mapstructure:"default_platform_id"` // defaults to external_jwt.default_platform_id
+}
+
+// AllIssuers returns the configured issuers followed by the implicit issuer of Secret,
+// with defaults filled in
+func (c *ExternalJWTConfig) AllIssuers() []ExternalIssuerConfig {
+	issuers := make([]ExternalIssuerConfig, 0, len(c.Issuers)+1)
+	issuers = append(issuers, c.Issuers...)
+	if c.Secret != "" {
+		issuers = append(issuers, ExternalIssuerConfig{Name: "default", Secret: c.Secret})
+	}
+	for i := range issuers {
+		if issuers[i].DefaultRole == "" {
+			issuers[i].DefaultRole = c.DefaultRole
+		}
+		if issuers[i].DefaultPlatformId == 0 {
+			issuers[i].DefaultPlatformId = c.DefaultPlatformId
+		}
+	}
+	return issuers
+}
+
+func (c *ExternalJWTConfig) validate() error {
+	seen := make(map[string]bool, len(c.Issuers))
+	for _, iss := range c.Issuers {
+		if iss.Name == "" {
+			return fmt.Errorf("issuers require name")
+		}
+		if seen[iss.Name] {
+			return fmt.Errorf("duplicate issuer %q", iss.Name)
+		}
+		seen[iss.Name] = true
+		if (iss.Secret == "") == (iss.JWKSURL == "") {
+			return fmt.Errorf("issuer %q requires exactly one of secret and jwks_url", iss.Name)
+		}
+		switch iss.UserIdMapping {
+		case "", "actor", "raw":
+		default:
+			return fmt.Errorf("issuer %q has unsupported user_id_mapping %q", iss.Name, iss.UserIdMapping)
+		}
+	}
+	return nil
 }
 
 // InternalAuthConfig holds internal service-to-service auth configuration
@@ -111,6 +373,793 @@ type WebSocketConfig struct {
 	WriteChannelSize int           `mapstructure:"write_channel_size"`
 }
 
+// MessageConfig holds message storage configuration
+type MessageConfig struct {
+	Compression  MessageCompressionConfig  `mapstructure:"compression"`
+	Retention    MessageRetentionConfig    `mapstructure:"retention"`
+	PreSend      MessagePreSendConfig      `mapstructure:"pre_send"`
+	Encrypted    MessageEncryptedConfig    `mapstructure:"encrypted"`
+	PII          MessagePIIConfig          `mapstructure:"pii"`
+	LinkPreview  MessageLinkPreviewConfig  `mapstructure:"link_preview"`
+	Revoke       MessageRevokeConfig       `mapstructure:"revoke"`
+	Disappearing MessageDisappearingConfig `mapstructure:"disappearing"`
+}
+
+// MessageCompressionConfig controls at-rest compression of large message content.
+// Content whose JSON encoding exceeds ThresholdBytes is compressed with Codec before storage.
+type MessageCompressionConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Codec          string `mapstructure:"codec"`           // "gzip" or "zstd"
+	ThresholdBytes int    `mapstructure:"threshold_bytes"` // defaults to 4096
+}
+
+// MessageRetentionConfig controls how long messages are kept per conversation type.
+// A per-type window of 0 falls back to DefaultDays; a resolved window of 0 keeps messages forever.
+type MessageRetentionConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	DefaultDays   int           `mapstructure:"default_days"`
+	SingleDays    int           `mapstructure:"single_days"`    // single chats (si_)
+	GroupDays     int           `mapstructure:"group_days"`     // group chats (sg_)
+	SystemDays    int           `mapstructure:"system_days"`    // system conversations (sn_)
+	PurgeInterval time.Duration `mapstructure:"purge_interval"` // defaults to 1h
+	BatchSize     int           `mapstructure:"batch_size"`     // messages deleted per batch, defaults to 1000
+}
+
+// MessagePreSendConfig configures a synchronous policy callback consulted before a user
+// message is stored. The callback may reject the message or rewrite its content and extra.
+// Requests are signed like internal-auth requests with Secret; after BreakerThreshold
+// consecutive failures the callback is skipped for BreakerOpenTimeout.
+type MessagePreSendConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	URL                string        `mapstructure:"url"`
+	Secret             string        `mapstructure:"secret"`
+	ServiceName        string        `mapstructure:"service_name"`         // sent as X-Service-Name, defaults to "nexo_im"
+	Timeout            time.Duration `mapstructure:"timeout"`              // latency budget per message, defaults to 300ms
+	FailClosed         bool          `mapstructure:"fail_closed"`          // reject messages while the callback is unavailable
+	BreakerThreshold   int           `mapstructure:"breaker_threshold"`    // defaults to 5
+	BreakerOpenTimeout time.Duration `mapstructure:"breaker_open_timeout"` // defaults to 30s
+}
+
+func (c *MessagePreSendConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" || c.Secret == "" {
+		return fmt.Errorf("url and secret are required")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url")
+	}
+	return nil
+}
+
+// MessageEncryptedConfig caps end-to-end encrypted messages, whose payload is one ciphertext
+// per recipient device. A cap of -1 disables it.
+type MessageEncryptedConfig struct {
+	MaxBytes   int `mapstructure:"max_bytes"`   // total size of the ciphertexts, defaults to 65536
+	MaxDevices int `mapstructure:"max_devices"` // ciphertexts per message, defaults to 64
+}
+
+// PII actions
+const (
+	PIIActionOff  = "off"  // leave the message as sent
+	PIIActionFlag = "flag" // add the detected kinds to the message extra as "pii"
+	PIIActionMask = "mask" // mask the personal data in the text
+)
+
+// MessagePIIConfig detects personal data (phone numbers, emails, card numbers) in the text
+// of user messages before they are stored and applies the action of the conversation type
+type MessagePIIConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Kinds   []string `mapstructure:"kinds"`  // phone, email, card; defaults to all
+	Single  string   `mapstructure:"single"` // action in single chats, defaults to mask
+	Group   string   `mapstructure:"group"`  // action in group chats, defaults to mask
+}
+
+func (c *MessagePIIConfig) validate() error {
+	for _, kind := range c.Kinds {
+		if !slices.Contains(pii.Kinds, kind) {
+			return fmt.Errorf("unsupported kind %q", kind)
+		}
+	}
+	for _, action := range []string{c.Single, c.Group} {
+		switch action {
+		case PIIActionOff, PIIActionFlag, PIIActionMask:
+		default:
+			return fmt.Errorf("unsupported action %q", action)
+		}
+	}
+	return nil
+}
+
+// MessageLinkPreviewConfig controls the link previews of text messages: the first http(s) link
+// of a stored message is fetched in the background, only from public addresses, and the
+// OpenGraph card of the page is attached to the message, pushed as an edit. Cards, and
+// failures, are cached in Redis per URL.
+type MessageLinkPreviewConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Timeout      time.Duration `mapstructure:"timeout"`       // per page, redirects included, defaults to 5s
+	MaxBytes     int64         `mapstructure:"max_bytes"`     // of the page read for metadata, defaults to 512KB
+	MaxRedirects int           `mapstructure:"max_redirects"` // defaults to 3
+	UserAgent    string        `mapstructure:"user_agent"`
+	CacheTTL     time.Duration `mapstructure:"cache_ttl"`   // defaults to 24h
+	FailureTTL   time.Duration `mapstructure:"failure_ttl"` // pages without a card, defaults to 1h
+	QueueSize    int           `mapstructure:"queue_size"`  // pending messages, defaults to 256
+	Workers      int           `mapstructure:"workers"`     // concurrent fetches, defaults to 4
+}
+
+// MessageRevokeConfig controls how long after sending a sender may recall a message
+type MessageRevokeConfig struct {
+	Window time.Duration `mapstructure:"window"` // defaults to 2m, negative for no limit
+}
+
+// MessageDisappearingConfig controls the sweeper deleting the messages of conversations with
+// a disappearing messages timer once they expired. Pulls hide them in the meantime.
+type MessageDisappearingConfig struct {
+	SweepInterval time.Duration `mapstructure:"sweep_interval"` // defaults to 1m
+	BatchSize     int           `mapstructure:"batch_size"`     // messages deleted per batch, defaults to 1000
+}
+
+// DataDeletionConfig holds GDPR user data purge configuration
+type DataDeletionConfig struct {
+	DefaultMode string `mapstructure:"default_mode"` // "tombstone" or "hard", defaults to "tombstone"
+	BatchSize   int    `mapstructure:"batch_size"`   // rows processed per batch when purging messages
+}
+
+// AdminConfig holds admin API authentication configuration.
+// Admin requests authenticate with one of the configured API keys, separate from user JWTs and internal auth.
+type AdminConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	APIKeys []AdminAPIKey `mapstructure:"api_keys"`
+}
+
+// BroadcastConfig controls delivery of admin broadcast announcements
+type BroadcastConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often due broadcasts are picked up, defaults to 10s
+	BatchSize    int           `mapstructure:"batch_size"`    // users delivered per progress update, defaults to 200
+	Workers      int           `mapstructure:"workers"`       // concurrent deliveries within a batch, defaults to 8
+}
+
+// ReminderConfig controls delivery of message reminders
+type ReminderConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often due reminders are picked up, defaults to 10s
+	MaxPending   int           `mapstructure:"max_pending"`   // pending reminders per user, defaults to 100
+}
+
+// FavoriteConfig controls favorite (saved) messages
+type FavoriteConfig struct {
+	MaxPerUser    int  `mapstructure:"max_per_user"`   // favorites per user, defaults to 1000
+	ReferenceOnly bool `mapstructure:"reference_only"` // store no snapshot; favorites then follow recalls and deletions of the original
+}
+
+// AdminAPIKey identifies an operator by name for audit logs
+type AdminAPIKey struct {
+	Name string `mapstructure:"name"`
+	Key  string `mapstructure:"key"`
+}
+
+// DebugConfig controls the diagnostic endpoints (/debug/pprof, /debug/runtime).
+// They are only registered when enabled and always require internal auth.
+type DebugConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// MetricsConfig controls the Prometheus endpoint (/metrics). It is only registered when
+// enabled and is restricted to ip_access.internal, as it exposes per route traffic.
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// GraphQLConfig controls the read-only GraphQL endpoint (/im/graphql). It authenticates
+// like the user routes and checks the route group scope of every queried field.
+type GraphQLConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// MQTTConfig controls the MQTT bridge for constrained clients. The bridge connects to an
+// external broker as a client; the broker must restrict every device to the topics of
+// its own user, <topic_prefix>/users/<user_id>/#.
+type MQTTConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	BrokerURL   string        `mapstructure:"broker_url"` // e.g. tcp://mqtt:1883 or ssl://mqtt:8883
+	ClientId    string        `mapstructure:"client_id"`  // defaults to nexo_im-<hostname>
+	Username    string        `mapstructure:"username"`
+	Password    string        `mapstructure:"password"`
+	TopicPrefix string        `mapstructure:"topic_prefix"` // defaults to "nexo"
+	QoS         int           `mapstructure:"qos"`          // 0 or 1 for every publish and subscription
+	SessionTTL  time.Duration `mapstructure:"session_ttl"`  // a device session ends without a connect renewal for this long, defaults to 2m
+}
+
+func (c *MQTTConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	u, err := url.Parse(c.BrokerURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("broker_url is invalid")
+	}
+	switch u.Scheme {
+	case "tcp", "ssl", "tls", "mqtt", "mqtts", "ws", "wss":
+	default:
+		return fmt.Errorf("broker_url has an unsupported scheme %q", u.Scheme)
+	}
+	if c.QoS != 0 && c.QoS != 1 {
+		return fmt.Errorf("qos must be 0 or 1")
+	}
+	if strings.ContainsAny(c.TopicPrefix, "+#") {
+		return fmt.Errorf("topic_prefix must not contain wildcards")
+	}
+	return nil
+}
+
+// ChatBridgeConfig controls the connectors of external chat platforms. A connector receives
+// the webhook of its platform at /im/bridge/<name> and sends the messages of each mapped
+// external chat into a nexo_im conversation, through a shadow user <name>_<external user id>
+// standing for the external sender.
+type ChatBridgeConfig struct {
+	Enabled    bool                  `mapstructure:"enabled"`
+	Connectors []ChatBridgeConnector `mapstructure:"connectors"`
+}
+
+// ChatBridgeConnector maps the chats of one external bot or app
+type ChatBridgeConnector struct {
+	Name          string           `mapstructure:"name"`            // lowercase letters and digits, e.g. "tg"
+	Type          string           `mapstructure:"type"`            // "telegram" or "slack"
+	Secret        string           `mapstructure:"secret"`          // telegram: secret_token of setWebhook; slack: signing secret
+	DefaultRecvId string           `mapstructure:"default_recv_id"` // single chat peer of the chats not listed; empty drops them
+	Chats         []ChatBridgeChat `mapstructure:"chats"`
+}
+
+// ChatBridgeChat maps an external chat to the single chat with RecvId or to the group GroupId
+type ChatBridgeChat struct {
+	ChatId  string `mapstructure:"chat_id"`
+	RecvId  string `mapstructure:"recv_id"`
+	GroupId string `mapstructure:"group_id"`
+}
+
+func (c *ChatBridgeConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	seen := make(map[string]bool, len(c.Connectors))
+	for _, conn := range c.Connectors {
+		if conn.Name == "" || strings.Trim(conn.Name, "abcdefghijklmnopqrstuvwxyz0123456789") != "" || len(conn.Name) > 16 {
+			return fmt.Errorf("connector name %q must be 1-16 lowercase letters and digits", conn.Name)
+		}
+		if seen[conn.Name] {
+			return fmt.Errorf("duplicate connector %q", conn.Name)
+		}
+		seen[conn.Name] = true
+		if conn.Type != "telegram" && conn.Type != "slack" {
+			return fmt.Errorf("connector %q has an unsupported type %q", conn.Name, conn.Type)
+		}
+		if conn.Secret == "" {
+			return fmt.Errorf("connector %q requires a secret", conn.Name)
+		}
+		for _, chat := range conn.Chats {
+			if chat.ChatId == "" || (chat.RecvId == "") == (chat.GroupId == "") {
+				return fmt.Errorf("connector %q chats require chat_id and one of recv_id or group_id", conn.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// BotConfig delivers the messages addressed to bot users to their webhooks. Deliveries go
+// through the webhook queue, so they are signed, retried and logged with the webhook settings;
+// bots without a webhook can long-poll /im/msg/poll instead.
+type BotConfig struct {
+	Enabled  bool         `mapstructure:"enabled"`
+	Webhooks []BotWebhook `mapstructure:"webhooks"`
+}
+
+// BotWebhook receives the bot.message events of the bot user UserId, signed with Secret
+type BotWebhook struct {
+	UserId   string       `mapstructure:"user_id"`
+	URL      string       `mapstructure:"url"`
+	Secret   string       `mapstructure:"secret"`
+	Commands []BotCommand `mapstructure:"commands"` // slash commands listed to clients
+}
+
+// BotCommand is a slash command a bot understands, e.g. "weather" for "/weather <city>"
+type BotCommand struct {
+	Name        string `mapstructure:"name"` // lowercase letters, digits and underscores
+	Description string `mapstructure:"description"`
+}
+
+// maxBotUserIdLen keeps the delivery endpoint name "bot:<user_id>" within webhook_deliveries.endpoint
+const maxBotUserIdLen = 60
+
+func (c *BotConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	seen := make(map[string]bool, len(c.Webhooks))
+	for _, w := range c.Webhooks {
+		if w.UserId == "" || w.URL == "" || w.Secret == "" {
+			return fmt.Errorf("webhooks require user_id, url and secret")
+		}
+		if len(w.UserId) > maxBotUserIdLen {
+			return fmt.Errorf("webhook user_id %q is longer than %d", w.UserId, maxBotUserIdLen)
+		}
+		if seen[w.UserId] {
+			return fmt.Errorf("duplicate webhook for user %q", w.UserId)
+		}
+		seen[w.UserId] = true
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook of user %q has an invalid url", w.UserId)
+		}
+		for _, cmd := range w.Commands {
+			if cmd.Name == "" || strings.Trim(cmd.Name, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" || len(cmd.Name) > 32 {
+				return fmt.Errorf("command %q of user %q must be 1-32 lowercase letters, digits and underscores", cmd.Name, w.UserId)
+			}
+		}
+	}
+	return nil
+}
+
+// Agent backends
+const (
+	AgentBackendHTTP  = "http"
+	AgentBackendQueue = "queue"
+)
+
+// AgentConfig routes the messages addressed to agent actors (user ids "ag__<id>", see
+// common.Actor) to an agent backend. The http backend posts each request and turns the
+// response, which may be streamed, into the agent's reply; the queue backend appends requests
+// to a Redis stream and the consumers reply through /im/internal/agent/reply.
+type AgentConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Backend      string        `mapstructure:"backend"`       // http or queue
+	URL          string        `mapstructure:"url"`           // http
+	Secret       string        `mapstructure:"secret"`        // http: signs requests the same way as webhooks
+	ServiceName  string        `mapstructure:"service_name"`  // http: sent as X-Service-Name, defaults to "nexo_im"
+	Timeout      time.Duration `mapstructure:"timeout"`       // http: per request including a streamed reply, defaults to 2m
+	Stream       string        `mapstructure:"stream"`        // queue: Redis stream key, defaults to "nexo:agent:requests"
+	StreamMaxLen int64         `mapstructure:"stream_maxlen"` // queue: approximate stream length cap, defaults to 100000
+	EditInterval time.Duration `mapstructure:"edit_interval"` // minimum time between edits of a streamed reply, defaults to 500ms
+	QueueSize    int           `mapstructure:"queue_size"`    // pending requests, defaults to 1024
+	Workers      int           `mapstructure:"workers"`       // concurrent requests, defaults to 8
+}
+
+func (c *AgentConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Backend {
+	case AgentBackendHTTP:
+		if c.URL == "" || c.Secret == "" {
+			return fmt.Errorf("http backend requires url and secret")
+		}
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url")
+		}
+	case AgentBackendQueue:
+	default:
+		return fmt.Errorf("unsupported backend %q", c.Backend)
+	}
+	if c.Workers < 1 || c.QueueSize < 1 {
+		return fmt.Errorf("workers and queue_size must be positive")
+	}
+	return nil
+}
+
+// CallConfig controls call signaling. Signals are relayed to the online devices of the call
+// participants; media flows peer to peer or through the clients' TURN/SFU servers.
+type CallConfig struct {
+	Enabled         bool           `mapstructure:"enabled"`
+	RingTimeout     time.Duration  `mapstructure:"ring_timeout"`      // invitees who did not answer time out, defaults to 60s
+	MaxDuration     time.Duration  `mapstructure:"max_duration"`      // state of a call is dropped after it, defaults to 4h
+	MaxInvitees     int            `mapstructure:"max_invitees"`      // defaults to 8
+	MaxPayloadBytes int            `mapstructure:"max_payload_bytes"` // SDP/ICE payload of a signal, defaults to 16KB
+	VoIP            VoIPPushConfig `mapstructure:"voip"`
+}
+
+// VoIPPushConfig configures the high-priority pushes ringing callees without a connection:
+// PushKit through APNs for iOS apps and data messages through FCM for Android apps.
+type VoIPPushConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Timeout time.Duration `mapstructure:"timeout"` // per push request, defaults to 5s
+	APNs    APNsConfig    `mapstructure:"apns"`
+	FCM     FCMConfig     `mapstructure:"fcm"`
+}
+
+// APNsConfig holds the token-based APNs credentials of the iOS app
+type APNsConfig struct {
+	TeamId     string `mapstructure:"team_id"`
+	KeyId      string `mapstructure:"key_id"`
+	KeyFile    string `mapstructure:"key_file"` // .p8 signing key
+	Topic      string `mapstructure:"topic"`    // bundle id with the .voip suffix
+	Production bool   `mapstructure:"production"`
+}
+
+// FCMConfig holds the FCM HTTP v1 credentials of the Android app
+type FCMConfig struct {
+	CredentialsFile string `mapstructure:"credentials_file"` // service account JSON key
+	ProjectId       string `mapstructure:"project_id"`       // defaults to the project of the service account
+}
+
+func (c *VoIPPushConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.APNs.KeyFile == "" && c.FCM.CredentialsFile == "" {
+		return fmt.Errorf("apns or fcm credentials required")
+	}
+	if c.APNs.KeyFile != "" && (c.APNs.TeamId == "" || c.APNs.KeyId == "" || !strings.HasSuffix(c.APNs.Topic, ".voip")) {
+		return fmt.Errorf("apns requires team_id, key_id and a topic ending with .voip")
+	}
+	return nil
+}
+
+// E2EEConfig controls the key bundle service of end-to-end encrypted clients
+type E2EEConfig struct {
+	Enabled           bool `mapstructure:"enabled"`
+	MaxOneTimePrekeys int  `mapstructure:"max_one_time_prekeys"` // unclaimed per device, defaults to 200
+}
+
+// Storage drivers
+const (
+	StorageDriverS3    = "s3"
+	StorageDriverMinIO = "minio"
+	StorageDriverOSS   = "oss"
+)
+
+// StorageConfig configures the object storage media is uploaded to. Clients upload directly
+// to the bucket with presigned URLs and confirm the upload; with RequireManagedMedia, media
+// messages may only reference objects of the bucket.
+type StorageConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	Driver              string        `mapstructure:"driver"`   // s3, minio or oss
+	Endpoint            string        `mapstructure:"endpoint"` // e.g. http://minio:9000; s3 defaults to the regional endpoint
+	Region              string        `mapstructure:"region"`   // s3 and minio signing region, defaults to us-east-1
+	Bucket              string        `mapstructure:"bucket"`
+	AccessKeyId         string        `mapstructure:"access_key_id"`
+	AccessKeySecret     string        `mapstructure:"access_key_secret"`
+	PathStyle           bool          `mapstructure:"path_style"`            // s3: bucket in the path instead of the host; minio always uses it
+	PublicURL           string        `mapstructure:"public_url"`            // base URL objects are read from, e.g. a CDN; defaults to the bucket URL
+	KeyPrefix           string        `mapstructure:"key_prefix"`            // defaults to "uploads/"
+	PresignExpiry       time.Duration `mapstructure:"presign_expiry"`        // defaults to 15m
+	MaxSize             int64         `mapstructure:"max_size"`              // bytes per object, defaults to 100MB
+	MultipartPartSize   int64         `mapstructure:"multipart_part_size"`   // bytes per part of multipart uploads, defaults to 8MB, at least 5MB
+	MonthlyQuota        int64         `mapstructure:"monthly_quota"`         // bytes a user may upload per calendar month (UTC), 0 for no quota
+	AllowedContentTypes []string      `mapstructure:"allowed_content_types"` // e.g. image/*; empty allows all
+	RequireManagedMedia bool          `mapstructure:"require_managed_media"`
+	Timeout             time.Duration `mapstructure:"timeout"` // requests to the storage, defaults to 10s
+
+	Images   StorageImagesConfig   `mapstructure:"images"`
+	Scan     StorageScanConfig     `mapstructure:"scan"`
+	Download StorageDownloadConfig `mapstructure:"download"`
+
+	Audio StorageAudioConfig `mapstructure:"audio"`
+}
+
+// StorageAudioConfig controls the duration check of confirmed audio uploads (WAV, Ogg Opus or
+// Vorbis and MP4/M4A): the duration is read from the object headers on confirmation and
+// replaces the one sent in audio messages referencing the upload.
+type StorageAudioConfig struct {
+	Enabled  bool  `mapstructure:"enabled"`
+	MaxBytes int64 `mapstructure:"max_bytes"` // larger files are not checked, defaults to 10MB
+}
+
+// StorageDownloadConfig serves uploads through the authenticated download proxy instead of
+// public object URLs: uploads are referenced by <BaseURL>/im/storage/download/<id>, which
+// redirects participants of a conversation the upload was sent to, and the uploader, to a
+// short-lived presigned URL. The bucket can then be private.
+type StorageDownloadConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	BaseURL   string        `mapstructure:"base_url"`   // external URL of the HTTP API, e.g. https://im.example.com
+	URLExpiry time.Duration `mapstructure:"url_expiry"` // presigned URLs redirected to, defaults to 5m
+}
+
+// StorageImagesConfig controls the background processing of confirmed image uploads
+// (JPEG, PNG and GIF): their EXIF metadata is stripped, their dimensions recorded and a
+// JPEG thumbnail stored next to them, which image messages referencing them then carry.
+type StorageImagesConfig struct {
+	Enabled       bool  `mapstructure:"enabled"`
+	ThumbnailSize int   `mapstructure:"thumbnail_size"` // longest side in pixels, defaults to 320
+	MaxBytes      int64 `mapstructure:"max_bytes"`      // larger images are not processed, defaults to 20MB
+	QueueSize     int   `mapstructure:"queue_size"`     // pending images, defaults to 256
+	Workers       int   `mapstructure:"workers"`        // concurrent images, defaults to 2
+}
+
+// Upload scanning backends
+const (
+	ScanBackendClamAV = "clamav"
+	ScanBackendHTTP   = "http"
+)
+
+// StorageScanConfig configures the malware scan of uploads on confirmation, by a clamd
+// daemon or an external service posted the file and signed like internal-auth requests.
+// Infected objects are deleted, the upload rejected and the attempt audited.
+type StorageScanConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Backend     string        `mapstructure:"backend"`      // clamav or http
+	Address     string        `mapstructure:"address"`      // clamav: clamd TCP address, e.g. clamd:3310
+	URL         string        `mapstructure:"url"`          // http
+	Secret      string        `mapstructure:"secret"`       // http
+	ServiceName string        `mapstructure:"service_name"` // http: sent as X-Service-Name, defaults to "nexo_im"
+	Timeout     time.Duration `mapstructure:"timeout"`      // per scan, defaults to 8s
+	MaxBytes    int64         `mapstructure:"max_bytes"`    // larger files are not scanned, defaults to 25MB
+	FailClosed  bool          `mapstructure:"fail_closed"`  // reject uploads that could not be scanned
+}
+
+func (c *StorageScanConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Backend {
+	case ScanBackendClamAV:
+		if c.Address == "" {
+			return fmt.Errorf("clamav backend requires address")
+		}
+	case ScanBackendHTTP:
+		if c.URL == "" || c.Secret == "" {
+			return fmt.Errorf("http backend requires url and secret")
+		}
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url")
+		}
+	default:
+		return fmt.Errorf("unsupported backend %q", c.Backend)
+	}
+	return nil
+}
+
+func (c *StorageConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Driver {
+	case StorageDriverS3:
+	case StorageDriverMinIO, StorageDriverOSS:
+		if c.Endpoint == "" {
+			return fmt.Errorf("endpoint is required for %s", c.Driver)
+		}
+	default:
+		return fmt.Errorf("unsupported driver %q", c.Driver)
+	}
+	if c.Bucket == "" || c.AccessKeyId == "" || c.AccessKeySecret == "" {
+		return fmt.Errorf("bucket, access_key_id and access_key_secret are required")
+	}
+	for _, raw := range []string{c.Endpoint, c.PublicURL} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url %q", raw)
+		}
+	}
+	if c.MonthlyQuota < 0 {
+		return fmt.Errorf("monthly_quota must not be negative")
+	}
+	if c.Images.Enabled && (c.Images.ThumbnailSize < 1 || c.Images.MaxBytes < 1 || c.Images.Workers < 1 || c.Images.QueueSize < 1) {
+		return fmt.Errorf("images: thumbnail_size, max_bytes, workers and queue_size must be positive")
+	}
+	if c.Audio.Enabled && c.Audio.MaxBytes < 1 {
+		return fmt.Errorf("audio: max_bytes must be positive")
+	}
+	if err := c.Scan.validate(); err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+	if c.Download.Enabled {
+		u, err := url.Parse(c.Download.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("download: invalid base_url")
+		}
+	}
+	return nil
+}
+
+// RequestLogConfig controls what the HTTP request logger may write.
+// JSON fields whose name contains one of RedactFields (case-insensitive) are masked
+// in logged request and response bodies; requests to SkipPaths are not logged at all.
+type RequestLogConfig struct {
+	RedactFields []string `mapstructure:"redact_fields"`
+	SkipPaths    []string `mapstructure:"skip_paths"`
+}
+
+// UsageStatsConfig controls the daily usage statistics of conversations and users kept in
+// Redis: messages, active senders, conversations written to and media bytes, plus the daily
+// rankings of the busiest conversations and senders.
+type UsageStatsConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	TTL      time.Duration `mapstructure:"ttl"`       // how long each day is kept, defaults to 720h (30 days)
+	TopLimit int           `mapstructure:"top_limit"` // entries returned by the rankings at most, defaults to 100
+}
+
+// Analytics sinks
+const (
+	AnalyticsSinkHTTP  = "http"
+	AnalyticsSinkKafka = "kafka"
+)
+
+// AnalyticsConfig controls the export of activity events (message_sent, user_online,
+// group_created) to an HTTP collector, signed like internal-auth requests, or to a Kafka topic
+// through a Kafka REST proxy. User, group and conversation ids are replaced by keyed hashes.
+// Events are buffered and sent in batches; when the buffer is full new events are dropped.
+type AnalyticsConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Sink          string        `mapstructure:"sink"`           // http or kafka
+	URL           string        `mapstructure:"url"`            // http: collector URL; kafka: REST proxy base URL
+	Secret        string        `mapstructure:"secret"`         // http
+	ServiceName   string        `mapstructure:"service_name"`   // http: sent as X-Service-Name, defaults to "nexo_im"
+	Topic         string        `mapstructure:"topic"`          // kafka
+	Username      string        `mapstructure:"username"`       // kafka: basic auth of the REST proxy, optional
+	Password      string        `mapstructure:"password"`       // kafka
+	HashKey       string        `mapstructure:"hash_key"`       // keys the id hashes; changing it breaks the continuity of the pseudonyms
+	Timeout       time.Duration `mapstructure:"timeout"`        // per batch, defaults to 5s
+	BufferSize    int           `mapstructure:"buffer_size"`    // defaults to 4096
+	BatchSize     int           `mapstructure:"batch_size"`     // events per request, defaults to 100
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // defaults to 5s
+}
+
+func (c *AnalyticsConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Sink {
+	case AnalyticsSinkHTTP:
+		if c.URL == "" || c.Secret == "" {
+			return fmt.Errorf("http sink requires url and secret")
+		}
+	case AnalyticsSinkKafka:
+		if c.URL == "" || c.Topic == "" {
+			return fmt.Errorf("kafka sink requires url and topic")
+		}
+	default:
+		return fmt.Errorf("unsupported sink %q", c.Sink)
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url")
+	}
+	if c.HashKey == "" {
+		return fmt.Errorf("hash_key is required")
+	}
+	return nil
+}
+
+// HealthConfig controls the readiness probe (/im/readyz)
+type HealthConfig struct {
+	CheckTimeout time.Duration `mapstructure:"check_timeout"`  // per dependency, defaults to 2s
+	CheckAppPush bool          `mapstructure:"check_app_push"` // also probe the app push gateway (non-critical)
+}
+
+// AuditConfig controls the security audit trail stored in the audit_events table.
+// Events are buffered and written in batches; when the buffer is full new events are dropped.
+type AuditConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	BufferSize    int           `mapstructure:"buffer_size"`    // defaults to 4096
+	BatchSize     int           `mapstructure:"batch_size"`     // events per insert, defaults to 100
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // defaults to 1s
+}
+
+// WebhookConfig controls outgoing webhooks. Events are queued and posted to every endpoint
+// whose filters match; failed deliveries are retried with exponential backoff and the final
+// outcome of each delivery is logged in the webhook_deliveries table.
+type WebhookConfig struct {
+	Enabled      bool              `mapstructure:"enabled"`
+	ServiceName  string            `mapstructure:"service_name"` // sent as X-Service-Name, defaults to "nexo_im"
+	Endpoints    []WebhookEndpoint `mapstructure:"endpoints"`
+	Timeout      time.Duration     `mapstructure:"timeout"`       // per attempt, defaults to 5s
+	MaxAttempts  int               `mapstructure:"max_attempts"`  // defaults to 5
+	RetryBackoff time.Duration     `mapstructure:"retry_backoff"` // first retry delay, doubled per attempt, defaults to 1s
+	MaxBackoff   time.Duration     `mapstructure:"max_backoff"`   // defaults to 1m
+	QueueSize    int               `mapstructure:"queue_size"`    // pending deliveries, defaults to 4096
+	Workers      int               `mapstructure:"workers"`       // concurrent deliveries, defaults to 4
+}
+
+// WebhookEndpoint receives the events matching Events, signed with Secret the same way
+// internal-auth requests are
+type WebhookEndpoint struct {
+	Name   string   `mapstructure:"name"`
+	URL    string   `mapstructure:"url"`
+	Secret string   `mapstructure:"secret"`
+	Events []string `mapstructure:"events"` // e.g. "group.created" or "group.*"; empty means all events
+}
+
+func (c *WebhookConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	seen := make(map[string]bool, len(c.Endpoints))
+	for _, e := range c.Endpoints {
+		if e.Name == "" || e.URL == "" || e.Secret == "" {
+			return fmt.Errorf("endpoints require name, url and secret")
+		}
+		if seen[e.Name] {
+			return fmt.Errorf("duplicate endpoint %q", e.Name)
+		}
+		seen[e.Name] = true
+		u, err := url.Parse(e.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("endpoint %q has an invalid url", e.Name)
+		}
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1")
+	}
+	return nil
+}
+
+// RateLimitConfig holds HTTP rate limiting configuration.
+// Buckets live in Redis so limits hold across nodes; a route override replaces
+// the default rule of the same dimension for that route only.
+type RateLimitConfig struct {
+	Enabled bool                 `mapstructure:"enabled"`
+	PerIP   RateLimitRule        `mapstructure:"per_ip"`
+	PerUser RateLimitRule        `mapstructure:"per_user"`
+	Routes  []RateLimitRouteRule `mapstructure:"routes"`
+}
+
+// RateLimitRule is a token bucket; a zero Rate or Burst disables the rule
+type RateLimitRule struct {
+	Rate  float64 `mapstructure:"rate"`  // tokens refilled per second
+	Burst int     `mapstructure:"burst"` // bucket capacity
+}
+
+// RateLimitRouteRule overrides the default rules for one route, e.g. /im/msg/send
+type RateLimitRouteRule struct {
+	Path    string        `mapstructure:"path"`
+	PerIP   RateLimitRule `mapstructure:"per_ip"`
+	PerUser RateLimitRule `mapstructure:"per_user"`
+}
+
+// RequestTimeoutConfig bounds how long an HTTP handler may run. The request context is
+// cancelled at the deadline and the client gets a 504; WebSocket and pprof routes are exempt.
+type RequestTimeoutConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Default    time.Duration `mapstructure:"default"`     // defaults to 10s
+	Long       time.Duration `mapstructure:"long"`        // budget for LongRoutes, defaults to 60s
+	LongRoutes []string      `mapstructure:"long_routes"` // pull and export style routes, e.g. /im/msg/pull
+}
+
+// IPAccessConfig restricts the internal (/im/internal, /debug) and admin (/im/admin)
+// routes to CIDR ranges, checked before signature or API key validation.
+type IPAccessConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TrustedProxies are peers whose X-Forwarded-For / X-Real-IP is trusted for the client IP.
+	// Requests from other peers are checked by their socket address.
+	TrustedProxies []string     `mapstructure:"trusted_proxies"`
+	Internal       IPAccessList `mapstructure:"internal"`
+	Admin          IPAccessList `mapstructure:"admin"`
+}
+
+// IPAccessList is evaluated deny first; an empty Allow allows every address not denied.
+// Entries are CIDRs ("10.0.0.0/8") or single addresses ("10.0.0.1").
+type IPAccessList struct {
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// ParseIPRanges parses CIDRs or single addresses into prefixes
+func ParseIPRanges(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
 // Global config instance
 var GlobalConfig *Config
 
@@ -144,7 +1193,39 @@ func ResolveConfigPath(configPath string) string {
 	}
 }
 
-// Load loads configuration from file and environment variables.
+// bindEnvs binds every leaf key of t to its env var; AutomaticEnv alone only
+// overrides keys that already exist in the config file.
+func bindEnvs(t reflect.Type, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := bindEnvs(field.Type, key); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
+			continue
+		}
+		if err := viper.BindEnv(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load loads configuration from file, environment variables and the optional secret manager.
+// Every key can be overridden by INFRA_<KEY> with dots replaced by underscores
+// (e.g. INFRA_MYSQL_PASSWORD), including keys absent from the file.
 func Load(configPath string) (*Config, error) {
 	configPath = ResolveConfigPath(configPath)
 	viper.SetConfigFile(configPath)
@@ -152,6 +1233,9 @@ func Load(configPath string) (*Config, error) {
 	viper.SetEnvPrefix("INFRA")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
+	if err := bindEnvs(reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, fmt.Errorf("failed to bind env: %w", err)
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -162,6 +1246,20 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := loadSecrets(context.Background(), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+
+	for _, entries := range [][]string{
+		cfg.IPAccess.TrustedProxies,
+		cfg.IPAccess.Internal.Allow, cfg.IPAccess.Internal.Deny,
+		cfg.IPAccess.Admin.Allow, cfg.IPAccess.Admin.Deny,
+	} {
+		if _, err := ParseIPRanges(entries); err != nil {
+			return nil, fmt.Errorf("invalid ip_access config: %w", err)
+		}
+	}
+
 	// Set defaults
 	if cfg.Server.HTTPPort == 0 {
 		cfg.Server.HTTPPort = 8080
@@ -172,6 +1270,80 @@ func Load(configPath string) (*Config, error) {
 	if cfg.Server.Mode == "" {
 		cfg.Server.Mode = "debug"
 	}
+	if cfg.Server.MaxBodyBytes == 0 {
+		cfg.Server.MaxBodyBytes = 1 << 20 // 1MB
+	}
+	if cfg.Server.ShutdownTimeout == 0 {
+		cfg.Server.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Server.TLS.MinVersion == "" {
+		cfg.Server.TLS.MinVersion = "1.2"
+	}
+	if cfg.Server.TLS.HSTS.MaxAge == 0 {
+		cfg.Server.TLS.HSTS.MaxAge = 180 * 24 * time.Hour
+	}
+	if err := cfg.Server.TLS.validate(); err != nil {
+		return nil, fmt.Errorf("invalid server.tls config: %w", err)
+	}
+	if cfg.LoginThrottle.FreeAttempts == 0 {
+		cfg.LoginThrottle.FreeAttempts = 3
+	}
+	if cfg.LoginThrottle.BaseDelay == 0 {
+		cfg.LoginThrottle.BaseDelay = time.Second
+	}
+	if cfg.LoginThrottle.LockoutAttempts == 0 {
+		cfg.LoginThrottle.LockoutAttempts = 10
+	}
+	if cfg.LoginThrottle.LockoutDuration == 0 {
+		cfg.LoginThrottle.LockoutDuration = 15 * time.Minute
+	}
+	if cfg.LoginThrottle.IPMultiplier == 0 {
+		cfg.LoginThrottle.IPMultiplier = 5
+	}
+	if cfg.Captcha.LoginAfterFailures == 0 {
+		cfg.Captcha.LoginAfterFailures = 3
+	}
+	if err := cfg.Captcha.validate(); err != nil {
+		return nil, fmt.Errorf("invalid captcha config: %w", err)
+	}
+	if err := cfg.OAuth.validate(); err != nil {
+		return nil, fmt.Errorf("invalid oauth config: %w", err)
+	}
+	if cfg.RequestTimeout.Default == 0 {
+		cfg.RequestTimeout.Default = 10 * time.Second
+	}
+	if cfg.RequestTimeout.Long == 0 {
+		cfg.RequestTimeout.Long = 60 * time.Second
+	}
+	if cfg.RequestTimeout.LongRoutes == nil {
+		cfg.RequestTimeout.LongRoutes = []string{
+			"/im/msg/pull",
+			"/im/msg/poll",
+			"/im/admin/msg/search",
+			"/im/admin/audit/logs",
+			"/im/admin/audit/events",
+			"/im/admin/webhook/deliveries",
+			"/im/internal/admin/user/deletion_records",
+		}
+	}
+	if cfg.Broadcast.PollInterval == 0 {
+		cfg.Broadcast.PollInterval = 10 * time.Second
+	}
+	if cfg.Broadcast.BatchSize == 0 {
+		cfg.Broadcast.BatchSize = 200
+	}
+	if cfg.Broadcast.Workers == 0 {
+		cfg.Broadcast.Workers = 8
+	}
+	if cfg.Reminder.PollInterval == 0 {
+		cfg.Reminder.PollInterval = 10 * time.Second
+	}
+	if cfg.Reminder.MaxPending == 0 {
+		cfg.Reminder.MaxPending = 100
+	}
+	if cfg.Favorite.MaxPerUser == 0 {
+		cfg.Favorite.MaxPerUser = 1000
+	}
 	if cfg.MySQL.Charset == "" {
 		cfg.MySQL.Charset = "utf8mb4"
 	}
@@ -190,12 +1362,33 @@ func Load(configPath string) (*Config, error) {
 	if cfg.JWT.ExpireHours == 0 {
 		cfg.JWT.ExpireHours = 168 // 7 days
 	}
+	if cfg.JWT.AccessTTL == 0 {
+		cfg.JWT.AccessTTL = 15 * time.Minute
+	}
+	if err := cfg.JWT.validate(); err != nil {
+		return nil, fmt.Errorf("invalid jwt config: %w", err)
+	}
+	if cfg.Verification.CodeTTL == 0 {
+		cfg.Verification.CodeTTL = 10 * time.Minute
+	}
+	if cfg.Verification.ResendInterval == 0 {
+		cfg.Verification.ResendInterval = 60 * time.Second
+	}
+	if cfg.Verification.MaxAttempts == 0 {
+		cfg.Verification.MaxAttempts = 5
+	}
+	if cfg.Guest.TTL == 0 {
+		cfg.Guest.TTL = 24 * time.Hour
+	}
 	if cfg.ExternalJWT.DefaultRole == "" {
 		cfg.ExternalJWT.DefaultRole = "user"
 	}
 	if cfg.ExternalJWT.DefaultPlatformId == 0 {
 		cfg.ExternalJWT.DefaultPlatformId = 1 // ios
 	}
+	if err := cfg.ExternalJWT.validate(); err != nil {
+		return nil, fmt.Errorf("invalid external_jwt config: %w", err)
+	}
 	if cfg.InternalAuth.MaxSkewSeconds == 0 {
 		cfg.InternalAuth.MaxSkewSeconds = 300
 	}
@@ -224,6 +1417,286 @@ func Load(configPath string) (*Config, error) {
 		cfg.WebSocket.WriteChannelSize = 256
 	}
 
+	if cfg.Message.Compression.Codec == "" {
+		cfg.Message.Compression.Codec = "gzip"
+	}
+	if cfg.Message.Compression.ThresholdBytes == 0 {
+		cfg.Message.Compression.ThresholdBytes = 4096
+	}
+	if cfg.Message.Retention.PurgeInterval == 0 {
+		cfg.Message.Retention.PurgeInterval = time.Hour
+	}
+	if cfg.Message.Retention.BatchSize == 0 {
+		cfg.Message.Retention.BatchSize = 1000
+	}
+	if cfg.Message.PreSend.ServiceName == "" {
+		cfg.Message.PreSend.ServiceName = "nexo_im"
+	}
+	if cfg.Message.PreSend.Timeout == 0 {
+		cfg.Message.PreSend.Timeout = 300 * time.Millisecond
+	}
+	if cfg.Message.PreSend.BreakerThreshold == 0 {
+		cfg.Message.PreSend.BreakerThreshold = 5
+	}
+	if cfg.Message.PreSend.BreakerOpenTimeout == 0 {
+		cfg.Message.PreSend.BreakerOpenTimeout = 30 * time.Second
+	}
+	if cfg.Message.Encrypted.MaxBytes == 0 {
+		cfg.Message.Encrypted.MaxBytes = 65536
+	}
+	if cfg.Message.Encrypted.MaxDevices == 0 {
+		cfg.Message.Encrypted.MaxDevices = 64
+	}
+	if err := cfg.Message.PreSend.validate(); err != nil {
+		return nil, fmt.Errorf("invalid message.pre_send config: %w", err)
+	}
+	if len(cfg.Message.PII.Kinds) == 0 {
+		cfg.Message.PII.Kinds = pii.Kinds
+	}
+	if cfg.Message.PII.Single == "" {
+		cfg.Message.PII.Single = PIIActionMask
+	}
+	if cfg.Message.PII.Group == "" {
+		cfg.Message.PII.Group = PIIActionMask
+	}
+	if err := cfg.Message.PII.validate(); err != nil {
+		return nil, fmt.Errorf("invalid message.pii config: %w", err)
+	}
+	if cfg.Message.LinkPreview.Timeout == 0 {
+		cfg.Message.LinkPreview.Timeout = 5 * time.Second
+	}
+	if cfg.Message.LinkPreview.MaxBytes == 0 {
+		cfg.Message.LinkPreview.MaxBytes = 512 << 10
+	}
+	if cfg.Message.LinkPreview.MaxRedirects == 0 {
+		cfg.Message.LinkPreview.MaxRedirects = 3
+	}
+	if cfg.Message.LinkPreview.UserAgent == "" {
+		cfg.Message.LinkPreview.UserAgent = "Mozilla/5.0 (compatible; nexo_im-linkpreview/1.0)"
+	}
+	if cfg.Message.LinkPreview.CacheTTL == 0 {
+		cfg.Message.LinkPreview.CacheTTL = 24 * time.Hour
+	}
+	if cfg.Message.LinkPreview.FailureTTL == 0 {
+		cfg.Message.LinkPreview.FailureTTL = time.Hour
+	}
+	if cfg.Message.LinkPreview.QueueSize == 0 {
+		cfg.Message.LinkPreview.QueueSize = 256
+	}
+	if cfg.Message.LinkPreview.Workers == 0 {
+		cfg.Message.LinkPreview.Workers = 4
+	}
+	if cfg.Message.Revoke.Window == 0 {
+		cfg.Message.Revoke.Window = 2 * time.Minute
+	}
+	if cfg.Message.Disappearing.SweepInterval == 0 {
+		cfg.Message.Disappearing.SweepInterval = time.Minute
+	}
+	if cfg.Message.Disappearing.BatchSize == 0 {
+		cfg.Message.Disappearing.BatchSize = 1000
+	}
+	if cfg.DataDeletion.DefaultMode == "" {
+		cfg.DataDeletion.DefaultMode = "tombstone"
+	}
+	if cfg.DataDeletion.BatchSize == 0 {
+		cfg.DataDeletion.BatchSize = 1000
+	}
+	if cfg.RequestLog.RedactFields == nil {
+		cfg.RequestLog.RedactFields = []string{"password", "token", "secret", "authorization", "api_key"}
+	}
+	if cfg.RequestLog.SkipPaths == nil {
+		cfg.RequestLog.SkipPaths = []string{"/im/auth/login", "/im/auth/register", "/im/auth/refresh", "/im/auth/verify", "/im/auth/oauth/callback", "/im/auth/guest/upgrade", "/im/internal/auth/register"}
+	}
+	if cfg.Health.CheckTimeout == 0 {
+		cfg.Health.CheckTimeout = 2 * time.Second
+	}
+	if cfg.UsageStats.TTL == 0 {
+		cfg.UsageStats.TTL = 30 * 24 * time.Hour
+	}
+	if cfg.UsageStats.TopLimit == 0 {
+		cfg.UsageStats.TopLimit = 100
+	}
+	if cfg.Analytics.ServiceName == "" {
+		cfg.Analytics.ServiceName = "nexo_im"
+	}
+	if cfg.Analytics.Timeout == 0 {
+		cfg.Analytics.Timeout = 5 * time.Second
+	}
+	if cfg.Analytics.BufferSize == 0 {
+		cfg.Analytics.BufferSize = 4096
+	}
+	if cfg.Analytics.BatchSize == 0 {
+		cfg.Analytics.BatchSize = 100
+	}
+	if cfg.Analytics.FlushInterval == 0 {
+		cfg.Analytics.FlushInterval = 5 * time.Second
+	}
+	if err := cfg.Analytics.validate(); err != nil {
+		return nil, fmt.Errorf("invalid analytics config: %w", err)
+	}
+	if cfg.Audit.BufferSize == 0 {
+		cfg.Audit.BufferSize = 4096
+	}
+	if cfg.Audit.BatchSize == 0 {
+		cfg.Audit.BatchSize = 100
+	}
+	if cfg.Audit.FlushInterval == 0 {
+		cfg.Audit.FlushInterval = time.Second
+	}
+	if cfg.Webhook.ServiceName == "" {
+		cfg.Webhook.ServiceName = "nexo_im"
+	}
+	if cfg.Webhook.Timeout == 0 {
+		cfg.Webhook.Timeout = 5 * time.Second
+	}
+	if cfg.Webhook.MaxAttempts == 0 {
+		cfg.Webhook.MaxAttempts = 5
+	}
+	if cfg.Webhook.RetryBackoff == 0 {
+		cfg.Webhook.RetryBackoff = time.Second
+	}
+	if cfg.Webhook.MaxBackoff == 0 {
+		cfg.Webhook.MaxBackoff = time.Minute
+	}
+	if cfg.Webhook.QueueSize == 0 {
+		cfg.Webhook.QueueSize = 4096
+	}
+	if cfg.Webhook.Workers == 0 {
+		cfg.Webhook.Workers = 4
+	}
+	if err := cfg.Webhook.validate(); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	if cfg.GRPC.Port == 0 {
+		cfg.GRPC.Port = 9090
+	}
+	if cfg.GRPC.Auth == "" {
+		cfg.GRPC.Auth = GRPCAuthToken
+	}
+	if cfg.GRPC.MaxRecvMsgSize == 0 {
+		cfg.GRPC.MaxRecvMsgSize = cfg.Server.MaxBodyBytes
+	}
+	if err := cfg.GRPC.validate(&cfg.InternalAuth); err != nil {
+		return nil, fmt.Errorf("invalid grpc config: %w", err)
+	}
+	if cfg.MQTT.ClientId == "" {
+		hostname, _ := os.Hostname()
+		cfg.MQTT.ClientId = "nexo_im-" + hostname
+	}
+	if cfg.MQTT.TopicPrefix == "" {
+		cfg.MQTT.TopicPrefix = "nexo"
+	}
+	if cfg.MQTT.SessionTTL == 0 {
+		cfg.MQTT.SessionTTL = 2 * time.Minute
+	}
+	if err := cfg.MQTT.validate(); err != nil {
+		return nil, fmt.Errorf("invalid mqtt config: %w", err)
+	}
+	if err := cfg.ChatBridge.validate(); err != nil {
+		return nil, fmt.Errorf("invalid chat_bridge config: %w", err)
+	}
+	if err := cfg.Bot.validate(); err != nil {
+		return nil, fmt.Errorf("invalid bot config: %w", err)
+	}
+	if cfg.Agent.ServiceName == "" {
+		cfg.Agent.ServiceName = "nexo_im"
+	}
+	if cfg.Agent.Timeout == 0 {
+		cfg.Agent.Timeout = 2 * time.Minute
+	}
+	if cfg.Agent.Stream == "" {
+		cfg.Agent.Stream = "nexo:agent:requests"
+	}
+	if cfg.Agent.StreamMaxLen == 0 {
+		cfg.Agent.StreamMaxLen = 100000
+	}
+	if cfg.Agent.EditInterval == 0 {
+		cfg.Agent.EditInterval = 500 * time.Millisecond
+	}
+	if cfg.Agent.QueueSize == 0 {
+		cfg.Agent.QueueSize = 1024
+	}
+	if cfg.Agent.Workers == 0 {
+		cfg.Agent.Workers = 8
+	}
+	if err := cfg.Agent.validate(); err != nil {
+		return nil, fmt.Errorf("invalid agent config: %w", err)
+	}
+	if cfg.Call.RingTimeout == 0 {
+		cfg.Call.RingTimeout = time.Minute
+	}
+	if cfg.Call.MaxDuration == 0 {
+		cfg.Call.MaxDuration = 4 * time.Hour
+	}
+	if cfg.Call.MaxInvitees == 0 {
+		cfg.Call.MaxInvitees = 8
+	}
+	if cfg.Call.MaxPayloadBytes == 0 {
+		cfg.Call.MaxPayloadBytes = 16 << 10
+	}
+	if cfg.Call.VoIP.Timeout == 0 {
+		cfg.Call.VoIP.Timeout = 5 * time.Second
+	}
+	if err := cfg.Call.VoIP.validate(); err != nil {
+		return nil, fmt.Errorf("invalid call voip config: %w", err)
+	}
+	if cfg.E2EE.MaxOneTimePrekeys == 0 {
+		cfg.E2EE.MaxOneTimePrekeys = 200
+	}
+	if cfg.Storage.Region == "" {
+		cfg.Storage.Region = "us-east-1"
+	}
+	if cfg.Storage.Endpoint == "" && cfg.Storage.Driver == StorageDriverS3 {
+		cfg.Storage.Endpoint = "https://s3." + cfg.Storage.Region + ".amazonaws.com"
+	}
+	if cfg.Storage.KeyPrefix == "" {
+		cfg.Storage.KeyPrefix = "uploads/"
+	}
+	if cfg.Storage.PresignExpiry == 0 {
+		cfg.Storage.PresignExpiry = 15 * time.Minute
+	}
+	if cfg.Storage.MaxSize == 0 {
+		cfg.Storage.MaxSize = 100 << 20
+	}
+	if cfg.Storage.MultipartPartSize == 0 {
+		cfg.Storage.MultipartPartSize = 8 << 20
+	}
+	// S3 and OSS reject parts but the last smaller than 5MB (OSS: 100KB)
+	cfg.Storage.MultipartPartSize = max(cfg.Storage.MultipartPartSize, 5<<20)
+	if cfg.Storage.Timeout == 0 {
+		cfg.Storage.Timeout = 10 * time.Second
+	}
+	if cfg.Storage.Images.ThumbnailSize == 0 {
+		cfg.Storage.Images.ThumbnailSize = 320
+	}
+	if cfg.Storage.Images.MaxBytes == 0 {
+		cfg.Storage.Images.MaxBytes = 20 << 20
+	}
+	if cfg.Storage.Images.QueueSize == 0 {
+		cfg.Storage.Images.QueueSize = 256
+	}
+	if cfg.Storage.Images.Workers == 0 {
+		cfg.Storage.Images.Workers = 2
+	}
+	if cfg.Storage.Scan.ServiceName == "" {
+		cfg.Storage.Scan.ServiceName = "nexo_im"
+	}
+	if cfg.Storage.Scan.Timeout == 0 {
+		cfg.Storage.Scan.Timeout = 8 * time.Second
+	}
+	if cfg.Storage.Scan.MaxBytes == 0 {
+		cfg.Storage.Scan.MaxBytes = 25 << 20
+	}
+	if cfg.Storage.Download.URLExpiry == 0 {
+		cfg.Storage.Download.URLExpiry = 5 * time.Minute
+	}
+	if cfg.Storage.Audio.MaxBytes == 0 {
+		cfg.Storage.Audio.MaxBytes = 10 << 20
+	}
+	if err := cfg.Storage.validate(); err != nil {
+		return nil, fmt.Errorf("invalid storage config: %w", err)
+	}
+
 	GlobalConfig = &cfg
 	return &cfg, nil
 }
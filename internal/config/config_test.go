@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPerPlatformTokenLifetimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "jwt:\n  expire_hours: 168\n  platforms:\n    web:\n      expire_hours: 24\n      access_ttl: 5m\n    ios:\n      expire_hours: 720\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if got := cfg.JWT.SessionTTL(5); got != 24*time.Hour {
+		t.Fatalf("expected web session of 24h, got %v", got)
+	}
+	if got := cfg.JWT.SessionTTL(1); got != 720*time.Hour {
+		t.Fatalf("expected ios session of 720h, got %v", got)
+	}
+	if got := cfg.JWT.SessionTTL(3); got != 168*time.Hour {
+		t.Fatalf("expected default session for windows, got %v", got)
+	}
+	if cfg.JWT.AccessTokenTTL(5) != 5*time.Minute || cfg.JWT.AccessTokenTTL(1) != 15*time.Minute {
+		t.Fatalf("unexpected access token lifetimes: web=%v ios=%v", cfg.JWT.AccessTokenTTL(5), cfg.JWT.AccessTokenTTL(1))
+	}
+}
+
+func TestLoadRejectsUnknownTokenPlatform(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("jwt:\n  platforms:\n    symbian:\n      expire_hours: 1\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatalf("expected unknown platform to be rejected")
+	}
+}
+
+func TestExternalJWTIssuers(t *testing.T) {
+	cfg := ExternalJWTConfig{
+		Secret:            "legacy",
+		DefaultRole:       "user",
+		DefaultPlatformId: 1,
+		Issuers:           []ExternalIssuerConfig{{Name: "sso", JWKSURL: "https://sso.example.com/jwks", DefaultPlatformId: 5}},
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	issuers := cfg.AllIssuers()
+	if len(issuers) != 2 || issuers[1].Secret != "legacy" {
+		t.Fatalf("expected legacy secret as last issuer, got %+v", issuers)
+	}
+	if issuers[0].DefaultRole != "user" || issuers[0].DefaultPlatformId != 5 || issuers[1].DefaultPlatformId != 1 {
+		t.Fatalf("unexpected issuer defaults: %+v", issuers)
+	}
+
+	for _, iss := range []ExternalIssuerConfig{
+		{Name: "both", Secret: "s", JWKSURL: "https://sso.example.com/jwks"},
+		{Name: "none"},
+		{Name: "mapping", Secret: "s", UserIdMapping: "email"},
+	} {
+		bad := ExternalJWTConfig{Issuers: []ExternalIssuerConfig{iss}}
+		if err := bad.validate(); err == nil {
+			t.Fatalf("expected issuer %+v to be rejected", iss)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// gRPC authentication modes
+const (
+	GRPCAuthToken = "token" // internal-auth signature in the call metadata
+	GRPCAuthMTLS  = "mtls"  // client certificate, its common name is the service name
+)
+
+// GRPCConfig controls the gRPC API mirroring the internal HTTP routes. Calls are authenticated
+// like internal routes: with token auth the metadata carries the internal_auth signature, with
+// mtls the client certificate must be signed by ClientCAFile.
+type GRPCConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Port           int    `mapstructure:"port"`      // defaults to 9090
+	Auth           string `mapstructure:"auth"`      // token or mtls, defaults to token
+	CertFile       string `mapstructure:"cert_file"` // server certificate; plaintext when empty, required for mtls
+	KeyFile        string `mapstructure:"key_file"`
+	ClientCAFile   string `mapstructure:"client_ca_file"`    // CA bundle verifying client certificates, required for mtls
+	MaxRecvMsgSize int    `mapstructure:"max_recv_msg_size"` // bytes, defaults to server.max_body_bytes
+}
+
+func (c *GRPCConfig) validate(internalAuth *InternalAuthConfig) error {
+	if !c.Enabled {
+		return nil
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+	switch c.Auth {
+	case GRPCAuthToken:
+		if !internalAuth.Enabled || internalAuth.Secret == "" {
+			return fmt.Errorf("token auth requires internal_auth to be enabled with a secret")
+		}
+	case GRPCAuthMTLS:
+		if c.CertFile == "" || c.ClientCAFile == "" {
+			return fmt.Errorf("mtls auth requires cert_file, key_file and client_ca_file")
+		}
+	default:
+		return fmt.Errorf("unsupported auth %q", c.Auth)
+	}
+	return nil
+}
+
+// BuildTLS returns the server tls.Config, or nil when TLS is not configured. The server
+// certificate is reloaded when its files change; with mtls auth client certificates are required.
+func (c *GRPCConfig) BuildTLS() (*tls.Config, error) {
+	if c.CertFile == "" {
+		return nil, nil
+	}
+	reloader := &certReloader{certFile: c.CertFile, keyFile: c.KeyFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+	if c.Auth == GRPCAuthMTLS {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client_ca_file has no certificates")
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsCfg, nil
+}
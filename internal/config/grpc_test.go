@@ -0,0 +1,51 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestGRPCConfigValidate(t *testing.T) {
+	internalAuth := &InternalAuthConfig{Enabled: true, Secret: "secret"}
+	cases := []GRPCConfig{
+		{Enabled: true, Auth: "basic"},
+		{Enabled: true, Auth: GRPCAuthToken, CertFile: "a"},
+		{Enabled: true, Auth: GRPCAuthMTLS, CertFile: "a", KeyFile: "b"},
+	}
+	for _, c := range cases {
+		if err := c.validate(internalAuth); err == nil {
+			t.Fatalf("expected %+v to be rejected", c)
+		}
+	}
+	token := &GRPCConfig{Enabled: true, Auth: GRPCAuthToken}
+	if err := token.validate(internalAuth); err != nil {
+		t.Fatalf("expected token auth to be valid: %v", err)
+	}
+	if err := token.validate(&InternalAuthConfig{}); err == nil {
+		t.Fatalf("expected token auth without internal_auth to be rejected")
+	}
+}
+
+func TestGRPCConfigBuildTLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir(), "nexo")
+
+	tlsCfg, err := (&GRPCConfig{Auth: GRPCAuthToken}).BuildTLS()
+	if err != nil || tlsCfg != nil {
+		t.Fatalf("expected plaintext without a certificate, got %v, %v", tlsCfg, err)
+	}
+
+	// The self-signed server certificate doubles as the client CA
+	cfg := &GRPCConfig{Auth: GRPCAuthMTLS, CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile}
+	tlsCfg, err = cfg.BuildTLS()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if tlsCfg.ClientAuth != tls.RequireAndVerifyClientCert || tlsCfg.ClientCAs == nil {
+		t.Fatalf("expected client certificates to be required")
+	}
+
+	cfg.ClientCAFile = keyFile
+	if _, err = cfg.BuildTLS(); err == nil {
+		t.Fatalf("expected a client ca without certificates to be rejected")
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Secret providers
+const (
+	SecretProviderVault = "vault"
+	SecretProviderAWS   = "aws"
+)
+
+// Secret keys looked up in the secret payload (a flat JSON object)
+const (
+	SecretKeyJWT          = "jwt_secret"
+	SecretKeyExternalJWT  = "external_jwt_secret"
+	SecretKeyMySQL        = "mysql_password"
+	SecretKeyRedis        = "redis_password"
+	SecretKeyInternalAuth = "internal_auth_secret"
+	SecretKeyCaptcha      = "captcha_secret"
+)
+
+const secretFetchTimeout = 10 * time.Second
+
+// SecretsConfig configures an external secret manager. Secrets it returns
+// override the same fields loaded from YAML or environment variables.
+type SecretsConfig struct {
+	Provider string           `mapstructure:"provider"` // "" (disabled), "vault" or "aws"
+	Vault    VaultConfig      `mapstructure:"vault"`
+	AWS      AWSSecretsConfig `mapstructure:"aws"`
+}
+
+// VaultConfig locates a secret in a Vault KV v2 engine
+type VaultConfig struct {
+	Addr  string `mapstructure:"addr"`  // defaults to $VAULT_ADDR
+	Token string `mapstructure:"token"` // defaults to $VAULT_TOKEN
+	Mount string `mapstructure:"mount"` // KV v2 mount, defaults to "secret"
+	Path  string `mapstructure:"path"`
+}
+
+// AWSSecretsConfig locates a secret in AWS Secrets Manager.
+// Credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type AWSSecretsConfig struct {
+	Region   string `mapstructure:"region"` // defaults to $AWS_REGION
+	SecretId string `mapstructure:"secret_id"`
+	Endpoint string `mapstructure:"endpoint"` // optional, overrides the regional endpoint
+}
+
+// loadSecrets fetches secrets from the configured provider and applies them to cfg
+func loadSecrets(ctx context.Context, cfg *Config) error {
+	var (
+		secrets map[string]string
+		err     error
+	)
+	switch strings.ToLower(strings.TrimSpace(cfg.Secrets.Provider)) {
+	case "":
+		return nil
+	case SecretProviderVault:
+		secrets, err = fetchVaultSecrets(ctx, &cfg.Secrets.Vault)
+	case SecretProviderAWS:
+		secrets, err = fetchAWSSecrets(ctx, &cfg.Secrets.AWS)
+	default:
+		return fmt.Errorf("unknown secret provider: %s", cfg.Secrets.Provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	applySecrets(cfg, secrets)
+	return nil
+}
+
+// applySecrets overrides secret fields of cfg with the non-empty values in secrets
+func applySecrets(cfg *Config, secrets map[string]string) {
+	fields := map[string]*string{
+		SecretKeyJWT:          &cfg.JWT.Secret,
+		SecretKeyExternalJWT:  &cfg.ExternalJWT.Secret,
+		SecretKeyMySQL:        &cfg.MySQL.Password,
+		SecretKeyRedis:        &cfg.Redis.Password,
+		SecretKeyInternalAuth: &cfg.InternalAuth.Secret,
+		SecretKeyCaptcha:      &cfg.Captcha.Secret,
+	}
+	for key, field := range fields {
+		if v := secrets[key]; v != "" {
+			*field = v
+		}
+	}
+}
+
+func fetchVaultSecrets(ctx context.Context, vc *VaultConfig) (map[string]string, error) {
+	addr := firstNonEmpty(vc.Addr, os.Getenv("VAULT_ADDR"))
+	token := firstNonEmpty(vc.Token, os.Getenv("VAULT_TOKEN"))
+	mount := firstNonEmpty(vc.Mount, "secret")
+	if addr == "" || token == "" || vc.Path == "" {
+		return nil, fmt.Errorf("vault addr, token and path are required")
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimRight(addr, "/"), strings.Trim(mount, "/"), strings.TrimLeft(vc.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	var resp struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err = doSecretRequest(req, &resp); err != nil {
+		return nil, fmt.Errorf("read vault secret %s: %w", vc.Path, err)
+	}
+	return resp.Data.Data, nil
+}
+
+func fetchAWSSecrets(ctx context.Context, ac *AWSSecretsConfig) (map[string]string, error) {
+	region := firstNonEmpty(ac.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if region == "" || ac.SecretId == "" {
+		return nil, fmt.Errorf("aws region and secret_id are required")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+
+	endpoint := firstNonEmpty(ac.Endpoint, fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region))
+	body, err := json.Marshal(map[string]string{"SecretId": ac.SecretId})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if sessionToken := os.Getenv("AWS_SESSION_TOKEN"); sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+	signAWSRequest(req, body, region, "secretsmanager", accessKey, secretKey, time.Now())
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err = doSecretRequest(req, &resp); err != nil {
+		return nil, fmt.Errorf("get aws secret %s: %w", ac.SecretId, err)
+	}
+
+	var secrets map[string]string
+	if err = json.Unmarshal([]byte(resp.SecretString), &secrets); err != nil {
+		return nil, fmt.Errorf("aws secret %s is not a JSON object: %w", ac.SecretId, err)
+	}
+	return secrets, nil
+}
+
+func doSecretRequest(req *http.Request, out any) error {
+	client := &http.Client{Timeout: secretFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// signAWSRequest signs req with AWS Signature Version 4, covering host and all set headers
+func signAWSRequest(req *http.Request, body []byte, region, service, accessKey, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadOverlaysEnvForKeysMissingFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("mysql:\n  host: db\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("INFRA_MYSQL_PASSWORD", "from-env")
+	t.Setenv("INFRA_JWT_SECRET", "jwt-from-env")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.MySQL.Host != "db" || cfg.MySQL.Password != "from-env" || cfg.JWT.Secret != "jwt-from-env" {
+		t.Fatalf("expected env overlay, got host=%q password=%q jwt=%q", cfg.MySQL.Host, cfg.MySQL.Password, cfg.JWT.Secret)
+	}
+}
+
+func TestLoadSecretsFromVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/nexo/prod" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"jwt_secret":"vault-jwt","mysql_password":"vault-db"}}}`))
+	}))
+	defer srv.Close()
+
+	cfg := &Config{
+		JWT:   JWTConfig{Secret: "yaml-jwt"},
+		Redis: RedisConfig{Password: "yaml-redis"},
+		Secrets: SecretsConfig{
+			Provider: SecretProviderVault,
+			Vault:    VaultConfig{Addr: srv.URL, Token: "token", Mount: "kv", Path: "nexo/prod"},
+		},
+	}
+	if err := loadSecrets(context.Background(), cfg); err != nil {
+		t.Fatalf("load secrets: %v", err)
+	}
+	if cfg.JWT.Secret != "vault-jwt" || cfg.MySQL.Password != "vault-db" {
+		t.Fatalf("expected vault secrets applied, got jwt=%q db=%q", cfg.JWT.Secret, cfg.MySQL.Password)
+	}
+	if cfg.Redis.Password != "yaml-redis" {
+		t.Fatalf("expected secrets missing from vault to keep file value, got %q", cfg.Redis.Password)
+	}
+}
+
+func TestLoadSecretsUnknownProvider(t *testing.T) {
+	cfg := &Config{Secrets: SecretsConfig{Provider: "etcd"}}
+	if err := loadSecrets(context.Background(), cfg); err == nil {
+		t.Fatalf("expected error for unknown provider")
+	}
+}
+
+// Example request from the AWS Signature Version 4 documentation
+func TestSignAWSRequest(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	signAWSRequest(req, nil, "us-east-1", "iam", "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Fatalf("unexpected authorization header:\n got %s\nwant %s", got, expected)
+	}
+}
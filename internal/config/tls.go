@@ -0,0 +1,127 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig holds native HTTPS/WSS termination configuration.
+// When enabled the HTTP port serves TLS only, so WebSocket clients connect with wss://.
+type TLSConfig struct {
+	Enabled          bool       `mapstructure:"enabled"`
+	CertFile         string     `mapstructure:"cert_file"`
+	KeyFile          string     `mapstructure:"key_file"`
+	MinVersion       string     `mapstructure:"min_version"`        // "1.2" or "1.3", defaults to 1.2
+	RedirectHTTPPort int        `mapstructure:"redirect_http_port"` // plain HTTP port redirecting to HTTPS, 0 disables
+	HSTS             HSTSConfig `mapstructure:"hsts"`
+}
+
+// HSTSConfig holds the Strict-Transport-Security header configuration
+type HSTSConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	MaxAge            time.Duration `mapstructure:"max_age"`
+	IncludeSubdomains bool          `mapstructure:"include_subdomains"`
+	Preload           bool          `mapstructure:"preload"`
+}
+
+// certReloadInterval bounds how often the certificate files are checked for changes
+const certReloadInterval = time.Minute
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (c *TLSConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("cert_file and key_file are required")
+	}
+	if _, ok := tlsVersions[c.MinVersion]; !ok {
+		return fmt.Errorf("unsupported min_version %q", c.MinVersion)
+	}
+	return nil
+}
+
+// Build loads the certificate and returns the server tls.Config.
+// The certificate files are re-read when they change, so renewals do not need a restart.
+func (c *TLSConfig) Build() (*tls.Config, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	reloader := &certReloader{certFile: c.CertFile, keyFile: c.KeyFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:     tlsVersions[c.MinVersion],
+		NextProtos:     []string{"http/1.1"},
+		GetCertificate: reloader.getCertificate,
+	}, nil
+}
+
+// certReloader serves a certificate and reloads it when its files are modified
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < certReloadInterval {
+		return r.cert, nil
+	}
+	r.checkedAt = time.Now()
+
+	// Keep serving the current certificate if the new one is unreadable, e.g. half written
+	if modTime, err := r.latestModTime(); err == nil && modTime.After(r.modTime) {
+		if cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile); err == nil {
+			r.cert = &cert
+			r.modTime = modTime
+		}
+	}
+	return r.cert, nil
+}
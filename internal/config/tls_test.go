@@ -0,0 +1,115 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCert(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func certCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestTLSConfigBuild(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir(), "nexo")
+
+	cfg := &TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"}
+	tlsCfg, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("build tls config: %v", err)
+	}
+	if tlsCfg.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected tls 1.3 min version, got %x", tlsCfg.MinVersion)
+	}
+	cert, err := tlsCfg.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil || certCommonName(t, cert) != "nexo" {
+		t.Fatalf("expected loaded certificate, got err=%v", err)
+	}
+}
+
+func TestCertReloaderPicksUpRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "first")
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	writeTestCert(t, dir, "second")
+	renewedAt := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, renewedAt, renewedAt)
+	_ = os.Chtimes(keyFile, renewedAt, renewedAt)
+
+	// Within the reload interval the cached certificate is served
+	cert, _ := r.getCertificate(nil)
+	if name := certCommonName(t, cert); name != "first" {
+		t.Fatalf("expected cached certificate, got %q", name)
+	}
+
+	r.checkedAt = time.Time{}
+	cert, _ = r.getCertificate(nil)
+	if name := certCommonName(t, cert); name != "second" {
+		t.Fatalf("expected renewed certificate, got %q", name)
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	cases := []TLSConfig{
+		{Enabled: true, MinVersion: "1.2"},
+		{Enabled: true, CertFile: "a", KeyFile: "b", MinVersion: "1.1"},
+	}
+	for _, c := range cases {
+		if err := c.validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", c)
+		}
+	}
+	if err := (&TLSConfig{}).validate(); err != nil {
+		t.Fatalf("disabled tls should not be validated: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package entity
+
+// AdminAuditLog records an admin access to user data for compliance review
+type AdminAuditLog struct {
+	Id          int64   `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Operator    string  `json:"operator" gorm:"column:operator"`
+	Action      string  `json:"action" gorm:"column:action"`
+	Params      *string `json:"params" gorm:"column:params;type:json"`
+	ResultCount int64   `json:"result_count" gorm:"column:result_count"`
+	CreatedAt   int64   `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for AdminAuditLog
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "github.com/ZaiSpace/nexo_im/pkg/scope"
+
+// APIKey is a long-lived credential of a bot user for the HTTP API.
+// Only the hash of the key is stored; the key itself is shown once on creation.
+type APIKey struct {
+	Id         int64    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId     string   `json:"user_id" gorm:"column:user_id"`
+	Name       string   `json:"name" gorm:"column:name"`
+	Prefix     string   `json:"prefix" gorm:"column:prefix"` // public part of the key, identifies it in lookups and logs
+	KeyHash    string   `json:"-" gorm:"column:key_hash"`
+	Scopes     []string `json:"scopes" gorm:"column:scopes;serializer:json"`
+	Operator   string   `json:"operator" gorm:"column:operator"`     // admin API key name that created it
+	ExpiresAt  int64    `json:"expires_at" gorm:"column:expires_at"` // 0 = never
+	RevokedAt  int64    `json:"revoked_at" gorm:"column:revoked_at"` // 0 = active
+	LastUsedAt int64    `json:"last_used_at" gorm:"column:last_used_at"`
+	CreatedAt  int64    `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActive checks if the key can authenticate at now (unix ms)
+func (k *APIKey) IsActive(now int64) bool {
+	return k.RevokedAt == 0 && (k.ExpiresAt == 0 || k.ExpiresAt > now)
+}
+
+// Allows checks if the key grants access to a route group, see package scope
+func (k *APIKey) Allows(group string, write bool) bool {
+	return scope.Allows(k.Scopes, group, write)
+}
@@ -0,0 +1,22 @@
+package entity
+
+// AuditEvent records a security-sensitive operation (login, token issuance,
+// admin action, internal call, permission failure)
+type AuditEvent struct {
+	Id        int64   `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Category  string  `json:"category" gorm:"column:category"`
+	Action    string  `json:"action" gorm:"column:action"`
+	Actor     string  `json:"actor" gorm:"column:actor"`
+	Path      string  `json:"path" gorm:"column:path"`
+	ClientIp  string  `json:"client_ip" gorm:"column:client_ip"`
+	Result    string  `json:"result" gorm:"column:result"`
+	Code      int     `json:"code" gorm:"column:code"`
+	Detail    *string `json:"detail" gorm:"column:detail;type:json"`
+	TraceId   string  `json:"trace_id" gorm:"column:trace_id"`
+	CreatedAt int64   `json:"created_at" gorm:"column:created_at"`
+}
+
+// TableName returns the table name for AuditEvent
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
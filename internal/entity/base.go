@@ -22,6 +22,13 @@ func GenSingleConversationId(userA, userB string) string {
 	return fmt.Sprintf("%s%s:%s", constant.SingleConversationPrefix, users[0], users[1])
 }
 
+// GenSavedConversationId generates the Id of a user's saved messages conversation, the single
+// chat with themselves
+// Format: si_{userId}:{userId}
+func GenSavedConversationId(userId string) string {
+	return GenSingleConversationId(userId, userId)
+}
+
 // GenGroupConversationId generates conversation Id for group chat
 // Format: sg_{groupId}
 func GenGroupConversationId(groupId string) string {
@@ -33,6 +40,12 @@ func IsSingleConversation(conversationId string) bool {
 	return len(conversationId) > 3 && conversationId[:3] == constant.SingleConversationPrefix
 }
 
+// GenSystemConversationId generates the system notification conversation Id of a user
+// Format: sn_{userId}
+func GenSystemConversationId(userId string) string {
+	return fmt.Sprintf("%s%s", constant.SystemConversationPrefix, userId)
+}
+
 // IsGroupConversation checks if conversation Id is for group chat
 func IsGroupConversation(conversationId string) bool {
 	return len(conversationId) > 3 && conversationId[:3] == constant.GroupConversationPrefix
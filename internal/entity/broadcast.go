@@ -0,0 +1,53 @@
+package entity
+
+import "github.com/ZaiSpace/nexo_im/pkg/constant"
+
+// Broadcast is an admin announcement delivered to every user of a cohort
+// as a message in their system notification conversation
+type Broadcast struct {
+	Id          int64   `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Operator    string  `json:"operator" gorm:"column:operator"`
+	Text        string  `json:"text" gorm:"column:text"`
+	Link        string  `json:"link" gorm:"column:link"`
+	Cohort      *string `json:"cohort" gorm:"column:cohort;type:json"`
+	Status      int32   `json:"status" gorm:"column:status"`
+	ScheduledAt int64   `json:"scheduled_at" gorm:"column:scheduled_at"`
+	TotalCount  int64   `json:"total_count" gorm:"column:total_count"`
+	SentCount   int64   `json:"sent_count" gorm:"column:sent_count"`
+	FailedCount int64   `json:"failed_count" gorm:"column:failed_count"`
+	LastUserId  string  `json:"-" gorm:"column:last_user_id"` // resume point of the delivery
+	ErrorMsg    string  `json:"error_msg,omitempty" gorm:"column:error_msg"`
+	StartedAt   int64   `json:"started_at" gorm:"column:started_at"`
+	FinishedAt  int64   `json:"finished_at" gorm:"column:finished_at"`
+	CreatedAt   int64   `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt   int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for Broadcast
+func (Broadcast) TableName() string {
+	return "broadcasts"
+}
+
+// IsFinished checks if the broadcast has reached a final status
+func (b *Broadcast) IsFinished() bool {
+	return b.Status == constant.BroadcastStatusCompleted ||
+		b.Status == constant.BroadcastStatusFailed ||
+		b.Status == constant.BroadcastStatusCancelled
+}
+
+// BroadcastCohort selects the recipients of a broadcast; an empty cohort is all users.
+// Deleted and banned users are never included.
+type BroadcastCohort struct {
+	UserIds          []string `json:"user_ids,omitempty" validate:"max=10000"`
+	RegisteredAfter  int64    `json:"registered_after,omitempty"`      // created_at >= (ms)
+	RegisteredBefore int64    `json:"registered_before,omitempty"`     // created_at < (ms)
+	Tag              string   `json:"tag,omitempty" validate:"max=64"` // users carrying the tag
+}
+
+// AnnouncementContent is the custom content payload of a broadcast message
+type AnnouncementContent struct {
+	Type        string `json:"type"` // always "announcement"
+	BroadcastId int64  `json:"broadcast_id"`
+	Text        string `json:"text"`
+	Link        string `json:"link,omitempty"`
+}
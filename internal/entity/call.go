@@ -0,0 +1,71 @@
+package entity
+
+import "github.com/ZaiSpace/nexo_im/pkg/constant"
+
+// Call is the signaling state of an audio or video call, stored in Redis while it lasts
+type Call struct {
+	Id           string          `json:"id"`
+	InviterId    string          `json:"inviter_id"`
+	InviteeIds   []string        `json:"invitee_ids"`
+	GroupId      string          `json:"group_id,omitempty"`
+	MediaType    string          `json:"media_type"`
+	State        string          `json:"state"`
+	Answers      map[string]int  `json:"answers,omitempty"`  // invitee -> platform id of the device that answered
+	Rejected     map[string]bool `json:"rejected,omitempty"` // invitees who declined or did not answer in time
+	Left         map[string]bool `json:"left,omitempty"`     // participants who hung up
+	Pushed       []string        `json:"pushed,omitempty"`   // invitees rung by VoIP push, being offline when invited
+	RingDeadline int64           `json:"ring_deadline"`      // ms, pending invitees time out after it
+	CreatedAt    int64           `json:"created_at"`
+	EndedAt      int64           `json:"ended_at,omitempty"`
+}
+
+// IsParticipant reports whether userId is the inviter or an invitee
+func (c *Call) IsParticipant(userId string) bool {
+	return userId == c.InviterId || c.IsInvitee(userId)
+}
+
+// IsInvitee reports whether userId was invited
+func (c *Call) IsInvitee(userId string) bool {
+	for _, id := range c.InviteeIds {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// ParticipantIds returns the inviter followed by the invitees
+func (c *Call) ParticipantIds() []string {
+	return append([]string{c.InviterId}, c.InviteeIds...)
+}
+
+// PendingInviteeIds returns the invitees who have neither answered nor declined
+func (c *Call) PendingInviteeIds() []string {
+	var ids []string
+	for _, id := range c.InviteeIds {
+		if _, answered := c.Answers[id]; !answered && !c.Rejected[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// JoinedCount returns the number of participants in the call: the inviter and the invitees
+// who answered, minus those who hung up
+func (c *Call) JoinedCount() int {
+	n := 0
+	if !c.Left[c.InviterId] {
+		n++
+	}
+	for id := range c.Answers {
+		if !c.Left[id] {
+			n++
+		}
+	}
+	return n
+}
+
+// IsEnded reports whether the call is over
+func (c *Call) IsEnded() bool {
+	return c.State == constant.CallStateEnded
+}
@@ -1,5 +1,7 @@
 package entity
 
+import "github.com/ZaiSpace/nexo_im/pkg/constant"
+
 // Conversation represents a conversation
 type Conversation struct {
 	Id               int64   `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
@@ -33,6 +35,36 @@ type ConversationInfo struct {
 	ReadSeq          int64        `json:"read_seq"`
 	UpdatedAt        int64        `json:"updated_at"`
 	LastMessage      *MessageInfo `json:"last_message,omitempty"`
+	// UnreadMentionCount counts the unread messages mentioning the user, the oldest of
+	// which is FirstUnreadMentionSeq
+	UnreadMentionCount    int64 `json:"unread_mention_count"`
+	FirstUnreadMentionSeq int64 `json:"first_unread_mention_seq,omitempty"`
+	// IsSaved marks the owner's saved messages conversation, the single chat with themselves
+	IsSaved bool `json:"is_saved,omitempty"`
+	// TTLSeconds is the disappearing messages timer of the conversation, 0 when off
+	TTLSeconds int32 `json:"ttl_seconds,omitempty"`
+	// PinnedSeqs are the seqs of the messages pinned in the conversation, newest pin first
+	PinnedSeqs []int64 `json:"pinned_seqs,omitempty"`
+}
+
+// ApplySaved flags the saved messages conversation of ownerId, which never counts as unread
+func (c *ConversationInfo) ApplySaved(ownerId string) {
+	if c.ConversationType != constant.SessionTypeSingle || c.PeerUserId != ownerId {
+		return
+	}
+	c.IsSaved = true
+	c.UnreadCount = 0
+	c.UnreadMentionCount = 0
+	c.FirstUnreadMentionSeq = 0
+}
+
+// SetMentionStat sets the unread mentions of the conversation, stat may be nil
+func (c *ConversationInfo) SetMentionStat(stat *MentionStat) {
+	if stat == nil {
+		return
+	}
+	c.UnreadMentionCount = stat.Count
+	c.FirstUnreadMentionSeq = stat.FirstSeq
 }
 
 // ConversationWithSeq represents conversation with seq info
@@ -41,4 +73,5 @@ type ConversationWithSeq struct {
 	MaxSeq      int64 `json:"max_seq"`
 	ReadSeq     int64 `json:"read_seq"`
 	UnreadCount int64 `json:"unread_count"`
+	TTLSeconds  int32 `json:"ttl_seconds"`
 }
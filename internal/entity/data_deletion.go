@@ -0,0 +1,23 @@
+package entity
+
+// UserDeletionRecord is the audit trail entry for a user data deletion request
+type UserDeletionRecord struct {
+	Id                int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId            string `json:"user_id" gorm:"column:user_id"`
+	Mode              string `json:"mode" gorm:"column:mode"`
+	Operator          string `json:"operator" gorm:"column:operator"`
+	Reason            string `json:"reason" gorm:"column:reason"`
+	Status            int32  `json:"status" gorm:"column:status"`
+	MessageCount      int64  `json:"message_count" gorm:"column:message_count"`
+	ConversationCount int64  `json:"conversation_count" gorm:"column:conversation_count"`
+	MembershipCount   int64  `json:"membership_count" gorm:"column:membership_count"`
+	ErrorMsg          string `json:"error_msg,omitempty" gorm:"column:error_msg"`
+	FinishedAt        int64  `json:"finished_at" gorm:"column:finished_at"`
+	CreatedAt         int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt         int64  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for UserDeletionRecord
+func (UserDeletionRecord) TableName() string {
+	return "user_deletion_records"
+}
@@ -0,0 +1,34 @@
+package entity
+
+// E2EEDeviceKeys holds the long-lived public keys of a device for X3DH key agreement
+type E2EEDeviceKeys struct {
+	UserId                string `json:"user_id" gorm:"column:user_id;primaryKey"`
+	DeviceId              int    `json:"device_id" gorm:"column:device_id;primaryKey"` // platform id of the device
+	RegistrationId        int    `json:"registration_id" gorm:"column:registration_id"`
+	IdentityKey           string `json:"identity_key" gorm:"column:identity_key"` // base64
+	SignedPrekeyId        int    `json:"signed_prekey_id" gorm:"column:signed_prekey_id"`
+	SignedPrekey          string `json:"signed_prekey" gorm:"column:signed_prekey"`                     // base64
+	SignedPrekeySignature string `json:"signed_prekey_signature" gorm:"column:signed_prekey_signature"` // base64
+	CreatedAt             int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt             int64  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for E2EEDeviceKeys
+func (E2EEDeviceKeys) TableName() string {
+	return "e2ee_device_keys"
+}
+
+// E2EEOneTimePrekey is a one-time prekey of a device, deleted when a peer claims it
+type E2EEOneTimePrekey struct {
+	Id        int64  `json:"-" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId    string `json:"-" gorm:"column:user_id"`
+	DeviceId  int    `json:"-" gorm:"column:device_id"`
+	KeyId     int    `json:"key_id" gorm:"column:key_id"`
+	PublicKey string `json:"public_key" gorm:"column:public_key"` // base64
+	CreatedAt int64  `json:"-" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for E2EEOneTimePrekey
+func (E2EEOneTimePrekey) TableName() string {
+	return "e2ee_one_time_prekeys"
+}
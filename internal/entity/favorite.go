@@ -0,0 +1,19 @@
+package entity
+
+// MessageFavorite is a message a user saved for later. Unless favorites are configured to
+// be reference only, Message holds a copy taken when favoriting so the favorite outlives
+// the original being recalled or deleted.
+type MessageFavorite struct {
+	Id             int64        `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId         string       `json:"-" gorm:"column:user_id"`
+	ConversationId string       `json:"conversation_id" gorm:"column:conversation_id"`
+	Seq            int64        `json:"seq" gorm:"column:seq"`
+	SenderId       string       `json:"sender_id" gorm:"column:sender_id"`
+	Message        *MessageInfo `json:"message,omitempty" gorm:"column:snapshot;type:json;serializer:json"`
+	CreatedAt      int64        `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for MessageFavorite
+func (MessageFavorite) TableName() string {
+	return "message_favorites"
+}
@@ -4,16 +4,20 @@ import "github.com/ZaiSpace/nexo_im/pkg/constant"
 
 // Group represents a group
 type Group struct {
-	Id            string  `json:"id" gorm:"column:id;primaryKey"`
-	Name          string  `json:"name" gorm:"column:name"`
-	Introduction  string  `json:"introduction" gorm:"column:introduction"`
-	Avatar        string  `json:"avatar" gorm:"column:avatar"`
-	Extra         *string `json:"extra" gorm:"column:extra;type:json"`
-	Status        int32   `json:"status" gorm:"column:status"`
-	CreatorUserId string  `json:"creator_user_id" gorm:"column:creator_user_id"`
-	GroupType     int32   `json:"group_type" gorm:"column:group_type"`
-	CreatedAt     int64   `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt     int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+	Id                    string  `json:"id" gorm:"column:id;primaryKey"`
+	Name                  string  `json:"name" gorm:"column:name"`
+	Introduction          string  `json:"introduction" gorm:"column:introduction"`
+	Avatar                string  `json:"avatar" gorm:"column:avatar"`
+	Extra                 *string `json:"extra" gorm:"column:extra;type:json"`
+	Status                int32   `json:"status" gorm:"column:status"`
+	CreatorUserId         string  `json:"creator_user_id" gorm:"column:creator_user_id"`
+	GroupType             int32   `json:"group_type" gorm:"column:group_type"`
+	MuteAll               bool    `json:"mute_all" gorm:"column:mute_all"`
+	Announcement          string  `json:"announcement" gorm:"column:announcement"`
+	AnnouncementUpdatedAt int64   `json:"announcement_updated_at" gorm:"column:announcement_updated_at"`
+	AnnouncementUserId    string  `json:"announcement_user_id" gorm:"column:announcement_user_id"`
+	CreatedAt             int64   `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt             int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
 }
 
 // TableName returns the table name for Group
@@ -39,6 +43,7 @@ type GroupMember struct {
 	JoinedAt      int64   `json:"joined_at" gorm:"column:joined_at"`
 	JoinSeq       int64   `json:"join_seq" gorm:"column:join_seq"`
 	InviterUserId string  `json:"inviter_user_id" gorm:"column:inviter_user_id"`
+	MutedUntil    int64   `json:"muted_until" gorm:"column:muted_until"` // unix milli, 0 when not muted
 	CreatedAt     int64   `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
 	UpdatedAt     int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
 }
@@ -63,16 +68,34 @@ func (gm *GroupMember) IsAdmin() bool {
 	return gm.RoleLevel >= constant.RoleLevelAdmin
 }
 
+// IsMuted checks if the member is muted at now (unix milli)
+func (gm *GroupMember) IsMuted(now int64) bool {
+	return gm.MutedUntil > now
+}
+
 // GroupInfo represents group info with member count
 type GroupInfo struct {
-	Id            string `json:"id"`
-	Name          string `json:"name"`
-	Introduction  string `json:"introduction"`
-	Avatar        string `json:"avatar"`
-	Status        int32  `json:"status"`
+	Id                    string `json:"id"`
+	Name                  string `json:"name"`
+	Introduction          string `json:"introduction"`
+	Avatar                string `json:"avatar"`
+	Status                int32  `json:"status"`
+	CreatorUserId         string `json:"creator_user_id"`
+	MemberCount           int64  `json:"member_count"`
+	MuteAll               bool   `json:"mute_all"`
+	Announcement          string `json:"announcement"`
+	AnnouncementUpdatedAt int64  `json:"announcement_updated_at"`
+	CreatedAt             int64  `json:"created_at"`
+}
+
+// GroupInviteLink is a shareable link letting users join a group, stored in Redis
+type GroupInviteLink struct {
+	Token         string `json:"token"`
+	GroupId       string `json:"group_id"`
 	CreatorUserId string `json:"creator_user_id"`
-	MemberCount   int64  `json:"member_count"`
-	CreatedAt     int64  `json:"created_at"`
+	MaxUses       int64  `json:"max_uses"` // 0 for unlimited
+	Uses          int64  `json:"uses"`
+	ExpiresAt     int64  `json:"expires_at"`
 }
 
 // GroupMemberInfo represents member info in group
@@ -0,0 +1,22 @@
+package entity
+
+// HiddenMessage is a message a user deleted for themselves; the other participants still see it
+type HiddenMessage struct {
+	UserId         string `json:"user_id" gorm:"column:user_id;primaryKey"`
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id;primaryKey"`
+	Seq            int64  `json:"seq" gorm:"column:seq;primaryKey"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for HiddenMessage
+func (HiddenMessage) TableName() string {
+	return "hidden_messages"
+}
+
+// MessageDeletion is a message deleted for everyone by UserId, pushed to the participants of
+// the conversation
+type MessageDeletion struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	UserId         string `json:"user_id"`
+}
@@ -0,0 +1,20 @@
+package entity
+
+// MessageMention records that a message mentions a user, kept until the user reads past it
+type MessageMention struct {
+	UserId         string `json:"user_id" gorm:"column:user_id;primaryKey"`
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id;primaryKey"`
+	Seq            int64  `json:"seq" gorm:"column:seq;primaryKey"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for MessageMention
+func (MessageMention) TableName() string {
+	return "message_mentions"
+}
+
+// MentionStat sums up the unread mentions of a user in a conversation
+type MentionStat struct {
+	Count    int64 // unread messages mentioning the user
+	FirstSeq int64 // seq of the oldest one
+}
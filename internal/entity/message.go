@@ -1,21 +1,46 @@
 package entity
 
-import "encoding/json"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
 
 type TextContent struct {
-	Text string `json:"text"`
+	Text     string       `json:"text"`
+	Preview  *LinkPreview `json:"preview,omitempty"`
+	Mentions []string     `json:"mentions,omitempty"` // ids of the mentioned users
+}
+
+// LinkPreview is the card of the first link in a text message, built by the server from the
+// OpenGraph metadata of the linked page
+type LinkPreview struct {
+	Url         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageUrl    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
 }
 
 type ImageContent struct {
-	Url string `json:"url"`
+	Url          string `json:"url"`
+	ThumbnailUrl string `json:"thumbnail_url,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
 }
 
 type VideoContent struct {
 	Url string `json:"url"`
 }
 
+// AudioContent is a voice note or audio clip: its duration in milliseconds and an optional
+// waveform of amplitude samples (0-255) drawn by voice-note UIs
 type AudioContent struct {
-	Url string `json:"url"`
+	Url      string `json:"url"`
+	Duration int    `json:"duration,omitempty"`
+	Waveform []int  `json:"waveform,omitempty"`
 }
 
 type FileContent struct {
@@ -23,39 +48,127 @@ type FileContent struct {
 	Name string `json:"name,omitempty"`
 }
 
+// PollContent is a poll: a question and the options members vote for, referred to by their
+// index. Votes are stored apart from the message, so its content never changes.
+type PollContent struct {
+	Question    string   `json:"question"`
+	Options     []string `json:"options"`
+	Anonymous   bool     `json:"anonymous,omitempty"`    // results do not tell who voted for what
+	MultiChoice bool     `json:"multi_choice,omitempty"` // a voter may pick several options
+}
+
+// RevokeContent is the content of a revoke notification: the seq of the message its sender
+// recalled, in the same conversation
+type RevokeContent struct {
+	Seq int64 `json:"seq"`
+}
+
+// MergedContent is the content of a merged forward: copies of messages of one conversation
+// packaged as one message, in their original order
+type MergedContent struct {
+	Title    string           `json:"title,omitempty"`
+	Messages []*MergedMessage `json:"messages"`
+}
+
+// MergedMessage is a message of a merged forward as it was when forwarded
+type MergedMessage struct {
+	SenderId string         `json:"sender_id"`
+	MsgType  int32          `json:"msg_type"`
+	Content  MessageContent `json:"content"`
+	SendAt   int64          `json:"send_at"`
+}
+
+// FlatMergedContent is MergedContent in the external API shape
+type FlatMergedContent struct {
+	Title    string               `json:"title,omitempty"`
+	Messages []*FlatMergedMessage `json:"messages"`
+}
+
+// FlatMergedMessage is MergedMessage in the external API shape
+type FlatMergedMessage struct {
+	SenderId string             `json:"sender_id"`
+	MsgType  int32              `json:"msg_type"`
+	Content  FlatMessageContent `json:"content"`
+	SendAt   int64              `json:"send_at"`
+}
+
+// EncryptedContent is an end-to-end encrypted payload, stored and relayed without being
+// inspected. The sender encrypts the message once per recipient device, including its own
+// other devices; each device is delivered its own ciphertext only.
+type EncryptedContent struct {
+	SenderDeviceId int                 `json:"sender_device_id"`
+	Ciphertexts    []*DeviceCiphertext `json:"ciphertexts"`
+}
+
+// DeviceCiphertext is the ciphertext of a message for one device
+type DeviceCiphertext struct {
+	UserId   string `json:"user_id"`
+	DeviceId int    `json:"device_id"` // platform id of the device, as in the e2ee key bundles
+	Type     int    `json:"type"`      // protocol message type, e.g. a prekey or a session message
+	Body     string `json:"body"`      // base64
+}
+
+// ForDevice returns the content with only the ciphertext of a device
+func (c *EncryptedContent) ForDevice(userId string, deviceId int) *EncryptedContent {
+	filtered := &EncryptedContent{SenderDeviceId: c.SenderDeviceId, Ciphertexts: []*DeviceCiphertext{}}
+	for _, ct := range c.Ciphertexts {
+		if ct.UserId == userId && ct.DeviceId == deviceId {
+			filtered.Ciphertexts = append(filtered.Ciphertexts, ct)
+		}
+	}
+	return filtered
+}
+
 // MessageContent is the internal typed content payload stored in JSON.
 type MessageContent struct {
-	Text   *TextContent    `json:"text,omitempty"`
-	Image  *ImageContent   `json:"image,omitempty"`
-	Video  *VideoContent   `json:"video,omitempty"`
-	Audio  *AudioContent   `json:"audio,omitempty"`
-	File   *FileContent    `json:"file,omitempty"`
-	Custom json.RawMessage `json:"custom,omitempty"`
+	Text      *TextContent      `json:"text,omitempty"`
+	Image     *ImageContent     `json:"image,omitempty"`
+	Video     *VideoContent     `json:"video,omitempty"`
+	Audio     *AudioContent     `json:"audio,omitempty"`
+	File      *FileContent      `json:"file,omitempty"`
+	Custom    json.RawMessage   `json:"custom,omitempty"`
+	Encrypted *EncryptedContent `json:"encrypted,omitempty"`
+	Poll      *PollContent      `json:"poll,omitempty"`
+	Revoke    *RevokeContent    `json:"revoke,omitempty"`
+	Merged    *MergedContent    `json:"merged,omitempty"`
 }
 
 // FlatMessageContent keeps the external API shape stable.
 type FlatMessageContent struct {
-	Text   string `json:"text,omitempty"`
-	Image  string `json:"image,omitempty"`
-	Video  string `json:"video,omitempty"`
-	Audio  string `json:"audio,omitempty"`
-	File   string `json:"file,omitempty"`
-	Custom string `json:"custom,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Image     string `json:"image,omitempty"`
+	Video     string `json:"video,omitempty"`
+	Audio     string `json:"audio,omitempty"`
+	File      string `json:"file,omitempty"`
+	Custom    string `json:"custom,omitempty"`
+	Encrypted string `json:"encrypted,omitempty"` // JSON of EncryptedContent
+
+	ImageThumbnail string `json:"image_thumbnail,omitempty"`
+	ImageWidth     int    `json:"image_width,omitempty"`
+	ImageHeight    int    `json:"image_height,omitempty"`
+	AudioDuration  int    `json:"audio_duration,omitempty"`
+	AudioWaveform  []int  `json:"audio_waveform,omitempty"`
+
+	LinkPreview *LinkPreview       `json:"link_preview,omitempty"`
+	Mentions    []string           `json:"mentions,omitempty"`
+	Poll        *PollContent       `json:"poll,omitempty"`
+	Revoke      *RevokeContent     `json:"revoke,omitempty"`
+	Merged      *FlatMergedContent `json:"merged,omitempty"`
 }
 
 func NewMessageContentFromFlat(c FlatMessageContent) MessageContent {
 	content := MessageContent{}
 	if c.Text != "" {
-		content.Text = &TextContent{Text: c.Text}
+		content.Text = &TextContent{Text: c.Text, Preview: c.LinkPreview, Mentions: c.Mentions}
 	}
 	if c.Image != "" {
-		content.Image = &ImageContent{Url: c.Image}
+		content.Image = &ImageContent{Url: c.Image, ThumbnailUrl: c.ImageThumbnail, Width: c.ImageWidth, Height: c.ImageHeight}
 	}
 	if c.Video != "" {
 		content.Video = &VideoContent{Url: c.Video}
 	}
 	if c.Audio != "" {
-		content.Audio = &AudioContent{Url: c.Audio}
+		content.Audio = &AudioContent{Url: c.Audio, Duration: c.AudioDuration, Waveform: c.AudioWaveform}
 	}
 	if c.File != "" {
 		content.File = &FileContent{Url: c.File}
@@ -63,6 +176,21 @@ func NewMessageContentFromFlat(c FlatMessageContent) MessageContent {
 	if c.Custom != "" {
 		content.Custom = json.RawMessage(c.Custom)
 	}
+	if c.Encrypted != "" {
+		// Left unset when malformed, which fails the payload validation
+		var encrypted EncryptedContent
+		if json.Unmarshal([]byte(c.Encrypted), &encrypted) == nil {
+			content.Encrypted = &encrypted
+		}
+	}
+	content.Poll = c.Poll
+	content.Revoke = c.Revoke
+	if c.Merged != nil {
+		content.Merged = &MergedContent{Title: c.Merged.Title, Messages: make([]*MergedMessage, len(c.Merged.Messages))}
+		for i, m := range c.Merged.Messages {
+			content.Merged.Messages[i] = &MergedMessage{SenderId: m.SenderId, MsgType: m.MsgType, Content: NewMessageContentFromFlat(m.Content), SendAt: m.SendAt}
+		}
+	}
 	return content
 }
 
@@ -70,15 +198,20 @@ func (c MessageContent) ToFlat() FlatMessageContent {
 	flat := FlatMessageContent{}
 	if c.Text != nil {
 		flat.Text = c.Text.Text
+		flat.LinkPreview = c.Text.Preview
+		flat.Mentions = c.Text.Mentions
 	}
 	if c.Image != nil {
 		flat.Image = c.Image.Url
+		flat.ImageThumbnail = c.Image.ThumbnailUrl
+		flat.ImageWidth, flat.ImageHeight = c.Image.Width, c.Image.Height
 	}
 	if c.Video != nil {
 		flat.Video = c.Video.Url
 	}
 	if c.Audio != nil {
 		flat.Audio = c.Audio.Url
+		flat.AudioDuration, flat.AudioWaveform = c.Audio.Duration, c.Audio.Waveform
 	}
 	if c.File != nil {
 		flat.File = c.File.Url
@@ -86,6 +219,19 @@ func (c MessageContent) ToFlat() FlatMessageContent {
 	if len(c.Custom) > 0 {
 		flat.Custom = string(c.Custom)
 	}
+	if c.Encrypted != nil {
+		if b, err := json.Marshal(c.Encrypted); err == nil {
+			flat.Encrypted = string(b)
+		}
+	}
+	flat.Poll = c.Poll
+	flat.Revoke = c.Revoke
+	if c.Merged != nil {
+		flat.Merged = &FlatMergedContent{Title: c.Merged.Title, Messages: make([]*FlatMergedMessage, len(c.Merged.Messages))}
+		for i, m := range c.Merged.Messages {
+			flat.Merged.Messages[i] = &FlatMergedMessage{SenderId: m.SenderId, MsgType: m.MsgType, Content: m.Content.ToFlat(), SendAt: m.SendAt}
+		}
+	}
 	return flat
 }
 
@@ -109,6 +255,18 @@ func (c MessageContent) PayloadCount() int {
 	if len(c.Custom) > 0 {
 		count++
 	}
+	if c.Encrypted != nil {
+		count++
+	}
+	if c.Poll != nil {
+		count++
+	}
+	if c.Revoke != nil {
+		count++
+	}
+	if c.Merged != nil {
+		count++
+	}
 	return count
 }
 
@@ -124,10 +282,40 @@ type Message struct {
 	SessionType    int32          `json:"session_type" gorm:"column:session_type"`
 	MsgType        int32          `json:"msg_type" gorm:"column:msg_type"`
 	Content        MessageContent `json:"content" gorm:"column:content;type:json;serializer:json"`
+	ContentCodec   int32          `json:"-" gorm:"column:content_codec"`
+	ContentBlob    []byte         `json:"-" gorm:"column:content_blob"`
 	Extra          *string        `json:"extra" gorm:"column:extra;type:json"`
+	ContentHash    string         `json:"content_hash" gorm:"column:content_hash"`
 	SendAt         int64          `json:"send_at" gorm:"column:send_at"`
+	DeletedAt      int64          `json:"deleted_at" gorm:"column:deleted_at"`
+	RevokedAt      int64          `json:"revoked_at" gorm:"column:revoked_at"`     // recalled by the sender, content cleared
+	EditVersion    int32          `json:"edit_version" gorm:"column:edit_version"` // edits by the sender, see MessageEdit
+	QuoteSeq       int64          `json:"quote_seq" gorm:"column:quote_seq"`       // seq of the message the reply quotes, 0 if none
+	QuoteSnippet   string         `json:"-" gorm:"column:quote_snippet"`           // the quoted part of its text, empty for all of it
+	Forwarded      bool           `json:"forwarded" gorm:"column:forwarded"`       // a copy of another message, see ForwardMessages
+	ExpiresAt      int64          `json:"expires_at" gorm:"column:expires_at"`     // disappears at (ms), 0 = never, see SeqConversation.TTLSeconds
 	CreatedAt      int64          `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
 	UpdatedAt      int64          `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+	// ReadCount is the number of members other than the sender who read the message,
+	// aggregated when messages are pulled and not stored
+	ReadCount int64 `json:"read_count,omitempty" gorm:"-"`
+	// Reactions are the reactions to the message per emoji, aggregated for the requesting user
+	// when messages are pulled and not stored
+	Reactions []*ReactionSummary `json:"reactions,omitempty" gorm:"-"`
+	// Quote is the message the reply quotes as visible to the requesting user, resolved when
+	// messages are pulled or sent and not stored
+	Quote *QuotedMessage `json:"quoted_msg,omitempty" gorm:"-"`
+}
+
+// QuotedMessage is the message of the same conversation a reply quotes. Snippet is the quoted
+// part of its text; sender, type and snippet are left out once the quoted message is revoked,
+// deleted or not visible to the user, and the snippet once the text no longer contains it.
+type QuotedMessage struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	SenderId       string `json:"sender_id,omitempty"`
+	MsgType        int32  `json:"msg_type,omitempty"`
+	Snippet        string `json:"snippet,omitempty"`
 }
 
 // TableName returns the table name for Message
@@ -135,6 +323,81 @@ func (Message) TableName() string {
 	return "messages"
 }
 
+// ForDevice returns the message as delivered to a device: an encrypted message carries only
+// the ciphertext of the device, other messages are returned as is
+func (m *Message) ForDevice(userId string, deviceId int) *Message {
+	if m.Content.Encrypted == nil {
+		return m
+	}
+	copied := *m
+	copied.Content.Encrypted = m.Content.Encrypted.ForDevice(userId, deviceId)
+	if m.ContentHash != "" {
+		// The hash covers what the device receives
+		copied.ContentHash = copied.ComputeContentHash()
+	}
+	return &copied
+}
+
+// ComputeContentHash returns the integrity checksum of the message: the hex SHA-256 of its
+// msg_type, flat content fields and extra as delivered, each written as a netstring
+// "<length>:<value>," in the order msg_type, text, image, video, audio, file, custom,
+// encrypted, extra. A missing extra is written as an empty value. Images with a thumbnail or
+// dimensions are followed by image_thumbnail, image_width and image_height, so the hashes of
+// other messages do not depend on these fields. Likewise, audio with a duration or a waveform
+// is followed by audio_duration and audio_waveform, its samples joined with commas, and text
+// with a link preview by its url, title, description, image_url and site_name. A poll is
+// followed by its JSON encoding, a revoke notification by the revoked seq and a merged forward
+// by its JSON encoding.
+func (m *Message) ComputeContentHash() string {
+	flat := m.Content.ToFlat()
+	var extra string
+	if m.Extra != nil {
+		extra = *m.Extra
+	}
+	fields := []string{strconv.Itoa(int(m.MsgType)), flat.Text, flat.Image, flat.Video,
+		flat.Audio, flat.File, flat.Custom, flat.Encrypted, extra}
+	if flat.ImageThumbnail != "" || flat.ImageWidth != 0 || flat.ImageHeight != 0 {
+		fields = append(fields, flat.ImageThumbnail, strconv.Itoa(flat.ImageWidth), strconv.Itoa(flat.ImageHeight))
+	}
+	if flat.AudioDuration != 0 || len(flat.AudioWaveform) > 0 {
+		samples := make([]string, len(flat.AudioWaveform))
+		for i, sample := range flat.AudioWaveform {
+			samples[i] = strconv.Itoa(sample)
+		}
+		fields = append(fields, strconv.Itoa(flat.AudioDuration), strings.Join(samples, ","))
+	}
+	if p := flat.LinkPreview; p != nil {
+		fields = append(fields, p.Url, p.Title, p.Description, p.ImageUrl, p.SiteName)
+	}
+	if flat.Poll != nil {
+		poll, _ := json.Marshal(flat.Poll)
+		fields = append(fields, string(poll))
+	}
+	if flat.Revoke != nil {
+		fields = append(fields, strconv.FormatInt(flat.Revoke.Seq, 10))
+	}
+	if flat.Merged != nil {
+		merged, _ := json.Marshal(flat.Merged)
+		fields = append(fields, string(merged))
+	}
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(strconv.Itoa(len(field)) + ":" + field + ","))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyContentHash reports whether the message matches its stored checksum; messages stored
+// without one, such as deleted messages, are not checked
+func (m *Message) VerifyContentHash() bool {
+	return m.ContentHash == "" || m.ContentHash == m.ComputeContentHash()
+}
+
+// Disappeared reports whether the message disappeared at now (ms), see SeqConversation.TTLSeconds
+func (m *Message) Disappeared(now int64) bool {
+	return m.ExpiresAt > 0 && m.ExpiresAt <= now
+}
+
 // MessageInfo represents message info for API response
 type MessageInfo struct {
 	Id             int64              `json:"id"`
@@ -145,7 +408,17 @@ type MessageInfo struct {
 	SessionType    int32              `json:"session_type"`
 	MsgType        int32              `json:"msg_type"`
 	Content        FlatMessageContent `json:"content"`
+	Extra          *string            `json:"extra,omitempty"`
+	ContentHash    string             `json:"content_hash,omitempty"`
 	SendAt         int64              `json:"send_at"`
+	DeletedAt      int64              `json:"deleted_at,omitempty"`
+	RevokedAt      int64              `json:"revoked_at,omitempty"`
+	EditVersion    int32              `json:"edit_version,omitempty"`
+	Forwarded      bool               `json:"forwarded,omitempty"`
+	ExpiresAt      int64              `json:"expires_at,omitempty"`
+	ReadCount      int64              `json:"read_count,omitempty"`
+	Reactions      []*ReactionSummary `json:"reactions,omitempty"`
+	QuotedMsg      *QuotedMessage     `json:"quoted_msg,omitempty"`
 }
 
 // ToMessageInfo converts Message to MessageInfo
@@ -159,6 +432,16 @@ func (m *Message) ToMessageInfo() *MessageInfo {
 		SessionType:    m.SessionType,
 		MsgType:        m.MsgType,
 		Content:        m.Content.ToFlat(),
+		Extra:          m.Extra,
+		ContentHash:    m.ContentHash,
 		SendAt:         m.SendAt,
+		DeletedAt:      m.DeletedAt,
+		RevokedAt:      m.RevokedAt,
+		EditVersion:    m.EditVersion,
+		Forwarded:      m.Forwarded,
+		ExpiresAt:      m.ExpiresAt,
+		ReadCount:      m.ReadCount,
+		Reactions:      m.Reactions,
+		QuotedMsg:      m.Quote,
 	}
 }
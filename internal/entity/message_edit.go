@@ -0,0 +1,18 @@
+package entity
+
+// MessageEdit is a version of a message its sender replaced with /msg/edit. Version is the
+// edit_version the message had with this content, 0 for the content as sent.
+type MessageEdit struct {
+	Id             int64              `json:"-" gorm:"column:id;primaryKey;autoIncrement"`
+	ConversationId string             `json:"conversation_id" gorm:"column:conversation_id"`
+	Seq            int64              `json:"seq" gorm:"column:seq"`
+	SenderId       string             `json:"-" gorm:"column:sender_id"`
+	Version        int32              `json:"version" gorm:"column:version"`
+	Content        FlatMessageContent `json:"content" gorm:"column:content;type:json;serializer:json"`
+	EditedAt       int64              `json:"edited_at" gorm:"column:edited_at"` // when this version was replaced
+}
+
+// TableName returns the table name for MessageEdit
+func (MessageEdit) TableName() string {
+	return "message_edits"
+}
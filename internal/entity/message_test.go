@@ -2,6 +2,7 @@ package entity
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -39,3 +40,164 @@ func TestMessageToMessageInfoFlattensTextContent(t *testing.T) {
 		t.Fatalf("expected text content to be flattened, got %q", info.Content.Text)
 	}
 }
+
+func TestMessageForDeviceKeepsOwnCiphertext(t *testing.T) {
+	flat := FlatMessageContent{Encrypted: `{"sender_device_id":1,"ciphertexts":[` +
+		`{"user_id":"u1","device_id":1,"type":1,"body":"YQ=="},` +
+		`{"user_id":"u2","device_id":2,"type":3,"body":"Yg=="}]}`}
+	msg := &Message{MsgType: 6, Content: NewMessageContentFromFlat(flat)}
+
+	got := msg.ForDevice("u2", 2).Content.Encrypted
+	if len(got.Ciphertexts) != 1 || got.Ciphertexts[0].Body != "Yg==" || got.SenderDeviceId != 1 {
+		t.Fatalf("unexpected ciphertexts for device %+v", got)
+	}
+	if len(msg.Content.Encrypted.Ciphertexts) != 2 {
+		t.Fatal("expected the message itself to be left unchanged")
+	}
+	if info := msg.ForDevice("u3", 1).ToMessageInfo(); info.Content.Encrypted != `{"sender_device_id":1,"ciphertexts":[]}` {
+		t.Fatalf("unexpected content for another device %q", info.Content.Encrypted)
+	}
+}
+
+func TestMessageContentHash(t *testing.T) {
+	extra := `{"k":"v"}`
+	msg := &Message{MsgType: 1, Content: MessageContent{Text: &TextContent{Text: "hello"}}, Extra: &extra}
+	msg.ContentHash = msg.ComputeContentHash()
+	// sha256 of "1:1,5:hello,0:,0:,0:,0:,0:,0:,9:{"k":"v"},"
+	if msg.ContentHash != "f31e2e9a54e874fda6415b777f540e30bc6611b8671946a86436060e33c2a6a8" {
+		t.Fatalf("unexpected hash %q", msg.ContentHash)
+	}
+	if !msg.VerifyContentHash() {
+		t.Fatal("expected the stored hash to verify")
+	}
+
+	msg.Content.Text.Text = "hellp"
+	if msg.VerifyContentHash() {
+		t.Fatal("expected altered content to fail verification")
+	}
+	msg.Content.Text.Text = "hello"
+	msg.Extra = nil
+	if msg.VerifyContentHash() {
+		t.Fatal("expected altered extra to fail verification")
+	}
+
+	msg.ContentHash = ""
+	if !msg.VerifyContentHash() {
+		t.Fatal("expected a message without hash to be unchecked")
+	}
+}
+
+func TestMessageContentHashCoversImageMetadata(t *testing.T) {
+	msg := &Message{MsgType: 2, Content: MessageContent{Image: &ImageContent{Url: "https://cdn/a.jpg"}}}
+	plain := msg.ComputeContentHash()
+	flat := msg.Content.ToFlat()
+	if flat.ImageThumbnail != "" || flat.ImageWidth != 0 {
+		t.Fatalf("unexpected image metadata %+v", flat)
+	}
+
+	msg.Content.Image = &ImageContent{Url: "https://cdn/a.jpg", ThumbnailUrl: "https://cdn/a_thumb.jpg", Width: 640, Height: 480}
+	described := msg.ComputeContentHash()
+	if described == plain {
+		t.Fatal("expected the thumbnail to change the hash")
+	}
+	msg.Content.Image.Width = 641
+	if msg.ComputeContentHash() == described {
+		t.Fatal("expected the dimensions to change the hash")
+	}
+
+	content := NewMessageContentFromFlat(msg.Content.ToFlat())
+	if *content.Image != *msg.Content.Image {
+		t.Fatalf("unexpected round trip %+v", content.Image)
+	}
+}
+
+func TestMessageContentHashCoversAudioMetadata(t *testing.T) {
+	msg := &Message{MsgType: 4, Content: MessageContent{Audio: &AudioContent{Url: "https://cdn/a.ogg"}}}
+	plain := msg.ComputeContentHash()
+
+	msg.Content.Audio = &AudioContent{Url: "https://cdn/a.ogg", Duration: 3200, Waveform: []int{0, 12, 255}}
+	described := msg.ComputeContentHash()
+	if described == plain {
+		t.Fatal("expected the duration to change the hash")
+	}
+	msg.Content.Audio.Waveform = []int{0, 122, 55}
+	if msg.ComputeContentHash() == described {
+		t.Fatal("expected the waveform samples to change the hash")
+	}
+
+	content := NewMessageContentFromFlat(msg.Content.ToFlat())
+	if !reflect.DeepEqual(content.Audio, msg.Content.Audio) {
+		t.Fatalf("unexpected round trip %+v", content.Audio)
+	}
+}
+
+func TestMessageContentHashCoversLinkPreview(t *testing.T) {
+	msg := &Message{MsgType: 1, Content: MessageContent{Text: &TextContent{Text: "see https://example.com"}}}
+	plain := msg.ComputeContentHash()
+
+	msg.Content.Text.Preview = &LinkPreview{Url: "https://example.com", Title: "Example"}
+	described := msg.ComputeContentHash()
+	if described == plain {
+		t.Fatal("expected the preview to change the hash")
+	}
+	msg.Content.Text.Preview.Title = "Changed"
+	if msg.ComputeContentHash() == described {
+		t.Fatal("expected the preview title to change the hash")
+	}
+
+	content := NewMessageContentFromFlat(msg.Content.ToFlat())
+	if !reflect.DeepEqual(content.Text, msg.Content.Text) {
+		t.Fatalf("unexpected round trip %+v", content.Text)
+	}
+}
+
+func TestMessageContentHashCoversPoll(t *testing.T) {
+	msg := &Message{MsgType: 7, Content: MessageContent{Poll: &PollContent{Question: "Lunch?", Options: []string{"Pizza", "Sushi"}}}}
+	hash := msg.ComputeContentHash()
+
+	msg.Content.Poll.Options[1] = "Ramen"
+	if msg.ComputeContentHash() == hash {
+		t.Fatal("expected the poll options to change the hash")
+	}
+
+	content := NewMessageContentFromFlat(msg.Content.ToFlat())
+	if !reflect.DeepEqual(content.Poll, msg.Content.Poll) || content.PayloadCount() != 1 {
+		t.Fatalf("unexpected round trip %+v", content.Poll)
+	}
+}
+
+func TestMessageContentHashCoversRevoke(t *testing.T) {
+	msg := &Message{MsgType: 8, Content: MessageContent{Revoke: &RevokeContent{Seq: 3}}}
+	hash := msg.ComputeContentHash()
+
+	msg.Content.Revoke.Seq = 4
+	if msg.ComputeContentHash() == hash {
+		t.Fatal("expected the revoked seq to change the hash")
+	}
+
+	content := NewMessageContentFromFlat(msg.Content.ToFlat())
+	if content.Revoke == nil || content.Revoke.Seq != 4 || content.PayloadCount() != 1 {
+		t.Fatalf("unexpected round trip %+v", content.Revoke)
+	}
+}
+
+func TestMessageContentHashCoversMerged(t *testing.T) {
+	msg := &Message{MsgType: 9, Content: MessageContent{Merged: &MergedContent{
+		Title: "Chat history",
+		Messages: []*MergedMessage{
+			{SenderId: "alice", MsgType: 1, Content: MessageContent{Text: &TextContent{Text: "hi"}}, SendAt: 1},
+			{SenderId: "bob", MsgType: 2, Content: MessageContent{Image: &ImageContent{Url: "https://cdn/a.png", Width: 10}}, SendAt: 2},
+		},
+	}}}
+	hash := msg.ComputeContentHash()
+
+	msg.Content.Merged.Messages[0].Content.Text.Text = "hello"
+	if msg.ComputeContentHash() == hash {
+		t.Fatal("expected the merged messages to change the hash")
+	}
+
+	content := NewMessageContentFromFlat(msg.Content.ToFlat())
+	if !reflect.DeepEqual(content.Merged, msg.Content.Merged) || content.PayloadCount() != 1 {
+		t.Fatalf("unexpected round trip %+v", content.Merged)
+	}
+}
@@ -0,0 +1,66 @@
+package entity
+
+// NotificationSettings are the notification preferences of a user. Platform overrides
+// replace the flags they set on the devices of that platform.
+type NotificationSettings struct {
+	UserId            string                        `json:"-" gorm:"column:user_id;primaryKey"`
+	GlobalMute        bool                          `json:"global_mute" gorm:"column:global_mute"`
+	Sound             bool                          `json:"sound" gorm:"column:sound"`
+	Vibrate           bool                          `json:"vibrate" gorm:"column:vibrate"`
+	ShowPreview       bool                          `json:"show_preview" gorm:"column:show_preview"`
+	PlatformOverrides map[int]*NotificationOverride `json:"platform_overrides,omitempty" gorm:"column:platform_overrides;type:json;serializer:json"` // by platform id
+	UpdatedAt         int64                         `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for NotificationSettings
+func (NotificationSettings) TableName() string {
+	return "user_notification_settings"
+}
+
+// NotificationOverride sets some notification flags on one platform, nil flags follow the
+// global settings
+type NotificationOverride struct {
+	GlobalMute  *bool `json:"global_mute,omitempty"`
+	Sound       *bool `json:"sound,omitempty"`
+	Vibrate     *bool `json:"vibrate,omitempty"`
+	ShowPreview *bool `json:"show_preview,omitempty"`
+}
+
+// DefaultNotificationSettings returns the settings of users who never changed them
+func DefaultNotificationSettings(userId string) *NotificationSettings {
+	return &NotificationSettings{UserId: userId, Sound: true, Vibrate: true, ShowPreview: true}
+}
+
+// IsDefault reports whether notifications behave as for users who never changed them
+func (s *NotificationSettings) IsDefault() bool {
+	return !s.GlobalMute && s.Sound && s.Vibrate && s.ShowPreview
+}
+
+// ForPlatform returns the flags effective on a platform, without overrides
+func (s *NotificationSettings) ForPlatform(platformId int) *NotificationSettings {
+	effective := &NotificationSettings{
+		UserId:      s.UserId,
+		GlobalMute:  s.GlobalMute,
+		Sound:       s.Sound,
+		Vibrate:     s.Vibrate,
+		ShowPreview: s.ShowPreview,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	override := s.PlatformOverrides[platformId]
+	if override == nil {
+		return effective
+	}
+	if override.GlobalMute != nil {
+		effective.GlobalMute = *override.GlobalMute
+	}
+	if override.Sound != nil {
+		effective.Sound = *override.Sound
+	}
+	if override.Vibrate != nil {
+		effective.Vibrate = *override.Vibrate
+	}
+	if override.ShowPreview != nil {
+		effective.ShowPreview = *override.ShowPreview
+	}
+	return effective
+}
@@ -0,0 +1,23 @@
+package entity
+
+// PinnedMessage is a message pinned in a conversation, shared by all its participants
+type PinnedMessage struct {
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id;primaryKey"`
+	Seq            int64  `json:"seq" gorm:"column:seq;primaryKey"`
+	PinnedBy       string `json:"pinned_by" gorm:"column:pinned_by"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for PinnedMessage
+func (PinnedMessage) TableName() string {
+	return "pinned_messages"
+}
+
+// PinChange is a message pinned or unpinned in a conversation by UserId, pushed to the
+// participants of the conversation
+type PinChange struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	UserId         string `json:"user_id"`
+	Pinned         bool   `json:"pinned"`
+}
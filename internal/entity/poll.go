@@ -0,0 +1,32 @@
+package entity
+
+// PollVote is the vote of a user for one option of a poll message; a multi-choice vote is
+// one row per option
+type PollVote struct {
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id;primaryKey"`
+	Seq            int64  `json:"seq" gorm:"column:seq;primaryKey"`
+	UserId         string `json:"user_id" gorm:"column:user_id;primaryKey"`
+	Option         int    `json:"option" gorm:"column:option_index;primaryKey"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for PollVote
+func (PollVote) TableName() string {
+	return "poll_votes"
+}
+
+// PollResult is the tally of a poll message
+type PollResult struct {
+	ConversationId string              `json:"conversation_id"`
+	Seq            int64               `json:"seq"`
+	Options        []*PollOptionResult `json:"options"`
+	VoterCount     int64               `json:"voter_count"` // distinct voters
+	// MyOptions are the options the requesting user voted for, left out of pushed updates
+	MyOptions []int `json:"my_options,omitempty"`
+}
+
+// PollOptionResult is the tally of one option of a poll
+type PollOptionResult struct {
+	Count    int64    `json:"count"`
+	VoterIds []string `json:"voter_ids,omitempty"` // left out for anonymous polls
+}
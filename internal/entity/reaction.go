@@ -0,0 +1,34 @@
+package entity
+
+// MessageReaction is an emoji reaction of a user to a message; a user may react to a message
+// with several emojis, one row each
+type MessageReaction struct {
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id;primaryKey"`
+	Seq            int64  `json:"seq" gorm:"column:seq;primaryKey"`
+	UserId         string `json:"user_id" gorm:"column:user_id;primaryKey"`
+	Emoji          string `json:"emoji" gorm:"column:emoji;primaryKey"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for MessageReaction
+func (MessageReaction) TableName() string {
+	return "message_reactions"
+}
+
+// ReactionSummary aggregates the reactions to a message with one emoji
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted,omitempty"` // the requesting user reacted with this emoji
+}
+
+// ReactionChange is a reaction added to or removed from a message, pushed to the members of
+// its conversation. Count is the number of reactions with the emoji after the change.
+type ReactionChange struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	UserId         string `json:"user_id"`
+	Emoji          string `json:"emoji"`
+	Added          bool   `json:"added"`
+	Count          int64  `json:"count"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+// MessageReminder asks for a message to be brought back to a user at RemindAt, as a
+// message in their system notification conversation
+type MessageReminder struct {
+	Id             int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId         string `json:"-" gorm:"column:user_id"`
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id"`
+	Seq            int64  `json:"seq" gorm:"column:seq"`
+	Note           string `json:"note,omitempty" gorm:"column:note"`
+	RemindAt       int64  `json:"remind_at" gorm:"column:remind_at"`
+	Status         int32  `json:"status" gorm:"column:status"`
+	SentAt         int64  `json:"sent_at,omitempty" gorm:"column:sent_at"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt      int64  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for MessageReminder
+func (MessageReminder) TableName() string {
+	return "message_reminders"
+}
+
+// ReminderContent is the custom content payload of a reminder message
+type ReminderContent struct {
+	Type           string       `json:"type"` // always "reminder"
+	ReminderId     int64        `json:"reminder_id"`
+	ConversationId string       `json:"conversation_id"`
+	Seq            int64        `json:"seq"`
+	Note           string       `json:"note,omitempty"`
+	Message        *MessageInfo `json:"message,omitempty"` // the original, unless deleted since
+}
@@ -5,6 +5,9 @@ type SeqConversation struct {
 	ConversationId string `json:"conversation_id" gorm:"column:conversation_id;primaryKey"`
 	MaxSeq         int64  `json:"max_seq" gorm:"column:max_seq"`
 	MinSeq         int64  `json:"min_seq" gorm:"column:min_seq"`
+	// TTLSeconds is the disappearing messages timer shared by the participants: messages sent
+	// while it is set expire that long after they were sent. 0 means off.
+	TTLSeconds int32 `json:"ttl_seconds" gorm:"column:ttl_seconds"`
 }
 
 // TableName returns the table name for SeqConversation
@@ -20,6 +23,7 @@ type SeqUser struct {
 	MinSeq         int64  `json:"min_seq" gorm:"column:min_seq"`
 	MaxSeq         int64  `json:"max_seq" gorm:"column:max_seq"`
 	ReadSeq        int64  `json:"read_seq" gorm:"column:read_seq"`
+	DeliveredSeq   int64  `json:"delivered_seq" gorm:"column:delivered_seq"`
 }
 
 // TableName returns the table name for SeqUser
@@ -0,0 +1,43 @@
+package entity
+
+// DailyStats represents operational statistics of a single day
+type DailyStats struct {
+	Date          string `json:"date"` // yyyymmdd
+	MessageCount  int64  `json:"message_count"`
+	ActiveUsers   int64  `json:"active_users"` // approximate (HyperLogLog)
+	Registrations int64  `json:"registrations"`
+	GroupsCreated int64  `json:"groups_created"`
+	OnlinePeak    int64  `json:"online_peak"`
+}
+
+// ConversationUsage is the usage of a conversation on a day
+type ConversationUsage struct {
+	Date          string `json:"date"` // yyyymmdd
+	MessageCount  int64  `json:"message_count"`
+	ActiveSenders int64  `json:"active_senders"` // approximate (HyperLogLog)
+	MediaBytes    int64  `json:"media_bytes"`    // uploads of the managed storage sent
+}
+
+// UserUsage is the usage of a user on a day
+type UserUsage struct {
+	Date          string `json:"date"` // yyyymmdd
+	MessageCount  int64  `json:"message_count"`
+	Conversations int64  `json:"conversations"` // written to, approximate (HyperLogLog)
+	MediaBytes    int64  `json:"media_bytes"`   // uploads of the managed storage sent
+}
+
+// UsageRank is an entry of the daily ranking of conversations or senders by messages
+type UsageRank struct {
+	Id           string `json:"id"` // conversation or user id
+	MessageCount int64  `json:"message_count"`
+}
+
+// GroupDailyReport is the engagement of a group on a day
+type GroupDailyReport struct {
+	Date          string `json:"date"` // yyyymmdd
+	MessageCount  int64  `json:"message_count"`
+	ActiveMembers int64  `json:"active_members"` // members who sent messages
+	MediaBytes    int64  `json:"media_bytes"`
+	Joins         int64  `json:"joins"`
+	Leaves        int64  `json:"leaves"` // members who quit or were kicked
+}
@@ -0,0 +1,42 @@
+package entity
+
+// Upload is an object a user uploads to the storage with a presigned URL, or one per part for
+// large files. It is pending until the user confirms it once the object is uploaded.
+// Confirmed images are processed in the background for their dimensions and a thumbnail; the
+// duration of confirmed audio is read on confirmation.
+type Upload struct {
+	Id           int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId       string `json:"user_id" gorm:"column:user_id"`
+	ObjectKey    string `json:"object_key" gorm:"column:object_key"`
+	FileName     string `json:"file_name" gorm:"column:file_name"`
+	ContentType  string `json:"content_type" gorm:"column:content_type"`
+	Size         int64  `json:"size" gorm:"column:size"`
+	Status       int    `json:"status" gorm:"column:status"`             // see constant.UploadStatus*
+	MediaStatus  int    `json:"media_status" gorm:"column:media_status"` // see constant.UploadMedia*
+	Width        int    `json:"width" gorm:"column:width"`               // images, as displayed
+	Height       int    `json:"height" gorm:"column:height"`             // images, as displayed
+	ThumbnailKey string `json:"thumbnail_key" gorm:"column:thumbnail_key"`
+	Duration     int    `json:"duration" gorm:"column:duration"`         // audio, milliseconds
+	MultipartId  string `json:"multipart_id" gorm:"column:multipart_id"` // storage id of a multipart upload, empty for a single request
+	CreatedAt    int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt    int64  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for Upload
+func (Upload) TableName() string {
+	return "uploads"
+}
+
+// UploadRef records that an upload was sent in a conversation, whose participants may then
+// download it through the download proxy
+type UploadRef struct {
+	Id             int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UploadId       int64  `json:"upload_id" gorm:"column:upload_id"`
+	ConversationId string `json:"conversation_id" gorm:"column:conversation_id"`
+	CreatedAt      int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for UploadRef
+func (UploadRef) TableName() string {
+	return "upload_refs"
+}
@@ -1,14 +1,34 @@
 package entity
 
+import "github.com/ZaiSpace/nexo_im/pkg/constant"
+
 // User represents a user in the system
 type User struct {
-	Id        string  `json:"id" gorm:"column:id;primaryKey"`
-	Nickname  string  `json:"nickname" gorm:"column:nickname"`
-	Avatar    string  `json:"avatar" gorm:"column:avatar"`
-	Password  string  `json:"-" gorm:"column:password"`
-	Extra     *string `json:"extra" gorm:"column:extra;type:json"`
-	CreatedAt int64   `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt int64   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+	Id         string  `json:"id" gorm:"column:id;primaryKey"`
+	Nickname   string  `json:"nickname" gorm:"column:nickname"`
+	Handle     *string `json:"handle,omitempty" gorm:"column:handle"` // unique lowercase name users search by
+	Avatar     string  `json:"avatar" gorm:"column:avatar"`
+	Gender     int32   `json:"gender" gorm:"column:gender"`     // constant.Gender*
+	Birthday   string  `json:"birthday" gorm:"column:birthday"` // YYYY-MM-DD, "" when unset
+	Region     string  `json:"region" gorm:"column:region"`
+	Ext        *string `json:"ext" gorm:"column:ext;type:json"` // JSON object of custom profile attributes
+	Password   string  `json:"-" gorm:"column:password"`
+	Extra      *string `json:"extra" gorm:"column:extra;type:json"`
+	Email      *string `json:"email,omitempty" gorm:"column:email"`
+	Phone      *string `json:"phone,omitempty" gorm:"column:phone"`
+	VerifiedAt int64   `json:"verified_at" gorm:"column:verified_at"`
+	IsBot      bool    `json:"is_bot" gorm:"column:is_bot"`
+	// ProfilePrivacy maps optional profile fields (constant.ProfileField*) to their visibility,
+	// fields missing are public
+	ProfilePrivacy map[string]string `json:"profile_privacy,omitempty" gorm:"column:profile_privacy;type:json;serializer:json"`
+	// HideFromSearch opts the user out of /user/search
+	HideFromSearch bool `json:"hide_from_search" gorm:"column:hide_from_search"`
+	// GuestExpiresAt is set for temporary guest accounts and cleared when they are upgraded
+	GuestExpiresAt int64 `json:"guest_expires_at,omitempty" gorm:"column:guest_expires_at"`
+	Status         int32 `json:"status" gorm:"column:status"`
+	DeletedAt      int64 `json:"deleted_at" gorm:"column:deleted_at"`
+	CreatedAt      int64 `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt      int64 `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
 }
 
 // TableName returns the table name for User
@@ -16,22 +36,100 @@ func (User) TableName() string {
 	return "users"
 }
 
+// IsDeleted checks if the user has been tombstoned by a data deletion request
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt > 0
+}
+
+// IsBanned checks if the user has been disabled by an admin
+func (u *User) IsBanned() bool {
+	return u.Status == constant.UserStatusBanned
+}
+
+// IsGuest checks if the user is a temporary guest account
+func (u *User) IsGuest() bool {
+	return u.GuestExpiresAt > 0
+}
+
+// IsGuestExpired checks if the user is a guest past its expiry, now in Unix milliseconds
+func (u *User) IsGuestExpired(now int64) bool {
+	return u.IsGuest() && now >= u.GuestExpiresAt
+}
+
+// NeedsVerification checks if the user registered with an email or phone that is not verified yet
+func (u *User) NeedsVerification() bool {
+	return (u.Email != nil || u.Phone != nil) && u.VerifiedAt == 0
+}
+
+// ProfileVisible reports whether an optional profile field is shown to users other than u
+func (u *User) ProfileVisible(field string) bool {
+	return u.ProfilePrivacy[field] != constant.ProfileVisibilityPrivate
+}
+
 // UserInfo represents public user info (without password)
 type UserInfo struct {
 	Id        string  `json:"id"`
 	Nickname  string  `json:"nickname"`
+	Handle    string  `json:"handle,omitempty"`
 	Avatar    string  `json:"avatar"`
+	Gender    int32   `json:"gender,omitempty"`
+	Birthday  string  `json:"birthday,omitempty"`
+	Region    string  `json:"region,omitempty"`
+	Ext       *string `json:"ext,omitempty"`
 	Extra     *string `json:"extra,omitempty"`
+	IsBot     bool    `json:"is_bot,omitempty"`
+	IsGuest   bool    `json:"is_guest,omitempty"`
 	CreatedAt int64   `json:"created_at"`
+	// ProfilePrivacy is the visibility of the optional profile fields, only shown to the user
+	ProfilePrivacy map[string]string `json:"profile_privacy,omitempty"`
+	// HideFromSearch is the search opt-out, only shown to the user
+	HideFromSearch bool `json:"hide_from_search,omitempty"`
 }
 
-// ToUserInfo converts User to UserInfo
+// ToUserInfo converts User to UserInfo as seen by the user: all fields and their visibility
 func (u *User) ToUserInfo() *UserInfo {
+	var handle string
+	if u.Handle != nil {
+		handle = *u.Handle
+	}
 	return &UserInfo{
-		Id:        u.Id,
-		Nickname:  u.Nickname,
-		Avatar:    u.Avatar,
-		Extra:     u.Extra,
-		CreatedAt: u.CreatedAt,
+		Id:             u.Id,
+		Nickname:       u.Nickname,
+		Handle:         handle,
+		Avatar:         u.Avatar,
+		Gender:         u.Gender,
+		Birthday:       u.Birthday,
+		Region:         u.Region,
+		Ext:            u.Ext,
+		Extra:          u.Extra,
+		IsBot:          u.IsBot,
+		IsGuest:        u.IsGuest(),
+		CreatedAt:      u.CreatedAt,
+		ProfilePrivacy: u.ProfilePrivacy,
+		HideFromSearch: u.HideFromSearch,
+	}
+}
+
+// ToUserInfoFor converts User to UserInfo as seen by viewerId: other users do not see the
+// private optional fields nor the visibility settings
+func (u *User) ToUserInfoFor(viewerId string) *UserInfo {
+	info := u.ToUserInfo()
+	if viewerId == u.Id {
+		return info
+	}
+	info.ProfilePrivacy = nil
+	info.HideFromSearch = false
+	if !u.ProfileVisible(constant.ProfileFieldGender) {
+		info.Gender = constant.GenderUnknown
+	}
+	if !u.ProfileVisible(constant.ProfileFieldBirthday) {
+		info.Birthday = ""
+	}
+	if !u.ProfileVisible(constant.ProfileFieldRegion) {
+		info.Region = ""
+	}
+	if !u.ProfileVisible(constant.ProfileFieldExt) {
+		info.Ext = nil
 	}
+	return info
 }
@@ -0,0 +1,15 @@
+package entity
+
+// UserIdentity links an account at an external identity provider to an IM user
+type UserIdentity struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Provider  string `json:"provider" gorm:"column:provider"` // configured provider name
+	Subject   string `json:"subject" gorm:"column:subject"`   // user id at the provider
+	UserId    string `json:"user_id" gorm:"column:user_id"`
+	CreatedAt int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for UserIdentity
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
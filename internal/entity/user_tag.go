@@ -0,0 +1,14 @@
+package entity
+
+// UserTag is a tag set on a user by internal services, selecting the user for broadcasts
+// targeted at the tag
+type UserTag struct {
+	UserId    string `json:"user_id" gorm:"column:user_id;primaryKey"`
+	Tag       string `json:"tag" gorm:"column:tag;primaryKey"`
+	CreatedAt int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+// TableName returns the table name for UserTag
+func (UserTag) TableName() string {
+	return "user_tags"
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+func TestUserInfoForHidesPrivateFields(t *testing.T) {
+	ext := `{"company":"acme"}`
+	user := &User{
+		Id:       "u1",
+		Gender:   constant.GenderFemale,
+		Birthday: "1990-05-01",
+		Region:   "CN-SH",
+		Ext:      &ext,
+		ProfilePrivacy: map[string]string{
+			constant.ProfileFieldBirthday: constant.ProfileVisibilityPrivate,
+			constant.ProfileFieldExt:      constant.ProfileVisibilityPrivate,
+		},
+	}
+
+	self := user.ToUserInfoFor("u1")
+	if self.Birthday != "1990-05-01" || self.Ext == nil || self.ProfilePrivacy == nil {
+		t.Fatalf("expected the user to see every field, got %+v", self)
+	}
+
+	other := user.ToUserInfoFor("u2")
+	if other.Birthday != "" || other.Ext != nil || other.ProfilePrivacy != nil {
+		t.Fatalf("expected private fields hidden from others, got %+v", other)
+	}
+	if other.Gender != constant.GenderFemale || other.Region != "CN-SH" {
+		t.Fatalf("expected public fields shown to others, got %+v", other)
+	}
+}
@@ -0,0 +1,17 @@
+package entity
+
+// VoIPToken is the VoIP push token of an app, used to ring offline callees
+type VoIPToken struct {
+	Id         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId     string `json:"user_id" gorm:"column:user_id"`
+	PlatformId int    `json:"platform_id" gorm:"column:platform_id"`
+	Provider   string `json:"provider" gorm:"column:provider"` // apns (PushKit) or fcm
+	Token      string `json:"token" gorm:"column:token"`
+	CreatedAt  int64  `json:"created_at" gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt  int64  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime:milli"`
+}
+
+// TableName returns the table name for VoIPToken
+func (VoIPToken) TableName() string {
+	return "voip_tokens"
+}
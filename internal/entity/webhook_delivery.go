@@ -0,0 +1,21 @@
+package entity
+
+// WebhookDelivery records the final outcome of delivering one event to one webhook endpoint
+type WebhookDelivery struct {
+	Id         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	EventId    string `json:"event_id" gorm:"column:event_id"`
+	EventType  string `json:"event_type" gorm:"column:event_type"`
+	Endpoint   string `json:"endpoint" gorm:"column:endpoint"`
+	Result     string `json:"result" gorm:"column:result"`
+	Attempts   int    `json:"attempts" gorm:"column:attempts"`
+	StatusCode int    `json:"status_code" gorm:"column:status_code"`
+	Error      string `json:"error" gorm:"column:error"`
+	TraceId    string `json:"trace_id" gorm:"column:trace_id"`
+	CreatedAt  int64  `json:"created_at" gorm:"column:created_at"`
+	FinishedAt int64  `json:"finished_at" gorm:"column:finished_at"`
+}
+
+// TableName returns the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
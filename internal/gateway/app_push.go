@@ -3,6 +3,8 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -34,6 +36,11 @@ type AppPushSender interface {
 	SendPush(ctx context.Context, req *AppPushRequest) error
 }
 
+// AppPushPinger is implemented by senders that can probe their push provider
+type AppPushPinger interface {
+	Ping(ctx context.Context) error
+}
+
 type AppPushUserInfoProvider interface {
 	GetUserDisplayName(ctx context.Context, userId int64) (string, error)
 }
@@ -225,6 +232,29 @@ func (s *appGatewayPushSender) GetUserDisplayName(ctx context.Context, userId in
 	return strings.TrimSpace(resp.Data.UserInfo.UniqueId), nil
 }
 
+// Ping checks that the push gateway accepts TCP connections
+func (s *appGatewayPushSender) Ping(ctx context.Context) error {
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid push gateway url: %w", err)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 func parseUserId(raw string) (int64, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
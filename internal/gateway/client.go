@@ -7,6 +7,13 @@ import (
 	"sync/atomic"
 
 	"github.com/mbeoliero/kit/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
 )
 
 // Client represents a connected WebSocket client
@@ -17,6 +24,7 @@ type Client struct {
 	PlatformId int
 	SDKType    string
 	Token      string
+	Scopes     []string // scopes of the token, nil if unrestricted
 	ConnId     string
 	server     *WsServer
 	closed     atomic.Bool
@@ -91,27 +99,74 @@ func (c *Client) handleMessage(message []byte) error {
 
 	log.CtxDebug(c.ctx, "received message: req_identifier=%d, user_id=%s", req.ReqIdentifier, c.UserId)
 
+	if !c.allows(req.ReqIdentifier) {
+		return c.replyError(&req, ErrNoPermission)
+	}
+
+	ctx, span := c.startRequestSpan(&req)
+
 	var resp []byte
 	var err error
 
 	switch req.ReqIdentifier {
 	case WSGetNewestSeq:
-		resp, err = c.server.HandleGetNewestSeq(c.ctx, c, &req)
+		resp, err = c.server.HandleGetNewestSeq(ctx, c, &req)
 	case WSSendMsg:
-		resp, err = c.server.HandleSendMsg(c.ctx, c, &req)
+		resp, err = c.server.HandleSendMsg(ctx, c, &req)
 	case WSPullMsgBySeqList:
-		resp, err = c.server.HandlePullMsgBySeqList(c.ctx, c, &req)
+		resp, err = c.server.HandlePullMsgBySeqList(ctx, c, &req)
 	case WSPullMsg:
-		resp, err = c.server.HandlePullMsg(c.ctx, c, &req)
+		resp, err = c.server.HandlePullMsg(ctx, c, &req)
 	case WSGetConvMaxReadSeq:
-		resp, err = c.server.HandleGetConvMaxReadSeq(c.ctx, c, &req)
+		resp, err = c.server.HandleGetConvMaxReadSeq(ctx, c, &req)
+	case WSSendSignal:
+		resp, err = c.server.HandleSendSignal(ctx, c, &req)
+	case WSSendTyping:
+		resp, err = c.server.HandleSendTyping(ctx, c, &req)
 	default:
-		return c.replyError(&req, ErrInvalidProtocol)
+		err = ErrInvalidProtocol
+		tracing.End(span, err)
+		return c.replyError(&req, err)
 	}
 
+	tracing.End(span, err)
 	return c.reply(&req, err, resp)
 }
 
+// allows checks the request against the token scopes: sending needs the msg scope,
+// reads need read access to the group the data belongs to
+func (c *Client) allows(reqIdentifier int32) bool {
+	if len(c.Scopes) == 0 {
+		return true
+	}
+	switch reqIdentifier {
+	case WSSendMsg, WSSendSignal, WSSendTyping:
+		return scope.Allows(c.Scopes, scope.Msg, true)
+	case WSGetConvMaxReadSeq:
+		return scope.Allows(c.Scopes, scope.Conversation, false)
+	default:
+		return scope.Allows(c.Scopes, scope.Msg, false)
+	}
+}
+
+// startRequestSpan starts the span of a single WS request.
+// A traceparent in the request takes precedence over the connection's handshake trace,
+// and a request operation_id overrides the connection trace_id.
+func (c *Client) startRequestSpan(req *WSRequest) (context.Context, trace.Span) {
+	ctx := c.ctx
+	if req.Traceparent != "" {
+		ctx = tracing.Extract(ctx, propagation.MapCarrier{"traceparent": req.Traceparent})
+	}
+	if req.OperationId != "" {
+		ctx = middleware.WithTraceID(ctx, req.OperationId)
+	}
+	return tracing.Start(ctx, "ws.request",
+		attribute.Int("nexo.ws.req_identifier", int(req.ReqIdentifier)),
+		attribute.String("nexo.user_id", c.UserId),
+		attribute.String("nexo.conn_id", c.ConnId),
+	)
+}
+
 // reply sends a response to the client
 func (c *Client) reply(req *WSRequest, err error, data []byte) error {
 	resp := WSResponse{
@@ -183,6 +238,17 @@ func (c *Client) PushMessage(ctx context.Context, msg *MessageData) error {
 	return c.writeResponse(resp)
 }
 
+// PushEvent pushes a non-message notification to the client
+func (c *Client) PushEvent(reqIdentifier int32, data []byte) error {
+	if c.closed.Load() {
+		return ErrConnClosed
+	}
+	return c.writeResponse(WSResponse{
+		ReqIdentifier: reqIdentifier,
+		Data:          data,
+	})
+}
+
 // KickOnline sends kick message and closes connection
 func (c *Client) KickOnline() error {
 	resp := WSResponse{
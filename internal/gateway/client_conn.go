@@ -24,7 +24,6 @@ type WebsocketClientConn struct {
 	writeMu    sync.Mutex
 	closeOnce  sync.Once
 	closed     bool
-	closeChan  chan struct{}
 	pingPeriod time.Duration
 	pongWait   time.Duration
 	writeWait  time.Duration
@@ -36,7 +35,6 @@ func NewWebSocketClientConn(conn *websocket.Conn, maxMsgSize int64, pongWait, pi
 	c := &WebsocketClientConn{
 		conn:       conn,
 		writeChan:  make(chan []byte, 256), // Buffered write channel
-		closeChan:  make(chan struct{}),
 		pingPeriod: pingPeriod,
 		pongWait:   pongWait,
 		writeWait:  WriteWait,
@@ -58,7 +56,8 @@ func NewWebSocketClientConn(conn *websocket.Conn, maxMsgSize int64, pongWait, pi
 	return c
 }
 
-// writeLoop handles all writes to the connection (single writer pattern)
+// writeLoop handles all writes to the connection (single writer pattern).
+// After Close it still flushes the queued messages before sending the close frame.
 func (c *WebsocketClientConn) writeLoop() {
 	ticker := time.NewTicker(c.pingPeriod)
 	defer func() {
@@ -87,9 +86,6 @@ func (c *WebsocketClientConn) writeLoop() {
 				log.Debug("ping error: %v", err)
 				return
 			}
-
-		case <-c.closeChan:
-			return
 		}
 	}
 }
@@ -119,6 +115,11 @@ func (c *WebsocketClientConn) WriteMessage(data []byte) error {
 	}
 }
 
+// QueuedWrites returns the number of messages waiting in the write channel
+func (c *WebsocketClientConn) QueuedWrites() int {
+	return len(c.writeChan)
+}
+
 // Close closes the connection
 func (c *WebsocketClientConn) Close() error {
 	c.closeOnce.Do(func() {
@@ -126,8 +127,6 @@ func (c *WebsocketClientConn) Close() error {
 		c.closed = true
 		close(c.writeChan)
 		c.writeMu.Unlock()
-
-		close(c.closeChan)
 	})
 	return nil
 }
@@ -139,7 +139,7 @@ func TestWsServer_HandleConnection_DirectWebSocketMessage(t *testing.T) {
 	}))
 	defer httpServer.Close()
 
-	token, err := jwt.GenerateToken(userID, 5, jwtSecret, 1)
+	token, err := jwt.GenerateToken(userID, 5, jwtSecret, time.Hour)
 	if err != nil {
 		t.Fatalf("generate token failed: %v", err)
 	}
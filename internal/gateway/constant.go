@@ -10,11 +10,26 @@ const (
 	WSSendMsg           = 1003 // Send message
 	WSPullMsg           = 1005 // Pull messages
 	WSGetConvMaxReadSeq = 1006 // Get conversation max/read seq
+	WSSendSignal        = 1007 // Send call signal
+	WSSendTyping        = 1008 // Start or stop typing in a conversation
 
 	// Response identifiers
-	WSPushMsg       = 2001 // Server push message
-	WSKickOnlineMsg = 2002 // Kick user offline
-	WSDataError     = 3001 // Data error
+	WSPushMsg             = 2001 // Server push message
+	WSKickOnlineMsg       = 2002 // Kick user offline
+	WSReadReceipt         = 2003 // Server push: a conversation was read
+	WSConversationChanged = 2004 // Server push: conversation settings changed on another device
+	WSMessageEdited       = 2005 // Server push: content of a message already delivered was replaced
+	WSSignal              = 2006 // Server push: call signal
+	WSProfileChanged      = 2007 // Server push: nickname or avatar of a contact changed
+	WSPollUpdated         = 2008 // Server push: the result of a poll changed
+	WSMessageRevoked      = 2009 // Server push: revoke notification of a message recalled by its sender
+	WSReactionChanged     = 2010 // Server push: a reaction to a message was added or removed
+	WSReadCounts          = 2011 // Server push: read counts of group messages changed
+	WSTyping              = 2012 // Server push: a user started or stopped typing
+	WSDelivered           = 2013 // Server push: single chat messages reached the peer
+	WSPinChanged          = 2014 // Server push: a message was pinned or unpinned in a conversation
+	WSMessageDeleted      = 2015 // Server push: a message was deleted for everyone
+	WSDataError           = 3001 // Data error
 )
 
 // WebSocket message types
@@ -36,6 +51,11 @@ const (
 
 	// MaxMessageSize is maximum message size allowed from peer
 	MaxMessageSize = 51200
+
+	// TypingInterval is how often a typing start of a user in a conversation is fanned out;
+	// clients repeat the start while typing and drop a typing status not refreshed for twice
+	// the interval
+	TypingInterval = 3 * time.Second
 )
 
 // Query parameter keys
@@ -10,4 +10,5 @@ var (
 	ErrUserIdMismatch   = errors.New("user Id mismatch")
 	ErrTokenInvalid     = errors.New("token invalid")
 	ErrPanic            = errors.New("panic error")
+	ErrNoPermission     = errors.New("no permission")
 )
@@ -0,0 +1,272 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+)
+
+// Long-poll constants
+const (
+	// DefaultPollWait is how long a poll waits for events when the client sets no wait
+	DefaultPollWait = 25 * time.Second
+
+	// MaxPollWait bounds the wait of a poll
+	MaxPollWait = 30 * time.Second
+
+	// PollSessionTTL is how long a poll session stays registered without being polled.
+	// Events pushed between two polls are buffered for the next one.
+	PollSessionTTL = 60 * time.Second
+
+	// maxPollEvents bounds the events buffered for a session, like the WS write channel
+	maxPollEvents = 256
+)
+
+// PollEvent is a pushed frame buffered for a long-poll session
+type PollEvent struct {
+	Seq           int64           `json:"seq"`
+	ReqIdentifier int32           `json:"req_identifier"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+// PollRequest is a long-poll request of an authenticated user
+type PollRequest struct {
+	UserId     string
+	PlatformId int
+	SinceSeq   int64         // seq of the last event received, 0 on the first poll
+	Wait       time.Duration // capped to MaxPollWait
+}
+
+// PollResult is the response of a long-poll request
+type PollResult struct {
+	Events  []*PollEvent `json:"events"`
+	NextSeq int64        `json:"next_seq"` // since_seq of the next poll
+	// Reset is set when since_seq is unknown to the session, e.g. it expired: events may have
+	// been missed, so the client should resync with pull and poll again from next_seq
+	Reset bool `json:"reset"`
+}
+
+// PollClientConn implements ClientConn for HTTP long-polling: pushed frames are numbered
+// and buffered until a poll acknowledges them with since_seq.
+// ReadMessage blocks until the connection is closed.
+type PollClientConn struct {
+	mu       sync.Mutex
+	events   []*PollEvent
+	lastSeq  int64
+	notify   chan struct{} // closed and replaced when an event arrives
+	done     chan struct{}
+	closed   bool
+	polling  int // polls currently waiting
+	lastPoll time.Time
+}
+
+// NewPollClientConn creates a new long-poll client connection
+func NewPollClientConn() *PollClientConn {
+	return &PollClientConn{
+		notify:   make(chan struct{}),
+		done:     make(chan struct{}),
+		lastPoll: time.Now(),
+	}
+}
+
+// poll acknowledges the events up to sinceSeq and returns the later ones,
+// waiting up to wait for one to arrive
+func (c *PollClientConn) poll(ctx context.Context, sinceSeq int64, wait time.Duration) *PollResult {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	c.mu.Lock()
+	c.polling++
+	defer func() {
+		c.mu.Lock()
+		c.polling--
+		c.lastPoll = time.Now()
+		c.mu.Unlock()
+	}()
+	for {
+		if sinceSeq > c.lastSeq {
+			lastSeq := c.lastSeq
+			c.mu.Unlock()
+			return &PollResult{Events: []*PollEvent{}, NextSeq: lastSeq, Reset: true}
+		}
+		acked := 0
+		for acked < len(c.events) && c.events[acked].Seq <= sinceSeq {
+			acked++
+		}
+		c.events = c.events[acked:]
+		if len(c.events) > 0 || c.closed {
+			events := append([]*PollEvent(nil), c.events...)
+			lastSeq := c.lastSeq
+			c.mu.Unlock()
+			return &PollResult{Events: events, NextSeq: lastSeq}
+		}
+		notify := c.notify
+		c.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-c.done:
+		case <-timer.C:
+			return &PollResult{Events: []*PollEvent{}, NextSeq: sinceSeq}
+		case <-ctx.Done():
+			return &PollResult{Events: []*PollEvent{}, NextSeq: sinceSeq}
+		}
+		c.mu.Lock()
+	}
+}
+
+// idleSince reports whether no poll is waiting and none was made since t
+func (c *PollClientConn) idleSince(t time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.polling == 0 && c.lastPoll.Before(t)
+}
+
+// ReadMessage blocks until the connection is closed; long-poll clients send requests over HTTP
+func (c *PollClientConn) ReadMessage() ([]byte, error) {
+	<-c.done
+	return nil, ErrConnClosed
+}
+
+// WriteMessage buffers a frame for the next poll
+func (c *PollClientConn) WriteMessage(data []byte) error {
+	var frame WSResponse
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrConnClosed
+	}
+	if len(c.events) >= maxPollEvents {
+		// Client stopped polling, like a slow WS consumer
+		return ErrWriteChannelFull
+	}
+
+	c.lastSeq++
+	c.events = append(c.events, &PollEvent{Seq: c.lastSeq, ReqIdentifier: frame.ReqIdentifier, Data: frame.Data})
+	close(c.notify)
+	c.notify = make(chan struct{})
+	return nil
+}
+
+// QueuedWrites returns the number of events waiting for a poll
+func (c *PollClientConn) QueuedWrites() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.events)
+}
+
+// Close closes the connection; buffered events are still returned by the next poll
+func (c *PollClientConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	return nil
+}
+
+// SetReadDeadline is a no-op, long-poll connections are never read
+func (c *PollClientConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline is a no-op, writes only buffer
+func (c *PollClientConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// pollSession is the client registered for the long-polls of a user on a platform
+type pollSession struct {
+	client *Client
+	conn   *PollClientConn
+}
+
+func pollSessionKey(userId string, platformId int) string {
+	return userId + ":" + strconv.Itoa(platformId)
+}
+
+// Poll serves a long-poll request. The first poll of a user on a platform registers a
+// client in the user map, so the session receives the same pushes as a WebSocket
+// connection; it is unregistered after PollSessionTTL without polls.
+func (s *WsServer) Poll(ctx context.Context, req *PollRequest) (*PollResult, error) {
+	wait := req.Wait
+	if wait <= 0 {
+		wait = DefaultPollWait
+	}
+	if wait > MaxPollWait {
+		wait = MaxPollWait
+	}
+
+	session, created, err := s.pollSession(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if created && req.SinceSeq > 0 {
+		// Events of an expired session are lost
+		return &PollResult{Events: []*PollEvent{}, Reset: true}, nil
+	}
+	return session.conn.poll(ctx, req.SinceSeq, wait), nil
+}
+
+// pollSession returns the live session of the user on the platform, registering one if needed
+func (s *WsServer) pollSession(ctx context.Context, req *PollRequest) (*pollSession, bool, error) {
+	key := pollSessionKey(req.UserId, req.PlatformId)
+
+	s.pollMu.Lock()
+	defer s.pollMu.Unlock()
+	if session, ok := s.pollSessions[key]; ok {
+		// A kicked session is kept until its last events, e.g. the kick notice, are acknowledged
+		if !session.client.IsClosed() || session.conn.QueuedWrites() > 0 {
+			return session, false, nil
+		}
+	}
+
+	conn := NewPollClientConn()
+	client, err := s.Attach(ctx, conn, req.UserId, req.PlatformId, "")
+	if err != nil {
+		return nil, false, err
+	}
+	session := &pollSession{client: client, conn: conn}
+	s.pollSessions[key] = session
+	log.CtxDebug(ctx, "poll session created: user_id=%s, platform_id=%d, conn_id=%s", req.UserId, req.PlatformId, client.ConnId)
+	return session, true, nil
+}
+
+// pollReapLoop closes the poll sessions that were not polled for PollSessionTTL
+func (s *WsServer) pollReapLoop(ctx context.Context) {
+	defer s.eventWorker.Done()
+	ticker := time.NewTicker(PollSessionTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.eventStop:
+			return
+		case <-ticker.C:
+			s.reapPollSessions(time.Now().Add(-PollSessionTTL))
+		}
+	}
+}
+
+// reapPollSessions closes the sessions idle since before
+func (s *WsServer) reapPollSessions(before time.Time) {
+	s.pollMu.Lock()
+	defer s.pollMu.Unlock()
+	for key, session := range s.pollSessions {
+		if session.conn.idleSince(before) {
+			_ = session.client.Close()
+			delete(s.pollSessions, key)
+		}
+	}
+}
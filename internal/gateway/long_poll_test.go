@@ -0,0 +1,99 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+func newTestPollServer() *WsServer {
+	cfg := &config.Config{
+		WebSocket: config.WebSocketConfig{
+			MaxConnNum:      100,
+			PushChannelSize: 16,
+		},
+	}
+	return NewWsServer(cfg, nil, nil, nil)
+}
+
+func TestWsServerPollReceivesPushes(t *testing.T) {
+	s := newTestPollServer()
+	s.Run(context.Background())
+
+	// The first poll registers the session and times out empty
+	result, err := s.Poll(context.Background(), &PollRequest{UserId: "200", PlatformId: 5, Wait: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if len(result.Events) != 0 || result.NextSeq != 0 || result.Reset {
+		t.Fatalf("unexpected first poll result: %+v", result)
+	}
+
+	done := make(chan *PollResult, 1)
+	go func() {
+		result, _ := s.Poll(context.Background(), &PollRequest{UserId: "200", PlatformId: 5, Wait: 2 * time.Second})
+		done <- result
+	}()
+	time.Sleep(20 * time.Millisecond)
+	s.AsyncPushToUsers(newMessage("100", "200"), []string{"200"}, "")
+
+	select {
+	case result = <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("poll did not return")
+	}
+	if len(result.Events) != 1 || result.NextSeq != 1 {
+		t.Fatalf("unexpected poll result: %+v", result)
+	}
+	event := result.Events[0]
+	if event.Seq != 1 || event.ReqIdentifier != WSPushMsg {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	var push PushMsgData
+	if err = json.Unmarshal(event.Data, &push); err != nil || len(push.Msgs) != 1 {
+		t.Fatalf("unexpected push data: %s, error=%v", event.Data, err)
+	}
+
+	// Polling from next_seq acknowledges the event
+	result, err = s.Poll(context.Background(), &PollRequest{UserId: "200", PlatformId: 5, SinceSeq: 1, Wait: 50 * time.Millisecond})
+	if err != nil || len(result.Events) != 0 || result.NextSeq != 1 {
+		t.Fatalf("unexpected poll after ack: %+v, error=%v", result, err)
+	}
+}
+
+func TestWsServerPollUnknownSinceSeqResets(t *testing.T) {
+	s := newTestPollServer()
+	s.Run(context.Background())
+
+	result, err := s.Poll(context.Background(), &PollRequest{UserId: "200", SinceSeq: 42, Wait: time.Second})
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if !result.Reset || result.NextSeq != 0 {
+		t.Fatalf("expected reset to seq 0, got %+v", result)
+	}
+}
+
+func TestWsServerReapPollSessions(t *testing.T) {
+	s := newTestPollServer()
+	s.Run(context.Background())
+
+	if _, err := s.Poll(context.Background(), &PollRequest{UserId: "200", Wait: time.Millisecond}); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	s.reapPollSessions(time.Now().Add(time.Second))
+
+	deadline := time.Now().Add(time.Second)
+	for s.GetOnlineConnCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected idle poll session unregistered, online conns = %d", s.GetOnlineConnCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(s.pollSessions) != 0 {
+		t.Fatalf("expected poll session removed, got %d", len(s.pollSessions))
+	}
+}
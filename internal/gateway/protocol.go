@@ -4,12 +4,13 @@ import "encoding/json"
 
 // WSRequest represents a WebSocket request message
 type WSRequest struct {
-	ReqIdentifier int32  `json:"req_identifier"` // Request type
-	MsgIncr       string `json:"msg_incr"`       // Client message counter/trace Id
-	OperationId   string `json:"operation_id"`   // Operation Id
-	Token         string `json:"token"`          // JWT token (optional, used in handshake)
-	SendId        string `json:"send_id"`        // Sender user Id
-	Data          []byte `json:"data"`           // Business data
+	ReqIdentifier int32  `json:"req_identifier"`        // Request type
+	MsgIncr       string `json:"msg_incr"`              // Client message counter/trace Id
+	OperationId   string `json:"operation_id"`          // Operation Id
+	Token         string `json:"token"`                 // JWT token (optional, used in handshake)
+	SendId        string `json:"send_id"`               // Sender user Id
+	Data          []byte `json:"data"`                  // Business data
+	Traceparent   string `json:"traceparent,omitempty"` // W3C trace context of the caller (optional)
 }
 
 // WSResponse represents a WebSocket response message
@@ -24,12 +25,72 @@ type WSResponse struct {
 
 // SendMsgReq represents send message request data
 type WireMessageContent struct {
-	Text   string `json:"text,omitempty"`
-	Image  string `json:"image,omitempty"`
-	Video  string `json:"video,omitempty"`
-	Audio  string `json:"audio,omitempty"`
-	File   string `json:"file,omitempty"`
-	Custom string `json:"custom,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Image     string `json:"image,omitempty"`
+	Video     string `json:"video,omitempty"`
+	Audio     string `json:"audio,omitempty"`
+	File      string `json:"file,omitempty"`
+	Custom    string `json:"custom,omitempty"`
+	Encrypted string `json:"encrypted,omitempty"`
+
+	ImageThumbnail string `json:"image_thumbnail,omitempty"`
+	ImageWidth     int    `json:"image_width,omitempty"`
+	ImageHeight    int    `json:"image_height,omitempty"`
+	AudioDuration  int    `json:"audio_duration,omitempty"`
+	AudioWaveform  []int  `json:"audio_waveform,omitempty"`
+
+	LinkPreview *WireLinkPreview `json:"link_preview,omitempty"`
+	Mentions    []string         `json:"mentions,omitempty"`
+	Poll        *WirePoll        `json:"poll,omitempty"`
+	Revoke      *WireRevoke      `json:"revoke,omitempty"`
+	Merged      *WireMerged      `json:"merged,omitempty"`
+}
+
+// WireLinkPreview is the link preview card of a text message, set by the server
+type WireLinkPreview struct {
+	Url         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageUrl    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// WirePoll is the question and options of a poll message
+type WirePoll struct {
+	Question    string   `json:"question"`
+	Options     []string `json:"options"`
+	Anonymous   bool     `json:"anonymous,omitempty"`
+	MultiChoice bool     `json:"multi_choice,omitempty"`
+}
+
+// WireRevoke is the content of a revoke notification, set by the server
+type WireRevoke struct {
+	Seq int64 `json:"seq"`
+}
+
+// WireMerged is the content of a merged forward, set by the server
+type WireMerged struct {
+	Title    string               `json:"title,omitempty"`
+	Messages []*WireMergedMessage `json:"messages"`
+}
+
+// WireMergedMessage is a message of a merged forward as it was when forwarded
+type WireMergedMessage struct {
+	SenderId string             `json:"sender_id"`
+	MsgType  int32              `json:"msg_type"`
+	Content  WireMessageContent `json:"content"`
+	SendAt   int64              `json:"send_at"`
+}
+
+// WireQuote is the message of the same conversation a message replies to. Senders set
+// conversation_id, seq and optionally the quoted snippet of its text; the server adds the
+// sender and type of the quoted message.
+type WireQuote struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	SenderId       string `json:"sender_id,omitempty"`
+	MsgType        int32  `json:"msg_type,omitempty"`
+	Snippet        string `json:"snippet,omitempty"`
 }
 
 type SendMsgReq struct {
@@ -39,6 +100,7 @@ type SendMsgReq struct {
 	SessionType int32              `json:"session_type"`
 	MsgType     int32              `json:"msg_type"`
 	Content     WireMessageContent `json:"content"`
+	QuotedMsg   *WireQuote         `json:"quoted_msg,omitempty"`
 }
 
 // SendMsgResp represents send message response data
@@ -77,7 +139,24 @@ type MessageData struct {
 	SessionType    int32              `json:"session_type"`
 	MsgType        int32              `json:"msg_type"`
 	Content        WireMessageContent `json:"content"`
+	Extra          *string            `json:"extra,omitempty"`
+	ContentHash    string             `json:"content_hash,omitempty"`
 	SendAt         int64              `json:"send_at"`
+	RevokedAt      int64              `json:"revoked_at,omitempty"`
+	EditVersion    int32              `json:"edit_version,omitempty"`
+	Forwarded      bool               `json:"forwarded,omitempty"`
+	ExpiresAt      int64              `json:"expires_at,omitempty"`
+	QuotedMsg      *WireQuote         `json:"quoted_msg,omitempty"`
+	Notify         *NotifyHint        `json:"notify,omitempty"` // set on pushes when the receiver changed the defaults
+}
+
+// NotifyHint tells the client how to alert the user about a pushed message, following the
+// receiver's notification settings for the platform of the connection
+type NotifyHint struct {
+	Mute        bool `json:"mute"`
+	Sound       bool `json:"sound"`
+	Vibrate     bool `json:"vibrate"`
+	ShowPreview bool `json:"show_preview"`
 }
 
 // GetNewestSeqReq represents get newest seq request
@@ -107,6 +186,57 @@ type PushMsgData struct {
 	Msgs map[string][]*MessageData `json:"msgs"` // conversation_id -> messages
 }
 
+// DeliveredData represents delivery receipt push data
+type DeliveredData struct {
+	ConversationId string `json:"conversation_id"`
+	UserId         string `json:"user_id"` // the user the messages were delivered to
+	DeliveredSeq   int64  `json:"delivered_seq"`
+}
+
+// TypingData represents typing push data
+type TypingData struct {
+	ConversationId string `json:"conversation_id"`
+	UserId         string `json:"user_id"` // the user typing
+	Typing         bool   `json:"typing"`  // false when the user stopped typing
+}
+
+// ReadReceiptData represents read receipt push data
+type ReadReceiptData struct {
+	ConversationId string `json:"conversation_id"`
+	UserId         string `json:"user_id"` // the user who read
+	ReadSeq        int64  `json:"read_seq"`
+}
+
+// ReadCountsData represents group read counts push data: the new read counts of the messages
+// of the receiver that UserId read up to ReadSeq
+type ReadCountsData struct {
+	ConversationId string           `json:"conversation_id"`
+	UserId         string           `json:"user_id"` // the user who read
+	Counts         []*ReadCountData `json:"counts"`
+}
+
+// ReadCountData is the read count of one message
+type ReadCountData struct {
+	Seq       int64 `json:"seq"`
+	ReadCount int64 `json:"read_count"`
+}
+
+// ConversationChangedData represents conversation settings push data; only changed fields are set
+type ConversationChangedData struct {
+	ConversationId string `json:"conversation_id"`
+	RecvMsgOpt     *int32 `json:"recv_msg_opt,omitempty"`
+	IsPinned       *bool  `json:"is_pinned,omitempty"`
+	TTLSeconds     *int32 `json:"ttl_seconds,omitempty"` // disappearing messages timer, 0 turns it off
+}
+
+// ProfileChangedData represents profile change push data, clients refresh their copy of the
+// user's name and avatar from it
+type ProfileChangedData struct {
+	UserId   string `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
 // Encode encodes data to JSON bytes
 func Encode(v interface{}) ([]byte, error) {
 	return json.Marshal(v)
@@ -0,0 +1,154 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+)
+
+// SSEClientConn implements ClientConn over a Server-Sent Events stream.
+// The stream is push only: ReadMessage blocks until the connection is closed.
+type SSEClientConn struct {
+	writeChan  chan []byte
+	done       chan struct{}
+	writeMu    sync.Mutex
+	closeOnce  sync.Once
+	closed     bool
+	pingPeriod time.Duration
+}
+
+// NewSSEClientConn creates a new SSE client connection
+func NewSSEClientConn(pingPeriod time.Duration) *SSEClientConn {
+	return &SSEClientConn{
+		writeChan:  make(chan []byte, 256),
+		done:       make(chan struct{}),
+		pingPeriod: pingPeriod,
+	}
+}
+
+// serve writes the queued messages as SSE events until the connection is closed,
+// the request is done or a write fails, which is how a disconnected client is detected.
+// After Close it still flushes the queued messages.
+func (c *SSEClientConn) serve(ctx context.Context, w http.ResponseWriter, flusher http.Flusher) {
+	ticker := time.NewTicker(c.pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message, ok := <-c.writeChan:
+			if !ok {
+				return
+			}
+			// JSON never contains raw newlines, so every message fits in one data line
+			if _, err := w.Write(append(append([]byte("data: "), message...), '\n', '\n')); err != nil {
+				log.Debug("sse write error: %v", err)
+				return
+			}
+			flusher.Flush()
+
+		case <-ticker.C:
+			// Comment line, ignored by EventSource but keeps proxies from timing out
+			if _, err := w.Write([]byte(": ping\n\n")); err != nil {
+				log.Debug("sse ping error: %v", err)
+				return
+			}
+			flusher.Flush()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReadMessage blocks until the connection is closed; SSE clients send requests over HTTP
+func (c *SSEClientConn) ReadMessage() ([]byte, error) {
+	<-c.done
+	return nil, ErrConnClosed
+}
+
+// WriteMessage queues a message to be written
+func (c *SSEClientConn) WriteMessage(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return ErrConnClosed
+	}
+
+	select {
+	case c.writeChan <- data:
+		return nil
+	default:
+		// Channel full, connection is slow consumer
+		return ErrWriteChannelFull
+	}
+}
+
+// QueuedWrites returns the number of messages waiting in the write channel
+func (c *SSEClientConn) QueuedWrites() int {
+	return len(c.writeChan)
+}
+
+// Close closes the connection
+func (c *SSEClientConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.writeMu.Lock()
+		c.closed = true
+		close(c.writeChan)
+		close(c.done)
+		c.writeMu.Unlock()
+	})
+	return nil
+}
+
+// SetReadDeadline is a no-op, SSE connections are never read
+func (c *SSEClientConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline is a no-op, a stalled stream is detected by the failing writes
+func (c *SSEClientConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// HandleSSE handles a new Server-Sent Events connection (Hertz handler).
+// It authenticates like HandleConnection and registers the client in the same user map,
+// so the stream receives the same pushes as a WebSocket connection, each event's data
+// being the JSON of the WSResponse frame.
+func (s *WsServer) HandleSSE(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx, traceID := handshakeContext(ctx, r)
+	claims, token, ok := s.authenticate(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Create client
+	sseConn := NewSSEClientConn(PingPeriod)
+	client := s.newClient(ctx, sseConn, claims, r.URL.Query().Get(QuerySDKType), token, traceID)
+
+	// Register client
+	s.registerChan <- client
+
+	// Start client; its read loop unregisters it once the stream is closed
+	client.Start()
+
+	// The stream lives as long as the request
+	sseConn.serve(r.Context(), w, flusher)
+	_ = client.Close()
+}
@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+)
+
+func TestWsServer_HandleSSE_ReceivesPushes(t *testing.T) {
+	const (
+		userID    = "u_sse_test"
+		jwtSecret = "unit-test-secret"
+	)
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:      jwtSecret,
+			ExpireHours: 1,
+		},
+		WebSocket: config.WebSocketConfig{
+			MaxConnNum:      100,
+			PushChannelSize: 8,
+		},
+	}
+	wsServer := NewWsServer(cfg, nil, nil, nil)
+	wsServer.Run(context.Background())
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wsServer.HandleSSE(context.Background(), w, r)
+	}))
+	defer httpServer.Close()
+
+	token, err := jwt.GenerateToken(userID, 5, jwtSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+
+	resp, err := http.Get(httpServer.URL + "/events?token=" + token + "&send_id=" + userID + "&sdk_type=js")
+	if err != nil {
+		t.Fatalf("open event stream failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type: %q", ct)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for wsServer.GetOnlineConnCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("sse client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	wsServer.AsyncPushToUsers(newMessage("100", userID), []string{userID}, "")
+
+	lines := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+				lines <- strings.TrimPrefix(line, "data: ")
+				return
+			}
+		}
+	}()
+
+	var event WSResponse
+	select {
+	case line := <-lines:
+		if err = json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("unmarshal event failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+	if event.ReqIdentifier != WSPushMsg {
+		t.Fatalf("unexpected req_identifier: got %d want %d", event.ReqIdentifier, WSPushMsg)
+	}
+	var push PushMsgData
+	if err = json.Unmarshal(event.Data, &push); err != nil {
+		t.Fatalf("unmarshal push data failed: %v", err)
+	}
+	if len(push.Msgs) != 1 {
+		t.Fatalf("unexpected push data: %+v", push)
+	}
+}
+
+func TestWsServer_HandleSSE_RejectsInvalidToken(t *testing.T) {
+	cfg := &config.Config{
+		JWT:       config.JWTConfig{Secret: "unit-test-secret"},
+		WebSocket: config.WebSocketConfig{MaxConnNum: 100, PushChannelSize: 8},
+	}
+	wsServer := NewWsServer(cfg, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events?token=bad&send_id=u1", nil)
+	wsServer.HandleSSE(context.Background(), rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: got %d want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSSEClientConn_WriteAfterClose(t *testing.T) {
+	conn := NewSSEClientConn(time.Second)
+	_ = conn.Close()
+
+	if _, err := conn.ReadMessage(); err != ErrConnClosed {
+		t.Fatalf("unexpected read error: got %v want %v", err, ErrConnClosed)
+	}
+	if err := conn.WriteMessage([]byte("x")); err != ErrConnClosed {
+		t.Fatalf("unexpected write error: got %v want %v", err, ErrConnClosed)
+	}
+}
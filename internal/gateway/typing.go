@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTypingEntries bounds the senders a typingThrottle remembers before forgetting the stale ones
+const maxTypingEntries = 100000
+
+// typingThrottle keeps one sender from flooding a conversation with typing statuses: a start is
+// let through once per interval for a key (sender and conversation), a stop only after a start
+// was, so receivers never see a stop without a start. The state is local to the node, like the
+// connections of the sender it throttles.
+type typingThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	started  map[string]time.Time // key -> when the last start was let through
+}
+
+// newTypingThrottle creates a typingThrottle letting one start per key through every interval
+func newTypingThrottle(interval time.Duration) *typingThrottle {
+	return &typingThrottle{interval: interval, started: make(map[string]time.Time)}
+}
+
+// allow reports whether a typing status of key should be fanned out
+func (t *typingThrottle) allow(key string, typing bool, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.started[key]
+	if !typing {
+		delete(t.started, key)
+		return ok
+	}
+	if ok && now.Sub(last) < t.interval {
+		return false
+	}
+	if !ok && len(t.started) >= maxTypingEntries {
+		t.forgetStale(now)
+	}
+	t.started[key] = now
+	return true
+}
+
+// forgetStale drops the keys whose start receivers already dropped for not being refreshed
+func (t *typingThrottle) forgetStale(now time.Time) {
+	for key, last := range t.started {
+		if now.Sub(last) >= 2*t.interval {
+			delete(t.started, key)
+		}
+	}
+}
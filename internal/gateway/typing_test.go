@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypingThrottle(t *testing.T) {
+	throttle := newTypingThrottle(3 * time.Second)
+	now := time.Now()
+
+	if throttle.allow("alice:si_alice:bob", false, now) {
+		t.Fatal("expected a stop without a start to be dropped")
+	}
+	if !throttle.allow("alice:si_alice:bob", true, now) {
+		t.Fatal("expected the first start to pass")
+	}
+	if throttle.allow("alice:si_alice:bob", true, now.Add(time.Second)) {
+		t.Fatal("expected a start repeated within the interval to be dropped")
+	}
+	if !throttle.allow("bob:si_alice:bob", true, now.Add(time.Second)) {
+		t.Fatal("expected other senders not to be throttled")
+	}
+	if !throttle.allow("alice:si_alice:bob", true, now.Add(3*time.Second)) {
+		t.Fatal("expected a start after the interval to pass")
+	}
+	if !throttle.allow("alice:si_alice:bob", false, now.Add(4*time.Second)) {
+		t.Fatal("expected a stop after a start to pass")
+	}
+	if !throttle.allow("alice:si_alice:bob", true, now.Add(4*time.Second)) {
+		t.Fatal("expected a start right after a stop to pass")
+	}
+}
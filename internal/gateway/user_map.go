@@ -96,6 +96,18 @@ func (m *UserMap) GetAll(userId string) ([]*Client, bool) {
 	return clients, true
 }
 
+// GetAllClients returns a snapshot of all local clients
+func (m *UserMap) GetAllClients() []*Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(m.users))
+	for _, up := range m.users {
+		clients = append(clients, up.Clients...)
+	}
+	return clients
+}
+
 // GetByPlatform gets clients for a specific platform
 func (m *UserMap) GetByPlatform(userId string, platformId int) ([]*Client, bool) {
 	m.mu.RLock()
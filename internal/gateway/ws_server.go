@@ -6,20 +6,28 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/mbeoliero/kit/log"
 	"github.com/redis/go-redis/v9"
 	"github.com/tidwall/gjson"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/ZaiSpace/nexo_im/internal/config"
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/internal/middleware"
 	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/analytics"
 	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
 )
 
 // WsServer is the WebSocket server
@@ -33,18 +41,36 @@ type WsServer struct {
 	appPushSender  AppPushSender
 	msgService     *service.MessageService
 	convService    *service.ConversationService
+	callService    *service.CallService
+	stats          *service.StatsService
+	notifySettings *service.NotificationService
 	onlineUserNum  atomic.Int64
 	onlineConnNum  atomic.Int64
 	maxConnNum     int64
+	shuttingDown   atomic.Bool
+	pushStop       chan struct{} // closed by Shutdown to stop the push workers
+	eventStop      chan struct{} // closed by Shutdown to stop the event loop
+	pushWorkers    sync.WaitGroup
+	eventWorker    sync.WaitGroup
+	pollMu         sync.Mutex
+	pollSessions   map[string]*pollSession // user_id:platform_id -> long-poll session
+	typing         *typingThrottle
 }
 
-// PushTask represents a message push task
+// PushTask represents a message or event push task
 type PushTask struct {
 	Msg       *entity.Message
+	Event     *PushEvent // set instead of Msg for notifications
 	TargetIds []string
 	ExcludeId string // Exclude specific connection Id
 }
 
+// PushEvent is a notification pushed to online connections only, never as an app push
+type PushEvent struct {
+	ReqIdentifier int32
+	Data          []byte
+}
+
 // NewWsServer creates a new WebSocket server
 func NewWsServer(cfg *config.Config, rdb redis.UniversalClient, msgService *service.MessageService, convService *service.ConversationService) *WsServer {
 	upgrader := &websocket.Upgrader{
@@ -75,9 +101,13 @@ func NewWsServer(cfg *config.Config, rdb redis.UniversalClient, msgService *serv
 		registerChan:   make(chan *Client, 1000),
 		unregisterChan: make(chan *Client, 1000),
 		pushChan:       make(chan *PushTask, cfg.WebSocket.PushChannelSize),
+		pushStop:       make(chan struct{}),
+		eventStop:      make(chan struct{}),
 		msgService:     msgService,
 		convService:    convService,
 		maxConnNum:     cfg.WebSocket.MaxConnNum,
+		pollSessions:   make(map[string]*pollSession),
+		typing:         newTypingThrottle(TypingInterval),
 	}
 
 	return server
@@ -86,24 +116,98 @@ func NewWsServer(cfg *config.Config, rdb redis.UniversalClient, msgService *serv
 // Run starts the WebSocket server
 func (s *WsServer) Run(ctx context.Context) {
 	// Start event loop
+	s.eventWorker.Add(1)
 	go s.eventLoop(ctx)
+	// Expire idle long-poll sessions
+	s.eventWorker.Add(1)
+	go s.pollReapLoop(ctx)
 	// Start push workers
 	workerNum := s.cfg.WebSocket.PushWorkerNum
 	if workerNum <= 0 {
 		workerNum = 10
 	}
 	for i := 0; i < workerNum; i++ {
+		s.pushWorkers.Add(1)
 		go s.pushLoop(ctx)
 	}
 	log.Info("started %d push workers", workerNum)
 }
 
+// Shutdown stops accepting connections, delivers the queued pushes, then closes
+// every connection and marks its user offline. Returns ctx.Err() when the deadline
+// is hit first; connections are closed regardless.
+func (s *WsServer) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+
+	// Deliver what is already queued; workers finish their current task before exiting
+	err := waitUntil(ctx, func() bool { return len(s.pushChan) == 0 })
+	close(s.pushStop)
+	if waitErr := waitGroup(ctx, &s.pushWorkers); err == nil {
+		err = waitErr
+	}
+	if dropped := len(s.pushChan); dropped > 0 {
+		log.CtxWarn(ctx, "websocket shutdown dropped queued pushes: count=%d", dropped)
+	}
+
+	// Take over the user map from the event loop
+	close(s.eventStop)
+	if waitErr := waitGroup(ctx, &s.eventWorker); err == nil {
+		err = waitErr
+	}
+	for len(s.registerChan) > 0 {
+		s.registerClient(ctx, <-s.registerChan)
+	}
+	for len(s.unregisterChan) > 0 {
+		s.unregisterClient(ctx, <-s.unregisterChan)
+	}
+
+	clients := s.userMap.GetAllClients()
+	for _, client := range clients {
+		_ = client.Close()
+		s.unregisterClient(ctx, client)
+	}
+	log.CtxInfo(ctx, "websocket server stopped: closed_conns=%d", len(clients))
+	return err
+}
+
+// waitUntil polls cond until it holds or ctx is done
+func waitUntil(ctx context.Context, cond func() bool) error {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// waitGroup waits for wg or until ctx is done
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // eventLoop handles client registration and unregistration
 func (s *WsServer) eventLoop(ctx context.Context) {
+	defer s.eventWorker.Done()
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-s.eventStop:
+			return
 		case client := <-s.registerChan:
 			s.registerClient(ctx, client)
 		case client := <-s.unregisterChan:
@@ -114,10 +218,13 @@ func (s *WsServer) eventLoop(ctx context.Context) {
 
 // pushLoop handles async message pushing
 func (s *WsServer) pushLoop(ctx context.Context) {
+	defer s.pushWorkers.Done()
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-s.pushStop:
+			return
 		case task := <-s.pushChan:
 			s.processPushTask(ctx, task)
 		}
@@ -126,7 +233,14 @@ func (s *WsServer) pushLoop(ctx context.Context) {
 
 // processPushTask processes a single push task
 func (s *WsServer) processPushTask(ctx context.Context, task *PushTask) {
-	if task == nil || task.Msg == nil || len(task.TargetIds) == 0 {
+	if task == nil || len(task.TargetIds) == 0 {
+		return
+	}
+	if task.Event != nil {
+		s.processEventTask(ctx, task)
+		return
+	}
+	if task.Msg == nil {
 		return
 	}
 
@@ -142,6 +256,8 @@ func (s *WsServer) processPushTask(ctx context.Context, task *PushTask) {
 		}
 		seen[userId] = struct{}{}
 
+		settings := s.getNotificationSettings(ctx, task.Msg, userId)
+		delivered := false
 		clients, ok := s.userMap.GetAll(userId)
 		if ok {
 			for _, client := range clients {
@@ -150,16 +266,90 @@ func (s *WsServer) processPushTask(ctx context.Context, task *PushTask) {
 					continue
 				}
 
-				if err := client.PushMessage(ctx, msgData); err != nil {
+				data := msgData
+				if task.Msg.Content.Encrypted != nil {
+					// Each device gets its own ciphertext only
+					data = s.messageToMsgData(task.Msg.ForDevice(userId, client.PlatformId))
+				}
+				if hint := notifyHint(settings, client.PlatformId); hint != nil {
+					withHint := *data
+					withHint.Notify = hint
+					data = &withHint
+				}
+				if err := client.PushMessage(ctx, data); err != nil {
+					metrics.WSPushesTotal.WithLabelValues("failed").Inc()
 					log.CtxDebug(ctx, "push to client failed: user_id=%s, conn_id=%s, error=%v", userId, client.ConnId, err)
+					continue
 				}
+				metrics.WSPushesTotal.WithLabelValues("ok").Inc()
+				delivered = true
 			}
 		}
+		if delivered && s.msgService != nil && userId != task.Msg.SenderId && task.Msg.SessionType == constant.SessionTypeSingle {
+			s.msgService.MarkDelivered(ctx, userId, task.Msg.ConversationId, task.Msg.Seq)
+		}
 
 		if s.userMap.IsOnline(ctx, userId) {
 			continue
 		}
-		s.pushToAppIfNeeded(ctx, task.Msg, userId)
+		s.pushToAppIfNeeded(ctx, task.Msg, userId, settings)
+	}
+}
+
+// getNotificationSettings gets the notification settings of the receiver of a message, nil
+// for the sender or when they can't be loaded, in which case the defaults apply
+func (s *WsServer) getNotificationSettings(ctx context.Context, msg *entity.Message, userId string) *entity.NotificationSettings {
+	if s.notifySettings == nil || userId == msg.SenderId {
+		return nil
+	}
+	settings, err := s.notifySettings.GetSettings(ctx, userId)
+	if err != nil {
+		log.CtxWarn(ctx, "get notification settings failed: user_id=%s, error=%v", userId, err)
+		return nil
+	}
+	return settings
+}
+
+// notifyHint returns the hint attached to messages pushed to a connection, nil when the
+// settings effective on its platform are the defaults
+func notifyHint(settings *entity.NotificationSettings, platformId int) *NotifyHint {
+	if settings == nil {
+		return nil
+	}
+	effective := settings.ForPlatform(platformId)
+	if effective.IsDefault() {
+		return nil
+	}
+	return &NotifyHint{
+		Mute:        effective.GlobalMute,
+		Sound:       effective.Sound,
+		Vibrate:     effective.Vibrate,
+		ShowPreview: effective.ShowPreview,
+	}
+}
+
+// processEventTask pushes a notification to the online connections of the targets
+func (s *WsServer) processEventTask(ctx context.Context, task *PushTask) {
+	seen := make(map[string]struct{}, len(task.TargetIds))
+	for _, userId := range task.TargetIds {
+		if _, ok := seen[userId]; ok || userId == "" {
+			continue
+		}
+		seen[userId] = struct{}{}
+
+		clients, ok := s.userMap.GetAll(userId)
+		if !ok {
+			continue
+		}
+		for _, client := range clients {
+			if task.ExcludeId != "" && client.ConnId == task.ExcludeId {
+				continue
+			}
+			if err := client.PushEvent(task.Event.ReqIdentifier, task.Event.Data); err != nil {
+				log.CtxDebug(ctx, "push event to client failed: user_id=%s, conn_id=%s, req_identifier=%d, error=%v",
+					userId, client.ConnId, task.Event.ReqIdentifier, err)
+			}
+		}
 	}
 }
 
@@ -168,7 +358,9 @@ func (s *WsServer) SetAppPushSender(sender AppPushSender) {
 	s.appPushSender = sender
 }
 
-func (s *WsServer) pushToAppIfNeeded(ctx context.Context, msg *entity.Message, userId string) {
+// pushToAppIfNeeded sends an offline push to the user, following the global flags of their
+// notification settings (nil for the defaults)
+func (s *WsServer) pushToAppIfNeeded(ctx context.Context, msg *entity.Message, userId string, settings *entity.NotificationSettings) {
 	if s.appPushSender == nil || msg == nil || userId == "" {
 		return
 	}
@@ -176,6 +368,12 @@ func (s *WsServer) pushToAppIfNeeded(ctx context.Context, msg *entity.Message, u
 	if userId == msg.SenderId {
 		return
 	}
+	if settings == nil {
+		settings = entity.DefaultNotificationSettings(userId)
+	}
+	if settings.GlobalMute {
+		return
+	}
 
 	userInfoProvider, ok := s.appPushSender.(AppPushUserInfoProvider)
 	if !ok {
@@ -189,12 +387,28 @@ func (s *WsServer) pushToAppIfNeeded(ctx context.Context, msg *entity.Message, u
 	if req == nil {
 		return
 	}
+	applyNotificationSettings(req, settings)
 	if err := s.appPushSender.SendPush(ctx, req); err != nil {
 		log.CtxWarn(ctx, "app push failed: user_id=%s, conversation_id=%s, seq=%d, error=%v",
 			userId, msg.ConversationId, msg.Seq, err)
 	}
 }
 
+// SetCallService enables call signaling over the connections
+func (s *WsServer) SetCallService(callService *service.CallService) {
+	s.callService = callService
+}
+
+// SetNotificationSettings enables per-user notification settings on pushes
+func (s *WsServer) SetNotificationSettings(notifySettings *service.NotificationService) {
+	s.notifySettings = notifySettings
+}
+
+// SetStats sets the stats recorder
+func (s *WsServer) SetStats(stats *service.StatsService) {
+	s.stats = stats
+}
+
 // registerClient registers a client
 func (s *WsServer) registerClient(ctx context.Context, client *Client) {
 	existingClients, exists := s.userMap.GetAll(client.UserId)
@@ -204,6 +418,17 @@ func (s *WsServer) registerClient(ctx context.Context, client *Client) {
 
 	s.userMap.Register(ctx, client)
 	s.onlineConnNum.Add(1)
+	metrics.WSConnections.Set(float64(s.onlineConnNum.Load()))
+	metrics.WSOnlineUsers.Set(float64(s.onlineUserNum.Load()))
+	s.stats.RecordActiveUser(ctx, client.UserId)
+	s.stats.RecordOnline(ctx, s.onlineUserNum.Load())
+	if !exists {
+		analytics.Record(ctx, &analytics.Event{
+			Type:   analytics.EventUserOnline,
+			UserId: client.UserId,
+			Props:  map[string]any{"platform_id": client.PlatformId},
+		})
+	}
 
 	log.CtxInfo(ctx, "client registered: user_id=%s, platform_id=%d, conn_id=%s, existing_conns=%d, online_users=%d, online_conns=%d",
 		client.UserId, client.PlatformId, client.ConnId, len(existingClients), s.onlineUserNum.Load(), s.onlineConnNum.Load())
@@ -217,6 +442,8 @@ func (s *WsServer) unregisterClient(ctx context.Context, client *Client) {
 	if isUserOffline {
 		s.onlineUserNum.Add(-1)
 	}
+	metrics.WSConnections.Set(float64(s.onlineConnNum.Load()))
+	metrics.WSOnlineUsers.Set(float64(s.onlineUserNum.Load()))
 
 	log.CtxInfo(ctx, "client unregistered: user_id=%s, platform_id=%d, conn_id=%s, user_offline=%v, online_users=%d, online_conns=%d",
 		client.UserId, client.PlatformId, client.ConnId, isUserOffline, s.onlineUserNum.Load(), s.onlineConnNum.Load())
@@ -224,6 +451,10 @@ func (s *WsServer) unregisterClient(ctx context.Context, client *Client) {
 
 // UnregisterClient queues client for unregistration
 func (s *WsServer) UnregisterClient(client *Client) {
+	if s.shuttingDown.Load() {
+		// Shutdown unregisters every client itself
+		return
+	}
 	select {
 	case s.unregisterChan <- client:
 	default:
@@ -233,6 +464,51 @@ func (s *WsServer) UnregisterClient(client *Client) {
 
 // HandleConnection handles a new WebSocket connection (Hertz handler)
 func (s *WsServer) HandleConnection(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	ctx, traceID := handshakeContext(ctx, r)
+	claims, token, ok := s.authenticate(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	// Upgrade connection
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.CtxWarn(ctx, "websocket upgrade failed: %v", err)
+		return
+	}
+
+	// Create client
+	wsConn := NewWebSocketClientConn(conn, s.cfg.WebSocket.MaxMessageSize, PongWait, PingPeriod)
+	client := s.newClient(ctx, wsConn, claims, r.URL.Query().Get(QuerySDKType), token, traceID)
+
+	// Register client
+	s.registerChan <- client
+
+	// Start client
+	client.Start()
+}
+
+// Attach registers conn as a connection of the user, so it receives the same pushes as a
+// WebSocket connection. It serves the transports that do not go through a handshake here,
+// which have authenticated the user themselves. The client is unregistered once conn is
+// closed, which must make its ReadMessage fail.
+func (s *WsServer) Attach(ctx context.Context, conn ClientConn, userId string, platformId int, sdkType string) (*Client, error) {
+	if s.shuttingDown.Load() {
+		return nil, errcode.ErrConnClosed
+	}
+	if s.onlineConnNum.Load() >= s.maxConnNum {
+		return nil, errcode.ErrConnOverLimit
+	}
+	client := NewClient(conn, userId, platformId, sdkType, "", uuid.New().String(), s)
+	client.ctx = middleware.WithTraceID(client.ctx, middleware.GetTraceID(ctx))
+
+	s.registerChan <- client
+	client.Start()
+	return client, nil
+}
+
+// handshakeContext sets the trace id and W3C trace context sent with a connection handshake
+func handshakeContext(ctx context.Context, r *http.Request) (context.Context, string) {
 	traceID := middleware.GetTraceID(ctx)
 	if traceID == "" {
 		traceID = strings.TrimSpace(r.Header.Get(middleware.TraceIDHeader))
@@ -241,22 +517,35 @@ func (s *WsServer) HandleConnection(ctx context.Context, w http.ResponseWriter,
 		traceID = strings.TrimSpace(r.URL.Query().Get(QueryOperationId))
 	}
 	ctx = middleware.WithTraceID(ctx, traceID)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		// Accept W3C traceparent sent with the handshake
+		ctx = tracing.Extract(ctx, propagation.HeaderCarrier(r.Header))
+	}
+	return ctx, traceID
+}
+
+// authenticate checks the connection limit and the token of a handshake.
+// It writes the error response and returns false if the connection is refused.
+func (s *WsServer) authenticate(ctx context.Context, w http.ResponseWriter, r *http.Request) (*jwt.Claims, string, bool) {
+	if s.shuttingDown.Load() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return nil, "", false
+	}
 
 	// Check connection limit
 	if s.onlineConnNum.Load() >= s.maxConnNum {
 		http.Error(w, "connection limit exceeded", http.StatusServiceUnavailable)
-		return
+		return nil, "", false
 	}
 
 	// Parse query parameters
 	token := r.URL.Query().Get(QueryToken)
 	sendId := r.URL.Query().Get(QuerySendId)
 	platformIdStr := r.URL.Query().Get(QueryPlatformId)
-	sdkType := r.URL.Query().Get(QuerySDKType)
 
 	if token == "" || sendId == "" {
 		http.Error(w, "missing required parameters", http.StatusBadRequest)
-		return
+		return nil, "", false
 	}
 
 	// Validate token (supports external token fallback)
@@ -264,7 +553,7 @@ func (s *WsServer) HandleConnection(ctx context.Context, w http.ResponseWriter,
 	if err != nil {
 		log.CtxDebug(ctx, "token validation failed: send_id=%s, error=%v", sendId, err)
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return nil, "", false
 	}
 
 	// Allow query param to override platform ID from claims
@@ -273,25 +562,19 @@ func (s *WsServer) HandleConnection(ctx context.Context, w http.ResponseWriter,
 			claims.PlatformId = pid
 		}
 	}
+	return claims, token, true
+}
 
-	// Upgrade connection
-	conn, err := s.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.CtxWarn(ctx, "websocket upgrade failed: %v", err)
-		return
-	}
-
-	// Create client
-	connId := uuid.New().String()
-	wsConn := NewWebSocketClientConn(conn, s.cfg.WebSocket.MaxMessageSize, PongWait, PingPeriod)
-	client := NewClient(wsConn, claims.UserId, claims.PlatformId, sdkType, token, connId, s)
+// newClient creates the client of an accepted connection
+func (s *WsServer) newClient(ctx context.Context, conn ClientConn, claims *jwt.Claims, sdkType, token, traceID string) *Client {
+	client := NewClient(conn, claims.UserId, claims.PlatformId, sdkType, token, uuid.New().String(), s)
+	client.Scopes = claims.Scopes
 	client.ctx = middleware.WithTraceID(client.ctx, traceID)
-
-	// Register client
-	s.registerChan <- client
-
-	// Start client
-	client.Start()
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		// Request spans join the handshake trace unless a request carries its own traceparent
+		client.ctx = trace.ContextWithRemoteSpanContext(client.ctx, spanCtx)
+	}
+	return client
 }
 
 // AsyncPushToUsers queues a message push to users
@@ -307,10 +590,168 @@ func (s *WsServer) AsyncPushToUsers(msg *entity.Message, userIds []string, exclu
 		// Successfully queued
 	default:
 		// Queue full, log warning
+		metrics.WSPushDroppedTotal.Inc()
 		log.Warn("push channel full, message dropped: conversation_id=%s, seq=%d", msg.ConversationId, msg.Seq)
 	}
 }
 
+// asyncPushEvent queues a notification push to users
+func (s *WsServer) asyncPushEvent(reqIdentifier int32, v any, userIds []string) {
+	s.asyncPushEventExcept(reqIdentifier, v, userIds, "")
+}
+
+// asyncPushEventExcept queues a notification push to users, skipping the connection excludeConnId
+func (s *WsServer) asyncPushEventExcept(reqIdentifier int32, v any, userIds []string, excludeConnId string) {
+	data, err := Encode(v)
+	if err != nil {
+		log.Error("encode push event failed: req_identifier=%d, error=%v", reqIdentifier, err)
+		return
+	}
+	task := &PushTask{
+		Event:     &PushEvent{ReqIdentifier: reqIdentifier, Data: data},
+		TargetIds: userIds,
+		ExcludeId: excludeConnId,
+	}
+
+	select {
+	case s.pushChan <- task:
+	default:
+		metrics.WSPushDroppedTotal.Inc()
+		log.Warn("push channel full, event dropped: req_identifier=%d", reqIdentifier)
+	}
+}
+
+// NotifyReadReceipt pushes a read receipt of readerId to userIds
+func (s *WsServer) NotifyReadReceipt(conversationId, readerId string, readSeq int64, userIds []string) {
+	s.asyncPushEvent(WSReadReceipt, &ReadReceiptData{
+		ConversationId: conversationId,
+		UserId:         readerId,
+		ReadSeq:        readSeq,
+	}, userIds)
+}
+
+// NotifyDelivered pushes a delivery receipt of the messages delivered to userId up to
+// deliveredSeq to userIds
+func (s *WsServer) NotifyDelivered(conversationId, userId string, deliveredSeq int64, userIds []string) {
+	s.asyncPushEvent(WSDelivered, &DeliveredData{
+		ConversationId: conversationId,
+		UserId:         userId,
+		DeliveredSeq:   deliveredSeq,
+	}, userIds)
+}
+
+// NotifyReadCounts pushes the new read counts of messages of userId after readerId read them;
+// offline users get the counts when they pull the messages
+func (s *WsServer) NotifyReadCounts(conversationId, readerId string, counts []*service.MessageReadCount, userId string) {
+	data := &ReadCountsData{
+		ConversationId: conversationId,
+		UserId:         readerId,
+		Counts:         make([]*ReadCountData, 0, len(counts)),
+	}
+	for _, count := range counts {
+		data.Counts = append(data.Counts, &ReadCountData{Seq: count.Seq, ReadCount: count.ReadCount})
+	}
+	s.asyncPushEvent(WSReadCounts, data, []string{userId})
+}
+
+// NotifyConversationChanged pushes changed conversation settings to the devices of their owner
+func (s *WsServer) NotifyConversationChanged(userId, conversationId string, req *service.UpdateConversationRequest) {
+	s.asyncPushEvent(WSConversationChanged, &ConversationChangedData{
+		ConversationId: conversationId,
+		RecvMsgOpt:     req.RecvMsgOpt,
+		IsPinned:       req.IsPinned,
+	}, []string{userId})
+}
+
+// NotifyMessageTTLChanged pushes a new disappearing messages timer to the participants of the
+// conversation, the timer being shared unlike the other settings
+func (s *WsServer) NotifyMessageTTLChanged(conversationId string, ttlSeconds int32, userIds []string) {
+	s.asyncPushEvent(WSConversationChanged, &ConversationChangedData{
+		ConversationId: conversationId,
+		TTLSeconds:     &ttlSeconds,
+	}, userIds)
+}
+
+// NotifyMessageEdited pushes an edited message to userIds; offline users get it when they pull
+func (s *WsServer) NotifyMessageEdited(msg *entity.Message, userIds []string) {
+	s.asyncPushEvent(WSMessageEdited, s.messageToMsgData(msg), userIds)
+}
+
+// NotifyMessageRevoked pushes the revoke notification of a recalled message to userIds;
+// offline users get it when they pull
+func (s *WsServer) NotifyMessageRevoked(notice *entity.Message, userIds []string) {
+	s.asyncPushEvent(WSMessageRevoked, s.messageToMsgData(notice), userIds)
+}
+
+// NotifyMessageDeleted pushes a message deleted for everyone to userIds; offline users find it
+// cleared when they pull
+func (s *WsServer) NotifyMessageDeleted(deletion *entity.MessageDeletion, userIds []string) {
+	s.asyncPushEvent(WSMessageDeleted, deletion, userIds)
+}
+
+// NotifyPollUpdated pushes the new result of a poll to userIds; offline users fetch it when
+// they next display the poll
+func (s *WsServer) NotifyPollUpdated(result *entity.PollResult, userIds []string) {
+	s.asyncPushEvent(WSPollUpdated, result, userIds)
+}
+
+// NotifyReactionChanged pushes a reaction change to userIds; offline users get the counts
+// when they pull the message
+func (s *WsServer) NotifyReactionChanged(change *entity.ReactionChange, userIds []string) {
+	s.asyncPushEvent(WSReactionChanged, change, userIds)
+}
+
+// NotifyPinChanged pushes a pin change to userIds; offline users get the pinned seqs with the
+// conversation
+func (s *WsServer) NotifyPinChanged(change *entity.PinChange, userIds []string) {
+	s.asyncPushEvent(WSPinChanged, change, userIds)
+}
+
+// NotifyProfileChanged pushes the new nickname and avatar of a user to userIds; offline users
+// see them when they next fetch the profile
+func (s *WsServer) NotifyProfileChanged(info *entity.UserInfo, userIds []string) {
+	s.asyncPushEvent(WSProfileChanged, &ProfileChangedData{
+		UserId:   info.Id,
+		Nickname: info.Nickname,
+		Avatar:   info.Avatar,
+	}, userIds)
+}
+
+// NotifyCallSignal pushes a call signal to the devices of userIds but the one that sent it
+func (s *WsServer) NotifyCallSignal(signal *service.CallSignal, userIds []string, excludeConnId string) {
+	s.asyncPushEventExcept(WSSignal, signal, userIds, excludeConnId)
+}
+
+// KickUser sends a kick notice to all connections of a user and closes them.
+// Returns the number of kicked connections.
+func (s *WsServer) KickUser(ctx context.Context, userId string) int {
+	clients, ok := s.userMap.GetAll(userId)
+	if !ok {
+		return 0
+	}
+	for _, client := range clients {
+		if err := client.KickOnline(); err != nil {
+			log.CtxWarn(ctx, "kick client failed: user_id=%s, conn_id=%s, error=%v", userId, client.ConnId, err)
+		}
+	}
+	return len(clients)
+}
+
+// KickUserPlatform sends a kick notice to the connections of a user on one platform and closes them.
+// Returns the number of kicked connections.
+func (s *WsServer) KickUserPlatform(ctx context.Context, userId string, platformId int) int {
+	clients, ok := s.userMap.GetByPlatform(userId, platformId)
+	if !ok {
+		return 0
+	}
+	for _, client := range clients {
+		if err := client.KickOnline(); err != nil {
+			log.CtxWarn(ctx, "kick client failed: user_id=%s, conn_id=%s, error=%v", userId, client.ConnId, err)
+		}
+	}
+	return len(clients)
+}
+
 // GetOnlineUserCount returns online user count
 func (s *WsServer) GetOnlineUserCount() int64 {
 	return s.onlineUserNum.Load()
@@ -321,6 +762,43 @@ func (s *WsServer) GetOnlineConnCount() int64 {
 	return s.onlineConnNum.Load()
 }
 
+// QueueStats is a snapshot of the gateway queue depths
+type QueueStats struct {
+	RegisterLen      int `json:"register_len"`
+	RegisterCap      int `json:"register_cap"`
+	UnregisterLen    int `json:"unregister_len"`
+	UnregisterCap    int `json:"unregister_cap"`
+	PushLen          int `json:"push_len"`
+	PushCap          int `json:"push_cap"`
+	ClientWriteTotal int `json:"client_write_total"` // queued writes across all connections
+	ClientWriteMax   int `json:"client_write_max"`   // deepest single connection write queue
+}
+
+// GetQueueStats returns the current depths of the gateway queues
+func (s *WsServer) GetQueueStats() *QueueStats {
+	stats := &QueueStats{
+		RegisterLen:   len(s.registerChan),
+		RegisterCap:   cap(s.registerChan),
+		UnregisterLen: len(s.unregisterChan),
+		UnregisterCap: cap(s.unregisterChan),
+		PushLen:       len(s.pushChan),
+		PushCap:       cap(s.pushChan),
+	}
+
+	for _, client := range s.userMap.GetAllClients() {
+		conn, ok := client.conn.(interface{ QueuedWrites() int })
+		if !ok {
+			continue
+		}
+		queued := conn.QueuedWrites()
+		stats.ClientWriteTotal += queued
+		if queued > stats.ClientWriteMax {
+			stats.ClientWriteMax = queued
+		}
+	}
+	return stats
+}
+
 // OnlineStatusResult represents a user's online status
 type OnlineStatusResult struct {
 	UserId               string                  `json:"user_id"`
@@ -335,6 +813,11 @@ type PlatformStatusDetail struct {
 	ConnId       string `json:"conn_id"`
 }
 
+// IsOnline reports whether a user has a connection on any node
+func (s *WsServer) IsOnline(ctx context.Context, userId string) bool {
+	return s.userMap.IsOnline(ctx, userId)
+}
+
 // GetUsersOnlineStatus returns online status for the given user IDs
 func (s *WsServer) GetUsersOnlineStatus(userIds []string) []*OnlineStatusResult {
 	results := make([]*OnlineStatusResult, 0, len(userIds))
@@ -364,25 +847,63 @@ func (s *WsServer) GetUsersOnlineStatus(userIds []string) []*OnlineStatusResult
 
 func wireContentToEntityContent(content WireMessageContent) entity.MessageContent {
 	return entity.NewMessageContentFromFlat(entity.FlatMessageContent{
-		Text:   content.Text,
-		Image:  content.Image,
-		Video:  content.Video,
-		Audio:  content.Audio,
-		File:   content.File,
-		Custom: content.Custom,
+		Text:      content.Text,
+		Image:     content.Image,
+		Video:     content.Video,
+		Audio:     content.Audio,
+		File:      content.File,
+		Custom:    content.Custom,
+		Encrypted: content.Encrypted,
+
+		ImageThumbnail: content.ImageThumbnail,
+		ImageWidth:     content.ImageWidth,
+		ImageHeight:    content.ImageHeight,
+		AudioDuration:  content.AudioDuration,
+		AudioWaveform:  content.AudioWaveform,
+
+		LinkPreview: (*entity.LinkPreview)(content.LinkPreview),
+		Mentions:    content.Mentions,
+		Poll:        (*entity.PollContent)(content.Poll),
 	})
 }
 
 func entityContentToWireContent(content entity.MessageContent) WireMessageContent {
-	flat := content.ToFlat()
-	return WireMessageContent{
-		Text:   flat.Text,
-		Image:  flat.Image,
-		Video:  flat.Video,
-		Audio:  flat.Audio,
-		File:   flat.File,
-		Custom: flat.Custom,
+	return flatContentToWireContent(content.ToFlat())
+}
+
+func flatContentToWireContent(flat entity.FlatMessageContent) WireMessageContent {
+	wire := WireMessageContent{
+		Text:      flat.Text,
+		Image:     flat.Image,
+		Video:     flat.Video,
+		Audio:     flat.Audio,
+		File:      flat.File,
+		Custom:    flat.Custom,
+		Encrypted: flat.Encrypted,
+
+		ImageThumbnail: flat.ImageThumbnail,
+		ImageWidth:     flat.ImageWidth,
+		ImageHeight:    flat.ImageHeight,
+		AudioDuration:  flat.AudioDuration,
+		AudioWaveform:  flat.AudioWaveform,
+
+		LinkPreview: (*WireLinkPreview)(flat.LinkPreview),
+		Mentions:    flat.Mentions,
+		Poll:        (*WirePoll)(flat.Poll),
+		Revoke:      (*WireRevoke)(flat.Revoke),
+	}
+	if flat.Merged != nil {
+		wire.Merged = &WireMerged{Title: flat.Merged.Title, Messages: make([]*WireMergedMessage, len(flat.Merged.Messages))}
+		for i, m := range flat.Merged.Messages {
+			wire.Merged.Messages[i] = &WireMergedMessage{
+				SenderId: m.SenderId,
+				MsgType:  m.MsgType,
+				Content:  flatContentToWireContent(m.Content),
+				SendAt:   m.SendAt,
+			}
+		}
 	}
+	return wire
 }
 
 // messageToMsgData converts entity.Message to MessageData
@@ -398,7 +919,14 @@ func (s *WsServer) messageToMsgData(msg *entity.Message) *MessageData {
 		SessionType:    msg.SessionType,
 		MsgType:        msg.MsgType,
 		Content:        entityContentToWireContent(msg.Content),
+		Extra:          msg.Extra,
+		ContentHash:    msg.ContentHash,
 		SendAt:         msg.SendAt,
+		RevokedAt:      msg.RevokedAt,
+		EditVersion:    msg.EditVersion,
+		Forwarded:      msg.Forwarded,
+		ExpiresAt:      msg.ExpiresAt,
+		QuotedMsg:      (*WireQuote)(msg.Quote),
 	}
 }
 
@@ -425,6 +953,8 @@ func buildAppPushRequest(ctx context.Context, msg *entity.Message, userId string
 	title := "You have a new message"
 	if msg.SessionType == constant.SessionTypeGroup {
 		title = "You have a new group message"
+	} else if msg.SessionType == constant.SessionTypeSystem {
+		title = "You have a new notification"
 	} else if msg.SessionType == constant.SessionTypeSingle && userInfoProvider != nil {
 		senderIdInt, parseErr := parseUserId(msg.SenderId)
 		if parseErr == nil {
@@ -444,6 +974,17 @@ func buildAppPushRequest(ctx context.Context, msg *entity.Message, userId string
 	}, nil
 }
 
+// applyNotificationSettings hides the sender and content of a push when previews are off and
+// tells the push gateway whether to play a sound and vibrate
+func applyNotificationSettings(req *AppPushRequest, settings *entity.NotificationSettings) {
+	if !settings.ShowPreview {
+		req.Title = "You have a new message"
+		req.Body = "You received a new message"
+	}
+	req.Data["sound"] = settings.Sound
+	req.Data["vibrate"] = settings.Vibrate
+}
+
 func buildPushBody(msg *entity.Message) string {
 	if msg == nil {
 		return "You received a new message"
@@ -461,6 +1002,18 @@ func buildPushBody(msg *entity.Message) string {
 		return "[Audio]"
 	case constant.MsgTypeFile:
 		return "[File]"
+	case constant.MsgTypeEncrypted:
+		return "[Encrypted message]"
+	case constant.MsgTypePoll:
+		if flatMsg.Poll != nil {
+			return "[Poll] " + flatMsg.Poll.Question
+		}
+		return "[Poll]"
+	case constant.MsgTypeMerged:
+		if flatMsg.Merged != nil && flatMsg.Merged.Title != "" {
+			return "[Chat history] " + flatMsg.Merged.Title
+		}
+		return "[Chat history]"
 	case constant.MsgTypeCustom:
 		if flatMsg.Custom != "" {
 			return gjson.Get(flatMsg.Custom, "show_text").String() // 统一约定按这个展示
@@ -506,6 +1059,7 @@ func (s *WsServer) HandleSendMsg(ctx context.Context, client *Client, req *WSReq
 		SessionType: sendReq.SessionType,
 		MsgType:     sendReq.MsgType,
 		Content:     wireContentToEntityContent(sendReq.Content),
+		QuotedMsg:   (*entity.QuotedMessage)(sendReq.QuotedMsg),
 	}
 
 	msg, err := s.msgService.SendMessage(ctx, client.UserId, svcReq)
@@ -524,6 +1078,46 @@ func (s *WsServer) HandleSendMsg(ctx context.Context, client *Client, req *WSReq
 	return json.Marshal(resp)
 }
 
+// HandleSendSignal handles send call signal request
+func (s *WsServer) HandleSendSignal(ctx context.Context, client *Client, req *WSRequest) ([]byte, error) {
+	if s.callService == nil {
+		return nil, ErrInvalidProtocol
+	}
+	var signalReq service.CallSignalRequest
+	if err := json.Unmarshal(req.Data, &signalReq); err != nil {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	call, err := s.callService.Signal(ctx, client.UserId, client.PlatformId, client.ConnId, &signalReq)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(call)
+}
+
+// HandleSendTyping handles typing status request. The status is fanned out to the conversation
+// without being stored; starts repeated within TypingInterval are dropped, see typingThrottle.
+func (s *WsServer) HandleSendTyping(ctx context.Context, client *Client, req *WSRequest) ([]byte, error) {
+	var typingReq service.TypingRequest
+	if err := json.Unmarshal(req.Data, &typingReq); err != nil {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	userIds, err := s.msgService.TypingTargets(ctx, client.UserId, typingReq.ConversationId)
+	if err != nil {
+		return nil, err
+	}
+	key := client.UserId + ":" + typingReq.ConversationId
+	if len(userIds) > 0 && s.typing.allow(key, typingReq.Typing, time.Now()) {
+		s.asyncPushEvent(WSTyping, &TypingData{
+			ConversationId: typingReq.ConversationId,
+			UserId:         client.UserId,
+			Typing:         typingReq.Typing,
+		}, userIds)
+	}
+	return nil, nil
+}
+
 // HandlePullMsg handles pull messages request
 func (s *WsServer) HandlePullMsg(ctx context.Context, client *Client, req *WSRequest) ([]byte, error) {
 	var pullReq PullMsgReq
@@ -545,7 +1139,7 @@ func (s *WsServer) HandlePullMsg(ctx context.Context, client *Client, req *WSReq
 
 	msgDataList := make([]*MessageData, 0, len(messages))
 	for _, msg := range messages {
-		msgDataList = append(msgDataList, s.messageToMsgData(msg))
+		msgDataList = append(msgDataList, s.messageToMsgData(msg.ForDevice(client.UserId, client.PlatformId)))
 	}
 
 	resp := PullMsgResp{
@@ -171,3 +171,83 @@ func TestProcessPushTask_OfflineSingleUserUsesSenderDisplayNameInTitle(t *testin
 		t.Fatalf("expected title from sender display name, got %q", got)
 	}
 }
+
+func TestProcessPushTask_EventSkipsAppPush(t *testing.T) {
+	s := newTestWsServer()
+	mockPush := &mockAppPushSender{}
+	s.SetAppPushSender(mockPush)
+
+	conn := &mockClientConn{}
+	client := NewClient(conn, "200", constant.PlatformIdIOS, "go", "token", "conn-1", s)
+	s.userMap.Register(context.Background(), client)
+
+	s.NotifyReadReceipt("si_100_200", "200", 10, []string{"100", "200"})
+	task := <-s.pushChan
+	if task.Event == nil || task.Event.ReqIdentifier != WSReadReceipt {
+		t.Fatalf("expected read receipt event task, got %+v", task)
+	}
+
+	s.processPushTask(context.Background(), task)
+
+	if conn.writeCount != 1 {
+		t.Fatalf("expected 1 websocket push to online user, got %d", conn.writeCount)
+	}
+	if len(mockPush.calls) != 0 {
+		t.Fatalf("expected no app push for events, got %d", len(mockPush.calls))
+	}
+}
+
+func TestNotifyProfileChanged_PushesOncePerConnection(t *testing.T) {
+	s := newTestWsServer()
+	conn := &mockClientConn{}
+	client := NewClient(conn, "200", constant.PlatformIdIOS, "go", "token", "conn-1", s)
+	s.userMap.Register(context.Background(), client)
+
+	// 200 is both a single chat peer and a group co-member of 100
+	s.NotifyProfileChanged(&entity.UserInfo{Id: "100", Nickname: "Alice"}, []string{"100", "200", "300", "200"})
+	task := <-s.pushChan
+	if task.Event == nil || task.Event.ReqIdentifier != WSProfileChanged {
+		t.Fatalf("expected profile changed event task, got %+v", task)
+	}
+
+	s.processPushTask(context.Background(), task)
+
+	if conn.writeCount != 1 {
+		t.Fatalf("expected 1 websocket push to online contact, got %d", conn.writeCount)
+	}
+}
+
+func TestNotifyHint_FollowsPlatformOverrides(t *testing.T) {
+	muted := true
+	settings := entity.DefaultNotificationSettings("200")
+	settings.PlatformOverrides = map[int]*entity.NotificationOverride{
+		constant.PlatformIdMacOS: {GlobalMute: &muted},
+	}
+
+	if hint := notifyHint(settings, constant.PlatformIdIOS); hint != nil {
+		t.Fatalf("expected no hint with default settings, got %+v", hint)
+	}
+	hint := notifyHint(settings, constant.PlatformIdMacOS)
+	if hint == nil || !hint.Mute || !hint.Sound || !hint.ShowPreview {
+		t.Fatalf("expected muted hint on the overridden platform, got %+v", hint)
+	}
+}
+
+func TestApplyNotificationSettings_HidesPreview(t *testing.T) {
+	settings := entity.DefaultNotificationSettings("200")
+	settings.ShowPreview = false
+	settings.Sound = false
+
+	req, err := buildAppPushRequest(context.Background(), newMessage("100", "200"), "200", nil)
+	if err != nil {
+		t.Fatalf("build app push request failed: %v", err)
+	}
+	applyNotificationSettings(req, settings)
+
+	if req.Body == "hello" {
+		t.Fatalf("expected message content hidden, got %q", req.Body)
+	}
+	if req.Data["sound"] != false || req.Data["vibrate"] != true {
+		t.Fatalf("expected sound and vibrate flags in push data, got %+v", req.Data)
+	}
+}
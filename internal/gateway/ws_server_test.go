@@ -1,9 +1,14 @@
 package gateway
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 )
 
 func TestWsServerMessageToMsgDataKeepsWireShape(t *testing.T) {
@@ -27,3 +32,84 @@ func TestWsServerMessageToMsgDataKeepsWireShape(t *testing.T) {
 		t.Fatalf("expected text content on wire, got %q", data.Content.Text)
 	}
 }
+
+type queuedClientConn struct {
+	mockClientConn
+	queued int
+}
+
+func (m *queuedClientConn) QueuedWrites() int {
+	return m.queued
+}
+
+func TestWsServerGetQueueStats(t *testing.T) {
+	s := newTestWsServer()
+	s.pushChan <- &PushTask{}
+	s.userMap.Register(context.Background(), NewClient(&queuedClientConn{queued: 3}, "100", constant.PlatformIdIOS, "go", "token", "conn-1", s))
+	s.userMap.Register(context.Background(), NewClient(&queuedClientConn{queued: 5}, "200", constant.PlatformIdIOS, "go", "token", "conn-2", s))
+	s.userMap.Register(context.Background(), NewClient(&mockClientConn{}, "300", constant.PlatformIdIOS, "go", "token", "conn-3", s))
+
+	stats := s.GetQueueStats()
+	if stats.PushLen != 1 || stats.PushCap != 16 {
+		t.Fatalf("expected push queue 1/16, got %d/%d", stats.PushLen, stats.PushCap)
+	}
+	if stats.ClientWriteTotal != 8 || stats.ClientWriteMax != 5 {
+		t.Fatalf("expected client write total 8 max 5, got %d/%d", stats.ClientWriteTotal, stats.ClientWriteMax)
+	}
+}
+
+type closeRecordingConn struct {
+	mockClientConn
+	closed bool
+}
+
+func (c *closeRecordingConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWsServerShutdownDeliversQueuedPushesAndClosesConns(t *testing.T) {
+	s := newTestWsServer()
+	conn := &closeRecordingConn{}
+	client := NewClient(conn, "200", constant.PlatformIdIOS, "go", "token", "conn-1", s)
+	s.registerClient(context.Background(), client)
+	s.AsyncPushToUsers(newMessage("100", "200"), []string{"200"}, "")
+
+	s.Run(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if conn.writeCount != 1 {
+		t.Fatalf("expected queued push to be delivered before close, got %d writes", conn.writeCount)
+	}
+	if !conn.closed || !client.IsClosed() {
+		t.Fatal("expected connection to be closed")
+	}
+	if s.GetOnlineConnCount() != 0 || s.userMap.HasConnection("200") {
+		t.Fatalf("expected no connections left, got %d", s.GetOnlineConnCount())
+	}
+
+	w := httptest.NewRecorder()
+	s.HandleConnection(context.Background(), w, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected new connections to be rejected, got %d", w.Code)
+	}
+}
+
+func TestClientScopesRestrictRequests(t *testing.T) {
+	client := &Client{Scopes: []string{"msg:read"}}
+	if client.allows(WSSendMsg) {
+		t.Fatalf("expected read only token to be denied sending")
+	}
+	if !client.allows(WSPullMsg) || client.allows(WSGetConvMaxReadSeq) {
+		t.Fatalf("expected msg:read to allow pulls only")
+	}
+
+	client.Scopes = nil
+	if !client.allows(WSSendMsg) {
+		t.Fatalf("expected unrestricted token to send")
+	}
+}
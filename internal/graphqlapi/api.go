@@ -0,0 +1,126 @@
+// Package graphqlapi serves the read-only GraphQL endpoint (/im/graphql) exposing the
+// client read models, so a client can fetch its home screen in one round trip.
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+)
+
+// UserReader loads user profiles
+type UserReader interface {
+	GetUserInfo(ctx context.Context, userId string) (*entity.UserInfo, error)
+	GetUserInfos(ctx context.Context, viewerId string, userIds []string) ([]*entity.UserInfo, error)
+}
+
+// ConversationReader loads the conversation list of a user
+type ConversationReader interface {
+	GetUserConversationsPage(ctx context.Context, userId string, withLastMessage bool, limit int, cursorUpdatedAt int64, cursorConversationId string) (*service.ConversationListResult, error)
+}
+
+// GroupReader loads groups and their members
+type GroupReader interface {
+	GetGroupInfo(ctx context.Context, groupId string) (*entity.GroupInfo, error)
+	GetGroupMembers(ctx context.Context, groupId string) ([]*entity.GroupMember, error)
+}
+
+// Services holds the business services the GraphQL API reads from
+type Services struct {
+	User         UserReader
+	Conversation ConversationReader
+	Group        GroupReader
+}
+
+// Viewer is the authenticated user running a query
+type Viewer struct {
+	UserId string
+	Scopes []string // nil if unrestricted
+}
+
+// Request is a GraphQL request as posted by clients
+type Request struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// API executes GraphQL queries against the read models
+type API struct {
+	schema graphql.Schema
+}
+
+// New builds the GraphQL schema over services
+func New(services *Services) (*API, error) {
+	schema, err := newSchema(services)
+	if err != nil {
+		return nil, err
+	}
+	return &API{schema: schema}, nil
+}
+
+// Execute runs req as viewer. Field errors are reported in the result, not returned.
+func (a *API) Execute(ctx context.Context, viewer Viewer, req *Request) *graphql.Result {
+	ctx = context.WithValue(ctx, requestKey{}, &requestState{viewer: viewer})
+	return graphql.Do(graphql.Params{
+		Schema:         a.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+}
+
+type requestKey struct{}
+
+// requestState is shared by the resolvers of one request
+type requestState struct {
+	viewer Viewer
+	users  userLoader
+	groups groupLoader
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	return ctx.Value(requestKey{}).(*requestState)
+}
+
+// authorize returns the request state if the viewer may read the route group
+func authorize(ctx context.Context, group string) (*requestState, error) {
+	state := stateFrom(ctx)
+	if state.viewer.UserId == "" {
+		return nil, fieldError(errcode.ErrUnauthorized)
+	}
+	if state.viewer.Scopes != nil && !scope.Allows(state.viewer.Scopes, group, false) {
+		return nil, fieldError(errcode.ErrNoPermission)
+	}
+	return state, nil
+}
+
+// codedError reports the errcode of a failed field in the error extensions
+type codedError struct {
+	code int
+	msg  string
+}
+
+func (e codedError) Error() string {
+	return e.msg
+}
+
+func (e codedError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+// fieldError converts a service error into a GraphQL field error
+func fieldError(err error) error {
+	var e *errcode.Error
+	if !errors.As(err, &e) {
+		e = errcode.ErrInternalServer
+	}
+	return codedError{code: e.Code, msg: e.Msg}
+}
@@ -0,0 +1,221 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+)
+
+type fakeUsers struct {
+	users      map[string]*entity.UserInfo
+	batchCalls int
+}
+
+func (f *fakeUsers) GetUserInfo(ctx context.Context, userId string) (*entity.UserInfo, error) {
+	if u := f.users[userId]; u != nil {
+		return u, nil
+	}
+	return nil, errcode.ErrUserNotFound
+}
+
+func (f *fakeUsers) GetUserInfos(ctx context.Context, viewerId string, userIds []string) ([]*entity.UserInfo, error) {
+	f.batchCalls++
+	var infos []*entity.UserInfo
+	for _, id := range userIds {
+		if u := f.users[id]; u != nil {
+			infos = append(infos, u)
+		}
+	}
+	return infos, nil
+}
+
+type fakeConversations struct {
+	page *service.ConversationListResult
+}
+
+func (f *fakeConversations) GetUserConversationsPage(ctx context.Context, userId string, withLastMessage bool, limit int, cursorUpdatedAt int64, cursorConversationId string) (*service.ConversationListResult, error) {
+	return f.page, nil
+}
+
+type fakeGroups struct {
+	groups  map[string]*entity.GroupInfo
+	members map[string][]*entity.GroupMember
+}
+
+func (f *fakeGroups) GetGroupInfo(ctx context.Context, groupId string) (*entity.GroupInfo, error) {
+	if g := f.groups[groupId]; g != nil {
+		return g, nil
+	}
+	return nil, errcode.ErrGroupNotFound
+}
+
+func (f *fakeGroups) GetGroupMembers(ctx context.Context, groupId string) ([]*entity.GroupMember, error) {
+	return f.members[groupId], nil
+}
+
+func newTestAPI(t *testing.T) (*API, *fakeUsers) {
+	t.Helper()
+	users := &fakeUsers{users: map[string]*entity.UserInfo{
+		"alice": {Id: "alice", Nickname: "Alice"},
+		"bob":   {Id: "bob", Nickname: "Bob"},
+		"carol": {Id: "carol", Nickname: "Carol"},
+	}}
+	convs := &fakeConversations{page: &service.ConversationListResult{
+		List: []*entity.ConversationInfo{
+			{
+				ConversationId: "si_alice_bob",
+				PeerUserId:     "bob",
+				UpdatedAt:      1700000000123,
+				LastMessage:    &entity.MessageInfo{Seq: 7, SenderId: "bob", Content: entity.FlatMessageContent{Text: "hi"}},
+			},
+			{ConversationId: "si_alice_carol", PeerUserId: "carol"},
+			{ConversationId: "sg_g1", GroupId: "g1"},
+		},
+	}}
+	groups := &fakeGroups{
+		groups: map[string]*entity.GroupInfo{"g1": {Id: "g1", Name: "Team", MemberCount: 2}},
+		members: map[string][]*entity.GroupMember{"g1": {
+			{GroupId: "g1", UserId: "alice", RoleLevel: 3},
+			{GroupId: "g1", UserId: "bob", RoleLevel: 1},
+		}},
+	}
+	api, err := New(&Services{User: users, Conversation: convs, Group: groups})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return api, users
+}
+
+// run executes query and decodes the result data into out
+func run(t *testing.T, api *API, viewer Viewer, query string, out any) []map[string]any {
+	t.Helper()
+	result := api.Execute(context.Background(), viewer, &Request{Query: query})
+	raw, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal result: %v", err)
+	}
+	var decoded struct {
+		Data   json.RawMessage  `json:"data"`
+		Errors []map[string]any `json:"errors"`
+	}
+	if err = json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if out != nil {
+		if err = json.Unmarshal(decoded.Data, out); err != nil {
+			t.Fatalf("unmarshal data: %v", err)
+		}
+	}
+	return decoded.Errors
+}
+
+func TestHomeScreenQuery(t *testing.T) {
+	api, users := newTestAPI(t)
+
+	var data struct {
+		Me struct {
+			Nickname string `json:"nickname"`
+		} `json:"me"`
+		Conversations struct {
+			List []struct {
+				ConversationId string `json:"conversation_id"`
+				UpdatedAt      int64  `json:"updated_at"`
+				LastMessage    *struct {
+					Seq     int64 `json:"seq"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"last_message"`
+				Peer *struct {
+					Nickname string `json:"nickname"`
+				} `json:"peer"`
+				Group *struct {
+					Name    string `json:"name"`
+					Members []struct {
+						User struct {
+							Nickname string `json:"nickname"`
+						} `json:"user"`
+					} `json:"members"`
+				} `json:"group"`
+			} `json:"list"`
+		} `json:"conversations"`
+	}
+	errs := run(t, api, Viewer{UserId: "alice"}, `{
+		me { nickname }
+		conversations {
+			list {
+				conversation_id updated_at
+				last_message { seq content { text } }
+				peer { nickname }
+				group { name members { user { nickname } } }
+			}
+		}
+	}`, &data)
+	if len(errs) > 0 {
+		t.Fatalf("errors = %v", errs)
+	}
+
+	if data.Me.Nickname != "Alice" {
+		t.Errorf("me.nickname = %q, want Alice", data.Me.Nickname)
+	}
+	list := data.Conversations.List
+	if len(list) != 3 {
+		t.Fatalf("conversations = %d, want 3", len(list))
+	}
+	if list[0].UpdatedAt != 1700000000123 || list[0].LastMessage == nil || list[0].LastMessage.Content.Text != "hi" {
+		t.Errorf("first conversation = %+v, want updated_at and last message", list[0])
+	}
+	if list[0].Peer == nil || list[0].Peer.Nickname != "Bob" || list[1].Peer == nil || list[1].Peer.Nickname != "Carol" {
+		t.Errorf("peers not resolved: %+v, %+v", list[0].Peer, list[1].Peer)
+	}
+	if list[2].Peer != nil || list[2].Group == nil || list[2].Group.Name != "Team" || len(list[2].Group.Members) != 2 {
+		t.Errorf("group conversation = %+v, want group with 2 members", list[2])
+	}
+	// Peer and member profiles load in a single batch
+	if users.batchCalls != 1 {
+		t.Errorf("GetUserInfos calls = %d, want 1", users.batchCalls)
+	}
+}
+
+func TestFieldScopes(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	var data struct {
+		Me            *struct{ Id string } `json:"me"`
+		Conversations *struct{}            `json:"conversations"`
+	}
+	viewer := Viewer{UserId: "alice", Scopes: []string{scope.Read(scope.User)}}
+	errs := run(t, api, viewer, `{ me { id } conversations { has_more } }`, &data)
+
+	if data.Me == nil || data.Me.Id != "alice" {
+		t.Errorf("me = %+v, want alice", data.Me)
+	}
+	if data.Conversations != nil {
+		t.Errorf("conversations = %+v, want null without conversation scope", data.Conversations)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want 1", errs)
+	}
+	ext, _ := errs[0]["extensions"].(map[string]any)
+	if code, _ := ext["code"].(float64); int(code) != errcode.ErrNoPermission.Code {
+		t.Errorf("error code = %v, want %d", ext["code"], errcode.ErrNoPermission.Code)
+	}
+}
+
+func TestGroupNotFound(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	errs := run(t, api, Viewer{UserId: "alice"}, `{ group(group_id: "missing") { name } }`, nil)
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want 1", errs)
+	}
+	ext, _ := errs[0]["extensions"].(map[string]any)
+	if code, _ := ext["code"].(float64); int(code) != errcode.ErrGroupNotFound.Code {
+		t.Errorf("error code = %v, want %d", ext["code"], errcode.ErrGroupNotFound.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package graphqlapi
+
+import (
+	"context"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// userLoader batches the profile lookups of one request: resolvers queue user ids and
+// return thunks, and the first thunk called loads every queued id with a single query.
+// graphql-go runs resolvers and thunks of a request sequentially, so no locking is needed.
+type userLoader struct {
+	pending []string
+	loaded  map[string]*entity.UserInfo
+}
+
+// load queues userId and returns a thunk resolving to its profile as seen by viewerId, nil if
+// it does not exist
+func (l *userLoader) load(ctx context.Context, users UserReader, viewerId, userId string) func() (any, error) {
+	if _, ok := l.loaded[userId]; !ok {
+		l.pending = append(l.pending, userId)
+	}
+	return func() (any, error) {
+		if len(l.pending) > 0 {
+			ids := l.pending
+			l.pending = nil
+			infos, err := users.GetUserInfos(ctx, viewerId, ids)
+			if err != nil {
+				return nil, fieldError(err)
+			}
+			if l.loaded == nil {
+				l.loaded = make(map[string]*entity.UserInfo, len(ids))
+			}
+			for _, id := range ids {
+				l.loaded[id] = nil
+			}
+			for _, info := range infos {
+				l.loaded[info.Id] = info
+			}
+		}
+		if info := l.loaded[userId]; info != nil {
+			return info, nil
+		}
+		return nil, nil
+	}
+}
+
+// groupLoader memoizes the groups loaded by one request
+type groupLoader struct {
+	loaded map[string]*entity.GroupInfo
+}
+
+func (l *groupLoader) load(ctx context.Context, groups GroupReader, groupId string) (*entity.GroupInfo, error) {
+	if info, ok := l.loaded[groupId]; ok {
+		return info, nil
+	}
+	info, err := groups.GetGroupInfo(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+	if l.loaded == nil {
+		l.loaded = make(map[string]*entity.GroupInfo)
+	}
+	l.loaded[groupId] = info
+	return info, nil
+}
@@ -0,0 +1,294 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+)
+
+// Field names follow the JSON of the HTTP API, so the default resolver maps them
+// onto the entity structs by their json tags.
+
+// longType carries ids, seqs and millisecond timestamps, which overflow the 32-bit Int
+var longType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Long",
+	Description: "64-bit integer",
+	Serialize:   coerceLong,
+	ParseValue:  coerceLong,
+	ParseLiteral: func(value ast.Value) any {
+		if v, ok := value.(*ast.IntValue); ok {
+			if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return n
+			}
+		}
+		return nil
+	},
+})
+
+func coerceLong(value any) any {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+	}
+	return nil
+}
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"nickname":   &graphql.Field{Type: graphql.String},
+		"avatar":     &graphql.Field{Type: graphql.String},
+		"extra":      &graphql.Field{Type: graphql.String},
+		"is_bot":     &graphql.Field{Type: graphql.Boolean},
+		"is_guest":   &graphql.Field{Type: graphql.Boolean},
+		"created_at": &graphql.Field{Type: longType},
+	},
+})
+
+var linkPreviewType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LinkPreview",
+	Fields: graphql.Fields{
+		"url":         &graphql.Field{Type: graphql.String},
+		"title":       &graphql.Field{Type: graphql.String},
+		"description": &graphql.Field{Type: graphql.String},
+		"image_url":   &graphql.Field{Type: graphql.String},
+		"site_name":   &graphql.Field{Type: graphql.String},
+	},
+})
+
+var messageContentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "MessageContent",
+	Fields: graphql.Fields{
+		"text":            &graphql.Field{Type: graphql.String},
+		"link_preview":    &graphql.Field{Type: linkPreviewType},
+		"image":           &graphql.Field{Type: graphql.String},
+		"image_thumbnail": &graphql.Field{Type: graphql.String},
+		"image_width":     &graphql.Field{Type: graphql.Int},
+		"image_height":    &graphql.Field{Type: graphql.Int},
+		"video":           &graphql.Field{Type: graphql.String},
+		"audio":           &graphql.Field{Type: graphql.String},
+		"audio_duration":  &graphql.Field{Type: graphql.Int},
+		"audio_waveform":  &graphql.Field{Type: graphql.NewList(graphql.Int)},
+		"file":            &graphql.Field{Type: graphql.String},
+		"custom":          &graphql.Field{Type: graphql.String},
+	},
+})
+
+var messageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Message",
+	Fields: graphql.Fields{
+		"id":              &graphql.Field{Type: longType},
+		"conversation_id": &graphql.Field{Type: graphql.String},
+		"seq":             &graphql.Field{Type: longType},
+		"client_msg_id":   &graphql.Field{Type: graphql.String},
+		"sender_id":       &graphql.Field{Type: graphql.String},
+		"session_type":    &graphql.Field{Type: graphql.Int},
+		"msg_type":        &graphql.Field{Type: graphql.Int},
+		"content":         &graphql.Field{Type: messageContentType},
+		"extra":           &graphql.Field{Type: graphql.String},
+		"content_hash":    &graphql.Field{Type: graphql.String},
+		"send_at":         &graphql.Field{Type: longType},
+	},
+})
+
+var cursorType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ConversationListCursor",
+	Fields: graphql.Fields{
+		"updated_at":      &graphql.Field{Type: longType},
+		"conversation_id": &graphql.Field{Type: graphql.String},
+	},
+})
+
+func newSchema(services *Services) (graphql.Schema, error) {
+	// Members resolve their profiles through the request's user loader
+	memberType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "GroupMember",
+		Fields: graphql.Fields{
+			"user_id":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"group_nickname": &graphql.Field{Type: graphql.String},
+			"group_avatar":   &graphql.Field{Type: graphql.String},
+			"role_level":     &graphql.Field{Type: graphql.Int},
+			"joined_at":      &graphql.Field{Type: longType},
+			"muted_until":    &graphql.Field{Type: longType},
+			"user": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					state, err := authorize(p.Context, scope.User)
+					if err != nil {
+						return nil, err
+					}
+					return state.users.load(p.Context, services.User, state.viewer.UserId, p.Source.(*entity.GroupMember).UserId), nil
+				},
+			},
+		},
+	})
+
+	groupType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Group",
+		Fields: graphql.Fields{
+			"id":                      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":                    &graphql.Field{Type: graphql.String},
+			"introduction":            &graphql.Field{Type: graphql.String},
+			"avatar":                  &graphql.Field{Type: graphql.String},
+			"status":                  &graphql.Field{Type: graphql.Int},
+			"creator_user_id":         &graphql.Field{Type: graphql.String},
+			"member_count":            &graphql.Field{Type: longType},
+			"mute_all":                &graphql.Field{Type: graphql.Boolean},
+			"announcement":            &graphql.Field{Type: graphql.String},
+			"announcement_updated_at": &graphql.Field{Type: longType},
+			"created_at":              &graphql.Field{Type: longType},
+			"members": &graphql.Field{
+				Type: graphql.NewList(memberType),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					members, err := services.Group.GetGroupMembers(p.Context, p.Source.(*entity.GroupInfo).Id)
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					return members, nil
+				},
+			},
+		},
+	})
+
+	conversationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Conversation",
+		Fields: graphql.Fields{
+			"conversation_id":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"conversation_type": &graphql.Field{Type: graphql.Int},
+			"peer_user_id":      &graphql.Field{Type: graphql.String},
+			"group_id":          &graphql.Field{Type: graphql.String},
+			"recv_msg_opt":      &graphql.Field{Type: graphql.Int},
+			"is_pinned":         &graphql.Field{Type: graphql.Boolean},
+			"unread_count":      &graphql.Field{Type: longType},
+			"max_seq":           &graphql.Field{Type: longType},
+			"read_seq":          &graphql.Field{Type: longType},
+			"updated_at":        &graphql.Field{Type: longType},
+			"last_message":      &graphql.Field{Type: messageType},
+			"peer": &graphql.Field{
+				Type:        userType,
+				Description: "Profile of the other user of a single chat",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					conv := p.Source.(*entity.ConversationInfo)
+					if conv.PeerUserId == "" {
+						return nil, nil
+					}
+					state, err := authorize(p.Context, scope.User)
+					if err != nil {
+						return nil, err
+					}
+					return state.users.load(p.Context, services.User, state.viewer.UserId, conv.PeerUserId), nil
+				},
+			},
+			"group": &graphql.Field{
+				Type:        groupType,
+				Description: "Group of a group chat",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					conv := p.Source.(*entity.ConversationInfo)
+					if conv.GroupId == "" {
+						return nil, nil
+					}
+					state, err := authorize(p.Context, scope.Group)
+					if err != nil {
+						return nil, err
+					}
+					info, err := state.groups.load(p.Context, services.Group, conv.GroupId)
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					return info, nil
+				},
+			},
+		},
+	})
+
+	conversationPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ConversationPage",
+		Fields: graphql.Fields{
+			"list":        &graphql.Field{Type: graphql.NewList(conversationType)},
+			"has_more":    &graphql.Field{Type: graphql.Boolean},
+			"next_cursor": &graphql.Field{Type: cursorType},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					state, err := authorize(p.Context, scope.User)
+					if err != nil {
+						return nil, err
+					}
+					info, err := services.User.GetUserInfo(p.Context, state.viewer.UserId)
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					return info, nil
+				},
+			},
+			"conversations": &graphql.Field{
+				Type:        conversationPageType,
+				Description: "Conversations of the viewer, most recently updated first, with their last message",
+				Args: graphql.FieldConfigArgument{
+					"limit":                  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: service.DefaultConversationListLimit},
+					"cursor_updated_at":      &graphql.ArgumentConfig{Type: longType},
+					"cursor_conversation_id": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					state, err := authorize(p.Context, scope.Conversation)
+					if err != nil {
+						return nil, err
+					}
+					limit, _ := p.Args["limit"].(int)
+					cursorUpdatedAt, _ := p.Args["cursor_updated_at"].(int64)
+					cursorConversationId, _ := p.Args["cursor_conversation_id"].(string)
+					page, err := services.Conversation.GetUserConversationsPage(p.Context, state.viewer.UserId, true, limit, cursorUpdatedAt, cursorConversationId)
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					return page, nil
+				},
+			},
+			"group": &graphql.Field{
+				Type: groupType,
+				Args: graphql.FieldConfigArgument{
+					"group_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					state, err := authorize(p.Context, scope.Group)
+					if err != nil {
+						return nil, err
+					}
+					info, err := state.groups.load(p.Context, services.Group, p.Args["group_id"].(string))
+					if err != nil {
+						return nil, fieldError(err)
+					}
+					return info, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
@@ -0,0 +1,183 @@
+package grpcapi
+
+import (
+	"context"
+
+	imv1 "github.com/ZaiSpace/nexo_im/api/im/v1"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/validate"
+)
+
+// Request checks, the same rules as the HTTP request bodies
+
+type sendMessageRequest struct {
+	ClientMsgId string `json:"client_msg_id" validate:"required,max=64"`
+	RecvId      string `json:"recv_id" validate:"max=64"`
+	GroupId     string `json:"group_id" validate:"max=64"`
+}
+
+type userIdsRequest struct {
+	UserIds []string `json:"user_ids" validate:"required,max=100"`
+}
+
+type userIdRequest struct {
+	UserId string `json:"user_id" validate:"required,max=64"`
+}
+
+type groupIdRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+}
+
+type conversationListRequest struct {
+	Limit                int32  `json:"limit" validate:"min=0,max=100"`
+	CursorUpdatedAt      int64  `json:"cursor.updated_at" validate:"min=0"`
+	CursorConversationId string `json:"cursor.conversation_id" validate:"max=256"`
+}
+
+// checkRequest validates v, reporting the offending fields in the error message
+func checkRequest(v any) error {
+	if errs := validate.Struct(v); errs != nil {
+		return errcode.ErrInvalidParam.Wrap(errs)
+	}
+	return nil
+}
+
+// requireUser returns the acting user id of the call
+func requireUser(ctx context.Context) (string, error) {
+	user, ok := userFromContext(ctx)
+	if !ok {
+		return "", errcode.ErrUnauthorized
+	}
+	return user.UserId, nil
+}
+
+type messageServer struct {
+	imv1.UnimplementedMessageServiceServer
+	msgService *service.MessageService
+}
+
+// SendMessage sends a message as the acting user, like POST /internal/msg/send
+func (s *messageServer) SendMessage(ctx context.Context, req *imv1.SendMessageRequest) (*imv1.MessageInfo, error) {
+	userId, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkRequest(&sendMessageRequest{ClientMsgId: req.ClientMsgId, RecvId: req.RecvId, GroupId: req.GroupId}); err != nil {
+		return nil, err
+	}
+
+	msg, err := s.msgService.SendMessage(ctx, userId, &service.SendMessageRequest{
+		ClientMsgId: req.ClientMsgId,
+		RecvId:      req.RecvId,
+		GroupId:     req.GroupId,
+		SessionType: req.SessionType,
+		MsgType:     req.MsgType,
+		Content:     entity.NewMessageContentFromFlat(toFlatContent(req.Content)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toMessageInfo(msg.ToMessageInfo()), nil
+}
+
+type userServer struct {
+	imv1.UnimplementedUserServiceServer
+	userService *service.UserService
+}
+
+// GetUserInfo returns one user, like GET /internal/user/profile/:user_id
+func (s *userServer) GetUserInfo(ctx context.Context, req *imv1.GetUserInfoRequest) (*imv1.UserInfo, error) {
+	if err := checkRequest(&userIdRequest{UserId: req.UserId}); err != nil {
+		return nil, err
+	}
+	user, err := s.userService.GetUserInfoFor(ctx, "", req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return toUserInfo(user), nil
+}
+
+// GetUsersInfo returns several users, like POST /internal/user/batch_info
+func (s *userServer) GetUsersInfo(ctx context.Context, req *imv1.GetUsersInfoRequest) (*imv1.GetUsersInfoResponse, error) {
+	if err := checkRequest(&userIdsRequest{UserIds: req.UserIds}); err != nil {
+		return nil, err
+	}
+	users, err := s.userService.GetUserInfos(ctx, "", req.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	resp := &imv1.GetUsersInfoResponse{Users: make([]*imv1.UserInfo, 0, len(users))}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toUserInfo(u))
+	}
+	return resp, nil
+}
+
+type groupServer struct {
+	imv1.UnimplementedGroupServiceServer
+	groupService *service.GroupService
+}
+
+// GetGroupInfo returns the group, like GET /internal/group/info
+func (s *groupServer) GetGroupInfo(ctx context.Context, req *imv1.GetGroupInfoRequest) (*imv1.GroupInfo, error) {
+	if err := checkRequest(&groupIdRequest{GroupId: req.GroupId}); err != nil {
+		return nil, err
+	}
+	group, err := s.groupService.GetGroupInfo(ctx, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return toGroupInfo(group), nil
+}
+
+// GetGroupMembers returns the active members, like GET /internal/group/members
+func (s *groupServer) GetGroupMembers(ctx context.Context, req *imv1.GetGroupMembersRequest) (*imv1.GetGroupMembersResponse, error) {
+	if err := checkRequest(&groupIdRequest{GroupId: req.GroupId}); err != nil {
+		return nil, err
+	}
+	members, err := s.groupService.GetGroupMembers(ctx, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &imv1.GetGroupMembersResponse{Members: make([]*imv1.GroupMember, 0, len(members))}
+	for _, m := range members {
+		resp.Members = append(resp.Members, toGroupMember(m))
+	}
+	return resp, nil
+}
+
+type conversationServer struct {
+	imv1.UnimplementedConversationServiceServer
+	convService *service.ConversationService
+}
+
+// GetConversationList returns a page of the acting user's conversations, like
+// GET /internal/conversation/list
+func (s *conversationServer) GetConversationList(ctx context.Context, req *imv1.GetConversationListRequest) (*imv1.GetConversationListResponse, error) {
+	userId, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// GetUpdatedAt and GetConversationId are nil-safe, a missing cursor reads as the first page
+	cursorUpdatedAt, cursorConversationId := req.Cursor.GetUpdatedAt(), req.Cursor.GetConversationId()
+	if err = checkRequest(&conversationListRequest{
+		Limit:                req.Limit,
+		CursorUpdatedAt:      cursorUpdatedAt,
+		CursorConversationId: cursorConversationId,
+	}); err != nil {
+		return nil, err
+	}
+	// The cursor is only meaningful as a pair
+	if (cursorUpdatedAt > 0) != (cursorConversationId != "") {
+		return nil, errcode.ErrInvalidParam.Wrap(validate.Errors{{Field: "cursor", Reason: validate.ReasonRequired}})
+	}
+
+	result, err := s.convService.GetUserConversationsPage(ctx, userId, req.WithLastMessage, int(req.Limit),
+		cursorUpdatedAt, cursorConversationId)
+	if err != nil {
+		return nil, err
+	}
+	return toConversationListResponse(result), nil
+}
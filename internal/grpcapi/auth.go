@@ -0,0 +1,256 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+// Call metadata, the lower-case forms of the internal route headers
+var (
+	ServiceNameMetadata = strings.ToLower(signature.ServiceNameHeader)
+	TimestampMetadata   = strings.ToLower(signature.TimestampHeader)
+	SignatureMetadata   = strings.ToLower(signature.SignatureHeader)
+	UserIdMetadata      = strings.ToLower(middleware.InternalUserIdHeader)
+	PlatformIdMetadata  = strings.ToLower(middleware.InternalPlatformIdHeader)
+	TraceIdMetadata     = strings.ToLower(middleware.XTraceIDHeader)
+)
+
+// Audit actions, the same as the HTTP internal-auth middleware
+const (
+	auditActionInternalAuth = "internal_auth"
+	auditActionInternalCall = "internal_call"
+	auditActionIPAccess     = "ip_access"
+)
+
+// SignCall returns the signature of a token-auth call: the internal-auth signature of a POST
+// to the full gRPC method with an empty body
+func SignCall(secret, serviceName, timestamp, fullMethod string) string {
+	return signature.Sign(secret, serviceName, timestamp, http.MethodPost, fullMethod, nil)
+}
+
+type actingUserKey struct{}
+
+// actingUser is the user a call acts as
+type actingUser struct {
+	UserId     string
+	PlatformId int
+}
+
+// userFromContext returns the acting user set by the auth interceptor
+func userFromContext(ctx context.Context) (actingUser, bool) {
+	user, ok := ctx.Value(actingUserKey{}).(actingUser)
+	return user, ok && user.UserId != ""
+}
+
+// authenticator checks the calling service and the acting user of every call
+type authenticator struct {
+	cfg   *config.Config
+	allow []netip.Prefix // ip_access.internal
+	deny  []netip.Prefix
+	now   func() time.Time
+}
+
+func newAuthenticator(cfg *config.Config) *authenticator {
+	a := &authenticator{cfg: cfg, now: time.Now}
+	if cfg.IPAccess.Enabled {
+		// Entries were validated by config.Load
+		a.allow, _ = config.ParseIPRanges(cfg.IPAccess.Internal.Allow)
+		a.deny, _ = config.ParseIPRanges(cfg.IPAccess.Internal.Deny)
+	}
+	return a
+}
+
+// unaryInterceptor authenticates the call, runs the handler as the acting user and records the
+// call in the audit trail. Handlers return service errors, converted to statuses here.
+func (a *authenticator) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = middleware.WithTraceID(ctx, traceIdOf(md))
+	clientIP := peerIP(ctx)
+
+	if !a.permitsIP(clientIP) {
+		a.recordDenied(ctx, info.FullMethod, clientIP, auditActionIPAccess, "", errcode.ErrForbidden)
+		return nil, toStatus(ctx, errcode.ErrForbidden)
+	}
+
+	serviceName, authErr := a.authenticate(ctx, md, info.FullMethod)
+	if authErr != nil {
+		a.recordDenied(ctx, info.FullMethod, clientIP, auditActionInternalAuth, serviceName, authErr)
+		return nil, toStatus(ctx, authErr)
+	}
+
+	user := actingUser{UserId: firstValue(md, UserIdMetadata), PlatformId: constant.PlatformIdWeb}
+	if user.UserId == "" {
+		a.recordDenied(ctx, info.FullMethod, clientIP, auditActionInternalAuth, serviceName, errcode.ErrUnauthorized)
+		return nil, toStatus(ctx, errcode.ErrUnauthorized)
+	}
+	if platform := firstValue(md, PlatformIdMetadata); platform != "" {
+		pid, err := strconv.Atoi(platform)
+		if err != nil || pid <= 0 {
+			return nil, toStatus(ctx, errcode.ErrInvalidParam)
+		}
+		user.PlatformId = pid
+	}
+
+	resp, err := handler(context.WithValue(ctx, actingUserKey{}, user), req)
+
+	event := &audit.Event{
+		Category: audit.CategoryInternal,
+		Action:   auditActionInternalCall,
+		Actor:    serviceName,
+		Path:     info.FullMethod,
+		ClientIP: clientIP.String(),
+		Detail: map[string]any{
+			"method":      "grpc",
+			"user_id":     user.UserId,
+			"platform_id": user.PlatformId,
+		},
+	}
+	if err != nil {
+		event.Code = errorCode(err)
+		err = toStatus(ctx, err)
+	}
+	audit.Record(ctx, event)
+	return resp, err
+}
+
+// authenticate returns the calling service: the client certificate common name with mtls auth,
+// the signed x-service-name with token auth
+func (a *authenticator) authenticate(ctx context.Context, md metadata.MD, fullMethod string) (string, *errcode.Error) {
+	internalAuth := &a.cfg.InternalAuth
+	if a.cfg.GRPC.Auth == config.GRPCAuthMTLS {
+		serviceName := clientCertName(ctx)
+		if serviceName == "" {
+			return "", errcode.ErrUnauthorized
+		}
+		if !isServiceAllowed(serviceName, internalAuth.AllowedServices) {
+			return serviceName, errcode.ErrForbidden
+		}
+		return serviceName, nil
+	}
+
+	if !internalAuth.Enabled || strings.TrimSpace(internalAuth.Secret) == "" {
+		return "", errcode.ErrForbidden
+	}
+	serviceName := firstValue(md, ServiceNameMetadata)
+	ts := firstValue(md, TimestampMetadata)
+	sig := firstValue(md, SignatureMetadata)
+	if serviceName == "" || ts == "" || sig == "" {
+		return serviceName, errcode.ErrUnauthorized
+	}
+	if !isServiceAllowed(serviceName, internalAuth.AllowedServices) {
+		return serviceName, errcode.ErrForbidden
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return serviceName, errcode.ErrUnauthorized
+	}
+	if skew := a.now().Unix() - unix; skew > internalAuth.MaxSkewSeconds || -skew > internalAuth.MaxSkewSeconds {
+		return serviceName, errcode.ErrUnauthorized
+	}
+	if !signature.Verify(internalAuth.Secret, serviceName, ts, http.MethodPost, fullMethod, nil, sig) {
+		return serviceName, errcode.ErrUnauthorized
+	}
+	return serviceName, nil
+}
+
+// permitsIP applies ip_access.internal to the peer address; proxy headers are not trusted
+func (a *authenticator) permitsIP(addr netip.Addr) bool {
+	if !a.cfg.IPAccess.Enabled {
+		return true
+	}
+	if !addr.IsValid() || containsAddr(a.deny, addr) {
+		return false
+	}
+	return len(a.allow) == 0 || containsAddr(a.allow, addr)
+}
+
+// recordDenied records a call rejected before reaching its handler
+func (a *authenticator) recordDenied(ctx context.Context, fullMethod string, clientIP netip.Addr, action, actor string, e *errcode.Error) {
+	audit.Record(ctx, &audit.Event{
+		Category: audit.CategoryPermission,
+		Action:   action,
+		Actor:    actor,
+		Path:     fullMethod,
+		ClientIP: clientIP.String(),
+		Code:     e.Code,
+	})
+}
+
+// clientCertName returns the common name of the verified client certificate
+func clientCertName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(tlsInfo.State.VerifiedChains[0][0].Subject.CommonName)
+}
+
+func peerIP(ctx context.Context) netip.Addr {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr.Unmap()
+}
+
+func traceIdOf(md metadata.MD) string {
+	if traceId := firstValue(md, TraceIdMetadata); traceId != "" {
+		return traceId
+	}
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+func isServiceAllowed(serviceName string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, s := range allowed {
+		if strings.EqualFold(strings.TrimSpace(s), serviceName) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
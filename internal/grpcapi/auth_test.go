@@ -0,0 +1,145 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const testMethod = "/nexo.im.v1.UserService/GetUserInfo"
+
+func testConfig() *config.Config {
+	return &config.Config{
+		InternalAuth: config.InternalAuthConfig{
+			Enabled:         true,
+			Secret:          "secret",
+			AllowedServices: []string{"svc"},
+			MaxSkewSeconds:  300,
+		},
+		GRPC: config.GRPCConfig{Enabled: true, Auth: config.GRPCAuthToken},
+	}
+}
+
+func signedContext(serviceName, secret string, at time.Time, pairs ...string) context.Context {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	md := metadata.Pairs(
+		ServiceNameMetadata, serviceName,
+		TimestampMetadata, ts,
+		SignatureMetadata, SignCall(secret, serviceName, ts, testMethod),
+	)
+	md = metadata.Join(md, metadata.Pairs(pairs...))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+}
+
+// callAs runs the interceptor around a handler reporting the acting user
+func callAs(t *testing.T, a *authenticator, ctx context.Context) (actingUser, error) {
+	t.Helper()
+	var got actingUser
+	_, err := a.unaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, _ any) (any, error) {
+		got, _ = userFromContext(ctx)
+		return nil, nil
+	})
+	return got, err
+}
+
+func TestTokenAuth(t *testing.T) {
+	a := newAuthenticator(testConfig())
+	now := time.Now()
+
+	user, err := callAs(t, a, signedContext("svc", "secret", now, UserIdMetadata, "u1", PlatformIdMetadata, "2"))
+	if err != nil {
+		t.Fatalf("expected signed call to pass, got %v", err)
+	}
+	if user.UserId != "u1" || user.PlatformId != 2 {
+		t.Fatalf("expected acting user u1 on platform 2, got %+v", user)
+	}
+	if user, _ = callAs(t, a, signedContext("svc", "secret", now, UserIdMetadata, "u1")); user.PlatformId != 5 {
+		t.Fatalf("expected web platform by default, got %d", user.PlatformId)
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"wrong secret", signedContext("svc", "other", now, UserIdMetadata, "u1"), codes.Unauthenticated},
+		{"stale timestamp", signedContext("svc", "secret", now.Add(-time.Hour), UserIdMetadata, "u1"), codes.Unauthenticated},
+		{"unknown service", signedContext("other", "secret", now, UserIdMetadata, "u1"), codes.PermissionDenied},
+		{"no acting user", signedContext("svc", "secret", now), codes.Unauthenticated},
+		{"bad platform", signedContext("svc", "secret", now, UserIdMetadata, "u1", PlatformIdMetadata, "x"), codes.InvalidArgument},
+		{"no metadata", context.Background(), codes.Unauthenticated},
+	}
+	for _, c := range cases {
+		if _, err := callAs(t, a, c.ctx); status.Code(err) != c.code {
+			t.Errorf("%s: expected %v, got %v", c.name, c.code, err)
+		}
+	}
+}
+
+func TestMTLSAuth(t *testing.T) {
+	cfg := testConfig()
+	cfg.GRPC.Auth = config.GRPCAuthMTLS
+	a := newAuthenticator(cfg)
+
+	withCert := func(commonName string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		info := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(UserIdMetadata, "u1"))
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1")}, AuthInfo: info})
+	}
+
+	if user, err := callAs(t, a, withCert("svc")); err != nil || user.UserId != "u1" {
+		t.Fatalf("expected certificate of an allowed service to pass, got %+v, %v", user, err)
+	}
+	if _, err := callAs(t, a, withCert("other")); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected unknown service to be denied, got %v", err)
+	}
+	// A signed token is not enough without a client certificate
+	if _, err := callAs(t, a, signedContext("svc", "secret", time.Now(), UserIdMetadata, "u1")); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected call without certificate to be rejected, got %v", err)
+	}
+}
+
+func TestIPAccess(t *testing.T) {
+	cfg := testConfig()
+	cfg.IPAccess = config.IPAccessConfig{Enabled: true, Internal: config.IPAccessList{Allow: []string{"192.168.0.0/16"}}}
+	a := newAuthenticator(cfg)
+	if _, err := callAs(t, a, signedContext("svc", "secret", time.Now(), UserIdMetadata, "u1")); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected peer outside ip_access.internal to be denied, got %v", err)
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{errcode.ErrGroupNotFound, codes.NotFound},
+		{errcode.ErrInvalidParam.Wrap(context.Canceled), codes.InvalidArgument},
+		{errcode.ErrTokenExpired, codes.Unauthenticated},
+		{errcode.ErrMemberMuted, codes.FailedPrecondition},
+		{context.Canceled, codes.Internal},
+	}
+	for _, c := range cases {
+		st, _ := status.FromError(toStatus(context.Background(), c.err))
+		if st.Code() != c.code {
+			t.Errorf("%v: expected %v, got %v", c.err, c.code, st.Code())
+		}
+	}
+}
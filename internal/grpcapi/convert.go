@@ -0,0 +1,124 @@
+package grpcapi
+
+import (
+	imv1 "github.com/ZaiSpace/nexo_im/api/im/v1"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+)
+
+func toFlatContent(c *imv1.MessageContent) entity.FlatMessageContent {
+	if c == nil {
+		return entity.FlatMessageContent{}
+	}
+	return entity.FlatMessageContent{
+		Text:   c.Text,
+		Image:  c.Image,
+		Video:  c.Video,
+		Audio:  c.Audio,
+		File:   c.File,
+		Custom: c.Custom,
+	}
+}
+
+func toMessageInfo(m *entity.MessageInfo) *imv1.MessageInfo {
+	if m == nil {
+		return nil
+	}
+	return &imv1.MessageInfo{
+		Id:             m.Id,
+		ConversationId: m.ConversationId,
+		Seq:            m.Seq,
+		ClientMsgId:    m.ClientMsgId,
+		SenderId:       m.SenderId,
+		SessionType:    m.SessionType,
+		MsgType:        m.MsgType,
+		Content: &imv1.MessageContent{
+			Text:   m.Content.Text,
+			Image:  m.Content.Image,
+			Video:  m.Content.Video,
+			Audio:  m.Content.Audio,
+			File:   m.Content.File,
+			Custom: m.Content.Custom,
+		},
+		Extra:     m.Extra,
+		SendAt:    m.SendAt,
+		DeletedAt: m.DeletedAt,
+	}
+}
+
+func toUserInfo(u *entity.UserInfo) *imv1.UserInfo {
+	return &imv1.UserInfo{
+		Id:        u.Id,
+		Nickname:  u.Nickname,
+		Avatar:    u.Avatar,
+		Extra:     u.Extra,
+		IsBot:     u.IsBot,
+		IsGuest:   u.IsGuest,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func toGroupInfo(g *entity.GroupInfo) *imv1.GroupInfo {
+	return &imv1.GroupInfo{
+		Id:                    g.Id,
+		Name:                  g.Name,
+		Introduction:          g.Introduction,
+		Avatar:                g.Avatar,
+		Status:                g.Status,
+		CreatorUserId:         g.CreatorUserId,
+		MemberCount:           g.MemberCount,
+		MuteAll:               g.MuteAll,
+		Announcement:          g.Announcement,
+		AnnouncementUpdatedAt: g.AnnouncementUpdatedAt,
+		CreatedAt:             g.CreatedAt,
+	}
+}
+
+func toGroupMember(m *entity.GroupMember) *imv1.GroupMember {
+	return &imv1.GroupMember{
+		GroupId:       m.GroupId,
+		UserId:        m.UserId,
+		GroupNickname: m.GroupNickname,
+		GroupAvatar:   m.GroupAvatar,
+		Extra:         m.Extra,
+		RoleLevel:     m.RoleLevel,
+		Status:        m.Status,
+		JoinedAt:      m.JoinedAt,
+		JoinSeq:       m.JoinSeq,
+		InviterUserId: m.InviterUserId,
+		MutedUntil:    m.MutedUntil,
+	}
+}
+
+func toConversationInfo(c *entity.ConversationInfo) *imv1.ConversationInfo {
+	return &imv1.ConversationInfo{
+		ConversationId:   c.ConversationId,
+		ConversationType: c.ConversationType,
+		PeerUserId:       c.PeerUserId,
+		GroupId:          c.GroupId,
+		RecvMsgOpt:       c.RecvMsgOpt,
+		IsPinned:         c.IsPinned,
+		UnreadCount:      c.UnreadCount,
+		MaxSeq:           c.MaxSeq,
+		ReadSeq:          c.ReadSeq,
+		UpdatedAt:        c.UpdatedAt,
+		LastMessage:      toMessageInfo(c.LastMessage),
+	}
+}
+
+func toConversationListResponse(r *service.ConversationListResult) *imv1.GetConversationListResponse {
+	resp := &imv1.GetConversationListResponse{
+		List:    make([]*imv1.ConversationInfo, 0, len(r.List)),
+		HasMore: r.HasMore,
+	}
+	for _, c := range r.List {
+		resp.List = append(resp.List, toConversationInfo(c))
+	}
+	if r.NextCursor != nil {
+		resp.NextCursor = &imv1.ConversationListCursor{
+			UpdatedAt:      r.NextCursor.UpdatedAt,
+			ConversationId: r.NextCursor.ConversationId,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,72 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// ErrorCodeTrailer carries the errcode of a failed call, the same code the HTTP API returns
+const ErrorCodeTrailer = "x-error-code"
+
+// grpcCodes maps errcodes to the closest gRPC status code; unlisted ones map by range
+var grpcCodes = map[int]codes.Code{
+	errcode.ErrInvalidParam.Code:       codes.InvalidArgument,
+	errcode.ErrInternalServer.Code:     codes.Internal,
+	errcode.ErrUnauthorized.Code:       codes.Unauthenticated,
+	errcode.ErrForbidden.Code:          codes.PermissionDenied,
+	errcode.ErrNotFound.Code:           codes.NotFound,
+	errcode.ErrTooManyRequests.Code:    codes.ResourceExhausted,
+	errcode.ErrNoPermission.Code:       codes.PermissionDenied,
+	errcode.ErrRequestTimeout.Code:     codes.DeadlineExceeded,
+	errcode.ErrUserNotFound.Code:       codes.NotFound,
+	errcode.ErrGroupNotFound.Code:      codes.NotFound,
+	errcode.ErrNotGroupMember.Code:     codes.PermissionDenied,
+	errcode.ErrNotGroupOwner.Code:      codes.PermissionDenied,
+	errcode.ErrNotGroupAdmin.Code:      codes.PermissionDenied,
+	errcode.ErrAlreadyGroupMember.Code: codes.AlreadyExists,
+	errcode.ErrMessageNotFound.Code:    codes.NotFound,
+	errcode.ErrConvNotFound.Code:       codes.NotFound,
+	errcode.ErrSeqAllocFailed.Code:     codes.Internal,
+	errcode.ErrSendFailed.Code:         codes.Internal,
+	errcode.ErrPullFailed.Code:         codes.Internal,
+}
+
+// toStatus converts a service error to a gRPC status error and sets the errcode trailer
+func toStatus(ctx context.Context, err error) error {
+	var e *errcode.Error
+	if !errors.As(err, &e) {
+		return status.Error(codes.Internal, errcode.ErrInternalServer.Msg)
+	}
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(ErrorCodeTrailer, strconv.Itoa(e.Code)))
+	return status.Error(grpcCode(e.Code), e.Msg)
+}
+
+// errorCode returns the errcode of a service error
+func errorCode(err error) int {
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return errcode.ErrInternalServer.Code
+}
+
+func grpcCode(code int) codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+	switch {
+	case code >= 2000 && code < 3000:
+		return codes.Unauthenticated
+	default:
+		// Business rule violations, e.g. muted members or dismissed groups
+		return codes.FailedPrecondition
+	}
+}
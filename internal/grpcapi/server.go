@@ -0,0 +1,80 @@
+// Package grpcapi serves the gRPC API defined in api/im/v1, mirroring the internal HTTP routes
+// for service-to-service calls.
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/mbeoliero/kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	imv1 "github.com/ZaiSpace/nexo_im/api/im/v1"
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+)
+
+// Services holds the business services the gRPC API calls into
+type Services struct {
+	Message      *service.MessageService
+	User         *service.UserService
+	Group        *service.GroupService
+	Conversation *service.ConversationService
+}
+
+// Server is the gRPC server
+type Server struct {
+	port   int
+	server *grpc.Server
+}
+
+// NewServer creates the gRPC server with every API service registered
+func NewServer(cfg *config.Config, services *Services) (*Server, error) {
+	auth := newAuthenticator(cfg)
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.GRPC.MaxRecvMsgSize),
+		grpc.ChainUnaryInterceptor(auth.unaryInterceptor),
+	}
+	tlsCfg, err := cfg.GRPC.BuildTLS()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	server := grpc.NewServer(opts...)
+	imv1.RegisterMessageServiceServer(server, &messageServer{msgService: services.Message})
+	imv1.RegisterUserServiceServer(server, &userServer{userService: services.User})
+	imv1.RegisterGroupServiceServer(server, &groupServer{groupService: services.Group})
+	imv1.RegisterConversationServiceServer(server, &conversationServer{convService: services.Conversation})
+	return &Server{port: cfg.GRPC.Port, server: server}, nil
+}
+
+// Run listens on the configured port and serves until Shutdown
+func (s *Server) Run() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+	return s.server.Serve(lis)
+}
+
+// Shutdown stops accepting calls and waits for in-flight ones, cancelling them once ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		log.CtxWarn(ctx, "grpc server forced to stop: %v", ctx.Err())
+		return ctx.Err()
+	}
+}
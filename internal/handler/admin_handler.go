@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// AdminUserRequest represents an admin action on a single user
+type AdminUserRequest struct {
+	UserId string `json:"user_id" validate:"required,max=64"`
+}
+
+// AdminHandler handles admin management requests
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// SearchUsers handles admin user search request
+func (h *AdminHandler) SearchUsers(ctx context.Context, c *app.RequestContext) {
+	var req service.SearchUsersRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.adminService.SearchUsers(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// BanUser handles ban user request
+func (h *AdminHandler) BanUser(ctx context.Context, c *app.RequestContext) {
+	h.setUserBanned(ctx, c, true)
+}
+
+// UnbanUser handles unban user request
+func (h *AdminHandler) UnbanUser(ctx context.Context, c *app.RequestContext) {
+	h.setUserBanned(ctx, c, false)
+}
+
+func (h *AdminHandler) setUserBanned(ctx context.Context, c *app.RequestContext, banned bool) {
+	var req AdminUserRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.adminService.SetUserBanned(ctx, middleware.GetAdminName(c), req.UserId, banned); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// ForceLogout handles force logout request
+func (h *AdminHandler) ForceLogout(ctx context.Context, c *app.RequestContext) {
+	var req AdminUserRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.adminService.ForceLogout(ctx, middleware.GetAdminName(c), req.UserId); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// GetUserConversations handles view user conversations request
+func (h *AdminHandler) GetUserConversations(ctx context.Context, c *app.RequestContext) {
+	var query userQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+	userId := query.UserId
+
+	convs, err := h.adminService.GetUserConversations(ctx, userId)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, convs)
+}
+
+// DeleteMessages handles delete messages request
+func (h *AdminHandler) DeleteMessages(ctx context.Context, c *app.RequestContext) {
+	var req service.DeleteMessagesRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	count, err := h.adminService.DeleteMessages(ctx, middleware.GetAdminName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, map[string]int64{"deleted_count": count})
+}
+
+// SearchMessages handles admin message audit search request
+func (h *AdminHandler) SearchMessages(ctx context.Context, c *app.RequestContext) {
+	var req service.SearchMessagesRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	result, err := h.adminService.SearchMessages(ctx, middleware.GetAdminName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, result)
+}
+
+// ListAuditLogs handles list admin audit logs request
+func (h *AdminHandler) ListAuditLogs(ctx context.Context, c *app.RequestContext) {
+	var req service.ListAuditLogsRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	logs, err := h.adminService.ListAuditLogs(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, logs)
+}
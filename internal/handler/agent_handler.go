@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/agent"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// AgentHandler handles the replies of agent backends
+type AgentHandler struct {
+	router *agent.Router
+}
+
+// NewAgentHandler creates a new AgentHandler
+func NewAgentHandler(router *agent.Router) *AgentHandler {
+	return &AgentHandler{router: router}
+}
+
+// Reply handles an agent reply request, sent again with the growing text to stream the reply
+func (h *AgentHandler) Reply(ctx context.Context, c *app.RequestContext) {
+	var req agent.Reply
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.router.Reply(ctx, &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// APIKeyHandler handles admin requests managing bot users and their API keys
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// CreateBot handles create bot user request
+func (h *APIKeyHandler) CreateBot(ctx context.Context, c *app.RequestContext) {
+	var req service.CreateBotRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	userInfo, err := h.apiKeyService.CreateBot(ctx, middleware.GetAdminName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, userInfo)
+}
+
+// CreateKey handles create API key request
+func (h *APIKeyHandler) CreateKey(ctx context.Context, c *app.RequestContext) {
+	var req service.CreateAPIKeyRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.apiKeyService.CreateKey(ctx, middleware.GetAdminName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// ListKeys handles list API keys request
+// Query: user_id
+func (h *APIKeyHandler) ListKeys(ctx context.Context, c *app.RequestContext) {
+	var req service.ListAPIKeysRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, keys)
+}
+
+// RotateKey handles rotate API key request
+func (h *APIKeyHandler) RotateKey(ctx context.Context, c *app.RequestContext) {
+	var req service.RotateAPIKeyRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.apiKeyService.RotateKey(ctx, middleware.GetAdminName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// RevokeKey handles revoke API key request
+func (h *APIKeyHandler) RevokeKey(ctx context.Context, c *app.RequestContext) {
+	var req service.APIKeyIdRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(ctx, middleware.GetAdminName(c), &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// AuditHandler handles security audit trail requests
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListEvents handles list audit events request
+// Query: category, actor, result, start_time, end_time (ms), offset, limit
+func (h *AuditHandler) ListEvents(ctx context.Context, c *app.RequestContext) {
+	var req service.ListAuditEventsRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	events, err := h.auditService.ListEvents(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, events)
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
 	"github.com/ZaiSpace/nexo_im/internal/service"
-	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 	"github.com/ZaiSpace/nexo_im/pkg/response"
 )
 
@@ -22,12 +22,27 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 // Register handles user registration
 func (h *AuthHandler) Register(ctx context.Context, c *app.RequestContext) {
 	var req service.RegisterRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
-	userInfo, err := h.authService.Register(ctx, &req)
+	userInfo, err := h.authService.Register(ctx, c.ClientIP(), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, userInfo)
+}
+
+// InternalRegister handles user registration from internal services, without captcha
+func (h *AuthHandler) InternalRegister(ctx context.Context, c *app.RequestContext) {
+	var req service.RegisterRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	userInfo, err := h.authService.InternalRegister(ctx, &req)
 	if err != nil {
 		response.Error(ctx, c, err)
 		return
@@ -39,12 +54,162 @@ func (h *AuthHandler) Register(ctx context.Context, c *app.RequestContext) {
 // Login handles user login
 func (h *AuthHandler) Login(ctx context.Context, c *app.RequestContext) {
 	var req service.LoginRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.authService.Login(ctx, c.ClientIP(), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// OAuthCallback handles login with an authorization code from an identity provider
+func (h *AuthHandler) OAuthCallback(ctx context.Context, c *app.RequestContext) {
+	var req service.OAuthLoginRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.authService.OAuthLogin(ctx, c.ClientIP(), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// RefreshToken handles access token refresh with refresh token rotation
+func (h *AuthHandler) RefreshToken(ctx context.Context, c *app.RequestContext) {
+	var req service.RefreshTokenRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.authService.RefreshToken(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// Verify handles email / phone verification of a registered user
+func (h *AuthHandler) Verify(ctx context.Context, c *app.RequestContext) {
+	var req service.VerifyRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.authService.Verify(ctx, &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// ResendVerifyCode handles verification code resend
+func (h *AuthHandler) ResendVerifyCode(ctx context.Context, c *app.RequestContext) {
+	var req service.ResendVerifyCodeRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.authService.ResendVerifyCode(ctx, &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// ListSessions handles list active sessions request
+func (h *AuthHandler) ListSessions(ctx context.Context, c *app.RequestContext) {
+	sessions, err := h.authService.ListSessions(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, sessions)
+}
+
+// RenameSession handles rename session request
+func (h *AuthHandler) RenameSession(ctx context.Context, c *app.RequestContext) {
+	var req service.RenameSessionRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.authService.RenameSession(ctx, middleware.GetUserId(c), &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// RevokeSession handles revoke session request
+func (h *AuthHandler) RevokeSession(ctx context.Context, c *app.RequestContext) {
+	var req service.RevokeSessionRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.authService.RevokeSession(ctx, middleware.GetUserId(c), &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// IssueScopedToken handles scoped access token request
+func (h *AuthHandler) IssueScopedToken(ctx context.Context, c *app.RequestContext) {
+	var req service.ScopedTokenRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.authService.IssueScopedToken(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c), middleware.GetScopes(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// CreateGuest handles temporary guest account creation
+func (h *AuthHandler) CreateGuest(ctx context.Context, c *app.RequestContext) {
+	var req service.CreateGuestRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	resp, err := h.authService.CreateGuest(ctx, c.ClientIP(), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// UpgradeGuest handles turning the calling guest into a full account
+func (h *AuthHandler) UpgradeGuest(ctx context.Context, c *app.RequestContext) {
+	var req service.UpgradeGuestRequest
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
-	resp, err := h.authService.Login(ctx, &req)
+	resp, err := h.authService.UpgradeGuest(ctx, middleware.GetUserId(c), &req)
 	if err != nil {
 		response.Error(ctx, c, err)
 		return
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+	"github.com/ZaiSpace/nexo_im/pkg/validate"
+)
+
+// reasonMalformed is reported when the request cannot be decoded at all
+const reasonMalformed = "malformed"
+
+// bindRequest binds the request into req and validates it by its `validate` tags.
+// On failure it writes a 400 response listing the offending fields and returns false.
+func bindRequest(ctx context.Context, c *app.RequestContext, req any) bool {
+	if err := c.Bind(req); err != nil {
+		response.InvalidParams(ctx, c, validate.Errors{{Field: "", Reason: reasonMalformed}})
+		return false
+	}
+	if errs := validate.Struct(req); errs != nil {
+		response.InvalidParams(ctx, c, errs)
+		return false
+	}
+	return true
+}
+
+// conversationQuery is the query of requests addressing a single conversation
+type conversationQuery struct {
+	ConversationId string `query:"conversation_id" validate:"required,max=256"`
+}
+
+// groupQuery is the query of requests addressing a single group
+type groupQuery struct {
+	GroupId string `query:"group_id" validate:"required,max=64"`
+}
+
+// userQuery is the query of requests addressing a single user
+type userQuery struct {
+	UserId string `query:"user_id" validate:"required,max=64"`
+}
+
+// botQuery is the query of requests addressing a single bot user
+type botQuery struct {
+	BotId string `query:"bot_id" validate:"required,max=64"`
+}
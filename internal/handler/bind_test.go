@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+
+	"github.com/ZaiSpace/nexo_im/internal/service"
+)
+
+// performBind posts body to a route binding it into a fresh request of newReq and returns
+// the response status, 200 when the request bound and validated
+func performBind(t *testing.T, newReq func() any, body string) int {
+	t.Helper()
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.POST("/bind", func(ctx context.Context, c *app.RequestContext) {
+		if bindRequest(ctx, c, newReq()) {
+			c.JSON(http.StatusOK, nil)
+		}
+	})
+	w := ut.PerformRequest(engine, http.MethodPost, "/bind",
+		&ut.Body{Body: strings.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	return w.Code
+}
+
+func TestBindCallSignalRequest(t *testing.T) {
+	newReq := func() any { return &service.CallSignalRequest{} }
+	if code := performBind(t, newReq, `{"signal_type":1,"invitee_ids":["bob","carol"],"media_type":"audio"}`); code != http.StatusOK {
+		t.Fatalf("expected invite to bind, got %d", code)
+	}
+	if code := performBind(t, newReq, `{"invitee_ids":["bob"]}`); code != http.StatusBadRequest {
+		t.Fatalf("expected missing signal type to be rejected, got %d", code)
+	}
+}
+
+func TestBindSetUserTagsRequest(t *testing.T) {
+	newReq := func() any { return &service.SetUserTagsRequest{} }
+	if code := performBind(t, newReq, `{"user_id":"alice","tags":["beta","vip"]}`); code != http.StatusOK {
+		t.Fatalf("expected tags to bind, got %d", code)
+	}
+	tooMany := `{"user_id":"alice","tags":["t"` + strings.Repeat(`,"t"`, 20) + `]}`
+	if code := performBind(t, newReq, tooMany); code != http.StatusBadRequest {
+		t.Fatalf("expected more than 20 tags to be rejected, got %d", code)
+	}
+}
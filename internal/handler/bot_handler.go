@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// BotHandler handles requests about bot users
+type BotHandler struct {
+	botService *service.BotService
+}
+
+// NewBotHandler creates a new BotHandler
+func NewBotHandler(botService *service.BotService) *BotHandler {
+	return &BotHandler{botService: botService}
+}
+
+// GetCommands handles get bot slash commands request, used by clients to suggest commands
+func (h *BotHandler) GetCommands(ctx context.Context, c *app.RequestContext) {
+	var query botQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	commands, err := h.botService.GetCommands(ctx, query.BotId)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, map[string]any{"bot_id": query.BotId, "commands": commands})
+}
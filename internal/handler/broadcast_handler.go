@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// broadcastQuery identifies a broadcast in the query string
+type broadcastQuery struct {
+	Id int64 `query:"id" validate:"required,min=1"`
+}
+
+// BroadcastIdRequest represents an admin action on a single broadcast
+type BroadcastIdRequest struct {
+	Id int64 `json:"id" validate:"required,min=1"`
+}
+
+// BroadcastHandler handles admin and internal broadcast announcement requests
+type BroadcastHandler struct {
+	broadcastService *service.BroadcastService
+}
+
+// NewBroadcastHandler creates a new BroadcastHandler
+func NewBroadcastHandler(broadcastService *service.BroadcastService) *BroadcastHandler {
+	return &BroadcastHandler{broadcastService: broadcastService}
+}
+
+// CreateBroadcast handles create broadcast request
+func (h *BroadcastHandler) CreateBroadcast(ctx context.Context, c *app.RequestContext) {
+	var req service.CreateBroadcastRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	broadcast, err := h.broadcastService.CreateBroadcast(ctx, middleware.GetAdminName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, broadcast)
+}
+
+// ListBroadcasts handles list broadcasts request
+// Query: offset, limit
+func (h *BroadcastHandler) ListBroadcasts(ctx context.Context, c *app.RequestContext) {
+	var req service.ListBroadcastsRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	broadcasts, err := h.broadcastService.ListBroadcasts(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, broadcasts)
+}
+
+// GetBroadcast handles get broadcast delivery progress request
+func (h *BroadcastHandler) GetBroadcast(ctx context.Context, c *app.RequestContext) {
+	var query broadcastQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	broadcast, err := h.broadcastService.GetBroadcast(ctx, query.Id)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, broadcast)
+}
+
+// CancelBroadcast handles cancel broadcast request
+func (h *BroadcastHandler) CancelBroadcast(ctx context.Context, c *app.RequestContext) {
+	var req BroadcastIdRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.broadcastService.CancelBroadcast(ctx, middleware.GetAdminName(c), req.Id); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// InternalBroadcast handles internal broadcast request. The broadcast is returned once
+// scheduled; it is delivered in the background, see GetBroadcast for its progress.
+func (h *BroadcastHandler) InternalBroadcast(ctx context.Context, c *app.RequestContext) {
+	var req service.CreateBroadcastRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	broadcast, err := h.broadcastService.CreateBroadcast(ctx, "internal:"+middleware.GetInternalServiceName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, broadcast)
+}
+
+// SetUserTags handles internal set user tags request
+func (h *BroadcastHandler) SetUserTags(ctx context.Context, c *app.RequestContext) {
+	var req service.SetUserTagsRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	tags, err := h.broadcastService.SetUserTags(ctx, middleware.GetInternalServiceName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, tags)
+}
+
+// GetUserTags handles internal get user tags request
+// Query: user_id
+func (h *BroadcastHandler) GetUserTags(ctx context.Context, c *app.RequestContext) {
+	var query userQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	tags, err := h.broadcastService.GetUserTags(ctx, query.UserId)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, tags)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// CallHandler handles call signaling requests
+type CallHandler struct {
+	callService *service.CallService
+}
+
+// NewCallHandler creates a new CallHandler
+func NewCallHandler(callService *service.CallService) *CallHandler {
+	return &CallHandler{callService: callService}
+}
+
+// Signal handles send call signal request (HTTP fallback); the signal is relayed to all
+// devices of the participants, including the other devices of the sender
+func (h *CallHandler) Signal(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req service.CallSignalRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	call, err := h.callService.Signal(ctx, userId, middleware.GetPlatformId(c), "", &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, call)
+}
+
+// voipTokenRequest is the body of a register VoIP token request
+type voipTokenRequest struct {
+	Token string `json:"token" validate:"required,max=512"`
+}
+
+// RegisterVoIPToken handles register VoIP push token request of the app on the request platform
+func (h *CallHandler) RegisterVoIPToken(ctx context.Context, c *app.RequestContext) {
+	var req voipTokenRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.callService.RegisterVoIPToken(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c), req.Token); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// UnregisterVoIPToken handles unregister VoIP push token request of the app on the request platform
+func (h *CallHandler) UnregisterVoIPToken(ctx context.Context, c *app.RequestContext) {
+	if err := h.callService.UnregisterVoIPToken(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c)); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/ZaiSpace/nexo_im/internal/chatbridge"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// ChatBridgeHandler receives the webhooks of external chat platforms
+type ChatBridgeHandler struct {
+	bridge *chatbridge.Bridge
+}
+
+// NewChatBridgeHandler creates a new ChatBridgeHandler
+func NewChatBridgeHandler(bridge *chatbridge.Bridge) *ChatBridgeHandler {
+	return &ChatBridgeHandler{bridge: bridge}
+}
+
+// Receive handles a webhook request of the connector in the path. Platforms only look at the
+// status code, so failures use non-2xx statuses: 5xx makes them redeliver the update.
+// Replies the platform expects, such as Slack's url_verification challenge, are written as is.
+func (h *ChatBridgeHandler) Receive(ctx context.Context, c *app.RequestContext) {
+	header := func(name string) string {
+		return string(c.GetHeader(name))
+	}
+	reply, err := h.bridge.Handle(ctx, c.Param("connector"), header, c.Request.Body())
+	switch {
+	case err == nil && reply != nil:
+		c.JSON(consts.StatusOK, reply)
+	case err == nil:
+		response.Success(ctx, c, nil)
+	case errors.Is(err, errcode.ErrNotFound):
+		c.JSON(consts.StatusNotFound, response.Response{Code: errcode.ErrNotFound.Code, Message: errcode.ErrNotFound.Msg})
+	case errors.Is(err, errcode.ErrUnauthorized):
+		response.Unauthorized(ctx, c, "")
+	case errors.Is(err, errcode.ErrInvalidParam):
+		c.JSON(consts.StatusBadRequest, response.Response{Code: errcode.ErrInvalidParam.Code, Message: errcode.ErrInvalidParam.Msg})
+	default:
+		e := errcode.ErrInternalServer
+		errors.As(err, &e)
+		c.JSON(consts.StatusInternalServerError, response.Response{Code: e.Code, Message: e.Msg})
+	}
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"strconv"
 
 	"github.com/cloudwego/hertz/pkg/app"
 
@@ -10,6 +9,7 @@ import (
 	"github.com/ZaiSpace/nexo_im/internal/service"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 	"github.com/ZaiSpace/nexo_im/pkg/response"
+	"github.com/ZaiSpace/nexo_im/pkg/validate"
 )
 
 // GetAllConversationListRequest represents conversation list request options.
@@ -20,9 +20,9 @@ type GetAllConversationListRequest struct {
 // GetConversationListRequest represents conversation list page request options.
 type GetConversationListRequest struct {
 	WithLastMessage      *bool  `json:"with_last_message" query:"with_last_message"`
-	Limit                int    `json:"limit" query:"limit"`
-	CursorUpdatedAt      int64  `json:"cursor_updated_at" query:"cursor_updated_at"`
-	CursorConversationId string `json:"cursor_conversation_id" query:"cursor_conversation_id"`
+	Limit                int    `json:"limit" query:"limit" validate:"min=0,max=100"`
+	CursorUpdatedAt      int64  `json:"cursor_updated_at" query:"cursor_updated_at" validate:"min=0"`
+	CursorConversationId string `json:"cursor_conversation_id" query:"cursor_conversation_id" validate:"max=256"`
 }
 
 // ConversationHandler handles conversation-related requests
@@ -46,8 +46,7 @@ func (h *ConversationHandler) GetAllConversationList(ctx context.Context, c *app
 	// By default do not include latest message to reduce payload.
 	withLastMessage := false
 	var req GetAllConversationListRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 	if req.WithLastMessage != nil {
@@ -73,24 +72,20 @@ func (h *ConversationHandler) GetConversationList(ctx context.Context, c *app.Re
 
 	withLastMessage := false
 	var req GetConversationListRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 	if req.WithLastMessage != nil {
 		withLastMessage = *req.WithLastMessage
 	}
 
-	if req.Limit < 0 || req.Limit > service.MaxConversationListLimit {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
-		return
-	}
+	// The cursor is only meaningful as a pair
 	if req.CursorUpdatedAt > 0 && req.CursorConversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+		response.InvalidParams(ctx, c, validate.Errors{{Field: "cursor_conversation_id", Reason: validate.ReasonRequired}})
 		return
 	}
 	if req.CursorConversationId != "" && req.CursorUpdatedAt <= 0 {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+		response.InvalidParams(ctx, c, validate.Errors{{Field: "cursor_updated_at", Reason: validate.ReasonRequired}})
 		return
 	}
 
@@ -118,11 +113,11 @@ func (h *ConversationHandler) GetConversation(ctx context.Context, c *app.Reques
 		return
 	}
 
-	conversationId := c.Query("conversation_id")
-	if conversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query conversationQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	conversationId := query.ConversationId
 
 	conv, err := h.convService.GetConversation(ctx, userId, conversationId)
 	if err != nil {
@@ -133,6 +128,23 @@ func (h *ConversationHandler) GetConversation(ctx context.Context, c *app.Reques
 	response.Success(ctx, c, conv)
 }
 
+// GetSavedConversation handles get saved messages conversation request
+func (h *ConversationHandler) GetSavedConversation(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	conv, err := h.convService.GetSavedConversation(ctx, userId)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, conv)
+}
+
 // UpdateConversation handles update conversation settings request
 func (h *ConversationHandler) UpdateConversation(ctx context.Context, c *app.RequestContext) {
 	userId := middleware.GetUserId(c)
@@ -141,15 +153,14 @@ func (h *ConversationHandler) UpdateConversation(ctx context.Context, c *app.Req
 		return
 	}
 
-	conversationId := c.Query("conversation_id")
-	if conversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query conversationQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	conversationId := query.ConversationId
 
 	var req service.UpdateConversationRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -163,8 +174,8 @@ func (h *ConversationHandler) UpdateConversation(ctx context.Context, c *app.Req
 
 // MarkReadRequest represents mark read request
 type MarkReadRequest struct {
-	ConversationId string `json:"conversation_id"`
-	ReadSeq        int64  `json:"read_seq"`
+	ConversationId string `json:"conversation_id" validate:"required,max=256"`
+	ReadSeq        int64  `json:"read_seq" validate:"min=0"`
 }
 
 // MarkRead handles mark conversation as read request
@@ -176,8 +187,7 @@ func (h *ConversationHandler) MarkRead(ctx context.Context, c *app.RequestContex
 	}
 
 	var req MarkReadRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -197,11 +207,11 @@ func (h *ConversationHandler) GetMaxReadSeq(ctx context.Context, c *app.RequestC
 		return
 	}
 
-	conversationId := c.Query("conversation_id")
-	if conversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query conversationQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	conversationId := query.ConversationId
 
 	maxSeq, readSeq, err := h.convService.GetMaxReadSeq(ctx, userId, conversationId)
 	if err != nil {
@@ -221,6 +231,12 @@ func (h *ConversationHandler) GetMaxReadSeq(ctx context.Context, c *app.RequestC
 	})
 }
 
+// unreadCountQuery represents get unread count query
+type unreadCountQuery struct {
+	ConversationId string `query:"conversation_id" validate:"required,max=256"`
+	ReadSeq        int64  `query:"read_seq" validate:"min=0"`
+}
+
 // GetUnreadCount handles get unread count request
 func (h *ConversationHandler) GetUnreadCount(ctx context.Context, c *app.RequestContext) {
 	userId := middleware.GetUserId(c)
@@ -229,16 +245,13 @@ func (h *ConversationHandler) GetUnreadCount(ctx context.Context, c *app.Request
 		return
 	}
 
-	conversationId := c.Query("conversation_id")
-	if conversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query unreadCountQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	readSeq := query.ReadSeq
 
-	readSeqStr := c.Query("read_seq")
-	readSeq, _ := strconv.ParseInt(readSeqStr, 10, 64)
-
-	maxSeq, currentReadSeq, err := h.convService.GetMaxReadSeq(ctx, userId, conversationId)
+	maxSeq, currentReadSeq, err := h.convService.GetMaxReadSeq(ctx, userId, query.ConversationId)
 	if err != nil {
 		response.Error(ctx, c, err)
 		return
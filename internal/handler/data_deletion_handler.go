@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// DataDeletionHandler handles GDPR user data purge requests
+type DataDeletionHandler struct {
+	deletionService *service.DataDeletionService
+}
+
+// NewDataDeletionHandler creates a new DataDeletionHandler
+func NewDataDeletionHandler(deletionService *service.DataDeletionService) *DataDeletionHandler {
+	return &DataDeletionHandler{deletionService: deletionService}
+}
+
+// PurgeUser handles user data purge request
+func (h *DataDeletionHandler) PurgeUser(ctx context.Context, c *app.RequestContext) {
+	var req service.PurgeUserRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	record, err := h.deletionService.PurgeUser(ctx, middleware.GetInternalServiceName(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, record)
+}
+
+// ListDeletionRecords handles list user data deletion records request
+func (h *DataDeletionHandler) ListDeletionRecords(ctx context.Context, c *app.RequestContext) {
+	var query userQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+	userId := query.UserId
+
+	records, err := h.deletionService.ListDeletionRecords(ctx, userId)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, records)
+}
+
+// DeleteAccount handles self-service account deletion request
+func (h *DataDeletionHandler) DeleteAccount(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req service.DeleteAccountRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	record, err := h.deletionService.DeleteAccount(ctx, userId, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, record)
+}
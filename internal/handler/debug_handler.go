@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/gateway"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// DebugHandler handles runtime diagnostic requests
+type DebugHandler struct {
+	wsServer *gateway.WsServer
+}
+
+// NewDebugHandler creates a new DebugHandler
+func NewDebugHandler(wsServer *gateway.WsServer) *DebugHandler {
+	return &DebugHandler{wsServer: wsServer}
+}
+
+// RuntimeStats is a snapshot of process and gateway runtime state
+type RuntimeStats struct {
+	GoVersion   string              `json:"go_version"`
+	NumCPU      int                 `json:"num_cpu"`
+	Goroutines  int                 `json:"goroutines"`
+	HeapAlloc   uint64              `json:"heap_alloc"`
+	HeapInuse   uint64              `json:"heap_inuse"`
+	HeapObjects uint64              `json:"heap_objects"`
+	Sys         uint64              `json:"sys"`
+	NumGC       uint32              `json:"num_gc"`
+	LastGCAt    int64               `json:"last_gc_at"`     // ms, 0 if no GC yet
+	LastPauseNs uint64              `json:"last_pause_ns"`  // duration of the most recent GC pause
+	PauseTotal  uint64              `json:"pause_total_ns"` // cumulative GC pause time
+	OnlineUsers int64               `json:"online_users"`
+	OnlineConns int64               `json:"online_conns"`
+	Queues      *gateway.QueueStats `json:"queues"`
+}
+
+// GetRuntimeStats handles runtime stats request
+func (h *DebugHandler) GetRuntimeStats(ctx context.Context, c *app.RequestContext) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := &RuntimeStats{
+		GoVersion:   runtime.Version(),
+		NumCPU:      runtime.NumCPU(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapInuse:   mem.HeapInuse,
+		HeapObjects: mem.HeapObjects,
+		Sys:         mem.Sys,
+		NumGC:       mem.NumGC,
+		PauseTotal:  mem.PauseTotalNs,
+		OnlineUsers: h.wsServer.GetOnlineUserCount(),
+		OnlineConns: h.wsServer.GetOnlineConnCount(),
+		Queues:      h.wsServer.GetQueueStats(),
+	}
+	if mem.NumGC > 0 {
+		stats.LastGCAt = time.Unix(0, int64(mem.LastGC)).UnixMilli()
+		stats.LastPauseNs = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+
+	response.Success(ctx, c, stats)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// E2EEHandler handles end-to-end encryption key requests. The device of a request is its platform.
+type E2EEHandler struct {
+	e2eeService *service.E2EEService
+}
+
+// NewE2EEHandler creates a new E2EEHandler
+func NewE2EEHandler(e2eeService *service.E2EEService) *E2EEHandler {
+	return &E2EEHandler{e2eeService: e2eeService}
+}
+
+// UploadKeys handles upload keys request of the requesting device
+func (h *E2EEHandler) UploadKeys(ctx context.Context, c *app.RequestContext) {
+	var req service.UploadE2EEKeysRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	count, err := h.e2eeService.UploadKeys(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, map[string]any{"one_time_prekey_count": count})
+}
+
+// GetBundles handles get key bundles request, claiming a one-time prekey of each device
+func (h *E2EEHandler) GetBundles(ctx context.Context, c *app.RequestContext) {
+	var req service.GetE2EEBundlesRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	bundles, err := h.e2eeService.GetBundles(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, map[string]any{"bundles": bundles})
+}
+
+// GetPrekeyCount handles get unclaimed one-time prekey count request of the requesting device
+func (h *E2EEHandler) GetPrekeyCount(ctx context.Context, c *app.RequestContext) {
+	count, err := h.e2eeService.PrekeyCount(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, map[string]any{"one_time_prekey_count": count})
+}
+
+// ResetKeys handles reset keys request of the requesting device
+func (h *E2EEHandler) ResetKeys(ctx context.Context, c *app.RequestContext) {
+	if err := h.e2eeService.ResetDevice(ctx, middleware.GetUserId(c), middleware.GetPlatformId(c)); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// FavoriteHandler handles favorite message requests
+type FavoriteHandler struct {
+	favoriteService *service.FavoriteService
+}
+
+// NewFavoriteHandler creates a new FavoriteHandler
+func NewFavoriteHandler(favoriteService *service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{favoriteService: favoriteService}
+}
+
+// AddFavorite handles favorite message request
+func (h *FavoriteHandler) AddFavorite(ctx context.Context, c *app.RequestContext) {
+	var req service.AddFavoriteRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	favorite, err := h.favoriteService.AddFavorite(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, favorite)
+}
+
+// ListFavorites handles list favorite messages request
+func (h *FavoriteHandler) ListFavorites(ctx context.Context, c *app.RequestContext) {
+	var req service.ListFavoritesRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	result, err := h.favoriteService.ListFavorites(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, result)
+}
+
+// RemoveFavorite handles remove favorite message request
+func (h *FavoriteHandler) RemoveFavorite(ctx context.Context, c *app.RequestContext) {
+	var req service.RemoveFavoriteRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.favoriteService.RemoveFavorite(ctx, middleware.GetUserId(c), req.FavoriteId); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/ZaiSpace/nexo_im/internal/graphqlapi"
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// GraphQLHandler handles GraphQL queries
+type GraphQLHandler struct {
+	api *graphqlapi.API
+}
+
+// NewGraphQLHandler creates a new GraphQLHandler
+func NewGraphQLHandler(api *graphqlapi.API) *GraphQLHandler {
+	return &GraphQLHandler{api: api}
+}
+
+// Query handles a GraphQL query request. The result is written in the GraphQL response
+// shape ({"data", "errors"}) rather than the standard envelope, for GraphQL clients.
+func (h *GraphQLHandler) Query(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req graphqlapi.Request
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	viewer := graphqlapi.Viewer{UserId: userId, Scopes: middleware.GetScopes(c)}
+	c.JSON(consts.StatusOK, h.api.Execute(ctx, viewer, &req))
+}
@@ -29,8 +29,7 @@ func (h *GroupHandler) CreateGroup(ctx context.Context, c *app.RequestContext) {
 	}
 
 	var req service.CreateGroupRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -45,8 +44,8 @@ func (h *GroupHandler) CreateGroup(ctx context.Context, c *app.RequestContext) {
 
 // JoinGroupRequest represents join group request
 type JoinGroupRequest struct {
-	GroupId   string `json:"group_id"`
-	InviterId string `json:"inviter_id,omitempty"`
+	GroupId   string `json:"group_id" validate:"required,max=64"`
+	InviterId string `json:"inviter_id,omitempty" validate:"max=64"`
 }
 
 // JoinGroup handles join group request
@@ -58,8 +57,7 @@ func (h *GroupHandler) JoinGroup(ctx context.Context, c *app.RequestContext) {
 	}
 
 	var req JoinGroupRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -73,7 +71,7 @@ func (h *GroupHandler) JoinGroup(ctx context.Context, c *app.RequestContext) {
 
 // QuitGroupRequest represents quit group request
 type QuitGroupRequest struct {
-	GroupId string `json:"group_id"`
+	GroupId string `json:"group_id" validate:"required,max=64"`
 }
 
 // QuitGroup handles quit group request
@@ -85,8 +83,7 @@ func (h *GroupHandler) QuitGroup(ctx context.Context, c *app.RequestContext) {
 	}
 
 	var req QuitGroupRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -100,11 +97,11 @@ func (h *GroupHandler) QuitGroup(ctx context.Context, c *app.RequestContext) {
 
 // GetGroupInfo handles get group info request
 func (h *GroupHandler) GetGroupInfo(ctx context.Context, c *app.RequestContext) {
-	groupId := c.Query("group_id")
-	if groupId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query groupQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	groupId := query.GroupId
 
 	groupInfo, err := h.groupService.GetGroupInfo(ctx, groupId)
 	if err != nil {
@@ -117,11 +114,11 @@ func (h *GroupHandler) GetGroupInfo(ctx context.Context, c *app.RequestContext)
 
 // GetGroupMembers handles get group members request
 func (h *GroupHandler) GetGroupMembers(ctx context.Context, c *app.RequestContext) {
-	groupId := c.Query("group_id")
-	if groupId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query groupQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	groupId := query.GroupId
 
 	members, err := h.groupService.GetGroupMembers(ctx, groupId)
 	if err != nil {
@@ -131,3 +128,316 @@ func (h *GroupHandler) GetGroupMembers(ctx context.Context, c *app.RequestContex
 
 	response.Success(ctx, c, members)
 }
+
+// KickGroupMembersRequest represents kick group members request
+type KickGroupMembersRequest struct {
+	GroupId string   `json:"group_id" validate:"required,max=64"`
+	UserIds []string `json:"user_ids" validate:"required,max=100"`
+}
+
+// KickMembers handles kick group members request
+func (h *GroupHandler) KickMembers(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req KickGroupMembersRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.groupService.KickMembers(ctx, req.GroupId, userId, req.UserIds); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// MuteGroupMemberRequest represents mute group member request
+type MuteGroupMemberRequest struct {
+	GroupId         string `json:"group_id" validate:"required,max=64"`
+	UserId          string `json:"user_id" validate:"required,max=64"`
+	DurationSeconds int64  `json:"duration_seconds" validate:"min=0"` // 0 lifts the mute
+}
+
+// MuteMember handles mute group member request
+func (h *GroupHandler) MuteMember(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req MuteGroupMemberRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.groupService.MuteMember(ctx, req.GroupId, userId, req.UserId, req.DurationSeconds); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// TransferGroupRequest represents transfer group ownership request
+type TransferGroupRequest struct {
+	GroupId    string `json:"group_id" validate:"required,max=64"`
+	NewOwnerId string `json:"new_owner_id" validate:"required,max=64"`
+}
+
+// TransferOwnership handles transfer group ownership request
+func (h *GroupHandler) TransferOwnership(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req TransferGroupRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.groupService.TransferOwnership(ctx, req.GroupId, userId, req.NewOwnerId); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// GroupAdminRequest represents promote or demote group admin request
+type GroupAdminRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+	UserId  string `json:"user_id" validate:"required,max=64"`
+}
+
+// PromoteAdmin handles promote group admin request
+func (h *GroupHandler) PromoteAdmin(ctx context.Context, c *app.RequestContext) {
+	h.setAdmin(ctx, c, true)
+}
+
+// DemoteAdmin handles demote group admin request
+func (h *GroupHandler) DemoteAdmin(ctx context.Context, c *app.RequestContext) {
+	h.setAdmin(ctx, c, false)
+}
+
+// setAdmin sets the role of the member of the request on behalf of the group owner
+func (h *GroupHandler) setAdmin(ctx context.Context, c *app.RequestContext, admin bool) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req GroupAdminRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.groupService.SetGroupAdmin(ctx, req.GroupId, userId, req.UserId, admin); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// DismissGroupRequest represents dismiss group request
+type DismissGroupRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+}
+
+// DismissGroup handles dismiss group request
+func (h *GroupHandler) DismissGroup(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req DismissGroupRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.groupService.DismissGroup(ctx, req.GroupId, userId); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// UpdateGroupInfoRequest represents update group info request
+type UpdateGroupInfoRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+	service.UpdateGroupInfoRequest
+}
+
+// UpdateGroupInfo handles update group info request
+func (h *GroupHandler) UpdateGroupInfo(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req UpdateGroupInfoRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	groupInfo, err := h.groupService.UpdateGroupInfo(ctx, req.GroupId, userId, &req.UpdateGroupInfoRequest)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, groupInfo)
+}
+
+// SetAnnouncementRequest represents set group announcement request
+type SetAnnouncementRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+	Content string `json:"content" validate:"max=1024"` // empty clears the announcement
+}
+
+// SetAnnouncement handles set group announcement request
+func (h *GroupHandler) SetAnnouncement(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req SetAnnouncementRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	groupInfo, err := h.groupService.SetAnnouncement(ctx, req.GroupId, userId, req.Content)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, groupInfo)
+}
+
+// CreateInviteLinkRequest represents create group invite link request
+type CreateInviteLinkRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+	service.CreateInviteLinkRequest
+}
+
+// CreateInviteLink handles create group invite link request
+func (h *GroupHandler) CreateInviteLink(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req CreateInviteLinkRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	link, err := h.groupService.CreateInviteLink(ctx, req.GroupId, userId, &req.CreateInviteLinkRequest)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, link)
+}
+
+// RevokeInviteLinkRequest represents revoke group invite link request
+type RevokeInviteLinkRequest struct {
+	GroupId string `json:"group_id" validate:"required,max=64"`
+	Token   string `json:"token" validate:"required,max=64"`
+}
+
+// RevokeInviteLink handles revoke group invite link request
+func (h *GroupHandler) RevokeInviteLink(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req RevokeInviteLinkRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.groupService.RevokeInviteLink(ctx, req.GroupId, userId, req.Token); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// JoinByInviteLinkRequest represents join group by invite link request
+type JoinByInviteLinkRequest struct {
+	Token string `json:"token" validate:"required,max=64"`
+}
+
+// JoinByInviteLink handles join group by invite link request
+func (h *GroupHandler) JoinByInviteLink(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req JoinByInviteLinkRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	groupId, err := h.groupService.JoinGroupByInviteLink(ctx, userId, req.Token)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, map[string]interface{}{"group_id": groupId})
+}
+
+// groupReportQuery selects the group and date range of an engagement report
+type groupReportQuery struct {
+	GroupId   string `query:"group_id" validate:"required,max=64"`
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+}
+
+// GetGroupReport handles group engagement report request (group admins only)
+// Query: group_id, start_date, end_date (yyyymmdd, default today)
+func (h *GroupHandler) GetGroupReport(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var query groupReportQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	report, err := h.groupService.GetGroupReport(ctx, query.GroupId, userId, query.StartDate, query.EndDate)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, report)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"github.com/ZaiSpace/nexo_im/internal/service"
+)
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Livez reports that the process is up and serving; it never checks dependencies,
+// so a dependency outage does not get the instance restarted
+func (h *HealthHandler) Livez(ctx context.Context, c *app.RequestContext) {
+	c.JSON(consts.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports whether the instance can take traffic, with the status of every dependency.
+// Returns 503 when a critical dependency is down.
+func (h *HealthHandler) Readyz(ctx context.Context, c *app.RequestContext) {
+	report := h.healthService.Readiness(ctx)
+	status := consts.StatusOK
+	if !report.Ready {
+		status = consts.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
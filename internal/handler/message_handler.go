@@ -2,11 +2,17 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+	"github.com/mbeoliero/kit/log"
 
 	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/gateway"
 	"github.com/ZaiSpace/nexo_im/internal/middleware"
 	"github.com/ZaiSpace/nexo_im/internal/service"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
@@ -16,20 +22,22 @@ import (
 // MessageHandler handles message-related requests
 type MessageHandler struct {
 	msgService *service.MessageService
+	wsServer   *gateway.WsServer
 }
 
 type sendMessageRequest struct {
-	ClientMsgId string                    `json:"client_msg_id"`
-	RecvId      string                    `json:"recv_id,omitempty"`
-	GroupId     string                    `json:"group_id,omitempty"`
+	ClientMsgId string                    `json:"client_msg_id" validate:"required,max=64"`
+	RecvId      string                    `json:"recv_id,omitempty" validate:"max=64"`
+	GroupId     string                    `json:"group_id,omitempty" validate:"max=64"`
 	SessionType int32                     `json:"session_type"`
 	MsgType     int32                     `json:"msg_type"`
 	Content     entity.FlatMessageContent `json:"content"`
+	QuotedMsg   *entity.QuotedMessage     `json:"quoted_msg,omitempty"`
 }
 
 // NewMessageHandler creates a new MessageHandler
-func NewMessageHandler(msgService *service.MessageService) *MessageHandler {
-	return &MessageHandler{msgService: msgService}
+func NewMessageHandler(msgService *service.MessageService, wsServer *gateway.WsServer) *MessageHandler {
+	return &MessageHandler{msgService: msgService, wsServer: wsServer}
 }
 
 // SendMessage handles send message request (HTTP fallback)
@@ -41,8 +49,7 @@ func (h *MessageHandler) SendMessage(ctx context.Context, c *app.RequestContext)
 	}
 
 	var req sendMessageRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -53,6 +60,7 @@ func (h *MessageHandler) SendMessage(ctx context.Context, c *app.RequestContext)
 		SessionType: req.SessionType,
 		MsgType:     req.MsgType,
 		Content:     entity.NewMessageContentFromFlat(req.Content),
+		QuotedMsg:   req.QuotedMsg,
 	}
 
 	msg, err := h.msgService.SendMessage(ctx, userId, svcReq)
@@ -73,8 +81,7 @@ func (h *MessageHandler) SendMessageWithoutMarkRead(ctx context.Context, c *app.
 	}
 
 	var req sendMessageRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -85,6 +92,7 @@ func (h *MessageHandler) SendMessageWithoutMarkRead(ctx context.Context, c *app.
 		SessionType: req.SessionType,
 		MsgType:     req.MsgType,
 		Content:     entity.NewMessageContentFromFlat(req.Content),
+		QuotedMsg:   req.QuotedMsg,
 	}
 
 	msg, err := h.msgService.SendMessageWithoutMarkRead(ctx, userId, svcReq)
@@ -96,6 +104,133 @@ func (h *MessageHandler) SendMessageWithoutMarkRead(ctx context.Context, c *app.
 	response.Success(ctx, c, msg.ToMessageInfo())
 }
 
+type batchSendMessageRequest struct {
+	Messages        []sendMessageRequest `json:"messages" validate:"required,max=100"`
+	WithoutMarkRead bool                 `json:"without_mark_read"`
+}
+
+// batchSendResult is the outcome of one message of a batch, Code 0 means sent
+type batchSendResult struct {
+	Code    int                 `json:"code"`
+	Error   string              `json:"error,omitempty"`
+	Message *entity.MessageInfo `json:"message,omitempty"`
+}
+
+// BatchSendMessage handles sending up to 100 messages from one sender in a request,
+// e.g. notifications to many users. Each message gets its own result.
+func (h *MessageHandler) BatchSendMessage(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req batchSendMessageRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	svcReqs := make([]*service.SendMessageRequest, len(req.Messages))
+	for i, m := range req.Messages {
+		svcReqs[i] = &service.SendMessageRequest{
+			ClientMsgId: m.ClientMsgId,
+			RecvId:      m.RecvId,
+			GroupId:     m.GroupId,
+			SessionType: m.SessionType,
+			MsgType:     m.MsgType,
+			Content:     entity.NewMessageContentFromFlat(m.Content),
+			QuotedMsg:   m.QuotedMsg,
+		}
+	}
+
+	results := h.msgService.SendMessagesBatch(ctx, userId, svcReqs, !req.WithoutMarkRead)
+	resp := make([]*batchSendResult, len(results))
+	for i, r := range results {
+		if r.Err == nil {
+			resp[i] = &batchSendResult{Message: r.Message.ToMessageInfo()}
+			continue
+		}
+		e := errcode.ErrInternalServer
+		errors.As(r.Err, &e)
+		resp[i] = &batchSendResult{Code: e.Code, Error: e.Msg}
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+type forwardMessagesRequest struct {
+	ClientMsgId    string                   `json:"client_msg_id" validate:"required,max=40"`
+	ConversationId string                   `json:"conversation_id" validate:"required,max=256"`
+	Seqs           []int64                  `json:"seqs" validate:"required,max=100"`
+	Targets        []*service.ForwardTarget `json:"targets" validate:"required,max=20"`
+	Merged         bool                     `json:"merged"`
+	Title          string                   `json:"title,omitempty"`
+}
+
+// forwardResult is the outcome of a forward to one target, Code 0 means all copies were sent
+type forwardResult struct {
+	RecvId   string                `json:"recv_id,omitempty"`
+	GroupId  string                `json:"group_id,omitempty"`
+	Code     int                   `json:"code"`
+	Error    string                `json:"error,omitempty"`
+	Messages []*entity.MessageInfo `json:"messages"`
+}
+
+// ForwardMessages handles forward messages request. Each target gets its own result.
+func (h *MessageHandler) ForwardMessages(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req forwardMessagesRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	results, err := h.msgService.ForwardMessages(ctx, userId, &service.ForwardMessagesRequest{
+		ClientMsgId:    req.ClientMsgId,
+		ConversationId: req.ConversationId,
+		Seqs:           req.Seqs,
+		Targets:        req.Targets,
+		Merged:         req.Merged,
+		Title:          req.Title,
+	})
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	resp := make([]*forwardResult, len(results))
+	for i, r := range results {
+		result := &forwardResult{
+			RecvId:   r.Target.RecvId,
+			GroupId:  r.Target.GroupId,
+			Messages: make([]*entity.MessageInfo, len(r.Messages)),
+		}
+		for j, msg := range r.Messages {
+			result.Messages[j] = msg.ToMessageInfo()
+		}
+		if r.Err != nil {
+			e := errcode.ErrInternalServer
+			errors.As(r.Err, &e)
+			result.Code, result.Error = e.Code, e.Msg
+		}
+		resp[i] = result
+	}
+
+	response.Success(ctx, c, resp)
+}
+
+// pullMessagesQuery represents pull messages query
+type pullMessagesQuery struct {
+	ConversationId string `query:"conversation_id" validate:"required,max=256"`
+	BeginSeq       int64  `query:"begin_seq" validate:"min=0"`
+	EndSeq         int64  `query:"end_seq" validate:"min=0"`
+	Limit          int    `query:"limit" validate:"min=0"`
+}
+
 // PullMessages handles pull messages request
 func (h *MessageHandler) PullMessages(ctx context.Context, c *app.RequestContext) {
 	userId := middleware.GetUserId(c)
@@ -104,21 +239,16 @@ func (h *MessageHandler) PullMessages(ctx context.Context, c *app.RequestContext
 		return
 	}
 
-	conversationId := c.Query("conversation_id")
-	if conversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query pullMessagesQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
 
-	beginSeq, _ := strconv.ParseInt(c.Query("begin_seq"), 10, 64)
-	endSeq, _ := strconv.ParseInt(c.Query("end_seq"), 10, 64)
-	limit, _ := strconv.Atoi(c.Query("limit"))
-
 	req := &service.PullMessagesRequest{
-		ConversationId: conversationId,
-		BeginSeq:       beginSeq,
-		EndSeq:         endSeq,
-		Limit:          limit,
+		ConversationId: query.ConversationId,
+		BeginSeq:       query.BeginSeq,
+		EndSeq:         query.EndSeq,
+		Limit:          query.Limit,
 	}
 
 	messages, maxSeq, err := h.msgService.PullMessages(ctx, userId, req)
@@ -127,9 +257,10 @@ func (h *MessageHandler) PullMessages(ctx context.Context, c *app.RequestContext
 		return
 	}
 
+	platformId := middleware.GetPlatformId(c)
 	msgInfos := make([]*any, 0, len(messages))
 	for _, msg := range messages {
-		info := msg.ToMessageInfo()
+		info := msg.ForDevice(userId, platformId).ToMessageInfo()
 		msgInfos = append(msgInfos, func() *any { var i any = info; return &i }())
 	}
 
@@ -139,6 +270,144 @@ func (h *MessageHandler) PullMessages(ctx context.Context, c *app.RequestContext
 	})
 }
 
+// ListThread handles list replies of a message request
+func (h *MessageHandler) ListThread(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req service.ListThreadRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	result, err := h.msgService.ListThread(ctx, userId, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	platformId := middleware.GetPlatformId(c)
+	list := make([]*entity.MessageInfo, 0, len(result.List))
+	for _, msg := range result.List {
+		list = append(list, msg.ForDevice(userId, platformId).ToMessageInfo())
+	}
+	resp := map[string]any{
+		"list":     list,
+		"has_more": result.HasMore,
+	}
+	if result.HasMore {
+		resp["next_cursor"] = result.NextCursor
+	}
+	response.Success(ctx, c, resp)
+}
+
+// pollMessagesQuery represents long-poll query
+type pollMessagesQuery struct {
+	SinceSeq int64  `query:"since_seq" validate:"min=0"`
+	Wait     string `query:"wait" validate:"max=16"` // duration such as 25s, or seconds
+}
+
+// PollMessages handles long-poll requests, the last-resort transport when neither
+// WebSocket nor SSE get through: it returns the pushes after since_seq, holding the
+// request until one arrives or the wait times out.
+func (h *MessageHandler) PollMessages(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var query pollMessagesQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+	var wait time.Duration
+	if query.Wait != "" {
+		var err error
+		if wait, err = time.ParseDuration(query.Wait); err != nil {
+			seconds, convErr := strconv.Atoi(query.Wait)
+			if convErr != nil {
+				response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+				return
+			}
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+
+	result, err := h.wsServer.Poll(ctx, &gateway.PollRequest{
+		UserId:     userId,
+		PlatformId: middleware.GetPlatformId(c),
+		SinceSeq:   query.SinceSeq,
+		Wait:       wait,
+	})
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, result)
+}
+
+// ndjsonContentType is the content type of streamed exports, one JSON document per line
+const ndjsonContentType = "application/x-ndjson"
+
+// ExportMessages streams the whole visible history of a conversation as NDJSON, one
+// message per line in seq order. Errors before the first message get the usual JSON error
+// response; a failure mid-stream ends the stream with a {"code","message"} line.
+func (h *MessageHandler) ExportMessages(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var query conversationQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	streaming := false
+	err := h.msgService.ExportMessages(ctx, userId, query.ConversationId, func(messages []*entity.Message) error {
+		if !streaming {
+			streaming = true
+			c.SetContentType(ndjsonContentType)
+			c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+		}
+		var buf []byte
+		for _, msg := range messages {
+			line, err := json.Marshal(msg.ToMessageInfo())
+			if err != nil {
+				return err
+			}
+			buf = append(append(buf, line...), '\n')
+		}
+		if _, err := c.Write(buf); err != nil {
+			return err
+		}
+		return c.Flush()
+	})
+	if err == nil {
+		if !streaming {
+			c.SetContentType(ndjsonContentType)
+		}
+		return
+	}
+	if !streaming {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	log.CtxWarn(ctx, "export messages interrupted: user_id=%s, conversation_id=%s, error=%v", userId, query.ConversationId, err)
+	e := errcode.ErrPullFailed
+	errors.As(err, &e)
+	line, _ := json.Marshal(response.Response{Code: e.Code, Message: e.Msg})
+	_, _ = c.Write(append(line, '\n'))
+	_ = c.Flush()
+}
+
 // GetMaxSeqRequest represents get max seq request
 type GetMaxSeqRequest struct {
 	ConversationId string `json:"conversation_id"`
@@ -152,11 +421,11 @@ func (h *MessageHandler) GetMaxSeq(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	conversationId := c.Query("conversation_id")
-	if conversationId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var query conversationQuery
+	if !bindRequest(ctx, c, &query) {
 		return
 	}
+	conversationId := query.ConversationId
 
 	maxSeq, err := h.msgService.GetMaxSeq(ctx, userId, conversationId)
 	if err != nil {
@@ -168,3 +437,139 @@ func (h *MessageHandler) GetMaxSeq(ctx context.Context, c *app.RequestContext) {
 		"max_seq": maxSeq,
 	})
 }
+
+// RevokeMessage handles revoke message request
+func (h *MessageHandler) RevokeMessage(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req service.RevokeMessageRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	notice, err := h.msgService.RevokeMessage(ctx, userId, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, notice.ToMessageInfo())
+}
+
+// DeleteMessage handles delete message request
+func (h *MessageHandler) DeleteMessage(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req service.DeleteMessageRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.msgService.DeleteMessage(ctx, userId, &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// editMessageRequest represents edit message request
+type editMessageRequest struct {
+	ConversationId string                    `json:"conversation_id" validate:"required,max=256"`
+	Seq            int64                     `json:"seq" validate:"min=1"`
+	Content        entity.FlatMessageContent `json:"content"`
+}
+
+// EditMessage handles edit message request
+func (h *MessageHandler) EditMessage(ctx context.Context, c *app.RequestContext) {
+	userId := middleware.GetUserId(c)
+	if userId == "" {
+		response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
+		return
+	}
+
+	var req editMessageRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	msg, err := h.msgService.EditMessage(ctx, userId, &service.EditMessageRequest{
+		ConversationId: req.ConversationId,
+		Seq:            req.Seq,
+		Content:        entity.NewMessageContentFromFlat(req.Content),
+	})
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, msg.ToMessageInfo())
+}
+
+// editHistoryQuery represents get edit history query
+type editHistoryQuery struct {
+	ConversationId string `query:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `query:"seq" validate:"min=1"`
+}
+
+// GetEditHistory handles get edit history request
+func (h *MessageHandler) GetEditHistory(ctx context.Context, c *app.RequestContext) {
+	var query editHistoryQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	edits, err := h.msgService.GetEditHistory(ctx, middleware.GetUserId(c), query.ConversationId, query.Seq)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, edits)
+}
+
+// readStatusQuery represents the query of the read status or readers of a message
+type readStatusQuery struct {
+	ConversationId string `query:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `query:"seq" validate:"min=1"`
+}
+
+// GetReadStatus handles get read status of a message request
+func (h *MessageHandler) GetReadStatus(ctx context.Context, c *app.RequestContext) {
+	var query readStatusQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	status, err := h.msgService.GetReadStatus(ctx, middleware.GetUserId(c), query.ConversationId, query.Seq)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, status)
+}
+
+// GetGroupReadMembers handles list readers of a group message request
+func (h *MessageHandler) GetGroupReadMembers(ctx context.Context, c *app.RequestContext) {
+	var query readStatusQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	members, err := h.msgService.GetGroupReadMembers(ctx, middleware.GetUserId(c), query.ConversationId, query.Seq)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, members)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// NotificationHandler handles notification settings requests
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetSettings handles get notification settings request
+func (h *NotificationHandler) GetSettings(ctx context.Context, c *app.RequestContext) {
+	settings, err := h.notificationService.GetSettings(ctx, middleware.GetUserId(c))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, settings)
+}
+
+// UpdateSettings handles update notification settings request
+func (h *NotificationHandler) UpdateSettings(ctx context.Context, c *app.RequestContext) {
+	var req service.UpdateNotificationSettingsRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, settings)
+}
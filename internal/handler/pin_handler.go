@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// PinHandler handles pinned message requests
+type PinHandler struct {
+	pinService *service.PinService
+}
+
+// NewPinHandler creates a new PinHandler
+func NewPinHandler(pinService *service.PinService) *PinHandler {
+	return &PinHandler{pinService: pinService}
+}
+
+// PinMessage handles pin message request
+func (h *PinHandler) PinMessage(ctx context.Context, c *app.RequestContext) {
+	var req service.PinMessageRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	change, err := h.pinService.PinMessage(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, change)
+}
+
+// UnpinMessage handles unpin message request
+func (h *PinHandler) UnpinMessage(ctx context.Context, c *app.RequestContext) {
+	var req service.PinMessageRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	change, err := h.pinService.UnpinMessage(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, change)
+}
+
+// ListPinnedMessages handles list pinned messages request
+func (h *PinHandler) ListPinnedMessages(ctx context.Context, c *app.RequestContext) {
+	var query conversationQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	list, err := h.pinService.ListPinnedMessages(ctx, middleware.GetUserId(c), query.ConversationId)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, list)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// PollHandler handles poll message requests
+type PollHandler struct {
+	pollService *service.PollService
+}
+
+// NewPollHandler creates a new PollHandler
+func NewPollHandler(pollService *service.PollService) *PollHandler {
+	return &PollHandler{pollService: pollService}
+}
+
+// Vote handles vote on a poll request
+func (h *PollHandler) Vote(ctx context.Context, c *app.RequestContext) {
+	var req service.VotePollRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	result, err := h.pollService.Vote(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, result)
+}
+
+// pollResultQuery represents get poll result query
+type pollResultQuery struct {
+	ConversationId string `query:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `query:"seq" validate:"min=1"`
+}
+
+// GetResult handles get poll result request
+func (h *PollHandler) GetResult(ctx context.Context, c *app.RequestContext) {
+	var query pollResultQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	result, err := h.pollService.GetResult(ctx, middleware.GetUserId(c), query.ConversationId, query.Seq)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, result)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// ReactionHandler handles message reaction requests
+type ReactionHandler struct {
+	reactionService *service.ReactionService
+}
+
+// NewReactionHandler creates a new ReactionHandler
+func NewReactionHandler(reactionService *service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{reactionService: reactionService}
+}
+
+// AddReaction handles add reaction request
+func (h *ReactionHandler) AddReaction(ctx context.Context, c *app.RequestContext) {
+	var req service.ReactionRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	change, err := h.reactionService.AddReaction(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, change)
+}
+
+// RemoveReaction handles remove reaction request
+func (h *ReactionHandler) RemoveReaction(ctx context.Context, c *app.RequestContext) {
+	var req service.ReactionRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	change, err := h.reactionService.RemoveReaction(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, change)
+}
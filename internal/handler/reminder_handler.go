@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// ReminderHandler handles message reminder requests
+type ReminderHandler struct {
+	reminderService *service.ReminderService
+}
+
+// NewReminderHandler creates a new ReminderHandler
+func NewReminderHandler(reminderService *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{reminderService: reminderService}
+}
+
+// CreateReminder handles create message reminder request
+func (h *ReminderHandler) CreateReminder(ctx context.Context, c *app.RequestContext) {
+	var req service.CreateReminderRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	reminder, err := h.reminderService.CreateReminder(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, reminder)
+}
+
+// ListReminders handles list pending message reminders request
+func (h *ReminderHandler) ListReminders(ctx context.Context, c *app.RequestContext) {
+	reminders, err := h.reminderService.ListReminders(ctx, middleware.GetUserId(c))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, reminders)
+}
+
+// CancelReminder handles cancel message reminder request
+func (h *ReminderHandler) CancelReminder(ctx context.Context, c *app.RequestContext) {
+	var req service.CancelReminderRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.reminderService.CancelReminder(ctx, middleware.GetUserId(c), req.ReminderId); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// StatsHandler handles operational statistics requests
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+// NewStatsHandler creates a new StatsHandler
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
+}
+
+// GetDailyStats handles daily statistics request
+// Query: start_date, end_date (yyyymmdd, default today)
+func (h *StatsHandler) GetDailyStats(ctx context.Context, c *app.RequestContext) {
+	stats, err := h.statsService.GetDailyStats(ctx, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, stats)
+}
+
+// UsageStatsHandler handles per conversation and per user usage statistics requests
+type UsageStatsHandler struct {
+	statsService *service.StatsService
+}
+
+// NewUsageStatsHandler creates a new UsageStatsHandler
+func NewUsageStatsHandler(statsService *service.StatsService) *UsageStatsHandler {
+	return &UsageStatsHandler{statsService: statsService}
+}
+
+// GetConversationUsage handles conversation usage request
+// Query: conversation_id or group_id, start_date, end_date (yyyymmdd, default today)
+func (h *UsageStatsHandler) GetConversationUsage(ctx context.Context, c *app.RequestContext) {
+	usage, err := h.statsService.GetConversationUsage(ctx, c.Query("conversation_id"), c.Query("group_id"),
+		c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, usage)
+}
+
+// GetUserUsage handles user usage request
+// Query: user_id, start_date, end_date (yyyymmdd, default today)
+func (h *UsageStatsHandler) GetUserUsage(ctx context.Context, c *app.RequestContext) {
+	usage, err := h.statsService.GetUserUsage(ctx, c.Query("user_id"), c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, usage)
+}
+
+// topUsageQuery selects a daily ranking in the query string
+type topUsageQuery struct {
+	Kind  string `query:"kind" validate:"required,max=16"` // conversation or user, others are rejected by StatsService.GetTopUsage
+	Date  string `query:"date"`
+	Limit int    `query:"limit" validate:"min=0"`
+}
+
+// GetTopUsage handles the daily ranking request
+// Query: kind (conversation or user), date (yyyymmdd, default today), limit
+func (h *UsageStatsHandler) GetTopUsage(ctx context.Context, c *app.RequestContext) {
+	var query topUsageQuery
+	if !bindRequest(ctx, c, &query) {
+		return
+	}
+
+	top, err := h.statsService.GetTopUsage(ctx, query.Kind, query.Date, query.Limit)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, top)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+
+	imconfig "github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+)
+
+func TestGetTopUsageRejectsUnknownKind(t *testing.T) {
+	statsService := &service.StatsService{}
+	statsService.SetUsage(&imconfig.UsageStatsConfig{Enabled: true, TopLimit: 100})
+	h := NewUsageStatsHandler(statsService)
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.GET("/top", h.GetTopUsage)
+
+	w := ut.PerformRequest(engine, http.MethodGet, "/top?kind=group", nil)
+	if w.Code != http.StatusOK || w.Body.String() != `{"code":1001,"message":"invalid parameter"}` {
+		t.Fatalf("expected invalid param for an unknown kind, got %d %s", w.Code, w.Body.String())
+	}
+	w = ut.PerformRequest(engine, http.MethodGet, "/top", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a missing kind to be rejected, got %d %s", w.Code, w.Body.String())
+	}
+}
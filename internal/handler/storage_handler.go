@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// StorageHandler handles media upload requests
+type StorageHandler struct {
+	storageService *service.StorageService
+}
+
+// NewStorageHandler creates a new StorageHandler
+func NewStorageHandler(storageService *service.StorageService) *StorageHandler {
+	return &StorageHandler{storageService: storageService}
+}
+
+// PresignUpload handles presign upload request
+func (h *StorageHandler) PresignUpload(ctx context.Context, c *app.RequestContext) {
+	var req service.PresignUploadRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	upload, err := h.storageService.PresignUpload(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, upload)
+}
+
+// GetQuota handles get upload quota request
+func (h *StorageHandler) GetQuota(ctx context.Context, c *app.RequestContext) {
+	quota, err := h.storageService.GetQuota(ctx, middleware.GetUserId(c))
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, quota)
+}
+
+// ConfirmUpload handles confirm upload request
+func (h *StorageHandler) ConfirmUpload(ctx context.Context, c *app.RequestContext) {
+	var req service.ConfirmUploadRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	info, err := h.storageService.ConfirmUpload(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, info)
+}
+
+// InitMultipartUpload handles init multipart upload request
+func (h *StorageHandler) InitMultipartUpload(ctx context.Context, c *app.RequestContext) {
+	var req service.PresignUploadRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	upload, err := h.storageService.InitMultipartUpload(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, upload)
+}
+
+// CompleteMultipartUpload handles complete multipart upload request
+func (h *StorageHandler) CompleteMultipartUpload(ctx context.Context, c *app.RequestContext) {
+	var req service.CompleteMultipartUploadRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	info, err := h.storageService.CompleteMultipartUpload(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, info)
+}
+
+// AbortMultipartUpload handles abort multipart upload request
+func (h *StorageHandler) AbortMultipartUpload(ctx context.Context, c *app.RequestContext) {
+	var req service.AbortMultipartUploadRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	if err := h.storageService.AbortMultipartUpload(ctx, middleware.GetUserId(c), &req); err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, nil)
+}
+
+// Download handles download request, redirecting to a short-lived URL of the file
+func (h *StorageHandler) Download(ctx context.Context, c *app.RequestContext) {
+	uploadId, err := strconv.ParseInt(c.Param("file_id"), 10, 64)
+	if err != nil {
+		response.Error(ctx, c, errcode.ErrInvalidParam)
+		return
+	}
+
+	url, err := h.storageService.Download(ctx, middleware.GetUserId(c), uploadId, c.Query("thumbnail") == "true")
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	// The redirect expires with the presigned URL
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, []byte(url))
+}
@@ -42,15 +42,19 @@ func (h *UserHandler) GetUserInfo(ctx context.Context, c *app.RequestContext) {
 	response.Success(ctx, c, userInfo)
 }
 
+// userPathParam is the path of requests addressing a single user
+type userPathParam struct {
+	UserId string `path:"user_id" validate:"required,max=64"`
+}
+
 // GetUserInfoById handles get user info by Id request
 func (h *UserHandler) GetUserInfoById(ctx context.Context, c *app.RequestContext) {
-	userId := c.Param("user_id")
-	if userId == "" {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	var req userPathParam
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
-	userInfo, err := h.userService.GetUserInfo(ctx, userId)
+	userInfo, err := h.userService.GetUserInfoFor(ctx, middleware.GetUserId(c), req.UserId)
 	if err != nil {
 		response.Error(ctx, c, err)
 		return
@@ -68,8 +72,7 @@ func (h *UserHandler) UpdateUserInfo(ctx context.Context, c *app.RequestContext)
 	}
 
 	var req service.UpdateUserRequest
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -82,20 +85,35 @@ func (h *UserHandler) UpdateUserInfo(ctx context.Context, c *app.RequestContext)
 	response.Success(ctx, c, userInfo)
 }
 
+// SearchUsers handles user search by handle or nickname request
+func (h *UserHandler) SearchUsers(ctx context.Context, c *app.RequestContext) {
+	var req service.SearchUsersQuery
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	userInfos, err := h.userService.SearchUsers(ctx, middleware.GetUserId(c), &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, userInfos)
+}
+
 // GetUsersInfoReq represents the request for batch getting users' info
 type GetUsersInfoReq struct {
-	UserIds []string `json:"user_ids" vd:"len($)>0,len($)<=100"`
+	UserIds []string `json:"user_ids" validate:"required,max=100"`
 }
 
 // GetUsersInfo handles batch get users info request
 func (h *UserHandler) GetUsersInfo(ctx context.Context, c *app.RequestContext) {
 	var req GetUsersInfoReq
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
-	userInfos, err := h.userService.GetUserInfos(ctx, req.UserIds)
+	userInfos, err := h.userService.GetUserInfos(ctx, middleware.GetUserId(c), req.UserIds)
 	if err != nil {
 		response.Error(ctx, c, err)
 		return
@@ -106,14 +124,13 @@ func (h *UserHandler) GetUsersInfo(ctx context.Context, c *app.RequestContext) {
 
 // GetUsersOnlineStatusReq represents the request for getting users' online status
 type GetUsersOnlineStatusReq struct {
-	UserIds []string `json:"user_ids" vd:"len($)>0"`
+	UserIds []string `json:"user_ids" validate:"required"`
 }
 
 // GetUsersOnlineStatus handles get users online status request
 func (h *UserHandler) GetUsersOnlineStatus(ctx context.Context, c *app.RequestContext) {
 	var req GetUsersOnlineStatusReq
-	if err := c.BindAndValidate(&req); err != nil {
-		response.ErrorWithCode(ctx, c, errcode.ErrInvalidParam)
+	if !bindRequest(ctx, c, &req) {
 		return
 	}
 
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// WebhookHandler handles outgoing webhook delivery log requests
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// ListDeliveries handles list webhook deliveries request
+// Query: endpoint, event_type, event_id, result, start_time, end_time (ms), offset, limit
+func (h *WebhookHandler) ListDeliveries(ctx context.Context, c *app.RequestContext) {
+	var req service.ListWebhookDeliveriesRequest
+	if !bindRequest(ctx, c, &req) {
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(ctx, &req)
+	if err != nil {
+		response.Error(ctx, c, err)
+		return
+	}
+
+	response.Success(ctx, c, deliveries)
+}
@@ -0,0 +1,136 @@
+// Package linkpreview builds the preview cards of links sent in messages from the OpenGraph
+// metadata of the linked pages, fetched only from public addresses.
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// maxURLLength bounds the links previewed
+const maxURLLength = 2048
+
+// ErrNoPreview is returned for pages that are not HTML or have neither a title nor a
+// description
+var ErrNoPreview = errors.New("no preview")
+
+// urlPattern matches http(s) links in text
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// FirstURL returns the first http(s) link of text, without trailing punctuation, or "" if
+// there is none
+func FirstURL(text string) string {
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		match = strings.TrimRight(match, ".,;:!?'\")]}")
+		if len(match) > maxURLLength {
+			continue
+		}
+		if u, err := url.Parse(match); err == nil && u.Host != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+// Fetcher fetches pages and builds their preview. Connections are only made to public
+// addresses, checked once the host is resolved so that DNS cannot point it elsewhere, and
+// through every redirect; proxies from the environment are not used.
+type Fetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	userAgent string
+}
+
+// New creates a Fetcher for cfg
+func New(cfg *config.MessageLinkPreviewConfig) *Fetcher {
+	return newFetcher(cfg, isPublic)
+}
+
+func newFetcher(cfg *config.MessageLinkPreviewConfig, allowed func(net.IP) bool) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return fmt.Errorf("address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       time.Minute,
+	}
+	maxRedirects := cfg.MaxRedirects
+	return &Fetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		maxBytes:  cfg.MaxBytes,
+		userAgent: cfg.UserAgent,
+	}
+}
+
+// Fetch fetches the page at rawURL and builds its preview
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*entity.LinkPreview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNoPreview
+	}
+
+	// Relative image URLs are resolved against the final URL, after redirects
+	preview := parse(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL)
+	if preview.Title == "" && preview.Description == "" {
+		return nil, ErrNoPreview
+	}
+	preview.Url = rawURL
+	return preview, nil
+}
@@ -0,0 +1,131 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+const testPage = `<!DOCTYPE html>
+<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="  Nexo &amp; friends ">
+<meta property="og:description" content="A chat
+server">
+<meta property="og:image" content="/img/cover.png">
+<meta property="og:site_name" content="Nexo">
+</head><body><meta property="og:title" content="ignored"></body></html>`
+
+func testConfig() *config.MessageLinkPreviewConfig {
+	return &config.MessageLinkPreviewConfig{Timeout: time.Second, MaxBytes: 64 << 10, MaxRedirects: 2, UserAgent: "test"}
+}
+
+func TestFirstURL(t *testing.T) {
+	for text, want := range map[string]string{
+		"see https://example.com/a?b=1.":        "https://example.com/a?b=1",
+		"(HTTP://example.com/x) and http://b.c": "HTTP://example.com/x",
+		"mail me at a@b.c, ftp://example.com":   "",
+		"https:// nothing":                      "",
+		"<https://example.com/p>":               "https://example.com/p",
+	} {
+		if got := FirstURL(text); got != want {
+			t.Errorf("FirstURL(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/post/1")
+	preview := parse(strings.NewReader(testPage), base)
+	if preview.Title != "Nexo & friends" || preview.Description != "A chat server" ||
+		preview.ImageUrl != "https://example.com/img/cover.png" || preview.SiteName != "Nexo" {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+
+	preview = parse(strings.NewReader(`<title> Only
+ a title </title><meta name="description" content="desc"><meta property="og:image" content="javascript:alert(1)">`), base)
+	if preview.Title != "Only a title" || preview.Description != "desc" || preview.ImageUrl != "" {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+
+	if got := clean(strings.Repeat("é", 10), 5); got != "éééé…" {
+		t.Fatalf("unexpected truncation %q", got)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+		"64:ff9b::a00:1":   false,
+	} {
+		if got := isPublic(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			if r.Header.Get("User-Agent") != "test" {
+				t.Errorf("unexpected user agent %q", r.Header.Get("User-Agent"))
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(testPage))
+		case "/moved":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/file":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	f := newFetcher(testConfig(), func(net.IP) bool { return true })
+	preview, err := f.Fetch(ctx, srv.URL+"/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Url != srv.URL+"/moved" || preview.ImageUrl != srv.URL+"/img/cover.png" {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	if _, err = f.Fetch(ctx, srv.URL+"/file"); !errors.Is(err, ErrNoPreview) {
+		t.Fatalf("expected ErrNoPreview, got %v", err)
+	}
+	for _, path := range []string{"/loop", "/missing"} {
+		if _, err = f.Fetch(ctx, srv.URL+path); err == nil || errors.Is(err, ErrNoPreview) {
+			t.Errorf("%s: expected a fetch error, got %v", path, err)
+		}
+	}
+
+	// The test server listens on a loopback address
+	if _, err = New(testConfig()).Fetch(ctx, srv.URL+"/page"); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected the loopback address to be refused, got %v", err)
+	}
+}
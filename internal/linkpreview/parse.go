@@ -0,0 +1,117 @@
+package linkpreview
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// Lengths of the preview fields, in characters
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+	maxSiteNameLength    = 100
+)
+
+// parse reads the preview of an HTML page from its head: the OpenGraph properties, falling
+// back to the Twitter card, the description meta tag and the title element
+func parse(r io.Reader, base *url.URL) *entity.LinkPreview {
+	meta := map[string]string{}
+	var title strings.Builder
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// End of the page, or of what was read of it
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Body:
+				break loop
+			case atom.Title:
+				inTitle = true
+			case atom.Meta:
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = string(v)
+					}
+				}
+				if _, ok := meta[key]; !ok && key != "" {
+					meta[key] = content
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Head:
+				break loop
+			case atom.Title:
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle && title.Len() < maxTitleLength*4 {
+				title.Write(z.Text())
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if v := strings.TrimSpace(meta[key]); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	preview := &entity.LinkPreview{
+		Title:       clean(first("og:title", "twitter:title"), maxTitleLength),
+		Description: clean(first("og:description", "twitter:description", "description"), maxDescriptionLength),
+		SiteName:    clean(first("og:site_name"), maxSiteNameLength),
+	}
+	if preview.Title == "" {
+		preview.Title = clean(title.String(), maxTitleLength)
+	}
+	if image := first("og:image:secure_url", "og:image", "og:image:url", "twitter:image"); image != "" {
+		preview.ImageUrl = resolve(base, image)
+	}
+	return preview
+}
+
+// clean collapses the white space of s, drops invalid UTF-8 and truncates it to max characters
+func clean(s string, max int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+// resolve returns ref as an absolute http(s) URL relative to base, or "" if it is not one
+func resolve(base *url.URL, ref string) string {
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	s := u.String()
+	if len(s) > maxURLLength {
+		return ""
+	}
+	return s
+}
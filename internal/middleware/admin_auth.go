@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	AdminKeyHeader = "X-Admin-Key"
+	AdminNameKey   = "admin_name"
+)
+
+// AdminAuth validates admin requests using X-Admin-Key against the configured admin API keys.
+func AdminAuth() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		adminName, authErr := validateAdminRequest(c)
+		if authErr != nil {
+			denyRequest(ctx, c, auditActionAdminAuth, "", authErr)
+			return
+		}
+		c.Set(AdminNameKey, adminName)
+		c.Next(ctx)
+
+		recordRequest(ctx, c, audit.CategoryAdmin, c.FullPath(), adminName, nil)
+	}
+}
+
+func validateAdminRequest(c *app.RequestContext) (string, *errcode.Error) {
+	cfg := config.GlobalConfig
+	if cfg == nil || !cfg.Admin.Enabled {
+		return "", errcode.ErrForbidden
+	}
+
+	key := strings.TrimSpace(string(c.GetHeader(AdminKeyHeader)))
+	if key == "" {
+		return "", errcode.ErrUnauthorized
+	}
+
+	for _, apiKey := range cfg.Admin.APIKeys {
+		if apiKey.Key == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey.Key)) == 1 {
+			return apiKey.Name, nil
+		}
+	}
+	return "", errcode.ErrUnauthorized
+}
+
+// GetAdminName returns the authenticated admin name from context.
+func GetAdminName(c *app.RequestContext) string {
+	if v, ok := c.Get(AdminNameKey); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
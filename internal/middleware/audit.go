@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// Audit actions recorded by the auth middlewares
+const (
+	auditActionJWTAuth      = "jwt_auth"
+	auditActionInternalAuth = "internal_auth"
+	auditActionAdminAuth    = "admin_auth"
+	auditActionAPIKeyAuth   = "api_key_auth"
+	auditActionInternalCall = "internal_call"
+)
+
+// denyRequest writes the error response, aborts the chain and records a permission failure
+func denyRequest(ctx context.Context, c *app.RequestContext, action, actor string, e *errcode.Error) {
+	response.ErrorWithCode(ctx, c, e)
+	c.Abort()
+
+	audit.Record(ctx, &audit.Event{
+		Category: audit.CategoryPermission,
+		Action:   action,
+		Actor:    actor,
+		Path:     string(c.Path()),
+		ClientIP: c.ClientIP(),
+		Code:     e.Code,
+	})
+}
+
+// recordRequest records a successfully authenticated request after it was handled,
+// using the errcode from the response body as the result
+func recordRequest(ctx context.Context, c *app.RequestContext, category, action, actor string, detail map[string]any) {
+	if detail == nil {
+		detail = make(map[string]any, 1)
+	}
+	detail["method"] = string(c.Method())
+
+	audit.Record(ctx, &audit.Event{
+		Category: category,
+		Action:   action,
+		Actor:    actor,
+		Path:     string(c.Path()),
+		ClientIP: c.ClientIP(),
+		Code:     responseCode(c),
+		Detail:   detail,
+	})
+}
+
+// responseCode returns the code of a standard JSON response, 0 if it is not one
+func responseCode(c *app.RequestContext) int {
+	var resp response.Response
+	if err := json.Unmarshal(c.Response.Body(), &resp); err != nil {
+		return 0
+	}
+	return resp.Code
+}
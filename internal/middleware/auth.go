@@ -5,13 +5,13 @@ import (
 	"errors"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/cloudwego/hertz/pkg/app"
 
 	"github.com/ZaiSpace/nexo_im/internal/config"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 	"github.com/ZaiSpace/nexo_im/pkg/jwt"
-	"github.com/ZaiSpace/nexo_im/pkg/response"
 )
 
 const (
@@ -27,6 +27,8 @@ const (
 	UserIdKey = "user_id"
 	// PlatformIdKey is the context key for platform Id
 	PlatformIdKey = "platform_id"
+	// ScopesKey is the context key for the scopes of the credential, nil if unrestricted
+	ScopesKey = "scopes"
 )
 
 // JWTAuth is the JWT authentication middleware
@@ -37,33 +39,40 @@ func JWTAuth() app.HandlerFunc {
 			return
 		}
 
-		tokenString, err := extractToken(c)
-		if errors.Is(err, errcode.ErrTokenMissing) {
-			response.ErrorWithCode(ctx, c, errcode.ErrTokenMissing)
-			c.Abort()
-			return
-		}
-		if err != nil {
-			response.ErrorWithCode(ctx, c, errcode.ErrTokenInvalid)
-			c.Abort()
-			return
-		}
-
-		claims, err := ParseTokenWithFallback(tokenString, config.GlobalConfig)
-		if err != nil {
-			response.ErrorWithCode(ctx, c, errcode.ErrTokenInvalid)
-			c.Abort()
+		claims, ok := authenticateJWT(ctx, c)
+		if !ok {
 			return
 		}
 
 		// Store user info in context
 		c.Set(UserIdKey, claims.UserId)
 		c.Set(PlatformIdKey, claims.PlatformId)
+		c.Set(ScopesKey, claims.Scopes)
 
 		c.Next(ctx)
 	}
 }
 
+// authenticateJWT parses the request token; on failure the request is denied and ok is false
+func authenticateJWT(ctx context.Context, c *app.RequestContext) (claims *jwt.Claims, ok bool) {
+	tokenString, err := extractToken(c)
+	if errors.Is(err, errcode.ErrTokenMissing) {
+		denyRequest(ctx, c, auditActionJWTAuth, "", errcode.ErrTokenMissing)
+		return nil, false
+	}
+	if err != nil {
+		denyRequest(ctx, c, auditActionJWTAuth, "", errcode.ErrTokenInvalid)
+		return nil, false
+	}
+
+	claims, err = ParseTokenWithFallback(tokenString, config.GlobalConfig)
+	if err != nil {
+		denyRequest(ctx, c, auditActionJWTAuth, "", errcode.ErrTokenInvalid)
+		return nil, false
+	}
+	return claims, true
+}
+
 func extractToken(c *app.RequestContext) (string, error) {
 	authHeader := strings.TrimSpace(string(c.GetHeader(AuthorizationHeader)))
 	if authHeader != "" {
@@ -94,7 +103,7 @@ func isTestEnv() bool {
 		strings.EqualFold(strings.TrimSpace(os.Getenv("INFRA_ENV")), config.LOCAL)
 }
 
-// ParseTokenWithFallback tries nexo token first, then falls back to external token if enabled.
+// ParseTokenWithFallback tries nexo token first, then falls back to the configured external issuers if enabled.
 func ParseTokenWithFallback(tokenString string, cfg *config.Config) (*jwt.Claims, error) {
 	if cfg == nil {
 		return nil, errcode.ErrTokenInvalid
@@ -108,17 +117,57 @@ func ParseTokenWithFallback(tokenString string, cfg *config.Config) (*jwt.Claims
 
 	// Fall back to external token if enabled
 	if cfg.ExternalJWT.Enabled {
-		return jwt.ParseExternalToken(
-			tokenString,
-			cfg.ExternalJWT.Secret,
-			cfg.ExternalJWT.DefaultRole,
-			cfg.ExternalJWT.DefaultPlatformId,
-		)
+		verifier, vErr := externalVerifierFor(cfg)
+		if vErr != nil {
+			return nil, errcode.ErrTokenInvalid.Wrap(vErr)
+		}
+		return verifier.Parse(context.Background(), tokenString)
 	}
 
 	return nil, err
 }
 
+// The external verifier holds the JWKS caches, so it is built once per config
+var (
+	externalVerifierMu  sync.Mutex
+	externalVerifierCfg *config.Config
+	externalVerifier    *jwt.ExternalVerifier
+)
+
+func externalVerifierFor(cfg *config.Config) (*jwt.ExternalVerifier, error) {
+	externalVerifierMu.Lock()
+	defer externalVerifierMu.Unlock()
+	if externalVerifierCfg == cfg {
+		return externalVerifier, nil
+	}
+
+	issuers := cfg.ExternalJWT.AllIssuers()
+	configs := make([]jwt.ExternalIssuer, 0, len(issuers))
+	for _, iss := range issuers {
+		configs = append(configs, jwt.ExternalIssuer{
+			Name:              iss.Name,
+			Issuer:            iss.Issuer,
+			Audience:          iss.Audience,
+			Secret:            iss.Secret,
+			JWKSURL:           iss.JWKSURL,
+			JWKSRefresh:       iss.JWKSRefresh,
+			UserIdClaim:       iss.UserIdClaim,
+			UserIdMapping:     iss.UserIdMapping,
+			UserIdPrefix:      iss.UserIdPrefix,
+			RoleClaim:         iss.RoleClaim,
+			DefaultRole:       iss.DefaultRole,
+			PlatformIdClaim:   iss.PlatformIdClaim,
+			DefaultPlatformId: iss.DefaultPlatformId,
+		})
+	}
+	verifier, err := jwt.NewExternalVerifier(configs)
+	if err != nil {
+		return nil, err
+	}
+	externalVerifierCfg, externalVerifier = cfg, verifier
+	return verifier, nil
+}
+
 // GetUserId gets user Id from context
 func GetUserId(c *app.RequestContext) string {
 	if v, ok := c.Get(UserIdKey); ok {
@@ -134,3 +183,13 @@ func GetPlatformId(c *app.RequestContext) int {
 	}
 	return 0
 }
+
+// GetScopes gets the scopes of the request credential from context, nil if unrestricted
+func GetScopes(c *app.RequestContext) []string {
+	if v, ok := c.Get(ScopesKey); ok {
+		if scopes, ok := v.([]string); ok {
+			return scopes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+// HSTS sets the Strict-Transport-Security header when the server terminates TLS itself
+func HSTS() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		cfg := config.GlobalConfig
+		if cfg != nil && cfg.Server.TLS.Enabled && cfg.Server.TLS.HSTS.Enabled {
+			c.Header("Strict-Transport-Security", hstsValue(&cfg.Server.TLS.HSTS))
+		}
+		c.Next(ctx)
+	}
+}
+
+func hstsValue(cfg *config.HSTSConfig) string {
+	value := "max-age=" + strconv.FormatInt(int64(cfg.MaxAge.Seconds()), 10)
+	if cfg.IncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if cfg.Preload {
+		value += "; preload"
+	}
+	return value
+}
@@ -2,9 +2,6 @@ package middleware
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"strconv"
 	"strings"
 	"time"
@@ -12,14 +9,16 @@ import (
 	"github.com/cloudwego/hertz/pkg/app"
 
 	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 	"github.com/ZaiSpace/nexo_im/pkg/response"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
 )
 
 const (
-	InternalServiceNameHeader = "X-Service-Name"
-	InternalTimestampHeader   = "X-Timestamp"
-	InternalSignatureHeader   = "X-Signature"
+	InternalServiceNameHeader = signature.ServiceNameHeader
+	InternalTimestampHeader   = signature.TimestampHeader
+	InternalSignatureHeader   = signature.SignatureHeader
 	InternalUserIdHeader      = "X-User-Id"
 	InternalPlatformIdHeader  = "X-Platform-Id"
 	InternalServiceNameKey    = "internal_service_name"
@@ -31,12 +30,13 @@ func InternalAuth() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
 		serviceName, authErr := validateInternalRequest(c)
 		if authErr != nil {
-			response.ErrorWithCode(ctx, c, authErr)
-			c.Abort()
+			denyRequest(ctx, c, auditActionInternalAuth, serviceName, authErr)
 			return
 		}
 		c.Set(InternalServiceNameKey, serviceName)
 		c.Next(ctx)
+
+		recordRequest(ctx, c, audit.CategoryInternal, auditActionInternalCall, serviceName, nil)
 	}
 }
 
@@ -45,15 +45,13 @@ func InternalAuthAsUser() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
 		serviceName, authErr := validateInternalRequest(c)
 		if authErr != nil {
-			response.ErrorWithCode(ctx, c, authErr)
-			c.Abort()
+			denyRequest(ctx, c, auditActionInternalAuth, serviceName, authErr)
 			return
 		}
 
 		userId := strings.TrimSpace(string(c.GetHeader(InternalUserIdHeader)))
 		if userId == "" {
-			response.ErrorWithCode(ctx, c, errcode.ErrUnauthorized)
-			c.Abort()
+			denyRequest(ctx, c, auditActionInternalAuth, serviceName, errcode.ErrUnauthorized)
 			return
 		}
 
@@ -73,6 +71,11 @@ func InternalAuthAsUser() app.HandlerFunc {
 		c.Set(UserIdKey, userId)
 		c.Set(PlatformIdKey, platformId)
 		c.Next(ctx)
+
+		recordRequest(ctx, c, audit.CategoryInternal, auditActionInternalCall, serviceName, map[string]any{
+			"user_id":     userId,
+			"platform_id": platformId,
+		})
 	}
 }
 
@@ -87,8 +90,8 @@ func validateInternalRequest(c *app.RequestContext) (string, *errcode.Error) {
 
 	serviceName := strings.TrimSpace(string(c.GetHeader(InternalServiceNameHeader)))
 	tsStr := strings.TrimSpace(string(c.GetHeader(InternalTimestampHeader)))
-	signature := strings.TrimSpace(string(c.GetHeader(InternalSignatureHeader)))
-	if serviceName == "" || tsStr == "" || signature == "" {
+	sig := strings.TrimSpace(string(c.GetHeader(InternalSignatureHeader)))
+	if serviceName == "" || tsStr == "" || sig == "" {
 		return "", errcode.ErrUnauthorized
 	}
 
@@ -106,37 +109,20 @@ func validateInternalRequest(c *app.RequestContext) (string, *errcode.Error) {
 		return "", errcode.ErrUnauthorized
 	}
 
-	body := c.Request.Body()
-	expected := signInternalRequest(
+	if !signature.Verify(
 		cfg.InternalAuth.Secret,
 		serviceName,
 		tsStr,
 		string(c.Method()),
 		string(c.Path()),
-		body,
-	)
-	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		c.Request.Body(),
+		sig,
+	) {
 		return "", errcode.ErrUnauthorized
 	}
 	return serviceName, nil
 }
 
-func signInternalRequest(secret, serviceName, timestamp, method, path string, body []byte) string {
-	bodyHashBytes := sha256.Sum256(body)
-	bodyHash := hex.EncodeToString(bodyHashBytes[:])
-	payload := strings.Join([]string{
-		serviceName,
-		timestamp,
-		strings.ToUpper(method),
-		path,
-		bodyHash,
-	}, "\n")
-
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(payload))
-	return hex.EncodeToString(mac.Sum(nil))
-}
-
 func isServiceAllowed(serviceName string, allowed []string) bool {
 	if len(allowed) == 0 {
 		return true
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const auditActionIPAccess = "ip_access"
+
+// ipAccessRules is a parsed config.IPAccessList plus the trusted proxies
+type ipAccessRules struct {
+	enabled        bool
+	trustedProxies []netip.Prefix
+	allow          []netip.Prefix
+	deny           []netip.Prefix
+}
+
+// InternalIPAccess restricts internal routes to ip_access.internal. Install it before InternalAuth.
+func InternalIPAccess() app.HandlerFunc {
+	return ipAccess(func(cfg *config.IPAccessConfig) config.IPAccessList { return cfg.Internal })
+}
+
+// AdminIPAccess restricts admin routes to ip_access.admin. Install it before AdminAuth.
+func AdminIPAccess() app.HandlerFunc {
+	return ipAccess(func(cfg *config.IPAccessConfig) config.IPAccessList { return cfg.Admin })
+}
+
+func ipAccess(listOf func(cfg *config.IPAccessConfig) config.IPAccessList) app.HandlerFunc {
+	var (
+		once  sync.Once
+		rules *ipAccessRules
+	)
+	return func(ctx context.Context, c *app.RequestContext) {
+		// Parsed lazily so the middleware can be built before config.GlobalConfig is set;
+		// entries were validated by config.Load.
+		once.Do(func() {
+			rules = &ipAccessRules{}
+			cfg := config.GlobalConfig
+			if cfg == nil || !cfg.IPAccess.Enabled {
+				return
+			}
+			list := listOf(&cfg.IPAccess)
+			rules.enabled = true
+			rules.trustedProxies, _ = config.ParseIPRanges(cfg.IPAccess.TrustedProxies)
+			rules.allow, _ = config.ParseIPRanges(list.Allow)
+			rules.deny, _ = config.ParseIPRanges(list.Deny)
+		})
+
+		if rules.enabled && !rules.permits(accessClientIP(c, rules.trustedProxies)) {
+			denyRequest(ctx, c, auditActionIPAccess, "", errcode.ErrForbidden)
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+// permits checks addr against deny then allow; an invalid addr is never permitted
+func (r *ipAccessRules) permits(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	if containsAddr(r.deny, addr) {
+		return false
+	}
+	return len(r.allow) == 0 || containsAddr(r.allow, addr)
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessClientIP returns the socket peer address, or the forwarded client address
+// when the peer is a trusted proxy
+func accessClientIP(c *app.RequestContext, trustedProxies []netip.Prefix) netip.Addr {
+	var remote netip.Addr
+	if addr := c.RemoteAddr(); addr != nil {
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			host = addr.String()
+		}
+		remote, _ = netip.ParseAddr(host)
+	}
+	return forwardedClientIP(remote.Unmap(), string(c.GetHeader("X-Forwarded-For")),
+		string(c.GetHeader("X-Real-IP")), trustedProxies)
+}
+
+// forwardedClientIP returns the client address of a request from remote. Only a trusted
+// proxy is believed: X-Forwarded-For is walked from the right, as each proxy appends the
+// address it received from, skipping trusted proxies; the first other address is the client.
+// Entries left of it are supplied by the client and ignored. An unparsable hop yields an
+// invalid address, which is never permitted.
+func forwardedClientIP(remote netip.Addr, forwardedFor, realIP string, trustedProxies []netip.Prefix) netip.Addr {
+	if !remote.IsValid() || !containsAddr(trustedProxies, remote) {
+		return remote
+	}
+
+	if strings.TrimSpace(forwardedFor) == "" {
+		if client, err := netip.ParseAddr(strings.TrimSpace(realIP)); err == nil {
+			return client.Unmap()
+		}
+		return remote
+	}
+	hops := strings.Split(forwardedFor, ",")
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}
+		}
+		client = hop.Unmap()
+		if !containsAddr(trustedProxies, client) {
+			break
+		}
+	}
+	return client
+}
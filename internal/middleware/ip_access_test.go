@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+func TestIPAccessRulesPermits(t *testing.T) {
+	allow, err := config.ParseIPRanges([]string{"10.0.0.0/8", "192.168.1.7"})
+	if err != nil {
+		t.Fatalf("parse allow: %v", err)
+	}
+	deny, err := config.ParseIPRanges([]string{"10.0.13.0/24"})
+	if err != nil {
+		t.Fatalf("parse deny: %v", err)
+	}
+	rules := &ipAccessRules{enabled: true, allow: allow, deny: deny}
+
+	cases := map[string]bool{
+		"10.1.2.3":    true,
+		"10.0.13.5":   false, // deny wins over allow
+		"192.168.1.7": true,
+		"192.168.1.8": false,
+		"8.8.8.8":     false,
+	}
+	for ip, expected := range cases {
+		if got := rules.permits(netip.MustParseAddr(ip)); got != expected {
+			t.Fatalf("ip %s: expected %v, got %v", ip, expected, got)
+		}
+	}
+	if rules.permits(netip.Addr{}) {
+		t.Fatalf("expected invalid address to be rejected")
+	}
+}
+
+func TestIPAccessRulesEmptyAllowPermitsAllButDenied(t *testing.T) {
+	deny, _ := config.ParseIPRanges([]string{"203.0.113.0/24"})
+	rules := &ipAccessRules{enabled: true, deny: deny}
+
+	if !rules.permits(netip.MustParseAddr("198.51.100.1")) {
+		t.Fatalf("expected address outside deny list to be permitted")
+	}
+	if rules.permits(netip.MustParseAddr("203.0.113.9")) {
+		t.Fatalf("expected denied address to be rejected")
+	}
+}
+
+func TestParseIPRangesRejectsInvalid(t *testing.T) {
+	if _, err := config.ParseIPRanges([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatalf("expected error for invalid CIDR")
+	}
+	if _, err := config.ParseIPRanges([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("expected error for invalid IP")
+	}
+}
+
+func TestForwardedClientIP(t *testing.T) {
+	trusted, _ := config.ParseIPRanges([]string{"10.0.0.0/8"})
+	proxy := netip.MustParseAddr("10.0.0.2")
+
+	cases := []struct {
+		name         string
+		remote       netip.Addr
+		forwardedFor string
+		realIP       string
+		expected     string
+	}{
+		{"untrusted peer ignores headers", netip.MustParseAddr("203.0.113.5"), "10.0.0.1", "10.0.0.1", "203.0.113.5"},
+		{"client behind proxy", proxy, "198.51.100.7", "", "198.51.100.7"},
+		{"spoofed leftmost entry", proxy, "10.0.0.1, 198.51.100.7", "", "198.51.100.7"},
+		{"chain of trusted proxies", proxy, "10.9.9.9, 198.51.100.7, 10.0.0.3", "", "198.51.100.7"},
+		{"all hops trusted", proxy, "10.0.0.4, 10.0.0.3", "", "10.0.0.4"},
+		{"real ip without forwarded for", proxy, "", "198.51.100.8", "198.51.100.8"},
+		{"no headers", proxy, "", "", "10.0.0.2"},
+	}
+	for _, c := range cases {
+		if got := forwardedClientIP(c.remote, c.forwardedFor, c.realIP, trusted); got != netip.MustParseAddr(c.expected) {
+			t.Fatalf("%s: expected %s, got %s", c.name, c.expected, got)
+		}
+	}
+	if got := forwardedClientIP(proxy, "198.51.100.7, garbage", "", trusted); got.IsValid() {
+		t.Fatalf("expected an unparsable hop to yield an invalid address, got %s", got)
+	}
+}
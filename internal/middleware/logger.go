@@ -1,32 +1,49 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
 )
 
 const maxLogBodyBytes = 2048
 
+const redactedValue = "***"
+
 // Logger logs request/response summary for each HTTP request.
+// Sensitive JSON fields are masked and credential routes are not logged, see config.RequestLogConfig.
 func Logger() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
+		var logCfg config.RequestLogConfig
+		if cfg := config.GlobalConfig; cfg != nil {
+			logCfg = cfg.RequestLog
+		}
+		uri := string(c.Path())
+		if slices.Contains(logCfg.SkipPaths, uri) {
+			c.Next(ctx)
+			return
+		}
+
 		startAt := time.Now()
 		clientIP := c.ClientIP()
 		method := string(c.Method())
-		uri := string(c.Path())
 		isWS := isWebSocketHandshake(c)
-		reqBody := formatBody(c.Request.Body(), isWS)
+		reqBody := formatBody(redactBody(c.Request.Body(), logCfg.RedactFields), isWS)
 
 		c.Next(ctx)
 
 		status := c.Response.StatusCode()
-		respBody := formatBody(c.Response.Body(), status == http.StatusSwitchingProtocols)
+		respBody := formatBody(redactBody(c.Response.Body(), logCfg.RedactFields), status == http.StatusSwitchingProtocols)
 		cost := time.Since(startAt)
 
 		if status >= http.StatusBadRequest {
@@ -56,6 +73,67 @@ func isWebSocketHandshake(c *app.RequestContext) bool {
 	return len(c.GetHeader("Sec-WebSocket-Key")) > 0
 }
 
+// redactBody masks the values of sensitive fields in a JSON body.
+// Bodies that are not JSON are returned unchanged.
+func redactBody(body []byte, fields []string) []byte {
+	if len(fields) == 0 || len(body) == 0 || (body[0] != '{' && body[0] != '[') {
+		return body
+	}
+	// Cheap scan first, most bodies have no sensitive field and need no decoding
+	lower := bytes.ToLower(body)
+	if !slices.ContainsFunc(fields, func(field string) bool {
+		return bytes.Contains(lower, []byte(strings.ToLower(field)))
+	}) {
+		return body
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	if !redactValue(v, fields) {
+		return body
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue masks sensitive fields of v in place and reports whether anything was masked
+func redactValue(v any, fields []string) bool {
+	redacted := false
+	switch val := v.(type) {
+	case map[string]any:
+		for key, item := range val {
+			if isSensitiveField(key, fields) {
+				val[key] = redactedValue
+				redacted = true
+				continue
+			}
+			redacted = redactValue(item, fields) || redacted
+		}
+	case []any:
+		for _, item := range val {
+			redacted = redactValue(item, fields) || redacted
+		}
+	}
+	return redacted
+}
+
+func isSensitiveField(key string, fields []string) bool {
+	key = strings.ToLower(key)
+	for _, field := range fields {
+		if strings.Contains(key, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
+
 func formatBody(body []byte, skip bool) string {
 	if skip || len(body) == 0 {
 		return "-"
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	fields := []string{"password", "token", "secret"}
+
+	cases := []struct {
+		body string
+		want string
+	}{
+		{`{"user_id":"u1","password":"p@ss"}`, `{"password":"***","user_id":"u1"}`},
+		{`{"data":{"token":"t","user_info":{"id":"u1"}},"code":0}`, `{"code":0,"data":{"token":"***","user_info":{"id":"u1"}}}`},
+		{`[{"Access_Token":"t","n":12345678901234567890}]`, `[{"Access_Token":"***","n":12345678901234567890}]`},
+		// Untouched: no sensitive field, not JSON, or invalid JSON
+		{`{"user_id":"u1"}`, `{"user_id":"u1"}`},
+		{`password=p@ss`, `password=p@ss`},
+		{`{"password":`, `{"password":`},
+	}
+	for _, tc := range cases {
+		if got := string(redactBody([]byte(tc.body), fields)); got != tc.want {
+			t.Fatalf("redactBody(%s) = %s, want %s", tc.body, got, tc.want)
+		}
+	}
+}
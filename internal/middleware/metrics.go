@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+)
+
+// Metrics records HTTP request count and latency per route.
+// Routes are labeled by their registered pattern to keep label cardinality bounded.
+func Metrics() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		startAt := time.Now()
+
+		c.Next(ctx)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := string(c.Method())
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(startAt).Seconds())
+		metrics.HTTPRequestsTotal.WithLabelValues(method, route, strconv.Itoa(c.Response.StatusCode())).Inc()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/ratelimit"
+)
+
+func TestResolveRateLimitRule(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		PerIP:   config.RateLimitRule{Rate: 20, Burst: 40},
+		PerUser: config.RateLimitRule{Rate: 10, Burst: 20},
+		Routes: []config.RateLimitRouteRule{
+			{Path: "/im/auth/login", PerIP: config.RateLimitRule{Rate: 0.2, Burst: 5}},
+			{Path: "/im/msg/send", PerUser: config.RateLimitRule{Rate: 5, Burst: 10}},
+		},
+	}
+
+	cases := []struct {
+		route, dim string
+		rule       ratelimit.Rule
+		scope      string
+	}{
+		{"/im/auth/login", rateLimitDimIP, ratelimit.Rule{Rate: 0.2, Burst: 5}, "/im/auth/login"},
+		{"/im/msg/send", rateLimitDimUser, ratelimit.Rule{Rate: 5, Burst: 10}, "/im/msg/send"},
+		// Override without a rule for this dimension falls back to the shared default bucket
+		{"/im/msg/send", rateLimitDimIP, ratelimit.Rule{Rate: 20, Burst: 40}, rateLimitDefaultScope},
+		{"/im/user/info", rateLimitDimUser, ratelimit.Rule{Rate: 10, Burst: 20}, rateLimitDefaultScope},
+	}
+	for _, tc := range cases {
+		rule, scope := resolveRateLimitRule(cfg, tc.route, tc.dim)
+		if rule != tc.rule || scope != tc.scope {
+			t.Fatalf("route=%s dim=%s: expected %+v/%s, got %+v/%s", tc.route, tc.dim, tc.rule, tc.scope, rule, scope)
+		}
+	}
+}
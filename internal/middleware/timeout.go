@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/response"
+)
+
+// Timeout cancels the request context once the route's budget is spent and answers 504,
+// so handlers blocked on MySQL or Redis release the worker instead of holding it indefinitely.
+// The handler runs on the calling goroutine; it only stops early if its calls honour ctx.
+func Timeout() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		cfg := config.GlobalConfig
+		if cfg == nil || !cfg.RequestTimeout.Enabled || isTimeoutExempt(c) {
+			c.Next(ctx)
+			return
+		}
+
+		timeout := resolveTimeout(&cfg.RequestTimeout, c.FullPath())
+		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		c.Next(timeoutCtx)
+
+		if !errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) || ctx.Err() != nil {
+			return
+		}
+		log.CtxWarn(ctx, "request timeout: method=%s path=%s timeout=%s", c.Method(), c.FullPath(), timeout)
+		if c.Response.GetHijackWriter() != nil {
+			// The handler already streamed part of the body; a 504 can no longer be written
+			return
+		}
+		// Whatever the handler wrote is partial or an internal error caused by the cancellation
+		c.Response.ResetBody()
+		response.GatewayTimeout(ctx, c)
+	}
+}
+
+// resolveTimeout returns the long budget for pull/export routes and the default otherwise
+func resolveTimeout(cfg *config.RequestTimeoutConfig, route string) time.Duration {
+	if slices.Contains(cfg.LongRoutes, route) {
+		return cfg.Long
+	}
+	return cfg.Default
+}
+
+// timeoutExemptRoutes stream their response for as long as the data or the client lasts
+var timeoutExemptRoutes = []string{
+	"/im/events",
+	"/im/msg/export",
+	"/im/internal/msg/export",
+}
+
+// isTimeoutExempt reports long-lived requests: WebSocket upgrades, the SSE event stream,
+// NDJSON message exports and pprof profiles, whose duration is chosen by the caller
+func isTimeoutExempt(c *app.RequestContext) bool {
+	if strings.EqualFold(string(c.GetHeader("Upgrade")), "websocket") {
+		return true
+	}
+	if slices.Contains(timeoutExemptRoutes, c.FullPath()) {
+		return true
+	}
+	return strings.HasPrefix(c.FullPath(), "/debug/pprof/")
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+
+	imconfig "github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+func TestTimeoutCancelsSlowHandler(t *testing.T) {
+	prev := imconfig.GlobalConfig
+	imconfig.GlobalConfig = &imconfig.Config{RequestTimeout: imconfig.RequestTimeoutConfig{
+		Enabled:    true,
+		Default:    20 * time.Millisecond,
+		Long:       time.Second,
+		LongRoutes: []string{"/pull"},
+	}}
+	defer func() { imconfig.GlobalConfig = prev }()
+
+	slow := func(ctx context.Context, c *app.RequestContext) {
+		select {
+		case <-ctx.Done():
+			c.JSON(http.StatusOK, map[string]string{"error": ctx.Err().Error()})
+		case <-time.After(100 * time.Millisecond):
+			c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+		}
+	}
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(Timeout())
+	engine.GET("/send", slow)
+	engine.GET("/pull", slow)
+
+	w := ut.PerformRequest(engine, http.MethodGet, "/send", nil)
+	if w.Code != http.StatusGatewayTimeout || w.Body.String() != `{"code":1008,"message":"request timeout"}` {
+		t.Fatalf("expected 504 for default budget, got %d %s", w.Code, w.Body.String())
+	}
+	w = ut.PerformRequest(engine, http.MethodGet, "/pull", nil)
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"ok"}` {
+		t.Fatalf("expected long budget to finish, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// streamWriter records what a handler streams through a hijacked response writer
+type streamWriter struct {
+	bytes.Buffer
+}
+
+func (w *streamWriter) Flush() error    { return nil }
+func (w *streamWriter) Finalize() error { return nil }
+
+func TestTimeoutSkipsStreamedExport(t *testing.T) {
+	prev := imconfig.GlobalConfig
+	imconfig.GlobalConfig = &imconfig.Config{RequestTimeout: imconfig.RequestTimeoutConfig{
+		Enabled: true,
+		Default: 20 * time.Millisecond,
+		Long:    20 * time.Millisecond,
+	}}
+	defer func() { imconfig.GlobalConfig = prev }()
+
+	streams := map[string]*streamWriter{}
+	export := func(ctx context.Context, c *app.RequestContext) {
+		stream := &streamWriter{}
+		streams[c.FullPath()] = stream
+		c.SetContentType("application/x-ndjson")
+		c.Response.HijackWriter(stream)
+		for _, line := range []string{`{"seq":1}`, `{"seq":2}`} {
+			time.Sleep(30 * time.Millisecond)
+			if ctx.Err() != nil {
+				_, _ = c.Write([]byte(`{"code":1008}` + "\n"))
+				_ = c.Flush()
+				return
+			}
+			_, _ = c.Write([]byte(line + "\n"))
+			_ = c.Flush()
+		}
+	}
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.Use(Timeout())
+	engine.GET("/im/msg/export", export)
+	engine.GET("/im/internal/msg/export", export)
+	engine.GET("/im/msg/other", export)
+
+	for _, path := range []string{"/im/msg/export", "/im/internal/msg/export"} {
+		w := ut.PerformRequest(engine, http.MethodGet, path, nil)
+		if w.Code != http.StatusOK || streams[path].String() != "{\"seq\":1}\n{\"seq\":2}\n" {
+			t.Fatalf("expected the %s stream to finish, got %d %q", path, w.Code, streams[path].String())
+		}
+	}
+	// A stream that does run out of budget keeps what it wrote instead of getting a 504 on top
+	w := ut.PerformRequest(engine, http.MethodGet, "/im/msg/other", nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || streams["/im/msg/other"].String() != "{\"code\":1008}\n" {
+		t.Fatalf("expected the stream to end with its own error line, got %d %q %q", w.Code, w.Body.String(), streams["/im/msg/other"].String())
+	}
+}
@@ -6,50 +6,44 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ZaiSpace/nexo_im/pkg/traceid"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
 )
 
 const (
 	TraceIDHeader       = "Trace-Id"
 	XTraceIDHeader      = "X-Trace-Id"
-	TraceIDContextKey   = "trace_id"
+	TraceIDContextKey   = traceid.ContextKey
 	operationIDQueryKey = "operation_id"
 )
 
 // TraceID injects trace_id into context and echoes it in response header.
 // It also writes the trace header back to request headers so adaptor-based
 // handlers (e.g. websocket net/http handlers) can read the same value.
+// The trace_id is attached to the request's OpenTelemetry span as nexo.trace_id.
 func TraceID() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
-		spanCtx := trace.SpanContextFromContext(ctx)
-		c.Response.Header.Set("Trace-Id", spanCtx.TraceID().String())
+		traceID := resolveTraceID(ctx, c)
+		ctx = WithTraceID(ctx, traceID)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String(tracing.AttrTraceID, traceID))
+
+		c.Request.Header.Set(TraceIDHeader, traceID)
+		c.Response.Header.Set(TraceIDHeader, traceID)
 		c.Next(ctx)
 	}
 }
 
 // GetTraceID returns trace ID from context.
 func GetTraceID(ctx context.Context) string {
-	if ctx == nil {
-		return ""
-	}
-	if v := ctx.Value(TraceIDContextKey); v != nil {
-		if traceID, ok := v.(string); ok {
-			return strings.TrimSpace(traceID)
-		}
-	}
-	return ""
+	return traceid.FromContext(ctx)
 }
 
 // WithTraceID returns a new context carrying trace ID.
 func WithTraceID(ctx context.Context, traceID string) context.Context {
-	traceID = strings.TrimSpace(traceID)
-	if traceID == "" {
-		return ctx
-	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	return context.WithValue(ctx, TraceIDContextKey, traceID)
+	return traceid.WithTraceID(ctx, traceID)
 }
 
 func resolveTraceID(ctx context.Context, c *app.RequestContext) string {
@@ -63,6 +57,12 @@ func resolveTraceID(ctx context.Context, c *app.RequestContext) string {
 	if traceID == "" {
 		traceID = GetTraceID(ctx)
 	}
+	if traceID == "" {
+		// Reuse the OpenTelemetry trace id (from traceparent or the server span) when no legacy id was sent
+		if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+			traceID = spanCtx.TraceID().String()
+		}
+	}
 	if traceID == "" {
 		traceID = strings.ReplaceAll(uuid.NewString(), "-", "")
 	}
@@ -0,0 +1,401 @@
+// Package mqttbridge lets constrained clients that speak MQTT instead of the WebSocket
+// protocol take part in conversations. Devices publish to per-user topics on an external
+// broker; the bridge authenticates them with their token, sends their messages through
+// the message service and publishes the gateway pushes back to them.
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/gateway"
+	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+)
+
+// Operations, the last level of the topics <prefix>/users/<user_id>/<op>
+const (
+	OpConnect    = "connect"    // device -> server: {"token","platform_id"}, starts or renews a session
+	OpDisconnect = "disconnect" // device -> server: {"platform_id"}, ends sessions; suitable as MQTT will
+	OpSend       = "send"       // device -> server: {"token","msg_incr", message fields of /msg/send}
+	OpAck        = "ack"        // server -> device: result of connect and send
+	OpPush       = "push"       // server -> device: {"req_identifier","data"}, the pushes of WS connections
+)
+
+// SDKType identifies bridged connections in the online status
+const SDKType = "mqtt"
+
+var errBrokerDisconnected = errors.New("mqtt broker disconnected")
+
+// MessageSender sends the messages of devices
+type MessageSender interface {
+	SendMessage(ctx context.Context, senderId string, req *service.SendMessageRequest) (*entity.Message, error)
+}
+
+// Gateway registers device sessions as connections
+type Gateway interface {
+	Attach(ctx context.Context, conn gateway.ClientConn, userId string, platformId int, sdkType string) (*gateway.Client, error)
+}
+
+// Bridge connects the device topics of the broker to the gateway and the message service
+type Bridge struct {
+	cfg        *config.Config
+	broker     Broker
+	gateway    Gateway
+	msgService MessageSender
+	prefix     string
+
+	ctx      context.Context
+	mu       sync.Mutex
+	sessions map[string]*session // user_id:platform_id -> device session
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// session is a device registered as a gateway connection
+type session struct {
+	client *gateway.Client
+	conn   *deviceConn
+}
+
+// connectRequest is published to the connect topic
+type connectRequest struct {
+	Token      string `json:"token"`
+	PlatformId int    `json:"platform_id"` // defaults to the platform of the token
+	MsgIncr    string `json:"msg_incr"`
+}
+
+// disconnectRequest is published to the disconnect topic
+type disconnectRequest struct {
+	PlatformId *int `json:"platform_id"` // all sessions of the user if unset
+}
+
+// sendRequest is published to the send topic
+type sendRequest struct {
+	Token   string `json:"token"`
+	MsgIncr string `json:"msg_incr"`
+	gateway.SendMsgReq
+}
+
+// ack is published to the ack topic in reply to connect and send
+type ack struct {
+	Op      string `json:"op"`
+	MsgIncr string `json:"msg_incr,omitempty"`
+	ErrCode int    `json:"err_code"` // errcode, 0 = success
+	ErrMsg  string `json:"err_msg,omitempty"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// pushEvent is published to the push topic
+type pushEvent struct {
+	ReqIdentifier int32           `json:"req_identifier"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+// New creates the bridge; Run subscribes to the device topics
+func New(cfg *config.Config, broker Broker, gw Gateway, msgService MessageSender) *Bridge {
+	return &Bridge{
+		cfg:        cfg,
+		broker:     broker,
+		gateway:    gw,
+		msgService: msgService,
+		prefix:     cfg.MQTT.TopicPrefix,
+		sessions:   make(map[string]*session),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Run subscribes to the device topics and starts expiring idle sessions
+func (b *Bridge) Run(ctx context.Context) error {
+	b.ctx = ctx
+	handlers := map[string]func(userId string, payload []byte){
+		OpConnect:    b.handleConnect,
+		OpDisconnect: b.handleDisconnect,
+		OpSend:       b.handleSend,
+	}
+	for op, handler := range handlers {
+		err := b.broker.Subscribe(b.topic("+", op), func(topic string, payload []byte) {
+			if userId, ok := b.topicUserId(topic); ok {
+				handler(userId, payload)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	b.wg.Add(1)
+	go b.reapLoop()
+	return nil
+}
+
+// Shutdown ends every device session and disconnects from the broker
+func (b *Bridge) Shutdown(ctx context.Context) error {
+	close(b.stop)
+	b.wg.Wait()
+
+	b.mu.Lock()
+	for key, s := range b.sessions {
+		_ = s.client.Close()
+		delete(b.sessions, key)
+	}
+	b.mu.Unlock()
+	b.broker.Close()
+	log.CtxInfo(ctx, "mqtt bridge stopped")
+	return nil
+}
+
+func (b *Bridge) topic(userId, op string) string {
+	return b.prefix + "/users/" + userId + "/" + op
+}
+
+// topicUserId extracts the user id of <prefix>/users/<user_id>/<op>
+func (b *Bridge) topicUserId(topic string) (string, bool) {
+	rest, ok := strings.CutPrefix(topic, b.prefix+"/users/")
+	if !ok {
+		return "", false
+	}
+	userId, _, ok := strings.Cut(rest, "/")
+	return userId, ok && userId != ""
+}
+
+func sessionKey(userId string, platformId int) string {
+	return userId + ":" + strconv.Itoa(platformId)
+}
+
+// authenticate checks that token belongs to the user of the topic and grants the msg scope
+func (b *Bridge) authenticate(userId, token string, write bool) (int, error) {
+	claims, err := middleware.ParseTokenWithFallback(token, b.cfg)
+	if err != nil || claims.UserId != userId {
+		return 0, errcode.ErrUnauthorized
+	}
+	if len(claims.Scopes) > 0 && !scope.Allows(claims.Scopes, scope.Msg, write) {
+		return 0, errcode.ErrNoPermission
+	}
+	return claims.PlatformId, nil
+}
+
+func (b *Bridge) handleConnect(userId string, payload []byte) {
+	var req connectRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		b.reply(userId, &ack{Op: OpConnect}, errcode.ErrInvalidParam)
+		return
+	}
+	reply := &ack{Op: OpConnect, MsgIncr: req.MsgIncr}
+	platformId, err := b.authenticate(userId, req.Token, false)
+	if err != nil {
+		b.reply(userId, reply, err)
+		return
+	}
+	if req.PlatformId != 0 {
+		platformId = req.PlatformId
+	}
+
+	key := sessionKey(userId, platformId)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.sessions[key]; ok && !s.client.IsClosed() {
+		s.conn.touch()
+		b.reply(userId, reply, nil)
+		return
+	}
+
+	conn := newDeviceConn(func(data []byte) error {
+		return b.broker.Publish(b.topic(userId, OpPush), data)
+	})
+	client, err := b.gateway.Attach(b.ctx, conn, userId, platformId, SDKType)
+	if err != nil {
+		b.reply(userId, reply, err)
+		return
+	}
+	b.sessions[key] = &session{client: client, conn: conn}
+	log.CtxDebug(b.ctx, "mqtt session created: user_id=%s, platform_id=%d, conn_id=%s", userId, platformId, client.ConnId)
+	b.reply(userId, reply, nil)
+}
+
+func (b *Bridge) handleDisconnect(userId string, payload []byte) {
+	var req disconnectRequest
+	if len(payload) > 0 {
+		// A malformed will still ends the sessions
+		_ = json.Unmarshal(payload, &req)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, s := range b.sessions {
+		if s.client.UserId != userId || (req.PlatformId != nil && s.client.PlatformId != *req.PlatformId) {
+			continue
+		}
+		_ = s.client.Close()
+		delete(b.sessions, key)
+	}
+}
+
+func (b *Bridge) handleSend(userId string, payload []byte) {
+	var req sendRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		b.reply(userId, &ack{Op: OpSend}, errcode.ErrInvalidParam)
+		return
+	}
+	reply := &ack{Op: OpSend, MsgIncr: req.MsgIncr}
+	platformId, err := b.authenticate(userId, req.Token, true)
+	if err != nil {
+		b.reply(userId, reply, err)
+		return
+	}
+
+	msg, err := b.msgService.SendMessage(b.ctx, userId, &service.SendMessageRequest{
+		ClientMsgId: req.ClientMsgId,
+		RecvId:      req.RecvId,
+		GroupId:     req.GroupId,
+		SessionType: req.SessionType,
+		MsgType:     req.MsgType,
+		Content: entity.NewMessageContentFromFlat(entity.FlatMessageContent{
+			Text:      req.Content.Text,
+			Image:     req.Content.Image,
+			Video:     req.Content.Video,
+			Audio:     req.Content.Audio,
+			File:      req.Content.File,
+			Custom:    req.Content.Custom,
+			Encrypted: req.Content.Encrypted,
+			Poll:      (*entity.PollContent)(req.Content.Poll),
+		}),
+	})
+	if err != nil {
+		b.reply(userId, reply, err)
+		return
+	}
+
+	b.mu.Lock()
+	if s, ok := b.sessions[sessionKey(userId, platformId)]; ok {
+		s.conn.touch()
+	}
+	b.mu.Unlock()
+	reply.Data = &gateway.SendMsgResp{
+		ServerMsgId:    msg.Id,
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		ClientMsgId:    msg.ClientMsgId,
+		SendAt:         msg.SendAt,
+	}
+	b.reply(userId, reply, nil)
+}
+
+// reply publishes reply to the ack topic of the user, with the errcode of err if set
+func (b *Bridge) reply(userId string, reply *ack, err error) {
+	if err != nil {
+		e := errcode.ErrInternalServer
+		errors.As(err, &e)
+		reply.ErrCode, reply.ErrMsg = e.Code, e.Msg
+	}
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.CtxError(b.ctx, "encode mqtt ack failed: user_id=%s, error=%v", userId, err)
+		return
+	}
+	if err = b.broker.Publish(b.topic(userId, OpAck), data); err != nil {
+		log.CtxWarn(b.ctx, "publish mqtt ack failed: user_id=%s, op=%s, error=%v", userId, reply.Op, err)
+	}
+}
+
+// reapLoop ends the sessions that were not renewed within the session TTL
+func (b *Bridge) reapLoop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.MQTT.SessionTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.reapSessions(time.Now().Add(-b.cfg.MQTT.SessionTTL))
+		}
+	}
+}
+
+// reapSessions ends the sessions last seen before, and those closed by the gateway
+func (b *Bridge) reapSessions(before time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, s := range b.sessions {
+		if s.client.IsClosed() || s.conn.lastSeenBefore(before) {
+			_ = s.client.Close()
+			delete(b.sessions, key)
+		}
+	}
+}
+
+// deviceConn implements gateway.ClientConn by publishing the pushes to the device topic.
+// ReadMessage blocks until the connection is closed.
+type deviceConn struct {
+	publish   func([]byte) error
+	done      chan struct{}
+	closeOnce sync.Once
+	lastSeen  atomic.Int64 // unix ms of the last connect or send
+}
+
+func newDeviceConn(publish func([]byte) error) *deviceConn {
+	c := &deviceConn{publish: publish, done: make(chan struct{})}
+	c.touch()
+	return c
+}
+
+func (c *deviceConn) touch() {
+	c.lastSeen.Store(time.Now().UnixMilli())
+}
+
+func (c *deviceConn) lastSeenBefore(t time.Time) bool {
+	return c.lastSeen.Load() < t.UnixMilli()
+}
+
+// ReadMessage blocks until the connection is closed; devices send over their own topics
+func (c *deviceConn) ReadMessage() ([]byte, error) {
+	<-c.done
+	return nil, gateway.ErrConnClosed
+}
+
+// WriteMessage publishes a gateway frame as a push event
+func (c *deviceConn) WriteMessage(data []byte) error {
+	select {
+	case <-c.done:
+		return gateway.ErrConnClosed
+	default:
+	}
+	var frame gateway.WSResponse
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return err
+	}
+	event, err := json.Marshal(&pushEvent{ReqIdentifier: frame.ReqIdentifier, Data: frame.Data})
+	if err != nil {
+		return err
+	}
+	return c.publish(event)
+}
+
+// Close closes the connection
+func (c *deviceConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+// SetReadDeadline is a no-op, device connections are never read
+func (c *deviceConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline is a no-op, publishing does not block
+func (c *deviceConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
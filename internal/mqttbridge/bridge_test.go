@@ -0,0 +1,220 @@
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/gateway"
+	"github.com/ZaiSpace/nexo_im/internal/service"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+)
+
+const testSecret = "unit-test-secret"
+
+type published struct {
+	topic   string
+	payload []byte
+}
+
+type fakeBroker struct {
+	mu        sync.Mutex
+	handlers  map[string]func(topic string, payload []byte)
+	published chan published
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{
+		handlers:  make(map[string]func(topic string, payload []byte)),
+		published: make(chan published, 16),
+	}
+}
+
+func (f *fakeBroker) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[topic] = handler
+	return nil
+}
+
+func (f *fakeBroker) Publish(topic string, payload []byte) error {
+	f.published <- published{topic: topic, payload: payload}
+	return nil
+}
+
+func (f *fakeBroker) Close() {}
+
+// deliver publishes payload from a device to topic, matching the + wildcard subscriptions
+func (f *fakeBroker) deliver(t *testing.T, topic string, payload any) {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	parts := strings.Split(topic, "/")
+	parts[2] = "+"
+	f.mu.Lock()
+	handler := f.handlers[strings.Join(parts, "/")]
+	f.mu.Unlock()
+	if handler == nil {
+		t.Fatalf("no subscription matches %s", topic)
+	}
+	handler(topic, data)
+}
+
+func (f *fakeBroker) next(t *testing.T) published {
+	t.Helper()
+	select {
+	case p := <-f.published:
+		return p
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing published")
+		return published{}
+	}
+}
+
+type fakeSender struct {
+	reqs []*service.SendMessageRequest
+}
+
+func (f *fakeSender) SendMessage(ctx context.Context, senderId string, req *service.SendMessageRequest) (*entity.Message, error) {
+	f.reqs = append(f.reqs, req)
+	return &entity.Message{Id: 9, ConversationId: "si_alice:bob", Seq: 3, ClientMsgId: req.ClientMsgId, SenderId: senderId, SendAt: 100}, nil
+}
+
+func newTestBridge(t *testing.T) (*Bridge, *fakeBroker, *fakeSender, *gateway.WsServer) {
+	t.Helper()
+	cfg := &config.Config{
+		JWT:       config.JWTConfig{Secret: testSecret},
+		WebSocket: config.WebSocketConfig{MaxConnNum: 100, PushChannelSize: 8},
+		MQTT:      config.MQTTConfig{TopicPrefix: "nexo", SessionTTL: time.Minute},
+	}
+	wsServer := gateway.NewWsServer(cfg, nil, nil, nil)
+	wsServer.Run(context.Background())
+	broker, sender := newFakeBroker(), &fakeSender{}
+	bridge := New(cfg, broker, wsServer, sender)
+	if err := bridge.Run(context.Background()); err != nil {
+		t.Fatalf("run bridge: %v", err)
+	}
+	t.Cleanup(func() { _ = bridge.Shutdown(context.Background()) })
+	return bridge, broker, sender, wsServer
+}
+
+func token(t *testing.T, userId string, scopes []string) string {
+	t.Helper()
+	tok, err := jwt.GenerateScopedToken(userId, 5, scopes, testSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return tok
+}
+
+func decodeAck(t *testing.T, p published) ack {
+	t.Helper()
+	if p.topic != "nexo/users/alice/ack" {
+		t.Fatalf("unexpected ack topic %s", p.topic)
+	}
+	var a ack
+	if err := json.Unmarshal(p.payload, &a); err != nil {
+		t.Fatalf("unmarshal ack: %v", err)
+	}
+	return a
+}
+
+func TestBridgeConnectReceivesPushes(t *testing.T) {
+	_, broker, _, wsServer := newTestBridge(t)
+
+	broker.deliver(t, "nexo/users/alice/connect", map[string]any{"token": token(t, "alice", nil), "msg_incr": "1"})
+	if a := decodeAck(t, broker.next(t)); a.Op != OpConnect || a.ErrCode != 0 || a.MsgIncr != "1" {
+		t.Fatalf("unexpected connect ack: %+v", a)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for wsServer.GetOnlineConnCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("mqtt session not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	wsServer.AsyncPushToUsers(&entity.Message{ConversationId: "si_alice:bob", Seq: 1, SenderId: "bob"}, []string{"alice"}, "")
+
+	p := broker.next(t)
+	if p.topic != "nexo/users/alice/push" {
+		t.Fatalf("unexpected push topic %s", p.topic)
+	}
+	var event struct {
+		ReqIdentifier int32               `json:"req_identifier"`
+		Data          gateway.PushMsgData `json:"data"`
+	}
+	if err := json.Unmarshal(p.payload, &event); err != nil {
+		t.Fatalf("unmarshal push: %v", err)
+	}
+	if event.ReqIdentifier != gateway.WSPushMsg || len(event.Data.Msgs["si_alice:bob"]) != 1 {
+		t.Fatalf("unexpected push: %s", p.payload)
+	}
+
+	// Disconnect unregisters the session
+	broker.deliver(t, "nexo/users/alice/disconnect", map[string]any{})
+	deadline = time.Now().Add(2 * time.Second)
+	for wsServer.GetOnlineConnCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("mqtt session not unregistered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBridgeSend(t *testing.T) {
+	_, broker, sender, _ := newTestBridge(t)
+
+	broker.deliver(t, "nexo/users/alice/send", map[string]any{
+		"token":         token(t, "alice", nil),
+		"msg_incr":      "7",
+		"client_msg_id": "c1",
+		"recv_id":       "bob",
+		"session_type":  1,
+		"msg_type":      1,
+		"content":       map[string]any{"text": "hi"},
+	})
+
+	a := decodeAck(t, broker.next(t))
+	if a.Op != OpSend || a.ErrCode != 0 || a.MsgIncr != "7" {
+		t.Fatalf("unexpected send ack: %+v", a)
+	}
+	if data, _ := a.Data.(map[string]any); data["seq"] != float64(3) {
+		t.Fatalf("unexpected send ack data: %+v", a.Data)
+	}
+	if len(sender.reqs) != 1 || sender.reqs[0].RecvId != "bob" || sender.reqs[0].Content.Text == nil || sender.reqs[0].Content.Text.Text != "hi" {
+		t.Fatalf("unexpected send request: %+v", sender.reqs)
+	}
+}
+
+func TestBridgeRejectsForeignAndReadOnlyTokens(t *testing.T) {
+	_, broker, sender, _ := newTestBridge(t)
+
+	// Token of another user
+	broker.deliver(t, "nexo/users/alice/connect", map[string]any{"token": token(t, "mallory", nil)})
+	if a := decodeAck(t, broker.next(t)); a.ErrCode != errcode.ErrUnauthorized.Code {
+		t.Fatalf("expected unauthorized, got %+v", a)
+	}
+
+	// Read-only token cannot send
+	broker.deliver(t, "nexo/users/alice/send", map[string]any{
+		"token":         token(t, "alice", []string{scope.Read(scope.Msg)}),
+		"client_msg_id": "c1",
+		"recv_id":       "bob",
+	})
+	if a := decodeAck(t, broker.next(t)); a.ErrCode != errcode.ErrNoPermission.Code {
+		t.Fatalf("expected no permission, got %+v", a)
+	}
+	if len(sender.reqs) != 0 {
+		t.Fatalf("unexpected send: %+v", sender.reqs)
+	}
+}
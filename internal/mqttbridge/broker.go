@@ -0,0 +1,110 @@
+package mqttbridge
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+// brokerTimeout bounds connecting and subscribing to the broker
+const brokerTimeout = 10 * time.Second
+
+// Broker is the MQTT broker connection of the bridge
+type Broker interface {
+	// Subscribe routes the messages published to topic, which may contain wildcards, to handler
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+	// Publish sends payload to topic without waiting for the broker
+	Publish(topic string, payload []byte) error
+	Close()
+}
+
+// pahoBroker implements Broker with the Eclipse Paho client
+type pahoBroker struct {
+	client mqtt.Client
+	qos    byte
+
+	mu   sync.Mutex
+	subs map[string]mqtt.MessageHandler // restored on reconnect, the session is clean
+}
+
+// NewBroker connects to the broker of cfg, reconnecting automatically when the connection drops
+func NewBroker(cfg *config.MQTTConfig) (Broker, error) {
+	b := &pahoBroker{qos: byte(cfg.QoS), subs: make(map[string]mqtt.MessageHandler)}
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(cfg.ClientId).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		// Handlers call the message service, they must not hold up each other
+		SetOrderMatters(false).
+		SetOnConnectHandler(b.resubscribe).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Warn("mqtt broker connection lost: %v", err)
+		})
+	b.client = mqtt.NewClient(opts)
+
+	token := b.client.Connect()
+	if !token.WaitTimeout(brokerTimeout) {
+		return nil, fmt.Errorf("connect to mqtt broker %s: timeout", cfg.BrokerURL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("connect to mqtt broker %s: %w", cfg.BrokerURL, err)
+	}
+	return b, nil
+}
+
+func (b *pahoBroker) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	callback := func(_ mqtt.Client, msg mqtt.Message) {
+		handler(msg.Topic(), msg.Payload())
+	}
+	b.mu.Lock()
+	b.subs[topic] = callback
+	b.mu.Unlock()
+	return b.subscribe(topic, callback)
+}
+
+func (b *pahoBroker) subscribe(topic string, callback mqtt.MessageHandler) error {
+	token := b.client.Subscribe(topic, b.qos, callback)
+	if !token.WaitTimeout(brokerTimeout) {
+		return fmt.Errorf("subscribe %s: timeout", topic)
+	}
+	return token.Error()
+}
+
+// resubscribe restores the subscriptions after a reconnect
+func (b *pahoBroker) resubscribe(_ mqtt.Client) {
+	b.mu.Lock()
+	subs := make(map[string]mqtt.MessageHandler, len(b.subs))
+	for topic, callback := range b.subs {
+		subs[topic] = callback
+	}
+	b.mu.Unlock()
+
+	// The handler runs on the client goroutine, waiting for the acks here would deadlock
+	go func() {
+		for topic, callback := range subs {
+			if err := b.subscribe(topic, callback); err != nil {
+				log.Error("mqtt resubscribe failed: topic=%s, error=%v", topic, err)
+			}
+		}
+	}()
+}
+
+func (b *pahoBroker) Publish(topic string, payload []byte) error {
+	if !b.client.IsConnectionOpen() {
+		return errBrokerDisconnected
+	}
+	b.client.Publish(topic, b.qos, false, payload)
+	return nil
+}
+
+func (b *pahoBroker) Close() {
+	b.client.Disconnect(250)
+}
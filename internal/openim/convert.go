@@ -0,0 +1,366 @@
+package openim
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// OpenIM content elements, only the fields nexo_im keeps
+type (
+	textElem struct {
+		Content string `json:"content"`
+	}
+	atTextElem struct {
+		Text string `json:"text"` // also the text of a quote message
+	}
+	pictureElem struct {
+		SourcePicture   pictureInfo `json:"sourcePicture"`
+		BigPicture      pictureInfo `json:"bigPicture"`
+		SnapshotPicture pictureInfo `json:"snapshotPicture"`
+	}
+	pictureInfo struct {
+		Url string `json:"url"`
+	}
+	soundElem struct {
+		SourceUrl string `json:"sourceUrl"`
+		Duration  int64  `json:"duration"` // seconds
+	}
+	videoElem struct {
+		VideoUrl string `json:"videoUrl"`
+	}
+	fileElem struct {
+		SourceUrl string `json:"sourceUrl"`
+		FileName  string `json:"fileName"`
+	}
+	customElem struct {
+		Data string `json:"data"`
+	}
+)
+
+// ToUser converts an OpenIM user. It has no password: like with OpenIM, the app server
+// registers the user through the internal API or issues its tokens.
+func ToUser(u *User) *entity.User {
+	return &entity.User{
+		Id:        u.UserID,
+		Nickname:  u.Nickname,
+		Avatar:    u.FaceURL,
+		Extra:     toExtra(u.Ex),
+		Status:    constant.UserStatusNormal,
+		CreatedAt: u.CreateTime.millis(),
+	}
+}
+
+// FromUser converts a nexo_im user
+func FromUser(u *entity.User) *User {
+	return &User{
+		UserID:     u.Id,
+		Nickname:   u.Nickname,
+		FaceURL:    u.Avatar,
+		Ex:         fromExtra(u.Extra),
+		CreateTime: Date(u.CreatedAt),
+	}
+}
+
+// ToGroup converts an OpenIM group; the notification becomes the announcement
+// and a muted group mutes all its members
+func ToGroup(g *Group) *entity.Group {
+	group := &entity.Group{
+		Id:                    g.GroupID,
+		Name:                  g.GroupName,
+		Introduction:          g.Introduction,
+		Avatar:                g.FaceURL,
+		Extra:                 toExtra(g.Ex),
+		Status:                constant.GroupStatusNormal,
+		CreatorUserId:         g.CreatorUserID,
+		GroupType:             int32(g.GroupType),
+		MuteAll:               g.Status == GroupStatusMuted,
+		Announcement:          g.Notification,
+		AnnouncementUpdatedAt: g.NotificationUpdateTime.millis(),
+		AnnouncementUserId:    g.NotificationUserID,
+		CreatedAt:             g.CreateTime.millis(),
+	}
+	if g.Status == GroupStatusDismissed {
+		group.Status = constant.GroupStatusDismissed
+	}
+	return group
+}
+
+// FromGroup converts a nexo_im group
+func FromGroup(g *entity.Group) *Group {
+	group := &Group{
+		GroupID:                g.Id,
+		GroupName:              g.Name,
+		Notification:           g.Announcement,
+		Introduction:           g.Introduction,
+		FaceURL:                g.Avatar,
+		CreateTime:             Date(g.CreatedAt),
+		Ex:                     fromExtra(g.Extra),
+		Status:                 GroupStatusOk,
+		CreatorUserID:          g.CreatorUserId,
+		GroupType:              Long(g.GroupType),
+		NotificationUpdateTime: Date(g.AnnouncementUpdatedAt),
+		NotificationUserID:     g.AnnouncementUserId,
+	}
+	switch {
+	case !g.IsNormal():
+		group.Status = GroupStatusDismissed
+	case g.MuteAll:
+		group.Status = GroupStatusMuted
+	}
+	return group
+}
+
+// ToGroupMember converts an OpenIM group member. Imported members see the whole imported
+// history of the group, OpenIM's per user min seq is not exported with the collections.
+func ToGroupMember(m *GroupMember, now int64) *entity.GroupMember {
+	member := &entity.GroupMember{
+		GroupId:       m.GroupID,
+		UserId:        m.UserID,
+		GroupNickname: m.Nickname,
+		GroupAvatar:   m.FaceURL,
+		Extra:         toExtra(m.Ex),
+		RoleLevel:     constant.RoleLevelMember,
+		Status:        constant.GroupMemberStatusNormal,
+		JoinedAt:      m.JoinTime.millis(),
+		JoinSeq:       1,
+		InviterUserId: m.InviterUserID,
+	}
+	switch {
+	case m.RoleLevel >= RoleLevelOwner:
+		member.RoleLevel = constant.RoleLevelOwner
+	case m.RoleLevel >= RoleLevelAdmin:
+		member.RoleLevel = constant.RoleLevelAdmin
+	}
+	if muteEnd := m.MuteEndTime.millis(); muteEnd > now {
+		member.MutedUntil = muteEnd
+	}
+	return member
+}
+
+// FromGroupMember converts a nexo_im group member
+func FromGroupMember(m *entity.GroupMember) *GroupMember {
+	member := &GroupMember{
+		GroupID:       m.GroupId,
+		UserID:        m.UserId,
+		Nickname:      m.GroupNickname,
+		FaceURL:       m.GroupAvatar,
+		RoleLevel:     RoleLevelOrdinary,
+		JoinTime:      Date(m.JoinedAt),
+		InviterUserID: m.InviterUserId,
+		MuteEndTime:   Date(m.MutedUntil),
+		Ex:            fromExtra(m.Extra),
+	}
+	switch {
+	case m.IsOwner():
+		member.RoleLevel = RoleLevelOwner
+	case m.IsAdmin():
+		member.RoleLevel = RoleLevelAdmin
+	}
+	return member
+}
+
+// ToMessage converts the message of an OpenIM msg slot. It returns false for the slots
+// nexo_im does not store: empty slots and notification conversations.
+// Revoked and deleted messages, notifications in a chat and content nexo_im cannot
+// decode become tombstones deleted at now, keeping the seqs of the conversation continuous.
+func ToMessage(info *MsgInfo, now int64) (*entity.Message, bool) {
+	m := info.Msg
+	if m == nil || m.Seq <= 0 {
+		return nil, false
+	}
+	msg := &entity.Message{
+		Seq:         int64(m.Seq),
+		ClientMsgId: m.ClientMsgID,
+		SenderId:    m.SendID,
+		MsgType:     constant.MsgTypeCustom,
+		Extra:       toExtra(m.Ex),
+		SendAt:      int64(m.SendTime),
+	}
+	switch m.SessionType {
+	case SessionTypeSingle:
+		msg.ConversationId = entity.GenSingleConversationId(m.SendID, m.RecvID)
+		msg.RecvId = m.RecvID
+		msg.SessionType = constant.SessionTypeSingle
+	case SessionTypeGroup, SessionTypeReadGroup:
+		msg.ConversationId = entity.GenGroupConversationId(m.GroupID)
+		msg.GroupId = m.GroupID
+		msg.SessionType = constant.SessionTypeGroup
+	default:
+		return nil, false
+	}
+	if msg.ClientMsgId == "" {
+		msg.ClientMsgId = m.ServerMsgID
+	}
+	if msg.ClientMsgId == "" {
+		msg.ClientMsgId = fmt.Sprintf("%s:%d", msg.ConversationId, msg.Seq)
+	}
+
+	msgType, content, ok := toContent(int64(m.ContentType), m.Content)
+	if !ok || info.isRevoked() || m.Status == MsgStatusDeleted {
+		msg.Extra = nil
+		msg.DeletedAt = now
+		return msg, true
+	}
+	msg.MsgType = msgType
+	msg.Content = content
+	return msg, true
+}
+
+// FromMessage converts a nexo_im message, it returns false for the messages OpenIM has no conversation for
+func FromMessage(msg *entity.Message) (*Msg, bool) {
+	m := &Msg{
+		SendID:      msg.SenderId,
+		ClientMsgID: msg.ClientMsgId,
+		ServerMsgID: fmt.Sprintf("%s:%d", msg.ConversationId, msg.Seq),
+		MsgFrom:     100, // User message
+		Seq:         Long(msg.Seq),
+		SendTime:    Long(msg.SendAt),
+		CreateTime:  Long(msg.CreatedAt),
+		Status:      MsgStatusSucceed,
+		Ex:          fromExtra(msg.Extra),
+	}
+	if msg.DeletedAt > 0 {
+		m.Status = MsgStatusDeleted
+	}
+	switch msg.SessionType {
+	case constant.SessionTypeSingle:
+		m.RecvID = msg.RecvId
+		m.SessionType = SessionTypeSingle
+	case constant.SessionTypeGroup:
+		m.GroupID = msg.GroupId
+		m.SessionType = SessionTypeReadGroup
+	default:
+		return nil, false
+	}
+	contentType, content := fromContent(msg.Content)
+	m.ContentType = Long(contentType)
+	m.Content = content
+	return m, true
+}
+
+// ConversationId returns the OpenIM conversation id of a message
+func ConversationId(m *Msg) string {
+	if m.SessionType == SessionTypeSingle {
+		users := []string{m.SendID, m.RecvID}
+		sort.Strings(users)
+		return "si_" + strings.Join(users, "_")
+	}
+	return "sg_" + m.GroupID
+}
+
+// toContent converts the content of an OpenIM content type
+func toContent(contentType int64, raw string) (int32, entity.MessageContent, bool) {
+	var content entity.MessageContent
+	switch contentType {
+	case ContentTypeText:
+		var elem textElem
+		if json.Unmarshal([]byte(raw), &elem) != nil {
+			return 0, content, false
+		}
+		content.Text = &entity.TextContent{Text: elem.Content}
+		return constant.MsgTypeText, content, true
+	case ContentTypeAtText, ContentTypeQuote:
+		var elem atTextElem
+		if json.Unmarshal([]byte(raw), &elem) != nil {
+			return 0, content, false
+		}
+		content.Text = &entity.TextContent{Text: elem.Text}
+		return constant.MsgTypeText, content, true
+	case ContentTypePicture:
+		var elem pictureElem
+		if json.Unmarshal([]byte(raw), &elem) != nil {
+			return 0, content, false
+		}
+		content.Image = &entity.ImageContent{Url: elem.SourcePicture.Url}
+		return constant.MsgTypeImage, content, true
+	case ContentTypeVoice:
+		var elem soundElem
+		if json.Unmarshal([]byte(raw), &elem) != nil {
+			return 0, content, false
+		}
+		content.Audio = &entity.AudioContent{Url: elem.SourceUrl, Duration: int(elem.Duration) * 1000}
+		return constant.MsgTypeAudio, content, true
+	case ContentTypeVideo:
+		var elem videoElem
+		if json.Unmarshal([]byte(raw), &elem) != nil {
+			return 0, content, false
+		}
+		content.Video = &entity.VideoContent{Url: elem.VideoUrl}
+		return constant.MsgTypeVideo, content, true
+	case ContentTypeFile:
+		var elem fileElem
+		if json.Unmarshal([]byte(raw), &elem) != nil {
+			return 0, content, false
+		}
+		content.File = &entity.FileContent{Url: elem.SourceUrl, Name: elem.FileName}
+		return constant.MsgTypeFile, content, true
+	case ContentTypeCustom:
+		var elem customElem
+		if json.Unmarshal([]byte(raw), &elem) == nil && json.Valid([]byte(elem.Data)) {
+			content.Custom = json.RawMessage(elem.Data)
+			return constant.MsgTypeCustom, content, true
+		}
+	}
+	if contentType >= contentTypeNotificationBegin {
+		return 0, content, false
+	}
+	// Other user content (cards, locations, ...) is kept as the custom payload it was sent as
+	if !json.Valid([]byte(raw)) {
+		return 0, content, false
+	}
+	content.Custom = json.RawMessage(raw)
+	return constant.MsgTypeCustom, content, true
+}
+
+// fromContent converts a nexo_im content to an OpenIM content type and element
+func fromContent(c entity.MessageContent) (int, string) {
+	var contentType int
+	var elem any
+	switch {
+	case c.Text != nil:
+		contentType, elem = ContentTypeText, textElem{Content: c.Text.Text}
+	case c.Image != nil:
+		picture := pictureInfo{Url: c.Image.Url}
+		contentType, elem = ContentTypePicture, pictureElem{SourcePicture: picture, BigPicture: picture, SnapshotPicture: picture}
+	case c.Audio != nil:
+		contentType, elem = ContentTypeVoice, soundElem{SourceUrl: c.Audio.Url, Duration: int64(c.Audio.Duration) / 1000}
+	case c.Video != nil:
+		contentType, elem = ContentTypeVideo, videoElem{VideoUrl: c.Video.Url}
+	case c.File != nil:
+		contentType, elem = ContentTypeFile, fileElem{SourceUrl: c.File.Url, FileName: c.File.Name}
+	default:
+		// Custom content, and the empty content of tombstones
+		contentType, elem = ContentTypeCustom, customElem{Data: string(c.Custom)}
+	}
+	data, _ := json.Marshal(elem)
+	return contentType, string(data)
+}
+
+// toExtra converts an OpenIM ex string to a JSON extra, wrapping the strings that are not JSON
+func toExtra(ex string) *string {
+	if ex == "" {
+		return nil
+	}
+	if !json.Valid([]byte(ex)) {
+		data, _ := json.Marshal(ex)
+		ex = string(data)
+	}
+	return &ex
+}
+
+// fromExtra converts a JSON extra to an OpenIM ex string
+func fromExtra(extra *string) string {
+	if extra == nil {
+		return ""
+	}
+	var s string
+	if json.Unmarshal([]byte(*extra), &s) == nil {
+		return s
+	}
+	return *extra
+}
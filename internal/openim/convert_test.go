@@ -0,0 +1,115 @@
+package openim
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+func TestDecodeEachExtendedJSON(t *testing.T) {
+	// Relaxed lines, then the canonical form of the same document
+	lines := `{"_id":{"$oid":"64b0"},"user_id":"u1","nickname":"Ann","create_time":{"$date":"2023-07-14T10:00:00.5Z"},"app_manger_level":1}
+{"_id":{"$oid":"64b1"},"user_id":"u2","create_time":{"$date":{"$numberLong":"1689328800500"}},"app_manger_level":{"$numberInt":"1"}}
+`
+	array := `[` + strings.ReplaceAll(strings.TrimSpace(lines), "\n", ",") + `]`
+	for name, input := range map[string]string{"lines": lines, "array": array} {
+		var users []*User
+		err := decodeEach(strings.NewReader(input), func(u *User) error {
+			users = append(users, u)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: decode failed: %v", name, err)
+		}
+		if len(users) != 2 || users[0].UserID != "u1" || users[1].UserID != "u2" {
+			t.Fatalf("%s: unexpected users: %+v", name, users)
+		}
+		for _, u := range users {
+			if u.CreateTime != 1689328800500 || u.AppMangerLevel != 1 {
+				t.Fatalf("%s: unexpected user: %+v", name, u)
+			}
+		}
+	}
+}
+
+func TestToMessage(t *testing.T) {
+	info := &MsgInfo{Msg: &Msg{
+		SendID: "bob", RecvID: "alice", ClientMsgID: "c1", SessionType: SessionTypeSingle,
+		ContentType: ContentTypeText, Content: `{"content":"hi"}`, Seq: 3, SendTime: 100,
+	}}
+	msg, ok := ToMessage(info, 1000)
+	if !ok || msg.ConversationId != "si_alice:bob" || msg.Seq != 3 || msg.MsgType != constant.MsgTypeText ||
+		msg.Content.Text == nil || msg.Content.Text.Text != "hi" || msg.DeletedAt != 0 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	// Group notifications keep their seq as tombstones
+	info = &MsgInfo{Msg: &Msg{SendID: "bob", GroupID: "g1", SessionType: SessionTypeReadGroup, ContentType: 1504, Content: `{}`, Seq: 4}}
+	msg, ok = ToMessage(info, 1000)
+	if !ok || msg.ConversationId != "sg_g1" || msg.DeletedAt != 1000 || msg.Content.PayloadCount() != 0 || msg.ClientMsgId != "sg_g1:4" {
+		t.Fatalf("unexpected notification: %+v", msg)
+	}
+
+	// Revoked messages too
+	info = &MsgInfo{Msg: &Msg{SendID: "bob", RecvID: "alice", SessionType: SessionTypeSingle, ContentType: ContentTypeText, Content: `{"content":"oops"}`, Seq: 5}, Revoke: []byte(`{"role":1}`)}
+	if msg, ok = ToMessage(info, 1000); !ok || msg.DeletedAt != 1000 || msg.Content.Text != nil {
+		t.Fatalf("unexpected revoked message: %+v", msg)
+	}
+
+	// Empty slots and notification conversations are not stored
+	if _, ok = ToMessage(&MsgInfo{}, 1000); ok {
+		t.Fatal("expected empty slot skipped")
+	}
+	if _, ok = ToMessage(&MsgInfo{Msg: &Msg{SessionType: SessionTypeNotification, Seq: 1}}, 1000); ok {
+		t.Fatal("expected notification conversation skipped")
+	}
+}
+
+func TestContentRoundTrip(t *testing.T) {
+	contents := []entity.MessageContent{
+		{Text: &entity.TextContent{Text: "hi"}},
+		{Image: &entity.ImageContent{Url: "https://cdn/a.png"}},
+		{Audio: &entity.AudioContent{Url: "https://cdn/a.mp3", Duration: 3000}},
+		{Video: &entity.VideoContent{Url: "https://cdn/a.mp4"}},
+		{File: &entity.FileContent{Url: "https://cdn/a.pdf", Name: "a.pdf"}},
+		{Custom: []byte(`{"card":1}`)},
+	}
+	for _, content := range contents {
+		contentType, raw := fromContent(content)
+		_, got, ok := toContent(int64(contentType), raw)
+		if !ok || !reflect.DeepEqual(got.ToFlat(), content.ToFlat()) {
+			t.Fatalf("round trip of %+v: got %+v, ok=%v", content.ToFlat(), got.ToFlat(), ok)
+		}
+	}
+}
+
+func TestGroupMemberRoles(t *testing.T) {
+	for level, want := range map[Long]int32{
+		RoleLevelOwner:    constant.RoleLevelOwner,
+		RoleLevelAdmin:    constant.RoleLevelAdmin,
+		RoleLevelOrdinary: constant.RoleLevelMember,
+	} {
+		member := ToGroupMember(&GroupMember{GroupID: "g1", UserID: "u1", RoleLevel: level, MuteEndTime: 500}, 1000)
+		if member.RoleLevel != want || member.MutedUntil != 0 {
+			t.Fatalf("role level %d: unexpected member %+v", level, member)
+		}
+		if back := FromGroupMember(member); back.RoleLevel != level {
+			t.Fatalf("role level %d exported as %d", level, back.RoleLevel)
+		}
+	}
+}
+
+func TestExtra(t *testing.T) {
+	if extra := toExtra("plain"); extra == nil || *extra != `"plain"` || fromExtra(extra) != "plain" {
+		t.Fatalf("unexpected extra of a plain string: %v", extra)
+	}
+	if extra := toExtra(`{"a":1}`); extra == nil || fromExtra(extra) != `{"a":1}` {
+		t.Fatalf("unexpected extra of a JSON object: %v", extra)
+	}
+	if toExtra("") != nil {
+		t.Fatal("expected no extra")
+	}
+}
@@ -0,0 +1,192 @@
+package openim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+)
+
+// exportPageSize is the number of rows Export loads per query
+const exportPageSize = 500
+
+// ExportReport counts the exported documents of each collection
+type ExportReport struct {
+	Users        int64 `json:"users"`
+	Groups       int64 `json:"groups"`
+	GroupMembers int64 `json:"group_members"`
+	Friends      int64 `json:"friends"`
+	Messages     int64 `json:"messages"`
+}
+
+// Exporter exports nexo_im in the shape of the collections of an OpenIM deployment
+type Exporter struct {
+	repos *repository.Repositories
+}
+
+// NewExporter creates a new Exporter
+func NewExporter(repos *repository.Repositories) *Exporter {
+	return &Exporter{repos: repos}
+}
+
+// Export writes the collection files to dir as mongoexport would, ready for mongoimport.
+// Each single chat conversation becomes a friendship of its owner, deleted users and the
+// members who left a group are not exported, and deleted messages keep their seq with
+// the deleted status.
+func (ex *Exporter) Export(ctx context.Context, dir string) (*ExportReport, error) {
+	report := &ExportReport{}
+	steps := []struct {
+		name  string
+		count *int64
+		run   func(ctx context.Context, w *fileWriter, count *int64) error
+	}{
+		{FileUser, &report.Users, ex.exportUsers},
+		{FileGroup, &report.Groups, ex.exportGroups},
+		{FileGroupMember, &report.GroupMembers, ex.exportGroupMembers},
+		{FileFriend, &report.Friends, ex.exportFriends},
+		{FileMsg, &report.Messages, ex.exportMessages},
+	}
+	for _, step := range steps {
+		w, err := createFile(dir, step.name)
+		if err != nil {
+			return report, err
+		}
+		err = step.run(ctx, w, step.count)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return report, nil
+}
+
+func (ex *Exporter) exportUsers(ctx context.Context, w *fileWriter, count *int64) error {
+	afterId := ""
+	for {
+		users, err := ex.repos.User.ListAfter(ctx, afterId, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err = w.write(FromUser(user)); err != nil {
+				return err
+			}
+			*count++
+		}
+		if len(users) < exportPageSize {
+			return nil
+		}
+		afterId = users[len(users)-1].Id
+	}
+}
+
+func (ex *Exporter) exportGroups(ctx context.Context, w *fileWriter, count *int64) error {
+	afterId := ""
+	for {
+		groups, err := ex.repos.Group.ListAfter(ctx, afterId, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			if err = w.write(FromGroup(group)); err != nil {
+				return err
+			}
+			*count++
+		}
+		if len(groups) < exportPageSize {
+			return nil
+		}
+		afterId = groups[len(groups)-1].Id
+	}
+}
+
+func (ex *Exporter) exportGroupMembers(ctx context.Context, w *fileWriter, count *int64) error {
+	var afterId int64
+	for {
+		members, err := ex.repos.Group.ListActiveMembersAfter(ctx, afterId, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if err = w.write(FromGroupMember(member)); err != nil {
+				return err
+			}
+			*count++
+		}
+		if len(members) < exportPageSize {
+			return nil
+		}
+		afterId = members[len(members)-1].Id
+	}
+}
+
+func (ex *Exporter) exportFriends(ctx context.Context, w *fileWriter, count *int64) error {
+	var afterId int64
+	for {
+		convs, err := ex.repos.Conversation.ListSingleChatAfter(ctx, afterId, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, conv := range convs {
+			if conv.PeerUserId == "" || conv.PeerUserId == conv.OwnerId {
+				continue
+			}
+			friend := &Friend{OwnerUserID: conv.OwnerId, FriendUserID: conv.PeerUserId, CreateTime: Date(conv.CreatedAt)}
+			if err = w.write(friend); err != nil {
+				return err
+			}
+			*count++
+		}
+		if len(convs) < exportPageSize {
+			return nil
+		}
+		afterId = convs[len(convs)-1].Id
+	}
+}
+
+// exportMessages writes the messages in msg documents of 100 seq slots per conversation
+func (ex *Exporter) exportMessages(ctx context.Context, w *fileWriter, count *int64) error {
+	var doc *MsgDoc
+	var docStart int64
+	flush := func() error {
+		if doc == nil {
+			return nil
+		}
+		return w.write(doc)
+	}
+
+	afterConversationId, afterSeq := "", int64(0)
+	for {
+		messages, err := ex.repos.Message.ListAfter(ctx, afterConversationId, afterSeq, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			m, ok := FromMessage(msg)
+			if !ok {
+				continue
+			}
+			index := (msg.Seq - 1) / msgsPerDoc
+			docId := fmt.Sprintf("%s:%d", ConversationId(m), index)
+			if doc == nil || doc.DocID != docId {
+				if err = flush(); err != nil {
+					return err
+				}
+				doc = &MsgDoc{DocID: docId, Msgs: make([]*MsgInfo, msgsPerDoc)}
+				for i := range doc.Msgs {
+					doc.Msgs[i] = &MsgInfo{}
+				}
+				docStart = index*msgsPerDoc + 1
+			}
+			doc.Msgs[msg.Seq-docStart].Msg = m
+			*count++
+		}
+		if len(messages) < exportPageSize {
+			return flush()
+		}
+		last := messages[len(messages)-1]
+		afterConversationId, afterSeq = last.ConversationId, last.Seq
+	}
+}
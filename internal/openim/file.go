@@ -0,0 +1,106 @@
+package openim
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// decodeEach decodes the documents of r, one per line or as a single JSON array, calling fn for each
+func decodeEach[T any](r io.Reader, fn func(doc *T) error) error {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	array, err := startsArray(br)
+	if err != nil {
+		return err
+	}
+	if array {
+		if _, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+	for n := 1; ; n++ {
+		if array && !dec.More() {
+			return nil
+		}
+		doc := new(T)
+		if err = dec.Decode(doc); err != nil {
+			if errors.Is(err, io.EOF) && !array {
+				return nil
+			}
+			return fmt.Errorf("document %d: %w", n, err)
+		}
+		if err = fn(doc); err != nil {
+			return err
+		}
+	}
+}
+
+// startsArray peeks the first non-space byte of r
+func startsArray(r *bufio.Reader) (bool, error) {
+	for {
+		b, err := r.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.ReadByte()
+		default:
+			return b[0] == '[', nil
+		}
+	}
+}
+
+// readFile decodes the documents of the collection file name in dir, a missing file has no documents
+func readFile[T any](dir, name string, fn func(doc *T) error) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = decodeEach(f, fn); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// fileWriter writes documents as JSON lines, the mongoexport default
+type fileWriter struct {
+	f   *os.File
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func createFile(dir, name string) (*fileWriter, error) {
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(f)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &fileWriter{f: f, buf: buf, enc: enc}, nil
+}
+
+func (w *fileWriter) write(doc any) error {
+	return w.enc.Encode(doc)
+}
+
+func (w *fileWriter) Close() error {
+	err := w.buf.Flush()
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
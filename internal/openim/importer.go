@@ -0,0 +1,281 @@
+package openim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mbeoliero/kit/log"
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// ImportStats counts the documents of a collection
+type ImportStats struct {
+	Imported int64 `json:"imported"`
+	Skipped  int64 `json:"skipped"`
+}
+
+// ImportReport is the result of an import
+type ImportReport struct {
+	Users        ImportStats `json:"users"`
+	Groups       ImportStats `json:"groups"`
+	GroupMembers ImportStats `json:"group_members"`
+	Friends      ImportStats `json:"friends"`
+	Messages     ImportStats `json:"messages"`
+}
+
+// Importer imports the collections of an OpenIM deployment
+type Importer struct {
+	repos *repository.Repositories
+	now   int64
+
+	groups map[string]bool                // imported or existing groups
+	convs  map[string]*importConversation // conversations messages were seen for
+}
+
+// importConversation tracks the seqs of a conversation during the import
+type importConversation struct {
+	baseSeq int64 // max seq before the import, lower messages are already stored
+	maxSeq  int64
+	userIds []string // single chat parties
+}
+
+// NewImporter creates a new Importer
+func NewImporter(repos *repository.Repositories) *Importer {
+	return &Importer{repos: repos}
+}
+
+// Import imports the collection files of dir: users, groups, group members, friendships and
+// message history. nexo_im has no friend relation, a friendship becomes the single chat
+// conversation of the two users. Messages keep their OpenIM seq and the imported history
+// is marked read.
+//
+// Import is meant to run before the users move to nexo_im and can be run again after a
+// failure: existing users and groups are kept, and the messages up to the max seq a
+// conversation had when the import started are skipped, msg documents being exported
+// in seq order.
+func (im *Importer) Import(ctx context.Context, dir string) (*ImportReport, error) {
+	im.now = entity.NowUnixMilli()
+	im.groups = make(map[string]bool)
+	im.convs = make(map[string]*importConversation)
+	report := &ImportReport{}
+
+	if err := readFile(dir, FileUser, func(u *User) error {
+		return im.importUser(ctx, u, &report.Users)
+	}); err != nil {
+		return report, err
+	}
+	if err := readFile(dir, FileGroup, func(g *Group) error {
+		return im.importGroup(ctx, g, &report.Groups)
+	}); err != nil {
+		return report, err
+	}
+	if err := readFile(dir, FileGroupMember, func(m *GroupMember) error {
+		return im.importGroupMember(ctx, m, &report.GroupMembers)
+	}); err != nil {
+		return report, err
+	}
+	if err := readFile(dir, FileFriend, func(f *Friend) error {
+		return im.importFriend(ctx, f, &report.Friends)
+	}); err != nil {
+		return report, err
+	}
+	if err := readFile(dir, FileMsg, func(doc *MsgDoc) error {
+		return im.importMsgDoc(ctx, doc, &report.Messages)
+	}); err != nil {
+		return report, err
+	}
+	return report, im.finishConversations(ctx)
+}
+
+func (im *Importer) importUser(ctx context.Context, u *User, stats *ImportStats) error {
+	if u.UserID == "" {
+		stats.Skipped++
+		return nil
+	}
+	exists, err := im.repos.User.Exists(ctx, u.UserID)
+	if err != nil {
+		return fmt.Errorf("check user %s: %w", u.UserID, err)
+	}
+	if exists {
+		stats.Skipped++
+		return nil
+	}
+	if err = im.repos.User.Create(ctx, ToUser(u)); err != nil {
+		return fmt.Errorf("create user %s: %w", u.UserID, err)
+	}
+	stats.Imported++
+	return nil
+}
+
+func (im *Importer) importGroup(ctx context.Context, g *Group, stats *ImportStats) error {
+	if g.GroupID == "" {
+		stats.Skipped++
+		return nil
+	}
+	_, err := im.repos.Group.GetById(ctx, g.GroupID)
+	if err == nil {
+		im.groups[g.GroupID] = true
+		stats.Skipped++
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("get group %s: %w", g.GroupID, err)
+	}
+
+	err = im.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(ToGroup(g)).Error; err != nil {
+			return err
+		}
+		return im.repos.Seq.EnsureSeqConversationExists(ctx, tx, entity.GenGroupConversationId(g.GroupID))
+	})
+	if err != nil {
+		return fmt.Errorf("create group %s: %w", g.GroupID, err)
+	}
+	im.groups[g.GroupID] = true
+	stats.Imported++
+	return nil
+}
+
+func (im *Importer) importGroupMember(ctx context.Context, m *GroupMember, stats *ImportStats) error {
+	if m.UserID == "" || !im.groups[m.GroupID] {
+		// Members of groups missing from the group collection
+		stats.Skipped++
+		return nil
+	}
+
+	conversationId := entity.GenGroupConversationId(m.GroupID)
+	err := im.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := im.repos.Group.AddMember(ctx, tx, ToGroupMember(m, im.now)); err != nil {
+			return err
+		}
+		if err := im.repos.Seq.SetSeqUserMinSeq(ctx, tx, m.UserID, conversationId, 1); err != nil {
+			return err
+		}
+		return im.repos.Conversation.EnsureConversationsExist(ctx, tx, conversationId, constant.SessionTypeGroup, []string{m.UserID}, m.GroupID, "")
+	})
+	if err != nil {
+		return fmt.Errorf("add member %s to group %s: %w", m.UserID, m.GroupID, err)
+	}
+	stats.Imported++
+	return nil
+}
+
+func (im *Importer) importFriend(ctx context.Context, f *Friend, stats *ImportStats) error {
+	if f.OwnerUserID == "" || f.FriendUserID == "" || f.OwnerUserID == f.FriendUserID {
+		stats.Skipped++
+		return nil
+	}
+	conversationId := entity.GenSingleConversationId(f.OwnerUserID, f.FriendUserID)
+	if err := im.repos.Conversation.EnsureSingleChatConversations(ctx, im.repos.DB, conversationId, f.OwnerUserID, f.FriendUserID); err != nil {
+		return fmt.Errorf("create conversation %s: %w", conversationId, err)
+	}
+	stats.Imported++
+	return nil
+}
+
+// importMsgDoc stores the messages of doc and moves the seq of their conversation with them,
+// so an import run again after a failure skips them
+func (im *Importer) importMsgDoc(ctx context.Context, doc *MsgDoc, stats *ImportStats) error {
+	var messages []*entity.Message
+	touched := make(map[string]*importConversation)
+	for _, info := range doc.Msgs {
+		if info == nil {
+			continue
+		}
+		msg, ok := ToMessage(info, im.now)
+		if !ok {
+			if info.Msg != nil {
+				stats.Skipped++
+			}
+			continue
+		}
+		conv, err := im.conversation(ctx, msg)
+		if err != nil {
+			return err
+		}
+		if msg.Seq <= conv.baseSeq {
+			stats.Skipped++
+			continue
+		}
+		conv.maxSeq = max(conv.maxSeq, msg.Seq)
+		touched[msg.ConversationId] = conv
+		messages = append(messages, msg)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	err := im.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		for _, msg := range messages {
+			if err := im.repos.Message.Create(ctx, tx, msg); err != nil {
+				return err
+			}
+		}
+		for conversationId, conv := range touched {
+			if err := im.repos.Seq.SyncSeqToMySQLWithTx(ctx, tx, conversationId, conv.maxSeq); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("import messages of %s: %w", doc.DocID, err)
+	}
+	for conversationId := range touched {
+		if err = im.repos.Seq.InitSeqFromMySQL(ctx, conversationId); err != nil {
+			return fmt.Errorf("init seq of %s: %w", conversationId, err)
+		}
+	}
+	stats.Imported += int64(len(messages))
+	return nil
+}
+
+// conversation returns the import state of the conversation of msg, creating the conversations
+// of the single chat parties the first time
+func (im *Importer) conversation(ctx context.Context, msg *entity.Message) (*importConversation, error) {
+	if conv := im.convs[msg.ConversationId]; conv != nil {
+		return conv, nil
+	}
+	baseSeq, err := im.repos.Seq.GetMaxSeq(ctx, msg.ConversationId)
+	if err != nil {
+		return nil, fmt.Errorf("get max seq of %s: %w", msg.ConversationId, err)
+	}
+	conv := &importConversation{baseSeq: baseSeq, maxSeq: baseSeq}
+	if msg.SessionType == constant.SessionTypeSingle {
+		conv.userIds = []string{msg.SenderId, msg.RecvId}
+		if err = im.repos.Conversation.EnsureSingleChatConversations(ctx, im.repos.DB, msg.ConversationId, msg.SenderId, msg.RecvId); err != nil {
+			return nil, fmt.Errorf("create conversation %s: %w", msg.ConversationId, err)
+		}
+	}
+	im.convs[msg.ConversationId] = conv
+	return conv, nil
+}
+
+// finishConversations marks the imported history of the conversations read
+func (im *Importer) finishConversations(ctx context.Context) error {
+	for conversationId, conv := range im.convs {
+		if conv.maxSeq == conv.baseSeq {
+			continue
+		}
+		userIds := conv.userIds
+		if userIds == nil {
+			groupId := conversationId[len(constant.GroupConversationPrefix):]
+			var err error
+			if userIds, err = im.repos.Group.GetActiveMemberUserIds(ctx, groupId); err != nil {
+				return fmt.Errorf("get members of group %s: %w", groupId, err)
+			}
+		}
+		for _, userId := range userIds {
+			if err := im.repos.Seq.UpdateReadSeq(ctx, userId, conversationId, conv.maxSeq); err != nil {
+				return fmt.Errorf("mark %s read for %s: %w", conversationId, userId, err)
+			}
+		}
+		log.CtxInfo(ctx, "openim conversation imported: conversation_id=%s, max_seq=%d", conversationId, conv.maxSeq)
+	}
+	return nil
+}
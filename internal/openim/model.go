@@ -0,0 +1,241 @@
+// Package openim converts between the MongoDB documents of an OpenIM deployment and nexo_im
+// entities, to import an OpenIM deployment into nexo_im and export nexo_im in the same shape.
+//
+// The documents are read and written as mongoexport files, one per collection
+// (user.json, group.json, group_member.json, friend.json, msg.json), in relaxed or
+// canonical Extended JSON, one document per line or as a single array.
+package openim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Collection file names, as written by mongoexport --collection=<name> --out=<name>.json
+const (
+	FileUser        = "user.json"
+	FileGroup       = "group.json"
+	FileGroupMember = "group_member.json"
+	FileFriend      = "friend.json"
+	FileMsg         = "msg.json"
+)
+
+// OpenIM session types
+const (
+	SessionTypeSingle       = 1
+	SessionTypeGroup        = 2
+	SessionTypeReadGroup    = 3 // Super group, the only group type of OpenIM v3
+	SessionTypeNotification = 4
+)
+
+// OpenIM content types nexo_im stores
+const (
+	ContentTypeText    = 101
+	ContentTypePicture = 102
+	ContentTypeVoice   = 103
+	ContentTypeVideo   = 104
+	ContentTypeFile    = 105
+	ContentTypeAtText  = 106
+	ContentTypeCustom  = 110
+	ContentTypeQuote   = 114
+
+	// contentTypeNotificationBegin starts the notification content types, which are not imported
+	contentTypeNotificationBegin = 1000
+)
+
+// OpenIM group member role levels
+const (
+	RoleLevelOwner    = 100
+	RoleLevelAdmin    = 60
+	RoleLevelOrdinary = 20
+)
+
+// OpenIM group status
+const (
+	GroupStatusOk        = 0
+	GroupStatusDismissed = 2
+	GroupStatusMuted     = 3
+)
+
+// OpenIM message status
+const (
+	MsgStatusSucceed = 2
+	MsgStatusDeleted = 4
+)
+
+// msgsPerDoc is the number of message slots of a msg document
+const msgsPerDoc = 100
+
+// Long is an integer that also decodes from the Extended JSON {"$numberLong"} and {"$numberInt"} wrappers
+type Long int64
+
+func (l *Long) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*l = 0
+		return nil
+	}
+	if len(data) > 0 && data[0] == '{' {
+		var wrapper struct {
+			NumberLong *string `json:"$numberLong"`
+			NumberInt  *string `json:"$numberInt"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return err
+		}
+		switch {
+		case wrapper.NumberLong != nil:
+			data = []byte(*wrapper.NumberLong)
+		case wrapper.NumberInt != nil:
+			data = []byte(*wrapper.NumberInt)
+		default:
+			return fmt.Errorf("openim: unsupported number %s", data)
+		}
+	}
+	n, err := strconv.ParseInt(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("openim: invalid number %s", data)
+	}
+	*l = Long(n)
+	return nil
+}
+
+// Date is a BSON date in Unix milliseconds, 0 for the zero time.
+// It decodes from {"$date"} and plain milliseconds, and encodes as relaxed Extended JSON.
+type Date int64
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '{' {
+		return (*Long)(d).UnmarshalJSON(data)
+	}
+	var wrapper struct {
+		Date json.RawMessage `json:"$date"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return err
+	}
+	if len(wrapper.Date) > 0 && wrapper.Date[0] == '"' {
+		var s string
+		if err := json.Unmarshal(wrapper.Date, &s); err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("openim: invalid date %s", s)
+		}
+		*d = Date(t.UnixMilli())
+		return nil
+	}
+	return (*Long)(d).UnmarshalJSON(wrapper.Date)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	s := time.UnixMilli(int64(d)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	return json.Marshal(map[string]string{"$date": s})
+}
+
+// millis returns d in Unix milliseconds, 0 for dates before the epoch
+func (d Date) millis() int64 {
+	return max(int64(d), 0)
+}
+
+// User is a document of the user collection
+type User struct {
+	UserID           string `json:"user_id"`
+	Nickname         string `json:"nickname"`
+	FaceURL          string `json:"face_url"`
+	Ex               string `json:"ex"`
+	AppMangerLevel   Long   `json:"app_manger_level"`
+	GlobalRecvMsgOpt Long   `json:"global_recv_msg_opt"`
+	CreateTime       Date   `json:"create_time"`
+}
+
+// Group is a document of the group collection
+type Group struct {
+	GroupID                string `json:"group_id"`
+	GroupName              string `json:"group_name"`
+	Notification           string `json:"notification"`
+	Introduction           string `json:"introduction"`
+	FaceURL                string `json:"face_url"`
+	CreateTime             Date   `json:"create_time"`
+	Ex                     string `json:"ex"`
+	Status                 Long   `json:"status"`
+	CreatorUserID          string `json:"creator_user_id"`
+	GroupType              Long   `json:"group_type"`
+	NeedVerification       Long   `json:"need_verification"`
+	LookMemberInfo         Long   `json:"look_member_info"`
+	ApplyMemberFriend      Long   `json:"apply_member_friend"`
+	NotificationUpdateTime Date   `json:"notification_update_time"`
+	NotificationUserID     string `json:"notification_user_id"`
+}
+
+// GroupMember is a document of the group_member collection
+type GroupMember struct {
+	GroupID        string `json:"group_id"`
+	UserID         string `json:"user_id"`
+	Nickname       string `json:"nickname"`
+	FaceURL        string `json:"face_url"`
+	RoleLevel      Long   `json:"role_level"`
+	JoinTime       Date   `json:"join_time"`
+	JoinSource     Long   `json:"join_source"`
+	InviterUserID  string `json:"inviter_user_id"`
+	OperatorUserID string `json:"operator_user_id"`
+	MuteEndTime    Date   `json:"mute_end_time"`
+	Ex             string `json:"ex"`
+}
+
+// Friend is a document of the friend collection, one per direction of a friendship
+type Friend struct {
+	OwnerUserID    string `json:"owner_user_id"`
+	FriendUserID   string `json:"friend_user_id"`
+	Remark         string `json:"remark"`
+	CreateTime     Date   `json:"create_time"`
+	AddSource      Long   `json:"add_source"`
+	OperatorUserID string `json:"operator_user_id"`
+	Ex             string `json:"ex"`
+}
+
+// MsgDoc is a document of the msg collection, holding the messages of one conversation
+// with seq in [index*100+1, index*100+100], DocID being "<conversation_id>:<index>"
+type MsgDoc struct {
+	DocID string     `json:"doc_id"`
+	Msgs  []*MsgInfo `json:"msgs"`
+}
+
+// MsgInfo is a message slot of a MsgDoc, Msg is nil for an empty slot
+type MsgInfo struct {
+	Msg     *Msg            `json:"msg"`
+	Revoke  json.RawMessage `json:"revoke"`
+	DelList []string        `json:"del_list"`
+	IsRead  bool            `json:"is_read"`
+}
+
+// Msg is a message, Content being the JSON of the content type's element as a string
+type Msg struct {
+	SendID           string   `json:"send_id"`
+	RecvID           string   `json:"recv_id"`
+	GroupID          string   `json:"group_id"`
+	ClientMsgID      string   `json:"client_msg_id"`
+	ServerMsgID      string   `json:"server_msg_id"`
+	SenderPlatformID Long     `json:"sender_platform_id"`
+	SenderNickname   string   `json:"sender_nickname"`
+	SenderFaceURL    string   `json:"sender_face_url"`
+	SessionType      Long     `json:"session_type"`
+	MsgFrom          Long     `json:"msg_from"`
+	ContentType      Long     `json:"content_type"`
+	Content          string   `json:"content"`
+	Seq              Long     `json:"seq"`
+	SendTime         Long     `json:"send_time"`
+	CreateTime       Long     `json:"create_time"`
+	Status           Long     `json:"status"`
+	AtUserIDList     []string `json:"at_user_id_list"`
+	AttachedInfo     string   `json:"attached_info"`
+	Ex               string   `json:"ex"`
+}
+
+// isRevoked checks if the message was revoked, revoke being null otherwise
+func (i *MsgInfo) isRevoked() bool {
+	return len(i.Revoke) > 0 && !bytes.Equal(i.Revoke, []byte("null"))
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// AdminAuditRepo is the repository for admin audit logs
+type AdminAuditRepo struct {
+	db *gorm.DB
+}
+
+// NewAdminAuditRepo creates a new AdminAuditRepo
+func NewAdminAuditRepo(db *gorm.DB) *AdminAuditRepo {
+	return &AdminAuditRepo{db: db}
+}
+
+// Create creates a new audit log
+func (r *AdminAuditRepo) Create(ctx context.Context, auditLog *entity.AdminAuditLog) error {
+	return r.db.WithContext(ctx).Create(auditLog).Error
+}
+
+// List lists audit logs newest first, optionally filtered by operator
+func (r *AdminAuditRepo) List(ctx context.Context, operator string, offset, limit int) ([]*entity.AdminAuditLog, error) {
+	query := r.db.WithContext(ctx).Model(&entity.AdminAuditLog{})
+	if operator != "" {
+		query = query.Where("operator = ?", operator)
+	}
+
+	var logs []*entity.AdminAuditLog
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
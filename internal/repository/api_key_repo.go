@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// APIKeyRepo is the repository for bot API keys
+type APIKeyRepo struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepo creates a new APIKeyRepo
+func NewAPIKeyRepo(db *gorm.DB) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+// Create creates a new API key
+func (r *APIKeyRepo) Create(ctx context.Context, key *entity.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetById gets an API key by Id
+func (r *APIKeyRepo) GetById(ctx context.Context, id int64) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByPrefix gets an API key by its public prefix
+func (r *APIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser lists the API keys of a user newest first, including revoked ones
+func (r *APIKeyRepo) ListByUser(ctx context.Context, userId string) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("id DESC").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateRotation creates the replacement key and moves the expiry of the old key in one transaction
+func (r *APIKeyRepo) CreateRotation(ctx context.Context, oldId, oldExpiresAt int64, key *entity.APIKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(key).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.APIKey{}).Where("id = ?", oldId).Update("expires_at", oldExpiresAt).Error
+	})
+}
+
+// Revoke revokes an active API key; reports false if it was already revoked
+func (r *APIKeyRepo) Revoke(ctx context.Context, id, revokedAt int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.APIKey{}).
+		Where("id = ? AND revoked_at = 0", id).
+		Update("revoked_at", revokedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchLastUsed records the last use of an API key
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id, usedAt int64) error {
+	return r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// DeleteByUser deletes all API keys of a user
+func (r *APIKeyRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.APIKey{}).Error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// AuditEventRepo is the repository for security audit events
+type AuditEventRepo struct {
+	db *gorm.DB
+}
+
+// NewAuditEventRepo creates a new AuditEventRepo
+func NewAuditEventRepo(db *gorm.DB) *AuditEventRepo {
+	return &AuditEventRepo{db: db}
+}
+
+// AuditEventFilter filters audit events; zero values are ignored
+type AuditEventFilter struct {
+	Category  string
+	Actor     string
+	Result    string
+	StartTime int64 // created_at >= StartTime (ms)
+	EndTime   int64 // created_at < EndTime (ms)
+}
+
+// BatchCreate inserts audit events in one statement
+func (r *AuditEventRepo) BatchCreate(ctx context.Context, events []*entity.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&events).Error
+}
+
+// List lists audit events matching filter, newest first
+func (r *AuditEventRepo) List(ctx context.Context, filter *AuditEventFilter, offset, limit int) ([]*entity.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Model(&entity.AuditEvent{})
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	if filter.StartTime > 0 {
+		query = query.Where("created_at >= ?", filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		query = query.Where("created_at < ?", filter.EndTime)
+	}
+
+	var events []*entity.AuditEvent
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -15,6 +15,8 @@ import (
 	"gorm.io/gorm/logger"
 
 	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
 )
 
 // Repositories holds all repositories
@@ -22,10 +24,32 @@ type Repositories struct {
 	DB           *gorm.DB
 	Redis        redis.UniversalClient
 	User         *UserRepo
+	UserIdentity *UserIdentityRepo
+	APIKey       *APIKeyRepo
 	Group        *GroupRepo
 	Message      *MessageRepo
 	Conversation *ConversationRepo
 	Seq          *SeqRepo
+	UserDeletion *UserDeletionRepo
+	AdminAudit   *AdminAuditRepo
+	AuditEvent   *AuditEventRepo
+	Webhook      *WebhookDeliveryRepo
+	Stats        *StatsRepo
+	Broadcast    *BroadcastRepo
+	VoIPToken    *VoIPTokenRepo
+	E2EEKey      *E2EEKeyRepo
+	Upload       *UploadRepo
+	LinkPreview  *LinkPreviewRepo
+	Notification *NotificationSettingsRepo
+	Mention      *MentionRepo
+	Reminder     *ReminderRepo
+	Favorite     *FavoriteRepo
+	PollVote     *PollVoteRepo
+	MessageEdit  *MessageEditRepo
+	Reaction     *ReactionRepo
+	Pin          *PinRepo
+	Hidden       *HiddenMessageRepo
+	UserTag      *UserTagRepo
 }
 
 // NewRepositories creates all repositories
@@ -46,10 +70,37 @@ func NewRepositories(cfg *config.Config) (*Repositories, error) {
 
 	// Initialize individual repositories
 	repos.User = NewUserRepo(db, rdb)
+	repos.UserIdentity = NewUserIdentityRepo(db)
+	repos.APIKey = NewAPIKeyRepo(db)
 	repos.Group = NewGroupRepo(db, rdb)
 	repos.Message = NewMessageRepo(db, rdb)
+	if cfg.Message.Compression.Enabled {
+		if err = repos.Message.EnableCompression(cfg.Message.Compression.Codec, cfg.Message.Compression.ThresholdBytes); err != nil {
+			return nil, err
+		}
+	}
 	repos.Conversation = NewConversationRepo(db, rdb)
 	repos.Seq = NewSeqRepo(db, rdb)
+	repos.UserDeletion = NewUserDeletionRepo(db)
+	repos.AdminAudit = NewAdminAuditRepo(db)
+	repos.AuditEvent = NewAuditEventRepo(db)
+	repos.Webhook = NewWebhookDeliveryRepo(db)
+	repos.Stats = NewStatsRepo(rdb)
+	repos.Broadcast = NewBroadcastRepo(db)
+	repos.VoIPToken = NewVoIPTokenRepo(db)
+	repos.E2EEKey = NewE2EEKeyRepo(db)
+	repos.Upload = NewUploadRepo(db)
+	repos.LinkPreview = NewLinkPreviewRepo(rdb)
+	repos.Notification = NewNotificationSettingsRepo(db, rdb)
+	repos.Mention = NewMentionRepo(db)
+	repos.Reminder = NewReminderRepo(db)
+	repos.Favorite = NewFavoriteRepo(db)
+	repos.PollVote = NewPollVoteRepo(db)
+	repos.MessageEdit = NewMessageEditRepo(db)
+	repos.Reaction = NewReactionRepo(db)
+	repos.Pin = NewPinRepo(db)
+	repos.Hidden = NewHiddenMessageRepo(db)
+	repos.UserTag = NewUserTagRepo(db)
 
 	return repos, nil
 }
@@ -80,6 +131,13 @@ func initMySQL(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err = db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err = db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
@@ -101,24 +159,28 @@ func initRedis(cfg *config.Config) redis.UniversalClient {
 		}
 	}
 
+	var rdb redis.UniversalClient
 	if cfg.Redis.Cluster {
 		addrs := cfg.Redis.Addrs
 		if len(addrs) == 0 {
 			addrs = []string{cfg.Redis.Addr()}
 		}
-		return redis.NewClusterClient(&redis.ClusterOptions{
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
 			Addrs:     addrs,
 			Password:  cfg.Redis.Password,
 			TLSConfig: tlsConfig,
 		})
+	} else {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:      cfg.Redis.Addr(),
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			TLSConfig: tlsConfig,
+		})
 	}
 
-	return redis.NewClient(&redis.Options{
-		Addr:      cfg.Redis.Addr(),
-		Password:  cfg.Redis.Password,
-		DB:        cfg.Redis.DB,
-		TLSConfig: tlsConfig,
-	})
+	rdb.AddHook(tracing.RedisHook{})
+	return rdb
 }
 
 // Close closes all connections
@@ -145,21 +207,27 @@ func (r *Repositories) TransactionWithOptions(ctx context.Context, opts *sql.TxO
 
 // CheckConnection checks if database and redis connections are alive
 func (r *Repositories) CheckConnection(ctx context.Context) error {
-	// Check MySQL
-	sqlDB, err := r.DB.DB()
-	if err != nil {
+	if err := r.PingMySQL(ctx); err != nil {
+		log.CtxError(ctx, "mysql ping failed: %v", err)
 		return err
 	}
-	if err = sqlDB.PingContext(ctx); err != nil {
-		log.CtxError(ctx, "mysql ping failed: %v", err)
+	if err := r.PingRedis(ctx); err != nil {
+		log.CtxError(ctx, "redis ping failed: %v", err)
 		return err
 	}
+	return nil
+}
 
-	// Check Redis
-	if err = r.Redis.Ping(ctx).Err(); err != nil {
-		log.CtxError(ctx, "redis ping failed: %v", err)
+// PingMySQL checks if the database connection is alive
+func (r *Repositories) PingMySQL(ctx context.Context) error {
+	sqlDB, err := r.DB.DB()
+	if err != nil {
 		return err
 	}
+	return sqlDB.PingContext(ctx)
+}
 
-	return nil
+// PingRedis checks if the redis connection is alive
+func (r *Repositories) PingRedis(ctx context.Context) error {
+	return r.Redis.Ping(ctx).Err()
 }
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// BroadcastRepo is the repository for admin broadcasts
+type BroadcastRepo struct {
+	db *gorm.DB
+}
+
+// NewBroadcastRepo creates a new BroadcastRepo
+func NewBroadcastRepo(db *gorm.DB) *BroadcastRepo {
+	return &BroadcastRepo{db: db}
+}
+
+// Create creates a new broadcast
+func (r *BroadcastRepo) Create(ctx context.Context, broadcast *entity.Broadcast) error {
+	return r.db.WithContext(ctx).Create(broadcast).Error
+}
+
+// GetById gets a broadcast by Id
+func (r *BroadcastRepo) GetById(ctx context.Context, id int64) (*entity.Broadcast, error) {
+	var broadcast entity.Broadcast
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&broadcast).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+// List lists broadcasts newest first
+func (r *BroadcastRepo) List(ctx context.Context, offset, limit int) ([]*entity.Broadcast, error) {
+	var broadcasts []*entity.Broadcast
+	err := r.db.WithContext(ctx).Order("id DESC").Offset(offset).Limit(limit).Find(&broadcasts).Error
+	if err != nil {
+		return nil, err
+	}
+	return broadcasts, nil
+}
+
+// ListDue lists broadcasts ready to be delivered: scheduled ones whose time has come,
+// and running ones without progress since staleBefore (ms), whose worker died
+func (r *BroadcastRepo) ListDue(ctx context.Context, now, staleBefore int64, limit int) ([]*entity.Broadcast, error) {
+	var broadcasts []*entity.Broadcast
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND scheduled_at <= ?) OR (status = ? AND updated_at < ?)",
+			constant.BroadcastStatusScheduled, now, constant.BroadcastStatusRunning, staleBefore).
+		Order("scheduled_at ASC").
+		Limit(limit).
+		Find(&broadcasts).Error
+	if err != nil {
+		return nil, err
+	}
+	return broadcasts, nil
+}
+
+// Claim marks a due broadcast as running. It compares status and updated_at with the values
+// read by ListDue, so only one server instance wins the claim. Returns false if it lost.
+func (r *BroadcastRepo) Claim(ctx context.Context, broadcast *entity.Broadcast, totalCount int64) (bool, error) {
+	updates := map[string]interface{}{
+		"status": constant.BroadcastStatusRunning,
+	}
+	if broadcast.Status == constant.BroadcastStatusScheduled {
+		updates["total_count"] = totalCount
+		updates["started_at"] = entity.NowUnixMilli()
+	}
+
+	result := r.db.WithContext(ctx).Model(&entity.Broadcast{}).
+		Where("id = ? AND status = ? AND updated_at = ?", broadcast.Id, broadcast.Status, broadcast.UpdatedAt).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Finish sets the final status of a running broadcast; a cancelled broadcast is left as is
+func (r *BroadcastRepo) Finish(ctx context.Context, id int64, status int32, errorMsg string) error {
+	return r.db.WithContext(ctx).Model(&entity.Broadcast{}).
+		Where("id = ? AND status = ?", id, constant.BroadcastStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"error_msg":   errorMsg,
+			"finished_at": entity.NowUnixMilli(),
+		}).Error
+}
+
+// SaveProgress records a delivered batch; the running status is kept so a cancel is not overwritten
+func (r *BroadcastRepo) SaveProgress(ctx context.Context, id int64, lastUserId string, sent, failed int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Broadcast{}).
+		Where("id = ? AND status = ?", id, constant.BroadcastStatusRunning).
+		Updates(map[string]interface{}{
+			"last_user_id": lastUserId,
+			"sent_count":   gorm.Expr("sent_count + ?", sent),
+			"failed_count": gorm.Expr("failed_count + ?", failed),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Cancel cancels a broadcast that has not finished. Returns false if it already finished.
+func (r *BroadcastRepo) Cancel(ctx context.Context, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Broadcast{}).
+		Where("id = ? AND status IN ?", id, []int32{constant.BroadcastStatusScheduled, constant.BroadcastStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      constant.BroadcastStatusCancelled,
+			"finished_at": entity.NowUnixMilli(),
+		})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// maxCallUpdateAttempts bounds the retries of an update losing the race to a concurrent one
+const maxCallUpdateAttempts = 5
+
+// ErrCallConflict is returned when an update keeps losing to concurrent updates of the call
+var ErrCallConflict = errors.New("call updated concurrently")
+
+// CallRepo is the repository for the signaling state of calls
+type CallRepo struct {
+	rdb redis.UniversalClient
+}
+
+// NewCallRepo creates a new CallRepo
+func NewCallRepo(rdb redis.UniversalClient) *CallRepo {
+	return &CallRepo{rdb: rdb}
+}
+
+// Create stores a new ringing call and schedules its ring timeout
+func (r *CallRepo) Create(ctx context.Context, call *entity.Call, ttl time.Duration) error {
+	data, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+	if err = r.rdb.Set(ctx, fmt.Sprintf(constant.RedisKeyCall(), call.Id), data, ttl).Err(); err != nil {
+		return err
+	}
+	return r.rdb.ZAdd(ctx, constant.RedisKeyCallRinging(), redis.Z{Score: float64(call.RingDeadline), Member: call.Id}).Err()
+}
+
+// Get gets a call, nil when it does not exist or has expired
+func (r *CallRepo) Get(ctx context.Context, callId string) (*entity.Call, error) {
+	data, err := r.rdb.Get(ctx, fmt.Sprintf(constant.RedisKeyCall(), callId)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var call entity.Call
+	if err = json.Unmarshal(data, &call); err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+// Update applies fn to the stored call atomically, retrying when the call changes meanwhile,
+// and stores the result with the ttl fn returns. fn is not called for a missing call, in
+// which case Update returns nil.
+func (r *CallRepo) Update(ctx context.Context, callId string, fn func(call *entity.Call) (time.Duration, error)) (*entity.Call, error) {
+	key := fmt.Sprintf(constant.RedisKeyCall(), callId)
+	var updated *entity.Call
+	txf := func(tx *redis.Tx) error {
+		updated = nil
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			return err
+		}
+		var call entity.Call
+		if err = json.Unmarshal(data, &call); err != nil {
+			return err
+		}
+		ttl, err := fn(&call)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(&call); err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		if err == nil {
+			updated = &call
+		}
+		return err
+	}
+	for range maxCallUpdateAttempts {
+		err := r.rdb.Watch(ctx, txf, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return updated, err
+	}
+	return nil, ErrCallConflict
+}
+
+// StopRinging removes the ring timeout of a call
+func (r *CallRepo) StopRinging(ctx context.Context, callId string) error {
+	return r.rdb.ZRem(ctx, constant.RedisKeyCallRinging(), callId).Err()
+}
+
+// ClaimRingTimeouts returns up to limit calls whose ring deadline is before now, removing
+// their timeouts; each call is claimed by one caller only when several nodes poll.
+func (r *CallRepo) ClaimRingTimeouts(ctx context.Context, now int64, limit int64) ([]string, error) {
+	ids, err := r.rdb.ZRangeByScore(ctx, constant.RedisKeyCallRinging(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now, 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	claimed := ids[:0]
+	for _, id := range ids {
+		n, err := r.rdb.ZRem(ctx, constant.RedisKeyCallRinging(), id).Result()
+		if err != nil {
+			return claimed, err
+		}
+		if n == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+	return claimed, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -64,6 +65,33 @@ func (r *ConversationRepo) GetUserConversations(ctx context.Context, ownerId str
 	return convs, nil
 }
 
+// GetSingleChatPeerIds gets the peers of all single chat conversations of a user
+func (r *ConversationRepo) GetSingleChatPeerIds(ctx context.Context, ownerId string) ([]string, error) {
+	var peerIds []string
+	err := r.db.WithContext(ctx).
+		Model(&entity.Conversation{}).
+		Where("owner_id = ? AND conversation_type = ? AND peer_user_id <> ''", ownerId, constant.SessionTypeSingle).
+		Pluck("peer_user_id", &peerIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return peerIds, nil
+}
+
+// ListSingleChatAfter lists the single chat conversations of all users after the row afterId, in id order
+func (r *ConversationRepo) ListSingleChatAfter(ctx context.Context, afterId int64, limit int) ([]*entity.Conversation, error) {
+	var convs []*entity.Conversation
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND conversation_type = ?", afterId, constant.SessionTypeSingle).
+		Order("id ASC").
+		Limit(limit).
+		Find(&convs).Error
+	if err != nil {
+		return nil, err
+	}
+	return convs, nil
+}
+
 // GetUserConversationsWithSeq gets conversations with sequence info
 func (r *ConversationRepo) GetUserConversationsWithSeq(ctx context.Context, ownerId string) ([]*entity.ConversationWithSeq, error) {
 	return r.GetUserConversationsWithSeqPage(ctx, ownerId, 0, 0, "")
@@ -81,7 +109,8 @@ func (r *ConversationRepo) GetUserConversationsWithSeqPage(ctx context.Context,
 			c.*,
 			COALESCE(sc.max_seq, 0) as max_seq,
 			COALESCE(su.read_seq, 0) as read_seq,
-			GREATEST(0, COALESCE(sc.max_seq, 0) - COALESCE(su.read_seq, 0)) as unread_count
+			GREATEST(0, COALESCE(sc.max_seq, 0) - COALESCE(su.read_seq, 0)) as unread_count,
+			COALESCE(sc.ttl_seconds, 0) as ttl_seconds
 		`).
 		Joins("LEFT JOIN seq_conversations sc ON sc.conversation_id = c.conversation_id").
 		Joins("LEFT JOIN seq_users su ON su.user_id = c.owner_id AND su.conversation_id = c.conversation_id").
@@ -117,6 +146,12 @@ func (r *ConversationRepo) Update(ctx context.Context, ownerId, conversationId s
 		Updates(updates).Error
 }
 
+// DeleteByOwner deletes all conversations owned by a user
+func (r *ConversationRepo) DeleteByOwner(ctx context.Context, tx *gorm.DB, ownerId string) (int64, error) {
+	result := tx.WithContext(ctx).Where("owner_id = ?", ownerId).Delete(&entity.Conversation{})
+	return result.RowsAffected, result.Error
+}
+
 // Touch updates the updated_at timestamp
 func (r *ConversationRepo) Touch(ctx context.Context, ownerId, conversationId string) error {
 	return r.Update(ctx, ownerId, conversationId, map[string]interface{}{})
@@ -158,6 +193,18 @@ func (r *ConversationRepo) EnsureSingleChatConversations(ctx context.Context, tx
 	}).Create(recvConv).Error
 }
 
+// EnsureSavedConversation creates the saved messages conversation of a user, the single chat
+// with themselves, leaving an existing one untouched
+func (r *ConversationRepo) EnsureSavedConversation(ctx context.Context, userId, conversationId string) error {
+	conv := &entity.Conversation{
+		ConversationId:   conversationId,
+		OwnerId:          userId,
+		ConversationType: constant.SessionTypeSingle,
+		PeerUserId:       userId,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(conv).Error
+}
+
 // EnsureConversationsExist ensures conversations exist for all participants
 // For single chat: creates conversation for both users
 // For group chat: creates conversation for the user
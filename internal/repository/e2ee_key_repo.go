@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// E2EEKeyRepo is the repository for the public end-to-end encryption keys of devices
+type E2EEKeyRepo struct {
+	db *gorm.DB
+}
+
+// NewE2EEKeyRepo creates a new E2EEKeyRepo
+func NewE2EEKeyRepo(db *gorm.DB) *E2EEKeyRepo {
+	return &E2EEKeyRepo{db: db}
+}
+
+// GetDevice gets the keys of a device, nil if it has none
+func (r *E2EEKeyRepo) GetDevice(ctx context.Context, userId string, deviceId int) (*entity.E2EEDeviceKeys, error) {
+	var keys entity.E2EEDeviceKeys
+	err := r.db.WithContext(ctx).Where("user_id = ? AND device_id = ?", userId, deviceId).First(&keys).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &keys, nil
+}
+
+// GetDevices gets the keys of all devices of a user
+func (r *E2EEKeyRepo) GetDevices(ctx context.Context, userId string) ([]*entity.E2EEDeviceKeys, error) {
+	var devices []*entity.E2EEDeviceKeys
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("device_id").Find(&devices).Error
+	return devices, err
+}
+
+// SaveDevice stores the keys of a device and adds one-time prekeys; a new identity key
+// drops the one-time prekeys of the previous one first. Prekeys whose key id is already
+// stored are ignored.
+func (r *E2EEKeyRepo) SaveDevice(ctx context.Context, keys *entity.E2EEDeviceKeys, identityChanged bool, prekeys []*entity.E2EEOneTimePrekey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if identityChanged {
+			err := tx.Where("user_id = ? AND device_id = ?", keys.UserId, keys.DeviceId).Delete(&entity.E2EEOneTimePrekey{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "device_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"registration_id", "identity_key", "signed_prekey_id",
+				"signed_prekey", "signed_prekey_signature", "updated_at"}),
+		}).Create(keys).Error
+		if err != nil || len(prekeys) == 0 {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(prekeys).Error
+	})
+}
+
+// CountPrekeys returns the number of unclaimed one-time prekeys of a device
+func (r *E2EEKeyRepo) CountPrekeys(ctx context.Context, userId string, deviceId int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.E2EEOneTimePrekey{}).
+		Where("user_id = ? AND device_id = ?", userId, deviceId).Count(&count).Error
+	return count, err
+}
+
+// ClaimPrekey removes and returns the oldest one-time prekey of a device, nil when none is
+// left. Concurrent claims get different prekeys.
+func (r *E2EEKeyRepo) ClaimPrekey(ctx context.Context, userId string, deviceId int) (*entity.E2EEOneTimePrekey, error) {
+	var claimed *entity.E2EEOneTimePrekey
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var prekey entity.E2EEOneTimePrekey
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("user_id = ? AND device_id = ?", userId, deviceId).
+			Order("id").First(&prekey).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err = tx.Delete(&prekey).Error; err != nil {
+			return err
+		}
+		claimed = &prekey
+		return nil
+	})
+	return claimed, err
+}
+
+// DeleteDevice removes all keys of a device
+func (r *E2EEKeyRepo) DeleteDevice(ctx context.Context, userId string, deviceId int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND device_id = ?", userId, deviceId).Delete(&entity.E2EEOneTimePrekey{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND device_id = ?", userId, deviceId).Delete(&entity.E2EEDeviceKeys{}).Error
+	})
+}
+
+// DeleteByUser removes all keys of a user
+func (r *E2EEKeyRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	if err := tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.E2EEOneTimePrekey{}).Error; err != nil {
+		return err
+	}
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.E2EEDeviceKeys{}).Error
+}
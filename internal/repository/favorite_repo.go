@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// FavoriteRepo is the repository for favorite messages
+type FavoriteRepo struct {
+	db *gorm.DB
+}
+
+// NewFavoriteRepo creates a new FavoriteRepo
+func NewFavoriteRepo(db *gorm.DB) *FavoriteRepo {
+	return &FavoriteRepo{db: db}
+}
+
+// Create creates a favorite unless the user already favorited the message.
+// Returns false if it already existed.
+func (r *FavoriteRepo) Create(ctx context.Context, favorite *entity.MessageFavorite) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(favorite)
+	return result.RowsAffected == 1, result.Error
+}
+
+// GetByMessage gets the favorite of a user for a message
+func (r *FavoriteRepo) GetByMessage(ctx context.Context, userId, conversationId string, seq int64) (*entity.MessageFavorite, error) {
+	var favorite entity.MessageFavorite
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND conversation_id = ? AND seq = ?", userId, conversationId, seq).
+		First(&favorite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &favorite, nil
+}
+
+// CountByUser counts the favorites of a user
+func (r *FavoriteRepo) CountByUser(ctx context.Context, userId string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.MessageFavorite{}).Where("user_id = ?", userId).Count(&count).Error
+	return count, err
+}
+
+// ListByUser lists the favorites of a user newest first, starting below beforeId if set
+func (r *FavoriteRepo) ListByUser(ctx context.Context, userId string, beforeId int64, limit int) ([]*entity.MessageFavorite, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userId)
+	if beforeId > 0 {
+		query = query.Where("id < ?", beforeId)
+	}
+	var favorites []*entity.MessageFavorite
+	if err := query.Order("id DESC").Limit(limit).Find(&favorites).Error; err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// Delete deletes a favorite of a user. Returns false if there was none.
+func (r *FavoriteRepo) Delete(ctx context.Context, userId string, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).Delete(&entity.MessageFavorite{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUser deletes all favorites of a user
+func (r *FavoriteRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.MessageFavorite{}).Error
+}
+
+// DeleteBySender deletes the favorites other users made of a sender's messages
+func (r *FavoriteRepo) DeleteBySender(ctx context.Context, senderId string) (int64, error) {
+	result := r.db.WithContext(ctx).Where("sender_id = ?", senderId).Delete(&entity.MessageFavorite{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// consumeGroupInviteScript counts one use of the invite link at KEYS[1] and returns its
+// group id, or an empty string when the link is gone or used up. The link is dropped
+// once its last use is taken.
+var consumeGroupInviteScript = redis.NewScript(`
+local fields = redis.call('HMGET', KEYS[1], 'group_id', 'max_uses', 'uses')
+if not fields[1] then
+	return ''
+end
+local maxUses = tonumber(fields[2])
+local uses = tonumber(fields[3])
+if maxUses > 0 and uses >= maxUses then
+	return ''
+end
+uses = redis.call('HINCRBY', KEYS[1], 'uses', 1)
+if maxUses > 0 and uses >= maxUses then
+	redis.call('DEL', KEYS[1])
+end
+return fields[1]
+`)
+
+// GroupInviteRepo is the repository for group invite links
+type GroupInviteRepo struct {
+	rdb redis.UniversalClient
+}
+
+// NewGroupInviteRepo creates a new GroupInviteRepo
+func NewGroupInviteRepo(rdb redis.UniversalClient) *GroupInviteRepo {
+	return &GroupInviteRepo{rdb: rdb}
+}
+
+// Save stores the invite link until it expires
+func (r *GroupInviteRepo) Save(ctx context.Context, invite *entity.GroupInviteLink, ttl time.Duration) error {
+	key := fmt.Sprintf(constant.RedisKeyGroupInvite(), invite.Token)
+	pipe := r.rdb.TxPipeline()
+	pipe.HSet(ctx, key,
+		"group_id", invite.GroupId,
+		"creator_user_id", invite.CreatorUserId,
+		"max_uses", invite.MaxUses,
+		"uses", invite.Uses,
+		"expires_at", invite.ExpiresAt,
+	)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Get gets an invite link, nil when it does not exist or has expired
+func (r *GroupInviteRepo) Get(ctx context.Context, token string) (*entity.GroupInviteLink, error) {
+	key := fmt.Sprintf(constant.RedisKeyGroupInvite(), token)
+	fields, err := r.rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if fields["group_id"] == "" {
+		return nil, nil
+	}
+	maxUses, _ := strconv.ParseInt(fields["max_uses"], 10, 64)
+	uses, _ := strconv.ParseInt(fields["uses"], 10, 64)
+	expiresAt, _ := strconv.ParseInt(fields["expires_at"], 10, 64)
+	return &entity.GroupInviteLink{
+		Token:         token,
+		GroupId:       fields["group_id"],
+		CreatorUserId: fields["creator_user_id"],
+		MaxUses:       maxUses,
+		Uses:          uses,
+		ExpiresAt:     expiresAt,
+	}, nil
+}
+
+// Consume takes one use of the invite link and returns its group id, empty when the
+// link is no longer valid
+func (r *GroupInviteRepo) Consume(ctx context.Context, token string) (string, error) {
+	key := fmt.Sprintf(constant.RedisKeyGroupInvite(), token)
+	return consumeGroupInviteScript.Run(ctx, r.rdb, []string{key}).Text()
+}
+
+// Delete revokes an invite link
+func (r *GroupInviteRepo) Delete(ctx context.Context, token string) error {
+	key := fmt.Sprintf(constant.RedisKeyGroupInvite(), token)
+	return r.rdb.Del(ctx, key).Err()
+}
@@ -52,11 +52,30 @@ func (r *GroupRepo) Update(ctx context.Context, id string, updates map[string]in
 	return r.db.WithContext(ctx).Model(&entity.Group{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// UpdateWithTx updates group info with transaction
+func (r *GroupRepo) UpdateWithTx(ctx context.Context, tx *gorm.DB, id string, updates map[string]interface{}) error {
+	return tx.WithContext(ctx).Model(&entity.Group{}).Where("id = ?", id).Updates(updates).Error
+}
+
 // Dismiss dismisses a group
 func (r *GroupRepo) Dismiss(ctx context.Context, id string) error {
 	return r.Update(ctx, id, map[string]interface{}{"status": constant.GroupStatusDismissed})
 }
 
+// ListAfter lists the groups after afterId, in id order
+func (r *GroupRepo) ListAfter(ctx context.Context, afterId string, limit int) ([]*entity.Group, error) {
+	var groups []*entity.Group
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterId).
+		Order("id ASC").
+		Limit(limit).
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
 // AddMember adds a member to group using ON DUPLICATE KEY UPDATE for rejoining
 func (r *GroupRepo) AddMember(ctx context.Context, tx *gorm.DB, member *entity.GroupMember) error {
 	// Use ON DUPLICATE KEY UPDATE for handling rejoin scenario
@@ -130,6 +149,37 @@ func (r *GroupRepo) GetActiveMemberUserIds(ctx context.Context, groupId string)
 	return userIds, nil
 }
 
+// GetCoMemberUserIds gets the user Ids of the active members of every group userId is an
+// active member of, userId included
+func (r *GroupRepo) GetCoMemberUserIds(ctx context.Context, userId string) ([]string, error) {
+	var userIds []string
+	err := r.db.WithContext(ctx).
+		Table("group_members AS peers").
+		Joins("JOIN group_members AS self ON self.group_id = peers.group_id").
+		Where("self.user_id = ? AND self.status = ? AND peers.status = ?",
+			userId, constant.GroupMemberStatusNormal, constant.GroupMemberStatusNormal).
+		Distinct().
+		Pluck("peers.user_id", &userIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
+
+// ListActiveMembersAfter lists the active members of all groups after the member row afterId, in id order
+func (r *GroupRepo) ListActiveMembersAfter(ctx context.Context, afterId int64, limit int) ([]*entity.GroupMember, error) {
+	var members []*entity.GroupMember
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND status = ?", afterId, constant.GroupMemberStatusNormal).
+		Order("id ASC").
+		Limit(limit).
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
 // UpdateMemberStatus updates member status
 func (r *GroupRepo) UpdateMemberStatus(ctx context.Context, tx *gorm.DB, groupId, userId string, status int32) error {
 	err := tx.WithContext(ctx).
@@ -147,6 +197,21 @@ func (r *GroupRepo) UpdateMemberStatus(ctx context.Context, tx *gorm.DB, groupId
 	return nil
 }
 
+// UpdateMember updates member fields such as role_level or muted_until
+func (r *GroupRepo) UpdateMember(ctx context.Context, tx *gorm.DB, groupId, userId string, updates map[string]interface{}) error {
+	err := tx.WithContext(ctx).
+		Model(&entity.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupId, userId).
+		Updates(updates).Error
+	if err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	r.invalidateMemberCache(ctx, groupId)
+	return nil
+}
+
 // GetMemberCount gets the count of active members in a group
 func (r *GroupRepo) GetMemberCount(ctx context.Context, groupId string) (int64, error) {
 	var count int64
@@ -183,6 +248,53 @@ func (r *GroupRepo) GetUserGroups(ctx context.Context, userId string) ([]*entity
 	return groups, nil
 }
 
+// GetUserGroupIds gets ids of all groups the user has a membership record in (any status)
+func (r *GroupRepo) GetUserGroupIds(ctx context.Context, tx *gorm.DB, userId string) ([]string, error) {
+	var groupIds []string
+	err := tx.WithContext(ctx).
+		Model(&entity.GroupMember{}).
+		Where("user_id = ?", userId).
+		Pluck("group_id", &groupIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return groupIds, nil
+}
+
+// AnonymizeMembersByUser marks all memberships of a user as left and clears member profile fields
+func (r *GroupRepo) AnonymizeMembersByUser(ctx context.Context, tx *gorm.DB, userId string, groupIds []string) (int64, error) {
+	result := tx.WithContext(ctx).
+		Model(&entity.GroupMember{}).
+		Where("user_id = ?", userId).
+		Updates(map[string]interface{}{
+			"status":         constant.GroupMemberStatusLeft,
+			"group_nickname": "",
+			"group_avatar":   "",
+			"extra":          nil,
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	for _, groupId := range groupIds {
+		r.invalidateMemberCache(ctx, groupId)
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteMembersByUser physically deletes all memberships of a user
+func (r *GroupRepo) DeleteMembersByUser(ctx context.Context, tx *gorm.DB, userId string, groupIds []string) (int64, error) {
+	result := tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.GroupMember{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	for _, groupId := range groupIds {
+		r.invalidateMemberCache(ctx, groupId)
+	}
+	return result.RowsAffected, nil
+}
+
 // invalidateMemberCache invalidates the group members cache
 func (r *GroupRepo) invalidateMemberCache(ctx context.Context, groupId string) {
 	key := fmt.Sprintf(constant.RedisKeyGroupMembers(), groupId)
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// HiddenMessageRepo is the repository for the messages users deleted for themselves
+type HiddenMessageRepo struct {
+	db *gorm.DB
+}
+
+// NewHiddenMessageRepo creates a new HiddenMessageRepo
+func NewHiddenMessageRepo(db *gorm.DB) *HiddenMessageRepo {
+	return &HiddenMessageRepo{db: db}
+}
+
+// Hide hides a message for a user; hiding it again is a no-op
+func (r *HiddenMessageRepo) Hide(ctx context.Context, hidden *entity.HiddenMessage) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(hidden).Error
+}
+
+// GetSeqs returns which of seqs of a conversation the user hid
+func (r *HiddenMessageRepo) GetSeqs(ctx context.Context, userId, conversationId string, seqs []int64) (map[int64]bool, error) {
+	result := make(map[int64]bool)
+	if len(seqs) == 0 {
+		return result, nil
+	}
+	var hidden []int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.HiddenMessage{}).
+		Where("user_id = ? AND conversation_id = ? AND seq IN ?", userId, conversationId, seqs).
+		Pluck("seq", &hidden).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range hidden {
+		result[seq] = true
+	}
+	return result, nil
+}
+
+// GetConversations returns which conversations of convSeqs have their seq hidden by the user,
+// with one query
+func (r *HiddenMessageRepo) GetConversations(ctx context.Context, userId string, convSeqs map[string]int64) (map[string]bool, error) {
+	result := make(map[string]bool)
+	if len(convSeqs) == 0 {
+		return result, nil
+	}
+	pairs := r.db
+	first := true
+	for conversationId, seq := range convSeqs {
+		if first {
+			pairs = pairs.Where("(conversation_id = ? AND seq = ?)", conversationId, seq)
+			first = false
+		} else {
+			pairs = pairs.Or("(conversation_id = ? AND seq = ?)", conversationId, seq)
+		}
+	}
+
+	var hidden []*entity.HiddenMessage
+	err := r.db.WithContext(ctx).
+		Select("conversation_id, seq").
+		Where("user_id = ?", userId).
+		Where(pairs).
+		Find(&hidden).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hidden {
+		if convSeqs[h.ConversationId] == h.Seq {
+			result[h.ConversationId] = true
+		}
+	}
+	return result, nil
+}
+
+// DeleteByUser deletes all messages hidden by a user within tx
+func (r *HiddenMessageRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.HiddenMessage{}).Error
+}
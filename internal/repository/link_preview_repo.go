@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// LinkPreviewRepo caches the link previews of URLs in Redis, as well as the URLs without one
+type LinkPreviewRepo struct {
+	rdb redis.UniversalClient
+}
+
+// NewLinkPreviewRepo creates a new LinkPreviewRepo
+func NewLinkPreviewRepo(rdb redis.UniversalClient) *LinkPreviewRepo {
+	return &LinkPreviewRepo{rdb: rdb}
+}
+
+func linkPreviewKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return fmt.Sprintf(constant.RedisKeyLinkPreview(), hex.EncodeToString(sum[:]))
+}
+
+// Get returns the cached preview of a URL; found is false when the URL is not cached, and
+// preview nil when it is cached as having none
+func (r *LinkPreviewRepo) Get(ctx context.Context, rawURL string) (preview *entity.LinkPreview, found bool, err error) {
+	data, err := r.rdb.Get(ctx, linkPreviewKey(rawURL)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) == 0 {
+		return nil, true, nil
+	}
+	preview = &entity.LinkPreview{}
+	if err = json.Unmarshal(data, preview); err != nil {
+		return nil, false, err
+	}
+	return preview, true, nil
+}
+
+// Set caches the preview of a URL for ttl; a nil preview records that the URL has none
+func (r *LinkPreviewRepo) Set(ctx context.Context, rawURL string, preview *entity.LinkPreview, ttl time.Duration) error {
+	var data []byte
+	if preview != nil {
+		var err error
+		if data, err = json.Marshal(preview); err != nil {
+			return err
+		}
+	}
+	return r.rdb.Set(ctx, linkPreviewKey(rawURL), data, ttl).Err()
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// MentionRepo is the repository for the unread @mentions of users
+type MentionRepo struct {
+	db *gorm.DB
+}
+
+// NewMentionRepo creates a new MentionRepo
+func NewMentionRepo(db *gorm.DB) *MentionRepo {
+	return &MentionRepo{db: db}
+}
+
+// Create records the users mentioned by a message
+func (r *MentionRepo) Create(ctx context.Context, conversationId string, seq int64, userIds []string) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	mentions := make([]*entity.MessageMention, 0, len(userIds))
+	for _, userId := range userIds {
+		mentions = append(mentions, &entity.MessageMention{UserId: userId, ConversationId: conversationId, Seq: seq})
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&mentions).Error
+}
+
+// GetUnreadStats sums up the mentions of a user after the read seq of each conversation,
+// keyed by conversation id. Conversations without unread mentions are missing from the result.
+func (r *MentionRepo) GetUnreadStats(ctx context.Context, userId string, readSeqs map[string]int64) (map[string]*entity.MentionStat, error) {
+	result := make(map[string]*entity.MentionStat, len(readSeqs))
+	if len(readSeqs) == 0 {
+		return result, nil
+	}
+
+	// Grouped so that the OR conditions stay within the user's rows
+	conds := r.db
+	condCount := 0
+	for conversationId, readSeq := range readSeqs {
+		if condCount == 0 {
+			conds = conds.Where("(conversation_id = ? AND seq > ?)", conversationId, readSeq)
+		} else {
+			conds = conds.Or("(conversation_id = ? AND seq > ?)", conversationId, readSeq)
+		}
+		condCount++
+	}
+	var rows []struct {
+		ConversationId string
+		Mentions       int64
+		FirstSeq       int64
+	}
+	err := r.db.WithContext(ctx).Model(&entity.MessageMention{}).
+		Select("conversation_id, COUNT(*) AS mentions, MIN(seq) AS first_seq").
+		Where("user_id = ?", userId).
+		Where(conds).
+		Group("conversation_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ConversationId] = &entity.MentionStat{Count: row.Mentions, FirstSeq: row.FirstSeq}
+	}
+	return result, nil
+}
+
+// DeleteRead removes the mentions of a user in a conversation up to readSeq
+func (r *MentionRepo) DeleteRead(ctx context.Context, userId, conversationId string, readSeq int64) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND conversation_id = ? AND seq <= ?", userId, conversationId, readSeq).
+		Delete(&entity.MessageMention{}).Error
+}
+
+// DeleteByMessage removes the mentions of userIds by a message within tx
+func (r *MentionRepo) DeleteByMessage(ctx context.Context, tx *gorm.DB, conversationId string, seq int64, userIds []string) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	return tx.WithContext(ctx).
+		Where("user_id IN ? AND conversation_id = ? AND seq = ?", userIds, conversationId, seq).
+		Delete(&entity.MessageMention{}).Error
+}
+
+// DeleteByUser removes all mentions of a user
+func (r *MentionRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.MessageMention{}).Error
+}
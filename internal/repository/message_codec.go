@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// contentCompressor compresses message content above a size threshold before storage.
+// A nil compressor (or a disabled one) stores content as plain JSON.
+type contentCompressor struct {
+	codec     int32
+	threshold int
+}
+
+// zstdDecoder decodes zstd content for every row; DecodeAll is safe for concurrent use
+// and creating a decoder without options cannot fail
+var zstdDecoder, _ = zstd.NewReader(nil)
+
+// zstdEncoder encodes zstd content; EncodeAll is safe for concurrent use and creating
+// an encoder without options cannot fail
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// newContentCompressor creates a compressor for the given codec name.
+// Unknown codec names return an error so misconfiguration fails at startup.
+func newContentCompressor(codec string, threshold int) (*contentCompressor, error) {
+	switch codec {
+	case "gzip":
+		return &contentCompressor{codec: constant.ContentCodecGzip, threshold: threshold}, nil
+	case "zstd":
+		return &contentCompressor{codec: constant.ContentCodecZstd, threshold: threshold}, nil
+	default:
+		return nil, fmt.Errorf("unsupported message compression codec: %s", codec)
+	}
+}
+
+// encode compresses msg.Content into msg.ContentBlob when it exceeds the threshold.
+// The JSON content column keeps an empty object so the NOT NULL constraint holds.
+func (c *contentCompressor) encode(msg *entity.Message) error {
+	if c == nil || msg == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(msg.Content)
+	if err != nil {
+		return err
+	}
+	if len(raw) <= c.threshold {
+		msg.ContentCodec = constant.ContentCodecNone
+		msg.ContentBlob = nil
+		return nil
+	}
+
+	blob, err := c.compress(raw)
+	if err != nil {
+		return err
+	}
+
+	msg.ContentCodec = c.codec
+	msg.ContentBlob = blob
+	msg.Content = entity.MessageContent{}
+	return nil
+}
+
+// compress encodes raw with the compressor's codec
+func (c *contentCompressor) compress(raw []byte) ([]byte, error) {
+	if c.codec == constant.ContentCodecZstd {
+		return zstdEncoder.EncodeAll(raw, nil), nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeMessageContent restores msg.Content from msg.ContentBlob based on the row's codec flag.
+// Rows written before compression was enabled (codec none) are returned unchanged.
+func decodeMessageContent(msg *entity.Message) error {
+	if msg == nil {
+		return nil
+	}
+
+	var raw []byte
+	var err error
+	switch msg.ContentCodec {
+	case constant.ContentCodecNone:
+		return nil
+	case constant.ContentCodecGzip:
+		raw, err = gunzip(msg.ContentBlob)
+	case constant.ContentCodecZstd:
+		raw, err = zstdDecoder.DecodeAll(msg.ContentBlob, nil)
+	default:
+		return fmt.Errorf("unknown message content codec: %d", msg.ContentCodec)
+	}
+	if err != nil {
+		return err
+	}
+
+	var content entity.MessageContent
+	if err = json.Unmarshal(raw, &content); err != nil {
+		return err
+	}
+	msg.Content = content
+	msg.ContentCodec = constant.ContentCodecNone
+	msg.ContentBlob = nil
+	return nil
+}
+
+// gunzip decompresses a gzip blob
+func gunzip(blob []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+	return io.ReadAll(zr)
+}
+
+// decodeMessagesContent decodes content for a batch of messages.
+func decodeMessagesContent(messages []*entity.Message) error {
+	for _, msg := range messages {
+		if err := decodeMessageContent(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
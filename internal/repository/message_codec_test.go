@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+func TestContentCompressorRoundTripsLargeContent(t *testing.T) {
+	for codecName, codec := range map[string]int32{"gzip": constant.ContentCodecGzip, "zstd": constant.ContentCodecZstd} {
+		compressor, err := newContentCompressor(codecName, 64)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		text := strings.Repeat("hello nexo ", 100)
+		msg := &entity.Message{Content: entity.MessageContent{Text: &entity.TextContent{Text: text}}}
+		if err = compressor.encode(msg); err != nil {
+			t.Fatalf("%s encode failed: %v", codecName, err)
+		}
+		if msg.ContentCodec != codec || len(msg.ContentBlob) == 0 {
+			t.Fatalf("expected %s-compressed content, got codec=%d", codecName, msg.ContentCodec)
+		}
+		if msg.Content.Text != nil {
+			t.Fatalf("expected plain content to be cleared after %s compression", codecName)
+		}
+
+		if err = decodeMessageContent(msg); err != nil {
+			t.Fatalf("%s decode failed: %v", codecName, err)
+		}
+		if msg.Content.Text == nil || msg.Content.Text.Text != text {
+			t.Fatalf("expected text content to be restored after %s decode", codecName)
+		}
+	}
+}
+
+func TestContentCompressorKeepsSmallContentPlain(t *testing.T) {
+	compressor, err := newContentCompressor("gzip", 4096)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := &entity.Message{Content: entity.MessageContent{Text: &entity.TextContent{Text: "hi"}}}
+	if err = compressor.encode(msg); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if msg.ContentCodec != constant.ContentCodecNone || msg.ContentBlob != nil {
+		t.Fatalf("expected small content to stay plain, got codec=%d", msg.ContentCodec)
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// MessageEditRepo is the repository for the edit history of messages
+type MessageEditRepo struct {
+	db *gorm.DB
+}
+
+// NewMessageEditRepo creates a new MessageEditRepo
+func NewMessageEditRepo(db *gorm.DB) *MessageEditRepo {
+	return &MessageEditRepo{db: db}
+}
+
+// Create stores a replaced version of a message within tx
+func (r *MessageEditRepo) Create(ctx context.Context, tx *gorm.DB, edit *entity.MessageEdit) error {
+	return tx.WithContext(ctx).Create(edit).Error
+}
+
+// ListByMessage lists the replaced versions of a message, oldest first
+func (r *MessageEditRepo) ListByMessage(ctx context.Context, conversationId string, seq int64) ([]*entity.MessageEdit, error) {
+	var edits []*entity.MessageEdit
+	err := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ?", conversationId, seq).
+		Order("version ASC").
+		Find(&edits).Error
+	return edits, err
+}
+
+// DeleteByMessage removes the edit history of a message within tx
+func (r *MessageEditRepo) DeleteByMessage(ctx context.Context, tx *gorm.DB, conversationId string, seq int64) error {
+	return tx.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ?", conversationId, seq).
+		Delete(&entity.MessageEdit{}).Error
+}
+
+// DeleteBySender removes the edit history of all messages of a sender
+func (r *MessageEditRepo) DeleteBySender(ctx context.Context, senderId string) (int64, error) {
+	result := r.db.WithContext(ctx).Where("sender_id = ?", senderId).Delete(&entity.MessageEdit{})
+	return result.RowsAffected, result.Error
+}
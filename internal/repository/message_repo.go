@@ -2,17 +2,21 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 // MessageRepo is the repository for message operations
 type MessageRepo struct {
-	db  *gorm.DB
-	rdb redis.UniversalClient
+	db         *gorm.DB
+	rdb        redis.UniversalClient
+	compressor *contentCompressor
 }
 
 // NewMessageRepo creates a new MessageRepo
@@ -20,9 +24,29 @@ func NewMessageRepo(db *gorm.DB, rdb redis.UniversalClient) *MessageRepo {
 	return &MessageRepo{db: db, rdb: rdb}
 }
 
-// Create creates a new message
+// EnableCompression enables at-rest compression for message content larger than threshold bytes
+func (r *MessageRepo) EnableCompression(codec string, threshold int) error {
+	compressor, err := newContentCompressor(codec, threshold)
+	if err != nil {
+		return err
+	}
+	r.compressor = compressor
+	return nil
+}
+
+// Create creates a new message with its content hash
+// Content is compressed for storage when enabled; msg keeps its plain content for the caller.
 func (r *MessageRepo) Create(ctx context.Context, tx *gorm.DB, msg *entity.Message) error {
-	return tx.WithContext(ctx).Create(msg).Error
+	msg.ContentHash = msg.ComputeContentHash()
+	content := msg.Content
+	if err := r.compressor.encode(msg); err != nil {
+		return err
+	}
+	err := tx.WithContext(ctx).Create(msg).Error
+	msg.Content = content
+	msg.ContentCodec = constant.ContentCodecNone
+	msg.ContentBlob = nil
+	return err
 }
 
 // GetByClientMsgId gets message by sender_id and client_msg_id (for idempotency check)
@@ -37,6 +61,9 @@ func (r *MessageRepo) GetByClientMsgId(ctx context.Context, senderId, clientMsgI
 		}
 		return nil, err
 	}
+	if err = decodeMessageContent(&msg); err != nil {
+		return nil, err
+	}
 	return &msg, nil
 }
 
@@ -49,6 +76,9 @@ func (r *MessageRepo) GetByConvSeq(ctx context.Context, conversationId string, s
 	if err != nil {
 		return nil, err
 	}
+	if err = decodeMessageContent(&msg); err != nil {
+		return nil, err
+	}
 	return &msg, nil
 }
 
@@ -68,6 +98,9 @@ func (r *MessageRepo) PullMessages(ctx context.Context, conversationId string, b
 	if err != nil {
 		return nil, err
 	}
+	if err = decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
 	return messages, nil
 }
 
@@ -90,6 +123,46 @@ func (r *MessageRepo) PullMessagesBySeqList(ctx context.Context, conversationId
 	if err != nil {
 		return nil, err
 	}
+	if err = decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListReplies lists the messages of a conversation quoting the message at quoteSeq with a seq
+// in (afterSeq, endSeq], in seq order
+func (r *MessageRepo) ListReplies(ctx context.Context, conversationId string, quoteSeq, afterSeq, endSeq int64, limit int) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	err := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND quote_seq = ? AND seq > ? AND seq <= ?", conversationId, quoteSeq, afterSeq, endSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	if err = decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListAfter lists the single and group chat messages after (conversationId, seq),
+// in conversation_id then seq order
+func (r *MessageRepo) ListAfter(ctx context.Context, conversationId string, seq int64, limit int) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	err := r.db.WithContext(ctx).
+		Where("(conversation_id > ? OR (conversation_id = ? AND seq > ?)) AND session_type IN ?",
+			conversationId, conversationId, seq, []int32{constant.SessionTypeSingle, constant.SessionTypeGroup}).
+		Order("conversation_id ASC, seq ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	if err = decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
 	return messages, nil
 }
 
@@ -108,6 +181,9 @@ func (r *MessageRepo) GetLatestMessages(ctx context.Context, conversationId stri
 	if err != nil {
 		return nil, err
 	}
+	if err = decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
 
 	// Reverse to ascending order
 	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
@@ -157,9 +233,311 @@ func (r *MessageRepo) BatchGetByConvSeq(ctx context.Context, convMaxSeq map[stri
 	if err := query.Find(&messages).Error; err != nil {
 		return nil, err
 	}
+	if err := decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
 
 	for _, msg := range messages {
 		result[msg.ConversationId] = msg
 	}
 	return result, nil
 }
+
+// TombstoneBySender clears content of all messages sent by a user in batches, keeping rows for seq continuity.
+// Returns the number of tombstoned messages.
+func (r *MessageRepo) TombstoneBySender(ctx context.Context, senderId string, deletedAt int64, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var ids []int64
+		err := r.db.WithContext(ctx).
+			Model(&entity.Message{}).
+			Where("sender_id = ? AND deleted_at = 0", senderId).
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := r.db.WithContext(ctx).
+			Model(&entity.Message{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"content":       "{}",
+				"content_codec": constant.ContentCodecNone,
+				"content_blob":  nil,
+				"extra":         nil,
+				"content_hash":  "",
+				"deleted_at":    deletedAt,
+			})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+	}
+}
+
+// MessageSearchFilter holds admin message search conditions; empty fields are not filtered on
+type MessageSearchFilter struct {
+	SenderId       string
+	ConversationId string
+	Keyword        string // matched literally against text content; compressed content is not searchable, see CountCompressed
+	StartTime      int64  // send_at lower bound (ms, inclusive)
+	EndTime        int64  // send_at upper bound (ms, inclusive)
+	Offset         int
+	Limit          int
+}
+
+// Search searches messages by filter, newest first
+func (r *MessageRepo) Search(ctx context.Context, filter *MessageSearchFilter) ([]*entity.Message, error) {
+	query := r.searchQuery(ctx, filter)
+	if filter.Keyword != "" {
+		query = query.Where("JSON_UNQUOTE(JSON_EXTRACT(content, '$.text.text')) LIKE ?", "%"+escapeLike(filter.Keyword)+"%")
+	}
+
+	var messages []*entity.Message
+	err := query.Order("send_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	if err = decodeMessagesContent(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// CountCompressed counts the compressed messages matching filter but its keyword, which a
+// keyword search cannot see
+func (r *MessageRepo) CountCompressed(ctx context.Context, filter *MessageSearchFilter) (int64, error) {
+	var count int64
+	err := r.searchQuery(ctx, filter).
+		Where("content_codec <> ?", constant.ContentCodecNone).
+		Count(&count).Error
+	return count, err
+}
+
+// searchQuery selects the messages matching filter but its keyword
+func (r *MessageRepo) searchQuery(ctx context.Context, filter *MessageSearchFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entity.Message{})
+	if filter.SenderId != "" {
+		query = query.Where("sender_id = ?", filter.SenderId)
+	}
+	if filter.ConversationId != "" {
+		query = query.Where("conversation_id = ?", filter.ConversationId)
+	}
+	if filter.StartTime > 0 {
+		query = query.Where("send_at >= ?", filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		query = query.Where("send_at <= ?", filter.EndTime)
+	}
+	return query
+}
+
+// TombstoneBySeqs clears content of specific messages in a conversation, keeping rows for seq continuity.
+// Returns the number of tombstoned messages.
+func (r *MessageRepo) TombstoneBySeqs(ctx context.Context, conversationId string, seqs []int64, deletedAt int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("conversation_id = ? AND seq IN ? AND deleted_at = 0", conversationId, seqs).
+		Updates(map[string]interface{}{
+			"content":       "{}",
+			"content_codec": constant.ContentCodecNone,
+			"content_blob":  nil,
+			"extra":         nil,
+			"content_hash":  "",
+			"deleted_at":    deletedAt,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Revoke clears the content of a message recalled by its sender within tx, keeping the row for
+// seq continuity. Returns false if the message was already revoked or deleted.
+func (r *MessageRepo) Revoke(ctx context.Context, tx *gorm.DB, id, revokedAt int64) (bool, error) {
+	result := tx.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("id = ? AND deleted_at = 0 AND revoked_at = 0", id).
+		Updates(map[string]interface{}{
+			"content":       "{}",
+			"content_codec": constant.ContentCodecNone,
+			"content_blob":  nil,
+			"extra":         nil,
+			"content_hash":  "",
+			"revoked_at":    revokedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteForAll clears the content of a message deleted for everyone within tx, keeping the row
+// for seq continuity. Returns false if the message was already revoked or deleted.
+func (r *MessageRepo) DeleteForAll(ctx context.Context, tx *gorm.DB, id, deletedAt int64) (bool, error) {
+	result := tx.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("id = ? AND deleted_at = 0 AND revoked_at = 0", id).
+		Updates(map[string]interface{}{
+			"content":       "{}",
+			"content_codec": constant.ContentCodecNone,
+			"content_blob":  nil,
+			"extra":         nil,
+			"content_hash":  "",
+			"deleted_at":    deletedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateContent stores the content and extra of msg with its new content hash, compressed like
+// Create, unless the message was deleted or revoked. Returns whether the message was updated.
+func (r *MessageRepo) UpdateContent(ctx context.Context, msg *entity.Message) (bool, error) {
+	return r.updateContent(ctx, msg, nil)
+}
+
+// UpdateContentIfUnchanged is UpdateContent for a message whose stored content hash is still
+// prevHash, so that concurrent edits are not overwritten
+func (r *MessageRepo) UpdateContentIfUnchanged(ctx context.Context, msg *entity.Message, prevHash string) (bool, error) {
+	return r.updateContent(ctx, msg, &prevHash)
+}
+
+func (r *MessageRepo) updateContent(ctx context.Context, msg *entity.Message, prevHash *string) (bool, error) {
+	columns, err := r.contentColumns(msg)
+	if err != nil {
+		return false, err
+	}
+	query := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("id = ? AND deleted_at = 0 AND revoked_at = 0", msg.Id)
+	if prevHash != nil {
+		query = query.Where("content_hash = ?", *prevHash)
+	}
+	result := query.Updates(columns)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Edit stores the content and extra of a message edited by its sender within tx, along with its
+// edit_version, which must be one past the stored one. Returns false if the message was edited,
+// deleted or revoked meanwhile.
+func (r *MessageRepo) Edit(ctx context.Context, tx *gorm.DB, msg *entity.Message) (bool, error) {
+	columns, err := r.contentColumns(msg)
+	if err != nil {
+		return false, err
+	}
+	columns["edit_version"] = msg.EditVersion
+	result := tx.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("id = ? AND edit_version = ? AND deleted_at = 0 AND revoked_at = 0", msg.Id, msg.EditVersion-1).
+		Updates(columns)
+	return result.RowsAffected > 0, result.Error
+}
+
+// contentColumns sets the new content hash of msg and returns its content columns, compressed
+// like Create
+func (r *MessageRepo) contentColumns(msg *entity.Message) (map[string]interface{}, error) {
+	msg.ContentHash = msg.ComputeContentHash()
+	stored := *msg
+	stored.ContentCodec = constant.ContentCodecNone
+	stored.ContentBlob = nil
+	if err := r.compressor.encode(&stored); err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(stored.Content)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"content":       string(content),
+		"content_codec": stored.ContentCodec,
+		"content_blob":  stored.ContentBlob,
+		"extra":         stored.Extra,
+		"content_hash":  stored.ContentHash,
+	}, nil
+}
+
+// DeleteBySender physically deletes all messages sent by a user in batches.
+// Returns the number of deleted messages.
+func (r *MessageRepo) DeleteBySender(ctx context.Context, senderId string, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var ids []int64
+		err := r.db.WithContext(ctx).
+			Model(&entity.Message{}).
+			Where("sender_id = ?", senderId).
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&entity.Message{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+	}
+}
+
+// DeleteExpired physically deletes messages sent before the given time in conversations
+// whose id starts with conversationPrefix, in batches.
+// Returns the highest purged seq per conversation and the number of deleted messages.
+func (r *MessageRepo) DeleteExpired(ctx context.Context, conversationPrefix string, before int64, batchSize int) (map[string]int64, int64, error) {
+	pattern := strings.ReplaceAll(conversationPrefix, "_", "\\_") + "%"
+	purgedSeq := make(map[string]int64)
+	var total int64
+	for {
+		var rows []*entity.Message
+		err := r.db.WithContext(ctx).
+			Select("id", "conversation_id", "seq").
+			Where("conversation_id LIKE ? AND send_at < ?", pattern, before).
+			Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return purgedSeq, total, err
+		}
+		if len(rows) == 0 {
+			return purgedSeq, total, nil
+		}
+
+		ids := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.Id)
+			if row.Seq > purgedSeq[row.ConversationId] {
+				purgedSeq[row.ConversationId] = row.Seq
+			}
+		}
+
+		result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&entity.Message{})
+		if result.Error != nil {
+			return purgedSeq, total, result.Error
+		}
+		total += result.RowsAffected
+	}
+}
+
+// DeleteDisappeared physically deletes messages whose expires_at passed before the given
+// time, in batches. Returns the number of deleted messages.
+func (r *MessageRepo) DeleteDisappeared(ctx context.Context, before int64, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var ids []int64
+		err := r.db.WithContext(ctx).
+			Model(&entity.Message{}).
+			Where("expires_at > 0 AND expires_at <= ?", before).
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&entity.Message{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+	}
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// notificationSettingsCacheTTL bounds how long cached settings live, they are read for every
+// message delivered to the user
+const notificationSettingsCacheTTL = time.Hour
+
+// NotificationSettingsRepo is the repository for user notification preferences, cached in Redis
+type NotificationSettingsRepo struct {
+	db  *gorm.DB
+	rdb redis.UniversalClient
+}
+
+// NewNotificationSettingsRepo creates a new NotificationSettingsRepo
+func NewNotificationSettingsRepo(db *gorm.DB, rdb redis.UniversalClient) *NotificationSettingsRepo {
+	return &NotificationSettingsRepo{db: db, rdb: rdb}
+}
+
+// Get gets the settings of a user, nil if the user never changed them
+func (r *NotificationSettingsRepo) Get(ctx context.Context, userId string) (*entity.NotificationSettings, error) {
+	key := fmt.Sprintf(constant.RedisKeyNotifySettings(), userId)
+	if data, err := r.rdb.Get(ctx, key).Bytes(); err == nil {
+		if len(data) == 0 {
+			return nil, nil
+		}
+		var settings entity.NotificationSettings
+		if json.Unmarshal(data, &settings) == nil {
+			settings.UserId = userId
+			return &settings, nil
+		}
+	}
+
+	var settings entity.NotificationSettings
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).First(&settings).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Users without settings are cached too, as an empty value
+	var data []byte
+	result := &settings
+	if err != nil {
+		result = nil
+	} else if data, err = json.Marshal(result); err != nil {
+		return nil, err
+	}
+	r.rdb.Set(ctx, key, data, notificationSettingsCacheTTL)
+	return result, nil
+}
+
+// Upsert stores the settings of a user
+func (r *NotificationSettingsRepo) Upsert(ctx context.Context, settings *entity.NotificationSettings) error {
+	overrides, err := json.Marshal(settings.PlatformOverrides)
+	if err != nil {
+		return err
+	}
+	err = r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"global_mute":        settings.GlobalMute,
+			"sound":              settings.Sound,
+			"vibrate":            settings.Vibrate,
+			"show_preview":       settings.ShowPreview,
+			"platform_overrides": string(overrides),
+			"updated_at":         entity.NowUnixMilli(),
+		}),
+	}).Create(settings).Error
+	if err != nil {
+		return err
+	}
+	r.rdb.Del(ctx, fmt.Sprintf(constant.RedisKeyNotifySettings(), settings.UserId))
+	return nil
+}
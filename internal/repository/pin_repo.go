@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// PinRepo is the repository for the messages pinned in conversations
+type PinRepo struct {
+	db *gorm.DB
+}
+
+// NewPinRepo creates a new PinRepo
+func NewPinRepo(db *gorm.DB) *PinRepo {
+	return &PinRepo{db: db}
+}
+
+// Add pins a message. Returns false if it is already pinned.
+func (r *PinRepo) Add(ctx context.Context, pin *entity.PinnedMessage) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(pin)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Remove unpins a message. Returns false if it was not pinned.
+func (r *PinRepo) Remove(ctx context.Context, conversationId string, seq int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ?", conversationId, seq).
+		Delete(&entity.PinnedMessage{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Count counts the messages pinned in a conversation
+func (r *PinRepo) Count(ctx context.Context, conversationId string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.PinnedMessage{}).
+		Where("conversation_id = ?", conversationId).
+		Count(&count).Error
+	return count, err
+}
+
+// List gets the messages pinned in a conversation, newest pin first
+func (r *PinRepo) List(ctx context.Context, conversationId string) ([]*entity.PinnedMessage, error) {
+	var pins []*entity.PinnedMessage
+	err := r.db.WithContext(ctx).
+		Where("conversation_id = ?", conversationId).
+		Order("created_at DESC, seq DESC").
+		Find(&pins).Error
+	return pins, err
+}
+
+// GetSeqs gets the seqs pinned in conversations with one query, keyed by conversation, newest
+// pin first. Conversations without pins are missing from the result.
+func (r *PinRepo) GetSeqs(ctx context.Context, conversationIds []string) (map[string][]int64, error) {
+	result := make(map[string][]int64)
+	if len(conversationIds) == 0 {
+		return result, nil
+	}
+	var pins []*entity.PinnedMessage
+	err := r.db.WithContext(ctx).
+		Select("conversation_id, seq").
+		Where("conversation_id IN ?", conversationIds).
+		Order("created_at DESC, seq DESC").
+		Find(&pins).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, pin := range pins {
+		result[pin.ConversationId] = append(result[pin.ConversationId], pin.Seq)
+	}
+	return result, nil
+}
+
+// DeleteByMessage unpins a message within tx
+func (r *PinRepo) DeleteByMessage(ctx context.Context, tx *gorm.DB, conversationId string, seq int64) error {
+	return tx.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ?", conversationId, seq).
+		Delete(&entity.PinnedMessage{}).Error
+}
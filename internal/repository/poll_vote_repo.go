@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// PollVoteRepo is the repository for the votes of poll messages
+type PollVoteRepo struct {
+	db *gorm.DB
+}
+
+// NewPollVoteRepo creates a new PollVoteRepo
+func NewPollVoteRepo(db *gorm.DB) *PollVoteRepo {
+	return &PollVoteRepo{db: db}
+}
+
+// ReplaceVote replaces the vote of a user on a poll with options; no options retracts it
+func (r *PollVoteRepo) ReplaceVote(ctx context.Context, conversationId string, seq int64, userId string, options []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("conversation_id = ? AND seq = ? AND user_id = ?", conversationId, seq, userId).
+			Delete(&entity.PollVote{}).Error
+		if err != nil || len(options) == 0 {
+			return err
+		}
+		votes := make([]*entity.PollVote, 0, len(options))
+		for _, option := range options {
+			votes = append(votes, &entity.PollVote{
+				ConversationId: conversationId,
+				Seq:            seq,
+				UserId:         userId,
+				Option:         option,
+			})
+		}
+		return tx.Create(&votes).Error
+	})
+}
+
+// ListByPoll lists the votes of a poll, oldest first
+func (r *PollVoteRepo) ListByPoll(ctx context.Context, conversationId string, seq int64) ([]*entity.PollVote, error) {
+	var votes []*entity.PollVote
+	err := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ?", conversationId, seq).
+		Order("created_at ASC").
+		Find(&votes).Error
+	if err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+// DeleteByUser deletes all votes of a user
+func (r *PollVoteRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.PollVote{}).Error
+}
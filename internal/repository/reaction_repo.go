@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// ReactionRepo is the repository for the emoji reactions to messages
+type ReactionRepo struct {
+	db *gorm.DB
+}
+
+// NewReactionRepo creates a new ReactionRepo
+func NewReactionRepo(db *gorm.DB) *ReactionRepo {
+	return &ReactionRepo{db: db}
+}
+
+// Add stores a reaction. Returns false if the user already reacted with the emoji.
+func (r *ReactionRepo) Add(ctx context.Context, reaction *entity.MessageReaction) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Remove deletes a reaction. Returns false if the user did not react with the emoji.
+func (r *ReactionRepo) Remove(ctx context.Context, conversationId string, seq int64, userId, emoji string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ? AND user_id = ? AND emoji = ?", conversationId, seq, userId, emoji).
+		Delete(&entity.MessageReaction{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountByUser counts the emojis a user reacted with to a message
+func (r *ReactionRepo) CountByUser(ctx context.Context, conversationId string, seq int64, userId string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.MessageReaction{}).
+		Where("conversation_id = ? AND seq = ? AND user_id = ?", conversationId, seq, userId).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByEmoji counts the reactions with an emoji to a message
+func (r *ReactionRepo) CountByEmoji(ctx context.Context, conversationId string, seq int64, emoji string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.MessageReaction{}).
+		Where("conversation_id = ? AND seq = ? AND emoji = ?", conversationId, seq, emoji).
+		Count(&count).Error
+	return count, err
+}
+
+// GetSummaries aggregates the reactions to messages of a conversation with one grouped query,
+// keyed by seq. Emojis are in the order they were first reacted with; Reacted tells whether
+// userId reacted with them. Messages without reactions are missing from the result.
+func (r *ReactionRepo) GetSummaries(ctx context.Context, conversationId string, seqs []int64, userId string) (map[int64][]*entity.ReactionSummary, error) {
+	result := make(map[int64][]*entity.ReactionSummary)
+	if len(seqs) == 0 {
+		return result, nil
+	}
+	var rows []struct {
+		Seq     int64
+		Emoji   string
+		Count   int64
+		Reacted bool
+	}
+	err := r.db.WithContext(ctx).
+		Model(&entity.MessageReaction{}).
+		Select("seq, emoji, COUNT(*) AS count, MAX(user_id = ?) AS reacted", userId).
+		Where("conversation_id = ? AND seq IN ?", conversationId, seqs).
+		Group("seq, emoji").
+		Order("MIN(created_at) ASC, emoji ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.Seq] = append(result[row.Seq], &entity.ReactionSummary{
+			Emoji:   row.Emoji,
+			Count:   row.Count,
+			Reacted: row.Reacted,
+		})
+	}
+	return result, nil
+}
+
+// DeleteByMessage deletes all reactions to a message within tx
+func (r *ReactionRepo) DeleteByMessage(ctx context.Context, tx *gorm.DB, conversationId string, seq int64) error {
+	return tx.WithContext(ctx).
+		Where("conversation_id = ? AND seq = ?", conversationId, seq).
+		Delete(&entity.MessageReaction{}).Error
+}
+
+// DeleteByUser deletes all reactions of a user
+func (r *ReactionRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.MessageReaction{}).Error
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// ReminderRepo is the repository for message reminders
+type ReminderRepo struct {
+	db *gorm.DB
+}
+
+// NewReminderRepo creates a new ReminderRepo
+func NewReminderRepo(db *gorm.DB) *ReminderRepo {
+	return &ReminderRepo{db: db}
+}
+
+// Create creates a new reminder
+func (r *ReminderRepo) Create(ctx context.Context, reminder *entity.MessageReminder) error {
+	return r.db.WithContext(ctx).Create(reminder).Error
+}
+
+// GetById gets a reminder of a user by Id
+func (r *ReminderRepo) GetById(ctx context.Context, userId string, id int64) (*entity.MessageReminder, error) {
+	var reminder entity.MessageReminder
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).First(&reminder).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// CountPending counts the pending reminders of a user
+func (r *ReminderRepo) CountPending(ctx context.Context, userId string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.MessageReminder{}).
+		Where("user_id = ? AND status = ?", userId, constant.ReminderStatusPending).
+		Count(&count).Error
+	return count, err
+}
+
+// ListPending lists the pending reminders of a user, soonest first
+func (r *ReminderRepo) ListPending(ctx context.Context, userId string) ([]*entity.MessageReminder, error) {
+	var reminders []*entity.MessageReminder
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userId, constant.ReminderStatusPending).
+		Order("remind_at ASC, id ASC").
+		Find(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// ListDue lists pending reminders whose time has come, oldest first
+func (r *ReminderRepo) ListDue(ctx context.Context, now int64, limit int) ([]*entity.MessageReminder, error) {
+	var reminders []*entity.MessageReminder
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND remind_at <= ?", constant.ReminderStatusPending, now).
+		Order("remind_at ASC, id ASC").
+		Limit(limit).
+		Find(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// MarkSent marks a pending reminder as sent
+func (r *ReminderRepo) MarkSent(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&entity.MessageReminder{}).
+		Where("id = ? AND status = ?", id, constant.ReminderStatusPending).
+		Updates(map[string]interface{}{
+			"status":  constant.ReminderStatusSent,
+			"sent_at": entity.NowUnixMilli(),
+		}).Error
+}
+
+// Cancel cancels a pending reminder of a user. Returns false if it was not pending.
+func (r *ReminderRepo) Cancel(ctx context.Context, userId string, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.MessageReminder{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userId, constant.ReminderStatusPending).
+		Update("status", constant.ReminderStatusCancelled)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUser deletes all reminders of a user
+func (r *ReminderRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.MessageReminder{}).Error
+}
@@ -174,6 +174,11 @@ func (r *SeqRepo) SetSeqUserMaxSeq(ctx context.Context, tx *gorm.DB, userId, con
 		Update("max_seq", maxSeq).Error
 }
 
+// DeleteSeqUsersByUser deletes all per-conversation seq records of a user
+func (r *SeqRepo) DeleteSeqUsersByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.SeqUser{}).Error
+}
+
 // UpdateReadSeq updates the read_seq for a user in a conversation
 // Uses upsert to create record if it doesn't exist
 func (r *SeqRepo) UpdateReadSeq(ctx context.Context, userId, conversationId string, readSeq int64) error {
@@ -207,6 +212,79 @@ func (r *SeqRepo) UpdateReadSeq(ctx context.Context, userId, conversationId stri
 	return r.db.WithContext(ctx).Create(seqUser).Error
 }
 
+// UpdateDeliveredSeq raises the delivered_seq of a user in a conversation to deliveredSeq,
+// creating the record if it doesn't exist, and reports whether it advanced
+func (r *SeqRepo) UpdateDeliveredSeq(ctx context.Context, userId, conversationId string, deliveredSeq int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.SeqUser{}).
+		Where("user_id = ? AND conversation_id = ? AND delivered_seq < ?", userId, conversationId, deliveredSeq).
+		Update("delivered_seq", deliveredSeq)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// Nothing advanced: the record is missing or already delivered that far
+	seqUser := &entity.SeqUser{
+		UserId:         userId,
+		ConversationId: conversationId,
+		DeliveredSeq:   deliveredSeq,
+	}
+	result = r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(seqUser)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetReadSeqs gets the members of a conversation who have read up to minSeq or further,
+// with only user_id and read_seq set
+func (r *SeqRepo) GetReadSeqs(ctx context.Context, conversationId string, minSeq int64) ([]*entity.SeqUser, error) {
+	var seqUsers []*entity.SeqUser
+	err := r.db.WithContext(ctx).
+		Select("user_id", "read_seq").
+		Where("conversation_id = ? AND read_seq >= ?", conversationId, minSeq).
+		Find(&seqUsers).Error
+	if err != nil {
+		return nil, err
+	}
+	return seqUsers, nil
+}
+
+// CountReaders counts the readers of one message per conversation in a single query: the
+// members other than the sender whose read_seq reached the message seq. Conversations
+// without readers are missing from the result.
+func (r *SeqRepo) CountReaders(ctx context.Context, messages []*entity.Message) (map[string]int64, error) {
+	result := make(map[string]int64, len(messages))
+	query := r.db.WithContext(ctx).Model(&entity.SeqUser{})
+	condCount := 0
+	for _, msg := range messages {
+		if condCount == 0 {
+			query = query.Where("(conversation_id = ? AND read_seq >= ? AND user_id <> ?)", msg.ConversationId, msg.Seq, msg.SenderId)
+		} else {
+			query = query.Or("(conversation_id = ? AND read_seq >= ? AND user_id <> ?)", msg.ConversationId, msg.Seq, msg.SenderId)
+		}
+		condCount++
+	}
+	if condCount == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		ConversationId string
+		Readers        int64
+	}
+	if err := query.Select("conversation_id, COUNT(*) AS readers").Group("conversation_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ConversationId] = row.Readers
+	}
+	return result, nil
+}
+
 // GetConversationSeqInfo gets sequence info for a conversation
 func (r *SeqRepo) GetConversationSeqInfo(ctx context.Context, conversationId string) (*entity.SeqConversation, error) {
 	var seqConv entity.SeqConversation
@@ -220,6 +298,29 @@ func (r *SeqRepo) GetConversationSeqInfo(ctx context.Context, conversationId str
 	return &seqConv, nil
 }
 
+// RaiseMinSeq raises the conversation min_seq so messages below it are no longer pullable.
+// min_seq never moves backwards.
+func (r *SeqRepo) RaiseMinSeq(ctx context.Context, conversationId string, minSeq int64) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.SeqConversation{}).
+		Where("conversation_id = ? AND min_seq < ?", conversationId, minSeq).
+		Update("min_seq", minSeq).Error
+}
+
+// SetTTL sets the disappearing messages timer of a conversation, creating its
+// seq_conversations record when no message was sent yet
+func (r *SeqRepo) SetTTL(ctx context.Context, conversationId string, ttlSeconds int32) error {
+	seqConv := &entity.SeqConversation{
+		ConversationId: conversationId,
+		TTLSeconds:     ttlSeconds,
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "conversation_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"ttl_seconds"}),
+	}).Create(seqConv).Error
+}
+
 // EnsureSeqConversationExists ensures seq_conversations record exists
 func (r *SeqRepo) EnsureSeqConversationExists(ctx context.Context, tx *gorm.DB, conversationId string) error {
 	seqConv := &entity.SeqConversation{
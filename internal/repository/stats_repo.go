@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// statsTTL is how long daily counters are kept in Redis
+const statsTTL = 90 * 24 * time.Hour
+
+// setMaxScript sets KEYS[1] to ARGV[1] if it is greater than the current value
+var setMaxScript = redis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local val = tonumber(ARGV[1])
+if val > cur then
+	redis.call('SET', KEYS[1], val, 'PX', ARGV[2])
+	return val
+end
+return cur
+`)
+
+// StatsRepo is the repository for incrementally maintained operational statistics
+type StatsRepo struct {
+	rdb redis.UniversalClient
+}
+
+// NewStatsRepo creates a new StatsRepo
+func NewStatsRepo(rdb redis.UniversalClient) *StatsRepo {
+	return &StatsRepo{rdb: rdb}
+}
+
+// incrDaily increments a daily counter and refreshes its TTL
+func (r *StatsRepo) incrDaily(ctx context.Context, keyPattern, day string) error {
+	key := fmt.Sprintf(keyPattern, day)
+	pipe := r.rdb.Pipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, statsTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IncrMessages increments the daily message count
+func (r *StatsRepo) IncrMessages(ctx context.Context, day string) error {
+	return r.incrDaily(ctx, constant.RedisKeyStatsMessages(), day)
+}
+
+// IncrRegistrations increments the daily new registration count
+func (r *StatsRepo) IncrRegistrations(ctx context.Context, day string) error {
+	return r.incrDaily(ctx, constant.RedisKeyStatsRegister(), day)
+}
+
+// IncrGroups increments the daily created group count and the total group count
+func (r *StatsRepo) IncrGroups(ctx context.Context, day string) error {
+	if err := r.incrDaily(ctx, constant.RedisKeyStatsGroups(), day); err != nil {
+		return err
+	}
+	return r.rdb.Incr(ctx, constant.RedisKeyStatsGroupTotal()).Err()
+}
+
+// AddActiveUser marks a user as active for the day
+func (r *StatsRepo) AddActiveUser(ctx context.Context, day, userId string) error {
+	key := fmt.Sprintf(constant.RedisKeyStatsActive(), day)
+	pipe := r.rdb.Pipeline()
+	pipe.PFAdd(ctx, key, userId)
+	pipe.Expire(ctx, key, statsTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// UpdateOnlinePeak raises the daily online peak to count if it is higher
+func (r *StatsRepo) UpdateOnlinePeak(ctx context.Context, day string, count int64) error {
+	key := fmt.Sprintf(constant.RedisKeyStatsOnlinePeak(), day)
+	return setMaxScript.Run(ctx, r.rdb, []string{key}, count, statsTTL.Milliseconds()).Err()
+}
+
+// GetDailyStats reads the statistics of a day
+func (r *StatsRepo) GetDailyStats(ctx context.Context, day string) (*entity.DailyStats, error) {
+	stats := &entity.DailyStats{Date: day}
+
+	var err error
+	if stats.MessageCount, err = r.getInt(ctx, fmt.Sprintf(constant.RedisKeyStatsMessages(), day)); err != nil {
+		return nil, err
+	}
+	if stats.Registrations, err = r.getInt(ctx, fmt.Sprintf(constant.RedisKeyStatsRegister(), day)); err != nil {
+		return nil, err
+	}
+	if stats.GroupsCreated, err = r.getInt(ctx, fmt.Sprintf(constant.RedisKeyStatsGroups(), day)); err != nil {
+		return nil, err
+	}
+	if stats.OnlinePeak, err = r.getInt(ctx, fmt.Sprintf(constant.RedisKeyStatsOnlinePeak(), day)); err != nil {
+		return nil, err
+	}
+	if stats.ActiveUsers, err = r.rdb.PFCount(ctx, fmt.Sprintf(constant.RedisKeyStatsActive(), day)).Result(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetGroupTotal returns the total number of groups created since counting started
+func (r *StatsRepo) GetGroupTotal(ctx context.Context) (int64, error) {
+	return r.getInt(ctx, constant.RedisKeyStatsGroupTotal())
+}
+
+// Fields of the daily usage hashes of conversations and users
+const (
+	usageFieldMessages   = "messages"
+	usageFieldMediaBytes = "media_bytes"
+)
+
+// RecordUsage counts a message in the daily usage of its conversation and sender, of its group
+// when groupId is set, and in the daily rankings, keeping the day for ttl
+func (r *StatsRepo) RecordUsage(ctx context.Context, day, conversationId, groupId, senderId string, mediaBytes int64, ttl time.Duration) error {
+	convKey := fmt.Sprintf(constant.RedisKeyStatsConv(), day, conversationId)
+	convUsersKey := fmt.Sprintf(constant.RedisKeyStatsConvUsers(), day, conversationId)
+	userKey := fmt.Sprintf(constant.RedisKeyStatsUser(), day, senderId)
+	userConvsKey := fmt.Sprintf(constant.RedisKeyStatsUserConvs(), day, senderId)
+	topConvKey := fmt.Sprintf(constant.RedisKeyStatsTopConv(), day)
+	topUserKey := fmt.Sprintf(constant.RedisKeyStatsTopUser(), day)
+
+	pipe := r.rdb.Pipeline()
+	for _, key := range []string{convKey, userKey} {
+		pipe.HIncrBy(ctx, key, usageFieldMessages, 1)
+		if mediaBytes > 0 {
+			pipe.HIncrBy(ctx, key, usageFieldMediaBytes, mediaBytes)
+		}
+	}
+	pipe.PFAdd(ctx, convUsersKey, senderId)
+	pipe.PFAdd(ctx, userConvsKey, conversationId)
+	pipe.ZIncrBy(ctx, topConvKey, 1, conversationId)
+	pipe.ZIncrBy(ctx, topUserKey, 1, senderId)
+	for _, key := range []string{convKey, convUsersKey, userKey, userConvsKey, topConvKey, topUserKey} {
+		pipe.Expire(ctx, key, ttl)
+	}
+	if groupId != "" {
+		// Exact per member counts, for the top contributors of group reports
+		sendersKey := fmt.Sprintf(constant.RedisKeyStatsSenders(), day, groupId)
+		pipe.ZIncrBy(ctx, sendersKey, 1, senderId)
+		pipe.Expire(ctx, sendersKey, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Fields of the daily membership hashes of groups
+const (
+	groupFieldJoins  = "joins"
+	groupFieldLeaves = "leaves"
+)
+
+// RecordGroupMembership counts members joining and leaving a group on a day, keeping the day for ttl
+func (r *StatsRepo) RecordGroupMembership(ctx context.Context, day, groupId string, joins, leaves int64, ttl time.Duration) error {
+	key := fmt.Sprintf(constant.RedisKeyStatsGroup(), day, groupId)
+	pipe := r.rdb.Pipeline()
+	if joins > 0 {
+		pipe.HIncrBy(ctx, key, groupFieldJoins, joins)
+	}
+	if leaves > 0 {
+		pipe.HIncrBy(ctx, key, groupFieldLeaves, leaves)
+	}
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetGroupMembership reads the members who joined and left a group on a day
+func (r *StatsRepo) GetGroupMembership(ctx context.Context, day, groupId string) (joins, leaves int64, err error) {
+	return r.getCounts(ctx, fmt.Sprintf(constant.RedisKeyStatsGroup(), day, groupId), groupFieldJoins, groupFieldLeaves)
+}
+
+// GetGroupSenders returns the messages each member sent to a group on a day, most first
+func (r *StatsRepo) GetGroupSenders(ctx context.Context, day, groupId string) ([]*entity.UsageRank, error) {
+	return r.getTop(ctx, fmt.Sprintf(constant.RedisKeyStatsSenders(), day, groupId), 0)
+}
+
+// GetConversationUsage reads the usage of a conversation on a day
+func (r *StatsRepo) GetConversationUsage(ctx context.Context, day, conversationId string) (*entity.ConversationUsage, error) {
+	usage := &entity.ConversationUsage{Date: day}
+	var err error
+	if usage.MessageCount, usage.MediaBytes, err = r.getUsage(ctx, fmt.Sprintf(constant.RedisKeyStatsConv(), day, conversationId)); err != nil {
+		return nil, err
+	}
+	if usage.ActiveSenders, err = r.rdb.PFCount(ctx, fmt.Sprintf(constant.RedisKeyStatsConvUsers(), day, conversationId)).Result(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// GetUserUsage reads the usage of a user on a day
+func (r *StatsRepo) GetUserUsage(ctx context.Context, day, userId string) (*entity.UserUsage, error) {
+	usage := &entity.UserUsage{Date: day}
+	var err error
+	if usage.MessageCount, usage.MediaBytes, err = r.getUsage(ctx, fmt.Sprintf(constant.RedisKeyStatsUser(), day, userId)); err != nil {
+		return nil, err
+	}
+	if usage.Conversations, err = r.rdb.PFCount(ctx, fmt.Sprintf(constant.RedisKeyStatsUserConvs(), day, userId)).Result(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// GetTopConversations returns the conversations with the most messages on a day
+func (r *StatsRepo) GetTopConversations(ctx context.Context, day string, limit int) ([]*entity.UsageRank, error) {
+	return r.getTop(ctx, fmt.Sprintf(constant.RedisKeyStatsTopConv(), day), limit)
+}
+
+// GetTopUsers returns the users who sent the most messages on a day
+func (r *StatsRepo) GetTopUsers(ctx context.Context, day string, limit int) ([]*entity.UsageRank, error) {
+	return r.getTop(ctx, fmt.Sprintf(constant.RedisKeyStatsTopUser(), day), limit)
+}
+
+func (r *StatsRepo) getUsage(ctx context.Context, key string) (messages, mediaBytes int64, err error) {
+	return r.getCounts(ctx, key, usageFieldMessages, usageFieldMediaBytes)
+}
+
+// getCounts reads two integer fields of a hash, missing ones as 0
+func (r *StatsRepo) getCounts(ctx context.Context, key, field1, field2 string) (int64, int64, error) {
+	values, err := r.rdb.HMGet(ctx, key, field1, field2).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	parse := func(v interface{}) int64 {
+		s, _ := v.(string)
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	return parse(values[0]), parse(values[1]), nil
+}
+
+// getTop returns the first limit entries of a sorted set, highest score first; all of them when limit is 0
+func (r *StatsRepo) getTop(ctx context.Context, key string, limit int) ([]*entity.UsageRank, error) {
+	entries, err := r.rdb.ZRevRangeWithScores(ctx, key, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	ranks := make([]*entity.UsageRank, 0, len(entries))
+	for _, entry := range entries {
+		id, _ := entry.Member.(string)
+		ranks = append(ranks, &entity.UsageRank{Id: id, MessageCount: int64(entry.Score)})
+	}
+	return ranks, nil
+}
+
+func (r *StatsRepo) getInt(ctx context.Context, key string) (int64, error) {
+	v, err := r.rdb.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
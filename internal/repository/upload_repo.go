@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// UploadRepo is the repository for media uploads
+type UploadRepo struct {
+	db *gorm.DB
+}
+
+// NewUploadRepo creates a new UploadRepo
+func NewUploadRepo(db *gorm.DB) *UploadRepo {
+	return &UploadRepo{db: db}
+}
+
+// Create creates a pending upload
+func (r *UploadRepo) Create(ctx context.Context, upload *entity.Upload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+// GetById gets an upload, nil if there is none
+func (r *UploadRepo) GetById(ctx context.Context, id int64) (*entity.Upload, error) {
+	var upload entity.Upload
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// GetByKey gets the upload of an object, nil if there is none
+func (r *UploadRepo) GetByKey(ctx context.Context, objectKey string) (*entity.Upload, error) {
+	var upload entity.Upload
+	err := r.db.WithContext(ctx).Where("object_key = ?", objectKey).First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// SumSize returns the total size of the uploads a user presigned since a time, counting the
+// pending ones only when presigned since pendingSince, while they may still be uploaded
+func (r *UploadRepo) SumSize(ctx context.Context, userId string, since, pendingSince int64) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&entity.Upload{}).Select("COALESCE(SUM(size), 0)").
+		Where("user_id = ? AND created_at >= ? AND (status = ? OR (status = ? AND created_at >= ?))",
+			userId, since, constant.UploadStatusConfirmed, constant.UploadStatusPending, pendingSince).
+		Scan(&total).Error
+	return total, err
+}
+
+// Confirm marks an upload as confirmed with the size of the stored object and the status of
+// its media processing
+func (r *UploadRepo) Confirm(ctx context.Context, id, size int64, mediaStatus int) error {
+	return r.db.WithContext(ctx).Model(&entity.Upload{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": constant.UploadStatusConfirmed, "size": size, "media_status": mediaStatus}).Error
+}
+
+// Reject marks an upload as rejected by the malware scan
+func (r *UploadRepo) Reject(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&entity.Upload{}).Where("id = ?", id).
+		Update("status", constant.UploadStatusRejected).Error
+}
+
+// Abort marks the multipart upload of a pending upload as aborted, reporting whether it was
+// pending
+func (r *UploadRepo) Abort(ctx context.Context, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Upload{}).
+		Where("id = ? AND status = ?", id, constant.UploadStatusPending).
+		Update("status", constant.UploadStatusAborted)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetMedia records the outcome of processing the image or audio of an upload; size is the
+// size of the stored object, which changes when its metadata is stripped
+func (r *UploadRepo) SetMedia(ctx context.Context, upload *entity.Upload) error {
+	return r.db.WithContext(ctx).Model(&entity.Upload{}).Where("id = ?", upload.Id).
+		Updates(map[string]interface{}{
+			"media_status":  upload.MediaStatus,
+			"width":         upload.Width,
+			"height":        upload.Height,
+			"thumbnail_key": upload.ThumbnailKey,
+			"duration":      upload.Duration,
+			"size":          upload.Size,
+		}).Error
+}
+
+// AddRefs records that uploads were sent in a conversation; known references are ignored
+func (r *UploadRepo) AddRefs(ctx context.Context, uploadIds []int64, conversationId string) error {
+	refs := make([]*entity.UploadRef, len(uploadIds))
+	for i, id := range uploadIds {
+		refs[i] = &entity.UploadRef{UploadId: id, ConversationId: conversationId}
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&refs).Error
+}
+
+// GetRefConversations gets the conversations an upload was sent in
+func (r *UploadRepo) GetRefConversations(ctx context.Context, uploadId int64) ([]string, error) {
+	var conversationIds []string
+	err := r.db.WithContext(ctx).Model(&entity.UploadRef{}).Where("upload_id = ?", uploadId).
+		Pluck("conversation_id", &conversationIds).Error
+	return conversationIds, err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// UserDeletionRepo is the repository for user data deletion audit records
+type UserDeletionRepo struct {
+	db *gorm.DB
+}
+
+// NewUserDeletionRepo creates a new UserDeletionRepo
+func NewUserDeletionRepo(db *gorm.DB) *UserDeletionRepo {
+	return &UserDeletionRepo{db: db}
+}
+
+// Create creates a new deletion record
+func (r *UserDeletionRepo) Create(ctx context.Context, record *entity.UserDeletionRecord) error {
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+// Update updates a deletion record
+func (r *UserDeletionRepo) Update(ctx context.Context, id int64, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.UserDeletionRecord{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListByUser lists deletion records of a user, newest first
+func (r *UserDeletionRepo) ListByUser(ctx context.Context, userId string) ([]*entity.UserDeletionRecord, error) {
+	var records []*entity.UserDeletionRecord
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Order("id DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// UserIdentityRepo is the repository for external identity links
+type UserIdentityRepo struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepo creates a new UserIdentityRepo
+func NewUserIdentityRepo(db *gorm.DB) *UserIdentityRepo {
+	return &UserIdentityRepo{db: db}
+}
+
+// Create links an identity inside tx
+func (r *UserIdentityRepo) Create(ctx context.Context, tx *gorm.DB, identity *entity.UserIdentity) error {
+	return tx.WithContext(ctx).Create(identity).Error
+}
+
+// Get gets the identity of subject at provider, nil if it is not linked
+func (r *UserIdentityRepo) Get(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// DeleteByUser unlinks all identities of a user
+func (r *UserIdentityRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.UserIdentity{}).Error
+}
@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepo is the repository for user operations
@@ -25,6 +28,11 @@ func (r *UserRepo) Create(ctx context.Context, user *entity.User) error {
 	return r.db.WithContext(ctx).Create(user).Error
 }
 
+// CreateWithTx creates a new user with transaction
+func (r *UserRepo) CreateWithTx(ctx context.Context, tx *gorm.DB, user *entity.User) error {
+	return tx.WithContext(ctx).Create(user).Error
+}
+
 // GetById gets user by Id
 func (r *UserRepo) GetById(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
@@ -53,6 +61,17 @@ func (r *UserRepo) Update(ctx context.Context, id string, updates map[string]int
 	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// UpgradeGuest applies updates to a guest and turns it into a regular account.
+// It reports false if the user is not a guest, e.g. after a concurrent upgrade.
+func (r *UserRepo) UpgradeGuest(ctx context.Context, id string, updates map[string]interface{}) (bool, error) {
+	updates["guest_expires_at"] = 0
+	result := r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ? AND guest_expires_at > 0", id).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // Exists checks if user exists
 func (r *UserRepo) Exists(ctx context.Context, id string) (bool, error) {
 	var count int64
@@ -63,6 +82,43 @@ func (r *UserRepo) Exists(ctx context.Context, id string) (bool, error) {
 	return count > 0, nil
 }
 
+// ExistsByEmail checks if an email is already registered
+func (r *UserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.User{}).Where("email = ?", email).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ExistsByPhone checks if a phone number is already registered
+func (r *UserRepo) ExistsByPhone(ctx context.Context, phone string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.User{}).Where("phone = ?", phone).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ExistsByHandle checks if a handle is used by a user other than exceptId
+func (r *UserRepo) ExistsByHandle(ctx context.Context, handle, exceptId string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.User{}).Where("handle = ? AND id <> ?", handle, exceptId).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// MarkVerified records the time a user confirmed their email or phone
+func (r *UserRepo) MarkVerified(ctx context.Context, id string, verifiedAt int64) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ? AND verified_at = 0", id).
+		Update("verified_at", verifiedAt).Error
+}
+
 // GetByIdWithTx gets user by Id with transaction
 func (r *UserRepo) GetByIdWithTx(ctx context.Context, tx *gorm.DB, id string) (*entity.User, error) {
 	var user entity.User
@@ -72,3 +128,139 @@ func (r *UserRepo) GetByIdWithTx(ctx context.Context, tx *gorm.DB, id string) (*
 	}
 	return &user, nil
 }
+
+// Tombstone anonymizes a user's profile in place, keeping the row so the id stays reserved
+func (r *UserRepo) Tombstone(ctx context.Context, tx *gorm.DB, id string, deletedAt int64) error {
+	return tx.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"nickname":        "",
+		"handle":          nil,
+		"avatar":          "",
+		"gender":          0,
+		"birthday":        "",
+		"region":          "",
+		"ext":             nil,
+		"profile_privacy": nil,
+		"password":        "",
+		"extra":           nil,
+		"email":           nil,
+		"phone":           nil,
+		"deleted_at":      deletedAt,
+	}).Error
+}
+
+// HardDelete physically deletes a user
+func (r *UserRepo) HardDelete(ctx context.Context, tx *gorm.DB, id string) error {
+	return tx.WithContext(ctx).Where("id = ?", id).Delete(&entity.User{}).Error
+}
+
+// Search searches users by id prefix or nickname keyword, matched literally, newest first.
+// An empty keyword lists all users. Returns the page and the total match count.
+func (r *UserRepo) Search(ctx context.Context, keyword string, offset, limit int) ([]*entity.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.User{})
+	if keyword != "" {
+		pattern := escapeLike(keyword)
+		query = query.Where("id LIKE ? OR nickname LIKE ?", pattern+"%", "%"+pattern+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []*entity.User
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SearchDiscoverable finds the active users whose handle or nickname starts with prefix, leaving
+// out deleted, banned and guest users and those who opted out of search. handle is compared
+// to the lowercase handles; handleOnly skips nicknames. Exact matches rank first, then handle
+// prefixes, then nickname prefixes, shorter names first.
+func (r *UserRepo) SearchDiscoverable(ctx context.Context, prefix, handle string, limit int, handleOnly bool) ([]*entity.User, error) {
+	pattern := escapeLike(prefix) + "%"
+	handlePattern := escapeLike(handle) + "%"
+	rank := clause.Expr{
+		SQL: "CASE WHEN handle = ? THEN 0 WHEN nickname = ? THEN 1 WHEN handle LIKE ? THEN 2 ELSE 3 END, " +
+			"CHAR_LENGTH(nickname), created_at",
+		Vars:               []interface{}{handle, prefix, handlePattern},
+		WithoutParentheses: true,
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("deleted_at = 0 AND status = ? AND guest_expires_at = 0 AND hide_from_search = ?", constant.UserStatusNormal, false)
+	if handleOnly {
+		query = query.Where("handle LIKE ?", handlePattern)
+	} else {
+		query = query.Where("(handle LIKE ? OR nickname LIKE ?)", handlePattern, pattern)
+	}
+
+	var users []*entity.User
+	err := query.Clauses(clause.OrderBy{Expression: rank}).Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// escapeLike escapes the LIKE wildcards of s so it matches literally
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// ListAfter lists the users that are not deleted after afterId, in id order
+func (r *UserRepo) ListAfter(ctx context.Context, afterId string, limit int) ([]*entity.User, error) {
+	var users []*entity.User
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND deleted_at = 0", afterId).
+		Order("id ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CountCohort counts the active users selected by a broadcast cohort
+func (r *UserRepo) CountCohort(ctx context.Context, cohort *entity.BroadcastCohort) (int64, error) {
+	var count int64
+	err := r.cohortQuery(ctx, cohort).Count(&count).Error
+	return count, err
+}
+
+// ListCohortIds lists the ids of active users selected by a broadcast cohort after afterId, in id order
+func (r *UserRepo) ListCohortIds(ctx context.Context, cohort *entity.BroadcastCohort, afterId string, limit int) ([]string, error) {
+	var ids []string
+	err := r.cohortQuery(ctx, cohort).
+		Where("id > ?", afterId).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *UserRepo) cohortQuery(ctx context.Context, cohort *entity.BroadcastCohort) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("deleted_at = 0 AND status = ?", constant.UserStatusNormal)
+	if len(cohort.UserIds) > 0 {
+		query = query.Where("id IN ?", cohort.UserIds)
+	}
+	if cohort.RegisteredAfter > 0 {
+		query = query.Where("created_at >= ?", cohort.RegisteredAfter)
+	}
+	if cohort.RegisteredBefore > 0 {
+		query = query.Where("created_at < ?", cohort.RegisteredBefore)
+	}
+	if cohort.Tag != "" {
+		query = query.Where("id IN (?)", r.db.Model(&entity.UserTag{}).Select("user_id").Where("tag = ?", cohort.Tag))
+	}
+	return query
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"plain":      "plain",
+		"100%":       `100\%`,
+		"user_1":     `user\_1`,
+		`back\slash`: `back\\slash`,
+	}
+	for in, expected := range cases {
+		if got := escapeLike(in); got != expected {
+			t.Fatalf("escapeLike(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
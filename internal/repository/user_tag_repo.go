@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// UserTagRepo is the repository for the tags of users
+type UserTagRepo struct {
+	db *gorm.DB
+}
+
+// NewUserTagRepo creates a new UserTagRepo
+func NewUserTagRepo(db *gorm.DB) *UserTagRepo {
+	return &UserTagRepo{db: db}
+}
+
+// SetTags replaces the tags of a user with tags
+func (r *UserTagRepo) SetTags(ctx context.Context, userId string, tags []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&entity.UserTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		rows := make([]*entity.UserTag, len(tags))
+		for i, tag := range tags {
+			rows[i] = &entity.UserTag{UserId: userId, Tag: tag}
+		}
+		return tx.Create(rows).Error
+	})
+}
+
+// GetTags returns the tags of a user in tag order
+func (r *UserTagRepo) GetTags(ctx context.Context, userId string) ([]string, error) {
+	tags := make([]string, 0)
+	err := r.db.WithContext(ctx).
+		Model(&entity.UserTag{}).
+		Where("user_id = ?", userId).
+		Order("tag ASC").
+		Pluck("tag", &tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// DeleteByUser deletes all tags of a user within tx
+func (r *UserTagRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.UserTag{}).Error
+}
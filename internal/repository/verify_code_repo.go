@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// checkVerifyCodeScript compares ARGV[1] with the pending code hash at KEYS[1].
+// A match consumes the code; the code is also dropped after ARGV[2] wrong attempts.
+// Returns 1 on match, 0 otherwise.
+var checkVerifyCodeScript = redis.NewScript(`
+local code = redis.call('HGET', KEYS[1], 'code')
+if not code then
+	return 0
+end
+if code == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// VerifyCodeRepo is the repository for pending registration verification codes
+type VerifyCodeRepo struct {
+	rdb redis.UniversalClient
+}
+
+// NewVerifyCodeRepo creates a new VerifyCodeRepo
+func NewVerifyCodeRepo(rdb redis.UniversalClient) *VerifyCodeRepo {
+	return &VerifyCodeRepo{rdb: rdb}
+}
+
+// AcquireSendSlot reports whether a code may be sent to the user now; it then blocks
+// further sends for interval
+func (r *VerifyCodeRepo) AcquireSendSlot(ctx context.Context, userId string, interval time.Duration) (bool, error) {
+	key := fmt.Sprintf(constant.RedisKeyVerifyResend(), userId)
+	return r.rdb.SetNX(ctx, key, 1, interval).Result()
+}
+
+// ReleaseSendSlot lifts the send throttle, used when sending failed
+func (r *VerifyCodeRepo) ReleaseSendSlot(ctx context.Context, userId string) error {
+	key := fmt.Sprintf(constant.RedisKeyVerifyResend(), userId)
+	return r.rdb.Del(ctx, key).Err()
+}
+
+// Save stores the code hash for the user, replacing any pending code
+func (r *VerifyCodeRepo) Save(ctx context.Context, userId, codeHash string, ttl time.Duration) error {
+	key := fmt.Sprintf(constant.RedisKeyVerifyCode(), userId)
+	pipe := r.rdb.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "code", codeHash, "attempts", 0)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Check consumes the pending code if codeHash matches it
+func (r *VerifyCodeRepo) Check(ctx context.Context, userId, codeHash string, maxAttempts int) (bool, error) {
+	key := fmt.Sprintf(constant.RedisKeyVerifyCode(), userId)
+	res, err := checkVerifyCodeScript.Run(ctx, r.rdb, []string{key}, codeHash, maxAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// VoIPTokenRepo is the repository for VoIP push tokens
+type VoIPTokenRepo struct {
+	db *gorm.DB
+}
+
+// NewVoIPTokenRepo creates a new VoIPTokenRepo
+func NewVoIPTokenRepo(db *gorm.DB) *VoIPTokenRepo {
+	return &VoIPTokenRepo{db: db}
+}
+
+// Upsert stores the token of a user on a platform, replacing the previous one. A token
+// registered by another user before, after switching accounts on a device, is removed.
+func (r *VoIPTokenRepo) Upsert(ctx context.Context, token *entity.VoIPToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("provider = ? AND token = ? AND user_id <> ?", token.Provider, token.Token, token.UserId).
+			Delete(&entity.VoIPToken{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "platform_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"provider":   token.Provider,
+				"token":      token.Token,
+				"updated_at": entity.NowUnixMilli(),
+			}),
+		}).Create(token).Error
+	})
+}
+
+// GetByUsers gets the tokens of users
+func (r *VoIPTokenRepo) GetByUsers(ctx context.Context, userIds []string) ([]*entity.VoIPToken, error) {
+	var tokens []*entity.VoIPToken
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIds).Find(&tokens).Error
+	return tokens, err
+}
+
+// Delete removes the token of a user on a platform
+func (r *VoIPTokenRepo) Delete(ctx context.Context, userId string, platformId int) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND platform_id = ?", userId, platformId).
+		Delete(&entity.VoIPToken{}).Error
+}
+
+// DeleteToken removes a token the push provider reported as no longer valid
+func (r *VoIPTokenRepo) DeleteToken(ctx context.Context, provider, token string) error {
+	return r.db.WithContext(ctx).Where("provider = ? AND token = ?", provider, token).
+		Delete(&entity.VoIPToken{}).Error
+}
+
+// DeleteByUser removes all tokens of a user
+func (r *VoIPTokenRepo) DeleteByUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userId).Delete(&entity.VoIPToken{}).Error
+}
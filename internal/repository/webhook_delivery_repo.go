@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+// WebhookDeliveryRepo is the repository for the outgoing webhook delivery log
+type WebhookDeliveryRepo struct {
+	db *gorm.DB
+}
+
+// NewWebhookDeliveryRepo creates a new WebhookDeliveryRepo
+func NewWebhookDeliveryRepo(db *gorm.DB) *WebhookDeliveryRepo {
+	return &WebhookDeliveryRepo{db: db}
+}
+
+// WebhookDeliveryFilter filters webhook deliveries; zero values are ignored
+type WebhookDeliveryFilter struct {
+	Endpoint  string
+	EventType string
+	EventId   string
+	Result    string
+	StartTime int64 // created_at >= StartTime (ms)
+	EndTime   int64 // created_at < EndTime (ms)
+}
+
+// Create inserts a delivery record
+func (r *WebhookDeliveryRepo) Create(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// List lists webhook deliveries matching filter, newest first
+func (r *WebhookDeliveryRepo) List(ctx context.Context, filter *WebhookDeliveryFilter, offset, limit int) ([]*entity.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx).Model(&entity.WebhookDelivery{})
+	if filter.Endpoint != "" {
+		query = query.Where("endpoint = ?", filter.Endpoint)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.EventId != "" {
+		query = query.Where("event_id = ?", filter.EventId)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	if filter.StartTime > 0 {
+		query = query.Where("created_at >= ?", filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		query = query.Where("created_at < ?", filter.EndTime)
+	}
+
+	var deliveries []*entity.WebhookDelivery
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// NewHTTPSRedirectServer creates a plain HTTP server on port that redirects every request to HTTPS on httpsPort
+func NewHTTPSRedirectServer(port, httpsPort int) *server.Hertz {
+	h := server.New(
+		server.WithHostPorts(fmt.Sprintf(":%d", port)),
+		server.WithDisablePrintRoute(true),
+	)
+	h.NoRoute(func(ctx context.Context, c *app.RequestContext) {
+		// 308 keeps the method and body of non-GET requests
+		status := consts.StatusPermanentRedirect
+		if method := string(c.Method()); method == consts.MethodGet || method == consts.MethodHead {
+			status = consts.StatusMovedPermanently
+		}
+		c.Redirect(status, []byte(httpsURL(string(c.Host()), string(c.Request.RequestURI()), httpsPort)))
+	})
+	return h
+}
+
+// httpsURL rewrites a request host and URI to the HTTPS listener
+func httpsURL(host, requestURI string, httpsPort int) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if httpsPort != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+	}
+	return "https://" + host + requestURI
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"context"
 	"net/http"
+	"net/http/pprof"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
@@ -12,69 +13,208 @@ import (
 	"github.com/ZaiSpace/nexo_im/internal/gateway"
 	"github.com/ZaiSpace/nexo_im/internal/handler"
 	"github.com/ZaiSpace/nexo_im/internal/middleware"
+	"github.com/ZaiSpace/nexo_im/pkg/ratelimit"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
 )
 
 // SetupRouter sets up all routes
-func SetupRouter(h *server.Hertz, handlers *Handlers, wsServer *gateway.WsServer) {
+// apiKeys authenticates bot users calling the user routes with X-API-Key.
+func SetupRouter(h *server.Hertz, handlers *Handlers, wsServer *gateway.WsServer, limiter *ratelimit.Limiter, apiKeys middleware.APIKeyAuthenticator) {
 	// Global middlewares
 	h.Use(middleware.TraceID())
+	h.Use(middleware.HSTS())
 	h.Use(middleware.CORS())
 	h.Use(middleware.Logger())
+	h.Use(middleware.Metrics())
+	h.Use(middleware.IPRateLimit(limiter))
+	h.Use(middleware.Timeout())
+
+	// Prometheus metrics, only registered when enabled
+	if handlers.Metrics != nil {
+		h.GET("/metrics", middleware.InternalIPAccess(), adaptor.HertzHandler(handlers.Metrics))
+	}
+
+	// Diagnostic routes, only registered when debug is enabled
+	if handlers.Debug != nil {
+		setupDebugRoutes(h, handlers.Debug)
+	}
 
 	root := h.Group("/im")
-	// Health check
-	root.GET("/health", func(ctx context.Context, c *app.RequestContext) {
-		c.JSON(consts.StatusOK, map[string]string{"status": "ok"})
-	})
+	// Probes; /health is kept as an alias of /livez for existing checks
+	root.GET("/health", handlers.Health.Livez)
+	root.GET("/livez", handlers.Health.Livez)
+	root.GET("/readyz", handlers.Health.Readyz)
 
 	// Auth routes (no auth required)
 	authGroup := root.Group("/auth")
 	{
 		authGroup.POST("/register", handlers.Auth.Register)
 		authGroup.POST("/login", handlers.Auth.Login)
+		authGroup.POST("/refresh", handlers.Auth.RefreshToken)
+		authGroup.POST("/oauth/callback", handlers.Auth.OAuthCallback)
+		authGroup.POST("/verify", handlers.Auth.Verify)
+		authGroup.POST("/verify/resend", handlers.Auth.ResendVerifyCode)
+		authGroup.POST("/guest", handlers.Auth.CreateGuest)
+		// Guest tokens cannot write the user group, so the upgrade only needs a JWT
+		authGroup.POST("/guest/upgrade", middleware.JWTAuth(), handlers.Auth.UpgradeGuest)
 	}
 
-	// User routes (JWT auth required)
-	userGroup := root.Group("/user", middleware.JWTAuth())
+	// User routes (JWT or bot API key required)
+	userGroup := root.Group("/user", middleware.UserAuth(apiKeys, scope.User), middleware.UserRateLimit(limiter))
 	{
 		userGroup.GET("/info", handlers.User.GetUserInfo)
 		userGroup.GET("/profile/:user_id", handlers.User.GetUserInfoById)
 		userGroup.PUT("/update", handlers.User.UpdateUserInfo)
 		userGroup.POST("/batch_info", handlers.User.GetUsersInfo)
+		userGroup.GET("/search", handlers.User.SearchUsers)
 		userGroup.POST("/get_users_online_status", handlers.User.GetUsersOnlineStatus)
+		userGroup.GET("/session/list", handlers.Auth.ListSessions)
+		userGroup.POST("/session/rename", handlers.Auth.RenameSession)
+		userGroup.POST("/session/revoke", handlers.Auth.RevokeSession)
+		userGroup.POST("/token/scoped", handlers.Auth.IssueScopedToken)
+		userGroup.POST("/delete_account", handlers.DataDeletion.DeleteAccount)
+		userGroup.GET("/notification_settings", handlers.Notification.GetSettings)
+		userGroup.PUT("/notification_settings", handlers.Notification.UpdateSettings)
 	}
 
-	// Group routes (JWT auth required)
-	groupGroup := root.Group("/group", middleware.JWTAuth())
+	// Group routes (JWT or bot API key required)
+	groupGroup := root.Group("/group", middleware.UserAuth(apiKeys, scope.Group), middleware.UserRateLimit(limiter))
 	{
 		groupGroup.POST("/create", handlers.Group.CreateGroup)
 		groupGroup.POST("/join", handlers.Group.JoinGroup)
 		groupGroup.POST("/quit", handlers.Group.QuitGroup)
 		groupGroup.GET("/info", handlers.Group.GetGroupInfo)
 		groupGroup.GET("/members", handlers.Group.GetGroupMembers)
+		groupGroup.GET("/report", handlers.Group.GetGroupReport)
+		groupGroup.POST("/kick", handlers.Group.KickMembers)
+		groupGroup.POST("/mute_member", handlers.Group.MuteMember)
+		groupGroup.POST("/transfer", handlers.Group.TransferOwnership)
+		groupGroup.POST("/admin/promote", handlers.Group.PromoteAdmin)
+		groupGroup.POST("/admin/demote", handlers.Group.DemoteAdmin)
+		groupGroup.POST("/dismiss", handlers.Group.DismissGroup)
+		groupGroup.POST("/update", handlers.Group.UpdateGroupInfo)
+		groupGroup.POST("/announcement", handlers.Group.SetAnnouncement)
+		groupGroup.POST("/invite_link/create", handlers.Group.CreateInviteLink)
+		groupGroup.POST("/invite_link/revoke", handlers.Group.RevokeInviteLink)
+		groupGroup.POST("/join_by_link", handlers.Group.JoinByInviteLink)
 	}
 
-	// Message routes (JWT auth required)
-	msgGroup := root.Group("/msg", middleware.JWTAuth())
+	// Message routes (JWT or bot API key required)
+	msgGroup := root.Group("/msg", middleware.UserAuth(apiKeys, scope.Msg), middleware.UserRateLimit(limiter))
 	{
 		msgGroup.POST("/send", handlers.Message.SendMessage)
 		msgGroup.POST("/send_without_mark_read", handlers.Message.SendMessageWithoutMarkRead)
+		msgGroup.POST("/batch_send", handlers.Message.BatchSendMessage)
+		msgGroup.POST("/forward", handlers.Message.ForwardMessages)
 		msgGroup.GET("/pull", handlers.Message.PullMessages)
 		msgGroup.GET("/max_seq", handlers.Message.GetMaxSeq)
+		msgGroup.GET("/poll", handlers.Message.PollMessages)
+		msgGroup.GET("/export", handlers.Message.ExportMessages)
+		msgGroup.POST("/revoke", handlers.Message.RevokeMessage)
+		msgGroup.POST("/delete", handlers.Message.DeleteMessage)
+		msgGroup.POST("/edit", handlers.Message.EditMessage)
+		msgGroup.GET("/thread", handlers.Message.ListThread)
+		msgGroup.GET("/edit/history", handlers.Message.GetEditHistory)
+		msgGroup.GET("/read_status", handlers.Message.GetReadStatus)
+		msgGroup.GET("/group_read_members", handlers.Message.GetGroupReadMembers)
+		msgGroup.POST("/reminder/create", handlers.Reminder.CreateReminder)
+		msgGroup.GET("/reminder/list", handlers.Reminder.ListReminders)
+		msgGroup.POST("/reminder/cancel", handlers.Reminder.CancelReminder)
+		msgGroup.POST("/favorite/add", handlers.Favorite.AddFavorite)
+		msgGroup.GET("/favorite/list", handlers.Favorite.ListFavorites)
+		msgGroup.POST("/favorite/remove", handlers.Favorite.RemoveFavorite)
+		msgGroup.POST("/poll/vote", handlers.Poll.Vote)
+		msgGroup.GET("/poll/result", handlers.Poll.GetResult)
+		msgGroup.POST("/reaction/add", handlers.Reaction.AddReaction)
+		msgGroup.POST("/reaction/remove", handlers.Reaction.RemoveReaction)
 	}
 
-	// Conversation routes (JWT auth required)
-	convGroup := root.Group("/conversation", middleware.JWTAuth())
+	// Conversation routes (JWT or bot API key required)
+	convGroup := root.Group("/conversation", middleware.UserAuth(apiKeys, scope.Conversation), middleware.UserRateLimit(limiter))
 	{
 		convGroup.GET("/list", handlers.Conversation.GetConversationList)
 		convGroup.POST("/list", handlers.Conversation.GetConversationList)
 		convGroup.GET("/all", handlers.Conversation.GetAllConversationList)
 		convGroup.POST("/all", handlers.Conversation.GetAllConversationList)
 		convGroup.GET("/info", handlers.Conversation.GetConversation)
+		convGroup.GET("/saved", handlers.Conversation.GetSavedConversation)
 		convGroup.PUT("/update", handlers.Conversation.UpdateConversation)
 		convGroup.POST("/mark_read", handlers.Conversation.MarkRead)
 		convGroup.GET("/max_read_seq", handlers.Conversation.GetMaxReadSeq)
 		convGroup.GET("/unread_count", handlers.Conversation.GetUnreadCount)
+		convGroup.POST("/pin_msg", handlers.Pin.PinMessage)
+		convGroup.POST("/unpin_msg", handlers.Pin.UnpinMessage)
+		convGroup.GET("/pinned_msgs", handlers.Pin.ListPinnedMessages)
+	}
+
+	// GraphQL read models (JWT or bot API key required, scopes checked per field)
+	if handlers.GraphQL != nil {
+		root.POST("/graphql", middleware.UserAuth(apiKeys, ""), middleware.UserRateLimit(limiter), handlers.GraphQL.Query)
+	}
+
+	// Call signaling (JWT or bot API key required)
+	if handlers.Call != nil {
+		callGroup := root.Group("/call", middleware.UserAuth(apiKeys, scope.Msg), middleware.UserRateLimit(limiter))
+		callGroup.POST("/signal", handlers.Call.Signal)
+		callGroup.POST("/voip_token/register", handlers.Call.RegisterVoIPToken)
+		callGroup.POST("/voip_token/unregister", handlers.Call.UnregisterVoIPToken)
+	}
+
+	// End-to-end encryption key bundles (JWT or bot API key required)
+	if handlers.E2EE != nil {
+		e2eeGroup := root.Group("/e2ee/keys", middleware.UserAuth(apiKeys, scope.User), middleware.UserRateLimit(limiter))
+		e2eeGroup.POST("/upload", handlers.E2EE.UploadKeys)
+		e2eeGroup.POST("/bundle", handlers.E2EE.GetBundles)
+		e2eeGroup.GET("/count", handlers.E2EE.GetPrekeyCount)
+		e2eeGroup.POST("/reset", handlers.E2EE.ResetKeys)
+	}
+
+	// Media uploads to object storage (JWT or bot API key required)
+	if handlers.Storage != nil {
+		storageGroup := root.Group("/storage", middleware.UserAuth(apiKeys, scope.Msg), middleware.UserRateLimit(limiter))
+		storageGroup.POST("/presign_upload", handlers.Storage.PresignUpload)
+		storageGroup.POST("/confirm", handlers.Storage.ConfirmUpload)
+		storageGroup.POST("/multipart/init", handlers.Storage.InitMultipartUpload)
+		storageGroup.POST("/multipart/complete", handlers.Storage.CompleteMultipartUpload)
+		storageGroup.POST("/multipart/abort", handlers.Storage.AbortMultipartUpload)
+		storageGroup.GET("/quota", handlers.Storage.GetQuota)
+		storageGroup.GET("/download/:file_id", handlers.Storage.Download)
+		// Object presign API; same handler as /storage/presign_upload
+		root.POST("/object/presign", middleware.UserAuth(apiKeys, scope.Msg), middleware.UserRateLimit(limiter), handlers.Storage.PresignUpload)
+	}
+
+	// Bot metadata (JWT or bot API key required)
+	if handlers.Bot != nil {
+		root.GET("/bot/commands", middleware.UserAuth(apiKeys, scope.User), middleware.UserRateLimit(limiter), handlers.Bot.GetCommands)
+	}
+
+	// External chat platform webhooks (authenticated by the connector secrets)
+	if handlers.ChatBridge != nil {
+		root.POST("/bridge/:connector", handlers.ChatBridge.Receive)
+	}
+
+	// Admin routes (admin API key required)
+	adminGroup := root.Group("/admin", middleware.AdminIPAccess(), middleware.AdminAuth())
+	{
+		adminGroup.GET("/user/search", handlers.Admin.SearchUsers)
+		adminGroup.POST("/user/ban", handlers.Admin.BanUser)
+		adminGroup.POST("/user/unban", handlers.Admin.UnbanUser)
+		adminGroup.POST("/user/force_logout", handlers.Admin.ForceLogout)
+		adminGroup.GET("/user/conversations", handlers.Admin.GetUserConversations)
+		adminGroup.POST("/msg/delete", handlers.Admin.DeleteMessages)
+		adminGroup.POST("/msg/search", handlers.Admin.SearchMessages)
+		adminGroup.GET("/audit/logs", handlers.Admin.ListAuditLogs)
+		adminGroup.GET("/audit/events", handlers.Audit.ListEvents)
+		adminGroup.GET("/webhook/deliveries", handlers.Webhook.ListDeliveries)
+		adminGroup.POST("/broadcast/create", handlers.Broadcast.CreateBroadcast)
+		adminGroup.GET("/broadcast/list", handlers.Broadcast.ListBroadcasts)
+		adminGroup.GET("/broadcast/info", handlers.Broadcast.GetBroadcast)
+		adminGroup.POST("/broadcast/cancel", handlers.Broadcast.CancelBroadcast)
+		adminGroup.POST("/bot/create", handlers.APIKey.CreateBot)
+		adminGroup.POST("/apikey/create", handlers.APIKey.CreateKey)
+		adminGroup.GET("/apikey/list", handlers.APIKey.ListKeys)
+		adminGroup.POST("/apikey/rotate", handlers.APIKey.RotateKey)
+		adminGroup.POST("/apikey/revoke", handlers.APIKey.RevokeKey)
 	}
 
 	// WebSocket route using net/http handler via Hertz adaptor
@@ -82,17 +222,42 @@ func SetupRouter(h *server.Hertz, handlers *Handlers, wsServer *gateway.WsServer
 		wsServer.HandleConnection(r.Context(), w, r)
 	})))
 
+	// Server-Sent Events fallback for clients that cannot open a WebSocket
+	root.GET("/events", adaptor.HertzHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wsServer.HandleSSE(r.Context(), w, r)
+	})))
+
 	// Internal service routes (service-to-service auth required)
-	internalGroup := root.Group("/internal", middleware.InternalAuth())
+	internalGroup := root.Group("/internal", middleware.InternalIPAccess(), middleware.InternalAuth())
 	{
 		internalGroup.GET("/health", func(ctx context.Context, c *app.RequestContext) {
 			c.JSON(consts.StatusOK, map[string]string{"status": "ok"})
 		})
-		internalGroup.POST("/auth/register", handlers.Auth.Register)
+		internalGroup.POST("/auth/register", handlers.Auth.InternalRegister)
+		internalGroup.GET("/stats/daily", handlers.Stats.GetDailyStats)
+		if handlers.UsageStats != nil {
+			internalGroup.GET("/stats/conversation", handlers.UsageStats.GetConversationUsage)
+			internalGroup.GET("/stats/user", handlers.UsageStats.GetUserUsage)
+			internalGroup.GET("/stats/top", handlers.UsageStats.GetTopUsage)
+		}
+		if handlers.Agent != nil {
+			internalGroup.POST("/agent/reply", handlers.Agent.Reply)
+		}
+		internalGroup.POST("/msg/broadcast", handlers.Broadcast.InternalBroadcast)
+		internalGroup.GET("/msg/broadcast/info", handlers.Broadcast.GetBroadcast)
+		internalGroup.POST("/user_tags/set", handlers.Broadcast.SetUserTags)
+		internalGroup.GET("/user_tags/get", handlers.Broadcast.GetUserTags)
+	}
+
+	// Internal data deletion routes (service-to-service auth required)
+	internalAdminGroup := root.Group("/internal/admin", middleware.InternalIPAccess(), middleware.InternalAuth())
+	{
+		internalAdminGroup.POST("/user/purge", handlers.DataDeletion.PurgeUser)
+		internalAdminGroup.GET("/user/deletion_records", handlers.DataDeletion.ListDeletionRecords)
 	}
 
 	// Internal user routes (service-to-service auth + acting user required)
-	internalUserGroup := root.Group("/internal/user", middleware.InternalAuthAsUser())
+	internalUserGroup := root.Group("/internal/user", middleware.InternalIPAccess(), middleware.InternalAuthAsUser(), middleware.UserRateLimit(limiter))
 	{
 		internalUserGroup.GET("/info", handlers.User.GetUserInfo)
 		internalUserGroup.GET("/profile/:user_id", handlers.User.GetUserInfoById)
@@ -102,14 +267,38 @@ func SetupRouter(h *server.Hertz, handlers *Handlers, wsServer *gateway.WsServer
 	}
 
 	// Internal message routes (service-to-service auth + acting user required)
-	internalMsgGroup := root.Group("/internal/msg", middleware.InternalAuthAsUser())
+	internalMsgGroup := root.Group("/internal/msg", middleware.InternalIPAccess(), middleware.InternalAuthAsUser(), middleware.UserRateLimit(limiter))
 	{
 		internalMsgGroup.POST("/send", handlers.Message.SendMessage)
 		internalMsgGroup.POST("/send_without_mark_read", handlers.Message.SendMessageWithoutMarkRead)
+		internalMsgGroup.POST("/batch_send", handlers.Message.BatchSendMessage)
+		internalMsgGroup.GET("/export", handlers.Message.ExportMessages)
+	}
+
+	// Internal group routes (service-to-service auth + acting user required)
+	internalGroupGroup := root.Group("/internal/group", middleware.InternalIPAccess(), middleware.InternalAuthAsUser(), middleware.UserRateLimit(limiter))
+	{
+		internalGroupGroup.POST("/create", handlers.Group.CreateGroup)
+		internalGroupGroup.POST("/join", handlers.Group.JoinGroup)
+		internalGroupGroup.POST("/quit", handlers.Group.QuitGroup)
+		internalGroupGroup.GET("/info", handlers.Group.GetGroupInfo)
+		internalGroupGroup.GET("/members", handlers.Group.GetGroupMembers)
+		internalGroupGroup.GET("/report", handlers.Group.GetGroupReport)
+		internalGroupGroup.POST("/kick", handlers.Group.KickMembers)
+		internalGroupGroup.POST("/mute_member", handlers.Group.MuteMember)
+		internalGroupGroup.POST("/transfer", handlers.Group.TransferOwnership)
+		internalGroupGroup.POST("/admin/promote", handlers.Group.PromoteAdmin)
+		internalGroupGroup.POST("/admin/demote", handlers.Group.DemoteAdmin)
+		internalGroupGroup.POST("/dismiss", handlers.Group.DismissGroup)
+		internalGroupGroup.POST("/update", handlers.Group.UpdateGroupInfo)
+		internalGroupGroup.POST("/announcement", handlers.Group.SetAnnouncement)
+		internalGroupGroup.POST("/invite_link/create", handlers.Group.CreateInviteLink)
+		internalGroupGroup.POST("/invite_link/revoke", handlers.Group.RevokeInviteLink)
+		internalGroupGroup.POST("/join_by_link", handlers.Group.JoinByInviteLink)
 	}
 
 	// Internal conversation routes (service-to-service auth + acting user required)
-	internalConvGroup := root.Group("/internal/conversation", middleware.InternalAuthAsUser())
+	internalConvGroup := root.Group("/internal/conversation", middleware.InternalIPAccess(), middleware.InternalAuthAsUser(), middleware.UserRateLimit(limiter))
 	{
 		internalConvGroup.GET("/list", handlers.Conversation.GetConversationList)
 		internalConvGroup.POST("/list", handlers.Conversation.GetConversationList)
@@ -118,6 +307,22 @@ func SetupRouter(h *server.Hertz, handlers *Handlers, wsServer *gateway.WsServer
 	}
 }
 
+// setupDebugRoutes exposes pprof and runtime stats behind internal auth
+func setupDebugRoutes(h *server.Hertz, debugHandler *handler.DebugHandler) {
+	debugGroup := h.Group("/debug", middleware.InternalIPAccess(), middleware.InternalAuth())
+	{
+		debugGroup.GET("/runtime", debugHandler.GetRuntimeStats)
+		debugGroup.GET("/pprof/", adaptor.HertzHandler(http.HandlerFunc(pprof.Index)))
+		debugGroup.GET("/pprof/cmdline", adaptor.HertzHandler(http.HandlerFunc(pprof.Cmdline)))
+		debugGroup.GET("/pprof/profile", adaptor.HertzHandler(http.HandlerFunc(pprof.Profile)))
+		debugGroup.GET("/pprof/symbol", adaptor.HertzHandler(http.HandlerFunc(pprof.Symbol)))
+		debugGroup.POST("/pprof/symbol", adaptor.HertzHandler(http.HandlerFunc(pprof.Symbol)))
+		debugGroup.GET("/pprof/trace", adaptor.HertzHandler(http.HandlerFunc(pprof.Trace)))
+		// Named profiles: heap, goroutine, allocs, block, mutex, threadcreate
+		debugGroup.GET("/pprof/:name", adaptor.HertzHandler(http.HandlerFunc(pprof.Index)))
+	}
+}
+
 // Handlers holds all HTTP handlers
 type Handlers struct {
 	Auth         *handler.AuthHandler
@@ -125,4 +330,28 @@ type Handlers struct {
 	Group        *handler.GroupHandler
 	Message      *handler.MessageHandler
 	Conversation *handler.ConversationHandler
+	DataDeletion *handler.DataDeletionHandler
+	Notification *handler.NotificationHandler
+	Reminder     *handler.ReminderHandler
+	Favorite     *handler.FavoriteHandler
+	Poll         *handler.PollHandler
+	Reaction     *handler.ReactionHandler
+	Pin          *handler.PinHandler
+	Admin        *handler.AdminHandler
+	Stats        *handler.StatsHandler
+	Audit        *handler.AuditHandler
+	Webhook      *handler.WebhookHandler
+	Broadcast    *handler.BroadcastHandler
+	APIKey       *handler.APIKeyHandler
+	Health       *handler.HealthHandler
+	GraphQL      *handler.GraphQLHandler    // nil unless the GraphQL endpoint is enabled
+	ChatBridge   *handler.ChatBridgeHandler // nil unless the chat bridge is enabled
+	Bot          *handler.BotHandler        // nil unless bot webhooks are enabled
+	Agent        *handler.AgentHandler      // nil unless agent routing is enabled
+	Call         *handler.CallHandler       // nil unless call signaling is enabled
+	E2EE         *handler.E2EEHandler       // nil unless the e2ee key service is enabled
+	Storage      *handler.StorageHandler    // nil unless object storage is enabled
+	UsageStats   *handler.UsageStatsHandler // nil unless usage statistics are enabled
+	Debug        *handler.DebugHandler      // nil unless debug endpoints are enabled
+	Metrics      http.Handler               // nil unless the Prometheus endpoint is enabled
 }
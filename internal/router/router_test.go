@@ -0,0 +1,29 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+
+	"github.com/ZaiSpace/nexo_im/internal/handler"
+)
+
+func TestSetupRouterRegistersObjectPresign(t *testing.T) {
+	h := server.New()
+	SetupRouter(h, &Handlers{Storage: &handler.StorageHandler{}}, nil, nil, nil)
+
+	handlers := map[string]string{}
+	for _, r := range h.Routes() {
+		if r.Method == http.MethodPost {
+			handlers[r.Path] = r.Handler
+		}
+	}
+	presign, ok := handlers["/im/object/presign"]
+	if !ok {
+		t.Fatal("expected POST /im/object/presign to be registered")
+	}
+	if presign != handlers["/im/storage/presign_upload"] {
+		t.Fatalf("expected /im/object/presign to use the presign upload handler, got %s", presign)
+	}
+}
@@ -0,0 +1,72 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamAVChunkSize is the size of the chunks streamed to clamd, below its default
+// StreamMaxLength per chunk
+const clamAVChunkSize = 64 << 10
+
+// ClamAV scans files with a clamd daemon over TCP using the INSTREAM command
+type ClamAV struct {
+	address string
+	timeout time.Duration
+}
+
+// Scan streams data to clamd and parses its reply, "stream: OK" for a clean file and
+// "stream: <signature> FOUND" for an infected one
+func (c *ClamAV) Scan(ctx context.Context, _ string, data []byte) (*Result, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("zINSTREAM\x00")
+	for len(data) > 0 {
+		chunk := data[:min(len(data), clamAVChunkSize)]
+		data = data[len(chunk):]
+		binary.Write(w, binary.BigEndian, uint32(len(chunk)))
+		w.Write(chunk)
+	}
+	// A zero-length chunk ends the stream
+	binary.Write(w, binary.BigEndian, uint32(0))
+	if err = w.Flush(); err != nil {
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return nil, err
+	}
+	return parseClamAVReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+func parseClamAVReply(reply string) (*Result, error) {
+	status := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case status == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,75 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+// maxHTTPResponseSize bounds the verdict read from the scanning service
+const maxHTTPResponseSize = 64 << 10
+
+// FileNameHeader carries the object key of the scanned file
+const FileNameHeader = "X-File-Name"
+
+// httpVerdict is the JSON response of the scanning service
+type httpVerdict struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature"`
+}
+
+// HTTP scans files with an external service: the file is posted as the request body, signed
+// like internal-auth requests, and the service answers {"infected": bool, "signature": "..."}
+type HTTP struct {
+	url         string
+	secret      string
+	serviceName string
+	client      *http.Client
+}
+
+// NewHTTP creates a new HTTP scanner
+func NewHTTP(cfg *config.StorageScanConfig) *HTTP {
+	return &HTTP{
+		url:         cfg.URL,
+		secret:      cfg.Secret,
+		serviceName: cfg.ServiceName,
+		client:      &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Scan posts data to the scanning service
+func (h *HTTP) Scan(ctx context.Context, name string, data []byte) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(FileNameHeader, name)
+	req.Header.Set(signature.ServiceNameHeader, h.serviceName)
+	req.Header.Set(signature.TimestampHeader, ts)
+	req.Header.Set(signature.SignatureHeader,
+		signature.Sign(h.secret, h.serviceName, ts, http.MethodPost, req.URL.Path, data))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var verdict httpVerdict
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxHTTPResponseSize)).Decode(&verdict); err != nil {
+		return nil, err
+	}
+	return &Result{Infected: verdict.Infected, Signature: verdict.Signature}, nil
+}
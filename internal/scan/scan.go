@@ -0,0 +1,33 @@
+// Package scan checks uploaded files for malware before they are confirmed, with a clamd
+// daemon or an external scanning service over HTTP.
+package scan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+// Result is the verdict of a scan
+type Result struct {
+	Infected  bool
+	Signature string // name of the threat found, set when infected
+}
+
+// Scanner scans the content of a file
+type Scanner interface {
+	Scan(ctx context.Context, name string, data []byte) (*Result, error)
+}
+
+// New creates the Scanner of the backend of cfg
+func New(cfg *config.StorageScanConfig) (Scanner, error) {
+	switch cfg.Backend {
+	case config.ScanBackendClamAV:
+		return &ClamAV{address: cfg.Address, timeout: cfg.Timeout}, nil
+	case config.ScanBackendHTTP:
+		return NewHTTP(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported backend %q", cfg.Backend)
+	}
+}
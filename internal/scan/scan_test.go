@@ -0,0 +1,132 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+// eicar is the standard antivirus test file
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd serves one INSTREAM command per connection, finding eicar in the stream
+func fakeClamd(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var data []byte
+				for {
+					var size uint32
+					if binary.Read(r, binary.BigEndian, &size) != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					chunk := make([]byte, size)
+					if _, err := io.ReadFull(r, chunk); err != nil {
+						return
+					}
+					data = append(data, chunk...)
+				}
+				if strings.Contains(string(data), eicar) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScan(t *testing.T) {
+	scanner, err := New(&config.StorageScanConfig{Backend: config.ScanBackendClamAV, Address: fakeClamd(t), Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Larger than a chunk, so that the stream is split
+	clean := []byte(strings.Repeat("a", clamAVChunkSize+10))
+	if result, err := scanner.Scan(ctx, "a.txt", clean); err != nil || result.Infected {
+		t.Fatalf("expected a clean file, got %+v, err=%v", result, err)
+	}
+	result, err := scanner.Scan(ctx, "eicar.com", append(clean, eicar...))
+	if err != nil || !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("expected an infected file, got %+v, err=%v", result, err)
+	}
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	if _, err := parseClamAVReply("INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Fatal("expected an error reply to fail")
+	}
+	if result, err := parseClamAVReply("stream: OK"); err != nil || result.Infected {
+		t.Fatalf("unexpected result %+v, err=%v", result, err)
+	}
+}
+
+func TestHTTPScan(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !signature.Verify("secret", r.Header.Get(signature.ServiceNameHeader), r.Header.Get(signature.TimestampHeader),
+			r.Method, r.URL.Path, body, r.Header.Get(signature.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(FileNameHeader) != "uploads/a.exe" {
+			t.Errorf("unexpected file name %q", r.Header.Get(FileNameHeader))
+		}
+		if string(body) == eicar {
+			w.Write([]byte(`{"infected":true,"signature":"EICAR"}`))
+			return
+		}
+		w.Write([]byte(`{"infected":false}`))
+	}))
+	defer srv.Close()
+
+	scanner, err := New(&config.StorageScanConfig{Backend: config.ScanBackendHTTP, URL: srv.URL + "/scan",
+		Secret: "secret", ServiceName: "nexo_im", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if result, err := scanner.Scan(ctx, "uploads/a.exe", []byte("hello")); err != nil || result.Infected {
+		t.Fatalf("expected a clean file, got %+v, err=%v", result, err)
+	}
+	result, err := scanner.Scan(ctx, "uploads/a.exe", []byte(eicar))
+	if err != nil || !result.Infected || result.Signature != "EICAR" {
+		t.Fatalf("expected an infected file, got %+v, err=%v", result, err)
+	}
+
+	bad := NewHTTP(&config.StorageScanConfig{URL: srv.URL + "/scan", Secret: "wrong", ServiceName: "nexo_im", Timeout: time.Second})
+	if _, err = bad.Scan(ctx, "uploads/a.exe", []byte("hello")); err == nil {
+		t.Fatal("expected a rejected request to fail")
+	}
+}
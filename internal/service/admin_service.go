@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+)
+
+const (
+	defaultAdminSearchLimit = 20
+	maxAdminSearchLimit     = 100
+	maxAdminDeleteSeqs      = 100
+)
+
+// UserKicker interface for disconnecting a user's online connections
+type UserKicker interface {
+	KickUser(ctx context.Context, userId string) int
+}
+
+// AdminService handles operational admin actions
+type AdminService struct {
+	userRepo   *repository.UserRepo
+	convRepo   *repository.ConversationRepo
+	msgRepo    *repository.MessageRepo
+	auditRepo  *repository.AdminAuditRepo
+	tokenStore *jwt.TokenStore
+	kicker     UserKicker
+}
+
+// NewAdminService creates a new AdminService
+func NewAdminService(repos *repository.Repositories, cfg *config.Config) *AdminService {
+	return &AdminService{
+		userRepo:   repos.User,
+		convRepo:   repos.Conversation,
+		msgRepo:    repos.Message,
+		auditRepo:  repos.AdminAudit,
+		tokenStore: jwt.NewTokenStore(repos.Redis, cfg.JWT.SessionTTL),
+	}
+}
+
+// SetKicker sets the user kicker
+func (s *AdminService) SetKicker(kicker UserKicker) {
+	s.kicker = kicker
+}
+
+// SearchUsersRequest represents admin user search request
+type SearchUsersRequest struct {
+	Keyword string `json:"keyword" query:"keyword" validate:"max=128"`
+	Offset  int    `json:"offset" query:"offset" validate:"min=0"`
+	Limit   int    `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// SearchUsersResponse represents admin user search response
+type SearchUsersResponse struct {
+	Users []*entity.User `json:"users"`
+	Total int64          `json:"total"`
+}
+
+// SearchUsers searches users by id prefix or nickname
+func (s *AdminService) SearchUsers(ctx context.Context, req *SearchUsersRequest) (*SearchUsersResponse, error) {
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > maxAdminSearchLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAdminSearchLimit
+	}
+
+	users, total, err := s.userRepo.Search(ctx, req.Keyword, req.Offset, limit)
+	if err != nil {
+		log.CtxError(ctx, "search users failed: keyword=%s, error=%v", req.Keyword, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return &SearchUsersResponse{Users: users, Total: total}, nil
+}
+
+// SetUserBanned bans or unbans a user. Banning also revokes all sessions.
+func (s *AdminService) SetUserBanned(ctx context.Context, operator, userId string, banned bool) error {
+	user, err := s.userRepo.GetById(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", userId, err)
+		return errcode.ErrInternalServer
+	}
+	if user == nil {
+		return errcode.ErrUserNotFound
+	}
+
+	status := constant.UserStatusNormal
+	if banned {
+		status = constant.UserStatusBanned
+	}
+	if err = s.userRepo.Update(ctx, userId, map[string]interface{}{"status": status}); err != nil {
+		log.CtxError(ctx, "update user status failed: user_id=%s, error=%v", userId, err)
+		return errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "admin set user status: operator=%s, user_id=%s, status=%d", operator, userId, status)
+	if banned {
+		return s.ForceLogout(ctx, operator, userId)
+	}
+	return nil
+}
+
+// ForceLogout revokes all tokens of a user and disconnects their online connections
+func (s *AdminService) ForceLogout(ctx context.Context, operator, userId string) error {
+	if err := s.tokenStore.ForceLogoutUser(ctx, userId); err != nil {
+		log.CtxError(ctx, "force logout failed: user_id=%s, error=%v", userId, err)
+		return errcode.ErrInternalServer
+	}
+
+	kicked := 0
+	if s.kicker != nil {
+		kicked = s.kicker.KickUser(ctx, userId)
+	}
+	log.CtxInfo(ctx, "admin force logout: operator=%s, user_id=%s, kicked_conns=%d", operator, userId, kicked)
+	return nil
+}
+
+// GetUserConversations lists all conversations of a user with seq info
+func (s *AdminService) GetUserConversations(ctx context.Context, userId string) ([]*entity.ConversationWithSeq, error) {
+	convs, err := s.convRepo.GetUserConversationsWithSeq(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "get user conversations failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return convs, nil
+}
+
+// DeleteMessagesRequest represents admin delete messages request
+type DeleteMessagesRequest struct {
+	ConversationId string  `json:"conversation_id" validate:"required,max=256"`
+	Seqs           []int64 `json:"seqs" validate:"required,max=100"`
+}
+
+// DeleteMessages clears the content of messages in a conversation, keeping rows for seq continuity.
+// Returns the number of deleted messages.
+func (s *AdminService) DeleteMessages(ctx context.Context, operator string, req *DeleteMessagesRequest) (int64, error) {
+	if req.ConversationId == "" || len(req.Seqs) == 0 || len(req.Seqs) > maxAdminDeleteSeqs {
+		return 0, errcode.ErrInvalidParam
+	}
+
+	count, err := s.msgRepo.TombstoneBySeqs(ctx, req.ConversationId, req.Seqs, entity.NowUnixMilli())
+	if err != nil {
+		log.CtxError(ctx, "delete messages failed: conversation_id=%s, error=%v", req.ConversationId, err)
+		return 0, errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "admin deleted messages: operator=%s, conversation_id=%s, seqs=%v, count=%d",
+		operator, req.ConversationId, req.Seqs, count)
+	return count, nil
+}
+
+// SearchMessagesRequest represents admin message audit search request
+type SearchMessagesRequest struct {
+	SenderId       string `json:"sender_id,omitempty" validate:"max=64"`
+	ConversationId string `json:"conversation_id,omitempty" validate:"max=256"`
+	Keyword        string `json:"keyword,omitempty" validate:"max=128"`
+	StartTime      int64  `json:"start_time,omitempty" validate:"min=0"` // ms
+	EndTime        int64  `json:"end_time,omitempty" validate:"min=0"`   // ms
+	Offset         int    `json:"offset,omitempty" validate:"min=0"`
+	Limit          int    `json:"limit,omitempty" validate:"min=0,max=100"`
+}
+
+// SearchMessagesResponse represents admin message audit search response
+type SearchMessagesResponse struct {
+	Messages []*entity.MessageInfo `json:"messages"`
+	// SkippedCompressed counts the messages in scope whose content is compressed at rest, which
+	// a keyword cannot match; 0 without a keyword
+	SkippedCompressed int64 `json:"skipped_compressed"`
+}
+
+// SearchMessages searches messages for compliance investigations. The keyword matches the
+// text literally; compressed messages are not matched and are counted in the response instead.
+// The access is recorded in the audit log before results are returned; if it cannot be recorded the search fails.
+func (s *AdminService) SearchMessages(ctx context.Context, operator string, req *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	// Refuse unscoped searches over the whole message table
+	if req.SenderId == "" && req.ConversationId == "" && req.Keyword == "" && req.StartTime == 0 && req.EndTime == 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > maxAdminSearchLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.StartTime > 0 && req.EndTime > 0 && req.StartTime > req.EndTime {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAdminSearchLimit
+	}
+
+	filter := &repository.MessageSearchFilter{
+		SenderId:       req.SenderId,
+		ConversationId: req.ConversationId,
+		Keyword:        req.Keyword,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Offset:         req.Offset,
+		Limit:          limit,
+	}
+	messages, err := s.msgRepo.Search(ctx, filter)
+	if err != nil {
+		log.CtxError(ctx, "search messages failed: operator=%s, error=%v", operator, err)
+		return nil, errcode.ErrInternalServer
+	}
+	var skipped int64
+	if req.Keyword != "" {
+		if skipped, err = s.msgRepo.CountCompressed(ctx, filter); err != nil {
+			log.CtxError(ctx, "count compressed messages failed: operator=%s, error=%v", operator, err)
+			return nil, errcode.ErrInternalServer
+		}
+	}
+
+	if err = s.recordAudit(ctx, operator, constant.AdminAuditActionSearchMessages, req, int64(len(messages))); err != nil {
+		return nil, errcode.ErrInternalServer
+	}
+
+	result := make([]*entity.MessageInfo, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, msg.ToMessageInfo())
+	}
+	return &SearchMessagesResponse{Messages: result, SkippedCompressed: skipped}, nil
+}
+
+// ListAuditLogsRequest represents admin audit log list request
+type ListAuditLogsRequest struct {
+	Operator string `json:"operator" query:"operator" validate:"max=128"`
+	Offset   int    `json:"offset" query:"offset" validate:"min=0"`
+	Limit    int    `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// ListAuditLogs lists admin audit logs, newest first
+func (s *AdminService) ListAuditLogs(ctx context.Context, req *ListAuditLogsRequest) ([]*entity.AdminAuditLog, error) {
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > maxAdminSearchLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAdminSearchLimit
+	}
+
+	logs, err := s.auditRepo.List(ctx, req.Operator, req.Offset, limit)
+	if err != nil {
+		log.CtxError(ctx, "list audit logs failed: error=%v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	return logs, nil
+}
+
+// recordAudit persists an admin audit log entry
+func (s *AdminService) recordAudit(ctx context.Context, operator, action string, params any, resultCount int64) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		log.CtxError(ctx, "marshal audit params failed: action=%s, error=%v", action, err)
+		return err
+	}
+	paramsStr := string(raw)
+
+	if err = s.auditRepo.Create(ctx, &entity.AdminAuditLog{
+		Operator:    operator,
+		Action:      action,
+		Params:      &paramsStr,
+		ResultCount: resultCount,
+	}); err != nil {
+		log.CtxError(ctx, "create audit log failed: operator=%s, action=%s, error=%v", operator, action, err)
+		return err
+	}
+
+	log.CtxInfo(ctx, "admin audit: operator=%s, action=%s, params=%s, result_count=%d", operator, action, paramsStr, resultCount)
+	return nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestAdminSearchUsersRejectsOversizedLimit(t *testing.T) {
+	s := &AdminService{}
+	_, err := s.SearchUsers(context.Background(), &SearchUsersRequest{Limit: maxAdminSearchLimit + 1})
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error, got %v", err)
+	}
+}
+
+func TestAdminDeleteMessagesRequiresSeqs(t *testing.T) {
+	s := &AdminService{}
+	_, err := s.DeleteMessages(context.Background(), "ops", &DeleteMessagesRequest{ConversationId: "si_a:b"})
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error, got %v", err)
+	}
+}
+
+func TestAdminSearchMessagesRequiresScope(t *testing.T) {
+	s := &AdminService{}
+	_, err := s.SearchMessages(context.Background(), "ops", &SearchMessagesRequest{Limit: 10})
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error, got %v", err)
+	}
+}
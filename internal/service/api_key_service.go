@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+const (
+	// API keys look like nxk_<prefix>_<secret>; the prefix is stored in clear to look the key up
+	apiKeyMarker       = "nxk"
+	apiKeyPrefixBytes  = 8
+	apiKeySecretBytes  = 32
+	maxAPIKeyGrace     = 7 * 24 * time.Hour
+	apiKeyTouchEvery   = time.Minute // last_used_at is written at most this often per key
+	maxAPIKeysPerUser  = 20
+	maxAPIKeyExpiresIn = 10 * 365 * 24 * time.Hour
+)
+
+// APIKeyService manages bot users and their API keys
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepo
+	userRepo   *repository.UserRepo
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(repos *repository.Repositories) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: repos.APIKey,
+		userRepo:   repos.User,
+	}
+}
+
+// CreateBotRequest represents admin create bot user request
+type CreateBotRequest struct {
+	UserId   string `json:"user_id" validate:"max=64"` // generated if empty
+	Nickname string `json:"nickname" validate:"required,max=128"`
+	Avatar   string `json:"avatar,omitempty" validate:"max=512"`
+}
+
+// CreateBot creates a bot user. Bots have no password and can only authenticate with API keys.
+func (s *APIKeyService) CreateBot(ctx context.Context, operator string, req *CreateBotRequest) (*entity.UserInfo, error) {
+	if req.Nickname == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	exists, err := s.userRepo.Exists(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "check user exists failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	if exists {
+		return nil, errcode.ErrUserExists
+	}
+
+	userId := req.UserId
+	if userId == "" {
+		userId = uuid.New().String()
+	}
+	user := &entity.User{
+		Id:       userId,
+		Nickname: req.Nickname,
+		Avatar:   req.Avatar,
+		IsBot:    true,
+	}
+	if err = s.userRepo.Create(ctx, user); err != nil {
+		log.CtxError(ctx, "create bot user failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	info := user.ToUserInfo()
+	webhook.Emit(ctx, webhook.EventUserRegistered, &UserRegisteredEvent{User: info, Source: registerSourceBot})
+
+	log.CtxInfo(ctx, "admin created bot user: operator=%s, user_id=%s", operator, userId)
+	return info, nil
+}
+
+// CreateAPIKeyRequest represents admin create API key request
+type CreateAPIKeyRequest struct {
+	UserId    string   `json:"user_id" validate:"required,max=64"`
+	Name      string   `json:"name" validate:"max=128"`
+	Scopes    []string `json:"scopes" validate:"required"`
+	ExpiresIn int64    `json:"expires_in,omitempty" validate:"min=0"` // seconds, 0 = never
+}
+
+// APIKeyResponse is a newly issued API key. Key is only returned here and cannot be recovered;
+// its json name is covered by the default request_log.redact_fields.
+type APIKeyResponse struct {
+	Key    string         `json:"api_key"`
+	Detail *entity.APIKey `json:"detail"`
+}
+
+// CreateKey issues an API key for a bot user
+func (s *APIKeyService) CreateKey(ctx context.Context, operator string, req *CreateAPIKeyRequest) (*APIKeyResponse, error) {
+	if !scope.Valid(req.Scopes) || req.ExpiresIn < 0 || time.Duration(req.ExpiresIn)*time.Second > maxAPIKeyExpiresIn {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	user, err := s.userRepo.GetById(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrUserNotFound
+	}
+	if !user.IsBot {
+		return nil, errcode.ErrNotBotUser
+	}
+
+	keys, err := s.apiKeyRepo.ListByUser(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "list api keys failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	now := entity.NowUnixMilli()
+	active := 0
+	for _, k := range keys {
+		if k.IsActive(now) {
+			active++
+		}
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	var expiresAt int64
+	if req.ExpiresIn > 0 {
+		expiresAt = now + req.ExpiresIn*1000
+	}
+	key, apiKey, err := newAPIKey(req.UserId, req.Name, req.Scopes, operator, expiresAt)
+	if err != nil {
+		log.CtxError(ctx, "generate api key failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	if err = s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		log.CtxError(ctx, "create api key failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "admin created api key: operator=%s, user_id=%s, prefix=%s, scopes=%v",
+		operator, req.UserId, apiKey.Prefix, apiKey.Scopes)
+	return &APIKeyResponse{Key: key, Detail: apiKey}, nil
+}
+
+// ListAPIKeysRequest represents admin list API keys request
+type ListAPIKeysRequest struct {
+	UserId string `json:"user_id" query:"user_id" validate:"required,max=64"`
+}
+
+// ListKeys lists the API keys of a user, newest first
+func (s *APIKeyService) ListKeys(ctx context.Context, req *ListAPIKeysRequest) ([]*entity.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListByUser(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "list api keys failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return keys, nil
+}
+
+// RotateAPIKeyRequest represents admin rotate API key request
+type RotateAPIKeyRequest struct {
+	Id int64 `json:"id" validate:"required,min=1"`
+	// GraceSeconds keeps the old key valid for a while so deployments can switch over, 0 = expire now
+	GraceSeconds int64 `json:"grace_seconds,omitempty" validate:"min=0"`
+}
+
+// RotateKey issues a replacement for an active key with the same user, name, scopes and expiry,
+// and expires the old key after the grace period
+func (s *APIKeyService) RotateKey(ctx context.Context, operator string, req *RotateAPIKeyRequest) (*APIKeyResponse, error) {
+	grace := time.Duration(req.GraceSeconds) * time.Second
+	if req.GraceSeconds < 0 || grace > maxAPIKeyGrace {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	old, err := s.getActiveKey(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := entity.NowUnixMilli()
+	oldExpiresAt := now + grace.Milliseconds()
+	if old.ExpiresAt > 0 && old.ExpiresAt < oldExpiresAt {
+		oldExpiresAt = old.ExpiresAt
+	}
+	key, apiKey, err := newAPIKey(old.UserId, old.Name, old.Scopes, operator, old.ExpiresAt)
+	if err != nil {
+		log.CtxError(ctx, "generate api key failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	if err = s.apiKeyRepo.CreateRotation(ctx, old.Id, oldExpiresAt, apiKey); err != nil {
+		log.CtxError(ctx, "rotate api key failed: id=%d, error=%v", old.Id, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "admin rotated api key: operator=%s, user_id=%s, old_prefix=%s, new_prefix=%s, old_expires_at=%d",
+		operator, old.UserId, old.Prefix, apiKey.Prefix, oldExpiresAt)
+	return &APIKeyResponse{Key: key, Detail: apiKey}, nil
+}
+
+// APIKeyIdRequest represents an admin action on a single API key
+type APIKeyIdRequest struct {
+	Id int64 `json:"id" validate:"required,min=1"`
+}
+
+// RevokeKey revokes an API key immediately
+func (s *APIKeyService) RevokeKey(ctx context.Context, operator string, req *APIKeyIdRequest) error {
+	key, err := s.getActiveKey(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.apiKeyRepo.Revoke(ctx, key.Id, entity.NowUnixMilli())
+	if err != nil {
+		log.CtxError(ctx, "revoke api key failed: id=%d, error=%v", key.Id, err)
+		return errcode.ErrInternalServer
+	}
+	if !revoked {
+		return errcode.ErrInvalidParam
+	}
+
+	log.CtxInfo(ctx, "admin revoked api key: operator=%s, user_id=%s, prefix=%s", operator, key.UserId, key.Prefix)
+	return nil
+}
+
+// getActiveKey gets a key that can still authenticate
+func (s *APIKeyService) getActiveKey(ctx context.Context, id int64) (*entity.APIKey, error) {
+	key, err := s.apiKeyRepo.GetById(ctx, id)
+	if err != nil {
+		log.CtxError(ctx, "get api key failed: id=%d, error=%v", id, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if key == nil || !key.IsActive(entity.NowUnixMilli()) {
+		return nil, errcode.ErrInvalidParam
+	}
+	return key, nil
+}
+
+// AuthenticateAPIKey resolves a key presented in a request to its bot user.
+// It returns the key so the caller can check scopes.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return nil, errcode.ErrAPIKeyInvalid
+	}
+	apiKey, err := s.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		log.CtxError(ctx, "get api key failed: prefix=%s, error=%v", prefix, err)
+		return nil, errcode.ErrInternalServer
+	}
+	now := entity.NowUnixMilli()
+	if apiKey == nil || !apiKey.IsActive(now) ||
+		subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, errcode.ErrAPIKeyInvalid
+	}
+
+	user, err := s.userRepo.GetById(ctx, apiKey.UserId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", apiKey.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrAPIKeyInvalid
+	}
+	if user.IsBanned() {
+		return nil, errcode.ErrUserBanned
+	}
+
+	if now-apiKey.LastUsedAt >= apiKeyTouchEvery.Milliseconds() {
+		if err = s.apiKeyRepo.TouchLastUsed(ctx, apiKey.Id, now); err != nil {
+			log.CtxWarn(ctx, "update api key last used failed: prefix=%s, error=%v", prefix, err)
+		}
+	}
+	return apiKey, nil
+}
+
+// newAPIKey generates a key and the record holding its hash
+func newAPIKey(userId, name string, scopes []string, operator string, expiresAt int64) (string, *entity.APIKey, error) {
+	prefixBytes := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", nil, err
+	}
+	secretBytes := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", nil, err
+	}
+
+	prefix := apiKeyMarker + "_" + hex.EncodeToString(prefixBytes)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return key, &entity.APIKey{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(key),
+		Scopes:    scopes,
+		Operator:  operator,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// parseAPIKey returns the lookup prefix of a well-formed key
+func parseAPIKey(key string) (string, bool) {
+	// The base64url secret may itself contain underscores
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyMarker ||
+		len(parts[1]) != hex.EncodedLen(apiKeyPrefixBytes) || parts[2] == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return parts[0] + "_" + parts[1], true
+}
+
+// hashAPIKey returns the stored form of a key. Keys are random, so an unsalted hash is enough.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+func TestNewAPIKeyRoundTrip(t *testing.T) {
+	key, apiKey, err := newAPIKey("bot1", "ci", []string{"msg"}, "ops", 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	if strings.Contains(apiKey.KeyHash, key) || apiKey.KeyHash != hashAPIKey(key) {
+		t.Fatalf("expected only the hash of the key to be stored")
+	}
+	prefix, ok := parseAPIKey(key)
+	if !ok || prefix != apiKey.Prefix {
+		t.Fatalf("expected prefix %q, got %q ok=%v", apiKey.Prefix, prefix, ok)
+	}
+	for _, bad := range []string{"", "nxk", "nxk_zz_secret", "abc_0123456789abcdef_secret", apiKey.Prefix + "_"} {
+		if _, ok = parseAPIKey(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestAPIKeyAllows(t *testing.T) {
+	key := &entity.APIKey{Scopes: []string{"msg", "group:read"}}
+	if !key.Allows("msg", true) || !key.Allows("group", false) {
+		t.Fatalf("expected granted scopes to pass for %v", key.Scopes)
+	}
+	if key.Allows("group", true) || key.Allows("user", false) {
+		t.Fatalf("expected other scopes to fail for %v", key.Scopes)
+	}
+}
+
+func TestAPIKeyIsActive(t *testing.T) {
+	now := int64(1_000_000)
+	for _, tc := range []struct {
+		key    entity.APIKey
+		active bool
+	}{
+		{entity.APIKey{}, true},
+		{entity.APIKey{ExpiresAt: now + 1}, true},
+		{entity.APIKey{ExpiresAt: now}, false},
+		{entity.APIKey{RevokedAt: now - 1}, false},
+	} {
+		if tc.key.IsActive(now) != tc.active {
+			t.Fatalf("expected active=%v for %+v", tc.active, tc.key)
+		}
+	}
+}
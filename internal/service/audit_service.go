@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// AuditService is the audit.Sink backed by the audit_events table.
+// Events are queued without blocking the request and written in batches by Run.
+type AuditService struct {
+	repo          *repository.AuditEventRepo
+	events        chan *entity.AuditEvent
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{} // closed when the writer exits
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(repos *repository.Repositories, cfg *config.Config) *AuditService {
+	return &AuditService{
+		repo:          repos.AuditEvent,
+		events:        make(chan *entity.AuditEvent, cfg.Audit.BufferSize),
+		batchSize:     cfg.Audit.BatchSize,
+		flushInterval: cfg.Audit.FlushInterval,
+	}
+}
+
+// Record queues an audit event; it is dropped when the buffer is full
+func (s *AuditService) Record(ctx context.Context, event *audit.Event) {
+	select {
+	case s.events <- toAuditEvent(ctx, event):
+	default:
+		log.CtxWarn(ctx, "audit buffer full, event dropped: category=%s, action=%s, actor=%s",
+			event.Category, event.Action, event.Actor)
+	}
+}
+
+func toAuditEvent(ctx context.Context, event *audit.Event) *entity.AuditEvent {
+	var detail *string
+	if len(event.Detail) > 0 {
+		raw, err := json.Marshal(event.Detail)
+		if err != nil {
+			log.CtxWarn(ctx, "marshal audit detail failed: action=%s, error=%v", event.Action, err)
+		} else {
+			detailStr := string(raw)
+			detail = &detailStr
+		}
+	}
+
+	return &entity.AuditEvent{
+		Category:  event.Category,
+		Action:    event.Action,
+		Actor:     event.Actor,
+		Path:      event.Path,
+		ClientIp:  event.ClientIP,
+		Result:    event.Result,
+		Code:      event.Code,
+		Detail:    detail,
+		TraceId:   event.TraceId,
+		CreatedAt: event.At.UnixMilli(),
+	}
+}
+
+// Run starts the writer goroutine; pending events are flushed when ctx is done
+func (s *AuditService) Run(ctx context.Context) {
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.flushInterval)
+		defer ticker.Stop()
+
+		batch := make([]*entity.AuditEvent, 0, s.batchSize)
+		flush := func(ctx context.Context) {
+			if len(batch) == 0 {
+				return
+			}
+			if err := s.repo.BatchCreate(ctx, batch); err != nil {
+				log.CtxError(ctx, "write audit events failed: count=%d, error=%v", len(batch), err)
+			}
+			batch = batch[:0]
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case event := <-s.events:
+						batch = append(batch, event)
+					default:
+						flush(context.Background())
+						return
+					}
+				}
+			case event := <-s.events:
+				batch = append(batch, event)
+				if len(batch) >= s.batchSize {
+					flush(ctx)
+				}
+			case <-ticker.C:
+				flush(ctx)
+			}
+		}
+	}()
+	log.CtxInfo(ctx, "audit writer started: batch_size=%d, flush_interval=%s", s.batchSize, s.flushInterval)
+}
+
+// Wait blocks until the writer has flushed and exited after its Run ctx is done, or until ctx is done
+func (s *AuditService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ListAuditEventsRequest represents audit event query request
+type ListAuditEventsRequest struct {
+	Category  string `json:"category" query:"category" validate:"max=32"`
+	Actor     string `json:"actor" query:"actor" validate:"max=128"`
+	Result    string `json:"result" query:"result" validate:"max=16"`
+	StartTime int64  `json:"start_time" query:"start_time" validate:"min=0"` // ms, inclusive
+	EndTime   int64  `json:"end_time" query:"end_time" validate:"min=0"`     // ms, exclusive
+	Offset    int    `json:"offset" query:"offset" validate:"min=0"`
+	Limit     int    `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// ListEvents lists audit events, newest first
+func (s *AuditService) ListEvents(ctx context.Context, req *ListAuditEventsRequest) ([]*entity.AuditEvent, error) {
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > maxAdminSearchLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.StartTime < 0 || req.EndTime < 0 || (req.EndTime > 0 && req.EndTime <= req.StartTime) {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAdminSearchLimit
+	}
+
+	events, err := s.repo.List(ctx, &repository.AuditEventFilter{
+		Category:  req.Category,
+		Actor:     req.Actor,
+		Result:    req.Result,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}, req.Offset, limit)
+	if err != nil {
+		log.CtxError(ctx, "list audit events failed: error=%v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	return events, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestAuditRecordDropsWhenBufferFull(t *testing.T) {
+	s := &AuditService{events: make(chan *entity.AuditEvent, 1)}
+	at := time.UnixMilli(1700000000000)
+
+	s.Record(context.Background(), &audit.Event{
+		Category: audit.CategoryLogin,
+		Action:   "login",
+		Actor:    "u1",
+		Result:   audit.ResultSuccess,
+		Detail:   map[string]any{"platform_id": 1},
+		At:       at,
+	})
+	s.Record(context.Background(), &audit.Event{Category: audit.CategoryLogin, Action: "login", Actor: "u2", At: at})
+
+	if len(s.events) != 1 {
+		t.Fatalf("expected 1 queued event, got %d", len(s.events))
+	}
+	event := <-s.events
+	if event.Actor != "u1" || event.CreatedAt != at.UnixMilli() {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.Detail == nil || *event.Detail != `{"platform_id":1}` {
+		t.Fatalf("unexpected detail: %v", event.Detail)
+	}
+}
+
+func TestAuditListEventsRejectsInvalidRange(t *testing.T) {
+	s := &AuditService{}
+	_, err := s.ListEvents(context.Background(), &ListAuditEventsRequest{StartTime: 200, EndTime: 100})
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error, got %v", err)
+	}
+}
@@ -2,58 +2,193 @@ package service
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mbeoliero/kit/log"
-	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"github.com/ZaiSpace/nexo_im/internal/config"
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/captcha"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+	"github.com/ZaiSpace/nexo_im/pkg/oauth"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
 )
 
 // AuthService handles authentication logic
 type AuthService struct {
-	userRepo   *repository.UserRepo
-	cfg        *config.Config
-	tokenStore *jwt.TokenStore
+	userRepo       *repository.UserRepo
+	identityRepo   *repository.UserIdentityRepo
+	loginAttempts  *repository.LoginAttemptRepo
+	verifyCodes    *repository.VerifyCodeRepo
+	repos          *repository.Repositories
+	cfg            *config.Config
+	tokenStore     *jwt.TokenStore
+	stats          *StatsService
+	sender         VerificationSender
+	captcha        captcha.Verifier // nil when captcha is disabled
+	oauthProviders map[string]oauth.Provider
+	sessionKicker  SessionKicker
 }
 
 // NewAuthService creates a new AuthService
-func NewAuthService(userRepo *repository.UserRepo, cfg *config.Config, rdb redis.UniversalClient) *AuthService {
+func NewAuthService(repos *repository.Repositories, cfg *config.Config) *AuthService {
+	providers := make(map[string]oauth.Provider, len(cfg.OAuth.Providers))
+	for _, pc := range cfg.OAuth.Providers {
+		provider, err := oauth.NewProvider(oauth.Config{
+			Name:         pc.Name,
+			Type:         pc.Type,
+			ClientId:     pc.ClientId,
+			ClientSecret: pc.ClientSecret,
+			RedirectURL:  pc.RedirectURL,
+			Issuer:       pc.Issuer,
+			TokenURL:     pc.TokenURL,
+			UserInfoURL:  pc.UserInfoURL,
+		})
+		if err != nil {
+			log.Error("skip oauth provider %s: %v", pc.Name, err)
+			continue
+		}
+		providers[pc.Name] = provider
+	}
+
+	var verifier captcha.Verifier
+	if cfg.Captcha.Enabled {
+		v, err := captcha.NewVerifier(cfg.Captcha.Provider, cfg.Captcha.Secret, cfg.Captcha.VerifyURL)
+		if err != nil {
+			log.Error("captcha disabled: %v", err)
+		} else {
+			verifier = v
+		}
+	}
+
 	return &AuthService{
-		userRepo:   userRepo,
-		cfg:        cfg,
-		tokenStore: jwt.NewTokenStore(rdb, cfg.JWT.ExpireHours),
+		userRepo:       repos.User,
+		identityRepo:   repos.UserIdentity,
+		loginAttempts:  repository.NewLoginAttemptRepo(repos.Redis),
+		verifyCodes:    repository.NewVerifyCodeRepo(repos.Redis),
+		repos:          repos,
+		cfg:            cfg,
+		tokenStore:     jwt.NewTokenStore(repos.Redis, cfg.JWT.SessionTTL),
+		sender:         NewLogVerificationSender(),
+		captcha:        verifier,
+		oauthProviders: providers,
 	}
 }
 
+// SetCaptchaVerifier replaces the configured captcha provider, e.g. with a custom one
+func (s *AuthService) SetCaptchaVerifier(verifier captcha.Verifier) {
+	s.captcha = verifier
+}
+
+// SetVerificationSender sets the sender of registration verification codes
+func (s *AuthService) SetVerificationSender(sender VerificationSender) {
+	s.sender = sender
+}
+
+// SetSessionKicker sets the disconnector of revoked sessions' connections
+func (s *AuthService) SetSessionKicker(kicker SessionKicker) {
+	s.sessionKicker = kicker
+}
+
+// SetStats sets the stats recorder
+func (s *AuthService) SetStats(stats *StatsService) {
+	s.stats = stats
+}
+
 // RegisterRequest represents user registration request
 type RegisterRequest struct {
-	UserId   string `json:"user_id"`
-	Nickname string `json:"nickname"`
-	Password string `json:"password"`
-	Avatar   string `json:"avatar,omitempty"`
+	UserId   string `json:"user_id" validate:"max=64"`
+	Nickname string `json:"nickname" validate:"max=128"`
+	Password string `json:"password" validate:"max=72"` // bcrypt only uses the first 72 bytes
+	Avatar   string `json:"avatar,omitempty" validate:"max=512"`
+	// Email or Phone (E.164) registers an account that must be verified before login; at most one
+	Email string `json:"email,omitempty" validate:"max=255"`
+	Phone string `json:"phone,omitempty" validate:"max=32"`
+	// CaptchaToken is required when captcha.on_register is enabled
+	CaptchaToken string `json:"captcha_token,omitempty" validate:"max=4096"`
+}
+
+// VerifyRequest represents registration verification request
+type VerifyRequest struct {
+	UserId string `json:"user_id" validate:"required,max=64"`
+	Code   string `json:"code" validate:"required,max=16"`
+}
+
+// ResendVerifyCodeRequest represents verification code resend request
+type ResendVerifyCodeRequest struct {
+	UserId string `json:"user_id" validate:"required,max=64"`
 }
 
 // LoginRequest represents user login request
 type LoginRequest struct {
-	UserId     string `json:"user_id"`
-	Password   string `json:"password"`
-	PlatformId int    `json:"platform_id"`
+	UserId     string `json:"user_id" validate:"required,max=64"`
+	Password   string `json:"password" validate:"max=72"`
+	PlatformId int    `json:"platform_id" validate:"min=0"`
+	DeviceName string `json:"device_name,omitempty" validate:"max=64"` // shown in the session list
+	// CaptchaToken is required after repeated failed logins, see errcode.ErrCaptchaRequired
+	CaptchaToken string `json:"captcha_token,omitempty" validate:"max=4096"`
 }
 
 // LoginResponse represents user login response
 type LoginResponse struct {
-	Token    string           `json:"token"`
-	UserInfo *entity.UserInfo `json:"user_info"`
+	Token        string           `json:"token"`
+	RefreshToken string           `json:"refresh_token"`
+	ExpiresIn    int64            `json:"expires_in"` // access token lifetime in seconds
+	UserInfo     *entity.UserInfo `json:"user_info"`
+}
+
+// OAuthLoginRequest represents login with an authorization code from an identity provider
+type OAuthLoginRequest struct {
+	Provider    string `json:"provider" validate:"required,max=64"`
+	Code        string `json:"code" validate:"required,max=1024"`
+	RedirectURI string `json:"redirect_uri,omitempty" validate:"max=1024"` // defaults to the provider's redirect_url
+	PlatformId  int    `json:"platform_id" validate:"min=0"`
+	DeviceName  string `json:"device_name,omitempty" validate:"max=64"` // shown in the session list
+}
+
+// RefreshTokenRequest represents refresh token request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=128"`
+}
+
+// RefreshTokenResponse represents refresh token response
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token lifetime in seconds
+}
+
+// Register registers a new user through the public route, checking the captcha when enabled
+func (s *AuthService) Register(ctx context.Context, clientIP string, req *RegisterRequest) (*entity.UserInfo, error) {
+	if s.captcha != nil && s.cfg.Captcha.OnRegister {
+		if err := s.verifyCaptcha(ctx, clientIP, req.CaptchaToken); err != nil {
+			return nil, err
+		}
+	}
+	return s.register(ctx, req, registerSourcePublic)
+}
+
+// InternalRegister registers a new user on behalf of a trusted internal service
+func (s *AuthService) InternalRegister(ctx context.Context, req *RegisterRequest) (*entity.UserInfo, error) {
+	return s.register(ctx, req, registerSourceInternal)
 }
 
-// Register registers a new user
-func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*entity.UserInfo, error) {
+// register creates the user and sends the verification code if needed
+func (s *AuthService) register(ctx context.Context, req *RegisterRequest, source string) (*entity.UserInfo, error) {
+	email, phone, err := s.checkContact(ctx, req.Email, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if user already exists
 	exists, err := s.userRepo.Exists(ctx, req.UserId)
 	if err != nil {
@@ -83,6 +218,8 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*enti
 		Nickname: req.Nickname,
 		Password: string(hashedPassword),
 		Avatar:   req.Avatar,
+		Email:    email,
+		Phone:    phone,
 	}
 
 	if err = s.userRepo.Create(ctx, user); err != nil {
@@ -90,12 +227,84 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*enti
 		return nil, errcode.ErrInternalServer
 	}
 
+	s.stats.RecordRegistration(ctx)
+
+	// The account exists either way; a failed send can be retried through resend
+	if user.NeedsVerification() {
+		if err = s.sendVerifyCode(ctx, user); err != nil {
+			log.CtxWarn(ctx, "send verification code failed: user_id=%s, error=%v", userId, err)
+		}
+	}
+
+	info := user.ToUserInfo()
+	webhook.Emit(ctx, webhook.EventUserRegistered, &UserRegisteredEvent{User: info, Source: source})
+
 	log.CtxInfo(ctx, "user registered: user_id=%s", userId)
-	return user.ToUserInfo(), nil
+	return info, nil
 }
 
-// Login authenticates a user and returns a token
-func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+// checkContact normalizes the email or phone of a new account and checks it is not taken.
+// At most one may be given; either one must be verified before login.
+func (s *AuthService) checkContact(ctx context.Context, rawEmail, rawPhone string) (email, phone *string, err error) {
+	if rawEmail != "" && rawPhone != "" {
+		return nil, nil, errcode.ErrInvalidParam
+	}
+	if rawEmail != "" {
+		addr, ok := normalizeEmail(rawEmail)
+		if !ok {
+			return nil, nil, errcode.ErrInvalidParam
+		}
+		email = &addr
+		taken, err := s.userRepo.ExistsByEmail(ctx, addr)
+		if err != nil {
+			log.CtxError(ctx, "check email exists failed: %v", err)
+			return nil, nil, errcode.ErrInternalServer
+		}
+		if taken {
+			return nil, nil, errcode.ErrContactExists
+		}
+	}
+	if rawPhone != "" {
+		number, ok := normalizePhone(rawPhone)
+		if !ok {
+			return nil, nil, errcode.ErrInvalidParam
+		}
+		phone = &number
+		taken, err := s.userRepo.ExistsByPhone(ctx, number)
+		if err != nil {
+			log.CtxError(ctx, "check phone exists failed: %v", err)
+			return nil, nil, errcode.ErrInternalServer
+		}
+		if taken {
+			return nil, nil, errcode.ErrContactExists
+		}
+	}
+	return email, phone, nil
+}
+
+// Login authenticates a user and returns a token.
+// Repeated failures from one user or client IP are throttled, see config.LoginThrottleConfig.
+func (s *AuthService) Login(ctx context.Context, clientIP string, req *LoginRequest) (resp *LoginResponse, err error) {
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryLogin,
+			Action:   "login",
+			Actor:    req.UserId,
+			ClientIP: clientIP,
+			Code:     audit.CodeOf(err),
+			Detail:   map[string]any{"platform_id": req.PlatformId},
+		})
+	}()
+
+	if err = s.checkLoginLock(ctx, req.UserId, clientIP); err != nil {
+		return nil, err
+	}
+	if s.loginNeedsCaptcha(ctx, req.UserId, clientIP) {
+		if err = s.verifyCaptcha(ctx, clientIP, req.CaptchaToken); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get user
 	user, err := s.userRepo.GetById(ctx, req.UserId)
 	if err != nil {
@@ -103,43 +312,402 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		return nil, errcode.ErrUserNotFound
 	}
 	if user == nil {
+		s.recordLoginFailure(ctx, req.UserId, clientIP, false)
 		return nil, errcode.ErrUserNotFound
 	}
 
 	// Verify password with bcrypt
 	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		s.recordLoginFailure(ctx, req.UserId, clientIP, true)
 		return nil, errcode.ErrPasswordWrong
 	}
+	s.resetLoginFailures(ctx, user.Id)
+	if user.IsBanned() {
+		return nil, errcode.ErrUserBanned
+	}
+	if user.NeedsVerification() {
+		return nil, errcode.ErrUserNotVerified
+	}
 
-	// Generate token
-	token, err := jwt.GenerateToken(user.Id, req.PlatformId, s.cfg.JWT.Secret, s.cfg.JWT.ExpireHours)
+	return s.startSession(ctx, user, req.PlatformId, &jwt.SessionMeta{Name: req.DeviceName, ClientIP: clientIP})
+}
+
+// OAuthLogin logs in with an authorization code issued by a configured identity provider.
+// The first login of an external account creates an IM user linked to it.
+func (s *AuthService) OAuthLogin(ctx context.Context, clientIP string, req *OAuthLoginRequest) (resp *LoginResponse, err error) {
+	var userId string
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryLogin,
+			Action:   "oauth_login",
+			Actor:    userId,
+			ClientIP: clientIP,
+			Code:     audit.CodeOf(err),
+			Detail:   map[string]any{"provider": req.Provider, "platform_id": req.PlatformId},
+		})
+	}()
+
+	provider, ok := s.oauthProviders[req.Provider]
+	if !ok {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, req.RedirectURI)
 	if err != nil {
-		log.CtxError(ctx, "generate token failed: %v", err)
+		log.CtxWarn(ctx, "oauth exchange failed: provider=%s, error=%v", req.Provider, err)
+		return nil, errcode.ErrOAuthFailed
+	}
+
+	user, err := s.getOrProvisionOAuthUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	userId = user.Id
+	if user.IsBanned() {
+		return nil, errcode.ErrUserBanned
+	}
+
+	return s.startSession(ctx, user, req.PlatformId, &jwt.SessionMeta{Name: req.DeviceName, ClientIP: clientIP})
+}
+
+// getOrProvisionOAuthUser returns the user linked to identity, creating and linking one on first login
+func (s *AuthService) getOrProvisionOAuthUser(ctx context.Context, identity *oauth.Identity) (*entity.User, error) {
+	link, err := s.identityRepo.Get(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		log.CtxError(ctx, "get user identity failed: provider=%s, error=%v", identity.Provider, err)
 		return nil, errcode.ErrInternalServer
 	}
+	if link != nil {
+		user, err := s.userRepo.GetById(ctx, link.UserId)
+		if err != nil {
+			log.CtxError(ctx, "get user failed: user_id=%s, error=%v", link.UserId, err)
+			return nil, errcode.ErrInternalServer
+		}
+		if user == nil || user.IsDeleted() {
+			return nil, errcode.ErrUserNotFound
+		}
+		return user, nil
+	}
 
-	// Store token in Redis
-	if err = s.tokenStore.StoreToken(ctx, user.Id, req.PlatformId, token); err != nil {
-		log.CtxError(ctx, "store token failed: %v", err)
+	user := &entity.User{
+		Id:       uuid.New().String(),
+		Nickname: truncateRunes(identity.Name, 128),
+	}
+	if len(identity.Avatar) <= 512 {
+		user.Avatar = identity.Avatar
+	}
+	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.userRepo.CreateWithTx(ctx, tx, user); err != nil {
+			return err
+		}
+		return s.identityRepo.Create(ctx, tx, &entity.UserIdentity{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			UserId:   user.Id,
+		})
+	})
+	if err != nil {
+		// A concurrent first login may have linked the identity already
+		if link, getErr := s.identityRepo.Get(ctx, identity.Provider, identity.Subject); getErr == nil && link != nil {
+			return s.getOrProvisionOAuthUser(ctx, identity)
+		}
+		log.CtxError(ctx, "provision oauth user failed: provider=%s, error=%v", identity.Provider, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	s.stats.RecordRegistration(ctx)
+	webhook.Emit(ctx, webhook.EventUserRegistered, &UserRegisteredEvent{User: user.ToUserInfo(), Source: registerSourceOAuth})
+
+	log.CtxInfo(ctx, "user provisioned from oauth: user_id=%s, provider=%s", user.Id, identity.Provider)
+	return user, nil
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// startSession issues an access token and a new refresh session for a logged in user
+func (s *AuthService) startSession(ctx context.Context, user *entity.User, platformId int, meta *jwt.SessionMeta) (*LoginResponse, error) {
+	// Generate token
+	token, ttl, err := s.issueAccessToken(ctx, user, platformId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a new refresh session, replacing the previous one of this platform
+	refreshToken, err := s.tokenStore.IssueRefreshToken(ctx, user.Id, platformId, meta)
+	if err != nil {
+		log.CtxError(ctx, "issue refresh token failed: %v", err)
 		return nil, errcode.ErrInternalServer
 	}
 
 	// Kick other tokens on the same platform (single device per platform policy)
-	kickedTokens, err := s.tokenStore.KickOtherTokens(ctx, user.Id, req.PlatformId, token)
+	kickedTokens, err := s.tokenStore.KickOtherTokens(ctx, user.Id, platformId, token)
 	if err != nil {
 		log.CtxWarn(ctx, "kick other tokens failed: %v", err)
 		// Don't fail login for this
 	} else if len(kickedTokens) > 0 {
-		log.CtxInfo(ctx, "kicked %d tokens for user_id=%s, platform_id=%d", len(kickedTokens), user.Id, req.PlatformId)
+		log.CtxInfo(ctx, "kicked %d tokens for user_id=%s, platform_id=%d", len(kickedTokens), user.Id, platformId)
 	}
 
-	log.CtxInfo(ctx, "user logged in: user_id=%s, platform_id=%d", user.Id, req.PlatformId)
+	audit.Record(ctx, &audit.Event{
+		Category: audit.CategoryToken,
+		Action:   "issue_token",
+		Actor:    user.Id,
+		Detail:   map[string]any{"platform_id": platformId, "kicked_tokens": len(kickedTokens)},
+	})
+
+	s.stats.RecordActiveUser(ctx, user.Id)
+
+	log.CtxInfo(ctx, "user logged in: user_id=%s, platform_id=%d", user.Id, platformId)
 	return &LoginResponse{
-		Token:    token,
-		UserInfo: user.ToUserInfo(),
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(ttl.Seconds()),
+		UserInfo:     user.ToUserInfo(),
 	}, nil
 }
 
+// Verify confirms the email or phone of a registered user with the code sent to it.
+// Verifying an already verified user succeeds.
+func (s *AuthService) Verify(ctx context.Context, req *VerifyRequest) (err error) {
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryLogin,
+			Action:   "verify_contact",
+			Actor:    req.UserId,
+			Code:     audit.CodeOf(err),
+		})
+	}()
+
+	user, err := s.getUserForVerification(ctx, req.UserId)
+	if err != nil {
+		return err
+	}
+	if !user.NeedsVerification() {
+		return nil
+	}
+
+	ok, err := s.verifyCodes.Check(ctx, user.Id, hashVerifyCode(user.Id, req.Code), s.cfg.Verification.MaxAttempts)
+	if err != nil {
+		log.CtxError(ctx, "check verification code failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+	if !ok {
+		return errcode.ErrVerifyCodeWrong
+	}
+
+	if err = s.userRepo.MarkVerified(ctx, user.Id, time.Now().UnixMilli()); err != nil {
+		log.CtxError(ctx, "mark user verified failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "user verified: user_id=%s", user.Id)
+	return nil
+}
+
+// ResendVerifyCode sends a new verification code, replacing the pending one.
+// Sends to one user are throttled by the configured resend interval.
+func (s *AuthService) ResendVerifyCode(ctx context.Context, req *ResendVerifyCodeRequest) error {
+	user, err := s.getUserForVerification(ctx, req.UserId)
+	if err != nil {
+		return err
+	}
+	if !user.NeedsVerification() {
+		return nil
+	}
+	return s.sendVerifyCode(ctx, user)
+}
+
+// getUserForVerification loads a user that can still be verified
+func (s *AuthService) getUserForVerification(ctx context.Context, userId string) (*entity.User, error) {
+	user, err := s.userRepo.GetById(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// sendVerifyCode generates, stores and delivers a verification code to the user's email or phone
+func (s *AuthService) sendVerifyCode(ctx context.Context, user *entity.User) error {
+	allowed, err := s.verifyCodes.AcquireSendSlot(ctx, user.Id, s.cfg.Verification.ResendInterval)
+	if err != nil {
+		log.CtxError(ctx, "acquire verification send slot failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+	if !allowed {
+		return errcode.ErrVerifyTooOften
+	}
+
+	code, err := generateVerifyCode()
+	if err != nil {
+		log.CtxError(ctx, "generate verification code failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+	if err = s.verifyCodes.Save(ctx, user.Id, hashVerifyCode(user.Id, code), s.cfg.Verification.CodeTTL); err != nil {
+		log.CtxError(ctx, "save verification code failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+
+	var channel, target string
+	if user.Email != nil {
+		channel, target = constant.VerifyChannelEmail, *user.Email
+	} else {
+		channel, target = constant.VerifyChannelPhone, *user.Phone
+	}
+	if err = s.sender.SendVerificationCode(ctx, channel, target, code); err != nil {
+		log.CtxError(ctx, "deliver verification code failed: user_id=%s, channel=%s, error=%v", user.Id, channel, err)
+		// Let the user retry right away instead of waiting out the throttle
+		if releaseErr := s.verifyCodes.ReleaseSendSlot(ctx, user.Id); releaseErr != nil {
+			log.CtxWarn(ctx, "release verification send slot failed: %v", releaseErr)
+		}
+		return errcode.ErrInternalServer
+	}
+	return nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a rotated refresh token.
+// Reusing an already rotated refresh token revokes the session and all tokens of its platform.
+func (s *AuthService) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (resp *RefreshTokenResponse, err error) {
+	var userId string
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryToken,
+			Action:   "refresh_token",
+			Actor:    userId,
+			Code:     audit.CodeOf(err),
+		})
+	}()
+
+	session, refreshToken, err := s.tokenStore.RotateRefreshToken(ctx, req.RefreshToken)
+	if errors.Is(err, errcode.ErrRefreshReused) {
+		userId = session.UserId
+		log.CtxWarn(ctx, "refresh token reuse detected, revoking session: user_id=%s, platform_id=%d",
+			session.UserId, session.PlatformId)
+		if revokeErr := s.tokenStore.ForceLogoutPlatform(ctx, session.UserId, session.PlatformId); revokeErr != nil {
+			log.CtxError(ctx, "revoke platform tokens failed: %v", revokeErr)
+		}
+		return nil, errcode.ErrRefreshReused
+	}
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		return nil, e
+	}
+	if err != nil {
+		log.CtxError(ctx, "rotate refresh token failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	userId = session.UserId
+
+	// Sessions of users banned or deleted since login end at the next refresh
+	user, err := s.userRepo.GetById(ctx, session.UserId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", session.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrUserNotFound
+	}
+	if user.IsBanned() {
+		return nil, errcode.ErrUserBanned
+	}
+	if user.IsGuestExpired(entity.NowUnixMilli()) {
+		return nil, errcode.ErrGuestExpired
+	}
+
+	token, ttl, err := s.issueAccessToken(ctx, user, session.PlatformId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.tokenStore.KickOtherTokens(ctx, session.UserId, session.PlatformId, token); err != nil {
+		log.CtxWarn(ctx, "kick other tokens failed: %v", err)
+	}
+
+	return &RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(ttl.Seconds()),
+	}, nil
+}
+
+// issueAccessToken generates an access token and stores it in Redis.
+// Guest tokens carry guestScopes and do not outlive the guest account.
+func (s *AuthService) issueAccessToken(ctx context.Context, user *entity.User, platformId int) (string, time.Duration, error) {
+	ttl := s.cfg.JWT.AccessTokenTTL(platformId)
+	var scopes []string
+	if user.IsGuest() {
+		scopes = guestScopes
+		ttl = min(ttl, time.Duration(user.GuestExpiresAt-entity.NowUnixMilli())*time.Millisecond)
+	}
+	token, err := jwt.GenerateScopedToken(user.Id, platformId, scopes, s.cfg.JWT.Secret, ttl)
+	if err != nil {
+		log.CtxError(ctx, "generate token failed: %v", err)
+		return "", 0, errcode.ErrInternalServer
+	}
+	if err = s.tokenStore.StoreToken(ctx, user.Id, platformId, token); err != nil {
+		log.CtxError(ctx, "store token failed: %v", err)
+		return "", 0, errcode.ErrInternalServer
+	}
+	return token, ttl, nil
+}
+
+// ScopedTokenRequest represents a request for a restricted access token, e.g. for an embedded widget
+type ScopedTokenRequest struct {
+	Scopes     []string `json:"scopes" validate:"required"`
+	TTLSeconds int64    `json:"ttl_seconds,omitempty" validate:"min=0"` // defaults to and is capped at the platform's access token lifetime
+}
+
+// ScopedTokenResponse represents a restricted access token. It cannot be refreshed;
+// the holder of the full session asks for a new one when it expires.
+type ScopedTokenResponse struct {
+	Token     string   `json:"token"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int64    `json:"expires_in"` // seconds
+}
+
+// IssueScopedToken issues an access token for the caller restricted to req.Scopes.
+// held are the scopes of the calling credential (nil if unrestricted); the new token
+// cannot grant more than them.
+func (s *AuthService) IssueScopedToken(ctx context.Context, userId string, platformId int, held []string, req *ScopedTokenRequest) (*ScopedTokenResponse, error) {
+	if !scope.Valid(req.Scopes) || req.TTLSeconds < 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	if len(held) > 0 && !scope.Within(req.Scopes, held) {
+		return nil, errcode.ErrNoPermission
+	}
+
+	ttl := s.cfg.JWT.AccessTokenTTL(platformId)
+	if req.TTLSeconds > 0 {
+		ttl = min(time.Duration(req.TTLSeconds)*time.Second, ttl)
+	}
+	token, err := jwt.GenerateScopedToken(userId, platformId, req.Scopes, s.cfg.JWT.Secret, ttl)
+	if err != nil {
+		log.CtxError(ctx, "generate scoped token failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	if err = s.tokenStore.StoreToken(ctx, userId, platformId, token); err != nil {
+		log.CtxError(ctx, "store token failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	audit.Record(ctx, &audit.Event{
+		Category: audit.CategoryToken,
+		Action:   "issue_scoped_token",
+		Actor:    userId,
+		Detail:   map[string]any{"platform_id": platformId, "scopes": req.Scopes, "ttl_seconds": int64(ttl.Seconds())},
+	})
+	return &ScopedTokenResponse{Token: token, Scopes: req.Scopes, ExpiresIn: int64(ttl.Seconds())}, nil
+}
+
 // ValidateToken validates a token and returns claims
 func (s *AuthService) ValidateToken(ctx context.Context, token string) (*jwt.Claims, error) {
 	claims, err := jwt.ParseToken(token, s.cfg.JWT.Secret)
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+// botEndpointPrefix names the delivery endpoint of a bot webhook in the delivery log
+const botEndpointPrefix = "bot:"
+
+// BotNotifier is told about each new message and its recipients
+type BotNotifier interface {
+	NotifyBots(ctx context.Context, msg *entity.Message, userIds []string)
+}
+
+// BotEventDispatcher queues the delivery of an event to a single endpoint
+type BotEventDispatcher interface {
+	DispatchTo(ctx context.Context, endpoint *config.WebhookEndpoint, event *webhook.Event)
+}
+
+// BotCommandCall is the slash command of a message, e.g. "/weather Paris" has name "weather"
+// and args "Paris"
+type BotCommandCall struct {
+	Name string `json:"name"`
+	Args string `json:"args,omitempty"`
+}
+
+// BotMessageEvent is the data of webhook.EventBotMessage. Command is set when the text of the
+// message is a slash command for this bot; "/cmd@<bot_id>" addresses one bot of a group.
+type BotMessageEvent struct {
+	BotId   string              `json:"bot_id"`
+	RecvId  string              `json:"recv_id,omitempty"`
+	GroupId string              `json:"group_id,omitempty"`
+	Message *entity.MessageInfo `json:"message"`
+	Command *BotCommandCall     `json:"command,omitempty"`
+}
+
+// BotCommandInfo is a slash command a bot lists to clients
+type BotCommandInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// bot is a bot user with a webhook
+type bot struct {
+	endpoint config.WebhookEndpoint
+	commands []*BotCommandInfo
+}
+
+// BotService delivers the messages addressed to bot users to their webhooks. Messages sent
+// by these bots are not delivered to other bots, so two bots cannot keep replying to each other.
+type BotService struct {
+	bots       map[string]*bot
+	dispatcher BotEventDispatcher
+}
+
+// NewBotService creates a new BotService
+func NewBotService(cfg *config.BotConfig, dispatcher BotEventDispatcher) *BotService {
+	s := &BotService{bots: make(map[string]*bot, len(cfg.Webhooks)), dispatcher: dispatcher}
+	for _, w := range cfg.Webhooks {
+		b := &bot{
+			endpoint: config.WebhookEndpoint{Name: botEndpointPrefix + w.UserId, URL: w.URL, Secret: w.Secret},
+			commands: make([]*BotCommandInfo, 0, len(w.Commands)),
+		}
+		for _, cmd := range w.Commands {
+			b.commands = append(b.commands, &BotCommandInfo{Name: cmd.Name, Description: cmd.Description})
+		}
+		s.bots[w.UserId] = b
+	}
+	return s
+}
+
+// NotifyBots queues a bot.message event for each recipient of msg that has a bot webhook
+func (s *BotService) NotifyBots(ctx context.Context, msg *entity.Message, userIds []string) {
+	if s.bots[msg.SenderId] != nil {
+		return
+	}
+	var info *entity.MessageInfo
+	var command *BotCommandCall
+	var target string
+	for _, userId := range userIds {
+		b := s.bots[userId]
+		if b == nil {
+			continue
+		}
+		if info == nil {
+			info = msg.ToMessageInfo()
+			if msg.Content.Text != nil {
+				command, target = parseBotCommand(msg.Content.Text.Text)
+			}
+		}
+		data := &BotMessageEvent{BotId: userId, RecvId: msg.RecvId, GroupId: msg.GroupId, Message: info}
+		if command != nil && (target == "" || target == userId) {
+			data.Command = command
+		}
+		s.dispatcher.DispatchTo(ctx, &b.endpoint, webhook.NewEvent(ctx, webhook.EventBotMessage, data))
+	}
+}
+
+// GetCommands returns the slash commands of a bot, empty for users without a bot webhook
+func (s *BotService) GetCommands(ctx context.Context, botId string) ([]*BotCommandInfo, error) {
+	if botId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	if b := s.bots[botId]; b != nil {
+		return b.commands, nil
+	}
+	return []*BotCommandInfo{}, nil
+}
+
+// parseBotCommand parses text of the form "/name[@target] [args]", returning nil when text is
+// not a command. Names are matched case-insensitively and returned in lowercase.
+func parseBotCommand(text string) (*BotCommandCall, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return nil, ""
+	}
+	head, args, _ := strings.Cut(text[1:], " ")
+	name, target, _ := strings.Cut(head, "@")
+	name = strings.ToLower(name)
+	if name == "" || len(name) > 32 || strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+		return nil, ""
+	}
+	return &BotCommandCall{Name: name, Args: strings.TrimSpace(args)}, target
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+type botDelivery struct {
+	endpoint string
+	event    *webhook.Event
+}
+
+type fakeBotDispatcher struct {
+	deliveries []botDelivery
+}
+
+func (d *fakeBotDispatcher) DispatchTo(_ context.Context, endpoint *config.WebhookEndpoint, event *webhook.Event) {
+	d.deliveries = append(d.deliveries, botDelivery{endpoint: endpoint.Name, event: event})
+}
+
+func newTestBotService(d *fakeBotDispatcher) *BotService {
+	return NewBotService(&config.BotConfig{
+		Enabled: true,
+		Webhooks: []config.BotWebhook{
+			{UserId: "weather", URL: "http://bots/weather", Secret: "s1", Commands: []config.BotCommand{{Name: "forecast", Description: "Forecast of a city"}}},
+			{UserId: "echo", URL: "http://bots/echo", Secret: "s2"},
+		},
+	}, d)
+}
+
+func textMessage(senderId, text string) *entity.Message {
+	return &entity.Message{
+		ConversationId: "sg_g1",
+		Seq:            7,
+		SenderId:       senderId,
+		GroupId:        "g1",
+		SessionType:    2,
+		MsgType:        1,
+		Content:        entity.MessageContent{Text: &entity.TextContent{Text: text}},
+	}
+}
+
+func TestParseBotCommand(t *testing.T) {
+	tests := []struct {
+		text   string
+		name   string
+		args   string
+		target string
+	}{
+		{text: "/forecast Paris ", name: "forecast", args: "Paris"},
+		{text: "/Forecast@weather  Paris  today", name: "forecast", args: "Paris  today", target: "weather"},
+		{text: "/start", name: "start"},
+		{text: "hello /start"},
+		{text: "/"},
+		{text: "/not-a-command"},
+		{text: "/path/to/file"},
+	}
+	for _, tt := range tests {
+		call, target := parseBotCommand(tt.text)
+		if tt.name == "" {
+			if call != nil {
+				t.Errorf("parseBotCommand(%q) = %+v, want nil", tt.text, call)
+			}
+			continue
+		}
+		if call == nil || call.Name != tt.name || call.Args != tt.args || target != tt.target {
+			t.Errorf("parseBotCommand(%q) = %+v, %q", tt.text, call, target)
+		}
+	}
+}
+
+func TestNotifyBotsDeliversToBotRecipients(t *testing.T) {
+	d := &fakeBotDispatcher{}
+	s := newTestBotService(d)
+
+	s.NotifyBots(context.Background(), textMessage("alice", "/forecast@weather Paris"), []string{"alice", "bob", "weather", "echo"})
+	if len(d.deliveries) != 2 {
+		t.Fatalf("expected deliveries to both bots, got %d", len(d.deliveries))
+	}
+	for _, delivery := range d.deliveries {
+		data := delivery.event.Data.(*BotMessageEvent)
+		if delivery.event.Type != webhook.EventBotMessage || delivery.endpoint != "bot:"+data.BotId {
+			t.Fatalf("unexpected delivery %+v", delivery)
+		}
+		if data.GroupId != "g1" || data.Message.Seq != 7 || data.Message.Content.Text != "/forecast@weather Paris" {
+			t.Fatalf("unexpected event data %+v", data)
+		}
+		switch data.BotId {
+		case "weather":
+			if data.Command == nil || data.Command.Name != "forecast" || data.Command.Args != "Paris" {
+				t.Fatalf("expected command for the addressed bot, got %+v", data.Command)
+			}
+		case "echo":
+			if data.Command != nil {
+				t.Fatalf("expected no command for another bot, got %+v", data.Command)
+			}
+		}
+	}
+}
+
+func TestNotifyBotsSkipsBotSenders(t *testing.T) {
+	d := &fakeBotDispatcher{}
+	s := newTestBotService(d)
+
+	s.NotifyBots(context.Background(), textMessage("echo", "/forecast Paris"), []string{"echo", "weather"})
+	s.NotifyBots(context.Background(), textMessage("alice", "hi"), []string{"alice", "bob"})
+	if len(d.deliveries) != 0 {
+		t.Fatalf("expected no deliveries, got %d", len(d.deliveries))
+	}
+}
+
+func TestGetBotCommands(t *testing.T) {
+	s := newTestBotService(&fakeBotDispatcher{})
+
+	commands, err := s.GetCommands(context.Background(), "weather")
+	if err != nil || len(commands) != 1 || commands[0].Name != "forecast" {
+		t.Fatalf("unexpected commands %+v, err=%v", commands, err)
+	}
+	commands, err = s.GetCommands(context.Background(), "bob")
+	if err != nil || commands == nil || len(commands) != 0 {
+		t.Fatalf("expected empty commands, got %+v, err=%v", commands, err)
+	}
+	if _, err = s.GetCommands(context.Background(), ""); err == nil {
+		t.Fatalf("expected error for empty bot id")
+	}
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	defaultBroadcastListLimit = 20
+	maxBroadcastListLimit     = 100
+	maxBroadcastCohortUserIds = 10000
+	broadcastDueLimit         = 10
+	maxUserTags               = 20
+	maxUserTagLen             = 64
+
+	// A running broadcast without progress for this long lost its worker and is picked up again
+	broadcastStaleAfter = 5 * time.Minute
+
+	announcementContentType = "announcement"
+)
+
+// BroadcastService composes announcements of admins and internal services and delivers them
+// in the background as system messages to every user of the target cohort
+type BroadcastService struct {
+	broadcastRepo *repository.BroadcastRepo
+	userRepo      *repository.UserRepo
+	tagRepo       *repository.UserTagRepo
+	msgService    *MessageService
+	interval      time.Duration
+	batchSize     int
+	workers       int
+	wake          chan struct{} // signalled when a broadcast is due now
+	done          chan struct{} // closed when the delivery job exits
+}
+
+// NewBroadcastService creates a new BroadcastService
+func NewBroadcastService(repos *repository.Repositories, msgService *MessageService, cfg *config.Config) *BroadcastService {
+	return &BroadcastService{
+		broadcastRepo: repos.Broadcast,
+		userRepo:      repos.User,
+		tagRepo:       repos.UserTag,
+		msgService:    msgService,
+		interval:      cfg.Broadcast.PollInterval,
+		batchSize:     cfg.Broadcast.BatchSize,
+		workers:       cfg.Broadcast.Workers,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// CreateBroadcastRequest represents create broadcast request
+type CreateBroadcastRequest struct {
+	Text        string                 `json:"text" validate:"required,max=4096"`
+	Link        string                 `json:"link,omitempty" validate:"max=1024"`
+	Cohort      entity.BroadcastCohort `json:"cohort"`
+	ScheduledAt int64                  `json:"scheduled_at,omitempty" validate:"min=0"` // ms, 0 = now
+}
+
+// CreateBroadcast schedules an announcement; the delivery job sends it once scheduled_at is
+// reached, right away when it is due now
+func (s *BroadcastService) CreateBroadcast(ctx context.Context, operator string, req *CreateBroadcastRequest) (*entity.Broadcast, error) {
+	if req.Text == "" || len(req.Cohort.UserIds) > maxBroadcastCohortUserIds || req.ScheduledAt < 0 ||
+		len(req.Cohort.Tag) > maxUserTagLen {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.Cohort.RegisteredBefore > 0 && req.Cohort.RegisteredAfter >= req.Cohort.RegisteredBefore {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.Link != "" && !isHTTPURL(req.Link) {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	now := entity.NowUnixMilli()
+	broadcast := &entity.Broadcast{
+		Operator:    operator,
+		Text:        req.Text,
+		Link:        req.Link,
+		Status:      constant.BroadcastStatusScheduled,
+		ScheduledAt: max(req.ScheduledAt, now),
+	}
+	if len(req.Cohort.UserIds) > 0 || req.Cohort.RegisteredAfter > 0 || req.Cohort.RegisteredBefore > 0 ||
+		req.Cohort.Tag != "" {
+		data, err := json.Marshal(req.Cohort)
+		if err != nil {
+			return nil, errcode.ErrInvalidParam
+		}
+		cohort := string(data)
+		broadcast.Cohort = &cohort
+	}
+
+	if err := s.broadcastRepo.Create(ctx, broadcast); err != nil {
+		log.CtxError(ctx, "create broadcast failed: operator=%s, error=%v", operator, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "broadcast created: operator=%s, broadcast_id=%d, scheduled_at=%d",
+		operator, broadcast.Id, broadcast.ScheduledAt)
+	if broadcast.ScheduledAt <= now {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return broadcast, nil
+}
+
+// isHTTPURL checks that a link is an absolute http(s) URL
+func isHTTPURL(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ListBroadcastsRequest represents admin list broadcasts request
+type ListBroadcastsRequest struct {
+	Offset int `json:"offset" query:"offset" validate:"min=0"`
+	Limit  int `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// ListBroadcasts lists broadcasts newest first with their delivery progress
+func (s *BroadcastService) ListBroadcasts(ctx context.Context, req *ListBroadcastsRequest) ([]*entity.Broadcast, error) {
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > maxBroadcastListLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultBroadcastListLimit
+	}
+
+	broadcasts, err := s.broadcastRepo.List(ctx, req.Offset, limit)
+	if err != nil {
+		log.CtxError(ctx, "list broadcasts failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	return broadcasts, nil
+}
+
+// GetBroadcast gets a broadcast with its delivery progress
+func (s *BroadcastService) GetBroadcast(ctx context.Context, id int64) (*entity.Broadcast, error) {
+	broadcast, err := s.broadcastRepo.GetById(ctx, id)
+	if err != nil {
+		log.CtxError(ctx, "get broadcast failed: broadcast_id=%d, error=%v", id, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if broadcast == nil {
+		return nil, errcode.ErrNotFound
+	}
+	return broadcast, nil
+}
+
+// CancelBroadcast stops a scheduled or running broadcast; users already delivered to keep the message
+func (s *BroadcastService) CancelBroadcast(ctx context.Context, operator string, id int64) error {
+	broadcast, err := s.GetBroadcast(ctx, id)
+	if err != nil {
+		return err
+	}
+	if broadcast.IsFinished() {
+		return errcode.ErrInvalidParam
+	}
+
+	cancelled, err := s.broadcastRepo.Cancel(ctx, id)
+	if err != nil {
+		log.CtxError(ctx, "cancel broadcast failed: broadcast_id=%d, error=%v", id, err)
+		return errcode.ErrInternalServer
+	}
+	if !cancelled {
+		// Finished between the read and the update
+		return errcode.ErrInvalidParam
+	}
+
+	log.CtxInfo(ctx, "admin cancelled broadcast: operator=%s, broadcast_id=%d", operator, id)
+	return nil
+}
+
+// Run starts the delivery job
+func (s *BroadcastService) Run(ctx context.Context) {
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.DeliverDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+	log.CtxInfo(ctx, "broadcast delivery job started: interval=%s", s.interval)
+}
+
+// Wait blocks until the delivery job has exited after its Run ctx is done, or until ctx is done
+func (s *BroadcastService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DeliverDue delivers the broadcasts whose scheduled time has come, and resumes
+// running broadcasts abandoned by a stopped server
+func (s *BroadcastService) DeliverDue(ctx context.Context) {
+	now := time.Now()
+	broadcasts, err := s.broadcastRepo.ListDue(ctx, now.UnixMilli(), now.Add(-broadcastStaleAfter).UnixMilli(), broadcastDueLimit)
+	if err != nil {
+		log.CtxError(ctx, "list due broadcasts failed: %v", err)
+		return
+	}
+
+	for _, broadcast := range broadcasts {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, broadcast)
+	}
+}
+
+// deliver sends a broadcast to its cohort in user id order, each batch by a pool of workers,
+// saving progress after each batch so a restarted server resumes after the last delivered user
+func (s *BroadcastService) deliver(ctx context.Context, broadcast *entity.Broadcast) {
+	var cohort entity.BroadcastCohort
+	var cohortErr error
+	if broadcast.Cohort != nil {
+		cohortErr = json.Unmarshal([]byte(*broadcast.Cohort), &cohort)
+	}
+
+	var total int64
+	if broadcast.Status == constant.BroadcastStatusScheduled && cohortErr == nil {
+		var err error
+		if total, err = s.userRepo.CountCohort(ctx, &cohort); err != nil {
+			log.CtxError(ctx, "count broadcast cohort failed: broadcast_id=%d, error=%v", broadcast.Id, err)
+			return
+		}
+	}
+	claimed, err := s.broadcastRepo.Claim(ctx, broadcast, total)
+	if err != nil {
+		log.CtxError(ctx, "claim broadcast failed: broadcast_id=%d, error=%v", broadcast.Id, err)
+		return
+	}
+	if !claimed {
+		// Another server instance is delivering it
+		return
+	}
+
+	if cohortErr != nil {
+		log.CtxError(ctx, "invalid broadcast cohort: broadcast_id=%d, error=%v", broadcast.Id, cohortErr)
+		s.finish(ctx, broadcast.Id, constant.BroadcastStatusFailed, "invalid cohort")
+		return
+	}
+
+	content := announcementMessageContent(broadcast)
+
+	log.CtxInfo(ctx, "broadcast delivery started: broadcast_id=%d, resume_after=%q", broadcast.Id, broadcast.LastUserId)
+	lastUserId := broadcast.LastUserId
+	for {
+		userIds, err := s.userRepo.ListCohortIds(ctx, &cohort, lastUserId, s.batchSize)
+		if err != nil {
+			// Left running, picked up again once stale
+			log.CtxError(ctx, "list broadcast cohort failed: broadcast_id=%d, error=%v", broadcast.Id, err)
+			return
+		}
+		if len(userIds) == 0 {
+			s.finish(ctx, broadcast.Id, constant.BroadcastStatusCompleted, "")
+			return
+		}
+
+		handled, sent, failed := fanOut(ctx, userIds, s.workers, func(userId string) error {
+			_, err := s.msgService.SendSystemMessage(ctx, userId, broadcastClientMsgId(broadcast.Id, userId),
+				constant.MsgTypeCustom, content)
+			if err != nil {
+				log.CtxWarn(ctx, "deliver broadcast failed: broadcast_id=%d, user_id=%s, error=%v", broadcast.Id, userId, err)
+			}
+			return err
+		})
+		if handled > 0 {
+			lastUserId = userIds[handled-1]
+		}
+
+		// Use a fresh context so the progress of a batch interrupted by shutdown is still saved
+		running, err := s.broadcastRepo.SaveProgress(context.WithoutCancel(ctx), broadcast.Id, lastUserId, sent, failed)
+		if err != nil {
+			log.CtxError(ctx, "save broadcast progress failed: broadcast_id=%d, error=%v", broadcast.Id, err)
+			return
+		}
+		if !running {
+			log.CtxInfo(ctx, "broadcast delivery stopped, cancelled: broadcast_id=%d", broadcast.Id)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// fanOut calls send for userIds in order with up to workers calls at a time, and counts the
+// sends that succeeded and failed. Once ctx is done no further user is started; handled is the
+// number of users started, all of them finished, so userIds[:handled] are done.
+func fanOut(ctx context.Context, userIds []string, workers int, send func(userId string) error) (handled int, sent, failed int64) {
+	var sentCount, failedCount atomic.Int64
+	next := make(chan string)
+	var wg sync.WaitGroup
+	for range max(min(workers, len(userIds)), 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userId := range next {
+				if send(userId) != nil {
+					failedCount.Add(1)
+				} else {
+					sentCount.Add(1)
+				}
+			}
+		}()
+	}
+
+loop:
+	for _, userId := range userIds {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case next <- userId:
+			handled++
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(next)
+	wg.Wait()
+	return handled, sentCount.Load(), failedCount.Load()
+}
+
+func (s *BroadcastService) finish(ctx context.Context, id int64, status int32, errorMsg string) {
+	if err := s.broadcastRepo.Finish(ctx, id, status, errorMsg); err != nil {
+		log.CtxError(ctx, "finish broadcast failed: broadcast_id=%d, error=%v", id, err)
+		return
+	}
+	log.CtxInfo(ctx, "broadcast delivery finished: broadcast_id=%d, status=%d", id, status)
+}
+
+// announcementMessageContent builds the custom message content of a broadcast
+func announcementMessageContent(broadcast *entity.Broadcast) entity.MessageContent {
+	data, _ := json.Marshal(&entity.AnnouncementContent{
+		Type:        announcementContentType,
+		BroadcastId: broadcast.Id,
+		Text:        broadcast.Text,
+		Link:        broadcast.Link,
+	})
+	return entity.MessageContent{Custom: data}
+}
+
+// broadcastClientMsgId is the idempotency key of a broadcast delivery to one user.
+// The user id is hashed to fit client_msg_id (64 chars) whatever its length.
+func broadcastClientMsgId(broadcastId int64, userId string) string {
+	sum := sha256.Sum256([]byte(userId))
+	return fmt.Sprintf("bc_%d_%x", broadcastId, sum[:8])
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestCreateBroadcastRejectsInvalidRequest(t *testing.T) {
+	s := &BroadcastService{}
+	cases := []*CreateBroadcastRequest{
+		{},
+		{Text: "hello", Link: "javascript:alert(1)"},
+		{Text: "hello", Link: "/relative"},
+		{Text: "hello", Cohort: entity.BroadcastCohort{RegisteredAfter: 200, RegisteredBefore: 100}},
+		{Text: "hello", Cohort: entity.BroadcastCohort{UserIds: make([]string, maxBroadcastCohortUserIds+1)}},
+		{Text: "hello", Cohort: entity.BroadcastCohort{Tag: strings.Repeat("t", maxUserTagLen+1)}},
+	}
+	for i, req := range cases {
+		if _, err := s.CreateBroadcast(context.Background(), "ops", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("case %d: expected invalid param error, got %v", i, err)
+		}
+	}
+}
+
+func TestBroadcastClientMsgIdFitsColumn(t *testing.T) {
+	long := strings.Repeat("u", 64)
+	id := broadcastClientMsgId(1<<62, long)
+	if len(id) > 64 {
+		t.Fatalf("client_msg_id too long: %d", len(id))
+	}
+	if id != broadcastClientMsgId(1<<62, long) {
+		t.Fatalf("client_msg_id must be stable for resumed deliveries")
+	}
+	if id == broadcastClientMsgId(1<<62, long[1:]) {
+		t.Fatalf("client_msg_id must differ per user")
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	userIds := make([]string, 50)
+	for i := range userIds {
+		userIds[i] = fmt.Sprintf("u%02d", i)
+	}
+	var mu sync.Mutex
+	var running, peak int
+	seen := make(map[string]bool)
+	handled, sent, failed := fanOut(context.Background(), userIds, 4, func(userId string) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		seen[userId] = true
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		if userId == "u07" {
+			return errors.New("send failed")
+		}
+		return nil
+	})
+	if handled != 50 || sent != 49 || failed != 1 || len(seen) != 50 {
+		t.Fatalf("unexpected result: handled=%d, sent=%d, failed=%d, seen=%d", handled, sent, failed, len(seen))
+	}
+	if peak > 4 {
+		t.Fatalf("expected at most 4 concurrent sends, got %d", peak)
+	}
+}
+
+func TestFanOutStopsWhenCancelled(t *testing.T) {
+	userIds := []string{"a", "b", "c", "d", "e", "f"}
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var done []string
+	handled, sent, _ := fanOut(ctx, userIds, 1, func(userId string) error {
+		mu.Lock()
+		done = append(done, userId)
+		mu.Unlock()
+		if userId == "c" {
+			cancel()
+		}
+		return nil
+	})
+	if handled != len(done) || int64(handled) != sent || handled < 3 || handled == len(userIds) {
+		t.Fatalf("unexpected result: handled=%d, sent=%d, done=%v", handled, sent, done)
+	}
+	for i, userId := range done {
+		if userIds[i] != userId {
+			t.Fatalf("expected users handled in order, got %v", done)
+		}
+	}
+}
+
+func TestNormalizeUserTags(t *testing.T) {
+	tags, ok := normalizeUserTags([]string{"vip", "beta", "vip"})
+	if !ok || len(tags) != 2 || tags[0] != "beta" || tags[1] != "vip" {
+		t.Fatalf("unexpected tags %v, ok=%v", tags, ok)
+	}
+	if tags, ok = normalizeUserTags(nil); !ok || len(tags) != 0 {
+		t.Fatalf("expected no tags to clear, got %v, ok=%v", tags, ok)
+	}
+	tooMany := make([]string, maxUserTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("t%d", i)
+	}
+	for _, invalid := range [][]string{{""}, {strings.Repeat("t", maxUserTagLen+1)}, tooMany} {
+		if _, ok = normalizeUserTags(invalid); ok {
+			t.Fatalf("expected %d tags to be rejected", len(invalid))
+		}
+	}
+}
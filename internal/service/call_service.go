@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	// endedCallTTL keeps an ended call long enough for late signals to get ErrCallStateInvalid
+	endedCallTTL = time.Minute
+	// callTimeoutInterval is how often ring timeouts are checked
+	callTimeoutInterval = time.Second
+	// callTimeoutBatch bounds the ring timeouts handled per check
+	callTimeoutBatch = 100
+	// maxCallUserIdLen bounds the length of an invitee id, like the user_id column
+	maxCallUserIdLen = 64
+)
+
+// CallNotifier relays call signals to the online devices of users
+type CallNotifier interface {
+	NotifyCallSignal(signal *CallSignal, userIds []string, excludeConnId string)
+}
+
+// CallPusher rings the devices of callees without a connection, typically with VoIP pushes
+type CallPusher interface {
+	PushCall(ctx context.Context, call *entity.Call, signalType int32, userIds []string)
+}
+
+// PresenceChecker tells whether a user has a connection on any node
+type PresenceChecker interface {
+	IsOnline(ctx context.Context, userId string) bool
+}
+
+// CallSignalRequest is a signal sent by a participant. Invites leave CallId empty and set
+// InviteeIds and MediaType; GroupId makes a group call between members of the group.
+type CallSignalRequest struct {
+	CallId     string          `json:"call_id,omitempty" validate:"max=64"`
+	SignalType int32           `json:"signal_type" validate:"required"`
+	InviteeIds []string        `json:"invitee_ids,omitempty" validate:"max=64"` // each id checked by Signal
+	GroupId    string          `json:"group_id,omitempty" validate:"max=64"`
+	MediaType  string          `json:"media_type,omitempty"`
+	To         string          `json:"to,omitempty" validate:"max=64"` // ICE: the participant to relay to, empty for all
+	Payload    json.RawMessage `json:"payload,omitempty"`              // passed through untouched
+}
+
+// CallSignal is the signal relayed to the participants, with a snapshot of the call after it
+type CallSignal struct {
+	CallId     string          `json:"call_id"`
+	SignalType int32           `json:"signal_type"`
+	FromUserId string          `json:"from_user_id"` // empty for signals of the server
+	Call       *entity.Call    `json:"call"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	SendAt     int64           `json:"send_at"`
+}
+
+// CallService handles call signaling. Calls are kept in Redis so that signals and the
+// answer arbitration work across gateway nodes; ring timeouts are handled by Run.
+type CallService struct {
+	callRepo    *repository.CallRepo
+	tokenRepo   *repository.VoIPTokenRepo
+	userRepo    *repository.UserRepo
+	groupRepo   *repository.GroupRepo
+	notifier    CallNotifier
+	pusher      CallPusher
+	presence    PresenceChecker
+	ringTimeout time.Duration
+	maxDuration time.Duration
+	maxInvitees int
+	maxPayload  int
+}
+
+// NewCallService creates a new CallService
+func NewCallService(repos *repository.Repositories, cfg *config.CallConfig) *CallService {
+	return &CallService{
+		callRepo:    repository.NewCallRepo(repos.Redis),
+		tokenRepo:   repos.VoIPToken,
+		userRepo:    repos.User,
+		groupRepo:   repos.Group,
+		ringTimeout: cfg.RingTimeout,
+		maxDuration: cfg.MaxDuration,
+		maxInvitees: cfg.MaxInvitees,
+		maxPayload:  cfg.MaxPayloadBytes,
+	}
+}
+
+// SetNotifier sets the notifier relaying signals
+func (s *CallService) SetNotifier(notifier CallNotifier) {
+	s.notifier = notifier
+}
+
+// SetPusher sets the pusher ringing the invitees presence reports offline
+func (s *CallService) SetPusher(pusher CallPusher, presence PresenceChecker) {
+	s.pusher = pusher
+	s.presence = presence
+}
+
+// Signal handles a signal of userId sent from the connection connId (empty for HTTP requests)
+// on platformId, relays it and returns the call after it
+func (s *CallService) Signal(ctx context.Context, userId string, platformId int, connId string, req *CallSignalRequest) (*entity.Call, error) {
+	if len(req.Payload) > s.maxPayload {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.SignalType == constant.SignalTypeInvite {
+		return s.invite(ctx, userId, connId, req)
+	}
+	if req.CallId == "" || req.SignalType == constant.SignalTypeTimeout {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	var targets, ringing []string
+	call, err := s.callRepo.Update(ctx, req.CallId, func(call *entity.Call) (time.Duration, error) {
+		var err error
+		ringing = call.PendingInviteeIds()
+		if targets, err = applyCallSignal(call, userId, platformId, req, time.Now().UnixMilli()); err != nil {
+			return 0, err
+		}
+		return s.callTTL(call), nil
+	})
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			return nil, e
+		}
+		log.CtxError(ctx, "update call failed: call_id=%s, signal_type=%d, error=%v", req.CallId, req.SignalType, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if call == nil {
+		return nil, errcode.ErrCallNotFound
+	}
+	if call.IsEnded() {
+		s.stopRinging(ctx, call.Id)
+	}
+	s.pushStopped(ctx, call, req.SignalType, ringing)
+
+	s.notify(&CallSignal{
+		CallId:     call.Id,
+		SignalType: req.SignalType,
+		FromUserId: userId,
+		Call:       call,
+		Payload:    req.Payload,
+		SendAt:     time.Now().UnixMilli(),
+	}, targets, connId)
+	log.CtxInfo(ctx, "call signal: call_id=%s, user_id=%s, signal_type=%d, state=%s", call.Id, userId, req.SignalType, call.State)
+	return call, nil
+}
+
+// invite starts a call ringing the invitees
+func (s *CallService) invite(ctx context.Context, inviterId, connId string, req *CallSignalRequest) (*entity.Call, error) {
+	if req.CallId != "" || (req.MediaType != constant.CallMediaAudio && req.MediaType != constant.CallMediaVideo) {
+		return nil, errcode.ErrInvalidParam
+	}
+	if len(req.InviteeIds) == 0 || len(req.InviteeIds) > s.maxInvitees || (req.GroupId == "" && len(req.InviteeIds) != 1) {
+		return nil, errcode.ErrInvalidParam
+	}
+	seen := make(map[string]bool, len(req.InviteeIds))
+	for _, id := range req.InviteeIds {
+		if id == "" || len(id) > maxCallUserIdLen || id == inviterId || seen[id] {
+			return nil, errcode.ErrInvalidParam
+		}
+		seen[id] = true
+	}
+	if err := s.checkInvitees(ctx, inviterId, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	call := &entity.Call{
+		Id:           uuid.NewString(),
+		InviterId:    inviterId,
+		InviteeIds:   req.InviteeIds,
+		GroupId:      req.GroupId,
+		MediaType:    req.MediaType,
+		State:        constant.CallStateRinging,
+		RingDeadline: now.Add(s.ringTimeout).UnixMilli(),
+		CreatedAt:    now.UnixMilli(),
+	}
+	if s.pusher != nil {
+		call.Pushed = s.offlineUsers(ctx, call.InviteeIds)
+	}
+	if err := s.callRepo.Create(ctx, call, s.maxDuration); err != nil {
+		log.CtxError(ctx, "create call failed: inviter_id=%s, error=%v", inviterId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	s.push(ctx, call, constant.SignalTypeInvite, call.Pushed)
+
+	s.notify(&CallSignal{
+		CallId:     call.Id,
+		SignalType: constant.SignalTypeInvite,
+		FromUserId: inviterId,
+		Call:       call,
+		Payload:    req.Payload,
+		SendAt:     call.CreatedAt,
+	}, call.ParticipantIds(), connId)
+	log.CtxInfo(ctx, "call invited: call_id=%s, inviter_id=%s, invitees=%d, group_id=%s", call.Id, inviterId, len(call.InviteeIds), call.GroupId)
+	return call, nil
+}
+
+// checkInvitees checks that a single call invitee exists, or that the inviter and the
+// invitees of a group call are active members of the group
+func (s *CallService) checkInvitees(ctx context.Context, inviterId string, req *CallSignalRequest) error {
+	if req.GroupId == "" {
+		user, err := s.userRepo.GetById(ctx, req.InviteeIds[0])
+		if err != nil {
+			log.CtxError(ctx, "get invitee failed: user_id=%s, error=%v", req.InviteeIds[0], err)
+			return errcode.ErrInternalServer
+		}
+		if user == nil {
+			return errcode.ErrUserNotFound
+		}
+		return nil
+	}
+	for _, id := range append([]string{inviterId}, req.InviteeIds...) {
+		member, err := s.groupRepo.GetMember(ctx, req.GroupId, id)
+		if err != nil || !member.IsNormal() {
+			return errcode.ErrNotGroupMember
+		}
+	}
+	return nil
+}
+
+// RegisterVoIPToken stores the VoIP push token of the app of userId on platformId: a
+// PushKit token on iOS, an FCM registration token on Android
+func (s *CallService) RegisterVoIPToken(ctx context.Context, userId string, platformId int, token string) error {
+	var provider string
+	switch platformId {
+	case constant.PlatformIdIOS:
+		provider = constant.VoIPProviderAPNs
+	case constant.PlatformIdAndroid:
+		provider = constant.VoIPProviderFCM
+	default:
+		return errcode.ErrInvalidParam
+	}
+	if token == "" {
+		return errcode.ErrInvalidParam
+	}
+	err := s.tokenRepo.Upsert(ctx, &entity.VoIPToken{UserId: userId, PlatformId: platformId, Provider: provider, Token: token})
+	if err != nil {
+		log.CtxError(ctx, "register voip token failed: user_id=%s, platform_id=%d, error=%v", userId, platformId, err)
+		return errcode.ErrInternalServer
+	}
+	return nil
+}
+
+// UnregisterVoIPToken removes the VoIP push token of userId on platformId, e.g. on logout
+func (s *CallService) UnregisterVoIPToken(ctx context.Context, userId string, platformId int) error {
+	if err := s.tokenRepo.Delete(ctx, userId, platformId); err != nil {
+		log.CtxError(ctx, "unregister voip token failed: user_id=%s, platform_id=%d, error=%v", userId, platformId, err)
+		return errcode.ErrInternalServer
+	}
+	return nil
+}
+
+// Run checks ring timeouts until ctx is done
+func (s *CallService) Run(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(callTimeoutInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.handleRingTimeouts(ctx)
+			}
+		}
+	}()
+}
+
+// handleRingTimeouts marks the invitees who did not answer in time as declined, ending calls
+// nobody answered, and tells the participants
+func (s *CallService) handleRingTimeouts(ctx context.Context) {
+	now := time.Now().UnixMilli()
+	ids, err := s.callRepo.ClaimRingTimeouts(ctx, now, callTimeoutBatch)
+	if err != nil {
+		log.CtxWarn(ctx, "claim call ring timeouts failed: %v", err)
+	}
+	for _, id := range ids {
+		var timedOut bool
+		var ringing []string
+		call, err := s.callRepo.Update(ctx, id, func(call *entity.Call) (time.Duration, error) {
+			ringing = call.PendingInviteeIds()
+			timedOut = applyRingTimeout(call, now)
+			return s.callTTL(call), nil
+		})
+		if err != nil {
+			log.CtxWarn(ctx, "apply call ring timeout failed: call_id=%s, error=%v", id, err)
+			continue
+		}
+		if call == nil || !timedOut {
+			continue
+		}
+		s.pushStopped(ctx, call, constant.SignalTypeTimeout, ringing)
+		s.notify(&CallSignal{CallId: id, SignalType: constant.SignalTypeTimeout, Call: call, SendAt: now}, call.ParticipantIds(), "")
+		log.CtxInfo(ctx, "call ring timeout: call_id=%s, state=%s", id, call.State)
+	}
+}
+
+// callTTL is how long the state of call is kept after an update
+func (s *CallService) callTTL(call *entity.Call) time.Duration {
+	if call.IsEnded() {
+		return endedCallTTL
+	}
+	return max(s.maxDuration-time.Since(time.UnixMilli(call.CreatedAt)), endedCallTTL)
+}
+
+func (s *CallService) stopRinging(ctx context.Context, callId string) {
+	if err := s.callRepo.StopRinging(ctx, callId); err != nil {
+		log.CtxWarn(ctx, "stop call ringing failed: call_id=%s, error=%v", callId, err)
+	}
+}
+
+// offlineUsers returns the users without a connection
+func (s *CallService) offlineUsers(ctx context.Context, userIds []string) []string {
+	var offline []string
+	for _, userId := range userIds {
+		if !s.presence.IsOnline(ctx, userId) {
+			offline = append(offline, userId)
+		}
+	}
+	return offline
+}
+
+// pushStopped tells the devices rung by push of the invitees in ringing that the ringing
+// stopped with signalType, unless they have connected since and get the signal that way
+func (s *CallService) pushStopped(ctx context.Context, call *entity.Call, signalType int32, ringing []string) {
+	if s.pusher == nil || len(call.Pushed) == 0 {
+		return
+	}
+	s.push(ctx, call, signalType, s.offlineUsers(ctx, stoppedRinging(call, ringing)))
+}
+
+// push sends the pushes in the background, the signal is not held up by the push services
+func (s *CallService) push(ctx context.Context, call *entity.Call, signalType int32, userIds []string) {
+	if s.pusher == nil || len(userIds) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go s.pusher.PushCall(ctx, call, signalType, userIds)
+}
+
+func (s *CallService) notify(signal *CallSignal, userIds []string, excludeConnId string) {
+	if s.notifier != nil && len(userIds) > 0 {
+		s.notifier.NotifyCallSignal(signal, userIds, excludeConnId)
+	}
+}
+
+// applyCallSignal applies a signal of userId to call and returns the users to relay it to.
+// Only the first device of an invitee to accept wins; the accept relayed to the invitee's
+// other devices tells them to stop ringing.
+func applyCallSignal(call *entity.Call, userId string, platformId int, req *CallSignalRequest, now int64) ([]string, error) {
+	if !call.IsParticipant(userId) {
+		return nil, errcode.ErrNotCallParticipant
+	}
+	if call.IsEnded() {
+		return nil, errcode.ErrCallStateInvalid
+	}
+	_, answered := call.Answers[userId]
+
+	switch req.SignalType {
+	case constant.SignalTypeAccept:
+		if !call.IsInvitee(userId) || call.Rejected[userId] {
+			return nil, errcode.ErrCallStateInvalid
+		}
+		if answered {
+			return nil, errcode.ErrCallAnswered
+		}
+		if call.Answers == nil {
+			call.Answers = make(map[string]int)
+		}
+		call.Answers[userId] = platformId
+		call.State = constant.CallStateActive
+	case constant.SignalTypeReject:
+		if !call.IsInvitee(userId) || answered || call.Rejected[userId] {
+			return nil, errcode.ErrCallStateInvalid
+		}
+		if call.Rejected == nil {
+			call.Rejected = make(map[string]bool)
+		}
+		call.Rejected[userId] = true
+		if len(call.PendingInviteeIds()) == 0 && call.JoinedCount() < 2 {
+			endCall(call, now)
+		}
+	case constant.SignalTypeCancel:
+		if userId != call.InviterId || call.State != constant.CallStateRinging {
+			return nil, errcode.ErrCallStateInvalid
+		}
+		endCall(call, now)
+	case constant.SignalTypeHangup:
+		joined := userId == call.InviterId || answered
+		if call.State != constant.CallStateActive || !joined || call.Left[userId] {
+			return nil, errcode.ErrCallStateInvalid
+		}
+		if call.Left == nil {
+			call.Left = make(map[string]bool)
+		}
+		call.Left[userId] = true
+		if call.JoinedCount() < 2 {
+			endCall(call, now)
+		}
+	case constant.SignalTypeICE:
+		if call.Rejected[userId] || call.Left[userId] {
+			return nil, errcode.ErrCallStateInvalid
+		}
+		if req.To != "" {
+			if req.To == userId || !call.IsParticipant(req.To) {
+				return nil, errcode.ErrInvalidParam
+			}
+			return []string{req.To}, nil
+		}
+		targets := make([]string, 0, len(call.InviteeIds))
+		for _, id := range call.ParticipantIds() {
+			if id != userId {
+				targets = append(targets, id)
+			}
+		}
+		return targets, nil
+	default:
+		return nil, errcode.ErrInvalidParam
+	}
+	return call.ParticipantIds(), nil
+}
+
+// applyRingTimeout marks the pending invitees of call as declined once its ring deadline has
+// passed, ending it when nobody is left to talk. Returns whether anything changed.
+func applyRingTimeout(call *entity.Call, now int64) bool {
+	pending := call.PendingInviteeIds()
+	if call.IsEnded() || call.RingDeadline > now || len(pending) == 0 {
+		return false
+	}
+	if call.Rejected == nil {
+		call.Rejected = make(map[string]bool)
+	}
+	for _, id := range pending {
+		call.Rejected[id] = true
+	}
+	if call.JoinedCount() < 2 {
+		endCall(call, now)
+	}
+	return true
+}
+
+// stoppedRinging returns the invitees rung by push among ringing, the pending invitees before
+// a change of call, whose ringing the change stopped
+func stoppedRinging(call *entity.Call, ringing []string) []string {
+	pending := make(map[string]bool)
+	if !call.IsEnded() {
+		for _, id := range call.PendingInviteeIds() {
+			pending[id] = true
+		}
+	}
+	pushed := make(map[string]bool, len(call.Pushed))
+	for _, id := range call.Pushed {
+		pushed[id] = true
+	}
+	var stopped []string
+	for _, id := range ringing {
+		if pushed[id] && !pending[id] {
+			stopped = append(stopped, id)
+		}
+	}
+	return stopped
+}
+
+func endCall(call *entity.Call, now int64) {
+	call.State = constant.CallStateEnded
+	call.EndedAt = now
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func newTestCall(invitees ...string) *entity.Call {
+	return &entity.Call{Id: "c1", InviterId: "alice", InviteeIds: invitees, MediaType: constant.CallMediaAudio,
+		State: constant.CallStateRinging, RingDeadline: 1000}
+}
+
+func callSignalReq(signalType int32) *CallSignalRequest {
+	return &CallSignalRequest{CallId: "c1", SignalType: signalType}
+}
+
+func TestApplyCallSignalAnswerArbitration(t *testing.T) {
+	call := newTestCall("bob")
+
+	if _, err := applyCallSignal(call, "bob", 1, callSignalReq(constant.SignalTypeAccept), 10); err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	if call.State != constant.CallStateActive || call.Answers["bob"] != 1 {
+		t.Fatalf("unexpected call after accept %+v", call)
+	}
+	// A second device of the same invitee loses
+	if _, err := applyCallSignal(call, "bob", 2, callSignalReq(constant.SignalTypeAccept), 11); err != errcode.ErrCallAnswered {
+		t.Fatalf("expected call answered, got %v", err)
+	}
+	if _, err := applyCallSignal(call, "carol", 1, callSignalReq(constant.SignalTypeAccept), 11); err != errcode.ErrNotCallParticipant {
+		t.Fatalf("expected not participant, got %v", err)
+	}
+	if _, err := applyCallSignal(call, "alice", 1, callSignalReq(constant.SignalTypeCancel), 12); err != errcode.ErrCallStateInvalid {
+		t.Fatalf("expected cancel of an answered call to fail, got %v", err)
+	}
+
+	targets, err := applyCallSignal(call, "alice", 1, callSignalReq(constant.SignalTypeHangup), 20)
+	if err != nil || !call.IsEnded() || call.EndedAt != 20 || len(targets) != 2 {
+		t.Fatalf("unexpected hangup: targets=%v, call=%+v, err=%v", targets, call, err)
+	}
+	if _, err = applyCallSignal(call, "bob", 1, callSignalReq(constant.SignalTypeICE), 21); err != errcode.ErrCallStateInvalid {
+		t.Fatalf("expected signals of an ended call to fail, got %v", err)
+	}
+}
+
+func TestApplyCallSignalGroupCall(t *testing.T) {
+	call := newTestCall("bob", "carol")
+
+	if _, err := applyCallSignal(call, "bob", 1, callSignalReq(constant.SignalTypeReject), 10); err != nil || call.IsEnded() {
+		t.Fatalf("a reject with invitees left should not end the call: %v", err)
+	}
+	if _, err := applyCallSignal(call, "carol", 1, callSignalReq(constant.SignalTypeAccept), 11); err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+
+	ice := callSignalReq(constant.SignalTypeICE)
+	ice.To = "alice"
+	if targets, err := applyCallSignal(call, "carol", 1, ice, 12); err != nil || len(targets) != 1 || targets[0] != "alice" {
+		t.Fatalf("unexpected ice targets %v, err=%v", targets, err)
+	}
+	ice.To = ""
+	if targets, err := applyCallSignal(call, "carol", 1, ice, 12); err != nil || len(targets) != 2 {
+		t.Fatalf("expected ice to the other participants, got %v, err=%v", targets, err)
+	}
+	if _, err := applyCallSignal(call, "bob", 1, ice, 12); err != errcode.ErrCallStateInvalid {
+		t.Fatalf("expected ice of a rejected invitee to fail, got %v", err)
+	}
+
+	if _, err := applyCallSignal(call, "carol", 1, callSignalReq(constant.SignalTypeHangup), 13); err != nil || !call.IsEnded() {
+		t.Fatalf("expected the call to end when one participant is left: %v", err)
+	}
+}
+
+func TestApplyCallSignalCancelAndReject(t *testing.T) {
+	call := newTestCall("bob")
+	if _, err := applyCallSignal(call, "bob", 1, callSignalReq(constant.SignalTypeCancel), 10); err != errcode.ErrCallStateInvalid {
+		t.Fatalf("expected cancel by an invitee to fail, got %v", err)
+	}
+	if _, err := applyCallSignal(call, "alice", 1, callSignalReq(constant.SignalTypeCancel), 10); err != nil || !call.IsEnded() {
+		t.Fatalf("cancel failed: %v", err)
+	}
+
+	call = newTestCall("bob")
+	if _, err := applyCallSignal(call, "bob", 1, callSignalReq(constant.SignalTypeReject), 10); err != nil || !call.IsEnded() {
+		t.Fatalf("expected the last reject to end the call: %v", err)
+	}
+}
+
+func TestApplyRingTimeout(t *testing.T) {
+	call := newTestCall("bob", "carol")
+	if applyRingTimeout(call, 999) {
+		t.Fatalf("timeout before the deadline")
+	}
+	if !applyRingTimeout(call, 1000) || !call.IsEnded() || !call.Rejected["bob"] || !call.Rejected["carol"] {
+		t.Fatalf("expected an unanswered call to end, got %+v", call)
+	}
+
+	call = newTestCall("bob", "carol")
+	call.State = constant.CallStateActive
+	call.Answers = map[string]int{"bob": 1}
+	if !applyRingTimeout(call, 1000) || call.IsEnded() || !call.Rejected["carol"] {
+		t.Fatalf("expected an answered call to go on without carol, got %+v", call)
+	}
+	if applyRingTimeout(call, 2000) {
+		t.Fatalf("expected no change without pending invitees")
+	}
+}
+
+func TestStoppedRinging(t *testing.T) {
+	call := newTestCall("bob", "carol", "dave")
+	call.Pushed = []string{"bob", "carol"}
+	ringing := call.PendingInviteeIds()
+
+	// bob answered, carol keeps ringing, dave was never rung by push
+	call.Answers = map[string]int{"bob": 1}
+	call.Rejected = map[string]bool{"dave": true}
+	if stopped := stoppedRinging(call, ringing); len(stopped) != 1 || stopped[0] != "bob" {
+		t.Fatalf("unexpected stopped %v", stopped)
+	}
+
+	call = newTestCall("bob", "carol")
+	call.Pushed = []string{"carol"}
+	ringing = call.PendingInviteeIds()
+	endCall(call, 10)
+	if stopped := stoppedRinging(call, ringing); len(stopped) != 1 || stopped[0] != "carol" {
+		t.Fatalf("expected a cancel to stop carol, got %v", stopped)
+	}
+}
+
+func TestSignalRejectsInvalidInvitees(t *testing.T) {
+	s := &CallService{maxInvitees: 8, maxPayload: 1024}
+	for name, invitees := range map[string][]string{
+		"empty id":    {""},
+		"too long id": {strings.Repeat("b", maxCallUserIdLen+1)},
+		"inviter":     {"alice"},
+		"repeated":    {"bob", "bob"},
+	} {
+		req := &CallSignalRequest{SignalType: constant.SignalTypeInvite, InviteeIds: invitees, GroupId: "g1", MediaType: constant.CallMediaAudio}
+		if _, err := s.Signal(context.Background(), "alice", 1, "", req); err != errcode.ErrInvalidParam {
+			t.Errorf("%s: expected invalid param, got %v", name, err)
+		}
+	}
+}
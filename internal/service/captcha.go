@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// verifyCaptcha checks the captcha token sent with a request
+func (s *AuthService) verifyCaptcha(ctx context.Context, clientIP, token string) error {
+	if token == "" {
+		return errcode.ErrCaptchaRequired
+	}
+	ok, err := s.captcha.Verify(ctx, token, clientIP)
+	if err != nil {
+		log.CtxError(ctx, "verify captcha failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+	if !ok {
+		return errcode.ErrCaptchaInvalid
+	}
+	return nil
+}
+
+// loginNeedsCaptcha reports whether the user or client IP failed often enough that
+// password logins must carry a captcha. Failures are counted by the login throttle.
+func (s *AuthService) loginNeedsCaptcha(ctx context.Context, userId, clientIP string) bool {
+	if s.captcha == nil || !s.cfg.LoginThrottle.Enabled {
+		return false
+	}
+	for dim, subject := range loginSubjects(userId, clientIP) {
+		threshold := int64(s.cfg.Captcha.LoginAfterFailures)
+		if dim == loginThrottleDimIP {
+			threshold *= int64(s.cfg.LoginThrottle.IPMultiplier)
+		}
+		failures, err := s.loginAttempts.Failures(ctx, subject)
+		if err != nil {
+			log.CtxWarn(ctx, "get login failures failed: dim=%s, error=%v", dim, err)
+			continue
+		}
+		if failures >= threshold {
+			return true
+		}
+	}
+	return false
+}
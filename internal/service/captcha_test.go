@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestRegisterRequiresCaptchaWhenEnabled(t *testing.T) {
+	s := &AuthService{
+		cfg:     &config.Config{Captcha: config.CaptchaConfig{Enabled: true, OnRegister: true}},
+		captcha: stubCaptcha{valid: "solved"},
+	}
+	if _, err := s.Register(context.Background(), "10.0.0.1", &RegisterRequest{}); !errors.Is(err, errcode.ErrCaptchaRequired) {
+		t.Fatalf("expected captcha required, got %v", err)
+	}
+	if _, err := s.Register(context.Background(), "10.0.0.1", &RegisterRequest{CaptchaToken: "guess"}); !errors.Is(err, errcode.ErrCaptchaInvalid) {
+		t.Fatalf("expected captcha invalid, got %v", err)
+	}
+}
+
+type stubCaptcha struct {
+	valid string
+}
+
+func (c stubCaptcha) Verify(_ context.Context, token, _ string) (bool, error) {
+	return token == c.valid, nil
+}
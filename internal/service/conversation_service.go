@@ -5,16 +5,30 @@ import (
 
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
 	"github.com/mbeoliero/kit/log"
 )
 
+// ConversationNotifier tells online clients about conversation state changes
+type ConversationNotifier interface {
+	NotifyReadReceipt(conversationId, readerId string, readSeq int64, userIds []string)
+	NotifyReadCounts(conversationId, readerId string, counts []*MessageReadCount, userId string)
+	NotifyConversationChanged(userId, conversationId string, req *UpdateConversationRequest)
+	NotifyMessageTTLChanged(conversationId string, ttlSeconds int32, userIds []string)
+}
+
 // ConversationService handles conversation-related business logic
 type ConversationService struct {
-	convRepo *repository.ConversationRepo
-	msgRepo  *repository.MessageRepo
-	seqRepo  *repository.SeqRepo
-	repos    *repository.Repositories
+	convRepo  *repository.ConversationRepo
+	msgRepo   *repository.MessageRepo
+	seqRepo   *repository.SeqRepo
+	groupRepo *repository.GroupRepo
+	mentions  *repository.MentionRepo
+	pins      *repository.PinRepo
+	repos     *repository.Repositories
+	notifier  ConversationNotifier
 }
 
 const (
@@ -38,16 +52,27 @@ type ConversationListResult struct {
 // NewConversationService creates a new ConversationService
 func NewConversationService(repos *repository.Repositories) *ConversationService {
 	return &ConversationService{
-		convRepo: repos.Conversation,
-		msgRepo:  repos.Message,
-		seqRepo:  repos.Seq,
-		repos:    repos,
+		convRepo:  repos.Conversation,
+		msgRepo:   repos.Message,
+		seqRepo:   repos.Seq,
+		groupRepo: repos.Group,
+		mentions:  repos.Mention,
+		pins:      repos.Pin,
+		repos:     repos,
 	}
 }
 
+// SetNotifier sets the notifier of read receipts and conversation changes
+func (s *ConversationService) SetNotifier(notifier ConversationNotifier) {
+	s.notifier = notifier
+}
+
 // GetAllUserConversations gets all conversations for a user.
 // withLastMessage controls whether to include the latest message for each conversation.
 func (s *ConversationService) GetAllUserConversations(ctx context.Context, userId string, withLastMessage bool) ([]*entity.ConversationInfo, error) {
+	ctx, span := tracing.Start(ctx, "ConversationService.GetAllUserConversations")
+	defer span.End()
+
 	convWithSeqs, err := s.convRepo.GetUserConversationsWithSeq(ctx, userId)
 	if err != nil {
 		log.CtxError(ctx, "get user conversations failed: user_id=%s, error=%v", userId, err)
@@ -58,6 +83,9 @@ func (s *ConversationService) GetAllUserConversations(ctx context.Context, userI
 
 // GetUserConversationsPage gets conversations for a user with cursor pagination.
 func (s *ConversationService) GetUserConversationsPage(ctx context.Context, userId string, withLastMessage bool, limit int, cursorUpdatedAt int64, cursorConversationId string) (*ConversationListResult, error) {
+	ctx, span := tracing.Start(ctx, "ConversationService.GetUserConversationsPage")
+	defer span.End()
+
 	if limit <= 0 {
 		limit = DefaultConversationListLimit
 	}
@@ -113,6 +141,43 @@ func (s *ConversationService) buildConversationInfos(ctx context.Context, userId
 			log.CtxError(ctx, "batch get last messages failed: user_id=%s, error=%v", userId, err)
 			return nil, errcode.ErrInternalServer
 		}
+		// The sweeper may not have deleted a disappeared last message yet
+		now := entity.NowUnixMilli()
+		for conversationId, msg := range lastMsgMap {
+			if msg.Disappeared(now) {
+				delete(lastMsgMap, conversationId)
+			}
+		}
+		// Nor is a last message the user deleted for themselves shown
+		hidden, err := s.repos.Hidden.GetConversations(ctx, userId, convMaxSeq)
+		if err != nil {
+			log.CtxWarn(ctx, "get hidden last messages failed: user_id=%s, error=%v", userId, err)
+		}
+		for conversationId := range hidden {
+			delete(lastMsgMap, conversationId)
+		}
+		if err = fillLastMessageReadCounts(ctx, s.seqRepo, lastMsgMap); err != nil {
+			log.CtxWarn(ctx, "fill last message read counts failed: user_id=%s, error=%v", userId, err)
+		}
+	}
+
+	readSeqs := make(map[string]int64, len(convWithSeqs))
+	for _, conv := range convWithSeqs {
+		if conv.UnreadCount > 0 {
+			readSeqs[conv.ConversationId] = conv.ReadSeq
+		}
+	}
+	mentionStats, err := s.mentions.GetUnreadStats(ctx, userId, readSeqs)
+	if err != nil {
+		log.CtxWarn(ctx, "get unread mentions failed: user_id=%s, error=%v", userId, err)
+	}
+	conversationIds := make([]string, 0, len(convWithSeqs))
+	for _, conv := range convWithSeqs {
+		conversationIds = append(conversationIds, conv.ConversationId)
+	}
+	pinnedSeqs, err := s.pins.GetSeqs(ctx, conversationIds)
+	if err != nil {
+		log.CtxWarn(ctx, "get pinned messages failed: user_id=%s, error=%v", userId, err)
 	}
 
 	list := make([]*entity.ConversationInfo, 0, len(convWithSeqs))
@@ -134,7 +199,11 @@ func (s *ConversationService) buildConversationInfos(ctx context.Context, userId
 			ReadSeq:          conv.ReadSeq,
 			UpdatedAt:        conv.UpdatedAt,
 			LastMessage:      lastMsg,
+			TTLSeconds:       conv.TTLSeconds,
+			PinnedSeqs:       pinnedSeqs[conv.ConversationId],
 		}
+		info.SetMentionStat(mentionStats[conv.ConversationId])
+		info.ApplySaved(userId)
 		list = append(list, info)
 	}
 
@@ -158,8 +227,10 @@ func (s *ConversationService) GetConversation(ctx context.Context, userId, conve
 
 	maxSeq := int64(0)
 	readSeq := int64(0)
+	ttlSeconds := int32(0)
 	if seqConv != nil {
 		maxSeq = seqConv.MaxSeq
+		ttlSeconds = seqConv.TTLSeconds
 	}
 	if seqUser != nil {
 		readSeq = seqUser.ReadSeq
@@ -170,7 +241,7 @@ func (s *ConversationService) GetConversation(ctx context.Context, userId, conve
 		unreadCount = 0
 	}
 
-	return &entity.ConversationInfo{
+	info := &entity.ConversationInfo{
 		ConversationId:   conv.ConversationId,
 		ConversationType: conv.ConversationType,
 		PeerUserId:       conv.PeerUserId,
@@ -181,17 +252,50 @@ func (s *ConversationService) GetConversation(ctx context.Context, userId, conve
 		MaxSeq:           maxSeq,
 		ReadSeq:          readSeq,
 		UpdatedAt:        conv.UpdatedAt,
-	}, nil
+		TTLSeconds:       ttlSeconds,
+	}
+	if unreadCount > 0 {
+		mentionStats, err := s.mentions.GetUnreadStats(ctx, userId, map[string]int64{conversationId: readSeq})
+		if err != nil {
+			log.CtxWarn(ctx, "get unread mentions failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		}
+		info.SetMentionStat(mentionStats[conversationId])
+	}
+	pinnedSeqs, err := s.pins.GetSeqs(ctx, []string{conversationId})
+	if err != nil {
+		log.CtxWarn(ctx, "get pinned messages failed: conversation_id=%s, error=%v", conversationId, err)
+	}
+	info.PinnedSeqs = pinnedSeqs[conversationId]
+	info.ApplySaved(userId)
+	return info, nil
+}
+
+// GetSavedConversation gets the user's saved messages conversation, creating it on first use
+func (s *ConversationService) GetSavedConversation(ctx context.Context, userId string) (*entity.ConversationInfo, error) {
+	conversationId := entity.GenSavedConversationId(userId)
+	if err := s.convRepo.EnsureSavedConversation(ctx, userId, conversationId); err != nil {
+		log.CtxError(ctx, "ensure saved conversation failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return s.GetConversation(ctx, userId, conversationId)
 }
 
-// UpdateConversationRequest represents update conversation request
+// UpdateConversationRequest represents update conversation request. TTLSeconds sets the
+// disappearing messages timer, shared by all participants unlike the other settings.
 type UpdateConversationRequest struct {
 	RecvMsgOpt *int32 `json:"recv_msg_opt,omitempty"`
 	IsPinned   *bool  `json:"is_pinned,omitempty"`
+	TTLSeconds *int32 `json:"ttl_seconds,omitempty"`
 }
 
 // UpdateConversation updates conversation settings
 func (s *ConversationService) UpdateConversation(ctx context.Context, userId, conversationId string, req *UpdateConversationRequest) error {
+	if req.TTLSeconds != nil {
+		if err := s.setMessageTTL(ctx, userId, conversationId, *req.TTLSeconds); err != nil {
+			return err
+		}
+	}
+
 	updates := make(map[string]interface{})
 	if req.RecvMsgOpt != nil {
 		updates["recv_msg_opt"] = *req.RecvMsgOpt
@@ -209,11 +313,57 @@ func (s *ConversationService) UpdateConversation(ctx context.Context, userId, co
 		return errcode.ErrInternalServer
 	}
 
+	if s.notifier != nil {
+		s.notifier.NotifyConversationChanged(userId, conversationId, req)
+	}
 	return nil
 }
 
+// SharedSettingTargets checks that the user may change a setting shared by the participants of
+// a conversation the user has, such as its disappearing messages timer or pinned messages:
+// either participant of a single chat may, only admins in a group. Returns the participants.
+func (s *ConversationService) SharedSettingTargets(ctx context.Context, userId, conversationId string) ([]string, error) {
+	conv, err := s.convRepo.GetByOwnerAndConvId(ctx, userId, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "get conversation failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if conv == nil {
+		return nil, errcode.ErrConvNotFound
+	}
+
+	switch conv.ConversationType {
+	case constant.SessionTypeSingle:
+		participants := []string{userId}
+		if conv.PeerUserId != "" && conv.PeerUserId != userId {
+			participants = append(participants, conv.PeerUserId)
+		}
+		return participants, nil
+	case constant.SessionTypeGroup:
+		member, err := s.groupRepo.GetMember(ctx, conv.GroupId, userId)
+		if err != nil || !member.IsNormal() {
+			return nil, errcode.ErrNotGroupMember
+		}
+		if !member.IsAdmin() {
+			return nil, errcode.ErrNotGroupAdmin
+		}
+		participants, err := s.groupRepo.GetActiveMemberUserIds(ctx, conv.GroupId)
+		if err != nil {
+			log.CtxError(ctx, "get group members failed: group_id=%s, error=%v", conv.GroupId, err)
+			return nil, errcode.ErrInternalServer
+		}
+		return participants, nil
+	default:
+		// System conversations are written by the server only
+		return nil, errcode.ErrInvalidParam
+	}
+}
+
 // MarkRead marks a conversation as read up to a seq
 func (s *ConversationService) MarkRead(ctx context.Context, userId, conversationId string, readSeq int64) error {
+	ctx, span := tracing.Start(ctx, "ConversationService.MarkRead")
+	defer span.End()
+
 	if readSeq < 0 {
 		return errcode.ErrInvalidParam
 	}
@@ -242,10 +392,40 @@ func (s *ConversationService) MarkRead(ctx context.Context, userId, conversation
 		readSeq = maxReadableSeq
 	}
 
+	// Read seqs only advance; a read that does not move it sends no receipt
+	seqUser, err := s.seqRepo.GetSeqUser(ctx, userId, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "get read seq failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		return errcode.ErrInternalServer
+	}
+	var prevReadSeq int64
+	if seqUser != nil {
+		prevReadSeq = seqUser.ReadSeq
+	}
+	advanced := seqUser == nil || readSeq > prevReadSeq
+
 	if err := s.seqRepo.UpdateReadSeq(ctx, userId, conversationId, readSeq); err != nil {
 		log.CtxError(ctx, "update read seq failed: %v", err)
 		return errcode.ErrInternalServer
 	}
+	// Mentions at or below the read seq are no longer unread
+	if err := s.mentions.DeleteRead(ctx, userId, conversationId, readSeq); err != nil {
+		log.CtxWarn(ctx, "delete read mentions failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+	}
+
+	// The reader's other devices and, in single chats, the peer get a read receipt, which tells
+	// the peer that its messages up to readSeq were read
+	if s.notifier != nil && advanced {
+		targets := []string{userId}
+		if conv.PeerUserId != "" && conv.PeerUserId != userId {
+			targets = append(targets, conv.PeerUserId)
+		}
+		s.notifier.NotifyReadReceipt(conversationId, userId, readSeq, targets)
+		// In groups the senders of the messages read get their new read counts instead
+		if conv.GroupId != "" && readSeq > prevReadSeq {
+			s.notifyGroupReadCounts(ctx, conversationId, userId, prevReadSeq, readSeq)
+		}
+	}
 	return nil
 }
 
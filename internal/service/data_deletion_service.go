@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+// deleteAccountReason is recorded on deletions requested by the users themselves
+const deleteAccountReason = "self-service account deletion"
+
+// DataDeletionService handles GDPR user data purge requests
+type DataDeletionService struct {
+	userRepo     *repository.UserRepo
+	groupRepo    *repository.GroupRepo
+	convRepo     *repository.ConversationRepo
+	seqRepo      *repository.SeqRepo
+	msgRepo      *repository.MessageRepo
+	deletionRepo *repository.UserDeletionRepo
+	repos        *repository.Repositories
+	tokenStore   *jwt.TokenStore
+	kicker       UserKicker
+	cfg          *config.Config
+}
+
+// NewDataDeletionService creates a new DataDeletionService
+func NewDataDeletionService(repos *repository.Repositories, cfg *config.Config) *DataDeletionService {
+	return &DataDeletionService{
+		userRepo:     repos.User,
+		groupRepo:    repos.Group,
+		convRepo:     repos.Conversation,
+		seqRepo:      repos.Seq,
+		msgRepo:      repos.Message,
+		deletionRepo: repos.UserDeletion,
+		repos:        repos,
+		tokenStore:   jwt.NewTokenStore(repos.Redis, cfg.JWT.SessionTTL),
+		cfg:          cfg,
+	}
+}
+
+// SetKicker sets the disconnector of deleted accounts' online connections
+func (s *DataDeletionService) SetKicker(kicker UserKicker) {
+	s.kicker = kicker
+}
+
+// PurgeUserRequest represents a user data purge request
+type PurgeUserRequest struct {
+	UserId string `json:"user_id" validate:"required,max=64"`
+	Mode   string `json:"mode,omitempty" validate:"max=16"` // "tombstone" or "hard", defaults to config
+	Reason string `json:"reason,omitempty" validate:"max=512"`
+}
+
+// PurgeUser purges or anonymizes a user's profile, memberships, conversations and messages.
+// Every request is recorded in user_deletion_records, including failed runs.
+func (s *DataDeletionService) PurgeUser(ctx context.Context, operator string, req *PurgeUserRequest) (*entity.UserDeletionRecord, error) {
+	if req.UserId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = s.cfg.DataDeletion.DefaultMode
+	}
+	if mode != constant.DeletionModeTombstone && mode != constant.DeletionModeHard {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	user, err := s.userRepo.GetById(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil {
+		return nil, errcode.ErrUserNotFound
+	}
+
+	record := &entity.UserDeletionRecord{
+		UserId:   req.UserId,
+		Mode:     mode,
+		Operator: operator,
+		Reason:   req.Reason,
+		Status:   constant.DeletionStatusRunning,
+	}
+	if err = s.deletionRepo.Create(ctx, record); err != nil {
+		log.CtxError(ctx, "create deletion record failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	err = s.purge(ctx, record)
+	if err != nil {
+		log.CtxError(ctx, "purge user failed: user_id=%s, mode=%s, error=%v", req.UserId, mode, err)
+	}
+	s.finishRecord(ctx, record, err)
+	if err != nil {
+		return nil, errcode.ErrInternalServer
+	}
+
+	webhook.Emit(ctx, webhook.EventUserDeleted, &UserDeletedEvent{UserId: record.UserId, Mode: mode, Operator: operator})
+
+	log.CtxInfo(ctx, "user data purged: user_id=%s, mode=%s, operator=%s, messages=%d, conversations=%d, memberships=%d",
+		record.UserId, mode, operator, record.MessageCount, record.ConversationCount, record.MembershipCount)
+	return record, nil
+}
+
+// DeleteAccountRequest represents a self-service account deletion request
+type DeleteAccountRequest struct {
+	Password string `json:"password,omitempty" validate:"max=72"` // required for accounts that have a password
+	Confirm  string `json:"confirm" validate:"required,max=64"`   // must repeat the caller's user id
+}
+
+// DeleteAccount deletes the calling user's own account.
+// Sessions are revoked, groups left and the profile tombstoned before returning; the user's
+// messages are anonymized in the background and the deletion record completes when they are done.
+func (s *DataDeletionService) DeleteAccount(ctx context.Context, userId string, req *DeleteAccountRequest) (*entity.UserDeletionRecord, error) {
+	if req.Confirm != userId {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	user, err := s.userRepo.GetById(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrUserNotFound
+	}
+	// Accounts provisioned by OAuth have no password and rely on the confirmation alone
+	if user.Password != "" {
+		if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			return nil, errcode.ErrPasswordWrong
+		}
+	}
+
+	record := &entity.UserDeletionRecord{
+		UserId:   userId,
+		Mode:     constant.DeletionModeTombstone,
+		Operator: userId,
+		Reason:   deleteAccountReason,
+		Status:   constant.DeletionStatusRunning,
+	}
+	if err = s.deletionRepo.Create(ctx, record); err != nil {
+		log.CtxError(ctx, "create deletion record failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	now := entity.NowUnixMilli()
+	if err = s.purgeProfile(ctx, record, now); err != nil {
+		log.CtxError(ctx, "delete account failed: user_id=%s, error=%v", userId, err)
+		s.finishRecord(ctx, record, err)
+		return nil, errcode.ErrInternalServer
+	}
+	log.CtxInfo(ctx, "account deleted: user_id=%s, conversations=%d, memberships=%d",
+		userId, record.ConversationCount, record.MembershipCount)
+
+	// The caller gets a snapshot; the background run keeps updating record.
+	scheduled := *record
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		err := s.purgeMessages(ctx, record, now)
+		if err != nil {
+			log.CtxError(ctx, "anonymize messages of deleted account failed: user_id=%s, error=%v", userId, err)
+		}
+		s.finishRecord(ctx, record, err)
+		if err == nil {
+			webhook.Emit(ctx, webhook.EventUserDeleted, &UserDeletedEvent{UserId: userId, Mode: record.Mode, Operator: userId})
+		}
+	}()
+	return &scheduled, nil
+}
+
+// finishRecord stores the outcome and counters of a deletion run
+func (s *DataDeletionService) finishRecord(ctx context.Context, record *entity.UserDeletionRecord, err error) {
+	if err != nil {
+		record.Status = constant.DeletionStatusFailed
+		record.ErrorMsg = err.Error()
+	} else {
+		record.Status = constant.DeletionStatusCompleted
+	}
+	record.FinishedAt = entity.NowUnixMilli()
+
+	if updateErr := s.deletionRepo.Update(ctx, record.Id, map[string]interface{}{
+		"status":             record.Status,
+		"message_count":      record.MessageCount,
+		"conversation_count": record.ConversationCount,
+		"membership_count":   record.MembershipCount,
+		"error_msg":          record.ErrorMsg,
+		"finished_at":        record.FinishedAt,
+	}); updateErr != nil {
+		log.CtxError(ctx, "update deletion record failed: record_id=%d, error=%v", record.Id, updateErr)
+	}
+}
+
+// purge runs the deletion pipeline and fills the counters on record
+func (s *DataDeletionService) purge(ctx context.Context, record *entity.UserDeletionRecord) error {
+	now := entity.NowUnixMilli()
+	if err := s.purgeProfile(ctx, record, now); err != nil {
+		return err
+	}
+	return s.purgeMessages(ctx, record, now)
+}
+
+// purgeProfile revokes sessions and removes the profile, memberships and conversations of the user
+func (s *DataDeletionService) purgeProfile(ctx context.Context, record *entity.UserDeletionRecord, now int64) error {
+	userId := record.UserId
+	hard := record.Mode == constant.DeletionModeHard
+
+	// Revoke sessions first so the user cannot write while data is being removed.
+	if err := s.tokenStore.ForceLogoutUser(ctx, userId); err != nil {
+		log.CtxWarn(ctx, "force logout during purge failed: user_id=%s, error=%v", userId, err)
+	}
+	if s.kicker != nil {
+		s.kicker.KickUser(ctx, userId)
+	}
+
+	return s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		groupIds, err := s.groupRepo.GetUserGroupIds(ctx, tx, userId)
+		if err != nil {
+			return err
+		}
+		// Bot accounts lose their API keys
+		if err = s.repos.APIKey.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+
+		if hard {
+			record.MembershipCount, err = s.groupRepo.DeleteMembersByUser(ctx, tx, userId, groupIds)
+		} else {
+			record.MembershipCount, err = s.groupRepo.AnonymizeMembersByUser(ctx, tx, userId, groupIds)
+		}
+		if err != nil {
+			return err
+		}
+
+		if record.ConversationCount, err = s.convRepo.DeleteByOwner(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.seqRepo.DeleteSeqUsersByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.Mention.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.Reminder.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.Favorite.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.PollVote.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.Reaction.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.Hidden.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err = s.repos.UserTag.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+
+		// Unlink external identities so the next OAuth login creates a fresh account
+		if err = s.repos.UserIdentity.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		// Stop ringing the user's devices for calls
+		if err = s.repos.VoIPToken.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+		// Peers can no longer start encrypted sessions with the user
+		if err = s.repos.E2EEKey.DeleteByUser(ctx, tx, userId); err != nil {
+			return err
+		}
+
+		if hard {
+			return s.userRepo.HardDelete(ctx, tx, userId)
+		}
+		return s.userRepo.Tombstone(ctx, tx, userId, now)
+	})
+}
+
+// purgeMessages deletes or anonymizes the messages sent by the user.
+// Messages can be numerous, so they are processed in batches outside the profile transaction.
+func (s *DataDeletionService) purgeMessages(ctx context.Context, record *entity.UserDeletionRecord, now int64) error {
+	var err error
+	batchSize := s.cfg.DataDeletion.BatchSize
+	if record.Mode == constant.DeletionModeHard {
+		record.MessageCount, err = s.msgRepo.DeleteBySender(ctx, record.UserId, batchSize)
+	} else {
+		record.MessageCount, err = s.msgRepo.TombstoneBySender(ctx, record.UserId, now, batchSize)
+	}
+	if err != nil {
+		return err
+	}
+	// Favorites of other users and edit histories would otherwise keep copies of the messages
+	if _, err = s.repos.Favorite.DeleteBySender(ctx, record.UserId); err != nil {
+		return err
+	}
+	_, err = s.repos.MessageEdit.DeleteBySender(ctx, record.UserId)
+	return err
+}
+
+// ListDeletionRecords lists the deletion audit trail of a user
+func (s *DataDeletionService) ListDeletionRecords(ctx context.Context, userId string) ([]*entity.UserDeletionRecord, error) {
+	records, err := s.deletionRepo.ListByUser(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "list deletion records failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return records, nil
+}
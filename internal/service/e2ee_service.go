@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	// maxE2EEKeyId is the largest prekey id, ids are 24 bit as in the Signal protocol
+	maxE2EEKeyId = 0xFFFFFF
+	// e2eeSignatureLen is the length of an XEdDSA/Ed25519 signature
+	e2eeSignatureLen = 64
+)
+
+// E2EEService stores the public keys end-to-end encrypted clients use to establish sessions
+// (X3DH). The server only relays public keys; signatures are verified by the clients.
+type E2EEService struct {
+	keyRepo    *repository.E2EEKeyRepo
+	maxPrekeys int64
+}
+
+// NewE2EEService creates a new E2EEService
+func NewE2EEService(repos *repository.Repositories, cfg *config.E2EEConfig) *E2EEService {
+	return &E2EEService{
+		keyRepo:    repos.E2EEKey,
+		maxPrekeys: int64(cfg.MaxOneTimePrekeys),
+	}
+}
+
+// SignedPrekey is a medium-term prekey signed by the identity key of its device
+type SignedPrekey struct {
+	KeyId     int    `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// OneTimePrekey is a prekey used for a single session
+type OneTimePrekey struct {
+	KeyId     int    `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// UploadE2EEKeysRequest uploads the keys of the requesting device. The first upload needs the
+// identity key, registration id and signed prekey; later uploads rotate the signed prekey or
+// add one-time prekeys. A new identity key replaces the device and needs a new signed prekey.
+type UploadE2EEKeysRequest struct {
+	RegistrationId int              `json:"registration_id,omitempty"`
+	IdentityKey    string           `json:"identity_key,omitempty"`
+	SignedPrekey   *SignedPrekey    `json:"signed_prekey,omitempty"`
+	OneTimePrekeys []*OneTimePrekey `json:"one_time_prekeys,omitempty" validate:"max=100"`
+}
+
+// GetE2EEBundlesRequest asks for the key bundles of the devices of a user
+type GetE2EEBundlesRequest struct {
+	UserId   string `json:"user_id" validate:"required,max=64"`
+	DeviceId int    `json:"device_id,omitempty"` // a single device, 0 for all
+}
+
+// E2EEKeyBundle is what a peer needs to start a session with a device. OneTimePrekey is
+// claimed for the requester and nil once the device has run out of them.
+type E2EEKeyBundle struct {
+	UserId         string         `json:"user_id"`
+	DeviceId       int            `json:"device_id"`
+	RegistrationId int            `json:"registration_id"`
+	IdentityKey    string         `json:"identity_key"`
+	SignedPrekey   *SignedPrekey  `json:"signed_prekey"`
+	OneTimePrekey  *OneTimePrekey `json:"one_time_prekey,omitempty"`
+}
+
+// UploadKeys stores the keys of a device and returns its number of unclaimed one-time prekeys
+func (s *E2EEService) UploadKeys(ctx context.Context, userId string, deviceId int, req *UploadE2EEKeysRequest) (int64, error) {
+	if err := validateUploadE2EEKeys(req); err != nil {
+		return 0, err
+	}
+	keys, err := s.keyRepo.GetDevice(ctx, userId, deviceId)
+	if err != nil {
+		log.CtxError(ctx, "get e2ee device keys failed: user_id=%s, device_id=%d, error=%v", userId, deviceId, err)
+		return 0, errcode.ErrInternalServer
+	}
+
+	identityChanged := req.IdentityKey != "" && (keys == nil || keys.IdentityKey != req.IdentityKey)
+	if identityChanged && (req.SignedPrekey == nil || req.RegistrationId == 0) {
+		return 0, errcode.ErrInvalidParam
+	}
+	if keys == nil && !identityChanged {
+		return 0, errcode.ErrE2EEKeysNotFound
+	}
+
+	var count int64
+	if !identityChanged && len(req.OneTimePrekeys) > 0 {
+		if count, err = s.keyRepo.CountPrekeys(ctx, userId, deviceId); err != nil {
+			log.CtxError(ctx, "count e2ee prekeys failed: user_id=%s, device_id=%d, error=%v", userId, deviceId, err)
+			return 0, errcode.ErrInternalServer
+		}
+	}
+	if count+int64(len(req.OneTimePrekeys)) > s.maxPrekeys {
+		return 0, errcode.ErrE2EEPrekeyLimit
+	}
+
+	if keys == nil {
+		keys = &entity.E2EEDeviceKeys{UserId: userId, DeviceId: deviceId}
+	}
+	if req.IdentityKey != "" {
+		keys.IdentityKey = req.IdentityKey
+	}
+	if req.RegistrationId != 0 {
+		keys.RegistrationId = req.RegistrationId
+	}
+	if req.SignedPrekey != nil {
+		keys.SignedPrekeyId = req.SignedPrekey.KeyId
+		keys.SignedPrekey = req.SignedPrekey.PublicKey
+		keys.SignedPrekeySignature = req.SignedPrekey.Signature
+	}
+	prekeys := make([]*entity.E2EEOneTimePrekey, 0, len(req.OneTimePrekeys))
+	for _, prekey := range req.OneTimePrekeys {
+		prekeys = append(prekeys, &entity.E2EEOneTimePrekey{UserId: userId, DeviceId: deviceId, KeyId: prekey.KeyId, PublicKey: prekey.PublicKey})
+	}
+	if err = s.keyRepo.SaveDevice(ctx, keys, identityChanged, prekeys); err != nil {
+		log.CtxError(ctx, "save e2ee device keys failed: user_id=%s, device_id=%d, error=%v", userId, deviceId, err)
+		return 0, errcode.ErrInternalServer
+	}
+	if identityChanged {
+		log.CtxInfo(ctx, "e2ee identity key set: user_id=%s, device_id=%d", userId, deviceId)
+	}
+	return s.PrekeyCount(ctx, userId, deviceId)
+}
+
+// GetBundles returns the key bundles of the devices of a user, claiming a one-time prekey of
+// each for the requester. The requesting device itself is skipped when a user asks for the
+// bundles of their other devices.
+func (s *E2EEService) GetBundles(ctx context.Context, requesterId string, requesterDeviceId int, req *GetE2EEBundlesRequest) ([]*E2EEKeyBundle, error) {
+	devices, err := s.keyRepo.GetDevices(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "get e2ee devices failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	bundles := make([]*E2EEKeyBundle, 0, len(devices))
+	for _, device := range devices {
+		if (req.DeviceId != 0 && device.DeviceId != req.DeviceId) || (req.UserId == requesterId && device.DeviceId == requesterDeviceId) {
+			continue
+		}
+		bundle := &E2EEKeyBundle{
+			UserId:         device.UserId,
+			DeviceId:       device.DeviceId,
+			RegistrationId: device.RegistrationId,
+			IdentityKey:    device.IdentityKey,
+			SignedPrekey: &SignedPrekey{
+				KeyId:     device.SignedPrekeyId,
+				PublicKey: device.SignedPrekey,
+				Signature: device.SignedPrekeySignature,
+			},
+		}
+		prekey, err := s.keyRepo.ClaimPrekey(ctx, device.UserId, device.DeviceId)
+		if err != nil {
+			log.CtxError(ctx, "claim e2ee prekey failed: user_id=%s, device_id=%d, error=%v", device.UserId, device.DeviceId, err)
+			return nil, errcode.ErrInternalServer
+		}
+		if prekey != nil {
+			bundle.OneTimePrekey = &OneTimePrekey{KeyId: prekey.KeyId, PublicKey: prekey.PublicKey}
+		}
+		bundles = append(bundles, bundle)
+	}
+	if len(bundles) == 0 {
+		return nil, errcode.ErrE2EEKeysNotFound
+	}
+	return bundles, nil
+}
+
+// PrekeyCount returns the number of unclaimed one-time prekeys of a device, for the device
+// to upload more before it runs out
+func (s *E2EEService) PrekeyCount(ctx context.Context, userId string, deviceId int) (int64, error) {
+	count, err := s.keyRepo.CountPrekeys(ctx, userId, deviceId)
+	if err != nil {
+		log.CtxError(ctx, "count e2ee prekeys failed: user_id=%s, device_id=%d, error=%v", userId, deviceId, err)
+		return 0, errcode.ErrInternalServer
+	}
+	return count, nil
+}
+
+// ResetDevice removes the keys of a device, e.g. when the app logs out or loses its keys
+func (s *E2EEService) ResetDevice(ctx context.Context, userId string, deviceId int) error {
+	if err := s.keyRepo.DeleteDevice(ctx, userId, deviceId); err != nil {
+		log.CtxError(ctx, "delete e2ee device keys failed: user_id=%s, device_id=%d, error=%v", userId, deviceId, err)
+		return errcode.ErrInternalServer
+	}
+	log.CtxInfo(ctx, "e2ee device keys reset: user_id=%s, device_id=%d", userId, deviceId)
+	return nil
+}
+
+// validateUploadE2EEKeys checks the encoding and lengths of the uploaded keys
+func validateUploadE2EEKeys(req *UploadE2EEKeysRequest) error {
+	if req.RegistrationId < 0 || (req.IdentityKey != "" && !validE2EEPublicKey(req.IdentityKey)) {
+		return errcode.ErrInvalidParam
+	}
+	if p := req.SignedPrekey; p != nil {
+		if !validE2EEKeyId(p.KeyId) || !validE2EEPublicKey(p.PublicKey) || !validE2EESignature(p.Signature) {
+			return errcode.ErrInvalidParam
+		}
+	}
+	seen := make(map[int]bool, len(req.OneTimePrekeys))
+	for _, p := range req.OneTimePrekeys {
+		if p == nil || !validE2EEKeyId(p.KeyId) || !validE2EEPublicKey(p.PublicKey) || seen[p.KeyId] {
+			return errcode.ErrInvalidParam
+		}
+		seen[p.KeyId] = true
+	}
+	return nil
+}
+
+func validE2EEKeyId(id int) bool {
+	return id > 0 && id <= maxE2EEKeyId
+}
+
+// validE2EEPublicKey accepts base64 Curve25519 public keys, raw or with the type byte
+func validE2EEPublicKey(key string) bool {
+	b, err := base64.StdEncoding.DecodeString(key)
+	return err == nil && (len(b) == 32 || (len(b) == 33 && b[0] == 0x05))
+}
+
+func validE2EESignature(sig string) bool {
+	b, err := base64.StdEncoding.DecodeString(sig)
+	return err == nil && len(b) == e2eeSignatureLen
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestValidateUploadE2EEKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(append([]byte{0x05}, bytes.Repeat([]byte{1}, 32)...))
+	rawKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	sig := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 64))
+
+	valid := &UploadE2EEKeysRequest{
+		RegistrationId: 1234,
+		IdentityKey:    key,
+		SignedPrekey:   &SignedPrekey{KeyId: 1, PublicKey: rawKey, Signature: sig},
+		OneTimePrekeys: []*OneTimePrekey{{KeyId: 1, PublicKey: key}, {KeyId: 2, PublicKey: key}},
+	}
+	if err := validateUploadE2EEKeys(valid); err != nil {
+		t.Fatalf("expected valid keys, got %v", err)
+	}
+
+	for name, req := range map[string]*UploadE2EEKeysRequest{
+		"bad identity key":   {IdentityKey: "not base64"},
+		"short identity key": {IdentityKey: base64.StdEncoding.EncodeToString([]byte{5, 1, 2})},
+		"wrong type byte":    {IdentityKey: base64.StdEncoding.EncodeToString(append([]byte{0x06}, bytes.Repeat([]byte{1}, 32)...))},
+		"short signature":    {SignedPrekey: &SignedPrekey{KeyId: 1, PublicKey: key, Signature: key}},
+		"key id zero":        {OneTimePrekeys: []*OneTimePrekey{{KeyId: 0, PublicKey: key}}},
+		"key id too large":   {OneTimePrekeys: []*OneTimePrekey{{KeyId: maxE2EEKeyId + 1, PublicKey: key}}},
+		"duplicate key id":   {OneTimePrekeys: []*OneTimePrekey{{KeyId: 3, PublicKey: key}, {KeyId: 3, PublicKey: key}}},
+	} {
+		if err := validateUploadE2EEKeys(req); err != errcode.ErrInvalidParam {
+			t.Errorf("%s: expected invalid param, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	DefaultFavoriteListLimit = 20
+	MaxFavoriteListLimit     = 100
+)
+
+// FavoriteService lets users favorite messages they can see and list them across conversations
+type FavoriteService struct {
+	favoriteRepo  *repository.FavoriteRepo
+	msgService    *MessageService
+	maxPerUser    int
+	referenceOnly bool
+}
+
+// NewFavoriteService creates a new FavoriteService
+func NewFavoriteService(repos *repository.Repositories, msgService *MessageService, cfg *config.Config) *FavoriteService {
+	return &FavoriteService{
+		favoriteRepo:  repos.Favorite,
+		msgService:    msgService,
+		maxPerUser:    cfg.Favorite.MaxPerUser,
+		referenceOnly: cfg.Favorite.ReferenceOnly,
+	}
+}
+
+// AddFavoriteRequest represents favorite message request
+type AddFavoriteRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+}
+
+// AddFavorite favorites a message the user can see. Favoriting a message twice returns the
+// existing favorite.
+func (s *FavoriteService) AddFavorite(ctx context.Context, userId string, req *AddFavoriteRequest) (*entity.MessageFavorite, error) {
+	if req.ConversationId == "" || req.Seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	msg, err := s.msgService.GetMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.favoriteRepo.GetByMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		log.CtxError(ctx, "get favorite failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if existing != nil {
+		s.fillMessage(existing, msg)
+		return existing, nil
+	}
+	count, err := s.favoriteRepo.CountByUser(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "count favorites failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if count >= int64(s.maxPerUser) {
+		return nil, errcode.ErrTooManyRequests
+	}
+
+	favorite := &entity.MessageFavorite{
+		UserId:         userId,
+		ConversationId: req.ConversationId,
+		Seq:            req.Seq,
+		SenderId:       msg.SenderId,
+	}
+	if !s.referenceOnly {
+		favorite.Message = favoriteSnapshot(msg)
+	}
+	created, err := s.favoriteRepo.Create(ctx, favorite)
+	if err != nil {
+		log.CtxError(ctx, "create favorite failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if !created {
+		// Favorited concurrently
+		if favorite, err = s.favoriteRepo.GetByMessage(ctx, userId, req.ConversationId, req.Seq); err != nil || favorite == nil {
+			log.CtxError(ctx, "get favorite failed: user_id=%s, error=%v", userId, err)
+			return nil, errcode.ErrInternalServer
+		}
+	}
+	s.fillMessage(favorite, msg)
+	return favorite, nil
+}
+
+// ListFavoritesRequest represents list favorite messages request. Cursor is the next_cursor
+// of the previous page.
+type ListFavoritesRequest struct {
+	Cursor int64 `json:"cursor" query:"cursor" validate:"min=0"`
+	Limit  int   `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// FavoriteListResult is a page of favorite messages, newest first
+type FavoriteListResult struct {
+	List       []*entity.MessageFavorite `json:"list"`
+	HasMore    bool                      `json:"has_more"`
+	NextCursor int64                     `json:"next_cursor,omitempty"`
+}
+
+// ListFavorites lists the favorites of a user across conversations, newest first.
+// In reference only mode messages are loaded live and left out once the user can no longer see them.
+func (s *FavoriteService) ListFavorites(ctx context.Context, userId string, req *ListFavoritesRequest) (*FavoriteListResult, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultFavoriteListLimit
+	}
+	limit = min(limit, MaxFavoriteListLimit)
+
+	favorites, err := s.favoriteRepo.ListByUser(ctx, userId, req.Cursor, limit+1)
+	if err != nil {
+		log.CtxError(ctx, "list favorites failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	result := favoritePage(favorites, limit)
+
+	if s.referenceOnly {
+		for _, favorite := range result.List {
+			msg, err := s.msgService.GetMessage(ctx, userId, favorite.ConversationId, favorite.Seq)
+			if err != nil && err != errcode.ErrMessageNotFound && err != errcode.ErrNoPermission {
+				return nil, err
+			}
+			s.fillMessage(favorite, msg)
+		}
+	}
+	return result, nil
+}
+
+// RemoveFavoriteRequest represents remove favorite message request
+type RemoveFavoriteRequest struct {
+	FavoriteId int64 `json:"favorite_id" validate:"min=1"`
+}
+
+// RemoveFavorite removes a favorite of a user
+func (s *FavoriteService) RemoveFavorite(ctx context.Context, userId string, id int64) error {
+	removed, err := s.favoriteRepo.Delete(ctx, userId, id)
+	if err != nil {
+		log.CtxError(ctx, "remove favorite failed: user_id=%s, favorite_id=%d, error=%v", userId, id, err)
+		return errcode.ErrInternalServer
+	}
+	if !removed {
+		return errcode.ErrNotFound
+	}
+	return nil
+}
+
+// fillMessage sets the live message on a favorite stored without a snapshot; msg is nil
+// when the user can no longer see it
+func (s *FavoriteService) fillMessage(favorite *entity.MessageFavorite, msg *entity.Message) {
+	if favorite.Message == nil && msg != nil {
+		favorite.Message = msg.ToMessageInfo()
+	}
+}
+
+// favoriteSnapshot copies a message for a favorite. The copy never disappears: the favorite
+// outlives a message deleted by the disappearing messages timer, so its expiry is dropped.
+func favoriteSnapshot(msg *entity.Message) *entity.MessageInfo {
+	info := msg.ToMessageInfo()
+	info.ExpiresAt = 0
+	return info
+}
+
+// favoritePage cuts favorites loaded with limit+1 down to a page of limit
+func favoritePage(favorites []*entity.MessageFavorite, limit int) *FavoriteListResult {
+	result := &FavoriteListResult{List: favorites}
+	if len(favorites) > limit {
+		result.List = favorites[:limit]
+		result.HasMore = true
+		result.NextCursor = result.List[limit-1].Id
+	}
+	if result.List == nil {
+		result.List = []*entity.MessageFavorite{}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestAddFavoriteRejectsInvalidRequest(t *testing.T) {
+	s := &FavoriteService{}
+	for i, req := range []*AddFavoriteRequest{{Seq: 1}, {ConversationId: "si_a_b"}} {
+		if _, err := s.AddFavorite(context.Background(), "a", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("case %d: expected invalid param error, got %v", i, err)
+		}
+	}
+}
+
+func TestFavoritePage(t *testing.T) {
+	favorites := []*entity.MessageFavorite{{Id: 9}, {Id: 7}, {Id: 4}}
+
+	page := favoritePage(favorites, 2)
+	if len(page.List) != 2 || !page.HasMore || page.NextCursor != 7 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	page = favoritePage(favorites, 3)
+	if len(page.List) != 3 || page.HasMore || page.NextCursor != 0 {
+		t.Fatalf("unexpected last page: %+v", page)
+	}
+
+	page = favoritePage(nil, 20)
+	if page.List == nil || len(page.List) != 0 || page.HasMore {
+		t.Fatalf("expected empty list, got %+v", page)
+	}
+}
+
+func TestFillMessageKeepsSnapshot(t *testing.T) {
+	s := &FavoriteService{}
+	live := &entity.Message{ConversationId: "si_a_b", Seq: 3, Content: entity.MessageContent{Text: &entity.TextContent{Text: "edited"}}}
+
+	favorite := &entity.MessageFavorite{Message: &entity.MessageInfo{Seq: 3, Content: entity.FlatMessageContent{Text: "original"}}}
+	s.fillMessage(favorite, live)
+	if favorite.Message.Content.Text != "original" {
+		t.Fatalf("expected snapshot kept, got %q", favorite.Message.Content.Text)
+	}
+
+	favorite = &entity.MessageFavorite{ConversationId: "si_a_b", Seq: 3}
+	s.fillMessage(favorite, live)
+	if favorite.Message == nil || favorite.Message.Content.Text != "edited" {
+		t.Fatalf("expected live message, got %+v", favorite.Message)
+	}
+
+	favorite = &entity.MessageFavorite{ConversationId: "si_a_b", Seq: 3}
+	s.fillMessage(favorite, nil)
+	if favorite.Message != nil {
+		t.Fatalf("expected no message, got %+v", favorite.Message)
+	}
+}
+
+func TestFavoriteSnapshotDropsExpiry(t *testing.T) {
+	msg := &entity.Message{ConversationId: "si_a_b", Seq: 3, SendAt: 1000, ExpiresAt: 61000,
+		Content: entity.MessageContent{Text: &entity.TextContent{Text: "this disappears"}}}
+
+	snapshot := favoriteSnapshot(msg)
+	if snapshot.ExpiresAt != 0 || snapshot.Content.Text != "this disappears" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if msg.ExpiresAt != 61000 {
+		t.Fatalf("expected the message left alone, got expires_at %d", msg.ExpiresAt)
+	}
+}
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+const (
+	maxGroupMuteSeconds  = int64(30 * 24 * time.Hour / time.Second)
+	defaultInviteLinkTTL = 7 * 24 * time.Hour
+	maxInviteLinkTTL     = 30 * 24 * time.Hour
+	inviteLinkTokenBytes = 16
+)
+
+// UpdateGroupInfoRequest represents a group settings update, empty fields are left unchanged
+type UpdateGroupInfoRequest struct {
+	Name         string `json:"name,omitempty" validate:"max=128"`
+	Introduction string `json:"introduction,omitempty" validate:"max=512"`
+	Avatar       string `json:"avatar,omitempty" validate:"max=512"`
+	MuteAll      *bool  `json:"mute_all,omitempty"`
+}
+
+// CreateInviteLinkRequest represents invite link creation request
+type CreateInviteLinkRequest struct {
+	TtlSeconds int64 `json:"ttl_seconds,omitempty" validate:"min=0"` // 0 for 7 days, at most 30 days
+	MaxUses    int64 `json:"max_uses,omitempty" validate:"min=0"`    // 0 for unlimited
+}
+
+// KickMembers removes members from a group. Admins may kick members, only the owner
+// may kick admins. Kicked members stop seeing new messages like members who quit.
+func (s *GroupService) KickMembers(ctx context.Context, groupId, operatorId string, userIds []string) error {
+	conversationId := entity.GenGroupConversationId(groupId)
+
+	err := s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		operator, err := s.requireGroupAdmin(ctx, tx, groupId, operatorId)
+		if err != nil {
+			return err
+		}
+
+		maxSeq, err := s.seqRepo.GetMaxSeqWithLock(ctx, tx, conversationId)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		for _, userId := range userIds {
+			target, err := s.groupRepo.GetMemberWithTx(ctx, tx, groupId, userId)
+			if err != nil || !target.IsNormal() {
+				return errcode.ErrNotGroupMember
+			}
+			if err := checkCanManageMember(operator, target); err != nil {
+				return err
+			}
+			if err := s.groupRepo.UpdateMemberStatus(ctx, tx, groupId, userId, constant.GroupMemberStatusKicked); err != nil {
+				return err
+			}
+			if err := s.seqRepo.SetSeqUserMaxSeq(ctx, tx, userId, conversationId, maxSeq); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return e
+		}
+		log.CtxError(ctx, "kick group members failed: group_id=%s, operator=%s, error=%v", groupId, operatorId, err)
+		return errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "group members kicked: group_id=%s, operator=%s, user_ids=%v", groupId, operatorId, userIds)
+	s.stats.RecordGroupMembership(ctx, groupId, 0, len(userIds))
+	s.emitGroupEvent(ctx, webhook.EventGroupMemberKicked, groupId, &GroupEvent{OperatorId: operatorId, UserIds: userIds})
+	return nil
+}
+
+// MuteMember mutes a member for durationSeconds, 0 lifts the mute
+func (s *GroupService) MuteMember(ctx context.Context, groupId, operatorId, userId string, durationSeconds int64) error {
+	if durationSeconds < 0 || durationSeconds > maxGroupMuteSeconds {
+		return errcode.ErrInvalidParam
+	}
+	var mutedUntil int64
+	if durationSeconds > 0 {
+		mutedUntil = entity.NowUnixMilli() + durationSeconds*1000
+	}
+
+	err := s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		operator, err := s.requireGroupAdmin(ctx, tx, groupId, operatorId)
+		if err != nil {
+			return err
+		}
+		target, err := s.groupRepo.GetMemberWithTx(ctx, tx, groupId, userId)
+		if err != nil || !target.IsNormal() {
+			return errcode.ErrNotGroupMember
+		}
+		if err := checkCanManageMember(operator, target); err != nil {
+			return err
+		}
+		return s.groupRepo.UpdateMember(ctx, tx, groupId, userId, map[string]interface{}{"muted_until": mutedUntil})
+	})
+
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return e
+		}
+		log.CtxError(ctx, "mute group member failed: group_id=%s, user_id=%s, error=%v", groupId, userId, err)
+		return errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "group member mute updated: group_id=%s, user_id=%s, muted_until=%d", groupId, userId, mutedUntil)
+	return nil
+}
+
+// TransferOwnership hands the group over to another active member; the previous
+// owner stays in the group as a normal member
+func (s *GroupService) TransferOwnership(ctx context.Context, groupId, ownerId, newOwnerId string) error {
+	if ownerId == newOwnerId {
+		return errcode.ErrInvalidParam
+	}
+
+	err := s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		owner, err := s.requireGroupAdmin(ctx, tx, groupId, ownerId)
+		if err != nil {
+			if err == errcode.ErrNotGroupAdmin {
+				return errcode.ErrNotGroupOwner
+			}
+			return err
+		}
+		if !owner.IsOwner() {
+			return errcode.ErrNotGroupOwner
+		}
+		newOwner, err := s.groupRepo.GetMemberWithTx(ctx, tx, groupId, newOwnerId)
+		if err != nil || !newOwner.IsNormal() {
+			return errcode.ErrNotGroupMember
+		}
+
+		if err := s.groupRepo.UpdateMember(ctx, tx, groupId, newOwnerId, map[string]interface{}{"role_level": constant.RoleLevelOwner}); err != nil {
+			return err
+		}
+		return s.groupRepo.UpdateMember(ctx, tx, groupId, ownerId, map[string]interface{}{"role_level": constant.RoleLevelMember})
+	})
+
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return e
+		}
+		log.CtxError(ctx, "transfer group ownership failed: group_id=%s, new_owner=%s, error=%v", groupId, newOwnerId, err)
+		return errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "group ownership transferred: group_id=%s, from=%s, to=%s", groupId, ownerId, newOwnerId)
+	s.emitGroupEvent(ctx, webhook.EventGroupOwnershipTransferred, groupId, &GroupEvent{OperatorId: ownerId, NewOwnerId: newOwnerId})
+	return nil
+}
+
+// SetGroupAdmin promotes an active member to admin or demotes an admin to a normal member;
+// only the owner may do it. Setting the role the member already has changes nothing.
+func (s *GroupService) SetGroupAdmin(ctx context.Context, groupId, ownerId, userId string, admin bool) error {
+	if userId == "" || ownerId == userId {
+		return errcode.ErrInvalidParam
+	}
+	roleLevel := int32(constant.RoleLevelMember)
+	if admin {
+		roleLevel = constant.RoleLevelAdmin
+	}
+
+	changed := false
+	err := s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		owner, err := s.requireGroupAdmin(ctx, tx, groupId, ownerId)
+		if err != nil {
+			if err == errcode.ErrNotGroupAdmin {
+				return errcode.ErrNotGroupOwner
+			}
+			return err
+		}
+		if !owner.IsOwner() {
+			return errcode.ErrNotGroupOwner
+		}
+		target, err := s.groupRepo.GetMemberWithTx(ctx, tx, groupId, userId)
+		if err != nil || !target.IsNormal() {
+			return errcode.ErrNotGroupMember
+		}
+		if target.RoleLevel == roleLevel {
+			return nil
+		}
+		changed = true
+		return s.groupRepo.UpdateMember(ctx, tx, groupId, userId, map[string]interface{}{"role_level": roleLevel})
+	})
+
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return e
+		}
+		log.CtxError(ctx, "set group admin failed: group_id=%s, user_id=%s, admin=%t, error=%v", groupId, userId, admin, err)
+		return errcode.ErrInternalServer
+	}
+	if !changed {
+		return nil
+	}
+
+	log.CtxInfo(ctx, "group admin updated: group_id=%s, user_id=%s, admin=%t", groupId, userId, admin)
+	eventType := webhook.EventGroupAdminDemoted
+	if admin {
+		eventType = webhook.EventGroupAdminPromoted
+	}
+	s.emitGroupEvent(ctx, eventType, groupId, &GroupEvent{OperatorId: ownerId, UserIds: []string{userId}})
+	return nil
+}
+
+// DismissGroup dismisses the group; only the owner may do it. Members keep their history
+// but the group no longer accepts messages, joins or changes.
+func (s *GroupService) DismissGroup(ctx context.Context, groupId, ownerId string) error {
+	err := s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		owner, err := s.requireGroupAdmin(ctx, tx, groupId, ownerId)
+		if err != nil {
+			if err == errcode.ErrNotGroupAdmin {
+				return errcode.ErrNotGroupOwner
+			}
+			return err
+		}
+		if !owner.IsOwner() {
+			return errcode.ErrNotGroupOwner
+		}
+		return s.groupRepo.UpdateWithTx(ctx, tx, groupId, map[string]interface{}{"status": constant.GroupStatusDismissed})
+	})
+
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return e
+		}
+		log.CtxError(ctx, "dismiss group failed: group_id=%s, owner=%s, error=%v", groupId, ownerId, err)
+		return errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "group dismissed: group_id=%s, owner=%s", groupId, ownerId)
+	s.emitGroupEvent(ctx, webhook.EventGroupDismissed, groupId, &GroupEvent{OperatorId: ownerId})
+	return nil
+}
+
+// UpdateGroupInfo updates group name, introduction, avatar and the group-wide mute
+func (s *GroupService) UpdateGroupInfo(ctx context.Context, groupId, operatorId string, req *UpdateGroupInfoRequest) (*entity.GroupInfo, error) {
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Introduction != "" {
+		updates["introduction"] = req.Introduction
+	}
+	if req.Avatar != "" {
+		updates["avatar"] = req.Avatar
+	}
+	if req.MuteAll != nil {
+		updates["mute_all"] = *req.MuteAll
+	}
+
+	if err := s.updateGroupAsAdmin(ctx, groupId, operatorId, updates); err != nil {
+		return nil, err
+	}
+	return s.GetGroupInfo(ctx, groupId)
+}
+
+// SetAnnouncement replaces the group announcement, empty content clears it
+func (s *GroupService) SetAnnouncement(ctx context.Context, groupId, operatorId, content string) (*entity.GroupInfo, error) {
+	updates := map[string]interface{}{
+		"announcement":            content,
+		"announcement_updated_at": entity.NowUnixMilli(),
+		"announcement_user_id":    operatorId,
+	}
+	if err := s.updateGroupAsAdmin(ctx, groupId, operatorId, updates); err != nil {
+		return nil, err
+	}
+	return s.GetGroupInfo(ctx, groupId)
+}
+
+// CreateInviteLink creates an invite link admins can share with users outside the group
+func (s *GroupService) CreateInviteLink(ctx context.Context, groupId, operatorId string, req *CreateInviteLinkRequest) (*entity.GroupInviteLink, error) {
+	if req.TtlSeconds < 0 || req.TtlSeconds > int64(maxInviteLinkTTL/time.Second) || req.MaxUses < 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	ttl := defaultInviteLinkTTL
+	if req.TtlSeconds > 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+
+	if _, err := s.requireGroupAdmin(ctx, s.repos.DB, groupId, operatorId); err != nil {
+		return nil, err
+	}
+
+	token, err := newInviteLinkToken()
+	if err != nil {
+		log.CtxError(ctx, "generate invite link token failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	link := &entity.GroupInviteLink{
+		Token:         token,
+		GroupId:       groupId,
+		CreatorUserId: operatorId,
+		MaxUses:       req.MaxUses,
+		ExpiresAt:     time.Now().Add(ttl).UnixMilli(),
+	}
+	if err := s.invites.Save(ctx, link, ttl); err != nil {
+		log.CtxError(ctx, "save invite link failed: group_id=%s, error=%v", groupId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return link, nil
+}
+
+// RevokeInviteLink invalidates an invite link of the group
+func (s *GroupService) RevokeInviteLink(ctx context.Context, groupId, operatorId, token string) error {
+	if _, err := s.requireGroupAdmin(ctx, s.repos.DB, groupId, operatorId); err != nil {
+		return err
+	}
+
+	link, err := s.invites.Get(ctx, token)
+	if err != nil {
+		log.CtxError(ctx, "get invite link failed: error=%v", err)
+		return errcode.ErrInternalServer
+	}
+	if link == nil || link.GroupId != groupId {
+		return errcode.ErrInviteLinkInvalid
+	}
+	if err := s.invites.Delete(ctx, token); err != nil {
+		log.CtxError(ctx, "delete invite link failed: error=%v", err)
+		return errcode.ErrInternalServer
+	}
+	return nil
+}
+
+// JoinGroupByInviteLink joins the user to the group of the invite link and returns the group id.
+// The link creator is recorded as the inviter.
+func (s *GroupService) JoinGroupByInviteLink(ctx context.Context, userId, token string) (string, error) {
+	link, err := s.invites.Get(ctx, token)
+	if err != nil {
+		log.CtxError(ctx, "get invite link failed: error=%v", err)
+		return "", errcode.ErrInternalServer
+	}
+	if link == nil {
+		return "", errcode.ErrInviteLinkInvalid
+	}
+
+	// Check membership first so members opening the link again do not use it up
+	isMember, err := s.groupRepo.IsActiveMember(ctx, link.GroupId, userId)
+	if err != nil {
+		log.CtxError(ctx, "check group member failed: group_id=%s, error=%v", link.GroupId, err)
+		return "", errcode.ErrInternalServer
+	}
+	if isMember {
+		return "", errcode.ErrAlreadyGroupMember
+	}
+
+	groupId, err := s.invites.Consume(ctx, token)
+	if err != nil {
+		log.CtxError(ctx, "consume invite link failed: error=%v", err)
+		return "", errcode.ErrInternalServer
+	}
+	if groupId == "" {
+		return "", errcode.ErrInviteLinkInvalid
+	}
+
+	if err := s.JoinGroup(ctx, groupId, userId, link.CreatorUserId); err != nil {
+		return "", err
+	}
+	return groupId, nil
+}
+
+// GetGroupReport returns the engagement statistics of a group to its admins, see
+// StatsService.GetGroupReport
+func (s *GroupService) GetGroupReport(ctx context.Context, groupId, operatorId, startDate, endDate string) (*GroupReportResponse, error) {
+	if _, err := s.requireGroupAdmin(ctx, s.repos.DB, groupId, operatorId); err != nil {
+		return nil, err
+	}
+	return s.stats.GetGroupReport(ctx, groupId, startDate, endDate)
+}
+
+// updateGroupAsAdmin applies group column updates after checking the operator is an admin
+func (s *GroupService) updateGroupAsAdmin(ctx context.Context, groupId, operatorId string, updates map[string]interface{}) error {
+	err := s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		if _, err := s.requireGroupAdmin(ctx, tx, groupId, operatorId); err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return s.groupRepo.UpdateWithTx(ctx, tx, groupId, updates)
+	})
+
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return e
+		}
+		log.CtxError(ctx, "update group failed: group_id=%s, operator=%s, error=%v", groupId, operatorId, err)
+		return errcode.ErrInternalServer
+	}
+	return nil
+}
+
+// requireGroupAdmin returns the operator's membership when the group is active and the
+// operator is one of its admins
+func (s *GroupService) requireGroupAdmin(ctx context.Context, tx *gorm.DB, groupId, operatorId string) (*entity.GroupMember, error) {
+	group, err := s.groupRepo.GetByIdWithTx(ctx, tx, groupId)
+	if err != nil {
+		return nil, errcode.ErrGroupNotFound
+	}
+	if !group.IsNormal() {
+		return nil, errcode.ErrGroupDismissed
+	}
+
+	operator, err := s.groupRepo.GetMemberWithTx(ctx, tx, groupId, operatorId)
+	if err != nil || !operator.IsNormal() {
+		return nil, errcode.ErrNotGroupMember
+	}
+	if !operator.IsAdmin() {
+		return nil, errcode.ErrNotGroupAdmin
+	}
+	return operator, nil
+}
+
+// checkCanManageMember reports whether operator may kick or mute target: nobody manages
+// the owner and only the owner manages other admins
+func checkCanManageMember(operator, target *entity.GroupMember) error {
+	if target.IsOwner() {
+		return errcode.ErrCannotKickOwner
+	}
+	if target.IsAdmin() && !operator.IsOwner() {
+		return errcode.ErrNotGroupOwner
+	}
+	return nil
+}
+
+// newInviteLinkToken returns a random URL-safe invite link token
+func newInviteLinkToken() (string, error) {
+	buf := make([]byte, inviteLinkTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
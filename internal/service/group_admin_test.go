@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestCheckCanManageMember(t *testing.T) {
+	owner := &entity.GroupMember{RoleLevel: constant.RoleLevelOwner}
+	admin := &entity.GroupMember{RoleLevel: constant.RoleLevelAdmin}
+	member := &entity.GroupMember{RoleLevel: constant.RoleLevelMember}
+
+	cases := []struct {
+		name     string
+		operator *entity.GroupMember
+		target   *entity.GroupMember
+		want     error
+	}{
+		{"owner manages admin", owner, admin, nil},
+		{"owner manages member", owner, member, nil},
+		{"admin manages member", admin, member, nil},
+		{"admin cannot manage admin", admin, admin, errcode.ErrNotGroupOwner},
+		{"nobody manages owner", admin, owner, errcode.ErrCannotKickOwner},
+		{"owner cannot manage self", owner, owner, errcode.ErrCannotKickOwner},
+	}
+	for _, c := range cases {
+		if err := checkCanManageMember(c.operator, c.target); err != c.want {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.want, err)
+		}
+	}
+}
+
+func TestGroupMemberIsMuted(t *testing.T) {
+	m := &entity.GroupMember{MutedUntil: 2000}
+	if !m.IsMuted(1999) {
+		t.Fatal("expected member to be muted before muted_until")
+	}
+	if m.IsMuted(2000) {
+		t.Fatal("expected mute to end at muted_until")
+	}
+	if (&entity.GroupMember{}).IsMuted(1) {
+		t.Fatal("expected zero muted_until to mean not muted")
+	}
+}
+
+func TestSetGroupAdminRejectsInvalidRequest(t *testing.T) {
+	s := &GroupService{}
+	if err := s.SetGroupAdmin(context.Background(), "g1", "owner", "", true); err != errcode.ErrInvalidParam {
+		t.Fatalf("expected invalid param for a missing user, got %v", err)
+	}
+	if err := s.SetGroupAdmin(context.Background(), "g1", "owner", "owner", false); err != errcode.ErrInvalidParam {
+		t.Fatalf("expected invalid param for the owner's own role, got %v", err)
+	}
+}
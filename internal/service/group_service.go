@@ -6,9 +6,11 @@ import (
 	"github.com/mbeoliero/kit/log"
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/analytics"
 	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 	"github.com/ZaiSpace/nexo_im/pkg/idgen"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
 	"gorm.io/gorm"
 )
 
@@ -16,7 +18,9 @@ import (
 type GroupService struct {
 	groupRepo *repository.GroupRepo
 	seqRepo   *repository.SeqRepo
+	invites   *repository.GroupInviteRepo
 	repos     *repository.Repositories
+	stats     *StatsService
 }
 
 // NewGroupService creates a new GroupService
@@ -24,16 +28,22 @@ func NewGroupService(repos *repository.Repositories) *GroupService {
 	return &GroupService{
 		groupRepo: repos.Group,
 		seqRepo:   repos.Seq,
+		invites:   repository.NewGroupInviteRepo(repos.Redis),
 		repos:     repos,
 	}
 }
 
+// SetStats sets the stats recorder
+func (s *GroupService) SetStats(stats *StatsService) {
+	s.stats = stats
+}
+
 // CreateGroupRequest represents group creation request
 type CreateGroupRequest struct {
-	Name         string   `json:"name"`
-	Introduction string   `json:"introduction,omitempty"`
-	Avatar       string   `json:"avatar,omitempty"`
-	MemberIds    []string `json:"member_ids,omitempty"` // Initial members to invite
+	Name         string   `json:"name" validate:"required,max=128"`
+	Introduction string   `json:"introduction,omitempty" validate:"max=512"`
+	Avatar       string   `json:"avatar,omitempty" validate:"max=512"`
+	MemberIds    []string `json:"member_ids,omitempty" validate:"max=500"` // Initial members to invite
 }
 
 // CreateGroup creates a new group
@@ -54,7 +64,9 @@ func (s *GroupService) CreateGroup(ctx context.Context, creatorId string, req *C
 		CreatorUserId: creatorId,
 	}
 
+	var memberIds []string
 	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		memberIds = memberIds[:0]
 		// Create group
 		group.CreatedAt = now
 		group.UpdatedAt = now
@@ -91,6 +103,7 @@ func (s *GroupService) CreateGroup(ctx context.Context, creatorId string, req *C
 			if memberId == creatorId {
 				continue
 			}
+			memberIds = append(memberIds, memberId)
 			member := &entity.GroupMember{
 				GroupId:       groupId,
 				UserId:        memberId,
@@ -116,7 +129,16 @@ func (s *GroupService) CreateGroup(ctx context.Context, creatorId string, req *C
 		return nil, errcode.ErrInternalServer
 	}
 
+	s.stats.RecordGroupCreated(ctx)
+	analytics.Record(ctx, &analytics.Event{
+		Type:    analytics.EventGroupCreated,
+		UserId:  creatorId,
+		GroupId: groupId,
+		Props:   map[string]any{"member_count": len(memberIds) + 1},
+	})
+
 	log.CtxInfo(ctx, "group created: group_id=%s, creator_id=%s", groupId, creatorId)
+	s.emitGroupEvent(ctx, webhook.EventGroupCreated, groupId, &GroupEvent{OperatorId: creatorId, UserIds: memberIds})
 	return group, nil
 }
 
@@ -186,6 +208,12 @@ func (s *GroupService) JoinGroup(ctx context.Context, groupId, userId, inviterId
 	}
 
 	log.CtxInfo(ctx, "user joined group: group_id=%s, user_id=%s", groupId, userId)
+	s.stats.RecordGroupMembership(ctx, groupId, 1, 0)
+	s.emitGroupEvent(ctx, webhook.EventGroupMemberJoined, groupId, &GroupEvent{
+		OperatorId: userId,
+		UserIds:    []string{userId},
+		InviterId:  inviterId,
+	})
 	return nil
 }
 
@@ -237,6 +265,8 @@ func (s *GroupService) QuitGroup(ctx context.Context, groupId, userId string) er
 	}
 
 	log.CtxInfo(ctx, "user quit group: group_id=%s, user_id=%s", groupId, userId)
+	s.stats.RecordGroupMembership(ctx, groupId, 0, 1)
+	s.emitGroupEvent(ctx, webhook.EventGroupMemberLeft, groupId, &GroupEvent{OperatorId: userId, UserIds: []string{userId}})
 	return nil
 }
 
@@ -254,14 +284,17 @@ func (s *GroupService) GetGroupInfo(ctx context.Context, groupId string) (*entit
 	}
 
 	return &entity.GroupInfo{
-		Id:            group.Id,
-		Name:          group.Name,
-		Introduction:  group.Introduction,
-		Avatar:        group.Avatar,
-		Status:        group.Status,
-		CreatorUserId: group.CreatorUserId,
-		MemberCount:   memberCount,
-		CreatedAt:     group.CreatedAt,
+		Id:                    group.Id,
+		Name:                  group.Name,
+		Introduction:          group.Introduction,
+		Avatar:                group.Avatar,
+		Status:                group.Status,
+		CreatorUserId:         group.CreatorUserId,
+		MemberCount:           memberCount,
+		MuteAll:               group.MuteAll,
+		Announcement:          group.Announcement,
+		AnnouncementUpdatedAt: group.AnnouncementUpdatedAt,
+		CreatedAt:             group.CreatedAt,
 	}, nil
 }
 
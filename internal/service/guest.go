@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mbeoliero/kit/log"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+const (
+	guestUserIdPrefix = "guest_"
+	guestNickname     = "Guest"
+)
+
+// guestScopes restrict guest tokens to chatting; the message service further limits
+// guests to the configured support users
+var guestScopes = []string{scope.Msg, scope.Conversation, scope.Read(scope.User)}
+
+// CreateGuestRequest represents guest account creation request
+type CreateGuestRequest struct {
+	Nickname   string `json:"nickname,omitempty" validate:"max=128"` // defaults to "Guest"
+	PlatformId int    `json:"platform_id" validate:"min=0"`
+	DeviceName string `json:"device_name,omitempty" validate:"max=64"` // shown in the session list
+	// CaptchaToken is required when captcha.on_register is enabled
+	CaptchaToken string `json:"captcha_token,omitempty" validate:"max=4096"`
+}
+
+// UpgradeGuestRequest represents the request turning a guest into a full account.
+// The user id is kept so the guest's conversations carry over.
+type UpgradeGuestRequest struct {
+	Password string `json:"password" validate:"required,max=72"`
+	Nickname string `json:"nickname,omitempty" validate:"max=128"`
+	// Email or Phone (E.164) must be verified before the next password login; at most one
+	Email string `json:"email,omitempty" validate:"max=255"`
+	Phone string `json:"phone,omitempty" validate:"max=32"`
+}
+
+// CreateGuest creates a temporary guest account and logs it in. Guests expire after
+// guest.ttl; their tokens are restricted to guestScopes.
+func (s *AuthService) CreateGuest(ctx context.Context, clientIP string, req *CreateGuestRequest) (resp *LoginResponse, err error) {
+	var userId string
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryLogin,
+			Action:   "create_guest",
+			Actor:    userId,
+			ClientIP: clientIP,
+			Code:     audit.CodeOf(err),
+			Detail:   map[string]any{"platform_id": req.PlatformId},
+		})
+	}()
+
+	if !s.cfg.Guest.Enabled {
+		return nil, errcode.ErrNoPermission
+	}
+	if s.captcha != nil && s.cfg.Captcha.OnRegister {
+		if err = s.verifyCaptcha(ctx, clientIP, req.CaptchaToken); err != nil {
+			return nil, err
+		}
+	}
+
+	nickname := req.Nickname
+	if nickname == "" {
+		nickname = guestNickname
+	}
+	user := &entity.User{
+		Id:             guestUserIdPrefix + uuid.New().String(),
+		Nickname:       nickname,
+		GuestExpiresAt: entity.NowUnixMilli() + s.cfg.Guest.TTL.Milliseconds(),
+	}
+	if err = s.userRepo.Create(ctx, user); err != nil {
+		log.CtxError(ctx, "create guest failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	userId = user.Id
+
+	log.CtxInfo(ctx, "guest created: user_id=%s", user.Id)
+	return s.startSession(ctx, user, req.PlatformId, &jwt.SessionMeta{Name: req.DeviceName, ClientIP: clientIP})
+}
+
+// UpgradeGuest turns the calling guest into a full account that logs in with req.Password.
+// The guest's current token keeps its restrictions until it expires; the client logs in again.
+func (s *AuthService) UpgradeGuest(ctx context.Context, userId string, req *UpgradeGuestRequest) (info *entity.UserInfo, err error) {
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryLogin,
+			Action:   "upgrade_guest",
+			Actor:    userId,
+			Code:     audit.CodeOf(err),
+		})
+	}()
+
+	user, err := s.userRepo.GetById(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrUserNotFound
+	}
+	if !user.IsGuest() {
+		return nil, errcode.ErrNotGuestUser
+	}
+	if user.IsGuestExpired(entity.NowUnixMilli()) {
+		return nil, errcode.ErrGuestExpired
+	}
+
+	email, phone, err := s.checkContact(ctx, req.Email, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.CtxError(ctx, "hash password failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	updates := map[string]interface{}{"password": string(hashedPassword)}
+	if req.Nickname != "" {
+		updates["nickname"] = req.Nickname
+		user.Nickname = req.Nickname
+	}
+	if email != nil {
+		updates["email"] = *email
+		user.Email = email
+	}
+	if phone != nil {
+		updates["phone"] = *phone
+		user.Phone = phone
+	}
+	upgraded, err := s.userRepo.UpgradeGuest(ctx, userId, updates)
+	if err != nil {
+		log.CtxError(ctx, "upgrade guest failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if !upgraded {
+		return nil, errcode.ErrNotGuestUser
+	}
+	user.GuestExpiresAt = 0
+
+	s.stats.RecordRegistration(ctx)
+
+	if user.NeedsVerification() {
+		if err = s.sendVerifyCode(ctx, user); err != nil {
+			log.CtxWarn(ctx, "send verification code failed: user_id=%s, error=%v", userId, err)
+			err = nil
+		}
+	}
+
+	info = user.ToUserInfo()
+	webhook.Emit(ctx, webhook.EventUserRegistered, &UserRegisteredEvent{User: info, Source: registerSourceGuest})
+
+	log.CtxInfo(ctx, "guest upgraded: user_id=%s", userId)
+	return info, nil
+}
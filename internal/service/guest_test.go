@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/scope"
+)
+
+func TestCheckGuestChat(t *testing.T) {
+	s := &MessageService{}
+	s.SetGuestContacts([]string{"support"})
+
+	now := entity.NowUnixMilli()
+	guest := &entity.User{Id: "guest_1", GuestExpiresAt: now + 60_000}
+	support := &entity.User{Id: "support"}
+	alice := &entity.User{Id: "alice"}
+	bob := &entity.User{Id: "bob"}
+
+	for _, tc := range []struct {
+		sender, recv *entity.User
+		want         error
+	}{
+		{guest, support, nil},
+		{support, guest, nil},
+		{alice, bob, nil},
+		{guest, alice, errcode.ErrNoPermission},
+		{alice, guest, errcode.ErrNoPermission},
+		{&entity.User{Id: "guest_2", GuestExpiresAt: now - 1}, support, errcode.ErrGuestExpired},
+	} {
+		if err := s.checkGuestChat(tc.sender, tc.recv); !errors.Is(err, tc.want) {
+			t.Fatalf("%s -> %s: expected %v, got %v", tc.sender.Id, tc.recv.Id, tc.want, err)
+		}
+	}
+}
+
+func TestCreateGuestRequiresGuestMode(t *testing.T) {
+	s := &AuthService{cfg: &config.Config{}}
+	if _, err := s.CreateGuest(context.Background(), "", &CreateGuestRequest{}); !errors.Is(err, errcode.ErrNoPermission) {
+		t.Fatalf("expected no permission with guest mode disabled, got %v", err)
+	}
+}
+
+func TestGuestScopesOnlyReadUsers(t *testing.T) {
+	if !scope.Valid(guestScopes) {
+		t.Fatalf("invalid guest scopes: %v", guestScopes)
+	}
+	if scope.Allows(guestScopes, scope.User, true) || scope.Allows(guestScopes, scope.Group, false) {
+		t.Fatalf("guest scopes grant too much: %v", guestScopes)
+	}
+	if !scope.Allows(guestScopes, scope.Msg, true) || !scope.Allows(guestScopes, scope.Conversation, true) {
+		t.Fatalf("guest scopes must allow chatting: %v", guestScopes)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+)
+
+// Dependency status values
+const (
+	DependencyStatusOk   = "ok"
+	DependencyStatusFail = "fail"
+)
+
+// HealthCheck probes one dependency
+type HealthCheck struct {
+	Name     string
+	Critical bool // a failing critical dependency makes the instance not ready
+	Check    func(ctx context.Context) error
+}
+
+// DependencyStatus is the probe result of one dependency
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessReport is the readiness probe result
+type ReadinessReport struct {
+	Ready        bool                `json:"ready"`
+	Draining     bool                `json:"draining,omitempty"` // the instance is shutting down
+	Dependencies []*DependencyStatus `json:"dependencies"`
+}
+
+// HealthService runs readiness checks against the service dependencies
+type HealthService struct {
+	checks   []HealthCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewHealthService creates a HealthService checking MySQL and Redis
+func NewHealthService(repos *repository.Repositories, cfg *config.Config) *HealthService {
+	s := &HealthService{timeout: cfg.Health.CheckTimeout}
+	s.AddCheck(HealthCheck{Name: "mysql", Critical: true, Check: repos.PingMySQL})
+	s.AddCheck(HealthCheck{Name: "redis", Critical: true, Check: repos.PingRedis})
+	return s
+}
+
+// AddCheck registers an additional dependency check
+func (s *HealthService) AddCheck(check HealthCheck) {
+	s.checks = append(s.checks, check)
+}
+
+// SetDraining marks the instance as shutting down, so readiness fails and load balancers stop routing to it
+func (s *HealthService) SetDraining() {
+	s.draining.Store(true)
+}
+
+// Readiness probes all dependencies concurrently, each bounded by the check timeout
+func (s *HealthService) Readiness(ctx context.Context) *ReadinessReport {
+	report := &ReadinessReport{
+		Ready:        true,
+		Draining:     s.draining.Load(),
+		Dependencies: make([]*DependencyStatus, len(s.checks)),
+	}
+	if report.Draining {
+		report.Ready = false
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Dependencies[i] = s.probe(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		if dep.Critical && dep.Status != DependencyStatusOk {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func (s *HealthService) probe(ctx context.Context, check HealthCheck) *DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	status := &DependencyStatus{Name: check.Name, Status: DependencyStatusOk, Critical: check.Critical}
+	start := time.Now()
+	err := check.Check(ctx)
+	status.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		status.Status = DependencyStatusFail
+		status.Error = err.Error()
+	}
+	return status
+}
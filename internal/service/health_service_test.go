@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthServiceReadiness(t *testing.T) {
+	s := &HealthService{timeout: 50 * time.Millisecond}
+	s.AddCheck(HealthCheck{Name: "mysql", Critical: true, Check: func(ctx context.Context) error { return nil }})
+	s.AddCheck(HealthCheck{Name: "app_push", Check: func(ctx context.Context) error { return errors.New("refused") }})
+
+	report := s.Readiness(context.Background())
+	if !report.Ready {
+		t.Fatalf("a failing non-critical dependency must not fail readiness: %+v", report.Dependencies)
+	}
+	if report.Dependencies[1].Status != DependencyStatusFail || report.Dependencies[1].Error != "refused" {
+		t.Fatalf("expected app_push failure to be reported, got %+v", report.Dependencies[1])
+	}
+
+	// A hanging critical dependency is cut off by the timeout
+	s.AddCheck(HealthCheck{Name: "redis", Critical: true, Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	start := time.Now()
+	report = s.Readiness(context.Background())
+	if report.Ready || report.Dependencies[2].Status != DependencyStatusFail {
+		t.Fatalf("expected not ready on redis timeout, got %+v", report.Dependencies[2])
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("readiness should be bounded by the check timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/linkpreview"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+)
+
+// LinkPreviewFetcher builds the preview of a page, see linkpreview.Fetcher
+type LinkPreviewFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*entity.LinkPreview, error)
+}
+
+// linkPreviewJob is a stored text message and the link to preview in it
+type linkPreviewJob struct {
+	msg *entity.Message
+	url string
+}
+
+// LinkPreviewService attaches the preview of the first link of text messages. Messages are
+// queued without blocking the sender and handled by the workers started by Run, which take
+// the preview from the cache or fetch the page, then edit the message unless it changed.
+type LinkPreviewService struct {
+	cache    *repository.LinkPreviewRepo
+	fetcher  LinkPreviewFetcher
+	messages *MessageService
+	cfg      config.MessageLinkPreviewConfig
+	jobs     chan *linkPreviewJob
+	done     chan struct{} // closed when all workers exit
+}
+
+// NewLinkPreviewService creates a new LinkPreviewService
+func NewLinkPreviewService(repos *repository.Repositories, cfg *config.MessageLinkPreviewConfig, fetcher LinkPreviewFetcher, messages *MessageService) *LinkPreviewService {
+	return &LinkPreviewService{
+		cache:    repos.LinkPreview,
+		fetcher:  fetcher,
+		messages: messages,
+		cfg:      *cfg,
+		jobs:     make(chan *linkPreviewJob, cfg.QueueSize),
+	}
+}
+
+// QueueLinkPreview queues a text message with a link without blocking, dropping it when the
+// queue is full
+func (s *LinkPreviewService) QueueLinkPreview(ctx context.Context, msg *entity.Message) {
+	if msg.Content.Text == nil {
+		return
+	}
+	url := linkpreview.FirstURL(msg.Content.Text.Text)
+	if url == "" {
+		return
+	}
+	select {
+	case s.jobs <- &linkPreviewJob{msg: msg, url: url}:
+	default:
+		metrics.MessageLinkPreviewsTotal.WithLabelValues("dropped").Inc()
+		log.CtxWarn(ctx, "link preview queue full, message skipped: conversation_id=%s, seq=%d",
+			msg.ConversationId, msg.Seq)
+	}
+}
+
+// Run starts the workers, which exit once ctx is done; messages still queued then get no preview
+func (s *LinkPreviewService) Run(ctx context.Context) {
+	s.done = make(chan struct{})
+	var wg sync.WaitGroup
+	for range s.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.jobs:
+					s.attach(ctx, job)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.done)
+	}()
+	log.CtxInfo(ctx, "link preview workers started: workers=%d", s.cfg.Workers)
+}
+
+// Wait blocks until the workers have exited after their Run ctx is done, or until ctx is done
+func (s *LinkPreviewService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// attach edits the message of job with the preview of its link
+func (s *LinkPreviewService) attach(ctx context.Context, job *linkPreviewJob) {
+	preview := s.preview(ctx, job.url)
+	if preview == nil {
+		return
+	}
+	attached, err := s.messages.AttachLinkPreview(ctx, job.msg, preview)
+	if err != nil {
+		log.CtxError(ctx, "attach link preview failed: conversation_id=%s, seq=%d, error=%v",
+			job.msg.ConversationId, job.msg.Seq, err)
+		return
+	}
+	if !attached {
+		log.CtxInfo(ctx, "message changed, link preview skipped: conversation_id=%s, seq=%d",
+			job.msg.ConversationId, job.msg.Seq)
+	}
+}
+
+// preview returns the preview of a URL from the cache, or fetches and caches it; nil when the
+// page has none
+func (s *LinkPreviewService) preview(ctx context.Context, url string) *entity.LinkPreview {
+	preview, found, err := s.cache.Get(ctx, url)
+	if err != nil {
+		log.CtxWarn(ctx, "get cached link preview failed: url=%s, error=%v", url, err)
+	}
+	if found {
+		metrics.MessageLinkPreviewsTotal.WithLabelValues("cached").Inc()
+		return preview
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	preview, err = s.fetcher.Fetch(fetchCtx, url)
+	ttl := s.cfg.CacheTTL
+	switch {
+	case errors.Is(err, linkpreview.ErrNoPreview):
+		metrics.MessageLinkPreviewsTotal.WithLabelValues("none").Inc()
+		ttl = s.cfg.FailureTTL
+	case err != nil:
+		if ctx.Err() != nil {
+			// Shutting down, the page may be fine
+			return nil
+		}
+		metrics.MessageLinkPreviewsTotal.WithLabelValues("failed").Inc()
+		log.CtxInfo(ctx, "fetch link preview failed: url=%s, error=%v", url, err)
+		ttl = s.cfg.FailureTTL
+	default:
+		metrics.MessageLinkPreviewsTotal.WithLabelValues("fetched").Inc()
+	}
+	if err = s.cache.Set(context.WithoutCancel(ctx), url, preview, ttl); err != nil {
+		log.CtxWarn(ctx, "cache link preview failed: url=%s, error=%v", url, err)
+	}
+	return preview
+}
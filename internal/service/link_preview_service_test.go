@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+func TestQueueLinkPreview(t *testing.T) {
+	s := &LinkPreviewService{jobs: make(chan *linkPreviewJob, 1)}
+	ctx := context.Background()
+
+	s.QueueLinkPreview(ctx, &entity.Message{Content: entity.MessageContent{Text: &entity.TextContent{Text: "no link"}}})
+	if len(s.jobs) != 0 {
+		t.Fatal("expected a message without link to be skipped")
+	}
+	msg := &entity.Message{Content: entity.MessageContent{Text: &entity.TextContent{Text: "see https://example.com/a."}}}
+	s.QueueLinkPreview(ctx, msg)
+	if job := <-s.jobs; job.msg != msg || job.url != "https://example.com/a" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	// Dropped without blocking once the queue is full
+	s.QueueLinkPreview(ctx, msg)
+	s.QueueLinkPreview(ctx, msg)
+	if len(s.jobs) != 1 {
+		t.Fatalf("expected one queued job, got %d", len(s.jobs))
+	}
+}
+
+func TestClearLinkPreview(t *testing.T) {
+	content := entity.MessageContent{Text: &entity.TextContent{Text: "hi", Preview: &entity.LinkPreview{Url: "https://x"}}}
+	s := &MessageService{}
+	s.clearLinkPreview(content)
+	if content.Text.Preview == nil {
+		t.Fatal("expected the client preview to be kept without a previewer")
+	}
+	s.SetLinkPreviewer(&LinkPreviewService{})
+	s.clearLinkPreview(content)
+	if content.Text.Preview != nil {
+		t.Fatal("expected the client preview to be dropped")
+	}
+}
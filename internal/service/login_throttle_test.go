@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+func TestLoginBackoffScalesIPLimits(t *testing.T) {
+	s := &AuthService{cfg: &config.Config{LoginThrottle: config.LoginThrottleConfig{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		LockoutAttempts: 10,
+		LockoutDuration: 15 * time.Minute,
+		IPMultiplier:    5,
+	}}}
+
+	user := s.loginBackoff(loginThrottleDimUser)
+	if user.FreeAttempts != 3 || user.LockoutAttempts != 10 {
+		t.Fatalf("unexpected user policy: %+v", user)
+	}
+	ip := s.loginBackoff(loginThrottleDimIP)
+	if ip.FreeAttempts != 15 || ip.LockoutAttempts != 50 || ip.LockoutDuration != 15*time.Minute {
+		t.Fatalf("unexpected ip policy: %+v", ip)
+	}
+}
+
+func TestLoginSubjectsSkipsMissingIP(t *testing.T) {
+	subjects := loginSubjects("alice", "")
+	if len(subjects) != 1 || subjects[loginThrottleDimUser] != "user:alice" {
+		t.Fatalf("unexpected subjects: %v", subjects)
+	}
+	subjects = loginSubjects("alice", "10.0.0.1")
+	if subjects[loginThrottleDimIP] != "ip:10.0.0.1" {
+		t.Fatalf("unexpected subjects: %v", subjects)
+	}
+}
+
+func TestCheckLoginLockDisabled(t *testing.T) {
+	s := &AuthService{cfg: &config.Config{}}
+	if err := s.checkLoginLock(context.Background(), "alice", "10.0.0.1"); err != nil {
+		t.Fatalf("expected no lock when throttling is disabled, got %v", err)
+	}
+}
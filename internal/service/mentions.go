@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// maxMentions caps the users a single message may mention
+const maxMentions = 50
+
+// normalizeMentions removes duplicate mentions from a text message
+func normalizeMentions(content entity.MessageContent) error {
+	if content.Text == nil || len(content.Text.Mentions) == 0 {
+		return nil
+	}
+	if len(content.Text.Mentions) > maxMentions {
+		return errcode.ErrInvalidParam
+	}
+	seen := make(map[string]bool, len(content.Text.Mentions))
+	mentions := content.Text.Mentions[:0]
+	for _, userId := range content.Text.Mentions {
+		if userId == "" {
+			return errcode.ErrInvalidParam
+		}
+		if seen[userId] {
+			continue
+		}
+		seen[userId] = true
+		mentions = append(mentions, userId)
+	}
+	content.Text.Mentions = mentions
+	return nil
+}
+
+// hasMentions reports whether a message mentions anyone
+func hasMentions(msg *entity.Message) bool {
+	return msg.Content.Text != nil && len(msg.Content.Text.Mentions) > 0
+}
+
+// mentionedRecipients returns the recipients of a message it mentions, never its sender
+func mentionedRecipients(msg *entity.Message, recipientIds []string) []string {
+	if !hasMentions(msg) {
+		return nil
+	}
+	isRecipient := make(map[string]bool, len(recipientIds))
+	for _, userId := range recipientIds {
+		isRecipient[userId] = true
+	}
+	var userIds []string
+	for _, userId := range msg.Content.Text.Mentions {
+		if userId != msg.SenderId && isRecipient[userId] {
+			userIds = append(userIds, userId)
+		}
+	}
+	return userIds
+}
+
+// recordMentions stores the unread mentions of a message for the mentioned recipients
+func (s *MessageService) recordMentions(ctx context.Context, msg *entity.Message, recipientIds []string) {
+	userIds := mentionedRecipients(msg, recipientIds)
+	if len(userIds) == 0 {
+		return
+	}
+	if err := s.mentionRepo.Create(ctx, msg.ConversationId, msg.Seq, userIds); err != nil {
+		log.CtxWarn(ctx, "record mentions failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestNormalizeMentionsDedupes(t *testing.T) {
+	content := entity.MessageContent{Text: &entity.TextContent{Text: "hi", Mentions: []string{"bob", "carol", "bob"}}}
+	if err := normalizeMentions(content); err != nil {
+		t.Fatalf("normalize mentions failed: %v", err)
+	}
+	if !slices.Equal(content.Text.Mentions, []string{"bob", "carol"}) {
+		t.Fatalf("expected duplicate mentions removed, got %v", content.Text.Mentions)
+	}
+
+	content.Text.Mentions = []string{"bob", ""}
+	if err := normalizeMentions(content); err != errcode.ErrInvalidParam {
+		t.Fatalf("expected empty user id rejected, got %v", err)
+	}
+}
+
+func TestMentionedRecipientsSkipsSenderAndOutsiders(t *testing.T) {
+	msg := &entity.Message{
+		SenderId: "alice",
+		Content:  entity.MessageContent{Text: &entity.TextContent{Text: "hi", Mentions: []string{"alice", "bob", "mallory"}}},
+	}
+	got := mentionedRecipients(msg, []string{"alice", "bob", "carol"})
+	if !slices.Equal(got, []string{"bob"}) {
+		t.Fatalf("expected only bob mentioned, got %v", got)
+	}
+}
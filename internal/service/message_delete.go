@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mbeoliero/kit/log"
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
+)
+
+// DeleteMessageRequest represents delete message request. Scope is constant.MsgDeleteScopeSelf
+// or constant.MsgDeleteScopeAll.
+type DeleteMessageRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+	Scope          string `json:"scope" validate:"required,max=16"`
+}
+
+// DeleteMessage deletes a message the user can see. The self scope hides it from the user's
+// pulls only. The all scope clears its content for everyone like a revoke but without a
+// notification in the conversation: the sender may do it within the revoke window, group
+// admins at any time. The deletion is pushed to the participants as a message_deleted event.
+func (s *MessageService) DeleteMessage(ctx context.Context, userId string, req *DeleteMessageRequest) error {
+	ctx, span := tracing.Start(ctx, "MessageService.DeleteMessage")
+	defer span.End()
+
+	if req.ConversationId == "" || req.Seq <= 0 ||
+		(req.Scope != constant.MsgDeleteScopeSelf && req.Scope != constant.MsgDeleteScopeAll) {
+		return errcode.ErrInvalidParam
+	}
+	msg, err := s.GetMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return err
+	}
+	if req.Scope == constant.MsgDeleteScopeAll {
+		return s.deleteForAll(ctx, userId, msg)
+	}
+
+	err = s.repos.Hidden.Hide(ctx, &entity.HiddenMessage{
+		UserId:         userId,
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+	})
+	if err != nil {
+		log.CtxError(ctx, "hide message failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, msg.ConversationId, msg.Seq, err)
+		return errcode.ErrInternalServer
+	}
+	return nil
+}
+
+// deleteForAll clears the content of msg for everyone and pushes the deletion
+func (s *MessageService) deleteForAll(ctx context.Context, userId string, msg *entity.Message) error {
+	if msg.RevokedAt > 0 {
+		return errcode.ErrMessageRevoked
+	}
+	if msg.DeletedAt > 0 || msg.MsgType == constant.MsgTypeRevoke {
+		return errcode.ErrInvalidParam
+	}
+	admin, err := s.isGroupAdmin(ctx, msg, userId)
+	if err != nil {
+		return err
+	}
+	now := entity.NowUnixMilli()
+	if !admin {
+		if msg.SenderId != userId {
+			return errcode.ErrNoPermission
+		}
+		if s.revokeWindow > 0 && now-msg.SendAt > s.revokeWindow.Milliseconds() {
+			return errcode.ErrRevokeExpired
+		}
+	}
+
+	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		deleted, err := s.msgRepo.DeleteForAll(ctx, tx, msg.Id, now)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			// Revoked or deleted meanwhile
+			return errcode.ErrMessageRevoked
+		}
+		return s.dropMessageData(ctx, tx, msg)
+	})
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			return e
+		}
+		log.CtxError(ctx, "delete message failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return errcode.ErrInternalServer
+	}
+
+	if s.pusher != nil {
+		if userIds, err := s.Participants(ctx, msg); err != nil {
+			log.CtxWarn(ctx, "get members for message delete failed: group_id=%s, error=%v", msg.GroupId, err)
+		} else {
+			s.pusher.NotifyMessageDeleted(&entity.MessageDeletion{
+				ConversationId: msg.ConversationId,
+				Seq:            msg.Seq,
+				UserId:         userId,
+			}, userIds)
+		}
+	}
+
+	log.CtxInfo(ctx, "message deleted for all: user_id=%s, sender_id=%s, conversation_id=%s, seq=%d", userId, msg.SenderId, msg.ConversationId, msg.Seq)
+	return nil
+}
+
+// isGroupAdmin reports whether the user is an active admin of the group msg was sent to
+func (s *MessageService) isGroupAdmin(ctx context.Context, msg *entity.Message, userId string) (bool, error) {
+	if msg.SessionType != constant.SessionTypeGroup {
+		return false, nil
+	}
+	member, err := s.groupRepo.GetMember(ctx, msg.GroupId, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		log.CtxError(ctx, "get group member failed: group_id=%s, user_id=%s, error=%v", msg.GroupId, userId, err)
+		return false, errcode.ErrInternalServer
+	}
+	return member.IsNormal() && member.IsAdmin(), nil
+}
+
+// filterHidden drops the messages the user deleted for themselves. Failing to load them is
+// logged and the messages are kept.
+func (s *MessageService) filterHidden(ctx context.Context, userId, conversationId string, messages []*entity.Message) []*entity.Message {
+	if len(messages) == 0 {
+		return messages
+	}
+	seqs := make([]int64, len(messages))
+	for i, msg := range messages {
+		seqs[i] = msg.Seq
+	}
+	hidden, err := s.repos.Hidden.GetSeqs(ctx, userId, conversationId, seqs)
+	if err != nil {
+		log.CtxWarn(ctx, "get hidden messages failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		return messages
+	}
+	return dropHidden(messages, hidden)
+}
+
+// dropHidden drops the messages whose seq is hidden
+func dropHidden(messages []*entity.Message, hidden map[int64]bool) []*entity.Message {
+	if len(hidden) == 0 {
+		return messages
+	}
+	kept := messages[:0]
+	for _, msg := range messages {
+		if !hidden[msg.Seq] {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestDeleteMessageChecksTheRequest(t *testing.T) {
+	s := &MessageService{}
+	for name, req := range map[string]*DeleteMessageRequest{
+		"no conversation": {Seq: 1, Scope: "self"},
+		"no seq":          {ConversationId: "si_alice_bob", Scope: "all"},
+		"no scope":        {ConversationId: "si_alice_bob", Seq: 1},
+		"unknown scope":   {ConversationId: "si_alice_bob", Seq: 1, Scope: "others"},
+	} {
+		if err := s.DeleteMessage(context.Background(), "alice", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%s: expected invalid param, got %v", name, err)
+		}
+	}
+}
+
+func TestDropHidden(t *testing.T) {
+	messages := []*entity.Message{{Seq: 1}, {Seq: 2}, {Seq: 3}}
+	if kept := dropHidden(messages, map[int64]bool{}); len(kept) != 3 {
+		t.Fatalf("expected all messages kept, got %d", len(kept))
+	}
+	kept := dropHidden(messages, map[int64]bool{2: true, 9: true})
+	if len(kept) != 2 || kept[0].Seq != 1 || kept[1].Seq != 3 {
+		t.Fatalf("expected seqs 1 and 3 to be kept, got %d messages", len(kept))
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// Bounds of the disappearing messages timer of a conversation, 0 turns it off
+const (
+	minMessageTTLSeconds = 5
+	maxMessageTTLSeconds = 30 * 24 * 60 * 60
+)
+
+// validateMessageTTL checks a disappearing messages timer in seconds
+func validateMessageTTL(ttlSeconds int32) error {
+	if ttlSeconds != 0 && (ttlSeconds < minMessageTTLSeconds || ttlSeconds > maxMessageTTLSeconds) {
+		return errcode.ErrInvalidParam
+	}
+	return nil
+}
+
+// setMessageTTL sets the disappearing messages timer of a conversation the user has. The timer
+// is shared, see SharedSettingTargets. All participants are told, it applies to the messages
+// sent from now on.
+func (s *ConversationService) setMessageTTL(ctx context.Context, userId, conversationId string, ttlSeconds int32) error {
+	if err := validateMessageTTL(ttlSeconds); err != nil {
+		return err
+	}
+	participants, err := s.SharedSettingTargets(ctx, userId, conversationId)
+	if err != nil {
+		return err
+	}
+
+	if err = s.seqRepo.SetTTL(ctx, conversationId, ttlSeconds); err != nil {
+		log.CtxError(ctx, "set message ttl failed: conversation_id=%s, error=%v", conversationId, err)
+		return errcode.ErrInternalServer
+	}
+	if s.notifier != nil {
+		s.notifier.NotifyMessageTTLChanged(conversationId, ttlSeconds, participants)
+	}
+	log.CtxInfo(ctx, "message ttl set: user_id=%s, conversation_id=%s, ttl_seconds=%d", userId, conversationId, ttlSeconds)
+	return nil
+}
+
+// stampExpiry sets when msg, sent at msg.SendAt, disappears following the timer of its
+// conversation
+func (s *MessageService) stampExpiry(ctx context.Context, msg *entity.Message) error {
+	seqConv, err := s.seqRepo.GetConversationSeqInfo(ctx, msg.ConversationId)
+	if err != nil {
+		log.CtxError(ctx, "get conversation seq failed: conversation_id=%s, error=%v", msg.ConversationId, err)
+		return errcode.ErrSendFailed
+	}
+	if seqConv.TTLSeconds > 0 {
+		msg.ExpiresAt = msg.SendAt + int64(seqConv.TTLSeconds)*1000
+	}
+	return nil
+}
+
+// filterDisappeared drops messages that disappeared at now (ms)
+func filterDisappeared(messages []*entity.Message, now int64) []*entity.Message {
+	kept := messages[:0]
+	for _, msg := range messages {
+		if !msg.Disappeared(now) {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+// DisappearingService periodically deletes the messages that disappeared. Unlike retention
+// it leaves min_seq alone: the timer changes over time, so the messages it deletes are not a
+// prefix of the conversation and pulls just skip their seqs.
+type DisappearingService struct {
+	msgRepo   *repository.MessageRepo
+	interval  time.Duration
+	batchSize int
+	done      chan struct{} // closed when the sweeper exits
+}
+
+// NewDisappearingService creates a new DisappearingService
+func NewDisappearingService(repos *repository.Repositories, cfg *config.Config) *DisappearingService {
+	return &DisappearingService{
+		msgRepo:   repos.Message,
+		interval:  cfg.Message.Disappearing.SweepInterval,
+		batchSize: cfg.Message.Disappearing.BatchSize,
+	}
+}
+
+// Run starts the sweeper
+func (s *DisappearingService) Run(ctx context.Context) {
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.Sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.CtxInfo(ctx, "disappearing messages sweeper started: interval=%s", s.interval)
+}
+
+// Wait blocks until the sweeper has exited after its Run ctx is done, or until ctx is done
+func (s *DisappearingService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sweep deletes the messages that disappeared by now
+func (s *DisappearingService) Sweep(ctx context.Context) {
+	count, err := s.msgRepo.DeleteDisappeared(ctx, time.Now().UnixMilli(), s.batchSize)
+	if err != nil {
+		log.CtxError(ctx, "sweep disappeared messages failed: deleted=%d, error=%v", count, err)
+		return
+	}
+	if count > 0 {
+		log.CtxInfo(ctx, "swept disappeared messages: count=%d", count)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestValidateMessageTTL(t *testing.T) {
+	for _, ttl := range []int32{0, minMessageTTLSeconds, 24 * 60 * 60, maxMessageTTLSeconds} {
+		if err := validateMessageTTL(ttl); err != nil {
+			t.Errorf("ttl %d: unexpected error %v", ttl, err)
+		}
+	}
+	for _, ttl := range []int32{-1, minMessageTTLSeconds - 1, maxMessageTTLSeconds + 1} {
+		if err := validateMessageTTL(ttl); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("ttl %d: expected invalid param, got %v", ttl, err)
+		}
+	}
+}
+
+func TestFilterDisappeared(t *testing.T) {
+	messages := []*entity.Message{
+		{Seq: 1},                 // sent before the timer was set
+		{Seq: 2, ExpiresAt: 900}, // disappeared
+		{Seq: 3, ExpiresAt: 1000},
+		{Seq: 4, ExpiresAt: 1001},
+	}
+	kept := filterDisappeared(messages, 1000)
+	if len(kept) != 2 || kept[0].Seq != 1 || kept[1].Seq != 4 {
+		t.Fatalf("expected seqs 1 and 4 to be kept, got %d messages", len(kept))
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/mbeoliero/kit/log"
+	"gorm.io/gorm"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
+)
+
+// maxMessageEdits caps the edits of a message, and so the size of its edit history
+const maxMessageEdits = 100
+
+// EditMessageRequest represents edit message request
+type EditMessageRequest struct {
+	ConversationId string
+	Seq            int64
+	Content        entity.MessageContent
+}
+
+// EditMessage replaces the text of a text message of the user. The replaced content is kept in
+// the edit history of the message and its edit_version is incremented; the seq is unchanged,
+// so the edited message is pushed to the participants as a message_edited event.
+func (s *MessageService) EditMessage(ctx context.Context, userId string, req *EditMessageRequest) (*entity.Message, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.EditMessage")
+	defer span.End()
+
+	if req.ConversationId == "" || req.Seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	if err := validateMessageContent(constant.MsgTypeText, req.Content); err != nil {
+		return nil, err
+	}
+	if err := normalizeMentions(req.Content); err != nil {
+		return nil, err
+	}
+	s.clearLinkPreview(req.Content)
+
+	msg, err := s.GetMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.SenderId != userId {
+		return nil, errcode.ErrNoPermission
+	}
+	if msg.RevokedAt > 0 {
+		return nil, errcode.ErrMessageRevoked
+	}
+	if msg.DeletedAt > 0 || msg.MsgType != constant.MsgTypeText || msg.Content.Text == nil {
+		return nil, errcode.ErrInvalidParam
+	}
+	if msg.EditVersion >= maxMessageEdits {
+		return nil, errcode.ErrTooManyRequests
+	}
+
+	edited := *msg
+	edited.Content = req.Content
+	edited.Extra = nil
+	edited.EditVersion++
+	if err = s.checkPreSend(ctx, &edited); err != nil {
+		return nil, err
+	}
+	prev := &entity.MessageEdit{
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		SenderId:       msg.SenderId,
+		Version:        msg.EditVersion,
+		Content:        msg.Content.ToFlat(),
+		EditedAt:       entity.NowUnixMilli(),
+	}
+	removed := removedMentions(msg, &edited)
+
+	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		updated, err := s.msgRepo.Edit(ctx, tx, &edited)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			// Edited, revoked or deleted meanwhile
+			return errcode.ErrEditConflict
+		}
+		if err = s.editRepo.Create(ctx, tx, prev); err != nil {
+			return err
+		}
+		return s.mentionRepo.DeleteByMessage(ctx, tx, msg.ConversationId, msg.Seq, removed)
+	})
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			return nil, e
+		}
+		log.CtxError(ctx, "edit message failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	if userIds, err := s.Participants(ctx, &edited); err != nil {
+		log.CtxWarn(ctx, "get members for message edit failed: group_id=%s, error=%v", msg.GroupId, err)
+	} else {
+		// Users mentioned before keep their unread mention, recording it again is a no-op
+		s.recordMentions(ctx, &edited, userIds)
+		if s.pusher != nil {
+			s.pusher.NotifyMessageEdited(&edited, userIds)
+		}
+	}
+	s.queueLinkPreview(ctx, &edited)
+
+	log.CtxInfo(ctx, "message edited: sender_id=%s, conversation_id=%s, seq=%d, edit_version=%d", userId, msg.ConversationId, msg.Seq, edited.EditVersion)
+	return &edited, nil
+}
+
+// GetEditHistory lists the replaced versions of a message the user can see, oldest first; the
+// current content is the message itself. Revoked and deleted messages have no history.
+func (s *MessageService) GetEditHistory(ctx context.Context, userId, conversationId string, seq int64) ([]*entity.MessageEdit, error) {
+	if conversationId == "" || seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	msg, err := s.GetMessage(ctx, userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.EditVersion == 0 || msg.RevokedAt > 0 || msg.DeletedAt > 0 {
+		return []*entity.MessageEdit{}, nil
+	}
+	edits, err := s.editRepo.ListByMessage(ctx, conversationId, seq)
+	if err != nil {
+		log.CtxError(ctx, "list message edits failed: conversation_id=%s, seq=%d, error=%v", conversationId, seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return edits, nil
+}
+
+// removedMentions returns the users msg mentions that edited no longer does
+func removedMentions(msg, edited *entity.Message) []string {
+	if !hasMentions(msg) {
+		return nil
+	}
+	var kept []string
+	if hasMentions(edited) {
+		kept = edited.Content.Text.Mentions
+	}
+	var userIds []string
+	for _, userId := range msg.Content.Text.Mentions {
+		if !slices.Contains(kept, userId) {
+			userIds = append(userIds, userId)
+		}
+	}
+	return userIds
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestEditMessageOnlyAcceptsText(t *testing.T) {
+	s := &MessageService{}
+	for name, content := range map[string]entity.MessageContent{
+		"image":    {Image: &entity.ImageContent{Url: "https://example.com/a.png"}},
+		"empty":    {},
+		"two kind": {Text: &entity.TextContent{Text: "hi"}, Custom: []byte(`{}`)},
+	} {
+		req := &EditMessageRequest{ConversationId: "si_alice_bob", Seq: 1, Content: content}
+		if _, err := s.EditMessage(context.Background(), "alice", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%s: expected invalid param, got %v", name, err)
+		}
+	}
+	req := &EditMessageRequest{ConversationId: "si_alice_bob", Content: entity.MessageContent{Text: &entity.TextContent{Text: "hi"}}}
+	if _, err := s.EditMessage(context.Background(), "alice", req); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a seq, got %v", err)
+	}
+}
+
+func TestRemovedMentions(t *testing.T) {
+	text := func(mentions ...string) *entity.Message {
+		return &entity.Message{Content: entity.MessageContent{Text: &entity.TextContent{Text: "hi", Mentions: mentions}}}
+	}
+	if got := removedMentions(text("bob", "carol"), text("carol", "dave")); !slices.Equal(got, []string{"bob"}) {
+		t.Fatalf("expected bob to be removed, got %v", got)
+	}
+	if got := removedMentions(text("bob"), text()); !slices.Equal(got, []string{"bob"}) {
+		t.Fatalf("expected all mentions to be removed, got %v", got)
+	}
+	if got := removedMentions(text(), text("bob")); got != nil {
+		t.Fatalf("expected nothing to be removed, got %v", got)
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
+)
+
+// Bounds of forwards
+const (
+	maxForwardMessages   = 100 // messages a forward copies, and copies a forward sends
+	maxForwardTargets    = 20
+	maxMergedTitleLength = 100 // characters
+	maxMergedDepth       = 3   // merged forwards nested in one another
+)
+
+// ForwardTarget is a conversation messages are forwarded to: a single chat with RecvId or a
+// group, exactly one of the two is set
+type ForwardTarget struct {
+	RecvId  string `json:"recv_id,omitempty"`
+	GroupId string `json:"group_id,omitempty"`
+}
+
+// ForwardMessagesRequest represents forward messages request. The messages of ConversationId
+// at Seqs are copied to each target one by one or, when Merged, packaged in one merged forward
+// titled Title. ClientMsgId makes the forward idempotent, see ForwardMessages.
+type ForwardMessagesRequest struct {
+	ClientMsgId    string
+	ConversationId string
+	Seqs           []int64
+	Targets        []*ForwardTarget
+	Merged         bool
+	Title          string
+}
+
+// ForwardResult is the outcome of a forward to one target. Messages are the copies sent to it
+// in order; Err, when set, stopped the forward to the target after them.
+type ForwardResult struct {
+	Target   *ForwardTarget
+	Messages []*entity.Message
+	Err      error
+}
+
+// validateSendContent checks the content of a message to send: merged forwards are built by
+// ForwardMessages only, other types are checked by validateMessageContent
+func validateSendContent(req *SendMessageRequest) error {
+	if req.MsgType == constant.MsgTypeMerged {
+		if !req.Forwarded || req.Content.Merged == nil || req.Content.PayloadCount() != 1 {
+			return errcode.ErrInvalidParam
+		}
+		return nil
+	}
+	return validateMessageContent(req.MsgType, req.Content)
+}
+
+// ForwardMessages copies messages of a conversation the user can see to other conversations
+// as messages of the user flagged forwarded. The copies are sent like any message of the user,
+// so each target applies its own checks, and a failed target does not stop the others.
+// The copies get the client message ids "<ClientMsgId>_<target index>_<seq>", or
+// "<ClientMsgId>_<target index>" for a merged forward, so retrying a forward returns the
+// copies already sent.
+func (s *MessageService) ForwardMessages(ctx context.Context, userId string, req *ForwardMessagesRequest) ([]*ForwardResult, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.ForwardMessages")
+	defer span.End()
+
+	if req.ClientMsgId == "" || req.ConversationId == "" || len(req.Seqs) == 0 || len(req.Targets) == 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	copies := len(req.Seqs) * len(req.Targets)
+	if req.Merged {
+		copies = len(req.Targets)
+	}
+	if len(req.Seqs) > maxForwardMessages || len(req.Targets) > maxForwardTargets || copies > maxForwardMessages {
+		return nil, errcode.ErrInvalidParam
+	}
+	if utf8.RuneCountInString(req.Title) > maxMergedTitleLength || (req.Title != "" && !req.Merged) {
+		return nil, errcode.ErrInvalidParam
+	}
+	for _, target := range req.Targets {
+		if target == nil || (target.RecvId == "") == (target.GroupId == "") {
+			return nil, errcode.ErrInvalidParam
+		}
+	}
+
+	sources, err := s.forwardSources(ctx, userId, req.ConversationId, req.Seqs)
+	if err != nil {
+		return nil, err
+	}
+	var merged *entity.MergedContent
+	if req.Merged {
+		if merged, err = mergeMessages(req.Title, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*ForwardResult, len(req.Targets))
+	for i, target := range req.Targets {
+		var sendReqs []*SendMessageRequest
+		if merged != nil {
+			sendReqs = append(sendReqs, &SendMessageRequest{
+				ClientMsgId: fmt.Sprintf("%s_%d", req.ClientMsgId, i),
+				MsgType:     constant.MsgTypeMerged,
+				Content:     entity.MessageContent{Merged: merged},
+			})
+		} else {
+			for _, src := range sources {
+				sendReqs = append(sendReqs, &SendMessageRequest{
+					ClientMsgId: fmt.Sprintf("%s_%d_%d", req.ClientMsgId, i, src.Seq),
+					MsgType:     src.MsgType,
+					Content:     src.Content,
+				})
+			}
+		}
+
+		result := &ForwardResult{Target: target, Messages: []*entity.Message{}}
+		for _, sendReq := range sendReqs {
+			sendReq.RecvId, sendReq.GroupId = target.RecvId, target.GroupId
+			sendReq.SessionType = constant.SessionTypeGroup
+			if target.RecvId != "" {
+				sendReq.SessionType = constant.SessionTypeSingle
+			}
+			sendReq.Forwarded = true
+			// The send path fills in the content, each copy gets its own
+			if sendReq.Content, err = forwardedContent(sendReq.Content); err != nil {
+				log.CtxError(ctx, "copy forwarded content failed: conversation_id=%s, error=%v", req.ConversationId, err)
+				result.Err = errcode.ErrInternalServer
+				break
+			}
+			msg, err := s.SendMessage(ctx, userId, sendReq)
+			if err != nil {
+				result.Err = err
+				break
+			}
+			result.Messages = append(result.Messages, msg)
+		}
+		results[i] = result
+	}
+
+	log.CtxInfo(ctx, "messages forwarded: user_id=%s, conversation_id=%s, count=%d, targets=%d, merged=%v",
+		userId, req.ConversationId, len(sources), len(req.Targets), req.Merged)
+	return results, nil
+}
+
+// forwardSources loads the messages to forward in seq order. All of them must be visible to
+// the user, following the rules of PullMessages, and have content to copy: revoked and
+// deleted messages, revoke notifications and encrypted messages, whose ciphertexts are for
+// the devices of the original conversation, cannot be forwarded.
+func (s *MessageService) forwardSources(ctx context.Context, userId, conversationId string, seqs []int64) ([]*entity.Message, error) {
+	beginSeq, endSeq, _, err := s.visibleSeqRange(ctx, userId, conversationId, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int64]bool, len(seqs))
+	for _, seq := range seqs {
+		if seq <= 0 || seen[seq] {
+			return nil, errcode.ErrInvalidParam
+		}
+		if seq < beginSeq || seq > endSeq {
+			return nil, errcode.ErrMessageNotFound
+		}
+		seen[seq] = true
+	}
+
+	messages, err := s.msgRepo.PullMessagesBySeqList(ctx, conversationId, seqs)
+	if err != nil {
+		log.CtxError(ctx, "load forwarded messages failed: conversation_id=%s, error=%v", conversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	now := time.Now()
+	if cutoff := s.retention.Cutoff(conversationId, now); cutoff > 0 {
+		messages = filterExpiredMessages(messages, cutoff)
+	}
+	messages = filterDisappeared(messages, now.UnixMilli())
+	messages = s.filterHidden(ctx, userId, conversationId, messages)
+	if len(messages) != len(seqs) {
+		return nil, errcode.ErrMessageNotFound
+	}
+	for _, msg := range messages {
+		switch {
+		case msg.RevokedAt > 0:
+			return nil, errcode.ErrMessageRevoked
+		case msg.DeletedAt > 0:
+			return nil, errcode.ErrMessageNotFound
+		case msg.MsgType == constant.MsgTypeRevoke, msg.MsgType == constant.MsgTypeEncrypted:
+			return nil, errcode.ErrInvalidParam
+		}
+	}
+	return messages, nil
+}
+
+// mergeMessages packages messages in a merged forward, which may nest merged forwards up to
+// maxMergedDepth
+func mergeMessages(title string, messages []*entity.Message) (*entity.MergedContent, error) {
+	merged := &entity.MergedContent{Title: title, Messages: make([]*entity.MergedMessage, len(messages))}
+	for i, msg := range messages {
+		merged.Messages[i] = &entity.MergedMessage{
+			SenderId: msg.SenderId,
+			MsgType:  msg.MsgType,
+			Content:  msg.Content,
+			SendAt:   msg.SendAt,
+		}
+	}
+	if mergedDepth(entity.MessageContent{Merged: merged}) > maxMergedDepth {
+		return nil, errcode.ErrInvalidParam
+	}
+	return merged, nil
+}
+
+// mergedDepth returns how deep merged forwards nest in content, 0 when it is not one
+func mergedDepth(content entity.MessageContent) int {
+	if content.Merged == nil {
+		return 0
+	}
+	depth := 0
+	for _, m := range content.Merged.Messages {
+		depth = max(depth, mergedDepth(m.Content))
+	}
+	return depth + 1
+}
+
+// forwardedContent returns a deep copy of content to forward. Mentions are dropped, a copy
+// should not notify the users the original mentioned.
+func forwardedContent(content entity.MessageContent) (entity.MessageContent, error) {
+	var copied entity.MessageContent
+	b, err := json.Marshal(content)
+	if err != nil {
+		return copied, err
+	}
+	if err = json.Unmarshal(b, &copied); err != nil {
+		return copied, err
+	}
+	if copied.Text != nil {
+		copied.Text.Mentions = nil
+	}
+	return copied, nil
+}
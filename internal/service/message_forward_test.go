@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestValidateSendContentOnlyLetsForwardsMerge(t *testing.T) {
+	req := &SendMessageRequest{
+		MsgType: constant.MsgTypeMerged,
+		Content: entity.MessageContent{Merged: &entity.MergedContent{Messages: []*entity.MergedMessage{}}},
+	}
+	if err := validateSendContent(req); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected clients not to send merged forwards, got %v", err)
+	}
+	req.Forwarded = true
+	if err := validateSendContent(req); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestMergeMessagesCapsNesting(t *testing.T) {
+	msg := &entity.Message{SenderId: "alice", MsgType: constant.MsgTypeText, Content: entity.MessageContent{Text: &entity.TextContent{Text: "hi"}}}
+	for depth := 1; depth <= maxMergedDepth; depth++ {
+		merged, err := mergeMessages("", []*entity.Message{msg})
+		if err != nil {
+			t.Fatalf("depth %d: unexpected error %v", depth, err)
+		}
+		if got := mergedDepth(entity.MessageContent{Merged: merged}); got != depth {
+			t.Fatalf("expected depth %d, got %d", depth, got)
+		}
+		msg = &entity.Message{SenderId: "bob", MsgType: constant.MsgTypeMerged, Content: entity.MessageContent{Merged: merged}}
+	}
+	if _, err := mergeMessages("", []*entity.Message{msg}); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected nesting past %d to be rejected, got %v", maxMergedDepth, err)
+	}
+}
+
+func TestForwardedContentIsACopyWithoutMentions(t *testing.T) {
+	content := entity.MessageContent{Text: &entity.TextContent{Text: "hi @bob", Mentions: []string{"bob"}}}
+	copied, err := forwardedContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.Text.Text != "hi @bob" || copied.Text.Mentions != nil {
+		t.Fatalf("unexpected copy %+v", copied.Text)
+	}
+	copied.Text.Text = "changed"
+	if content.Text.Text != "hi @bob" || len(content.Text.Mentions) != 1 {
+		t.Fatalf("expected the original to be untouched, got %+v", content.Text)
+	}
+}
+
+func TestForwardMessagesChecksTheRequest(t *testing.T) {
+	s := &MessageService{}
+	valid := func() *ForwardMessagesRequest {
+		return &ForwardMessagesRequest{
+			ClientMsgId:    "fw1",
+			ConversationId: "si_alice_bob",
+			Seqs:           []int64{1},
+			Targets:        []*ForwardTarget{{RecvId: "carol"}},
+		}
+	}
+	for name, mutate := range map[string]func(*ForwardMessagesRequest){
+		"no seqs":          func(r *ForwardMessagesRequest) { r.Seqs = nil },
+		"no targets":       func(r *ForwardMessagesRequest) { r.Targets = nil },
+		"ambiguous target": func(r *ForwardMessagesRequest) { r.Targets[0].GroupId = "g1" },
+		"empty target":     func(r *ForwardMessagesRequest) { r.Targets[0].RecvId = "" },
+		"title unmerged":   func(r *ForwardMessagesRequest) { r.Title = "history" },
+		"too many copies": func(r *ForwardMessagesRequest) {
+			r.Seqs = []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+			r.Targets = make([]*ForwardTarget, 11)
+			for i := range r.Targets {
+				r.Targets[i] = &ForwardTarget{RecvId: "carol"}
+			}
+		},
+	} {
+		req := valid()
+		mutate(req)
+		if _, err := s.ForwardMessages(context.Background(), "alice", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%s: expected invalid param, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// Bounds of quotes
+const (
+	maxQuoteSnippetLength = 200 // characters a reply may quote of a text
+	quotePreviewLength    = 100 // characters of the text shown when a reply quotes all of it
+)
+
+// applyQuote checks the quote of a message about to be sent: the quoted message must be one of
+// the same conversation the sender can see, and a snippet part of its text. It then records
+// the quote on msg.
+func (s *MessageService) applyQuote(ctx context.Context, senderId string, msg *entity.Message, quote *entity.QuotedMessage) error {
+	if (quote.ConversationId != "" && quote.ConversationId != msg.ConversationId) || quote.Seq <= 0 {
+		return errcode.ErrInvalidParam
+	}
+	if utf8.RuneCountInString(quote.Snippet) > maxQuoteSnippetLength {
+		return errcode.ErrInvalidParam
+	}
+	quoted, err := s.GetMessage(ctx, senderId, msg.ConversationId, quote.Seq)
+	if err != nil {
+		return err
+	}
+	if quoted.RevokedAt > 0 {
+		return errcode.ErrMessageRevoked
+	}
+	if quoted.DeletedAt > 0 || quoted.MsgType == constant.MsgTypeRevoke {
+		return errcode.ErrInvalidParam
+	}
+	if quote.Snippet != "" && (quoted.Content.Text == nil || !strings.Contains(quoted.Content.Text.Text, quote.Snippet)) {
+		return errcode.ErrInvalidParam
+	}
+	msg.QuoteSeq = quoted.Seq
+	msg.QuoteSnippet = quote.Snippet
+	msg.Quote = quoteOf(msg, quoted)
+	return nil
+}
+
+// quoteOf describes the message a reply quotes from its current state; quoted is nil when the
+// user cannot see it
+func quoteOf(reply, quoted *entity.Message) *entity.QuotedMessage {
+	quote := &entity.QuotedMessage{ConversationId: reply.ConversationId, Seq: reply.QuoteSeq}
+	if quoted == nil || quoted.RevokedAt > 0 || quoted.DeletedAt > 0 {
+		return quote
+	}
+	quote.SenderId = quoted.SenderId
+	quote.MsgType = quoted.MsgType
+	if quoted.Content.Text != nil {
+		text := quoted.Content.Text.Text
+		switch {
+		case reply.QuoteSnippet == "":
+			quote.Snippet = truncateRunes(text, quotePreviewLength)
+		case strings.Contains(text, reply.QuoteSnippet):
+			// Edits may have removed the quoted part
+			quote.Snippet = reply.QuoteSnippet
+		}
+	}
+	return quote
+}
+
+// fillQuotes sets the quotes of pulled messages of one conversation, loading the quoted
+// messages with one query. Quoted messages before beginSeq, the start of the user's visible
+// range, sent before the retention cutoff or disappeared are treated as not visible.
+func (s *MessageService) fillQuotes(ctx context.Context, conversationId string, messages []*entity.Message, beginSeq, cutoff int64) error {
+	quoted := make(map[int64]*entity.Message)
+	var seqs []int64
+	for _, msg := range messages {
+		if msg.QuoteSeq >= beginSeq && msg.QuoteSeq > 0 {
+			if _, ok := quoted[msg.QuoteSeq]; !ok {
+				quoted[msg.QuoteSeq] = nil
+				seqs = append(seqs, msg.QuoteSeq)
+			}
+		}
+	}
+	if len(seqs) > 0 {
+		loaded, err := s.msgRepo.PullMessagesBySeqList(ctx, conversationId, seqs)
+		if err != nil {
+			return err
+		}
+		now := entity.NowUnixMilli()
+		for _, msg := range loaded {
+			if (cutoff <= 0 || msg.SendAt >= cutoff) && !msg.Disappeared(now) {
+				quoted[msg.Seq] = msg
+			}
+		}
+	}
+	for _, msg := range messages {
+		if msg.QuoteSeq > 0 {
+			msg.Quote = quoteOf(msg, quoted[msg.QuoteSeq])
+		}
+	}
+	return nil
+}
+
+const (
+	DefaultThreadListLimit = 20
+	MaxThreadListLimit     = 100
+)
+
+// ListThreadRequest represents list replies of a message request. Cursor is the next_cursor of
+// the previous page.
+type ListThreadRequest struct {
+	ConversationId string `json:"conversation_id" query:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `json:"seq" query:"seq" validate:"min=1"`
+	Cursor         int64  `json:"cursor" query:"cursor" validate:"min=0"`
+	Limit          int    `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// ThreadResult is a page of the replies to a message, oldest first
+type ThreadResult struct {
+	List       []*entity.Message
+	HasMore    bool
+	NextCursor int64
+}
+
+// ListThread lists the replies quoting a message the user can see, oldest first, following
+// the visibility rules of PullMessages
+func (s *MessageService) ListThread(ctx context.Context, userId string, req *ListThreadRequest) (*ThreadResult, error) {
+	if req.ConversationId == "" || req.Seq <= 0 || req.Cursor < 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultThreadListLimit
+	}
+	limit = min(limit, MaxThreadListLimit)
+
+	if _, err := s.GetMessage(ctx, userId, req.ConversationId, req.Seq); err != nil {
+		return nil, err
+	}
+	beginSeq, endSeq, _, err := s.visibleSeqRange(ctx, userId, req.ConversationId, req.Seq+1, 0)
+	if err != nil {
+		return nil, err
+	}
+	after := max(req.Cursor, beginSeq-1)
+	result := &ThreadResult{List: []*entity.Message{}}
+	if after >= endSeq {
+		return result, nil
+	}
+
+	replies, err := s.msgRepo.ListReplies(ctx, req.ConversationId, req.Seq, after, endSeq, limit+1)
+	if err != nil {
+		log.CtxError(ctx, "list replies failed: conversation_id=%s, seq=%d, error=%v", req.ConversationId, req.Seq, err)
+		return nil, errcode.ErrPullFailed
+	}
+	if len(replies) > limit {
+		replies = replies[:limit]
+		result.HasMore = true
+		result.NextCursor = replies[limit-1].Seq
+	}
+	result.List = s.decoratePulled(ctx, userId, req.ConversationId, beginSeq, replies)
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestQuoteOf(t *testing.T) {
+	quoted := &entity.Message{
+		ConversationId: "sg_g1",
+		Seq:            3,
+		SenderId:       "bob",
+		MsgType:        constant.MsgTypeText,
+		Content:        entity.MessageContent{Text: &entity.TextContent{Text: "lunch at noon? " + strings.Repeat("x", 200)}},
+	}
+	reply := &entity.Message{ConversationId: "sg_g1", QuoteSeq: 3, QuoteSnippet: "at noon"}
+
+	quote := quoteOf(reply, quoted)
+	if quote.SenderId != "bob" || quote.MsgType != constant.MsgTypeText || quote.Snippet != "at noon" {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+
+	whole := *reply
+	whole.QuoteSnippet = ""
+	if quote = quoteOf(&whole, quoted); len([]rune(quote.Snippet)) != quotePreviewLength || !strings.HasPrefix(quote.Snippet, "lunch") {
+		t.Fatalf("expected a preview of the text, got %q", quote.Snippet)
+	}
+
+	edited := *quoted
+	edited.Content = entity.MessageContent{Text: &entity.TextContent{Text: "lunch tomorrow?"}}
+	if quote = quoteOf(reply, &edited); quote.Snippet != "" || quote.SenderId != "bob" {
+		t.Fatalf("expected the snippet edited away to be left out, got %+v", quote)
+	}
+
+	revoked := *quoted
+	revoked.RevokedAt = 1
+	for _, q := range []*entity.Message{&revoked, nil} {
+		if quote = quoteOf(reply, q); *quote != (entity.QuotedMessage{ConversationId: "sg_g1", Seq: 3}) {
+			t.Fatalf("expected only the seq of a hidden quoted message, got %+v", quote)
+		}
+	}
+}
+
+func TestApplyQuoteChecksTheReference(t *testing.T) {
+	s := &MessageService{}
+	msg := &entity.Message{ConversationId: "si_alice_bob"}
+	for name, quote := range map[string]*entity.QuotedMessage{
+		"no seq":             {ConversationId: "si_alice_bob"},
+		"other conversation": {ConversationId: "si_alice_carol", Seq: 1},
+		"long snippet":       {Seq: 1, Snippet: strings.Repeat("x", maxQuoteSnippetLength+1)},
+	} {
+		if err := s.applyQuote(context.Background(), "alice", msg, quote); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%s: expected invalid param, got %v", name, err)
+		}
+	}
+}
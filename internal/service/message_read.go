@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// ReadStatus tells whether a single chat message was delivered to its receiver and whether they
+// read it. ReadSeq and DeliveredSeq are the seqs of the receiver in the conversation, which
+// also answer for the messages before; a read message counts as delivered.
+type ReadStatus struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	Delivered      bool   `json:"delivered"`
+	DeliveredSeq   int64  `json:"delivered_seq"`
+	Read           bool   `json:"read"`
+	ReadSeq        int64  `json:"read_seq"`
+}
+
+// GetReadStatus gets the delivery and read status of a single chat message the user can see.
+// The receiver read it once their read seq reached its seq; read seqs only advance, so a
+// message stays read. Delivered seqs work the same way.
+func (s *MessageService) GetReadStatus(ctx context.Context, userId, conversationId string, seq int64) (*ReadStatus, error) {
+	msg, err := s.GetMessage(ctx, userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.SessionType != constant.SessionTypeSingle {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	status := &ReadStatus{ConversationId: conversationId, Seq: seq}
+	seqUser, err := s.seqRepo.GetSeqUser(ctx, msg.RecvId, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "get receiver read seq failed: conversation_id=%s, user_id=%s, error=%v", conversationId, msg.RecvId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if seqUser != nil {
+		status.ReadSeq = seqUser.ReadSeq
+		status.DeliveredSeq = max(seqUser.DeliveredSeq, seqUser.ReadSeq)
+	}
+	status.Read = status.ReadSeq >= seq
+	status.Delivered = status.DeliveredSeq >= seq
+	return status, nil
+}
+
+// MarkDelivered records that the messages of a single chat up to seq reached the user, when
+// the gateway pushed them to a connection of the user or the user pulled them, and tells the
+// peer when the delivered seq advanced. It is best effort: failures are logged, a later
+// delivery or pull records the seq again.
+func (s *MessageService) MarkDelivered(ctx context.Context, userId, conversationId string, seq int64) {
+	peerId := singleChatPeer(conversationId, userId)
+	if peerId == "" || peerId == userId || seq <= 0 {
+		return
+	}
+	advanced, err := s.seqRepo.UpdateDeliveredSeq(ctx, userId, conversationId, seq)
+	if err != nil {
+		log.CtxWarn(ctx, "update delivered seq failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		return
+	}
+	if advanced && s.pusher != nil {
+		s.pusher.NotifyDelivered(conversationId, userId, seq, []string{peerId})
+	}
+}
+
+// singleChatPeer returns the other participant of a single chat userId takes part in, empty
+// for other conversations
+func singleChatPeer(conversationId, userId string) string {
+	participants, ok := strings.CutPrefix(conversationId, constant.SingleConversationPrefix)
+	if !ok {
+		return ""
+	}
+	userA, userB, ok := strings.Cut(participants, ":")
+	switch {
+	case !ok:
+		return ""
+	case userA == userId:
+		return userB
+	case userB == userId:
+		return userA
+	}
+	return ""
+}
+
+// GroupReadMembers lists the members who read a group message, the sender excepted, in user id
+// order. ReadCount is their number, the read_count of the message.
+type GroupReadMembers struct {
+	ConversationId string         `json:"conversation_id"`
+	Seq            int64          `json:"seq"`
+	ReadCount      int64          `json:"read_count"`
+	Readers        []*GroupReader `json:"readers"`
+}
+
+// GroupReader is a member who read a group message, ReadSeq is how far they read
+type GroupReader struct {
+	UserId  string `json:"user_id"`
+	ReadSeq int64  `json:"read_seq"`
+}
+
+// GetGroupReadMembers lists the readers of a group message the user can see, counted from the
+// read seqs of the members like the read_count of pulled messages
+func (s *MessageService) GetGroupReadMembers(ctx context.Context, userId, conversationId string, seq int64) (*GroupReadMembers, error) {
+	msg, err := s.GetMessage(ctx, userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.SessionType != constant.SessionTypeGroup {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	seqUsers, err := s.seqRepo.GetReadSeqs(ctx, conversationId, seq)
+	if err != nil {
+		log.CtxError(ctx, "get read seqs failed: conversation_id=%s, seq=%d, error=%v", conversationId, seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	result := &GroupReadMembers{ConversationId: conversationId, Seq: seq, Readers: make([]*GroupReader, 0, len(seqUsers))}
+	for _, seqUser := range seqUsers {
+		if seqUser.UserId == msg.SenderId {
+			continue
+		}
+		result.Readers = append(result.Readers, &GroupReader{UserId: seqUser.UserId, ReadSeq: seqUser.ReadSeq})
+	}
+	sort.Slice(result.Readers, func(i, j int) bool { return result.Readers[i].UserId < result.Readers[j].UserId })
+	result.ReadCount = int64(len(result.Readers))
+	return result, nil
+}
+
+// maxReadCountUpdates is the number of the latest messages a group read pushes new read counts
+// for; older messages of the range get theirs when pulled
+const maxReadCountUpdates = 100
+
+// MessageReadCount is the new read count of a message
+type MessageReadCount struct {
+	Seq       int64 `json:"seq"`
+	ReadCount int64 `json:"read_count"`
+}
+
+// notifyGroupReadCounts pushes the new read counts of the messages a member read from fromSeq,
+// exclusive, up to readSeq to their senders, in the background as it counts the readers
+func (s *ConversationService) notifyGroupReadCounts(ctx context.Context, conversationId, readerId string, fromSeq, readSeq int64) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		beginSeq := max(fromSeq+1, readSeq-maxReadCountUpdates+1)
+		messages, err := s.msgRepo.PullMessages(ctx, conversationId, beginSeq, readSeq, maxReadCountUpdates)
+		if err != nil {
+			log.CtxWarn(ctx, "load read messages failed: conversation_id=%s, error=%v", conversationId, err)
+			return
+		}
+		messages = readCountedMessages(messages, readerId)
+		if err = fillReadCounts(ctx, s.seqRepo, conversationId, messages); err != nil {
+			log.CtxWarn(ctx, "count readers failed: conversation_id=%s, error=%v", conversationId, err)
+			return
+		}
+		for senderId, counts := range readCountsBySender(messages) {
+			s.notifier.NotifyReadCounts(conversationId, readerId, counts, senderId)
+		}
+	}()
+}
+
+// readCountedMessages keeps the messages whose read count a read by readerId changed: those of
+// other senders that are still shown
+func readCountedMessages(messages []*entity.Message, readerId string) []*entity.Message {
+	kept := messages[:0]
+	for _, msg := range messages {
+		if msg.SenderId == readerId || msg.SenderId == "" || msg.DeletedAt > 0 || msg.RevokedAt > 0 {
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	return kept
+}
+
+// readCountsBySender groups the read counts of messages by sender, in seq order
+func readCountsBySender(messages []*entity.Message) map[string][]*MessageReadCount {
+	counts := make(map[string][]*MessageReadCount)
+	for _, msg := range messages {
+		counts[msg.SenderId] = append(counts[msg.SenderId], &MessageReadCount{Seq: msg.Seq, ReadCount: msg.ReadCount})
+	}
+	return counts
+}
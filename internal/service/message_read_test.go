@@ -0,0 +1,42 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+func TestReadCountsBySenderSkipsTheReader(t *testing.T) {
+	messages := []*entity.Message{
+		{Seq: 1, SenderId: "alice", ReadCount: 2},
+		{Seq: 2, SenderId: "bob", ReadCount: 1},
+		{Seq: 3, SenderId: "alice", ReadCount: 1, RevokedAt: 1},
+		{Seq: 4, SenderId: "carol", ReadCount: 1},
+		{Seq: 5, SenderId: "alice", ReadCount: 1},
+	}
+	got := readCountsBySender(readCountedMessages(messages, "bob"))
+	want := map[string][]*MessageReadCount{
+		"alice": {{Seq: 1, ReadCount: 2}, {Seq: 5, ReadCount: 1}},
+		"carol": {{Seq: 4, ReadCount: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSingleChatPeer(t *testing.T) {
+	for _, tc := range []struct {
+		conversationId, userId, want string
+	}{
+		{"si_alice:bob", "alice", "bob"},
+		{"si_alice:bob", "bob", "alice"},
+		{"si_alice:bob", "carol", ""},
+		{"si_alice:alice", "alice", "alice"},
+		{"sg_team", "alice", ""},
+	} {
+		if got := singleChatPeer(tc.conversationId, tc.userId); got != tc.want {
+			t.Errorf("%s as %s: expected %q, got %q", tc.conversationId, tc.userId, tc.want, got)
+		}
+	}
+}
@@ -2,21 +2,39 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mbeoliero/kit/log"
 	"gorm.io/gorm"
 
+	"github.com/ZaiSpace/nexo_im/internal/config"
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/analytics"
 	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
+)
+
+// Session type labels for message metrics
+const (
+	sessionTypeSingleLabel = "single"
+	sessionTypeGroupLabel  = "group"
+	sessionTypeSystemLabel = "system"
 )
 
 // MessagePusher interface for pushing messages
 type MessagePusher interface {
 	AsyncPushToUsers(msg *entity.Message, userIds []string, excludeConnId string)
+	NotifyMessageEdited(msg *entity.Message, userIds []string)
+	NotifyMessageRevoked(notice *entity.Message, userIds []string)
+	NotifyMessageDeleted(deletion *entity.MessageDeletion, userIds []string)
+	NotifyDelivered(conversationId, userId string, deliveredSeq int64, userIds []string)
 }
 
 // MessageService handles message-related business logic
@@ -28,6 +46,27 @@ type MessageService struct {
 	userRepo  *repository.UserRepo
 	repos     *repository.Repositories
 	pusher    MessagePusher
+	retention *RetentionPolicy
+	stats     *StatsService
+	// guestContacts are the users guests may chat with, see config.GuestConfig
+	guestContacts map[string]bool
+	preSend       []PreSendChecker
+	bots          []BotNotifier
+	// encryptedLimits caps the size of end-to-end encrypted messages
+	encryptedLimits config.MessageEncryptedConfig
+	// mediaURLPrefix, when set, is the URL prefix media content must start with
+	mediaURLPrefix string
+	media          MediaDescriber
+	mediaTracker   MediaTracker
+	linkPreviews   LinkPreviewer
+	// mentionRepo tracks the unread @mentions of recipients
+	mentionRepo *repository.MentionRepo
+	// editRepo keeps the versions of messages replaced by their senders
+	editRepo *repository.MessageEditRepo
+	// reactionRepo holds the emoji reactions aggregated onto pulled messages
+	reactionRepo *repository.ReactionRepo
+	// revokeWindow is how long after sending a sender may recall a message, negative for no limit
+	revokeWindow time.Duration
 }
 
 // NewMessageService creates a new MessageService
@@ -39,6 +78,10 @@ func NewMessageService(repos *repository.Repositories) *MessageService {
 		groupRepo: repos.Group,
 		userRepo:  repos.User,
 		repos:     repos,
+
+		mentionRepo:  repos.Mention,
+		editRepo:     repos.MessageEdit,
+		reactionRepo: repos.Reaction,
 	}
 }
 
@@ -47,6 +90,183 @@ func (s *MessageService) SetPusher(pusher MessagePusher) {
 	s.pusher = pusher
 }
 
+// AddPreSendChecker adds a policy consulted before user messages are stored; policies run in
+// the order they were added, each seeing the message as rewritten by the previous ones
+func (s *MessageService) AddPreSendChecker(checker PreSendChecker) {
+	s.preSend = append(s.preSend, checker)
+}
+
+// AddBotNotifier adds a notifier routing new messages to bots, such as bot webhooks or agent backends
+func (s *MessageService) AddBotNotifier(bots BotNotifier) {
+	s.bots = append(s.bots, bots)
+}
+
+// notifyBots tells the bot notifiers about a new message and its recipients
+func (s *MessageService) notifyBots(ctx context.Context, msg *entity.Message, userIds []string) {
+	for _, bots := range s.bots {
+		bots.NotifyBots(ctx, msg, userIds)
+	}
+}
+
+// SetStats sets the stats recorder
+func (s *MessageService) SetStats(stats *StatsService) {
+	s.stats = stats
+}
+
+// SetGuestContacts sets the users guest accounts may chat with
+func (s *MessageService) SetGuestContacts(userIds []string) {
+	s.guestContacts = make(map[string]bool, len(userIds))
+	for _, id := range userIds {
+		s.guestContacts[id] = true
+	}
+}
+
+// checkGuestChat limits single chats involving a guest to the guest contacts, so guests
+// can neither reach nor be reached by other users
+func (s *MessageService) checkGuestChat(sender, recv *entity.User) error {
+	if sender.IsGuestExpired(entity.NowUnixMilli()) {
+		return errcode.ErrGuestExpired
+	}
+	if sender.IsGuest() && !s.guestContacts[recv.Id] {
+		return errcode.ErrNoPermission
+	}
+	if recv.IsGuest() && !s.guestContacts[sender.Id] {
+		return errcode.ErrNoPermission
+	}
+	return nil
+}
+
+// SetEncryptedLimits sets the size caps of end-to-end encrypted messages
+func (s *MessageService) SetEncryptedLimits(cfg *config.MessageEncryptedConfig) {
+	s.encryptedLimits = *cfg
+}
+
+// SetRevokeWindow sets how long after sending a sender may recall a message
+func (s *MessageService) SetRevokeWindow(window time.Duration) {
+	s.revokeWindow = window
+}
+
+// SetMediaURLPrefix restricts the media of user messages to URLs starting with prefix, such as
+// the objects of the managed storage
+func (s *MessageService) SetMediaURLPrefix(prefix string) {
+	s.mediaURLPrefix = prefix
+}
+
+// checkMediaURL rejects media content outside the managed storage
+func (s *MessageService) checkMediaURL(content entity.MessageContent) error {
+	if s.mediaURLPrefix == "" {
+		return nil
+	}
+	var url string
+	switch {
+	case content.Image != nil:
+		url = content.Image.Url
+	case content.Video != nil:
+		url = content.Video.Url
+	case content.Audio != nil:
+		url = content.Audio.Url
+	case content.File != nil:
+		url = content.File.Url
+	default:
+		return nil
+	}
+	if !strings.HasPrefix(url, s.mediaURLPrefix) {
+		return errcode.ErrInvalidParam
+	}
+	return nil
+}
+
+// MediaDescriber fills in what the server knows about the media a message references
+type MediaDescriber interface {
+	// DescribeImage sets the thumbnail and dimensions of an image
+	DescribeImage(ctx context.Context, image *entity.ImageContent)
+	// DescribeAudio sets the duration of an audio clip
+	DescribeAudio(ctx context.Context, audio *entity.AudioContent)
+}
+
+// SetMediaDescriber sets the describer of the media of user messages
+func (s *MessageService) SetMediaDescriber(media MediaDescriber) {
+	s.media = media
+}
+
+// describeMedia lets the media describer fill in the content
+func (s *MessageService) describeMedia(ctx context.Context, content entity.MessageContent) {
+	if s.media == nil {
+		return
+	}
+	if content.Image != nil {
+		s.media.DescribeImage(ctx, content.Image)
+	}
+	if content.Audio != nil {
+		s.media.DescribeAudio(ctx, content.Audio)
+	}
+}
+
+// LinkPreviewer builds the link previews of text messages once they are stored, see
+// LinkPreviewService
+type LinkPreviewer interface {
+	QueueLinkPreview(ctx context.Context, msg *entity.Message)
+}
+
+// SetLinkPreviewer sets the builder of link previews; previews are then set by the server only
+func (s *MessageService) SetLinkPreviewer(previewer LinkPreviewer) {
+	s.linkPreviews = previewer
+}
+
+// clearLinkPreview drops the link preview sent by the client when the server builds them
+func (s *MessageService) clearLinkPreview(content entity.MessageContent) {
+	if s.linkPreviews != nil && content.Text != nil {
+		content.Text.Preview = nil
+	}
+}
+
+// queueLinkPreview hands a stored text message to the link previewer
+func (s *MessageService) queueLinkPreview(ctx context.Context, msg *entity.Message) {
+	if s.linkPreviews != nil && msg.MsgType == constant.MsgTypeText {
+		s.linkPreviews.QueueLinkPreview(ctx, msg)
+	}
+}
+
+// recordSent emits the message_sent analytics event of a stored message
+func recordSent(ctx context.Context, msg *entity.Message) {
+	if !analytics.Enabled() {
+		return
+	}
+	analytics.Record(ctx, &analytics.Event{
+		Type:           analytics.EventMessageSent,
+		UserId:         msg.SenderId,
+		GroupId:        msg.GroupId,
+		ConversationId: msg.ConversationId,
+		Props:          map[string]any{"session_type": msg.SessionType, "msg_type": msg.MsgType},
+	})
+}
+
+// MediaTracker checks the uploads messages share and records the conversations they are sent
+// in, see StorageService
+type MediaTracker interface {
+	// CheckMedia verifies that a user may share the uploads content references
+	CheckMedia(ctx context.Context, userId string, content entity.MessageContent) error
+	// TrackMedia records the uploads a stored message references as sent in its conversation
+	TrackMedia(ctx context.Context, msg *entity.Message)
+}
+
+// SetMediaTracker sets the tracker of the uploads shared in user messages
+func (s *MessageService) SetMediaTracker(tracker MediaTracker) {
+	s.mediaTracker = tracker
+}
+
+// trackMedia lets the media tracker record the uploads of a stored message
+func (s *MessageService) trackMedia(ctx context.Context, msg *entity.Message) {
+	if s.mediaTracker != nil {
+		s.mediaTracker.TrackMedia(ctx, msg)
+	}
+}
+
+// SetRetentionPolicy sets the retention policy applied to pull ranges
+func (s *MessageService) SetRetentionPolicy(policy *RetentionPolicy) {
+	s.retention = policy
+}
+
 // SendMessageRequest represents send message request
 type SendMessageRequest struct {
 	ClientMsgId string                `json:"client_msg_id"`
@@ -55,6 +275,9 @@ type SendMessageRequest struct {
 	SessionType int32                 `json:"session_type"`
 	MsgType     int32                 `json:"msg_type"`
 	Content     entity.MessageContent `json:"content"`
+	QuotedMsg   *entity.QuotedMessage `json:"quoted_msg,omitempty"` // the message replied to; only conversation_id, seq and snippet are read
+	Extra       *string               `json:"-"`                    // set by internal senders only
+	Forwarded   bool                  `json:"-"`                    // set by ForwardMessages only
 }
 
 func validateMessageContent(msgType int32, content entity.MessageContent) error {
@@ -79,6 +302,7 @@ func validateMessageContent(msgType int32, content entity.MessageContent) error
 		if content.Audio == nil {
 			return errcode.ErrInvalidParam
 		}
+		return validateAudioContent(content.Audio)
 	case constant.MsgTypeFile:
 		if content.File == nil {
 			return errcode.ErrInvalidParam
@@ -87,6 +311,16 @@ func validateMessageContent(msgType int32, content entity.MessageContent) error
 		if len(content.Custom) == 0 {
 			return errcode.ErrInvalidParam
 		}
+	case constant.MsgTypeEncrypted:
+		if content.Encrypted == nil {
+			return errcode.ErrInvalidParam
+		}
+		return validateEncryptedContent(content.Encrypted)
+	case constant.MsgTypePoll:
+		if content.Poll == nil {
+			return errcode.ErrInvalidParam
+		}
+		return validatePollContent(content.Poll)
 	default:
 		return errcode.ErrInvalidParam
 	}
@@ -94,6 +328,73 @@ func validateMessageContent(msgType int32, content entity.MessageContent) error
 	return nil
 }
 
+// Bounds of the voice-note fields of audio messages
+const (
+	maxAudioDuration     = 60 * 60 * 1000 // milliseconds
+	maxAudioWaveform     = 256            // samples
+	maxAudioWaveformPeak = 255
+)
+
+// validateAudioContent checks the duration and the waveform of an audio message
+func validateAudioContent(content *entity.AudioContent) error {
+	if content.Duration < 0 || content.Duration > maxAudioDuration || len(content.Waveform) > maxAudioWaveform {
+		return errcode.ErrInvalidParam
+	}
+	for _, sample := range content.Waveform {
+		if sample < 0 || sample > maxAudioWaveformPeak {
+			return errcode.ErrInvalidParam
+		}
+	}
+	return nil
+}
+
+// validateEncryptedContent checks the envelope of an encrypted message; the ciphertexts
+// themselves are opaque to the server
+func validateEncryptedContent(content *entity.EncryptedContent) error {
+	if len(content.Ciphertexts) == 0 {
+		return errcode.ErrInvalidParam
+	}
+	type device struct {
+		userId   string
+		deviceId int
+	}
+	seen := make(map[device]bool, len(content.Ciphertexts))
+	for _, ct := range content.Ciphertexts {
+		if ct == nil || ct.UserId == "" || ct.DeviceId <= 0 || ct.Body == "" {
+			return errcode.ErrInvalidParam
+		}
+		if _, err := base64.StdEncoding.DecodeString(ct.Body); err != nil {
+			return errcode.ErrInvalidParam
+		}
+		d := device{ct.UserId, ct.DeviceId}
+		if seen[d] {
+			return errcode.ErrInvalidParam
+		}
+		seen[d] = true
+	}
+	return nil
+}
+
+// checkEncryptedLimits enforces the device and size caps of encrypted messages
+func (s *MessageService) checkEncryptedLimits(content entity.MessageContent) error {
+	if content.Encrypted == nil {
+		return nil
+	}
+	if limit := s.encryptedLimits.MaxDevices; limit > 0 && len(content.Encrypted.Ciphertexts) > limit {
+		return errcode.ErrMessageTooLarge
+	}
+	if limit := s.encryptedLimits.MaxBytes; limit > 0 {
+		size := 0
+		for _, ct := range content.Encrypted.Ciphertexts {
+			size += len(ct.Body)
+		}
+		if size > limit {
+			return errcode.ErrMessageTooLarge
+		}
+	}
+	return nil
+}
+
 // SendSingleMessage sends a single chat message
 func (s *MessageService) SendSingleMessage(ctx context.Context, senderId string, req *SendMessageRequest) (*entity.Message, error) {
 	return s.sendSingleMessage(ctx, senderId, req, true)
@@ -105,6 +406,9 @@ func (s *MessageService) SendSingleMessageWithoutMarkRead(ctx context.Context, s
 }
 
 func (s *MessageService) sendSingleMessage(ctx context.Context, senderId string, req *SendMessageRequest, markSenderRead bool) (*entity.Message, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.SendSingleMessage")
+	defer span.End()
+
 	// Validate request
 	if req.RecvId == "" {
 		return nil, errcode.ErrInvalidParam
@@ -112,30 +416,50 @@ func (s *MessageService) sendSingleMessage(ctx context.Context, senderId string,
 	if req.ClientMsgId == "" {
 		return nil, errcode.ErrInvalidParam
 	}
-	if err := validateMessageContent(req.MsgType, req.Content); err != nil {
+	if err := validateSendContent(req); err != nil {
+		return nil, err
+	}
+	if err := normalizeMentions(req.Content); err != nil {
+		return nil, err
+	}
+	if err := s.checkEncryptedLimits(req.Content); err != nil {
+		return nil, err
+	}
+	if err := s.checkMediaURL(req.Content); err != nil {
 		return nil, err
 	}
+	if s.mediaTracker != nil {
+		if err := s.mediaTracker.CheckMedia(ctx, senderId, req.Content); err != nil {
+			return nil, err
+		}
+	}
+	s.describeMedia(ctx, req.Content)
+	s.clearLinkPreview(req.Content)
 
 	// Validate sender/receiver existence to avoid writing conversations with invalid user ids.
-	senderExists, err := s.userRepo.Exists(ctx, senderId)
+	sender, err := s.userRepo.GetById(ctx, senderId)
 	if err != nil {
-		log.CtxError(ctx, "check sender exists failed: sender_id=%s, error=%v", senderId, err)
+		log.CtxError(ctx, "get sender failed: sender_id=%s, error=%v", senderId, err)
 		return nil, errcode.ErrInternalServer
 	}
-	if !senderExists {
+	if sender == nil {
 		return nil, errcode.ErrUserNotFound
 	}
 
+	recv := sender
 	if req.RecvId != senderId {
-		recvExists, err := s.userRepo.Exists(ctx, req.RecvId)
+		recv, err = s.userRepo.GetById(ctx, req.RecvId)
 		if err != nil {
-			log.CtxError(ctx, "check receiver exists failed: recv_id=%s, error=%v", req.RecvId, err)
+			log.CtxError(ctx, "get receiver failed: recv_id=%s, error=%v", req.RecvId, err)
 			return nil, errcode.ErrInternalServer
 		}
-		if !recvExists {
+		if recv == nil {
 			return nil, errcode.ErrUserNotFound
 		}
 	}
+	if err = s.checkGuestChat(sender, recv); err != nil {
+		return nil, err
+	}
 
 	// Check for idempotency
 	existingMsg, err := s.msgRepo.GetByClientMsgId(ctx, senderId, req.ClientMsgId)
@@ -150,9 +474,29 @@ func (s *MessageService) sendSingleMessage(ctx context.Context, senderId string,
 	}
 
 	conversationId := entity.GenSingleConversationId(senderId, req.RecvId)
-	now := entity.NowUnixMilli()
-
-	var msg *entity.Message
+	msg := &entity.Message{
+		ConversationId: conversationId,
+		ClientMsgId:    req.ClientMsgId,
+		SenderId:       senderId,
+		RecvId:         req.RecvId,
+		SessionType:    constant.SessionTypeSingle,
+		MsgType:        req.MsgType,
+		Content:        req.Content,
+		Extra:          req.Extra,
+		Forwarded:      req.Forwarded,
+	}
+	if req.QuotedMsg != nil {
+		if err = s.applyQuote(ctx, senderId, msg, req.QuotedMsg); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.checkPreSend(ctx, msg); err != nil {
+		return nil, err
+	}
+	msg.SendAt = entity.NowUnixMilli()
+	if err = s.stampExpiry(ctx, msg); err != nil {
+		return nil, err
+	}
 
 	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
 		// Allocate seq
@@ -160,19 +504,7 @@ func (s *MessageService) sendSingleMessage(ctx context.Context, senderId string,
 		if err != nil {
 			return errcode.ErrSeqAllocFailed.Wrap(err)
 		}
-
-		// Create message
-		msg = &entity.Message{
-			ConversationId: conversationId,
-			Seq:            seq,
-			ClientMsgId:    req.ClientMsgId,
-			SenderId:       senderId,
-			RecvId:         req.RecvId,
-			SessionType:    constant.SessionTypeSingle,
-			MsgType:        req.MsgType,
-			Content:        req.Content,
-			SendAt:         now,
-		}
+		msg.Seq = seq
 
 		if err = s.msgRepo.Create(ctx, tx, msg); err != nil {
 			return err
@@ -192,6 +524,7 @@ func (s *MessageService) sendSingleMessage(ctx context.Context, senderId string,
 	})
 
 	if err != nil {
+		metrics.MessagesSentTotal.WithLabelValues(sessionTypeSingleLabel, "failed").Inc()
 		var e *errcode.Error
 		if errors.As(err, &e) {
 			return nil, e
@@ -200,20 +533,46 @@ func (s *MessageService) sendSingleMessage(ctx context.Context, senderId string,
 		return nil, errcode.ErrSendFailed
 	}
 
-	if markSenderRead {
+	// Messages to the saved messages conversation are always read, so it never counts as unread
+	if markSenderRead || req.RecvId == senderId {
 		// Normal messages keep sender fully read; this path intentionally does not.
 		_ = s.seqRepo.UpdateReadSeq(ctx, senderId, conversationId, msg.Seq)
 	}
+	// Before the push, so that the recipients may download the media right away
+	s.trackMedia(ctx, msg)
+	s.recordMentions(ctx, msg, []string{req.RecvId})
 
 	// Async push to receiver (and sender's other connections)
 	if s.pusher != nil {
 		s.pusher.AsyncPushToUsers(msg, []string{senderId, req.RecvId}, "")
 	}
+	if req.RecvId != senderId {
+		s.notifyBots(ctx, msg, []string{req.RecvId})
+	}
+	s.queueLinkPreview(ctx, msg)
+
+	metrics.MessagesSentTotal.WithLabelValues(sessionTypeSingleLabel, "ok").Inc()
+	s.stats.RecordMessage(ctx, msg)
+	recordSent(ctx, msg)
 
 	log.CtxInfo(ctx, "single message sent: sender_id=%s, recv_id=%s, seq=%d", senderId, req.RecvId, msg.Seq)
 	return msg, nil
 }
 
+// checkPreSend lets the pre-send policies reject msg or rewrite it before it is stored
+func (s *MessageService) checkPreSend(ctx context.Context, msg *entity.Message) error {
+	// Encrypted payloads are opaque, there is nothing for the policies to inspect
+	if msg.MsgType == constant.MsgTypeEncrypted {
+		return nil
+	}
+	for _, checker := range s.preSend {
+		if err := checker.CheckPreSend(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SendGroupMessage sends a group chat message
 func (s *MessageService) SendGroupMessage(ctx context.Context, senderId string, req *SendMessageRequest) (*entity.Message, error) {
 	return s.sendGroupMessage(ctx, senderId, req, true)
@@ -225,6 +584,9 @@ func (s *MessageService) SendGroupMessageWithoutMarkRead(ctx context.Context, se
 }
 
 func (s *MessageService) sendGroupMessage(ctx context.Context, senderId string, req *SendMessageRequest, markSenderRead bool) (*entity.Message, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.SendGroupMessage")
+	defer span.End()
+
 	// Validate request
 	if req.GroupId == "" {
 		return nil, errcode.ErrInvalidParam
@@ -232,9 +594,25 @@ func (s *MessageService) sendGroupMessage(ctx context.Context, senderId string,
 	if req.ClientMsgId == "" {
 		return nil, errcode.ErrInvalidParam
 	}
-	if err := validateMessageContent(req.MsgType, req.Content); err != nil {
+	if err := validateSendContent(req); err != nil {
+		return nil, err
+	}
+	if err := normalizeMentions(req.Content); err != nil {
+		return nil, err
+	}
+	if err := s.checkEncryptedLimits(req.Content); err != nil {
+		return nil, err
+	}
+	if err := s.checkMediaURL(req.Content); err != nil {
 		return nil, err
 	}
+	if s.mediaTracker != nil {
+		if err := s.mediaTracker.CheckMedia(ctx, senderId, req.Content); err != nil {
+			return nil, err
+		}
+	}
+	s.describeMedia(ctx, req.Content)
+	s.clearLinkPreview(req.Content)
 
 	// Check permission: sender must be active group member
 	member, err := s.groupRepo.GetMember(ctx, req.GroupId, senderId)
@@ -266,10 +644,38 @@ func (s *MessageService) sendGroupMessage(ctx context.Context, senderId string,
 		return existingMsg, nil
 	}
 
-	conversationId := entity.GenGroupConversationId(req.GroupId)
-	now := entity.NowUnixMilli()
+	// Muted members may not send new messages; a group-wide mute leaves only admins talking
+	if member.IsMuted(entity.NowUnixMilli()) {
+		return nil, errcode.ErrMemberMuted
+	}
+	if group.MuteAll && !member.IsAdmin() {
+		return nil, errcode.ErrGroupMuted
+	}
 
-	var msg *entity.Message
+	conversationId := entity.GenGroupConversationId(req.GroupId)
+	msg := &entity.Message{
+		ConversationId: conversationId,
+		ClientMsgId:    req.ClientMsgId,
+		SenderId:       senderId,
+		GroupId:        req.GroupId,
+		SessionType:    constant.SessionTypeGroup,
+		MsgType:        req.MsgType,
+		Content:        req.Content,
+		Extra:          req.Extra,
+		Forwarded:      req.Forwarded,
+	}
+	if req.QuotedMsg != nil {
+		if err = s.applyQuote(ctx, senderId, msg, req.QuotedMsg); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.checkPreSend(ctx, msg); err != nil {
+		return nil, err
+	}
+	msg.SendAt = entity.NowUnixMilli()
+	if err = s.stampExpiry(ctx, msg); err != nil {
+		return nil, err
+	}
 
 	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
 		// Allocate seq
@@ -277,19 +683,7 @@ func (s *MessageService) sendGroupMessage(ctx context.Context, senderId string,
 		if err != nil {
 			return errcode.ErrSeqAllocFailed.Wrap(err)
 		}
-
-		// Create message
-		msg = &entity.Message{
-			ConversationId: conversationId,
-			Seq:            seq,
-			ClientMsgId:    req.ClientMsgId,
-			SenderId:       senderId,
-			GroupId:        req.GroupId,
-			SessionType:    constant.SessionTypeGroup,
-			MsgType:        req.MsgType,
-			Content:        req.Content,
-			SendAt:         now,
-		}
+		msg.Seq = seq
 
 		if err := s.msgRepo.Create(ctx, tx, msg); err != nil {
 			return err
@@ -304,6 +698,7 @@ func (s *MessageService) sendGroupMessage(ctx context.Context, senderId string,
 	})
 
 	if err != nil {
+		metrics.MessagesSentTotal.WithLabelValues(sessionTypeGroupLabel, "failed").Inc()
 		if e, ok := err.(*errcode.Error); ok {
 			return nil, e
 		}
@@ -314,14 +709,24 @@ func (s *MessageService) sendGroupMessage(ctx context.Context, senderId string,
 	if markSenderRead {
 		_ = s.seqRepo.UpdateReadSeq(ctx, senderId, conversationId, msg.Seq)
 	}
+	s.trackMedia(ctx, msg)
 
 	// Async push to all active group members
-	if s.pusher != nil {
+	if s.pusher != nil || len(s.bots) > 0 || hasMentions(msg) {
 		memberIds, err := s.groupRepo.GetActiveMemberUserIds(ctx, req.GroupId)
 		if err == nil && len(memberIds) > 0 {
-			s.pusher.AsyncPushToUsers(msg, memberIds, "")
+			s.recordMentions(ctx, msg, memberIds)
+			if s.pusher != nil {
+				s.pusher.AsyncPushToUsers(msg, memberIds, "")
+			}
+			s.notifyBots(ctx, msg, memberIds)
 		}
 	}
+	s.queueLinkPreview(ctx, msg)
+
+	metrics.MessagesSentTotal.WithLabelValues(sessionTypeGroupLabel, "ok").Inc()
+	s.stats.RecordMessage(ctx, msg)
+	recordSent(ctx, msg)
 
 	log.CtxInfo(ctx, "group message sent: sender_id=%s, group_id=%s, seq=%d", senderId, req.GroupId, msg.Seq)
 	return msg, nil
@@ -349,6 +754,277 @@ func (s *MessageService) SendMessageWithoutMarkRead(ctx context.Context, senderI
 	return nil, errcode.ErrInvalidParam
 }
 
+// BatchSendResult is the outcome of one message of a batch, exactly one field is set
+type BatchSendResult struct {
+	Message *entity.Message
+	Err     error
+}
+
+// SendMessagesBatch sends messages in order; a failed message does not stop the rest
+func (s *MessageService) SendMessagesBatch(ctx context.Context, senderId string, reqs []*SendMessageRequest, markSenderRead bool) []*BatchSendResult {
+	ctx, span := tracing.Start(ctx, "MessageService.SendMessagesBatch")
+	defer span.End()
+
+	send := s.SendMessage
+	if !markSenderRead {
+		send = s.SendMessageWithoutMarkRead
+	}
+	results := make([]*BatchSendResult, len(reqs))
+	for i, req := range reqs {
+		msg, err := send(ctx, senderId, req)
+		results[i] = &BatchSendResult{Message: msg, Err: err}
+	}
+	return results
+}
+
+// SendSystemMessage delivers a message from the system to a user's system notification conversation.
+// clientMsgId makes the delivery idempotent: resending the same id returns the existing message.
+func (s *MessageService) SendSystemMessage(ctx context.Context, userId, clientMsgId string, msgType int32, content entity.MessageContent) (*entity.Message, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.SendSystemMessage")
+	defer span.End()
+
+	if userId == "" || clientMsgId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	if err := validateMessageContent(msgType, content); err != nil {
+		return nil, err
+	}
+
+	existingMsg, err := s.msgRepo.GetByClientMsgId(ctx, constant.SystemSenderId, clientMsgId)
+	if err != nil {
+		log.CtxError(ctx, "check idempotency failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	if existingMsg != nil {
+		return existingMsg, nil
+	}
+
+	conversationId := entity.GenSystemConversationId(userId)
+	var msg *entity.Message
+
+	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		seq, err := s.seqRepo.AllocSeq(ctx, conversationId)
+		if err != nil {
+			return errcode.ErrSeqAllocFailed.Wrap(err)
+		}
+
+		msg = &entity.Message{
+			ConversationId: conversationId,
+			Seq:            seq,
+			ClientMsgId:    clientMsgId,
+			SenderId:       constant.SystemSenderId,
+			RecvId:         userId,
+			SessionType:    constant.SessionTypeSystem,
+			MsgType:        msgType,
+			Content:        content,
+			SendAt:         entity.NowUnixMilli(),
+		}
+		if err = s.msgRepo.Create(ctx, tx, msg); err != nil {
+			return err
+		}
+		if err = s.seqRepo.SyncSeqToMySQLWithTx(ctx, tx, conversationId, seq); err != nil {
+			return err
+		}
+		return s.convRepo.EnsureConversationsExist(ctx, tx, conversationId, constant.SessionTypeSystem, []string{userId}, "", "")
+	})
+
+	if err != nil {
+		metrics.MessagesSentTotal.WithLabelValues(sessionTypeSystemLabel, "failed").Inc()
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			return nil, e
+		}
+		log.CtxError(ctx, "send system message failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrSendFailed
+	}
+
+	if s.pusher != nil {
+		s.pusher.AsyncPushToUsers(msg, []string{userId}, "")
+	}
+
+	metrics.MessagesSentTotal.WithLabelValues(sessionTypeSystemLabel, "ok").Inc()
+	return msg, nil
+}
+
+// ReplaceContent replaces the content and extra of msg for internal senders and pushes the
+// edited message to the online members of its conversation; the seq is unchanged, so clients
+// replace the message they have. Unlike EditMessage no edit history is kept.
+func (s *MessageService) ReplaceContent(ctx context.Context, msg *entity.Message, content entity.MessageContent, extra *string) error {
+	ctx, span := tracing.Start(ctx, "MessageService.ReplaceContent")
+	defer span.End()
+
+	if err := validateMessageContent(msg.MsgType, content); err != nil {
+		return err
+	}
+	edited := *msg
+	edited.Content = content
+	edited.Extra = extra
+	updated, err := s.msgRepo.UpdateContent(ctx, &edited)
+	if err != nil {
+		log.CtxError(ctx, "edit message failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return errcode.ErrInternalServer
+	}
+	if !updated {
+		// Deleted meanwhile
+		return errcode.ErrNotFound
+	}
+	*msg = edited
+	s.notifyEdited(ctx, msg)
+	return nil
+}
+
+// AttachLinkPreview sets the link preview of a stored text message, unless the message was
+// edited or deleted meanwhile, and pushes the edit. Returns whether the preview was attached.
+func (s *MessageService) AttachLinkPreview(ctx context.Context, msg *entity.Message, preview *entity.LinkPreview) (bool, error) {
+	if msg.Content.Text == nil {
+		return false, nil
+	}
+	edited := *msg
+	text := *msg.Content.Text
+	text.Preview = preview
+	edited.Content.Text = &text
+	updated, err := s.msgRepo.UpdateContentIfUnchanged(ctx, &edited, msg.ContentHash)
+	if err != nil || !updated {
+		return false, err
+	}
+	*msg = edited
+	s.notifyEdited(ctx, msg)
+	return true, nil
+}
+
+// notifyEdited pushes an edited message to the participants of its conversation
+func (s *MessageService) notifyEdited(ctx context.Context, msg *entity.Message) {
+	if s.pusher == nil {
+		return
+	}
+	userIds, err := s.Participants(ctx, msg)
+	if err != nil {
+		log.CtxWarn(ctx, "get members for message edit failed: group_id=%s, error=%v", msg.GroupId, err)
+		return
+	}
+	s.pusher.NotifyMessageEdited(msg, userIds)
+}
+
+// Participants returns the users of the conversation of msg: both parties of a single chat,
+// the active members of a group
+func (s *MessageService) Participants(ctx context.Context, msg *entity.Message) ([]string, error) {
+	if msg.SessionType == constant.SessionTypeGroup {
+		return s.groupRepo.GetActiveMemberUserIds(ctx, msg.GroupId)
+	}
+	return []string{msg.SenderId, msg.RecvId}, nil
+}
+
+// RevokeMessageRequest represents revoke message request
+type RevokeMessageRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+}
+
+// RevokeMessage recalls a message of the user within the revoke window: its content is cleared
+// and a revoke notification naming its seq is added to the conversation, so that clients
+// syncing by seq learn about it too. The notification is pushed to the participants as a
+// revoke event and returned.
+func (s *MessageService) RevokeMessage(ctx context.Context, userId string, req *RevokeMessageRequest) (*entity.Message, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.RevokeMessage")
+	defer span.End()
+
+	if req.ConversationId == "" || req.Seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	msg, err := s.GetMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.SenderId != userId {
+		return nil, errcode.ErrNoPermission
+	}
+	if msg.RevokedAt > 0 {
+		return nil, errcode.ErrMessageRevoked
+	}
+	if msg.DeletedAt > 0 || msg.MsgType == constant.MsgTypeRevoke {
+		return nil, errcode.ErrInvalidParam
+	}
+	now := entity.NowUnixMilli()
+	if s.revokeWindow > 0 && now-msg.SendAt > s.revokeWindow.Milliseconds() {
+		return nil, errcode.ErrRevokeExpired
+	}
+
+	notice := &entity.Message{
+		ConversationId: msg.ConversationId,
+		ClientMsgId:    fmt.Sprintf("rv_%d", msg.Id),
+		SenderId:       userId,
+		RecvId:         msg.RecvId,
+		GroupId:        msg.GroupId,
+		SessionType:    msg.SessionType,
+		MsgType:        constant.MsgTypeRevoke,
+		Content:        entity.MessageContent{Revoke: &entity.RevokeContent{Seq: msg.Seq}},
+		SendAt:         now,
+	}
+	err = s.repos.Transaction(ctx, func(tx *gorm.DB) error {
+		revoked, err := s.msgRepo.Revoke(ctx, tx, msg.Id, now)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			// Revoked or deleted meanwhile
+			return errcode.ErrMessageRevoked
+		}
+		if err = s.dropMessageData(ctx, tx, msg); err != nil {
+			return err
+		}
+
+		seq, err := s.seqRepo.AllocSeq(ctx, msg.ConversationId)
+		if err != nil {
+			return errcode.ErrSeqAllocFailed.Wrap(err)
+		}
+		notice.Seq = seq
+		if err = s.msgRepo.Create(ctx, tx, notice); err != nil {
+			return err
+		}
+		return s.seqRepo.SyncSeqToMySQLWithTx(ctx, tx, msg.ConversationId, seq)
+	})
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			return nil, e
+		}
+		log.CtxError(ctx, "revoke message failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	_ = s.seqRepo.UpdateReadSeq(ctx, userId, notice.ConversationId, notice.Seq)
+	if s.pusher != nil {
+		if userIds, err := s.Participants(ctx, msg); err != nil {
+			log.CtxWarn(ctx, "get members for message revoke failed: group_id=%s, error=%v", msg.GroupId, err)
+		} else {
+			s.pusher.NotifyMessageRevoked(notice, userIds)
+		}
+	}
+
+	log.CtxInfo(ctx, "message revoked: sender_id=%s, conversation_id=%s, seq=%d, notice_seq=%d", userId, msg.ConversationId, msg.Seq, notice.Seq)
+	return notice, nil
+}
+
+// dropMessageData deletes within tx what refers to a message whose content was cleared: its
+// unread mentions, edit history, reactions and pin
+func (s *MessageService) dropMessageData(ctx context.Context, tx *gorm.DB, msg *entity.Message) error {
+	if hasMentions(msg) {
+		if err := s.mentionRepo.DeleteByMessage(ctx, tx, msg.ConversationId, msg.Seq, msg.Content.Text.Mentions); err != nil {
+			return err
+		}
+	}
+	// The previous versions would otherwise outlive the content
+	if msg.EditVersion > 0 {
+		if err := s.editRepo.DeleteByMessage(ctx, tx, msg.ConversationId, msg.Seq); err != nil {
+			return err
+		}
+	}
+	if err := s.reactionRepo.DeleteByMessage(ctx, tx, msg.ConversationId, msg.Seq); err != nil {
+		return err
+	}
+	return s.repos.Pin.DeleteByMessage(ctx, tx, msg.ConversationId, msg.Seq)
+}
+
 // PullMessagesRequest represents pull messages request
 type PullMessagesRequest struct {
 	ConversationId string `json:"conversation_id"`
@@ -357,30 +1033,73 @@ type PullMessagesRequest struct {
 	Limit          int    `json:"limit"`
 }
 
-// PullMessages pulls messages for a user
+// PullMessages pulls messages for a user. The messages of single chats pulled are delivered to
+// the user, see MarkDelivered.
 func (s *MessageService) PullMessages(ctx context.Context, userId string, req *PullMessagesRequest) ([]*entity.Message, int64, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.PullMessages")
+	defer span.End()
+
+	messages, maxSeq, err := s.pullMessages(ctx, userId, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(messages) > 0 {
+		s.MarkDelivered(ctx, userId, req.ConversationId, messages[len(messages)-1].Seq)
+	}
+	return messages, maxSeq, nil
+}
+
+// pullMessages pulls messages for a user without marking them delivered
+func (s *MessageService) pullMessages(ctx context.Context, userId string, req *PullMessagesRequest) ([]*entity.Message, int64, error) {
+	beginSeq, endSeq, maxSeq, err := s.visibleSeqRange(ctx, userId, req.ConversationId, req.BeginSeq, req.EndSeq)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Validate range
+	if beginSeq > endSeq {
+		return []*entity.Message{}, maxSeq, nil
+	}
+
+	// Pull messages
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	messages, err := s.msgRepo.PullMessages(ctx, req.ConversationId, beginSeq, endSeq, limit)
+	if err != nil {
+		log.CtxError(ctx, "pull messages failed: %v", err)
+		return nil, 0, errcode.ErrPullFailed
+	}
+	return s.decoratePulled(ctx, userId, req.ConversationId, beginSeq, messages), maxSeq, nil
+}
+
+// visibleSeqRange clamps [beginSeq, endSeq] of a conversation, endSeq 0 meaning up to the
+// latest message, to the range the user may see: the user's visible range as a member and
+// the seqs retention has not purged. The range is empty when beginSeq > endSeq.
+// Also returns the max seq of the conversation.
+func (s *MessageService) visibleSeqRange(ctx context.Context, userId, conversationId string, beginSeq, endSeq int64) (int64, int64, int64, error) {
 	// Authorization check: verify user has access to this conversation
-	hasAccess, err := s.checkConversationAccess(ctx, userId, req.ConversationId)
+	hasAccess, err := s.checkConversationAccess(ctx, userId, conversationId)
 	if err != nil {
 		log.CtxError(ctx, "check conversation access failed: %v", err)
-		return nil, 0, errcode.ErrInternalServer
+		return 0, 0, 0, errcode.ErrInternalServer
 	}
 	if !hasAccess {
-		return nil, 0, errcode.ErrNoPermission
+		return 0, 0, 0, errcode.ErrNoPermission
 	}
 
 	// Get conversation max seq
-	convSeq, err := s.seqRepo.GetConversationSeqInfo(ctx, req.ConversationId)
+	convSeq, err := s.seqRepo.GetConversationSeqInfo(ctx, conversationId)
 	if err != nil {
 		log.CtxError(ctx, "get conversation seq failed: %v", err)
-		return nil, 0, errcode.ErrInternalServer
+		return 0, 0, 0, errcode.ErrInternalServer
 	}
 
 	// Get user's visible range for this conversation
-	seqUser, _ := s.seqRepo.GetSeqUser(ctx, userId, req.ConversationId)
+	seqUser, _ := s.seqRepo.GetSeqUser(ctx, userId, conversationId)
 
-	beginSeq := req.BeginSeq
-	endSeq := req.EndSeq
 	if endSeq == 0 {
 		endSeq = convSeq.MaxSeq
 	}
@@ -390,24 +1109,173 @@ func (s *MessageService) PullMessages(ctx context.Context, userId string, req *P
 		beginSeq, endSeq = seqUser.ClampSeqRange(beginSeq, endSeq, convSeq.MaxSeq)
 	}
 
-	// Validate range
-	if beginSeq > endSeq {
-		return []*entity.Message{}, convSeq.MaxSeq, nil
+	// Messages below the conversation min seq have been purged by retention
+	if beginSeq < convSeq.MinSeq {
+		beginSeq = convSeq.MinSeq
 	}
+	return beginSeq, endSeq, convSeq.MaxSeq, nil
+}
 
-	// Pull messages
-	limit := req.Limit
-	if limit <= 0 || limit > 100 {
-		limit = 100
+// decoratePulled prepares messages of a conversation loaded within a visible range starting at
+// beginSeq for the user: it hides expired and disappeared messages and those the user deleted
+// for themselves, checks their integrity and adds read counts, reactions and quotes
+func (s *MessageService) decoratePulled(ctx context.Context, userId, conversationId string, beginSeq int64, messages []*entity.Message) []*entity.Message {
+	// Hide messages past the retention window or their expiry that the jobs have not removed yet
+	now := time.Now()
+	cutoff := s.retention.Cutoff(conversationId, now)
+	if cutoff > 0 {
+		messages = filterExpiredMessages(messages, cutoff)
+	}
+	messages = filterDisappeared(messages, now.UnixMilli())
+	messages = s.filterHidden(ctx, userId, conversationId, messages)
+	checkMessagesIntegrity(ctx, messages)
+	// Counts are a decoration, the messages are still returned without them
+	if err := fillReadCounts(ctx, s.seqRepo, conversationId, messages); err != nil {
+		log.CtxWarn(ctx, "fill read counts failed: conversation_id=%s, error=%v", conversationId, err)
 	}
+	if err := s.fillReactions(ctx, userId, conversationId, messages); err != nil {
+		log.CtxWarn(ctx, "fill reactions failed: conversation_id=%s, error=%v", conversationId, err)
+	}
+	if err := s.fillQuotes(ctx, conversationId, messages, beginSeq, cutoff); err != nil {
+		log.CtxWarn(ctx, "fill quotes failed: conversation_id=%s, error=%v", conversationId, err)
+	}
+	return messages
+}
 
-	messages, err := s.msgRepo.PullMessages(ctx, req.ConversationId, beginSeq, endSeq, limit)
+// GetMessage gets one message of a conversation visible to the user, following the same
+// access and visibility rules as PullMessages
+func (s *MessageService) GetMessage(ctx context.Context, userId, conversationId string, seq int64) (*entity.Message, error) {
+	if seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	messages, _, err := s.pullMessages(ctx, userId, &PullMessagesRequest{
+		ConversationId: conversationId,
+		BeginSeq:       seq,
+		EndSeq:         seq,
+		Limit:          1,
+	})
 	if err != nil {
-		log.CtxError(ctx, "pull messages failed: %v", err)
-		return nil, 0, errcode.ErrPullFailed
+		return nil, err
+	}
+	if len(messages) == 0 || messages[0].Seq != seq {
+		return nil, errcode.ErrMessageNotFound
+	}
+	return messages[0], nil
+}
+
+// GetMessagesBySeqs gets the messages of a conversation at seqs visible to the user, following
+// the same access and visibility rules as PullMessages, in seq order. Seqs the user cannot see
+// are skipped.
+func (s *MessageService) GetMessagesBySeqs(ctx context.Context, userId, conversationId string, seqs []int64) ([]*entity.Message, error) {
+	beginSeq, endSeq, _, err := s.visibleSeqRange(ctx, userId, conversationId, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]int64, 0, len(seqs))
+	for _, seq := range seqs {
+		if seq >= beginSeq && seq <= endSeq {
+			visible = append(visible, seq)
+		}
+	}
+	if len(visible) == 0 {
+		return []*entity.Message{}, nil
+	}
+	messages, err := s.msgRepo.PullMessagesBySeqList(ctx, conversationId, visible)
+	if err != nil {
+		log.CtxError(ctx, "pull messages by seqs failed: conversation_id=%s, error=%v", conversationId, err)
+		return nil, errcode.ErrPullFailed
+	}
+	return s.decoratePulled(ctx, userId, conversationId, beginSeq, messages), nil
+}
+
+// checkMessagesIntegrity reports messages altered or corrupted since they were stored. They are
+// still returned: clients compare content_hash themselves and audits follow up on the report.
+func checkMessagesIntegrity(ctx context.Context, messages []*entity.Message) {
+	for _, msg := range messages {
+		if !msg.VerifyContentHash() {
+			metrics.MessageIntegrityFailuresTotal.Inc()
+			log.CtxWarn(ctx, "message content hash mismatch: conversation_id=%s, seq=%d", msg.ConversationId, msg.Seq)
+		}
+	}
+}
+
+// exportPageSize is the number of messages ExportMessages loads per query
+const exportPageSize = 100
+
+// ExportMessages walks the whole history of a conversation visible to the user in seq order,
+// handing emit one page at a time so large histories are never held in memory.
+// Access is checked before the first page, an error from emit stops the export.
+func (s *MessageService) ExportMessages(ctx context.Context, userId, conversationId string, emit func([]*entity.Message) error) error {
+	ctx, span := tracing.Start(ctx, "MessageService.ExportMessages")
+	defer span.End()
+
+	hasAccess, err := s.checkConversationAccess(ctx, userId, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "check conversation access failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+	if !hasAccess {
+		return errcode.ErrNoPermission
 	}
 
-	return messages, convSeq.MaxSeq, nil
+	convSeq, err := s.seqRepo.GetConversationSeqInfo(ctx, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "get conversation seq failed: %v", err)
+		return errcode.ErrInternalServer
+	}
+
+	beginSeq, endSeq := int64(0), convSeq.MaxSeq
+	if seqUser, _ := s.seqRepo.GetSeqUser(ctx, userId, conversationId); seqUser != nil {
+		beginSeq, endSeq = seqUser.ClampSeqRange(beginSeq, endSeq, convSeq.MaxSeq)
+	}
+	if beginSeq < convSeq.MinSeq {
+		beginSeq = convSeq.MinSeq
+	}
+	// The cutoff is fixed for the whole export so pages stay consistent
+	now := time.Now()
+	cutoff := s.retention.Cutoff(conversationId, now)
+
+	for beginSeq <= endSeq {
+		messages, err := s.msgRepo.PullMessages(ctx, conversationId, beginSeq, endSeq, exportPageSize)
+		if err != nil {
+			log.CtxError(ctx, "export messages failed: conversation_id=%s, begin_seq=%d, error=%v", conversationId, beginSeq, err)
+			return errcode.ErrPullFailed
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		// Seqs may have gaps, continue after the last message of the page
+		beginSeq = messages[len(messages)-1].Seq + 1
+
+		if cutoff > 0 {
+			messages = filterExpiredMessages(messages, cutoff)
+		}
+		messages = filterDisappeared(messages, now.UnixMilli())
+		messages = s.filterHidden(ctx, userId, conversationId, messages)
+		if len(messages) == 0 {
+			continue
+		}
+		if err = emit(messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterExpiredMessages drops messages sent before cutoff (ms)
+func filterExpiredMessages(messages []*entity.Message, cutoff int64) []*entity.Message {
+	kept := messages[:0]
+	for _, msg := range messages {
+		if msg.SendAt >= cutoff {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+// CanAccessConversation reports whether a user may read a conversation
+func (s *MessageService) CanAccessConversation(ctx context.Context, userId, conversationId string) (bool, error) {
+	return s.checkConversationAccess(ctx, userId, conversationId)
 }
 
 // checkConversationAccess verifies if a user has access to a conversation
@@ -428,6 +1296,9 @@ func (s *MessageService) checkConversationAccess(ctx context.Context, userId, co
 		// User must be an active member of the group
 		groupId := conversationId[3:]
 		return s.checkGroupChatAccess(ctx, userId, groupId)
+	case "sn_":
+		// System notifications: sn_{userId}, only readable by that user
+		return conversationId[3:] == userId, nil
 	default:
 		return false, nil
 	}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
+	"github.com/ZaiSpace/nexo_im/internal/config"
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
 )
 
 func TestValidateMessageContentRejectsMismatchedPayload(t *testing.T) {
@@ -25,3 +29,99 @@ func TestValidateMessageContentAcceptsCustomPayload(t *testing.T) {
 		t.Fatalf("expected custom payload to be valid, got %v", err)
 	}
 }
+
+func TestValidateMessageContentEncryptedPayload(t *testing.T) {
+	valid := func() *entity.EncryptedContent {
+		return &entity.EncryptedContent{SenderDeviceId: 1, Ciphertexts: []*entity.DeviceCiphertext{
+			{UserId: "u1", DeviceId: 2, Type: 1, Body: "YQ=="},
+			{UserId: "u2", DeviceId: 1, Type: 3, Body: "Yg=="},
+		}}
+	}
+	if err := validateMessageContent(constant.MsgTypeEncrypted, entity.MessageContent{Encrypted: valid()}); err != nil {
+		t.Fatalf("expected encrypted payload to be valid, got %v", err)
+	}
+
+	for name, mutate := range map[string]func(c *entity.EncryptedContent){
+		"no ciphertext":    func(c *entity.EncryptedContent) { c.Ciphertexts = nil },
+		"no user":          func(c *entity.EncryptedContent) { c.Ciphertexts[0].UserId = "" },
+		"no device":        func(c *entity.EncryptedContent) { c.Ciphertexts[0].DeviceId = 0 },
+		"not base64":       func(c *entity.EncryptedContent) { c.Ciphertexts[0].Body = "not base64!" },
+		"duplicate device": func(c *entity.EncryptedContent) { c.Ciphertexts[1].UserId = "u1"; c.Ciphertexts[1].DeviceId = 2 },
+	} {
+		content := valid()
+		mutate(content)
+		if err := validateMessageContent(constant.MsgTypeEncrypted, entity.MessageContent{Encrypted: content}); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestValidateMessageContentAudioPayload(t *testing.T) {
+	audio := &entity.AudioContent{Url: "https://example.com/a.ogg", Duration: 3200, Waveform: []int{0, 128, 255}}
+	if err := validateMessageContent(constant.MsgTypeAudio, entity.MessageContent{Audio: audio}); err != nil {
+		t.Fatalf("expected audio payload to be valid, got %v", err)
+	}
+
+	for name, audio := range map[string]*entity.AudioContent{
+		"negative duration": {Url: "https://example.com/a.ogg", Duration: -1},
+		"too long":          {Url: "https://example.com/a.ogg", Duration: maxAudioDuration + 1},
+		"too many samples":  {Url: "https://example.com/a.ogg", Waveform: make([]int, maxAudioWaveform+1)},
+		"sample too high":   {Url: "https://example.com/a.ogg", Waveform: []int{0, 256}},
+		"negative sample":   {Url: "https://example.com/a.ogg", Waveform: []int{-1}},
+	} {
+		if err := validateMessageContent(constant.MsgTypeAudio, entity.MessageContent{Audio: audio}); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestCheckEncryptedLimits(t *testing.T) {
+	s := &MessageService{}
+	s.SetEncryptedLimits(&config.MessageEncryptedConfig{MaxBytes: 8, MaxDevices: 2})
+	content := entity.MessageContent{Encrypted: &entity.EncryptedContent{Ciphertexts: []*entity.DeviceCiphertext{
+		{UserId: "u1", DeviceId: 1, Body: "YQ=="},
+		{UserId: "u2", DeviceId: 1, Body: "Yg=="},
+	}}}
+	if err := s.checkEncryptedLimits(content); err != nil {
+		t.Fatalf("expected content within limits, got %v", err)
+	}
+	content.Encrypted.Ciphertexts[1].Body = "YmJiYg=="
+	if err := s.checkEncryptedLimits(content); err != errcode.ErrMessageTooLarge {
+		t.Fatalf("expected too large for bytes, got %v", err)
+	}
+	content.Encrypted.Ciphertexts[1].Body = "Yg=="
+	content.Encrypted.Ciphertexts = append(content.Encrypted.Ciphertexts, &entity.DeviceCiphertext{UserId: "u3", DeviceId: 1, Body: ""})
+	if err := s.checkEncryptedLimits(content); err != errcode.ErrMessageTooLarge {
+		t.Fatalf("expected too large for devices, got %v", err)
+	}
+}
+
+func TestSendMessagesBatchReportsPerMessageErrors(t *testing.T) {
+	s := &MessageService{}
+	results := s.SendMessagesBatch(context.Background(), "alice", []*SendMessageRequest{
+		{ClientMsgId: "m1"},
+		{ClientMsgId: "m2"},
+	}, true)
+	if len(results) != 2 {
+		t.Fatalf("expected one result per message, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.Message != nil || !errors.Is(r.Err, errcode.ErrInvalidParam) {
+			t.Fatalf("expected invalid param for message %d, got %+v", i, r)
+		}
+	}
+}
+
+func TestRevokeNoticesCannotBeSent(t *testing.T) {
+	err := validateMessageContent(constant.MsgTypeRevoke, entity.MessageContent{
+		Revoke: &entity.RevokeContent{Seq: 1},
+	})
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected revoke notices to be rejected, got %v", err)
+	}
+
+	s := &MessageService{}
+	if _, err = s.RevokeMessage(context.Background(), "alice", &RevokeMessageRequest{ConversationId: "si_alice_bob"}); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a seq, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+)
+
+// fillReadCounts sets the read count of pulled messages of one conversation from a single
+// query of the read seqs of its members, instead of one count per message
+func fillReadCounts(ctx context.Context, seqRepo *repository.SeqRepo, conversationId string, messages []*entity.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	minSeq := messages[0].Seq
+	for _, msg := range messages {
+		minSeq = min(minSeq, msg.Seq)
+	}
+	readers, err := seqRepo.GetReadSeqs(ctx, conversationId, minSeq)
+	if err != nil {
+		return err
+	}
+	countReaders(messages, readers)
+	return nil
+}
+
+// countReaders sets the read count of each message to the readers whose read seq reached it,
+// not counting its sender
+func countReaders(messages []*entity.Message, readers []*entity.SeqUser) {
+	readSeqs := make([]int64, 0, len(readers))
+	readSeqOf := make(map[string]int64, len(readers))
+	for _, reader := range readers {
+		readSeqs = append(readSeqs, reader.ReadSeq)
+		readSeqOf[reader.UserId] = reader.ReadSeq
+	}
+	sort.Slice(readSeqs, func(i, j int) bool { return readSeqs[i] < readSeqs[j] })
+
+	for _, msg := range messages {
+		below := sort.Search(len(readSeqs), func(i int) bool { return readSeqs[i] >= msg.Seq })
+		count := int64(len(readSeqs) - below)
+		if readSeq, ok := readSeqOf[msg.SenderId]; ok && readSeq >= msg.Seq {
+			count--
+		}
+		msg.ReadCount = count
+	}
+}
+
+// fillReactions sets the reactions of pulled messages of one conversation, as seen by userId,
+// from a single grouped query
+func (s *MessageService) fillReactions(ctx context.Context, userId, conversationId string, messages []*entity.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	seqs := make([]int64, 0, len(messages))
+	for _, msg := range messages {
+		seqs = append(seqs, msg.Seq)
+	}
+	summaries, err := s.reactionRepo.GetSummaries(ctx, conversationId, seqs, userId)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		msg.Reactions = summaries[msg.Seq]
+	}
+	return nil
+}
+
+// fillLastMessageReadCounts sets the read count of the last messages of many conversations
+// with one grouped query
+func fillLastMessageReadCounts(ctx context.Context, seqRepo *repository.SeqRepo, lastMsgMap map[string]*entity.Message) error {
+	messages := make([]*entity.Message, 0, len(lastMsgMap))
+	for _, msg := range lastMsgMap {
+		messages = append(messages, msg)
+	}
+	counts, err := seqRepo.CountReaders(ctx, messages)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		msg.ReadCount = counts[msg.ConversationId]
+	}
+	return nil
+}
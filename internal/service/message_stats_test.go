@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+)
+
+func TestCountReadersExcludesSender(t *testing.T) {
+	messages := []*entity.Message{
+		{Seq: 1, SenderId: "alice"},
+		{Seq: 2, SenderId: "bob"},
+		{Seq: 3, SenderId: "alice"},
+	}
+	readers := []*entity.SeqUser{
+		{UserId: "alice", ReadSeq: 3},
+		{UserId: "bob", ReadSeq: 2},
+		{UserId: "carol", ReadSeq: 1},
+	}
+	countReaders(messages, readers)
+
+	for i, want := range []int64{2, 1, 0} {
+		if messages[i].ReadCount != want {
+			t.Errorf("seq %d: expected read count %d, got %d", messages[i].Seq, want, messages[i].ReadCount)
+		}
+	}
+}
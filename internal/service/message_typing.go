@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// TypingRequest represents typing status request: Typing starts or stops showing the user as
+// typing in the conversation
+type TypingRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Typing         bool   `json:"typing"`
+}
+
+// TypingTargets returns the users a typing status of the user in a conversation goes to: the
+// peer of a single chat the user has, the other active members of a group
+func (s *MessageService) TypingTargets(ctx context.Context, userId, conversationId string) ([]string, error) {
+	if conversationId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	if !strings.HasPrefix(conversationId, constant.SingleConversationPrefix) && !strings.HasPrefix(conversationId, constant.GroupConversationPrefix) {
+		return nil, errcode.ErrInvalidParam
+	}
+	hasAccess, err := s.checkConversationAccess(ctx, userId, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "check conversation access failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if !hasAccess {
+		return nil, errcode.ErrNoPermission
+	}
+
+	if strings.HasPrefix(conversationId, constant.SingleConversationPrefix) {
+		// Single chat ids can be made up, typing goes only to chats the user already has
+		conv, err := s.convRepo.GetByOwnerAndConvId(ctx, userId, conversationId)
+		if err != nil {
+			log.CtxError(ctx, "get conversation failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+			return nil, errcode.ErrInternalServer
+		}
+		if conv == nil {
+			return nil, errcode.ErrConvNotFound
+		}
+		if conv.PeerUserId == "" || conv.PeerUserId == userId {
+			// Nobody else sees the saved messages conversation
+			return nil, nil
+		}
+		return []string{conv.PeerUserId}, nil
+	}
+
+	memberIds, err := s.groupRepo.GetActiveMemberUserIds(ctx, strings.TrimPrefix(conversationId, constant.GroupConversationPrefix))
+	if err != nil {
+		log.CtxError(ctx, "get group members failed: conversation_id=%s, error=%v", conversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	targets := make([]string, 0, len(memberIds))
+	for _, memberId := range memberIds {
+		if memberId != userId {
+			targets = append(targets, memberId)
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// NotificationService manages the notification preferences of users
+type NotificationService struct {
+	repo *repository.NotificationSettingsRepo
+}
+
+// NewNotificationService creates a new NotificationService
+func NewNotificationService(repos *repository.Repositories) *NotificationService {
+	return &NotificationService{repo: repos.Notification}
+}
+
+// UpdateNotificationSettingsRequest represents update notification settings request, nil fields
+// are left unchanged
+type UpdateNotificationSettingsRequest struct {
+	GlobalMute  *bool `json:"global_mute,omitempty"`
+	Sound       *bool `json:"sound,omitempty"`
+	Vibrate     *bool `json:"vibrate,omitempty"`
+	ShowPreview *bool `json:"show_preview,omitempty"`
+	// PlatformOverrides replaces the overrides of the platforms it contains, a null value
+	// removes the override of that platform
+	PlatformOverrides map[int]*entity.NotificationOverride `json:"platform_overrides,omitempty"`
+}
+
+// GetSettings gets the notification settings of a user, the defaults if never changed
+func (s *NotificationService) GetSettings(ctx context.Context, userId string) (*entity.NotificationSettings, error) {
+	settings, err := s.repo.Get(ctx, userId)
+	if err != nil {
+		return nil, errcode.ErrInternalServer
+	}
+	if settings == nil {
+		return entity.DefaultNotificationSettings(userId), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings updates the notification settings of a user
+func (s *NotificationService) UpdateSettings(ctx context.Context, userId string, req *UpdateNotificationSettingsRequest) (*entity.NotificationSettings, error) {
+	for platformId := range req.PlatformOverrides {
+		if constant.PlatformIdToName(platformId) == "Unknown" {
+			return nil, errcode.ErrInvalidParam
+		}
+	}
+
+	settings, err := s.GetSettings(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if req.GlobalMute != nil {
+		settings.GlobalMute = *req.GlobalMute
+	}
+	if req.Sound != nil {
+		settings.Sound = *req.Sound
+	}
+	if req.Vibrate != nil {
+		settings.Vibrate = *req.Vibrate
+	}
+	if req.ShowPreview != nil {
+		settings.ShowPreview = *req.ShowPreview
+	}
+	for platformId, override := range req.PlatformOverrides {
+		if override == nil || *override == (entity.NotificationOverride{}) {
+			delete(settings.PlatformOverrides, platformId)
+			continue
+		}
+		if settings.PlatformOverrides == nil {
+			settings.PlatformOverrides = make(map[int]*entity.NotificationOverride)
+		}
+		settings.PlatformOverrides[platformId] = override
+	}
+
+	if err := s.repo.Upsert(ctx, settings); err != nil {
+		return nil, errcode.ErrInternalServer
+	}
+	return settings, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/pii"
+)
+
+// piiExtraKey is the message extra field listing the kinds of personal data flagged
+const piiExtraKey = "pii"
+
+// PIIFilter is the PreSendChecker detecting personal data in the text of messages and
+// masking or flagging it per the action of the conversation type
+type PIIFilter struct {
+	kinds   []string
+	actions map[int32]string
+}
+
+// NewPIIFilter creates a new PIIFilter
+func NewPIIFilter(cfg *config.MessagePIIConfig) *PIIFilter {
+	return &PIIFilter{
+		kinds: cfg.Kinds,
+		actions: map[int32]string{
+			constant.SessionTypeSingle: cfg.Single,
+			constant.SessionTypeGroup:  cfg.Group,
+		},
+	}
+}
+
+// CheckPreSend applies the action of the conversation type to the personal data in msg
+func (f *PIIFilter) CheckPreSend(ctx context.Context, msg *entity.Message) error {
+	action := f.actions[msg.SessionType]
+	if action == "" || action == config.PIIActionOff || msg.Content.Text == nil {
+		return nil
+	}
+	matches := pii.Detect(msg.Content.Text.Text, f.kinds)
+	if len(matches) == 0 {
+		return nil
+	}
+	kinds := pii.KindsOf(matches)
+	for _, kind := range kinds {
+		metrics.MessagePIITotal.WithLabelValues(kind, action).Inc()
+	}
+
+	if action == config.PIIActionMask {
+		msg.Content.Text = &entity.TextContent{Text: pii.Mask(msg.Content.Text.Text, matches)}
+		return nil
+	}
+	flagged, _ := json.Marshal(kinds)
+	extra, err := mergeMessageExtra(msg.Extra, map[string]json.RawMessage{piiExtraKey: flagged})
+	if err != nil {
+		log.CtxWarn(ctx, "flag pii failed: sender_id=%s, client_msg_id=%s, error=%v", msg.SenderId, msg.ClientMsgId, err)
+		return errcode.ErrSendFailed
+	}
+	msg.Extra = extra
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/pii"
+)
+
+func TestPIIFilterActionsPerConversationType(t *testing.T) {
+	f := NewPIIFilter(&config.MessagePIIConfig{Kinds: pii.Kinds, Single: config.PIIActionMask, Group: config.PIIActionFlag})
+	ctx := context.Background()
+
+	msg := newTestTextMessage("mail bob@example.com or call 13800138000")
+	if err := f.CheckPreSend(ctx, msg); err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if msg.Content.Text.Text != "mail b***@example.com or call *******8000" || msg.Extra != nil {
+		t.Fatalf("unexpected masked message %q extra=%v", msg.Content.Text.Text, msg.Extra)
+	}
+
+	msg = newTestTextMessage("mail bob@example.com or call 13800138000")
+	msg.SessionType = constant.SessionTypeGroup
+	extra := `{"k":"v"}`
+	msg.Extra = &extra
+	if err := f.CheckPreSend(ctx, msg); err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if msg.Content.Text.Text != "mail bob@example.com or call 13800138000" || msg.Extra == nil ||
+		*msg.Extra != `{"k":"v","pii":["email","phone"]}` {
+		t.Fatalf("unexpected flagged message %q extra=%v", msg.Content.Text.Text, msg.Extra)
+	}
+
+	msg = newTestTextMessage("nothing to see")
+	msg.SessionType = constant.SessionTypeGroup
+	if err := f.CheckPreSend(ctx, msg); err != nil || msg.Extra != nil {
+		t.Fatalf("unexpected flag on a clean message: extra=%v, err=%v", msg.Extra, err)
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// maxPinnedMessages bounds the messages pinned in one conversation
+const maxPinnedMessages = 50
+
+// PinNotifier pushes the messages pinned and unpinned in conversations to their participants
+type PinNotifier interface {
+	NotifyPinChanged(change *entity.PinChange, userIds []string)
+}
+
+// PinService pins messages in conversations. Pins are shared by the participants of a
+// conversation and changed by those who may change its shared settings, see
+// ConversationService.SharedSettingTargets.
+type PinService struct {
+	pinRepo     *repository.PinRepo
+	convService *ConversationService
+	msgService  *MessageService
+	notifier    PinNotifier
+}
+
+// NewPinService creates a new PinService
+func NewPinService(repos *repository.Repositories, convService *ConversationService, msgService *MessageService) *PinService {
+	return &PinService{
+		pinRepo:     repos.Pin,
+		convService: convService,
+		msgService:  msgService,
+	}
+}
+
+// SetNotifier sets the notifier of pin changes
+func (s *PinService) SetNotifier(notifier PinNotifier) {
+	s.notifier = notifier
+}
+
+// PinMessageRequest represents pin or unpin message request
+type PinMessageRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+}
+
+// PinnedMessageInfo is a message pinned in a conversation
+type PinnedMessageInfo struct {
+	Seq      int64               `json:"seq"`
+	PinnedBy string              `json:"pinned_by"`
+	PinnedAt int64               `json:"pinned_at"`
+	Message  *entity.MessageInfo `json:"message"`
+}
+
+// PinMessage pins a message the user can see and pushes the change to the participants.
+// Pinning a pinned message returns the change without a push.
+func (s *PinService) PinMessage(ctx context.Context, userId string, req *PinMessageRequest) (*entity.PinChange, error) {
+	if req.ConversationId == "" || req.Seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	participants, err := s.convService.SharedSettingTargets(ctx, userId, req.ConversationId)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := s.msgService.GetMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.RevokedAt > 0 {
+		return nil, errcode.ErrMessageRevoked
+	}
+	if msg.DeletedAt > 0 || msg.MsgType == constant.MsgTypeRevoke {
+		return nil, errcode.ErrInvalidParam
+	}
+	count, err := s.pinRepo.Count(ctx, msg.ConversationId)
+	if err != nil {
+		log.CtxError(ctx, "count pinned messages failed: conversation_id=%s, error=%v", msg.ConversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if count >= maxPinnedMessages {
+		return nil, errcode.ErrTooManyRequests
+	}
+	added, err := s.pinRepo.Add(ctx, &entity.PinnedMessage{
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		PinnedBy:       userId,
+	})
+	if err != nil {
+		log.CtxError(ctx, "pin message failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return s.changed(userId, msg.ConversationId, msg.Seq, true, added, participants), nil
+}
+
+// UnpinMessage unpins a message and pushes the change to the participants. The message need
+// not be visible anymore; unpinning a message that is not pinned returns the change without
+// a push.
+func (s *PinService) UnpinMessage(ctx context.Context, userId string, req *PinMessageRequest) (*entity.PinChange, error) {
+	if req.ConversationId == "" || req.Seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	participants, err := s.convService.SharedSettingTargets(ctx, userId, req.ConversationId)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := s.pinRepo.Remove(ctx, req.ConversationId, req.Seq)
+	if err != nil {
+		log.CtxError(ctx, "unpin message failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, req.ConversationId, req.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return s.changed(userId, req.ConversationId, req.Seq, false, removed, participants), nil
+}
+
+// ListPinnedMessages lists the messages pinned in a conversation the user can read, newest pin
+// first. Pins of messages the user cannot see, such as those sent before joining a group or
+// disappeared, are skipped.
+func (s *PinService) ListPinnedMessages(ctx context.Context, userId, conversationId string) ([]*PinnedMessageInfo, error) {
+	if conversationId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	hasAccess, err := s.msgService.CanAccessConversation(ctx, userId, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "check conversation access failed: user_id=%s, conversation_id=%s, error=%v", userId, conversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if !hasAccess {
+		return nil, errcode.ErrNoPermission
+	}
+	pins, err := s.pinRepo.List(ctx, conversationId)
+	if err != nil {
+		log.CtxError(ctx, "list pinned messages failed: conversation_id=%s, error=%v", conversationId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	list := make([]*PinnedMessageInfo, 0, len(pins))
+	if len(pins) == 0 {
+		return list, nil
+	}
+
+	seqs := make([]int64, len(pins))
+	for i, pin := range pins {
+		seqs[i] = pin.Seq
+	}
+	messages, err := s.msgService.GetMessagesBySeqs(ctx, userId, conversationId, seqs)
+	if err != nil {
+		return nil, err
+	}
+	bySeq := make(map[int64]*entity.Message, len(messages))
+	for _, msg := range messages {
+		if msg.RevokedAt == 0 && msg.DeletedAt == 0 {
+			bySeq[msg.Seq] = msg
+		}
+	}
+	for _, pin := range pins {
+		if msg := bySeq[pin.Seq]; msg != nil {
+			list = append(list, &PinnedMessageInfo{
+				Seq:      pin.Seq,
+				PinnedBy: pin.PinnedBy,
+				PinnedAt: pin.CreatedAt,
+				Message:  msg.ToMessageInfo(),
+			})
+		}
+	}
+	return list, nil
+}
+
+// changed describes a pin change and, when it took effect, pushes it to the participants
+func (s *PinService) changed(userId, conversationId string, seq int64, pinned, effective bool, participants []string) *entity.PinChange {
+	change := &entity.PinChange{
+		ConversationId: conversationId,
+		Seq:            seq,
+		UserId:         userId,
+		Pinned:         pinned,
+	}
+	if effective && s.notifier != nil {
+		s.notifier.NotifyPinChanged(change, participants)
+	}
+	return change
+}
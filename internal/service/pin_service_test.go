@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+type recordingPinNotifier struct {
+	changes []*entity.PinChange
+	userIds [][]string
+}
+
+func (n *recordingPinNotifier) NotifyPinChanged(change *entity.PinChange, userIds []string) {
+	n.changes = append(n.changes, change)
+	n.userIds = append(n.userIds, userIds)
+}
+
+func TestPinsNeedAMessage(t *testing.T) {
+	s := &PinService{}
+	ctx := context.Background()
+	if _, err := s.PinMessage(ctx, "alice", &PinMessageRequest{ConversationId: "si_alice_bob"}); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a seq, got %v", err)
+	}
+	if _, err := s.UnpinMessage(ctx, "alice", &PinMessageRequest{Seq: 1}); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a conversation, got %v", err)
+	}
+	if _, err := s.ListPinnedMessages(ctx, "alice", ""); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a conversation, got %v", err)
+	}
+}
+
+func TestPinChangedPushesEffectiveChanges(t *testing.T) {
+	notifier := &recordingPinNotifier{}
+	s := &PinService{notifier: notifier}
+	participants := []string{"alice", "bob"}
+
+	change := s.changed("alice", "si_alice_bob", 7, true, true, participants)
+	if change.ConversationId != "si_alice_bob" || change.Seq != 7 || change.UserId != "alice" || !change.Pinned {
+		t.Fatalf("unexpected change %+v", change)
+	}
+	if len(notifier.changes) != 1 || notifier.changes[0] != change || len(notifier.userIds[0]) != 2 {
+		t.Fatalf("expected the change to be pushed to the participants, got %d pushes", len(notifier.changes))
+	}
+
+	if change = s.changed("bob", "si_alice_bob", 7, false, false, participants); change.Pinned {
+		t.Fatalf("unexpected change %+v", change)
+	}
+	if len(notifier.changes) != 1 {
+		t.Fatal("expected a change without effect not to be pushed")
+	}
+}
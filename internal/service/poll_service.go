@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// Bounds of poll messages
+const (
+	maxPollQuestionLength = 300 // characters
+	maxPollOptionLength   = 100 // characters
+	minPollOptions        = 2
+	maxPollOptions        = 10
+)
+
+// validatePollContent checks the question and options of a poll message
+func validatePollContent(content *entity.PollContent) error {
+	if content.Question == "" || utf8.RuneCountInString(content.Question) > maxPollQuestionLength {
+		return errcode.ErrInvalidParam
+	}
+	if len(content.Options) < minPollOptions || len(content.Options) > maxPollOptions {
+		return errcode.ErrInvalidParam
+	}
+	seen := make(map[string]bool, len(content.Options))
+	for _, option := range content.Options {
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength || seen[option] {
+			return errcode.ErrInvalidParam
+		}
+		seen[option] = true
+	}
+	return nil
+}
+
+// PollNotifier pushes poll results to the members of the conversation as votes come in
+type PollNotifier interface {
+	NotifyPollUpdated(result *entity.PollResult, userIds []string)
+}
+
+// PollService records the votes of poll messages and tallies their results
+type PollService struct {
+	voteRepo   *repository.PollVoteRepo
+	msgService *MessageService
+	notifier   PollNotifier
+}
+
+// NewPollService creates a new PollService
+func NewPollService(repos *repository.Repositories, msgService *MessageService) *PollService {
+	return &PollService{
+		voteRepo:   repos.PollVote,
+		msgService: msgService,
+	}
+}
+
+// SetNotifier sets the notifier of poll result updates
+func (s *PollService) SetNotifier(notifier PollNotifier) {
+	s.notifier = notifier
+}
+
+// VotePollRequest represents vote on a poll request. Options are the indexes of the chosen
+// options; an empty list retracts the vote.
+type VotePollRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+	Options        []int  `json:"options"`
+}
+
+// Vote replaces the vote of a user on a poll message they can see and pushes the new result
+// to the conversation
+func (s *PollService) Vote(ctx context.Context, userId string, req *VotePollRequest) (*entity.PollResult, error) {
+	msg, poll, err := s.getPoll(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	options, err := normalizePollVote(poll, req.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.voteRepo.ReplaceVote(ctx, msg.ConversationId, msg.Seq, userId, options); err != nil {
+		log.CtxError(ctx, "vote on poll failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	votes, err := s.voteRepo.ListByPoll(ctx, msg.ConversationId, msg.Seq)
+	if err != nil {
+		log.CtxError(ctx, "list poll votes failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	result := tallyPoll(msg, poll, votes)
+	if s.notifier != nil {
+		if userIds, err := s.msgService.Participants(ctx, msg); err != nil {
+			log.CtxWarn(ctx, "get members for poll update failed: conversation_id=%s, error=%v", msg.ConversationId, err)
+		} else {
+			s.notifier.NotifyPollUpdated(result, userIds)
+		}
+	}
+
+	mine := *result
+	mine.MyOptions = options
+	return &mine, nil
+}
+
+// GetResult gets the result of a poll message the user can see
+func (s *PollService) GetResult(ctx context.Context, userId, conversationId string, seq int64) (*entity.PollResult, error) {
+	msg, poll, err := s.getPoll(ctx, userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := s.voteRepo.ListByPoll(ctx, msg.ConversationId, msg.Seq)
+	if err != nil {
+		log.CtxError(ctx, "list poll votes failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	result := tallyPoll(msg, poll, votes)
+	for _, vote := range votes {
+		if vote.UserId == userId {
+			result.MyOptions = append(result.MyOptions, vote.Option)
+		}
+	}
+	slices.Sort(result.MyOptions)
+	return result, nil
+}
+
+// getPoll gets a poll message the user can see
+func (s *PollService) getPoll(ctx context.Context, userId, conversationId string, seq int64) (*entity.Message, *entity.PollContent, error) {
+	if conversationId == "" || seq <= 0 {
+		return nil, nil, errcode.ErrInvalidParam
+	}
+	msg, err := s.msgService.GetMessage(ctx, userId, conversationId, seq)
+	if err != nil {
+		return nil, nil, err
+	}
+	if msg.MsgType != constant.MsgTypePoll || msg.Content.Poll == nil {
+		return nil, nil, errcode.ErrInvalidParam
+	}
+	return msg, msg.Content.Poll, nil
+}
+
+// normalizePollVote checks the options of a vote against the poll and returns them sorted
+func normalizePollVote(poll *entity.PollContent, options []int) ([]int, error) {
+	if len(options) > 1 && !poll.MultiChoice {
+		return nil, errcode.ErrInvalidParam
+	}
+	sorted := slices.Clone(options)
+	slices.Sort(sorted)
+	for i, option := range sorted {
+		if option < 0 || option >= len(poll.Options) || (i > 0 && sorted[i-1] == option) {
+			return nil, errcode.ErrInvalidParam
+		}
+	}
+	return sorted, nil
+}
+
+// tallyPoll counts the votes of a poll; voters are only named when the poll is not anonymous
+func tallyPoll(msg *entity.Message, poll *entity.PollContent, votes []*entity.PollVote) *entity.PollResult {
+	result := &entity.PollResult{
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		Options:        make([]*entity.PollOptionResult, len(poll.Options)),
+	}
+	for i := range result.Options {
+		result.Options[i] = &entity.PollOptionResult{}
+	}
+	voters := make(map[string]bool)
+	for _, vote := range votes {
+		// Options of a poll never change, this only guards against stray rows
+		if vote.Option < 0 || vote.Option >= len(result.Options) {
+			continue
+		}
+		option := result.Options[vote.Option]
+		option.Count++
+		if !poll.Anonymous {
+			option.VoterIds = append(option.VoterIds, vote.UserId)
+		}
+		voters[vote.UserId] = true
+	}
+	result.VoterCount = int64(len(voters))
+	return result
+}
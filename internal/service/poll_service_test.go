@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestValidatePollContent(t *testing.T) {
+	valid := entity.MessageContent{Poll: &entity.PollContent{Question: "Lunch?", Options: []string{"Pizza", "Sushi"}}}
+	if err := validateMessageContent(constant.MsgTypePoll, valid); err != nil {
+		t.Fatalf("expected valid poll, got %v", err)
+	}
+
+	invalid := []*entity.PollContent{
+		{Options: []string{"Pizza", "Sushi"}},
+		{Question: "Lunch?", Options: []string{"Pizza"}},
+		{Question: "Lunch?", Options: []string{"Pizza", ""}},
+		{Question: "Lunch?", Options: []string{"Pizza", "Pizza"}},
+		{Question: "Lunch?", Options: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")},
+		{Question: strings.Repeat("?", maxPollQuestionLength+1), Options: []string{"Pizza", "Sushi"}},
+	}
+	for i, poll := range invalid {
+		if err := validateMessageContent(constant.MsgTypePoll, entity.MessageContent{Poll: poll}); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("case %d: expected invalid param error, got %v", i, err)
+		}
+	}
+	if err := validateMessageContent(constant.MsgTypeText, valid); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected a poll sent as text to be rejected, got %v", err)
+	}
+}
+
+func TestNormalizePollVote(t *testing.T) {
+	single := &entity.PollContent{Options: []string{"a", "b", "c"}}
+	multi := &entity.PollContent{Options: []string{"a", "b", "c"}, MultiChoice: true}
+
+	if options, err := normalizePollVote(multi, []int{2, 0}); err != nil || !reflect.DeepEqual(options, []int{0, 2}) {
+		t.Fatalf("unexpected vote %v, %v", options, err)
+	}
+	if options, err := normalizePollVote(single, nil); err != nil || len(options) != 0 {
+		t.Fatalf("expected retraction, got %v, %v", options, err)
+	}
+	for i, tc := range []struct {
+		poll    *entity.PollContent
+		options []int
+	}{
+		{single, []int{0, 1}},
+		{multi, []int{1, 1}},
+		{multi, []int{3}},
+		{single, []int{-1}},
+	} {
+		if _, err := normalizePollVote(tc.poll, tc.options); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("case %d: expected invalid param error, got %v", i, err)
+		}
+	}
+}
+
+func TestTallyPoll(t *testing.T) {
+	msg := &entity.Message{ConversationId: "sg_g1", Seq: 5}
+	poll := &entity.PollContent{Options: []string{"a", "b"}, MultiChoice: true}
+	votes := []*entity.PollVote{
+		{UserId: "u1", Option: 0},
+		{UserId: "u1", Option: 1},
+		{UserId: "u2", Option: 1},
+	}
+
+	result := tallyPoll(msg, poll, votes)
+	if result.VoterCount != 2 || result.Options[0].Count != 1 || result.Options[1].Count != 2 {
+		t.Fatalf("unexpected tally: %+v", result)
+	}
+	if !reflect.DeepEqual(result.Options[1].VoterIds, []string{"u1", "u2"}) {
+		t.Fatalf("unexpected voters: %v", result.Options[1].VoterIds)
+	}
+
+	poll.Anonymous = true
+	result = tallyPoll(msg, poll, votes)
+	if result.Options[1].Count != 2 || result.Options[1].VoterIds != nil {
+		t.Fatalf("expected anonymous tally, got %+v", result.Options[1])
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+	"github.com/ZaiSpace/nexo_im/pkg/tracing"
+)
+
+// Pre-send callback actions
+const (
+	PreSendActionAllow = "allow"
+	PreSendActionDeny  = "deny"
+)
+
+// maxPreSendResponseSize bounds the callback response, which may carry rewritten content
+const maxPreSendResponseSize = 1 << 20
+
+var errPreSendCircuitOpen = errors.New("circuit breaker is open")
+
+// PreSendChecker reviews a user message before it is stored. It may reject the message or
+// rewrite its content and extra in place.
+type PreSendChecker interface {
+	CheckPreSend(ctx context.Context, msg *entity.Message) error
+}
+
+// preSendCallbackRequest is the JSON body posted to the callback
+type preSendCallbackRequest struct {
+	ClientMsgId string                    `json:"client_msg_id"`
+	SenderId    string                    `json:"sender_id"`
+	RecvId      string                    `json:"recv_id,omitempty"`
+	GroupId     string                    `json:"group_id,omitempty"`
+	SessionType int32                     `json:"session_type"`
+	MsgType     int32                     `json:"msg_type"`
+	Content     entity.FlatMessageContent `json:"content"`
+	Extra       json.RawMessage           `json:"extra,omitempty"`
+}
+
+// preSendCallbackResponse is the decision of the callback. Content replaces the message
+// content when set; Extra fields are merged into the message extra.
+type preSendCallbackResponse struct {
+	Action  string                     `json:"action"` // allow (default) or deny
+	Reason  string                     `json:"reason"` // why the message was denied
+	Content *entity.FlatMessageContent `json:"content"`
+	Extra   map[string]json.RawMessage `json:"extra"`
+}
+
+// PreSendCallback is the PreSendChecker calling an external policy service over HTTP.
+// The call is bounded by the configured latency budget; while the callback is failing the
+// circuit breaker skips it and messages are allowed or rejected per FailClosed.
+type PreSendCallback struct {
+	url         string
+	secret      string
+	serviceName string
+	failClosed  bool
+	client      *http.Client
+	breaker     *preSendBreaker
+}
+
+// NewPreSendCallback creates a new PreSendCallback
+func NewPreSendCallback(cfg *config.MessagePreSendConfig) *PreSendCallback {
+	return &PreSendCallback{
+		url:         cfg.URL,
+		secret:      cfg.Secret,
+		serviceName: cfg.ServiceName,
+		failClosed:  cfg.FailClosed,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		breaker:     newPreSendBreaker(cfg.BreakerThreshold, cfg.BreakerOpenTimeout),
+	}
+}
+
+// CheckPreSend asks the callback about msg and applies its decision
+func (c *PreSendCallback) CheckPreSend(ctx context.Context, msg *entity.Message) error {
+	ctx, span := tracing.Start(ctx, "PreSendCallback.CheckPreSend")
+	defer span.End()
+
+	if !c.breaker.allow() {
+		metrics.MessagePreSendTotal.WithLabelValues("circuit_open").Inc()
+		return c.unavailable(ctx, msg, errPreSendCircuitOpen)
+	}
+	decision, err := c.call(ctx, msg)
+	c.breaker.record(err == nil)
+	if err != nil {
+		metrics.MessagePreSendTotal.WithLabelValues("failed").Inc()
+		return c.unavailable(ctx, msg, err)
+	}
+
+	if decision.Action == PreSendActionDeny {
+		metrics.MessagePreSendTotal.WithLabelValues("denied").Inc()
+		log.CtxInfo(ctx, "message denied by pre-send policy: sender_id=%s, client_msg_id=%s, reason=%s",
+			msg.SenderId, msg.ClientMsgId, decision.Reason)
+		if decision.Reason != "" {
+			return errcode.ErrMessageRejected.Wrap(errors.New(decision.Reason))
+		}
+		return errcode.ErrMessageRejected
+	}
+	if decision.Content == nil && len(decision.Extra) == 0 {
+		metrics.MessagePreSendTotal.WithLabelValues("allowed").Inc()
+		return nil
+	}
+
+	if decision.Content != nil {
+		msg.Content = entity.NewMessageContentFromFlat(*decision.Content)
+	}
+	if len(decision.Extra) > 0 {
+		extra, err := mergeMessageExtra(msg.Extra, decision.Extra)
+		if err != nil {
+			log.CtxWarn(ctx, "merge pre-send extra failed: sender_id=%s, client_msg_id=%s, error=%v",
+				msg.SenderId, msg.ClientMsgId, err)
+			return errcode.ErrSendFailed
+		}
+		msg.Extra = extra
+	}
+	metrics.MessagePreSendTotal.WithLabelValues("modified").Inc()
+	return nil
+}
+
+// unavailable applies the failure policy when the callback could not decide
+func (c *PreSendCallback) unavailable(ctx context.Context, msg *entity.Message, err error) error {
+	log.CtxWarn(ctx, "pre-send callback unavailable: sender_id=%s, client_msg_id=%s, fail_closed=%v, error=%v",
+		msg.SenderId, msg.ClientMsgId, c.failClosed, err)
+	if c.failClosed {
+		return errcode.ErrSendFailed
+	}
+	return nil
+}
+
+// call posts msg to the callback and returns its validated decision
+func (c *PreSendCallback) call(ctx context.Context, msg *entity.Message) (*preSendCallbackResponse, error) {
+	payload := &preSendCallbackRequest{
+		ClientMsgId: msg.ClientMsgId,
+		SenderId:    msg.SenderId,
+		RecvId:      msg.RecvId,
+		GroupId:     msg.GroupId,
+		SessionType: msg.SessionType,
+		MsgType:     msg.MsgType,
+		Content:     msg.Content.ToFlat(),
+	}
+	if msg.Extra != nil {
+		payload.Extra = json.RawMessage(*msg.Extra)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(signature.ServiceNameHeader, c.serviceName)
+	req.Header.Set(signature.TimestampHeader, ts)
+	req.Header.Set(signature.SignatureHeader,
+		signature.Sign(c.secret, c.serviceName, ts, http.MethodPost, req.URL.Path, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var decision preSendCallbackResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxPreSendResponseSize)).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	switch decision.Action {
+	case "":
+		decision.Action = PreSendActionAllow
+	case PreSendActionAllow, PreSendActionDeny:
+	default:
+		return nil, fmt.Errorf("unknown action %q", decision.Action)
+	}
+	if decision.Content != nil {
+		// The rewrite must still be valid content of the message type
+		if err = validateMessageContent(msg.MsgType, entity.NewMessageContentFromFlat(*decision.Content)); err != nil {
+			return nil, fmt.Errorf("invalid content for msg_type %d", msg.MsgType)
+		}
+	}
+	return &decision, nil
+}
+
+// mergeMessageExtra adds fields to the JSON object extra, replacing fields of the same name
+func mergeMessageExtra(extra *string, fields map[string]json.RawMessage) (*string, error) {
+	merged := make(map[string]json.RawMessage, len(fields))
+	if extra != nil && *extra != "" {
+		if err := json.Unmarshal([]byte(*extra), &merged); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	result := string(raw)
+	return &result, nil
+}
+
+// preSendBreaker opens after threshold consecutive failures, lets one trial call through
+// after openTimeout and closes again once it succeeds
+type preSendBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	trial    bool // a trial call is in flight
+	openedAt time.Time
+}
+
+func newPreSendBreaker(threshold int, openTimeout time.Duration) *preSendBreaker {
+	return &preSendBreaker{threshold: threshold, openTimeout: openTimeout, now: time.Now}
+}
+
+func (b *preSendBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.openTimeout {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *preSendBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case success:
+		b.failures, b.open, b.trial = 0, false, false
+	case b.open:
+		b.openedAt, b.trial = b.now(), false
+	default:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open, b.openedAt = true, b.now()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+)
+
+func newTestPreSendCallback(t *testing.T, handler http.HandlerFunc, failClosed bool) *PreSendCallback {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewPreSendCallback(&config.MessagePreSendConfig{
+		URL:                srv.URL + "/policy",
+		Secret:             "s3cret",
+		ServiceName:        "nexo_im",
+		Timeout:            time.Second,
+		FailClosed:         failClosed,
+		BreakerThreshold:   2,
+		BreakerOpenTimeout: time.Hour,
+	})
+}
+
+func newTestTextMessage(text string) *entity.Message {
+	return &entity.Message{
+		ClientMsgId: "c1",
+		SenderId:    "u1",
+		RecvId:      "u2",
+		SessionType: constant.SessionTypeSingle,
+		MsgType:     constant.MsgTypeText,
+		Content:     entity.MessageContent{Text: &entity.TextContent{Text: text}},
+	}
+}
+
+func TestPreSendCallbackRewritesContentAndMergesExtra(t *testing.T) {
+	cb := newTestPreSendCallback(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !signature.Verify("s3cret", "nexo_im", r.Header.Get(signature.TimestampHeader), r.Method, r.URL.Path, body,
+			r.Header.Get(signature.SignatureHeader)) {
+			t.Errorf("invalid signature")
+		}
+		var req preSendCallbackRequest
+		_ = json.Unmarshal(body, &req)
+		_, _ = io.WriteString(w, `{"content":{"text":"`+req.Content.Text+` (bonjour)"},"extra":{"risk":"low"}}`)
+	}, false)
+
+	msg := newTestTextMessage("hello")
+	extra := `{"source":"app"}`
+	msg.Extra = &extra
+	if err := cb.CheckPreSend(context.Background(), msg); err != nil {
+		t.Fatalf("expected message allowed, got %v", err)
+	}
+	if msg.Content.Text.Text != "hello (bonjour)" {
+		t.Fatalf("expected rewritten text, got %q", msg.Content.Text.Text)
+	}
+	if msg.Extra == nil || *msg.Extra != `{"risk":"low","source":"app"}` {
+		t.Fatalf("expected merged extra, got %v", msg.Extra)
+	}
+}
+
+func TestPreSendCallbackDeny(t *testing.T) {
+	cb := newTestPreSendCallback(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"action":"deny","reason":"spam"}`)
+	}, false)
+
+	err := cb.CheckPreSend(context.Background(), newTestTextMessage("buy now"))
+	var e *errcode.Error
+	if !errors.As(err, &e) || e.Code != errcode.ErrMessageRejected.Code {
+		t.Fatalf("expected message rejected, got %v", err)
+	}
+}
+
+func TestPreSendCallbackFailurePolicyAndBreaker(t *testing.T) {
+	calls := 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// A rewrite that no longer matches msg_type counts as a failure
+		_, _ = io.WriteString(w, `{"content":{"image":"https://example.com/a.png"}}`)
+	}
+
+	cb := newTestPreSendCallback(t, handler, false)
+	for range 3 {
+		msg := newTestTextMessage("hello")
+		if err := cb.CheckPreSend(context.Background(), msg); err != nil {
+			t.Fatalf("expected fail open, got %v", err)
+		}
+		if msg.Content.Text == nil || msg.Content.Text.Text != "hello" {
+			t.Fatalf("expected content untouched, got %+v", msg.Content)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected breaker to skip the callback after 2 failures, got %d calls", calls)
+	}
+
+	cb = newTestPreSendCallback(t, handler, true)
+	if err := cb.CheckPreSend(context.Background(), newTestTextMessage("hello")); !errors.Is(err, errcode.ErrSendFailed) {
+		t.Fatalf("expected fail closed, got %v", err)
+	}
+}
+
+func TestPreSendBreakerHalfOpen(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newPreSendBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.record(false)
+	if b.allow() {
+		t.Fatalf("expected breaker open")
+	}
+	now = now.Add(time.Minute)
+	if !b.allow() || b.allow() {
+		t.Fatalf("expected exactly one trial call")
+	}
+	b.record(true)
+	if !b.allow() || !b.allow() {
+		t.Fatalf("expected breaker closed after a successful trial")
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// Bounds of message reactions
+const (
+	maxReactionEmojiLength = 32 // bytes, room for ZWJ sequences such as family emojis
+	maxReactionsPerUser    = 20 // distinct emojis a user may react with to one message
+)
+
+// validateReactionEmoji checks that a reaction is a single short emoji-like string: valid
+// UTF-8 without spaces or control characters and with at least one non-ASCII character
+func validateReactionEmoji(emoji string) error {
+	if emoji == "" || len(emoji) > maxReactionEmojiLength || !utf8.ValidString(emoji) {
+		return errcode.ErrInvalidParam
+	}
+	hasSymbol := false
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return errcode.ErrInvalidParam
+		}
+		if r >= utf8.RuneSelf {
+			hasSymbol = true
+		}
+	}
+	if !hasSymbol {
+		return errcode.ErrInvalidParam
+	}
+	return nil
+}
+
+// ReactionNotifier pushes the reactions added to and removed from messages to the members of
+// their conversations
+type ReactionNotifier interface {
+	NotifyReactionChanged(change *entity.ReactionChange, userIds []string)
+}
+
+// ReactionService records the emoji reactions of users to the messages they can see
+type ReactionService struct {
+	reactionRepo *repository.ReactionRepo
+	msgService   *MessageService
+	notifier     ReactionNotifier
+}
+
+// NewReactionService creates a new ReactionService
+func NewReactionService(repos *repository.Repositories, msgService *MessageService) *ReactionService {
+	return &ReactionService{
+		reactionRepo: repos.Reaction,
+		msgService:   msgService,
+	}
+}
+
+// SetNotifier sets the notifier of reaction changes
+func (s *ReactionService) SetNotifier(notifier ReactionNotifier) {
+	s.notifier = notifier
+}
+
+// ReactionRequest represents add or remove reaction request
+type ReactionRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required,max=256"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+	Emoji          string `json:"emoji" validate:"required"`
+}
+
+// AddReaction reacts with an emoji to a message the user can see and pushes the change to the
+// conversation. Reacting twice with the same emoji returns the current count without a push.
+func (s *ReactionService) AddReaction(ctx context.Context, userId string, req *ReactionRequest) (*entity.ReactionChange, error) {
+	if err := validateReactionEmoji(req.Emoji); err != nil {
+		return nil, err
+	}
+	msg, err := s.getMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.reactionRepo.CountByUser(ctx, msg.ConversationId, msg.Seq, userId)
+	if err != nil {
+		log.CtxError(ctx, "count reactions failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if count >= maxReactionsPerUser {
+		return nil, errcode.ErrTooManyRequests
+	}
+	added, err := s.reactionRepo.Add(ctx, &entity.MessageReaction{
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		UserId:         userId,
+		Emoji:          req.Emoji,
+	})
+	if err != nil {
+		log.CtxError(ctx, "add reaction failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return s.changed(ctx, msg, userId, req.Emoji, true, added)
+}
+
+// RemoveReaction removes a reaction of the user to a message and pushes the change to the
+// conversation. Removing a reaction the user did not add returns the current count without a push.
+func (s *ReactionService) RemoveReaction(ctx context.Context, userId string, req *ReactionRequest) (*entity.ReactionChange, error) {
+	if req.Emoji == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	msg, err := s.getMessage(ctx, userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := s.reactionRepo.Remove(ctx, msg.ConversationId, msg.Seq, userId, req.Emoji)
+	if err != nil {
+		log.CtxError(ctx, "remove reaction failed: user_id=%s, conversation_id=%s, seq=%d, error=%v", userId, msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return s.changed(ctx, msg, userId, req.Emoji, false, removed)
+}
+
+// getMessage gets a message the user can see and react to
+func (s *ReactionService) getMessage(ctx context.Context, userId, conversationId string, seq int64) (*entity.Message, error) {
+	if conversationId == "" || seq <= 0 {
+		return nil, errcode.ErrInvalidParam
+	}
+	msg, err := s.msgService.GetMessage(ctx, userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	if msg.RevokedAt > 0 {
+		return nil, errcode.ErrMessageRevoked
+	}
+	if msg.DeletedAt > 0 || msg.MsgType == constant.MsgTypeRevoke {
+		return nil, errcode.ErrInvalidParam
+	}
+	return msg, nil
+}
+
+// changed counts the reactions with emoji after a change and, when the change took effect,
+// pushes it to the participants of the conversation
+func (s *ReactionService) changed(ctx context.Context, msg *entity.Message, userId, emoji string, added, effective bool) (*entity.ReactionChange, error) {
+	count, err := s.reactionRepo.CountByEmoji(ctx, msg.ConversationId, msg.Seq, emoji)
+	if err != nil {
+		log.CtxError(ctx, "count reactions failed: conversation_id=%s, seq=%d, error=%v", msg.ConversationId, msg.Seq, err)
+		return nil, errcode.ErrInternalServer
+	}
+	change := &entity.ReactionChange{
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		UserId:         userId,
+		Emoji:          emoji,
+		Added:          added,
+		Count:          count,
+	}
+	if effective && s.notifier != nil {
+		if userIds, err := s.msgService.Participants(ctx, msg); err != nil {
+			log.CtxWarn(ctx, "get members for reaction change failed: conversation_id=%s, error=%v", msg.ConversationId, err)
+		} else {
+			s.notifier.NotifyReactionChanged(change, userIds)
+		}
+	}
+	return change, nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestValidateReactionEmoji(t *testing.T) {
+	for _, emoji := range []string{"👍", "❤️", "1️⃣", "👨‍👩‍👧‍👦", "🇨🇳"} {
+		if err := validateReactionEmoji(emoji); err != nil {
+			t.Errorf("expected %q to be accepted, got %v", emoji, err)
+		}
+	}
+	for _, emoji := range []string{"", "ok", "👍 👍", "👍\n", "\xff", "👍👍👍👍👍👍👍👍👍"} {
+		if err := validateReactionEmoji(emoji); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("expected %q to be rejected, got %v", emoji, err)
+		}
+	}
+}
+
+func TestReactionsNeedAMessage(t *testing.T) {
+	s := &ReactionService{}
+	if _, err := s.AddReaction(context.Background(), "alice", &ReactionRequest{ConversationId: "si_alice_bob", Emoji: "👍"}); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a seq, got %v", err)
+	}
+	if _, err := s.RemoveReaction(context.Background(), "alice", &ReactionRequest{Seq: 1, Emoji: "👍"}); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param without a conversation, got %v", err)
+	}
+}
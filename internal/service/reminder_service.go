@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	reminderDueLimit    = 100
+	maxReminderDelay    = 365 * 24 * time.Hour
+	reminderContentType = "reminder"
+)
+
+// ReminderService lets users be reminded of a message later, delivering due reminders in the
+// background as custom messages in their system notification conversation
+type ReminderService struct {
+	reminderRepo *repository.ReminderRepo
+	msgService   *MessageService
+	interval     time.Duration
+	maxPending   int
+	done         chan struct{} // closed when the delivery job exits
+}
+
+// NewReminderService creates a new ReminderService
+func NewReminderService(repos *repository.Repositories, msgService *MessageService, cfg *config.Config) *ReminderService {
+	return &ReminderService{
+		reminderRepo: repos.Reminder,
+		msgService:   msgService,
+		interval:     cfg.Reminder.PollInterval,
+		maxPending:   cfg.Reminder.MaxPending,
+	}
+}
+
+// CreateReminderRequest represents create message reminder request, exactly one of
+// RemindAt and RemindIn is set
+type CreateReminderRequest struct {
+	ConversationId string `json:"conversation_id" validate:"required"`
+	Seq            int64  `json:"seq" validate:"min=1"`
+	RemindAt       int64  `json:"remind_at,omitempty" validate:"min=0"` // ms
+	RemindIn       int64  `json:"remind_in,omitempty" validate:"min=0"` // seconds from now
+	Note           string `json:"note,omitempty" validate:"max=512"`
+}
+
+// CreateReminder schedules a reminder of a message the user can see
+func (s *ReminderService) CreateReminder(ctx context.Context, userId string, req *CreateReminderRequest) (*entity.MessageReminder, error) {
+	if req.ConversationId == "" || req.Seq <= 0 || len(req.Note) > 512 {
+		return nil, errcode.ErrInvalidParam
+	}
+	now := time.Now()
+	remindAt := req.RemindAt
+	if (remindAt > 0) == (req.RemindIn > 0) {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.RemindIn > 0 {
+		if req.RemindIn > int64(maxReminderDelay/time.Second) {
+			return nil, errcode.ErrInvalidParam
+		}
+		remindAt = now.Add(time.Duration(req.RemindIn) * time.Second).UnixMilli()
+	}
+	if remindAt <= now.UnixMilli() || remindAt > now.Add(maxReminderDelay).UnixMilli() {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	if _, err := s.msgService.GetMessage(ctx, userId, req.ConversationId, req.Seq); err != nil {
+		return nil, err
+	}
+	pending, err := s.reminderRepo.CountPending(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "count pending reminders failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if pending >= int64(s.maxPending) {
+		return nil, errcode.ErrTooManyRequests
+	}
+
+	reminder := &entity.MessageReminder{
+		UserId:         userId,
+		ConversationId: req.ConversationId,
+		Seq:            req.Seq,
+		Note:           req.Note,
+		RemindAt:       remindAt,
+		Status:         constant.ReminderStatusPending,
+	}
+	if err = s.reminderRepo.Create(ctx, reminder); err != nil {
+		log.CtxError(ctx, "create reminder failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return reminder, nil
+}
+
+// ListReminders lists the pending reminders of a user, soonest first
+func (s *ReminderService) ListReminders(ctx context.Context, userId string) ([]*entity.MessageReminder, error) {
+	reminders, err := s.reminderRepo.ListPending(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "list reminders failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return reminders, nil
+}
+
+// CancelReminderRequest represents cancel message reminder request
+type CancelReminderRequest struct {
+	ReminderId int64 `json:"reminder_id" validate:"min=1"`
+}
+
+// CancelReminder cancels a pending reminder of a user
+func (s *ReminderService) CancelReminder(ctx context.Context, userId string, id int64) error {
+	cancelled, err := s.reminderRepo.Cancel(ctx, userId, id)
+	if err != nil {
+		log.CtxError(ctx, "cancel reminder failed: user_id=%s, reminder_id=%d, error=%v", userId, id, err)
+		return errcode.ErrInternalServer
+	}
+	if !cancelled {
+		// Unknown, someone else's, already sent or cancelled
+		return errcode.ErrNotFound
+	}
+	return nil
+}
+
+// Run starts the delivery job
+func (s *ReminderService) Run(ctx context.Context) {
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.DeliverDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.CtxInfo(ctx, "reminder delivery job started: interval=%s", s.interval)
+}
+
+// Wait blocks until the delivery job has exited after its Run ctx is done, or until ctx is done
+func (s *ReminderService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DeliverDue sends the reminders whose time has come. Sending is idempotent per reminder, so a
+// reminder sent by another instance or before a crash is not repeated.
+func (s *ReminderService) DeliverDue(ctx context.Context) {
+	reminders, err := s.reminderRepo.ListDue(ctx, entity.NowUnixMilli(), reminderDueLimit)
+	if err != nil {
+		log.CtxError(ctx, "list due reminders failed: %v", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, reminder)
+	}
+}
+
+// deliver sends one reminder; on failure it stays pending and is retried on the next tick
+func (s *ReminderService) deliver(ctx context.Context, reminder *entity.MessageReminder) {
+	// A message the user can no longer see, e.g. after leaving the group, is only referenced
+	msg, err := s.msgService.GetMessage(ctx, reminder.UserId, reminder.ConversationId, reminder.Seq)
+	if err != nil && err != errcode.ErrMessageNotFound && err != errcode.ErrNoPermission {
+		log.CtxWarn(ctx, "get reminded message failed: reminder_id=%d, error=%v", reminder.Id, err)
+		return
+	}
+
+	content := reminderMessageContent(reminder, msg)
+	if _, err = s.msgService.SendSystemMessage(ctx, reminder.UserId, reminderClientMsgId(reminder.Id),
+		constant.MsgTypeCustom, content); err != nil {
+		log.CtxWarn(ctx, "deliver reminder failed: reminder_id=%d, user_id=%s, error=%v", reminder.Id, reminder.UserId, err)
+		return
+	}
+	if err = s.reminderRepo.MarkSent(context.WithoutCancel(ctx), reminder.Id); err != nil {
+		log.CtxError(ctx, "mark reminder sent failed: reminder_id=%d, error=%v", reminder.Id, err)
+	}
+}
+
+// reminderMessageContent builds the custom message content of a reminder. msg is the reminded
+// message, nil when the user can no longer see it; encrypted messages are only referenced.
+func reminderMessageContent(reminder *entity.MessageReminder, msg *entity.Message) entity.MessageContent {
+	payload := &entity.ReminderContent{
+		Type:           reminderContentType,
+		ReminderId:     reminder.Id,
+		ConversationId: reminder.ConversationId,
+		Seq:            reminder.Seq,
+		Note:           reminder.Note,
+	}
+	if msg != nil && msg.MsgType != constant.MsgTypeEncrypted {
+		payload.Message = msg.ToMessageInfo()
+	}
+	data, _ := json.Marshal(payload)
+	return entity.MessageContent{Custom: data}
+}
+
+// reminderClientMsgId is the idempotency key of the delivery of a reminder
+func reminderClientMsgId(reminderId int64) string {
+	return fmt.Sprintf("rm_%d", reminderId)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestCreateReminderRejectsInvalidRequest(t *testing.T) {
+	s := &ReminderService{}
+	now := time.Now().UnixMilli()
+	cases := []*CreateReminderRequest{
+		{Seq: 1, RemindIn: 60},
+		{ConversationId: "si_a_b", RemindIn: 60},
+		{ConversationId: "si_a_b", Seq: 1},
+		{ConversationId: "si_a_b", Seq: 1, RemindIn: 60, RemindAt: now + 60000},
+		{ConversationId: "si_a_b", Seq: 1, RemindAt: now - 1000},
+		{ConversationId: "si_a_b", Seq: 1, RemindIn: int64(2 * maxReminderDelay / time.Second)},
+	}
+	for i, req := range cases {
+		if _, err := s.CreateReminder(context.Background(), "a", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("case %d: expected invalid param error, got %v", i, err)
+		}
+	}
+}
+
+func TestReminderMessageContentOmitsEncryptedMessage(t *testing.T) {
+	reminder := &entity.MessageReminder{Id: 7, ConversationId: "si_a_b", Seq: 3, Note: "reply"}
+	msg := &entity.Message{ConversationId: "si_a_b", Seq: 3, MsgType: constant.MsgTypeText,
+		Content: entity.MessageContent{Text: &entity.TextContent{Text: "hello"}}}
+
+	var payload entity.ReminderContent
+	if err := json.Unmarshal(reminderMessageContent(reminder, msg).Custom, &payload); err != nil {
+		t.Fatalf("decode reminder content failed: %v", err)
+	}
+	if payload.Type != reminderContentType || payload.ReminderId != 7 || payload.Message == nil || payload.Message.Content.Text != "hello" {
+		t.Fatalf("unexpected reminder content: %+v", payload)
+	}
+
+	msg.MsgType = constant.MsgTypeEncrypted
+	payload = entity.ReminderContent{}
+	if err := json.Unmarshal(reminderMessageContent(reminder, msg).Custom, &payload); err != nil {
+		t.Fatalf("decode reminder content failed: %v", err)
+	}
+	if payload.Message != nil || payload.Seq != 3 {
+		t.Fatalf("expected encrypted message referenced only, got %+v", payload)
+	}
+}
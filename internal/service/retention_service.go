@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// RetentionPolicy resolves the message retention window of a conversation by its type
+type RetentionPolicy struct {
+	windows map[string]time.Duration // conversation id prefix -> retention window
+}
+
+// NewRetentionPolicy creates a RetentionPolicy from config.
+// Returns nil when retention is disabled, which keeps all messages.
+func NewRetentionPolicy(cfg config.MessageRetentionConfig) *RetentionPolicy {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	window := func(days int) time.Duration {
+		if days <= 0 {
+			days = cfg.DefaultDays
+		}
+		if days <= 0 {
+			return 0
+		}
+		return time.Duration(days) * 24 * time.Hour
+	}
+
+	return &RetentionPolicy{
+		windows: map[string]time.Duration{
+			constant.SingleConversationPrefix: window(cfg.SingleDays),
+			constant.GroupConversationPrefix:  window(cfg.GroupDays),
+			constant.SystemConversationPrefix: window(cfg.SystemDays),
+		},
+	}
+}
+
+// Window returns the retention window for a conversation, 0 means keep forever
+func (p *RetentionPolicy) Window(conversationId string) time.Duration {
+	if p == nil || len(conversationId) < 3 {
+		return 0
+	}
+	return p.windows[conversationId[:3]]
+}
+
+// Cutoff returns the send_at (ms) before which messages of a conversation are expired, 0 means none
+func (p *RetentionPolicy) Cutoff(conversationId string, now time.Time) int64 {
+	window := p.Window(conversationId)
+	if window <= 0 {
+		return 0
+	}
+	return now.Add(-window).UnixMilli()
+}
+
+// RetentionService periodically purges messages that exceeded their retention window
+type RetentionService struct {
+	msgRepo   *repository.MessageRepo
+	seqRepo   *repository.SeqRepo
+	policy    *RetentionPolicy
+	interval  time.Duration
+	batchSize int
+	done      chan struct{} // closed when the purge job exits
+}
+
+// NewRetentionService creates a new RetentionService
+func NewRetentionService(repos *repository.Repositories, policy *RetentionPolicy, cfg *config.Config) *RetentionService {
+	return &RetentionService{
+		msgRepo:   repos.Message,
+		seqRepo:   repos.Seq,
+		policy:    policy,
+		interval:  cfg.Message.Retention.PurgeInterval,
+		batchSize: cfg.Message.Retention.BatchSize,
+	}
+}
+
+// Run starts the purge job; it does nothing when retention is disabled
+func (s *RetentionService) Run(ctx context.Context) {
+	if s.policy == nil {
+		return
+	}
+
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.PurgeExpired(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.CtxInfo(ctx, "message retention purge job started: interval=%s", s.interval)
+}
+
+// Wait blocks until the purge job has exited after its Run ctx is done, or until ctx is done
+func (s *RetentionService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PurgeExpired deletes expired messages of every conversation type and raises
+// the conversation min_seq past the purged range so pulls skip it.
+func (s *RetentionService) PurgeExpired(ctx context.Context) {
+	now := time.Now()
+	for prefix, window := range s.policy.windows {
+		if window <= 0 {
+			continue
+		}
+
+		purgedSeq, count, err := s.msgRepo.DeleteExpired(ctx, prefix, now.Add(-window).UnixMilli(), s.batchSize)
+		for conversationId, seq := range purgedSeq {
+			if raiseErr := s.seqRepo.RaiseMinSeq(ctx, conversationId, seq+1); raiseErr != nil {
+				log.CtxError(ctx, "raise min seq failed: conversation_id=%s, error=%v", conversationId, raiseErr)
+			}
+		}
+		if err != nil {
+			log.CtxError(ctx, "purge expired messages failed: prefix=%s, error=%v", prefix, err)
+			continue
+		}
+		if count > 0 {
+			log.CtxInfo(ctx, "purged expired messages: prefix=%s, count=%d, conversations=%d",
+				prefix, count, len(purgedSeq))
+		}
+	}
+}
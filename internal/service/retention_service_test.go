@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+func TestRetentionPolicyUsesPerTypeOverrides(t *testing.T) {
+	policy := NewRetentionPolicy(config.MessageRetentionConfig{
+		Enabled:     true,
+		DefaultDays: 30,
+		GroupDays:   7,
+	})
+
+	if got := policy.Window("si_a:b"); got != 30*24*time.Hour {
+		t.Fatalf("expected single chat to fall back to default window, got %s", got)
+	}
+	if got := policy.Window("sg_g1"); got != 7*24*time.Hour {
+		t.Fatalf("expected group override window, got %s", got)
+	}
+
+	now := time.UnixMilli(100 * 24 * time.Hour.Milliseconds())
+	if got, want := policy.Cutoff("sg_g1", now), now.Add(-7*24*time.Hour).UnixMilli(); got != want {
+		t.Fatalf("expected cutoff %d, got %d", want, got)
+	}
+}
+
+func TestRetentionPolicyDisabledKeepsMessages(t *testing.T) {
+	policy := NewRetentionPolicy(config.MessageRetentionConfig{Enabled: false, DefaultDays: 1})
+	if got := policy.Cutoff("si_a:b", time.Now()); got != 0 {
+		t.Fatalf("expected no cutoff when retention is disabled, got %d", got)
+	}
+}
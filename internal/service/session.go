@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+)
+
+// sessionPlatformIds are the platforms a login can start a session on; one session each
+var sessionPlatformIds = []int{
+	constant.PlatformIdUnknown,
+	constant.PlatformIdIOS,
+	constant.PlatformIdAndroid,
+	constant.PlatformIdWindows,
+	constant.PlatformIdMacOS,
+	constant.PlatformIdWeb,
+}
+
+// SessionKicker disconnects the online connections of a user on one platform
+type SessionKicker interface {
+	KickUserPlatform(ctx context.Context, userId string, platformId int) int
+}
+
+// SessionResponse is an active session in the session list
+type SessionResponse struct {
+	*jwt.SessionInfo
+	Platform string `json:"platform"`
+	Current  bool   `json:"current"` // the session of the calling token's platform
+}
+
+// RenameSessionRequest represents session rename request
+type RenameSessionRequest struct {
+	SessionId string `json:"session_id" validate:"required,max=64"`
+	Name      string `json:"name" validate:"max=64"`
+}
+
+// RevokeSessionRequest represents session revoke request
+type RevokeSessionRequest struct {
+	SessionId string `json:"session_id" validate:"required,max=64"`
+}
+
+// ListSessions lists the active sessions of a user, one per platform with a live login
+func (s *AuthService) ListSessions(ctx context.Context, userId string, currentPlatformId int) ([]*SessionResponse, error) {
+	sessions := make([]*SessionResponse, 0, len(sessionPlatformIds))
+	for _, platformId := range sessionPlatformIds {
+		session, err := s.tokenStore.GetSession(ctx, userId, platformId)
+		if err != nil {
+			log.CtxError(ctx, "get session failed: user_id=%s, platform_id=%d, error=%v", userId, platformId, err)
+			return nil, errcode.ErrInternalServer
+		}
+		if session == nil {
+			continue
+		}
+		sessions = append(sessions, &SessionResponse{
+			SessionInfo: session,
+			Platform:    constant.PlatformIdToName(platformId),
+			Current:     platformId == currentPlatformId,
+		})
+	}
+	return sessions, nil
+}
+
+// RenameSession sets the device name of one of the user's sessions
+func (s *AuthService) RenameSession(ctx context.Context, userId string, req *RenameSessionRequest) error {
+	session, err := s.findSession(ctx, userId, req.SessionId)
+	if err != nil {
+		return err
+	}
+	renamed, err := s.tokenStore.RenameSession(ctx, userId, session.PlatformId, session.Id, req.Name)
+	if err != nil {
+		log.CtxError(ctx, "rename session failed: user_id=%s, error=%v", userId, err)
+		return errcode.ErrInternalServer
+	}
+	if !renamed {
+		// Replaced by a new login in the meantime
+		return errcode.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeSession logs one of the user's sessions out: its tokens stop working and
+// its connections are closed. Revoking the caller's own session logs the caller out.
+func (s *AuthService) RevokeSession(ctx context.Context, userId string, req *RevokeSessionRequest) (err error) {
+	var platformId int
+	defer func() {
+		audit.Record(ctx, &audit.Event{
+			Category: audit.CategoryToken,
+			Action:   "revoke_session",
+			Actor:    userId,
+			Code:     audit.CodeOf(err),
+			Detail:   map[string]any{"platform_id": platformId},
+		})
+	}()
+
+	session, err := s.findSession(ctx, userId, req.SessionId)
+	if err != nil {
+		return err
+	}
+	platformId = session.PlatformId
+
+	if err = s.tokenStore.ForceLogoutPlatform(ctx, userId, platformId); err != nil {
+		log.CtxError(ctx, "revoke session failed: user_id=%s, platform_id=%d, error=%v", userId, platformId, err)
+		return errcode.ErrInternalServer
+	}
+	kicked := 0
+	if s.sessionKicker != nil {
+		kicked = s.sessionKicker.KickUserPlatform(ctx, userId, platformId)
+	}
+	log.CtxInfo(ctx, "session revoked: user_id=%s, platform_id=%d, kicked_conns=%d", userId, platformId, kicked)
+	return nil
+}
+
+// findSession finds a live session of the user by id
+func (s *AuthService) findSession(ctx context.Context, userId, sessionId string) (*jwt.SessionInfo, error) {
+	for _, platformId := range sessionPlatformIds {
+		session, err := s.tokenStore.GetSession(ctx, userId, platformId)
+		if err != nil {
+			log.CtxError(ctx, "get session failed: user_id=%s, platform_id=%d, error=%v", userId, platformId, err)
+			return nil, errcode.ErrInternalServer
+		}
+		if session != nil && session.Id == sessionId {
+			return session, nil
+		}
+	}
+	return nil, errcode.ErrNotFound
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/jwt"
+)
+
+func TestSessionResponseFlattensSessionInfo(t *testing.T) {
+	data, err := json.Marshal(&SessionResponse{
+		SessionInfo: &jwt.SessionInfo{Id: "s1", PlatformId: 1, Name: "phone"},
+		Platform:    "iOS",
+		Current:     true,
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if fields["session_id"] != "s1" || fields["name"] != "phone" || fields["platform"] != "iOS" || fields["current"] != true {
+		t.Fatalf("unexpected session json: %s", data)
+	}
+}
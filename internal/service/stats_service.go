@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+const (
+	statsDayLayout   = "20060102"
+	maxStatsRangeDay = 31
+)
+
+// StatsService maintains operational statistics incrementally as events happen.
+// Recording methods are nil-safe and never fail the calling request.
+type StatsService struct {
+	statsRepo *repository.StatsRepo
+	usage     *config.UsageStatsConfig
+	media     MediaSizer
+}
+
+// MediaSizer measures the uploads a message references, see StorageService.MediaSize
+type MediaSizer interface {
+	MediaSize(ctx context.Context, content entity.MessageContent) int64
+}
+
+// NewStatsService creates a new StatsService
+func NewStatsService(repos *repository.Repositories) *StatsService {
+	return &StatsService{statsRepo: repos.Stats}
+}
+
+// SetUsage enables the per conversation and per user usage statistics with cfg
+func (s *StatsService) SetUsage(cfg *config.UsageStatsConfig) {
+	if cfg.Enabled {
+		s.usage = cfg
+	}
+}
+
+// SetMediaSizer sets how the media bytes of messages are measured; without it they are not counted
+func (s *StatsService) SetMediaSizer(media MediaSizer) {
+	s.media = media
+}
+
+func statsDay(t time.Time) string {
+	return t.Format(statsDayLayout)
+}
+
+// RecordMessage records a sent message, marks the sender active and, when enabled, counts
+// the message in the usage of its conversation and sender
+func (s *StatsService) RecordMessage(ctx context.Context, msg *entity.Message) {
+	if s == nil {
+		return
+	}
+	day := statsDay(time.Now())
+	if err := s.statsRepo.IncrMessages(ctx, day); err != nil {
+		log.CtxWarn(ctx, "record message stats failed: %v", err)
+	}
+	s.recordActive(ctx, day, msg.SenderId)
+	if s.usage == nil {
+		return
+	}
+
+	var mediaBytes int64
+	if s.media != nil {
+		mediaBytes = s.media.MediaSize(ctx, msg.Content)
+	}
+	if err := s.statsRepo.RecordUsage(ctx, day, msg.ConversationId, msg.GroupId, msg.SenderId, mediaBytes, s.usage.TTL); err != nil {
+		log.CtxWarn(ctx, "record usage stats failed: conversation_id=%s, error=%v", msg.ConversationId, err)
+	}
+}
+
+// RecordGroupMembership records members joining and leaving a group when usage statistics are enabled
+func (s *StatsService) RecordGroupMembership(ctx context.Context, groupId string, joins, leaves int) {
+	if s == nil || s.usage == nil {
+		return
+	}
+	if err := s.statsRepo.RecordGroupMembership(ctx, statsDay(time.Now()), groupId, int64(joins), int64(leaves), s.usage.TTL); err != nil {
+		log.CtxWarn(ctx, "record group membership stats failed: group_id=%s, error=%v", groupId, err)
+	}
+}
+
+// RecordActiveUser marks a user active today
+func (s *StatsService) RecordActiveUser(ctx context.Context, userId string) {
+	if s == nil {
+		return
+	}
+	s.recordActive(ctx, statsDay(time.Now()), userId)
+}
+
+func (s *StatsService) recordActive(ctx context.Context, day, userId string) {
+	if err := s.statsRepo.AddActiveUser(ctx, day, userId); err != nil {
+		log.CtxWarn(ctx, "record active user stats failed: user_id=%s, error=%v", userId, err)
+	}
+}
+
+// RecordRegistration records a new user registration
+func (s *StatsService) RecordRegistration(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	if err := s.statsRepo.IncrRegistrations(ctx, statsDay(time.Now())); err != nil {
+		log.CtxWarn(ctx, "record registration stats failed: %v", err)
+	}
+}
+
+// RecordGroupCreated records a new group
+func (s *StatsService) RecordGroupCreated(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	if err := s.statsRepo.IncrGroups(ctx, statsDay(time.Now())); err != nil {
+		log.CtxWarn(ctx, "record group stats failed: %v", err)
+	}
+}
+
+// RecordOnline raises today's online peak with the current online user count of this instance
+func (s *StatsService) RecordOnline(ctx context.Context, onlineUsers int64) {
+	if s == nil {
+		return
+	}
+	if err := s.statsRepo.UpdateOnlinePeak(ctx, statsDay(time.Now()), onlineUsers); err != nil {
+		log.CtxWarn(ctx, "record online peak failed: %v", err)
+	}
+}
+
+// DailyStatsResponse represents operational statistics over a date range
+type DailyStatsResponse struct {
+	Days        []*entity.DailyStats `json:"days"`
+	GroupsTotal int64                `json:"groups_total"`
+}
+
+// GetDailyStats returns statistics for each day in [startDate, endDate] (yyyymmdd, at most 31 days).
+// Empty dates default to today.
+func (s *StatsService) GetDailyStats(ctx context.Context, startDate, endDate string) (*DailyStatsResponse, error) {
+	days, err := statsDayRange(startDate, endDate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &DailyStatsResponse{Days: make([]*entity.DailyStats, 0, len(days))}
+	for _, day := range days {
+		stats, err := s.statsRepo.GetDailyStats(ctx, day)
+		if err != nil {
+			log.CtxError(ctx, "get daily stats failed: day=%s, error=%v", day, err)
+			return nil, errcode.ErrInternalServer
+		}
+		resp.Days = append(resp.Days, stats)
+	}
+
+	if resp.GroupsTotal, err = s.statsRepo.GetGroupTotal(ctx); err != nil {
+		log.CtxError(ctx, "get group total failed: %v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	return resp, nil
+}
+
+// Kinds of usage rankings
+const (
+	UsageKindConversation = "conversation"
+	UsageKindUser         = "user"
+)
+
+// ConversationUsageResponse represents the usage of a conversation over a date range
+type ConversationUsageResponse struct {
+	ConversationId string                      `json:"conversation_id"`
+	Days           []*entity.ConversationUsage `json:"days"`
+	MessageCount   int64                       `json:"message_count"`
+	MediaBytes     int64                       `json:"media_bytes"`
+}
+
+// GetConversationUsage returns the usage of a conversation, or of the conversation of a group
+// when groupId is set, for each day in [startDate, endDate] (yyyymmdd, at most 31 days)
+func (s *StatsService) GetConversationUsage(ctx context.Context, conversationId, groupId, startDate, endDate string) (*ConversationUsageResponse, error) {
+	if s.usage == nil {
+		return nil, errcode.ErrNotFound
+	}
+	if groupId != "" {
+		conversationId = constant.GroupConversationPrefix + groupId
+	}
+	if conversationId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	days, err := statsDayRange(startDate, endDate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ConversationUsageResponse{ConversationId: conversationId, Days: make([]*entity.ConversationUsage, 0, len(days))}
+	for _, day := range days {
+		usage, err := s.statsRepo.GetConversationUsage(ctx, day, conversationId)
+		if err != nil {
+			log.CtxError(ctx, "get conversation usage failed: conversation_id=%s, day=%s, error=%v", conversationId, day, err)
+			return nil, errcode.ErrInternalServer
+		}
+		resp.Days = append(resp.Days, usage)
+		resp.MessageCount += usage.MessageCount
+		resp.MediaBytes += usage.MediaBytes
+	}
+	return resp, nil
+}
+
+// UserUsageResponse represents the usage of a user over a date range
+type UserUsageResponse struct {
+	UserId       string              `json:"user_id"`
+	Days         []*entity.UserUsage `json:"days"`
+	MessageCount int64               `json:"message_count"`
+	MediaBytes   int64               `json:"media_bytes"`
+}
+
+// GetUserUsage returns the usage of a user for each day in [startDate, endDate] (yyyymmdd, at
+// most 31 days)
+func (s *StatsService) GetUserUsage(ctx context.Context, userId, startDate, endDate string) (*UserUsageResponse, error) {
+	if s.usage == nil {
+		return nil, errcode.ErrNotFound
+	}
+	if userId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	days, err := statsDayRange(startDate, endDate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &UserUsageResponse{UserId: userId, Days: make([]*entity.UserUsage, 0, len(days))}
+	for _, day := range days {
+		usage, err := s.statsRepo.GetUserUsage(ctx, day, userId)
+		if err != nil {
+			log.CtxError(ctx, "get user usage failed: user_id=%s, day=%s, error=%v", userId, day, err)
+			return nil, errcode.ErrInternalServer
+		}
+		resp.Days = append(resp.Days, usage)
+		resp.MessageCount += usage.MessageCount
+		resp.MediaBytes += usage.MediaBytes
+	}
+	return resp, nil
+}
+
+// TopUsageResponse represents the daily ranking of conversations or senders by messages
+type TopUsageResponse struct {
+	Kind    string              `json:"kind"`
+	Date    string              `json:"date"`
+	Entries []*entity.UsageRank `json:"entries"`
+}
+
+// GetTopUsage returns the conversations (kind conversation) or senders (kind user) with the
+// most messages on date (yyyymmdd, default today); limit is capped by the configured top limit
+func (s *StatsService) GetTopUsage(ctx context.Context, kind, date string, limit int) (*TopUsageResponse, error) {
+	if s.usage == nil {
+		return nil, errcode.ErrNotFound
+	}
+	if kind != UsageKindConversation && kind != UsageKindUser {
+		return nil, errcode.ErrInvalidParam
+	}
+	days, err := statsDayRange(date, date, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > s.usage.TopLimit {
+		limit = s.usage.TopLimit
+	}
+
+	resp := &TopUsageResponse{Kind: kind, Date: days[0]}
+	switch kind {
+	case UsageKindConversation:
+		resp.Entries, err = s.statsRepo.GetTopConversations(ctx, resp.Date, limit)
+	case UsageKindUser:
+		resp.Entries, err = s.statsRepo.GetTopUsers(ctx, resp.Date, limit)
+	default:
+		return nil, errcode.ErrInvalidParam
+	}
+	if err != nil {
+		log.CtxError(ctx, "get top usage failed: kind=%s, date=%s, error=%v", kind, resp.Date, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return resp, nil
+}
+
+// maxGroupTopContributors bounds the top contributors of group reports
+const maxGroupTopContributors = 10
+
+// GroupReportResponse represents the engagement of a group over a date range
+type GroupReportResponse struct {
+	GroupId         string                     `json:"group_id"`
+	Days            []*entity.GroupDailyReport `json:"days"`
+	MessageCount    int64                      `json:"message_count"`
+	ActiveMembers   int64                      `json:"active_members"` // members who sent messages in the range
+	Joins           int64                      `json:"joins"`
+	Leaves          int64                      `json:"leaves"`
+	TopContributors []*entity.UsageRank        `json:"top_contributors"` // members with the most messages, id is the user id
+}
+
+// GetGroupReport returns the engagement of a group for each day in [startDate, endDate]
+// (yyyymmdd, at most 31 days) with the totals and top contributors over the range
+func (s *StatsService) GetGroupReport(ctx context.Context, groupId, startDate, endDate string) (*GroupReportResponse, error) {
+	if s == nil || s.usage == nil {
+		return nil, errcode.ErrNotFound
+	}
+	days, err := statsDayRange(startDate, endDate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	conversationId := constant.GroupConversationPrefix + groupId
+	resp := &GroupReportResponse{GroupId: groupId, Days: make([]*entity.GroupDailyReport, 0, len(days))}
+	sent := make(map[string]int64)
+	for _, day := range days {
+		usage, err := s.statsRepo.GetConversationUsage(ctx, day, conversationId)
+		if err != nil {
+			log.CtxError(ctx, "get conversation usage failed: conversation_id=%s, day=%s, error=%v", conversationId, day, err)
+			return nil, errcode.ErrInternalServer
+		}
+		senders, err := s.statsRepo.GetGroupSenders(ctx, day, groupId)
+		if err != nil {
+			log.CtxError(ctx, "get group senders failed: group_id=%s, day=%s, error=%v", groupId, day, err)
+			return nil, errcode.ErrInternalServer
+		}
+		report := &entity.GroupDailyReport{
+			Date:          day,
+			MessageCount:  usage.MessageCount,
+			ActiveMembers: int64(len(senders)),
+			MediaBytes:    usage.MediaBytes,
+		}
+		if report.Joins, report.Leaves, err = s.statsRepo.GetGroupMembership(ctx, day, groupId); err != nil {
+			log.CtxError(ctx, "get group membership stats failed: group_id=%s, day=%s, error=%v", groupId, day, err)
+			return nil, errcode.ErrInternalServer
+		}
+		for _, sender := range senders {
+			sent[sender.Id] += sender.MessageCount
+		}
+		resp.Days = append(resp.Days, report)
+		resp.MessageCount += report.MessageCount
+		resp.Joins += report.Joins
+		resp.Leaves += report.Leaves
+	}
+	resp.ActiveMembers = int64(len(sent))
+	resp.TopContributors = topUsage(sent, maxGroupTopContributors)
+	return resp, nil
+}
+
+// topUsage ranks counts by messages, most first and then by id, keeping at most limit entries
+func topUsage(counts map[string]int64, limit int) []*entity.UsageRank {
+	ranks := make([]*entity.UsageRank, 0, len(counts))
+	for id, n := range counts {
+		ranks = append(ranks, &entity.UsageRank{Id: id, MessageCount: n})
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].MessageCount != ranks[j].MessageCount {
+			return ranks[i].MessageCount > ranks[j].MessageCount
+		}
+		return ranks[i].Id < ranks[j].Id
+	})
+	if len(ranks) > limit {
+		ranks = ranks[:limit]
+	}
+	return ranks
+}
+
+// statsDayRange expands a yyyymmdd date range into days
+func statsDayRange(startDate, endDate string, now time.Time) ([]string, error) {
+	today := statsDay(now)
+	if startDate == "" {
+		startDate = today
+	}
+	if endDate == "" {
+		endDate = today
+	}
+
+	start, err := time.ParseInLocation(statsDayLayout, startDate, now.Location())
+	if err != nil {
+		return nil, errcode.ErrInvalidParam
+	}
+	end, err := time.ParseInLocation(statsDayLayout, endDate, now.Location())
+	if err != nil {
+		return nil, errcode.ErrInvalidParam
+	}
+	if end.Before(start) {
+		return nil, errcode.ErrInvalidParam
+	}
+
+	var days []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if len(days) == maxStatsRangeDay {
+			return nil, errcode.ErrInvalidParam
+		}
+		days = append(days, statsDay(d))
+	}
+	return days, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestStatsDayRangeExpandsInclusiveRange(t *testing.T) {
+	days, err := statsDayRange("20260130", "20260202", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"20260130", "20260131", "20260201", "20260202"}
+	if len(days) != len(want) {
+		t.Fatalf("expected %d days, got %v", len(want), days)
+	}
+	for i := range want {
+		if days[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, days)
+		}
+	}
+}
+
+func TestStatsDayRangeRejectsOversizedRange(t *testing.T) {
+	_, err := statsDayRange("20260101", "20260301", time.Now())
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error, got %v", err)
+	}
+}
+
+func TestUsageStatsDisabled(t *testing.T) {
+	s := &StatsService{}
+	if _, err := s.GetConversationUsage(context.Background(), "si_a_b", "", "", ""); !errors.Is(err, errcode.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if _, err := s.GetTopUsage(context.Background(), UsageKindUser, "", 10); !errors.Is(err, errcode.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestUsageStatsRejectsInvalidQueries(t *testing.T) {
+	s := &StatsService{}
+	s.SetUsage(&config.UsageStatsConfig{Enabled: true, TopLimit: 100})
+	ctx := context.Background()
+
+	if _, err := s.GetConversationUsage(ctx, "", "", "", ""); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error without conversation, got %v", err)
+	}
+	if _, err := s.GetUserUsage(ctx, "", "", ""); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error without user, got %v", err)
+	}
+	for _, kind := range []string{"group", "", "User", "conversations"} {
+		if _, err := s.GetTopUsage(ctx, kind, "", 10); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("expected invalid param error for kind %q, got %v", kind, err)
+		}
+	}
+	if _, err := s.GetTopUsage(ctx, UsageKindUser, "2026-01-01", 10); !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error for malformed date, got %v", err)
+	}
+}
+
+func TestTopUsageOrdersByMessagesThenId(t *testing.T) {
+	ranks := topUsage(map[string]int64{"u3": 2, "u1": 5, "u2": 2, "u4": 1}, 3)
+	want := []string{"u1", "u2", "u3"}
+	if len(ranks) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(ranks))
+	}
+	for i, id := range want {
+		if ranks[i].Id != id {
+			t.Fatalf("expected %v at %d, got %s", id, i, ranks[i].Id)
+		}
+	}
+}
+
+func TestGroupReportDisabled(t *testing.T) {
+	var s *StatsService
+	if _, err := s.GetGroupReport(context.Background(), "g1", "", ""); !errors.Is(err, errcode.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
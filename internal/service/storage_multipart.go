@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/storage"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// maxUploadParts is the most parts S3 and OSS assemble an object from
+const maxUploadParts = 10000
+
+// MultipartUpload is the requests uploading the parts of a file, valid until ExpiresAt. Each
+// part but the last is PartSize bytes.
+type MultipartUpload struct {
+	ObjectKey string        `json:"object_key"`
+	PartSize  int64         `json:"part_size"`
+	Parts     []*UploadPart `json:"parts"`
+	ExpiresAt int64         `json:"expires_at"`
+}
+
+// UploadPart is the request uploading a part; its response carries the ETag of the part
+type UploadPart struct {
+	PartNumber int                       `json:"part_number"`
+	Upload     *storage.PresignedRequest `json:"upload"`
+}
+
+// CompleteMultipartUploadRequest completes a multipart upload with the ETags of its parts
+type CompleteMultipartUploadRequest struct {
+	ObjectKey string                  `json:"object_key" validate:"required,max=512"`
+	Parts     []storage.CompletedPart `json:"parts" validate:"required,min=1,max=10000"`
+}
+
+// AbortMultipartUploadRequest aborts a multipart upload
+type AbortMultipartUploadRequest struct {
+	ObjectKey string `json:"object_key" validate:"required,max=512"`
+}
+
+// InitMultipartUpload records a pending upload of a new object uploaded in parts and returns
+// the presigned requests uploading them, with the same checks and quota as PresignUpload.
+func (s *StorageService) InitMultipartUpload(ctx context.Context, userId string, req *PresignUploadRequest) (*MultipartUpload, error) {
+	now := time.Now()
+	if err := s.checkUpload(ctx, userId, req, now); err != nil {
+		return nil, err
+	}
+	key := uploadObjectKey(s.keyPrefix, userId, req.FileName, now)
+	uploadId, err := s.storage.CreateMultipartUpload(ctx, key, req.ContentType)
+	if err != nil {
+		log.CtxError(ctx, "create multipart upload failed: object_key=%s, error=%v", key, err)
+		return nil, errcode.ErrInternalServer
+	}
+	upload := &entity.Upload{
+		UserId:      userId,
+		ObjectKey:   key,
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		Status:      constant.UploadStatusPending,
+		MultipartId: uploadId,
+	}
+	if err = s.uploadRepo.Create(ctx, upload); err != nil {
+		log.CtxError(ctx, "create upload failed: user_id=%s, error=%v", userId, err)
+		if err = s.storage.AbortMultipartUpload(ctx, key, uploadId); err != nil {
+			log.CtxWarn(ctx, "abort multipart upload failed: object_key=%s, error=%v", key, err)
+		}
+		return nil, errcode.ErrInternalServer
+	}
+
+	partSize, count := uploadParts(req.Size, s.partSize)
+	parts := make([]*UploadPart, count)
+	for i := range parts {
+		parts[i] = &UploadPart{PartNumber: i + 1, Upload: s.storage.PresignUploadPart(key, uploadId, i+1, s.expiry)}
+	}
+	return &MultipartUpload{
+		ObjectKey: key,
+		PartSize:  partSize,
+		Parts:     parts,
+		ExpiresAt: now.Add(s.expiry).UnixMilli(),
+	}, nil
+}
+
+// CompleteMultipartUpload assembles the object of a multipart upload of the user from its
+// parts, then confirms it as ConfirmUpload does. Completing again, as after a failed
+// confirmation, confirms the object already assembled.
+func (s *StorageService) CompleteMultipartUpload(ctx context.Context, userId string, req *CompleteMultipartUploadRequest) (*UploadInfo, error) {
+	if !validUploadParts(req.Parts) {
+		return nil, errcode.ErrInvalidParam
+	}
+	upload, err := s.multipartUpload(ctx, userId, req.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status == constant.UploadStatusPending {
+		err = s.storage.CompleteMultipartUpload(ctx, upload.ObjectKey, upload.MultipartId, req.Parts)
+		if err != nil && !errors.Is(err, storage.ErrIncompleteUpload) {
+			log.CtxError(ctx, "complete multipart upload failed: object_key=%s, error=%v", upload.ObjectKey, err)
+			return nil, errcode.ErrInternalServer
+		}
+		// A rejected completion leaves no object unless an earlier one assembled it, which
+		// the confirmation tells apart
+	}
+	return s.ConfirmUpload(ctx, userId, &ConfirmUploadRequest{ObjectKey: upload.ObjectKey})
+}
+
+// AbortMultipartUpload drops the parts of a pending multipart upload of the user; the upload no
+// longer counts towards the quota
+func (s *StorageService) AbortMultipartUpload(ctx context.Context, userId string, req *AbortMultipartUploadRequest) error {
+	upload, err := s.multipartUpload(ctx, userId, req.ObjectKey)
+	if err != nil {
+		return err
+	}
+	if upload.Status != constant.UploadStatusPending {
+		return errcode.ErrUploadNotFound
+	}
+	if err = s.storage.AbortMultipartUpload(ctx, upload.ObjectKey, upload.MultipartId); err != nil {
+		log.CtxError(ctx, "abort multipart upload failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		return errcode.ErrInternalServer
+	}
+	if _, err = s.uploadRepo.Abort(ctx, upload.Id); err != nil {
+		log.CtxError(ctx, "abort upload failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		return errcode.ErrInternalServer
+	}
+	log.CtxInfo(ctx, "multipart upload aborted: user_id=%s, object_key=%s", userId, upload.ObjectKey)
+	return nil
+}
+
+// multipartUpload returns a multipart upload of the user that was not aborted
+func (s *StorageService) multipartUpload(ctx context.Context, userId, objectKey string) (*entity.Upload, error) {
+	upload, err := s.uploadRepo.GetByKey(ctx, objectKey)
+	if err != nil {
+		log.CtxError(ctx, "get upload failed: object_key=%s, error=%v", objectKey, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if upload == nil || upload.UserId != userId || upload.MultipartId == "" ||
+		upload.Status == constant.UploadStatusAborted {
+		return nil, errcode.ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// uploadParts returns the size of the parts of a file of size bytes, at least partSize, and
+// their count
+func uploadParts(size, partSize int64) (int64, int) {
+	partSize = max(partSize, (size+maxUploadParts-1)/maxUploadParts)
+	return partSize, int(max((size+partSize-1)/partSize, 1))
+}
+
+// validUploadParts reports whether parts are listed in ascending order without repeats, each
+// with an ETag, as the storage requires
+func validUploadParts(parts []storage.CompletedPart) bool {
+	if len(parts) == 0 || len(parts) > maxUploadParts {
+		return false
+	}
+	for i, part := range parts {
+		if part.PartNumber < 1 || part.PartNumber > maxUploadParts || part.ETag == "" {
+			return false
+		}
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return false
+		}
+	}
+	return true
+}
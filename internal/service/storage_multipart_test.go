@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/internal/storage"
+)
+
+func TestUploadParts(t *testing.T) {
+	const mb = 1 << 20
+	for _, tc := range []struct {
+		size      int64
+		partSize  int64
+		wantSize  int64
+		wantCount int
+	}{
+		{size: 1, partSize: 8 * mb, wantSize: 8 * mb, wantCount: 1},
+		{size: 8 * mb, partSize: 8 * mb, wantSize: 8 * mb, wantCount: 1},
+		{size: 8*mb + 1, partSize: 8 * mb, wantSize: 8 * mb, wantCount: 2},
+		{size: 100 * mb, partSize: 8 * mb, wantSize: 8 * mb, wantCount: 13},
+		// Too many parts of the configured size, the parts grow
+		{size: 100000 * mb, partSize: 5 * mb, wantSize: 10 * mb, wantCount: 10000},
+	} {
+		size, count := uploadParts(tc.size, tc.partSize)
+		if size != tc.wantSize || count != tc.wantCount {
+			t.Errorf("uploadParts(%d, %d) = %d, %d, want %d, %d", tc.size, tc.partSize, size, count, tc.wantSize, tc.wantCount)
+		}
+	}
+}
+
+func TestValidUploadParts(t *testing.T) {
+	for name, tc := range map[string]struct {
+		parts []storage.CompletedPart
+		want  bool
+	}{
+		"ascending":     {[]storage.CompletedPart{{PartNumber: 1, ETag: "a"}, {PartNumber: 2, ETag: "b"}}, true},
+		"gap":           {[]storage.CompletedPart{{PartNumber: 1, ETag: "a"}, {PartNumber: 3, ETag: "b"}}, true},
+		"empty":         {nil, false},
+		"descending":    {[]storage.CompletedPart{{PartNumber: 2, ETag: "a"}, {PartNumber: 1, ETag: "b"}}, false},
+		"repeated":      {[]storage.CompletedPart{{PartNumber: 1, ETag: "a"}, {PartNumber: 1, ETag: "b"}}, false},
+		"missing etag":  {[]storage.CompletedPart{{PartNumber: 1}}, false},
+		"out of bounds": {[]storage.CompletedPart{{PartNumber: maxUploadParts + 1, ETag: "a"}}, false},
+		"zero":          {[]storage.CompletedPart{{PartNumber: 0, ETag: "a"}}, false},
+	} {
+		if got := validUploadParts(tc.parts); got != tc.want {
+			t.Errorf("%s: validUploadParts = %v, want %v", name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,782 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/internal/scan"
+	"github.com/ZaiSpace/nexo_im/internal/storage"
+	"github.com/ZaiSpace/nexo_im/pkg/audit"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+)
+
+const (
+	// maxUploadExtLen bounds the file extension kept in object keys, dot included
+	maxUploadExtLen = 10
+	// thumbnailSuffix replaces the extension of an image key in the key of its thumbnail
+	thumbnailSuffix = "_thumb.jpg"
+	// downloadPath is the route of the download proxy, followed by the upload id
+	downloadPath = "/im/storage/download/"
+	// thumbnailQuery selects the thumbnail of an image in a download proxy URL
+	thumbnailQuery = "?thumbnail=true"
+)
+
+// processableImageTypes are the content types of the images processed after confirmation
+var processableImageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true}
+
+// measurableAudioTypes are the content types of the audio whose duration is read on confirmation
+var measurableAudioTypes = map[string]bool{
+	"audio/wav": true, "audio/x-wav": true, "audio/wave": true, "audio/ogg": true, "audio/opus": true,
+	"audio/mp4": true, "audio/m4a": true, "audio/x-m4a": true,
+}
+
+// ObjectStorage is the bucket uploads are stored in, see storage.Client
+type ObjectStorage interface {
+	PresignPut(key, contentType string, ttl time.Duration) *storage.PresignedRequest
+	PresignGet(key string, ttl time.Duration) string
+	Stat(ctx context.Context, key string) (*storage.ObjectInfo, error)
+	Get(ctx context.Context, key string, limit int64) ([]byte, error)
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error)
+	PresignUploadPart(key, uploadId string, partNumber int, ttl time.Duration) *storage.PresignedRequest
+	CompleteMultipartUpload(ctx context.Context, key, uploadId string, parts []storage.CompletedPart) error
+	AbortMultipartUpload(ctx context.Context, key, uploadId string) error
+}
+
+// ConversationAccessChecker tells whether a user may read a conversation
+type ConversationAccessChecker interface {
+	CanAccessConversation(ctx context.Context, userId, conversationId string) (bool, error)
+}
+
+// StorageService issues presigned upload URLs scoped to a single new object per request and
+// confirms the uploads, so that media referenced in messages lives in the managed bucket.
+// With the download proxy, uploads are referenced by proxy URLs and downloaded by the
+// participants of the conversations they were sent in.
+// Confirmed images are queued without blocking the caller and processed by the workers
+// started by Run, which strip their EXIF metadata and store a thumbnail; image messages
+// referencing them are then described with the thumbnail and dimensions. The duration of
+// confirmed audio is read on confirmation and replaces the one sent in audio messages.
+type StorageService struct {
+	uploadRepo   *repository.UploadRepo
+	storage      ObjectStorage
+	keyPrefix    string
+	expiry       time.Duration
+	maxSize      int64
+	partSize     int64
+	monthlyQuota int64
+	contentTypes []string
+	images       config.StorageImagesConfig
+	audio        config.StorageAudioConfig
+	scanner      scan.Scanner
+	scanCfg      config.StorageScanConfig
+	downloadBase string // URL prefix of the download proxy, empty when disabled
+	downloadTTL  time.Duration
+	access       ConversationAccessChecker
+	imageJobs    chan *entity.Upload
+	done         chan struct{} // closed when all workers exit
+}
+
+// NewStorageService creates a new StorageService
+func NewStorageService(repos *repository.Repositories, cfg *config.StorageConfig, objects ObjectStorage) *StorageService {
+	s := &StorageService{
+		uploadRepo:   repos.Upload,
+		storage:      objects,
+		keyPrefix:    cfg.KeyPrefix,
+		expiry:       cfg.PresignExpiry,
+		maxSize:      cfg.MaxSize,
+		partSize:     cfg.MultipartPartSize,
+		monthlyQuota: cfg.MonthlyQuota,
+		contentTypes: cfg.AllowedContentTypes,
+		images:       cfg.Images,
+		audio:        cfg.Audio,
+		downloadTTL:  cfg.Download.URLExpiry,
+	}
+	if cfg.Download.Enabled {
+		s.downloadBase = strings.TrimSuffix(cfg.Download.BaseURL, "/") + downloadPath
+	}
+	if cfg.Images.Enabled {
+		s.imageJobs = make(chan *entity.Upload, cfg.Images.QueueSize)
+	}
+	return s
+}
+
+// SetScanner scans uploads for malware before they are confirmed
+func (s *StorageService) SetScanner(scanner scan.Scanner, cfg *config.StorageScanConfig) {
+	s.scanner = scanner
+	s.scanCfg = *cfg
+}
+
+// SetAccessChecker sets the checker of the conversations whose participants may download
+// the uploads sent in them
+func (s *StorageService) SetAccessChecker(access ConversationAccessChecker) {
+	s.access = access
+}
+
+// PresignUploadRequest asks for the URL to upload a file
+type PresignUploadRequest struct {
+	FileName    string `json:"file_name" validate:"max=255"`
+	ContentType string `json:"content_type" validate:"required,max=128"`
+	Size        int64  `json:"size" validate:"required,min=1"` // bytes, checked again on confirm
+}
+
+// PresignedUpload is the request uploading a file, valid until ExpiresAt
+type PresignedUpload struct {
+	ObjectKey string                    `json:"object_key"`
+	Upload    *storage.PresignedRequest `json:"upload"`
+	ExpiresAt int64                     `json:"expires_at"`
+}
+
+// ConfirmUploadRequest confirms the upload of an object
+type ConfirmUploadRequest struct {
+	ObjectKey string `json:"object_key" validate:"required,max=512"`
+}
+
+// UploadInfo describes a confirmed upload; URL is what media messages reference. The
+// thumbnail and dimensions of an image, and the duration of audio, are set once MediaStatus
+// is ready.
+type UploadInfo struct {
+	ObjectKey    string `json:"object_key"`
+	URL          string `json:"url"`
+	FileName     string `json:"file_name,omitempty"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	MediaStatus  int    `json:"media_status"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Duration     int    `json:"duration,omitempty"` // milliseconds
+}
+
+// UploadQuota is the upload usage of a user in the current month with the upload limits
+type UploadQuota struct {
+	Month               string   `json:"month"` // yyyy-mm, UTC
+	Used                int64    `json:"used"`
+	Quota               int64    `json:"quota"`               // 0 for no quota
+	Remaining           int64    `json:"remaining,omitempty"` // set with a quota
+	ResetsAt            int64    `json:"resets_at"`
+	MaxSize             int64    `json:"max_size"`
+	AllowedContentTypes []string `json:"allowed_content_types,omitempty"`
+}
+
+// PresignUpload records a pending upload of a new object and returns the presigned request
+// uploading it. The declared size counts towards the monthly quota of the user until the
+// presigned request expires, and for good once the upload is confirmed.
+func (s *StorageService) PresignUpload(ctx context.Context, userId string, req *PresignUploadRequest) (*PresignedUpload, error) {
+	now := time.Now()
+	if err := s.checkUpload(ctx, userId, req, now); err != nil {
+		return nil, err
+	}
+	upload := &entity.Upload{
+		UserId:      userId,
+		ObjectKey:   uploadObjectKey(s.keyPrefix, userId, req.FileName, now),
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		Status:      constant.UploadStatusPending,
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		log.CtxError(ctx, "create upload failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return &PresignedUpload{
+		ObjectKey: upload.ObjectKey,
+		Upload:    s.storage.PresignPut(upload.ObjectKey, upload.ContentType, s.expiry),
+		ExpiresAt: now.Add(s.expiry).UnixMilli(),
+	}, nil
+}
+
+// checkUpload checks the size and content type of a file a user asks to upload, and that it
+// fits in the monthly quota of the user
+func (s *StorageService) checkUpload(ctx context.Context, userId string, req *PresignUploadRequest, now time.Time) error {
+	if req.Size > s.maxSize {
+		return errcode.ErrUploadTooLarge
+	}
+	if !contentTypeAllowed(s.contentTypes, req.ContentType) {
+		return errcode.ErrUploadContentType
+	}
+	if s.monthlyQuota > 0 {
+		used, err := s.monthlyUsage(ctx, userId, now)
+		if err != nil {
+			log.CtxError(ctx, "get upload usage failed: user_id=%s, error=%v", userId, err)
+			return errcode.ErrInternalServer
+		}
+		if used+req.Size > s.monthlyQuota {
+			return errcode.ErrUploadQuota
+		}
+	}
+	return nil
+}
+
+// GetQuota returns the upload usage of a user in the current month
+func (s *StorageService) GetQuota(ctx context.Context, userId string) (*UploadQuota, error) {
+	now := time.Now()
+	used, err := s.monthlyUsage(ctx, userId, now)
+	if err != nil {
+		log.CtxError(ctx, "get upload usage failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	start := monthStart(now)
+	quota := &UploadQuota{
+		Month:               start.Format("2006-01"),
+		Used:                used,
+		Quota:               s.monthlyQuota,
+		ResetsAt:            start.AddDate(0, 1, 0).UnixMilli(),
+		MaxSize:             s.maxSize,
+		AllowedContentTypes: s.contentTypes,
+	}
+	if s.monthlyQuota > 0 {
+		quota.Remaining = max(s.monthlyQuota-used, 0)
+	}
+	return quota, nil
+}
+
+// monthlyUsage returns the bytes a user uploaded this month, including pending uploads whose
+// presigned request has not expired
+func (s *StorageService) monthlyUsage(ctx context.Context, userId string, now time.Time) (int64, error) {
+	return s.uploadRepo.SumSize(ctx, userId, monthStart(now).UnixMilli(), now.Add(-s.expiry).UnixMilli())
+}
+
+// monthStart returns the start of the calendar month of t in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ConfirmUpload checks that the object of an upload of the user was stored with the size
+// requested and passes the malware scan, then marks the upload as confirmed, queueing images
+// for processing and reading the duration of audio. An object of another size is removed, as is an infected object, whose
+// upload is rejected. Confirming again returns the upload with its current media status.
+func (s *StorageService) ConfirmUpload(ctx context.Context, userId string, req *ConfirmUploadRequest) (*UploadInfo, error) {
+	upload, err := s.uploadRepo.GetByKey(ctx, req.ObjectKey)
+	if err != nil {
+		log.CtxError(ctx, "get upload failed: object_key=%s, error=%v", req.ObjectKey, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if upload == nil || upload.UserId != userId {
+		return nil, errcode.ErrUploadNotFound
+	}
+	if upload.Status == constant.UploadStatusConfirmed {
+		return s.uploadInfo(upload), nil
+	}
+	if upload.Status == constant.UploadStatusRejected {
+		return nil, errcode.ErrUploadInfected
+	}
+
+	object, err := s.storage.Stat(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, errcode.ErrUploadIncomplete
+	}
+	if err != nil {
+		log.CtxError(ctx, "stat object failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if object.Size != upload.Size {
+		if err = s.storage.Delete(ctx, upload.ObjectKey); err != nil {
+			log.CtxWarn(ctx, "delete mismatched object failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		}
+		return nil, errcode.ErrUploadIncomplete
+	}
+	if s.scanner != nil {
+		if err = s.scanUpload(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+
+	upload.Status = constant.UploadStatusConfirmed
+	upload.MediaStatus = constant.UploadMediaNone
+	if s.processable(upload) {
+		upload.MediaStatus = constant.UploadMediaPending
+	}
+	if err = s.uploadRepo.Confirm(ctx, upload.Id, object.Size, upload.MediaStatus); err != nil {
+		log.CtxError(ctx, "confirm upload failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if upload.MediaStatus == constant.UploadMediaPending {
+		s.enqueueImage(ctx, upload)
+	} else if s.measurable(upload) {
+		s.measureAudio(ctx, upload)
+	}
+	return s.uploadInfo(upload), nil
+}
+
+// scanUpload scans the object of an upload, deleting it and rejecting the upload when it is
+// infected. Objects that could not be scanned, such as those larger than the scan limit, are
+// rejected per FailClosed and kept so that confirming may be retried.
+func (s *StorageService) scanUpload(ctx context.Context, upload *entity.Upload) error {
+	if upload.Size > s.scanCfg.MaxBytes {
+		metrics.StorageScansTotal.WithLabelValues("skipped").Inc()
+		return s.scanUnavailable(ctx, upload, storage.ErrTooLarge)
+	}
+	data, err := s.storage.Get(ctx, upload.ObjectKey, s.scanCfg.MaxBytes)
+	if err != nil {
+		metrics.StorageScansTotal.WithLabelValues("failed").Inc()
+		return s.scanUnavailable(ctx, upload, err)
+	}
+	result, err := s.scanner.Scan(ctx, upload.ObjectKey, data)
+	if err != nil {
+		metrics.StorageScansTotal.WithLabelValues("failed").Inc()
+		return s.scanUnavailable(ctx, upload, err)
+	}
+	if !result.Infected {
+		metrics.StorageScansTotal.WithLabelValues("clean").Inc()
+		return nil
+	}
+
+	metrics.StorageScansTotal.WithLabelValues("infected").Inc()
+	log.CtxWarn(ctx, "infected upload rejected: user_id=%s, object_key=%s, signature=%s",
+		upload.UserId, upload.ObjectKey, result.Signature)
+	audit.Record(ctx, &audit.Event{
+		Category: audit.CategoryUpload,
+		Action:   "reject_infected",
+		Actor:    upload.UserId,
+		Code:     errcode.ErrUploadInfected.Code,
+		Detail: map[string]any{
+			"object_key":   upload.ObjectKey,
+			"file_name":    upload.FileName,
+			"content_type": upload.ContentType,
+			"size":         upload.Size,
+			"signature":    result.Signature,
+		},
+	})
+	if err = s.storage.Delete(ctx, upload.ObjectKey); err != nil {
+		log.CtxError(ctx, "delete infected object failed: object_key=%s, error=%v", upload.ObjectKey, err)
+	}
+	if err = s.uploadRepo.Reject(ctx, upload.Id); err != nil {
+		log.CtxError(ctx, "reject upload failed: object_key=%s, error=%v", upload.ObjectKey, err)
+	}
+	return errcode.ErrUploadInfected
+}
+
+// scanUnavailable applies the failure policy when an upload could not be scanned
+func (s *StorageService) scanUnavailable(ctx context.Context, upload *entity.Upload, err error) error {
+	log.CtxWarn(ctx, "upload not scanned: object_key=%s, fail_closed=%v, error=%v",
+		upload.ObjectKey, s.scanCfg.FailClosed, err)
+	if s.scanCfg.FailClosed {
+		return errcode.ErrUploadScanFailed
+	}
+	return nil
+}
+
+func (s *StorageService) uploadInfo(upload *entity.Upload) *UploadInfo {
+	info := &UploadInfo{
+		ObjectKey:   upload.ObjectKey,
+		URL:         s.mediaURL(upload, false),
+		FileName:    upload.FileName,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		MediaStatus: upload.MediaStatus,
+	}
+	if upload.MediaStatus == constant.UploadMediaReady {
+		info.ThumbnailURL = s.mediaURL(upload, true)
+		info.Width, info.Height = upload.Width, upload.Height
+		info.Duration = upload.Duration
+	}
+	return info
+}
+
+// MediaURLPrefix returns the prefix of the URLs uploads are referenced by
+func (s *StorageService) MediaURLPrefix() string {
+	if s.downloadBase != "" {
+		return s.downloadBase
+	}
+	return s.storage.URL("")
+}
+
+// mediaURL returns the URL an upload, or its thumbnail, is referenced by
+func (s *StorageService) mediaURL(upload *entity.Upload, thumbnail bool) string {
+	if s.downloadBase != "" {
+		u := s.downloadBase + strconv.FormatInt(upload.Id, 10)
+		if thumbnail {
+			u += thumbnailQuery
+		}
+		return u
+	}
+	if thumbnail {
+		return s.storage.URL(upload.ThumbnailKey)
+	}
+	return s.storage.URL(upload.ObjectKey)
+}
+
+// uploadByURL returns the upload a media URL references, nil for a URL outside the storage
+func (s *StorageService) uploadByURL(ctx context.Context, rawURL string) (*entity.Upload, error) {
+	prefix := s.MediaURLPrefix()
+	if !strings.HasPrefix(rawURL, prefix) {
+		return nil, nil
+	}
+	rest := strings.TrimPrefix(rawURL, prefix)
+	if s.downloadBase != "" {
+		id, err := strconv.ParseInt(strings.TrimSuffix(rest, thumbnailQuery), 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		return s.uploadRepo.GetById(ctx, id)
+	}
+	key, err := url.PathUnescape(rest)
+	if err != nil {
+		return nil, nil
+	}
+	return s.uploadRepo.GetByKey(ctx, key)
+}
+
+// DescribeImage sets the thumbnail and dimensions of an image uploaded to the bucket once it
+// is processed, replacing those sent by the client; images stored elsewhere are left as is
+func (s *StorageService) DescribeImage(ctx context.Context, image *entity.ImageContent) {
+	if !strings.HasPrefix(image.Url, s.MediaURLPrefix()) {
+		return
+	}
+	image.ThumbnailUrl, image.Width, image.Height = "", 0, 0
+	upload, err := s.uploadByURL(ctx, image.Url)
+	if err != nil {
+		log.CtxWarn(ctx, "get upload failed: url=%s, error=%v", image.Url, err)
+		return
+	}
+	if upload == nil || upload.MediaStatus != constant.UploadMediaReady {
+		return
+	}
+	image.ThumbnailUrl = s.mediaURL(upload, true)
+	image.Width, image.Height = upload.Width, upload.Height
+}
+
+// DescribeAudio sets the duration of audio uploaded to the bucket once it is read, replacing
+// the one sent by the client; other audio keeps the duration sent
+func (s *StorageService) DescribeAudio(ctx context.Context, audio *entity.AudioContent) {
+	if !strings.HasPrefix(audio.Url, s.MediaURLPrefix()) {
+		return
+	}
+	upload, err := s.uploadByURL(ctx, audio.Url)
+	if err != nil {
+		log.CtxWarn(ctx, "get upload failed: url=%s, error=%v", audio.Url, err)
+		return
+	}
+	if upload == nil || upload.MediaStatus != constant.UploadMediaReady || upload.Duration == 0 {
+		return
+	}
+	audio.Duration = upload.Duration
+}
+
+// CheckMedia verifies that a user may share the uploads content references: the uploads must
+// be confirmed and downloadable by the user
+func (s *StorageService) CheckMedia(ctx context.Context, userId string, content entity.MessageContent) error {
+	for _, u := range mediaURLs(content) {
+		upload, err := s.uploadByURL(ctx, u)
+		if err != nil {
+			log.CtxError(ctx, "get upload failed: url=%s, error=%v", u, err)
+			return errcode.ErrInternalServer
+		}
+		if upload == nil {
+			if strings.HasPrefix(u, s.MediaURLPrefix()) {
+				return errcode.ErrUploadNotFound
+			}
+			continue
+		}
+		if ok, err := s.canDownload(ctx, userId, upload); err != nil {
+			return err
+		} else if !ok {
+			return errcode.ErrUploadNotFound
+		}
+	}
+	return nil
+}
+
+// TrackMedia records the uploads a stored message references as sent in its conversation
+func (s *StorageService) TrackMedia(ctx context.Context, msg *entity.Message) {
+	var uploadIds []int64
+	for _, u := range mediaURLs(msg.Content) {
+		if !strings.HasPrefix(u, s.downloadBase) {
+			continue
+		}
+		if id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(u, s.downloadBase), thumbnailQuery), 10, 64); err == nil {
+			uploadIds = append(uploadIds, id)
+		}
+	}
+	if len(uploadIds) == 0 {
+		return
+	}
+	if err := s.uploadRepo.AddRefs(ctx, uploadIds, msg.ConversationId); err != nil {
+		log.CtxError(ctx, "add upload refs failed: conversation_id=%s, error=%v", msg.ConversationId, err)
+	}
+}
+
+// MediaSize returns the total size of the uploads content references, each counted once
+func (s *StorageService) MediaSize(ctx context.Context, content entity.MessageContent) int64 {
+	var size int64
+	seen := make(map[int64]bool)
+	for _, u := range mediaURLs(content) {
+		upload, err := s.uploadByURL(ctx, u)
+		if err != nil {
+			log.CtxWarn(ctx, "get upload failed: url=%s, error=%v", u, err)
+			continue
+		}
+		if upload == nil || seen[upload.Id] {
+			continue
+		}
+		seen[upload.Id] = true
+		size += upload.Size
+	}
+	return size
+}
+
+// Download returns a presigned URL reading an upload, or its thumbnail, for the uploader and
+// the participants of the conversations it was sent in
+func (s *StorageService) Download(ctx context.Context, userId string, uploadId int64, thumbnail bool) (string, error) {
+	if s.downloadBase == "" {
+		return "", errcode.ErrUploadNotFound
+	}
+	upload, err := s.uploadRepo.GetById(ctx, uploadId)
+	if err != nil {
+		log.CtxError(ctx, "get upload failed: upload_id=%d, error=%v", uploadId, err)
+		return "", errcode.ErrInternalServer
+	}
+	if upload == nil {
+		return "", errcode.ErrUploadNotFound
+	}
+	ok, err := s.canDownload(ctx, userId, upload)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errcode.ErrUploadNotFound
+	}
+	key := upload.ObjectKey
+	if thumbnail {
+		if upload.MediaStatus != constant.UploadMediaReady {
+			return "", errcode.ErrUploadNotFound
+		}
+		key = upload.ThumbnailKey
+	}
+	return s.storage.PresignGet(key, s.downloadTTL), nil
+}
+
+// canDownload reports whether a user may download a confirmed upload: the uploader always may,
+// other users when they may read a conversation the upload was sent in
+func (s *StorageService) canDownload(ctx context.Context, userId string, upload *entity.Upload) (bool, error) {
+	if upload.Status != constant.UploadStatusConfirmed {
+		return false, nil
+	}
+	if upload.UserId == userId {
+		return true, nil
+	}
+	if s.access == nil {
+		return false, nil
+	}
+	conversationIds, err := s.uploadRepo.GetRefConversations(ctx, upload.Id)
+	if err != nil {
+		log.CtxError(ctx, "get upload refs failed: upload_id=%d, error=%v", upload.Id, err)
+		return false, errcode.ErrInternalServer
+	}
+	for _, conversationId := range conversationIds {
+		ok, err := s.access.CanAccessConversation(ctx, userId, conversationId)
+		if err != nil {
+			log.CtxError(ctx, "check conversation access failed: conversation_id=%s, error=%v", conversationId, err)
+			return false, errcode.ErrInternalServer
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// mediaURLs returns the URLs of the media of content, including the messages of a merged forward
+func mediaURLs(content entity.MessageContent) []string {
+	var urls []string
+	if content.Image != nil {
+		urls = append(urls, content.Image.Url)
+		if content.Image.ThumbnailUrl != "" {
+			urls = append(urls, content.Image.ThumbnailUrl)
+		}
+	}
+	if content.Video != nil {
+		urls = append(urls, content.Video.Url)
+	}
+	if content.Audio != nil {
+		urls = append(urls, content.Audio.Url)
+	}
+	if content.File != nil {
+		urls = append(urls, content.File.Url)
+	}
+	if content.Merged != nil {
+		for _, m := range content.Merged.Messages {
+			urls = append(urls, mediaURLs(m.Content)...)
+		}
+	}
+	return urls
+}
+
+// processable reports whether the image of a confirmed upload is to be processed
+func (s *StorageService) processable(upload *entity.Upload) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(upload.ContentType, ";")[0]))
+	return s.imageJobs != nil && processableImageTypes[mediaType] && upload.Size <= s.images.MaxBytes
+}
+
+// measurable reports whether the duration of a confirmed audio upload is to be read
+func (s *StorageService) measurable(upload *entity.Upload) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(upload.ContentType, ";")[0]))
+	return s.audio.Enabled && measurableAudioTypes[mediaType] && upload.Size <= s.audio.MaxBytes
+}
+
+// measureAudio records the duration of an uploaded audio file, read from its headers
+func (s *StorageService) measureAudio(ctx context.Context, upload *entity.Upload) {
+	data, err := s.storage.Get(ctx, upload.ObjectKey, s.audio.MaxBytes)
+	if err != nil {
+		log.CtxWarn(ctx, "get audio failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+		return
+	}
+	duration, err := storage.AudioDuration(data)
+	if err != nil {
+		log.CtxWarn(ctx, "read audio duration failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+		return
+	}
+	upload.Duration = int(duration.Milliseconds())
+	s.saveMedia(ctx, upload, constant.UploadMediaReady)
+}
+
+// enqueueImage queues the processing of an image without blocking, marking it as failed when
+// the queue is full
+func (s *StorageService) enqueueImage(ctx context.Context, upload *entity.Upload) {
+	select {
+	case s.imageJobs <- upload:
+	default:
+		log.CtxWarn(ctx, "image queue full, processing skipped: object_key=%s", upload.ObjectKey)
+		s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+	}
+}
+
+// Run starts the image processing workers, which exit once ctx is done; images still queued
+// then stay pending
+func (s *StorageService) Run(ctx context.Context) {
+	if s.imageJobs == nil {
+		return
+	}
+	s.done = make(chan struct{})
+	var wg sync.WaitGroup
+	for range s.images.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case upload := <-s.imageJobs:
+					s.processImage(ctx, upload)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.done)
+	}()
+	log.CtxInfo(ctx, "image processing started: workers=%d, thumbnail_size=%d", s.images.Workers, s.images.ThumbnailSize)
+}
+
+// Wait blocks until the workers have exited after their Run ctx is done, or until ctx is done
+func (s *StorageService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// processImage stores the thumbnail of an uploaded image, replaces the object with a copy
+// without EXIF metadata when it has some and records the dimensions
+func (s *StorageService) processImage(ctx context.Context, upload *entity.Upload) {
+	data, err := s.storage.Get(ctx, upload.ObjectKey, s.images.MaxBytes)
+	if err != nil {
+		log.CtxWarn(ctx, "get image failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+		return
+	}
+	image, err := storage.ProcessImage(data, s.images.ThumbnailSize)
+	if err != nil {
+		log.CtxWarn(ctx, "process image failed: object_key=%s, error=%v", upload.ObjectKey, err)
+		s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+		return
+	}
+
+	thumbnailKey := thumbnailObjectKey(upload.ObjectKey)
+	if err = s.storage.Put(ctx, thumbnailKey, "image/jpeg", image.Thumbnail); err != nil {
+		log.CtxWarn(ctx, "put thumbnail failed: object_key=%s, error=%v", thumbnailKey, err)
+		s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+		return
+	}
+	if image.Stripped != nil {
+		if err = s.storage.Put(ctx, upload.ObjectKey, upload.ContentType, image.Stripped); err != nil {
+			log.CtxWarn(ctx, "put stripped image failed: object_key=%s, error=%v", upload.ObjectKey, err)
+			s.saveMedia(ctx, upload, constant.UploadMediaFailed)
+			return
+		}
+		upload.Size = int64(len(image.Stripped))
+	}
+	upload.ThumbnailKey = thumbnailKey
+	upload.Width, upload.Height = image.Width, image.Height
+	s.saveMedia(ctx, upload, constant.UploadMediaReady)
+}
+
+func (s *StorageService) saveMedia(ctx context.Context, upload *entity.Upload, status int) {
+	upload.MediaStatus = status
+	if err := s.uploadRepo.SetMedia(context.WithoutCancel(ctx), upload); err != nil {
+		log.CtxError(ctx, "save upload media failed: object_key=%s, error=%v", upload.ObjectKey, err)
+	}
+}
+
+// thumbnailObjectKey returns the key of the thumbnail of an image, next to it
+func thumbnailObjectKey(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + thumbnailSuffix
+}
+
+// uploadObjectKey returns a new key "<prefix><user>/<yyyymmdd>/<uuid><ext>", keeping the file
+// extension when it is short and alphanumeric
+func uploadObjectKey(prefix, userId, fileName string, now time.Time) string {
+	key := prefix + userId + "/" + now.UTC().Format("20060102") + "/" + uuid.NewString()
+	ext := strings.ToLower(path.Ext(fileName))
+	if len(ext) < 2 || len(ext) > maxUploadExtLen {
+		return key
+	}
+	for _, c := range ext[1:] {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return key
+		}
+	}
+	return key + ext
+}
+
+// contentTypeAllowed reports whether contentType matches one of the allowed types, which may
+// end in "/*" to allow a whole type; no allowed types allow any
+func contentTypeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == mediaType || strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
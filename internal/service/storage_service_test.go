@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestUploadObjectKey(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	for fileName, ext := range map[string]string{
+		"photo.JPG":       ".jpg",
+		"notes":           "",
+		"archive.tar.gz":  ".gz",
+		"x.verylongext12": "",
+		"evil.p/h":        "",
+		"a.b c":           "",
+	} {
+		key := uploadObjectKey("uploads/", "u1", fileName, now)
+		pattern := `^uploads/u1/20260301/[0-9a-f-]{36}` + regexp.QuoteMeta(ext) + `$`
+		if !regexp.MustCompile(pattern).MatchString(key) {
+			t.Errorf("unexpected key %q for %q", key, fileName)
+		}
+	}
+	if uploadObjectKey("", "u1", "a.png", now) == uploadObjectKey("", "u1", "a.png", now) {
+		t.Fatal("expected a new key per upload")
+	}
+}
+
+func TestContentTypeAllowed(t *testing.T) {
+	allowed := []string{"image/*", "application/pdf"}
+	for contentType, want := range map[string]bool{
+		"image/png":                true,
+		"Image/JPEG":               true,
+		"application/pdf":          true,
+		"application/pdf; q=1":     true,
+		"application/x-msdownload": false,
+		"imagex/png":               false,
+	} {
+		if got := contentTypeAllowed(allowed, contentType); got != want {
+			t.Errorf("contentTypeAllowed(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+	if !contentTypeAllowed(nil, "application/x-msdownload") {
+		t.Fatal("expected any type to be allowed without a list")
+	}
+}
+
+func TestCheckMediaURL(t *testing.T) {
+	s := &MessageService{}
+	external := entity.MessageContent{Image: &entity.ImageContent{Url: "https://evil.example.com/a.png"}}
+	if err := s.checkMediaURL(external); err != nil {
+		t.Fatalf("expected any url without managed media, got %v", err)
+	}
+
+	s.SetMediaURLPrefix("https://cdn.example.com/")
+	if err := s.checkMediaURL(external); err != errcode.ErrInvalidParam {
+		t.Fatalf("expected external media to be rejected, got %v", err)
+	}
+	managed := entity.MessageContent{File: &entity.FileContent{Url: "https://cdn.example.com/uploads/u1/a.pdf", Name: "a.pdf"}}
+	if err := s.checkMediaURL(managed); err != nil {
+		t.Fatalf("expected managed media, got %v", err)
+	}
+	text := entity.MessageContent{Text: &entity.TextContent{Text: "https://evil.example.com"}}
+	if err := s.checkMediaURL(text); err != nil {
+		t.Fatalf("expected text to be unchecked, got %v", err)
+	}
+}
+
+func TestThumbnailObjectKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"uploads/u1/20260301/abc.png": "uploads/u1/20260301/abc_thumb.jpg",
+		"uploads/u1/20260301/abc":     "uploads/u1/20260301/abc_thumb.jpg",
+	} {
+		if got := thumbnailObjectKey(key); got != want {
+			t.Errorf("thumbnailObjectKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestProcessableUpload(t *testing.T) {
+	s := &StorageService{images: config.StorageImagesConfig{MaxBytes: 100}, imageJobs: make(chan *entity.Upload, 1)}
+	for upload, want := range map[*entity.Upload]bool{
+		{ContentType: "image/jpeg", Size: 100}:              true,
+		{ContentType: "Image/PNG; charset=binary", Size: 1}: true,
+		{ContentType: "image/webp", Size: 1}:                false,
+		{ContentType: "image/jpeg", Size: 101}:              false,
+	} {
+		if got := s.processable(upload); got != want {
+			t.Errorf("processable(%+v) = %v, want %v", upload, got, want)
+		}
+	}
+	s.imageJobs = nil
+	if s.processable(&entity.Upload{ContentType: "image/jpeg", Size: 1}) {
+		t.Fatal("expected no processing when disabled")
+	}
+}
+
+func TestMeasurableUpload(t *testing.T) {
+	s := &StorageService{audio: config.StorageAudioConfig{Enabled: true, MaxBytes: 100}}
+	for upload, want := range map[*entity.Upload]bool{
+		{ContentType: "audio/ogg", Size: 100}:            true,
+		{ContentType: "Audio/MP4; codecs=mp4a", Size: 1}: true,
+		{ContentType: "audio/mpeg", Size: 1}:             false,
+		{ContentType: "audio/wav", Size: 101}:            false,
+	} {
+		if got := s.measurable(upload); got != want {
+			t.Errorf("measurable(%+v) = %v, want %v", upload, got, want)
+		}
+	}
+	s.audio.Enabled = false
+	if s.measurable(&entity.Upload{ContentType: "audio/ogg", Size: 1}) {
+		t.Fatal("expected no measuring when disabled")
+	}
+}
+
+func TestMonthStart(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	for in, want := range map[time.Time]time.Time{
+		time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC):   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 1, 7, 0, 0, 0, shanghai):     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC): time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got := monthStart(in); !got.Equal(want) {
+			t.Errorf("monthStart(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestDownloadProxyURLs(t *testing.T) {
+	s := &StorageService{downloadBase: "https://im.example.com" + downloadPath}
+	upload := &entity.Upload{Id: 42, ObjectKey: "uploads/u1/20260301/a.png"}
+	if got := s.mediaURL(upload, false); got != "https://im.example.com/im/storage/download/42" {
+		t.Fatalf("unexpected url %s", got)
+	}
+	if got := s.mediaURL(upload, true); got != "https://im.example.com/im/storage/download/42?thumbnail=true" {
+		t.Fatalf("unexpected thumbnail url %s", got)
+	}
+	if s.MediaURLPrefix() != s.downloadBase {
+		t.Fatalf("unexpected prefix %s", s.MediaURLPrefix())
+	}
+
+	// Neither needs a lookup
+	ctx := context.Background()
+	for _, u := range []string{"https://cdn.example.com/a.png", s.downloadBase + "abc"} {
+		if upload, err := s.uploadByURL(ctx, u); upload != nil || err != nil {
+			t.Fatalf("expected no upload for %s, got %+v, err=%v", u, upload, err)
+		}
+	}
+}
+
+func TestMediaURLs(t *testing.T) {
+	content := entity.MessageContent{Image: &entity.ImageContent{Url: "a", ThumbnailUrl: "b"}}
+	if got := mediaURLs(content); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("unexpected urls %v", got)
+	}
+	if got := mediaURLs(entity.MessageContent{Text: &entity.TextContent{Text: "https://x"}}); len(got) != 0 {
+		t.Fatalf("unexpected urls %v", got)
+	}
+	merged := entity.MessageContent{Merged: &entity.MergedContent{Messages: []*entity.MergedMessage{
+		{Content: content},
+		{Content: entity.MessageContent{File: &entity.FileContent{Url: "c"}}},
+	}}}
+	if got := mediaURLs(merged); len(got) != 3 || got[2] != "c" {
+		t.Fatalf("unexpected urls %v", got)
+	}
+}
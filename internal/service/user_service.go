@@ -2,16 +2,30 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/mbeoliero/kit/log"
 	"github.com/ZaiSpace/nexo_im/internal/entity"
 	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
 	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
 )
 
+// ProfileNotifier tells online clients that the nickname or avatar of a user changed
+type ProfileNotifier interface {
+	NotifyProfileChanged(info *entity.UserInfo, userIds []string)
+}
+
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo *repository.UserRepo
+	userRepo  *repository.UserRepo
+	convRepo  *repository.ConversationRepo
+	groupRepo *repository.GroupRepo
+	notifier  ProfileNotifier
 }
 
 // NewUserService creates a new UserService
@@ -21,8 +35,22 @@ func NewUserService(userRepo *repository.UserRepo) *UserService {
 	}
 }
 
-// GetUserInfo gets user info by Id
+// SetNotifier sets the notifier of profile changes, which reach the devices of the user, the
+// peers of the user's single chats and the members of the user's groups
+func (s *UserService) SetNotifier(notifier ProfileNotifier, repos *repository.Repositories) {
+	s.notifier = notifier
+	s.convRepo = repos.Conversation
+	s.groupRepo = repos.Group
+}
+
+// GetUserInfo gets the user info of userId as seen by the user
 func (s *UserService) GetUserInfo(ctx context.Context, userId string) (*entity.UserInfo, error) {
+	return s.GetUserInfoFor(ctx, userId, userId)
+}
+
+// GetUserInfoFor gets user info by Id as seen by viewerId, without the optional fields the
+// user made private unless viewerId is the user
+func (s *UserService) GetUserInfoFor(ctx context.Context, viewerId, userId string) (*entity.UserInfo, error) {
 	user, err := s.userRepo.GetById(ctx, userId)
 	if err != nil {
 		log.CtxDebug(ctx, "get user failed: user_id=%s, error=%v", userId, err)
@@ -31,11 +59,12 @@ func (s *UserService) GetUserInfo(ctx context.Context, userId string) (*entity.U
 	if user == nil {
 		return nil, errcode.ErrUserNotFound
 	}
-	return user.ToUserInfo(), nil
+	return user.ToUserInfoFor(viewerId), nil
 }
 
-// GetUserInfos gets multiple users info by Ids
-func (s *UserService) GetUserInfos(ctx context.Context, userIds []string) ([]*entity.UserInfo, error) {
+// GetUserInfos gets multiple users info by Ids as seen by viewerId, see GetUserInfoFor; an
+// empty viewerId sees the public fields only
+func (s *UserService) GetUserInfos(ctx context.Context, viewerId string, userIds []string) ([]*entity.UserInfo, error) {
 	users, err := s.userRepo.GetByIds(ctx, userIds)
 	if err != nil {
 		log.CtxError(ctx, "get users failed: %v", err)
@@ -44,40 +73,81 @@ func (s *UserService) GetUserInfos(ctx context.Context, userIds []string) ([]*en
 
 	infos := make([]*entity.UserInfo, 0, len(users))
 	for _, user := range users {
-		infos = append(infos, user.ToUserInfo())
+		infos = append(infos, user.ToUserInfoFor(viewerId))
 	}
 	return infos, nil
 }
 
-// UpdateUserRequest represents user update request
+// maxUserExtBytes bounds the JSON of the ext attribute bag of user profiles
+const maxUserExtBytes = 4096
+
+// minBirthday is the earliest birthday accepted
+const minBirthday = "1900-01-01"
+
+// UpdateUserRequest represents user update request. Omitted optional profile fields are
+// left unchanged and empty ones are cleared; ProfilePrivacy sets the visibility of the
+// fields it lists.
 type UpdateUserRequest struct {
-	Nickname string `json:"nickname,omitempty"`
-	Avatar   string `json:"avatar,omitempty"`
-	Extra    string `json:"extra,omitempty"`
+	Nickname       string            `json:"nickname,omitempty" validate:"max=128"`
+	Handle         *string           `json:"handle,omitempty" validate:"max=32"` // "" clears the handle
+	HideFromSearch *bool             `json:"hide_from_search,omitempty"`
+	Avatar         string            `json:"avatar,omitempty" validate:"max=512"`
+	Extra          string            `json:"extra,omitempty" validate:"max=4096"`
+	Gender         *int32            `json:"gender,omitempty" validate:"min=0,max=3"` // constant.Gender*
+	Birthday       *string           `json:"birthday,omitempty" validate:"max=10"`    // YYYY-MM-DD
+	Region         *string           `json:"region,omitempty" validate:"max=64"`
+	Ext            json.RawMessage   `json:"ext,omitempty"`                              // JSON object, or null to clear
+	ProfilePrivacy map[string]string `json:"profile_privacy,omitempty" validate:"max=4"` // field: public or private
 }
 
 // UpdateUserInfo updates user info
 func (s *UserService) UpdateUserInfo(ctx context.Context, userId string, req *UpdateUserRequest) (*entity.UserInfo, error) {
 	// Check if user exists
-	exists, err := s.userRepo.Exists(ctx, userId)
+	user, err := s.userRepo.GetById(ctx, userId)
 	if err != nil {
-		log.CtxError(ctx, "check user exists failed: %v", err)
+		log.CtxError(ctx, "get user failed: %v", err)
 		return nil, errcode.ErrInternalServer
 	}
-	if !exists {
+	if user == nil {
 		return nil, errcode.ErrUserNotFound
 	}
 
 	// Build updates map
 	updates := make(map[string]interface{})
+	var changed []string
 	if req.Nickname != "" {
 		updates["nickname"] = req.Nickname
+		changed = append(changed, "nickname")
 	}
 	if req.Avatar != "" {
 		updates["avatar"] = req.Avatar
+		changed = append(changed, "avatar")
 	}
 	if req.Extra != "" {
 		updates["extra"] = req.Extra
+		changed = append(changed, "extra")
+	}
+	if req.Handle != nil {
+		handle, err := s.checkHandle(ctx, userId, *req.Handle)
+		if err != nil {
+			return nil, err
+		}
+		updates["handle"] = handle
+		changed = append(changed, "handle")
+	}
+	if req.HideFromSearch != nil {
+		updates["hide_from_search"] = *req.HideFromSearch
+		changed = append(changed, "hide_from_search")
+	}
+	profile, err := profileUpdates(user, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range profileColumns {
+		if value, ok := profile[column]; ok {
+			updates[column] = value
+			changed = append(changed, column)
+		}
 	}
 
 	if len(updates) > 0 {
@@ -88,5 +158,157 @@ func (s *UserService) UpdateUserInfo(ctx context.Context, userId string, req *Up
 	}
 
 	// Return updated user info
-	return s.GetUserInfo(ctx, userId)
+	info, err := s.GetUserInfo(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) > 0 {
+		webhook.Emit(ctx, webhook.EventUserProfileUpdated, &UserProfileUpdatedEvent{User: info, ChangedFields: changed})
+	}
+	if req.Nickname != "" || req.Avatar != "" {
+		s.notifyProfileChanged(ctx, info)
+	}
+	return info, nil
+}
+
+// handlePattern is the format of user handles, which are stored lowercase
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,32}$`)
+
+// checkHandle validates a new handle of userId and returns its column value, nil to clear it
+func (s *UserService) checkHandle(ctx context.Context, userId, raw string) (*string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	handle := strings.ToLower(raw)
+	if !handlePattern.MatchString(handle) {
+		return nil, errcode.ErrInvalidParam
+	}
+	taken, err := s.userRepo.ExistsByHandle(ctx, handle, userId)
+	if err != nil {
+		log.CtxError(ctx, "check handle failed: handle=%s, error=%v", handle, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if taken {
+		return nil, errcode.ErrHandleTaken
+	}
+	return &handle, nil
+}
+
+const (
+	defaultUserSearchLimit = 20
+	maxUserSearchLimit     = 50
+)
+
+// SearchUsersQuery represents user search request
+type SearchUsersQuery struct {
+	Q     string `query:"q" validate:"required,max=128"` // handle or nickname prefix, a leading @ only matches handles
+	Limit int    `query:"limit" validate:"min=0,max=50"`
+}
+
+// SearchUsers finds users by handle or nickname prefix as seen by viewerId.
+// Users who opted out of search are never returned.
+func (s *UserService) SearchUsers(ctx context.Context, viewerId string, req *SearchUsersQuery) ([]*entity.UserInfo, error) {
+	q := strings.TrimSpace(req.Q)
+	handleOnly := strings.HasPrefix(q, "@")
+	q = strings.TrimPrefix(q, "@")
+	if q == "" || req.Limit < 0 || req.Limit > maxUserSearchLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultUserSearchLimit
+	}
+
+	users, err := s.userRepo.SearchDiscoverable(ctx, q, strings.ToLower(q), limit, handleOnly)
+	if err != nil {
+		log.CtxError(ctx, "search users failed: q=%s, error=%v", q, err)
+		return nil, errcode.ErrInternalServer
+	}
+	infos := make([]*entity.UserInfo, 0, len(users))
+	for _, user := range users {
+		infos = append(infos, user.ToUserInfoFor(viewerId))
+	}
+	return infos, nil
+}
+
+// notifyProfileChanged pushes the new nickname and avatar of a user to everyone who displays
+// them, in the background as large groups make the audience query slow
+func (s *UserService) notifyProfileChanged(ctx context.Context, info *entity.UserInfo) {
+	if s.notifier == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		peerIds, err := s.convRepo.GetSingleChatPeerIds(ctx, info.Id)
+		if err != nil {
+			log.CtxWarn(ctx, "get single chat peers failed: user_id=%s, error=%v", info.Id, err)
+		}
+		memberIds, err := s.groupRepo.GetCoMemberUserIds(ctx, info.Id)
+		if err != nil {
+			log.CtxWarn(ctx, "get group co-members failed: user_id=%s, error=%v", info.Id, err)
+		}
+		userIds := append([]string{info.Id}, peerIds...)
+		s.notifier.NotifyProfileChanged(info, append(userIds, memberIds...))
+	}()
+}
+
+// profileColumns are the columns of the optional profile fields, in webhook changed_fields order
+var profileColumns = []string{"gender", "birthday", "region", "ext", "profile_privacy"}
+
+// profileUpdates validates the optional profile fields of req and returns their column updates
+func profileUpdates(user *entity.User, req *UpdateUserRequest, now time.Time) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	if req.Gender != nil {
+		updates["gender"] = *req.Gender
+	}
+	if req.Birthday != nil {
+		if *req.Birthday != "" {
+			birthday, err := time.ParseInLocation(time.DateOnly, *req.Birthday, now.Location())
+			if err != nil || *req.Birthday < minBirthday || birthday.After(now) {
+				return nil, errcode.ErrInvalidParam
+			}
+		}
+		updates["birthday"] = *req.Birthday
+	}
+	if req.Region != nil {
+		updates["region"] = *req.Region
+	}
+	if len(req.Ext) > 0 {
+		if string(req.Ext) == "null" {
+			updates["ext"] = nil
+		} else {
+			var ext map[string]interface{}
+			if len(req.Ext) > maxUserExtBytes || json.Unmarshal(req.Ext, &ext) != nil || ext == nil {
+				return nil, errcode.ErrInvalidParam
+			}
+			updates["ext"] = string(req.Ext)
+		}
+	}
+	if len(req.ProfilePrivacy) > 0 {
+		privacy := make(map[string]string, len(user.ProfilePrivacy)+len(req.ProfilePrivacy))
+		for field, visibility := range user.ProfilePrivacy {
+			privacy[field] = visibility
+		}
+		for field, visibility := range req.ProfilePrivacy {
+			switch field {
+			case constant.ProfileFieldGender, constant.ProfileFieldBirthday, constant.ProfileFieldRegion, constant.ProfileFieldExt:
+			default:
+				return nil, errcode.ErrInvalidParam
+			}
+			switch visibility {
+			case constant.ProfileVisibilityPublic:
+				delete(privacy, field)
+			case constant.ProfileVisibilityPrivate:
+				privacy[field] = visibility
+			default:
+				return nil, errcode.ErrInvalidParam
+			}
+		}
+		raw, err := json.Marshal(privacy)
+		if err != nil {
+			return nil, errcode.ErrInternalServer
+		}
+		updates["profile_privacy"] = string(raw)
+	}
+	return updates, nil
 }
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestProfileUpdatesValidatesFields(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	user := &entity.User{Id: "u1"}
+	str := func(s string) *string { return &s }
+
+	cases := []struct {
+		name string
+		req  *UpdateUserRequest
+	}{
+		{"malformed birthday", &UpdateUserRequest{Birthday: str("1990-13-01")}},
+		{"future birthday", &UpdateUserRequest{Birthday: str("2027-01-01")}},
+		{"ancient birthday", &UpdateUserRequest{Birthday: str("1899-12-31")}},
+		{"ext not an object", &UpdateUserRequest{Ext: json.RawMessage(`[1,2]`)}},
+		{"ext too large", &UpdateUserRequest{Ext: json.RawMessage(`{"k":"` + strings.Repeat("x", maxUserExtBytes) + `"}`)}},
+		{"unknown privacy field", &UpdateUserRequest{ProfilePrivacy: map[string]string{"nickname": constant.ProfileVisibilityPrivate}}},
+		{"unknown visibility", &UpdateUserRequest{ProfilePrivacy: map[string]string{constant.ProfileFieldRegion: "friends"}}},
+	}
+	for _, tc := range cases {
+		if _, err := profileUpdates(user, tc.req, now); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%s: expected invalid param error, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestProfileUpdatesClearsAndMergesPrivacy(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	user := &entity.User{Id: "u1", ProfilePrivacy: map[string]string{
+		constant.ProfileFieldBirthday: constant.ProfileVisibilityPrivate,
+		constant.ProfileFieldRegion:   constant.ProfileVisibilityPrivate,
+	}}
+	empty := ""
+	updates, err := profileUpdates(user, &UpdateUserRequest{
+		Birthday: &empty,
+		Ext:      json.RawMessage(`null`),
+		ProfilePrivacy: map[string]string{
+			constant.ProfileFieldRegion: constant.ProfileVisibilityPublic,
+			constant.ProfileFieldExt:    constant.ProfileVisibilityPrivate,
+		},
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updates["birthday"] != "" || updates["ext"] != nil {
+		t.Fatalf("expected birthday and ext cleared, got %v", updates)
+	}
+	if _, ok := updates["region"]; ok {
+		t.Fatal("expected region left unchanged")
+	}
+	var privacy map[string]string
+	if err = json.Unmarshal([]byte(updates["profile_privacy"].(string)), &privacy); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		constant.ProfileFieldBirthday: constant.ProfileVisibilityPrivate,
+		constant.ProfileFieldExt:      constant.ProfileVisibilityPrivate,
+	}
+	if len(privacy) != len(want) || privacy[constant.ProfileFieldBirthday] != want[constant.ProfileFieldBirthday] ||
+		privacy[constant.ProfileFieldExt] != want[constant.ProfileFieldExt] {
+		t.Fatalf("expected privacy %v, got %v", want, privacy)
+	}
+}
+
+func TestCheckHandleRejectsMalformedHandles(t *testing.T) {
+	s := &UserService{}
+	for _, handle := range []string{"ab", "has space", "dash-ed", "ünïcode", strings.Repeat("a", 33)} {
+		if _, err := s.checkHandle(context.Background(), "u1", handle); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%q: expected invalid param error, got %v", handle, err)
+		}
+	}
+	if handle, err := s.checkHandle(context.Background(), "u1", ""); err != nil || handle != nil {
+		t.Fatalf("expected an empty handle to clear, got %v, %v", handle, err)
+	}
+}
+
+func TestSearchUsersRejectsEmptyQuery(t *testing.T) {
+	s := &UserService{}
+	for _, q := range []string{" ", "@"} {
+		if _, err := s.SearchUsers(context.Background(), "u1", &SearchUsersQuery{Q: q}); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Errorf("%q: expected invalid param error, got %v", q, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// SetUserTagsRequest represents internal set user tags request; Tags replace the user's tags
+type SetUserTagsRequest struct {
+	UserId string   `json:"user_id" validate:"required,max=64"`
+	Tags   []string `json:"tags" validate:"max=20"` // each tag checked by normalizeUserTags
+}
+
+// UserTagsInfo is the tags of a user
+type UserTagsInfo struct {
+	UserId string   `json:"user_id"`
+	Tags   []string `json:"tags"`
+}
+
+// SetUserTags replaces the tags of a user, which broadcasts target with their cohort tag.
+// Repeated tags are set once; an empty list clears the tags.
+func (s *BroadcastService) SetUserTags(ctx context.Context, operator string, req *SetUserTagsRequest) (*UserTagsInfo, error) {
+	tags, ok := normalizeUserTags(req.Tags)
+	if req.UserId == "" || !ok {
+		return nil, errcode.ErrInvalidParam
+	}
+	user, err := s.userRepo.GetById(ctx, req.UserId)
+	if err != nil {
+		log.CtxError(ctx, "get user failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	if user == nil || user.IsDeleted() {
+		return nil, errcode.ErrUserNotFound
+	}
+	if err = s.tagRepo.SetTags(ctx, req.UserId, tags); err != nil {
+		log.CtxError(ctx, "set user tags failed: user_id=%s, error=%v", req.UserId, err)
+		return nil, errcode.ErrInternalServer
+	}
+
+	log.CtxInfo(ctx, "user tags set: operator=%s, user_id=%s, tags=%v", operator, req.UserId, tags)
+	return &UserTagsInfo{UserId: req.UserId, Tags: tags}, nil
+}
+
+// GetUserTags returns the tags of a user
+func (s *BroadcastService) GetUserTags(ctx context.Context, userId string) (*UserTagsInfo, error) {
+	if userId == "" {
+		return nil, errcode.ErrInvalidParam
+	}
+	tags, err := s.tagRepo.GetTags(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "get user tags failed: user_id=%s, error=%v", userId, err)
+		return nil, errcode.ErrInternalServer
+	}
+	return &UserTagsInfo{UserId: userId, Tags: tags}, nil
+}
+
+// normalizeUserTags sorts tags and drops repeats, reporting false when there are too many or
+// one is empty or too long
+func normalizeUserTags(tags []string) ([]string, bool) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == "" || len(tag) > maxUserTagLen {
+			return nil, false
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	if len(result) > maxUserTags {
+		return nil, false
+	}
+	slices.Sort(result)
+	return result, true
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/mbeoliero/kit/log"
+)
+
+// verifyCodeDigits is the length of registration verification codes
+const verifyCodeDigits = 6
+
+// phonePattern accepts E.164 numbers, e.g. +8613800138000
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// VerificationSender delivers verification codes to an email address or phone number.
+// Channel is constant.VerifyChannelEmail or constant.VerifyChannelPhone.
+type VerificationSender interface {
+	SendVerificationCode(ctx context.Context, channel, target, code string) error
+}
+
+// logVerificationSender only logs codes; it is the default until a real sender is set
+type logVerificationSender struct{}
+
+// NewLogVerificationSender creates a sender that logs codes instead of delivering them,
+// for local development
+func NewLogVerificationSender() VerificationSender {
+	return logVerificationSender{}
+}
+
+func (logVerificationSender) SendVerificationCode(ctx context.Context, channel, target, code string) error {
+	log.CtxInfo(ctx, "verification code (not delivered): channel=%s, target=%s, code=%s", channel, target, code)
+	return nil
+}
+
+// generateVerifyCode returns a random numeric code of verifyCodeDigits digits
+func generateVerifyCode() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < verifyCodeDigits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+	return sb.String(), nil
+}
+
+// hashVerifyCode scopes the code to the user so stored hashes are not reusable across users
+func hashVerifyCode(userId, code string) string {
+	sum := sha256.Sum256([]byte(userId + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail validates a bare email address and lowercases it
+func normalizeEmail(email string) (string, bool) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", false
+	}
+	return strings.ToLower(email), true
+}
+
+// normalizePhone validates an E.164 phone number
+func normalizePhone(phone string) (string, bool) {
+	return phone, phonePattern.MatchString(phone)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+func TestGenerateVerifyCode(t *testing.T) {
+	code, err := generateVerifyCode()
+	if err != nil {
+		t.Fatalf("generate code: %v", err)
+	}
+	if len(code) != verifyCodeDigits {
+		t.Fatalf("expected %d digits, got %q", verifyCodeDigits, code)
+	}
+	for _, ch := range code {
+		if ch < '0' || ch > '9' {
+			t.Fatalf("expected numeric code, got %q", code)
+		}
+	}
+}
+
+func TestHashVerifyCodeIsScopedToUser(t *testing.T) {
+	if hashVerifyCode("alice", "123456") == hashVerifyCode("bob", "123456") {
+		t.Fatalf("expected different hashes for different users")
+	}
+}
+
+func TestNormalizeContact(t *testing.T) {
+	if got, ok := normalizeEmail("Alice@Example.com"); !ok || got != "alice@example.com" {
+		t.Fatalf("expected lowercased email, got %q ok=%v", got, ok)
+	}
+	for _, email := range []string{"alice", "Alice <alice@example.com>", "alice@example.com "} {
+		if _, ok := normalizeEmail(email); ok {
+			t.Fatalf("expected %q to be rejected", email)
+		}
+	}
+	if _, ok := normalizePhone("+8613800138000"); !ok {
+		t.Fatalf("expected E.164 phone to be accepted")
+	}
+	for _, phone := range []string{"13800138000", "+0123456789", "+86 138 0013 8000"} {
+		if _, ok := normalizePhone(phone); ok {
+			t.Fatalf("expected %q to be rejected", phone)
+		}
+	}
+}
+
+func TestRegisterRejectsInvalidContact(t *testing.T) {
+	s := &AuthService{}
+	for _, req := range []*RegisterRequest{
+		{Email: "alice@example.com", Phone: "+8613800138000"},
+		{Email: "not-an-email"},
+		{Phone: "13800138000"},
+	} {
+		if _, err := s.Register(context.Background(), "", req); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("expected invalid param error for %+v, got %v", req, err)
+		}
+	}
+}
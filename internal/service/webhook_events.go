@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+// Sources of webhook.EventUserRegistered
+const (
+	registerSourcePublic   = "register"
+	registerSourceInternal = "internal"
+	registerSourceOAuth    = "oauth"
+	registerSourceBot      = "bot"
+	registerSourceGuest    = "guest_upgrade"
+)
+
+// UserRegisteredEvent is the data of webhook.EventUserRegistered
+type UserRegisteredEvent struct {
+	User   *entity.UserInfo `json:"user"`
+	Source string           `json:"source"` // register, internal, oauth, bot or guest_upgrade
+}
+
+// UserProfileUpdatedEvent is the data of webhook.EventUserProfileUpdated
+type UserProfileUpdatedEvent struct {
+	User          *entity.UserInfo `json:"user"`
+	ChangedFields []string         `json:"changed_fields"` // nickname, avatar, extra
+}
+
+// UserDeletedEvent is the data of webhook.EventUserDeleted
+type UserDeletedEvent struct {
+	UserId   string `json:"user_id"`
+	Mode     string `json:"mode"` // tombstone or hard
+	Operator string `json:"operator"`
+}
+
+// GroupEvent is the data of the group.* webhook events. Group is a snapshot taken after the
+// change; UserIds lists the members who joined, left or were kicked.
+type GroupEvent struct {
+	OperatorId string            `json:"operator_id"`
+	Group      *entity.GroupInfo `json:"group"`
+	UserIds    []string          `json:"user_ids,omitempty"`
+	InviterId  string            `json:"inviter_id,omitempty"`   // group.member_joined
+	NewOwnerId string            `json:"new_owner_id,omitempty"` // group.ownership_transferred
+}
+
+// emitGroupEvent fills in the group snapshot and emits a group.* event
+func (s *GroupService) emitGroupEvent(ctx context.Context, eventType, groupId string, event *GroupEvent) {
+	if !webhook.Enabled() {
+		return
+	}
+	group, err := s.GetGroupInfo(ctx, groupId)
+	if err != nil {
+		log.CtxWarn(ctx, "get group snapshot for webhook failed: group_id=%s, type=%s, error=%v", groupId, eventType, err)
+		return
+	}
+	event.Group = group
+	webhook.Emit(ctx, eventType, event)
+}
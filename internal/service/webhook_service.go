@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/internal/repository"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/metrics"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+// Webhook delivery results
+const (
+	WebhookResultSuccess = "success"
+	WebhookResultFailure = "failure"
+	webhookResultDropped = "dropped" // metrics only, dropped deliveries are not logged
+)
+
+const (
+	// maxWebhookErrorLen matches webhook_deliveries.error
+	maxWebhookErrorLen = 512
+	// maxWebhookResponseDrain bounds how much of a response body is read to reuse the connection
+	maxWebhookResponseDrain = 64 << 10
+)
+
+// webhookJob is the delivery of one event to one endpoint
+type webhookJob struct {
+	endpoint *config.WebhookEndpoint
+	event    *webhook.Event
+	body     []byte
+}
+
+// WebhookService is the webhook.Dispatcher posting events to the configured endpoints.
+// Deliveries are queued without blocking the caller and sent by the workers started by Run,
+// retried with exponential backoff; the final outcome of each is logged.
+type WebhookService struct {
+	repo         *repository.WebhookDeliveryRepo
+	endpoints    []config.WebhookEndpoint
+	serviceName  string
+	client       *http.Client
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	workers      int
+	jobs         chan *webhookJob
+	done         chan struct{} // closed when all workers exit
+}
+
+// NewWebhookService creates a new WebhookService
+func NewWebhookService(repos *repository.Repositories, cfg *config.Config) *WebhookService {
+	return &WebhookService{
+		repo:         repos.Webhook,
+		endpoints:    cfg.Webhook.Endpoints,
+		serviceName:  cfg.Webhook.ServiceName,
+		client:       &http.Client{Timeout: cfg.Webhook.Timeout},
+		maxAttempts:  cfg.Webhook.MaxAttempts,
+		retryBackoff: cfg.Webhook.RetryBackoff,
+		maxBackoff:   cfg.Webhook.MaxBackoff,
+		workers:      cfg.Webhook.Workers,
+		jobs:         make(chan *webhookJob, cfg.Webhook.QueueSize),
+	}
+}
+
+// Dispatch queues a delivery of event to every endpoint whose filters match; deliveries are
+// dropped when the queue is full
+func (s *WebhookService) Dispatch(ctx context.Context, event *webhook.Event) {
+	var body []byte
+	for i := range s.endpoints {
+		endpoint := &s.endpoints[i]
+		if !webhook.Matches(endpoint.Events, event.Type) {
+			continue
+		}
+		if body == nil {
+			if body = marshalWebhookEvent(ctx, event); body == nil {
+				return
+			}
+		}
+		s.enqueue(ctx, &webhookJob{endpoint: endpoint, event: event, body: body})
+	}
+}
+
+// DispatchTo queues a delivery of event to endpoint, which need not be one of the configured
+// webhook endpoints, regardless of its filters
+func (s *WebhookService) DispatchTo(ctx context.Context, endpoint *config.WebhookEndpoint, event *webhook.Event) {
+	if body := marshalWebhookEvent(ctx, event); body != nil {
+		s.enqueue(ctx, &webhookJob{endpoint: endpoint, event: event, body: body})
+	}
+}
+
+func marshalWebhookEvent(ctx context.Context, event *webhook.Event) []byte {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.CtxError(ctx, "marshal webhook event failed: type=%s, event_id=%s, error=%v", event.Type, event.Id, err)
+		return nil
+	}
+	return body
+}
+
+// enqueue queues job without blocking, dropping it when the queue is full
+func (s *WebhookService) enqueue(ctx context.Context, job *webhookJob) {
+	select {
+	case s.jobs <- job:
+	default:
+		metrics.WebhookDeliveriesTotal.WithLabelValues(job.endpoint.Name, webhookResultDropped).Inc()
+		log.CtxWarn(ctx, "webhook queue full, delivery dropped: endpoint=%s, type=%s, event_id=%s",
+			job.endpoint.Name, job.event.Type, job.event.Id)
+	}
+}
+
+// Run starts the delivery workers. Once ctx is done, retries stop and each queued delivery
+// gets a single attempt before the workers exit.
+func (s *WebhookService) Run(ctx context.Context) {
+	s.done = make(chan struct{})
+	var wg sync.WaitGroup
+	for range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					for {
+						select {
+						case job := <-s.jobs:
+							s.saveDelivery(s.deliver(context.Background(), job, 1))
+						default:
+							return
+						}
+					}
+				case job := <-s.jobs:
+					s.saveDelivery(s.deliver(ctx, job, s.maxAttempts))
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.done)
+	}()
+	log.CtxInfo(ctx, "webhook dispatcher started: endpoints=%d, workers=%d, max_attempts=%d",
+		len(s.endpoints), s.workers, s.maxAttempts)
+}
+
+// Wait blocks until the workers have exited after their Run ctx is done, or until ctx is done
+func (s *WebhookService) Wait(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver posts job up to maxAttempts times, waiting between attempts until ctx is done,
+// and returns the outcome
+func (s *WebhookService) deliver(ctx context.Context, job *webhookJob, maxAttempts int) *entity.WebhookDelivery {
+	delivery := &entity.WebhookDelivery{
+		EventId:   job.event.Id,
+		EventType: job.event.Type,
+		Endpoint:  job.endpoint.Name,
+		Result:    WebhookResultFailure,
+		TraceId:   job.event.TraceId,
+		CreatedAt: job.event.CreatedAt,
+	}
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		delivery.Attempts = attempt
+		statusCode, err := s.post(ctx, job, attempt)
+		delivery.StatusCode = statusCode
+		if err == nil {
+			delivery.Result = WebhookResultSuccess
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = truncateRunes(err.Error(), maxWebhookErrorLen)
+		if attempt == maxAttempts {
+			break
+		}
+		timer := time.NewTimer(s.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			attempt = maxAttempts // shutting down, keep the last error
+		case <-timer.C:
+		}
+	}
+	delivery.FinishedAt = time.Now().UnixMilli()
+	return delivery
+}
+
+// post sends one signed attempt of job, returning the response status
+func (s *WebhookService) post(ctx context.Context, job *webhookJob, attempt int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.endpoint.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.EventHeader, job.event.Type)
+	req.Header.Set(webhook.IdHeader, job.event.Id)
+	req.Header.Set(webhook.AttemptHeader, strconv.Itoa(attempt))
+	req.Header.Set(signature.ServiceNameHeader, s.serviceName)
+	req.Header.Set(signature.TimestampHeader, ts)
+	req.Header.Set(signature.SignatureHeader,
+		signature.Sign(job.endpoint.Secret, s.serviceName, ts, http.MethodPost, req.URL.Path, job.body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseDrain))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait after the given failed attempt: retryBackoff doubled per attempt,
+// capped at maxBackoff
+func (s *WebhookService) backoff(attempt int) time.Duration {
+	d := s.retryBackoff
+	for i := 1; i < attempt && d < s.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.maxBackoff)
+}
+
+// saveDelivery logs the outcome of a delivery
+func (s *WebhookService) saveDelivery(delivery *entity.WebhookDelivery) {
+	ctx := context.Background()
+	metrics.WebhookDeliveriesTotal.WithLabelValues(delivery.Endpoint, delivery.Result).Inc()
+	if delivery.Result != WebhookResultSuccess {
+		log.CtxWarn(ctx, "webhook delivery failed: endpoint=%s, type=%s, event_id=%s, attempts=%d, error=%s",
+			delivery.Endpoint, delivery.EventType, delivery.EventId, delivery.Attempts, delivery.Error)
+	}
+	if err := s.repo.Create(ctx, delivery); err != nil {
+		log.CtxError(ctx, "save webhook delivery failed: endpoint=%s, event_id=%s, error=%v",
+			delivery.Endpoint, delivery.EventId, err)
+	}
+}
+
+// ListWebhookDeliveriesRequest represents webhook delivery log query request
+type ListWebhookDeliveriesRequest struct {
+	Endpoint  string `json:"endpoint" query:"endpoint" validate:"max=64"`
+	EventType string `json:"event_type" query:"event_type" validate:"max=64"`
+	EventId   string `json:"event_id" query:"event_id" validate:"max=64"`
+	Result    string `json:"result" query:"result" validate:"max=16"`
+	StartTime int64  `json:"start_time" query:"start_time" validate:"min=0"` // ms, inclusive
+	EndTime   int64  `json:"end_time" query:"end_time" validate:"min=0"`     // ms, exclusive
+	Offset    int    `json:"offset" query:"offset" validate:"min=0"`
+	Limit     int    `json:"limit" query:"limit" validate:"min=0,max=100"`
+}
+
+// ListDeliveries lists webhook deliveries, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, req *ListWebhookDeliveriesRequest) ([]*entity.WebhookDelivery, error) {
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > maxAdminSearchLimit {
+		return nil, errcode.ErrInvalidParam
+	}
+	if req.StartTime < 0 || req.EndTime < 0 || (req.EndTime > 0 && req.EndTime <= req.StartTime) {
+		return nil, errcode.ErrInvalidParam
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAdminSearchLimit
+	}
+
+	deliveries, err := s.repo.List(ctx, &repository.WebhookDeliveryFilter{
+		Endpoint:  req.Endpoint,
+		EventType: req.EventType,
+		EventId:   req.EventId,
+		Result:    req.Result,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}, req.Offset, limit)
+	if err != nil {
+		log.CtxError(ctx, "list webhook deliveries failed: error=%v", err)
+		return nil, errcode.ErrInternalServer
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/signature"
+	"github.com/ZaiSpace/nexo_im/pkg/webhook"
+)
+
+func TestWebhookDeliverRetriesAndSigns(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !signature.Verify("s3cret", r.Header.Get(signature.ServiceNameHeader), r.Header.Get(signature.TimestampHeader),
+			r.Method, r.URL.Path, body, r.Header.Get(signature.SignatureHeader)) {
+			t.Errorf("invalid signature on attempt %s", r.Header.Get(webhook.AttemptHeader))
+		}
+		if r.Header.Get(webhook.IdHeader) != "evt-1" || r.Header.Get(webhook.EventHeader) != "group.created" {
+			t.Errorf("unexpected webhook headers: %v", r.Header)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	s := &WebhookService{
+		serviceName:  "nexo_im",
+		client:       srv.Client(),
+		retryBackoff: time.Millisecond,
+		maxBackoff:   time.Millisecond,
+	}
+	job := &webhookJob{
+		endpoint: &config.WebhookEndpoint{Name: "crm", URL: srv.URL + "/hooks/im", Secret: "s3cret"},
+		event:    &webhook.Event{Id: "evt-1", Type: "group.created", CreatedAt: 1700000000000},
+		body:     []byte(`{"id":"evt-1"}`),
+	}
+
+	delivery := s.deliver(context.Background(), job, 5)
+	if delivery.Result != WebhookResultSuccess || delivery.Attempts != 3 || delivery.StatusCode != http.StatusOK {
+		t.Fatalf("expected success on third attempt, got %+v", delivery)
+	}
+
+	calls.Store(-10)
+	delivery = s.deliver(context.Background(), job, 2)
+	if delivery.Result != WebhookResultFailure || delivery.Attempts != 2 || delivery.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected failure after two attempts, got %+v", delivery)
+	}
+	if delivery.Error == "" {
+		t.Fatalf("expected error of last attempt recorded")
+	}
+}
+
+func TestWebhookBackoffDoublesUpToMax(t *testing.T) {
+	s := &WebhookService{retryBackoff: time.Second, maxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := s.backoff(i + 1); got != w {
+			t.Fatalf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestWebhookDispatchFiltersAndDrops(t *testing.T) {
+	s := &WebhookService{
+		endpoints: []config.WebhookEndpoint{
+			{Name: "groups", Events: []string{"group.*"}},
+			{Name: "users", Events: []string{"user.registered"}},
+			{Name: "all"},
+		},
+		jobs: make(chan *webhookJob, 1),
+	}
+
+	s.Dispatch(context.Background(), &webhook.Event{Id: "evt-1", Type: "user.registered"})
+
+	if len(s.jobs) != 1 {
+		t.Fatalf("expected 1 queued delivery, got %d", len(s.jobs))
+	}
+	if job := <-s.jobs; job.endpoint.Name != "users" || len(job.body) == 0 {
+		t.Fatalf("unexpected job: endpoint=%s, body=%s", job.endpoint.Name, job.body)
+	}
+}
+
+func TestWebhookListDeliveriesRejectsInvalidRange(t *testing.T) {
+	s := &WebhookService{}
+	_, err := s.ListDeliveries(context.Background(), &ListWebhookDeliveriesRequest{StartTime: 200, EndTime: 100})
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Fatalf("expected invalid param error, got %v", err)
+	}
+}
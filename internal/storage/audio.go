@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrUnsupportedAudio is returned for audio whose duration AudioDuration cannot read
+var ErrUnsupportedAudio = errors.New("unsupported audio format")
+
+// opusSampleRate is the rate of Ogg Opus granule positions, whatever the input rate
+const opusSampleRate = 48000
+
+// AudioDuration reads the duration of WAV, Ogg (Opus or Vorbis) and MP4 (M4A) audio from its
+// headers, recognizing the format by its magic bytes
+func AudioDuration(data []byte) (time.Duration, error) {
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return wavDuration(data)
+	case len(data) >= 4 && string(data[:4]) == "OggS":
+		return oggDuration(data)
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		return mp4Duration(data)
+	default:
+		return 0, ErrUnsupportedAudio
+	}
+}
+
+// wavDuration divides the size of the data chunk by the byte rate of the fmt chunk
+func wavDuration(data []byte) (time.Duration, error) {
+	var byteRate uint32
+	for i := 12; i+8 <= len(data); {
+		id := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		body := data[i+8:]
+		switch id {
+		case "fmt ":
+			if size < 12 || len(body) < 12 {
+				return 0, ErrUnsupportedAudio
+			}
+			byteRate = binary.LittleEndian.Uint32(body[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, ErrUnsupportedAudio
+			}
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second)), nil
+		}
+		// Chunks are padded to an even size
+		i += 8 + size + size%2
+	}
+	return 0, ErrUnsupportedAudio
+}
+
+// oggDuration divides the granule position of the last page by the sample rate of the
+// identification header in the first page
+func oggDuration(data []byte) (time.Duration, error) {
+	if len(data) < 27 || len(data) < 27+int(data[26]) {
+		return 0, ErrUnsupportedAudio
+	}
+	// The first packet follows the segment table of the first page
+	packet := data[27+int(data[26]):]
+	var rate, preSkip int64
+	switch {
+	case len(packet) >= 12 && string(packet[:8]) == "OpusHead":
+		rate, preSkip = opusSampleRate, int64(binary.LittleEndian.Uint16(packet[10:12]))
+	case len(packet) >= 16 && string(packet[:7]) == "\x01vorbis":
+		rate = int64(binary.LittleEndian.Uint32(packet[12:16]))
+	default:
+		return 0, ErrUnsupportedAudio
+	}
+	if rate == 0 {
+		return 0, ErrUnsupportedAudio
+	}
+	// The capture pattern may also occur in audio data: take the last page header with the
+	// stream version 0 and a granule position, which is -1 on pages ending no packet
+	for end := len(data); end > 0; {
+		last := bytes.LastIndex(data[:end], []byte("OggS"))
+		if last < 0 {
+			break
+		}
+		end = last
+		if last+27 > len(data) || data[last+4] != 0 {
+			continue
+		}
+		granule := int64(binary.LittleEndian.Uint64(data[last+6 : last+14]))
+		if granule < 0 {
+			continue
+		}
+		if granule < preSkip {
+			return 0, ErrUnsupportedAudio
+		}
+		return time.Duration(float64(granule-preSkip) / float64(rate) * float64(time.Second)), nil
+	}
+	return 0, ErrUnsupportedAudio
+}
+
+// mp4Duration reads the duration and timescale of the movie header box moov/mvhd
+func mp4Duration(data []byte) (time.Duration, error) {
+	moov := mp4Box(data, "moov")
+	if moov == nil {
+		return 0, ErrUnsupportedAudio
+	}
+	mvhd := mp4Box(moov, "mvhd")
+	if len(mvhd) < 4 {
+		return 0, ErrUnsupportedAudio
+	}
+	var timescale uint32
+	var duration uint64
+	if mvhd[0] == 1 {
+		// version, flags, 64-bit creation and modification times
+		if len(mvhd) < 32 {
+			return 0, ErrUnsupportedAudio
+		}
+		timescale, duration = binary.BigEndian.Uint32(mvhd[20:24]), binary.BigEndian.Uint64(mvhd[24:32])
+	} else {
+		if len(mvhd) < 20 {
+			return 0, ErrUnsupportedAudio
+		}
+		timescale, duration = binary.BigEndian.Uint32(mvhd[12:16]), uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	}
+	if timescale == 0 {
+		return 0, ErrUnsupportedAudio
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// mp4Box returns the body of the first box of a type among sibling boxes, nil if there is none
+func mp4Box(data []byte, boxType string) []byte {
+	for i := 0; i+8 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[i : i+4]))
+		header := 8
+		switch size {
+		case 0:
+			// Extends to the end
+			size = len(data) - i
+		case 1:
+			if i+16 > len(data) {
+				return nil
+			}
+			size, header = int(binary.BigEndian.Uint64(data[i+8:i+16])), 16
+		}
+		if size < header || size > len(data)-i {
+			return nil
+		}
+		if string(data[i+4:i+8]) == boxType {
+			return data[i+header : i+size]
+		}
+		i += size
+	}
+	return nil
+}
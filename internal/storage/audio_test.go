@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// testWAV returns a 16-bit mono WAV of 8kHz holding the given duration of silence, with a
+// LIST chunk before the data
+func testWAV(duration time.Duration) []byte {
+	const byteRate = 8000 * 2
+	dataSize := int(duration.Seconds() * byteRate)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+24+14+8+dataSize))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, struct {
+		Size                      uint32
+		Format, Channels          uint16
+		SampleRate, ByteRate      uint32
+		BlockAlign, BitsPerSample uint16
+	}{16, 1, 1, 8000, byteRate, 2, 16})
+	// Odd-sized, so padded
+	b.WriteString("LIST")
+	binary.Write(&b, binary.LittleEndian, uint32(5))
+	b.WriteString("INFOx\x00")
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(dataSize))
+	b.Write(make([]byte, dataSize))
+	return b.Bytes()
+}
+
+// oggPage returns an Ogg page holding one packet
+func oggPage(granule int64, packet []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("OggS\x00\x00")
+	binary.Write(&b, binary.LittleEndian, granule)
+	b.Write(make([]byte, 12)) // serial number, sequence number and checksum
+	b.WriteByte(1)
+	b.WriteByte(byte(len(packet)))
+	b.Write(packet)
+	return b.Bytes()
+}
+
+// testOpus returns an Ogg Opus stream whose last page ends at granule
+func testOpus(preSkip uint16, granule int64) []byte {
+	head := []byte("OpusHead\x01\x01")
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 16000) // input rate, not the granule rate
+	head = append(head, 0, 0, 0)
+	data := oggPage(0, head)
+	data = append(data, oggPage(0, []byte("OpusTags"))...)
+	data = append(data, oggPage(granule/2, []byte("audio"))...)
+	// The capture pattern within audio data is not a page
+	return append(data, oggPage(granule, []byte("audio OggS\x00\x04"))...)
+}
+
+// mp4Atom returns a box of a type holding body
+func mp4Atom(boxType string, body ...[]byte) []byte {
+	data := bytes.Join(body, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(b, boxType...), data...)
+}
+
+func TestAudioDuration(t *testing.T) {
+	mvhd0 := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd0[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd0[16:], 2500)
+	mvhd1 := make([]byte, 112)
+	mvhd1[0] = 1
+	binary.BigEndian.PutUint32(mvhd1[20:], 44100)
+	binary.BigEndian.PutUint64(mvhd1[24:], 44100*3)
+	ftyp := mp4Atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+
+	for name, c := range map[string]struct {
+		data []byte
+		want time.Duration
+	}{
+		"wav":    {testWAV(1500 * time.Millisecond), 1500 * time.Millisecond},
+		"opus":   {testOpus(312, 312+48000*2), 2 * time.Second},
+		"mp4 v0": {append(ftyp, mp4Atom("moov", mp4Atom("mvhd", mvhd0))...), 2500 * time.Millisecond},
+		"mp4 v1": {append(append(ftyp, mp4Atom("free")...), mp4Atom("moov", mp4Atom("trak"), mp4Atom("mvhd", mvhd1))...), 3 * time.Second},
+		"vorbis": {append(oggPage(0, []byte("\x01vorbis\x00\x00\x00\x00\x01\x22\x56\x00\x00")), oggPage(22050*4, []byte("audio"))...), 4 * time.Second},
+	} {
+		got, err := AudioDuration(c.data)
+		if err != nil || got != c.want {
+			t.Errorf("%s: got %v, err=%v, want %v", name, got, err, c.want)
+		}
+	}
+}
+
+func TestAudioDurationRejectsMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":        nil,
+		"mp3":          []byte("ID3\x04\x00\x00\x00\x00\x00\x00"),
+		"wav no data":  testWAV(time.Second)[:44],
+		"opus no page": oggPage(0, []byte("OpusHead\x01\x01\x38\x01\x80\x3e\x00\x00\x00\x00\x00"))[:20],
+		"mp4 no moov":  mp4Atom("ftyp", []byte("M4A ")),
+		"mp4 overflow": append(mp4Atom("ftyp", []byte("M4A ")), 0xff, 0xff, 0xff, 0xff, 'm', 'o', 'o', 'v'),
+	} {
+		if _, err := AudioDuration(data); err != ErrUnsupportedAudio {
+			t.Errorf("%s: expected ErrUnsupportedAudio, got %v", name, err)
+		}
+	}
+}
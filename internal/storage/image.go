@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder
+)
+
+// thumbnailQuality is the JPEG quality of thumbnails
+const thumbnailQuality = 80
+
+// ErrImageTooLarge is returned for an image with more pixels than ProcessImage accepts
+var ErrImageTooLarge = errors.New("image too large")
+
+// maxImagePixels bounds the decoded size of an image, against decompression bombs
+const maxImagePixels = 50_000_000
+
+// ProcessedImage is the metadata and thumbnail of an image
+type ProcessedImage struct {
+	Width     int    // as displayed, after the EXIF orientation
+	Height    int    // as displayed, after the EXIF orientation
+	Thumbnail []byte // JPEG fitting in the thumbnail box, without metadata
+	Stripped  []byte // the JPEG without its EXIF and XMP segments, nil when it had none
+}
+
+// ProcessImage reads the dimensions and EXIF orientation of a JPEG, PNG or GIF image and
+// renders a thumbnail whose longest side is at most maxSide, upright
+func ProcessImage(data []byte, maxSide int) (*ProcessedImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProcessedImage{}
+	orientation := 1
+	if format == "jpeg" {
+		var stripped bool
+		var segments []byte
+		orientation, segments, stripped = parseJPEGMetadata(data)
+		if stripped {
+			result.Stripped = segments
+		}
+	}
+	thumb := orient(resize(img, maxSide), orientation)
+	result.Width, result.Height = cfg.Width, cfg.Height
+	if orientation >= 5 {
+		// Rotated by 90 degrees
+		result.Width, result.Height = cfg.Height, cfg.Width
+	}
+
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	result.Thumbnail = buf.Bytes()
+	return result, nil
+}
+
+// parseJPEGMetadata walks the segments of a JPEG up to the image data, returning the EXIF
+// orientation (1 when unknown) and the JPEG without its APP1 segments, holding EXIF and XMP,
+// with whether any was removed
+func parseJPEGMetadata(data []byte) (int, []byte, bool) {
+	orientation := 1
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return orientation, nil, false
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	removed := false
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		if marker == 0xDA {
+			// Start of scan: the rest is image data
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return orientation, nil, false
+		}
+		if marker == 0xE1 {
+			if o := exifOrientation(data[i+4 : end]); o != 0 {
+				orientation = o
+			}
+			removed = true
+		} else {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	if !removed {
+		return orientation, nil, false
+	}
+	return orientation, append(out, data[i:]...), true
+}
+
+// exifOrientation returns the orientation tag of an APP1 EXIF payload, 0 if there is none
+func exifOrientation(app1 []byte) int {
+	if len(app1) < 14 || string(app1[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := app1[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8 : entry+10])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// resize scales img down with a box filter so that its longest side is at most maxSide
+func resize(img image.Image, maxSide int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w >= h && w > maxSide {
+		tw, th = maxSide, max(1, h*maxSide/w)
+	} else if h > w && h > maxSide {
+		tw, th = max(1, w*maxSide/h), maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// orient applies an EXIF orientation, returning the image as it is meant to be displayed
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // mirrored along the top-left diagonal
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // mirrored along the top-right diagonal
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counterclockwise
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Left half red, right half blue
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF APP1 segment holding orientation after the SOI marker
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	// tag, type SHORT, count 1, value padded to 4 bytes
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3, 0, 1, orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessImageScalesThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(640, 320)); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ProcessImage(buf.Bytes(), 320)
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if result.Width != 640 || result.Height != 320 || result.Stripped != nil {
+		t.Fatalf("unexpected result %dx%d, stripped=%v", result.Width, result.Height, result.Stripped != nil)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(result.Thumbnail))
+	if err != nil {
+		t.Fatalf("decode thumbnail failed: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 320 || b.Dy() != 160 {
+		t.Fatalf("unexpected thumbnail size %v", b)
+	}
+}
+
+func TestProcessImageAppliesAndStripsEXIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(200, 100), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := withOrientation(buf.Bytes(), 6)
+
+	result, err := ProcessImage(data, 320)
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if result.Width != 100 || result.Height != 200 {
+		t.Fatalf("expected rotated dimensions, got %dx%d", result.Width, result.Height)
+	}
+	if !bytes.Equal(result.Stripped, buf.Bytes()) {
+		t.Fatal("expected the EXIF segment to be removed")
+	}
+
+	thumb, err := jpeg.Decode(bytes.NewReader(result.Thumbnail))
+	if err != nil {
+		t.Fatalf("decode thumbnail failed: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 100 || b.Dy() != 200 {
+		t.Fatalf("unexpected thumbnail size %v", b)
+	}
+	// Rotated clockwise, the red left half is now on top
+	if r, _, b, _ := thumb.At(50, 20).RGBA(); r < b {
+		t.Fatal("expected red at the top of the thumbnail")
+	}
+	if r, _, b, _ := thumb.At(50, 180).RGBA(); b < r {
+		t.Fatal("expected blue at the bottom of the thumbnail")
+	}
+}
+
+func TestProcessImageRejectsGarbage(t *testing.T) {
+	if _, err := ProcessImage([]byte("not an image"), 320); err == nil {
+		t.Fatal("expected an error")
+	}
+}
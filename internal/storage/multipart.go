@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrIncompleteUpload is returned by CompleteMultipartUpload when the parts given do not match
+// the uploaded ones, or the multipart upload no longer exists
+var ErrIncompleteUpload = errors.New("multipart upload incomplete")
+
+// maxMultipartResponse bounds the XML responses of the multipart requests read
+const maxMultipartResponse = 1 << 20
+
+// CompletedPart is an uploaded part of a multipart upload, with the ETag its upload returned
+type CompletedPart struct {
+	PartNumber int    `xml:"PartNumber" json:"part_number"`
+	ETag       string `xml:"ETag" json:"etag"`
+}
+
+// CreateMultipartUpload starts a multipart upload of an object of contentType to key,
+// returning its upload id. Both S3 and OSS speak the same XML here.
+func (c *Client) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	resp, err := c.send(ctx, http.MethodPost, key, contentType, map[string]string{"uploads": ""}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		UploadId string `xml:"UploadId"`
+	}
+	if err = xml.NewDecoder(io.LimitReader(resp.Body, maxMultipartResponse)).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.UploadId == "" {
+		return "", errors.New("missing upload id")
+	}
+	return result.UploadId, nil
+}
+
+// PresignUploadPart returns a request uploading part partNumber (from 1) of a multipart upload,
+// valid for ttl. Its response carries the ETag of the part in a header.
+func (c *Client) PresignUploadPart(key, uploadId string, partNumber int, ttl time.Duration) *PresignedRequest {
+	query := map[string]string{"partNumber": strconv.Itoa(partNumber), "uploadId": uploadId}
+	return &PresignedRequest{
+		Method: http.MethodPut,
+		URL:    c.signer.presign(http.MethodPut, key, "", query, c.now(), ttl),
+	}
+}
+
+// CompleteMultipartUpload assembles the object from its parts, listed in ascending order
+func (c *Client) CompleteMultipartUpload(ctx context.Context, key, uploadId string, parts []CompletedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := c.sendMultipart(ctx, http.MethodPost, key, uploadId, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 may fail the assembly after the 200 was sent, reporting it in the body
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMultipartResponse))
+	if err != nil {
+		return err
+	}
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+	}
+	if err = xml.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("complete multipart upload failed: %s", result.Code)
+	}
+	return nil
+}
+
+// AbortMultipartUpload drops a multipart upload and its parts; aborting a missing upload succeeds
+func (c *Client) AbortMultipartUpload(ctx context.Context, key, uploadId string) error {
+	resp, err := c.sendMultipart(ctx, http.MethodDelete, key, uploadId, nil)
+	if errors.Is(err, ErrIncompleteUpload) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// sendMultipart sends a presigned request about a multipart upload, returning the response of
+// a success with its body open. Unlike send it reports a request the storage rejected for the
+// parts or the upload id as ErrIncompleteUpload.
+func (c *Client) sendMultipart(ctx context.Context, method, key, uploadId string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	presigned := c.signer.presign(method, key, "", map[string]string{"uploadId": uploadId}, c.now(), time.Minute)
+	req, err := http.NewRequestWithContext(ctx, method, presigned, reader)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	resp.Body.Close()
+	// 400 for missing or mismatched parts, 404 for an unknown or finished upload
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		return nil, ErrIncompleteUpload
+	}
+	return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+func TestOSSPresignSubresources(t *testing.T) {
+	endpoint, _ := url.Parse("https://oss-cn-hangzhou.aliyuncs.com")
+	s := &ossSigner{endpoint: endpoint, bucket: "media", keyId: "ak", secret: "sk"}
+	now := time.Unix(1700000000, 0)
+
+	got, err := url.Parse(s.presign(http.MethodPut, "uploads/a.mp4", "", map[string]string{"uploadId": "u1", "partNumber": "2"}, now, time.Minute))
+	if err != nil {
+		t.Fatalf("invalid url: %v", err)
+	}
+	mac := hmac.New(sha1.New, []byte("sk"))
+	mac.Write([]byte("PUT\n\n\n1700000060\n/media/uploads/a.mp4?partNumber=2&uploadId=u1"))
+	q := got.Query()
+	if q.Get("partNumber") != "2" || q.Get("uploadId") != "u1" ||
+		q.Get("Signature") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("unexpected query %v", q)
+	}
+
+	got, _ = url.Parse(s.presign(http.MethodPost, "uploads/a.mp4", "video/mp4", map[string]string{"uploads": ""}, now, time.Minute))
+	if !strings.HasPrefix(got.RawQuery, "uploads&OSSAccessKeyId=") {
+		t.Fatalf("unexpected query %s", got.RawQuery)
+	}
+}
+
+func TestClientMultipartUpload(t *testing.T) {
+	var completed string
+	var aborted bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("X-Amz-Signature") == "" || r.URL.Path != "/media/uploads/a.mp4" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		switch {
+		case r.Method == http.MethodPost && q.Has("uploads"):
+			if r.Header.Get("Content-Type") != "video/mp4" {
+				t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+			}
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><InitiateMultipartUploadResult>`+
+				`<Bucket>media</Bucket><Key>uploads/a.mp4</Key><UploadId>u1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPost && q.Get("uploadId") == "u1":
+			data, _ := io.ReadAll(r.Body)
+			completed = string(data)
+			io.WriteString(w, `<CompleteMultipartUploadResult><Key>uploads/a.mp4</Key></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodPost && q.Get("uploadId") == "failed":
+			io.WriteString(w, `<Error><Code>InternalError</Code></Error>`)
+		case r.Method == http.MethodDelete && q.Get("uploadId") == "u1":
+			aborted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(&config.StorageConfig{Driver: config.StorageDriverMinIO, Endpoint: srv.URL, Region: "us-east-1",
+		Bucket: "media", AccessKeyId: "ak", AccessKeySecret: "sk", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("new client failed: %v", err)
+	}
+	ctx := context.Background()
+
+	uploadId, err := c.CreateMultipartUpload(ctx, "uploads/a.mp4", "video/mp4")
+	if err != nil || uploadId != "u1" {
+		t.Fatalf("unexpected upload id %q, err=%v", uploadId, err)
+	}
+	part := c.PresignUploadPart("uploads/a.mp4", uploadId, 2, time.Minute)
+	if part.Method != http.MethodPut || !strings.Contains(part.URL, "partNumber=2&") || !strings.Contains(part.URL, "uploadId=u1&") {
+		t.Fatalf("unexpected presigned part %+v", part)
+	}
+
+	parts := []CompletedPart{{PartNumber: 1, ETag: `"e1"`}, {PartNumber: 2, ETag: `"e2"`}}
+	if err = c.CompleteMultipartUpload(ctx, "uploads/a.mp4", uploadId, parts); err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	want := `<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>&#34;e1&#34;</ETag></Part>` +
+		`<Part><PartNumber>2</PartNumber><ETag>&#34;e2&#34;</ETag></Part></CompleteMultipartUpload>`
+	if completed != want {
+		t.Fatalf("unexpected complete body %s", completed)
+	}
+	if err = c.CompleteMultipartUpload(ctx, "uploads/a.mp4", "failed", parts); err == nil {
+		t.Fatal("expected an error reported in the body to fail")
+	}
+	if err = c.CompleteMultipartUpload(ctx, "uploads/a.mp4", "missing", parts); err != ErrIncompleteUpload {
+		t.Fatalf("expected incomplete upload, got %v", err)
+	}
+
+	if err = c.AbortMultipartUpload(ctx, "uploads/a.mp4", uploadId); err != nil || !aborted {
+		t.Fatalf("abort failed: %v", err)
+	}
+	if err = c.AbortMultipartUpload(ctx, "uploads/a.mp4", "missing"); err != nil {
+		t.Fatalf("expected aborting a missing upload to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ossSigner presigns OSS requests with the query string signature of OSS, which signs the
+// verb, content type, expiry and resource with HMAC-SHA1
+type ossSigner struct {
+	endpoint *url.URL
+	bucket   string
+	keyId    string
+	secret   string
+}
+
+func (s *ossSigner) objectURL(key string) string {
+	return s.endpoint.Scheme + "://" + s.bucket + "." + s.endpoint.Host + "/" + escapePath(key)
+}
+
+func (s *ossSigner) presign(method, key, contentType string, query map[string]string, now time.Time, ttl time.Duration) string {
+	expires := strconv.FormatInt(now.Add(ttl).Unix(), 10)
+	// The subresources are signed with the resource, sorted by name and not escaped
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var resource, subresources strings.Builder
+	for i, name := range names {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		resource.WriteString(sep + name)
+		subresources.WriteString(uriEncode(name))
+		if query[name] != "" {
+			resource.WriteString("=" + query[name])
+			subresources.WriteString("=" + uriEncode(query[name]))
+		}
+		subresources.WriteString("&")
+	}
+
+	// VERB, Content-MD5, Content-Type, Expires, then the resource
+	stringToSign := method + "\n\n" + contentType + "\n" + expires + "\n/" + s.bucket + "/" + key + resource.String()
+	mac := hmac.New(sha1.New, []byte(s.secret))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return s.objectURL(key) + "?" + subresources.String() + "OSSAccessKeyId=" + uriEncode(s.keyId) + "&Expires=" + expires +
+		"&Signature=" + uriEncode(signature)
+}
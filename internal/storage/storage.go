@@ -0,0 +1,217 @@
+// Package storage signs requests to the object storage holding uploaded media: S3 and MinIO
+// with AWS Signature Version 4, Alibaba Cloud OSS with its query string signature. Clients
+// upload with presigned URLs; the server only checks and removes the objects.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+// ErrNotFound is returned for an object that does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ErrTooLarge is returned by Get for an object larger than the limit
+var ErrTooLarge = errors.New("object too large")
+
+// PresignedRequest is a request the client sends as is, without credentials
+type PresignedRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // signed, to be sent unchanged
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// signer presigns requests to the objects of a bucket
+type signer interface {
+	// presign returns the URL of a request valid for ttl from now; contentType, when set,
+	// is signed and must be sent in the Content-Type header. query holds the subresources of
+	// the request, such as the part of a multipart upload, an empty value for a bare name.
+	presign(method, key, contentType string, query map[string]string, now time.Time, ttl time.Duration) string
+	// objectURL returns the unsigned URL of an object
+	objectURL(key string) string
+}
+
+// Client accesses the objects of the configured bucket
+type Client struct {
+	signer    signer
+	publicURL string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// New creates a Client for the driver of cfg
+func New(cfg *config.StorageConfig) (*Client, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var s signer
+	switch cfg.Driver {
+	case config.StorageDriverS3, config.StorageDriverMinIO:
+		s = &sigV4Signer{
+			endpoint:  endpoint,
+			bucket:    cfg.Bucket,
+			region:    cfg.Region,
+			keyId:     cfg.AccessKeyId,
+			secret:    cfg.AccessKeySecret,
+			pathStyle: cfg.PathStyle || cfg.Driver == config.StorageDriverMinIO,
+		}
+	case config.StorageDriverOSS:
+		s = &ossSigner{endpoint: endpoint, bucket: cfg.Bucket, keyId: cfg.AccessKeyId, secret: cfg.AccessKeySecret}
+	default:
+		return nil, fmt.Errorf("unsupported driver %q", cfg.Driver)
+	}
+	return &Client{
+		signer:    s,
+		publicURL: strings.TrimSuffix(cfg.PublicURL, "/"),
+		client:    &http.Client{Timeout: cfg.Timeout},
+		now:       time.Now,
+	}, nil
+}
+
+// PresignPut returns a request uploading an object of contentType to key, valid for ttl
+func (c *Client) PresignPut(key, contentType string, ttl time.Duration) *PresignedRequest {
+	return &PresignedRequest{
+		Method:  http.MethodPut,
+		URL:     c.signer.presign(http.MethodPut, key, contentType, nil, c.now(), ttl),
+		Headers: map[string]string{"Content-Type": contentType},
+	}
+}
+
+// PresignGet returns the URL reading an object, valid for ttl
+func (c *Client) PresignGet(key string, ttl time.Duration) string {
+	return c.signer.presign(http.MethodGet, key, "", nil, c.now(), ttl)
+}
+
+// Stat returns the size and content type of an object
+func (c *Client) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, key)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// Get reads an object of at most limit bytes
+func (c *Client) Get(ctx context.Context, key string, limit int64) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodGet, key, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength > limit {
+		return nil, ErrTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Put writes an object of contentType, replacing any object at key
+func (c *Client) Put(ctx context.Context, key, contentType string, data []byte) error {
+	resp, err := c.send(ctx, http.MethodPut, key, contentType, nil, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Delete removes an object; deleting a missing object succeeds
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, http.MethodDelete, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// URL returns the URL an object is read from
+func (c *Client) URL(key string) string {
+	if c.publicURL != "" {
+		return c.publicURL + "/" + escapePath(key)
+	}
+	return c.signer.objectURL(key)
+}
+
+// do sends a presigned request without body about an object
+func (c *Client) do(ctx context.Context, method, key string) (*http.Response, error) {
+	resp, err := c.send(ctx, method, key, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// send sends a presigned request about an object, or a subresource of it in query, returning
+// the response of a success with its body open
+func (c *Client) send(ctx context.Context, method, key, contentType string, query map[string]string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.signer.presign(method, key, contentType, query, c.now(), time.Minute), reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// escapePath escapes the segments of an object key, keeping the slashes
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode escapes every byte but the unreserved characters of RFC 3986, as both
+// signatures require
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,158 @@
+package voip
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// apnsTokenTTL is how long a provider token is reused; APNs accepts tokens up to an hour
+	// old and rejects new ones more often than every 20 minutes
+	apnsTokenTTL = 50 * time.Minute
+)
+
+// apnsError is the body of a rejected APNs request
+type apnsError struct {
+	Reason string `json:"reason"`
+}
+
+// APNs sends PushKit pushes with token-based authentication over HTTP/2
+type APNs struct {
+	baseURL string
+	topic   string
+	teamId  string
+	keyId   string
+	key     *ecdsa.PrivateKey
+	client  *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNs creates the APNs provider of cfg
+func NewAPNs(cfg *config.APNsConfig, timeout time.Duration) (*APNs, error) {
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseAPNsKey(data)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := apnsSandboxURL
+	if cfg.Production {
+		baseURL = apnsProductionURL
+	}
+	return &APNs{
+		baseURL: baseURL,
+		topic:   cfg.Topic,
+		teamId:  cfg.TeamId,
+		keyId:   cfg.KeyId,
+		key:     key,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// parseAPNsKey parses the PEM PKCS#8 .p8 key downloaded from the developer account
+func parseAPNsKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid key file: no PEM block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid key file: not an ECDSA key")
+	}
+	return ecKey, nil
+}
+
+func (a *APNs) Send(ctx context.Context, token string, n *Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	authToken, err := a.providerToken()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("apns-push-type", "voip")
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("apns-expiration", strconv.FormatInt(n.Expiration.Unix(), 10))
+	req.Header.Set("apns-collapse-id", n.CallId)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var apnsErr apnsError
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apnsErr)
+	switch {
+	case resp.StatusCode == http.StatusGone, apnsErr.Reason == "BadDeviceToken", apnsErr.Reason == "DeviceTokenNotForTopic":
+		return ErrUnregistered
+	case apnsErr.Reason == "ExpiredProviderToken":
+		a.resetProviderToken(authToken)
+	}
+	return fmt.Errorf("apns status %d: %s", resp.StatusCode, apnsErr.Reason)
+}
+
+// providerToken returns the cached provider token, signing a new one when it is due
+func (a *APNs) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Since(a.issuedAt) < apnsTokenTTL {
+		return a.token, nil
+	}
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": a.teamId, "iat": now.Unix()})
+	t.Header["kid"] = a.keyId
+	signed, err := t.SignedString(a.key)
+	if err != nil {
+		return "", err
+	}
+	a.token, a.issuedAt = signed, now
+	return signed, nil
+}
+
+// resetProviderToken drops the cached provider token unless it was replaced already
+func (a *APNs) resetProviderToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == token {
+		a.token = ""
+	}
+}
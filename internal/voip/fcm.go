@@ -0,0 +1,228 @@
+package voip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+)
+
+const (
+	fcmBaseURL = "https://fcm.googleapis.com"
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+	// fcmTokenLifetime is the lifetime requested for access tokens, the maximum Google grants
+	fcmTokenLifetime = time.Hour
+)
+
+// fcmServiceAccount holds the fields used from a service account JSON key
+type fcmServiceAccount struct {
+	ProjectId   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// fcmAccessToken is the response of the OAuth2 token endpoint
+type fcmAccessToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// fcmRequest is the body of an FCM HTTP v1 send request
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token   string            `json:"token"`
+	Data    map[string]string `json:"data"`
+	Android fcmAndroidConfig  `json:"android"`
+}
+
+type fcmAndroidConfig struct {
+	Priority    string `json:"priority"`
+	TTL         string `json:"ttl"`
+	CollapseKey string `json:"collapse_key,omitempty"`
+}
+
+// fcmError is the body of a rejected FCM request
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// FCM sends high-priority data messages through the FCM HTTP v1 API, authenticated with
+// OAuth2 access tokens of a service account
+type FCM struct {
+	sendURL     string
+	tokenURI    string
+	clientEmail string
+	key         *rsa.PrivateKey
+	client      *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewFCM creates the FCM provider of cfg
+func NewFCM(cfg *config.FCMConfig, timeout time.Duration) (*FCM, error) {
+	data, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var account fcmServiceAccount
+	if err = json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid credentials file: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials file: %w", err)
+	}
+	projectId := cfg.ProjectId
+	if projectId == "" {
+		projectId = account.ProjectId
+	}
+	if projectId == "" || account.ClientEmail == "" || account.TokenURI == "" {
+		return nil, errors.New("invalid credentials file: project_id, client_email and token_uri required")
+	}
+	return &FCM{
+		sendURL:     fcmBaseURL + "/v1/projects/" + projectId + "/messages:send",
+		tokenURI:    account.TokenURI,
+		clientEmail: account.ClientEmail,
+		key:         key,
+		client:      &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (f *FCM) Send(ctx context.Context, token string, n *Notification) error {
+	ttl := max(time.Until(n.Expiration), 0)
+	body, err := json.Marshal(&fcmRequest{Message: fcmMessage{
+		Token: token,
+		Data: map[string]string{
+			"call_id":     n.CallId,
+			"signal_type": strconv.Itoa(int(n.SignalType)),
+			"caller_id":   n.CallerId,
+			"group_id":    n.GroupId,
+			"media_type":  n.MediaType,
+		},
+		Android: fcmAndroidConfig{
+			Priority:    "HIGH",
+			TTL:         strconv.FormatInt(int64(ttl/time.Second), 10) + "s",
+			CollapseKey: n.CallId,
+		},
+	}})
+	if err != nil {
+		return err
+	}
+	accessToken, err := f.accessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.sendURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var fcmErr fcmError
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&fcmErr)
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrUnregistered
+	}
+	for _, detail := range fcmErr.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrUnregistered
+		}
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		f.resetAccessToken(accessToken)
+	}
+	return fmt.Errorf("fcm status %d: %s %s", resp.StatusCode, fcmErr.Error.Status, fcmErr.Error.Message)
+}
+
+// accessToken returns the cached access token, exchanging a signed assertion of the service
+// account for a new one shortly before it expires
+func (f *FCM) accessToken(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token != "" && time.Until(f.expiresAt) > time.Minute {
+		return f.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.clientEmail,
+		"scope": fcmScope,
+		"aud":   f.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(fcmTokenLifetime).Unix(),
+	}).SignedString(f.key)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token status %d", resp.StatusCode)
+	}
+	var result fcmAccessToken
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 65536)).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("empty access token")
+	}
+	f.token, f.expiresAt = result.AccessToken, now.Add(time.Duration(result.ExpiresIn)*time.Second)
+	return f.token, nil
+}
+
+// resetAccessToken drops the cached access token unless it was replaced already
+func (f *FCM) resetAccessToken(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token == token {
+		f.token = ""
+	}
+}
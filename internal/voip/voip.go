@@ -0,0 +1,108 @@
+// Package voip rings the devices of callees without a connection with VoIP pushes: PushKit
+// through APNs for iOS apps and high-priority data messages through FCM for Android apps.
+package voip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mbeoliero/kit/log"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// ErrUnregistered is returned by a Provider for a token that is no longer valid
+var ErrUnregistered = errors.New("voip token unregistered")
+
+// Notification is the payload of a push about a call. Invites ring the device; any other
+// signal type tells the app to stop ringing.
+type Notification struct {
+	CallId     string    `json:"call_id"`
+	SignalType int32     `json:"signal_type"`
+	CallerId   string    `json:"caller_id"`
+	GroupId    string    `json:"group_id,omitempty"`
+	MediaType  string    `json:"media_type"`
+	Expiration time.Time `json:"-"` // the push is dropped when it cannot be delivered before
+}
+
+// Provider sends pushes through a push service
+type Provider interface {
+	Send(ctx context.Context, token string, n *Notification) error
+}
+
+// TokenStore holds the VoIP tokens of users
+type TokenStore interface {
+	GetByUsers(ctx context.Context, userIds []string) ([]*entity.VoIPToken, error)
+	DeleteToken(ctx context.Context, provider, token string) error
+}
+
+// Pusher is the service.CallPusher sending call pushes to every token of the users
+type Pusher struct {
+	tokens    TokenStore
+	providers map[string]Provider
+}
+
+// New creates a Pusher with the providers whose credentials are configured in cfg
+func New(cfg *config.VoIPPushConfig, tokens TokenStore) (*Pusher, error) {
+	providers := make(map[string]Provider)
+	if cfg.APNs.KeyFile != "" {
+		apns, err := NewAPNs(&cfg.APNs, cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("apns: %w", err)
+		}
+		providers[constant.VoIPProviderAPNs] = apns
+	}
+	if cfg.FCM.CredentialsFile != "" {
+		fcm, err := NewFCM(&cfg.FCM, cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("fcm: %w", err)
+		}
+		providers[constant.VoIPProviderFCM] = fcm
+	}
+	return &Pusher{tokens: tokens, providers: providers}, nil
+}
+
+// PushCall sends a push about call with signalType to the devices of userIds; invites
+// expire at the ring deadline of the call. Tokens rejected by their provider are removed.
+func (p *Pusher) PushCall(ctx context.Context, call *entity.Call, signalType int32, userIds []string) {
+	tokens, err := p.tokens.GetByUsers(ctx, userIds)
+	if err != nil {
+		log.CtxWarn(ctx, "get voip tokens failed: call_id=%s, error=%v", call.Id, err)
+		return
+	}
+	n := &Notification{
+		CallId:     call.Id,
+		SignalType: signalType,
+		CallerId:   call.InviterId,
+		GroupId:    call.GroupId,
+		MediaType:  call.MediaType,
+		Expiration: time.UnixMilli(call.RingDeadline),
+	}
+	if now := time.Now(); n.Expiration.Before(now) {
+		// Pushes that stop the ringing may follow the deadline
+		n.Expiration = now.Add(time.Minute)
+	}
+
+	for _, token := range tokens {
+		provider := p.providers[token.Provider]
+		if provider == nil {
+			continue
+		}
+		err = provider.Send(ctx, token.Token, n)
+		if errors.Is(err, ErrUnregistered) {
+			log.CtxInfo(ctx, "voip token unregistered: user_id=%s, provider=%s", token.UserId, token.Provider)
+			if err = p.tokens.DeleteToken(ctx, token.Provider, token.Token); err != nil {
+				log.CtxWarn(ctx, "delete voip token failed: user_id=%s, error=%v", token.UserId, err)
+			}
+			continue
+		}
+		if err != nil {
+			log.CtxWarn(ctx, "voip push failed: call_id=%s, user_id=%s, provider=%s, error=%v",
+				call.Id, token.UserId, token.Provider, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package voip
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ZaiSpace/nexo_im/internal/config"
+	"github.com/ZaiSpace/nexo_im/internal/entity"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+type fakeStore struct {
+	tokens  []*entity.VoIPToken
+	deleted []string
+}
+
+func (f *fakeStore) GetByUsers(_ context.Context, _ []string) ([]*entity.VoIPToken, error) {
+	return f.tokens, nil
+}
+
+func (f *fakeStore) DeleteToken(_ context.Context, _, token string) error {
+	f.deleted = append(f.deleted, token)
+	return nil
+}
+
+type fakeProvider struct {
+	sent []string
+	err  error
+}
+
+func (f *fakeProvider) Send(_ context.Context, token string, n *Notification) error {
+	f.sent = append(f.sent, token)
+	return f.err
+}
+
+func TestPushCallRemovesUnregisteredTokens(t *testing.T) {
+	store := &fakeStore{tokens: []*entity.VoIPToken{
+		{UserId: "bob", Provider: constant.VoIPProviderAPNs, Token: "t1"},
+		{UserId: "bob", Provider: constant.VoIPProviderFCM, Token: "t2"},
+		{UserId: "carol", Provider: "other", Token: "t3"},
+	}}
+	apns, fcm := &fakeProvider{}, &fakeProvider{err: ErrUnregistered}
+	p := &Pusher{tokens: store, providers: map[string]Provider{constant.VoIPProviderAPNs: apns, constant.VoIPProviderFCM: fcm}}
+
+	p.PushCall(context.Background(), &entity.Call{Id: "c1", InviterId: "alice"}, constant.SignalTypeInvite, []string{"bob", "carol"})
+	if len(apns.sent) != 1 || len(fcm.sent) != 1 {
+		t.Fatalf("expected one push per provider, got %v and %v", apns.sent, fcm.sent)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "t2" {
+		t.Fatalf("expected the unregistered token to be deleted, got %v", store.deleted)
+	}
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAPNsSend(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyFile := writeFile(t, "key.p8", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/3/device/tok" || r.Header.Get("apns-push-type") != "voip" || r.Header.Get("apns-topic") != "com.example.app.voip" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		authToken := strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")
+		parsed, err := jwt.Parse(authToken, func(*jwt.Token) (any, error) { return &key.PublicKey, nil })
+		if err != nil || parsed.Header["kid"] != "KEY1" {
+			t.Errorf("invalid provider token: %v", err)
+		}
+		var n Notification
+		if err = json.NewDecoder(r.Body).Decode(&n); err != nil || n.CallId != "c1" {
+			t.Errorf("unexpected body %+v, err=%v", n, err)
+		}
+		w.WriteHeader(status)
+		if status == http.StatusGone {
+			_, _ = w.Write([]byte(`{"reason":"Unregistered"}`))
+		}
+	}))
+	defer srv.Close()
+
+	apns, err := NewAPNs(&config.APNsConfig{TeamId: "TEAM", KeyId: "KEY1", KeyFile: keyFile, Topic: "com.example.app.voip"}, time.Second)
+	if err != nil {
+		t.Fatalf("new apns failed: %v", err)
+	}
+	apns.baseURL = srv.URL
+	n := &Notification{CallId: "c1", SignalType: constant.SignalTypeInvite, Expiration: time.Now().Add(time.Minute)}
+
+	status = http.StatusOK
+	if err = apns.Send(context.Background(), "tok", n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	status = http.StatusGone
+	if err = apns.Send(context.Background(), "tok", n); !errors.Is(err, ErrUnregistered) {
+		t.Fatalf("expected unregistered, got %v", err)
+	}
+}
+
+func TestFCMSend(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var tokenRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("unexpected grant %q", r.FormValue("grant_type"))
+		}
+		_, _ = w.Write([]byte(`{"access_token":"at","expires_in":3600}`))
+	})
+	mux.HandleFunc("/send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		var req fcmRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Message.Token == "gone" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+		if req.Message.Android.Priority != "HIGH" || req.Message.Data["call_id"] != "c1" || req.Message.Data["signal_type"] != "1" {
+			t.Errorf("unexpected message %+v", req.Message)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	account, _ := json.Marshal(&fcmServiceAccount{ProjectId: "p", PrivateKey: string(keyPEM), ClientEmail: "sa@p.iam", TokenURI: srv.URL + "/token"})
+	fcm, err := NewFCM(&config.FCMConfig{CredentialsFile: writeFile(t, "sa.json", account)}, time.Second)
+	if err != nil {
+		t.Fatalf("new fcm failed: %v", err)
+	}
+	fcm.sendURL = srv.URL + "/send"
+	n := &Notification{CallId: "c1", SignalType: constant.SignalTypeInvite, Expiration: time.Now().Add(time.Minute)}
+
+	if err = fcm.Send(context.Background(), "tok", n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if err = fcm.Send(context.Background(), "gone", n); !errors.Is(err, ErrUnregistered) {
+		t.Fatalf("expected unregistered, got %v", err)
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("expected the access token to be reused, got %d token requests", tokenRequests)
+	}
+}
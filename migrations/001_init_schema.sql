@@ -11,11 +11,49 @@ CREATE TABLE IF NOT EXISTS users (
     avatar VARCHAR(512) DEFAULT '',
     password VARCHAR(128) NOT NULL DEFAULT '',
     extra JSON,
+    email VARCHAR(255) NULL,
+    phone VARCHAR(32) NULL,
+    verified_at BIGINT NOT NULL DEFAULT 0 COMMENT 'email/phone verified time, 0 if unverified',
+    is_bot TINYINT(1) NOT NULL DEFAULT 0,
+    guest_expires_at BIGINT NOT NULL DEFAULT 0 COMMENT 'temporary guest account expiry, 0 for regular users',
+    status INT NOT NULL DEFAULT 0 COMMENT '0=normal, 1=banned',
+    deleted_at BIGINT NOT NULL DEFAULT 0 COMMENT 'tombstoned by data deletion when > 0',
     created_at BIGINT NOT NULL,
     updated_at BIGINT NOT NULL,
+    UNIQUE KEY uk_email (email),
+    UNIQUE KEY uk_phone (phone),
+    INDEX idx_nickname (nickname),
     INDEX idx_created_at (created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
+-- External identity links (OAuth2 / OIDC login)
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(64) NOT NULL COMMENT 'configured provider name',
+    subject VARCHAR(255) NOT NULL COMMENT 'user id at the provider',
+    user_id VARCHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_provider_subject (provider, subject),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- API keys of bot users
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL COMMENT 'bot user',
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    prefix VARCHAR(32) NOT NULL COMMENT 'public part of the key',
+    key_hash CHAR(64) NOT NULL COMMENT 'hex SHA-256 of the full key',
+    scopes JSON NOT NULL,
+    operator VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'admin API key name',
+    expires_at BIGINT NOT NULL DEFAULT 0 COMMENT '0 = never',
+    revoked_at BIGINT NOT NULL DEFAULT 0 COMMENT '0 = active',
+    last_used_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_prefix (prefix),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
 -- Groups table
 CREATE TABLE IF NOT EXISTS `groups` (
     id VARCHAR(64) PRIMARY KEY,
@@ -26,6 +64,10 @@ CREATE TABLE IF NOT EXISTS `groups` (
     status INT DEFAULT 0 COMMENT '0=normal, 1=dismissed',
     creator_user_id VARCHAR(64) NOT NULL,
     group_type INT DEFAULT 0,
+    mute_all TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'only admins may send when set',
+    announcement VARCHAR(1024) NOT NULL DEFAULT '',
+    announcement_updated_at BIGINT NOT NULL DEFAULT 0,
+    announcement_user_id VARCHAR(64) NOT NULL DEFAULT '',
     created_at BIGINT NOT NULL,
     updated_at BIGINT NOT NULL,
     INDEX idx_creator (creator_user_id),
@@ -45,6 +87,7 @@ CREATE TABLE IF NOT EXISTS group_members (
     joined_at BIGINT NOT NULL,
     join_seq BIGINT NOT NULL DEFAULT 0 COMMENT 'first visible seq after joining',
     inviter_user_id VARCHAR(64) DEFAULT '',
+    muted_until BIGINT NOT NULL DEFAULT 0 COMMENT 'unix milli, 0 when not muted',
     created_at BIGINT NOT NULL,
     updated_at BIGINT NOT NULL,
     UNIQUE KEY uk_group_user (group_id, user_id),
@@ -57,7 +100,7 @@ CREATE TABLE IF NOT EXISTS conversations (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
     conversation_id VARCHAR(256) NOT NULL,
     owner_id VARCHAR(64) NOT NULL,
-    conversation_type INT NOT NULL COMMENT '1=single, 2=group',
+    conversation_type INT NOT NULL COMMENT '1=single, 2=group, 3=system',
     peer_user_id VARCHAR(64) DEFAULT '',
     group_id VARCHAR(64) DEFAULT '',
     recv_msg_opt INT DEFAULT 0 COMMENT '0=normal, 1=no_notify, 2=not_recv',
@@ -99,15 +142,104 @@ CREATE TABLE IF NOT EXISTS messages (
     sender_id VARCHAR(64) NOT NULL,
     recv_id VARCHAR(64) DEFAULT '',
     group_id VARCHAR(64) DEFAULT '',
-    session_type INT NOT NULL COMMENT '1=single, 2=group',
+    session_type INT NOT NULL COMMENT '1=single, 2=group, 3=system',
     msg_type INT NOT NULL COMMENT '1=text, 2=image, 3=video, 4=audio, 5=file, 100=custom',
     content JSON NOT NULL,
+    content_codec TINYINT NOT NULL DEFAULT 0 COMMENT '0=plain json, 1=gzip in content_blob',
+    content_blob MEDIUMBLOB NULL COMMENT 'compressed content when content_codec != 0',
     extra JSON,
+    content_hash CHAR(64) NOT NULL DEFAULT '' COMMENT 'hex sha256 of msg_type, content and extra',
     send_at BIGINT NOT NULL,
+    deleted_at BIGINT NOT NULL DEFAULT 0 COMMENT 'content cleared by data deletion when > 0',
     created_at BIGINT NOT NULL,
     updated_at BIGINT NOT NULL,
     UNIQUE KEY uk_conv_seq (conversation_id, seq),
     UNIQUE KEY uk_sender_client_msg (sender_id, client_msg_id),
     INDEX idx_sender (sender_id),
+    INDEX idx_sender_deleted (sender_id, deleted_at),
     INDEX idx_send_at (send_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- User data deletion audit trail
+CREATE TABLE IF NOT EXISTS user_deletion_records (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    mode VARCHAR(16) NOT NULL COMMENT 'tombstone or hard',
+    operator VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'internal service that requested the deletion',
+    reason VARCHAR(512) DEFAULT '',
+    status INT NOT NULL DEFAULT 0 COMMENT '0=running, 1=completed, 2=failed',
+    message_count BIGINT NOT NULL DEFAULT 0,
+    conversation_count BIGINT NOT NULL DEFAULT 0,
+    membership_count BIGINT NOT NULL DEFAULT 0,
+    error_msg VARCHAR(1024) DEFAULT '',
+    finished_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Admin audit trail (compliance access to user data)
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    operator VARCHAR(128) NOT NULL COMMENT 'admin API key name',
+    action VARCHAR(64) NOT NULL,
+    params JSON,
+    result_count BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    INDEX idx_operator (operator, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Security audit events (logins, token issuance, admin actions, internal calls, permission failures)
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    category VARCHAR(32) NOT NULL COMMENT 'login, token, admin, internal, permission',
+    action VARCHAR(128) NOT NULL,
+    actor VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'user id, admin key name or service name',
+    path VARCHAR(255) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    result VARCHAR(16) NOT NULL COMMENT 'success or failure',
+    code INT NOT NULL DEFAULT 0,
+    detail JSON,
+    trace_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    INDEX idx_category_time (category, created_at),
+    INDEX idx_actor_time (actor, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Admin broadcast announcements
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    operator VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'admin API key name',
+    text VARCHAR(4096) NOT NULL,
+    link VARCHAR(1024) NOT NULL DEFAULT '',
+    cohort JSON COMMENT 'recipient filter, NULL = all users',
+    status INT NOT NULL DEFAULT 0 COMMENT '0=scheduled, 1=running, 2=completed, 3=failed, 4=cancelled',
+    scheduled_at BIGINT NOT NULL,
+    total_count BIGINT NOT NULL DEFAULT 0,
+    sent_count BIGINT NOT NULL DEFAULT 0,
+    failed_count BIGINT NOT NULL DEFAULT 0,
+    last_user_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'last user id delivered to, for resuming',
+    error_msg VARCHAR(1024) DEFAULT '',
+    started_at BIGINT NOT NULL DEFAULT 0,
+    finished_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_status_scheduled (status, scheduled_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Outgoing webhook deliveries (final outcome per event and endpoint)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    endpoint VARCHAR(64) NOT NULL COMMENT 'endpoint name from config',
+    result VARCHAR(16) NOT NULL COMMENT 'success or failure',
+    attempts INT NOT NULL DEFAULT 0,
+    status_code INT NOT NULL DEFAULT 0 COMMENT 'HTTP status of the last attempt, 0 when no response',
+    error VARCHAR(512) NOT NULL DEFAULT '',
+    trace_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL COMMENT 'when the event was emitted',
+    finished_at BIGINT NOT NULL COMMENT 'when the last attempt ended',
+    INDEX idx_endpoint_time (endpoint, created_at),
+    INDEX idx_event (event_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+-- Message content compression
+--
+-- Large message content can be stored compressed in `content_blob`, with
+-- `content_codec` recording how to decode it (0 = plain JSON in `content`,
+-- 1 = gzip-compressed JSON in `content_blob`). Existing rows default to 0 and
+-- are read unchanged, so this migration is safe to run before deploying.
+ALTER TABLE messages
+    ADD COLUMN content_codec TINYINT NOT NULL DEFAULT 0 COMMENT '0=plain json, 1=gzip in content_blob' AFTER content,
+    ADD COLUMN content_blob MEDIUMBLOB NULL COMMENT 'compressed content when content_codec != 0' AFTER content_codec;
@@ -0,0 +1,27 @@
+-- User data deletion (GDPR purge)
+--
+-- `deleted_at` marks users/messages anonymized by a tombstone purge.
+-- `user_deletion_records` is the audit trail of every purge request.
+ALTER TABLE users
+    ADD COLUMN deleted_at BIGINT NOT NULL DEFAULT 0 COMMENT 'tombstoned by data deletion when > 0' AFTER extra;
+
+ALTER TABLE messages
+    ADD COLUMN deleted_at BIGINT NOT NULL DEFAULT 0 COMMENT 'content cleared by data deletion when > 0' AFTER send_at,
+    ADD INDEX idx_sender_deleted (sender_id, deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_deletion_records (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    mode VARCHAR(16) NOT NULL COMMENT 'tombstone or hard',
+    operator VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'internal service that requested the deletion',
+    reason VARCHAR(512) DEFAULT '',
+    status INT NOT NULL DEFAULT 0 COMMENT '0=running, 1=completed, 2=failed',
+    message_count BIGINT NOT NULL DEFAULT 0,
+    conversation_count BIGINT NOT NULL DEFAULT 0,
+    membership_count BIGINT NOT NULL DEFAULT 0,
+    error_msg VARCHAR(1024) DEFAULT '',
+    finished_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,6 @@
+-- Admin account control
+--
+-- `status` lets admins disable (ban) accounts; banned users cannot log in.
+ALTER TABLE users
+    ADD COLUMN status INT NOT NULL DEFAULT 0 COMMENT '0=normal, 1=banned' AFTER extra,
+    ADD INDEX idx_nickname (nickname);
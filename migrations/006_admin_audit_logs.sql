@@ -0,0 +1,12 @@
+-- Admin audit trail
+--
+-- Every admin access to message data (e.g. /im/admin/msg/search) is recorded here.
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    operator VARCHAR(128) NOT NULL COMMENT 'admin API key name',
+    action VARCHAR(64) NOT NULL,
+    params JSON,
+    result_count BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    INDEX idx_operator (operator, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,19 @@
+-- Security audit trail
+--
+-- Logins, token issuance, admin actions, internal-auth calls and permission failures
+-- are written here asynchronously and queried via GET /im/admin/audit/events.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    category VARCHAR(32) NOT NULL COMMENT 'login, token, admin, internal, permission',
+    action VARCHAR(128) NOT NULL,
+    actor VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'user id, admin key name or service name',
+    path VARCHAR(255) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    result VARCHAR(16) NOT NULL COMMENT 'success or failure',
+    code INT NOT NULL DEFAULT 0,
+    detail JSON,
+    trace_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    INDEX idx_category_time (category, created_at),
+    INDEX idx_actor_time (actor, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- Admin broadcast announcements
+--
+-- Each broadcast is delivered to its cohort as a custom message in the users'
+-- system notification conversations (sn_{user_id}) by the broadcast worker.
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    operator VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'admin API key name',
+    text VARCHAR(4096) NOT NULL,
+    link VARCHAR(1024) NOT NULL DEFAULT '',
+    cohort JSON COMMENT 'recipient filter, NULL = all users',
+    status INT NOT NULL DEFAULT 0 COMMENT '0=scheduled, 1=running, 2=completed, 3=failed, 4=cancelled',
+    scheduled_at BIGINT NOT NULL,
+    total_count BIGINT NOT NULL DEFAULT 0,
+    sent_count BIGINT NOT NULL DEFAULT 0,
+    failed_count BIGINT NOT NULL DEFAULT 0,
+    last_user_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'last user id delivered to, for resuming',
+    error_msg VARCHAR(1024) DEFAULT '',
+    started_at BIGINT NOT NULL DEFAULT 0,
+    finished_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_status_scheduled (status, scheduled_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
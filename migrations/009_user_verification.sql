@@ -0,0 +1,10 @@
+-- Email / phone verification at registration
+--
+-- Users registered with an email or phone cannot log in until `verified_at` is set
+-- by confirming the code sent to them. Users without either are not gated.
+ALTER TABLE users
+    ADD COLUMN email VARCHAR(255) NULL AFTER extra,
+    ADD COLUMN phone VARCHAR(32) NULL AFTER email,
+    ADD COLUMN verified_at BIGINT NOT NULL DEFAULT 0 COMMENT 'email/phone verified time, 0 if unverified' AFTER phone,
+    ADD UNIQUE KEY uk_email (email),
+    ADD UNIQUE KEY uk_phone (phone);
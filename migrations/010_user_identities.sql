@@ -0,0 +1,13 @@
+-- OAuth2 / OIDC login
+--
+-- Links accounts at external identity providers (Google, WeChat, OIDC) to IM users.
+-- A user is created and linked on the first login through a provider.
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(64) NOT NULL COMMENT 'configured provider name',
+    subject VARCHAR(255) NOT NULL COMMENT 'user id at the provider',
+    user_id VARCHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_provider_subject (provider, subject),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- API keys for bot / service accounts
+--
+-- Bot users authenticate HTTP API calls with long-lived keys (X-API-Key header)
+-- instead of password logins. Keys carry route group scopes and can be rotated
+-- with a grace period; only a SHA-256 hash of each key is stored.
+ALTER TABLE users
+    ADD COLUMN is_bot TINYINT(1) NOT NULL DEFAULT 0 AFTER verified_at;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL COMMENT 'bot user',
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    prefix VARCHAR(32) NOT NULL COMMENT 'public part of the key',
+    key_hash CHAR(64) NOT NULL COMMENT 'hex SHA-256 of the full key',
+    scopes JSON NOT NULL,
+    operator VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'admin API key name',
+    expires_at BIGINT NOT NULL DEFAULT 0 COMMENT '0 = never',
+    revoked_at BIGINT NOT NULL DEFAULT 0 COMMENT '0 = active',
+    last_used_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_prefix (prefix),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
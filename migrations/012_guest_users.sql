@@ -0,0 +1,6 @@
+-- Guest users
+--
+-- Temporary accounts created by /im/auth/guest for pre-login support chat.
+-- guest_expires_at is cleared when the guest is upgraded to a full account.
+ALTER TABLE users
+    ADD COLUMN guest_expires_at BIGINT NOT NULL DEFAULT 0 COMMENT 'temporary guest account expiry, 0 for regular users' AFTER is_bot;
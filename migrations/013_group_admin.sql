@@ -0,0 +1,12 @@
+-- Group administration
+--
+-- Group-wide mute and announcements on groups, per-member mute on group_members.
+-- Invite links live in Redis and need no schema.
+ALTER TABLE `groups`
+    ADD COLUMN mute_all TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'only admins may send when set' AFTER group_type,
+    ADD COLUMN announcement VARCHAR(1024) NOT NULL DEFAULT '' AFTER mute_all,
+    ADD COLUMN announcement_updated_at BIGINT NOT NULL DEFAULT 0 AFTER announcement,
+    ADD COLUMN announcement_user_id VARCHAR(64) NOT NULL DEFAULT '' AFTER announcement_updated_at;
+
+ALTER TABLE group_members
+    ADD COLUMN muted_until BIGINT NOT NULL DEFAULT 0 COMMENT 'unix milli, 0 when not muted' AFTER inviter_user_id;
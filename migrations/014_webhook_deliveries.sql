@@ -0,0 +1,19 @@
+-- Outgoing webhook delivery log
+--
+-- One row per event and endpoint with the final outcome after retries,
+-- queried via GET /im/admin/webhook/deliveries.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    endpoint VARCHAR(64) NOT NULL COMMENT 'endpoint name from config',
+    result VARCHAR(16) NOT NULL COMMENT 'success or failure',
+    attempts INT NOT NULL DEFAULT 0,
+    status_code INT NOT NULL DEFAULT 0 COMMENT 'HTTP status of the last attempt, 0 when no response',
+    error VARCHAR(512) NOT NULL DEFAULT '',
+    trace_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL COMMENT 'when the event was emitted',
+    finished_at BIGINT NOT NULL COMMENT 'when the last attempt ended',
+    INDEX idx_endpoint_time (endpoint, created_at),
+    INDEX idx_event (event_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
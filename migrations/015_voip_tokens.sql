@@ -0,0 +1,15 @@
+-- VoIP push tokens for incoming calls
+--
+-- One PushKit (APNs) or FCM token per user and platform, registered by the
+-- apps and used to wake offline callees when a call invite arrives.
+CREATE TABLE IF NOT EXISTS voip_tokens (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    platform_id INT NOT NULL,
+    provider VARCHAR(16) NOT NULL COMMENT 'apns or fcm',
+    token VARCHAR(512) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    UNIQUE KEY uk_user_platform (user_id, platform_id),
+    INDEX idx_token (token(191))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
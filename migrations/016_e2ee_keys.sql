@@ -0,0 +1,27 @@
+-- End-to-end encryption key bundles (X3DH)
+--
+-- Public keys only: each device of a user uploads its identity key and signed
+-- prekey, plus a batch of one-time prekeys that peers claim one at a time when
+-- they start a session. Private keys never leave the devices.
+CREATE TABLE IF NOT EXISTS e2ee_device_keys (
+    user_id VARCHAR(64) NOT NULL,
+    device_id INT NOT NULL COMMENT 'platform id of the device',
+    registration_id INT NOT NULL,
+    identity_key VARCHAR(64) NOT NULL COMMENT 'base64 public key',
+    signed_prekey_id INT NOT NULL,
+    signed_prekey VARCHAR(64) NOT NULL COMMENT 'base64 public key',
+    signed_prekey_signature VARCHAR(128) NOT NULL COMMENT 'base64 signature by the identity key',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    PRIMARY KEY (user_id, device_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS e2ee_one_time_prekeys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    device_id INT NOT NULL,
+    key_id INT NOT NULL,
+    public_key VARCHAR(64) NOT NULL COMMENT 'base64 public key',
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_device_key (user_id, device_id, key_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
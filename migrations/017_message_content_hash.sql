@@ -0,0 +1,8 @@
+-- Message integrity checksums
+--
+-- `content_hash` is the hex SHA-256 the server computes over msg_type, content
+-- and extra when a message is stored or edited, and pushes to clients with the
+-- message. Rows stored before this migration keep an empty hash and are not
+-- verified; deleted messages have their hash cleared with their content.
+ALTER TABLE messages
+    ADD COLUMN content_hash CHAR(64) NOT NULL DEFAULT '' COMMENT 'hex sha256 of msg_type, content and extra' AFTER extra;
@@ -0,0 +1,18 @@
+-- Media uploads to object storage
+--
+-- Clients request a presigned URL for each object, upload it directly to the
+-- bucket and confirm the upload, after which the object may be referenced in
+-- messages. Pending rows are uploads that were never confirmed.
+CREATE TABLE IF NOT EXISTS uploads (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    object_key VARCHAR(512) NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    content_type VARCHAR(128) NOT NULL,
+    size BIGINT NOT NULL COMMENT 'declared when presigned, bytes',
+    status TINYINT NOT NULL DEFAULT 0 COMMENT '0=pending, 1=confirmed',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    UNIQUE KEY uk_object_key (object_key(191)),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
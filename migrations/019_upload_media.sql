@@ -0,0 +1,11 @@
+-- Image metadata and thumbnails of uploads
+--
+-- Confirmed image uploads are processed in the background: the dimensions are
+-- read, EXIF metadata is stripped from the stored object and a JPEG thumbnail
+-- is stored next to it under `thumbnail_key`. Image messages referencing the
+-- upload carry the thumbnail URL and dimensions.
+ALTER TABLE uploads
+    ADD COLUMN media_status TINYINT NOT NULL DEFAULT 0 COMMENT '0=none, 1=pending, 2=ready, 3=failed' AFTER status,
+    ADD COLUMN width INT NOT NULL DEFAULT 0 AFTER media_status,
+    ADD COLUMN height INT NOT NULL DEFAULT 0 AFTER width,
+    ADD COLUMN thumbnail_key VARCHAR(512) NOT NULL DEFAULT '' AFTER height;
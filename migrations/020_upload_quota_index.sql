@@ -0,0 +1,7 @@
+-- Monthly upload quotas
+--
+-- The usage of a user is the total size of their uploads presigned this month,
+-- so the user index is extended with created_at.
+ALTER TABLE uploads
+    DROP INDEX idx_user,
+    ADD INDEX idx_user_created (user_id, created_at);
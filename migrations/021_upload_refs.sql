@@ -0,0 +1,12 @@
+-- Conversations uploads were sent in
+--
+-- With the download proxy, an upload may be downloaded by its uploader and by
+-- the participants of the conversations whose messages reference it. A row is
+-- added when a message referencing the upload is sent.
+CREATE TABLE IF NOT EXISTS upload_refs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    upload_id BIGINT NOT NULL,
+    conversation_id VARCHAR(256) NOT NULL,
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_upload_conversation (upload_id, conversation_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
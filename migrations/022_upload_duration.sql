@@ -0,0 +1,6 @@
+-- Duration of audio uploads
+--
+-- The duration of confirmed audio uploads is read from the object headers and
+-- replaces the one sent in audio messages referencing the upload.
+ALTER TABLE uploads
+    ADD COLUMN duration INT NOT NULL DEFAULT 0 COMMENT 'audio, milliseconds' AFTER thumbnail_key;
@@ -0,0 +1,11 @@
+-- Extended user profiles
+--
+-- Optional gender, birthday and region, a JSON bag of custom attributes (`ext`)
+-- and the visibility of each of them to other users (`profile_privacy`, fields
+-- missing are public).
+ALTER TABLE users
+    ADD COLUMN gender TINYINT NOT NULL DEFAULT 0 COMMENT '0=unknown, 1=male, 2=female, 3=other' AFTER avatar,
+    ADD COLUMN birthday VARCHAR(10) NOT NULL DEFAULT '' COMMENT 'YYYY-MM-DD' AFTER gender,
+    ADD COLUMN region VARCHAR(64) NOT NULL DEFAULT '' AFTER birthday,
+    ADD COLUMN ext JSON COMMENT 'custom profile attributes' AFTER region,
+    ADD COLUMN profile_privacy JSON COMMENT 'field -> public/private' AFTER ext;
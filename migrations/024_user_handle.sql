@@ -0,0 +1,9 @@
+-- User handles and search
+--
+-- `handle` is an optional unique lowercase name users can be found by through
+-- /user/search together with their nickname. `hide_from_search` opts a user out
+-- of the search results.
+ALTER TABLE users
+    ADD COLUMN handle VARCHAR(32) NULL AFTER nickname,
+    ADD COLUMN hide_from_search TINYINT(1) NOT NULL DEFAULT 0 AFTER profile_privacy,
+    ADD UNIQUE KEY uk_handle (handle);
@@ -0,0 +1,14 @@
+-- Notification preferences
+--
+-- One row per user who changed the defaults (not muted, sound, vibrate and
+-- message previews on). `platform_overrides` maps platform ids to the flags
+-- replaced on the devices of that platform.
+CREATE TABLE IF NOT EXISTS user_notification_settings (
+    user_id VARCHAR(64) PRIMARY KEY,
+    global_mute TINYINT(1) NOT NULL DEFAULT 0,
+    sound TINYINT(1) NOT NULL DEFAULT 1,
+    vibrate TINYINT(1) NOT NULL DEFAULT 1,
+    show_preview TINYINT(1) NOT NULL DEFAULT 1,
+    platform_overrides JSON COMMENT 'platform_id -> overridden flags',
+    updated_at BIGINT NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- Unread @mentions
+--
+-- One row per user mentioned by a message, removed once the user marks the
+-- conversation read past the message. Conversation lists count the rows
+-- above the user's read_seq.
+CREATE TABLE IF NOT EXISTS message_mentions (
+    user_id VARCHAR(64) NOT NULL,
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (user_id, conversation_id, seq)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,19 @@
+-- Message reminders
+--
+-- A user asks to be reminded of a message; once remind_at is reached the
+-- reminder worker sends a custom message referencing it to the user's system
+-- notification conversation (sn_{user_id}).
+CREATE TABLE IF NOT EXISTS message_reminders (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    note VARCHAR(512) NOT NULL DEFAULT '',
+    remind_at BIGINT NOT NULL,
+    status INT NOT NULL DEFAULT 0 COMMENT '0=pending, 1=sent, 2=cancelled',
+    sent_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_status_remind_at (status, remind_at),
+    INDEX idx_user_status (user_id, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
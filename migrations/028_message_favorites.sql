@@ -0,0 +1,18 @@
+-- Favorite (saved) messages
+--
+-- One row per message a user favorited. snapshot holds the message as it was
+-- when favorited (NULL when favorite.reference_only is set, the message is
+-- then loaded live). sender_id lets account deletion drop snapshots of the
+-- deleted user's messages.
+CREATE TABLE IF NOT EXISTS message_favorites (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    sender_id VARCHAR(64) NOT NULL,
+    snapshot JSON NULL,
+    created_at BIGINT NOT NULL,
+    UNIQUE KEY uk_user_message (user_id, conversation_id, seq),
+    INDEX idx_user_id (user_id, id),
+    INDEX idx_sender_id (sender_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,14 @@
+-- Poll votes
+--
+-- One row per user and option voted for on a poll message (msg_type 7);
+-- a multi-choice vote spans several rows. Voting again replaces the user's
+-- rows, so results are always tallied from this table.
+CREATE TABLE IF NOT EXISTS poll_votes (
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    option_index INT NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (conversation_id, seq, user_id, option_index),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
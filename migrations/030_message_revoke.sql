@@ -0,0 +1,8 @@
+-- Message revoke
+--
+-- A sender may recall their message within message.revoke.window. The row is
+-- kept for seq continuity with its content cleared and `revoked_at` set, and
+-- a revoke notification (msg_type 8) naming the seq is added to the
+-- conversation. Existing rows default to 0 (not revoked).
+ALTER TABLE messages
+    ADD COLUMN revoked_at BIGINT NOT NULL DEFAULT 0 COMMENT 'ms, 0 = not revoked' AFTER deleted_at;
@@ -0,0 +1,22 @@
+-- Message edits
+--
+-- Senders may edit the text of their messages. `edit_version` counts the
+-- edits of a message; each edit keeps the replaced content in
+-- message_edits, so the history of a message is its rows in version order
+-- followed by the current content. Rows are removed when the message is
+-- revoked or its sender's data is deleted.
+ALTER TABLE messages
+    ADD COLUMN edit_version INT NOT NULL DEFAULT 0 COMMENT 'number of edits by the sender' AFTER revoked_at;
+
+CREATE TABLE IF NOT EXISTS message_edits (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    sender_id VARCHAR(64) NOT NULL,
+    version INT NOT NULL COMMENT 'edit_version of the replaced content',
+    content JSON NOT NULL,
+    edited_at BIGINT NOT NULL COMMENT 'when the content was replaced (ms)',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_message_version (conversation_id, seq, version),
+    INDEX idx_sender_id (sender_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
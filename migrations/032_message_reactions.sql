@@ -0,0 +1,16 @@
+-- Message reactions
+--
+-- One row per user and emoji reacted with on a message. Pulled messages
+-- carry the counts per emoji aggregated from this table. The binary
+-- collation keeps emojis differing only in variation selectors or skin
+-- tones apart. Rows are removed when the message is revoked or the user's
+-- data is deleted.
+CREATE TABLE IF NOT EXISTS message_reactions (
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    emoji VARCHAR(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (conversation_id, seq, user_id, emoji),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,11 @@
+-- Message quotes
+--
+-- A reply records the seq of the message of the same conversation it
+-- quotes and optionally the quoted part of its text. The sender, type and
+-- current text of the quoted message are looked up when messages are
+-- pulled, so quotes of revoked or deleted messages show nothing of them.
+-- /msg/thread lists the replies to a message through idx_quote.
+ALTER TABLE messages
+    ADD COLUMN quote_seq BIGINT NOT NULL DEFAULT 0 COMMENT 'seq of the quoted message, 0 if none' AFTER edit_version,
+    ADD COLUMN quote_snippet VARCHAR(1024) NOT NULL DEFAULT '' COMMENT 'quoted part of the text, empty for all of it' AFTER quote_seq,
+    ADD INDEX idx_quote (conversation_id, quote_seq, seq);
@@ -0,0 +1,7 @@
+-- Message forwards
+--
+-- /msg/forward sends copies of messages to other conversations, flagged
+-- `forwarded`. A merged forward (msg_type 9) packages copies of several
+-- messages in the content of one message, so it needs no table of its own.
+ALTER TABLE messages
+    ADD COLUMN forwarded TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'copy of another message' AFTER quote_snippet;
@@ -0,0 +1,9 @@
+-- Delivery receipts
+--
+-- A single chat message is delivered to its receiver once the gateway pushed
+-- it to one of their connections or they pulled it. delivered_seq only
+-- advances, like read_seq; the sender learns about it from a push and from
+-- /msg/read_status. A read message counts as delivered even when
+-- delivered_seq is behind read_seq.
+ALTER TABLE seq_users
+    ADD COLUMN delivered_seq BIGINT NOT NULL DEFAULT 0 COMMENT 'last delivered seq (single chats)' AFTER read_seq;
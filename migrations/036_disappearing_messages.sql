@@ -0,0 +1,14 @@
+-- Disappearing messages
+--
+-- ttl_seconds is the disappearing messages timer of a conversation, set with
+-- PUT /conversation/update by a participant of a single chat or a group admin
+-- and shared by all participants, so it lives on seq_conversations rather than
+-- on the per-owner conversations rows. Messages sent while it is set get an
+-- expires_at (ms); pulls hide them past it and the sweeper deletes them.
+-- 0 turns the timer off, messages sent before keep their expires_at.
+ALTER TABLE seq_conversations
+    ADD COLUMN ttl_seconds INT NOT NULL DEFAULT 0 COMMENT 'disappearing messages timer, 0 = off' AFTER min_seq;
+
+ALTER TABLE messages
+    ADD COLUMN expires_at BIGINT NOT NULL DEFAULT 0 COMMENT 'disappears at (ms), 0 = never' AFTER forwarded,
+    ADD INDEX idx_expires_at (expires_at);
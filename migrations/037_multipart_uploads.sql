@@ -0,0 +1,8 @@
+-- Multipart uploads of large files
+--
+-- The object of an upload may be uploaded in parts, each with its own presigned
+-- URL, then assembled by the storage when the user completes the upload. The
+-- storage id of the multipart upload is kept to complete or abort it. Aborted
+-- uploads (status 3) no longer count towards the monthly quota.
+ALTER TABLE uploads
+    ADD COLUMN multipart_id VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'storage id of a multipart upload' AFTER duration;
@@ -0,0 +1,15 @@
+-- Pinned messages
+--
+-- Messages pinned in a conversation, shared by all its participants: either
+-- participant of a single chat and the admins of a group pin and unpin them.
+-- Conversation infos carry the pinned seqs, newest pin first. Rows are removed
+-- when the message is revoked; pins of messages no longer visible, such as
+-- disappeared or purged ones, are skipped when listed.
+CREATE TABLE IF NOT EXISTS pinned_messages (
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    pinned_by VARCHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (conversation_id, seq),
+    INDEX idx_conversation_created (conversation_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- Messages deleted for one user
+--
+-- One row per message a user deleted for themselves ("self" scope of
+-- /msg/delete). Pulls and the last message of the conversation list skip them
+-- for that user only. Deleting a message for everyone clears the message row
+-- itself instead, like revoking it.
+CREATE TABLE IF NOT EXISTS hidden_messages (
+    user_id VARCHAR(64) NOT NULL,
+    conversation_id VARCHAR(256) NOT NULL,
+    seq BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (user_id, conversation_id, seq)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- User tags
+--
+-- Tags set on users by internal services (POST /internal/user_tags/set) to
+-- target broadcasts at a segment of users: a broadcast cohort with a tag only
+-- reaches the users carrying it. Tags are not visible to users.
+CREATE TABLE IF NOT EXISTS user_tags (
+    user_id VARCHAR(64) NOT NULL,
+    tag VARCHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (user_id, tag),
+    INDEX idx_tag_user (tag, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,62 @@
+// Package analytics emits product activity events (messages sent, users coming online,
+// groups created) to a pluggable sink that exports them, anonymized, to the data platform.
+package analytics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Event types
+const (
+	EventMessageSent  = "message_sent"
+	EventUserOnline   = "user_online"
+	EventGroupCreated = "group_created"
+)
+
+// Event is a single activity record. Ids identify the user, group and conversation involved
+// and are pseudonymized by the sink; Props only carry non-identifying attributes.
+type Event struct {
+	Type           string
+	UserId         string
+	GroupId        string
+	ConversationId string
+	Props          map[string]any
+	At             time.Time
+}
+
+// Sink exports activity events. Implementations must not block the caller for long.
+type Sink interface {
+	Record(ctx context.Context, event *Event)
+}
+
+type sinkHolder struct {
+	sink Sink
+}
+
+var defaultSink atomic.Pointer[sinkHolder]
+
+// SetSink sets the process-wide analytics sink; nil disables analytics
+func SetSink(sink Sink) {
+	defaultSink.Store(&sinkHolder{sink: sink})
+}
+
+// Enabled reports whether a sink is set, so that callers can skip building events
+func Enabled() bool {
+	holder := defaultSink.Load()
+	return holder != nil && holder.sink != nil
+}
+
+// Record sends event to the configured sink, filling in the time.
+// It is a no-op when no sink is set.
+func Record(ctx context.Context, event *Event) {
+	holder := defaultSink.Load()
+	if holder == nil || holder.sink == nil || event == nil {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	holder.sink.Record(ctx, event)
+}
@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+)
+
+type captureSink struct {
+	events []*Event
+}
+
+func (s *captureSink) Record(_ context.Context, event *Event) {
+	s.events = append(s.events, event)
+}
+
+func TestRecordFillsTime(t *testing.T) {
+	sink := &captureSink{}
+	SetSink(sink)
+	defer SetSink(nil)
+
+	if !Enabled() {
+		t.Fatal("expected analytics enabled with a sink")
+	}
+	Record(context.Background(), &Event{Type: EventUserOnline, UserId: "u1"})
+	if len(sink.events) != 1 || sink.events[0].At.IsZero() {
+		t.Fatalf("expected one event with its time filled, got %+v", sink.events)
+	}
+}
+
+func TestRecordWithoutSink(t *testing.T) {
+	SetSink(nil)
+	if Enabled() {
+		t.Fatal("expected analytics disabled without a sink")
+	}
+	// Must not panic
+	Record(context.Background(), &Event{Type: EventGroupCreated})
+}
@@ -0,0 +1,93 @@
+// Package audit records security-sensitive operations (logins, token issuance,
+// admin actions, internal calls, permission failures) to a pluggable sink.
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/traceid"
+)
+
+// Event categories
+const (
+	CategoryLogin      = "login"
+	CategoryToken      = "token"
+	CategoryAdmin      = "admin"
+	CategoryInternal   = "internal"
+	CategoryPermission = "permission"
+	CategoryUpload     = "upload"
+)
+
+// Event results
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Event is a single audit record
+type Event struct {
+	Category string
+	Action   string
+	Actor    string // user id, admin key name or internal service name
+	Path     string
+	ClientIP string
+	Result   string
+	Code     int // errcode of a failure, 0 on success
+	Detail   map[string]any
+	TraceId  string
+	At       time.Time
+}
+
+// Sink persists audit events. Implementations must not block the caller for long.
+type Sink interface {
+	Record(ctx context.Context, event *Event)
+}
+
+type sinkHolder struct {
+	sink Sink
+}
+
+var defaultSink atomic.Pointer[sinkHolder]
+
+// SetSink sets the process-wide audit sink; nil disables auditing
+func SetSink(sink Sink) {
+	defaultSink.Store(&sinkHolder{sink: sink})
+}
+
+// Record sends event to the configured sink, filling in trace id and time.
+// It is a no-op when no sink is set.
+func Record(ctx context.Context, event *Event) {
+	holder := defaultSink.Load()
+	if holder == nil || holder.sink == nil || event == nil {
+		return
+	}
+	if event.TraceId == "" {
+		event.TraceId = traceid.FromContext(ctx)
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	if event.Result == "" {
+		event.Result = ResultSuccess
+		if event.Code != 0 {
+			event.Result = ResultFailure
+		}
+	}
+	holder.sink.Record(ctx, event)
+}
+
+// CodeOf returns the errcode of err for Event.Code, 0 when err is nil
+func CodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return errcode.ErrInternalServer.Code
+}
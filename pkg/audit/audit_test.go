@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+	"github.com/ZaiSpace/nexo_im/pkg/traceid"
+)
+
+type captureSink struct {
+	events []*Event
+}
+
+func (s *captureSink) Record(_ context.Context, event *Event) {
+	s.events = append(s.events, event)
+}
+
+func TestRecordFillsDefaults(t *testing.T) {
+	sink := &captureSink{}
+	SetSink(sink)
+	defer SetSink(nil)
+
+	ctx := traceid.WithTraceID(context.Background(), "trace-1")
+	Record(ctx, &Event{Category: CategoryLogin, Action: "login", Actor: "u1"})
+	Record(ctx, &Event{Category: CategoryPermission, Action: "jwt_auth", Code: 1002})
+
+	if len(sink.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(sink.events))
+	}
+	if sink.events[0].TraceId != "trace-1" || sink.events[0].At.IsZero() {
+		t.Fatalf("expected trace id and time filled, got %+v", sink.events[0])
+	}
+	if sink.events[0].Result != ResultSuccess || sink.events[1].Result != ResultFailure {
+		t.Fatalf("expected success then failure, got %s, %s", sink.events[0].Result, sink.events[1].Result)
+	}
+}
+
+func TestRecordWithoutSink(t *testing.T) {
+	SetSink(nil)
+	Record(context.Background(), &Event{Category: CategoryLogin})
+}
+
+func TestCodeOf(t *testing.T) {
+	if CodeOf(nil) != 0 {
+		t.Fatalf("expected 0 for nil error")
+	}
+	if CodeOf(errcode.ErrPasswordWrong) != errcode.ErrPasswordWrong.Code {
+		t.Fatalf("expected errcode of wrapped error")
+	}
+	if CodeOf(errors.New("boom")) != errcode.ErrInternalServer.Code {
+		t.Fatalf("expected internal server code for plain error")
+	}
+}
@@ -0,0 +1,59 @@
+package captcha
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSiteVerifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		if form.Get("secret") != "s3cret" || form.Get("remoteip") != "10.0.0.1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if form.Get("response") == "solved" {
+			_, _ = io.WriteString(w, `{"success":true}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"success":false,"error-codes":["invalid-input-response"]}`)
+	}))
+	defer srv.Close()
+
+	v, err := NewVerifier(TypeSlider, "s3cret", srv.URL)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	ok, err := v.Verify(context.Background(), "solved", "10.0.0.1")
+	if err != nil || !ok {
+		t.Fatalf("expected solved token to pass, ok=%v err=%v", ok, err)
+	}
+	ok, err = v.Verify(context.Background(), "guess", "10.0.0.1")
+	if err != nil || ok {
+		t.Fatalf("expected wrong token to fail, ok=%v err=%v", ok, err)
+	}
+	if _, err = v.Verify(context.Background(), "solved", ""); err == nil {
+		t.Fatalf("expected error on non-200 response")
+	}
+}
+
+func TestNewVerifierRejectsIncompleteConfig(t *testing.T) {
+	for _, tc := range []struct{ provider, secret, url string }{
+		{TypeHCaptcha, "", ""},
+		{TypeSlider, "s3cret", ""},
+		{"recaptcha", "s3cret", ""},
+	} {
+		if _, err := NewVerifier(tc.provider, tc.secret, tc.url); err == nil {
+			t.Fatalf("expected error for %+v", tc)
+		}
+	}
+	if _, err := NewVerifier(TypeTurnstile, "s3cret", ""); err != nil {
+		t.Fatalf("expected turnstile default endpoint, got %v", err)
+	}
+}
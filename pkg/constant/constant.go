@@ -4,16 +4,151 @@ package constant
 const (
 	SessionTypeSingle = 1 // Single chat
 	SessionTypeGroup  = 2 // Group chat
+	SessionTypeSystem = 3 // System notification
 )
 
+// SystemSenderId is the sender of system notification messages
+const SystemSenderId = "system"
+
 // Message types
 const (
-	MsgTypeText   = 1
-	MsgTypeImage  = 2
-	MsgTypeVideo  = 3
-	MsgTypeAudio  = 4
-	MsgTypeFile   = 5
-	MsgTypeCustom = 100
+	MsgTypeText      = 1
+	MsgTypeImage     = 2
+	MsgTypeVideo     = 3
+	MsgTypeAudio     = 4
+	MsgTypeFile      = 5
+	MsgTypeEncrypted = 6 // End-to-end encrypted, the server never inspects the payload
+	MsgTypePoll      = 7 // Poll, voted on with /msg/poll/vote
+	MsgTypeRevoke    = 8 // Revoke notification, added by the server when a message is recalled
+	MsgTypeMerged    = 9 // Merged forward of several messages, built by /msg/forward
+	MsgTypeCustom    = 100
+)
+
+// Call signal types, relayed between the participants of a call
+const (
+	SignalTypeInvite  = 1 // Caller starts a call, payload usually carries the SDP offer
+	SignalTypeAccept  = 2 // Callee answers on one device, payload usually carries the SDP answer
+	SignalTypeReject  = 3 // Callee declines
+	SignalTypeCancel  = 4 // Caller gives up before anyone answered
+	SignalTypeHangup  = 5 // Participant leaves an answered call
+	SignalTypeICE     = 6 // ICE candidates or renegotiation, payload passed through as is
+	SignalTypeTimeout = 7 // Sent by the server when invitees did not answer in time
+)
+
+// Call states
+const (
+	CallStateRinging = "ringing"
+	CallStateActive  = "active"
+	CallStateEnded   = "ended"
+)
+
+// Call media types
+const (
+	CallMediaAudio = "audio"
+	CallMediaVideo = "video"
+)
+
+// VoIP push providers
+const (
+	VoIPProviderAPNs = "apns" // PushKit tokens of iOS apps
+	VoIPProviderFCM  = "fcm"  // FCM registration tokens of Android apps
+)
+
+// Upload statuses
+const (
+	UploadStatusPending   = 0 // presigned, not confirmed yet
+	UploadStatusConfirmed = 1
+	UploadStatusRejected  = 2 // failed the malware scan, object deleted
+	UploadStatusAborted   = 3 // multipart upload aborted by the user, parts dropped
+)
+
+// Upload media processing statuses (thumbnail and metadata of images)
+const (
+	UploadMediaNone    = 0 // not an image, or processing is disabled
+	UploadMediaPending = 1
+	UploadMediaReady   = 2
+	UploadMediaFailed  = 3
+)
+
+// Message content codecs (how messages.content_blob is encoded)
+const (
+	ContentCodecNone = 0 // Content stored as plain JSON in messages.content
+	ContentCodecGzip = 1 // Content JSON gzip-compressed in messages.content_blob
+	ContentCodecZstd = 2 // Content JSON zstd-compressed in messages.content_blob
+)
+
+// User data deletion modes
+const (
+	DeletionModeTombstone = "tombstone" // Anonymize in place, keep rows for seq continuity
+	DeletionModeHard      = "hard"      // Physically delete rows
+)
+
+// Message delete scopes
+const (
+	MsgDeleteScopeSelf = "self" // Hidden for the requesting user only
+	MsgDeleteScopeAll  = "all"  // Content cleared for every participant
+)
+
+// User data deletion record status
+const (
+	DeletionStatusRunning   = 0
+	DeletionStatusCompleted = 1
+	DeletionStatusFailed    = 2
+)
+
+// User status
+const (
+	UserStatusNormal = 0
+	UserStatusBanned = 1 // Disabled by an admin, cannot log in
+)
+
+// User genders
+const (
+	GenderUnknown = 0
+	GenderMale    = 1
+	GenderFemale  = 2
+	GenderOther   = 3
+)
+
+// Optional user profile fields with a visibility setting
+const (
+	ProfileFieldGender   = "gender"
+	ProfileFieldBirthday = "birthday"
+	ProfileFieldRegion   = "region"
+	ProfileFieldExt      = "ext"
+)
+
+// Visibilities of optional profile fields
+const (
+	ProfileVisibilityPublic  = "public"  // shown to everyone, the default
+	ProfileVisibilityPrivate = "private" // shown to the user only
+)
+
+// Verification channels
+const (
+	VerifyChannelEmail = "email"
+	VerifyChannelPhone = "phone"
+)
+
+// Broadcast status
+const (
+	BroadcastStatusScheduled = 0
+	BroadcastStatusRunning   = 1
+	BroadcastStatusCompleted = 2
+	BroadcastStatusFailed    = 3
+	BroadcastStatusCancelled = 4
+)
+
+// Message reminder status
+const (
+	ReminderStatusPending   = 0
+	ReminderStatusSent      = 1
+	ReminderStatusCancelled = 2
+)
+
+// Admin audit actions
+const (
+	AdminAuditActionSearchMessages = "search_messages"
 )
 
 // Group status
@@ -57,6 +192,7 @@ const (
 	PlatformIdWindows = 3
 	PlatformIdMacOS   = 4
 	PlatformIdWeb     = 5
+	PlatformIdBot     = 6 // HTTP API requests authenticated with an API key
 )
 
 // PlatformIdToName converts platform Id to name
@@ -72,6 +208,8 @@ func PlatformIdToName(platformId int) string {
 		return "macOS"
 	case PlatformIdWeb:
 		return "Web"
+	case PlatformIdBot:
+		return "Bot"
 	default:
 		return "Unknown"
 	}
@@ -81,6 +219,7 @@ func PlatformIdToName(platformId int) string {
 const (
 	SingleConversationPrefix = "si_"
 	GroupConversationPrefix  = "sg_"
+	SystemConversationPrefix = "sn_" // System notification conversations
 )
 
 // Redis key patterns (without prefix, use RedisKey() to get full key)
@@ -91,6 +230,33 @@ const (
 	redisKeyUser            = "user:%s"          // user:{user_id}
 	redisKeyGroupMembers    = "group:members:%s" // group:members:{group_id}
 	redisKeySeqConversation = "seq:conv:%s"      // seq:conv:{conversation_id}
+	redisKeyStatsMessages   = "stats:msg:%s"     // stats:msg:{yyyymmdd}
+	redisKeyStatsActive     = "stats:active:%s"  // stats:active:{yyyymmdd} (HyperLogLog)
+	redisKeyStatsRegister   = "stats:reg:%s"     // stats:reg:{yyyymmdd}
+	redisKeyStatsGroups     = "stats:group:%s"   // stats:group:{yyyymmdd}
+	redisKeyStatsGroupTotal = "stats:group:total"
+	redisKeyStatsOnlinePeak = "stats:online:%s" // stats:online:{yyyymmdd}
+	redisKeyRateLimit       = "ratelimit:%s"    // ratelimit:{dimension}:{scope}:{subject}
+	redisKeyVerifyCode      = "verify:code:%s"  // verify:code:{user_id}
+	redisKeyVerifyResend    = "verify:send:%s"  // verify:send:{user_id}
+	redisKeyLoginFail       = "login:fail:%s"   // login:fail:{dimension}:{subject}
+	redisKeyGroupInvite     = "group:invite:%s" // group:invite:{token}
+	redisKeyCall            = "call:%s"         // call:{call_id}
+	redisKeyCallRinging     = "call:ringing"    // sorted set of ringing call ids by ring deadline
+	redisKeyLinkPreview     = "link:preview:%s" // link:preview:{sha256 of the url}
+	redisKeyNotifySettings  = "notify:settings:%s" // notify:settings:{user_id}
+)
+
+// Redis key patterns of the daily usage statistics of conversations, users and groups
+const (
+	redisKeyStatsConv      = "stats:conv:%s:%s"       // stats:conv:{yyyymmdd}:{conversation_id} (hash)
+	redisKeyStatsConvUsers = "stats:conv:users:%s:%s" // stats:conv:users:{yyyymmdd}:{conversation_id} (HyperLogLog)
+	redisKeyStatsUser      = "stats:user:%s:%s"       // stats:user:{yyyymmdd}:{user_id} (hash)
+	redisKeyStatsUserConvs = "stats:user:convs:%s:%s" // stats:user:convs:{yyyymmdd}:{user_id} (HyperLogLog)
+	redisKeyStatsTopConv   = "stats:top:conv:%s"      // stats:top:conv:{yyyymmdd} (sorted set by messages)
+	redisKeyStatsTopUser   = "stats:top:user:%s"      // stats:top:user:{yyyymmdd} (sorted set by messages)
+	redisKeyStatsSenders   = "stats:senders:%s:%s"    // stats:senders:{yyyymmdd}:{group_id} (sorted set by messages)
+	redisKeyStatsGroup     = "stats:group:%s:%s"      // stats:group:{yyyymmdd}:{group_id} (hash of joins and leaves)
 )
 
 // redisKeyPrefix is the global prefix for all Redis keys
@@ -115,3 +281,26 @@ func RedisKeyOnlineConns() string     { return redisKeyPrefix + redisKeyOnlineCo
 func RedisKeyUser() string            { return redisKeyPrefix + redisKeyUser }
 func RedisKeyGroupMembers() string    { return redisKeyPrefix + redisKeyGroupMembers }
 func RedisKeySeqConversation() string { return redisKeyPrefix + redisKeySeqConversation }
+func RedisKeyStatsMessages() string   { return redisKeyPrefix + redisKeyStatsMessages }
+func RedisKeyStatsActive() string     { return redisKeyPrefix + redisKeyStatsActive }
+func RedisKeyStatsRegister() string   { return redisKeyPrefix + redisKeyStatsRegister }
+func RedisKeyStatsGroups() string     { return redisKeyPrefix + redisKeyStatsGroups }
+func RedisKeyStatsGroupTotal() string { return redisKeyPrefix + redisKeyStatsGroupTotal }
+func RedisKeyStatsOnlinePeak() string { return redisKeyPrefix + redisKeyStatsOnlinePeak }
+func RedisKeyRateLimit() string       { return redisKeyPrefix + redisKeyRateLimit }
+func RedisKeyVerifyCode() string      { return redisKeyPrefix + redisKeyVerifyCode }
+func RedisKeyVerifyResend() string    { return redisKeyPrefix + redisKeyVerifyResend }
+func RedisKeyLoginFail() string       { return redisKeyPrefix + redisKeyLoginFail }
+func RedisKeyGroupInvite() string     { return redisKeyPrefix + redisKeyGroupInvite }
+func RedisKeyCall() string            { return redisKeyPrefix + redisKeyCall }
+func RedisKeyCallRinging() string     { return redisKeyPrefix + redisKeyCallRinging }
+func RedisKeyLinkPreview() string     { return redisKeyPrefix + redisKeyLinkPreview }
+func RedisKeyNotifySettings() string  { return redisKeyPrefix + redisKeyNotifySettings }
+func RedisKeyStatsConv() string       { return redisKeyPrefix + redisKeyStatsConv }
+func RedisKeyStatsConvUsers() string  { return redisKeyPrefix + redisKeyStatsConvUsers }
+func RedisKeyStatsUser() string       { return redisKeyPrefix + redisKeyStatsUser }
+func RedisKeyStatsUserConvs() string  { return redisKeyPrefix + redisKeyStatsUserConvs }
+func RedisKeyStatsTopConv() string    { return redisKeyPrefix + redisKeyStatsTopConv }
+func RedisKeyStatsTopUser() string    { return redisKeyPrefix + redisKeyStatsTopUser }
+func RedisKeyStatsSenders() string    { return redisKeyPrefix + redisKeyStatsSenders }
+func RedisKeyStatsGroup() string      { return redisKeyPrefix + redisKeyStatsGroup }
@@ -41,6 +41,7 @@ var (
 	ErrNotFound        = New(1005, "not found")
 	ErrTooManyRequests = New(1006, "too many requests")
 	ErrNoPermission    = New(1007, "no permission to access this resource")
+	ErrRequestTimeout  = New(1008, "request timeout")
 
 	// Auth errors (2xxx)
 	ErrTokenInvalid    = New(2001, "token invalid")
@@ -51,6 +52,21 @@ var (
 	ErrUserNotFound    = New(2006, "user not found")
 	ErrUserExists      = New(2007, "user already exists")
 	ErrPasswordWrong   = New(2008, "password wrong")
+	ErrUserBanned      = New(2009, "user is banned")
+	ErrRefreshReused   = New(2010, "refresh token reused, session revoked")
+	ErrUserNotVerified = New(2011, "email or phone not verified")
+	ErrVerifyCodeWrong = New(2012, "verification code wrong or expired")
+	ErrVerifyTooOften  = New(2013, "verification code requested too often")
+	ErrContactExists   = New(2014, "email or phone already registered")
+	ErrOAuthFailed     = New(2015, "oauth login failed")
+	ErrLoginLocked     = New(2016, "too many failed logins, try again later")
+	ErrCaptchaRequired = New(2017, "captcha required")
+	ErrCaptchaInvalid  = New(2018, "captcha invalid")
+	ErrAPIKeyInvalid   = New(2019, "api key invalid")
+	ErrNotBotUser      = New(2020, "user is not a bot")
+	ErrGuestExpired    = New(2021, "guest account expired")
+	ErrNotGuestUser    = New(2022, "user is not a guest")
+	ErrHandleTaken     = New(2023, "handle already taken")
 
 	// Group errors (3xxx)
 	ErrGroupNotFound      = New(3001, "group not found")
@@ -61,6 +77,9 @@ var (
 	ErrNotGroupOwner      = New(3006, "not group owner")
 	ErrNotGroupAdmin      = New(3007, "not group admin")
 	ErrCannotKickOwner    = New(3008, "cannot kick group owner")
+	ErrMemberMuted        = New(3009, "member is muted")
+	ErrGroupMuted         = New(3010, "group is muted")
+	ErrInviteLinkInvalid  = New(3011, "invite link is invalid or expired")
 
 	// Message errors (4xxx)
 	ErrMessageNotFound  = New(4001, "message not found")
@@ -69,10 +88,34 @@ var (
 	ErrSeqAllocFailed   = New(4004, "seq allocation failed")
 	ErrSendFailed       = New(4005, "message send failed")
 	ErrPullFailed       = New(4006, "message pull failed")
+	ErrMessageRejected  = New(4007, "message rejected by policy")
+	ErrMessageTooLarge  = New(4008, "message too large")
+	ErrRevokeExpired    = New(4009, "message can no longer be revoked")
+	ErrMessageRevoked   = New(4010, "message already revoked")
+	ErrEditConflict     = New(4011, "message was edited meanwhile")
 
 	// WebSocket errors (5xxx)
 	ErrConnOverLimit    = New(5001, "connection over max limit")
 	ErrConnClosed       = New(5002, "connection closed")
 	ErrInvalidProtocol  = New(5003, "invalid protocol")
 	ErrPushFailed       = New(5004, "push message failed")
+
+	// Call errors (6xxx)
+	ErrCallNotFound       = New(6001, "call not found or ended")
+	ErrCallAnswered       = New(6002, "call already answered")
+	ErrCallStateInvalid   = New(6003, "signal not allowed in the call state")
+	ErrNotCallParticipant = New(6004, "not a call participant")
+
+	// E2EE errors (7xxx)
+	ErrE2EEKeysNotFound = New(7001, "e2ee keys not found")
+	ErrE2EEPrekeyLimit  = New(7002, "too many one-time prekeys")
+
+	// Storage errors (8xxx)
+	ErrUploadNotFound    = New(8001, "upload not found")
+	ErrUploadTooLarge    = New(8002, "upload too large")
+	ErrUploadContentType = New(8003, "content type not allowed")
+	ErrUploadIncomplete  = New(8004, "object not uploaded or size mismatch")
+	ErrUploadQuota       = New(8005, "monthly upload quota exceeded")
+	ErrUploadInfected    = New(8006, "upload rejected by malware scan")
+	ErrUploadScanFailed  = New(8007, "upload could not be scanned")
 )
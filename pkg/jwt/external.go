@@ -0,0 +1,220 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ZaiSpace/nexo_im/common"
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// User id mappings of external issuers
+const (
+	// UserIdMappingActor treats the user id claim as an int id converted through
+	// common.Actor with the role claim, e.g. 42 with role "agent" => "ag__42"
+	UserIdMappingActor = "actor"
+	// UserIdMappingRaw uses the user id claim as the IM user id, after the optional prefix
+	UserIdMappingRaw = "raw"
+)
+
+const defaultJWKSRefresh = time.Hour
+
+var (
+	hmacMethods = []string{"HS256", "HS384", "HS512"}
+	jwksMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+// ExternalIssuer describes a system whose tokens are accepted in place of nexo tokens.
+// Tokens are verified with either a shared HMAC secret or the keys published at JWKSURL.
+type ExternalIssuer struct {
+	Name              string
+	Issuer            string // expected iss claim, empty accepts any issuer
+	Audience          string // expected aud claim, empty skips the check
+	Secret            string
+	JWKSURL           string
+	JWKSRefresh       time.Duration
+	UserIdClaim       string // defaults to "user_id"
+	UserIdMapping     string // UserIdMappingActor (default) or UserIdMappingRaw
+	UserIdPrefix      string // prepended to raw user ids
+	RoleClaim         string // defaults to "role", only used by the actor mapping
+	DefaultRole       string
+	PlatformIdClaim   string // optional claim carrying the platform id
+	DefaultPlatformId int
+}
+
+// ExternalVerifier converts tokens of external issuers into nexo claims
+type ExternalVerifier struct {
+	issuers []*externalIssuer
+}
+
+type externalIssuer struct {
+	ExternalIssuer
+	parser *jwt.Parser
+	jwks   *jwksCache
+}
+
+// NewExternalVerifier creates a verifier for issuers; issuers are tried in order
+func NewExternalVerifier(issuers []ExternalIssuer) (*ExternalVerifier, error) {
+	v := &ExternalVerifier{issuers: make([]*externalIssuer, 0, len(issuers))}
+	for _, cfg := range issuers {
+		if (cfg.Secret == "") == (cfg.JWKSURL == "") {
+			return nil, fmt.Errorf("issuer %q requires exactly one of secret and jwks_url", cfg.Name)
+		}
+		if cfg.UserIdClaim == "" {
+			cfg.UserIdClaim = "user_id"
+		}
+		if cfg.RoleClaim == "" {
+			cfg.RoleClaim = "role"
+		}
+		if cfg.UserIdMapping == "" {
+			cfg.UserIdMapping = UserIdMappingActor
+		}
+		if cfg.UserIdMapping != UserIdMappingActor && cfg.UserIdMapping != UserIdMappingRaw {
+			return nil, fmt.Errorf("issuer %q has unsupported user_id_mapping %q", cfg.Name, cfg.UserIdMapping)
+		}
+		if cfg.JWKSRefresh <= 0 {
+			cfg.JWKSRefresh = defaultJWKSRefresh
+		}
+
+		iss := &externalIssuer{ExternalIssuer: cfg}
+		opts := []jwt.ParserOption{jwt.WithJSONNumber()}
+		if cfg.Issuer != "" {
+			opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+		}
+		if cfg.Audience != "" {
+			opts = append(opts, jwt.WithAudience(cfg.Audience))
+		}
+		if cfg.Secret != "" {
+			opts = append(opts, jwt.WithValidMethods(hmacMethods))
+		} else {
+			opts = append(opts, jwt.WithValidMethods(jwksMethods))
+			iss.jwks = newJWKSCache(cfg.JWKSURL, cfg.JWKSRefresh)
+		}
+		iss.parser = jwt.NewParser(opts...)
+		v.issuers = append(v.issuers, iss)
+	}
+	return v, nil
+}
+
+// Parse verifies tokenString against the issuers matching its iss claim
+func (v *ExternalVerifier) Parse(ctx context.Context, tokenString string) (*Claims, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return nil, errcode.ErrTokenInvalid.Wrap(err)
+	}
+	issuer, _ := unverified.Claims.GetIssuer()
+
+	lastErr := errors.New("no external issuer matches the token")
+	for _, iss := range v.issuers {
+		if iss.Issuer != "" && iss.Issuer != issuer {
+			continue
+		}
+		claims, err := iss.parse(ctx, tokenString)
+		if err == nil {
+			return claims, nil
+		}
+		lastErr = err
+	}
+	return nil, errcode.ErrTokenInvalid.Wrap(lastErr)
+}
+
+func (iss *externalIssuer) parse(ctx context.Context, tokenString string) (*Claims, error) {
+	mapClaims := jwt.MapClaims{}
+	token, err := iss.parser.ParseWithClaims(tokenString, mapClaims, func(token *jwt.Token) (any, error) {
+		if iss.jwks == nil {
+			return []byte(iss.Secret), nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		return iss.jwks.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	userId, err := iss.mapUserId(mapClaims)
+	if err != nil {
+		return nil, err
+	}
+	platformId, err := iss.mapPlatformId(mapClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{UserId: userId, PlatformId: platformId}
+	claims.Subject, _ = mapClaims.GetSubject()
+	claims.Issuer, _ = mapClaims.GetIssuer()
+	claims.Audience, _ = mapClaims.GetAudience()
+	claims.ExpiresAt, _ = mapClaims.GetExpirationTime()
+	claims.IssuedAt, _ = mapClaims.GetIssuedAt()
+	claims.NotBefore, _ = mapClaims.GetNotBefore()
+	return claims, nil
+}
+
+func (iss *externalIssuer) mapUserId(claims jwt.MapClaims) (string, error) {
+	raw, ok := claims[iss.UserIdClaim]
+	if !ok {
+		return "", fmt.Errorf("missing %s claim", iss.UserIdClaim)
+	}
+
+	if iss.UserIdMapping == UserIdMappingRaw {
+		var id string
+		switch v := raw.(type) {
+		case string:
+			id = v
+		case json.Number:
+			id = v.String()
+		}
+		if id == "" {
+			return "", fmt.Errorf("invalid %s claim", iss.UserIdClaim)
+		}
+		return iss.UserIdPrefix + id, nil
+	}
+
+	id, err := claimInt(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s claim: %w", iss.UserIdClaim, err)
+	}
+	role := iss.DefaultRole
+	if r, ok := claims[iss.RoleClaim].(string); ok && r != "" {
+		role = r
+	}
+	actor := common.Actor{Id: id, Role: common.RoleType(role)}
+	return actor.ToIMUserId()
+}
+
+func (iss *externalIssuer) mapPlatformId(claims jwt.MapClaims) (int, error) {
+	if iss.PlatformIdClaim == "" {
+		return iss.DefaultPlatformId, nil
+	}
+	raw, ok := claims[iss.PlatformIdClaim]
+	if !ok {
+		return iss.DefaultPlatformId, nil
+	}
+	id, err := claimInt(raw)
+	if err != nil || id < constant.PlatformIdIOS || id > constant.PlatformIdWeb {
+		return 0, fmt.Errorf("invalid %s claim", iss.PlatformIdClaim)
+	}
+	return int(id), nil
+}
+
+// claimInt reads an integer claim that may be encoded as a JSON number or a string
+func claimInt(v any) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Int64()
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
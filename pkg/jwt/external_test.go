@@ -0,0 +1,145 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestExternalVerifierHMACActorMapping(t *testing.T) {
+	v, err := NewExternalVerifier([]ExternalIssuer{{Name: "default", Secret: "s3cret", DefaultRole: "user", DefaultPlatformId: 1}})
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	token := signHMAC(t, "s3cret", jwt.MapClaims{"user_id": 42, "role": "agent", "exp": time.Now().Add(time.Hour).Unix()})
+	claims, err := v.Parse(context.Background(), token)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims.UserId != "ag__42" || claims.PlatformId != 1 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	token = signHMAC(t, "s3cret", jwt.MapClaims{"user_id": 7})
+	if claims, err = v.Parse(context.Background(), token); err != nil || claims.UserId != "u___7" {
+		t.Fatalf("expected default role, got %+v err=%v", claims, err)
+	}
+
+	if _, err = v.Parse(context.Background(), signHMAC(t, "other", jwt.MapClaims{"user_id": 7})); err == nil {
+		t.Fatalf("expected wrong secret to be rejected")
+	}
+	if _, err = v.Parse(context.Background(), signHMAC(t, "s3cret", jwt.MapClaims{"user_id": 7, "exp": time.Now().Add(-time.Hour).Unix()})); err == nil {
+		t.Fatalf("expected expired token to be rejected")
+	}
+}
+
+func TestExternalVerifierSelectsIssuer(t *testing.T) {
+	v, err := NewExternalVerifier([]ExternalIssuer{
+		{Name: "partner", Issuer: "https://partner.example.com", Secret: "partner", UserIdClaim: "sub", UserIdMapping: UserIdMappingRaw, UserIdPrefix: "p_", PlatformIdClaim: "pid", DefaultPlatformId: 5},
+		{Name: "default", Secret: "s3cret", DefaultRole: "user", DefaultPlatformId: 1},
+	})
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	token := signHMAC(t, "partner", jwt.MapClaims{"iss": "https://partner.example.com", "sub": "alice", "pid": 2})
+	claims, err := v.Parse(context.Background(), token)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims.UserId != "p_alice" || claims.PlatformId != 2 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	// A partner-signed token claiming another issuer must not reach the partner key
+	token = signHMAC(t, "partner", jwt.MapClaims{"iss": "https://evil.example.com", "sub": "alice"})
+	if _, err = v.Parse(context.Background(), token); err == nil {
+		t.Fatalf("expected token of unknown issuer to be rejected")
+	}
+	token = signHMAC(t, "partner", jwt.MapClaims{"iss": "https://partner.example.com", "sub": "alice", "pid": 6})
+	if _, err = v.Parse(context.Background(), token); err == nil {
+		t.Fatalf("expected unknown platform to be rejected")
+	}
+}
+
+func TestExternalVerifierJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+
+	v, err := NewExternalVerifier([]ExternalIssuer{{Name: "sso", Issuer: "sso", Audience: "nexo", JWKSURL: srv.URL, DefaultRole: "user", DefaultPlatformId: 5}})
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	sign := func(kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return s
+	}
+
+	for i := 0; i < 2; i++ {
+		claims, err := v.Parse(context.Background(), sign("k1", jwt.MapClaims{"iss": "sso", "aud": "nexo", "user_id": "9"}))
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if claims.UserId != "u___9" || claims.PlatformId != 5 {
+			t.Fatalf("unexpected claims: %+v", claims)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Fatalf("expected keys to be cached, got %d fetches", fetches.Load())
+	}
+
+	if _, err = v.Parse(context.Background(), sign("k1", jwt.MapClaims{"iss": "sso", "aud": "other", "user_id": 9})); err == nil {
+		t.Fatalf("expected wrong audience to be rejected")
+	}
+	// Unknown kids refetch at most once a minute
+	if _, err = v.Parse(context.Background(), sign("k2", jwt.MapClaims{"iss": "sso", "aud": "nexo", "user_id": 9})); err == nil {
+		t.Fatalf("expected unknown kid to be rejected")
+	}
+	if fetches.Load() != 1 {
+		t.Fatalf("expected no refetch within a minute, got %d fetches", fetches.Load())
+	}
+	// HMAC tokens must not be accepted by a JWKS issuer
+	if _, err = v.Parse(context.Background(), signHMAC(t, "x", jwt.MapClaims{"iss": "sso", "aud": "nexo", "user_id": 9})); err == nil {
+		t.Fatalf("expected hmac token to be rejected")
+	}
+}
+
+func signHMAC(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
@@ -12,16 +12,24 @@ import (
 type Claims struct {
 	UserId     string `json:"user_id"`
 	PlatformId int    `json:"platform_id"`
+	// Scopes restricts the token to some route groups, see package scope; empty means unrestricted
+	Scopes []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a new JWT token
-func GenerateToken(userId string, platformId int, secret string, expireHours int) (string, error) {
+// GenerateToken generates a new JWT access token valid for ttl
+func GenerateToken(userId string, platformId int, secret string, ttl time.Duration) (string, error) {
+	return GenerateScopedToken(userId, platformId, nil, secret, ttl)
+}
+
+// GenerateScopedToken generates a JWT access token restricted to scopes, unrestricted if scopes is empty
+func GenerateScopedToken(userId string, platformId int, scopes []string, secret string, ttl time.Duration) (string, error) {
 	claims := Claims{
 		UserId:     userId,
 		PlatformId: platformId,
+		Scopes:     scopes,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expireHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "nexo-im",
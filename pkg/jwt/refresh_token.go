@@ -0,0 +1,220 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/pkg/errcode"
+)
+
+// Refresh tokens are opaque random strings. Each login starts a session (one per user/platform)
+// whose refresh tokens form a family: every refresh rotates the token, and presenting a rotated
+// token again revokes the session, since only a stolen copy can still hold it.
+//
+//	{refreshKeyPrefix}{sha256(token)}  hash user_id, platform_id, family   kept until expiry for reuse detection
+//	{tokenKey}:refresh                 hash family, current (token hash),  the live session of the platform
+//	                                        id, name, client_ip, created_at, refreshed_at
+
+// RefreshSession identifies the login a refresh token belongs to
+type RefreshSession struct {
+	UserId     string
+	PlatformId int
+}
+
+// SessionMeta describes the device a session was started on
+type SessionMeta struct {
+	Name     string // device name chosen by the client, can be changed later
+	ClientIP string
+}
+
+// SessionInfo is the live session of a user on a platform
+type SessionInfo struct {
+	Id          string `json:"session_id"` // changes with every login, not with refreshes
+	PlatformId  int    `json:"platform_id"`
+	Name        string `json:"name"`
+	ClientIP    string `json:"client_ip"`
+	CreatedAt   int64  `json:"created_at"`   // login time, ms
+	RefreshedAt int64  `json:"refreshed_at"` // last token refresh, ms, 0 if never refreshed
+}
+
+// rotateRefreshScript moves the session from the presented token to the new one.
+// KEYS[1] = session key; ARGV = family, presented hash, new hash, ttl (ms), now (ms)
+// Returns 1 on success, 0 if the session was replaced or revoked, -1 on reuse (session deleted).
+var rotateRefreshScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'family') ~= ARGV[1] then
+	return 0
+end
+if redis.call('HGET', KEYS[1], 'current') ~= ARGV[2] then
+	redis.call('DEL', KEYS[1])
+	return -1
+end
+redis.call('HSET', KEYS[1], 'current', ARGV[3], 'refreshed_at', ARGV[5])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+// renameSessionScript renames a session if it is still the live one of its platform.
+// KEYS[1] = session key; ARGV = session id, name
+var renameSessionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'id') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'name', ARGV[2])
+return 1
+`)
+
+// refreshSessionKey lives under the user's token prefix so ForceLogoutUser removes it too
+func (s *TokenStore) refreshSessionKey(userId string, platformId int) string {
+	return s.tokenKey(userId, platformId) + ":refresh"
+}
+
+func (s *TokenStore) refreshTokenKey(hash string) string {
+	return s.refreshKeyPrefix + hash
+}
+
+// IssueRefreshToken starts a new refresh session for a login.
+// The previous session of the platform is replaced, its refresh tokens stop working.
+func (s *TokenStore) IssueRefreshToken(ctx context.Context, userId string, platformId int, meta *SessionMeta) (string, error) {
+	if meta == nil {
+		meta = &SessionMeta{}
+	}
+	family, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	// The session id is shown to the user, so it is kept separate from the family
+	sessionId, err := randomToken(12)
+	if err != nil {
+		return "", err
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	hash := hashRefreshToken(token)
+
+	if err = s.storeRefreshToken(ctx, hash, userId, platformId, family); err != nil {
+		return "", err
+	}
+
+	key := s.refreshSessionKey(userId, platformId)
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, "family", family, "current", hash, "id", sessionId,
+			"name", meta.Name, "client_ip", meta.ClientIP, "created_at", time.Now().UnixMilli())
+		pipe.Expire(ctx, key, s.sessionTTL(platformId))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store refresh session: %w", err)
+	}
+	return token, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one of the same session.
+// Unknown, expired and superseded tokens get errcode.ErrTokenInvalid. A token that was
+// already rotated gets errcode.ErrRefreshReused, and its session is revoked.
+func (s *TokenStore) RotateRefreshToken(ctx context.Context, token string) (*RefreshSession, string, error) {
+	hash := hashRefreshToken(token)
+	fields, err := s.rdb.HGetAll(ctx, s.refreshTokenKey(hash)).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, "", errcode.ErrTokenInvalid
+	}
+	platformId, _ := strconv.Atoi(fields["platform_id"])
+	session := &RefreshSession{UserId: fields["user_id"], PlatformId: platformId}
+
+	newToken, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	newHash := hashRefreshToken(newToken)
+
+	// The new token must exist before the session points to it
+	if err = s.storeRefreshToken(ctx, newHash, session.UserId, session.PlatformId, fields["family"]); err != nil {
+		return nil, "", err
+	}
+
+	result, err := rotateRefreshScript.Run(ctx, s.rdb, []string{s.refreshSessionKey(session.UserId, session.PlatformId)},
+		fields["family"], hash, newHash, s.sessionTTL(session.PlatformId).Milliseconds(), time.Now().UnixMilli()).Int()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	switch result {
+	case 1:
+		return session, newToken, nil
+	case -1:
+		return session, "", errcode.ErrRefreshReused
+	default:
+		return nil, "", errcode.ErrTokenInvalid
+	}
+}
+
+// GetSession returns the live session of a user on a platform, nil if there is none
+func (s *TokenStore) GetSession(ctx context.Context, userId string, platformId int) (*SessionInfo, error) {
+	fields, err := s.rdb.HGetAll(ctx, s.refreshSessionKey(userId, platformId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh session: %w", err)
+	}
+	if fields["id"] == "" {
+		// Missing, or started before sessions had ids
+		return nil, nil
+	}
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	refreshedAt, _ := strconv.ParseInt(fields["refreshed_at"], 10, 64)
+	return &SessionInfo{
+		Id:          fields["id"],
+		PlatformId:  platformId,
+		Name:        fields["name"],
+		ClientIP:    fields["client_ip"],
+		CreatedAt:   createdAt,
+		RefreshedAt: refreshedAt,
+	}, nil
+}
+
+// RenameSession sets the name of a live session; reports false if sessionId is not live anymore
+func (s *TokenStore) RenameSession(ctx context.Context, userId string, platformId int, sessionId, name string) (bool, error) {
+	result, err := renameSessionScript.Run(ctx, s.rdb, []string{s.refreshSessionKey(userId, platformId)}, sessionId, name).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to rename refresh session: %w", err)
+	}
+	return result == 1, nil
+}
+
+func (s *TokenStore) storeRefreshToken(ctx context.Context, hash, userId string, platformId int, family string) error {
+	key := s.refreshTokenKey(hash)
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "user_id", userId, "platform_id", platformId, "family", family)
+		pipe.Expire(ctx, key, s.sessionTTL(platformId))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+// randomToken returns n random bytes, URL-safe base64 encoded
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashRefreshToken keys refresh tokens by hash so a Redis dump does not leak usable tokens
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -20,17 +20,20 @@ const (
 
 // TokenStore manages token storage in Redis
 type TokenStore struct {
-	rdb          redis.UniversalClient
-	accessExpire time.Duration
-	keyPrefix    string
+	rdb              redis.UniversalClient
+	sessionTTL       func(platformId int) time.Duration
+	keyPrefix        string
+	refreshKeyPrefix string
 }
 
-// NewTokenStore creates a new TokenStore
-func NewTokenStore(rdb redis.UniversalClient, expireHours int) *TokenStore {
+// NewTokenStore creates a new TokenStore. sessionTTL returns how long the tokens and
+// refresh sessions of a platform are kept; refresh tokens expire after this long unused.
+func NewTokenStore(rdb redis.UniversalClient, sessionTTL func(platformId int) time.Duration) *TokenStore {
 	return &TokenStore{
-		rdb:          rdb,
-		accessExpire: time.Duration(expireHours) * time.Hour,
-		keyPrefix:    "nexo:token:",
+		rdb:              rdb,
+		sessionTTL:       sessionTTL,
+		keyPrefix:        "nexo:token:",
+		refreshKeyPrefix: "nexo:refresh:",
 	}
 }
 
@@ -51,7 +54,7 @@ func (s *TokenStore) StoreToken(ctx context.Context, userId string, platformId i
 	}
 
 	// Set expiration on the key
-	if err := s.rdb.Expire(ctx, key, s.accessExpire).Err(); err != nil {
+	if err := s.rdb.Expire(ctx, key, s.sessionTTL(platformId)).Err(); err != nil {
 		return fmt.Errorf("failed to set token expiration: %w", err)
 	}
 
@@ -226,10 +229,11 @@ func (s *TokenStore) ForceLogoutUser(ctx context.Context, userId string) error {
 
 // ForceLogoutPlatform invalidates all tokens for a user on a specific platform
 func (s *TokenStore) ForceLogoutPlatform(ctx context.Context, userId string, platformId int) error {
-	key := s.tokenKey(userId, platformId)
-
-	if err := s.rdb.Del(ctx, key).Err(); err != nil {
-		return fmt.Errorf("failed to delete platform tokens: %w", err)
+	// Deleted one by one, the keys may live in different cluster slots
+	for _, key := range []string{s.tokenKey(userId, platformId), s.refreshSessionKey(userId, platformId)} {
+		if err := s.rdb.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to delete platform tokens: %w", err)
+		}
 	}
 
 	return nil
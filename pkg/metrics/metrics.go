@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "nexo"
+
+// Registry holds all nexo metrics, separate from the global default registry
+var Registry = prometheus.NewRegistry()
+
+// HTTP metrics
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// WebSocket gateway metrics
+var (
+	WSConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "connections",
+		Help:      "Current number of WebSocket connections on this instance.",
+	})
+
+	WSOnlineUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "online_users",
+		Help:      "Current number of online users on this instance.",
+	})
+
+	WSPushesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "pushes_total",
+		Help:      "Message pushes to client connections by result (ok, failed).",
+	}, []string{"result"})
+
+	WSPushDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "push_dropped_total",
+		Help:      "Push tasks dropped because the push channel was full.",
+	})
+)
+
+// Service metrics
+var (
+	MessagesSentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "msg",
+		Name:      "sent_total",
+		Help:      "Messages sent by session type and result (ok, failed).",
+	}, []string{"session_type", "result"})
+
+	MessagePreSendTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "msg",
+		Name:      "pre_send_total",
+		Help:      "Pre-send policy callback outcomes (allowed, modified, denied, failed, circuit_open).",
+	}, []string{"result"})
+
+	MessagePIITotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "msg",
+		Name:      "pii_total",
+		Help:      "Messages with personal data detected, by kind and action (flag, mask).",
+	}, []string{"kind", "action"})
+
+	MessageIntegrityFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "msg",
+		Name:      "integrity_failures_total",
+		Help:      "Pulled messages whose content no longer matches their stored content hash.",
+	})
+
+	MessageLinkPreviewsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "msg",
+		Name:      "link_previews_total",
+		Help:      "Link previews by result (fetched, cached, none, failed, dropped).",
+	}, []string{"result"})
+)
+
+// Storage metrics
+var (
+	StorageScansTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "scans_total",
+		Help:      "Malware scans of uploads by result (clean, infected, skipped, failed).",
+	}, []string{"result"})
+)
+
+// Webhook metrics
+var (
+	WebhookDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Webhook deliveries by endpoint and final result (success, failure, dropped).",
+	}, []string{"endpoint", "result"})
+)
+
+// Analytics metrics
+var (
+	AnalyticsEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "analytics",
+		Name:      "events_total",
+		Help:      "Exported analytics events by result (sent, failed, dropped).",
+	}, []string{"result"})
+)
+
+// Repository metrics
+var (
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "MySQL query latency by table and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"table", "operation"})
+
+	DBQueryErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "MySQL query errors by table and operation (record not found excluded).",
+	}, []string{"table", "operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		WSConnections,
+		WSOnlineUsers,
+		WSPushesTotal,
+		WSPushDroppedTotal,
+		MessagesSentTotal,
+		MessagePreSendTotal,
+		MessagePIITotal,
+		MessageIntegrityFailuresTotal,
+		MessageLinkPreviewsTotal,
+		StorageScansTotal,
+		WebhookDeliveriesTotal,
+		AnalyticsEventsTotal,
+		DBQueryDuration,
+		DBQueryErrorsTotal,
+	)
+}
+
+// Handler returns the HTTP handler exposing the registry in Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerExposesRegisteredMetrics(t *testing.T) {
+	MessagesSentTotal.WithLabelValues("single", "ok").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), `nexo_msg_sent_total{result="ok",session_type="single"}`) {
+		t.Fatalf("expected message counter in metrics output, got:\n%s", body)
+	}
+}
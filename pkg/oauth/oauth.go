@@ -0,0 +1,287 @@
+// Package oauth exchanges OAuth2 authorization codes for the identity of the
+// user at the provider. The code is obtained by the client; the server redeems
+// it with the client secret and reads the profile from the provider directly.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider types
+const (
+	TypeOIDC   = "oidc"
+	TypeGoogle = "google"
+	TypeWeChat = "wechat"
+)
+
+const (
+	googleIssuer        = "https://accounts.google.com"
+	wechatTokenURL      = "https://api.weixin.qq.com/sns/oauth2/access_token"
+	wechatUserInfoURL   = "https://api.weixin.qq.com/sns/userinfo"
+	requestTimeout      = 10 * time.Second
+	maxResponseBodySize = 1 << 20
+)
+
+// ErrExchange is returned when the provider rejects the code or returns an unusable profile
+var ErrExchange = errors.New("oauth exchange failed")
+
+// Config configures one provider
+type Config struct {
+	Name         string // identifies the provider in requests and stored identities
+	Type         string // TypeOIDC, TypeGoogle or TypeWeChat
+	ClientId     string // app id for WeChat
+	ClientSecret string
+	RedirectURL  string // default redirect_uri sent with the code
+	Issuer       string // OIDC issuer, endpoints are discovered from it
+	TokenURL     string // overrides the discovered or built-in token endpoint
+	UserInfoURL  string // overrides the discovered or built-in userinfo endpoint
+}
+
+// Identity is the account of a user at a provider
+type Identity struct {
+	Provider string
+	Subject  string // stable user id at the provider
+	Name     string
+	Avatar   string
+}
+
+// Provider redeems authorization codes
+type Provider interface {
+	Name() string
+	// Exchange redeems code; redirectURI overrides the configured one when not empty
+	Exchange(ctx context.Context, code, redirectURI string) (*Identity, error)
+}
+
+// NewProvider creates a provider from cfg
+func NewProvider(cfg Config) (Provider, error) {
+	if cfg.Name == "" || cfg.ClientId == "" {
+		return nil, fmt.Errorf("oauth provider requires name and client_id")
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	switch cfg.Type {
+	case TypeGoogle:
+		if cfg.Issuer == "" {
+			cfg.Issuer = googleIssuer
+		}
+		return &oidcProvider{cfg: cfg, client: client}, nil
+	case TypeOIDC:
+		if cfg.Issuer == "" && (cfg.TokenURL == "" || cfg.UserInfoURL == "") {
+			return nil, fmt.Errorf("oauth provider %s requires issuer or token_url and userinfo_url", cfg.Name)
+		}
+		return &oidcProvider{cfg: cfg, client: client}, nil
+	case TypeWeChat:
+		if cfg.TokenURL == "" {
+			cfg.TokenURL = wechatTokenURL
+		}
+		if cfg.UserInfoURL == "" {
+			cfg.UserInfoURL = wechatUserInfoURL
+		}
+		return &wechatProvider{cfg: cfg, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown oauth provider type %q for %s", cfg.Type, cfg.Name)
+	}
+}
+
+// oidcProvider implements the authorization code flow of OpenID Connect
+type oidcProvider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	tokenURL    string
+	userInfoURL string
+}
+
+func (p *oidcProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, redirectURI string) (*Identity, error) {
+	tokenURL, userInfoURL, err := p.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if redirectURI == "" {
+		redirectURI = p.cfg.RedirectURL
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.cfg.ClientId},
+		"client_secret": {p.cfg.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err = doJSON(p.client, req, &token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchange, token.Error, token.Description)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	var info struct {
+		Sub               string `json:"sub"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+		Picture           string `json:"picture"`
+	}
+	if err = doJSON(p.client, req, &info); err != nil {
+		return nil, err
+	}
+	if info.Sub == "" {
+		return nil, fmt.Errorf("%w: userinfo has no sub", ErrExchange)
+	}
+
+	name := info.Name
+	if name == "" {
+		name = info.PreferredUsername
+	}
+	return &Identity{Provider: p.cfg.Name, Subject: info.Sub, Name: name, Avatar: info.Picture}, nil
+}
+
+// endpoints returns the configured endpoints, discovering missing ones from the issuer once
+func (p *oidcProvider) endpoints(ctx context.Context) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tokenURL != "" {
+		return p.tokenURL, p.userInfoURL, nil
+	}
+
+	tokenURL, userInfoURL := p.cfg.TokenURL, p.cfg.UserInfoURL
+	if tokenURL == "" || userInfoURL == "" {
+		discoveryURL := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+		if err != nil {
+			return "", "", err
+		}
+		var doc struct {
+			TokenEndpoint    string `json:"token_endpoint"`
+			UserInfoEndpoint string `json:"userinfo_endpoint"`
+		}
+		if err = doJSON(p.client, req, &doc); err != nil {
+			return "", "", fmt.Errorf("oidc discovery for %s: %w", p.cfg.Name, err)
+		}
+		if tokenURL == "" {
+			tokenURL = doc.TokenEndpoint
+		}
+		if userInfoURL == "" {
+			userInfoURL = doc.UserInfoEndpoint
+		}
+		if tokenURL == "" || userInfoURL == "" {
+			return "", "", fmt.Errorf("oidc discovery for %s: missing token or userinfo endpoint", p.cfg.Name)
+		}
+	}
+
+	p.tokenURL, p.userInfoURL = tokenURL, userInfoURL
+	return tokenURL, userInfoURL, nil
+}
+
+// wechatProvider implements WeChat website / app login (sns oauth2)
+type wechatProvider struct {
+	cfg    Config
+	client *http.Client
+}
+
+func (p *wechatProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *wechatProvider) Exchange(ctx context.Context, code, _ string) (*Identity, error) {
+	query := url.Values{
+		"appid":      {p.cfg.ClientId},
+		"secret":     {p.cfg.ClientSecret},
+		"code":       {code},
+		"grant_type": {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.TokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		OpenId      string `json:"openid"`
+		UnionId     string `json:"unionid"`
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+	}
+	if err = doJSON(p.client, req, &token); err != nil {
+		return nil, err
+	}
+	if token.ErrCode != 0 || token.AccessToken == "" || token.OpenId == "" {
+		return nil, fmt.Errorf("%w: wechat errcode %d %s", ErrExchange, token.ErrCode, token.ErrMsg)
+	}
+
+	query = url.Values{"access_token": {token.AccessToken}, "openid": {token.OpenId}}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.UserInfoURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		Nickname   string `json:"nickname"`
+		HeadImgURL string `json:"headimgurl"`
+		UnionId    string `json:"unionid"`
+		ErrCode    int    `json:"errcode"`
+		ErrMsg     string `json:"errmsg"`
+	}
+	if err = doJSON(p.client, req, &info); err != nil {
+		return nil, err
+	}
+	if info.ErrCode != 0 {
+		return nil, fmt.Errorf("%w: wechat errcode %d %s", ErrExchange, info.ErrCode, info.ErrMsg)
+	}
+
+	// The union id is shared by all apps of one WeChat open platform account, prefer it
+	subject := token.OpenId
+	if info.UnionId != "" {
+		subject = info.UnionId
+	} else if token.UnionId != "" {
+		subject = token.UnionId
+	}
+	return &Identity{Provider: p.cfg.Name, Subject: subject, Name: info.Nickname, Avatar: info.HeadImgURL}, nil
+}
+
+// doJSON sends req and decodes the JSON response
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: unexpected status %d: %s", ErrExchange, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%w: invalid response: %v", ErrExchange, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestOIDCExchangeDiscoversEndpoints(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = io.WriteString(w, `{"token_endpoint":"`+srv.URL+`/token","userinfo_endpoint":"`+srv.URL+`/userinfo"}`)
+		case "/token":
+			body, _ := io.ReadAll(r.Body)
+			form, _ := url.ParseQuery(string(body))
+			if form.Get("code") != "good" || form.Get("client_secret") != "s3cret" || form.Get("redirect_uri") != "app://cb" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"access_token":"at","token_type":"Bearer"}`)
+		case "/userinfo":
+			if r.Header.Get("Authorization") != "Bearer at" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = io.WriteString(w, `{"sub":"123","preferred_username":"alice","picture":"https://img/a.png"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewProvider(Config{Name: "corp", Type: TypeOIDC, ClientId: "im", ClientSecret: "s3cret", RedirectURL: "app://cb", Issuer: srv.URL})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	identity, err := p.Exchange(context.Background(), "good", "")
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if identity.Provider != "corp" || identity.Subject != "123" || identity.Name != "alice" || identity.Avatar != "https://img/a.png" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	if _, err = p.Exchange(context.Background(), "bad", ""); !errors.Is(err, ErrExchange) {
+		t.Fatalf("expected exchange error, got %v", err)
+	}
+}
+
+func TestWeChatExchangePrefersUnionId(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/token":
+			if q.Get("appid") != "wx1" || q.Get("code") != "good" {
+				_, _ = io.WriteString(w, `{"errcode":40029,"errmsg":"invalid code"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"access_token":"at","openid":"o1"}`)
+		case "/userinfo":
+			if q.Get("access_token") != "at" || q.Get("openid") != "o1" {
+				_, _ = io.WriteString(w, `{"errcode":40001,"errmsg":"invalid token"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"openid":"o1","unionid":"u1","nickname":"bob","headimgurl":"https://img/b.png"}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewProvider(Config{Name: "wechat", Type: TypeWeChat, ClientId: "wx1", TokenURL: srv.URL + "/token", UserInfoURL: srv.URL + "/userinfo"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	identity, err := p.Exchange(context.Background(), "good", "")
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if identity.Subject != "u1" || identity.Name != "bob" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	if _, err = p.Exchange(context.Background(), "bad", ""); !errors.Is(err, ErrExchange) {
+		t.Fatalf("expected exchange error, got %v", err)
+	}
+}
+
+func TestNewProviderRejectsIncompleteConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Type: TypeGoogle, ClientId: "im"},
+		{Name: "corp", Type: TypeOIDC, ClientId: "im"},
+		{Name: "x", Type: "saml", ClientId: "im"},
+	} {
+		if _, err := NewProvider(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
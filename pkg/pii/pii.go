@@ -0,0 +1,177 @@
+// Package pii detects personal data in free text, namely phone numbers, email addresses and
+// payment card numbers, and masks it.
+package pii
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Kinds of personal data
+const (
+	KindPhone = "phone"
+	KindEmail = "email"
+	KindCard  = "card"
+)
+
+// Kinds lists every kind Detect knows, in detection order
+var Kinds = []string{KindEmail, KindCard, KindPhone}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\d(?:[ -]?\d){12,18}`)
+	phonePattern = regexp.MustCompile(`\+?\d(?:[ ().-]{0,2}\d){6,14}`)
+)
+
+// Match is a span of text holding personal data
+type Match struct {
+	Kind  string
+	Start int // byte offset of the first byte
+	End   int // byte offset past the last byte
+}
+
+// Detect returns the personal data of the given kinds in text, ordered by position. Emails
+// are matched first, then card numbers passing the Luhn check, then phone numbers of 10 to
+// 15 digits, or 8 to 15 with a leading "+"; a span is never matched twice.
+func Detect(text string, kinds []string) []Match {
+	var matches []Match
+	for _, kind := range Kinds {
+		if !slices.Contains(kinds, kind) {
+			continue
+		}
+		for _, loc := range pattern(kind).FindAllStringIndex(text, -1) {
+			m := Match{Kind: kind, Start: loc[0], End: loc[1]}
+			if kind != KindEmail && !isolated(text, m) {
+				continue
+			}
+			if !valid(kind, text[m.Start:m.End]) || overlaps(matches, m) {
+				continue
+			}
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+// Mask returns text with the matches masked: card and phone numbers keep their last four
+// digits, emails the first character of the local part and the domain
+func Mask(text string, matches []Match) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString(maskMatch(m.Kind, text[m.Start:m.End]))
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// KindsOf returns the distinct kinds of matches, in detection order
+func KindsOf(matches []Match) []string {
+	var kinds []string
+	for _, kind := range Kinds {
+		for _, m := range matches {
+			if m.Kind == kind {
+				kinds = append(kinds, kind)
+				break
+			}
+		}
+	}
+	return kinds
+}
+
+func pattern(kind string) *regexp.Regexp {
+	switch kind {
+	case KindEmail:
+		return emailPattern
+	case KindCard:
+		return cardPattern
+	default:
+		return phonePattern
+	}
+}
+
+// isolated reports whether a number is not part of a longer word or number
+func isolated(text string, m Match) bool {
+	if m.Start > 0 && isAlnum(text[m.Start-1]) {
+		return false
+	}
+	return m.End == len(text) || !isAlnum(text[m.End])
+}
+
+func valid(kind, s string) bool {
+	digits := digitsOf(s)
+	switch kind {
+	case KindCard:
+		return luhn(digits)
+	case KindPhone:
+		if strings.HasPrefix(s, "+") {
+			return len(digits) >= 8
+		}
+		return len(digits) >= 10
+	default:
+		return true
+	}
+}
+
+func maskMatch(kind, s string) string {
+	if kind == KindEmail {
+		at := strings.LastIndexByte(s, '@')
+		return s[:1] + "***" + s[at:]
+	}
+	keep := len(digitsOf(s)) - 4
+	b := []byte(s)
+	for i := range b {
+		if b[i] >= '0' && b[i] <= '9' {
+			if keep > 0 {
+				b[i] = '*'
+			}
+			keep--
+		}
+	}
+	return string(b)
+}
+
+// luhn reports whether digits pass the Luhn checksum of card numbers
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func overlaps(matches []Match, m Match) bool {
+	for _, other := range matches {
+		if m.Start < other.End && other.Start < m.End {
+			return true
+		}
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,43 @@
+package pii
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectAndMask(t *testing.T) {
+	text := "call +1 415-555-0132 or 13800138000, mail alice.w@example.com, card 4111 1111 1111 1111"
+	matches := Detect(text, Kinds)
+	if got := KindsOf(matches); !reflect.DeepEqual(got, []string{KindEmail, KindCard, KindPhone}) {
+		t.Fatalf("unexpected kinds %v in %+v", got, matches)
+	}
+	if len(matches) != 4 {
+		t.Fatalf("expected four matches, got %+v", matches)
+	}
+
+	want := "call +* ***-***-0132 or *******8000, mail a***@example.com, card **** **** **** 1111"
+	if got := Mask(text, matches); got != want {
+		t.Fatalf("unexpected masked text\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestDetectSkipsNonPII(t *testing.T) {
+	for _, text := range []string{
+		"meet on 2024-01-15 at 10:30",
+		"order 4111 1111 1111 1112", // fails the Luhn check
+		"ticket ABC1380013800099",   // part of a word
+		"short 555-0132",
+	} {
+		if matches := Detect(text, Kinds); len(matches) != 0 {
+			t.Errorf("unexpected matches in %q: %+v", text, matches)
+		}
+	}
+}
+
+func TestDetectOnlyRequestedKinds(t *testing.T) {
+	text := "bob@example.com 13800138000"
+	matches := Detect(text, []string{KindPhone})
+	if len(matches) != 1 || matches[0].Kind != KindPhone || text[matches[0].Start:matches[0].End] != "13800138000" {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+}
@@ -0,0 +1,83 @@
+// Package ratelimit implements a Redis-backed token bucket shared by all nodes.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ZaiSpace/nexo_im/pkg/constant"
+)
+
+// tokenBucketScript takes one token from the bucket at KEYS[1].
+// ARGV: rate (tokens/s), burst. Returns {allowed, retry_after_ms}.
+// Redis server time is used so all nodes share one clock.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, retry}
+`)
+
+// Rule is a token bucket: Rate tokens per second are refilled up to Burst
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the rule limits anything
+func (r Rule) Enabled() bool {
+	return r.Rate > 0 && r.Burst > 0
+}
+
+// Limiter checks token buckets stored in Redis
+type Limiter struct {
+	rdb redis.UniversalClient
+}
+
+// NewLimiter creates a new Limiter
+func NewLimiter(rdb redis.UniversalClient) *Limiter {
+	return &Limiter{rdb: rdb}
+}
+
+// Allow takes one token from the bucket identified by key.
+// When denied, retryAfter is how long until a token is available.
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) (allowed bool, retryAfter time.Duration, err error) {
+	if !rule.Enabled() {
+		return true, 0, nil
+	}
+
+	redisKey := fmt.Sprintf(constant.RedisKeyRateLimit(), key)
+	res, err := tokenBucketScript.Run(ctx, l.rdb, []string{redisKey}, rule.Rate, rule.Burst).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
@@ -3,7 +3,10 @@ package response
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 
@@ -15,6 +18,7 @@ type Response struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
+	Details any    `json:"details,omitempty"` // error details, e.g. invalid request fields
 }
 
 // Success sends a success response
@@ -72,3 +76,33 @@ func Forbidden(ctx context.Context, c *app.RequestContext, msg string) {
 		Message: msg,
 	})
 }
+
+// TooManyRequests sends a 429 response with a Retry-After header in whole seconds
+func TooManyRequests(ctx context.Context, c *app.RequestContext, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(http.StatusTooManyRequests, Response{
+		Code:    errcode.ErrTooManyRequests.Code,
+		Message: errcode.ErrTooManyRequests.Msg,
+	})
+}
+
+// GatewayTimeout sends a 504 response for a request that ran past its deadline
+func GatewayTimeout(ctx context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusGatewayTimeout, Response{
+		Code:    errcode.ErrRequestTimeout.Code,
+		Message: errcode.ErrRequestTimeout.Msg,
+	})
+}
+
+// InvalidParams sends a 400 response listing the offending request fields
+func InvalidParams(ctx context.Context, c *app.RequestContext, details any) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    errcode.ErrInvalidParam.Code,
+		Message: errcode.ErrInvalidParam.Msg,
+		Details: details,
+	})
+}
@@ -0,0 +1,79 @@
+// Package scope restricts what a credential may do on the user route groups.
+// API keys and scoped access tokens carry a list of scopes:
+//
+//	"*"              every route group, read and write
+//	"<group>"        one route group, read and write
+//	"<group>:read"   one route group, read only (GET routes and WebSocket reads)
+package scope
+
+import (
+	"slices"
+	"strings"
+)
+
+// Route groups
+const (
+	All          = "*"
+	User         = "user"
+	Group        = "group"
+	Msg          = "msg"
+	Conversation = "conversation"
+)
+
+const readSuffix = ":read"
+
+var groups = []string{User, Group, Msg, Conversation}
+
+// Read returns the read only scope of a route group
+func Read(group string) string {
+	return group + readSuffix
+}
+
+// Valid checks that every scope is known and there is at least one
+func Valid(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, s := range scopes {
+		if s == All {
+			continue
+		}
+		group := strings.TrimSuffix(s, readSuffix)
+		if !slices.Contains(groups, group) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allows checks if scopes grant access to a route group; write is any non-read access
+func Allows(scopes []string, group string, write bool) bool {
+	for _, s := range scopes {
+		if s == All || s == group || (!write && s == Read(group)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Within checks that every requested scope is granted by held, so a credential
+// cannot be used to create a more powerful one
+func Within(requested, held []string) bool {
+	for _, s := range requested {
+		switch {
+		case s == All:
+			if !Allows(held, All, true) {
+				return false
+			}
+		case strings.HasSuffix(s, readSuffix):
+			if !Allows(held, strings.TrimSuffix(s, readSuffix), false) {
+				return false
+			}
+		default:
+			if !Allows(held, s, true) {
+				return false
+			}
+		}
+	}
+	return true
+}
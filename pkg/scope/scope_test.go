@@ -0,0 +1,53 @@
+package scope
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	for _, scopes := range [][]string{{All}, {Msg, Read(Conversation)}, {User, Group}} {
+		if !Valid(scopes) {
+			t.Fatalf("expected %v to be valid", scopes)
+		}
+	}
+	for _, scopes := range [][]string{nil, {"admin"}, {"*:read"}, {"msg:write"}} {
+		if Valid(scopes) {
+			t.Fatalf("expected %v to be invalid", scopes)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	scopes := []string{Msg, Read(Conversation)}
+	for _, tc := range []struct {
+		group   string
+		write   bool
+		allowed bool
+	}{
+		{Msg, true, true},
+		{Msg, false, true},
+		{Conversation, false, true},
+		{Conversation, true, false},
+		{Group, false, false},
+	} {
+		if Allows(scopes, tc.group, tc.write) != tc.allowed {
+			t.Fatalf("expected allowed=%v for group=%s write=%v", tc.allowed, tc.group, tc.write)
+		}
+	}
+	if !Allows([]string{All}, Group, true) {
+		t.Fatalf("expected wildcard to allow every group")
+	}
+}
+
+func TestWithin(t *testing.T) {
+	held := []string{Msg, Read(Group)}
+	if !Within([]string{Read(Msg), Read(Group)}, held) || !Within([]string{Msg}, held) {
+		t.Fatalf("expected narrower scopes to be within %v", held)
+	}
+	for _, requested := range [][]string{{Group}, {All}, {User}} {
+		if Within(requested, held) {
+			t.Fatalf("expected %v not to be within %v", requested, held)
+		}
+	}
+	if !Within([]string{All}, []string{All}) {
+		t.Fatalf("expected wildcard to be within wildcard")
+	}
+}
@@ -0,0 +1,41 @@
+// Package signature implements the HMAC request signature shared by internal-auth
+// requests and outgoing webhooks.
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Headers carrying the signature of a request
+const (
+	ServiceNameHeader = "X-Service-Name"
+	TimestampHeader   = "X-Timestamp"
+	SignatureHeader   = "X-Signature"
+)
+
+// Sign returns the hex HMAC-SHA256 of serviceName, timestamp, upper-cased method, path and
+// the hex SHA-256 of body, joined by newlines
+func Sign(secret, serviceName, timestamp, method, path string, body []byte) string {
+	bodyHashBytes := sha256.Sum256(body)
+	bodyHash := hex.EncodeToString(bodyHashBytes[:])
+	payload := strings.Join([]string{
+		serviceName,
+		timestamp,
+		strings.ToUpper(method),
+		path,
+		bodyHash,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether sig is the signature of the request, compared in constant time
+func Verify(secret, serviceName, timestamp, method, path string, body []byte, sig string) bool {
+	expected := Sign(secret, serviceName, timestamp, method, path, body)
+	return hmac.Equal([]byte(strings.ToLower(sig)), []byte(expected))
+}
@@ -0,0 +1,36 @@
+// Package traceid carries the nexo trace id (Trace-Id header / operation_id) in a context.
+// It has no dependencies so that any package, including those the HTTP middleware imports,
+// can read the id set by the middleware.
+package traceid
+
+import (
+	"context"
+	"strings"
+)
+
+// ContextKey is the context key of the trace id. It is a plain string so values set by
+// context.WithValue(ctx, "trace_id", id) elsewhere are found too.
+const ContextKey = "trace_id"
+
+// FromContext returns the trace id carried by ctx, or "" if none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if traceID, ok := ctx.Value(ContextKey).(string); ok {
+		return strings.TrimSpace(traceID)
+	}
+	return ""
+}
+
+// WithTraceID returns a context carrying traceID; an empty traceID leaves ctx unchanged
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	traceID = strings.TrimSpace(traceID)
+	if traceID == "" {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, ContextKey, traceID)
+}
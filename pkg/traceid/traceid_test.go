@@ -0,0 +1,19 @@
+package traceid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceIDRoundTrip(t *testing.T) {
+	ctx := WithTraceID(context.Background(), " t1 ")
+	if got := FromContext(ctx); got != "t1" {
+		t.Fatalf("expected t1, got %q", got)
+	}
+	if got := FromContext(WithTraceID(context.Background(), " ")); got != "" {
+		t.Fatalf("expected an empty trace id to be ignored, got %q", got)
+	}
+	if got := FromContext(nil); got != "" {
+		t.Fatalf("expected no trace id in a nil context, got %q", got)
+	}
+}
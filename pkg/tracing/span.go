@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ZaiSpace/nexo_im/pkg/traceid"
+)
+
+const tracerName = "github.com/ZaiSpace/nexo_im"
+
+// AttrTraceID is the span attribute carrying the legacy nexo trace_id (Trace-Id header / operation_id)
+const AttrTraceID = "nexo.trace_id"
+
+// Tracer returns the nexo tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start starts a span as a child of ctx. The legacy trace_id carried by ctx, if any,
+// is attached as an attribute so spans can be found by either id.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if traceID := traceid.FromContext(ctx); traceID != "" {
+		attrs = append(attrs, attribute.String(AttrTraceID, traceID))
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span (if any) and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract extracts W3C trace context (traceparent/tracestate) from carrier into ctx
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,241 @@
+// Package validate checks request structs against `validate` struct tags.
+//
+// Supported rules, comma separated:
+//
+//	required  string/slice/map non-empty, pointer non-nil, number non-zero
+//	min=N     minimum string length (in characters), slice/map length or number value
+//	max=N     maximum string length (in characters), slice/map length or number value
+//
+// Every string reachable from the struct (fields, pointers, slices, nested structs)
+// must be valid UTF-8. Field names in errors follow the json tag, falling back to
+// the query/path tag, joined by dots for nested fields.
+//
+// A tag with an unknown or malformed rule fails the field with ReasonInvalidRule rather
+// than skipping the check; CheckTag lets tests reject such tags before they ship.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Failure reasons
+const (
+	ReasonRequired    = "required"
+	ReasonTooShort    = "too_short"
+	ReasonTooLong     = "too_long"
+	ReasonTooSmall    = "too_small"
+	ReasonTooLarge    = "too_large"
+	ReasonInvalidUTF8 = "invalid_utf8"
+	ReasonInvalidRule = "invalid_rule" // the validate tag itself is wrong
+)
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+	Limit  *int64 `json:"limit,omitempty"` // the min/max that was violated
+}
+
+// Errors lists every invalid field of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Field+": "+fe.Reason)
+	}
+	return "invalid fields: " + strings.Join(parts, ", ")
+}
+
+// Struct validates v, a struct or pointer to struct. Returns nil when v is valid.
+func Struct(v any) Errors {
+	var errs Errors
+	walk(reflect.ValueOf(v), "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func walk(v reflect.Value, path string, errs *Errors) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), path, errs)
+		}
+	case reflect.String:
+		if !utf8.ValidString(v.String()) {
+			*errs = append(*errs, FieldError{Field: path, Reason: ReasonInvalidUTF8})
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return // raw bytes (e.g. json.RawMessage) are not text
+		}
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			walk(iter.Value(), joinPath(path, key), errs)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fieldName(field)
+			if name == "-" {
+				continue
+			}
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinPath(path, name)
+			}
+			fv := v.Field(i)
+			if tag := field.Tag.Get("validate"); tag != "" {
+				if !checkRules(fv, tag, fieldPath, errs) {
+					continue
+				}
+			}
+			walk(fv, fieldPath, errs)
+		}
+	}
+}
+
+// CheckTag reports whether every rule of a validate tag is supported and well formed
+func CheckTag(tag string) error {
+	for _, rule := range strings.Split(tag, ",") {
+		if _, _, err := parseRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRule returns the name of a rule and the limit of min/max
+func parseRule(rule string) (string, int64, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(rule), "=")
+	switch name {
+	case "required", "":
+		if hasArg {
+			return "", 0, fmt.Errorf("validate: rule %q takes no argument", name)
+		}
+		return name, 0, nil
+	case "min", "max":
+		limit, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("validate: invalid %s rule %q", name, arg)
+		}
+		return name, limit, nil
+	default:
+		return "", 0, errors.New("validate: unknown rule " + strconv.Quote(name))
+	}
+}
+
+// checkRules applies the rules in tag to v; returns false if v failed and should not be walked further
+func checkRules(v reflect.Value, tag, path string, errs *Errors) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		name, limit, err := parseRule(rule)
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: path, Reason: ReasonInvalidRule})
+			return false
+		}
+		switch name {
+		case "required":
+			if isEmpty(v) {
+				*errs = append(*errs, FieldError{Field: path, Reason: ReasonRequired})
+				return false
+			}
+		case "min", "max":
+			if fe, ok := checkBound(v, name == "min", limit); !ok {
+				fe.Field = path
+				*errs = append(*errs, fe)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func checkBound(v reflect.Value, isMin bool, limit int64) (FieldError, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return FieldError{}, true
+		}
+		v = v.Elem()
+	}
+
+	var (
+		size    int64
+		isCount bool
+	)
+	switch v.Kind() {
+	case reflect.String:
+		size, isCount = int64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, isCount = int64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = int64(v.Uint())
+	default:
+		return FieldError{}, true
+	}
+
+	if isMin && size < limit {
+		reason := ReasonTooSmall
+		if isCount {
+			reason = ReasonTooShort
+		}
+		return FieldError{Reason: reason, Limit: &limit}, false
+	}
+	if !isMin && size > limit {
+		reason := ReasonTooLarge
+		if isCount {
+			reason = ReasonTooLong
+		}
+		return FieldError{Reason: reason, Limit: &limit}, false
+	}
+	return FieldError{}, true
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query", "path"} {
+		if tag := field.Tag.Get(key); tag != "" {
+			name, _, _ := strings.Cut(tag, ",")
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,146 @@
+package validate
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testNested struct {
+	Text string `json:"text" validate:"max=5"`
+}
+
+type testRequest struct {
+	UserId   string            `json:"user_id" validate:"required,max=8"`
+	Nickname string            `query:"nickname" validate:"min=2"`
+	Limit    int               `json:"limit" validate:"min=0,max=100"`
+	Ids      []string          `json:"ids" validate:"max=2"`
+	Nested   *testNested       `json:"nested"`
+	Extra    map[string]string `json:"extra"`
+}
+
+func TestStructValid(t *testing.T) {
+	req := &testRequest{UserId: "u1", Nickname: "bob", Limit: 10, Ids: []string{"a"}, Nested: &testNested{Text: "hi"}}
+	if errs := Struct(req); errs != nil {
+		t.Fatalf("expected valid request, got %v", errs)
+	}
+}
+
+func TestStructCollectsAllFieldErrors(t *testing.T) {
+	req := &testRequest{
+		UserId:   "",
+		Nickname: "b",
+		Limit:    101,
+		Ids:      []string{"a", "b", "c"},
+		Nested:   &testNested{Text: "héllo!"},
+		Extra:    map[string]string{"k": string([]byte{0xff})},
+	}
+	errs := Struct(req)
+
+	expected := map[string]string{
+		"user_id":     ReasonRequired,
+		"nickname":    ReasonTooShort,
+		"limit":       ReasonTooLarge,
+		"ids":         ReasonTooLong,
+		"nested.text": ReasonTooLong,
+		"extra.k":     ReasonInvalidUTF8,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errs)
+	}
+	for _, fe := range errs {
+		if expected[fe.Field] != fe.Reason {
+			t.Fatalf("unexpected error %s: %s", fe.Field, fe.Reason)
+		}
+	}
+}
+
+func TestStructCountsCharactersNotBytes(t *testing.T) {
+	req := &testNested{Text: "你好世界啊"}
+	if errs := Struct(req); errs != nil {
+		t.Fatalf("expected 5 characters to pass max=5, got %v", errs)
+	}
+}
+
+func TestStructReportsInvalidRule(t *testing.T) {
+	req := &struct {
+		Ids  []string `json:"ids" validate:"max=2,dive,max=64"`
+		Name string   `json:"name" validate:"max=ten"`
+	}{Ids: []string{"a"}}
+	errs := Struct(req)
+	if len(errs) != 2 || errs[0].Reason != ReasonInvalidRule || errs[1].Reason != ReasonInvalidRule {
+		t.Fatalf("expected both fields to report an invalid rule, got %v", errs)
+	}
+}
+
+func TestCheckTag(t *testing.T) {
+	for _, tag := range []string{"required", "required,max=64", "min=0,max=100", "max=10,"} {
+		if err := CheckTag(tag); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", tag, err)
+		}
+	}
+	for _, tag := range []string{"oneof=a b", "max=20,dive,max=64", "max=", "min=x", "required=true"} {
+		if err := CheckTag(tag); err == nil {
+			t.Fatalf("expected %q to be rejected", tag)
+		}
+	}
+}
+
+// TestRepoValidateTags checks the validate tags of every struct in the repository, so an
+// unsupported rule fails here instead of on the first request
+func TestRepoValidateTags(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	checked := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// The tests of this package hold invalid tags on purpose
+		if !strings.HasSuffix(path, ".go") || filepath.Dir(path) == filepath.Join(root, "pkg", "validate") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok || field.Tag == nil {
+				return true
+			}
+			raw, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return true
+			}
+			tag, ok := reflect.StructTag(raw).Lookup("validate")
+			if !ok {
+				return true
+			}
+			checked++
+			if err := CheckTag(tag); err != nil {
+				t.Errorf("%s: %v", fset.Position(field.Pos()), err)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk repository failed: %v", err)
+	}
+	if checked == 0 {
+		t.Fatal("expected validate tags in the repository")
+	}
+}
@@ -0,0 +1,117 @@
+// Package webhook notifies external systems of server-side events (user, group and
+// message lifecycle) through a pluggable dispatcher that delivers them to HTTP endpoints.
+package webhook
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ZaiSpace/nexo_im/pkg/traceid"
+)
+
+// Delivery headers; the request is also signed with the internal-auth headers
+// X-Service-Name, X-Timestamp and X-Signature
+const (
+	EventHeader   = "X-Webhook-Event"
+	IdHeader      = "X-Webhook-Id"
+	AttemptHeader = "X-Webhook-Attempt"
+)
+
+// User event types
+const (
+	EventUserRegistered     = "user.registered"
+	EventUserProfileUpdated = "user.profile_updated"
+	EventUserDeleted        = "user.deleted"
+)
+
+// Group event types
+const (
+	EventGroupCreated              = "group.created"
+	EventGroupDismissed            = "group.dismissed"
+	EventGroupMemberJoined         = "group.member_joined"
+	EventGroupMemberLeft           = "group.member_left"
+	EventGroupMemberKicked         = "group.member_kicked"
+	EventGroupOwnershipTransferred = "group.ownership_transferred"
+	EventGroupAdminPromoted        = "group.admin_promoted"
+	EventGroupAdminDemoted         = "group.admin_demoted"
+)
+
+// EventBotMessage is delivered to the webhook of a bot user for each message addressed to it
+const EventBotMessage = "bot.message"
+
+// Event is the JSON body posted to webhook endpoints
+type Event struct {
+	Id        string `json:"id"` // unique per event, retries reuse it so receivers can deduplicate
+	Type      string `json:"type"`
+	CreatedAt int64  `json:"created_at"` // ms
+	TraceId   string `json:"trace_id,omitempty"`
+	Data      any    `json:"data"`
+}
+
+// Dispatcher delivers events to the configured endpoints. Implementations must not block
+// the caller for long.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, event *Event)
+}
+
+type dispatcherHolder struct {
+	dispatcher Dispatcher
+}
+
+var defaultDispatcher atomic.Pointer[dispatcherHolder]
+
+// SetDispatcher sets the process-wide dispatcher; nil disables webhooks
+func SetDispatcher(dispatcher Dispatcher) {
+	defaultDispatcher.Store(&dispatcherHolder{dispatcher: dispatcher})
+}
+
+// Enabled reports whether a dispatcher is set, so callers can skip building costly payloads
+func Enabled() bool {
+	holder := defaultDispatcher.Load()
+	return holder != nil && holder.dispatcher != nil
+}
+
+// NewEvent returns an event of eventType carrying data, filling in id, trace id and time
+func NewEvent(ctx context.Context, eventType string, data any) *Event {
+	event := &Event{
+		Id:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: time.Now().UnixMilli(),
+		Data:      data,
+		TraceId:   traceid.FromContext(ctx),
+	}
+	return event
+}
+
+// Emit sends an event of eventType carrying data to the dispatcher. It is a no-op when no
+// dispatcher is set.
+func Emit(ctx context.Context, eventType string, data any) {
+	holder := defaultDispatcher.Load()
+	if holder == nil || holder.dispatcher == nil {
+		return
+	}
+	holder.dispatcher.Dispatch(ctx, NewEvent(ctx, eventType, data))
+}
+
+// Matches reports whether eventType passes an endpoint's event filters. An empty filter list
+// and "*" match every event, a filter ending in ".*" matches the events under that prefix,
+// e.g. "group.*" matches "group.created".
+func Matches(filters []string, eventType string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		f = strings.TrimSpace(f)
+		switch {
+		case f == "*" || f == eventType:
+			return true
+		case strings.HasSuffix(f, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(f, "*")):
+			return true
+		}
+	}
+	return false
+}
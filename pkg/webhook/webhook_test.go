@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZaiSpace/nexo_im/pkg/traceid"
+)
+
+type captureDispatcher struct {
+	events []*Event
+}
+
+func (d *captureDispatcher) Dispatch(_ context.Context, event *Event) {
+	d.events = append(d.events, event)
+}
+
+func TestEmitFillsDefaults(t *testing.T) {
+	d := &captureDispatcher{}
+	SetDispatcher(d)
+	defer SetDispatcher(nil)
+
+	ctx := traceid.WithTraceID(context.Background(), "trace-1")
+	Emit(ctx, "user.registered", map[string]string{"user_id": "u1"})
+	Emit(ctx, "user.registered", map[string]string{"user_id": "u2"})
+
+	if len(d.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(d.events))
+	}
+	e := d.events[0]
+	if e.Id == "" || e.Id == d.events[1].Id {
+		t.Fatalf("expected unique event ids, got %q and %q", e.Id, d.events[1].Id)
+	}
+	if e.Type != "user.registered" || e.TraceId != "trace-1" || e.CreatedAt == 0 {
+		t.Fatalf("expected type, trace id and time filled, got %+v", e)
+	}
+}
+
+func TestEmitWithoutDispatcher(t *testing.T) {
+	SetDispatcher(nil)
+	if Enabled() {
+		t.Fatalf("expected webhooks disabled")
+	}
+	Emit(context.Background(), "user.registered", nil)
+}
+
+func TestMatches(t *testing.T) {
+	cases := []struct {
+		filters []string
+		event   string
+		want    bool
+	}{
+		{nil, "group.created", true},
+		{[]string{"*"}, "group.created", true},
+		{[]string{"group.created"}, "group.created", true},
+		{[]string{"group.*"}, "group.member_joined", true},
+		{[]string{"group.*"}, "groups.created", false},
+		{[]string{"user.registered", "message.*"}, "group.created", false},
+	}
+	for _, c := range cases {
+		if got := Matches(c.filters, c.event); got != c.want {
+			t.Errorf("Matches(%v, %q) = %v, want %v", c.filters, c.event, got, c.want)
+		}
+	}
+}
@@ -11,6 +11,16 @@ func (c *Client) Register(ctx context.Context, req *RegisterRequest) (*UserInfo,
 	return &result, nil
 }
 
+// Verify confirms the email or phone given at registration with the code sent to it
+func (c *Client) Verify(ctx context.Context, req *VerifyRequest) error {
+	return c.post(ctx, "/im/auth/verify", req, nil)
+}
+
+// ResendVerifyCode sends a new verification code to the email or phone given at registration
+func (c *Client) ResendVerifyCode(ctx context.Context, userId string) error {
+	return c.post(ctx, "/im/auth/verify/resend", &ResendVerifyCodeRequest{UserId: userId}, nil)
+}
+
 // Login authenticates a user and returns a token
 // The token is automatically stored in the client for subsequent requests
 func (c *Client) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
@@ -18,8 +28,60 @@ func (c *Client) Login(ctx context.Context, req *LoginRequest) (*LoginResponse,
 	if err := c.post(ctx, "/im/auth/login", req, &result); err != nil {
 		return nil, err
 	}
-	// Auto-set token for subsequent requests
-	c.SetToken(result.Token)
+	// Auto-set tokens for subsequent requests
+	c.setTokens(result.Token, result.RefreshToken)
+	return &result, nil
+}
+
+// OAuthLogin logs in with an authorization code from an identity provider configured on the server.
+// The tokens are automatically stored in the client for subsequent requests.
+func (c *Client) OAuthLogin(ctx context.Context, req *OAuthLoginRequest) (*LoginResponse, error) {
+	var result LoginResponse
+	if err := c.post(ctx, "/im/auth/oauth/callback", req, &result); err != nil {
+		return nil, err
+	}
+	c.setTokens(result.Token, result.RefreshToken)
+	return &result, nil
+}
+
+// CreateGuest creates a temporary guest account on a server with guest mode enabled and logs it in.
+// Guests can only chat with the server's support users until they are upgraded.
+// The tokens are automatically stored in the client for subsequent requests.
+func (c *Client) CreateGuest(ctx context.Context, req *CreateGuestRequest) (*LoginResponse, error) {
+	var result LoginResponse
+	if err := c.post(ctx, "/im/auth/guest", req, &result); err != nil {
+		return nil, err
+	}
+	c.setTokens(result.Token, result.RefreshToken)
+	return &result, nil
+}
+
+// UpgradeGuest turns the logged in guest into a full account keeping its user id.
+// Log in with the new password afterwards to get an unrestricted token.
+func (c *Client) UpgradeGuest(ctx context.Context, req *UpgradeGuestRequest) (*UserInfo, error) {
+	var result UserInfo
+	if err := c.post(ctx, "/im/auth/guest/upgrade", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RefreshToken exchanges the stored refresh token for a new access token and refresh token.
+// Requests refresh automatically when the access token expires, so calling it is optional.
+func (c *Client) RefreshToken(ctx context.Context) (*RefreshTokenResponse, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refreshTokens(ctx)
+}
+
+// refreshTokens rotates the tokens; callers hold refreshMu
+func (c *Client) refreshTokens(ctx context.Context) (*RefreshTokenResponse, error) {
+	var result RefreshTokenResponse
+	req := &RefreshTokenRequest{RefreshToken: c.GetRefreshToken()}
+	if err := c.post(ctx, "/im/auth/refresh", req, &result); err != nil {
+		return nil, err
+	}
+	c.setTokens(result.Token, result.RefreshToken)
 	return &result, nil
 }
 
@@ -10,9 +10,12 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/common/config"
 	"github.com/cloudwego/hertz/pkg/protocol"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
@@ -20,10 +23,23 @@ import (
 // Client is the SDK client for Nexo IM API
 type Client struct {
 	baseURL    string
-	httpClient *client.Client
-	token      string
-	ignoreAuth bool
-	internal   *internalAuthConfig
+	httpClient Transport
+	// streamClient reads response bodies as they arrive, for exports. The default Hertz
+	// one has no read timeout so long streams are not cut off.
+	streamClient Transport
+	ignoreAuth   atomic.Bool
+	apiKey       string
+	internal     *internalAuthConfig
+	actAs        *actAsUserConfig // default acting user of internal requests
+	breaker      *circuitBreaker
+	hedging      *HedgingConfig
+	timeout      time.Duration // default per-request timeout, 0 keeps the Hertz client's timeouts
+
+	mu           sync.RWMutex // guards token, refreshToken and tokenSource
+	token        string
+	refreshToken string
+	tokenSource  TokenSource // supplies the access token when set, see WithTokenSource
+	refreshMu    sync.Mutex  // serializes refreshes, a rotated refresh token must not be reused
 }
 
 type internalAuthConfig struct {
@@ -38,6 +54,8 @@ type actAsUserConfig struct {
 
 type requestOptions struct {
 	actAsUser *actAsUserConfig
+	timeout   time.Duration
+	token     string // overrides the client's access token
 }
 
 // RequestOption configures per-request behavior.
@@ -47,6 +65,9 @@ const (
 	traceIDContextKey = "trace_id"
 	traceIDHeader     = "Trace-Id"
 	xTraceIDHeader    = "X-Trace-Id"
+
+	// traceparentKey is both the ctx key and header name of a W3C trace context
+	traceparentKey = "traceparent"
 )
 
 // ClientOption is a function to configure the client
@@ -66,10 +87,24 @@ func WithToken(token string) ClientOption {
 	}
 }
 
+// WithRefreshToken sets the refresh token used to renew an expired access token
+func WithRefreshToken(refreshToken string) ClientOption {
+	return func(c *Client) {
+		c.refreshToken = refreshToken
+	}
+}
+
+// WithAPIKey authenticates as a bot user with an API key issued by an admin
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = strings.TrimSpace(apiKey)
+	}
+}
+
 // WithIgnoreAuthHeader enables Ignore-Auth header for TEST env bypass.
 func WithIgnoreAuthHeader(enabled bool) ClientOption {
 	return func(c *Client) {
-		c.ignoreAuth = enabled
+		c.ignoreAuth.Store(enabled)
 	}
 }
 
@@ -90,16 +125,17 @@ func WithInternalAuth(serviceName, secret string) ClientOption {
 }
 
 // WithActAsUser sets user context headers for a single internal request.
+// The option may be shared by concurrent requests, so it is normalized once here.
 func WithActAsUser(userId string, platformId int) RequestOption {
+	userId = strings.TrimSpace(userId)
+	if platformId <= 0 {
+		platformId = PlatformIdWeb
+	}
 	return func(o *requestOptions) {
-		userId = strings.TrimSpace(userId)
 		if userId == "" {
 			o.actAsUser = nil
 			return
 		}
-		if platformId <= 0 {
-			platformId = PlatformIdWeb
-		}
 		o.actAsUser = &actAsUserConfig{
 			userId:     userId,
 			platformId: platformId,
@@ -107,6 +143,58 @@ func WithActAsUser(userId string, platformId int) RequestOption {
 	}
 }
 
+// WithRequestToken sends a single request with token instead of the client's own access
+// token, so one client can serve goroutines acting as different users. A rejected token is
+// returned as is, the client's tokens are never refreshed for it.
+func WithRequestToken(token string) RequestOption {
+	token = strings.TrimSpace(token)
+	return func(o *requestOptions) {
+		o.token = token
+	}
+}
+
+// tokenContextKey is the ctx key of a per-request access token
+type tokenContextKey struct{}
+
+// ContextWithToken returns a context whose requests are sent with token like WithRequestToken,
+// for methods without request options
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, strings.TrimSpace(token))
+}
+
+func tokenFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	token, _ := ctx.Value(tokenContextKey{}).(string)
+	return token
+}
+
+// WithDefaultActAsUser sets the acting user of every internal request that does not pass
+// WithActAsUser, for clients working on behalf of a single user.
+func WithDefaultActAsUser(userId string, platformId int) ClientOption {
+	return func(c *Client) {
+		ro := buildRequestOptions(WithActAsUser(userId, platformId))
+		c.actAs = ro.actAsUser
+	}
+}
+
+// WithDefaultTimeout bounds every request, including connecting and reading the response.
+// It replaces the 30s read and write timeouts of the default Hertz client.
+func WithDefaultTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithTimeout overrides the client's default timeout for a single request.
+// Methods without request options follow the deadline of their context instead.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
 // NewClient creates a new SDK client
 func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
 	if baseURL == "" {
@@ -122,9 +210,19 @@ func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
 		return nil, fmt.Errorf("failed to create http client: %w", err)
 	}
 
+	streamClient, err := client.NewClient(
+		client.WithDialTimeout(10*time.Second),
+		client.WithWriteTimeout(30*time.Second),
+		client.WithResponseBodyStream(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
 	c := &Client{
-		baseURL:    baseURL,
-		httpClient: httpClient,
+		baseURL:      baseURL,
+		httpClient:   httpClient,
+		streamClient: streamClient,
 	}
 
 	for _, opt := range opts {
@@ -160,17 +258,59 @@ func MustNewInternalClient(baseURL, serviceName, secret string, opts ...ClientOp
 
 // SetToken sets the authentication token
 func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.token = token
 }
 
 // GetToken returns the current token
 func (c *Client) GetToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.token
 }
 
+// SetRefreshToken sets the refresh token
+func (c *Client) SetRefreshToken(refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshToken = refreshToken
+}
+
+// GetRefreshToken returns the current refresh token; it changes on every refresh
+func (c *Client) GetRefreshToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshToken
+}
+
+// clone returns a client with the same configuration and transports but no tokens
+func (c *Client) clone() *Client {
+	cl := &Client{
+		baseURL:      c.baseURL,
+		httpClient:   c.httpClient,
+		streamClient: c.streamClient,
+		apiKey:       c.apiKey,
+		internal:     c.internal,
+		actAs:        c.actAs,
+		breaker:      c.breaker,
+		hedging:      c.hedging,
+		timeout:      c.timeout,
+	}
+	cl.ignoreAuth.Store(c.ignoreAuth.Load())
+	return cl
+}
+
+func (c *Client) setTokens(token, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.refreshToken = refreshToken
+}
+
 // SetIgnoreAuth controls whether Ignore-Auth header is sent.
 func (c *Client) SetIgnoreAuth(enabled bool) {
-	c.ignoreAuth = enabled
+	c.ignoreAuth.Store(enabled)
 }
 
 func buildRequestOptions(opts ...RequestOption) *requestOptions {
@@ -183,17 +323,39 @@ func buildRequestOptions(opts ...RequestOption) *requestOptions {
 	return ro
 }
 
-func (c *Client) applyAuthHeaders(ctx context.Context, req *protocol.Request, method, path string, body []byte, reqOpts *requestOptions) {
+// resolveRequestOptions builds the options of a request, filling in the client's default
+// acting user and a token carried by ctx
+func (c *Client) resolveRequestOptions(ctx context.Context, opts []RequestOption) *requestOptions {
+	ro := buildRequestOptions(opts...)
+	if ro.actAsUser == nil {
+		ro.actAsUser = c.actAs
+	}
+	if ro.token == "" {
+		ro.token = tokenFromContext(ctx)
+	}
+	return ro
+}
+
+// applyAuthHeaders sets the trace and auth headers of a request sent with token
+func (c *Client) applyAuthHeaders(ctx context.Context, req *protocol.Request, method, path string, body []byte, token string, reqOpts *requestOptions) {
 	if traceID := traceIDFromContext(ctx); traceID != "" {
 		req.Header.Set(traceIDHeader, traceID)
 		req.Header.Set(xTraceIDHeader, traceID)
 	}
+	if ctx != nil {
+		if traceparent := contextValueToTraceID(ctx.Value(traceparentKey)); traceparent != "" {
+			req.Header.Set(traceparentKey, traceparent)
+		}
+	}
 
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-		req.Header.Set("X-Token", c.token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Token", token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
 	}
-	if c.ignoreAuth {
+	if c.ignoreAuth.Load() {
 		req.Header.Set("Ignore-Auth", "1")
 	}
 	if c.internal != nil {
@@ -209,16 +371,33 @@ func (c *Client) applyAuthHeaders(ctx context.Context, req *protocol.Request, me
 	}
 }
 
-// request makes an HTTP request and decodes the response
+// request makes an HTTP request with a JSON body and decodes the response
 func (c *Client) request(ctx context.Context, method, path string, body any, result any, opts ...RequestOption) error {
+	return c.requestWithQuery(ctx, method, path, nil, body, result, opts...)
+}
+
+// get makes a GET request with query parameters
+func (c *Client) get(ctx context.Context, path string, params map[string]string, result any, opts ...RequestOption) error {
+	return c.requestWithQuery(ctx, consts.MethodGet, path, params, nil, result, opts...)
+}
+
+// requestWithQuery makes an HTTP request with optional query parameters and JSON body.
+// Internal auth signs the path without the query, the same for every method.
+func (c *Client) requestWithQuery(ctx context.Context, method, path string, params map[string]string, body any, result any, opts ...RequestOption) error {
 	reqURL := c.baseURL + path
+	if len(params) > 0 {
+		query := url.Values{}
+		for k, v := range params {
+			query.Set(k, v)
+		}
+		reqURL += "?" + query.Encode()
+	}
 
 	req := &protocol.Request{}
 	resp := &protocol.Response{}
 
 	req.SetMethod(method)
 	req.SetRequestURI(reqURL)
-	req.Header.Set("Content-Type", "application/json")
 
 	var jsonBody []byte
 	if body != nil {
@@ -227,49 +406,92 @@ func (c *Client) request(ctx context.Context, method, path string, body any, res
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
+		req.Header.Set("Content-Type", "application/json")
 		req.SetBody(jsonBody)
 	}
-	c.applyAuthHeaders(ctx, req, method, path, jsonBody, buildRequestOptions(opts...))
 
-	err := c.httpClient.Do(ctx, req, resp)
+	return c.do(ctx, req, resp, path, jsonBody, result, opts...)
+}
+
+// do signs and sends a request. When the access token is rejected and the token source or a
+// refresh token can renew it, it retries once, so callers never see an expired access token.
+func (c *Client) do(ctx context.Context, req *protocol.Request, resp *protocol.Response, path string, body []byte, result any, opts ...RequestOption) error {
+	reqOpts := c.resolveRequestOptions(ctx, opts)
+	timeout, err := c.requestTimeout(ctx, reqOpts)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
+	if timeout > 0 {
+		req.SetOptions(config.WithRequestTimeout(timeout), config.WithReadTimeout(timeout), config.WithWriteTimeout(timeout))
+	}
+
+	send := func(token string) error {
+		c.applyAuthHeaders(ctx, req, string(req.Header.Method()), path, body, token, reqOpts)
+		if err := c.roundTrip(ctx, req, resp, path); err != nil {
+			return err
+		}
+		return decodeAPIResponse(resp, result)
+	}
 
-	return decodeAPIResponse(resp, result)
+	usedToken, err := c.requestToken(ctx, path, reqOpts)
+	if err != nil {
+		return err
+	}
+	err = send(usedToken)
+	if reqOpts.token != "" {
+		return err
+	}
+	token, ok := c.renewRejectedToken(ctx, path, usedToken, err)
+	if !ok {
+		return err
+	}
+	resp.Reset()
+	return send(token)
 }
 
-// get makes a GET request with query parameters
-func (c *Client) get(ctx context.Context, path string, params map[string]string, result any, opts ...RequestOption) error {
-	reqURL := c.baseURL + path
-	if len(params) > 0 {
-		query := url.Values{}
-		for k, v := range params {
-			query.Set(k, v)
+// requestTimeout returns the per-call timeout, else the client default, shortened to the context deadline
+func (c *Client) requestTimeout(ctx context.Context, reqOpts *requestOptions) (time.Duration, error) {
+	timeout := c.timeout
+	if reqOpts.timeout > 0 {
+		timeout = reqOpts.timeout
+	}
+	if ctx == nil {
+		return timeout, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, context.DeadlineExceeded
+		}
+		if timeout <= 0 || remaining < timeout {
+			timeout = remaining
 		}
-		reqURL += "?" + query.Encode()
 	}
+	return timeout, nil
+}
 
-	req := &protocol.Request{}
-	resp := &protocol.Response{}
+// refreshAfter refreshes the tokens unless another request already did since usedToken was sent
+func (c *Client) refreshAfter(ctx context.Context, usedToken string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
 
-	req.SetMethod(consts.MethodGet)
-	req.SetRequestURI(reqURL)
-	c.applyAuthHeaders(ctx, req, consts.MethodGet, path, nil, buildRequestOptions(opts...))
-
-	err := c.httpClient.Do(ctx, req, resp)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+	if c.GetToken() != usedToken {
+		return nil
 	}
-
-	return decodeAPIResponse(resp, result)
+	_, err := c.refreshTokens(ctx)
+	return err
 }
 
 func decodeAPIResponse(resp *protocol.Response, result any) error {
 	body := resp.Body()
 	statusCode := resp.StatusCode()
 	if statusCode < 200 || statusCode >= 300 {
-		return fmt.Errorf("unexpected status %d: %s", statusCode, responseBodyPreview(body))
+		// Validation and rate limit failures still carry a JSON error body
+		var apiResp Response
+		if err := json.Unmarshal(body, &apiResp); err == nil && apiResp.Code != 0 {
+			return &Error{Code: apiResp.Code, Msg: apiResp.ErrorMessage(), Details: apiResp.Details}
+		}
+		return &StatusError{StatusCode: statusCode, Body: responseBodyPreview(body)}
 	}
 
 	var apiResp Response
@@ -278,7 +500,7 @@ func decodeAPIResponse(resp *protocol.Response, result any) error {
 	}
 
 	if apiResp.Code != 0 {
-		return &Error{Code: apiResp.Code, Msg: apiResp.ErrorMessage()}
+		return &Error{Code: apiResp.Code, Msg: apiResp.ErrorMessage(), Details: apiResp.Details}
 	}
 
 	if result != nil && apiResp.Data != nil {
@@ -313,6 +535,16 @@ func (c *Client) put(ctx context.Context, path string, body interface{}, result
 	return c.request(ctx, consts.MethodPut, path, body, result, opts...)
 }
 
+// patch makes a PATCH request
+func (c *Client) patch(ctx context.Context, path string, body interface{}, result interface{}, opts ...RequestOption) error {
+	return c.request(ctx, consts.MethodPatch, path, body, result, opts...)
+}
+
+// del makes a DELETE request; the resource is usually named by query parameters, body may be nil
+func (c *Client) del(ctx context.Context, path string, params map[string]string, body interface{}, result interface{}, opts ...RequestOption) error {
+	return c.requestWithQuery(ctx, consts.MethodDelete, path, params, body, result, opts...)
+}
+
 func signInternalRequest(secret, serviceName, timestamp, method, path string, body []byte) string {
 	bodyHashBytes := sha256.Sum256(body)
 	bodyHash := hex.EncodeToString(bodyHashBytes[:])
@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go run ./internal/mockgen -src client_api.go -iface ClientAPI -type MockClient -out mock_client.go
+
+// ClientAPI is the method set of Client. Services calling nexo_im depend on it instead of
+// *Client so unit tests can substitute a MockClient or a FakeServer client.
+type ClientAPI interface {
+	// Auth
+	Register(ctx context.Context, req *RegisterRequest) (*UserInfo, error)
+	Verify(ctx context.Context, req *VerifyRequest) error
+	ResendVerifyCode(ctx context.Context, userId string) error
+	Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
+	OAuthLogin(ctx context.Context, req *OAuthLoginRequest) (*LoginResponse, error)
+	CreateGuest(ctx context.Context, req *CreateGuestRequest) (*LoginResponse, error)
+	UpgradeGuest(ctx context.Context, req *UpgradeGuestRequest) (*UserInfo, error)
+	RefreshToken(ctx context.Context) (*RefreshTokenResponse, error)
+	LoginWithUserId(ctx context.Context, userId, password string, platformId int) (*LoginResponse, error)
+	InternalRegister(ctx context.Context, req *RegisterRequest) (*UserInfo, error)
+	UseExternalToken(token string)
+	EnableTestAuthBypass(enabled bool)
+	SetToken(token string)
+	GetToken() string
+	SetRefreshToken(refreshToken string)
+	GetRefreshToken() string
+	SetIgnoreAuth(enabled bool)
+
+	// User
+	GetUserInfo(ctx context.Context) (*UserInfo, error)
+	GetUserInfoById(ctx context.Context, userId string) (*UserInfo, error)
+	UpdateUserInfo(ctx context.Context, req *UpdateUserRequest) (*UserInfo, error)
+	GetUsersInfo(ctx context.Context, userIds []string) ([]*UserInfo, error)
+	SearchUsers(ctx context.Context, q string, limit int) ([]*UserInfo, error)
+	GetUsersOnlineStatus(ctx context.Context, userIds []string) ([]*OnlineStatus, error)
+	ListSessions(ctx context.Context) ([]*Session, error)
+	RenameSession(ctx context.Context, sessionId, name string) error
+	RevokeSession(ctx context.Context, sessionId string) error
+	DeleteAccount(ctx context.Context, req *DeleteAccountRequest) error
+	GetNotificationSettings(ctx context.Context) (*NotificationSettings, error)
+	UpdateNotificationSettings(ctx context.Context, req *UpdateNotificationSettingsRequest) (*NotificationSettings, error)
+	IssueScopedToken(ctx context.Context, req *ScopedTokenRequest) (*ScopedTokenResponse, error)
+	InternalGetUserInfo(ctx context.Context, opts ...RequestOption) (*UserInfo, error)
+	InternalGetUserInfoById(ctx context.Context, userId string, opts ...RequestOption) (*UserInfo, error)
+	InternalUpdateUserInfo(ctx context.Context, req *UpdateUserRequest, opts ...RequestOption) (*UserInfo, error)
+	InternalGetUsersInfo(ctx context.Context, userIds []string, opts ...RequestOption) ([]*UserInfo, error)
+	InternalGetUsersOnlineStatus(ctx context.Context, userIds []string, opts ...RequestOption) ([]*OnlineStatus, error)
+
+	// Group
+	CreateGroup(ctx context.Context, req *CreateGroupRequest) (*GroupInfo, error)
+	JoinGroup(ctx context.Context, groupId string, inviterId string) error
+	QuitGroup(ctx context.Context, groupId string) error
+	GetGroupInfo(ctx context.Context, groupId string) (*GroupInfo, error)
+	GetGroupMembers(ctx context.Context, groupId string) ([]*GroupMember, error)
+	KickGroupMembers(ctx context.Context, groupId string, userIds []string) error
+	MuteGroupMember(ctx context.Context, groupId, userId string, duration time.Duration) error
+	TransferGroupOwnership(ctx context.Context, groupId, newOwnerId string) error
+	PromoteGroupAdmin(ctx context.Context, groupId, userId string) error
+	DemoteGroupAdmin(ctx context.Context, groupId, userId string) error
+	DismissGroup(ctx context.Context, groupId string) error
+	UpdateGroupInfo(ctx context.Context, req *UpdateGroupInfoRequest) (*GroupInfo, error)
+	SetGroupAnnouncement(ctx context.Context, groupId, content string) (*GroupInfo, error)
+	CreateGroupInviteLink(ctx context.Context, req *CreateInviteLinkRequest) (*GroupInviteLink, error)
+	RevokeGroupInviteLink(ctx context.Context, groupId, token string) error
+	JoinGroupByInviteLink(ctx context.Context, token string) (string, error)
+	InternalCreateGroup(ctx context.Context, req *CreateGroupRequest, opts ...RequestOption) (*GroupInfo, error)
+	InternalJoinGroup(ctx context.Context, groupId string, inviterId string, opts ...RequestOption) error
+	InternalQuitGroup(ctx context.Context, groupId string, opts ...RequestOption) error
+	InternalGetGroupInfo(ctx context.Context, groupId string, opts ...RequestOption) (*GroupInfo, error)
+	InternalGetGroupMembers(ctx context.Context, groupId string, opts ...RequestOption) ([]*GroupMember, error)
+	InternalKickGroupMembers(ctx context.Context, groupId string, userIds []string, opts ...RequestOption) error
+	InternalMuteGroupMember(ctx context.Context, groupId, userId string, duration time.Duration, opts ...RequestOption) error
+	InternalTransferGroupOwnership(ctx context.Context, groupId, newOwnerId string, opts ...RequestOption) error
+	InternalPromoteGroupAdmin(ctx context.Context, groupId, userId string, opts ...RequestOption) error
+	InternalDemoteGroupAdmin(ctx context.Context, groupId, userId string, opts ...RequestOption) error
+	InternalDismissGroup(ctx context.Context, groupId string, opts ...RequestOption) error
+	InternalUpdateGroupInfo(ctx context.Context, req *UpdateGroupInfoRequest, opts ...RequestOption) (*GroupInfo, error)
+	InternalSetGroupAnnouncement(ctx context.Context, groupId, content string, opts ...RequestOption) (*GroupInfo, error)
+	InternalCreateGroupInviteLink(ctx context.Context, req *CreateInviteLinkRequest, opts ...RequestOption) (*GroupInviteLink, error)
+	InternalRevokeGroupInviteLink(ctx context.Context, groupId, token string, opts ...RequestOption) error
+	InternalJoinGroupByInviteLink(ctx context.Context, token string, opts ...RequestOption) (string, error)
+
+	// Message
+	SendMessage(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error)
+	InternalSendMessage(ctx context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error)
+	SendMessageWithoutMarkRead(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error)
+	InternalSendMessageWithoutMarkRead(ctx context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error)
+	SendMessagesBatch(ctx context.Context, req *BatchSendMessageRequest) ([]*BatchSendResult, error)
+	InternalSendMessagesBatch(ctx context.Context, req *BatchSendMessageRequest, opts ...RequestOption) ([]*BatchSendResult, error)
+	ForwardMessages(ctx context.Context, req *ForwardMessagesRequest) ([]*ForwardResult, error)
+	SendTextMessage(ctx context.Context, clientMsgId, recvId, text string) (*MessageInfo, error)
+	SendGroupTextMessage(ctx context.Context, clientMsgId, groupId, text string) (*MessageInfo, error)
+	SendTextMessageWithoutMarkRead(ctx context.Context, clientMsgId, recvId, text string) (*MessageInfo, error)
+	SendGroupTextMessageWithoutMarkRead(ctx context.Context, clientMsgId, groupId, text string) (*MessageInfo, error)
+	PullMessages(ctx context.Context, conversationId string, beginSeq, endSeq int64, limit int) (*PullMessagesResponse, error)
+	GetMaxSeq(ctx context.Context, conversationId string) (int64, error)
+	CreateReminder(ctx context.Context, req *CreateReminderRequest) (*MessageReminder, error)
+	ListReminders(ctx context.Context) ([]*MessageReminder, error)
+	CancelReminder(ctx context.Context, reminderId int64) error
+	AddFavorite(ctx context.Context, conversationId string, seq int64) (*MessageFavorite, error)
+	ListFavorites(ctx context.Context, cursor int64, limit int) (*FavoriteListPage, error)
+	RemoveFavorite(ctx context.Context, favoriteId int64) error
+	RevokeMessage(ctx context.Context, conversationId string, seq int64) (*MessageInfo, error)
+	DeleteMessage(ctx context.Context, conversationId string, seq int64, scope string) error
+	EditMessage(ctx context.Context, req *EditMessageRequest) (*MessageInfo, error)
+	GetEditHistory(ctx context.Context, conversationId string, seq int64) ([]*MessageEdit, error)
+	GetReadStatus(ctx context.Context, conversationId string, seq int64) (*ReadStatus, error)
+	GetGroupReadMembers(ctx context.Context, conversationId string, seq int64) (*GroupReadMembers, error)
+	ListThread(ctx context.Context, conversationId string, seq int64, cursor int64, limit int) (*ThreadPage, error)
+	AddReaction(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error)
+	RemoveReaction(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error)
+	VotePoll(ctx context.Context, req *VotePollRequest) (*PollResult, error)
+	GetPollResult(ctx context.Context, conversationId string, seq int64) (*PollResult, error)
+	ExportMessages(ctx context.Context, conversationId string) (*MessageIterator, error)
+	InternalExportMessages(ctx context.Context, conversationId string, opts ...RequestOption) (*MessageIterator, error)
+
+	// Conversation
+	GetAllConversationList(ctx context.Context) ([]*ConversationInfo, error)
+	GetAllConversationListWithLastMessage(ctx context.Context, withLastMessage bool) ([]*ConversationInfo, error)
+	GetConversationList(ctx context.Context, limit int, cursor *ConversationListCursor) (*ConversationListPage, error)
+	GetConversationListWithLastMessage(ctx context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor) (*ConversationListPage, error)
+	InternalGetAllConversationList(ctx context.Context, opts ...RequestOption) ([]*ConversationInfo, error)
+	InternalGetAllConversationListWithLastMessage(ctx context.Context, withLastMessage bool, opts ...RequestOption) ([]*ConversationInfo, error)
+	InternalGetConversationList(ctx context.Context, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error)
+	InternalGetConversationListWithLastMessage(ctx context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error)
+	GetConversation(ctx context.Context, conversationId string) (*ConversationInfo, error)
+	GetSavedConversation(ctx context.Context) (*ConversationInfo, error)
+	UpdateConversation(ctx context.Context, conversationId string, req *UpdateConversationRequest) error
+	SetConversationPinned(ctx context.Context, conversationId string, isPinned bool) error
+	SetConversationRecvMsgOpt(ctx context.Context, conversationId string, recvMsgOpt int32) error
+	SetConversationTTL(ctx context.Context, conversationId string, ttlSeconds int32) error
+	PinMessage(ctx context.Context, conversationId string, seq int64) (*PinChange, error)
+	UnpinMessage(ctx context.Context, conversationId string, seq int64) (*PinChange, error)
+	GetPinnedMessages(ctx context.Context, conversationId string) ([]*PinnedMessage, error)
+	MarkRead(ctx context.Context, conversationId string, readSeq int64) error
+	GetMaxReadSeq(ctx context.Context, conversationId string) (*MaxReadSeqResponse, error)
+	GetUnreadCount(ctx context.Context, conversationId string, readSeq int64) (int64, error)
+}
+
+var (
+	_ ClientAPI = (*Client)(nil)
+	_ ClientAPI = (*MockClient)(nil)
+	_ ClientAPI = (*FakeClient)(nil)
+)
@@ -1,7 +1,16 @@
 package sdk
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/protocol"
 	"github.com/stretchr/testify/require"
@@ -18,3 +27,163 @@ func TestRequestReturnsHTTPStatusErrorBeforeJSONDecode(t *testing.T) {
 	require.Contains(t, err.Error(), "unexpected status 404")
 	require.Contains(t, err.Error(), "Not Found")
 }
+
+func TestRequestDecodesValidationErrorDetails(t *testing.T) {
+	resp := &protocol.Response{}
+	resp.SetStatusCode(400)
+	resp.SetBodyString(`{"code":1001,"message":"invalid parameter","details":[{"field":"group_id","reason":"too_long","limit":64}]}`)
+
+	err := decodeAPIResponse(resp, nil)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, CodeInvalidParam, apiErr.Code)
+	require.Len(t, apiErr.Details, 1)
+	require.Equal(t, "group_id", apiErr.Details[0].Field)
+	require.Equal(t, "too_long", apiErr.Details[0].Reason)
+	require.EqualValues(t, 64, *apiErr.Details[0].Limit)
+}
+
+func TestRequestRefreshesExpiredAccessToken(t *testing.T) {
+	var refreshes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/im/auth/refresh":
+			refreshes++
+			body, _ := io.ReadAll(r.Body)
+			require.Contains(t, string(body), `"refresh_token":"refresh-1"`)
+			_, _ = io.WriteString(w, `{"code":0,"data":{"token":"access-2","refresh_token":"refresh-2","expires_in":900}}`)
+		default:
+			if !strings.HasSuffix(r.Header.Get("Authorization"), "access-2") {
+				_, _ = io.WriteString(w, `{"code":2001,"message":"token invalid"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"code":0,"data":{"id":"u1"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL, WithToken("access-1"), WithRefreshToken("refresh-1"))
+	info, err := c.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "u1", info.Id)
+	require.Equal(t, 1, refreshes)
+	require.Equal(t, "access-2", c.GetToken())
+	require.Equal(t, "refresh-2", c.GetRefreshToken())
+}
+
+func TestRequestTimeouts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = io.WriteString(w, `{"code":0,"data":{"id":"u1"}}`)
+	}))
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL, WithDefaultTimeout(50*time.Millisecond))
+	start := time.Now()
+	_, err := c.GetUserInfo(context.Background())
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 250*time.Millisecond)
+
+	// A per-call timeout overrides the default
+	info, err := c.InternalGetUserInfo(context.Background(), WithTimeout(2*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, "u1", info.Id)
+
+	// The context deadline shortens the timeout
+	c = MustNewClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err = c.GetUserInfo(ctx)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 250*time.Millisecond)
+
+	<-ctx.Done()
+	_, err = c.GetUserInfo(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequestSigningIsConsistentAcrossVerbs(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// The server signs the path without the query string
+		want := signInternalRequest("s3cret", "svc", r.Header.Get("X-Timestamp"), r.Method, r.URL.Path, body)
+		if r.Header.Get("X-Signature") != want {
+			_, _ = io.WriteString(w, `{"code":1003,"message":"bad signature"}`)
+			return
+		}
+		mu.Lock()
+		methods = append(methods, r.Method+" "+r.URL.RequestURI())
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	c := MustNewInternalClient(srv.URL, "svc", "s3cret")
+	ctx := context.Background()
+	require.NoError(t, c.del(ctx, "/im/res/delete", map[string]string{"id": "1"}, nil, nil))
+	require.NoError(t, c.del(ctx, "/im/res/delete", nil, map[string]string{"id": "2"}, nil))
+	require.NoError(t, c.patch(ctx, "/im/res/patch", map[string]string{"name": "x"}, nil))
+	require.NoError(t, c.SetConversationPinned(ctx, "sg_g1", true))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{
+		"DELETE /im/res/delete?id=1",
+		"DELETE /im/res/delete",
+		"PATCH /im/res/patch",
+		"PUT /im/conversation/update?conversation_id=sg_g1",
+	}, methods)
+}
+
+func TestWithRequestTokenServesConcurrentUsersOnOneClient(t *testing.T) {
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/im/auth/refresh":
+			refreshes.Add(1)
+			_, _ = io.WriteString(w, `{"code":0,"data":{"token":"shared2","refresh_token":"r2"}}`)
+		case "/im/user/info":
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "expired" {
+				_, _ = io.WriteString(w, `{"code":2002,"message":"token expired"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"code":0,"data":{"id":"`+token+`"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL, WithToken("shared"), WithRefreshToken("r1"))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				c.SetToken("shared")
+				c.SetIgnoreAuth(false)
+			}
+			userToken := "user" + strconv.Itoa(i)
+			var info UserInfo
+			require.NoError(t, c.get(ctx, "/im/user/info", nil, &info, WithRequestToken(userToken)))
+			require.Equal(t, userToken, info.Id)
+		}()
+	}
+	wg.Wait()
+
+	info, err := c.GetUserInfo(ContextWithToken(ctx, "user42"))
+	require.NoError(t, err)
+	require.Equal(t, "user42", info.Id)
+
+	// A rejected per-request token is not refreshed with the client's refresh token
+	err = c.get(ctx, "/im/user/info", nil, nil, WithRequestToken("expired"))
+	requireCode(t, err, CodeTokenExpired)
+	_, err = c.GetUserInfo(ContextWithToken(ctx, "expired"))
+	requireCode(t, err, CodeTokenExpired)
+	require.Zero(t, refreshes.Load())
+	require.Equal(t, "shared", c.GetToken())
+}
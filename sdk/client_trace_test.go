@@ -13,7 +13,7 @@ func TestApplyAuthHeaders_WithTraceIDFromContext(t *testing.T) {
 	req := &protocol.Request{}
 	ctx := context.WithValue(context.Background(), traceIDContextKey, "trace-from-ctx")
 
-	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, nil)
+	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, "", nil)
 
 	require.Equal(t, "trace-from-ctx", string(req.Header.Peek(traceIDHeader)))
 	require.Equal(t, "trace-from-ctx", string(req.Header.Peek(xTraceIDHeader)))
@@ -23,7 +23,7 @@ func TestApplyAuthHeaders_WithoutTraceIDFromContext(t *testing.T) {
 	c := &Client{}
 	req := &protocol.Request{}
 
-	c.applyAuthHeaders(context.Background(), req, "GET", "/im/health", nil, nil)
+	c.applyAuthHeaders(context.Background(), req, "GET", "/im/health", nil, "", nil)
 
 	require.Empty(t, string(req.Header.Peek(traceIDHeader)))
 	require.Empty(t, string(req.Header.Peek(xTraceIDHeader)))
@@ -34,7 +34,7 @@ func TestApplyAuthHeaders_WithTraceIDHeaderKeyInContext(t *testing.T) {
 	req := &protocol.Request{}
 	ctx := context.WithValue(context.Background(), traceIDHeader, "trace-from-header-key")
 
-	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, nil)
+	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, "", nil)
 
 	require.Equal(t, "trace-from-header-key", string(req.Header.Peek(traceIDHeader)))
 	require.Equal(t, "trace-from-header-key", string(req.Header.Peek(xTraceIDHeader)))
@@ -45,8 +45,19 @@ func TestApplyAuthHeaders_WithTraceIDBytesInContext(t *testing.T) {
 	req := &protocol.Request{}
 	ctx := context.WithValue(context.Background(), traceIDContextKey, []byte("trace-from-bytes"))
 
-	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, nil)
+	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, "", nil)
 
 	require.Equal(t, "trace-from-bytes", string(req.Header.Peek(traceIDHeader)))
 	require.Equal(t, "trace-from-bytes", string(req.Header.Peek(xTraceIDHeader)))
 }
+
+func TestApplyAuthHeaders_WithTraceparentFromContext(t *testing.T) {
+	c := &Client{}
+	req := &protocol.Request{}
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := context.WithValue(context.Background(), traceparentKey, traceparent)
+
+	c.applyAuthHeaders(ctx, req, "GET", "/im/health", nil, "", nil)
+
+	require.Equal(t, traceparent, string(req.Header.Peek(traceparentKey)))
+}
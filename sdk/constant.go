@@ -10,6 +10,7 @@ const (
 const (
 	SessionTypeSingle = 1 // Single chat
 	SessionTypeGroup  = 2 // Group chat
+	SessionTypeSystem = 3 // System notification, e.g. admin announcements
 )
 
 // Message types
@@ -19,6 +20,9 @@ const (
 	MsgTypeVideo  = 3
 	MsgTypeAudio  = 4
 	MsgTypeFile   = 5
+	MsgTypePoll   = 7 // Content.Poll, voted on with VotePoll
+	MsgTypeRevoke = 8 // Content.Revoke, added by the server when a message is recalled
+	MsgTypeMerged = 9 // Content.Merged, built by ForwardMessages
 	MsgTypeCustom = 100
 )
 
@@ -55,6 +59,12 @@ const (
 	RecvMsgOptNotRecv  = 2 // Do not receive
 )
 
+// Message delete scopes, see DeleteMessage
+const (
+	DeleteScopeSelf = "self" // Hidden for the current user only
+	DeleteScopeAll  = "all"  // Content cleared for every participant
+)
+
 // Platform Ids
 const (
 	PlatformIdUnknown = 0
@@ -63,6 +73,7 @@ const (
 	PlatformIdWindows = 3
 	PlatformIdMacOS   = 4
 	PlatformIdWeb     = 5
+	PlatformIdBot     = 6 // requests authenticated with an API key
 )
 
 // PlatformIdToName converts platform Id to name
@@ -78,7 +89,16 @@ func PlatformIdToName(platformId int) string {
 		return "macOS"
 	case PlatformIdWeb:
 		return "Web"
+	case PlatformIdBot:
+		return "Bot"
 	default:
 		return "Unknown"
 	}
 }
+
+// Message reminder status
+const (
+	ReminderStatusPending   = 0 // Waiting to be delivered
+	ReminderStatusSent      = 1 // Delivered
+	ReminderStatusCancelled = 2 // Cancelled by the user
+)
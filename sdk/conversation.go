@@ -3,6 +3,8 @@ package sdk
 import (
 	"context"
 	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // GetAllConversationList gets all conversations for the current user.
@@ -102,13 +104,20 @@ func (c *Client) GetConversation(ctx context.Context, conversationId string) (*C
 	return &result, nil
 }
 
+// GetSavedConversation gets the saved messages conversation, the single chat with oneself,
+// creating it on first use. Send to it with RecvId set to one's own user id.
+func (c *Client) GetSavedConversation(ctx context.Context) (*ConversationInfo, error) {
+	var result ConversationInfo
+	if err := c.get(ctx, "/im/conversation/saved", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // UpdateConversation updates conversation settings
 func (c *Client) UpdateConversation(ctx context.Context, conversationId string, req *UpdateConversationRequest) error {
 	params := map[string]string{"conversation_id": conversationId}
-	// Build URL with query parameters for PUT request
-	path := "/im/conversation/update?conversation_id=" + conversationId
-	_ = params // params not used in PUT body approach
-	return c.put(ctx, path, req, nil)
+	return c.requestWithQuery(ctx, consts.MethodPut, "/im/conversation/update", params, req, nil)
 }
 
 // SetConversationPinned sets the pinned status of a conversation
@@ -125,6 +134,48 @@ func (c *Client) SetConversationRecvMsgOpt(ctx context.Context, conversationId s
 	})
 }
 
+// SetConversationTTL sets the disappearing messages timer of a conversation in seconds, 0 turns
+// it off. The timer is shared by all participants; in groups only admins may set it.
+func (c *Client) SetConversationTTL(ctx context.Context, conversationId string, ttlSeconds int32) error {
+	return c.UpdateConversation(ctx, conversationId, &UpdateConversationRequest{
+		TTLSeconds: &ttlSeconds,
+	})
+}
+
+// PinMessage pins a message in a conversation for all participants; in groups only admins may
+// pin. Pinning a pinned message is a no-op.
+func (c *Client) PinMessage(ctx context.Context, conversationId string, seq int64) (*PinChange, error) {
+	return c.changePin(ctx, "/im/conversation/pin_msg", conversationId, seq)
+}
+
+// UnpinMessage unpins a message in a conversation
+func (c *Client) UnpinMessage(ctx context.Context, conversationId string, seq int64) (*PinChange, error) {
+	return c.changePin(ctx, "/im/conversation/unpin_msg", conversationId, seq)
+}
+
+func (c *Client) changePin(ctx context.Context, path, conversationId string, seq int64) (*PinChange, error) {
+	req := &PinMessageRequest{
+		ConversationId: conversationId,
+		Seq:            seq,
+	}
+	var change PinChange
+	if err := c.post(ctx, path, req, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetPinnedMessages gets the messages pinned in a conversation that the user can see, newest
+// pin first
+func (c *Client) GetPinnedMessages(ctx context.Context, conversationId string) ([]*PinnedMessage, error) {
+	params := map[string]string{"conversation_id": conversationId}
+	var result []*PinnedMessage
+	if err := c.get(ctx, "/im/conversation/pinned_msgs", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // MarkRead marks a conversation as read up to a seq
 func (c *Client) MarkRead(ctx context.Context, conversationId string, readSeq int64) error {
 	req := &MarkReadRequest{
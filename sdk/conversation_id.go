@@ -0,0 +1,82 @@
+package sdk
+
+import "strings"
+
+// Conversation id prefixes, the same as the server's
+const (
+	singleConversationPrefix = "si_"
+	groupConversationPrefix  = "sg_"
+	systemConversationPrefix = "sn_"
+)
+
+// SingleConversationId returns the conversation id of the single chat between two users.
+// The order of the arguments does not matter.
+// Format: si_{min(userA,userB)}:{max(userA,userB)}
+func SingleConversationId(userA, userB string) string {
+	if userA > userB {
+		userA, userB = userB, userA
+	}
+	return singleConversationPrefix + userA + ":" + userB
+}
+
+// SavedConversationId returns the conversation id of a user's saved messages, the single
+// chat with themselves
+// Format: si_{userId}:{userId}
+func SavedConversationId(userId string) string {
+	return SingleConversationId(userId, userId)
+}
+
+// GroupConversationId returns the conversation id of a group chat
+// Format: sg_{groupId}
+func GroupConversationId(groupId string) string {
+	return groupConversationPrefix + groupId
+}
+
+// SystemConversationId returns the conversation id of a user's system notifications
+// Format: sn_{userId}
+func SystemConversationId(userId string) string {
+	return systemConversationPrefix + userId
+}
+
+// ConversationSessionType returns the SessionType of a conversation id, 0 if the id
+// has no known prefix
+func ConversationSessionType(conversationId string) int32 {
+	switch {
+	case strings.HasPrefix(conversationId, singleConversationPrefix):
+		return SessionTypeSingle
+	case strings.HasPrefix(conversationId, groupConversationPrefix):
+		return SessionTypeGroup
+	case strings.HasPrefix(conversationId, systemConversationPrefix):
+		return SessionTypeSystem
+	default:
+		return 0
+	}
+}
+
+// ParseSingleConversationId returns the two users of a single chat conversation id,
+// ok is false for other ids
+func ParseSingleConversationId(conversationId string) (userA, userB string, ok bool) {
+	rest, found := strings.CutPrefix(conversationId, singleConversationPrefix)
+	if !found {
+		return "", "", false
+	}
+	userA, userB, ok = strings.Cut(rest, ":")
+	if !ok || userA == "" || userB == "" {
+		return "", "", false
+	}
+	return userA, userB, true
+}
+
+// GenSingleConversationId returns the conversation id of the single chat between two users
+//
+// Deprecated: use SingleConversationId.
+func GenSingleConversationId(userA, userB string) string {
+	return SingleConversationId(userA, userB)
+}
+
+// GenGroupConversationId returns the conversation id of a group chat
+//
+// Deprecated: use GroupConversationId.
+func GenGroupConversationId(groupId string) string {
+	return GroupConversationId(groupId)
+}
@@ -0,0 +1,29 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationIds(t *testing.T) {
+	require.Equal(t, "si_alice:bob", SingleConversationId("bob", "alice"))
+	require.Equal(t, SingleConversationId("alice", "bob"), SingleConversationId("bob", "alice"))
+	require.Equal(t, "si_user_1:user_2", SingleConversationId("user_2", "user_1"))
+	require.Equal(t, "sg_g1", GroupConversationId("g1"))
+	require.Equal(t, "sn_alice", SystemConversationId("alice"))
+	require.Equal(t, "si_alice:alice", SavedConversationId("alice"))
+
+	require.EqualValues(t, SessionTypeSingle, ConversationSessionType("si_alice:bob"))
+	require.EqualValues(t, SessionTypeGroup, ConversationSessionType("sg_g1"))
+	require.EqualValues(t, SessionTypeSystem, ConversationSessionType("sn_alice"))
+	require.Zero(t, ConversationSessionType("g1"))
+
+	a, b, ok := ParseSingleConversationId("si_user_1:user_2")
+	require.True(t, ok)
+	require.Equal(t, []string{"user_1", "user_2"}, []string{a, b})
+	_, _, ok = ParseSingleConversationId("sg_g1")
+	require.False(t, ok)
+	_, _, ok = ParseSingleConversationId("si_alice")
+	require.False(t, ok)
+}
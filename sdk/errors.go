@@ -4,14 +4,26 @@ import "fmt"
 
 // Error represents an API error
 type Error struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
+	Code    int          `json:"code"`
+	Msg     string       `json:"msg"`
+	Details []FieldError `json:"details,omitempty"` // invalid fields of a rejected request
 }
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("code: %d, msg: %s", e.Code, e.Msg)
 }
 
+// StatusError is a non-2xx HTTP response without an API error body, e.g. a 404 from a
+// server that lacks the route or a 5xx from a proxy
+type StatusError struct {
+	StatusCode int
+	Body       string // preview of the response body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
 // NewError creates a new error
 func NewError(code int, msg string) *Error {
 	return &Error{Code: code, Msg: msg}
@@ -45,6 +57,21 @@ const (
 	CodeUserNotFound  = 2006
 	CodeUserExists    = 2007
 	CodePasswordWrong = 2008
+	CodeUserBanned    = 2009
+	CodeRefreshReused = 2010
+	CodeNotVerified   = 2011
+	CodeVerifyFailed  = 2012
+	CodeResendTooSoon = 2013
+	CodeContactExists = 2014
+	CodeOAuthFailed   = 2015
+	CodeLoginLocked   = 2016
+	CodeCaptchaNeeded = 2017
+	CodeCaptchaFailed = 2018
+	CodeAPIKeyInvalid = 2019
+	CodeNotBotUser    = 2020
+	CodeGuestExpired  = 2021
+	CodeNotGuestUser  = 2022
+	CodeHandleTaken   = 2023
 
 	// Group errors (3xxx)
 	CodeGroupNotFound      = 3001
@@ -55,6 +82,9 @@ const (
 	CodeNotGroupOwner      = 3006
 	CodeNotGroupAdmin      = 3007
 	CodeCannotKickOwner    = 3008
+	CodeMemberMuted        = 3009
+	CodeGroupMuted         = 3010
+	CodeInviteLinkInvalid  = 3011
 
 	// Message errors (4xxx)
 	CodeMessageNotFound  = 4001
@@ -63,6 +93,10 @@ const (
 	CodeSeqAllocFailed   = 4004
 	CodeSendFailed       = 4005
 	CodePullFailed       = 4006
+	CodeMessageRejected  = 4007
+	CodeRevokeExpired    = 4009
+	CodeMessageRevoked   = 4010
+	CodeEditConflict     = 4011
 
 	// WebSocket errors (5xxx)
 	CodeConnOverLimit   = 5001
@@ -92,4 +126,17 @@ var (
 	ErrGroupDismissed     = NewError(CodeGroupDismissed, "group has been dismissed")
 	ErrNotGroupMember     = NewError(CodeNotGroupMember, "not a group member")
 	ErrAlreadyGroupMember = NewError(CodeAlreadyGroupMember, "already a group member")
+	ErrNotGroupOwner      = NewError(CodeNotGroupOwner, "not group owner")
+	ErrNotGroupAdmin      = NewError(CodeNotGroupAdmin, "not group admin")
+	ErrCannotKickOwner    = NewError(CodeCannotKickOwner, "cannot kick group owner")
+	ErrMemberMuted        = NewError(CodeMemberMuted, "member is muted")
+	ErrGroupMuted         = NewError(CodeGroupMuted, "group is muted")
+	ErrInviteLinkInvalid  = NewError(CodeInviteLinkInvalid, "invite link is invalid or expired")
+
+	ErrMessageNotFound = NewError(CodeMessageNotFound, "message not found")
+	ErrConvNotFound    = NewError(CodeConvNotFound, "conversation not found")
+	ErrMessageRejected = NewError(CodeMessageRejected, "message rejected by policy")
+	ErrRevokeExpired   = NewError(CodeRevokeExpired, "message can no longer be revoked")
+	ErrMessageRevoked  = NewError(CodeMessageRevoked, "message already revoked")
+	ErrEditConflict    = NewError(CodeEditConflict, "message was edited meanwhile")
 )
@@ -0,0 +1,433 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// WebSocket push frame identifiers
+const (
+	PushNewMessage          = 2001
+	PushKicked              = 2002
+	PushReadReceipt         = 2003
+	PushConversationChanged = 2004
+	PushMessageEdited       = 2005
+	PushProfileChanged      = 2007
+	PushPollUpdated         = 2008
+	PushMessageRevoked      = 2009
+	PushReactionChanged     = 2010
+	PushReadCounts          = 2011
+	PushTyping              = 2012
+	PushDelivered           = 2013
+	PushPinChanged          = 2014
+	PushMessageDeleted      = 2015
+)
+
+// Frame is a frame received from the WebSocket gateway
+type Frame struct {
+	ReqIdentifier int32  `json:"req_identifier"`
+	MsgIncr       string `json:"msg_incr"`
+	OperationId   string `json:"operation_id"`
+	ErrCode       int    `json:"err_code"`
+	ErrMsg        string `json:"err_msg"`
+	Data          []byte `json:"data"`
+}
+
+// NewMessageEvent is a message pushed to the connection
+type NewMessageEvent struct {
+	ServerMsgId    int64          `json:"server_msg_id"`
+	ConversationId string         `json:"conversation_id"`
+	Seq            int64          `json:"seq"`
+	ClientMsgId    string         `json:"client_msg_id"`
+	SenderId       string         `json:"sender_id"`
+	RecvId         string         `json:"recv_id,omitempty"`
+	GroupId        string         `json:"group_id,omitempty"`
+	SessionType    int32          `json:"session_type"`
+	MsgType        int32          `json:"msg_type"`
+	Content        MessageContent `json:"content"`
+	Extra          *string        `json:"extra,omitempty"`
+	SendAt         int64          `json:"send_at"`
+	EditVersion    int32          `json:"edit_version,omitempty"`
+	Forwarded      bool           `json:"forwarded,omitempty"`
+	ExpiresAt      int64          `json:"expires_at,omitempty"`
+	QuotedMsg      *QuotedMessage `json:"quoted_msg,omitempty"`
+	Notify         *NotifyHint    `json:"notify,omitempty"` // nil when the receiver uses the default notification settings
+}
+
+// NotifyHint is the receiver's notification settings effective on the platform of the connection
+type NotifyHint struct {
+	Mute        bool `json:"mute"`
+	Sound       bool `json:"sound"`
+	Vibrate     bool `json:"vibrate"`
+	ShowPreview bool `json:"show_preview"`
+}
+
+// ReadReceiptEvent tells that UserId read a conversation up to ReadSeq. It is sent when the
+// read seq advances, to the reader's own connections and, in single chats, to the peer: the
+// peer's messages up to ReadSeq were read.
+type ReadReceiptEvent struct {
+	ConversationId string `json:"conversation_id"`
+	UserId         string `json:"user_id"`
+	ReadSeq        int64  `json:"read_seq"`
+}
+
+// ConversationChangedEvent tells that the current user changed conversation settings,
+// possibly from another device, or that a participant changed the disappearing messages timer
+// (TTLSeconds). Only the changed fields are set.
+type ConversationChangedEvent struct {
+	ConversationId string `json:"conversation_id"`
+	RecvMsgOpt     *int32 `json:"recv_msg_opt,omitempty"`
+	IsPinned       *bool  `json:"is_pinned,omitempty"`
+	TTLSeconds     *int32 `json:"ttl_seconds,omitempty"`
+}
+
+// ProfileChangedEvent tells that UserId changed nickname or avatar. It is sent to the user's
+// own connections, the peers of the user's single chats and the members of the user's groups.
+type ProfileChangedEvent struct {
+	UserId   string `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+// PollUpdatedEvent carries the new result of a poll after a vote. It is sent to the members of
+// the poll's conversation and never has MyOptions set.
+type PollUpdatedEvent struct {
+	PollResult
+}
+
+// MessageEditedEvent is a message whose content was replaced, by its sender (EditVersion is then
+// incremented) or by the server, e.g. to add a link preview. The seq is unchanged: replace the
+// message with the same ConversationId and Seq. It is sent to the members of the conversation.
+type MessageEditedEvent struct {
+	NewMessageEvent
+}
+
+// MessageRevokedEvent is the revoke notification (MsgTypeRevoke) added when a sender recalls a
+// message; Content.Revoke.Seq is the recalled message, to be shown as recalled. It is sent to
+// the members of the conversation.
+type MessageRevokedEvent struct {
+	NewMessageEvent
+}
+
+// MessageDeletedEvent is a message deleted for everyone by UserId, its content cleared: remove
+// or clear the message with the same ConversationId and Seq. It is sent to the members of the
+// conversation.
+type MessageDeletedEvent struct {
+	MessageDeletion
+}
+
+// ReactionChangedEvent is a reaction added to or removed from a message by UserId. It is sent
+// to the members of the conversation.
+type ReactionChangedEvent struct {
+	ReactionChange
+}
+
+// PinChangedEvent is a message pinned or unpinned in a conversation by UserId. It is sent to
+// the participants of the conversation.
+type PinChangedEvent struct {
+	PinChange
+}
+
+// ReadCountsEvent carries the new read counts of group messages of the receiver after UserId
+// read them. Only the latest messages read are counted, older ones get theirs when pulled.
+type ReadCountsEvent struct {
+	ConversationId string              `json:"conversation_id"`
+	UserId         string              `json:"user_id"`
+	Counts         []*MessageReadCount `json:"counts"`
+}
+
+// DeliveredEvent tells the sender of single chat messages that the ones up to DeliveredSeq
+// reached UserId, pushed to one of their connections or pulled
+type DeliveredEvent struct {
+	ConversationId string `json:"conversation_id"`
+	UserId         string `json:"user_id"`
+	DeliveredSeq   int64  `json:"delivered_seq"`
+}
+
+// TypingEvent tells that UserId started or, when Typing is false, stopped typing in a
+// conversation. Starts are repeated while typing; drop a start not refreshed within 6 seconds.
+type TypingEvent struct {
+	ConversationId string `json:"conversation_id"`
+	UserId         string `json:"user_id"`
+	Typing         bool   `json:"typing"`
+}
+
+// KickedEvent tells that the server closed the connection, e.g. after a login on the
+// same platform or a revoked session
+type KickedEvent struct{}
+
+// EventDispatcher decodes push frames of the WebSocket gateway and calls the typed
+// handlers registered for them. Feed it every frame read from the connection with Dispatch.
+// Handlers run on the goroutine calling Dispatch, in registration order.
+type EventDispatcher struct {
+	mu                    sync.RWMutex
+	onNewMessage          []func(*NewMessageEvent)
+	onReadReceipt         []func(*ReadReceiptEvent)
+	onConversationChanged []func(*ConversationChangedEvent)
+	onProfileChanged      []func(*ProfileChangedEvent)
+	onPollUpdated         []func(*PollUpdatedEvent)
+	onMessageEdited       []func(*MessageEditedEvent)
+	onMessageRevoked      []func(*MessageRevokedEvent)
+	onMessageDeleted      []func(*MessageDeletedEvent)
+	onReactionChanged     []func(*ReactionChangedEvent)
+	onReadCounts          []func(*ReadCountsEvent)
+	onTyping              []func(*TypingEvent)
+	onDelivered           []func(*DeliveredEvent)
+	onPinChanged          []func(*PinChangedEvent)
+	onKicked              []func(*KickedEvent)
+}
+
+// NewEventDispatcher creates an EventDispatcher without handlers
+func NewEventDispatcher() *EventDispatcher {
+	return &EventDispatcher{}
+}
+
+// OnNewMessage registers a handler called for each pushed message, in seq order per conversation
+func (d *EventDispatcher) OnNewMessage(handler func(*NewMessageEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onNewMessage = append(d.onNewMessage, handler)
+}
+
+// OnReadReceipt registers a handler for read receipts
+func (d *EventDispatcher) OnReadReceipt(handler func(*ReadReceiptEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onReadReceipt = append(d.onReadReceipt, handler)
+}
+
+// OnConversationChanged registers a handler for conversation settings changes
+func (d *EventDispatcher) OnConversationChanged(handler func(*ConversationChangedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onConversationChanged = append(d.onConversationChanged, handler)
+}
+
+// OnProfileChanged registers a handler for nickname and avatar changes of contacts
+func (d *EventDispatcher) OnProfileChanged(handler func(*ProfileChangedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onProfileChanged = append(d.onProfileChanged, handler)
+}
+
+// OnPollUpdated registers a handler for poll result updates
+func (d *EventDispatcher) OnPollUpdated(handler func(*PollUpdatedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onPollUpdated = append(d.onPollUpdated, handler)
+}
+
+// OnMessageEdited registers a handler for edited messages
+func (d *EventDispatcher) OnMessageEdited(handler func(*MessageEditedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onMessageEdited = append(d.onMessageEdited, handler)
+}
+
+// OnMessageRevoked registers a handler for recalled messages
+func (d *EventDispatcher) OnMessageRevoked(handler func(*MessageRevokedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onMessageRevoked = append(d.onMessageRevoked, handler)
+}
+
+// OnReactionChanged registers a handler for reaction changes
+func (d *EventDispatcher) OnReactionChanged(handler func(*ReactionChangedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onReactionChanged = append(d.onReactionChanged, handler)
+}
+
+// OnReadCounts registers a handler for read count changes of the user's group messages
+func (d *EventDispatcher) OnReadCounts(handler func(*ReadCountsEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onReadCounts = append(d.onReadCounts, handler)
+}
+
+// OnTyping registers a handler for typing statuses of peers and group members
+func (d *EventDispatcher) OnTyping(handler func(*TypingEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onTyping = append(d.onTyping, handler)
+}
+
+// OnDelivered registers a handler for delivery receipts of the user's single chat messages
+func (d *EventDispatcher) OnDelivered(handler func(*DeliveredEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onDelivered = append(d.onDelivered, handler)
+}
+
+// OnPinChanged registers a handler for messages pinned and unpinned in conversations
+func (d *EventDispatcher) OnPinChanged(handler func(*PinChangedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onPinChanged = append(d.onPinChanged, handler)
+}
+
+// OnMessageDeleted registers a handler for messages deleted for everyone
+func (d *EventDispatcher) OnMessageDeleted(handler func(*MessageDeletedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onMessageDeleted = append(d.onMessageDeleted, handler)
+}
+
+// OnKicked registers a handler for the kick notice sent before the server closes the connection
+func (d *EventDispatcher) OnKicked(handler func(*KickedEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onKicked = append(d.onKicked, handler)
+}
+
+// Dispatch decodes a raw frame and calls the handlers of its event. It reports whether the
+// frame was a push event; responses to requests are left to the caller.
+func (d *EventDispatcher) Dispatch(raw []byte) (bool, error) {
+	var frame Frame
+	if err := json.Unmarshal(raw, &frame); err != nil {
+		return false, fmt.Errorf("decode frame: %w", err)
+	}
+	return d.DispatchFrame(&frame)
+}
+
+// DispatchFrame calls the handlers of an already decoded frame, see Dispatch
+func (d *EventDispatcher) DispatchFrame(frame *Frame) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	switch frame.ReqIdentifier {
+	case PushNewMessage:
+		var data struct {
+			Msgs map[string][]*NewMessageEvent `json:"msgs"`
+		}
+		if err := decodeFrameData(frame, &data); err != nil {
+			return true, err
+		}
+		conversationIds := make([]string, 0, len(data.Msgs))
+		for id := range data.Msgs {
+			conversationIds = append(conversationIds, id)
+		}
+		sort.Strings(conversationIds)
+		for _, id := range conversationIds {
+			msgs := data.Msgs[id]
+			sort.Slice(msgs, func(i, j int) bool { return msgs[i].Seq < msgs[j].Seq })
+			for _, msg := range msgs {
+				for _, h := range d.onNewMessage {
+					h(msg)
+				}
+			}
+		}
+	case PushReadReceipt:
+		var event ReadReceiptEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onReadReceipt {
+			h(&event)
+		}
+	case PushConversationChanged:
+		var event ConversationChangedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onConversationChanged {
+			h(&event)
+		}
+	case PushProfileChanged:
+		var event ProfileChangedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onProfileChanged {
+			h(&event)
+		}
+	case PushPollUpdated:
+		var event PollUpdatedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onPollUpdated {
+			h(&event)
+		}
+	case PushMessageEdited:
+		var event MessageEditedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onMessageEdited {
+			h(&event)
+		}
+	case PushMessageRevoked:
+		var event MessageRevokedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onMessageRevoked {
+			h(&event)
+		}
+	case PushReactionChanged:
+		var event ReactionChangedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onReactionChanged {
+			h(&event)
+		}
+	case PushReadCounts:
+		var event ReadCountsEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onReadCounts {
+			h(&event)
+		}
+	case PushTyping:
+		var event TypingEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onTyping {
+			h(&event)
+		}
+	case PushDelivered:
+		var event DeliveredEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onDelivered {
+			h(&event)
+		}
+	case PushPinChanged:
+		var event PinChangedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onPinChanged {
+			h(&event)
+		}
+	case PushMessageDeleted:
+		var event MessageDeletedEvent
+		if err := decodeFrameData(frame, &event); err != nil {
+			return true, err
+		}
+		for _, h := range d.onMessageDeleted {
+			h(&event)
+		}
+	case PushKicked:
+		for _, h := range d.onKicked {
+			h(&KickedEvent{})
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func decodeFrameData(frame *Frame, v any) error {
+	if err := json.Unmarshal(frame.Data, v); err != nil {
+		return fmt.Errorf("decode push %d: %w", frame.ReqIdentifier, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pushFrame(t *testing.T, reqIdentifier int32, data any) []byte {
+	t.Helper()
+	payload, err := json.Marshal(data)
+	require.NoError(t, err)
+	raw, err := json.Marshal(&Frame{ReqIdentifier: reqIdentifier, Data: payload})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestEventDispatcherNewMessageInSeqOrder(t *testing.T) {
+	d := NewEventDispatcher()
+	var seqs []int64
+	d.OnNewMessage(func(e *NewMessageEvent) {
+		seqs = append(seqs, e.Seq)
+	})
+
+	raw := pushFrame(t, PushNewMessage, map[string]any{"msgs": map[string]any{
+		"si_a_b": []map[string]any{{"conversation_id": "si_a_b", "seq": 2}, {"conversation_id": "si_a_b", "seq": 1, "content": map[string]string{"text": "hi"}}},
+	}})
+	handled, err := d.Dispatch(raw)
+	require.NoError(t, err)
+	require.True(t, handled)
+	require.Equal(t, []int64{1, 2}, seqs)
+}
+
+func TestEventDispatcherTypedEvents(t *testing.T) {
+	d := NewEventDispatcher()
+	var receipt *ReadReceiptEvent
+	var changed *ConversationChangedEvent
+	var profile *ProfileChangedEvent
+	var poll *PollUpdatedEvent
+	var edited *MessageEditedEvent
+	var revoked *MessageRevokedEvent
+	var deleted *MessageDeletedEvent
+	var reaction *ReactionChangedEvent
+	var readCounts *ReadCountsEvent
+	var typing *TypingEvent
+	var delivered *DeliveredEvent
+	kicked := false
+	d.OnReadReceipt(func(e *ReadReceiptEvent) { receipt = e })
+	d.OnConversationChanged(func(e *ConversationChangedEvent) { changed = e })
+	d.OnProfileChanged(func(e *ProfileChangedEvent) { profile = e })
+	d.OnPollUpdated(func(e *PollUpdatedEvent) { poll = e })
+	d.OnMessageEdited(func(e *MessageEditedEvent) { edited = e })
+	d.OnMessageRevoked(func(e *MessageRevokedEvent) { revoked = e })
+	d.OnMessageDeleted(func(e *MessageDeletedEvent) { deleted = e })
+	d.OnReactionChanged(func(e *ReactionChangedEvent) { reaction = e })
+	d.OnReadCounts(func(e *ReadCountsEvent) { readCounts = e })
+	d.OnTyping(func(e *TypingEvent) { typing = e })
+	d.OnDelivered(func(e *DeliveredEvent) { delivered = e })
+	d.OnKicked(func(*KickedEvent) { kicked = true })
+
+	_, err := d.Dispatch(pushFrame(t, PushReadReceipt, map[string]any{"conversation_id": "si_a_b", "user_id": "b", "read_seq": 7}))
+	require.NoError(t, err)
+	require.Equal(t, &ReadReceiptEvent{ConversationId: "si_a_b", UserId: "b", ReadSeq: 7}, receipt)
+
+	_, err = d.Dispatch(pushFrame(t, PushConversationChanged, map[string]any{"conversation_id": "si_a_b", "is_pinned": true}))
+	require.NoError(t, err)
+	require.Equal(t, "si_a_b", changed.ConversationId)
+	require.Nil(t, changed.RecvMsgOpt)
+	require.True(t, *changed.IsPinned)
+
+	_, err = d.Dispatch(pushFrame(t, PushProfileChanged, map[string]any{"user_id": "b", "nickname": "Bob", "avatar": "https://example.com/b.png"}))
+	require.NoError(t, err)
+	require.Equal(t, &ProfileChangedEvent{UserId: "b", Nickname: "Bob", Avatar: "https://example.com/b.png"}, profile)
+
+	_, err = d.Dispatch(pushFrame(t, PushPollUpdated, map[string]any{
+		"conversation_id": "sg_g1", "seq": 3, "voter_count": 1,
+		"options": []map[string]any{{"count": 1, "voter_ids": []string{"b"}}, {"count": 0}},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "sg_g1", poll.ConversationId)
+	require.EqualValues(t, 1, poll.VoterCount)
+	require.Equal(t, []string{"b"}, poll.Options[0].VoterIds)
+
+	_, err = d.Dispatch(pushFrame(t, PushMessageEdited, map[string]any{
+		"conversation_id": "sg_g1", "seq": 4, "sender_id": "b", "msg_type": MsgTypeText,
+		"content": map[string]any{"text": "fixed"}, "edit_version": 1,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "fixed", edited.Content.Text)
+	require.EqualValues(t, 1, edited.EditVersion)
+
+	_, err = d.Dispatch(pushFrame(t, PushMessageRevoked, map[string]any{
+		"conversation_id": "sg_g1", "seq": 5, "sender_id": "b", "msg_type": MsgTypeRevoke,
+		"content": map[string]any{"revoke": map[string]any{"seq": 4}},
+	}))
+	require.NoError(t, err)
+	require.EqualValues(t, 5, revoked.Seq)
+	require.EqualValues(t, 4, revoked.Content.Revoke.Seq)
+
+	_, err = d.Dispatch(pushFrame(t, PushMessageDeleted, map[string]any{"conversation_id": "sg_g1", "seq": 3, "user_id": "a"}))
+	require.NoError(t, err)
+	require.Equal(t, &MessageDeletedEvent{MessageDeletion{ConversationId: "sg_g1", Seq: 3, UserId: "a"}}, deleted)
+
+	_, err = d.Dispatch(pushFrame(t, PushReactionChanged, map[string]any{
+		"conversation_id": "sg_g1", "seq": 4, "user_id": "b", "emoji": "👍", "added": true, "count": 2,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, &ReactionChangedEvent{ReactionChange{
+		ConversationId: "sg_g1", Seq: 4, UserId: "b", Emoji: "👍", Added: true, Count: 2,
+	}}, reaction)
+
+	_, err = d.Dispatch(pushFrame(t, PushReadCounts, map[string]any{
+		"conversation_id": "sg_g1", "user_id": "c", "counts": []map[string]any{{"seq": 4, "read_count": 2}},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, &ReadCountsEvent{
+		ConversationId: "sg_g1", UserId: "c", Counts: []*MessageReadCount{{Seq: 4, ReadCount: 2}},
+	}, readCounts)
+
+	_, err = d.Dispatch(pushFrame(t, PushTyping, map[string]any{"conversation_id": "si_a_b", "user_id": "b", "typing": true}))
+	require.NoError(t, err)
+	require.Equal(t, &TypingEvent{ConversationId: "si_a_b", UserId: "b", Typing: true}, typing)
+
+	_, err = d.Dispatch(pushFrame(t, PushDelivered, map[string]any{"conversation_id": "si_a_b", "user_id": "b", "delivered_seq": 9}))
+	require.NoError(t, err)
+	require.Equal(t, &DeliveredEvent{ConversationId: "si_a_b", UserId: "b", DeliveredSeq: 9}, delivered)
+
+	handled, err := d.Dispatch([]byte(`{"req_identifier":2002}`))
+	require.NoError(t, err)
+	require.True(t, handled)
+	require.True(t, kicked)
+}
+
+func TestEventDispatcherIgnoresResponses(t *testing.T) {
+	d := NewEventDispatcher()
+	handled, err := d.Dispatch([]byte(`{"req_identifier":1003,"msg_incr":"1","err_code":0}`))
+	require.NoError(t, err)
+	require.False(t, handled)
+
+	_, err = d.Dispatch([]byte(`not json`))
+	require.Error(t, err)
+}
@@ -0,0 +1,216 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// maxExportLineSize bounds one NDJSON line, well above the largest message the server accepts
+const maxExportLineSize = 4 << 20
+
+// ExportMessages streams the whole visible history of a conversation in seq order. Messages
+// are decoded one at a time while iterating, so histories of any size use constant memory.
+// The caller must Close the iterator.
+//
+//	it, err := c.ExportMessages(ctx, conversationId)
+//	if err != nil { ... }
+//	defer it.Close()
+//	for it.Next() {
+//		msg := it.Message()
+//	}
+//	if err := it.Err(); err != nil { ... }
+func (c *Client) ExportMessages(ctx context.Context, conversationId string) (*MessageIterator, error) {
+	return c.exportMessages(ctx, "/im/msg/export", conversationId)
+}
+
+// InternalExportMessages streams the history of a conversation via internal route.
+func (c *Client) InternalExportMessages(ctx context.Context, conversationId string, opts ...RequestOption) (*MessageIterator, error) {
+	return c.exportMessages(ctx, "/im/internal/msg/export", conversationId, opts...)
+}
+
+func (c *Client) exportMessages(ctx context.Context, path, conversationId string, opts ...RequestOption) (*MessageIterator, error) {
+	resp, err := c.getStream(ctx, path, map[string]string{"conversation_id": conversationId}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	body := resp.BodyStream()
+	if body == protocol.NoResponseBody {
+		// The transport read the whole body up front
+		body = bytes.NewReader(resp.Body())
+	}
+	return newMessageIterator(body, resp.CloseBodyStream), nil
+}
+
+// getStream sends a GET request whose NDJSON response body is read as it arrives. Errors the
+// server reports before streaming come back as regular API errors; the timeout applies to
+// receiving the response headers only. The caller must close the response body stream.
+func (c *Client) getStream(ctx context.Context, path string, params map[string]string, opts ...RequestOption) (*protocol.Response, error) {
+	reqOpts := c.resolveRequestOptions(ctx, opts)
+	timeout, err := c.requestTimeout(ctx, reqOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	send := func(token string) (*protocol.Response, error) {
+		req := &protocol.Request{}
+		resp := &protocol.Response{}
+		req.SetMethod(consts.MethodGet)
+		req.SetRequestURI(c.baseURL + path + "?" + query.Encode())
+		if timeout > 0 {
+			req.SetOptions(config.WithRequestTimeout(timeout))
+		}
+		c.applyAuthHeaders(ctx, req, consts.MethodGet, path, nil, token, reqOpts)
+
+		if err := c.streamRoundTrip(ctx, req, resp); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode() == consts.StatusOK && strings.HasPrefix(string(resp.Header.ContentType()), ndjsonContentType) {
+			return resp, nil
+		}
+		// Body reads the whole error response and closes the stream
+		if err := decodeAPIResponse(resp, nil); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unexpected response content type %q", resp.Header.ContentType())
+	}
+
+	usedToken, err := c.requestToken(ctx, path, reqOpts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := send(usedToken)
+	if reqOpts.token != "" {
+		return resp, err
+	}
+	token, ok := c.renewRejectedToken(ctx, path, usedToken, err)
+	if !ok {
+		return resp, err
+	}
+	return send(token)
+}
+
+// streamRoundTrip sends a request on the streaming transport through the circuit breaker.
+// Streams are never hedged.
+func (c *Client) streamRoundTrip(ctx context.Context, req *protocol.Request, resp *protocol.Response) error {
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			return err
+		}
+	}
+	err := c.streamClient.Do(ctx, req, resp)
+	if c.breaker != nil {
+		c.breaker.record(err == nil && resp.StatusCode() < 500)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	return nil
+}
+
+// MessageIterator reads the messages of an export one at a time. It is not safe for
+// concurrent use.
+type MessageIterator struct {
+	scanner *bufio.Scanner
+	close   func() error
+	msg     *MessageInfo
+	err     error
+	done    bool
+}
+
+// exportLine is one NDJSON line, a message or the error ending a failed export
+type exportLine struct {
+	MessageInfo
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func newMessageIterator(r io.Reader, closeFn func() error) *MessageIterator {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExportLineSize)
+	return &MessageIterator{scanner: scanner, close: closeFn}
+}
+
+// Next advances to the next message, false once the export ended or failed
+func (it *MessageIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	for it.scanner.Scan() {
+		line := it.scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var l exportLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return it.fail(fmt.Errorf("failed to decode exported message: %w", err))
+		}
+		if l.Code != 0 {
+			return it.fail(&Error{Code: l.Code, Msg: l.Message})
+		}
+		msg := l.MessageInfo
+		it.msg = &msg
+		return true
+	}
+	if err := it.scanner.Err(); err != nil {
+		return it.fail(fmt.Errorf("failed to read export: %w", err))
+	}
+	it.done = true
+	it.msg = nil
+	return false
+}
+
+func (it *MessageIterator) fail(err error) bool {
+	it.err = err
+	it.done = true
+	it.msg = nil
+	return false
+}
+
+// Message returns the current message, valid after Next returned true
+func (it *MessageIterator) Message() *MessageInfo {
+	return it.msg
+}
+
+// Err returns the error that ended the iteration, nil when the export completed
+func (it *MessageIterator) Err() error {
+	return it.err
+}
+
+// Close releases the connection; the rest of an unfinished export is discarded
+func (it *MessageIterator) Close() error {
+	it.done = true
+	if it.close == nil {
+		return nil
+	}
+	closeFn := it.close
+	it.close = nil
+	return closeFn()
+}
+
+// newSliceMessageIterator iterates over messages already in memory
+func newSliceMessageIterator(msgs []*MessageInfo) *MessageIterator {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			return &MessageIterator{err: fmt.Errorf("failed to encode message: %w", err), done: true}
+		}
+	}
+	return newMessageIterator(strings.NewReader(buf.String()), nil)
+}
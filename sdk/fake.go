@@ -0,0 +1,3028 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FakeServer is an in-memory stand-in for a nexo_im server, for unit tests of services
+// consuming the SDK. Clients from NewClient share its users, groups and messages, so a
+// test can log in several users and have them chat. It mirrors the server's error codes
+// for the common failures, but does not enforce scopes, rate limits or verification.
+type FakeServer struct {
+	mu       sync.Mutex
+	users    map[string]*fakeUser
+	groups   map[string]*fakeGroup
+	convs    map[string]*fakeConversation
+	sessions map[string]*fakeSession // by access or refresh token
+	invites  map[string]*GroupInviteLink
+	online   map[string]bool
+	nextId   int64
+	lastTime int64
+}
+
+type fakeUser struct {
+	info           UserInfo
+	password       string
+	hideFromSearch bool
+	notify         *NotificationSettings // nil for the defaults
+	reminders      []*MessageReminder    // reminders are never delivered by the fake
+	favorites      []*MessageFavorite    // oldest first
+	// hidden holds the messages the user deleted for themselves keyed by conversation id, then seq
+	hidden map[string]map[int64]bool
+	// convs holds the user's own conversation settings keyed by conversation id
+	convs map[string]*ConversationInfo
+}
+
+type fakeGroup struct {
+	info    GroupInfo
+	members []*GroupMember
+}
+
+type fakeConversation struct {
+	id       string
+	convType int32
+	groupId  string
+	messages []*MessageInfo
+	// pollVotes holds the votes of poll messages keyed by seq, then by voter
+	pollVotes map[int64]map[string][]int
+	// edits holds the versions of messages replaced by edits keyed by seq, oldest first
+	edits map[int64][]*MessageEdit
+	// reactions holds the reactions to messages keyed by seq, oldest first
+	reactions map[int64][]*fakeReaction
+	// quotes holds the quotes of replies as sent, keyed by the seq of the reply
+	quotes map[int64]*QuotedMessage
+	// delivered holds the delivered seqs of single chats keyed by user, raised by pulls only
+	delivered map[string]int64
+	// ttlSeconds is the disappearing messages timer, 0 when off
+	ttlSeconds int32
+	// pins holds the pinned messages, oldest pin first
+	pins []*PinnedMessage
+}
+
+type fakeReaction struct {
+	userId string
+	emoji  string
+}
+
+type fakeSession struct {
+	Session
+	userId       string
+	token        string
+	refreshToken string
+	scoped       bool // scoped tokens are not listed as sessions
+}
+
+// NewFakeServer creates an empty fake server
+func NewFakeServer() *FakeServer {
+	return &FakeServer{
+		users:    make(map[string]*fakeUser),
+		groups:   make(map[string]*fakeGroup),
+		convs:    make(map[string]*fakeConversation),
+		sessions: make(map[string]*fakeSession),
+		invites:  make(map[string]*GroupInviteLink),
+		online:   make(map[string]bool),
+	}
+}
+
+// NewClient creates a logged out client of the fake server
+func (s *FakeServer) NewClient() *FakeClient {
+	return &FakeClient{server: s}
+}
+
+// SetOnline sets the status GetUsersOnlineStatus reports for a user
+func (s *FakeServer) SetOnline(userId string, online bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.online[userId] = online
+}
+
+// now returns a strictly increasing millisecond timestamp so list order is deterministic
+func (s *FakeServer) now() int64 {
+	now := time.Now().UnixMilli()
+	if now <= s.lastTime {
+		now = s.lastTime + 1
+	}
+	s.lastTime = now
+	return now
+}
+
+func (s *FakeServer) genId(prefix string) string {
+	s.nextId++
+	return fmt.Sprintf("%s%d", prefix, s.nextId)
+}
+
+func (s *FakeServer) register(req *RegisterRequest, isGuest bool) (*fakeUser, error) {
+	if req == nil || strings.TrimSpace(req.UserId) == "" || req.Password == "" && !isGuest {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := s.users[req.UserId]; ok {
+		return nil, ErrUserExists
+	}
+	user := &fakeUser{
+		info: UserInfo{
+			Id:        req.UserId,
+			Nickname:  req.Nickname,
+			Avatar:    req.Avatar,
+			IsGuest:   isGuest,
+			CreatedAt: s.now(),
+		},
+		password: req.Password,
+		convs:    make(map[string]*ConversationInfo),
+	}
+	s.users[req.UserId] = user
+	return user, nil
+}
+
+func (s *FakeServer) login(user *fakeUser, platformId int, deviceName string) *LoginResponse {
+	if platformId <= 0 {
+		platformId = PlatformIdWeb
+	}
+	// One session per platform, like the server
+	for key, sess := range s.sessions {
+		if sess.userId == user.info.Id && sess.PlatformId == platformId {
+			delete(s.sessions, key)
+		}
+	}
+	now := s.now()
+	sess := &fakeSession{
+		Session: Session{
+			SessionId:   s.genId("session_"),
+			PlatformId:  platformId,
+			Platform:    PlatformIdToName(platformId),
+			Name:        deviceName,
+			CreatedAt:   now,
+			RefreshedAt: now,
+		},
+		userId: user.info.Id,
+	}
+	s.issueTokens(sess)
+	info := user.info
+	return &LoginResponse{
+		Token:        sess.token,
+		RefreshToken: sess.refreshToken,
+		ExpiresIn:    int64((24 * time.Hour).Seconds()),
+		UserInfo:     &info,
+	}
+}
+
+func (s *FakeServer) issueTokens(sess *fakeSession) {
+	delete(s.sessions, sess.token)
+	delete(s.sessions, sess.refreshToken)
+	sess.token = s.genId("fake-token-")
+	sess.refreshToken = s.genId("fake-refresh-")
+	s.sessions[sess.token] = sess
+	s.sessions[sess.refreshToken] = sess
+}
+
+// session returns the session of an access token
+func (s *FakeServer) session(token string) (*fakeSession, error) {
+	if token == "" {
+		return nil, ErrTokenMissing
+	}
+	sess, ok := s.sessions[token]
+	if !ok || sess.token != token {
+		return nil, ErrTokenInvalid
+	}
+	return sess, nil
+}
+
+func (s *FakeServer) user(userId string) (*fakeUser, error) {
+	user, ok := s.users[userId]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *FakeServer) usersInfo(userIds []string) []*UserInfo {
+	result := make([]*UserInfo, 0, len(userIds))
+	for _, id := range userIds {
+		if user, ok := s.users[id]; ok {
+			info := user.info
+			result = append(result, &info)
+		}
+	}
+	return result
+}
+
+func (s *FakeServer) updateUser(userId string, req *UpdateUserRequest) (*UserInfo, error) {
+	user, err := s.user(userId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Nickname != "" {
+		user.info.Nickname = req.Nickname
+	}
+	if req.Handle != nil {
+		handle := strings.ToLower(*req.Handle)
+		for id, other := range s.users {
+			if handle != "" && id != userId && other.info.Handle == handle {
+				return nil, NewError(CodeHandleTaken, "handle already taken")
+			}
+		}
+		user.info.Handle = handle
+	}
+	if req.HideFromSearch != nil {
+		user.hideFromSearch = *req.HideFromSearch
+	}
+	if req.Avatar != "" {
+		user.info.Avatar = req.Avatar
+	}
+	if req.Extra != "" {
+		extra := req.Extra
+		user.info.Extra = &extra
+	}
+	info := user.info
+	return &info, nil
+}
+
+// searchUsers ranks like the server: exact handle, exact nickname, handle prefix, nickname prefix
+func (s *FakeServer) searchUsers(q string, limit int) ([]*UserInfo, error) {
+	q = strings.TrimSpace(q)
+	handleOnly := strings.HasPrefix(q, "@")
+	q = strings.TrimPrefix(q, "@")
+	if q == "" || limit < 0 || limit > 50 {
+		return nil, ErrInvalidParam
+	}
+	if limit == 0 {
+		limit = 20
+	}
+	handle := strings.ToLower(q)
+	rank := func(info *UserInfo) int {
+		switch {
+		case info.Handle != "" && info.Handle == handle:
+			return 0
+		case !handleOnly && info.Nickname == q:
+			return 1
+		case info.Handle != "" && strings.HasPrefix(info.Handle, handle):
+			return 2
+		case !handleOnly && strings.HasPrefix(info.Nickname, q):
+			return 3
+		}
+		return -1
+	}
+	var result []*UserInfo
+	for _, user := range s.users {
+		if user.hideFromSearch || user.info.IsGuest || rank(&user.info) < 0 {
+			continue
+		}
+		info := user.info
+		result = append(result, &info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if ri, rj := rank(result[i]), rank(result[j]); ri != rj {
+			return ri < rj
+		}
+		if li, lj := len(result[i].Nickname), len(result[j].Nickname); li != lj {
+			return li < lj
+		}
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *FakeServer) onlineStatus(userIds []string) []*OnlineStatus {
+	result := make([]*OnlineStatus, 0, len(userIds))
+	for _, id := range userIds {
+		status := StatusOffline
+		if s.online[id] {
+			status = StatusOnline
+		}
+		result = append(result, &OnlineStatus{UserId: id, Status: status})
+	}
+	return result
+}
+
+func (s *FakeServer) activeMember(group *fakeGroup, userId string) *GroupMember {
+	for _, m := range group.members {
+		if m.UserId == userId && m.Status == GroupMemberStatusNormal {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *FakeServer) addMember(group *fakeGroup, userId, inviterId string, roleLevel int32) {
+	now := s.now()
+	conv := s.conversation(GroupConversationId(group.info.Id), SessionTypeGroup, group.info.Id)
+	member := &GroupMember{
+		Id:            int64(len(group.members) + 1),
+		GroupId:       group.info.Id,
+		UserId:        userId,
+		RoleLevel:     roleLevel,
+		Status:        GroupMemberStatusNormal,
+		JoinedAt:      now,
+		JoinSeq:       int64(len(conv.messages)),
+		InviterUserId: inviterId,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	group.members = append(group.members, member)
+	group.info.MemberCount++
+	s.ownConversation(s.users[userId], conv)
+}
+
+func (s *FakeServer) createGroup(userId string, req *CreateGroupRequest) (*GroupInfo, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, ErrInvalidParam
+	}
+	for _, id := range req.MemberIds {
+		if _, err := s.user(id); err != nil {
+			return nil, err
+		}
+	}
+	group := &fakeGroup{info: GroupInfo{
+		Id:            s.genId("group_"),
+		Name:          req.Name,
+		Introduction:  req.Introduction,
+		Avatar:        req.Avatar,
+		Status:        GroupStatusNormal,
+		CreatorUserId: userId,
+		CreatedAt:     s.now(),
+	}}
+	s.groups[group.info.Id] = group
+	s.addMember(group, userId, "", RoleLevelOwner)
+	for _, id := range req.MemberIds {
+		if id != userId && s.activeMember(group, id) == nil {
+			s.addMember(group, id, userId, RoleLevelMember)
+		}
+	}
+	info := group.info
+	return &info, nil
+}
+
+func (s *FakeServer) joinGroup(userId, groupId, inviterId string) error {
+	group, ok := s.groups[groupId]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	if group.info.Status != GroupStatusNormal {
+		return ErrGroupDismissed
+	}
+	if s.activeMember(group, userId) != nil {
+		return ErrAlreadyGroupMember
+	}
+	s.addMember(group, userId, inviterId, RoleLevelMember)
+	return nil
+}
+
+func (s *FakeServer) quitGroup(userId, groupId string) error {
+	group, ok := s.groups[groupId]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	member := s.activeMember(group, userId)
+	if member == nil {
+		return ErrNotGroupMember
+	}
+	if member.RoleLevel == RoleLevelOwner {
+		return ErrCannotKickOwner
+	}
+	s.removeMember(group, member, GroupMemberStatusLeft)
+	return nil
+}
+
+func (s *FakeServer) removeMember(group *fakeGroup, member *GroupMember, status int32) {
+	member.Status = status
+	member.UpdatedAt = s.now()
+	group.info.MemberCount--
+}
+
+func (s *FakeServer) groupInfo(groupId string) (*GroupInfo, error) {
+	group, ok := s.groups[groupId]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	info := group.info
+	return &info, nil
+}
+
+func (s *FakeServer) groupMembers(groupId string) ([]*GroupMember, error) {
+	group, ok := s.groups[groupId]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	var result []*GroupMember
+	for _, m := range group.members {
+		if m.Status == GroupMemberStatusNormal {
+			member := *m
+			result = append(result, &member)
+		}
+	}
+	return result, nil
+}
+
+// groupAdmin returns the group and the operator's membership when the operator is an admin
+func (s *FakeServer) groupAdmin(operatorId, groupId string) (*fakeGroup, *GroupMember, error) {
+	group, ok := s.groups[groupId]
+	if !ok {
+		return nil, nil, ErrGroupNotFound
+	}
+	if group.info.Status != GroupStatusNormal {
+		return nil, nil, ErrGroupDismissed
+	}
+	operator := s.activeMember(group, operatorId)
+	if operator == nil {
+		return nil, nil, ErrNotGroupMember
+	}
+	if operator.RoleLevel < RoleLevelAdmin {
+		return nil, nil, ErrNotGroupAdmin
+	}
+	return group, operator, nil
+}
+
+// manageableMember returns the target membership when the operator may kick or mute it
+func (s *FakeServer) manageableMember(group *fakeGroup, operator *GroupMember, userId string) (*GroupMember, error) {
+	target := s.activeMember(group, userId)
+	if target == nil {
+		return nil, ErrNotGroupMember
+	}
+	if target.RoleLevel == RoleLevelOwner {
+		return nil, ErrCannotKickOwner
+	}
+	if target.RoleLevel >= RoleLevelAdmin && operator.RoleLevel != RoleLevelOwner {
+		return nil, ErrNotGroupOwner
+	}
+	return target, nil
+}
+
+func (s *FakeServer) kickMembers(operatorId, groupId string, userIds []string) error {
+	group, operator, err := s.groupAdmin(operatorId, groupId)
+	if err != nil {
+		return err
+	}
+	// Check every target first, the server kicks all of them or none
+	targets := make([]*GroupMember, 0, len(userIds))
+	for _, userId := range userIds {
+		target, err := s.manageableMember(group, operator, userId)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	for _, target := range targets {
+		s.removeMember(group, target, GroupMemberStatusKicked)
+	}
+	return nil
+}
+
+func (s *FakeServer) muteMember(operatorId, groupId, userId string, duration time.Duration) error {
+	if duration < 0 {
+		return ErrInvalidParam
+	}
+	group, operator, err := s.groupAdmin(operatorId, groupId)
+	if err != nil {
+		return err
+	}
+	target, err := s.manageableMember(group, operator, userId)
+	if err != nil {
+		return err
+	}
+	target.MutedUntil = 0
+	if seconds := int64(duration / time.Second); seconds > 0 {
+		target.MutedUntil = time.Now().UnixMilli() + seconds*1000
+	}
+	target.UpdatedAt = s.now()
+	return nil
+}
+
+func (s *FakeServer) transferOwnership(ownerId, groupId, newOwnerId string) error {
+	if ownerId == newOwnerId {
+		return ErrInvalidParam
+	}
+	group, ok := s.groups[groupId]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	owner := s.activeMember(group, ownerId)
+	if owner == nil {
+		return ErrNotGroupMember
+	}
+	if owner.RoleLevel != RoleLevelOwner {
+		return ErrNotGroupOwner
+	}
+	newOwner := s.activeMember(group, newOwnerId)
+	if newOwner == nil {
+		return ErrNotGroupMember
+	}
+	owner.RoleLevel, newOwner.RoleLevel = RoleLevelMember, RoleLevelOwner
+	return nil
+}
+
+func (s *FakeServer) setGroupAdmin(ownerId, groupId, userId string, admin bool) error {
+	if userId == "" || ownerId == userId {
+		return ErrInvalidParam
+	}
+	group, owner, err := s.groupAdmin(ownerId, groupId)
+	if err != nil {
+		if err == ErrNotGroupAdmin {
+			return ErrNotGroupOwner
+		}
+		return err
+	}
+	if owner.RoleLevel != RoleLevelOwner {
+		return ErrNotGroupOwner
+	}
+	target := s.activeMember(group, userId)
+	if target == nil {
+		return ErrNotGroupMember
+	}
+	target.RoleLevel = RoleLevelMember
+	if admin {
+		target.RoleLevel = RoleLevelAdmin
+	}
+	return nil
+}
+
+func (s *FakeServer) dismissGroup(ownerId, groupId string) error {
+	group, owner, err := s.groupAdmin(ownerId, groupId)
+	if err != nil {
+		if err == ErrNotGroupAdmin {
+			return ErrNotGroupOwner
+		}
+		return err
+	}
+	if owner.RoleLevel != RoleLevelOwner {
+		return ErrNotGroupOwner
+	}
+	group.info.Status = GroupStatusDismissed
+	return nil
+}
+
+func (s *FakeServer) updateGroupInfo(operatorId string, req *UpdateGroupInfoRequest) (*GroupInfo, error) {
+	group, _, err := s.groupAdmin(operatorId, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" {
+		group.info.Name = req.Name
+	}
+	if req.Introduction != "" {
+		group.info.Introduction = req.Introduction
+	}
+	if req.Avatar != "" {
+		group.info.Avatar = req.Avatar
+	}
+	if req.MuteAll != nil {
+		group.info.MuteAll = *req.MuteAll
+	}
+	info := group.info
+	return &info, nil
+}
+
+func (s *FakeServer) setAnnouncement(operatorId, groupId, content string) (*GroupInfo, error) {
+	group, _, err := s.groupAdmin(operatorId, groupId)
+	if err != nil {
+		return nil, err
+	}
+	group.info.Announcement = content
+	group.info.AnnouncementUpdatedAt = s.now()
+	info := group.info
+	return &info, nil
+}
+
+func (s *FakeServer) createInviteLink(operatorId string, req *CreateInviteLinkRequest) (*GroupInviteLink, error) {
+	if req.TtlSeconds < 0 || req.MaxUses < 0 {
+		return nil, ErrInvalidParam
+	}
+	if _, _, err := s.groupAdmin(operatorId, req.GroupId); err != nil {
+		return nil, err
+	}
+	ttl := 7 * 24 * time.Hour
+	if req.TtlSeconds > 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+	link := &GroupInviteLink{
+		Token:         s.genId("invite_"),
+		GroupId:       req.GroupId,
+		CreatorUserId: operatorId,
+		MaxUses:       req.MaxUses,
+		ExpiresAt:     time.Now().Add(ttl).UnixMilli(),
+	}
+	s.invites[link.Token] = link
+	result := *link
+	return &result, nil
+}
+
+func (s *FakeServer) revokeInviteLink(operatorId, groupId, token string) error {
+	if _, _, err := s.groupAdmin(operatorId, groupId); err != nil {
+		return err
+	}
+	link, ok := s.invites[token]
+	if !ok || link.GroupId != groupId {
+		return ErrInviteLinkInvalid
+	}
+	delete(s.invites, token)
+	return nil
+}
+
+func (s *FakeServer) joinByInviteLink(userId, token string) (string, error) {
+	link, ok := s.invites[token]
+	if !ok || time.Now().UnixMilli() >= link.ExpiresAt {
+		return "", ErrInviteLinkInvalid
+	}
+	if err := s.joinGroup(userId, link.GroupId, link.CreatorUserId); err != nil {
+		return "", err
+	}
+	link.Uses++
+	if link.MaxUses > 0 && link.Uses >= link.MaxUses {
+		delete(s.invites, token)
+	}
+	return link.GroupId, nil
+}
+
+func (s *FakeServer) conversation(id string, convType int32, groupId string) *fakeConversation {
+	conv, ok := s.convs[id]
+	if !ok {
+		conv = &fakeConversation{id: id, convType: convType, groupId: groupId}
+		s.convs[id] = conv
+	}
+	return conv
+}
+
+// ownConversation returns the user's settings of a conversation, creating them on first use
+func (s *FakeServer) ownConversation(user *fakeUser, conv *fakeConversation) *ConversationInfo {
+	info, ok := user.convs[conv.id]
+	if !ok {
+		info = &ConversationInfo{
+			ConversationId:   conv.id,
+			ConversationType: conv.convType,
+			GroupId:          conv.groupId,
+			UpdatedAt:        s.now(),
+		}
+		if conv.convType == SessionTypeSingle {
+			info.PeerUserId = peerOf(conv.id, user.info.Id)
+			info.IsSaved = info.PeerUserId == user.info.Id
+		}
+		user.convs[conv.id] = info
+	}
+	return info
+}
+
+// poll gets a poll message in a conversation of the user
+func (s *FakeServer) poll(userId, conversationId string, seq int64) (*fakeConversation, *PollContent, error) {
+	if conversationId == "" || seq <= 0 {
+		return nil, nil, ErrInvalidParam
+	}
+	if _, ok := s.users[userId].convs[conversationId]; !ok {
+		return nil, nil, ErrNoPermission
+	}
+	conv := s.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, nil, ErrMessageNotFound
+	}
+	msg := conv.messages[seq-1]
+	if msg.MsgType != MsgTypePoll || msg.Content.Poll == nil {
+		return nil, nil, ErrInvalidParam
+	}
+	return conv, msg.Content.Poll, nil
+}
+
+// reactable returns the conversation of a message the user can react to
+func (s *FakeServer) reactable(userId, conversationId string, seq int64, emoji string) (*fakeConversation, error) {
+	if conversationId == "" || seq <= 0 || emoji == "" || len(emoji) > 32 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := s.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := s.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	msg := conv.messages[seq-1]
+	if msg.RevokedAt > 0 {
+		return nil, ErrMessageRevoked
+	}
+	if msg.MsgType == MsgTypeRevoke {
+		return nil, ErrInvalidParam
+	}
+	return conv, nil
+}
+
+// fakeMaxQuoteSnippetLength and fakeQuotePreviewLength bound quotes in characters, like the server
+const (
+	fakeMaxQuoteSnippetLength = 200
+	fakeQuotePreviewLength    = 100
+)
+
+// checkQuote checks the quote of a message about to be sent to conv, like the server
+func (s *FakeServer) checkQuote(conv *fakeConversation, quote *QuotedMessage) error {
+	if (quote.ConversationId != "" && quote.ConversationId != conv.id) || quote.Seq <= 0 ||
+		utf8.RuneCountInString(quote.Snippet) > fakeMaxQuoteSnippetLength {
+		return ErrInvalidParam
+	}
+	if quote.Seq > int64(len(conv.messages)) || disappeared(conv.messages[quote.Seq-1]) {
+		return ErrMessageNotFound
+	}
+	quoted := conv.messages[quote.Seq-1]
+	if quoted.RevokedAt > 0 {
+		return ErrMessageRevoked
+	}
+	if quoted.MsgType == MsgTypeRevoke || (quote.Snippet != "" && !strings.Contains(quoted.Content.Text, quote.Snippet)) {
+		return ErrInvalidParam
+	}
+	return nil
+}
+
+// quoteOf describes the message the reply with seq quotes from its current state, nil when the
+// message is not a reply
+func (s *FakeServer) quoteOf(conv *fakeConversation, seq int64) *QuotedMessage {
+	sent, ok := conv.quotes[seq]
+	if !ok {
+		return nil
+	}
+	quote := &QuotedMessage{ConversationId: conv.id, Seq: sent.Seq}
+	quoted := conv.messages[sent.Seq-1]
+	if quoted.RevokedAt > 0 || disappeared(quoted) {
+		return quote
+	}
+	quote.SenderId = quoted.SenderId
+	quote.MsgType = quoted.MsgType
+	if text := quoted.Content.Text; text != "" {
+		switch {
+		case sent.Snippet == "":
+			runes := []rune(text)
+			quote.Snippet = string(runes[:min(len(runes), fakeQuotePreviewLength)])
+		case strings.Contains(text, sent.Snippet):
+			quote.Snippet = sent.Snippet
+		}
+	}
+	return quote
+}
+
+// setTTL sets the disappearing messages timer of a conversation of userId like the server
+func (s *FakeServer) setTTL(userId string, conv *fakeConversation, ttlSeconds int32) error {
+	if ttlSeconds != 0 && (ttlSeconds < 5 || ttlSeconds > 30*24*60*60) {
+		return ErrInvalidParam
+	}
+	if err := s.checkSharedSetting(userId, conv); err != nil {
+		return err
+	}
+	conv.ttlSeconds = ttlSeconds
+	return nil
+}
+
+// checkSharedSetting checks that userId may change a setting shared by the participants of a
+// conversation, like the server: either participant of a single chat, admins of a group
+func (s *FakeServer) checkSharedSetting(userId string, conv *fakeConversation) error {
+	switch conv.convType {
+	case SessionTypeSingle:
+		return nil
+	case SessionTypeGroup:
+		_, _, err := s.groupAdmin(userId, conv.groupId)
+		return err
+	default:
+		return ErrInvalidParam
+	}
+}
+
+// fakeMaxPinnedMessages caps the messages pinned in a conversation, like the server
+const fakeMaxPinnedMessages = 50
+
+// pinnable returns the conversation of userId whose pins userId may change
+func (s *FakeServer) pinnable(userId, conversationId string, seq int64) (*fakeConversation, error) {
+	if conversationId == "" || seq <= 0 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := s.users[userId].convs[conversationId]; !ok {
+		return nil, ErrConvNotFound
+	}
+	conv := s.convs[conversationId]
+	if err := s.checkSharedSetting(userId, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// pinnedSeqs returns the seqs pinned in a conversation, newest pin first
+func (s *FakeServer) pinnedSeqs(conv *fakeConversation) []int64 {
+	var seqs []int64
+	for i := len(conv.pins) - 1; i >= 0; i-- {
+		seqs = append(seqs, conv.pins[i].Seq)
+	}
+	return seqs
+}
+
+// disappeared reports whether a message sent while a disappearing messages timer was set expired
+func disappeared(msg *MessageInfo) bool {
+	return msg.ExpiresAt > 0 && msg.ExpiresAt <= time.Now().UnixMilli()
+}
+
+// reactionChange describes a reaction change with the resulting count of the emoji
+func (s *FakeServer) reactionChange(userId string, conv *fakeConversation, seq int64, emoji string, added bool) *ReactionChange {
+	change := &ReactionChange{ConversationId: conv.id, Seq: seq, UserId: userId, Emoji: emoji, Added: added}
+	for _, reaction := range conv.reactions[seq] {
+		if reaction.emoji == emoji {
+			change.Count++
+		}
+	}
+	return change
+}
+
+// reactionSummaries aggregates the reactions to a message per emoji, in the order of first use
+func (s *FakeServer) reactionSummaries(userId string, conv *fakeConversation, seq int64) []*ReactionSummary {
+	var summaries []*ReactionSummary
+	byEmoji := make(map[string]*ReactionSummary)
+	for _, reaction := range conv.reactions[seq] {
+		summary, ok := byEmoji[reaction.emoji]
+		if !ok {
+			summary = &ReactionSummary{Emoji: reaction.emoji}
+			byEmoji[reaction.emoji] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Count++
+		if reaction.userId == userId {
+			summary.Reacted = true
+		}
+	}
+	return summaries
+}
+
+// pollResult tallies the votes of a poll message, naming voters in id order
+func (s *FakeServer) pollResult(userId string, conv *fakeConversation, poll *PollContent, seq int64) *PollResult {
+	result := &PollResult{ConversationId: conv.id, Seq: seq, Options: make([]*PollOptionResult, len(poll.Options))}
+	for i := range result.Options {
+		result.Options[i] = &PollOptionResult{}
+	}
+	voterIds := make([]string, 0, len(conv.pollVotes[seq]))
+	for voterId := range conv.pollVotes[seq] {
+		voterIds = append(voterIds, voterId)
+	}
+	sort.Strings(voterIds)
+	for _, voterId := range voterIds {
+		for _, option := range conv.pollVotes[seq][voterId] {
+			result.Options[option].Count++
+			if !poll.Anonymous {
+				result.Options[option].VoterIds = append(result.Options[option].VoterIds, voterId)
+			}
+		}
+	}
+	result.VoterCount = int64(len(voterIds))
+	result.MyOptions = slices.Clone(conv.pollVotes[seq][userId])
+	return result
+}
+
+func (s *FakeServer) sendMessage(senderId string, req *SendMessageRequest, markRead bool) (*MessageInfo, error) {
+	if req == nil || req.ClientMsgId == "" {
+		return nil, ErrInvalidParam
+	}
+	sender, err := s.user(senderId)
+	if err != nil {
+		return nil, err
+	}
+
+	var conv *fakeConversation
+	var recipients []*fakeUser
+	var muteErr error
+	switch req.SessionType {
+	case SessionTypeSingle:
+		recv, err := s.user(req.RecvId)
+		if err != nil {
+			return nil, err
+		}
+		conv = s.conversation(SingleConversationId(senderId, req.RecvId), SessionTypeSingle, "")
+		recipients = []*fakeUser{sender, recv}
+	case SessionTypeGroup:
+		group, ok := s.groups[req.GroupId]
+		if !ok {
+			return nil, ErrGroupNotFound
+		}
+		if group.info.Status != GroupStatusNormal {
+			return nil, ErrGroupDismissed
+		}
+		member := s.activeMember(group, senderId)
+		if member == nil {
+			return nil, ErrNotGroupMember
+		}
+		// Checked after the idempotency lookup so retries of sent messages still succeed
+		if member.MutedUntil > time.Now().UnixMilli() {
+			muteErr = ErrMemberMuted
+		} else if group.info.MuteAll && member.RoleLevel < RoleLevelAdmin {
+			muteErr = ErrGroupMuted
+		}
+		conv = s.conversation(GroupConversationId(req.GroupId), SessionTypeGroup, req.GroupId)
+		for _, m := range group.members {
+			if m.Status == GroupMemberStatusNormal {
+				recipients = append(recipients, s.users[m.UserId])
+			}
+		}
+	default:
+		return nil, ErrInvalidParam
+	}
+
+	// Resending a client message id returns the stored message, like the server
+	for _, msg := range conv.messages {
+		if msg.SenderId == senderId && msg.ClientMsgId == req.ClientMsgId {
+			result := *msg
+			result.QuotedMsg = s.quoteOf(conv, msg.Seq)
+			return &result, nil
+		}
+	}
+	if muteErr != nil {
+		return nil, muteErr
+	}
+	if req.QuotedMsg != nil {
+		if err := s.checkQuote(conv, req.QuotedMsg); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := &MessageInfo{
+		Id:             int64(len(conv.messages) + 1),
+		ConversationId: conv.id,
+		Seq:            int64(len(conv.messages) + 1),
+		ClientMsgId:    req.ClientMsgId,
+		SenderId:       senderId,
+		SessionType:    req.SessionType,
+		MsgType:        req.MsgType,
+		Content:        req.Content,
+		SendAt:         s.now(),
+	}
+	if conv.ttlSeconds > 0 {
+		msg.ExpiresAt = msg.SendAt + int64(conv.ttlSeconds)*1000
+	}
+	conv.messages = append(conv.messages, msg)
+	if req.QuotedMsg != nil {
+		if conv.quotes == nil {
+			conv.quotes = make(map[int64]*QuotedMessage)
+		}
+		conv.quotes[msg.Seq] = &QuotedMessage{Seq: req.QuotedMsg.Seq, Snippet: req.QuotedMsg.Snippet}
+	}
+	for _, user := range recipients {
+		info := s.ownConversation(user, conv)
+		info.UpdatedAt = msg.SendAt
+		// The saved messages conversation is always read
+		if (markRead || info.IsSaved) && user == sender {
+			info.ReadSeq = msg.Seq
+		}
+	}
+	result := *msg
+	result.QuotedMsg = s.quoteOf(conv, msg.Seq)
+	return &result, nil
+}
+
+func (s *FakeServer) sendMessagesBatch(senderId string, req *BatchSendMessageRequest) ([]*BatchSendResult, error) {
+	if len(req.Messages) == 0 || len(req.Messages) > 100 {
+		return nil, ErrInvalidParam
+	}
+	results := make([]*BatchSendResult, len(req.Messages))
+	for i, m := range req.Messages {
+		msg, err := s.sendMessage(senderId, m, !req.WithoutMarkRead)
+		if err != nil {
+			apiErr := err.(*Error) // the fake only fails with API errors
+			results[i] = &BatchSendResult{Code: apiErr.Code, Error: apiErr.Msg}
+			continue
+		}
+		results[i] = &BatchSendResult{Message: msg}
+	}
+	return results, nil
+}
+
+// conversationInfo returns a copy of the user's conversation with seq state filled in
+func (s *FakeServer) conversationInfo(userId, conversationId string, withLastMessage bool) (*ConversationInfo, error) {
+	user, err := s.user(userId)
+	if err != nil {
+		return nil, err
+	}
+	own, ok := user.convs[conversationId]
+	if !ok {
+		return nil, ErrConvNotFound
+	}
+	conv := s.convs[conversationId]
+	info := *own
+	info.MaxSeq = int64(len(conv.messages))
+	info.UnreadCount = max(info.MaxSeq-info.ReadSeq, 0)
+	for _, msg := range conv.messages[min(info.ReadSeq, info.MaxSeq):] {
+		if msg.SenderId != userId && slices.Contains(msg.Content.Mentions, userId) {
+			if info.UnreadMentionCount == 0 {
+				info.FirstUnreadMentionSeq = msg.Seq
+			}
+			info.UnreadMentionCount++
+		}
+	}
+	if info.IsSaved {
+		info.UnreadCount, info.UnreadMentionCount, info.FirstUnreadMentionSeq = 0, 0, 0
+	}
+	info.TTLSeconds = conv.ttlSeconds
+	info.PinnedSeqs = s.pinnedSeqs(conv)
+	if n := len(conv.messages); withLastMessage && n > 0 && !disappeared(conv.messages[n-1]) && !s.hiddenFor(userId, conv.messages[n-1]) {
+		last := *conv.messages[n-1]
+		info.LastMessage = &last
+	}
+	return &info, nil
+}
+
+// conversationList returns the user's conversations, latest first
+func (s *FakeServer) conversationList(userId string, withLastMessage bool) ([]*ConversationInfo, error) {
+	user, err := s.user(userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ConversationInfo, 0, len(user.convs))
+	for id := range user.convs {
+		info, err := s.conversationInfo(userId, id, withLastMessage)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].UpdatedAt != result[j].UpdatedAt {
+			return result[i].UpdatedAt > result[j].UpdatedAt
+		}
+		return result[i].ConversationId > result[j].ConversationId
+	})
+	return result, nil
+}
+
+func (s *FakeServer) conversationPage(userId string, withLastMessage bool, limit int, cursor *ConversationListCursor) (*ConversationListPage, error) {
+	all, err := s.conversationList(userId, withLastMessage)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	page := &ConversationListPage{List: []*ConversationInfo{}}
+	for _, info := range all {
+		if cursor != nil && (info.UpdatedAt > cursor.UpdatedAt ||
+			info.UpdatedAt == cursor.UpdatedAt && info.ConversationId >= cursor.ConversationId) {
+			continue
+		}
+		if len(page.List) == limit {
+			page.HasMore = true
+			break
+		}
+		page.List = append(page.List, info)
+	}
+	if page.HasMore {
+		last := page.List[len(page.List)-1]
+		page.NextCursor = &ConversationListCursor{UpdatedAt: last.UpdatedAt, ConversationId: last.ConversationId}
+	}
+	return page, nil
+}
+
+// peerOf returns the other user of a single chat conversation id
+func peerOf(conversationId, userId string) string {
+	a, b, _ := ParseSingleConversationId(conversationId)
+	if a == userId {
+		return b
+	}
+	return a
+}
+
+// FakeClient is a ClientAPI backed by a FakeServer. Internal methods act as the user
+// given with WithActAsUser.
+type FakeClient struct {
+	server *FakeServer
+
+	mu           sync.RWMutex
+	token        string
+	refreshToken string
+	ignoreAuth   bool
+}
+
+// lock locks the server and returns the logged in user id
+func (c *FakeClient) lock() (string, error) {
+	token := c.GetToken()
+	c.server.mu.Lock()
+	sess, err := c.server.session(token)
+	if err != nil {
+		return "", err
+	}
+	return sess.userId, nil
+}
+
+// lockActing locks the server and returns the user of an internal request
+func (c *FakeClient) lockActing(opts []RequestOption) (string, error) {
+	c.server.mu.Lock()
+	ro := buildRequestOptions(opts...)
+	if ro.actAsUser == nil {
+		return "", ErrUnauthorized
+	}
+	if _, err := c.server.user(ro.actAsUser.userId); err != nil {
+		return "", err
+	}
+	return ro.actAsUser.userId, nil
+}
+
+func (c *FakeClient) unlock() {
+	c.server.mu.Unlock()
+}
+
+func (c *FakeClient) setTokens(token, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.refreshToken = refreshToken
+}
+
+// Register registers a new user
+func (c *FakeClient) Register(_ context.Context, req *RegisterRequest) (*UserInfo, error) {
+	c.server.mu.Lock()
+	defer c.unlock()
+	user, err := c.server.register(req, false)
+	if err != nil {
+		return nil, err
+	}
+	info := user.info
+	return &info, nil
+}
+
+// Verify accepts any code, fake users never need verification
+func (c *FakeClient) Verify(_ context.Context, req *VerifyRequest) error {
+	c.server.mu.Lock()
+	defer c.unlock()
+	_, err := c.server.user(req.UserId)
+	return err
+}
+
+// ResendVerifyCode does nothing besides checking the user exists
+func (c *FakeClient) ResendVerifyCode(_ context.Context, userId string) error {
+	c.server.mu.Lock()
+	defer c.unlock()
+	_, err := c.server.user(userId)
+	return err
+}
+
+// Login logs a user in and stores the tokens
+func (c *FakeClient) Login(_ context.Context, req *LoginRequest) (*LoginResponse, error) {
+	c.server.mu.Lock()
+	defer c.unlock()
+	user, err := c.server.user(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user.info.IsGuest || user.password != req.Password {
+		return nil, ErrPasswordWrong
+	}
+	resp := c.server.login(user, req.PlatformId, req.DeviceName)
+	c.setTokens(resp.Token, resp.RefreshToken)
+	return resp, nil
+}
+
+// OAuthLogin always fails, the fake server has no identity providers
+func (c *FakeClient) OAuthLogin(_ context.Context, _ *OAuthLoginRequest) (*LoginResponse, error) {
+	return nil, NewError(CodeOAuthFailed, "oauth login is not supported by the fake server")
+}
+
+// CreateGuest creates a guest user and stores its tokens
+func (c *FakeClient) CreateGuest(_ context.Context, req *CreateGuestRequest) (*LoginResponse, error) {
+	c.server.mu.Lock()
+	defer c.unlock()
+	user, err := c.server.register(&RegisterRequest{UserId: c.server.genId("guest_"), Nickname: req.Nickname}, true)
+	if err != nil {
+		return nil, err
+	}
+	resp := c.server.login(user, req.PlatformId, req.DeviceName)
+	c.setTokens(resp.Token, resp.RefreshToken)
+	return resp, nil
+}
+
+// UpgradeGuest turns the logged in guest into a full account
+func (c *FakeClient) UpgradeGuest(_ context.Context, req *UpgradeGuestRequest) (*UserInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	user := c.server.users[userId]
+	if !user.info.IsGuest {
+		return nil, NewError(CodeNotGuestUser, "not a guest user")
+	}
+	if req.Password == "" {
+		return nil, ErrInvalidParam
+	}
+	user.password = req.Password
+	user.info.IsGuest = false
+	if req.Nickname != "" {
+		user.info.Nickname = req.Nickname
+	}
+	info := user.info
+	return &info, nil
+}
+
+// RefreshToken rotates the stored tokens
+func (c *FakeClient) RefreshToken(_ context.Context) (*RefreshTokenResponse, error) {
+	refreshToken := c.GetRefreshToken()
+	c.server.mu.Lock()
+	defer c.unlock()
+	sess, ok := c.server.sessions[refreshToken]
+	if !ok || sess.refreshToken != refreshToken {
+		return nil, ErrTokenInvalid
+	}
+	c.server.issueTokens(sess)
+	sess.RefreshedAt = c.server.now()
+	c.setTokens(sess.token, sess.refreshToken)
+	return &RefreshTokenResponse{
+		Token:        sess.token,
+		RefreshToken: sess.refreshToken,
+		ExpiresIn:    int64((24 * time.Hour).Seconds()),
+	}, nil
+}
+
+// LoginWithUserId is a convenience method to login with user Id, password and platform Id
+func (c *FakeClient) LoginWithUserId(ctx context.Context, userId, password string, platformId int) (*LoginResponse, error) {
+	return c.Login(ctx, &LoginRequest{UserId: userId, Password: password, PlatformId: platformId})
+}
+
+// InternalRegister registers a user
+func (c *FakeClient) InternalRegister(ctx context.Context, req *RegisterRequest) (*UserInfo, error) {
+	return c.Register(ctx, req)
+}
+
+// UseExternalToken sets the token for subsequent requests
+func (c *FakeClient) UseExternalToken(token string) {
+	c.SetToken(token)
+}
+
+// EnableTestAuthBypass is recorded but has no effect on the fake server
+func (c *FakeClient) EnableTestAuthBypass(enabled bool) {
+	c.SetIgnoreAuth(enabled)
+}
+
+// SetToken sets the authentication token
+func (c *FakeClient) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// GetToken returns the current token
+func (c *FakeClient) GetToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SetRefreshToken sets the refresh token
+func (c *FakeClient) SetRefreshToken(refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshToken = refreshToken
+}
+
+// GetRefreshToken returns the current refresh token
+func (c *FakeClient) GetRefreshToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshToken
+}
+
+// SetIgnoreAuth is recorded but has no effect on the fake server
+func (c *FakeClient) SetIgnoreAuth(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ignoreAuth = enabled
+}
+
+// GetUserInfo gets the current user's info
+func (c *FakeClient) GetUserInfo(_ context.Context) (*UserInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	info := c.server.users[userId].info
+	return &info, nil
+}
+
+// GetUserInfoById gets a user's info by Id
+func (c *FakeClient) GetUserInfoById(_ context.Context, userId string) (*UserInfo, error) {
+	_, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.server.user(userId)
+	if err != nil {
+		return nil, err
+	}
+	info := user.info
+	return &info, nil
+}
+
+// UpdateUserInfo updates the current user's info
+func (c *FakeClient) UpdateUserInfo(_ context.Context, req *UpdateUserRequest) (*UserInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.updateUser(userId, req)
+}
+
+// GetUsersInfo gets multiple users' info by Ids, unknown ids are skipped
+func (c *FakeClient) GetUsersInfo(_ context.Context, userIds []string) ([]*UserInfo, error) {
+	_, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.usersInfo(userIds), nil
+}
+
+// SearchUsers finds users by handle or nickname prefix
+func (c *FakeClient) SearchUsers(_ context.Context, q string, limit int) ([]*UserInfo, error) {
+	_, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.searchUsers(q, limit)
+}
+
+// GetUsersOnlineStatus gets the status set with FakeServer.SetOnline
+func (c *FakeClient) GetUsersOnlineStatus(_ context.Context, userIds []string) ([]*OnlineStatus, error) {
+	_, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.onlineStatus(userIds), nil
+}
+
+// ListSessions lists the current user's sessions
+func (c *FakeClient) ListSessions(_ context.Context) ([]*Session, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	token := c.GetToken()
+	var result []*Session
+	for key, sess := range c.server.sessions {
+		if sess.userId != userId || key != sess.token || sess.scoped {
+			continue
+		}
+		item := sess.Session
+		item.Current = sess.token == token
+		result = append(result, &item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt < result[j].CreatedAt })
+	return result, nil
+}
+
+// RenameSession sets the device name of a session
+func (c *FakeClient) RenameSession(_ context.Context, sessionId, name string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	sess := c.server.findSession(userId, sessionId)
+	if sess == nil {
+		return ErrNotFound
+	}
+	sess.Name = name
+	return nil
+}
+
+// RevokeSession logs a session out
+func (c *FakeClient) RevokeSession(_ context.Context, sessionId string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	sess := c.server.findSession(userId, sessionId)
+	if sess == nil {
+		return ErrNotFound
+	}
+	delete(c.server.sessions, sess.token)
+	delete(c.server.sessions, sess.refreshToken)
+	return nil
+}
+
+// DeleteAccount removes the current user, its sessions and group memberships
+func (c *FakeClient) DeleteAccount(_ context.Context, req *DeleteAccountRequest) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	if req == nil || req.Confirm != userId {
+		return ErrInvalidParam
+	}
+	user := c.server.users[userId]
+	if user.password != "" && req.Password != user.password {
+		return ErrPasswordWrong
+	}
+	for key, sess := range c.server.sessions {
+		if sess.userId == userId {
+			delete(c.server.sessions, key)
+		}
+	}
+	for _, group := range c.server.groups {
+		if member := c.server.activeMember(group, userId); member != nil {
+			c.server.removeMember(group, member, GroupMemberStatusLeft)
+		}
+	}
+	delete(c.server.users, userId)
+	return nil
+}
+
+// GetNotificationSettings gets the current user's notification settings, the defaults if never changed
+func (c *FakeClient) GetNotificationSettings(_ context.Context) (*NotificationSettings, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	settings := c.server.notificationSettings(userId)
+	return &settings, nil
+}
+
+// UpdateNotificationSettings updates the current user's notification settings
+func (c *FakeClient) UpdateNotificationSettings(_ context.Context, req *UpdateNotificationSettingsRequest) (*NotificationSettings, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrInvalidParam
+	}
+	for platformId := range req.PlatformOverrides {
+		if PlatformIdToName(platformId) == "Unknown" {
+			return nil, ErrInvalidParam
+		}
+	}
+
+	settings := c.server.notificationSettings(userId)
+	if req.GlobalMute != nil {
+		settings.GlobalMute = *req.GlobalMute
+	}
+	if req.Sound != nil {
+		settings.Sound = *req.Sound
+	}
+	if req.Vibrate != nil {
+		settings.Vibrate = *req.Vibrate
+	}
+	if req.ShowPreview != nil {
+		settings.ShowPreview = *req.ShowPreview
+	}
+	overrides := make(map[int]*NotificationOverride, len(settings.PlatformOverrides))
+	for platformId, override := range settings.PlatformOverrides {
+		overrides[platformId] = override
+	}
+	for platformId, override := range req.PlatformOverrides {
+		if override == nil || *override == (NotificationOverride{}) {
+			delete(overrides, platformId)
+			continue
+		}
+		copied := *override
+		overrides[platformId] = &copied
+	}
+	settings.PlatformOverrides = nil
+	if len(overrides) > 0 {
+		settings.PlatformOverrides = overrides
+	}
+	settings.UpdatedAt = c.server.now()
+
+	stored := settings
+	c.server.users[userId].notify = &stored
+	return &settings, nil
+}
+
+func (s *FakeServer) notificationSettings(userId string) NotificationSettings {
+	if notify := s.users[userId].notify; notify != nil {
+		return *notify
+	}
+	return NotificationSettings{Sound: true, Vibrate: true, ShowPreview: true}
+}
+
+func (s *FakeServer) findSession(userId, sessionId string) *fakeSession {
+	for _, sess := range s.sessions {
+		if sess.userId == userId && sess.SessionId == sessionId && !sess.scoped {
+			return sess
+		}
+	}
+	return nil
+}
+
+// IssueScopedToken returns a token of the current user; the fake server does not enforce scopes
+func (c *FakeClient) IssueScopedToken(_ context.Context, req *ScopedTokenRequest) (*ScopedTokenResponse, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Scopes) == 0 {
+		return nil, ErrInvalidParam
+	}
+	current, _ := c.server.session(c.GetToken())
+	sess := &fakeSession{Session: current.Session, userId: userId, scoped: true}
+	sess.token = c.server.genId("fake-scoped-")
+	c.server.sessions[sess.token] = sess
+	ttl := req.TTLSeconds
+	if ttl <= 0 {
+		ttl = int64(time.Hour.Seconds())
+	}
+	return &ScopedTokenResponse{Token: sess.token, Scopes: req.Scopes, ExpiresIn: ttl}, nil
+}
+
+// InternalGetUserInfo gets the acting user's info
+func (c *FakeClient) InternalGetUserInfo(_ context.Context, opts ...RequestOption) (*UserInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	info := c.server.users[userId].info
+	return &info, nil
+}
+
+// InternalGetUserInfoById gets a user's info by Id
+func (c *FakeClient) InternalGetUserInfoById(_ context.Context, userId string, opts ...RequestOption) (*UserInfo, error) {
+	_, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.server.user(userId)
+	if err != nil {
+		return nil, err
+	}
+	info := user.info
+	return &info, nil
+}
+
+// InternalUpdateUserInfo updates the acting user's info
+func (c *FakeClient) InternalUpdateUserInfo(_ context.Context, req *UpdateUserRequest, opts ...RequestOption) (*UserInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.updateUser(userId, req)
+}
+
+// InternalGetUsersInfo gets multiple users' info, unknown ids are skipped
+func (c *FakeClient) InternalGetUsersInfo(_ context.Context, userIds []string, opts ...RequestOption) ([]*UserInfo, error) {
+	_, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.usersInfo(userIds), nil
+}
+
+// InternalGetUsersOnlineStatus gets the status set with FakeServer.SetOnline
+func (c *FakeClient) InternalGetUsersOnlineStatus(_ context.Context, userIds []string, opts ...RequestOption) ([]*OnlineStatus, error) {
+	_, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.onlineStatus(userIds), nil
+}
+
+// CreateGroup creates a group owned by the current user
+func (c *FakeClient) CreateGroup(_ context.Context, req *CreateGroupRequest) (*GroupInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.createGroup(userId, req)
+}
+
+// JoinGroup joins a group
+func (c *FakeClient) JoinGroup(_ context.Context, groupId string, inviterId string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.joinGroup(userId, groupId, inviterId)
+}
+
+// QuitGroup quits a group
+func (c *FakeClient) QuitGroup(_ context.Context, groupId string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.quitGroup(userId, groupId)
+}
+
+// GetGroupInfo gets group info
+func (c *FakeClient) GetGroupInfo(_ context.Context, groupId string) (*GroupInfo, error) {
+	_, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.groupInfo(groupId)
+}
+
+// GetGroupMembers gets the active members of a group
+func (c *FakeClient) GetGroupMembers(_ context.Context, groupId string) ([]*GroupMember, error) {
+	_, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.groupMembers(groupId)
+}
+
+// KickGroupMembers removes members from a group
+func (c *FakeClient) KickGroupMembers(_ context.Context, groupId string, userIds []string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.kickMembers(userId, groupId, userIds)
+}
+
+// MuteGroupMember mutes a member for duration, 0 lifts the mute
+func (c *FakeClient) MuteGroupMember(_ context.Context, groupId, userId string, duration time.Duration) error {
+	operatorId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.muteMember(operatorId, groupId, userId, duration)
+}
+
+// TransferGroupOwnership hands the group over to another member
+func (c *FakeClient) TransferGroupOwnership(_ context.Context, groupId, newOwnerId string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.transferOwnership(userId, groupId, newOwnerId)
+}
+
+// PromoteGroupAdmin makes a member an admin
+func (c *FakeClient) PromoteGroupAdmin(_ context.Context, groupId, userId string) error {
+	ownerId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.setGroupAdmin(ownerId, groupId, userId, true)
+}
+
+// DemoteGroupAdmin makes an admin a normal member
+func (c *FakeClient) DemoteGroupAdmin(_ context.Context, groupId, userId string) error {
+	ownerId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.setGroupAdmin(ownerId, groupId, userId, false)
+}
+
+// DismissGroup dismisses the group
+func (c *FakeClient) DismissGroup(_ context.Context, groupId string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.dismissGroup(userId, groupId)
+}
+
+// UpdateGroupInfo updates group settings
+func (c *FakeClient) UpdateGroupInfo(_ context.Context, req *UpdateGroupInfoRequest) (*GroupInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.updateGroupInfo(userId, req)
+}
+
+// SetGroupAnnouncement replaces the group announcement
+func (c *FakeClient) SetGroupAnnouncement(_ context.Context, groupId, content string) (*GroupInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.setAnnouncement(userId, groupId, content)
+}
+
+// CreateGroupInviteLink creates an invite link for the group
+func (c *FakeClient) CreateGroupInviteLink(_ context.Context, req *CreateInviteLinkRequest) (*GroupInviteLink, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.createInviteLink(userId, req)
+}
+
+// RevokeGroupInviteLink invalidates an invite link of the group
+func (c *FakeClient) RevokeGroupInviteLink(_ context.Context, groupId, token string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.revokeInviteLink(userId, groupId, token)
+}
+
+// JoinGroupByInviteLink joins the group of the invite link
+func (c *FakeClient) JoinGroupByInviteLink(_ context.Context, token string) (string, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return "", err
+	}
+	return c.server.joinByInviteLink(userId, token)
+}
+
+// InternalCreateGroup creates a group owned by the acting user
+func (c *FakeClient) InternalCreateGroup(_ context.Context, req *CreateGroupRequest, opts ...RequestOption) (*GroupInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.createGroup(userId, req)
+}
+
+// InternalJoinGroup joins the acting user to a group
+func (c *FakeClient) InternalJoinGroup(_ context.Context, groupId string, inviterId string, opts ...RequestOption) error {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.joinGroup(userId, groupId, inviterId)
+}
+
+// InternalQuitGroup removes the acting user from a group
+func (c *FakeClient) InternalQuitGroup(_ context.Context, groupId string, opts ...RequestOption) error {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.quitGroup(userId, groupId)
+}
+
+// InternalGetGroupInfo gets group info
+func (c *FakeClient) InternalGetGroupInfo(_ context.Context, groupId string, opts ...RequestOption) (*GroupInfo, error) {
+	_, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.groupInfo(groupId)
+}
+
+// InternalGetGroupMembers gets the active members of a group
+func (c *FakeClient) InternalGetGroupMembers(_ context.Context, groupId string, opts ...RequestOption) ([]*GroupMember, error) {
+	_, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.groupMembers(groupId)
+}
+
+// InternalKickGroupMembers removes members from a group as the acting user
+func (c *FakeClient) InternalKickGroupMembers(_ context.Context, groupId string, userIds []string, opts ...RequestOption) error {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.kickMembers(userId, groupId, userIds)
+}
+
+// InternalMuteGroupMember mutes a member as the acting user
+func (c *FakeClient) InternalMuteGroupMember(_ context.Context, groupId, userId string, duration time.Duration, opts ...RequestOption) error {
+	operatorId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.muteMember(operatorId, groupId, userId, duration)
+}
+
+// InternalTransferGroupOwnership hands the acting user's group over to another member
+func (c *FakeClient) InternalTransferGroupOwnership(_ context.Context, groupId, newOwnerId string, opts ...RequestOption) error {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.transferOwnership(userId, groupId, newOwnerId)
+}
+
+// InternalPromoteGroupAdmin makes a member of the acting user's group an admin
+func (c *FakeClient) InternalPromoteGroupAdmin(_ context.Context, groupId, userId string, opts ...RequestOption) error {
+	ownerId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.setGroupAdmin(ownerId, groupId, userId, true)
+}
+
+// InternalDemoteGroupAdmin makes an admin of the acting user's group a normal member
+func (c *FakeClient) InternalDemoteGroupAdmin(_ context.Context, groupId, userId string, opts ...RequestOption) error {
+	ownerId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.setGroupAdmin(ownerId, groupId, userId, false)
+}
+
+// InternalDismissGroup dismisses the acting user's group
+func (c *FakeClient) InternalDismissGroup(_ context.Context, groupId string, opts ...RequestOption) error {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.dismissGroup(userId, groupId)
+}
+
+// InternalUpdateGroupInfo updates group settings as the acting user
+func (c *FakeClient) InternalUpdateGroupInfo(_ context.Context, req *UpdateGroupInfoRequest, opts ...RequestOption) (*GroupInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.updateGroupInfo(userId, req)
+}
+
+// InternalSetGroupAnnouncement replaces the group announcement as the acting user
+func (c *FakeClient) InternalSetGroupAnnouncement(_ context.Context, groupId, content string, opts ...RequestOption) (*GroupInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.setAnnouncement(userId, groupId, content)
+}
+
+// InternalCreateGroupInviteLink creates an invite link as the acting user
+func (c *FakeClient) InternalCreateGroupInviteLink(_ context.Context, req *CreateInviteLinkRequest, opts ...RequestOption) (*GroupInviteLink, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.createInviteLink(userId, req)
+}
+
+// InternalRevokeGroupInviteLink invalidates an invite link as the acting user
+func (c *FakeClient) InternalRevokeGroupInviteLink(_ context.Context, groupId, token string, opts ...RequestOption) error {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	return c.server.revokeInviteLink(userId, groupId, token)
+}
+
+// InternalJoinGroupByInviteLink joins the acting user to the group of the invite link
+func (c *FakeClient) InternalJoinGroupByInviteLink(_ context.Context, token string, opts ...RequestOption) (string, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return "", err
+	}
+	return c.server.joinByInviteLink(userId, token)
+}
+
+// SendMessage sends a message and marks the sender as read
+func (c *FakeClient) SendMessage(_ context.Context, req *SendMessageRequest) (*MessageInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.sendMessage(userId, req, true)
+}
+
+// InternalSendMessage sends a message as the acting user
+func (c *FakeClient) InternalSendMessage(_ context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.sendMessage(userId, req, true)
+}
+
+// SendMessageWithoutMarkRead sends a message without marking the sender as read
+func (c *FakeClient) SendMessageWithoutMarkRead(_ context.Context, req *SendMessageRequest) (*MessageInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.sendMessage(userId, req, false)
+}
+
+// InternalSendMessageWithoutMarkRead sends a message as the acting user without marking it as read
+func (c *FakeClient) InternalSendMessageWithoutMarkRead(_ context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.sendMessage(userId, req, false)
+}
+
+// SendMessagesBatch sends messages in order, each with its own result
+func (c *FakeClient) SendMessagesBatch(_ context.Context, req *BatchSendMessageRequest) ([]*BatchSendResult, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.sendMessagesBatch(userId, req)
+}
+
+// InternalSendMessagesBatch sends messages as the acting user, each with its own result
+func (c *FakeClient) InternalSendMessagesBatch(_ context.Context, req *BatchSendMessageRequest, opts ...RequestOption) ([]*BatchSendResult, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.sendMessagesBatch(userId, req)
+}
+
+// ForwardMessages copies messages of a conversation to other conversations, one by one or as
+// a merged forward, with the client message ids of the server. The fake does not limit how
+// deep merged forwards nest.
+func (c *FakeClient) ForwardMessages(_ context.Context, req *ForwardMessagesRequest) ([]*ForwardResult, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	copies := len(req.Seqs) * len(req.Targets)
+	if req.Merged {
+		copies = len(req.Targets)
+	}
+	if req.ClientMsgId == "" || req.ConversationId == "" || len(req.Seqs) == 0 || len(req.Seqs) > 100 ||
+		len(req.Targets) == 0 || len(req.Targets) > 20 || copies > 100 || (req.Title != "" && !req.Merged) {
+		return nil, ErrInvalidParam
+	}
+	for _, target := range req.Targets {
+		if target == nil || (target.RecvId == "") == (target.GroupId == "") {
+			return nil, ErrInvalidParam
+		}
+	}
+	if _, ok := c.server.users[userId].convs[req.ConversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[req.ConversationId]
+	seqs := slices.Clone(req.Seqs)
+	slices.Sort(seqs)
+	sources := make([]*MessageInfo, len(seqs))
+	for i, seq := range seqs {
+		if seq <= 0 || (i > 0 && seqs[i-1] == seq) {
+			return nil, ErrInvalidParam
+		}
+		if seq > int64(len(conv.messages)) || disappeared(conv.messages[seq-1]) {
+			return nil, ErrMessageNotFound
+		}
+		src := conv.messages[seq-1]
+		if src.RevokedAt > 0 {
+			return nil, ErrMessageRevoked
+		}
+		if src.MsgType == MsgTypeRevoke {
+			return nil, ErrInvalidParam
+		}
+		sources[i] = src
+	}
+
+	results := make([]*ForwardResult, len(req.Targets))
+	for i, target := range req.Targets {
+		var sendReqs []*SendMessageRequest
+		if req.Merged {
+			merged := &MergedContent{Title: req.Title}
+			for _, src := range sources {
+				merged.Messages = append(merged.Messages, &MergedMessage{SenderId: src.SenderId, MsgType: src.MsgType, Content: src.Content, SendAt: src.SendAt})
+			}
+			sendReqs = append(sendReqs, &SendMessageRequest{
+				ClientMsgId: fmt.Sprintf("%s_%d", req.ClientMsgId, i),
+				MsgType:     MsgTypeMerged,
+				Content:     MessageContent{Merged: merged},
+			})
+		} else {
+			for _, src := range sources {
+				content := src.Content
+				content.Mentions = nil
+				sendReqs = append(sendReqs, &SendMessageRequest{
+					ClientMsgId: fmt.Sprintf("%s_%d_%d", req.ClientMsgId, i, src.Seq),
+					MsgType:     src.MsgType,
+					Content:     content,
+				})
+			}
+		}
+
+		result := &ForwardResult{RecvId: target.RecvId, GroupId: target.GroupId, Messages: []*MessageInfo{}}
+		for _, sendReq := range sendReqs {
+			sendReq.RecvId, sendReq.GroupId = target.RecvId, target.GroupId
+			sendReq.SessionType = SessionTypeGroup
+			if target.RecvId != "" {
+				sendReq.SessionType = SessionTypeSingle
+			}
+			msg, err := c.server.sendMessage(userId, sendReq, true)
+			if err != nil {
+				apiErr := err.(*Error) // the fake only fails with API errors
+				result.Code, result.Error = apiErr.Code, apiErr.Msg
+				break
+			}
+			c.server.convs[msg.ConversationId].messages[msg.Seq-1].Forwarded = true
+			msg.Forwarded = true
+			result.Messages = append(result.Messages, msg)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// SendTextMessage sends a text message to a single user
+func (c *FakeClient) SendTextMessage(ctx context.Context, clientMsgId, recvId, text string) (*MessageInfo, error) {
+	return c.SendMessage(ctx, textMessage(clientMsgId, recvId, "", text))
+}
+
+// SendGroupTextMessage sends a text message to a group
+func (c *FakeClient) SendGroupTextMessage(ctx context.Context, clientMsgId, groupId, text string) (*MessageInfo, error) {
+	return c.SendMessage(ctx, textMessage(clientMsgId, "", groupId, text))
+}
+
+// SendTextMessageWithoutMarkRead sends a single chat text message without marking the sender as read
+func (c *FakeClient) SendTextMessageWithoutMarkRead(ctx context.Context, clientMsgId, recvId, text string) (*MessageInfo, error) {
+	return c.SendMessageWithoutMarkRead(ctx, textMessage(clientMsgId, recvId, "", text))
+}
+
+// SendGroupTextMessageWithoutMarkRead sends a group text message without marking the sender as read
+func (c *FakeClient) SendGroupTextMessageWithoutMarkRead(ctx context.Context, clientMsgId, groupId, text string) (*MessageInfo, error) {
+	return c.SendMessageWithoutMarkRead(ctx, textMessage(clientMsgId, "", groupId, text))
+}
+
+func textMessage(clientMsgId, recvId, groupId, text string) *SendMessageRequest {
+	req := &SendMessageRequest{
+		ClientMsgId: clientMsgId,
+		RecvId:      recvId,
+		GroupId:     groupId,
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: text},
+	}
+	if groupId != "" {
+		req.SessionType = SessionTypeGroup
+	}
+	return req
+}
+
+// PullMessages pulls messages with seq in [beginSeq, endSeq], at most limit (default 100)
+func (c *FakeClient) PullMessages(_ context.Context, conversationId string, beginSeq, endSeq int64, limit int) (*PullMessagesResponse, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	maxSeq := int64(len(conv.messages))
+	if endSeq <= 0 || endSeq > maxSeq {
+		endSeq = maxSeq
+	}
+	beginSeq = max(beginSeq, 1)
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	result := &PullMessagesResponse{Messages: []*MessageInfo{}, MaxSeq: maxSeq}
+	for seq := beginSeq; seq <= endSeq && len(result.Messages) < limit; seq++ {
+		if disappeared(conv.messages[seq-1]) || c.server.hiddenFor(userId, conv.messages[seq-1]) {
+			continue
+		}
+		msg := *conv.messages[seq-1]
+		msg.Reactions = c.server.reactionSummaries(userId, conv, seq)
+		msg.QuotedMsg = c.server.quoteOf(conv, seq)
+		result.Messages = append(result.Messages, &msg)
+	}
+	if n := len(result.Messages); n > 0 && conv.convType == SessionTypeSingle {
+		if conv.delivered == nil {
+			conv.delivered = make(map[string]int64)
+		}
+		conv.delivered[userId] = max(conv.delivered[userId], result.Messages[n-1].Seq)
+	}
+	return result, nil
+}
+
+// GetMaxSeq gets the max seq of a conversation
+func (c *FakeClient) GetMaxSeq(_ context.Context, conversationId string) (int64, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return 0, ErrNoPermission
+	}
+	return int64(len(c.server.convs[conversationId].messages)), nil
+}
+
+// CreateReminder stores a pending reminder, the fake never delivers it
+func (c *FakeClient) CreateReminder(_ context.Context, req *CreateReminderRequest) (*MessageReminder, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || req.ConversationId == "" || req.Seq <= 0 || len(req.Note) > 512 {
+		return nil, ErrInvalidParam
+	}
+	now := c.server.now()
+	var remindAt int64
+	switch {
+	case req.RemindAt > 0 && req.RemindIn > 0:
+		return nil, ErrInvalidParam
+	case req.RemindAt > 0:
+		remindAt = req.RemindAt
+	case req.RemindIn > 0:
+		remindAt = now + req.RemindIn*1000
+	default:
+		return nil, ErrInvalidParam
+	}
+	if remindAt <= now || remindAt > now+365*24*int64(time.Hour/time.Millisecond) {
+		return nil, ErrInvalidParam
+	}
+	user := c.server.users[userId]
+	if _, ok := user.convs[req.ConversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	if req.Seq > int64(len(c.server.convs[req.ConversationId].messages)) {
+		return nil, ErrMessageNotFound
+	}
+	if len(user.reminders) >= 100 {
+		return nil, ErrTooManyRequests
+	}
+	c.server.nextId++
+	reminder := &MessageReminder{
+		Id:             c.server.nextId,
+		ConversationId: req.ConversationId,
+		Seq:            req.Seq,
+		Note:           req.Note,
+		RemindAt:       remindAt,
+		Status:         ReminderStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	user.reminders = append(user.reminders, reminder)
+	result := *reminder
+	return &result, nil
+}
+
+// ListReminders lists the current user's pending reminders, soonest first
+func (c *FakeClient) ListReminders(_ context.Context) ([]*MessageReminder, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*MessageReminder, 0, len(c.server.users[userId].reminders))
+	for _, reminder := range c.server.users[userId].reminders {
+		r := *reminder
+		result = append(result, &r)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].RemindAt < result[j].RemindAt })
+	return result, nil
+}
+
+// CancelReminder cancels a pending reminder
+func (c *FakeClient) CancelReminder(_ context.Context, reminderId int64) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	user := c.server.users[userId]
+	for i, reminder := range user.reminders {
+		if reminder.Id == reminderId {
+			user.reminders = append(user.reminders[:i], user.reminders[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// AddFavorite favorites a message with a snapshot, returning the existing favorite if already favorited.
+// Like the server, the snapshot of a disappearing message does not disappear.
+func (c *FakeClient) AddFavorite(_ context.Context, conversationId string, seq int64) (*MessageFavorite, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" || seq <= 0 {
+		return nil, ErrInvalidParam
+	}
+	user := c.server.users[userId]
+	if _, ok := user.convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	messages := c.server.convs[conversationId].messages
+	if seq > int64(len(messages)) || disappeared(messages[seq-1]) || c.server.hiddenFor(userId, messages[seq-1]) {
+		return nil, ErrMessageNotFound
+	}
+	for _, favorite := range user.favorites {
+		if favorite.ConversationId == conversationId && favorite.Seq == seq {
+			result := *favorite
+			return &result, nil
+		}
+	}
+	if len(user.favorites) >= 1000 {
+		return nil, ErrTooManyRequests
+	}
+	msg := *messages[seq-1]
+	msg.ExpiresAt = 0
+	c.server.nextId++
+	favorite := &MessageFavorite{
+		Id:             c.server.nextId,
+		ConversationId: conversationId,
+		Seq:            seq,
+		SenderId:       msg.SenderId,
+		Message:        &msg,
+		CreatedAt:      c.server.now(),
+	}
+	user.favorites = append(user.favorites, favorite)
+	result := *favorite
+	return &result, nil
+}
+
+// ListFavorites lists favorite messages across conversations, newest first
+func (c *FakeClient) ListFavorites(_ context.Context, cursor int64, limit int) (*FavoriteListPage, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if cursor < 0 || limit < 0 || limit > 100 {
+		return nil, ErrInvalidParam
+	}
+	if limit == 0 {
+		limit = 20
+	}
+	favorites := c.server.users[userId].favorites
+	result := &FavoriteListPage{List: []*MessageFavorite{}}
+	for i := len(favorites) - 1; i >= 0; i-- {
+		if cursor > 0 && favorites[i].Id >= cursor {
+			continue
+		}
+		if len(result.List) == limit {
+			result.HasMore = true
+			result.NextCursor = result.List[limit-1].Id
+			break
+		}
+		favorite := *favorites[i]
+		result.List = append(result.List, &favorite)
+	}
+	return result, nil
+}
+
+// RemoveFavorite removes a favorite
+func (c *FakeClient) RemoveFavorite(_ context.Context, favoriteId int64) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	user := c.server.users[userId]
+	for i, favorite := range user.favorites {
+		if favorite.Id == favoriteId {
+			user.favorites = append(user.favorites[:i], user.favorites[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// fakeRevokeWindow is how long after sending a message can be revoked, the server's default
+const fakeRevokeWindow = 2 * time.Minute
+
+// RevokeMessage recalls a message the current user sent and adds the revoke notification to
+// the conversation. The fake does not push PushMessageRevoked events.
+func (c *FakeClient) RevokeMessage(_ context.Context, conversationId string, seq int64) (*MessageInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" || seq <= 0 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	msg := conv.messages[seq-1]
+	if msg.SenderId != userId {
+		return nil, ErrNoPermission
+	}
+	if msg.RevokedAt > 0 {
+		return nil, ErrMessageRevoked
+	}
+	if msg.MsgType == MsgTypeRevoke {
+		return nil, ErrInvalidParam
+	}
+	now := c.server.now()
+	if now-msg.SendAt > fakeRevokeWindow.Milliseconds() {
+		return nil, ErrRevokeExpired
+	}
+
+	msg.Content = MessageContent{}
+	msg.Extra = nil
+	msg.RevokedAt = now
+	notice := &MessageInfo{
+		Id:             int64(len(conv.messages) + 1),
+		ConversationId: conv.id,
+		Seq:            int64(len(conv.messages) + 1),
+		ClientMsgId:    fmt.Sprintf("rv_%d", msg.Id),
+		SenderId:       userId,
+		SessionType:    msg.SessionType,
+		MsgType:        MsgTypeRevoke,
+		Content:        MessageContent{Revoke: &RevokeContent{Seq: msg.Seq}},
+		SendAt:         now,
+	}
+	conv.messages = append(conv.messages, notice)
+	for id, user := range c.server.users {
+		if info, ok := user.convs[conv.id]; ok {
+			info.UpdatedAt = now
+			if id == userId {
+				info.ReadSeq = notice.Seq
+			}
+		}
+	}
+	conv.dropMessageData(msg.Seq)
+	result := *notice
+	return &result, nil
+}
+
+// DeleteMessage deletes a message for the current user only (DeleteScopeSelf) or for everyone
+// (DeleteScopeAll), like the server. The fake does not push PushMessageDeleted events.
+func (c *FakeClient) DeleteMessage(_ context.Context, conversationId string, seq int64, scope string) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	if conversationId == "" || seq <= 0 || (scope != DeleteScopeSelf && scope != DeleteScopeAll) {
+		return ErrInvalidParam
+	}
+	user := c.server.users[userId]
+	if _, ok := user.convs[conversationId]; !ok {
+		return ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	if seq > int64(len(conv.messages)) || disappeared(conv.messages[seq-1]) || c.server.hiddenFor(userId, conv.messages[seq-1]) {
+		return ErrMessageNotFound
+	}
+	msg := conv.messages[seq-1]
+	if scope == DeleteScopeSelf {
+		if user.hidden == nil {
+			user.hidden = make(map[string]map[int64]bool)
+		}
+		if user.hidden[conv.id] == nil {
+			user.hidden[conv.id] = make(map[int64]bool)
+		}
+		user.hidden[conv.id][seq] = true
+		return nil
+	}
+
+	if msg.RevokedAt > 0 {
+		return ErrMessageRevoked
+	}
+	if msg.DeletedAt > 0 || msg.MsgType == MsgTypeRevoke {
+		return ErrInvalidParam
+	}
+	now := c.server.now()
+	admin := false
+	if conv.convType == SessionTypeGroup {
+		_, _, err = c.server.groupAdmin(userId, conv.groupId)
+		admin = err == nil
+	}
+	if !admin {
+		if msg.SenderId != userId {
+			return ErrNoPermission
+		}
+		if now-msg.SendAt > fakeRevokeWindow.Milliseconds() {
+			return ErrRevokeExpired
+		}
+	}
+	msg.Content = MessageContent{}
+	msg.Extra = nil
+	msg.DeletedAt = now
+	conv.dropMessageData(seq)
+	return nil
+}
+
+// dropMessageData deletes what refers to the message at seq once its content is cleared, like
+// the server
+func (conv *fakeConversation) dropMessageData(seq int64) {
+	delete(conv.edits, seq)
+	delete(conv.reactions, seq)
+	conv.pins = slices.DeleteFunc(conv.pins, func(pin *PinnedMessage) bool { return pin.Seq == seq })
+}
+
+// hiddenFor reports whether userId deleted msg for themselves
+func (s *FakeServer) hiddenFor(userId string, msg *MessageInfo) bool {
+	return s.users[userId].hidden[msg.ConversationId][msg.Seq]
+}
+
+// fakeMaxMessageEdits caps the edits of a message, like the server
+const fakeMaxMessageEdits = 100
+
+// EditMessage replaces the text of a text message the current user sent and keeps the replaced
+// content in its edit history. The fake does not push PushMessageEdited events.
+func (c *FakeClient) EditMessage(_ context.Context, req *EditMessageRequest) (*MessageInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	content := req.Content
+	if req.ConversationId == "" || req.Seq <= 0 || content.Text == "" ||
+		content.Image != "" || content.Video != "" || content.Audio != "" || content.File != "" ||
+		content.Custom != "" || content.Poll != nil || content.Revoke != nil {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[req.ConversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[req.ConversationId]
+	if req.Seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	msg := conv.messages[req.Seq-1]
+	if msg.SenderId != userId {
+		return nil, ErrNoPermission
+	}
+	if msg.RevokedAt > 0 {
+		return nil, ErrMessageRevoked
+	}
+	if msg.MsgType != MsgTypeText {
+		return nil, ErrInvalidParam
+	}
+	if msg.EditVersion >= fakeMaxMessageEdits {
+		return nil, ErrTooManyRequests
+	}
+
+	if conv.edits == nil {
+		conv.edits = make(map[int64][]*MessageEdit)
+	}
+	conv.edits[msg.Seq] = append(conv.edits[msg.Seq], &MessageEdit{
+		ConversationId: conv.id,
+		Seq:            msg.Seq,
+		Version:        msg.EditVersion,
+		Content:        msg.Content,
+		EditedAt:       c.server.now(),
+	})
+	content.Mentions = slices.Clone(content.Mentions)
+	msg.Content = content
+	msg.Extra = nil
+	msg.EditVersion++
+	result := *msg
+	return &result, nil
+}
+
+// GetEditHistory lists the versions of a message replaced by edits, oldest first
+func (c *FakeClient) GetEditHistory(_ context.Context, conversationId string, seq int64) ([]*MessageEdit, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" || seq <= 0 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	edits := make([]*MessageEdit, 0, len(conv.edits[seq]))
+	for _, edit := range conv.edits[seq] {
+		copied := *edit
+		edits = append(edits, &copied)
+	}
+	return edits, nil
+}
+
+// GetReadStatus gets whether a single chat message was delivered to its receiver and read.
+// Messages are delivered when the receiver pulls them, the fake never pushes PushDelivered events.
+func (c *FakeClient) GetReadStatus(_ context.Context, conversationId string, seq int64) (*ReadStatus, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" || seq <= 0 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	if conv.convType != SessionTypeSingle {
+		return nil, ErrInvalidParam
+	}
+	// The receiver is the other party, or the user in the saved messages conversation
+	receiverId := peerOf(conv.id, conv.messages[seq-1].SenderId)
+	status := &ReadStatus{ConversationId: conv.id, Seq: seq}
+	if info, ok := c.server.users[receiverId].convs[conv.id]; ok {
+		status.ReadSeq = info.ReadSeq
+	}
+	status.DeliveredSeq = max(conv.delivered[receiverId], status.ReadSeq)
+	status.Read = status.ReadSeq >= seq
+	status.Delivered = status.DeliveredSeq >= seq
+	return status, nil
+}
+
+// GetGroupReadMembers lists the members who read a group message. The fake does not push
+// PushReadCounts events.
+func (c *FakeClient) GetGroupReadMembers(_ context.Context, conversationId string, seq int64) (*GroupReadMembers, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" || seq <= 0 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	if conv.convType != SessionTypeGroup {
+		return nil, ErrInvalidParam
+	}
+	senderId := conv.messages[seq-1].SenderId
+	members := &GroupReadMembers{ConversationId: conv.id, Seq: seq, Readers: []*GroupReader{}}
+	for id, user := range c.server.users {
+		if info, ok := user.convs[conv.id]; ok && id != senderId && info.ReadSeq >= seq {
+			members.Readers = append(members.Readers, &GroupReader{UserId: id, ReadSeq: info.ReadSeq})
+		}
+	}
+	sort.Slice(members.Readers, func(i, j int) bool { return members.Readers[i].UserId < members.Readers[j].UserId })
+	members.ReadCount = int64(len(members.Readers))
+	return members, nil
+}
+
+// ListThread lists the replies quoting a message, oldest first, at most limit (default 20)
+func (c *FakeClient) ListThread(_ context.Context, conversationId string, seq int64, cursor int64, limit int) (*ThreadPage, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" || seq <= 0 || cursor < 0 || limit > 100 {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	if seq > int64(len(conv.messages)) {
+		return nil, ErrMessageNotFound
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	page := &ThreadPage{List: []*MessageInfo{}}
+	for _, reply := range conv.messages[max(cursor, seq):] {
+		if sent, ok := conv.quotes[reply.Seq]; !ok || sent.Seq != seq {
+			continue
+		}
+		if len(page.List) == limit {
+			page.HasMore = true
+			page.NextCursor = page.List[limit-1].Seq
+			break
+		}
+		msg := *reply
+		msg.Reactions = c.server.reactionSummaries(userId, conv, msg.Seq)
+		msg.QuotedMsg = c.server.quoteOf(conv, msg.Seq)
+		page.List = append(page.List, &msg)
+	}
+	return page, nil
+}
+
+// fakeMaxReactionsPerUser caps the emojis a user reacts with to one message, like the server
+const fakeMaxReactionsPerUser = 20
+
+// AddReaction reacts with an emoji to a message. The fake does not push PushReactionChanged events.
+func (c *FakeClient) AddReaction(_ context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv, err := c.server.reactable(userId, conversationId, seq, emoji)
+	if err != nil {
+		return nil, err
+	}
+	mine := 0
+	for _, reaction := range conv.reactions[seq] {
+		if reaction.userId == userId {
+			if reaction.emoji == emoji {
+				return c.server.reactionChange(userId, conv, seq, emoji, true), nil
+			}
+			mine++
+		}
+	}
+	if mine >= fakeMaxReactionsPerUser {
+		return nil, ErrTooManyRequests
+	}
+	if conv.reactions == nil {
+		conv.reactions = make(map[int64][]*fakeReaction)
+	}
+	conv.reactions[seq] = append(conv.reactions[seq], &fakeReaction{userId: userId, emoji: emoji})
+	return c.server.reactionChange(userId, conv, seq, emoji, true), nil
+}
+
+// RemoveReaction removes a reaction of the current user to a message. The fake does not push
+// PushReactionChanged events.
+func (c *FakeClient) RemoveReaction(_ context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv, err := c.server.reactable(userId, conversationId, seq, emoji)
+	if err != nil {
+		return nil, err
+	}
+	conv.reactions[seq] = slices.DeleteFunc(conv.reactions[seq], func(reaction *fakeReaction) bool {
+		return reaction.userId == userId && reaction.emoji == emoji
+	})
+	return c.server.reactionChange(userId, conv, seq, emoji, false), nil
+}
+
+// VotePoll replaces the current user's vote on a poll message. The fake does not push
+// PushPollUpdated events.
+func (c *FakeClient) VotePoll(_ context.Context, req *VotePollRequest) (*PollResult, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv, poll, err := c.server.poll(userId, req.ConversationId, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	options := slices.Clone(req.Options)
+	slices.Sort(options)
+	if len(options) > 1 && !poll.MultiChoice {
+		return nil, ErrInvalidParam
+	}
+	for i, option := range options {
+		if option < 0 || option >= len(poll.Options) || (i > 0 && options[i-1] == option) {
+			return nil, ErrInvalidParam
+		}
+	}
+	if conv.pollVotes == nil {
+		conv.pollVotes = make(map[int64]map[string][]int)
+	}
+	if conv.pollVotes[req.Seq] == nil {
+		conv.pollVotes[req.Seq] = make(map[string][]int)
+	}
+	if len(options) == 0 {
+		delete(conv.pollVotes[req.Seq], userId)
+	} else {
+		conv.pollVotes[req.Seq][userId] = options
+	}
+	return c.server.pollResult(userId, conv, poll, req.Seq), nil
+}
+
+// GetPollResult gets the result of a poll message
+func (c *FakeClient) GetPollResult(_ context.Context, conversationId string, seq int64) (*PollResult, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv, poll, err := c.server.poll(userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	return c.server.pollResult(userId, conv, poll, seq), nil
+}
+
+// ExportMessages returns an iterator over all messages of a conversation
+func (c *FakeClient) ExportMessages(_ context.Context, conversationId string) (*MessageIterator, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.exportMessages(userId, conversationId)
+}
+
+// InternalExportMessages returns an iterator over all messages of a conversation of the acting user
+func (c *FakeClient) InternalExportMessages(_ context.Context, conversationId string, opts ...RequestOption) (*MessageIterator, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.exportMessages(userId, conversationId)
+}
+
+func (s *FakeServer) exportMessages(userId, conversationId string) (*MessageIterator, error) {
+	if _, ok := s.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	messages := make([]*MessageInfo, 0, len(s.convs[conversationId].messages))
+	for _, msg := range s.convs[conversationId].messages {
+		if !disappeared(msg) {
+			messages = append(messages, msg)
+		}
+	}
+	return newSliceMessageIterator(messages), nil
+}
+
+// GetAllConversationList gets all conversations of the current user
+func (c *FakeClient) GetAllConversationList(ctx context.Context) ([]*ConversationInfo, error) {
+	return c.GetAllConversationListWithLastMessage(ctx, false)
+}
+
+// GetAllConversationListWithLastMessage gets all conversations, latest first
+func (c *FakeClient) GetAllConversationListWithLastMessage(_ context.Context, withLastMessage bool) ([]*ConversationInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.conversationList(userId, withLastMessage)
+}
+
+// GetConversationList gets conversations with cursor pagination
+func (c *FakeClient) GetConversationList(ctx context.Context, limit int, cursor *ConversationListCursor) (*ConversationListPage, error) {
+	return c.GetConversationListWithLastMessage(ctx, false, limit, cursor)
+}
+
+// GetConversationListWithLastMessage gets conversations with cursor pagination
+func (c *FakeClient) GetConversationListWithLastMessage(_ context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor) (*ConversationListPage, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.conversationPage(userId, withLastMessage, limit, cursor)
+}
+
+// InternalGetAllConversationList gets all conversations of the acting user
+func (c *FakeClient) InternalGetAllConversationList(ctx context.Context, opts ...RequestOption) ([]*ConversationInfo, error) {
+	return c.InternalGetAllConversationListWithLastMessage(ctx, false, opts...)
+}
+
+// InternalGetAllConversationListWithLastMessage gets all conversations of the acting user
+func (c *FakeClient) InternalGetAllConversationListWithLastMessage(_ context.Context, withLastMessage bool, opts ...RequestOption) ([]*ConversationInfo, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.conversationList(userId, withLastMessage)
+}
+
+// InternalGetConversationList gets conversations of the acting user with cursor pagination
+func (c *FakeClient) InternalGetConversationList(ctx context.Context, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error) {
+	return c.InternalGetConversationListWithLastMessage(ctx, false, limit, cursor, opts...)
+}
+
+// InternalGetConversationListWithLastMessage gets conversations of the acting user with cursor pagination
+func (c *FakeClient) InternalGetConversationListWithLastMessage(_ context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error) {
+	userId, err := c.lockActing(opts)
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.conversationPage(userId, withLastMessage, limit, cursor)
+}
+
+// GetConversation gets a conversation of the current user
+func (c *FakeClient) GetConversation(_ context.Context, conversationId string) (*ConversationInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.server.conversationInfo(userId, conversationId, false)
+}
+
+// GetSavedConversation gets the saved messages conversation, creating it on first use
+func (c *FakeClient) GetSavedConversation(_ context.Context) (*ConversationInfo, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv := c.server.conversation(SavedConversationId(userId), SessionTypeSingle, "")
+	c.server.ownConversation(c.server.users[userId], conv)
+	return c.server.conversationInfo(userId, conv.id, false)
+}
+
+// UpdateConversation updates conversation settings
+func (c *FakeClient) UpdateConversation(_ context.Context, conversationId string, req *UpdateConversationRequest) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	own, ok := c.server.users[userId].convs[conversationId]
+	if !ok {
+		return ErrConvNotFound
+	}
+	if req.TTLSeconds != nil {
+		if err := c.server.setTTL(userId, c.server.convs[conversationId], *req.TTLSeconds); err != nil {
+			return err
+		}
+	}
+	if req.RecvMsgOpt != nil {
+		own.RecvMsgOpt = *req.RecvMsgOpt
+	}
+	if req.IsPinned != nil {
+		own.IsPinned = *req.IsPinned
+	}
+	own.UpdatedAt = c.server.now()
+	return nil
+}
+
+// SetConversationPinned sets the pinned status of a conversation
+func (c *FakeClient) SetConversationPinned(ctx context.Context, conversationId string, isPinned bool) error {
+	return c.UpdateConversation(ctx, conversationId, &UpdateConversationRequest{IsPinned: &isPinned})
+}
+
+// SetConversationRecvMsgOpt sets the receive message option of a conversation
+func (c *FakeClient) SetConversationRecvMsgOpt(ctx context.Context, conversationId string, recvMsgOpt int32) error {
+	return c.UpdateConversation(ctx, conversationId, &UpdateConversationRequest{RecvMsgOpt: &recvMsgOpt})
+}
+
+// SetConversationTTL sets the disappearing messages timer of a conversation
+func (c *FakeClient) SetConversationTTL(ctx context.Context, conversationId string, ttlSeconds int32) error {
+	return c.UpdateConversation(ctx, conversationId, &UpdateConversationRequest{TTLSeconds: &ttlSeconds})
+}
+
+// PinMessage pins a message in a conversation. The fake does not push PushPinChanged events.
+func (c *FakeClient) PinMessage(_ context.Context, conversationId string, seq int64) (*PinChange, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv, err := c.server.pinnable(userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	if seq > int64(len(conv.messages)) || disappeared(conv.messages[seq-1]) || c.server.hiddenFor(userId, conv.messages[seq-1]) {
+		return nil, ErrMessageNotFound
+	}
+	msg := conv.messages[seq-1]
+	if msg.RevokedAt > 0 {
+		return nil, ErrMessageRevoked
+	}
+	if msg.MsgType == MsgTypeRevoke {
+		return nil, ErrInvalidParam
+	}
+	change := &PinChange{ConversationId: conv.id, Seq: seq, UserId: userId, Pinned: true}
+	if slices.ContainsFunc(conv.pins, func(pin *PinnedMessage) bool { return pin.Seq == seq }) {
+		return change, nil
+	}
+	if len(conv.pins) >= fakeMaxPinnedMessages {
+		return nil, ErrTooManyRequests
+	}
+	conv.pins = append(conv.pins, &PinnedMessage{Seq: seq, PinnedBy: userId, PinnedAt: c.server.now()})
+	return change, nil
+}
+
+// UnpinMessage unpins a message in a conversation. The fake does not push PushPinChanged events.
+func (c *FakeClient) UnpinMessage(_ context.Context, conversationId string, seq int64) (*PinChange, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	conv, err := c.server.pinnable(userId, conversationId, seq)
+	if err != nil {
+		return nil, err
+	}
+	conv.pins = slices.DeleteFunc(conv.pins, func(pin *PinnedMessage) bool { return pin.Seq == seq })
+	return &PinChange{ConversationId: conv.id, Seq: seq, UserId: userId}, nil
+}
+
+// GetPinnedMessages gets the messages pinned in a conversation, newest pin first, skipping
+// the disappeared ones
+func (c *FakeClient) GetPinnedMessages(_ context.Context, conversationId string) ([]*PinnedMessage, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conversationId == "" {
+		return nil, ErrInvalidParam
+	}
+	if _, ok := c.server.users[userId].convs[conversationId]; !ok {
+		return nil, ErrNoPermission
+	}
+	conv := c.server.convs[conversationId]
+	result := []*PinnedMessage{}
+	for i := len(conv.pins) - 1; i >= 0; i-- {
+		pin := *conv.pins[i]
+		if disappeared(conv.messages[pin.Seq-1]) || c.server.hiddenFor(userId, conv.messages[pin.Seq-1]) {
+			continue
+		}
+		msg := *conv.messages[pin.Seq-1]
+		msg.Reactions = c.server.reactionSummaries(userId, conv, msg.Seq)
+		msg.QuotedMsg = c.server.quoteOf(conv, msg.Seq)
+		pin.Message = &msg
+		result = append(result, &pin)
+	}
+	return result, nil
+}
+
+// MarkRead marks a conversation as read up to a seq, clamped to the max seq. Like the server,
+// the read seq only advances.
+func (c *FakeClient) MarkRead(_ context.Context, conversationId string, readSeq int64) error {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return err
+	}
+	if readSeq < 0 {
+		return ErrInvalidParam
+	}
+	own, ok := c.server.users[userId].convs[conversationId]
+	if !ok {
+		return ErrConvNotFound
+	}
+	own.ReadSeq = max(own.ReadSeq, min(readSeq, int64(len(c.server.convs[conversationId].messages))))
+	return nil
+}
+
+// GetMaxReadSeq gets the max seq and read seq of a conversation
+func (c *FakeClient) GetMaxReadSeq(_ context.Context, conversationId string) (*MaxReadSeqResponse, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.server.conversationInfo(userId, conversationId, false)
+	if err != nil {
+		return nil, err
+	}
+	return &MaxReadSeqResponse{MaxSeq: info.MaxSeq, ReadSeq: info.ReadSeq, UnreadCount: info.UnreadCount}, nil
+}
+
+// GetUnreadCount gets the unread count of a conversation, counting from readSeq when it is set
+func (c *FakeClient) GetUnreadCount(_ context.Context, conversationId string, readSeq int64) (int64, error) {
+	userId, err := c.lock()
+	defer c.unlock()
+	if err != nil {
+		return 0, err
+	}
+	info, err := c.server.conversationInfo(userId, conversationId, false)
+	if err != nil {
+		return 0, err
+	}
+	if readSeq > 0 {
+		return max(info.MaxSeq-readSeq, 0), nil
+	}
+	return info.UnreadCount, nil
+}
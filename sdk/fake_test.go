@@ -0,0 +1,1137 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeServerChat(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+
+	for _, id := range []string{"alice", "bob"} {
+		_, err := alice.Register(ctx, &RegisterRequest{UserId: id, Nickname: id, Password: "secret"})
+		require.NoError(t, err)
+	}
+	_, err := alice.Register(ctx, &RegisterRequest{UserId: "bob", Password: "secret"})
+	requireCode(t, err, CodeUserExists)
+
+	_, err = alice.GetUserInfo(ctx)
+	requireCode(t, err, CodeTokenMissing)
+	_, err = alice.LoginWithUserId(ctx, "alice", "wrong", PlatformIdWeb)
+	requireCode(t, err, CodePasswordWrong)
+	_, err = alice.LoginWithUserId(ctx, "alice", "secret", PlatformIdWeb)
+	require.NoError(t, err)
+	_, err = bob.LoginWithUserId(ctx, "bob", "secret", PlatformIdIOS)
+	require.NoError(t, err)
+
+	msg, err := alice.SendTextMessage(ctx, "c1", "bob", "hi")
+	require.NoError(t, err)
+	require.Equal(t, SingleConversationId("bob", "alice"), msg.ConversationId)
+	require.EqualValues(t, 1, msg.Seq)
+
+	// Resending the same client message id is idempotent
+	again, err := alice.SendTextMessage(ctx, "c1", "bob", "hi")
+	require.NoError(t, err)
+	require.Equal(t, msg.Seq, again.Seq)
+
+	conv, err := bob.GetConversation(ctx, msg.ConversationId)
+	require.NoError(t, err)
+	require.Equal(t, "alice", conv.PeerUserId)
+	require.EqualValues(t, 1, conv.UnreadCount)
+
+	pulled, err := bob.PullMessages(ctx, msg.ConversationId, 0, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, pulled.Messages, 1)
+	require.Equal(t, "hi", pulled.Messages[0].Content.Text)
+
+	require.NoError(t, bob.MarkRead(ctx, msg.ConversationId, 10))
+	seqs, err := bob.GetMaxReadSeq(ctx, msg.ConversationId)
+	require.NoError(t, err)
+	require.Equal(t, &MaxReadSeqResponse{MaxSeq: 1, ReadSeq: 1}, seqs)
+
+	carol := server.NewClient()
+	_, err = carol.Register(ctx, &RegisterRequest{UserId: "carol", Password: "secret"})
+	require.NoError(t, err)
+	_, err = carol.LoginWithUserId(ctx, "carol", "secret", PlatformIdWeb)
+	require.NoError(t, err)
+	_, err = carol.PullMessages(ctx, msg.ConversationId, 0, 0, 0)
+	requireCode(t, err, CodeNoPermission)
+}
+
+func TestFakeServerGroupsAndConversationPages(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	owner := server.NewClient()
+	for _, id := range []string{"owner", "member"} {
+		_, err := owner.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+	}
+	_, err := owner.LoginWithUserId(ctx, "owner", "secret", PlatformIdWeb)
+	require.NoError(t, err)
+
+	group, err := owner.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"member"}})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, group.MemberCount)
+
+	_, err = owner.SendGroupTextMessage(ctx, "g1", group.Id, "hello team")
+	require.NoError(t, err)
+	_, err = owner.SendTextMessage(ctx, "s1", "member", "hello member")
+	require.NoError(t, err)
+
+	// The acting user of internal calls comes from WithActAsUser
+	convs, err := owner.InternalGetAllConversationListWithLastMessage(ctx, true, WithActAsUser("member", PlatformIdWeb))
+	require.NoError(t, err)
+	require.Len(t, convs, 2)
+	require.Equal(t, SingleConversationId("owner", "member"), convs[0].ConversationId)
+	require.Equal(t, "hello member", convs[0].LastMessage.Content.Text)
+	_, err = owner.InternalGetAllConversationList(ctx)
+	requireCode(t, err, CodeUnauthorized)
+
+	page, err := owner.GetConversationList(ctx, 1, nil)
+	require.NoError(t, err)
+	require.True(t, page.HasMore)
+	page, err = owner.GetConversationList(ctx, 1, page.NextCursor)
+	require.NoError(t, err)
+	require.False(t, page.HasMore)
+	require.Equal(t, GroupConversationId(group.Id), page.List[0].ConversationId)
+
+	// The owner has to hand the group over before quitting, like on the server
+	requireCode(t, owner.QuitGroup(ctx, group.Id), CodeCannotKickOwner)
+	require.NoError(t, owner.TransferGroupOwnership(ctx, group.Id, "member"))
+	require.NoError(t, owner.QuitGroup(ctx, group.Id))
+	_, err = owner.SendGroupTextMessage(ctx, "g2", group.Id, "bye")
+	requireCode(t, err, CodeNotGroupMember)
+}
+
+func TestFakeServerGroupAdministration(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	owner, admin, member := server.NewClient(), server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"owner": owner, "admin": admin, "member": member} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+
+	group, err := owner.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"admin"}})
+	require.NoError(t, err)
+	// Ownership moves to another member and back; only the current owner may hand it over
+	require.NoError(t, owner.TransferGroupOwnership(ctx, group.Id, "admin"))
+	requireCode(t, owner.TransferGroupOwnership(ctx, group.Id, "admin"), CodeNotGroupOwner)
+	require.NoError(t, admin.TransferGroupOwnership(ctx, group.Id, "owner"))
+
+	link, err := owner.CreateGroupInviteLink(ctx, &CreateInviteLinkRequest{GroupId: group.Id, MaxUses: 1})
+	require.NoError(t, err)
+	_, err = member.CreateGroupInviteLink(ctx, &CreateInviteLinkRequest{GroupId: group.Id})
+	requireCode(t, err, CodeNotGroupMember)
+	groupId, err := member.JoinGroupByInviteLink(ctx, link.Token)
+	require.NoError(t, err)
+	require.Equal(t, group.Id, groupId)
+	_, err = admin.InternalJoinGroupByInviteLink(ctx, link.Token, WithActAsUser("admin", PlatformIdWeb))
+	requireCode(t, err, CodeInviteLinkInvalid)
+
+	requireCode(t, admin.MuteGroupMember(ctx, group.Id, "member", time.Minute), CodeNotGroupAdmin)
+	require.NoError(t, owner.MuteGroupMember(ctx, group.Id, "member", time.Minute))
+	_, err = member.SendGroupTextMessage(ctx, "m1", group.Id, "hi")
+	requireCode(t, err, CodeMemberMuted)
+	require.NoError(t, owner.MuteGroupMember(ctx, group.Id, "member", 0))
+
+	// Only the owner promotes and demotes admins, who then manage normal members only
+	requireCode(t, admin.PromoteGroupAdmin(ctx, group.Id, "member"), CodeNotGroupOwner)
+	requireCode(t, owner.PromoteGroupAdmin(ctx, group.Id, "owner"), CodeInvalidParam)
+	requireCode(t, owner.PromoteGroupAdmin(ctx, group.Id, "stranger"), CodeNotGroupMember)
+	require.NoError(t, owner.PromoteGroupAdmin(ctx, group.Id, "admin"))
+	require.NoError(t, owner.PromoteGroupAdmin(ctx, group.Id, "admin"))
+	members, err := owner.GetGroupMembers(ctx, group.Id)
+	require.NoError(t, err)
+	for _, m := range members {
+		if m.UserId == "admin" {
+			require.Equal(t, int32(RoleLevelAdmin), m.RoleLevel)
+		}
+	}
+	require.NoError(t, admin.MuteGroupMember(ctx, group.Id, "member", time.Minute))
+	require.NoError(t, admin.MuteGroupMember(ctx, group.Id, "member", 0))
+	requireCode(t, admin.MuteGroupMember(ctx, group.Id, "owner", time.Minute), CodeCannotKickOwner)
+	requireCode(t, admin.DemoteGroupAdmin(ctx, group.Id, "member"), CodeNotGroupOwner)
+	require.NoError(t, owner.InternalDemoteGroupAdmin(ctx, group.Id, "admin", WithActAsUser("owner", PlatformIdWeb)))
+	requireCode(t, admin.MuteGroupMember(ctx, group.Id, "member", time.Minute), CodeNotGroupAdmin)
+
+	muteAll := true
+	info, err := owner.UpdateGroupInfo(ctx, &UpdateGroupInfoRequest{GroupId: group.Id, Name: "renamed", MuteAll: &muteAll})
+	require.NoError(t, err)
+	require.Equal(t, "renamed", info.Name)
+	_, err = member.SendGroupTextMessage(ctx, "m2", group.Id, "hi")
+	requireCode(t, err, CodeGroupMuted)
+	_, err = owner.SendGroupTextMessage(ctx, "o1", group.Id, "owners still talk")
+	require.NoError(t, err)
+
+	info, err = owner.SetGroupAnnouncement(ctx, group.Id, "be nice")
+	require.NoError(t, err)
+	require.Equal(t, "be nice", info.Announcement)
+
+	requireCode(t, owner.KickGroupMembers(ctx, group.Id, []string{"member", "owner"}), CodeCannotKickOwner)
+	require.NoError(t, owner.KickGroupMembers(ctx, group.Id, []string{"member"}))
+	members, err = owner.GetGroupMembers(ctx, group.Id)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	requireCode(t, owner.QuitGroup(ctx, group.Id), CodeCannotKickOwner)
+
+	// Only the owner dismisses the group, which then refuses messages and joins
+	requireCode(t, admin.DismissGroup(ctx, group.Id), CodeNotGroupOwner)
+	require.NoError(t, owner.DismissGroup(ctx, group.Id))
+	_, err = owner.SendGroupTextMessage(ctx, "o2", group.Id, "anyone?")
+	requireCode(t, err, CodeGroupDismissed)
+	requireCode(t, member.JoinGroup(ctx, group.Id, ""), CodeGroupDismissed)
+	info, err = owner.GetGroupInfo(ctx, group.Id)
+	require.NoError(t, err)
+	require.Equal(t, int32(GroupStatusDismissed), info.Status)
+}
+
+func TestFakeServerUnreadMentions(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	group, err := alice.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"bob"}})
+	require.NoError(t, err)
+
+	send := func(clientMsgId string, mentions ...string) *MessageInfo {
+		msg, err := alice.SendMessage(ctx, &SendMessageRequest{
+			ClientMsgId: clientMsgId,
+			GroupId:     group.Id,
+			SessionType: SessionTypeGroup,
+			MsgType:     MsgTypeText,
+			Content:     MessageContent{Text: "hello", Mentions: mentions},
+		})
+		require.NoError(t, err)
+		return msg
+	}
+	first := send("m1", "bob")
+	send("m2")
+	last := send("m3", "bob", "alice")
+
+	conv, err := bob.GetConversation(ctx, first.ConversationId)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, conv.UnreadMentionCount)
+	require.Equal(t, first.Seq, conv.FirstUnreadMentionSeq)
+
+	// The sender is never counted as mentioned
+	conv, err = alice.GetConversation(ctx, first.ConversationId)
+	require.NoError(t, err)
+	require.Zero(t, conv.UnreadMentionCount)
+
+	require.NoError(t, bob.MarkRead(ctx, first.ConversationId, first.Seq))
+	conv, err = bob.GetConversation(ctx, first.ConversationId)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, conv.UnreadMentionCount)
+	require.Equal(t, last.Seq, conv.FirstUnreadMentionSeq)
+}
+
+func TestFakeServerReminders(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	msg, err := alice.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "m1",
+		RecvId:      "bob",
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: "call me tomorrow"},
+	})
+	require.NoError(t, err)
+
+	_, err = bob.CreateReminder(ctx, &CreateReminderRequest{ConversationId: msg.ConversationId, Seq: msg.Seq})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = bob.CreateReminder(ctx, &CreateReminderRequest{ConversationId: msg.ConversationId, Seq: msg.Seq, RemindIn: 60, RemindAt: time.Now().Add(time.Hour).UnixMilli()})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = bob.CreateReminder(ctx, &CreateReminderRequest{ConversationId: msg.ConversationId, Seq: msg.Seq + 1, RemindIn: 60})
+	requireCode(t, err, CodeMessageNotFound)
+
+	later, err := bob.CreateReminder(ctx, &CreateReminderRequest{ConversationId: msg.ConversationId, Seq: msg.Seq, RemindIn: 3600, Note: "call back"})
+	require.NoError(t, err)
+	sooner, err := bob.CreateReminder(ctx, &CreateReminderRequest{ConversationId: msg.ConversationId, Seq: msg.Seq, RemindIn: 60})
+	require.NoError(t, err)
+	require.EqualValues(t, ReminderStatusPending, later.Status)
+
+	reminders, err := bob.ListReminders(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 2)
+	require.Equal(t, sooner.Id, reminders[0].Id)
+
+	// Reminders are private to their owner
+	requireCode(t, alice.CancelReminder(ctx, later.Id), CodeNotFound)
+	require.NoError(t, bob.CancelReminder(ctx, later.Id))
+	requireCode(t, bob.CancelReminder(ctx, later.Id), CodeNotFound)
+	reminders, err = bob.ListReminders(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+}
+
+func TestFakeServerFavorites(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	var sent []*MessageInfo
+	for _, text := range []string{"one", "two", "three"} {
+		msg, err := alice.SendMessage(ctx, &SendMessageRequest{
+			ClientMsgId: text,
+			RecvId:      "bob",
+			SessionType: SessionTypeSingle,
+			MsgType:     MsgTypeText,
+			Content:     MessageContent{Text: text},
+		})
+		require.NoError(t, err)
+		sent = append(sent, msg)
+	}
+	convId := sent[0].ConversationId
+
+	_, err := bob.AddFavorite(ctx, convId, 4)
+	requireCode(t, err, CodeMessageNotFound)
+	_, err = bob.AddFavorite(ctx, "si_x_y", 1)
+	requireCode(t, err, CodeNoPermission)
+
+	first, err := bob.AddFavorite(ctx, convId, sent[0].Seq)
+	require.NoError(t, err)
+	require.Equal(t, "alice", first.SenderId)
+	require.Equal(t, "one", first.Message.Content.Text)
+	again, err := bob.AddFavorite(ctx, convId, sent[0].Seq)
+	require.NoError(t, err)
+	require.Equal(t, first.Id, again.Id)
+	for _, msg := range sent[1:] {
+		_, err = bob.AddFavorite(ctx, convId, msg.Seq)
+		require.NoError(t, err)
+	}
+
+	page, err := bob.ListFavorites(ctx, 0, 2)
+	require.NoError(t, err)
+	require.True(t, page.HasMore)
+	require.Len(t, page.List, 2)
+	require.Equal(t, "three", page.List[0].Message.Content.Text)
+	page, err = bob.ListFavorites(ctx, page.NextCursor, 2)
+	require.NoError(t, err)
+	require.False(t, page.HasMore)
+	require.Len(t, page.List, 1)
+	require.Equal(t, first.Id, page.List[0].Id)
+
+	// Favorites are private to their owner
+	requireCode(t, alice.RemoveFavorite(ctx, first.Id), CodeNotFound)
+	require.NoError(t, bob.RemoveFavorite(ctx, first.Id))
+	page, err = bob.ListFavorites(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.List, 2)
+}
+
+func TestFakeServerRevokeMessage(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	var sent []*MessageInfo
+	for _, text := range []string{"oops", "old"} {
+		msg, err := alice.SendMessage(ctx, &SendMessageRequest{
+			ClientMsgId: text,
+			RecvId:      "bob",
+			SessionType: SessionTypeSingle,
+			MsgType:     MsgTypeText,
+			Content:     MessageContent{Text: text},
+		})
+		require.NoError(t, err)
+		sent = append(sent, msg)
+	}
+	convId := sent[0].ConversationId
+
+	_, err := bob.RevokeMessage(ctx, convId, sent[0].Seq)
+	requireCode(t, err, CodeNoPermission)
+	_, err = alice.RevokeMessage(ctx, convId, 3)
+	requireCode(t, err, CodeMessageNotFound)
+
+	notice, err := alice.RevokeMessage(ctx, convId, sent[0].Seq)
+	require.NoError(t, err)
+	require.EqualValues(t, MsgTypeRevoke, notice.MsgType)
+	require.EqualValues(t, 3, notice.Seq)
+	require.Equal(t, sent[0].Seq, notice.Content.Revoke.Seq)
+
+	resp, err := bob.PullMessages(ctx, convId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 3)
+	require.NotZero(t, resp.Messages[0].RevokedAt)
+	require.Empty(t, resp.Messages[0].Content.Text)
+
+	_, err = alice.RevokeMessage(ctx, convId, sent[0].Seq)
+	requireCode(t, err, CodeMessageRevoked)
+	_, err = alice.RevokeMessage(ctx, convId, notice.Seq)
+	requireCode(t, err, CodeInvalidParam)
+
+	// Past the revoke window
+	server.convs[convId].messages[sent[1].Seq-1].SendAt -= time.Hour.Milliseconds()
+	_, err = alice.RevokeMessage(ctx, convId, sent[1].Seq)
+	requireCode(t, err, CodeRevokeExpired)
+}
+
+func TestFakeServerDeleteMessage(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	var sent []*MessageInfo
+	for _, text := range []string{"mine", "oops", "old"} {
+		msg, err := alice.SendTextMessage(ctx, text, "bob", text)
+		require.NoError(t, err)
+		sent = append(sent, msg)
+	}
+	convId := sent[0].ConversationId
+
+	requireCode(t, bob.DeleteMessage(ctx, convId, sent[0].Seq, "others"), CodeInvalidParam)
+	// Deleting for oneself leaves the message to the others
+	require.NoError(t, bob.DeleteMessage(ctx, convId, sent[0].Seq, DeleteScopeSelf))
+	requireCode(t, bob.DeleteMessage(ctx, convId, sent[0].Seq, DeleteScopeSelf), CodeMessageNotFound)
+	resp, err := bob.PullMessages(ctx, convId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 2)
+	require.Equal(t, sent[1].Seq, resp.Messages[0].Seq)
+	resp, err = alice.PullMessages(ctx, convId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 3)
+
+	// Only the sender deletes a single chat message for everyone
+	requireCode(t, bob.DeleteMessage(ctx, convId, sent[1].Seq, DeleteScopeAll), CodeNoPermission)
+	require.NoError(t, alice.DeleteMessage(ctx, convId, sent[1].Seq, DeleteScopeAll))
+	resp, err = bob.PullMessages(ctx, convId, sent[1].Seq, sent[1].Seq, 1)
+	require.NoError(t, err)
+	require.NotZero(t, resp.Messages[0].DeletedAt)
+	require.Empty(t, resp.Messages[0].Content.Text)
+	requireCode(t, alice.DeleteMessage(ctx, convId, sent[1].Seq, DeleteScopeAll), CodeInvalidParam)
+
+	// Past the revoke window
+	server.convs[convId].messages[sent[2].Seq-1].SendAt -= time.Hour.Milliseconds()
+	requireCode(t, alice.DeleteMessage(ctx, convId, sent[2].Seq, DeleteScopeAll), CodeRevokeExpired)
+
+	// Group admins delete any message at any time
+	group, err := alice.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"bob"}})
+	require.NoError(t, err)
+	spam, err := bob.SendGroupTextMessage(ctx, "g1", group.Id, "spam")
+	require.NoError(t, err)
+	server.convs[spam.ConversationId].messages[spam.Seq-1].SendAt -= time.Hour.Milliseconds()
+	require.NoError(t, alice.DeleteMessage(ctx, spam.ConversationId, spam.Seq, DeleteScopeAll))
+}
+
+func TestFakeServerEditMessage(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	sent, err := alice.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "c1",
+		RecvId:      "bob",
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: "helo"},
+	})
+	require.NoError(t, err)
+	convId := sent.ConversationId
+	edit := func(c *FakeClient, content MessageContent) (*MessageInfo, error) {
+		return c.EditMessage(ctx, &EditMessageRequest{ConversationId: convId, Seq: sent.Seq, Content: content})
+	}
+
+	_, err = edit(bob, MessageContent{Text: "hi"})
+	requireCode(t, err, CodeNoPermission)
+	_, err = edit(alice, MessageContent{Image: "https://example.com/a.png"})
+	requireCode(t, err, CodeInvalidParam)
+
+	for i, text := range []string{"hello", "hello bob"} {
+		msg, err := edit(alice, MessageContent{Text: text})
+		require.NoError(t, err)
+		require.EqualValues(t, i+1, msg.EditVersion)
+		require.Equal(t, sent.Seq, msg.Seq)
+	}
+	resp, err := bob.PullMessages(ctx, convId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 1)
+	require.Equal(t, "hello bob", resp.Messages[0].Content.Text)
+	require.EqualValues(t, 2, resp.Messages[0].EditVersion)
+
+	history, err := bob.GetEditHistory(ctx, convId, sent.Seq)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.EqualValues(t, 0, history[0].Version)
+	require.Equal(t, "helo", history[0].Content.Text)
+	require.Equal(t, "hello", history[1].Content.Text)
+
+	_, err = alice.RevokeMessage(ctx, convId, sent.Seq)
+	require.NoError(t, err)
+	_, err = edit(alice, MessageContent{Text: "again"})
+	requireCode(t, err, CodeMessageRevoked)
+	history, err = bob.GetEditHistory(ctx, convId, sent.Seq)
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
+
+func TestFakeServerReactions(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	sent, err := alice.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "c1",
+		RecvId:      "bob",
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: "hi"},
+	})
+	require.NoError(t, err)
+	convId := sent.ConversationId
+
+	_, err = alice.AddReaction(ctx, convId, sent.Seq, "")
+	requireCode(t, err, CodeInvalidParam)
+	_, err = alice.AddReaction(ctx, convId, 2, "👍")
+	requireCode(t, err, CodeMessageNotFound)
+
+	change, err := alice.AddReaction(ctx, convId, sent.Seq, "👍")
+	require.NoError(t, err)
+	require.True(t, change.Added)
+	require.EqualValues(t, 1, change.Count)
+	_, err = bob.AddReaction(ctx, convId, sent.Seq, "🎉")
+	require.NoError(t, err)
+	change, err = bob.AddReaction(ctx, convId, sent.Seq, "👍")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, change.Count)
+	// Reacting twice is a no-op
+	change, err = bob.AddReaction(ctx, convId, sent.Seq, "👍")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, change.Count)
+
+	resp, err := alice.PullMessages(ctx, convId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, []*ReactionSummary{
+		{Emoji: "👍", Count: 2, Reacted: true},
+		{Emoji: "🎉", Count: 1},
+	}, resp.Messages[0].Reactions)
+
+	change, err = bob.RemoveReaction(ctx, convId, sent.Seq, "👍")
+	require.NoError(t, err)
+	require.False(t, change.Added)
+	require.EqualValues(t, 1, change.Count)
+
+	_, err = alice.RevokeMessage(ctx, convId, sent.Seq)
+	require.NoError(t, err)
+	_, err = bob.AddReaction(ctx, convId, sent.Seq, "👍")
+	requireCode(t, err, CodeMessageRevoked)
+	resp, err = bob.PullMessages(ctx, convId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, resp.Messages[0].Reactions)
+}
+
+func TestFakeServerQuotes(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	send := func(c *FakeClient, clientMsgId, text string, quote *QuotedMessage) (*MessageInfo, error) {
+		recvId := "bob"
+		if c == bob {
+			recvId = "alice"
+		}
+		return c.SendMessage(ctx, &SendMessageRequest{
+			ClientMsgId: clientMsgId,
+			RecvId:      recvId,
+			SessionType: SessionTypeSingle,
+			MsgType:     MsgTypeText,
+			Content:     MessageContent{Text: text},
+			QuotedMsg:   quote,
+		})
+	}
+	parent, err := send(alice, "c1", "lunch at noon?", nil)
+	require.NoError(t, err)
+	convId := parent.ConversationId
+
+	_, err = send(bob, "c2", "sure", &QuotedMessage{Seq: parent.Seq, Snippet: "dinner"})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = send(bob, "c2", "sure", &QuotedMessage{Seq: 9})
+	requireCode(t, err, CodeMessageNotFound)
+
+	reply, err := send(bob, "c2", "sure", &QuotedMessage{Seq: parent.Seq, Snippet: "noon"})
+	require.NoError(t, err)
+	require.Equal(t, &QuotedMessage{ConversationId: convId, Seq: parent.Seq, SenderId: "alice", MsgType: MsgTypeText, Snippet: "noon"}, reply.QuotedMsg)
+	_, err = send(alice, "c3", "unrelated", nil)
+	require.NoError(t, err)
+	whole, err := send(alice, "c4", "see above", &QuotedMessage{Seq: parent.Seq})
+	require.NoError(t, err)
+	require.Equal(t, "lunch at noon?", whole.QuotedMsg.Snippet)
+
+	page, err := alice.ListThread(ctx, convId, parent.Seq, 0, 1)
+	require.NoError(t, err)
+	require.True(t, page.HasMore)
+	require.Len(t, page.List, 1)
+	require.Equal(t, reply.Seq, page.List[0].Seq)
+	page, err = alice.ListThread(ctx, convId, parent.Seq, page.NextCursor, 1)
+	require.NoError(t, err)
+	require.False(t, page.HasMore)
+	require.Len(t, page.List, 1)
+	require.Equal(t, whole.Seq, page.List[0].Seq)
+
+	// Revoking the quoted message hides its details from the replies
+	_, err = alice.RevokeMessage(ctx, convId, parent.Seq)
+	require.NoError(t, err)
+	resp, err := bob.PullMessages(ctx, convId, reply.Seq, reply.Seq, 1)
+	require.NoError(t, err)
+	require.Equal(t, &QuotedMessage{ConversationId: convId, Seq: parent.Seq}, resp.Messages[0].QuotedMsg)
+	_, err = send(bob, "c5", "what?", &QuotedMessage{Seq: parent.Seq})
+	requireCode(t, err, CodeMessageRevoked)
+}
+
+func TestFakeServerForward(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	clients := map[string]*FakeClient{}
+	for _, id := range []string{"alice", "bob", "carol"} {
+		c := server.NewClient()
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+		clients[id] = c
+	}
+	alice, bob := clients["alice"], clients["bob"]
+	first, err := bob.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "c1",
+		RecvId:      "alice",
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: "hi @alice", Mentions: []string{"alice"}},
+	})
+	require.NoError(t, err)
+	_, err = alice.SendTextMessage(ctx, "c2", "bob", "hello")
+	require.NoError(t, err)
+	convId := first.ConversationId
+
+	_, err = alice.ForwardMessages(ctx, &ForwardMessagesRequest{ClientMsgId: "fw", ConversationId: convId, Seqs: []int64{1, 1}, Targets: []*ForwardTarget{{RecvId: "carol"}}})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = alice.ForwardMessages(ctx, &ForwardMessagesRequest{ClientMsgId: "fw", ConversationId: convId, Seqs: []int64{3}, Targets: []*ForwardTarget{{RecvId: "carol"}}})
+	requireCode(t, err, CodeMessageNotFound)
+
+	req := &ForwardMessagesRequest{
+		ClientMsgId:    "fw1",
+		ConversationId: convId,
+		Seqs:           []int64{2, 1},
+		Targets:        []*ForwardTarget{{RecvId: "carol"}, {RecvId: "nobody"}},
+	}
+	results, err := alice.ForwardMessages(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.NoError(t, results[0].Err())
+	require.Len(t, results[0].Messages, 2)
+	copied := results[0].Messages[0]
+	require.True(t, copied.Forwarded)
+	require.Equal(t, "alice", copied.SenderId)
+	require.Equal(t, MessageContent{Text: "hi @alice"}, copied.Content)
+	require.Equal(t, "fw1_0_1", copied.ClientMsgId)
+	requireCode(t, results[1].Err(), CodeUserNotFound)
+	require.Empty(t, results[1].Messages)
+
+	// Retrying returns the copies already sent
+	retried, err := alice.ForwardMessages(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, copied.Seq, retried[0].Messages[0].Seq)
+
+	results, err = alice.ForwardMessages(ctx, &ForwardMessagesRequest{
+		ClientMsgId:    "fw2",
+		ConversationId: convId,
+		Seqs:           []int64{1, 2},
+		Targets:        []*ForwardTarget{{RecvId: "carol"}},
+		Merged:         true,
+		Title:          "chat with bob",
+	})
+	require.NoError(t, err)
+	require.Len(t, results[0].Messages, 1)
+	merged := results[0].Messages[0]
+	require.Equal(t, int32(MsgTypeMerged), merged.MsgType)
+	require.Equal(t, "chat with bob", merged.Content.Merged.Title)
+	require.Len(t, merged.Content.Merged.Messages, 2)
+	require.Equal(t, "bob", merged.Content.Merged.Messages[0].SenderId)
+	require.Equal(t, "hello", merged.Content.Merged.Messages[1].Content.Text)
+
+	resp, err := clients["carol"].PullMessages(ctx, merged.ConversationId, 1, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 3)
+	require.True(t, resp.Messages[2].Forwarded)
+
+	_, err = bob.RevokeMessage(ctx, convId, first.Seq)
+	require.NoError(t, err)
+	_, err = alice.ForwardMessages(ctx, &ForwardMessagesRequest{ClientMsgId: "fw3", ConversationId: convId, Seqs: []int64{first.Seq}, Targets: []*ForwardTarget{{RecvId: "carol"}}})
+	requireCode(t, err, CodeMessageRevoked)
+}
+
+func TestFakeServerReadStatus(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	first, err := alice.SendTextMessage(ctx, "c1", "bob", "hi")
+	require.NoError(t, err)
+	second, err := alice.SendTextMessage(ctx, "c2", "bob", "are you there?")
+	require.NoError(t, err)
+	convId := first.ConversationId
+
+	status, err := alice.GetReadStatus(ctx, convId, second.Seq)
+	require.NoError(t, err)
+	require.Equal(t, &ReadStatus{ConversationId: convId, Seq: second.Seq}, status)
+
+	// Pulling delivers the messages
+	_, err = bob.PullMessages(ctx, convId, 0, first.Seq, 0)
+	require.NoError(t, err)
+	status, err = alice.GetReadStatus(ctx, convId, first.Seq)
+	require.NoError(t, err)
+	require.True(t, status.Delivered)
+	require.False(t, status.Read)
+	status, err = alice.GetReadStatus(ctx, convId, second.Seq)
+	require.NoError(t, err)
+	require.False(t, status.Delivered)
+
+	require.NoError(t, bob.MarkRead(ctx, convId, first.Seq))
+	status, err = alice.GetReadStatus(ctx, convId, first.Seq)
+	require.NoError(t, err)
+	require.True(t, status.Read)
+	status, err = alice.GetReadStatus(ctx, convId, second.Seq)
+	require.NoError(t, err)
+	require.False(t, status.Read)
+	require.Equal(t, first.Seq, status.ReadSeq)
+
+	// Read seqs only advance
+	require.NoError(t, bob.MarkRead(ctx, convId, second.Seq))
+	require.NoError(t, bob.MarkRead(ctx, convId, first.Seq))
+	status, err = alice.GetReadStatus(ctx, convId, second.Seq)
+	require.NoError(t, err)
+	require.True(t, status.Read)
+
+	_, err = alice.GetReadStatus(ctx, convId, 9)
+	requireCode(t, err, CodeMessageNotFound)
+	group, err := alice.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"bob"}})
+	require.NoError(t, err)
+	msg, err := alice.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "g1",
+		GroupId:     group.Id,
+		SessionType: SessionTypeGroup,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: "hello"},
+	})
+	require.NoError(t, err)
+	_, err = alice.GetReadStatus(ctx, msg.ConversationId, msg.Seq)
+	requireCode(t, err, CodeInvalidParam)
+	_, err = alice.GetGroupReadMembers(ctx, convId, first.Seq)
+	requireCode(t, err, CodeInvalidParam)
+
+	members, err := bob.GetGroupReadMembers(ctx, msg.ConversationId, msg.Seq)
+	require.NoError(t, err)
+	require.Zero(t, members.ReadCount)
+	require.Empty(t, members.Readers)
+	require.NoError(t, alice.MarkRead(ctx, msg.ConversationId, msg.Seq))
+	require.NoError(t, bob.MarkRead(ctx, msg.ConversationId, msg.Seq))
+	members, err = bob.GetGroupReadMembers(ctx, msg.ConversationId, msg.Seq)
+	require.NoError(t, err)
+	require.Equal(t, &GroupReadMembers{
+		ConversationId: msg.ConversationId,
+		Seq:            msg.Seq,
+		ReadCount:      1,
+		Readers:        []*GroupReader{{UserId: "bob", ReadSeq: msg.Seq}},
+	}, members)
+}
+
+func TestFakeServerDisappearingMessages(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	before, err := alice.SendTextMessage(ctx, "c1", "bob", "hi")
+	require.NoError(t, err)
+	convId := before.ConversationId
+
+	requireCode(t, bob.SetConversationTTL(ctx, convId, 1), CodeInvalidParam)
+	// The timer is shared, either participant sets it for both
+	require.NoError(t, bob.SetConversationTTL(ctx, convId, 60))
+	info, err := alice.GetConversation(ctx, convId)
+	require.NoError(t, err)
+	require.Equal(t, int32(60), info.TTLSeconds)
+
+	during, err := alice.SendTextMessage(ctx, "c2", "bob", "this disappears")
+	require.NoError(t, err)
+	require.Zero(t, before.ExpiresAt)
+	require.Equal(t, during.SendAt+60000, during.ExpiresAt)
+
+	favorite, err := bob.AddFavorite(ctx, convId, during.Seq)
+	require.NoError(t, err)
+
+	// Expire it, pulls skip it
+	server.convs[convId].messages[during.Seq-1].ExpiresAt = 1
+	pulled, err := bob.PullMessages(ctx, convId, 0, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, pulled.Messages, 1)
+	require.Equal(t, before.Seq, pulled.Messages[0].Seq)
+	require.Equal(t, during.Seq, pulled.MaxSeq)
+
+	// The favorite keeps its snapshot, which does not disappear
+	page, err := bob.ListFavorites(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.List, 1)
+	require.Equal(t, favorite.Id, page.List[0].Id)
+	require.Equal(t, "this disappears", page.List[0].Message.Content.Text)
+	require.Zero(t, page.List[0].Message.ExpiresAt)
+	_, err = alice.AddFavorite(ctx, convId, during.Seq)
+	requireCode(t, err, CodeMessageNotFound)
+
+	require.NoError(t, alice.SetConversationTTL(ctx, convId, 0))
+	after, err := alice.SendTextMessage(ctx, "c3", "bob", "this stays")
+	require.NoError(t, err)
+	require.Zero(t, after.ExpiresAt)
+
+	// Only group admins set the timer of a group
+	group, err := alice.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"bob"}})
+	require.NoError(t, err)
+	_, err = alice.SendGroupTextMessage(ctx, "g1", group.Id, "hello")
+	require.NoError(t, err)
+	requireCode(t, bob.SetConversationTTL(ctx, GroupConversationId(group.Id), 60), CodeNotGroupAdmin)
+	require.NoError(t, alice.SetConversationTTL(ctx, GroupConversationId(group.Id), 60))
+}
+
+func TestFakeServerPinnedMessages(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	first, err := alice.SendTextMessage(ctx, "c1", "bob", "first")
+	require.NoError(t, err)
+	second, err := alice.SendTextMessage(ctx, "c2", "bob", "second")
+	require.NoError(t, err)
+	convId := first.ConversationId
+
+	_, err = bob.PinMessage(ctx, convId, 9)
+	requireCode(t, err, CodeMessageNotFound)
+	// Pins are shared, either participant of a single chat pins for both
+	change, err := bob.PinMessage(ctx, convId, first.Seq)
+	require.NoError(t, err)
+	require.True(t, change.Pinned)
+	_, err = alice.PinMessage(ctx, convId, second.Seq)
+	require.NoError(t, err)
+	_, err = alice.PinMessage(ctx, convId, second.Seq)
+	require.NoError(t, err)
+
+	pins, err := alice.GetPinnedMessages(ctx, convId)
+	require.NoError(t, err)
+	require.Len(t, pins, 2)
+	require.Equal(t, second.Seq, pins[0].Seq)
+	require.Equal(t, "bob", pins[1].PinnedBy)
+	require.Equal(t, "first", pins[1].Message.Content.Text)
+	info, err := bob.GetConversation(ctx, convId)
+	require.NoError(t, err)
+	require.Equal(t, []int64{second.Seq, first.Seq}, info.PinnedSeqs)
+
+	// Revoking a message unpins it
+	_, err = alice.RevokeMessage(ctx, convId, second.Seq)
+	require.NoError(t, err)
+	_, err = alice.PinMessage(ctx, convId, second.Seq)
+	requireCode(t, err, CodeMessageRevoked)
+	change, err = bob.UnpinMessage(ctx, convId, first.Seq)
+	require.NoError(t, err)
+	require.False(t, change.Pinned)
+	pins, err = bob.GetPinnedMessages(ctx, convId)
+	require.NoError(t, err)
+	require.Empty(t, pins)
+
+	// Only group admins pin in a group
+	group, err := alice.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"bob"}})
+	require.NoError(t, err)
+	hello, err := alice.SendGroupTextMessage(ctx, "g1", group.Id, "hello")
+	require.NoError(t, err)
+	_, err = bob.PinMessage(ctx, hello.ConversationId, hello.Seq)
+	requireCode(t, err, CodeNotGroupAdmin)
+	_, err = alice.PinMessage(ctx, hello.ConversationId, hello.Seq)
+	require.NoError(t, err)
+	pins, err = bob.GetPinnedMessages(ctx, hello.ConversationId)
+	require.NoError(t, err)
+	require.Len(t, pins, 1)
+}
+
+func TestFakeServerPolls(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	alice, bob := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"alice": alice, "bob": bob} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	text, err := alice.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "text",
+		RecvId:      "bob",
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypeText,
+		Content:     MessageContent{Text: "lunch?"},
+	})
+	require.NoError(t, err)
+	poll, err := alice.SendMessage(ctx, &SendMessageRequest{
+		ClientMsgId: "poll",
+		RecvId:      "bob",
+		SessionType: SessionTypeSingle,
+		MsgType:     MsgTypePoll,
+		Content:     MessageContent{Poll: &PollContent{Question: "Where?", Options: []string{"Noodles", "Pizza", "Salad"}}},
+	})
+	require.NoError(t, err)
+	convId := poll.ConversationId
+
+	_, err = bob.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: text.Seq, Options: []int{0}})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = bob.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: poll.Seq, Options: []int{0, 1}})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = bob.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: poll.Seq, Options: []int{3}})
+	requireCode(t, err, CodeInvalidParam)
+	_, err = bob.GetPollResult(ctx, "si_x_y", 1)
+	requireCode(t, err, CodeNoPermission)
+
+	result, err := bob.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: poll.Seq, Options: []int{1}})
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, result.MyOptions)
+	_, err = alice.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: poll.Seq, Options: []int{1}})
+	require.NoError(t, err)
+
+	// A new vote replaces the previous one
+	_, err = bob.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: poll.Seq, Options: []int{2}})
+	require.NoError(t, err)
+	result, err = alice.GetPollResult(ctx, convId, poll.Seq)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, result.VoterCount)
+	require.Equal(t, []int{1}, result.MyOptions)
+	require.EqualValues(t, 0, result.Options[0].Count)
+	require.Equal(t, []string{"alice"}, result.Options[1].VoterIds)
+	require.Equal(t, []string{"bob"}, result.Options[2].VoterIds)
+
+	// An empty vote retracts it
+	result, err = bob.VotePoll(ctx, &VotePollRequest{ConversationId: convId, Seq: poll.Seq})
+	require.NoError(t, err)
+	require.Empty(t, result.MyOptions)
+	require.EqualValues(t, 1, result.VoterCount)
+}
+
+func TestFakeServerSavedConversation(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	client := server.NewClient()
+	_, err := client.Register(ctx, &RegisterRequest{UserId: "u1", Password: "secret"})
+	require.NoError(t, err)
+	_, err = client.LoginWithUserId(ctx, "u1", "secret", PlatformIdWeb)
+	require.NoError(t, err)
+
+	saved, err := client.GetSavedConversation(ctx)
+	require.NoError(t, err)
+	require.Equal(t, SavedConversationId("u1"), saved.ConversationId)
+	require.True(t, saved.IsSaved)
+	require.Zero(t, saved.MaxSeq)
+
+	results, err := client.SendMessagesBatch(ctx, &BatchSendMessageRequest{
+		WithoutMarkRead: true,
+		Messages: []*SendMessageRequest{{
+			ClientMsgId: "n1",
+			RecvId:      "u1",
+			SessionType: SessionTypeSingle,
+			MsgType:     MsgTypeText,
+			Content:     MessageContent{Text: "note to self"},
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, saved.ConversationId, results[0].Message.ConversationId)
+
+	saved, err = client.GetSavedConversation(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, saved.MaxSeq)
+	require.Zero(t, saved.UnreadCount)
+}
+
+func TestFakeServerSearchUsers(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	clients := make(map[string]*FakeClient)
+	for id, nickname := range map[string]string{"u1": "Annabel", "u2": "Ann", "u3": "Bob", "u4": "Anne"} {
+		c := server.NewClient()
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Nickname: nickname, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+		clients[id] = c
+	}
+	handle, hidden := "AnnieB", true
+	_, err := clients["u3"].UpdateUserInfo(ctx, &UpdateUserRequest{Handle: &handle})
+	require.NoError(t, err)
+	_, err = clients["u1"].UpdateUserInfo(ctx, &UpdateUserRequest{Handle: &handle})
+	requireCode(t, err, CodeHandleTaken)
+	_, err = clients["u4"].UpdateUserInfo(ctx, &UpdateUserRequest{HideFromSearch: &hidden})
+	require.NoError(t, err)
+
+	found, err := clients["u1"].SearchUsers(ctx, "Ann", 0)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(found))
+	for _, info := range found {
+		ids = append(ids, info.Id)
+	}
+	require.Equal(t, []string{"u2", "u3", "u1"}, ids)
+
+	found, err = clients["u1"].SearchUsers(ctx, "@annieb", 0)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "annieb", found[0].Handle)
+	_, err = clients["u1"].SearchUsers(ctx, "@", 0)
+	requireCode(t, err, CodeInvalidParam)
+}
+
+func TestFakeServerDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	owner, member := server.NewClient(), server.NewClient()
+	for id, c := range map[string]*FakeClient{"owner": owner, "member": member} {
+		_, err := c.Register(ctx, &RegisterRequest{UserId: id, Password: "secret"})
+		require.NoError(t, err)
+		_, err = c.LoginWithUserId(ctx, id, "secret", PlatformIdWeb)
+		require.NoError(t, err)
+	}
+	group, err := owner.CreateGroup(ctx, &CreateGroupRequest{Name: "team", MemberIds: []string{"member"}})
+	require.NoError(t, err)
+
+	requireCode(t, member.DeleteAccount(ctx, &DeleteAccountRequest{Password: "secret", Confirm: "owner"}), CodeInvalidParam)
+	requireCode(t, member.DeleteAccount(ctx, &DeleteAccountRequest{Password: "wrong", Confirm: "member"}), CodePasswordWrong)
+	require.NoError(t, member.DeleteAccount(ctx, &DeleteAccountRequest{Password: "secret", Confirm: "member"}))
+
+	// The deleted account is logged out and has left its groups
+	_, err = member.GetUserInfo(ctx)
+	require.Error(t, err)
+	members, err := owner.GetGroupMembers(ctx, group.Id)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	_, err = owner.GetUserInfoById(ctx, "member")
+	requireCode(t, err, CodeUserNotFound)
+}
+
+func TestFakeServerNotificationSettings(t *testing.T) {
+	ctx := context.Background()
+	server := NewFakeServer()
+	client := server.NewClient()
+	_, err := client.Register(ctx, &RegisterRequest{UserId: "u1", Password: "secret"})
+	require.NoError(t, err)
+	_, err = client.LoginWithUserId(ctx, "u1", "secret", PlatformIdWeb)
+	require.NoError(t, err)
+
+	settings, err := client.GetNotificationSettings(ctx)
+	require.NoError(t, err)
+	require.True(t, settings.Sound && settings.Vibrate && settings.ShowPreview)
+	require.False(t, settings.GlobalMute)
+
+	off, on := false, true
+	_, err = client.UpdateNotificationSettings(ctx, &UpdateNotificationSettingsRequest{
+		PlatformOverrides: map[int]*NotificationOverride{99: {GlobalMute: &on}},
+	})
+	requireCode(t, err, CodeInvalidParam)
+
+	_, err = client.UpdateNotificationSettings(ctx, &UpdateNotificationSettingsRequest{
+		Sound:             &off,
+		PlatformOverrides: map[int]*NotificationOverride{PlatformIdMacOS: {GlobalMute: &on}},
+	})
+	require.NoError(t, err)
+	settings, err = client.GetNotificationSettings(ctx)
+	require.NoError(t, err)
+	require.False(t, settings.Sound)
+	require.True(t, settings.Vibrate)
+	require.True(t, *settings.PlatformOverrides[PlatformIdMacOS].GlobalMute)
+
+	// A nil override removes the platform's override, other fields are kept
+	settings, err = client.UpdateNotificationSettings(ctx, &UpdateNotificationSettingsRequest{
+		PlatformOverrides: map[int]*NotificationOverride{PlatformIdMacOS: nil},
+	})
+	require.NoError(t, err)
+	require.Empty(t, settings.PlatformOverrides)
+	require.False(t, settings.Sound)
+}
+
+func TestMockClient(t *testing.T) {
+	var api ClientAPI = &MockClient{
+		GetMaxSeqFunc: func(_ context.Context, conversationId string) (int64, error) {
+			require.Equal(t, "sg_g1", conversationId)
+			return 7, nil
+		},
+	}
+	seq, err := api.GetMaxSeq(context.Background(), "sg_g1")
+	require.NoError(t, err)
+	require.EqualValues(t, 7, seq)
+	require.Equal(t, 1, api.(*MockClient).Calls("GetMaxSeq"))
+
+	require.PanicsWithValue(t, "MockClient.MarkRead called without MarkReadFunc", func() {
+		_ = api.MarkRead(context.Background(), "sg_g1", 1)
+	})
+}
+
+func requireCode(t *testing.T, err error, code int) {
+	t.Helper()
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr), "expected API error, got %v", err)
+	require.Equal(t, code, apiErr.Code)
+}
@@ -1,47 +1,281 @@
 package sdk
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+const (
+	groupPath         = "/im/group"
+	internalGroupPath = "/im/internal/group"
+)
 
 // CreateGroup creates a new group
 func (c *Client) CreateGroup(ctx context.Context, req *CreateGroupRequest) (*GroupInfo, error) {
+	return c.createGroup(ctx, groupPath, req)
+}
+
+// JoinGroup joins a group
+func (c *Client) JoinGroup(ctx context.Context, groupId string, inviterId string) error {
+	return c.joinGroup(ctx, groupPath, groupId, inviterId)
+}
+
+// QuitGroup quits a group
+func (c *Client) QuitGroup(ctx context.Context, groupId string) error {
+	return c.quitGroup(ctx, groupPath, groupId)
+}
+
+// GetGroupInfo gets group info
+func (c *Client) GetGroupInfo(ctx context.Context, groupId string) (*GroupInfo, error) {
+	return c.getGroupInfo(ctx, groupPath, groupId)
+}
+
+// GetGroupMembers gets group members
+func (c *Client) GetGroupMembers(ctx context.Context, groupId string) ([]*GroupMember, error) {
+	return c.getGroupMembers(ctx, groupPath, groupId)
+}
+
+// KickGroupMembers removes members from a group. Admins may kick members, only the
+// owner may kick admins.
+func (c *Client) KickGroupMembers(ctx context.Context, groupId string, userIds []string) error {
+	return c.kickGroupMembers(ctx, groupPath, groupId, userIds)
+}
+
+// MuteGroupMember mutes a member for duration (rounded down to seconds), 0 lifts the mute
+func (c *Client) MuteGroupMember(ctx context.Context, groupId, userId string, duration time.Duration) error {
+	return c.muteGroupMember(ctx, groupPath, groupId, userId, duration)
+}
+
+// TransferGroupOwnership hands the group over to another member; only the owner may call it
+func (c *Client) TransferGroupOwnership(ctx context.Context, groupId, newOwnerId string) error {
+	return c.transferGroupOwnership(ctx, groupPath, groupId, newOwnerId)
+}
+
+// PromoteGroupAdmin makes a member an admin; only the owner may call it
+func (c *Client) PromoteGroupAdmin(ctx context.Context, groupId, userId string) error {
+	return c.setGroupAdmin(ctx, groupPath+"/admin/promote", groupId, userId)
+}
+
+// DemoteGroupAdmin makes an admin a normal member; only the owner may call it
+func (c *Client) DemoteGroupAdmin(ctx context.Context, groupId, userId string) error {
+	return c.setGroupAdmin(ctx, groupPath+"/admin/demote", groupId, userId)
+}
+
+// DismissGroup dismisses the group; only the owner may call it
+func (c *Client) DismissGroup(ctx context.Context, groupId string) error {
+	return c.dismissGroup(ctx, groupPath, groupId)
+}
+
+// UpdateGroupInfo updates group settings and returns the updated group info
+func (c *Client) UpdateGroupInfo(ctx context.Context, req *UpdateGroupInfoRequest) (*GroupInfo, error) {
+	return c.updateGroupInfo(ctx, groupPath, req)
+}
+
+// SetGroupAnnouncement replaces the group announcement, empty content clears it
+func (c *Client) SetGroupAnnouncement(ctx context.Context, groupId, content string) (*GroupInfo, error) {
+	return c.setGroupAnnouncement(ctx, groupPath, groupId, content)
+}
+
+// CreateGroupInviteLink creates an invite link for the group
+func (c *Client) CreateGroupInviteLink(ctx context.Context, req *CreateInviteLinkRequest) (*GroupInviteLink, error) {
+	return c.createGroupInviteLink(ctx, groupPath, req)
+}
+
+// RevokeGroupInviteLink invalidates an invite link of the group
+func (c *Client) RevokeGroupInviteLink(ctx context.Context, groupId, token string) error {
+	return c.revokeGroupInviteLink(ctx, groupPath, groupId, token)
+}
+
+// JoinGroupByInviteLink joins the group of the invite link and returns its group id
+func (c *Client) JoinGroupByInviteLink(ctx context.Context, token string) (string, error) {
+	return c.joinGroupByInviteLink(ctx, groupPath, token)
+}
+
+// InternalCreateGroup creates a new group via internal route.
+func (c *Client) InternalCreateGroup(ctx context.Context, req *CreateGroupRequest, opts ...RequestOption) (*GroupInfo, error) {
+	return c.createGroup(ctx, internalGroupPath, req, opts...)
+}
+
+// InternalJoinGroup joins a group via internal route.
+func (c *Client) InternalJoinGroup(ctx context.Context, groupId string, inviterId string, opts ...RequestOption) error {
+	return c.joinGroup(ctx, internalGroupPath, groupId, inviterId, opts...)
+}
+
+// InternalQuitGroup quits a group via internal route.
+func (c *Client) InternalQuitGroup(ctx context.Context, groupId string, opts ...RequestOption) error {
+	return c.quitGroup(ctx, internalGroupPath, groupId, opts...)
+}
+
+// InternalGetGroupInfo gets group info via internal route.
+func (c *Client) InternalGetGroupInfo(ctx context.Context, groupId string, opts ...RequestOption) (*GroupInfo, error) {
+	return c.getGroupInfo(ctx, internalGroupPath, groupId, opts...)
+}
+
+// InternalGetGroupMembers gets group members via internal route.
+func (c *Client) InternalGetGroupMembers(ctx context.Context, groupId string, opts ...RequestOption) ([]*GroupMember, error) {
+	return c.getGroupMembers(ctx, internalGroupPath, groupId, opts...)
+}
+
+// InternalKickGroupMembers removes members from a group via internal route.
+func (c *Client) InternalKickGroupMembers(ctx context.Context, groupId string, userIds []string, opts ...RequestOption) error {
+	return c.kickGroupMembers(ctx, internalGroupPath, groupId, userIds, opts...)
+}
+
+// InternalMuteGroupMember mutes a member via internal route.
+func (c *Client) InternalMuteGroupMember(ctx context.Context, groupId, userId string, duration time.Duration, opts ...RequestOption) error {
+	return c.muteGroupMember(ctx, internalGroupPath, groupId, userId, duration, opts...)
+}
+
+// InternalTransferGroupOwnership transfers group ownership via internal route.
+func (c *Client) InternalTransferGroupOwnership(ctx context.Context, groupId, newOwnerId string, opts ...RequestOption) error {
+	return c.transferGroupOwnership(ctx, internalGroupPath, groupId, newOwnerId, opts...)
+}
+
+// InternalPromoteGroupAdmin makes a member an admin via internal route.
+func (c *Client) InternalPromoteGroupAdmin(ctx context.Context, groupId, userId string, opts ...RequestOption) error {
+	return c.setGroupAdmin(ctx, internalGroupPath+"/admin/promote", groupId, userId, opts...)
+}
+
+// InternalDemoteGroupAdmin makes an admin a normal member via internal route.
+func (c *Client) InternalDemoteGroupAdmin(ctx context.Context, groupId, userId string, opts ...RequestOption) error {
+	return c.setGroupAdmin(ctx, internalGroupPath+"/admin/demote", groupId, userId, opts...)
+}
+
+// InternalDismissGroup dismisses a group via internal route.
+func (c *Client) InternalDismissGroup(ctx context.Context, groupId string, opts ...RequestOption) error {
+	return c.dismissGroup(ctx, internalGroupPath, groupId, opts...)
+}
+
+// InternalUpdateGroupInfo updates group settings via internal route.
+func (c *Client) InternalUpdateGroupInfo(ctx context.Context, req *UpdateGroupInfoRequest, opts ...RequestOption) (*GroupInfo, error) {
+	return c.updateGroupInfo(ctx, internalGroupPath, req, opts...)
+}
+
+// InternalSetGroupAnnouncement replaces the group announcement via internal route.
+func (c *Client) InternalSetGroupAnnouncement(ctx context.Context, groupId, content string, opts ...RequestOption) (*GroupInfo, error) {
+	return c.setGroupAnnouncement(ctx, internalGroupPath, groupId, content, opts...)
+}
+
+// InternalCreateGroupInviteLink creates an invite link via internal route.
+func (c *Client) InternalCreateGroupInviteLink(ctx context.Context, req *CreateInviteLinkRequest, opts ...RequestOption) (*GroupInviteLink, error) {
+	return c.createGroupInviteLink(ctx, internalGroupPath, req, opts...)
+}
+
+// InternalRevokeGroupInviteLink invalidates an invite link via internal route.
+func (c *Client) InternalRevokeGroupInviteLink(ctx context.Context, groupId, token string, opts ...RequestOption) error {
+	return c.revokeGroupInviteLink(ctx, internalGroupPath, groupId, token, opts...)
+}
+
+// InternalJoinGroupByInviteLink joins a group by invite link via internal route.
+func (c *Client) InternalJoinGroupByInviteLink(ctx context.Context, token string, opts ...RequestOption) (string, error) {
+	return c.joinGroupByInviteLink(ctx, internalGroupPath, token, opts...)
+}
+
+func (c *Client) createGroup(ctx context.Context, base string, req *CreateGroupRequest, opts ...RequestOption) (*GroupInfo, error) {
 	var result GroupInfo
-	if err := c.post(ctx, "/im/group/create", req, &result); err != nil {
+	if err := c.post(ctx, base+"/create", req, &result, opts...); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
-// JoinGroup joins a group
-func (c *Client) JoinGroup(ctx context.Context, groupId string, inviterId string) error {
+func (c *Client) joinGroup(ctx context.Context, base, groupId, inviterId string, opts ...RequestOption) error {
 	req := &JoinGroupRequest{
 		GroupId:   groupId,
 		InviterId: inviterId,
 	}
-	return c.post(ctx, "/im/group/join", req, nil)
+	return c.post(ctx, base+"/join", req, nil, opts...)
 }
 
-// QuitGroup quits a group
-func (c *Client) QuitGroup(ctx context.Context, groupId string) error {
+func (c *Client) quitGroup(ctx context.Context, base, groupId string, opts ...RequestOption) error {
 	req := &QuitGroupRequest{GroupId: groupId}
-	return c.post(ctx, "/im/group/quit", req, nil)
+	return c.post(ctx, base+"/quit", req, nil, opts...)
 }
 
-// GetGroupInfo gets group info
-func (c *Client) GetGroupInfo(ctx context.Context, groupId string) (*GroupInfo, error) {
+func (c *Client) getGroupInfo(ctx context.Context, base, groupId string, opts ...RequestOption) (*GroupInfo, error) {
 	var result GroupInfo
 	params := map[string]string{"group_id": groupId}
-	if err := c.get(ctx, "/im/group/info", params, &result); err != nil {
+	if err := c.get(ctx, base+"/info", params, &result, opts...); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
-// GetGroupMembers gets group members
-func (c *Client) GetGroupMembers(ctx context.Context, groupId string) ([]*GroupMember, error) {
+func (c *Client) getGroupMembers(ctx context.Context, base, groupId string, opts ...RequestOption) ([]*GroupMember, error) {
 	var result []*GroupMember
 	params := map[string]string{"group_id": groupId}
-	if err := c.get(ctx, "/im/group/members", params, &result); err != nil {
+	if err := c.get(ctx, base+"/members", params, &result, opts...); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
+
+func (c *Client) kickGroupMembers(ctx context.Context, base, groupId string, userIds []string, opts ...RequestOption) error {
+	req := &KickGroupMembersRequest{GroupId: groupId, UserIds: userIds}
+	return c.post(ctx, base+"/kick", req, nil, opts...)
+}
+
+func (c *Client) muteGroupMember(ctx context.Context, base, groupId, userId string, duration time.Duration, opts ...RequestOption) error {
+	req := &MuteGroupMemberRequest{
+		GroupId:         groupId,
+		UserId:          userId,
+		DurationSeconds: int64(duration / time.Second),
+	}
+	return c.post(ctx, base+"/mute_member", req, nil, opts...)
+}
+
+func (c *Client) transferGroupOwnership(ctx context.Context, base, groupId, newOwnerId string, opts ...RequestOption) error {
+	req := &TransferGroupRequest{GroupId: groupId, NewOwnerId: newOwnerId}
+	return c.post(ctx, base+"/transfer", req, nil, opts...)
+}
+
+func (c *Client) setGroupAdmin(ctx context.Context, path, groupId, userId string, opts ...RequestOption) error {
+	req := &GroupAdminRequest{GroupId: groupId, UserId: userId}
+	return c.post(ctx, path, req, nil, opts...)
+}
+
+func (c *Client) dismissGroup(ctx context.Context, base, groupId string, opts ...RequestOption) error {
+	req := &DismissGroupRequest{GroupId: groupId}
+	return c.post(ctx, base+"/dismiss", req, nil, opts...)
+}
+
+func (c *Client) updateGroupInfo(ctx context.Context, base string, req *UpdateGroupInfoRequest, opts ...RequestOption) (*GroupInfo, error) {
+	var result GroupInfo
+	if err := c.post(ctx, base+"/update", req, &result, opts...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) setGroupAnnouncement(ctx context.Context, base, groupId, content string, opts ...RequestOption) (*GroupInfo, error) {
+	var result GroupInfo
+	req := &SetAnnouncementRequest{GroupId: groupId, Content: content}
+	if err := c.post(ctx, base+"/announcement", req, &result, opts...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) createGroupInviteLink(ctx context.Context, base string, req *CreateInviteLinkRequest, opts ...RequestOption) (*GroupInviteLink, error) {
+	var result GroupInviteLink
+	if err := c.post(ctx, base+"/invite_link/create", req, &result, opts...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) revokeGroupInviteLink(ctx context.Context, base, groupId, token string, opts ...RequestOption) error {
+	req := &RevokeInviteLinkRequest{GroupId: groupId, Token: token}
+	return c.post(ctx, base+"/invite_link/revoke", req, nil, opts...)
+}
+
+func (c *Client) joinGroupByInviteLink(ctx context.Context, base, token string, opts ...RequestOption) (string, error) {
+	var result struct {
+		GroupId string `json:"group_id"`
+	}
+	req := &JoinByInviteLinkRequest{Token: token}
+	if err := c.post(ctx, base+"/join_by_link", req, &result, opts...); err != nil {
+		return "", err
+	}
+	return result.GroupId, nil
+}
@@ -0,0 +1,48 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalGroupAdministrationRoutes(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		require.Equal(t, "admin", r.Header.Get("X-User-Id"))
+		switch r.URL.Path {
+		case "/im/internal/group/mute_member":
+			var req MuteGroupMemberRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, MuteGroupMemberRequest{GroupId: "g1", UserId: "u1", DurationSeconds: 90}, req)
+			_, _ = io.WriteString(w, `{"code":0}`)
+		case "/im/internal/group/join_by_link":
+			_, _ = io.WriteString(w, `{"code":0,"data":{"group_id":"g1"}}`)
+		default:
+			_, _ = io.WriteString(w, `{"code":3007,"message":"not group admin"}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := MustNewInternalClient(srv.URL, "svc", "s3cret")
+	actAs := WithActAsUser("admin", PlatformIdWeb)
+
+	require.NoError(t, c.InternalMuteGroupMember(ctx, "g1", "u1", 90*time.Second+500*time.Millisecond, actAs))
+	groupId, err := c.InternalJoinGroupByInviteLink(ctx, "token", actAs)
+	require.NoError(t, err)
+	require.Equal(t, "g1", groupId)
+	err = c.InternalKickGroupMembers(ctx, "g1", []string{"u1"}, actAs)
+	requireCode(t, err, CodeNotGroupAdmin)
+
+	require.Equal(t, []string{
+		"/im/internal/group/mute_member", "/im/internal/group/join_by_link", "/im/internal/group/kick",
+	}, paths)
+}
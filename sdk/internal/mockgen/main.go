@@ -0,0 +1,151 @@
+// Command mockgen writes a function-field mock of an interface declared in the sdk package.
+// Every interface method becomes a <Method>Func field that the mock method delegates to,
+// and calls are counted per method. It only uses the standard library so the SDK keeps
+// no mocking dependency; run it through go generate in the sdk directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	src := flag.String("src", "", "file declaring the interface")
+	iface := flag.String("iface", "", "interface name")
+	typeName := flag.String("type", "", "mock type name")
+	out := flag.String("out", "", "output file")
+	flag.Parse()
+	if *src == "" || *iface == "" || *typeName == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	code, err := generate(*src, *iface, *typeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type method struct {
+	name     string
+	params   []string // "name type"
+	args     []string // call arguments, the variadic one spread
+	results  string
+	hasValue bool
+}
+
+func generate(src, ifaceName, typeName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, src, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var iface *ast.InterfaceType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && spec.Name.Name == ifaceName {
+			iface, _ = spec.Type.(*ast.InterfaceType)
+		}
+		return iface == nil
+	})
+	if iface == nil {
+		return nil, fmt.Errorf("interface %s not found in %s", ifaceName, src)
+	}
+
+	typeString := func(expr ast.Expr) string {
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, fset, expr)
+		return buf.String()
+	}
+
+	var methods []method
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded interfaces are not supported", fset.Position(field.Pos()))
+		}
+		m := method{name: field.Names[0].Name}
+		for i, p := range fn.Params.List {
+			names := p.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+			}
+			_, variadic := p.Type.(*ast.Ellipsis)
+			for _, n := range names {
+				m.params = append(m.params, n.Name+" "+typeString(p.Type))
+				if variadic {
+					m.args = append(m.args, n.Name+"...")
+				} else {
+					m.args = append(m.args, n.Name)
+				}
+			}
+		}
+		if fn.Results != nil {
+			var results []string
+			for _, r := range fn.Results.List {
+				for range max(len(r.Names), 1) {
+					results = append(results, typeString(r.Type))
+				}
+			}
+			m.hasValue = len(results) > 0
+			m.results = strings.Join(results, ", ")
+			if len(results) > 1 {
+				m.results = "(" + m.results + ")"
+			}
+		}
+		methods = append(methods, m)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by go run ./internal/mockgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", file.Name.Name)
+	// The mock needs the source file's imports for the method signatures plus sync
+	imports := []string{`"sync"`}
+	for _, spec := range file.Imports {
+		if spec.Path.Value != `"sync"` {
+			imports = append(imports, spec.Path.Value)
+		}
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(&buf, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\t"))
+	fmt.Fprintf(&buf, "// %s is a %s whose methods call the matching Func field.\n", typeName, ifaceName)
+	fmt.Fprintf(&buf, "// Calling a method whose Func field is nil panics.\n")
+	fmt.Fprintf(&buf, "type %s struct {\n", typeName)
+	for _, m := range methods {
+		fmt.Fprintf(&buf, "\t%sFunc func(%s) %s\n", m.name, strings.Join(m.params, ", "), m.results)
+	}
+	fmt.Fprintf(&buf, "\n\tmu    sync.Mutex\n\tcalls map[string]int\n}\n\n")
+	for _, m := range methods {
+		fmt.Fprintf(&buf, "// %s calls %sFunc.\n", m.name, m.name)
+		fmt.Fprintf(&buf, "func (m *%s) %s(%s) %s {\n", typeName, m.name, strings.Join(m.params, ", "), m.results)
+		fmt.Fprintf(&buf, "\tm.record(%q)\n", m.name)
+		fmt.Fprintf(&buf, "\tif m.%sFunc == nil {\n\t\tpanic(%q)\n\t}\n", m.name, typeName+"."+m.name+" called without "+m.name+"Func")
+		call := fmt.Sprintf("m.%sFunc(%s)", m.name, strings.Join(m.args, ", "))
+		if m.hasValue {
+			fmt.Fprintf(&buf, "\treturn %s\n}\n\n", call)
+		} else {
+			fmt.Fprintf(&buf, "\t%s\n}\n\n", call)
+		}
+	}
+	fmt.Fprintf(&buf, "func (m *%s) record(name string) {\n", typeName)
+	fmt.Fprintf(&buf, "\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n")
+	fmt.Fprintf(&buf, "\tif m.calls == nil {\n\t\tm.calls = make(map[string]int)\n\t}\n\tm.calls[name]++\n}\n\n")
+	fmt.Fprintf(&buf, "// Calls returns how many times the named method was called.\n")
+	fmt.Fprintf(&buf, "func (m *%s) Calls(name string) int {\n", typeName)
+	fmt.Fprintf(&buf, "\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\treturn m.calls[name]\n}\n")
+
+	return format.Source(buf.Bytes())
+}
@@ -2,9 +2,16 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
+// batchSendFallbackConcurrency bounds the single sends replacing a batch on servers without the batch endpoint
+const batchSendFallbackConcurrency = 8
+
 // SendMessage sends a message (single or group chat based on request)
 func (c *Client) SendMessage(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error) {
 	var result MessageInfo
@@ -41,6 +48,75 @@ func (c *Client) InternalSendMessageWithoutMarkRead(ctx context.Context, req *Se
 	return &result, nil
 }
 
+// SendMessagesBatch sends up to 100 messages from the current user in one request, e.g. a
+// notification to many users. Results follow the order of req.Messages and a failed message
+// does not stop the rest. Servers without the batch endpoint get concurrent single sends.
+func (c *Client) SendMessagesBatch(ctx context.Context, req *BatchSendMessageRequest) ([]*BatchSendResult, error) {
+	return c.sendMessagesBatch(ctx, "/im/msg/", req)
+}
+
+// InternalSendMessagesBatch sends a batch of messages as the acting user via internal route.
+func (c *Client) InternalSendMessagesBatch(ctx context.Context, req *BatchSendMessageRequest, opts ...RequestOption) ([]*BatchSendResult, error) {
+	return c.sendMessagesBatch(ctx, "/im/internal/msg/", req, opts...)
+}
+
+func (c *Client) sendMessagesBatch(ctx context.Context, prefix string, req *BatchSendMessageRequest, opts ...RequestOption) ([]*BatchSendResult, error) {
+	var results []*BatchSendResult
+	err := c.post(ctx, prefix+"batch_send", req, &results, opts...)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == consts.StatusNotFound {
+		sendPath := prefix + "send"
+		if req.WithoutMarkRead {
+			sendPath = prefix + "send_without_mark_read"
+		}
+		return c.sendMessagesOneByOne(ctx, sendPath, req.Messages, opts...), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (c *Client) sendMessagesOneByOne(ctx context.Context, path string, msgs []*SendMessageRequest, opts ...RequestOption) []*BatchSendResult {
+	results := make([]*BatchSendResult, len(msgs))
+	sem := make(chan struct{}, batchSendFallbackConcurrency)
+	var wg sync.WaitGroup
+	for i, msg := range msgs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var info MessageInfo
+			err := c.post(ctx, path, msg, &info, opts...)
+			if err == nil {
+				results[i] = &BatchSendResult{Message: &info}
+				return
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				apiErr = &Error{Code: CodeSendFailed, Msg: err.Error()}
+			}
+			results[i] = &BatchSendResult{Code: apiErr.Code, Error: apiErr.Msg}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ForwardMessages copies messages of a conversation to other conversations as messages of the
+// current user flagged Forwarded. Results follow the order of req.Targets and a failed target
+// does not stop the others. Retrying with the same ClientMsgId returns the copies already sent.
+func (c *Client) ForwardMessages(ctx context.Context, req *ForwardMessagesRequest) ([]*ForwardResult, error) {
+	var results []*ForwardResult
+	if err := c.post(ctx, "/im/msg/forward", req, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // SendTextMessage is a convenience method to send a text message to a single user
 func (c *Client) SendTextMessage(ctx context.Context, clientMsgId, recvId, text string) (*MessageInfo, error) {
 	return c.SendMessage(ctx, &SendMessageRequest{
@@ -115,6 +191,206 @@ func (c *Client) PullMessages(ctx context.Context, conversationId string, beginS
 	return &result, nil
 }
 
+// CreateReminder asks to be reminded of a message later
+func (c *Client) CreateReminder(ctx context.Context, req *CreateReminderRequest) (*MessageReminder, error) {
+	var result MessageReminder
+	if err := c.post(ctx, "/im/msg/reminder/create", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListReminders lists the current user's pending reminders, soonest first
+func (c *Client) ListReminders(ctx context.Context) ([]*MessageReminder, error) {
+	var result []*MessageReminder
+	if err := c.get(ctx, "/im/msg/reminder/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CancelReminder cancels a pending reminder
+func (c *Client) CancelReminder(ctx context.Context, reminderId int64) error {
+	return c.post(ctx, "/im/msg/reminder/cancel", map[string]int64{"reminder_id": reminderId}, nil)
+}
+
+// AddFavorite favorites a message, returning the existing favorite if already favorited
+func (c *Client) AddFavorite(ctx context.Context, conversationId string, seq int64) (*MessageFavorite, error) {
+	req := &AddFavoriteRequest{
+		ConversationId: conversationId,
+		Seq:            seq,
+	}
+	var result MessageFavorite
+	if err := c.post(ctx, "/im/msg/favorite/add", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListFavorites lists favorite messages across conversations, newest first.
+// Pass 0 as cursor for the first page, then the NextCursor of the previous page.
+func (c *Client) ListFavorites(ctx context.Context, cursor int64, limit int) (*FavoriteListPage, error) {
+	params := map[string]string{}
+	if cursor > 0 {
+		params["cursor"] = strconv.FormatInt(cursor, 10)
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	var result FavoriteListPage
+	if err := c.get(ctx, "/im/msg/favorite/list", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RemoveFavorite removes a favorite
+func (c *Client) RemoveFavorite(ctx context.Context, favoriteId int64) error {
+	return c.post(ctx, "/im/msg/favorite/remove", map[string]int64{"favorite_id": favoriteId}, nil)
+}
+
+// RevokeMessage recalls a message the current user sent, within the server's revoke window.
+// It returns the revoke notification added to the conversation.
+func (c *Client) RevokeMessage(ctx context.Context, conversationId string, seq int64) (*MessageInfo, error) {
+	req := &RevokeMessageRequest{
+		ConversationId: conversationId,
+		Seq:            seq,
+	}
+	var msg MessageInfo
+	if err := c.post(ctx, "/im/msg/revoke", req, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// DeleteMessage deletes a message. DeleteScopeSelf hides it from the current user's pulls only;
+// DeleteScopeAll clears its content for everyone, allowed to its sender within the server's
+// revoke window and to group admins at any time.
+func (c *Client) DeleteMessage(ctx context.Context, conversationId string, seq int64, scope string) error {
+	req := &DeleteMessageRequest{
+		ConversationId: conversationId,
+		Seq:            seq,
+		Scope:          scope,
+	}
+	return c.post(ctx, "/im/msg/delete", req, nil)
+}
+
+// EditMessage replaces the text of a text message the current user sent and returns the
+// edited message, its EditVersion incremented
+func (c *Client) EditMessage(ctx context.Context, req *EditMessageRequest) (*MessageInfo, error) {
+	var msg MessageInfo
+	if err := c.post(ctx, "/im/msg/edit", req, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetEditHistory lists the versions of a message replaced by edits, oldest first
+func (c *Client) GetEditHistory(ctx context.Context, conversationId string, seq int64) ([]*MessageEdit, error) {
+	params := map[string]string{
+		"conversation_id": conversationId,
+		"seq":             strconv.FormatInt(seq, 10),
+	}
+	var edits []*MessageEdit
+	if err := c.get(ctx, "/im/msg/edit/history", params, &edits); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// GetReadStatus gets whether a single chat message was delivered to its receiver and read
+func (c *Client) GetReadStatus(ctx context.Context, conversationId string, seq int64) (*ReadStatus, error) {
+	params := map[string]string{
+		"conversation_id": conversationId,
+		"seq":             strconv.FormatInt(seq, 10),
+	}
+	var status ReadStatus
+	if err := c.get(ctx, "/im/msg/read_status", params, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetGroupReadMembers lists the members who read a group message
+func (c *Client) GetGroupReadMembers(ctx context.Context, conversationId string, seq int64) (*GroupReadMembers, error) {
+	params := map[string]string{
+		"conversation_id": conversationId,
+		"seq":             strconv.FormatInt(seq, 10),
+	}
+	var members GroupReadMembers
+	if err := c.get(ctx, "/im/msg/group_read_members", params, &members); err != nil {
+		return nil, err
+	}
+	return &members, nil
+}
+
+// ListThread lists the replies quoting a message, oldest first.
+// Pass 0 as cursor for the first page, then the NextCursor of the previous page.
+func (c *Client) ListThread(ctx context.Context, conversationId string, seq int64, cursor int64, limit int) (*ThreadPage, error) {
+	params := map[string]string{
+		"conversation_id": conversationId,
+		"seq":             strconv.FormatInt(seq, 10),
+	}
+	if cursor > 0 {
+		params["cursor"] = strconv.FormatInt(cursor, 10)
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	var result ThreadPage
+	if err := c.get(ctx, "/im/msg/thread", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AddReaction reacts with an emoji to a message. Reacting twice with the same emoji is a no-op.
+func (c *Client) AddReaction(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	return c.changeReaction(ctx, "/im/msg/reaction/add", conversationId, seq, emoji)
+}
+
+// RemoveReaction removes a reaction of the current user to a message
+func (c *Client) RemoveReaction(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	return c.changeReaction(ctx, "/im/msg/reaction/remove", conversationId, seq, emoji)
+}
+
+func (c *Client) changeReaction(ctx context.Context, path, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	req := &ReactionRequest{
+		ConversationId: conversationId,
+		Seq:            seq,
+		Emoji:          emoji,
+	}
+	var change ReactionChange
+	if err := c.post(ctx, path, req, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// VotePoll replaces the current user's vote on a poll message and returns the new result
+func (c *Client) VotePoll(ctx context.Context, req *VotePollRequest) (*PollResult, error) {
+	var result PollResult
+	if err := c.post(ctx, "/im/msg/poll/vote", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPollResult gets the result of a poll message
+func (c *Client) GetPollResult(ctx context.Context, conversationId string, seq int64) (*PollResult, error) {
+	params := map[string]string{
+		"conversation_id": conversationId,
+		"seq":             strconv.FormatInt(seq, 10),
+	}
+	var result PollResult
+	if err := c.get(ctx, "/im/msg/poll/result", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // GetMaxSeq gets the max seq for a conversation
 func (c *Client) GetMaxSeq(ctx context.Context, conversationId string) (int64, error) {
 	params := map[string]string{"conversation_id": conversationId}
@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendMessagesBatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/im/msg/batch_send", r.URL.Path)
+		var req BatchSendMessageRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Messages, 2)
+		_, _ = io.WriteString(w, `{"code":0,"data":[{"code":0,"message":{"seq":1}},{"code":2006,"error":"user not found"}]}`)
+	}))
+	defer srv.Close()
+
+	results, err := MustNewClient(srv.URL).SendMessagesBatch(context.Background(), &BatchSendMessageRequest{
+		Messages: []*SendMessageRequest{{ClientMsgId: "m1", RecvId: "u1"}, {ClientMsgId: "m2", RecvId: "missing"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err())
+	require.EqualValues(t, 1, results[0].Message.Seq)
+	requireCode(t, results[1].Err(), CodeUserNotFound)
+}
+
+func TestSendMessagesBatchFallsBackToSingleSends(t *testing.T) {
+	var singles atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/im/internal/msg/send_without_mark_read":
+			singles.Add(1)
+			var req SendMessageRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.RecvId == "missing" {
+				_, _ = io.WriteString(w, `{"code":2006,"message":"user not found"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"code":0,"data":{"client_msg_id":"`+req.ClientMsgId+`"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var msgs []*SendMessageRequest
+	for _, id := range []string{"u1", "missing", "u2", "u3"} {
+		msgs = append(msgs, &SendMessageRequest{ClientMsgId: "to_" + id, RecvId: id})
+	}
+	c := MustNewInternalClient(srv.URL, "svc", "s3cret")
+	results, err := c.InternalSendMessagesBatch(context.Background(),
+		&BatchSendMessageRequest{Messages: msgs, WithoutMarkRead: true}, WithActAsUser("system", PlatformIdWeb))
+	require.NoError(t, err)
+	require.EqualValues(t, 4, singles.Load())
+	require.Len(t, results, 4)
+	require.Equal(t, "to_u1", results[0].Message.ClientMsgId)
+	requireCode(t, results[1].Err(), CodeUserNotFound)
+	require.Equal(t, "to_u3", results[3].Message.ClientMsgId)
+}
+
+func TestExportMessagesStreamsNDJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/im/internal/msg/export", r.URL.Path)
+		require.Equal(t, "u1", r.Header.Get("X-User-Id"))
+		switch r.URL.Query().Get("conversation_id") {
+		case "si_u1:u2":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for seq := 1; seq <= 3; seq++ {
+				_, _ = fmt.Fprintf(w, `{"conversation_id":"si_u1:u2","seq":%d,"content":{"text":"m%d"}}`+"\n", seq, seq)
+				w.(http.Flusher).Flush()
+			}
+		case "si_u1:u3":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = io.WriteString(w, `{"conversation_id":"si_u1:u3","seq":1}`+"\n"+`{"code":4006,"message":"message pull failed"}`+"\n")
+		default:
+			_, _ = io.WriteString(w, `{"code":1007,"message":"no permission"}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := MustNewInternalClient(srv.URL, "svc", "s3cret", WithDefaultActAsUser("u1", PlatformIdWeb))
+	ctx := context.Background()
+
+	it, err := c.InternalExportMessages(ctx, "si_u1:u2")
+	require.NoError(t, err)
+	var texts []string
+	for it.Next() {
+		texts = append(texts, it.Message().Content.Text)
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	require.Equal(t, []string{"m1", "m2", "m3"}, texts)
+
+	it, err = c.InternalExportMessages(ctx, "si_u1:u3")
+	require.NoError(t, err)
+	require.True(t, it.Next())
+	require.False(t, it.Next())
+	requireCode(t, it.Err(), CodePullFailed)
+	require.NoError(t, it.Close())
+
+	_, err = c.InternalExportMessages(ctx, "si_u2:u3")
+	requireCode(t, err, CodeNoPermission)
+}
@@ -0,0 +1,1223 @@
+// Code generated by go run ./internal/mockgen; DO NOT EDIT.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MockClient is a ClientAPI whose methods call the matching Func field.
+// Calling a method whose Func field is nil panics.
+type MockClient struct {
+	RegisterFunc                                      func(ctx context.Context, req *RegisterRequest) (*UserInfo, error)
+	VerifyFunc                                        func(ctx context.Context, req *VerifyRequest) error
+	ResendVerifyCodeFunc                              func(ctx context.Context, userId string) error
+	LoginFunc                                         func(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
+	OAuthLoginFunc                                    func(ctx context.Context, req *OAuthLoginRequest) (*LoginResponse, error)
+	CreateGuestFunc                                   func(ctx context.Context, req *CreateGuestRequest) (*LoginResponse, error)
+	UpgradeGuestFunc                                  func(ctx context.Context, req *UpgradeGuestRequest) (*UserInfo, error)
+	RefreshTokenFunc                                  func(ctx context.Context) (*RefreshTokenResponse, error)
+	LoginWithUserIdFunc                               func(ctx context.Context, userId string, password string, platformId int) (*LoginResponse, error)
+	InternalRegisterFunc                              func(ctx context.Context, req *RegisterRequest) (*UserInfo, error)
+	UseExternalTokenFunc                              func(token string)
+	EnableTestAuthBypassFunc                          func(enabled bool)
+	SetTokenFunc                                      func(token string)
+	GetTokenFunc                                      func() string
+	SetRefreshTokenFunc                               func(refreshToken string)
+	GetRefreshTokenFunc                               func() string
+	SetIgnoreAuthFunc                                 func(enabled bool)
+	GetUserInfoFunc                                   func(ctx context.Context) (*UserInfo, error)
+	GetUserInfoByIdFunc                               func(ctx context.Context, userId string) (*UserInfo, error)
+	UpdateUserInfoFunc                                func(ctx context.Context, req *UpdateUserRequest) (*UserInfo, error)
+	GetUsersInfoFunc                                  func(ctx context.Context, userIds []string) ([]*UserInfo, error)
+	SearchUsersFunc                                   func(ctx context.Context, q string, limit int) ([]*UserInfo, error)
+	GetUsersOnlineStatusFunc                          func(ctx context.Context, userIds []string) ([]*OnlineStatus, error)
+	ListSessionsFunc                                  func(ctx context.Context) ([]*Session, error)
+	RenameSessionFunc                                 func(ctx context.Context, sessionId string, name string) error
+	RevokeSessionFunc                                 func(ctx context.Context, sessionId string) error
+	DeleteAccountFunc                                 func(ctx context.Context, req *DeleteAccountRequest) error
+	GetNotificationSettingsFunc                       func(ctx context.Context) (*NotificationSettings, error)
+	UpdateNotificationSettingsFunc                    func(ctx context.Context, req *UpdateNotificationSettingsRequest) (*NotificationSettings, error)
+	IssueScopedTokenFunc                              func(ctx context.Context, req *ScopedTokenRequest) (*ScopedTokenResponse, error)
+	InternalGetUserInfoFunc                           func(ctx context.Context, opts ...RequestOption) (*UserInfo, error)
+	InternalGetUserInfoByIdFunc                       func(ctx context.Context, userId string, opts ...RequestOption) (*UserInfo, error)
+	InternalUpdateUserInfoFunc                        func(ctx context.Context, req *UpdateUserRequest, opts ...RequestOption) (*UserInfo, error)
+	InternalGetUsersInfoFunc                          func(ctx context.Context, userIds []string, opts ...RequestOption) ([]*UserInfo, error)
+	InternalGetUsersOnlineStatusFunc                  func(ctx context.Context, userIds []string, opts ...RequestOption) ([]*OnlineStatus, error)
+	CreateGroupFunc                                   func(ctx context.Context, req *CreateGroupRequest) (*GroupInfo, error)
+	JoinGroupFunc                                     func(ctx context.Context, groupId string, inviterId string) error
+	QuitGroupFunc                                     func(ctx context.Context, groupId string) error
+	GetGroupInfoFunc                                  func(ctx context.Context, groupId string) (*GroupInfo, error)
+	GetGroupMembersFunc                               func(ctx context.Context, groupId string) ([]*GroupMember, error)
+	KickGroupMembersFunc                              func(ctx context.Context, groupId string, userIds []string) error
+	MuteGroupMemberFunc                               func(ctx context.Context, groupId string, userId string, duration time.Duration) error
+	TransferGroupOwnershipFunc                        func(ctx context.Context, groupId string, newOwnerId string) error
+	PromoteGroupAdminFunc                             func(ctx context.Context, groupId string, userId string) error
+	DemoteGroupAdminFunc                              func(ctx context.Context, groupId string, userId string) error
+	DismissGroupFunc                                  func(ctx context.Context, groupId string) error
+	UpdateGroupInfoFunc                               func(ctx context.Context, req *UpdateGroupInfoRequest) (*GroupInfo, error)
+	SetGroupAnnouncementFunc                          func(ctx context.Context, groupId string, content string) (*GroupInfo, error)
+	CreateGroupInviteLinkFunc                         func(ctx context.Context, req *CreateInviteLinkRequest) (*GroupInviteLink, error)
+	RevokeGroupInviteLinkFunc                         func(ctx context.Context, groupId string, token string) error
+	JoinGroupByInviteLinkFunc                         func(ctx context.Context, token string) (string, error)
+	InternalCreateGroupFunc                           func(ctx context.Context, req *CreateGroupRequest, opts ...RequestOption) (*GroupInfo, error)
+	InternalJoinGroupFunc                             func(ctx context.Context, groupId string, inviterId string, opts ...RequestOption) error
+	InternalQuitGroupFunc                             func(ctx context.Context, groupId string, opts ...RequestOption) error
+	InternalGetGroupInfoFunc                          func(ctx context.Context, groupId string, opts ...RequestOption) (*GroupInfo, error)
+	InternalGetGroupMembersFunc                       func(ctx context.Context, groupId string, opts ...RequestOption) ([]*GroupMember, error)
+	InternalKickGroupMembersFunc                      func(ctx context.Context, groupId string, userIds []string, opts ...RequestOption) error
+	InternalMuteGroupMemberFunc                       func(ctx context.Context, groupId string, userId string, duration time.Duration, opts ...RequestOption) error
+	InternalTransferGroupOwnershipFunc                func(ctx context.Context, groupId string, newOwnerId string, opts ...RequestOption) error
+	InternalPromoteGroupAdminFunc                     func(ctx context.Context, groupId string, userId string, opts ...RequestOption) error
+	InternalDemoteGroupAdminFunc                      func(ctx context.Context, groupId string, userId string, opts ...RequestOption) error
+	InternalDismissGroupFunc                          func(ctx context.Context, groupId string, opts ...RequestOption) error
+	InternalUpdateGroupInfoFunc                       func(ctx context.Context, req *UpdateGroupInfoRequest, opts ...RequestOption) (*GroupInfo, error)
+	InternalSetGroupAnnouncementFunc                  func(ctx context.Context, groupId string, content string, opts ...RequestOption) (*GroupInfo, error)
+	InternalCreateGroupInviteLinkFunc                 func(ctx context.Context, req *CreateInviteLinkRequest, opts ...RequestOption) (*GroupInviteLink, error)
+	InternalRevokeGroupInviteLinkFunc                 func(ctx context.Context, groupId string, token string, opts ...RequestOption) error
+	InternalJoinGroupByInviteLinkFunc                 func(ctx context.Context, token string, opts ...RequestOption) (string, error)
+	SendMessageFunc                                   func(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error)
+	InternalSendMessageFunc                           func(ctx context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error)
+	SendMessageWithoutMarkReadFunc                    func(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error)
+	InternalSendMessageWithoutMarkReadFunc            func(ctx context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error)
+	SendMessagesBatchFunc                             func(ctx context.Context, req *BatchSendMessageRequest) ([]*BatchSendResult, error)
+	InternalSendMessagesBatchFunc                     func(ctx context.Context, req *BatchSendMessageRequest, opts ...RequestOption) ([]*BatchSendResult, error)
+	ForwardMessagesFunc                               func(ctx context.Context, req *ForwardMessagesRequest) ([]*ForwardResult, error)
+	SendTextMessageFunc                               func(ctx context.Context, clientMsgId string, recvId string, text string) (*MessageInfo, error)
+	SendGroupTextMessageFunc                          func(ctx context.Context, clientMsgId string, groupId string, text string) (*MessageInfo, error)
+	SendTextMessageWithoutMarkReadFunc                func(ctx context.Context, clientMsgId string, recvId string, text string) (*MessageInfo, error)
+	SendGroupTextMessageWithoutMarkReadFunc           func(ctx context.Context, clientMsgId string, groupId string, text string) (*MessageInfo, error)
+	PullMessagesFunc                                  func(ctx context.Context, conversationId string, beginSeq int64, endSeq int64, limit int) (*PullMessagesResponse, error)
+	GetMaxSeqFunc                                     func(ctx context.Context, conversationId string) (int64, error)
+	CreateReminderFunc                                func(ctx context.Context, req *CreateReminderRequest) (*MessageReminder, error)
+	ListRemindersFunc                                 func(ctx context.Context) ([]*MessageReminder, error)
+	CancelReminderFunc                                func(ctx context.Context, reminderId int64) error
+	AddFavoriteFunc                                   func(ctx context.Context, conversationId string, seq int64) (*MessageFavorite, error)
+	ListFavoritesFunc                                 func(ctx context.Context, cursor int64, limit int) (*FavoriteListPage, error)
+	RemoveFavoriteFunc                                func(ctx context.Context, favoriteId int64) error
+	RevokeMessageFunc                                 func(ctx context.Context, conversationId string, seq int64) (*MessageInfo, error)
+	DeleteMessageFunc                                 func(ctx context.Context, conversationId string, seq int64, scope string) error
+	EditMessageFunc                                   func(ctx context.Context, req *EditMessageRequest) (*MessageInfo, error)
+	GetEditHistoryFunc                                func(ctx context.Context, conversationId string, seq int64) ([]*MessageEdit, error)
+	GetReadStatusFunc                                 func(ctx context.Context, conversationId string, seq int64) (*ReadStatus, error)
+	GetGroupReadMembersFunc                           func(ctx context.Context, conversationId string, seq int64) (*GroupReadMembers, error)
+	ListThreadFunc                                    func(ctx context.Context, conversationId string, seq int64, cursor int64, limit int) (*ThreadPage, error)
+	AddReactionFunc                                   func(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error)
+	RemoveReactionFunc                                func(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error)
+	VotePollFunc                                      func(ctx context.Context, req *VotePollRequest) (*PollResult, error)
+	GetPollResultFunc                                 func(ctx context.Context, conversationId string, seq int64) (*PollResult, error)
+	ExportMessagesFunc                                func(ctx context.Context, conversationId string) (*MessageIterator, error)
+	InternalExportMessagesFunc                        func(ctx context.Context, conversationId string, opts ...RequestOption) (*MessageIterator, error)
+	GetAllConversationListFunc                        func(ctx context.Context) ([]*ConversationInfo, error)
+	GetAllConversationListWithLastMessageFunc         func(ctx context.Context, withLastMessage bool) ([]*ConversationInfo, error)
+	GetConversationListFunc                           func(ctx context.Context, limit int, cursor *ConversationListCursor) (*ConversationListPage, error)
+	GetConversationListWithLastMessageFunc            func(ctx context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor) (*ConversationListPage, error)
+	InternalGetAllConversationListFunc                func(ctx context.Context, opts ...RequestOption) ([]*ConversationInfo, error)
+	InternalGetAllConversationListWithLastMessageFunc func(ctx context.Context, withLastMessage bool, opts ...RequestOption) ([]*ConversationInfo, error)
+	InternalGetConversationListFunc                   func(ctx context.Context, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error)
+	InternalGetConversationListWithLastMessageFunc    func(ctx context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error)
+	GetConversationFunc                               func(ctx context.Context, conversationId string) (*ConversationInfo, error)
+	GetSavedConversationFunc                          func(ctx context.Context) (*ConversationInfo, error)
+	UpdateConversationFunc                            func(ctx context.Context, conversationId string, req *UpdateConversationRequest) error
+	SetConversationPinnedFunc                         func(ctx context.Context, conversationId string, isPinned bool) error
+	SetConversationRecvMsgOptFunc                     func(ctx context.Context, conversationId string, recvMsgOpt int32) error
+	SetConversationTTLFunc                            func(ctx context.Context, conversationId string, ttlSeconds int32) error
+	PinMessageFunc                                    func(ctx context.Context, conversationId string, seq int64) (*PinChange, error)
+	UnpinMessageFunc                                  func(ctx context.Context, conversationId string, seq int64) (*PinChange, error)
+	GetPinnedMessagesFunc                             func(ctx context.Context, conversationId string) ([]*PinnedMessage, error)
+	MarkReadFunc                                      func(ctx context.Context, conversationId string, readSeq int64) error
+	GetMaxReadSeqFunc                                 func(ctx context.Context, conversationId string) (*MaxReadSeqResponse, error)
+	GetUnreadCountFunc                                func(ctx context.Context, conversationId string, readSeq int64) (int64, error)
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// Register calls RegisterFunc.
+func (m *MockClient) Register(ctx context.Context, req *RegisterRequest) (*UserInfo, error) {
+	m.record("Register")
+	if m.RegisterFunc == nil {
+		panic("MockClient.Register called without RegisterFunc")
+	}
+	return m.RegisterFunc(ctx, req)
+}
+
+// Verify calls VerifyFunc.
+func (m *MockClient) Verify(ctx context.Context, req *VerifyRequest) error {
+	m.record("Verify")
+	if m.VerifyFunc == nil {
+		panic("MockClient.Verify called without VerifyFunc")
+	}
+	return m.VerifyFunc(ctx, req)
+}
+
+// ResendVerifyCode calls ResendVerifyCodeFunc.
+func (m *MockClient) ResendVerifyCode(ctx context.Context, userId string) error {
+	m.record("ResendVerifyCode")
+	if m.ResendVerifyCodeFunc == nil {
+		panic("MockClient.ResendVerifyCode called without ResendVerifyCodeFunc")
+	}
+	return m.ResendVerifyCodeFunc(ctx, userId)
+}
+
+// Login calls LoginFunc.
+func (m *MockClient) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	m.record("Login")
+	if m.LoginFunc == nil {
+		panic("MockClient.Login called without LoginFunc")
+	}
+	return m.LoginFunc(ctx, req)
+}
+
+// OAuthLogin calls OAuthLoginFunc.
+func (m *MockClient) OAuthLogin(ctx context.Context, req *OAuthLoginRequest) (*LoginResponse, error) {
+	m.record("OAuthLogin")
+	if m.OAuthLoginFunc == nil {
+		panic("MockClient.OAuthLogin called without OAuthLoginFunc")
+	}
+	return m.OAuthLoginFunc(ctx, req)
+}
+
+// CreateGuest calls CreateGuestFunc.
+func (m *MockClient) CreateGuest(ctx context.Context, req *CreateGuestRequest) (*LoginResponse, error) {
+	m.record("CreateGuest")
+	if m.CreateGuestFunc == nil {
+		panic("MockClient.CreateGuest called without CreateGuestFunc")
+	}
+	return m.CreateGuestFunc(ctx, req)
+}
+
+// UpgradeGuest calls UpgradeGuestFunc.
+func (m *MockClient) UpgradeGuest(ctx context.Context, req *UpgradeGuestRequest) (*UserInfo, error) {
+	m.record("UpgradeGuest")
+	if m.UpgradeGuestFunc == nil {
+		panic("MockClient.UpgradeGuest called without UpgradeGuestFunc")
+	}
+	return m.UpgradeGuestFunc(ctx, req)
+}
+
+// RefreshToken calls RefreshTokenFunc.
+func (m *MockClient) RefreshToken(ctx context.Context) (*RefreshTokenResponse, error) {
+	m.record("RefreshToken")
+	if m.RefreshTokenFunc == nil {
+		panic("MockClient.RefreshToken called without RefreshTokenFunc")
+	}
+	return m.RefreshTokenFunc(ctx)
+}
+
+// LoginWithUserId calls LoginWithUserIdFunc.
+func (m *MockClient) LoginWithUserId(ctx context.Context, userId string, password string, platformId int) (*LoginResponse, error) {
+	m.record("LoginWithUserId")
+	if m.LoginWithUserIdFunc == nil {
+		panic("MockClient.LoginWithUserId called without LoginWithUserIdFunc")
+	}
+	return m.LoginWithUserIdFunc(ctx, userId, password, platformId)
+}
+
+// InternalRegister calls InternalRegisterFunc.
+func (m *MockClient) InternalRegister(ctx context.Context, req *RegisterRequest) (*UserInfo, error) {
+	m.record("InternalRegister")
+	if m.InternalRegisterFunc == nil {
+		panic("MockClient.InternalRegister called without InternalRegisterFunc")
+	}
+	return m.InternalRegisterFunc(ctx, req)
+}
+
+// UseExternalToken calls UseExternalTokenFunc.
+func (m *MockClient) UseExternalToken(token string) {
+	m.record("UseExternalToken")
+	if m.UseExternalTokenFunc == nil {
+		panic("MockClient.UseExternalToken called without UseExternalTokenFunc")
+	}
+	m.UseExternalTokenFunc(token)
+}
+
+// EnableTestAuthBypass calls EnableTestAuthBypassFunc.
+func (m *MockClient) EnableTestAuthBypass(enabled bool) {
+	m.record("EnableTestAuthBypass")
+	if m.EnableTestAuthBypassFunc == nil {
+		panic("MockClient.EnableTestAuthBypass called without EnableTestAuthBypassFunc")
+	}
+	m.EnableTestAuthBypassFunc(enabled)
+}
+
+// SetToken calls SetTokenFunc.
+func (m *MockClient) SetToken(token string) {
+	m.record("SetToken")
+	if m.SetTokenFunc == nil {
+		panic("MockClient.SetToken called without SetTokenFunc")
+	}
+	m.SetTokenFunc(token)
+}
+
+// GetToken calls GetTokenFunc.
+func (m *MockClient) GetToken() string {
+	m.record("GetToken")
+	if m.GetTokenFunc == nil {
+		panic("MockClient.GetToken called without GetTokenFunc")
+	}
+	return m.GetTokenFunc()
+}
+
+// SetRefreshToken calls SetRefreshTokenFunc.
+func (m *MockClient) SetRefreshToken(refreshToken string) {
+	m.record("SetRefreshToken")
+	if m.SetRefreshTokenFunc == nil {
+		panic("MockClient.SetRefreshToken called without SetRefreshTokenFunc")
+	}
+	m.SetRefreshTokenFunc(refreshToken)
+}
+
+// GetRefreshToken calls GetRefreshTokenFunc.
+func (m *MockClient) GetRefreshToken() string {
+	m.record("GetRefreshToken")
+	if m.GetRefreshTokenFunc == nil {
+		panic("MockClient.GetRefreshToken called without GetRefreshTokenFunc")
+	}
+	return m.GetRefreshTokenFunc()
+}
+
+// SetIgnoreAuth calls SetIgnoreAuthFunc.
+func (m *MockClient) SetIgnoreAuth(enabled bool) {
+	m.record("SetIgnoreAuth")
+	if m.SetIgnoreAuthFunc == nil {
+		panic("MockClient.SetIgnoreAuth called without SetIgnoreAuthFunc")
+	}
+	m.SetIgnoreAuthFunc(enabled)
+}
+
+// GetUserInfo calls GetUserInfoFunc.
+func (m *MockClient) GetUserInfo(ctx context.Context) (*UserInfo, error) {
+	m.record("GetUserInfo")
+	if m.GetUserInfoFunc == nil {
+		panic("MockClient.GetUserInfo called without GetUserInfoFunc")
+	}
+	return m.GetUserInfoFunc(ctx)
+}
+
+// GetUserInfoById calls GetUserInfoByIdFunc.
+func (m *MockClient) GetUserInfoById(ctx context.Context, userId string) (*UserInfo, error) {
+	m.record("GetUserInfoById")
+	if m.GetUserInfoByIdFunc == nil {
+		panic("MockClient.GetUserInfoById called without GetUserInfoByIdFunc")
+	}
+	return m.GetUserInfoByIdFunc(ctx, userId)
+}
+
+// UpdateUserInfo calls UpdateUserInfoFunc.
+func (m *MockClient) UpdateUserInfo(ctx context.Context, req *UpdateUserRequest) (*UserInfo, error) {
+	m.record("UpdateUserInfo")
+	if m.UpdateUserInfoFunc == nil {
+		panic("MockClient.UpdateUserInfo called without UpdateUserInfoFunc")
+	}
+	return m.UpdateUserInfoFunc(ctx, req)
+}
+
+// GetUsersInfo calls GetUsersInfoFunc.
+func (m *MockClient) GetUsersInfo(ctx context.Context, userIds []string) ([]*UserInfo, error) {
+	m.record("GetUsersInfo")
+	if m.GetUsersInfoFunc == nil {
+		panic("MockClient.GetUsersInfo called without GetUsersInfoFunc")
+	}
+	return m.GetUsersInfoFunc(ctx, userIds)
+}
+
+// SearchUsers calls SearchUsersFunc.
+func (m *MockClient) SearchUsers(ctx context.Context, q string, limit int) ([]*UserInfo, error) {
+	m.record("SearchUsers")
+	if m.SearchUsersFunc == nil {
+		panic("MockClient.SearchUsers called without SearchUsersFunc")
+	}
+	return m.SearchUsersFunc(ctx, q, limit)
+}
+
+// GetUsersOnlineStatus calls GetUsersOnlineStatusFunc.
+func (m *MockClient) GetUsersOnlineStatus(ctx context.Context, userIds []string) ([]*OnlineStatus, error) {
+	m.record("GetUsersOnlineStatus")
+	if m.GetUsersOnlineStatusFunc == nil {
+		panic("MockClient.GetUsersOnlineStatus called without GetUsersOnlineStatusFunc")
+	}
+	return m.GetUsersOnlineStatusFunc(ctx, userIds)
+}
+
+// ListSessions calls ListSessionsFunc.
+func (m *MockClient) ListSessions(ctx context.Context) ([]*Session, error) {
+	m.record("ListSessions")
+	if m.ListSessionsFunc == nil {
+		panic("MockClient.ListSessions called without ListSessionsFunc")
+	}
+	return m.ListSessionsFunc(ctx)
+}
+
+// RenameSession calls RenameSessionFunc.
+func (m *MockClient) RenameSession(ctx context.Context, sessionId string, name string) error {
+	m.record("RenameSession")
+	if m.RenameSessionFunc == nil {
+		panic("MockClient.RenameSession called without RenameSessionFunc")
+	}
+	return m.RenameSessionFunc(ctx, sessionId, name)
+}
+
+// RevokeSession calls RevokeSessionFunc.
+func (m *MockClient) RevokeSession(ctx context.Context, sessionId string) error {
+	m.record("RevokeSession")
+	if m.RevokeSessionFunc == nil {
+		panic("MockClient.RevokeSession called without RevokeSessionFunc")
+	}
+	return m.RevokeSessionFunc(ctx, sessionId)
+}
+
+// DeleteAccount calls DeleteAccountFunc.
+func (m *MockClient) DeleteAccount(ctx context.Context, req *DeleteAccountRequest) error {
+	m.record("DeleteAccount")
+	if m.DeleteAccountFunc == nil {
+		panic("MockClient.DeleteAccount called without DeleteAccountFunc")
+	}
+	return m.DeleteAccountFunc(ctx, req)
+}
+
+// GetNotificationSettings calls GetNotificationSettingsFunc.
+func (m *MockClient) GetNotificationSettings(ctx context.Context) (*NotificationSettings, error) {
+	m.record("GetNotificationSettings")
+	if m.GetNotificationSettingsFunc == nil {
+		panic("MockClient.GetNotificationSettings called without GetNotificationSettingsFunc")
+	}
+	return m.GetNotificationSettingsFunc(ctx)
+}
+
+// UpdateNotificationSettings calls UpdateNotificationSettingsFunc.
+func (m *MockClient) UpdateNotificationSettings(ctx context.Context, req *UpdateNotificationSettingsRequest) (*NotificationSettings, error) {
+	m.record("UpdateNotificationSettings")
+	if m.UpdateNotificationSettingsFunc == nil {
+		panic("MockClient.UpdateNotificationSettings called without UpdateNotificationSettingsFunc")
+	}
+	return m.UpdateNotificationSettingsFunc(ctx, req)
+}
+
+// IssueScopedToken calls IssueScopedTokenFunc.
+func (m *MockClient) IssueScopedToken(ctx context.Context, req *ScopedTokenRequest) (*ScopedTokenResponse, error) {
+	m.record("IssueScopedToken")
+	if m.IssueScopedTokenFunc == nil {
+		panic("MockClient.IssueScopedToken called without IssueScopedTokenFunc")
+	}
+	return m.IssueScopedTokenFunc(ctx, req)
+}
+
+// InternalGetUserInfo calls InternalGetUserInfoFunc.
+func (m *MockClient) InternalGetUserInfo(ctx context.Context, opts ...RequestOption) (*UserInfo, error) {
+	m.record("InternalGetUserInfo")
+	if m.InternalGetUserInfoFunc == nil {
+		panic("MockClient.InternalGetUserInfo called without InternalGetUserInfoFunc")
+	}
+	return m.InternalGetUserInfoFunc(ctx, opts...)
+}
+
+// InternalGetUserInfoById calls InternalGetUserInfoByIdFunc.
+func (m *MockClient) InternalGetUserInfoById(ctx context.Context, userId string, opts ...RequestOption) (*UserInfo, error) {
+	m.record("InternalGetUserInfoById")
+	if m.InternalGetUserInfoByIdFunc == nil {
+		panic("MockClient.InternalGetUserInfoById called without InternalGetUserInfoByIdFunc")
+	}
+	return m.InternalGetUserInfoByIdFunc(ctx, userId, opts...)
+}
+
+// InternalUpdateUserInfo calls InternalUpdateUserInfoFunc.
+func (m *MockClient) InternalUpdateUserInfo(ctx context.Context, req *UpdateUserRequest, opts ...RequestOption) (*UserInfo, error) {
+	m.record("InternalUpdateUserInfo")
+	if m.InternalUpdateUserInfoFunc == nil {
+		panic("MockClient.InternalUpdateUserInfo called without InternalUpdateUserInfoFunc")
+	}
+	return m.InternalUpdateUserInfoFunc(ctx, req, opts...)
+}
+
+// InternalGetUsersInfo calls InternalGetUsersInfoFunc.
+func (m *MockClient) InternalGetUsersInfo(ctx context.Context, userIds []string, opts ...RequestOption) ([]*UserInfo, error) {
+	m.record("InternalGetUsersInfo")
+	if m.InternalGetUsersInfoFunc == nil {
+		panic("MockClient.InternalGetUsersInfo called without InternalGetUsersInfoFunc")
+	}
+	return m.InternalGetUsersInfoFunc(ctx, userIds, opts...)
+}
+
+// InternalGetUsersOnlineStatus calls InternalGetUsersOnlineStatusFunc.
+func (m *MockClient) InternalGetUsersOnlineStatus(ctx context.Context, userIds []string, opts ...RequestOption) ([]*OnlineStatus, error) {
+	m.record("InternalGetUsersOnlineStatus")
+	if m.InternalGetUsersOnlineStatusFunc == nil {
+		panic("MockClient.InternalGetUsersOnlineStatus called without InternalGetUsersOnlineStatusFunc")
+	}
+	return m.InternalGetUsersOnlineStatusFunc(ctx, userIds, opts...)
+}
+
+// CreateGroup calls CreateGroupFunc.
+func (m *MockClient) CreateGroup(ctx context.Context, req *CreateGroupRequest) (*GroupInfo, error) {
+	m.record("CreateGroup")
+	if m.CreateGroupFunc == nil {
+		panic("MockClient.CreateGroup called without CreateGroupFunc")
+	}
+	return m.CreateGroupFunc(ctx, req)
+}
+
+// JoinGroup calls JoinGroupFunc.
+func (m *MockClient) JoinGroup(ctx context.Context, groupId string, inviterId string) error {
+	m.record("JoinGroup")
+	if m.JoinGroupFunc == nil {
+		panic("MockClient.JoinGroup called without JoinGroupFunc")
+	}
+	return m.JoinGroupFunc(ctx, groupId, inviterId)
+}
+
+// QuitGroup calls QuitGroupFunc.
+func (m *MockClient) QuitGroup(ctx context.Context, groupId string) error {
+	m.record("QuitGroup")
+	if m.QuitGroupFunc == nil {
+		panic("MockClient.QuitGroup called without QuitGroupFunc")
+	}
+	return m.QuitGroupFunc(ctx, groupId)
+}
+
+// GetGroupInfo calls GetGroupInfoFunc.
+func (m *MockClient) GetGroupInfo(ctx context.Context, groupId string) (*GroupInfo, error) {
+	m.record("GetGroupInfo")
+	if m.GetGroupInfoFunc == nil {
+		panic("MockClient.GetGroupInfo called without GetGroupInfoFunc")
+	}
+	return m.GetGroupInfoFunc(ctx, groupId)
+}
+
+// GetGroupMembers calls GetGroupMembersFunc.
+func (m *MockClient) GetGroupMembers(ctx context.Context, groupId string) ([]*GroupMember, error) {
+	m.record("GetGroupMembers")
+	if m.GetGroupMembersFunc == nil {
+		panic("MockClient.GetGroupMembers called without GetGroupMembersFunc")
+	}
+	return m.GetGroupMembersFunc(ctx, groupId)
+}
+
+// KickGroupMembers calls KickGroupMembersFunc.
+func (m *MockClient) KickGroupMembers(ctx context.Context, groupId string, userIds []string) error {
+	m.record("KickGroupMembers")
+	if m.KickGroupMembersFunc == nil {
+		panic("MockClient.KickGroupMembers called without KickGroupMembersFunc")
+	}
+	return m.KickGroupMembersFunc(ctx, groupId, userIds)
+}
+
+// MuteGroupMember calls MuteGroupMemberFunc.
+func (m *MockClient) MuteGroupMember(ctx context.Context, groupId string, userId string, duration time.Duration) error {
+	m.record("MuteGroupMember")
+	if m.MuteGroupMemberFunc == nil {
+		panic("MockClient.MuteGroupMember called without MuteGroupMemberFunc")
+	}
+	return m.MuteGroupMemberFunc(ctx, groupId, userId, duration)
+}
+
+// TransferGroupOwnership calls TransferGroupOwnershipFunc.
+func (m *MockClient) TransferGroupOwnership(ctx context.Context, groupId string, newOwnerId string) error {
+	m.record("TransferGroupOwnership")
+	if m.TransferGroupOwnershipFunc == nil {
+		panic("MockClient.TransferGroupOwnership called without TransferGroupOwnershipFunc")
+	}
+	return m.TransferGroupOwnershipFunc(ctx, groupId, newOwnerId)
+}
+
+// PromoteGroupAdmin calls PromoteGroupAdminFunc.
+func (m *MockClient) PromoteGroupAdmin(ctx context.Context, groupId string, userId string) error {
+	m.record("PromoteGroupAdmin")
+	if m.PromoteGroupAdminFunc == nil {
+		panic("MockClient.PromoteGroupAdmin called without PromoteGroupAdminFunc")
+	}
+	return m.PromoteGroupAdminFunc(ctx, groupId, userId)
+}
+
+// DemoteGroupAdmin calls DemoteGroupAdminFunc.
+func (m *MockClient) DemoteGroupAdmin(ctx context.Context, groupId string, userId string) error {
+	m.record("DemoteGroupAdmin")
+	if m.DemoteGroupAdminFunc == nil {
+		panic("MockClient.DemoteGroupAdmin called without DemoteGroupAdminFunc")
+	}
+	return m.DemoteGroupAdminFunc(ctx, groupId, userId)
+}
+
+// DismissGroup calls DismissGroupFunc.
+func (m *MockClient) DismissGroup(ctx context.Context, groupId string) error {
+	m.record("DismissGroup")
+	if m.DismissGroupFunc == nil {
+		panic("MockClient.DismissGroup called without DismissGroupFunc")
+	}
+	return m.DismissGroupFunc(ctx, groupId)
+}
+
+// UpdateGroupInfo calls UpdateGroupInfoFunc.
+func (m *MockClient) UpdateGroupInfo(ctx context.Context, req *UpdateGroupInfoRequest) (*GroupInfo, error) {
+	m.record("UpdateGroupInfo")
+	if m.UpdateGroupInfoFunc == nil {
+		panic("MockClient.UpdateGroupInfo called without UpdateGroupInfoFunc")
+	}
+	return m.UpdateGroupInfoFunc(ctx, req)
+}
+
+// SetGroupAnnouncement calls SetGroupAnnouncementFunc.
+func (m *MockClient) SetGroupAnnouncement(ctx context.Context, groupId string, content string) (*GroupInfo, error) {
+	m.record("SetGroupAnnouncement")
+	if m.SetGroupAnnouncementFunc == nil {
+		panic("MockClient.SetGroupAnnouncement called without SetGroupAnnouncementFunc")
+	}
+	return m.SetGroupAnnouncementFunc(ctx, groupId, content)
+}
+
+// CreateGroupInviteLink calls CreateGroupInviteLinkFunc.
+func (m *MockClient) CreateGroupInviteLink(ctx context.Context, req *CreateInviteLinkRequest) (*GroupInviteLink, error) {
+	m.record("CreateGroupInviteLink")
+	if m.CreateGroupInviteLinkFunc == nil {
+		panic("MockClient.CreateGroupInviteLink called without CreateGroupInviteLinkFunc")
+	}
+	return m.CreateGroupInviteLinkFunc(ctx, req)
+}
+
+// RevokeGroupInviteLink calls RevokeGroupInviteLinkFunc.
+func (m *MockClient) RevokeGroupInviteLink(ctx context.Context, groupId string, token string) error {
+	m.record("RevokeGroupInviteLink")
+	if m.RevokeGroupInviteLinkFunc == nil {
+		panic("MockClient.RevokeGroupInviteLink called without RevokeGroupInviteLinkFunc")
+	}
+	return m.RevokeGroupInviteLinkFunc(ctx, groupId, token)
+}
+
+// JoinGroupByInviteLink calls JoinGroupByInviteLinkFunc.
+func (m *MockClient) JoinGroupByInviteLink(ctx context.Context, token string) (string, error) {
+	m.record("JoinGroupByInviteLink")
+	if m.JoinGroupByInviteLinkFunc == nil {
+		panic("MockClient.JoinGroupByInviteLink called without JoinGroupByInviteLinkFunc")
+	}
+	return m.JoinGroupByInviteLinkFunc(ctx, token)
+}
+
+// InternalCreateGroup calls InternalCreateGroupFunc.
+func (m *MockClient) InternalCreateGroup(ctx context.Context, req *CreateGroupRequest, opts ...RequestOption) (*GroupInfo, error) {
+	m.record("InternalCreateGroup")
+	if m.InternalCreateGroupFunc == nil {
+		panic("MockClient.InternalCreateGroup called without InternalCreateGroupFunc")
+	}
+	return m.InternalCreateGroupFunc(ctx, req, opts...)
+}
+
+// InternalJoinGroup calls InternalJoinGroupFunc.
+func (m *MockClient) InternalJoinGroup(ctx context.Context, groupId string, inviterId string, opts ...RequestOption) error {
+	m.record("InternalJoinGroup")
+	if m.InternalJoinGroupFunc == nil {
+		panic("MockClient.InternalJoinGroup called without InternalJoinGroupFunc")
+	}
+	return m.InternalJoinGroupFunc(ctx, groupId, inviterId, opts...)
+}
+
+// InternalQuitGroup calls InternalQuitGroupFunc.
+func (m *MockClient) InternalQuitGroup(ctx context.Context, groupId string, opts ...RequestOption) error {
+	m.record("InternalQuitGroup")
+	if m.InternalQuitGroupFunc == nil {
+		panic("MockClient.InternalQuitGroup called without InternalQuitGroupFunc")
+	}
+	return m.InternalQuitGroupFunc(ctx, groupId, opts...)
+}
+
+// InternalGetGroupInfo calls InternalGetGroupInfoFunc.
+func (m *MockClient) InternalGetGroupInfo(ctx context.Context, groupId string, opts ...RequestOption) (*GroupInfo, error) {
+	m.record("InternalGetGroupInfo")
+	if m.InternalGetGroupInfoFunc == nil {
+		panic("MockClient.InternalGetGroupInfo called without InternalGetGroupInfoFunc")
+	}
+	return m.InternalGetGroupInfoFunc(ctx, groupId, opts...)
+}
+
+// InternalGetGroupMembers calls InternalGetGroupMembersFunc.
+func (m *MockClient) InternalGetGroupMembers(ctx context.Context, groupId string, opts ...RequestOption) ([]*GroupMember, error) {
+	m.record("InternalGetGroupMembers")
+	if m.InternalGetGroupMembersFunc == nil {
+		panic("MockClient.InternalGetGroupMembers called without InternalGetGroupMembersFunc")
+	}
+	return m.InternalGetGroupMembersFunc(ctx, groupId, opts...)
+}
+
+// InternalKickGroupMembers calls InternalKickGroupMembersFunc.
+func (m *MockClient) InternalKickGroupMembers(ctx context.Context, groupId string, userIds []string, opts ...RequestOption) error {
+	m.record("InternalKickGroupMembers")
+	if m.InternalKickGroupMembersFunc == nil {
+		panic("MockClient.InternalKickGroupMembers called without InternalKickGroupMembersFunc")
+	}
+	return m.InternalKickGroupMembersFunc(ctx, groupId, userIds, opts...)
+}
+
+// InternalMuteGroupMember calls InternalMuteGroupMemberFunc.
+func (m *MockClient) InternalMuteGroupMember(ctx context.Context, groupId string, userId string, duration time.Duration, opts ...RequestOption) error {
+	m.record("InternalMuteGroupMember")
+	if m.InternalMuteGroupMemberFunc == nil {
+		panic("MockClient.InternalMuteGroupMember called without InternalMuteGroupMemberFunc")
+	}
+	return m.InternalMuteGroupMemberFunc(ctx, groupId, userId, duration, opts...)
+}
+
+// InternalTransferGroupOwnership calls InternalTransferGroupOwnershipFunc.
+func (m *MockClient) InternalTransferGroupOwnership(ctx context.Context, groupId string, newOwnerId string, opts ...RequestOption) error {
+	m.record("InternalTransferGroupOwnership")
+	if m.InternalTransferGroupOwnershipFunc == nil {
+		panic("MockClient.InternalTransferGroupOwnership called without InternalTransferGroupOwnershipFunc")
+	}
+	return m.InternalTransferGroupOwnershipFunc(ctx, groupId, newOwnerId, opts...)
+}
+
+// InternalPromoteGroupAdmin calls InternalPromoteGroupAdminFunc.
+func (m *MockClient) InternalPromoteGroupAdmin(ctx context.Context, groupId string, userId string, opts ...RequestOption) error {
+	m.record("InternalPromoteGroupAdmin")
+	if m.InternalPromoteGroupAdminFunc == nil {
+		panic("MockClient.InternalPromoteGroupAdmin called without InternalPromoteGroupAdminFunc")
+	}
+	return m.InternalPromoteGroupAdminFunc(ctx, groupId, userId, opts...)
+}
+
+// InternalDemoteGroupAdmin calls InternalDemoteGroupAdminFunc.
+func (m *MockClient) InternalDemoteGroupAdmin(ctx context.Context, groupId string, userId string, opts ...RequestOption) error {
+	m.record("InternalDemoteGroupAdmin")
+	if m.InternalDemoteGroupAdminFunc == nil {
+		panic("MockClient.InternalDemoteGroupAdmin called without InternalDemoteGroupAdminFunc")
+	}
+	return m.InternalDemoteGroupAdminFunc(ctx, groupId, userId, opts...)
+}
+
+// InternalDismissGroup calls InternalDismissGroupFunc.
+func (m *MockClient) InternalDismissGroup(ctx context.Context, groupId string, opts ...RequestOption) error {
+	m.record("InternalDismissGroup")
+	if m.InternalDismissGroupFunc == nil {
+		panic("MockClient.InternalDismissGroup called without InternalDismissGroupFunc")
+	}
+	return m.InternalDismissGroupFunc(ctx, groupId, opts...)
+}
+
+// InternalUpdateGroupInfo calls InternalUpdateGroupInfoFunc.
+func (m *MockClient) InternalUpdateGroupInfo(ctx context.Context, req *UpdateGroupInfoRequest, opts ...RequestOption) (*GroupInfo, error) {
+	m.record("InternalUpdateGroupInfo")
+	if m.InternalUpdateGroupInfoFunc == nil {
+		panic("MockClient.InternalUpdateGroupInfo called without InternalUpdateGroupInfoFunc")
+	}
+	return m.InternalUpdateGroupInfoFunc(ctx, req, opts...)
+}
+
+// InternalSetGroupAnnouncement calls InternalSetGroupAnnouncementFunc.
+func (m *MockClient) InternalSetGroupAnnouncement(ctx context.Context, groupId string, content string, opts ...RequestOption) (*GroupInfo, error) {
+	m.record("InternalSetGroupAnnouncement")
+	if m.InternalSetGroupAnnouncementFunc == nil {
+		panic("MockClient.InternalSetGroupAnnouncement called without InternalSetGroupAnnouncementFunc")
+	}
+	return m.InternalSetGroupAnnouncementFunc(ctx, groupId, content, opts...)
+}
+
+// InternalCreateGroupInviteLink calls InternalCreateGroupInviteLinkFunc.
+func (m *MockClient) InternalCreateGroupInviteLink(ctx context.Context, req *CreateInviteLinkRequest, opts ...RequestOption) (*GroupInviteLink, error) {
+	m.record("InternalCreateGroupInviteLink")
+	if m.InternalCreateGroupInviteLinkFunc == nil {
+		panic("MockClient.InternalCreateGroupInviteLink called without InternalCreateGroupInviteLinkFunc")
+	}
+	return m.InternalCreateGroupInviteLinkFunc(ctx, req, opts...)
+}
+
+// InternalRevokeGroupInviteLink calls InternalRevokeGroupInviteLinkFunc.
+func (m *MockClient) InternalRevokeGroupInviteLink(ctx context.Context, groupId string, token string, opts ...RequestOption) error {
+	m.record("InternalRevokeGroupInviteLink")
+	if m.InternalRevokeGroupInviteLinkFunc == nil {
+		panic("MockClient.InternalRevokeGroupInviteLink called without InternalRevokeGroupInviteLinkFunc")
+	}
+	return m.InternalRevokeGroupInviteLinkFunc(ctx, groupId, token, opts...)
+}
+
+// InternalJoinGroupByInviteLink calls InternalJoinGroupByInviteLinkFunc.
+func (m *MockClient) InternalJoinGroupByInviteLink(ctx context.Context, token string, opts ...RequestOption) (string, error) {
+	m.record("InternalJoinGroupByInviteLink")
+	if m.InternalJoinGroupByInviteLinkFunc == nil {
+		panic("MockClient.InternalJoinGroupByInviteLink called without InternalJoinGroupByInviteLinkFunc")
+	}
+	return m.InternalJoinGroupByInviteLinkFunc(ctx, token, opts...)
+}
+
+// SendMessage calls SendMessageFunc.
+func (m *MockClient) SendMessage(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error) {
+	m.record("SendMessage")
+	if m.SendMessageFunc == nil {
+		panic("MockClient.SendMessage called without SendMessageFunc")
+	}
+	return m.SendMessageFunc(ctx, req)
+}
+
+// InternalSendMessage calls InternalSendMessageFunc.
+func (m *MockClient) InternalSendMessage(ctx context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error) {
+	m.record("InternalSendMessage")
+	if m.InternalSendMessageFunc == nil {
+		panic("MockClient.InternalSendMessage called without InternalSendMessageFunc")
+	}
+	return m.InternalSendMessageFunc(ctx, req, opts...)
+}
+
+// SendMessageWithoutMarkRead calls SendMessageWithoutMarkReadFunc.
+func (m *MockClient) SendMessageWithoutMarkRead(ctx context.Context, req *SendMessageRequest) (*MessageInfo, error) {
+	m.record("SendMessageWithoutMarkRead")
+	if m.SendMessageWithoutMarkReadFunc == nil {
+		panic("MockClient.SendMessageWithoutMarkRead called without SendMessageWithoutMarkReadFunc")
+	}
+	return m.SendMessageWithoutMarkReadFunc(ctx, req)
+}
+
+// InternalSendMessageWithoutMarkRead calls InternalSendMessageWithoutMarkReadFunc.
+func (m *MockClient) InternalSendMessageWithoutMarkRead(ctx context.Context, req *SendMessageRequest, opts ...RequestOption) (*MessageInfo, error) {
+	m.record("InternalSendMessageWithoutMarkRead")
+	if m.InternalSendMessageWithoutMarkReadFunc == nil {
+		panic("MockClient.InternalSendMessageWithoutMarkRead called without InternalSendMessageWithoutMarkReadFunc")
+	}
+	return m.InternalSendMessageWithoutMarkReadFunc(ctx, req, opts...)
+}
+
+// SendMessagesBatch calls SendMessagesBatchFunc.
+func (m *MockClient) SendMessagesBatch(ctx context.Context, req *BatchSendMessageRequest) ([]*BatchSendResult, error) {
+	m.record("SendMessagesBatch")
+	if m.SendMessagesBatchFunc == nil {
+		panic("MockClient.SendMessagesBatch called without SendMessagesBatchFunc")
+	}
+	return m.SendMessagesBatchFunc(ctx, req)
+}
+
+// InternalSendMessagesBatch calls InternalSendMessagesBatchFunc.
+func (m *MockClient) InternalSendMessagesBatch(ctx context.Context, req *BatchSendMessageRequest, opts ...RequestOption) ([]*BatchSendResult, error) {
+	m.record("InternalSendMessagesBatch")
+	if m.InternalSendMessagesBatchFunc == nil {
+		panic("MockClient.InternalSendMessagesBatch called without InternalSendMessagesBatchFunc")
+	}
+	return m.InternalSendMessagesBatchFunc(ctx, req, opts...)
+}
+
+// ForwardMessages calls ForwardMessagesFunc.
+func (m *MockClient) ForwardMessages(ctx context.Context, req *ForwardMessagesRequest) ([]*ForwardResult, error) {
+	m.record("ForwardMessages")
+	if m.ForwardMessagesFunc == nil {
+		panic("MockClient.ForwardMessages called without ForwardMessagesFunc")
+	}
+	return m.ForwardMessagesFunc(ctx, req)
+}
+
+// SendTextMessage calls SendTextMessageFunc.
+func (m *MockClient) SendTextMessage(ctx context.Context, clientMsgId string, recvId string, text string) (*MessageInfo, error) {
+	m.record("SendTextMessage")
+	if m.SendTextMessageFunc == nil {
+		panic("MockClient.SendTextMessage called without SendTextMessageFunc")
+	}
+	return m.SendTextMessageFunc(ctx, clientMsgId, recvId, text)
+}
+
+// SendGroupTextMessage calls SendGroupTextMessageFunc.
+func (m *MockClient) SendGroupTextMessage(ctx context.Context, clientMsgId string, groupId string, text string) (*MessageInfo, error) {
+	m.record("SendGroupTextMessage")
+	if m.SendGroupTextMessageFunc == nil {
+		panic("MockClient.SendGroupTextMessage called without SendGroupTextMessageFunc")
+	}
+	return m.SendGroupTextMessageFunc(ctx, clientMsgId, groupId, text)
+}
+
+// SendTextMessageWithoutMarkRead calls SendTextMessageWithoutMarkReadFunc.
+func (m *MockClient) SendTextMessageWithoutMarkRead(ctx context.Context, clientMsgId string, recvId string, text string) (*MessageInfo, error) {
+	m.record("SendTextMessageWithoutMarkRead")
+	if m.SendTextMessageWithoutMarkReadFunc == nil {
+		panic("MockClient.SendTextMessageWithoutMarkRead called without SendTextMessageWithoutMarkReadFunc")
+	}
+	return m.SendTextMessageWithoutMarkReadFunc(ctx, clientMsgId, recvId, text)
+}
+
+// SendGroupTextMessageWithoutMarkRead calls SendGroupTextMessageWithoutMarkReadFunc.
+func (m *MockClient) SendGroupTextMessageWithoutMarkRead(ctx context.Context, clientMsgId string, groupId string, text string) (*MessageInfo, error) {
+	m.record("SendGroupTextMessageWithoutMarkRead")
+	if m.SendGroupTextMessageWithoutMarkReadFunc == nil {
+		panic("MockClient.SendGroupTextMessageWithoutMarkRead called without SendGroupTextMessageWithoutMarkReadFunc")
+	}
+	return m.SendGroupTextMessageWithoutMarkReadFunc(ctx, clientMsgId, groupId, text)
+}
+
+// PullMessages calls PullMessagesFunc.
+func (m *MockClient) PullMessages(ctx context.Context, conversationId string, beginSeq int64, endSeq int64, limit int) (*PullMessagesResponse, error) {
+	m.record("PullMessages")
+	if m.PullMessagesFunc == nil {
+		panic("MockClient.PullMessages called without PullMessagesFunc")
+	}
+	return m.PullMessagesFunc(ctx, conversationId, beginSeq, endSeq, limit)
+}
+
+// GetMaxSeq calls GetMaxSeqFunc.
+func (m *MockClient) GetMaxSeq(ctx context.Context, conversationId string) (int64, error) {
+	m.record("GetMaxSeq")
+	if m.GetMaxSeqFunc == nil {
+		panic("MockClient.GetMaxSeq called without GetMaxSeqFunc")
+	}
+	return m.GetMaxSeqFunc(ctx, conversationId)
+}
+
+// CreateReminder calls CreateReminderFunc.
+func (m *MockClient) CreateReminder(ctx context.Context, req *CreateReminderRequest) (*MessageReminder, error) {
+	m.record("CreateReminder")
+	if m.CreateReminderFunc == nil {
+		panic("MockClient.CreateReminder called without CreateReminderFunc")
+	}
+	return m.CreateReminderFunc(ctx, req)
+}
+
+// ListReminders calls ListRemindersFunc.
+func (m *MockClient) ListReminders(ctx context.Context) ([]*MessageReminder, error) {
+	m.record("ListReminders")
+	if m.ListRemindersFunc == nil {
+		panic("MockClient.ListReminders called without ListRemindersFunc")
+	}
+	return m.ListRemindersFunc(ctx)
+}
+
+// CancelReminder calls CancelReminderFunc.
+func (m *MockClient) CancelReminder(ctx context.Context, reminderId int64) error {
+	m.record("CancelReminder")
+	if m.CancelReminderFunc == nil {
+		panic("MockClient.CancelReminder called without CancelReminderFunc")
+	}
+	return m.CancelReminderFunc(ctx, reminderId)
+}
+
+// AddFavorite calls AddFavoriteFunc.
+func (m *MockClient) AddFavorite(ctx context.Context, conversationId string, seq int64) (*MessageFavorite, error) {
+	m.record("AddFavorite")
+	if m.AddFavoriteFunc == nil {
+		panic("MockClient.AddFavorite called without AddFavoriteFunc")
+	}
+	return m.AddFavoriteFunc(ctx, conversationId, seq)
+}
+
+// ListFavorites calls ListFavoritesFunc.
+func (m *MockClient) ListFavorites(ctx context.Context, cursor int64, limit int) (*FavoriteListPage, error) {
+	m.record("ListFavorites")
+	if m.ListFavoritesFunc == nil {
+		panic("MockClient.ListFavorites called without ListFavoritesFunc")
+	}
+	return m.ListFavoritesFunc(ctx, cursor, limit)
+}
+
+// RemoveFavorite calls RemoveFavoriteFunc.
+func (m *MockClient) RemoveFavorite(ctx context.Context, favoriteId int64) error {
+	m.record("RemoveFavorite")
+	if m.RemoveFavoriteFunc == nil {
+		panic("MockClient.RemoveFavorite called without RemoveFavoriteFunc")
+	}
+	return m.RemoveFavoriteFunc(ctx, favoriteId)
+}
+
+// RevokeMessage calls RevokeMessageFunc.
+func (m *MockClient) RevokeMessage(ctx context.Context, conversationId string, seq int64) (*MessageInfo, error) {
+	m.record("RevokeMessage")
+	if m.RevokeMessageFunc == nil {
+		panic("MockClient.RevokeMessage called without RevokeMessageFunc")
+	}
+	return m.RevokeMessageFunc(ctx, conversationId, seq)
+}
+
+// DeleteMessage calls DeleteMessageFunc.
+func (m *MockClient) DeleteMessage(ctx context.Context, conversationId string, seq int64, scope string) error {
+	m.record("DeleteMessage")
+	if m.DeleteMessageFunc == nil {
+		panic("MockClient.DeleteMessage called without DeleteMessageFunc")
+	}
+	return m.DeleteMessageFunc(ctx, conversationId, seq, scope)
+}
+
+// EditMessage calls EditMessageFunc.
+func (m *MockClient) EditMessage(ctx context.Context, req *EditMessageRequest) (*MessageInfo, error) {
+	m.record("EditMessage")
+	if m.EditMessageFunc == nil {
+		panic("MockClient.EditMessage called without EditMessageFunc")
+	}
+	return m.EditMessageFunc(ctx, req)
+}
+
+// GetEditHistory calls GetEditHistoryFunc.
+func (m *MockClient) GetEditHistory(ctx context.Context, conversationId string, seq int64) ([]*MessageEdit, error) {
+	m.record("GetEditHistory")
+	if m.GetEditHistoryFunc == nil {
+		panic("MockClient.GetEditHistory called without GetEditHistoryFunc")
+	}
+	return m.GetEditHistoryFunc(ctx, conversationId, seq)
+}
+
+// GetReadStatus calls GetReadStatusFunc.
+func (m *MockClient) GetReadStatus(ctx context.Context, conversationId string, seq int64) (*ReadStatus, error) {
+	m.record("GetReadStatus")
+	if m.GetReadStatusFunc == nil {
+		panic("MockClient.GetReadStatus called without GetReadStatusFunc")
+	}
+	return m.GetReadStatusFunc(ctx, conversationId, seq)
+}
+
+// GetGroupReadMembers calls GetGroupReadMembersFunc.
+func (m *MockClient) GetGroupReadMembers(ctx context.Context, conversationId string, seq int64) (*GroupReadMembers, error) {
+	m.record("GetGroupReadMembers")
+	if m.GetGroupReadMembersFunc == nil {
+		panic("MockClient.GetGroupReadMembers called without GetGroupReadMembersFunc")
+	}
+	return m.GetGroupReadMembersFunc(ctx, conversationId, seq)
+}
+
+// ListThread calls ListThreadFunc.
+func (m *MockClient) ListThread(ctx context.Context, conversationId string, seq int64, cursor int64, limit int) (*ThreadPage, error) {
+	m.record("ListThread")
+	if m.ListThreadFunc == nil {
+		panic("MockClient.ListThread called without ListThreadFunc")
+	}
+	return m.ListThreadFunc(ctx, conversationId, seq, cursor, limit)
+}
+
+// AddReaction calls AddReactionFunc.
+func (m *MockClient) AddReaction(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	m.record("AddReaction")
+	if m.AddReactionFunc == nil {
+		panic("MockClient.AddReaction called without AddReactionFunc")
+	}
+	return m.AddReactionFunc(ctx, conversationId, seq, emoji)
+}
+
+// RemoveReaction calls RemoveReactionFunc.
+func (m *MockClient) RemoveReaction(ctx context.Context, conversationId string, seq int64, emoji string) (*ReactionChange, error) {
+	m.record("RemoveReaction")
+	if m.RemoveReactionFunc == nil {
+		panic("MockClient.RemoveReaction called without RemoveReactionFunc")
+	}
+	return m.RemoveReactionFunc(ctx, conversationId, seq, emoji)
+}
+
+// VotePoll calls VotePollFunc.
+func (m *MockClient) VotePoll(ctx context.Context, req *VotePollRequest) (*PollResult, error) {
+	m.record("VotePoll")
+	if m.VotePollFunc == nil {
+		panic("MockClient.VotePoll called without VotePollFunc")
+	}
+	return m.VotePollFunc(ctx, req)
+}
+
+// GetPollResult calls GetPollResultFunc.
+func (m *MockClient) GetPollResult(ctx context.Context, conversationId string, seq int64) (*PollResult, error) {
+	m.record("GetPollResult")
+	if m.GetPollResultFunc == nil {
+		panic("MockClient.GetPollResult called without GetPollResultFunc")
+	}
+	return m.GetPollResultFunc(ctx, conversationId, seq)
+}
+
+// ExportMessages calls ExportMessagesFunc.
+func (m *MockClient) ExportMessages(ctx context.Context, conversationId string) (*MessageIterator, error) {
+	m.record("ExportMessages")
+	if m.ExportMessagesFunc == nil {
+		panic("MockClient.ExportMessages called without ExportMessagesFunc")
+	}
+	return m.ExportMessagesFunc(ctx, conversationId)
+}
+
+// InternalExportMessages calls InternalExportMessagesFunc.
+func (m *MockClient) InternalExportMessages(ctx context.Context, conversationId string, opts ...RequestOption) (*MessageIterator, error) {
+	m.record("InternalExportMessages")
+	if m.InternalExportMessagesFunc == nil {
+		panic("MockClient.InternalExportMessages called without InternalExportMessagesFunc")
+	}
+	return m.InternalExportMessagesFunc(ctx, conversationId, opts...)
+}
+
+// GetAllConversationList calls GetAllConversationListFunc.
+func (m *MockClient) GetAllConversationList(ctx context.Context) ([]*ConversationInfo, error) {
+	m.record("GetAllConversationList")
+	if m.GetAllConversationListFunc == nil {
+		panic("MockClient.GetAllConversationList called without GetAllConversationListFunc")
+	}
+	return m.GetAllConversationListFunc(ctx)
+}
+
+// GetAllConversationListWithLastMessage calls GetAllConversationListWithLastMessageFunc.
+func (m *MockClient) GetAllConversationListWithLastMessage(ctx context.Context, withLastMessage bool) ([]*ConversationInfo, error) {
+	m.record("GetAllConversationListWithLastMessage")
+	if m.GetAllConversationListWithLastMessageFunc == nil {
+		panic("MockClient.GetAllConversationListWithLastMessage called without GetAllConversationListWithLastMessageFunc")
+	}
+	return m.GetAllConversationListWithLastMessageFunc(ctx, withLastMessage)
+}
+
+// GetConversationList calls GetConversationListFunc.
+func (m *MockClient) GetConversationList(ctx context.Context, limit int, cursor *ConversationListCursor) (*ConversationListPage, error) {
+	m.record("GetConversationList")
+	if m.GetConversationListFunc == nil {
+		panic("MockClient.GetConversationList called without GetConversationListFunc")
+	}
+	return m.GetConversationListFunc(ctx, limit, cursor)
+}
+
+// GetConversationListWithLastMessage calls GetConversationListWithLastMessageFunc.
+func (m *MockClient) GetConversationListWithLastMessage(ctx context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor) (*ConversationListPage, error) {
+	m.record("GetConversationListWithLastMessage")
+	if m.GetConversationListWithLastMessageFunc == nil {
+		panic("MockClient.GetConversationListWithLastMessage called without GetConversationListWithLastMessageFunc")
+	}
+	return m.GetConversationListWithLastMessageFunc(ctx, withLastMessage, limit, cursor)
+}
+
+// InternalGetAllConversationList calls InternalGetAllConversationListFunc.
+func (m *MockClient) InternalGetAllConversationList(ctx context.Context, opts ...RequestOption) ([]*ConversationInfo, error) {
+	m.record("InternalGetAllConversationList")
+	if m.InternalGetAllConversationListFunc == nil {
+		panic("MockClient.InternalGetAllConversationList called without InternalGetAllConversationListFunc")
+	}
+	return m.InternalGetAllConversationListFunc(ctx, opts...)
+}
+
+// InternalGetAllConversationListWithLastMessage calls InternalGetAllConversationListWithLastMessageFunc.
+func (m *MockClient) InternalGetAllConversationListWithLastMessage(ctx context.Context, withLastMessage bool, opts ...RequestOption) ([]*ConversationInfo, error) {
+	m.record("InternalGetAllConversationListWithLastMessage")
+	if m.InternalGetAllConversationListWithLastMessageFunc == nil {
+		panic("MockClient.InternalGetAllConversationListWithLastMessage called without InternalGetAllConversationListWithLastMessageFunc")
+	}
+	return m.InternalGetAllConversationListWithLastMessageFunc(ctx, withLastMessage, opts...)
+}
+
+// InternalGetConversationList calls InternalGetConversationListFunc.
+func (m *MockClient) InternalGetConversationList(ctx context.Context, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error) {
+	m.record("InternalGetConversationList")
+	if m.InternalGetConversationListFunc == nil {
+		panic("MockClient.InternalGetConversationList called without InternalGetConversationListFunc")
+	}
+	return m.InternalGetConversationListFunc(ctx, limit, cursor, opts...)
+}
+
+// InternalGetConversationListWithLastMessage calls InternalGetConversationListWithLastMessageFunc.
+func (m *MockClient) InternalGetConversationListWithLastMessage(ctx context.Context, withLastMessage bool, limit int, cursor *ConversationListCursor, opts ...RequestOption) (*ConversationListPage, error) {
+	m.record("InternalGetConversationListWithLastMessage")
+	if m.InternalGetConversationListWithLastMessageFunc == nil {
+		panic("MockClient.InternalGetConversationListWithLastMessage called without InternalGetConversationListWithLastMessageFunc")
+	}
+	return m.InternalGetConversationListWithLastMessageFunc(ctx, withLastMessage, limit, cursor, opts...)
+}
+
+// GetConversation calls GetConversationFunc.
+func (m *MockClient) GetConversation(ctx context.Context, conversationId string) (*ConversationInfo, error) {
+	m.record("GetConversation")
+	if m.GetConversationFunc == nil {
+		panic("MockClient.GetConversation called without GetConversationFunc")
+	}
+	return m.GetConversationFunc(ctx, conversationId)
+}
+
+// GetSavedConversation calls GetSavedConversationFunc.
+func (m *MockClient) GetSavedConversation(ctx context.Context) (*ConversationInfo, error) {
+	m.record("GetSavedConversation")
+	if m.GetSavedConversationFunc == nil {
+		panic("MockClient.GetSavedConversation called without GetSavedConversationFunc")
+	}
+	return m.GetSavedConversationFunc(ctx)
+}
+
+// UpdateConversation calls UpdateConversationFunc.
+func (m *MockClient) UpdateConversation(ctx context.Context, conversationId string, req *UpdateConversationRequest) error {
+	m.record("UpdateConversation")
+	if m.UpdateConversationFunc == nil {
+		panic("MockClient.UpdateConversation called without UpdateConversationFunc")
+	}
+	return m.UpdateConversationFunc(ctx, conversationId, req)
+}
+
+// SetConversationPinned calls SetConversationPinnedFunc.
+func (m *MockClient) SetConversationPinned(ctx context.Context, conversationId string, isPinned bool) error {
+	m.record("SetConversationPinned")
+	if m.SetConversationPinnedFunc == nil {
+		panic("MockClient.SetConversationPinned called without SetConversationPinnedFunc")
+	}
+	return m.SetConversationPinnedFunc(ctx, conversationId, isPinned)
+}
+
+// SetConversationRecvMsgOpt calls SetConversationRecvMsgOptFunc.
+func (m *MockClient) SetConversationRecvMsgOpt(ctx context.Context, conversationId string, recvMsgOpt int32) error {
+	m.record("SetConversationRecvMsgOpt")
+	if m.SetConversationRecvMsgOptFunc == nil {
+		panic("MockClient.SetConversationRecvMsgOpt called without SetConversationRecvMsgOptFunc")
+	}
+	return m.SetConversationRecvMsgOptFunc(ctx, conversationId, recvMsgOpt)
+}
+
+// SetConversationTTL calls SetConversationTTLFunc.
+func (m *MockClient) SetConversationTTL(ctx context.Context, conversationId string, ttlSeconds int32) error {
+	m.record("SetConversationTTL")
+	if m.SetConversationTTLFunc == nil {
+		panic("MockClient.SetConversationTTL called without SetConversationTTLFunc")
+	}
+	return m.SetConversationTTLFunc(ctx, conversationId, ttlSeconds)
+}
+
+// PinMessage calls PinMessageFunc.
+func (m *MockClient) PinMessage(ctx context.Context, conversationId string, seq int64) (*PinChange, error) {
+	m.record("PinMessage")
+	if m.PinMessageFunc == nil {
+		panic("MockClient.PinMessage called without PinMessageFunc")
+	}
+	return m.PinMessageFunc(ctx, conversationId, seq)
+}
+
+// UnpinMessage calls UnpinMessageFunc.
+func (m *MockClient) UnpinMessage(ctx context.Context, conversationId string, seq int64) (*PinChange, error) {
+	m.record("UnpinMessage")
+	if m.UnpinMessageFunc == nil {
+		panic("MockClient.UnpinMessage called without UnpinMessageFunc")
+	}
+	return m.UnpinMessageFunc(ctx, conversationId, seq)
+}
+
+// GetPinnedMessages calls GetPinnedMessagesFunc.
+func (m *MockClient) GetPinnedMessages(ctx context.Context, conversationId string) ([]*PinnedMessage, error) {
+	m.record("GetPinnedMessages")
+	if m.GetPinnedMessagesFunc == nil {
+		panic("MockClient.GetPinnedMessages called without GetPinnedMessagesFunc")
+	}
+	return m.GetPinnedMessagesFunc(ctx, conversationId)
+}
+
+// MarkRead calls MarkReadFunc.
+func (m *MockClient) MarkRead(ctx context.Context, conversationId string, readSeq int64) error {
+	m.record("MarkRead")
+	if m.MarkReadFunc == nil {
+		panic("MockClient.MarkRead called without MarkReadFunc")
+	}
+	return m.MarkReadFunc(ctx, conversationId, readSeq)
+}
+
+// GetMaxReadSeq calls GetMaxReadSeqFunc.
+func (m *MockClient) GetMaxReadSeq(ctx context.Context, conversationId string) (*MaxReadSeqResponse, error) {
+	m.record("GetMaxReadSeq")
+	if m.GetMaxReadSeqFunc == nil {
+		panic("MockClient.GetMaxReadSeq called without GetMaxReadSeqFunc")
+	}
+	return m.GetMaxReadSeqFunc(ctx, conversationId)
+}
+
+// GetUnreadCount calls GetUnreadCountFunc.
+func (m *MockClient) GetUnreadCount(ctx context.Context, conversationId string, readSeq int64) (int64, error) {
+	m.record("GetUnreadCount")
+	if m.GetUnreadCountFunc == nil {
+		panic("MockClient.GetUnreadCount called without GetUnreadCountFunc")
+	}
+	return m.GetUnreadCountFunc(ctx, conversationId, readSeq)
+}
+
+func (m *MockClient) record(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	m.calls[name]++
+}
+
+// Calls returns how many times the named method was called.
+func (m *MockClient) Calls(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[name]
+}
@@ -0,0 +1,191 @@
+package sdk
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+const defaultPoolMaxUsers = 10000
+
+// PoolLoginFunc logs userId in on a fresh client of the pool, for example with Login and
+// a password from the backend's store, or UseExternalToken with a token it issued.
+// The tokens it leaves on the client are cached and refreshed by the pool.
+type PoolLoginFunc func(ctx context.Context, c *Client, userId string) error
+
+// ClientPoolConfig configures a ClientPool
+type ClientPoolConfig struct {
+	// Login logs users in. When nil the base client must use internal auth and the
+	// pool's clients act as their user on internal routes instead.
+	Login PoolLoginFunc
+	// PlatformId is the acting platform of internal requests, default PlatformIdWeb
+	PlatformId int
+	// MaxUsers caps the cached clients, the least recently used are dropped, default 10000
+	MaxUsers int
+}
+
+// ClientPool hands out clients working on behalf of individual users, for backends acting
+// for many users. Clients are created on first use and cached; they share the base
+// client's connection pool, circuit breaker, hedging and timeouts.
+//
+// With a Login func each user is logged in once and the client refreshes its tokens on
+// expiry. Without one, the clients send every internal request as their user, so Internal*
+// methods need no WithActAsUser option.
+type ClientPool struct {
+	base *Client
+	cfg  ClientPoolConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is the most recently used
+}
+
+type poolEntry struct {
+	userId string
+	client *Client
+	ready  chan struct{} // closed once login finished
+	err    error
+}
+
+// NewClientPool creates a pool of clients configured like base
+func NewClientPool(base *Client, cfg ClientPoolConfig) (*ClientPool, error) {
+	if base == nil {
+		return nil, errors.New("client pool needs a base client")
+	}
+	if cfg.Login == nil && base.internal == nil {
+		return nil, errors.New("client pool needs a login func or a base client with internal auth")
+	}
+	if cfg.PlatformId <= 0 {
+		cfg.PlatformId = PlatformIdWeb
+	}
+	if cfg.MaxUsers <= 0 {
+		cfg.MaxUsers = defaultPoolMaxUsers
+	}
+	return &ClientPool{
+		base:    base,
+		cfg:     cfg,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}, nil
+}
+
+// Client returns the client of userId, logging the user in on first use. Concurrent
+// callers for the same user share one login; a failed login is retried on the next call.
+func (p *ClientPool) Client(ctx context.Context, userId string) (*Client, error) {
+	userId = strings.TrimSpace(userId)
+	if userId == "" {
+		return nil, ErrInvalidParam
+	}
+
+	p.mu.Lock()
+	if elem, ok := p.entries[userId]; ok {
+		p.lru.MoveToFront(elem)
+		entry := elem.Value.(*poolEntry)
+		p.mu.Unlock()
+		return p.wait(ctx, entry)
+	}
+	entry := &poolEntry{userId: userId, client: p.newClient(userId), ready: make(chan struct{})}
+	p.entries[userId] = p.lru.PushFront(entry)
+	p.evictLocked()
+	p.mu.Unlock()
+
+	if p.cfg.Login != nil {
+		entry.err = p.cfg.Login(ctx, entry.client, userId)
+	}
+	if entry.err != nil {
+		p.remove(entry)
+	}
+	close(entry.ready)
+	return p.wait(ctx, entry)
+}
+
+// Do runs fn with the client of userId. When the user's tokens are rejected even after the
+// client's own refresh, e.g. because the refresh token expired too, the user is logged in
+// again and fn runs once more.
+func (p *ClientPool) Do(ctx context.Context, userId string, fn func(c *Client) error) error {
+	c, err := p.Client(ctx, userId)
+	if err != nil {
+		return err
+	}
+	err = fn(c)
+	if p.cfg.Login == nil || !isTokenRejected(err) {
+		return err
+	}
+
+	p.invalidate(userId, c)
+	if c, err = p.Client(ctx, userId); err != nil {
+		return err
+	}
+	return fn(c)
+}
+
+// Invalidate drops the cached client of userId, the next call logs the user in again
+func (p *ClientPool) Invalidate(userId string) {
+	p.invalidate(userId, nil)
+}
+
+// Len returns the number of cached clients
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
+
+func (p *ClientPool) newClient(userId string) *Client {
+	c := p.base.clone()
+	// Pool clients authenticate as their user, never as the base client's bot
+	c.apiKey = ""
+	if p.cfg.Login == nil {
+		c.actAs = &actAsUserConfig{userId: userId, platformId: p.cfg.PlatformId}
+	}
+	return c
+}
+
+func (p *ClientPool) wait(ctx context.Context, entry *poolEntry) (*Client, error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	select {
+	case <-entry.ready:
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return entry.client, nil
+	case <-done:
+		return nil, ctx.Err()
+	}
+}
+
+// invalidate drops the entry of userId; with c set, only while it still holds c so a
+// client logged in again by a concurrent Do is kept
+func (p *ClientPool) invalidate(userId string, c *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elem, ok := p.entries[userId]
+	if !ok || (c != nil && elem.Value.(*poolEntry).client != c) {
+		return
+	}
+	p.lru.Remove(elem)
+	delete(p.entries, userId)
+}
+
+func (p *ClientPool) remove(entry *poolEntry) {
+	p.invalidate(entry.userId, entry.client)
+}
+
+func (p *ClientPool) evictLocked() {
+	for p.lru.Len() > p.cfg.MaxUsers {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.entries, oldest.Value.(*poolEntry).userId)
+	}
+}
+
+// isTokenRejected reports whether the server rejected the access token
+func isTokenRejected(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && (apiErr.Code == CodeTokenInvalid || apiErr.Code == CodeTokenExpired)
+}
@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPoolLogsInOncePerUserAndRelogsOnRejectedTokens(t *testing.T) {
+	var logins atomic.Int32
+	var expired sync.Map // tokens the server rejects
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/im/auth/login":
+			var req LoginRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			n := logins.Add(1)
+			_, _ = io.WriteString(w, `{"code":0,"data":{"token":"`+req.UserId+`-`+strconv.Itoa(int(n))+`","refresh_token":"r"}}`)
+		case "/im/auth/refresh":
+			_, _ = io.WriteString(w, `{"code":2001,"message":"refresh token invalid"}`)
+		case "/im/user/info":
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if _, ok := expired.Load(token); ok {
+				_, _ = io.WriteString(w, `{"code":2002,"message":"token expired"}`)
+				return
+			}
+			userId, _, _ := strings.Cut(token, "-")
+			_, _ = io.WriteString(w, `{"code":0,"data":{"id":"`+userId+`"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	pool, err := NewClientPool(MustNewClient(srv.URL), ClientPoolConfig{
+		Login: func(ctx context.Context, c *Client, userId string) error {
+			_, err := c.LoginWithUserId(ctx, userId, "secret", PlatformIdWeb)
+			return err
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := pool.Client(ctx, "alice")
+			require.NoError(t, err)
+			info, err := c.GetUserInfo(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "alice", info.Id)
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 1, logins.Load())
+
+	// The access token expires and the refresh fails, Do logs the user in again
+	c, err := pool.Client(ctx, "alice")
+	require.NoError(t, err)
+	expired.Store(c.GetToken(), true)
+	var calls int
+	err = pool.Do(ctx, "alice", func(c *Client) error {
+		calls++
+		_, err := c.GetUserInfo(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.EqualValues(t, 2, logins.Load())
+}
+
+func TestClientPoolActsAsUsersOnInternalRoutes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/im/internal/user/info", r.URL.Path)
+		require.Equal(t, "svc", r.Header.Get("X-Service-Name"))
+		require.Equal(t, "3", r.Header.Get("X-Platform-Id"))
+		_, _ = io.WriteString(w, `{"code":0,"data":{"id":"`+r.Header.Get("X-User-Id")+`"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := NewClientPool(MustNewClient(srv.URL), ClientPoolConfig{})
+	require.Error(t, err)
+
+	pool, err := NewClientPool(MustNewInternalClient(srv.URL, "svc", "s3cret"), ClientPoolConfig{PlatformId: 3, MaxUsers: 2})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, id := range []string{"u1", "u2", "u3"} {
+		c, err := pool.Client(ctx, id)
+		require.NoError(t, err)
+		info, err := c.InternalGetUserInfo(ctx)
+		require.NoError(t, err)
+		require.Equal(t, id, info.Id)
+	}
+	require.Equal(t, 2, pool.Len())
+
+	// An explicit acting user still wins over the pool's
+	c, err := pool.Client(ctx, "u3")
+	require.NoError(t, err)
+	info, err := c.InternalGetUserInfo(ctx, WithActAsUser("u9", 3))
+	require.NoError(t, err)
+	require.Equal(t, "u9", info.Id)
+}
@@ -0,0 +1,237 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultHedgingDelay            = 200 * time.Millisecond
+	defaultHedgingMaxAttempts      = 2
+)
+
+// ErrCircuitOpen is returned without contacting the server while the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the client's circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // requests pass
+	CircuitOpen                         // requests fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // trial requests probe whether the server recovered
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker. Transport errors and 5xx responses
+// count as failures; API errors do not, the server answered them.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // consecutive failures opening the circuit, default 5
+	OpenTimeout      time.Duration // time the circuit stays open before trial requests, default 30s
+	HalfOpenRequests int           // trial requests allowed while half-open, default 1
+	OnStateChange    func(from, to CircuitState)
+}
+
+// HedgingConfig configures request hedging: when a GET request has not been answered
+// after Delay, another copy is sent and the first usable response wins. Other methods
+// are never hedged since repeating them is not safe.
+type HedgingConfig struct {
+	Delay       time.Duration // wait before sending the next copy, default 200ms
+	MaxAttempts int           // copies in flight at most including the original, default 2
+	OnHedge     func(path string, attempt int)
+}
+
+// WithCircuitBreaker makes requests fail fast with ErrCircuitOpen after repeated upstream failures
+func WithCircuitBreaker(cfg CircuitBreakerConfig) ClientOption {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(cfg)
+	}
+}
+
+// WithHedging sends extra copies of slow GET requests to cut tail latency
+func WithHedging(cfg HedgingConfig) ClientOption {
+	return func(c *Client) {
+		if cfg.Delay <= 0 {
+			cfg.Delay = defaultHedgingDelay
+		}
+		if cfg.MaxAttempts <= 1 {
+			cfg.MaxAttempts = defaultHedgingMaxAttempts
+		}
+		c.hedging = &cfg
+	}
+}
+
+// roundTrip sends a request through the circuit breaker and hedging when they are enabled
+func (c *Client) roundTrip(ctx context.Context, req *protocol.Request, resp *protocol.Response, path string) error {
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if c.hedging != nil && string(req.Header.Method()) == consts.MethodGet {
+		err = c.hedgedDo(ctx, req, resp, path)
+	} else {
+		err = c.httpClient.Do(ctx, req, resp)
+	}
+
+	if c.breaker != nil {
+		c.breaker.record(err == nil && resp.StatusCode() < 500)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	return nil
+}
+
+// hedgedDo returns the first response that is not a transport error or a 5xx. When every
+// copy fails the last failure is returned.
+func (c *Client) hedgedDo(ctx context.Context, req *protocol.Request, resp *protocol.Response, path string) error {
+	type result struct {
+		resp *protocol.Response
+		err  error
+	}
+	// Buffered so copies finishing after the winner do not block
+	results := make(chan result, c.hedging.MaxAttempts)
+	launch := func() {
+		attemptReq, attemptResp := &protocol.Request{}, &protocol.Response{}
+		req.CopyTo(attemptReq)
+		go func() {
+			results <- result{attemptResp, c.httpClient.Do(ctx, attemptReq, attemptResp)}
+		}()
+	}
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	timer := time.NewTimer(c.hedging.Delay)
+	defer timer.Stop()
+
+	launch()
+	launched, pending := 1, 1
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if pending == 0 || (r.err == nil && r.resp.StatusCode() < 500) {
+				if r.err == nil {
+					r.resp.CopyTo(resp)
+				}
+				return r.err
+			}
+		case <-timer.C:
+			if launched < c.hedging.MaxAttempts {
+				launched++
+				pending++
+				launch()
+				if c.hedging.OnHedge != nil {
+					c.hedging.OnHedge(path, launched)
+				}
+				timer.Reset(c.hedging.Delay)
+			}
+		case <-done:
+			return ctx.Err()
+		}
+	}
+}
+
+// circuitBreaker opens after FailureThreshold consecutive failures, lets trial requests
+// through after OpenTimeout and closes again once a trial succeeds
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	trials   int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultBreakerFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &circuitBreaker{cfg: cfg, now: time.Now}
+}
+
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	from := b.state
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state, b.trials = CircuitHalfOpen, 0
+	}
+	var err error
+	switch b.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.trials >= b.cfg.HalfOpenRequests {
+			err = ErrCircuitOpen
+		} else {
+			b.trials++
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return err
+}
+
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case success && b.state == CircuitHalfOpen:
+		b.state, b.failures = CircuitClosed, 0
+	case success:
+		b.failures = 0
+	case b.state == CircuitHalfOpen:
+		b.state, b.openedAt = CircuitOpen, b.now()
+	case b.state == CircuitClosed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.state, b.openedAt = CircuitOpen, b.now()
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// notify runs the callback outside the lock so it may call back into the client
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []string
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(from, to CircuitState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	})
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.allow())
+	b.record(false)
+	require.NoError(t, b.allow())
+	b.record(false)
+	require.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// One trial request passes after the timeout, a failed trial opens the circuit again
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow())
+	require.ErrorIs(t, b.allow(), ErrCircuitOpen)
+	b.record(false)
+	require.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow())
+	b.record(true)
+	require.NoError(t, b.allow())
+
+	require.Equal(t, []string{
+		"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed",
+	}, changes)
+}
+
+func TestClientCircuitBreakerFailsFast(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/im/user/profile/missing" {
+			// API errors mean the server is up and do not count as failures
+			_, _ = io.WriteString(w, `{"code":2006,"message":"user not found"}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL, WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2}))
+	ctx := context.Background()
+
+	_, err := c.GetUserInfoById(ctx, "missing")
+	requireCode(t, err, CodeUserNotFound)
+	for range 2 {
+		_, err = c.GetUserInfo(ctx)
+		require.ErrorContains(t, err, "unexpected status 503")
+	}
+	_, err = c.GetUserInfoById(ctx, "missing")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.EqualValues(t, 3, hits.Load())
+}
+
+func TestClientHedgesSlowGetRequests(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			time.Sleep(time.Second)
+		}
+		_, _ = io.WriteString(w, `{"code":0,"data":{"id":"u1"}}`)
+	}))
+	defer srv.Close()
+
+	var hedges atomic.Int32
+	c := MustNewClient(srv.URL, WithHedging(HedgingConfig{
+		Delay: 20 * time.Millisecond,
+		OnHedge: func(path string, attempt int) {
+			require.Equal(t, "/im/user/info", path)
+			require.Equal(t, 2, attempt)
+			hedges.Add(1)
+		},
+	}))
+
+	start := time.Now()
+	info, err := c.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "u1", info.Id)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.EqualValues(t, 1, hedges.Load())
+}
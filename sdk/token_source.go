@@ -0,0 +1,207 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTokenExpiryLeeway is how long before expiry a cached access token is renewed
+const defaultTokenExpiryLeeway = time.Minute
+
+// Token is an access token with its refresh token and expiry
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time // zero means the token does not expire
+}
+
+// expiresWithin reports whether the token expires in less than d
+func (t *Token) expiresWithin(d time.Duration) bool {
+	return !t.Expiry.IsZero() && time.Until(t.Expiry) < d
+}
+
+func newToken(accessToken, refreshToken string, expiresIn int64) *Token {
+	t := &Token{AccessToken: accessToken, RefreshToken: refreshToken}
+	if expiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return t
+}
+
+// TokenSource supplies the access token of a client. The client calls Token before every
+// request, so it must return a cached token quickly while it is still valid.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// tokenInvalidator is implemented by token sources that can drop a token the server rejected
+type tokenInvalidator interface {
+	invalidate(accessToken string)
+}
+
+// WithTokenSource makes the client take its access token from ts before each request instead
+// of the tokens stored by Login. Auth routes are sent without consulting ts.
+func WithTokenSource(ts TokenSource) ClientOption {
+	return func(c *Client) {
+		c.SetTokenSource(ts)
+	}
+}
+
+// SetTokenSource sets the token source, e.g. one built on the client itself
+func (c *Client) SetTokenSource(ts TokenSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokenSource = ts
+}
+
+func (c *Client) getTokenSource() TokenSource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tokenSource
+}
+
+// StaticTokenSource returns a source that always supplies token, e.g. one issued by another service
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource{token: &Token{AccessToken: token}}
+}
+
+type staticTokenSource struct {
+	token *Token
+}
+
+func (s staticTokenSource) Token(context.Context) (*Token, error) {
+	return s.token, nil
+}
+
+// NewLoginTokenSource returns a source that logs in with req on first use and renews the token
+// shortly before it expires, with the refresh token when possible and by logging in again
+// otherwise. c sends the auth requests and may be the client using the source.
+func NewLoginTokenSource(c *Client, req *LoginRequest) TokenSource {
+	return newCachingTokenSource(func(ctx context.Context, cur *Token) (*Token, error) {
+		if cur != nil && cur.RefreshToken != "" {
+			if t, err := refreshToken(ctx, c, cur.RefreshToken); err == nil {
+				return t, nil
+			}
+		}
+		var result LoginResponse
+		if err := c.post(ctx, "/im/auth/login", req, &result); err != nil {
+			return nil, err
+		}
+		return newToken(result.Token, result.RefreshToken, result.ExpiresIn), nil
+	})
+}
+
+// NewRefreshTokenSource returns a source that obtains access tokens from a refresh token, e.g.
+// one persisted from an earlier login. The rotated refresh token is kept for the next renewal.
+// c sends the auth requests and may be the client using the source.
+func NewRefreshTokenSource(c *Client, refreshTokenValue string) TokenSource {
+	return newCachingTokenSource(func(ctx context.Context, cur *Token) (*Token, error) {
+		rt := refreshTokenValue
+		if cur != nil && cur.RefreshToken != "" {
+			rt = cur.RefreshToken
+		}
+		return refreshToken(ctx, c, rt)
+	})
+}
+
+func refreshToken(ctx context.Context, c *Client, rt string) (*Token, error) {
+	var result RefreshTokenResponse
+	if err := c.post(ctx, "/im/auth/refresh", &RefreshTokenRequest{RefreshToken: rt}, &result); err != nil {
+		return nil, err
+	}
+	return newToken(result.Token, result.RefreshToken, result.ExpiresIn), nil
+}
+
+// cachingTokenSource caches the token of fetch until shortly before it expires. Concurrent
+// callers share one fetch, so a rotated refresh token is never used twice.
+type cachingTokenSource struct {
+	fetch  func(ctx context.Context, cur *Token) (*Token, error)
+	leeway time.Duration
+
+	mu    sync.Mutex
+	token *Token
+	stale bool // the server rejected token, renew on the next call
+}
+
+func newCachingTokenSource(fetch func(ctx context.Context, cur *Token) (*Token, error)) *cachingTokenSource {
+	return &cachingTokenSource{fetch: fetch, leeway: defaultTokenExpiryLeeway}
+}
+
+func (s *cachingTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && !s.stale && !s.token.expiresWithin(s.leeway) {
+		return s.token, nil
+	}
+	t, err := s.fetch(ctx, s.token)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.AccessToken == "" {
+		return nil, errors.New("token source returned an empty token")
+	}
+	s.token, s.stale = t, false
+	return t, nil
+}
+
+func (s *cachingTokenSource) invalidate(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != nil && s.token.AccessToken == accessToken {
+		s.stale = true
+	}
+}
+
+// isAuthPath reports routes that obtain tokens and are sent without one from the token source
+func isAuthPath(path string) bool {
+	return strings.HasPrefix(path, "/im/auth/")
+}
+
+// requestToken returns the access token to send on path: the per-request token, else the one
+// of the token source when set, else the client's stored token
+func (c *Client) requestToken(ctx context.Context, path string, reqOpts *requestOptions) (string, error) {
+	if reqOpts.token != "" {
+		return reqOpts.token, nil
+	}
+	return c.currentToken(ctx, path)
+}
+
+// currentToken returns the client's access token for path, taken from the token source when set
+func (c *Client) currentToken(ctx context.Context, path string) (string, error) {
+	ts := c.getTokenSource()
+	if ts == nil || isAuthPath(path) {
+		return c.GetToken(), nil
+	}
+	t, err := ts.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get token: %w", err)
+	}
+	c.setTokens(t.AccessToken, t.RefreshToken)
+	return t.AccessToken, nil
+}
+
+// renewRejectedToken gets a new access token after the server rejected usedToken with err,
+// reporting whether the request should be retried with it
+func (c *Client) renewRejectedToken(ctx context.Context, path, usedToken string, err error) (string, bool) {
+	if !isTokenRejected(err) || isAuthPath(path) {
+		return "", false
+	}
+	if ts := c.getTokenSource(); ts != nil {
+		inv, ok := ts.(tokenInvalidator)
+		if !ok {
+			return "", false
+		}
+		inv.invalidate(usedToken)
+		token, err := c.currentToken(ctx, path)
+		return token, err == nil && token != usedToken
+	}
+	if c.GetRefreshToken() == "" || c.refreshAfter(ctx, usedToken) != nil {
+		return "", false
+	}
+	return c.GetToken(), true
+}
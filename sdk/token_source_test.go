@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer issues numbered tokens valid for expiresIn seconds and serves /im/user/info
+type tokenServer struct {
+	expiresIn int
+	logins    atomic.Int32
+	refreshes atomic.Int32
+	issued    atomic.Int32
+	rejected  sync.Map // access tokens answered with token expired
+	refreshed sync.Map // refresh tokens already rotated
+}
+
+func (s *tokenServer) issue(w io.Writer) {
+	n := strconv.Itoa(int(s.issued.Add(1)))
+	_, _ = io.WriteString(w, `{"code":0,"data":{"token":"t`+n+`","refresh_token":"r`+n+`","expires_in":`+strconv.Itoa(s.expiresIn)+`}}`)
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/im/auth/login":
+		s.logins.Add(1)
+		s.issue(w)
+	case "/im/auth/refresh":
+		var req RefreshTokenRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, used := s.refreshed.LoadOrStore(req.RefreshToken, true); used || req.RefreshToken == "" {
+			_, _ = io.WriteString(w, `{"code":2001,"message":"refresh token invalid"}`)
+			return
+		}
+		s.refreshes.Add(1)
+		s.issue(w)
+	case "/im/user/info":
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if _, ok := s.rejected.Load(token); ok || token == "" {
+			_, _ = io.WriteString(w, `{"code":2002,"message":"token expired"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"code":0,"data":{"id":"`+token+`"}}`)
+	}
+}
+
+func TestLoginTokenSourceLogsInOnceAndRenewsRejectedTokens(t *testing.T) {
+	ts := &tokenServer{expiresIn: 3600}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL)
+	c.SetTokenSource(NewLoginTokenSource(c, &LoginRequest{UserId: "alice", Password: "secret", PlatformId: PlatformIdWeb}))
+	ctx := context.Background()
+
+	for range 3 {
+		info, err := c.GetUserInfo(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "t1", info.Id)
+	}
+	require.EqualValues(t, 1, ts.logins.Load())
+
+	// A revoked token is renewed with the refresh token and the request retried
+	ts.rejected.Store("t1", true)
+	info, err := c.GetUserInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "t2", info.Id)
+	require.EqualValues(t, 1, ts.refreshes.Load())
+
+	// When the refresh token is rejected too, the source logs in again
+	ts.rejected.Store("t2", true)
+	ts.refreshed.Store("r2", true)
+	info, err = c.GetUserInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "t3", info.Id)
+	require.EqualValues(t, 2, ts.logins.Load())
+}
+
+func TestRefreshTokenSourceRenewsBeforeExpiry(t *testing.T) {
+	// Tokens expire within the renewal leeway, so every request renews first and never sees a rejection
+	ts := &tokenServer{expiresIn: 30}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL)
+	c.SetTokenSource(NewRefreshTokenSource(c, "persisted"))
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		info, err := c.GetUserInfo(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "t"+strconv.Itoa(i), info.Id)
+	}
+	// Each renewal used the refresh token rotated by the previous one
+	require.EqualValues(t, 3, ts.refreshes.Load())
+	require.Zero(t, ts.logins.Load())
+	require.Equal(t, "r3", c.GetRefreshToken())
+}
+
+func TestStaticTokenSource(t *testing.T) {
+	ts := &tokenServer{}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	c := MustNewClient(srv.URL, WithTokenSource(StaticTokenSource("external")))
+	info, err := c.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "external", info.Id)
+
+	// A static token cannot be renewed, the rejection is returned as is
+	ts.rejected.Store("external", true)
+	_, err = c.GetUserInfo(context.Background())
+	requireCode(t, err, CodeTokenExpired)
+}
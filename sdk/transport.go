@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// Transport sends the client's HTTP requests. The Hertz client implements it; HTTPTransport
+// adapts a net/http client. A transport used for exports should hand the response body over
+// with Response.SetBodyStream so it is read as it arrives.
+type Transport interface {
+	Do(ctx context.Context, req *protocol.Request, resp *protocol.Response) error
+}
+
+// WithTransport sends all requests, including exports, through t instead of the Hertz client
+func WithTransport(t Transport) ClientOption {
+	return func(c *Client) {
+		c.httpClient = t
+		c.streamClient = t
+	}
+}
+
+// WithHTTPClient sends requests with a net/http client, for proxies, custom TLS settings or
+// connection reuse tuning the standard library supports. Requests honor the SDK timeouts in
+// addition to hc.Timeout, which also bounds reading export streams.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = &HTTPTransport{Client: hc}
+		c.streamClient = &HTTPTransport{Client: hc, StreamBody: true}
+	}
+}
+
+// HTTPTransport is a Transport sending requests with a net/http client
+type HTTPTransport struct {
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+	// StreamBody hands the response body over as a stream instead of reading it up front
+	StreamBody bool
+}
+
+// Do sends req with the net/http client and fills resp. The request timeout set by the SDK
+// covers receiving the whole response, or only its headers when the body is streamed.
+func (t *HTTPTransport) Do(ctx context.Context, req *protocol.Request, resp *protocol.Response) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	if timeout := req.Options().RequestTimeout(); timeout > 0 {
+		timer := time.AfterFunc(timeout, cancel)
+		defer timer.Stop()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, string(req.Method()), req.URI().String(), bytes.NewReader(req.Body()))
+	if err != nil {
+		cancel()
+		return err
+	}
+	req.Header.VisitAll(func(key, value []byte) {
+		k := string(key)
+		if k == consts.HeaderHost || k == consts.HeaderContentLength {
+			return
+		}
+		httpReq.Header.Add(k, string(value))
+	})
+
+	hc := t.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	httpResp, err := hc.Do(httpReq)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	resp.Reset()
+	resp.SetStatusCode(httpResp.StatusCode)
+	for k, values := range httpResp.Header {
+		if k == consts.HeaderContentLength || k == consts.HeaderTransferEncoding {
+			continue
+		}
+		for _, v := range values {
+			resp.Header.Add(k, v)
+		}
+	}
+
+	if t.StreamBody {
+		resp.SetBodyStream(&cancelOnClose{ReadCloser: httpResp.Body, cancel: cancel}, -1)
+		return nil
+	}
+	defer cancel()
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	resp.SetBody(body)
+	return nil
+}
+
+// cancelOnClose releases the request context once the streamed body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingRoundTripper counts the requests of a net/http client
+type countingRoundTripper struct {
+	next http.RoundTripper
+	n    atomic.Int32
+}
+
+func (rt *countingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.n.Add(1)
+	return rt.next.RoundTrip(r)
+}
+
+func TestHTTPClientTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/im/internal/user/info":
+			body, _ := io.ReadAll(r.Body)
+			want := signInternalRequest("s3cret", "svc", r.Header.Get("X-Timestamp"), r.Method, r.URL.Path, body)
+			if r.Header.Get("X-Signature") != want || r.Header.Get("X-User-Id") != "u1" {
+				_, _ = io.WriteString(w, `{"code":1003,"message":"bad signature"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"code":0,"data":{"id":"u1"}}`)
+		case "/im/internal/msg/export":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = io.WriteString(w, `{"seq":1}`+"\n")
+			w.(http.Flusher).Flush()
+			_, _ = io.WriteString(w, `{"seq":2}`+"\n")
+		case "/im/internal/slow":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = io.WriteString(w, `{"code":0}`)
+		}
+	}))
+	defer srv.Close()
+
+	rt := &countingRoundTripper{next: http.DefaultTransport}
+	c := MustNewInternalClient(srv.URL, "svc", "s3cret",
+		WithHTTPClient(&http.Client{Transport: rt}), WithDefaultActAsUser("u1", PlatformIdWeb))
+	ctx := context.Background()
+
+	info, err := c.InternalGetUserInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "u1", info.Id)
+
+	it, err := c.InternalExportMessages(ctx, "si_u1:u2")
+	require.NoError(t, err)
+	var seqs []int64
+	for it.Next() {
+		seqs = append(seqs, it.Message().Seq)
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	require.Equal(t, []int64{1, 2}, seqs)
+
+	err = c.get(ctx, "/im/internal/slow", nil, nil, WithTimeout(20*time.Millisecond))
+	require.ErrorIs(t, err, context.Canceled)
+	require.EqualValues(t, 3, rt.n.Load())
+}
@@ -2,9 +2,17 @@ package sdk
 
 // Response represents the standard API response
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Data    interface{}  `json:"data,omitempty"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// FieldError describes one invalid request field of a rejected request
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"` // required, too_short, too_long, too_small, too_large, invalid_utf8, malformed
+	Limit  *int64 `json:"limit,omitempty"`
 }
 
 // ErrorMessage returns the first non-empty error message field.
@@ -16,8 +24,11 @@ func (r *Response) ErrorMessage() string {
 type UserInfo struct {
 	Id        string  `json:"id"`
 	Nickname  string  `json:"nickname"`
+	Handle    string  `json:"handle,omitempty"`
 	Avatar    string  `json:"avatar"`
 	Extra     *string `json:"extra,omitempty"`
+	IsBot     bool    `json:"is_bot,omitempty"`
+	IsGuest   bool    `json:"is_guest,omitempty"`
 	CreatedAt int64   `json:"created_at"`
 }
 
@@ -29,6 +40,42 @@ type MessageContent struct {
 	Audio  string `json:"audio,omitempty"`
 	File   string `json:"file,omitempty"`
 	Custom string `json:"custom,omitempty"`
+	// Mentions are the ids of the users a text message mentions, counted in their unread_mention_count
+	Mentions []string     `json:"mentions,omitempty"`
+	Poll     *PollContent `json:"poll,omitempty"`
+	// Revoke is set on revoke notifications (MsgTypeRevoke) only
+	Revoke *RevokeContent `json:"revoke,omitempty"`
+	// Merged is set on merged forwards (MsgTypeMerged) only
+	Merged *MergedContent `json:"merged,omitempty"`
+}
+
+// MergedContent is a merged forward: copies of messages of one conversation in their
+// original order
+type MergedContent struct {
+	Title    string           `json:"title,omitempty"`
+	Messages []*MergedMessage `json:"messages"`
+}
+
+// MergedMessage is a message of a merged forward as it was when forwarded
+type MergedMessage struct {
+	SenderId string         `json:"sender_id"`
+	MsgType  int32          `json:"msg_type"`
+	Content  MessageContent `json:"content"`
+	SendAt   int64          `json:"send_at"`
+}
+
+// RevokeContent names the message of the same conversation its sender recalled
+type RevokeContent struct {
+	Seq int64 `json:"seq"`
+}
+
+// PollContent is the question and options of a poll message (MsgTypePoll). Votes refer to
+// the options by index.
+type PollContent struct {
+	Question    string   `json:"question"`
+	Options     []string `json:"options"` // 2 to 10, distinct
+	Anonymous   bool     `json:"anonymous,omitempty"`
+	MultiChoice bool     `json:"multi_choice,omitempty"`
 }
 
 // MessageInfo represents message info
@@ -41,7 +88,35 @@ type MessageInfo struct {
 	SessionType    int32          `json:"session_type"`
 	MsgType        int32          `json:"msg_type"`
 	Content        MessageContent `json:"content"`
+	Extra          *string        `json:"extra,omitempty"` // JSON object, e.g. fields added by the pre-send policy
 	SendAt         int64          `json:"send_at"`
+	RevokedAt      int64          `json:"revoked_at,omitempty"`   // set when the sender recalled it, its content cleared
+	DeletedAt      int64          `json:"deleted_at,omitempty"`   // set when deleted for everyone, its content cleared
+	EditVersion    int32          `json:"edit_version,omitempty"` // number of edits by the sender
+	Forwarded      bool           `json:"forwarded,omitempty"`    // a copy sent by ForwardMessages
+	ExpiresAt      int64          `json:"expires_at,omitempty"`   // when it disappears (ms), see SetConversationTTL
+	ReadCount      int64          `json:"read_count,omitempty"`   // members other than the sender who read it
+	// Reactions are the reactions to the message per emoji, in the order they were first used
+	Reactions []*ReactionSummary `json:"reactions,omitempty"`
+	QuotedMsg *QuotedMessage     `json:"quoted_msg,omitempty"` // the message it replies to
+}
+
+// QuotedMessage is the message a reply quotes. When sending, set Seq and optionally a Snippet
+// of its text; the server fills SenderId and MsgType, and only returns ConversationId and Seq
+// once the quoted message is revoked, deleted or not visible to the current user.
+type QuotedMessage struct {
+	ConversationId string `json:"conversation_id,omitempty"`
+	Seq            int64  `json:"seq"`
+	SenderId       string `json:"sender_id,omitempty"`
+	MsgType        int32  `json:"msg_type,omitempty"`
+	Snippet        string `json:"snippet,omitempty"`
+}
+
+// ReactionSummary aggregates the reactions to a message with one emoji
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted,omitempty"` // the current user reacted with this emoji
 }
 
 // ConversationInfo represents conversation info
@@ -57,18 +132,30 @@ type ConversationInfo struct {
 	ReadSeq          int64        `json:"read_seq"`
 	UpdatedAt        int64        `json:"updated_at"`
 	LastMessage      *MessageInfo `json:"last_message,omitempty"`
+	// UnreadMentionCount counts the unread messages mentioning the user, the oldest of which is FirstUnreadMentionSeq
+	UnreadMentionCount    int64 `json:"unread_mention_count"`
+	FirstUnreadMentionSeq int64 `json:"first_unread_mention_seq,omitempty"`
+	// IsSaved marks the user's saved messages conversation, which never counts as unread
+	IsSaved bool `json:"is_saved,omitempty"`
+	// TTLSeconds is the disappearing messages timer of the conversation, 0 when off
+	TTLSeconds int32 `json:"ttl_seconds,omitempty"`
+	// PinnedSeqs are the seqs of the messages pinned in the conversation, newest pin first
+	PinnedSeqs []int64 `json:"pinned_seqs,omitempty"`
 }
 
 // GroupInfo represents group info
 type GroupInfo struct {
-	Id            string `json:"id"`
-	Name          string `json:"name"`
-	Introduction  string `json:"introduction"`
-	Avatar        string `json:"avatar"`
-	Status        int32  `json:"status"`
-	CreatorUserId string `json:"creator_user_id"`
-	MemberCount   int64  `json:"member_count"`
-	CreatedAt     int64  `json:"created_at"`
+	Id                    string `json:"id"`
+	Name                  string `json:"name"`
+	Introduction          string `json:"introduction"`
+	Avatar                string `json:"avatar"`
+	Status                int32  `json:"status"`
+	CreatorUserId         string `json:"creator_user_id"`
+	MemberCount           int64  `json:"member_count"`
+	MuteAll               bool   `json:"mute_all"`
+	Announcement          string `json:"announcement"`
+	AnnouncementUpdatedAt int64  `json:"announcement_updated_at"`
+	CreatedAt             int64  `json:"created_at"`
 }
 
 // GroupMember
@@ -84,6 +171,7 @@ type GroupMember struct {
 	JoinedAt      int64   `json:"joined_at"`
 	JoinSeq       int64   `json:"join_seq"`
 	InviterUserId string  `json:"inviter_user_id"`
+	MutedUntil    int64   `json:"muted_until"` // unix milli, 0 when not muted
 	CreatedAt     int64   `json:"created_at"`
 	UpdatedAt     int64   `json:"updated_at"`
 }
@@ -95,6 +183,18 @@ type OnlineStatus struct {
 	Platform string `json:"platform,omitempty"`
 }
 
+// Session is an active login of the current user, one per platform
+type Session struct {
+	SessionId   string `json:"session_id"`
+	PlatformId  int    `json:"platform_id"`
+	Platform    string `json:"platform"`
+	Name        string `json:"name"`
+	ClientIP    string `json:"client_ip"`
+	CreatedAt   int64  `json:"created_at"`
+	RefreshedAt int64  `json:"refreshed_at"`
+	Current     bool   `json:"current"`
+}
+
 // ===== Request types =====
 
 // RegisterRequest represents user registration request
@@ -103,6 +203,22 @@ type RegisterRequest struct {
 	Nickname string `json:"nickname"`
 	Password string `json:"password"`
 	Avatar   string `json:"avatar,omitempty"`
+	// Email or Phone (E.164) requires verification before login; set at most one
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	// CaptchaToken is required when the server enables captcha on registration
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// VerifyRequest represents registration verification request
+type VerifyRequest struct {
+	UserId string `json:"user_id"`
+	Code   string `json:"code"`
+}
+
+// ResendVerifyCodeRequest represents verification code resend request
+type ResendVerifyCodeRequest struct {
+	UserId string `json:"user_id"`
 }
 
 // LoginRequest
@@ -110,19 +226,121 @@ type LoginRequest struct {
 	UserId     string `json:"user_id"`
 	Password   string `json:"password"`
 	PlatformId int    `json:"platform_id"`
+	DeviceName string `json:"device_name,omitempty"` // shown in the session list
+	// CaptchaToken is required after repeated failed logins (CodeCaptchaNeeded)
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // LoginResponse represents user login response
 type LoginResponse struct {
-	Token    string    `json:"token"`
-	UserInfo *UserInfo `json:"user_info"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int64     `json:"expires_in"` // access token lifetime in seconds
+	UserInfo     *UserInfo `json:"user_info"`
+}
+
+// OAuthLoginRequest represents login with an authorization code from an identity provider
+type OAuthLoginRequest struct {
+	Provider    string `json:"provider"`
+	Code        string `json:"code"`
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	PlatformId  int    `json:"platform_id"`
+	DeviceName  string `json:"device_name,omitempty"`
+}
+
+// CreateGuestRequest represents guest account creation request
+type CreateGuestRequest struct {
+	Nickname     string `json:"nickname,omitempty"`
+	PlatformId   int    `json:"platform_id"`
+	DeviceName   string `json:"device_name,omitempty"`
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// UpgradeGuestRequest represents the request turning a guest into a full account
+type UpgradeGuestRequest struct {
+	Password string `json:"password"`
+	Nickname string `json:"nickname,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+}
+
+// ScopedTokenRequest represents a request for a restricted access token
+type ScopedTokenRequest struct {
+	Scopes     []string `json:"scopes"`
+	TTLSeconds int64    `json:"ttl_seconds,omitempty"`
+}
+
+// ScopedTokenResponse represents a restricted access token, it cannot be refreshed
+type ScopedTokenResponse struct {
+	Token     string   `json:"token"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int64    `json:"expires_in"`
+}
+
+// RenameSessionRequest represents session rename request
+type RenameSessionRequest struct {
+	SessionId string `json:"session_id"`
+	Name      string `json:"name"`
+}
+
+// RevokeSessionRequest represents session revoke request
+type RevokeSessionRequest struct {
+	SessionId string `json:"session_id"`
+}
+
+// DeleteAccountRequest represents self-service account deletion request
+type DeleteAccountRequest struct {
+	Password string `json:"password,omitempty"`
+	Confirm  string `json:"confirm"` // the user id of the account being deleted
+}
+
+// NotificationSettings represents the notification preferences of the current user
+type NotificationSettings struct {
+	GlobalMute        bool                          `json:"global_mute"`
+	Sound             bool                          `json:"sound"`
+	Vibrate           bool                          `json:"vibrate"`
+	ShowPreview       bool                          `json:"show_preview"`
+	PlatformOverrides map[int]*NotificationOverride `json:"platform_overrides,omitempty"` // by platform id
+	UpdatedAt         int64                         `json:"updated_at"`
+}
+
+// NotificationOverride replaces some notification settings on one platform, nil fields follow the global settings
+type NotificationOverride struct {
+	GlobalMute  *bool `json:"global_mute,omitempty"`
+	Sound       *bool `json:"sound,omitempty"`
+	Vibrate     *bool `json:"vibrate,omitempty"`
+	ShowPreview *bool `json:"show_preview,omitempty"`
+}
+
+// UpdateNotificationSettingsRequest represents update notification settings request, nil fields are left unchanged.
+// PlatformOverrides only replaces the platforms it contains; a nil override removes that platform's override.
+type UpdateNotificationSettingsRequest struct {
+	GlobalMute        *bool                         `json:"global_mute,omitempty"`
+	Sound             *bool                         `json:"sound,omitempty"`
+	Vibrate           *bool                         `json:"vibrate,omitempty"`
+	ShowPreview       *bool                         `json:"show_preview,omitempty"`
+	PlatformOverrides map[int]*NotificationOverride `json:"platform_overrides,omitempty"`
+}
+
+// RefreshTokenRequest represents refresh token request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshTokenResponse represents refresh token response
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token lifetime in seconds
 }
 
 // UpdateUserRequest represents user update request
 type UpdateUserRequest struct {
-	Nickname string `json:"nickname,omitempty"`
-	Avatar   string `json:"avatar,omitempty"`
-	Extra    string `json:"extra,omitempty"`
+	Nickname       string  `json:"nickname,omitempty"`
+	Handle         *string `json:"handle,omitempty"` // unique name users search by, "" clears it
+	Avatar         string  `json:"avatar,omitempty"`
+	Extra          string  `json:"extra,omitempty"`
+	HideFromSearch *bool   `json:"hide_from_search,omitempty"`
 }
 
 // GetUsersInfoRequest represents batch get users info request
@@ -154,6 +372,79 @@ type QuitGroupRequest struct {
 	GroupId string `json:"group_id"`
 }
 
+// KickGroupMembersRequest represents kick group members request
+type KickGroupMembersRequest struct {
+	GroupId string   `json:"group_id"`
+	UserIds []string `json:"user_ids"`
+}
+
+// MuteGroupMemberRequest represents mute group member request
+type MuteGroupMemberRequest struct {
+	GroupId         string `json:"group_id"`
+	UserId          string `json:"user_id"`
+	DurationSeconds int64  `json:"duration_seconds"` // 0 lifts the mute
+}
+
+// TransferGroupRequest represents transfer group ownership request
+type TransferGroupRequest struct {
+	GroupId    string `json:"group_id"`
+	NewOwnerId string `json:"new_owner_id"`
+}
+
+// GroupAdminRequest represents promote or demote group admin request
+type GroupAdminRequest struct {
+	GroupId string `json:"group_id"`
+	UserId  string `json:"user_id"`
+}
+
+// DismissGroupRequest represents dismiss group request
+type DismissGroupRequest struct {
+	GroupId string `json:"group_id"`
+}
+
+// UpdateGroupInfoRequest represents update group info request, empty fields are left unchanged
+type UpdateGroupInfoRequest struct {
+	GroupId      string `json:"group_id"`
+	Name         string `json:"name,omitempty"`
+	Introduction string `json:"introduction,omitempty"`
+	Avatar       string `json:"avatar,omitempty"`
+	MuteAll      *bool  `json:"mute_all,omitempty"` // only admins may send while set
+}
+
+// SetAnnouncementRequest represents set group announcement request
+type SetAnnouncementRequest struct {
+	GroupId string `json:"group_id"`
+	Content string `json:"content"` // empty clears the announcement
+}
+
+// CreateInviteLinkRequest represents create group invite link request
+type CreateInviteLinkRequest struct {
+	GroupId    string `json:"group_id"`
+	TtlSeconds int64  `json:"ttl_seconds,omitempty"` // 0 for 7 days, at most 30 days
+	MaxUses    int64  `json:"max_uses,omitempty"`    // 0 for unlimited
+}
+
+// RevokeInviteLinkRequest represents revoke group invite link request
+type RevokeInviteLinkRequest struct {
+	GroupId string `json:"group_id"`
+	Token   string `json:"token"`
+}
+
+// JoinByInviteLinkRequest represents join group by invite link request
+type JoinByInviteLinkRequest struct {
+	Token string `json:"token"`
+}
+
+// GroupInviteLink represents a group invite link
+type GroupInviteLink struct {
+	Token         string `json:"token"`
+	GroupId       string `json:"group_id"`
+	CreatorUserId string `json:"creator_user_id"`
+	MaxUses       int64  `json:"max_uses"`
+	Uses          int64  `json:"uses"`
+	ExpiresAt     int64  `json:"expires_at"`
+}
+
 // SendMessageRequest represents send message request
 type SendMessageRequest struct {
 	ClientMsgId string         `json:"client_msg_id"`
@@ -162,6 +453,228 @@ type SendMessageRequest struct {
 	SessionType int32          `json:"session_type"`
 	MsgType     int32          `json:"msg_type"`
 	Content     MessageContent `json:"content"`
+	QuotedMsg   *QuotedMessage `json:"quoted_msg,omitempty"` // set to reply to a message of the same conversation
+}
+
+// MessageReminder is a reminder of a message, delivered to the user's system notification conversation at RemindAt
+type MessageReminder struct {
+	Id             int64  `json:"id"`
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	Note           string `json:"note,omitempty"`
+	RemindAt       int64  `json:"remind_at"`
+	Status         int32  `json:"status"` // see ReminderStatus*
+	SentAt         int64  `json:"sent_at,omitempty"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// CreateReminderRequest represents create message reminder request, set exactly one of RemindAt and RemindIn
+type CreateReminderRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	RemindAt       int64  `json:"remind_at,omitempty"` // ms
+	RemindIn       int64  `json:"remind_in,omitempty"` // seconds from now
+	Note           string `json:"note,omitempty"`
+}
+
+// MessageFavorite is a message the user favorited. Message is the copy taken when favoriting,
+// or the live message when the server stores references only.
+type MessageFavorite struct {
+	Id             int64        `json:"id"`
+	ConversationId string       `json:"conversation_id"`
+	Seq            int64        `json:"seq"`
+	SenderId       string       `json:"sender_id"`
+	Message        *MessageInfo `json:"message,omitempty"`
+	CreatedAt      int64        `json:"created_at"`
+}
+
+// AddFavoriteRequest represents favorite message request
+type AddFavoriteRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+}
+
+// FavoriteListPage represents a page of favorite messages, newest first
+type FavoriteListPage struct {
+	List       []*MessageFavorite `json:"list"`
+	HasMore    bool               `json:"has_more"`
+	NextCursor int64              `json:"next_cursor,omitempty"`
+}
+
+// RevokeMessageRequest represents revoke message request
+type RevokeMessageRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+}
+
+// DeleteMessageRequest represents delete message request
+type DeleteMessageRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	Scope          string `json:"scope"`
+}
+
+// MessageDeletion is a message deleted for everyone by UserId
+type MessageDeletion struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	UserId         string `json:"user_id"`
+}
+
+// EditMessageRequest represents edit message request. Content must be a text content,
+// optionally with mentions.
+type EditMessageRequest struct {
+	ConversationId string         `json:"conversation_id"`
+	Seq            int64          `json:"seq"`
+	Content        MessageContent `json:"content"`
+}
+
+// MessageEdit is a version of a message replaced by an edit. Version is the edit_version the
+// message had with this content, 0 for the content as sent.
+type MessageEdit struct {
+	ConversationId string         `json:"conversation_id"`
+	Seq            int64          `json:"seq"`
+	Version        int32          `json:"version"`
+	Content        MessageContent `json:"content"`
+	EditedAt       int64          `json:"edited_at"` // when this version was replaced
+}
+
+// ReadStatus tells whether a single chat message was delivered to its receiver and whether
+// they read it. DeliveredSeq and ReadSeq are the receiver's seqs, so the messages up to them
+// were delivered or read too; a read message counts as delivered.
+type ReadStatus struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	Delivered      bool   `json:"delivered"`
+	DeliveredSeq   int64  `json:"delivered_seq"`
+	Read           bool   `json:"read"`
+	ReadSeq        int64  `json:"read_seq"`
+}
+
+// GroupReadMembers lists the members who read a group message, the sender excepted, in user id
+// order. ReadCount is their number, the ReadCount of the message.
+type GroupReadMembers struct {
+	ConversationId string         `json:"conversation_id"`
+	Seq            int64          `json:"seq"`
+	ReadCount      int64          `json:"read_count"`
+	Readers        []*GroupReader `json:"readers"`
+}
+
+// GroupReader is a member who read a group message, ReadSeq is how far they read
+type GroupReader struct {
+	UserId  string `json:"user_id"`
+	ReadSeq int64  `json:"read_seq"`
+}
+
+// MessageReadCount is the read count of a message
+type MessageReadCount struct {
+	Seq       int64 `json:"seq"`
+	ReadCount int64 `json:"read_count"`
+}
+
+// ThreadPage is a page of the replies quoting a message, oldest first
+type ThreadPage struct {
+	List       []*MessageInfo `json:"list"`
+	HasMore    bool           `json:"has_more"`
+	NextCursor int64          `json:"next_cursor,omitempty"`
+}
+
+// ReactionRequest represents add or remove reaction request
+type ReactionRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	Emoji          string `json:"emoji"`
+}
+
+// ReactionChange is a reaction added to or removed from a message. Count is the number of
+// reactions with the emoji after the change.
+type ReactionChange struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	UserId         string `json:"user_id"`
+	Emoji          string `json:"emoji"`
+	Added          bool   `json:"added"`
+	Count          int64  `json:"count"`
+}
+
+// VotePollRequest represents vote on a poll request. Options are the indexes of the chosen
+// options, empty to retract the vote.
+type VotePollRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	Options        []int  `json:"options"`
+}
+
+// PollResult is the tally of a poll message
+type PollResult struct {
+	ConversationId string              `json:"conversation_id"`
+	Seq            int64               `json:"seq"`
+	Options        []*PollOptionResult `json:"options"` // in the order of the poll's options
+	VoterCount     int64               `json:"voter_count"`
+	MyOptions      []int               `json:"my_options,omitempty"` // the current user's vote
+}
+
+// PollOptionResult is the tally of one option of a poll
+type PollOptionResult struct {
+	Count    int64    `json:"count"`
+	VoterIds []string `json:"voter_ids,omitempty"` // empty for anonymous polls
+}
+
+// BatchSendMessageRequest represents a request sending up to 100 messages from one sender
+type BatchSendMessageRequest struct {
+	Messages        []*SendMessageRequest `json:"messages"`
+	WithoutMarkRead bool                  `json:"without_mark_read,omitempty"`
+}
+
+// BatchSendResult is the outcome of one message of a batch
+type BatchSendResult struct {
+	Code    int          `json:"code"` // 0 when the message was sent
+	Error   string       `json:"error,omitempty"`
+	Message *MessageInfo `json:"message,omitempty"`
+}
+
+// Err returns the error of a failed message, nil when it was sent
+func (r *BatchSendResult) Err() error {
+	if r.Code == CodeSuccess {
+		return nil
+	}
+	return &Error{Code: r.Code, Msg: r.Error}
+}
+
+// ForwardTarget is a conversation messages are forwarded to, set exactly one of RecvId and GroupId
+type ForwardTarget struct {
+	RecvId  string `json:"recv_id,omitempty"`
+	GroupId string `json:"group_id,omitempty"`
+}
+
+// ForwardMessagesRequest represents a request copying messages of ConversationId to up to 20
+// targets, one by one or, when Merged, packaged as one MsgTypeMerged message titled Title
+type ForwardMessagesRequest struct {
+	ClientMsgId    string           `json:"client_msg_id"` // at most 40 characters
+	ConversationId string           `json:"conversation_id"`
+	Seqs           []int64          `json:"seqs"`
+	Targets        []*ForwardTarget `json:"targets"`
+	Merged         bool             `json:"merged,omitempty"`
+	Title          string           `json:"title,omitempty"`
+}
+
+// ForwardResult is the outcome of a forward to one target. Messages are the copies sent
+// before the error, if any.
+type ForwardResult struct {
+	RecvId   string         `json:"recv_id,omitempty"`
+	GroupId  string         `json:"group_id,omitempty"`
+	Code     int            `json:"code"` // 0 when all copies were sent
+	Error    string         `json:"error,omitempty"`
+	Messages []*MessageInfo `json:"messages"`
+}
+
+// Err returns the error that stopped the forward to the target, nil when all copies were sent
+func (r *ForwardResult) Err() error {
+	if r.Code == CodeSuccess {
+		return nil
+	}
+	return &Error{Code: r.Code, Msg: r.Error}
 }
 
 // PullMessagesRequest represents pull messages request
@@ -182,6 +695,7 @@ type PullMessagesResponse struct {
 type UpdateConversationRequest struct {
 	RecvMsgOpt *int32 `json:"recv_msg_opt,omitempty"`
 	IsPinned   *bool  `json:"is_pinned,omitempty"`
+	TTLSeconds *int32 `json:"ttl_seconds,omitempty"` // shared by all participants, see SetConversationTTL
 }
 
 // GetConversationListRequest represents conversation list request
@@ -211,6 +725,28 @@ type MarkReadRequest struct {
 	ReadSeq        int64  `json:"read_seq"`
 }
 
+// PinMessageRequest represents pin or unpin message request
+type PinMessageRequest struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+}
+
+// PinChange is a message pinned or unpinned in a conversation by UserId
+type PinChange struct {
+	ConversationId string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	UserId         string `json:"user_id"`
+	Pinned         bool   `json:"pinned"`
+}
+
+// PinnedMessage is a message pinned in a conversation
+type PinnedMessage struct {
+	Seq      int64        `json:"seq"`
+	PinnedBy string       `json:"pinned_by"`
+	PinnedAt int64        `json:"pinned_at"`
+	Message  *MessageInfo `json:"message"`
+}
+
 // MaxReadSeqResponse represents max and read seq response
 type MaxReadSeqResponse struct {
 	MaxSeq      int64 `json:"max_seq"`
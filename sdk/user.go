@@ -1,6 +1,9 @@
 package sdk
 
-import "context"
+import (
+	"context"
+	"strconv"
+)
 
 // GetUserInfo gets the current user's info
 func (c *Client) GetUserInfo(ctx context.Context) (*UserInfo, error) {
@@ -39,6 +42,20 @@ func (c *Client) GetUsersInfo(ctx context.Context, userIds []string) ([]*UserInf
 	return result, nil
 }
 
+// SearchUsers finds users by handle or nickname prefix, best matches first.
+// A query starting with "@" only matches handles; limit 0 uses the server default.
+func (c *Client) SearchUsers(ctx context.Context, q string, limit int) ([]*UserInfo, error) {
+	params := map[string]string{"q": q}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	var result []*UserInfo
+	if err := c.get(ctx, "/im/user/search", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetUsersOnlineStatus gets online status for multiple users
 func (c *Client) GetUsersOnlineStatus(ctx context.Context, userIds []string) ([]*OnlineStatus, error) {
 	var result []*OnlineStatus
@@ -49,6 +66,61 @@ func (c *Client) GetUsersOnlineStatus(ctx context.Context, userIds []string) ([]
 	return result, nil
 }
 
+// ListSessions lists the current user's active sessions
+func (c *Client) ListSessions(ctx context.Context) ([]*Session, error) {
+	var result []*Session
+	if err := c.get(ctx, "/im/user/session/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RenameSession sets the device name of a session
+func (c *Client) RenameSession(ctx context.Context, sessionId, name string) error {
+	req := &RenameSessionRequest{SessionId: sessionId, Name: name}
+	return c.post(ctx, "/im/user/session/rename", req, nil)
+}
+
+// RevokeSession logs a session out; revoking the current session logs this client out
+func (c *Client) RevokeSession(ctx context.Context, sessionId string) error {
+	req := &RevokeSessionRequest{SessionId: sessionId}
+	return c.post(ctx, "/im/user/session/revoke", req, nil)
+}
+
+// DeleteAccount deletes the current user's account and logs out all of its sessions.
+// req.Confirm must repeat the user id; req.Password is required unless the account has no password.
+func (c *Client) DeleteAccount(ctx context.Context, req *DeleteAccountRequest) error {
+	return c.post(ctx, "/im/user/delete_account", req, nil)
+}
+
+// GetNotificationSettings gets the notification settings of the current user
+func (c *Client) GetNotificationSettings(ctx context.Context) (*NotificationSettings, error) {
+	var result NotificationSettings
+	if err := c.get(ctx, "/im/user/notification_settings", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateNotificationSettings updates the notification settings of the current user
+func (c *Client) UpdateNotificationSettings(ctx context.Context, req *UpdateNotificationSettingsRequest) (*NotificationSettings, error) {
+	var result NotificationSettings
+	if err := c.put(ctx, "/im/user/notification_settings", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// IssueScopedToken gets a restricted access token of the current user, e.g. for an embedded widget.
+// Scopes are "*", a route group ("user", "group", "msg", "conversation") or "<group>:read".
+func (c *Client) IssueScopedToken(ctx context.Context, req *ScopedTokenRequest) (*ScopedTokenResponse, error) {
+	var result ScopedTokenResponse
+	if err := c.post(ctx, "/im/user/token/scoped", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // InternalGetUserInfo gets current user info via internal route.
 func (c *Client) InternalGetUserInfo(ctx context.Context, opts ...RequestOption) (*UserInfo, error) {
 	var result UserInfo